@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// identityUnaryInterceptor 识别调用方身份并限流，与REST接口的Tenant和RateLimit中间件一致
+func (s *Server) identityUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx = s.identify(ctx)
+	if err := s.allow(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// identityStreamInterceptor 流式调用的身份识别和限流
+func (s *Server) identityStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := s.identify(ss.Context())
+	if err := s.allow(ctx); err != nil {
+		return err
+	}
+	return handler(srv, &identifiedStream{ServerStream: ss, ctx: ctx})
+}
+
+// identifiedStream 携带调用方身份的流
+type identifiedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回带有调用方身份的上下文
+func (s *identifiedStream) Context() context.Context {
+	return s.ctx
+}
+
+// identify 按元数据x-api-key或authorization中登记的API密钥识别租户和用户组，写入上下文
+// 未携带或携带未登记密钥的调用是匿名的，按连接的来源地址限流
+func (s *Server) identify(ctx context.Context) context.Context {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		ctx = auth.WithClientAddress(ctx, addr)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := auth.RequestKey(firstValue(md, "x-api-key"), firstValue(md, "authorization"))
+	if identity, ok := s.authenticator.Authenticate(key); ok {
+		ctx = auth.WithIdentity(ctx, identity)
+	}
+	return ctx
+}
+
+// allow 按调用方消耗一个令牌，令牌不足时返回ResourceExhausted；限流器出错时放行
+func (s *Server) allow(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
+	}
+	key := auth.ClientFromContext(ctx)
+	if key == "" {
+		key = "ip:unknown"
+	}
+	result, err := s.limiter.Allow(ctx, key)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Rate limiter unavailable, request allowed")
+		return nil
+	}
+	if !result.Allowed {
+		return status.Error(codes.ResourceExhausted, "请求过于频繁，请稍后再试")
+	}
+	return nil
+}
+
+// firstValue 返回元数据中指定键的第一个值
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// checkManageable 检查调用方能否修改文档，无权读取时返回NotFound，可以读取但无权修改时返回PermissionDenied
+func (s *Server) checkManageable(ctx context.Context, fileID string) error {
+	err := s.documentService.CheckManageable(ctx, fileID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, models.ErrDocumentNotFound):
+		return status.Error(codes.NotFound, "未找到文档")
+	case errors.Is(err, models.ErrAccessDenied):
+		return status.Error(codes.PermissionDenied, "无权修改该文档")
+	}
+	s.logger.WithError(err).WithField("file_id", fileID).Error("Failed to check document access")
+	return status.Error(codes.Internal, "检查文档访问权限失败")
+}
+
+// quotaStatus 将配额错误转换为gRPC状态，超出配额时返回ResourceExhausted
+func (s *Server) quotaStatus(ctx context.Context, err error) error {
+	if errors.Is(err, models.ErrQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	s.logger.WithContext(ctx).WithError(err).Error("Failed to check quota")
+	return status.Error(codes.Internal, "检查配额失败")
+}
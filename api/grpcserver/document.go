@@ -0,0 +1,290 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	docqav1 "github.com/fyerfyer/doc-QA-system/api/proto/docqa/v1"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// documentServer 文档管理服务
+type documentServer struct {
+	docqav1.UnimplementedDocumentServiceServer
+	*Server
+}
+
+// UploadDocument 接收分块上传的文件，保存后在后台解析和向量化
+func (d *documentServer) UploadDocument(stream docqav1.DocumentService_UploadDocumentServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "未提供文件")
+	}
+	info := first.GetInfo()
+	if info == nil || info.GetFilename() == "" {
+		return status.Error(codes.InvalidArgument, "第一条消息必须包含文件信息")
+	}
+	filename := info.GetFilename()
+	if err := d.validator.CheckName(filename); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// 边接收边写入存储，不在内存中缓存整个文件
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				pw.Close()
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := pw.Write(req.GetChunk()); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx := stream.Context()
+	fileInfo, err := d.fileStorage.Save(pr, filename)
+	pr.Close()
+	if err != nil {
+		if ctxErr := contextError(ctx.Err()); ctxErr != nil {
+			return ctxErr
+		}
+		d.logger.WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"filename": filename,
+		}).Error("Failed to save file")
+		return status.Error(codes.Internal, "保存文件失败")
+	}
+
+	// 文件边接收边保存，保存后读回校验内容，未通过时删除
+	if err := d.validateSaved(ctx, fileInfo.ID, filename); err != nil {
+		if delErr := d.fileStorage.Delete(fileInfo.ID); delErr != nil {
+			d.logger.WithError(delErr).WithField("file_id", fileInfo.ID).Warn("Failed to delete rejected file")
+		}
+		d.logger.WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"filename": filename,
+		}).Warn("Uploaded file rejected")
+		return validationStatus(err)
+	}
+
+	// 文件大小在接收完成后才能确定，保存后占用配额，超出时删除文件
+	if err := d.quota.ReserveDocument(ctx, fileInfo.Size); err != nil {
+		if delErr := d.fileStorage.Delete(fileInfo.ID); delErr != nil {
+			d.logger.WithError(delErr).WithField("file_id", fileInfo.ID).Warn("Failed to delete rejected file")
+		}
+		return d.quotaStatus(ctx, err)
+	}
+
+	d.logger.WithFields(logrus.Fields{
+		"file_id":  fileInfo.ID,
+		"filename": fileInfo.Name,
+		"size":     fileInfo.Size,
+	}).Info("File uploaded via gRPC")
+
+	// 文档记录上传者所属的租户，调用结束后仍在后台使用，因此不随调用取消
+	ctx = context.WithoutCancel(ctx)
+	if err := d.documentService.Init(); err == nil {
+		if statusManager := d.documentService.GetStatusManager(); statusManager != nil {
+			if err := statusManager.MarkAsUploaded(ctx, fileInfo.ID, filename, fileInfo.Path, fileInfo.Size); err != nil {
+				d.logger.WithError(err).Warn("Failed to mark document as uploaded")
+			}
+			if info.GetTags() != "" {
+				if err := d.documentService.UpdateDocumentTags(ctx, fileInfo.ID, info.GetTags()); err != nil {
+					d.logger.WithError(err).Warn("Failed to update document tags")
+				}
+			}
+		}
+	}
+
+	// 与REST上传相同，后台执行分块和向量化，状态更新由ProcessDocument内部处理
+	go func() {
+		if err := d.documentService.ProcessDocument(ctx, fileInfo.ID, fileInfo.Path); err != nil {
+			d.logger.WithFields(logrus.Fields{
+				"error":   err.Error(),
+				"file_id": fileInfo.ID,
+			}).Error("Failed to process document")
+		}
+	}()
+
+	return stream.SendAndClose(&docqav1.UploadDocumentResponse{
+		FileId:   fileInfo.ID,
+		Filename: filename,
+		Status:   string(models.DocStatusUploaded),
+	})
+}
+
+// validateSaved 读回已保存的文件并校验内容
+func (d *documentServer) validateSaved(ctx context.Context, fileID, filename string) error {
+	reader, err := d.fileStorage.Get(fileID)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return d.validator.Validate(ctx, filename, reader)
+}
+
+// validationStatus 将文件校验错误转换为gRPC状态
+func validationStatus(err error) error {
+	switch {
+	case errors.Is(err, filecheck.ErrTypeNotAllowed), errors.Is(err, filecheck.ErrTypeMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, filecheck.ErrEncryptedPDF), errors.Is(err, filecheck.ErrInfected):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if ctxErr := contextError(err); ctxErr != nil {
+		return ctxErr
+	}
+	return status.Error(codes.Internal, "校验文件失败")
+}
+
+// GetDocument 获取文档信息和处理状态
+func (d *documentServer) GetDocument(ctx context.Context, req *docqav1.GetDocumentRequest) (*docqav1.Document, error) {
+	if req.GetFileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "无效的文档ID")
+	}
+	doc, err := d.getDocument(ctx, req.GetFileId())
+	if err != nil {
+		return nil, err
+	}
+	return toProtoDocument(doc), nil
+}
+
+// ListDocuments 分页获取文档列表，分页参数的默认值和上限与REST接口一致
+func (d *documentServer) ListDocuments(ctx context.Context, req *docqav1.ListDocumentsRequest) (*docqav1.ListDocumentsResponse, error) {
+	page := model.PaginationRequest{Page: int(req.GetPage()), PageSize: int(req.GetPageSize())}
+	offset := (page.GetPage() - 1) * page.GetPageSize()
+
+	filters := make(map[string]interface{})
+	if req.GetStatus() != "" {
+		filters["status"] = req.GetStatus()
+	}
+	if req.GetTags() != "" {
+		filters["tags"] = req.GetTags()
+	}
+	if req.GetStartTime() != nil {
+		filters["start_time"] = req.GetStartTime().AsTime().Format(time.RFC3339)
+	}
+	if req.GetEndTime() != nil {
+		filters["end_time"] = req.GetEndTime().AsTime().Format(time.RFC3339)
+	}
+
+	docs, total, err := d.documentService.ListDocuments(ctx, offset, page.GetPageSize(), filters)
+	if err != nil {
+		d.logger.WithError(err).Error("Failed to fetch document list")
+		return nil, status.Error(codes.Internal, "获取文档列表失败: "+err.Error())
+	}
+
+	resp := &docqav1.ListDocumentsResponse{
+		Total:     total,
+		Page:      int32(page.GetPage()),
+		PageSize:  int32(page.GetPageSize()),
+		Documents: make([]*docqav1.Document, 0, len(docs)),
+	}
+	for _, doc := range docs {
+		resp.Documents = append(resp.Documents, toProtoDocument(doc))
+	}
+	return resp, nil
+}
+
+// UpdateDocumentTags 更新文档标签
+func (d *documentServer) UpdateDocumentTags(ctx context.Context, req *docqav1.UpdateDocumentTagsRequest) (*docqav1.Document, error) {
+	if req.GetFileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "无效的文档ID")
+	}
+	if err := d.checkManageable(ctx, req.GetFileId()); err != nil {
+		return nil, err
+	}
+	if err := d.documentService.UpdateDocumentTags(ctx, req.GetFileId(), req.GetTags()); err != nil {
+		d.logger.WithFields(logrus.Fields{
+			"error":   err.Error(),
+			"file_id": req.GetFileId(),
+		}).Error("Failed to update document tags")
+		return nil, status.Error(codes.Internal, "更新文档标签失败")
+	}
+
+	doc, err := d.getDocument(ctx, req.GetFileId())
+	if err != nil {
+		return nil, err
+	}
+	return toProtoDocument(doc), nil
+}
+
+// DeleteDocument 删除文档及其向量
+func (d *documentServer) DeleteDocument(ctx context.Context, req *docqav1.DeleteDocumentRequest) (*docqav1.DeleteDocumentResponse, error) {
+	if req.GetFileId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "无效的文档ID")
+	}
+	if err := d.checkManageable(ctx, req.GetFileId()); err != nil {
+		return nil, err
+	}
+
+	// 删除前读取文档所属的租户和文件大小，删除成功后归还配额
+	var owner *models.Document
+	if d.quota != nil {
+		if manager := d.documentService.GetStatusManager(); manager != nil {
+			owner, _ = manager.GetDocument(ctx, req.GetFileId())
+		}
+	}
+
+	if err := d.documentService.DeleteDocument(ctx, req.GetFileId()); err != nil {
+		d.logger.WithFields(logrus.Fields{
+			"error":   err.Error(),
+			"file_id": req.GetFileId(),
+		}).Error("Failed to delete document")
+		return nil, status.Error(codes.Internal, "删除文档失败")
+	}
+
+	d.logger.WithField("file_id", req.GetFileId()).Info("Document deleted successfully")
+	// 子文档不占用配额，配额统计在父文档上
+	if owner != nil && owner.ParentID == "" {
+		d.quota.ReleaseDocument(ctx, owner.Tenant, owner.FileSize)
+	}
+	return &docqav1.DeleteDocumentResponse{FileId: req.GetFileId(), Success: true}, nil
+}
+
+// getDocument 获取文档记录，不存在时返回NotFound
+func (d *documentServer) getDocument(ctx context.Context, fileID string) (*models.Document, error) {
+	if err := d.documentService.Init(); err != nil {
+		return nil, status.Error(codes.Internal, "文档服务未就绪")
+	}
+	doc, err := d.documentService.GetStatusManager().GetDocument(ctx, fileID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "未找到文档")
+	}
+	// 无权读取的文档按不存在处理
+	if err := d.documentService.CheckReadable(ctx, fileID); err != nil {
+		return nil, status.Error(codes.NotFound, "未找到文档")
+	}
+	return doc, nil
+}
+
+// toProtoDocument 将文档记录转换为gRPC消息
+func toProtoDocument(doc *models.Document) *docqav1.Document {
+	return &docqav1.Document{
+		FileId:     doc.ID,
+		Filename:   doc.FileName,
+		Status:     string(doc.Status),
+		Progress:   int32(doc.Progress),
+		Segments:   int32(doc.SegmentCount),
+		Size:       doc.FileSize,
+		Tags:       doc.Tags,
+		Error:      doc.Error,
+		UploadedAt: timestamppb.New(doc.UploadedAt),
+		UpdatedAt:  timestamppb.New(doc.UpdatedAt),
+	}
+}
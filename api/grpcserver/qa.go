@@ -0,0 +1,216 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	docqav1 "github.com/fyerfyer/doc-QA-system/api/proto/docqa/v1"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// answerChunkSize 流式返回时每个回答片段的字符数
+const answerChunkSize = 32
+
+// qaServer 问答服务
+type qaServer struct {
+	docqav1.UnimplementedQAServiceServer
+	*Server
+}
+
+// Ask 回答问题
+func (q *qaServer) Ask(ctx context.Context, req *docqav1.AskRequest) (*docqav1.AskResponse, error) {
+	return q.answer(ctx, req)
+}
+
+// AskStream 回答问题并流式返回
+// 大模型客户端目前不支持逐token输出，回答生成后按片段推送，
+// 客户端按顺序拼接delta即可，之后依次收到来源和结束消息
+func (q *qaServer) AskStream(req *docqav1.AskRequest, stream docqav1.QAService_AskStreamServer) error {
+	resp, err := q.answer(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	for _, chunk := range splitAnswer(resp.GetAnswer(), answerChunkSize) {
+		if err := stream.Send(&docqav1.AskStreamResponse{
+			Event: &docqav1.AskStreamResponse_Delta{Delta: chunk},
+		}); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&docqav1.AskStreamResponse{
+		Event: &docqav1.AskStreamResponse_Sources_{
+			Sources: &docqav1.AskStreamResponse_Sources{Sources: resp.GetSources()},
+		},
+	}); err != nil {
+		return err
+	}
+	return stream.Send(&docqav1.AskStreamResponse{
+		Event: &docqav1.AskStreamResponse_Done{Done: resp.GetStatus()},
+	})
+}
+
+// answer 按REST问答接口相同的流程回答问题：护栏、审核和一致性校验对两种接口同样生效
+func (q *qaServer) answer(ctx context.Context, req *docqav1.AskRequest) (*docqav1.AskResponse, error) {
+	question := req.GetQuestion()
+	if question == "" {
+		return nil, status.Error(codes.InvalidArgument, "问题不能为空")
+	}
+
+	var ask services.QuestionAnswerFunc
+	var scope services.ReviewScope
+	switch {
+	case req.GetFileId() != "":
+		fileID := req.GetFileId()
+		// 无权读取的文档按不存在处理
+		if err := q.documentService.CheckReadable(ctx, fileID); err != nil {
+			return nil, status.Error(codes.NotFound, "未找到文档")
+		}
+		scope.FileID = fileID
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return q.qaService.AnswerWithFile(ctx, question, fileID)
+		}
+	case len(req.GetMetadata()) > 0:
+		metadata := make(map[string]interface{}, len(req.GetMetadata()))
+		for k, v := range req.GetMetadata() {
+			metadata[k] = v
+		}
+		scope.Metadata = metadata
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return q.qaService.AnswerWithMetadata(ctx, question, metadata)
+		}
+	default:
+		ask = q.qaService.Answer
+	}
+
+	// 消耗当日问答配额
+	if err := q.quota.ConsumeQA(ctx); err != nil {
+		return nil, q.quotaStatus(ctx, err)
+	}
+
+	generate := func(ctx context.Context) (string, []vectordb.Document, error) {
+		if q.guard != nil {
+			return q.guard.Answer(ctx, question, ask)
+		}
+		return ask(ctx, question)
+	}
+
+	resp := &docqav1.AskResponse{Question: question, Status: &docqav1.AnswerStatus{}}
+	ctx, info := services.WithAnswerInfo(ctx)
+
+	var err error
+	if q.reviewService != nil {
+		var outcome *services.ReviewOutcome
+		outcome, err = q.reviewService.Gate(ctx, question, scope, generate)
+		if err == nil {
+			if outcome.Draft != nil {
+				resp.Status.Review = &docqav1.ReviewStatus{
+					DraftId: outcome.Draft.ID,
+					Status:  string(outcome.Draft.Status),
+					Topic:   outcome.Draft.Topic,
+				}
+				return resp, nil
+			}
+			resp.Answer = outcome.Answer
+			resp.Sources = toProtoSources(outcome.Sources)
+			resp.Status.Curated = outcome.Curated
+		}
+	} else {
+		var sourceDocs []vectordb.Document
+		resp.Answer, sourceDocs, err = generate(ctx)
+		if err == nil {
+			resp.Sources = toProtoSources(sourceDocs)
+		}
+	}
+
+	// 被护栏拦截时返回拒绝回答，而不是错误
+	if blocked, ok := moderation.AsBlocked(err); ok {
+		resp.Answer = q.guard.Refusal()
+		resp.Sources = nil
+		resp.Status.Refusal = &docqav1.Refusal{Stage: string(blocked.Stage), Category: blocked.Category}
+		return resp, nil
+	}
+
+	if err != nil {
+		if ctxErr := contextError(err); ctxErr != nil {
+			return nil, ctxErr
+		}
+		q.logger.WithFields(logrus.Fields{
+			"error":    err.Error(),
+			"question": question,
+			"file_id":  req.GetFileId(),
+		}).Error("Failed to answer question")
+
+		if llm.IsBudgetExceeded(err) {
+			return nil, status.Error(codes.ResourceExhausted, "本次请求的生成预算已用尽")
+		}
+		if errors.Is(err, services.ErrTooManyConcurrentQuestions) {
+			return nil, status.Error(codes.ResourceExhausted, "同时进行的问答过多，请稍后再试")
+		}
+		return nil, status.Error(codes.Internal, "处理问题时出错: "+err.Error())
+	}
+
+	resp.Status.Confidence = info.Confidence
+	resp.Status.LowConfidence = info.LowConfidence
+	return resp, nil
+}
+
+// toProtoSources 将来源文档转换为gRPC消息，来源信息与REST接口一致
+func toProtoSources(docs []vectordb.Document) []*docqav1.Source {
+	infos := model.ConvertToSourceInfo(docs)
+	sources := make([]*docqav1.Source, len(infos))
+	for i, info := range infos {
+		source := &docqav1.Source{
+			Text:        info.Text,
+			FileId:      info.FileID,
+			Filename:    info.FileName,
+			Position:    int32(info.Position),
+			SegmentId:   info.SegmentID,
+			Link:        info.Link,
+			Page:        int32(info.Page),
+			Section:     info.Section,
+			HeadingPath: info.HeadingPath,
+			Location:    info.Location,
+			Spans:       toProtoSpans(info.Spans),
+			Highlights:  toProtoSpans(info.Highlights),
+		}
+		for _, marker := range info.Citations {
+			source.Citations = append(source.Citations, int32(marker))
+		}
+		sources[i] = source
+	}
+	return sources
+}
+
+// toProtoSpans 转换文本范围
+func toProtoSpans(spans []llm.TextSpan) []*docqav1.TextSpan {
+	if len(spans) == 0 {
+		return nil
+	}
+	result := make([]*docqav1.TextSpan, len(spans))
+	for i, span := range spans {
+		result[i] = &docqav1.TextSpan{Start: int32(span.Start), End: int32(span.End)}
+	}
+	return result
+}
+
+// splitAnswer 按字符数切分回答，不会切断多字节字符
+func splitAnswer(answer string, size int) []string {
+	runes := []rune(answer)
+	chunks := make([]string, 0, (len(runes)+size-1)/size)
+	for start := 0; start < len(runes); start += size {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+	}
+	return chunks
+}
@@ -0,0 +1,170 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	docqav1 "github.com/fyerfyer/doc-QA-system/api/proto/docqa/v1"
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
+	"github.com/fyerfyer/doc-QA-system/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server gRPC服务，与REST接口共用同一套服务层
+// 供不使用JSON/HTTP的内部Go、Java客户端调用
+type Server struct {
+	documentService *services.DocumentService // 文档服务
+	fileStorage     storage.Storage           // 文件存储服务
+	qaService       *services.QAService       // 问答服务
+	reviewService   *services.ReviewService   // 回答审核服务，为空时不审核
+	guard           *services.GuardService    // 问答护栏，为空时不审核内容
+	validator       *filecheck.Validator      // 上传文件校验器
+	authenticator   *auth.Authenticator       // 按API密钥识别租户，为空时所有调用都是匿名的
+	limiter         ratelimit.Limiter         // 按调用方限流，为空时不限流
+	quota           *quota.Manager            // 租户配额，为空时不限制
+	logger          *logrus.Logger            // 日志记录器
+}
+
+// Option gRPC服务配置选项
+type Option func(*Server)
+
+// WithReviewService 设置回答审核服务，与REST接口使用同一审核流程
+func WithReviewService(reviewService *services.ReviewService) Option {
+	return func(s *Server) {
+		s.reviewService = reviewService
+	}
+}
+
+// WithGuard 设置问答护栏，拦截或脱敏不安全的问题和回答
+func WithGuard(guard *services.GuardService) Option {
+	return func(s *Server) {
+		s.guard = guard
+	}
+}
+
+// WithUploadValidator 设置上传文件校验器，与REST接口使用同一套校验规则
+func WithUploadValidator(validator *filecheck.Validator) Option {
+	return func(s *Server) {
+		s.validator = validator
+	}
+}
+
+// WithAuthenticator 设置API密钥认证器，与REST接口使用同一组登记的密钥
+func WithAuthenticator(authenticator *auth.Authenticator) Option {
+	return func(s *Server) {
+		s.authenticator = authenticator
+	}
+}
+
+// WithRateLimiter 设置限流器，与REST接口共用令牌桶，调用方不能通过切换接口绕过限流
+func WithRateLimiter(limiter ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.limiter = limiter
+	}
+}
+
+// WithQuota 设置租户配额，上传和问答与REST接口消耗同一份配额
+func WithQuota(manager *quota.Manager) Option {
+	return func(s *Server) {
+		s.quota = manager
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *logrus.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// NewServer 创建gRPC服务器并注册文档管理和问答服务
+func NewServer(documentService *services.DocumentService, fileStorage storage.Storage, qaService *services.QAService, opts ...Option) *grpc.Server {
+	s := &Server{
+		documentService: documentService,
+		fileStorage:     fileStorage,
+		qaService:       qaService,
+		validator:       filecheck.New(),
+		logger:          middleware.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor, s.identityUnaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor, s.identityStreamInterceptor),
+	)
+	docqav1.RegisterDocumentServiceServer(srv, &documentServer{Server: s})
+	docqav1.RegisterQAServiceServer(srv, &qaServer{Server: s})
+	return srv
+}
+
+// unaryInterceptor 记录调用日志并将panic转换为Internal错误
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(info.FullMethod, r)
+		}
+		s.logCall(info.FullMethod, start, err)
+	}()
+	return handler(ctx, req)
+}
+
+// streamInterceptor 流式调用的日志和panic恢复
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = s.recovered(info.FullMethod, r)
+		}
+		s.logCall(info.FullMethod, start, err)
+	}()
+	return handler(srv, ss)
+}
+
+// recovered 记录panic堆栈并返回Internal错误
+func (s *Server) recovered(method string, r interface{}) error {
+	s.logger.WithFields(logrus.Fields{
+		"method": method,
+		"panic":  r,
+		"stack":  string(debug.Stack()),
+	}).Error("gRPC handler panicked")
+	return status.Error(codes.Internal, "服务器内部错误")
+}
+
+// logCall 记录gRPC调用的方法、状态码和耗时
+func (s *Server) logCall(method string, start time.Time, err error) {
+	code := status.Code(err)
+	entry := s.logger.WithFields(logrus.Fields{
+		"method":  method,
+		"code":    code.String(),
+		"latency": time.Since(start),
+	})
+	switch code {
+	case codes.OK:
+		entry.Info("gRPC request")
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		entry.WithError(err).Error("gRPC request failed")
+	default:
+		entry.WithError(err).Warn("gRPC request failed")
+	}
+}
+
+// contextError 将上下文取消或超时转换为对应的gRPC状态，其他错误返回nil
+func contextError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
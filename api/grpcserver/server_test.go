@@ -0,0 +1,390 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	docqav1 "github.com/fyerfyer/doc-QA-system/api/proto/docqa/v1"
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
+	"github.com/fyerfyer/doc-QA-system/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testAnswer = "系统支持PDF、Markdown和纯文本文档的上传与问答[1]，上传后会自动分块并向量化[2]。"
+
+// fileParser 直接读取文件内容的解析器
+type fileParser struct{}
+
+func (fileParser) Parse(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	return string(data), err
+}
+
+func (fileParser) ParseReader(r io.Reader, filename string) (string, error) {
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+// lineSplitter 按行分块
+type lineSplitter struct{}
+
+func (lineSplitter) Split(text string) ([]document.Content, error) {
+	var contents []document.Content
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			contents = append(contents, document.Content{Text: line, Index: len(contents)})
+		}
+	}
+	return contents, nil
+}
+
+// constEmbedder 对所有文本返回相同的向量
+type constEmbedder struct{}
+
+func (constEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{1, 0, 0, 0}, nil
+}
+
+func (constEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1, 0, 0, 0}
+	}
+	return vectors, nil
+}
+
+func (constEmbedder) Name() string {
+	return "const-embedding"
+}
+
+// grpcTestEnv 基于内存连接的gRPC测试环境
+type grpcTestEnv struct {
+	documentService *services.DocumentService
+	docClient       docqav1.DocumentServiceClient
+	qaClient        docqav1.QAServiceClient
+}
+
+// setupGRPCTestEnv 启动基于内存连接的gRPC服务，返回两个服务的客户端
+func setupGRPCTestEnv(t *testing.T) (docqav1.DocumentServiceClient, docqav1.QAServiceClient) {
+	setupGRPCTestDB(t)
+	env := newGRPCTestEnv(t, nil)
+	return env.docClient, env.qaClient
+}
+
+// setupGRPCTestDB 使用内存数据库替换全局数据库连接，需要在创建依赖数据库的仓库之前调用
+func setupGRPCTestDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:grpcserver?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Document{}, &models.DocumentSegment{}, &models.DocumentShare{}, &models.QuotaUsage{}))
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = originalDB
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// newGRPCTestEnv 启动gRPC服务，docOpts追加到文档服务的配置，opts追加到gRPC服务的配置
+func newGRPCTestEnv(t *testing.T, docOpts []services.DocumentOption, opts ...Option) *grpcTestEnv {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	fileStorage, err := storage.NewLocalStorage(storage.LocalConfig{Path: t.TempDir()})
+	require.NoError(t, err)
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+
+	repo := repository.NewDocumentRepository()
+	docOptions := append([]services.DocumentOption{
+		services.WithDocumentRepository(repo),
+		services.WithStatusManager(services.NewDocumentStatusManager(repo, logger)),
+		services.WithLogger(logger),
+	}, docOpts...)
+	documentService := services.NewDocumentService(
+		fileStorage,
+		fileParser{},
+		lineSplitter{},
+		constEmbedder{},
+		vectorDB,
+		docOptions...,
+	)
+
+	mockLLM := llm.NewMockClient(t)
+	mockLLM.On("Name").Maybe().Return("mock-llm")
+	mockLLM.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return(
+		&llm.Response{Text: testAnswer, ModelName: "mock-llm", FinishTime: time.Now()}, nil,
+	)
+	cacheService, err := cache.NewCache(cache.Config{Type: "memory", DefaultTTL: time.Hour, CleanupInterval: time.Minute})
+	require.NoError(t, err)
+	qaService := services.NewQAService(constEmbedder{}, vectorDB, mockLLM, llm.NewRAG(mockLLM), cacheService)
+
+	srv := NewServer(documentService, fileStorage, qaService, append([]Option{WithLogger(logger)}, opts...)...)
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &grpcTestEnv{
+		documentService: documentService,
+		docClient:       docqav1.NewDocumentServiceClient(conn),
+		qaClient:        docqav1.NewQAServiceClient(conn),
+	}
+}
+
+// upload 分块上传文件
+func upload(ctx context.Context, client docqav1.DocumentServiceClient, filename, tags, content string) (*docqav1.UploadDocumentResponse, error) {
+	stream, err := client.UploadDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.Send(&docqav1.UploadDocumentRequest{
+		Payload: &docqav1.UploadDocumentRequest_Info{Info: &docqav1.UploadDocumentRequest_FileInfo{Filename: filename, Tags: tags}},
+	}); err != nil {
+		return nil, err
+	}
+	data := []byte(content)
+	for start := 0; start < len(data); start += 16 {
+		end := min(start+16, len(data))
+		if err := stream.Send(&docqav1.UploadDocumentRequest{
+			Payload: &docqav1.UploadDocumentRequest_Chunk{Chunk: data[start:end]},
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// TestGRPCServer 测试通过gRPC上传、管理文档并问答
+func TestGRPCServer(t *testing.T) {
+	docClient, qaClient := setupGRPCTestEnv(t)
+	ctx := context.Background()
+
+	content := "系统支持PDF、Markdown和纯文本文档。\n上传后自动分块并向量化。\n"
+	uploaded, err := upload(ctx, docClient, "guide.txt", "manual", content)
+	require.NoError(t, err)
+	assert.Equal(t, "guide.txt", uploaded.GetFilename())
+	assert.Equal(t, "uploaded", uploaded.GetStatus())
+
+	// 后台处理完成后可查询到段落数量
+	var doc *docqav1.Document
+	require.Eventually(t, func() bool {
+		doc, err = docClient.GetDocument(ctx, &docqav1.GetDocumentRequest{FileId: uploaded.GetFileId()})
+		return err == nil && doc.GetStatus() == string(models.DocStatusCompleted)
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, int32(2), doc.GetSegments())
+	assert.Equal(t, "manual", doc.GetTags())
+	assert.Equal(t, int64(len(content)), doc.GetSize())
+
+	t.Run("Documents", func(t *testing.T) {
+		_, err := upload(ctx, docClient, "virus.exe", "", "MZ")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = docClient.GetDocument(ctx, &docqav1.GetDocumentRequest{FileId: "missing"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		list, err := docClient.ListDocuments(ctx, &docqav1.ListDocumentsRequest{Tags: "manual"})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), list.GetTotal())
+		assert.Equal(t, int32(1), list.GetPage())
+		assert.Equal(t, int32(10), list.GetPageSize())
+		require.Len(t, list.GetDocuments(), 1)
+		assert.Equal(t, uploaded.GetFileId(), list.GetDocuments()[0].GetFileId())
+
+		updated, err := docClient.UpdateDocumentTags(ctx, &docqav1.UpdateDocumentTagsRequest{FileId: uploaded.GetFileId(), Tags: "manual,v2"})
+		require.NoError(t, err)
+		assert.Equal(t, "manual,v2", updated.GetTags())
+	})
+
+	t.Run("Ask", func(t *testing.T) {
+		_, err := qaClient.Ask(ctx, &docqav1.AskRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		resp, err := qaClient.Ask(ctx, &docqav1.AskRequest{Question: "支持哪些文档格式？", FileId: uploaded.GetFileId()})
+		require.NoError(t, err)
+		assert.Equal(t, testAnswer, resp.GetAnswer())
+		require.NotEmpty(t, resp.GetSources())
+		assert.Equal(t, uploaded.GetFileId(), resp.GetSources()[0].GetFileId())
+		assert.NotEmpty(t, resp.GetSources()[0].GetCitations())
+		assert.Nil(t, resp.GetStatus().Confidence)
+	})
+
+	t.Run("AskStream", func(t *testing.T) {
+		stream, err := qaClient.AskStream(ctx, &docqav1.AskRequest{Question: "上传后会做什么？"})
+		require.NoError(t, err)
+
+		var answer strings.Builder
+		var events []*docqav1.AskStreamResponse
+		for {
+			event, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			events = append(events, event)
+			answer.WriteString(event.GetDelta())
+		}
+
+		// 多个回答片段之后依次是来源和结束消息
+		require.Greater(t, len(events), 3)
+		assert.Equal(t, testAnswer, answer.String())
+		assert.NotEmpty(t, events[len(events)-2].GetSources().GetSources())
+		assert.NotNil(t, events[len(events)-1].GetDone())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		resp, err := docClient.DeleteDocument(ctx, &docqav1.DeleteDocumentRequest{FileId: uploaded.GetFileId()})
+		require.NoError(t, err)
+		assert.True(t, resp.GetSuccess())
+
+		_, err = docClient.GetDocument(ctx, &docqav1.GetDocumentRequest{FileId: uploaded.GetFileId()})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+// withKey 返回携带API密钥的调用上下文
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+// TestGRPCAccessControl 测试gRPC调用按登记的API密钥识别租户，文档访问控制和配额与REST接口一致
+func TestGRPCAccessControl(t *testing.T) {
+	authenticator := auth.NewAuthenticator(
+		auth.Key{Key: "sk-alice", Tenant: "alice"},
+		auth.Key{Key: "sk-bob", Tenant: "bob"},
+		auth.Key{Key: "sk-carol", Tenant: "carol"},
+	)
+	setupGRPCTestDB(t)
+	env := newGRPCTestEnv(t,
+		[]services.DocumentOption{services.WithAccessPolicy(acl.NewPolicy())},
+		WithAuthenticator(authenticator),
+		WithQuota(quota.NewManager(repository.NewQuotaRepository(), quota.WithDefaultLimits(quota.Limits{MaxQACallsPerDay: 1}))),
+	)
+	alice, bob, carol := withKey("sk-alice"), withKey("sk-bob"), withKey("sk-carol")
+
+	uploaded, err := upload(alice, env.docClient, "salary.txt", "", "薪酬标准按职级确定。\n")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		doc, err := env.docClient.GetDocument(alice, &docqav1.GetDocumentRequest{FileId: uploaded.GetFileId()})
+		return err == nil && doc.GetStatus() == string(models.DocStatusCompleted)
+	}, 5*time.Second, 20*time.Millisecond)
+	fileID := uploaded.GetFileId()
+
+	// 上传的文档属于密钥对应的租户，伪造的租户元数据和未登记的密钥都是匿名的
+	list, err := env.docClient.ListDocuments(alice, &docqav1.ListDocumentsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), list.GetTotal())
+	spoofed := metadata.AppendToOutgoingContext(context.Background(), "x-tenant-id", "alice")
+	for _, ctx := range []context.Context{bob, spoofed, withKey("sk-unknown")} {
+		list, err := env.docClient.ListDocuments(ctx, &docqav1.ListDocumentsRequest{})
+		require.NoError(t, err)
+		assert.Zero(t, list.GetTotal())
+
+		_, err = env.docClient.GetDocument(ctx, &docqav1.GetDocumentRequest{FileId: fileID})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = env.docClient.UpdateDocumentTags(ctx, &docqav1.UpdateDocumentTagsRequest{FileId: fileID, Tags: "leaked"})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = env.docClient.DeleteDocument(ctx, &docqav1.DeleteDocumentRequest{FileId: fileID})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		_, err = env.qaClient.Ask(ctx, &docqav1.AskRequest{Question: "薪酬标准是什么？", FileId: fileID})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	// 共享给carol后可以读取但不能修改
+	_, err = env.documentService.UpdateDocumentACL(auth.WithIdentity(context.Background(), auth.Identity{Tenant: "alice"}),
+		fileID, false, []string{"carol"}, nil)
+	require.NoError(t, err)
+	_, err = env.docClient.GetDocument(carol, &docqav1.GetDocumentRequest{FileId: fileID})
+	assert.NoError(t, err)
+	_, err = env.docClient.UpdateDocumentTags(carol, &docqav1.UpdateDocumentTagsRequest{FileId: fileID, Tags: "leaked"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = env.docClient.DeleteDocument(carol, &docqav1.DeleteDocumentRequest{FileId: fileID})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// 每日问答配额按租户计算
+	_, err = env.qaClient.Ask(alice, &docqav1.AskRequest{Question: "薪酬标准是什么？", FileId: fileID})
+	require.NoError(t, err)
+	_, err = env.qaClient.Ask(alice, &docqav1.AskRequest{Question: "薪酬标准是什么？", FileId: fileID})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	stream, err := env.qaClient.AskStream(carol, &docqav1.AskRequest{Question: "薪酬标准是什么？"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.NoError(t, err)
+
+	// 上传者可以修改和删除
+	updated, err := env.docClient.UpdateDocumentTags(alice, &docqav1.UpdateDocumentTagsRequest{FileId: fileID, Tags: "confidential"})
+	require.NoError(t, err)
+	assert.Equal(t, "confidential", updated.GetTags())
+	_, err = env.docClient.DeleteDocument(alice, &docqav1.DeleteDocumentRequest{FileId: fileID})
+	assert.NoError(t, err)
+}
+
+// TestGRPCRateLimit 测试gRPC调用按租户或来源地址限流
+func TestGRPCRateLimit(t *testing.T) {
+	authenticator := auth.NewAuthenticator(auth.Key{Key: "sk-alice", Tenant: "alice"})
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: 0.001, Burst: 2})
+	setupGRPCTestDB(t)
+	env := newGRPCTestEnv(t, nil, WithAuthenticator(authenticator), WithRateLimiter(limiter))
+
+	anonymous := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := env.docClient.ListDocuments(anonymous, &docqav1.ListDocumentsRequest{})
+		require.NoError(t, err)
+	}
+	_, err := env.docClient.ListDocuments(anonymous, &docqav1.ListDocumentsRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// 流式调用同样限流，伪造的租户元数据不能获得新的令牌桶
+	stream, err := env.qaClient.AskStream(metadata.AppendToOutgoingContext(anonymous, "x-tenant-id", "mallory"),
+		&docqav1.AskRequest{Question: "上传后会做什么？"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// 已认证的租户使用自己的令牌桶
+	_, err = env.docClient.ListDocuments(withKey("sk-alice"), &docqav1.ListDocumentsRequest{})
+	assert.NoError(t, err)
+}
+
+// TestSplitAnswer 测试按字符切分回答
+func TestSplitAnswer(t *testing.T) {
+	assert.Equal(t, []string{"问答系", "统"}, splitAnswer("问答系统", 3))
+	assert.Equal(t, []string{"ab"}, splitAnswer("ab", 3))
+	assert.Empty(t, splitAnswer("", 3))
+}
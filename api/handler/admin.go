@@ -0,0 +1,1112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
+	"github.com/fyerfyer/doc-QA-system/internal/events"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/scheduler"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminHandler 处理运维管理相关的API请求
+type AdminHandler struct {
+	queue           taskqueue.Queue             // 任务队列，未启用时为nil
+	documentService *services.DocumentService   // 文档服务
+	scheduler       *scheduler.Scheduler        // 定时任务调度器，未启用时为nil
+	stats           *services.StatsService      // 运维统计服务，未配置时为nil
+	qaService       *services.QAService         // 问答服务，用于清除问答缓存
+	audit           *audit.Recorder             // 审计日志记录器，未配置时为nil
+	llmLogs         repository.LLMLogRepository // 大模型交互日志仓储，未写入数据库时为nil
+	connectors      *services.ConnectorService  // 外部来源同步服务，未配置连接器时为nil
+	events          *events.Bus                 // 事件总线，未启用事件时为nil
+	eventLog        *events.MemoryPublisher     // 最近的事件，未启用事件时为nil
+	thresholds      taskqueue.ScalingThresholds // 扩缩容阈值
+	logger          *logrus.Logger              // 日志记录器
+}
+
+// AdminOption 管理处理器配置选项
+type AdminOption func(*AdminHandler)
+
+// WithScalingThresholds 设置扩缩容阈值
+func WithScalingThresholds(thresholds taskqueue.ScalingThresholds) AdminOption {
+	return func(h *AdminHandler) {
+		h.thresholds = thresholds
+	}
+}
+
+// WithAdminDocumentService 设置文档服务，用于向量导入等数据管理接口
+func WithAdminDocumentService(documentService *services.DocumentService) AdminOption {
+	return func(h *AdminHandler) {
+		h.documentService = documentService
+	}
+}
+
+// WithAdminScheduler 设置定时任务调度器，用于查看和手动触发定时任务
+func WithAdminScheduler(s *scheduler.Scheduler) AdminOption {
+	return func(h *AdminHandler) {
+		h.scheduler = s
+	}
+}
+
+// WithAdminStats 设置运维统计服务，用于运维看板统计接口
+func WithAdminStats(stats *services.StatsService) AdminOption {
+	return func(h *AdminHandler) {
+		h.stats = stats
+	}
+}
+
+// WithAdminQAService 设置问答服务，用于清除问答缓存
+func WithAdminQAService(qaService *services.QAService) AdminOption {
+	return func(h *AdminHandler) {
+		h.qaService = qaService
+	}
+}
+
+// WithAdminAudit 设置审计日志记录器，用于查询审计日志
+func WithAdminAudit(recorder *audit.Recorder) AdminOption {
+	return func(h *AdminHandler) {
+		h.audit = recorder
+	}
+}
+
+// WithAdminLLMLogs 设置大模型交互日志仓储，用于查询写入数据库的交互日志
+func WithAdminLLMLogs(repo repository.LLMLogRepository) AdminOption {
+	return func(h *AdminHandler) {
+		h.llmLogs = repo
+	}
+}
+
+// WithAdminConnectors 设置外部来源同步服务，用于查看和手动触发连接器同步
+func WithAdminConnectors(connectors *services.ConnectorService) AdminOption {
+	return func(h *AdminHandler) {
+		h.connectors = connectors
+	}
+}
+
+// WithAdminEvents 设置事件总线和保存最近事件的内存发布者，用于增量读取知识库变更事件
+func WithAdminEvents(bus *events.Bus, log *events.MemoryPublisher) AdminOption {
+	return func(h *AdminHandler) {
+		h.events = bus
+		h.eventLog = log
+	}
+}
+
+// NewAdminHandler 创建管理处理器
+func NewAdminHandler(queue taskqueue.Queue, opts ...AdminOption) *AdminHandler {
+	h := &AdminHandler{
+		queue:      queue,
+		thresholds: taskqueue.DefaultScalingThresholds(),
+		logger:     middleware.GetLogger(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// GetQueueStats 获取队列统计信息
+// GET /api/admin/queue/stats?window=5m&format=prometheus
+func (h *AdminHandler) GetQueueStats(c *gin.Context) {
+	provider, ok := h.queue.(taskqueue.StatsProvider)
+	if !ok {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("当前任务队列不支持统计"))
+		return
+	}
+
+	var window time.Duration
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的统计窗口", w))
+			return
+		}
+		window = d
+	}
+
+	stats, err := provider.Stats(c.Request.Context(), window)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to collect queue stats")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取队列统计失败", err))
+		return
+	}
+	stats.Evaluate(h.thresholds)
+
+	// Prometheus格式，便于Prometheus Adapter或KEDA prometheus scaler采集
+	if c.Query("format") == "prometheus" {
+		c.Header("Content-Type", "text/plain; version=0.0.4")
+		c.Status(http.StatusOK)
+		if err := stats.WritePrometheus(c.Writer); err != nil {
+			h.logger.WithError(err).Warn("Failed to write prometheus metrics")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(stats))
+}
+
+// ListDeadLetter 分页查询死信队列中的任务
+// GET /api/admin/queue/dead-letter?page=1&page_size=20
+func (h *AdminHandler) ListDeadLetter(c *gin.Context) {
+	if !h.requireQueue(c) {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	tasks, total, err := h.queue.ListDeadLetter(c.Request.Context(), (page-1)*pageSize, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list dead letter tasks")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取死信任务失败", nil))
+		return
+	}
+
+	resp := model.DeadLetterListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Tasks:    make([]model.DeadLetterTaskInfo, 0, len(tasks)),
+	}
+	for _, task := range tasks {
+		info := model.DeadLetterTaskInfo{
+			TaskID:         task.ID,
+			Type:           string(task.Type),
+			DocumentID:     task.DocumentID,
+			Attempts:       task.Attempts,
+			Error:          task.Error,
+			ErrorHistory:   make([]model.TaskAttemptInfo, 0, len(task.ErrorHistory)),
+			CreatedAt:      task.CreatedAt,
+			DeadLetteredAt: task.DeadLetteredAt,
+		}
+		for _, e := range task.ErrorHistory {
+			info.ErrorHistory = append(info.ErrorHistory, model.TaskAttemptInfo{
+				Attempt:  e.Attempt,
+				Error:    e.Error,
+				FailedAt: e.FailedAt,
+			})
+		}
+		resp.Tasks = append(resp.Tasks, info)
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// RequeueDeadLetter 将死信任务重新加入队列
+// POST /api/admin/queue/dead-letter/:id/requeue
+func (h *AdminHandler) RequeueDeadLetter(c *gin.Context) {
+	if !h.requireQueue(c) {
+		return
+	}
+
+	taskID := c.Param("id")
+	err := h.queue.RequeueDeadLetter(c.Request.Context(), taskID)
+	switch {
+	case errors.Is(err, taskqueue.ErrNotDeadLettered), errors.Is(err, taskqueue.ErrTaskNotFound):
+		middleware.AbortWithError(c, err)
+		return
+	case err != nil:
+		h.logger.WithError(err).WithField("task_id", taskID).Error("Failed to requeue dead letter task")
+		middleware.AbortWithError(c, middleware.NewInternalError("重新入队失败", nil))
+		return
+	}
+
+	h.logger.WithField("task_id", taskID).Info("Dead letter task requeued by admin")
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"task_id": taskID, "requeued": true}))
+}
+
+// requireQueue 检查任务队列是否已启用，未启用时写入错误响应
+func (h *AdminHandler) requireQueue(c *gin.Context) bool {
+	if h.queue == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用任务队列"))
+		return false
+	}
+	return true
+}
+
+// ImportEmbeddings 批量导入预先计算好的向量
+// POST /api/admin/embeddings/import?overwrite=true
+// 请求体为JSONL（每行一个段落），也可以通过multipart表单的file字段上传
+func (h *AdminHandler) ImportEmbeddings(c *gin.Context) {
+	if h.documentService == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置文档服务，无法导入向量"))
+		return
+	}
+
+	var overwrite bool
+	if v := c.Query("overwrite"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的overwrite参数", v))
+			return
+		}
+		overwrite = b
+	}
+
+	var body io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无法读取上传的文件"))
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	result, err := h.documentService.ImportEmbeddings(c.Request.Context(), body, services.ImportOptions{
+		Overwrite: overwrite,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to import embeddings")
+		middleware.AbortWithError(c, middleware.NewInternalError("导入向量失败", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// ListOrphans 比对存储文件、文档记录和向量，报告孤立的文件和向量，不做删除
+// GET /api/admin/storage/orphans?min_age=24h
+func (h *AdminHandler) ListOrphans(c *gin.Context) {
+	h.collectGarbage(c, true)
+}
+
+// CollectGarbage 删除孤立的存储文件和向量，返回清理报告
+// POST /api/admin/storage/gc?min_age=24h
+func (h *AdminHandler) CollectGarbage(c *gin.Context) {
+	h.collectGarbage(c, false)
+}
+
+// collectGarbage 执行存储垃圾回收，min_age为没有文档记录的文件最短存在时间
+func (h *AdminHandler) collectGarbage(c *gin.Context, dryRun bool) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	opts := services.StorageGCOptions{DryRun: dryRun}
+	if v := c.Query("min_age"); v != "" {
+		age, err := time.ParseDuration(v)
+		if err != nil || age <= 0 {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的min_age参数", v))
+			return
+		}
+		opts.MinAge = age
+	}
+
+	report, err := h.documentService.CollectStorageGarbage(c.Request.Context(), opts)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to collect storage garbage")
+		middleware.AbortWithError(c, middleware.NewInternalError("存储垃圾回收失败", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(report))
+}
+
+// ExportVectors 导出向量数据库快照
+// GET /api/admin/vectors/export
+// 快照为gzip压缩的JSON Lines，与向量数据库类型无关，可用于在不同后端之间迁移
+func (h *AdminHandler) ExportVectors(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	fileName := "vectors-" + time.Now().Format("20060102-150405") + ".jsonl.gz"
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if err := h.documentService.ExportVectors(c.Request.Context(), c.Writer); err != nil {
+		h.writeStreamError(c, err, "导出向量失败")
+	}
+}
+
+// ImportVectors 导入向量数据库快照
+// POST /api/admin/vectors/import
+// 请求体为ExportVectors导出的快照，也可以通过multipart表单的file字段上传
+func (h *AdminHandler) ImportVectors(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	body, closeBody, ok := h.uploadBody(c)
+	if !ok {
+		return
+	}
+	defer closeBody()
+
+	count, err := h.documentService.ImportVectors(c.Request.Context(), body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to import vector snapshot")
+		middleware.AbortWithError(c, middleware.NewValidationError("导入向量快照失败", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"imported": count}))
+}
+
+// Backup 备份文档元数据、段落和向量
+// GET /api/admin/backup
+func (h *AdminHandler) Backup(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	fileName := "docqa-backup-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if _, err := h.documentService.Backup(c.Request.Context(), c.Writer); err != nil {
+		h.writeStreamError(c, err, "备份失败")
+	}
+}
+
+// Restore 从备份归档恢复数据
+// POST /api/admin/restore
+// 请求体为Backup生成的归档，也可以通过multipart表单的file字段上传
+func (h *AdminHandler) Restore(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	body, closeBody, ok := h.uploadBody(c)
+	if !ok {
+		return
+	}
+	defer closeBody()
+
+	result, err := h.documentService.Restore(c.Request.Context(), body)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to restore backup")
+		middleware.AbortWithError(c, middleware.NewInternalError("恢复备份失败", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// ExportCorpus 导出可移植的语料归档，用于环境迁移和生成测试环境的种子数据
+// GET /api/admin/corpus/export?tags=a&status=completed&limit=100&files=false&vectors=false
+// 默认导出全部文档的段落、向量和原始文件
+func (h *AdminHandler) ExportCorpus(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	opts := services.CorpusExportOptions{Filters: make(map[string]interface{})}
+	if tags := c.Query("tags"); tags != "" {
+		opts.Filters["tags"] = tags
+	}
+	if status := c.Query("status"); status != "" {
+		opts.Filters["status"] = status
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的limit参数", v))
+			return
+		}
+		opts.Limit = limit
+	}
+	files, ok := queryBool(c, "files", true)
+	if !ok {
+		return
+	}
+	vectors, ok := queryBool(c, "vectors", true)
+	if !ok {
+		return
+	}
+	opts.WithoutFiles, opts.WithoutVectors = !files, !vectors
+
+	fileName := "docqa-corpus-" + time.Now().Format("20060102-150405") + ".tar.gz"
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	if _, err := h.documentService.ExportCorpus(c.Request.Context(), c.Writer, opts); err != nil {
+		h.writeStreamError(c, err, "导出语料失败")
+	}
+}
+
+// ImportCorpus 导入语料归档
+// POST /api/admin/corpus/import?overwrite=true&reembed=true
+// 请求体为ExportCorpus导出的归档，也可以通过multipart表单的file字段上传
+func (h *AdminHandler) ImportCorpus(c *gin.Context) {
+	if !h.requireDocumentService(c) {
+		return
+	}
+
+	overwrite, ok := queryBool(c, "overwrite", false)
+	if !ok {
+		return
+	}
+	reembed, ok := queryBool(c, "reembed", false)
+	if !ok {
+		return
+	}
+
+	body, closeBody, ok := h.uploadBody(c)
+	if !ok {
+		return
+	}
+	defer closeBody()
+
+	result, err := h.documentService.ImportCorpus(c.Request.Context(), body, services.CorpusImportOptions{
+		Overwrite: overwrite,
+		Reembed:   reembed,
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to import corpus")
+		middleware.AbortWithError(c, middleware.NewInternalError("导入语料失败", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(result))
+}
+
+// queryBool 解析布尔查询参数，参数无效时返回400
+func queryBool(c *gin.Context, name string, def bool) (bool, bool) {
+	v := c.Query(name)
+	if v == "" {
+		return def, true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的"+name+"参数", v))
+		return false, false
+	}
+	return b, true
+}
+
+// requireDocumentService 检查是否配置了文档服务，未配置时返回501
+func (h *AdminHandler) requireDocumentService(c *gin.Context) bool {
+	if h.documentService != nil {
+		return true
+	}
+	middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置文档服务"))
+	return false
+}
+
+// uploadBody 返回上传的数据，优先使用multipart表单的file字段，否则使用请求体
+func (h *AdminHandler) uploadBody(c *gin.Context) (io.Reader, func(), bool) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Request.Body, func() {}, true
+	}
+	f, err := file.Open()
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无法读取上传的文件"))
+		return nil, nil, false
+	}
+	return f, func() { f.Close() }, true
+}
+
+// writeStreamError 处理下载过程中的错误
+// 响应已开始写入时无法再返回JSON，只记录日志
+func (h *AdminHandler) writeStreamError(c *gin.Context, err error, message string) {
+	h.logger.WithError(err).Error(message)
+	if c.Writer.Written() {
+		return
+	}
+	c.Header("Content-Disposition", "")
+	middleware.AbortWithError(c, middleware.NewInternalError(message, err))
+}
+
+// ListJobs 列出定时任务
+// GET /api/admin/jobs
+func (h *AdminHandler) ListJobs(c *gin.Context) {
+	if !h.requireScheduler(c) {
+		return
+	}
+
+	jobs := h.scheduler.Jobs()
+	infos := make([]model.JobInfo, 0, len(jobs))
+	for _, j := range jobs {
+		infos = append(infos, model.JobInfo{
+			Name:     j.Name,
+			Type:     j.Type,
+			Schedule: j.Schedule,
+			Running:  j.Running,
+			NextRun:  j.NextRun,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(infos))
+}
+
+// ListJobRuns 查询定时任务运行历史
+// GET /api/admin/jobs/runs?job=recrawl-web-docs&page=1&page_size=20
+func (h *AdminHandler) ListJobRuns(c *gin.Context) {
+	if !h.requireScheduler(c) {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	runs, total, err := h.scheduler.Runs(c.Request.Context(), c.Query("job"), (page-1)*pageSize, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list job runs")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取运行记录失败", nil))
+		return
+	}
+
+	resp := model.JobRunListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Runs:     make([]model.JobRunInfo, 0, len(runs)),
+	}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, model.JobRunInfo{
+			ID:         run.ID,
+			JobName:    run.JobName,
+			JobType:    run.JobType,
+			Trigger:    run.Trigger,
+			Status:     string(run.Status),
+			Processed:  run.Processed,
+			Enqueued:   run.Enqueued,
+			Failed:     run.Failed,
+			Message:    run.Message,
+			StartedAt:  run.StartedAt,
+			FinishedAt: run.FinishedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// RunJob 立即触发定时任务，任务在后台运行
+// POST /api/admin/jobs/:name/run
+func (h *AdminHandler) RunJob(c *gin.Context) {
+	if !h.requireScheduler(c) {
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.scheduler.Trigger(name); err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("定时任务不存在", name))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, model.NewSuccessResponse(gin.H{"name": name, "triggered": true}))
+}
+
+// ListConnectors 查询外部来源连接器的同步状态
+// GET /api/admin/connectors
+func (h *AdminHandler) ListConnectors(c *gin.Context) {
+	if !h.requireConnectors(c) {
+		return
+	}
+
+	statuses, err := h.connectors.Statuses(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get connector statuses")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取连接器状态失败", nil))
+		return
+	}
+
+	infos := make([]model.ConnectorInfo, 0, len(statuses))
+	for _, s := range statuses {
+		infos = append(infos, model.ConnectorInfo{
+			Name:       s.Name,
+			Type:       s.Type,
+			GroupID:    s.GroupID,
+			Running:    s.Running,
+			Items:      s.Items,
+			LastSyncAt: s.LastSyncAt,
+			LastError:  s.LastError,
+		})
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(infos))
+}
+
+// SyncConnector 手动触发连接器同步，同步在后台执行
+// POST /api/admin/connectors/:name/sync?full=true
+// full为true时忽略游标全量同步，并删除来源中已不存在的文档
+func (h *AdminHandler) SyncConnector(c *gin.Context) {
+	if !h.requireConnectors(c) {
+		return
+	}
+
+	name := c.Param("name")
+	full, _ := strconv.ParseBool(c.DefaultQuery("full", "false"))
+	release, err := h.connectors.Start(name)
+	switch {
+	case errors.Is(err, services.ErrConnectorNotFound):
+		middleware.AbortWithError(c, middleware.NewNotFoundError("连接器不存在", name))
+		return
+	case errors.Is(err, services.ErrConnectorBusy):
+		middleware.AbortWithError(c, middleware.NewConflictError("连接器正在同步", name))
+		return
+	case err != nil:
+		middleware.AbortWithError(c, middleware.NewInternalError("启动同步失败", err))
+		return
+	}
+
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		defer release()
+		if _, err := h.connectors.SyncStarted(ctx, name, full); err != nil {
+			h.logger.WithError(err).WithField("connector", name).Error("Connector sync failed")
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, model.NewSuccessResponse(gin.H{"name": name, "full": full, "triggered": true}))
+}
+
+// ListEvents 增量读取最近的知识库变更事件
+// GET /api/admin/events?after=0&limit=100
+// 调用方保存返回的latest_seq，下次以after=latest_seq读取新事件；内存中只保留最近的事件，
+// 返回的第一个事件序号大于after+1时说明中间的事件已被淘汰
+func (h *AdminHandler) ListEvents(c *gin.Context) {
+	if h.eventLog == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用事件发布"))
+		return
+	}
+
+	after, err := strconv.ParseInt(c.DefaultQuery("after", "0"), 10, 64)
+	if err != nil || after < 0 {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的after参数", c.Query("after")))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		middleware.AbortWithError(c, middleware.NewValidationError("limit必须在1到1000之间", c.Query("limit")))
+		return
+	}
+
+	entries, latest := h.eventLog.Since(after, limit)
+	stats := h.events.Stats()
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.EventListResponse{
+		Events:    entries,
+		LatestSeq: latest,
+		Published: stats.Published,
+		Dropped:   stats.Dropped,
+		Failed:    stats.Failed,
+	}))
+}
+
+// requireConnectors 检查是否配置了连接器，未配置时写入错误响应
+func (h *AdminHandler) requireConnectors(c *gin.Context) bool {
+	if h.connectors == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置外部来源连接器"))
+		return false
+	}
+	return true
+}
+
+// requireScheduler 检查调度器是否已启用，未启用时写入错误响应
+func (h *AdminHandler) requireScheduler(c *gin.Context) bool {
+	if h.scheduler == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用定时任务"))
+		return false
+	}
+	return true
+}
+
+// GetStats 获取运维看板统计
+// GET /api/admin/stats?days=14
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	if h.stats == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用运维统计"))
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > 90 {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的统计天数", "days必须在1到90之间"))
+		return
+	}
+
+	stats, err := h.stats.Collect(c.Request.Context(), days)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to collect admin stats")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取运维统计失败", nil))
+		return
+	}
+
+	resp := model.AdminStatsResponse{
+		Documents:          stats.Documents,
+		DocumentsByStatus:  make(map[string]int64, len(stats.DocumentsByStatus)),
+		Segments:           stats.Segments,
+		DuplicateSegments:  stats.DuplicateSegments,
+		SuppressedSegments: stats.SuppressedSegments,
+		Vectors:            stats.Vectors,
+		StorageBytes:       stats.StorageBytes,
+		From:               stats.From,
+		To:                 stats.To,
+		Questions:          stats.Questions,
+		AvgLatencyMs:       stats.AvgLatencyMs,
+		CacheHitRate:       stats.CacheHitRate,
+		ErrorRate:          stats.ErrorRate,
+		QAByDay:            make([]model.QADayStatsInfo, 0, len(stats.QAByDay)),
+	}
+	for status, count := range stats.DocumentsByStatus {
+		resp.DocumentsByStatus[string(status)] = count
+	}
+	for _, day := range stats.QAByDay {
+		resp.QAByDay = append(resp.QAByDay, model.QADayStatsInfo{
+			Date:         day.Date,
+			Questions:    day.Questions,
+			CacheHits:    day.CacheHits,
+			Errors:       day.Errors,
+			AvgLatencyMs: day.AvgLatencyMs,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ComparePromptVariants 对比各提示词变体的问答量、耗时、失败率和负反馈
+// GET /api/admin/prompt-variants?days=14
+func (h *AdminHandler) ComparePromptVariants(c *gin.Context) {
+	if h.stats == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用运维统计"))
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "14"))
+	if err != nil || days < 1 || days > 90 {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的统计天数", "days必须在1到90之间"))
+		return
+	}
+
+	var variants []services.PromptVariant
+	if h.qaService != nil {
+		variants = h.qaService.PromptVariants()
+	}
+	stats, err := h.stats.ComparePromptVariants(c.Request.Context(), days, variants)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to compare prompt variants")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取提示词变体统计失败", nil))
+		return
+	}
+
+	resp := model.PromptVariantListResponse{
+		Days:     days,
+		Variants: make([]model.PromptVariantStatsInfo, 0, len(stats)),
+	}
+	for _, v := range stats {
+		resp.Variants = append(resp.Variants, model.PromptVariantStatsInfo{
+			Variant:          v.Variant,
+			Weight:           v.Weight,
+			Questions:        v.Questions,
+			Errors:           v.Errors,
+			NegativeFeedback: v.NegativeFeedback,
+			AvgLatencyMs:     v.AvgLatencyMs,
+			ErrorRate:        v.ErrorRate,
+			FeedbackRate:     v.FeedbackRate,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// GetVectorDBInfo 获取向量数据库的索引类型、维度、距离度量、向量数和持久化状态，
+// 并比对向量数与段落记录数，用于核对向量数据库配置与嵌入模型是否相符
+// GET /api/admin/vectordb/info?format=prometheus
+// Prometheus格式只输出查询缓存的命中统计
+func (h *AdminHandler) GetVectorDBInfo(c *gin.Context) {
+	if h.stats == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用运维统计"))
+		return
+	}
+
+	info, err := h.stats.DescribeVectorDB(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to describe vector database")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取向量数据库信息失败", nil))
+		return
+	}
+
+	if c.Query("format") == "prometheus" {
+		c.Header("Content-Type", "text/plain; version=0.0.4")
+		c.Status(http.StatusOK)
+		if info.QueryCache == nil {
+			return
+		}
+		if err := info.QueryCache.WritePrometheus(c.Writer); err != nil {
+			h.logger.WithError(err).Warn("Failed to write prometheus metrics")
+		}
+		return
+	}
+
+	resp := model.VectorDBInfoResponse{
+		Type:        info.Type,
+		IndexType:   info.IndexType,
+		Dimension:   info.Dimension,
+		Distance:    string(info.Distance),
+		Normalized:  info.Normalized,
+		Vectors:     info.Count,
+		MemoryBytes: info.MemoryBytes,
+		Segments:    info.Segments,
+		Suppressed:  info.Suppressed,
+		Consistent:  info.Consistent,
+	}
+	if !info.LastSave.IsZero() {
+		resp.LastSave = &info.LastSave
+	}
+	if info.QueryCache != nil {
+		resp.QueryCache = &model.QueryCacheInfo{QueryCacheStats: *info.QueryCache, HitRate: info.QueryCache.HitRate()}
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ListCalibrations 列出各嵌入模型校准的最低相似度和当前模型实际使用的阈值
+// GET /api/admin/calibration
+func (h *AdminHandler) ListCalibrations(c *gin.Context) {
+	if h.qaService == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置问答服务"))
+		return
+	}
+
+	calibrations, current, minScore, err := h.qaService.ScoreCalibrations(c.Request.Context())
+	if errors.Is(err, services.ErrCalibrationDisabled) {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用最低相似度自动校准"))
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list score calibrations")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取校准结果失败", nil))
+		return
+	}
+
+	resp := model.CalibrationListResponse{
+		Model:        current,
+		MinScore:     minScore,
+		Calibrations: make([]model.CalibrationInfo, 0, len(calibrations)),
+	}
+	for _, cal := range calibrations {
+		resp.Calibrations = append(resp.Calibrations, toCalibrationInfo(cal))
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// Calibrate 为当前嵌入模型重新校准最低相似度，操作记入审计日志
+// POST /api/admin/calibration
+// 请求体可选，未指定问题时使用最近的用户问题
+func (h *AdminHandler) Calibrate(c *gin.Context) {
+	if h.qaService == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置问答服务"))
+		return
+	}
+
+	var req model.CalibrateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+			return
+		}
+	}
+	if req.Percentile < 0 || req.Percentile > 100 {
+		middleware.AbortWithError(c, middleware.NewValidationError("percentile必须在0到100之间", strconv.FormatFloat(req.Percentile, 'g', -1, 64)))
+		return
+	}
+
+	calibration, err := h.qaService.CalibrateMinScore(c.Request.Context(), req.Questions, req.Percentile)
+	switch {
+	case errors.Is(err, services.ErrCalibrationDisabled):
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用最低相似度自动校准"))
+		return
+	case errors.Is(err, services.ErrNotEnoughCalibrationData):
+		middleware.AbortWithError(c, middleware.NewValidationError("校准数据不足", err.Error()))
+		return
+	case err != nil:
+		h.logger.WithContext(c.Request.Context()).WithError(err).Error("Failed to calibrate min score")
+		middleware.AbortWithError(c, middleware.NewInternalError("校准最低相似度失败", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toCalibrationInfo(calibration)))
+}
+
+// toCalibrationInfo 转换校准结果
+func toCalibrationInfo(cal *models.ScoreCalibration) model.CalibrationInfo {
+	return model.CalibrationInfo{
+		Model:        cal.Model,
+		Threshold:    cal.Threshold,
+		Percentile:   cal.Percentile,
+		Questions:    cal.Questions,
+		Samples:      cal.Samples,
+		MinScore:     cal.MinScore,
+		MedianScore:  cal.MedianScore,
+		MaxScore:     cal.MaxScore,
+		CalibratedAt: cal.CalibratedAt,
+	}
+}
+
+// ClearCache 清除问答缓存，操作记入审计日志
+// DELETE /api/admin/cache
+func (h *AdminHandler) ClearCache(c *gin.Context) {
+	if h.qaService == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置问答服务"))
+		return
+	}
+
+	if err := h.qaService.ClearCache(c.Request.Context()); err != nil {
+		h.logger.WithContext(c.Request.Context()).WithError(err).Error("Failed to clear QA cache")
+		middleware.AbortWithError(c, middleware.NewInternalError("清除缓存失败", nil))
+		return
+	}
+
+	h.logger.WithContext(c.Request.Context()).Info("QA cache cleared by admin")
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"cleared": true}))
+}
+
+// ListAuditLogs 分页查询审计日志，最新的在前
+// GET /api/admin/audit?actor=team-a&action=document.delete&target=xxx&since=2024-03-01T00:00:00Z&until=...&page=1&page_size=20
+func (h *AdminHandler) ListAuditLogs(c *gin.Context) {
+	if h.audit == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用审计日志"))
+		return
+	}
+
+	filter := repository.AuditFilter{
+		Actor:  c.Query("actor"),
+		Action: models.AuditAction(c.Query("action")),
+		Target: c.Query("target"),
+	}
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的时间参数", param+"必须是RFC3339格式"))
+			return
+		}
+		*dst = t
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	entries, total, err := h.audit.List(c.Request.Context(), filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list audit logs")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取审计日志失败", nil))
+		return
+	}
+
+	resp := model.AuditLogListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  make([]model.AuditLogInfo, 0, len(entries)),
+	}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, model.AuditLogInfo{
+			ID:        e.ID,
+			Actor:     e.Actor,
+			Action:    string(e.Action),
+			Target:    e.Target,
+			Detail:    e.Detail,
+			RequestID: e.RequestID,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ListLLMLogs 分页查询大模型交互日志，最新的在前
+// GET /api/admin/llm-logs?tenant=team-a&model=qwen-plus&request_id=xxx&since=2024-03-01T00:00:00Z&until=...&page=1&page_size=20
+func (h *AdminHandler) ListLLMLogs(c *gin.Context) {
+	if h.llmLogs == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用写入数据库的大模型交互日志"))
+		return
+	}
+
+	filter := repository.LLMLogFilter{
+		Tenant:    c.Query("tenant"),
+		Model:     c.Query("model"),
+		RequestID: c.Query("request_id"),
+	}
+	for param, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		v := c.Query(param)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的时间参数", param+"必须是RFC3339格式"))
+			return
+		}
+		*dst = t
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	entries, total, err := h.llmLogs.WithContext(c.Request.Context()).List(filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list LLM interaction logs")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取大模型交互日志失败", nil))
+		return
+	}
+
+	resp := model.LLMLogListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  make([]model.LLMLogInfo, 0, len(entries)),
+	}
+	for _, e := range entries {
+		info := model.LLMLogInfo{
+			ID:        e.ID,
+			Tenant:    e.Tenant,
+			RequestID: e.RequestID,
+			Model:     e.Model,
+			Method:    e.Method,
+			Prompt:    e.Prompt,
+			Response:  e.Response,
+			Error:     e.Error,
+			LatencyMs: e.LatencyMs,
+			Tokens:    e.Tokens,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.ContextIDs != "" {
+			info.ContextIDs = strings.Split(e.ContextIDs, ",")
+		}
+		resp.Entries = append(resp.Entries, info)
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
@@ -1,33 +1,169 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/api/middleware"
 	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 // ChatHandler 处理聊天相关的API请求
 type ChatHandler struct {
-	chatService *services.ChatService // 聊天服务
-	qaService   *services.QAService   // 问答服务
-	logger      *logrus.Logger        // 日志记录器
+	chatService *services.ChatService  // 聊天服务
+	qaService   *services.QAService    // 问答服务
+	guard       *services.GuardService // 问答护栏，为空时不审核内容
+	quota       *quota.Manager         // 租户配额，为空时不限制
+	groups      *services.GroupService // 文档组服务，用于解析会话绑定的文档组，为空时不支持绑定文档组
+	models      *llm.ModelRouter       // 可按消息选择的模型，为空时不支持选择模型
+	logger      *logrus.Logger         // 日志记录器
+}
+
+// ChatHandlerOption 聊天处理器配置选项
+type ChatHandlerOption func(*ChatHandler)
+
+// WithChatGuard 设置问答护栏，被拦截的问题以拒绝回答作为助手回复
+func WithChatGuard(guard *services.GuardService) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.guard = guard
+	}
+}
+
+// WithChatQuota 设置租户配额，每次生成助手回复消耗一次当日问答配额
+func WithChatQuota(manager *quota.Manager) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.quota = manager
+	}
+}
+
+// WithChatGroups 设置文档组服务，用于校验和解析会话绑定的文档和文档组
+func WithChatGroups(groups *services.GroupService) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.groups = groups
+	}
+}
+
+// WithChatModels 设置可按消息选择的模型，生成助手回复时使用消息中指定的模型
+func WithChatModels(models *llm.ModelRouter) ChatHandlerOption {
+	return func(h *ChatHandler) {
+		h.models = models
+	}
 }
 
 // NewChatHandler 创建新的聊天处理器
-func NewChatHandler(chatService *services.ChatService, qaService *services.QAService) *ChatHandler {
-	return &ChatHandler{
+func NewChatHandler(chatService *services.ChatService, qaService *services.QAService, opts ...ChatHandlerOption) *ChatHandler {
+	h := &ChatHandler{
 		chatService: chatService,
 		qaService:   qaService,
 		logger:      middleware.GetLogger(),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// answer 生成助手回复，配置护栏时被拦截的问题返回拒绝回答
+// 会话绑定了文档或文档组时只在绑定的范围内检索
+func (h *ChatHandler) answer(ctx context.Context, session *models.ChatSession, question string) (string, []vectordb.Document, error) {
+	// 会话的系统提示词设定助手角色，摘要作为对话记忆帮助理解追问
+	ctx = services.WithChatContext(ctx, session)
+	ask, err := h.scopedAnswer(ctx, session)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := h.quota.ConsumeQA(ctx); err != nil {
+		return "", nil, err
+	}
+	if h.guard == nil {
+		return ask(ctx, question)
+	}
+	answer, sources, err := h.guard.Answer(ctx, question, ask)
+	if _, ok := moderation.AsBlocked(err); ok {
+		return h.guard.Refusal(), nil, nil
+	}
+	return answer, sources, err
+}
+
+// scopedAnswer 按会话绑定的范围选择问答方式
+// 未绑定时在全部文档中检索，只绑定一个文档时按文件问答，否则按多文件范围问答
+func (h *ChatHandler) scopedAnswer(ctx context.Context, session *models.ChatSession) (services.QuestionAnswerFunc, error) {
+	documentIDs, groupIDs := session.ScopeDocumentIDs(), session.ScopeGroupIDs()
+	if len(documentIDs) == 0 && len(groupIDs) == 0 {
+		return h.qaService.Answer, nil
+	}
+	if len(documentIDs) == 1 && len(groupIDs) == 0 {
+		return func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return h.qaService.AnswerWithFile(ctx, question, documentIDs[0])
+		}, nil
+	}
+
+	files, err := h.resolveScope(ctx, documentIDs, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("chat scope has no available documents")
+	}
+	return func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+		return h.qaService.AnswerWithFiles(ctx, question, files)
+	}, nil
+}
+
+// resolveScope 把会话绑定的文档和文档组解析为问答范围
+// 未配置文档组服务时只支持绑定文档，标签为空时问答使用文件名
+func (h *ChatHandler) resolveScope(ctx context.Context, documentIDs, groupIDs []string) ([]services.ScopedFile, error) {
+	if h.groups != nil {
+		return h.groups.ResolveFiles(ctx, documentIDs, groupIDs)
+	}
+	if len(groupIDs) > 0 {
+		return nil, fmt.Errorf("document groups are not supported")
+	}
+	files := make([]services.ScopedFile, 0, len(documentIDs))
+	for _, id := range documentIDs {
+		files = append(files, services.ScopedFile{FileID: id})
+	}
+	return files, nil
+}
+
+// createChat 创建会话并绑定范围，绑定的文档或文档组不存在时不创建会话
+func (h *ChatHandler) createChat(c *gin.Context, title string, documentIDs, groupIDs []string, systemPrompt string) (*models.ChatSession, bool) {
+	ctx := c.Request.Context()
+	scoped := len(documentIDs) > 0 || len(groupIDs) > 0
+	if scoped {
+		if _, err := h.resolveScope(ctx, documentIDs, groupIDs); err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的会话范围", err.Error()))
+			return nil, false
+		}
+	}
+
+	session, err := h.chatService.CreateChat(ctx, title)
+	if err == nil && scoped {
+		session, err = h.chatService.SetChatScope(ctx, session.ID, documentIDs, groupIDs)
+	}
+	if err == nil && systemPrompt != "" {
+		session, err = h.chatService.SetSystemPrompt(ctx, session.ID, systemPrompt)
+	}
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create chat session")
+		middleware.AbortWithError(c, middleware.NewInternalError("创建聊天会话失败", nil))
+		return nil, false
+	}
+	return session, true
 }
 
 // CreateChat 创建新的聊天会话
@@ -37,29 +173,25 @@ func (h *ChatHandler) CreateChat(c *gin.Context) {
 	var req model.CreateChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid create chat request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
 	// 创建聊天会话
-	session, err := h.chatService.CreateChat(c.Request.Context(), req.Title)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to create chat session")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"创建聊天会话失败",
-		))
+	session, ok := h.createChat(c, req.Title, req.DocumentIDs, req.GroupIDs, req.SystemPrompt)
+	if !ok {
 		return
 	}
 
 	// 构建响应
 	resp := model.CreateChatResponse{
-		ChatID:    session.ID,
-		Title:     session.Title,
-		CreatedAt: session.CreatedAt,
+		ChatID:      session.ID,
+		Title:       session.Title,
+		CreatedAt:   session.CreatedAt,
+		DocumentIDs: session.ScopeDocumentIDs(),
+		GroupIDs:    session.ScopeGroupIDs(),
+
+		SystemPrompt: session.SystemPrompt,
 	}
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
@@ -72,10 +204,7 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	var req model.GetChatHistoryRequest
 	if err := c.ShouldBindUri(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid chat history request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的会话ID",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的会话ID"))
 		return
 	}
 
@@ -87,10 +216,7 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	session, err := h.chatService.GetChatSession(c.Request.Context(), req.SessionID)
 	if err != nil {
 		h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to get chat session")
-		c.JSON(http.StatusNotFound, model.NewErrorResponse(
-			http.StatusNotFound,
-			"聊天会话不存在",
-		))
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
 		return
 	}
 
@@ -98,48 +224,26 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 	messages, _, err := h.chatService.GetChatMessages(c.Request.Context(), req.SessionID, offset, limit)
 	if err != nil {
 		h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to get chat messages")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"获取聊天消息失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("获取聊天消息失败", nil))
 		return
 	}
 
 	// 转换为响应格式
 	messageInfos := make([]model.MessageInfo, 0, len(messages))
 	for _, msg := range messages {
-		// 处理消息中的引用来源（如果有）
-		var sources []model.QASourceInfo
-		if len(msg.Sources) > 0 {
-			// 解析Sources字段
-			var msgSources []models.Source
-			// 使用标准json包进行解析，而不是直接调用方法
-			if err := json.Unmarshal(msg.Sources, &msgSources); err == nil {
-				for _, src := range msgSources {
-					sources = append(sources, model.QASourceInfo{
-						FileID:   src.FileID,
-						FileName: src.FileName,
-						Text:     src.Text,
-						Position: src.Position,
-					})
-				}
-			}
-		}
-
-		messageInfos = append(messageInfos, model.MessageInfo{
-			ID:        strconv.Itoa(int(msg.ID)),
-			Role:      string(msg.Role),
-			Content:   msg.Content,
-			CreatedAt: msg.CreatedAt,
-			Sources:   sources,
-		})
+		messageInfos = append(messageInfos, toMessageInfo(msg))
 	}
 
 	// 构建响应
 	resp := model.ChatHistoryResponse{
-		ChatID:   session.ID,
-		Title:    session.Title,
-		Messages: messageInfos,
+		ChatID:      session.ID,
+		Title:       session.Title,
+		DocumentIDs: session.ScopeDocumentIDs(),
+		GroupIDs:    session.ScopeGroupIDs(),
+		Summary:     session.Summary,
+		Messages:    messageInfos,
+
+		SystemPrompt: session.SystemPrompt,
 	}
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
@@ -152,10 +256,7 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 	var req model.ChatListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid chat list request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
@@ -175,18 +276,52 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 		filters["end_time"] = *req.EndTime
 	}
 
+	// 提供游标时使用键集分页，翻页期间有会话更新也不会重复返回同一页
+	if req.Cursor != "" {
+		if _, err := repository.DecodeCursor(req.Cursor); err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的分页游标"))
+			return
+		}
+		chats, next, err := h.chatService.GetChatsWithMessageCountAfter(c.Request.Context(), req.Cursor, limit)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to list chat sessions")
+			middleware.AbortWithError(c, middleware.NewInternalError("获取聊天会话列表失败", nil))
+			return
+		}
+		c.JSON(http.StatusOK, model.NewSuccessResponse(model.ChatListResponse{
+			PageSize:   limit,
+			NextCursor: next,
+			Chats:      toChatInfos(chats),
+		}))
+		return
+	}
+
 	// 获取带有消息数量的聊天列表
 	chats, total, err := h.chatService.GetChatsWithMessageCount(c.Request.Context(), offset, limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to list chat sessions")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"获取聊天会话列表失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("获取聊天会话列表失败", nil))
 		return
 	}
 
-	// 转换为响应格式
+	// 构建响应
+	resp := model.ChatListResponse{
+		Total:    total,
+		Page:     req.GetPage(),
+		PageSize: req.GetPageSize(),
+		Chats:    toChatInfos(chats),
+	}
+	// 返回游标，客户端可以从第二页开始改用游标翻页
+	if len(chats) > 0 && int64(offset+len(chats)) < total {
+		last := chats[len(chats)-1]
+		resp.NextCursor = repository.Cursor{Time: last["updated_at"].(time.Time), ID: last["id"].(string)}.Encode()
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// toChatInfos 将带消息数量的会话列表转换为响应格式
+func toChatInfos(chats []map[string]interface{}) []model.ChatInfo {
 	chatInfos := make([]model.ChatInfo, 0, len(chats))
 	for _, chat := range chats {
 		chatInfos = append(chatInfos, model.ChatInfo{
@@ -197,13 +332,42 @@ func (h *ChatHandler) ListChats(c *gin.Context) {
 			MessageCount: int(chat["message_count"].(int64)),
 		})
 	}
+	return chatInfos
+}
 
-	// 构建响应
-	resp := model.ChatListResponse{
+// SearchChats 在聊天记录中搜索消息内容
+// GET /api/chats/search?q=...
+// 携带租户标识时只搜索该租户创建的会话
+func (h *ChatHandler) SearchChats(c *gin.Context) {
+	var req model.ChatSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("搜索内容不能为空且不超过200个字符"))
+		return
+	}
+
+	offset := (req.GetPage() - 1) * req.GetPageSize()
+	hits, total, err := h.chatService.SearchChats(c.Request.Context(), req.Query, offset, req.GetPageSize())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to search chats")
+		middleware.AbortWithError(c, middleware.NewInternalError("搜索聊天记录失败", nil))
+		return
+	}
+
+	resp := model.ChatSearchResponse{
 		Total:    total,
 		Page:     req.GetPage(),
 		PageSize: req.GetPageSize(),
-		Chats:    chatInfos,
+		Hits:     make([]model.ChatSearchHit, 0, len(hits)),
+	}
+	for _, hit := range hits {
+		resp.Hits = append(resp.Hits, model.ChatSearchHit{
+			SessionID: hit.Session.ID,
+			Title:     hit.Session.Title,
+			MessageID: hit.Message.ID,
+			Role:      string(hit.Message.Role),
+			Highlight: hit.Highlight,
+			CreatedAt: hit.Message.CreatedAt,
+		})
 	}
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
@@ -216,21 +380,20 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 	var req model.CreateMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid add message request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
 	// 检查会话是否存在
-	_, err := h.chatService.GetChatSession(c.Request.Context(), req.SessionID)
+	session, err := h.chatService.GetChatSession(c.Request.Context(), req.SessionID)
 	if err != nil {
 		h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Chat session not found")
-		c.JSON(http.StatusNotFound, model.NewErrorResponse(
-			http.StatusNotFound,
-			"聊天会话不存在",
-		))
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
+		return
+	}
+	answerCtx, modelName, err := h.models.Select(c.Request.Context(), req.Model)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的模型", err.Error()))
 		return
 	}
 
@@ -246,15 +409,12 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 		// 添加用户消息
 		if err := h.chatService.AddMessage(c.Request.Context(), message); err != nil {
 			h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to add user message")
-			c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-				http.StatusInternalServerError,
-				"添加用户消息失败",
-			))
+			middleware.AbortWithError(c, middleware.NewInternalError("添加用户消息失败", nil))
 			return
 		}
 
 		// 使用QA服务生成回答
-		answer, sources, err := h.qaService.Answer(c.Request.Context(), req.Content)
+		answer, sources, err := h.answer(answerCtx, session, req.Content)
 		if err != nil {
 			h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to generate answer")
 
@@ -266,10 +426,11 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 			}
 			h.chatService.AddMessage(c.Request.Context(), errMessage)
 
-			c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-				http.StatusInternalServerError,
-				"生成回答失败",
-			))
+			if errors.Is(err, models.ErrQuotaExceeded) {
+				middleware.AbortWithError(c, err)
+				return
+			}
+			middleware.AbortWithError(c, middleware.NewInternalError("生成回答失败", nil))
 			return
 		}
 
@@ -297,10 +458,7 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 			modelSources,
 		); err != nil {
 			h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to add assistant message")
-			c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-				http.StatusInternalServerError,
-				"添加助手回复失败",
-			))
+			middleware.AbortWithError(c, middleware.NewInternalError("添加助手回复失败", nil))
 			return
 		}
 
@@ -322,12 +480,7 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 		// 构建引用来源
 		var responseSources []model.QASourceInfo
 		for _, src := range modelSources {
-			responseSources = append(responseSources, model.QASourceInfo{
-				FileID:   src.FileID,
-				FileName: src.FileName,
-				Text:     src.Text,
-				Position: src.Position,
-			})
+			responseSources = append(responseSources, model.NewSourceInfo(src.FileID, src.FileName, src.Text, src.Position))
 		}
 
 		// 构建响应对象
@@ -347,6 +500,9 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 				Sources:   responseSources,
 			},
 		}
+		if modelName != "" {
+			resp["model"] = modelName
+		}
 
 		c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
 		return
@@ -355,10 +511,7 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 	// 非用户消息直接添加（系统消息等）
 	if err := h.chatService.AddMessage(c.Request.Context(), message); err != nil {
 		h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to add message")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"添加消息失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("添加消息失败", nil))
 		return
 	}
 
@@ -368,6 +521,318 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 	}))
 }
 
+// SetChatScope 设置会话绑定的文档和文档组
+// PUT /api/chats/:session_id/scope
+func (h *ChatHandler) SetChatScope(c *gin.Context) {
+	var req model.ChatScopeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessionID := c.Param("session_id")
+	if _, err := h.chatService.GetChatSession(ctx, sessionID); err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
+		return
+	}
+	if _, err := h.resolveScope(ctx, req.DocumentIDs, req.GroupIDs); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的会话范围", err.Error()))
+		return
+	}
+
+	session, err := h.chatService.SetChatScope(ctx, sessionID, req.DocumentIDs, req.GroupIDs)
+	if err != nil {
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to set chat scope")
+		middleware.AbortWithError(c, middleware.NewInternalError("设置会话范围失败", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.ChatScopeResponse{
+		SessionID:   session.ID,
+		DocumentIDs: session.ScopeDocumentIDs(),
+		GroupIDs:    session.ScopeGroupIDs(),
+	}))
+}
+
+// EditMessage 编辑用户消息，之后的消息全部失效并删除
+// 默认根据编辑后的问题生成新的助手回复，regenerate为false时只编辑
+// PATCH /api/chats/:session_id/messages/:id
+func (h *ChatHandler) EditMessage(c *gin.Context) {
+	var req model.EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessionID := c.Param("session_id")
+	session, err := h.chatService.GetChatSession(ctx, sessionID)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
+		return
+	}
+
+	message, invalidated, err := h.chatService.EditMessage(ctx, sessionID, messageID, req.Content)
+	if err != nil {
+		h.writeRevisionError(c, err)
+		return
+	}
+
+	resp := model.EditMessageResponse{
+		Message:     toMessageInfo(message),
+		Invalidated: invalidated,
+	}
+	if req.Regenerate == nil || *req.Regenerate {
+		answer, sources, err := h.answer(ctx, session, message.Content)
+		if err != nil {
+			h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to generate answer for edited message")
+			writeAnswerError(c, err)
+			return
+		}
+		reply := &models.ChatMessage{
+			SessionID: sessionID,
+			Role:      models.RoleAssistant,
+			Content:   answer,
+		}
+		if err := h.chatService.SaveMessageWithSources(ctx, reply, services.MessageSources(sources)); err != nil {
+			middleware.AbortWithError(c, middleware.NewInternalError("添加助手回复失败", nil))
+			return
+		}
+		info := toMessageInfo(reply)
+		resp.Reply = &info
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// RegenerateMessage 根据前一条用户消息重新生成助手回复，原回复保存为历史版本
+// POST /api/chats/:session_id/messages/:id/regenerate
+func (h *ChatHandler) RegenerateMessage(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessionID := c.Param("session_id")
+	session, err := h.chatService.GetChatSession(ctx, sessionID)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
+		return
+	}
+
+	message, err := h.chatService.RegenerateReply(ctx, sessionID, messageID,
+		func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return h.answer(ctx, session, question)
+		})
+	if err != nil {
+		h.writeRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toMessageInfo(message)))
+}
+
+// PinMessage 收藏助手回复
+// PUT /api/chats/:session_id/messages/:id/pin
+func (h *ChatHandler) PinMessage(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// UnpinMessage 取消收藏助手回复
+// DELETE /api/chats/:session_id/messages/:id/pin
+func (h *ChatHandler) UnpinMessage(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+// setPinned 设置消息的收藏状态
+func (h *ChatHandler) setPinned(c *gin.Context, pinned bool) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.chatService.PinMessage(c.Request.Context(), c.Param("session_id"), messageID, pinned)
+	if err != nil {
+		h.writeRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toMessageInfo(message)))
+}
+
+// ListPinnedMessages 列出收藏的助手回复
+// GET /api/chats/pinned
+// 携带租户标识时只列出该租户会话中的收藏
+func (h *ChatHandler) ListPinnedMessages(c *gin.Context) {
+	var req model.PaginationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	offset := (req.GetPage() - 1) * req.GetPageSize()
+	pinned, total, err := h.chatService.ListPinnedMessages(c.Request.Context(), offset, req.GetPageSize())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list pinned messages")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取收藏列表失败", nil))
+		return
+	}
+
+	resp := model.PinnedListResponse{
+		Total:    total,
+		Page:     req.GetPage(),
+		PageSize: req.GetPageSize(),
+		Messages: make([]model.PinnedMessageInfo, 0, len(pinned)),
+	}
+	for _, item := range pinned {
+		info := model.PinnedMessageInfo{
+			SessionID: item.Session.ID,
+			Title:     item.Session.Title,
+			Message:   toMessageInfo(item.Message),
+		}
+		if item.Question != nil {
+			info.Question = item.Question.Content
+		}
+		resp.Messages = append(resp.Messages, info)
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ListMessageVersions 获取消息的历史版本
+// GET /api/chats/:session_id/messages/:id/versions
+func (h *ChatHandler) ListMessageVersions(c *gin.Context) {
+	messageID, ok := parseMessageID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.chatService.GetMessageVersions(c.Request.Context(), c.Param("session_id"), messageID)
+	if err != nil {
+		h.writeRevisionError(c, err)
+		return
+	}
+
+	infos := make([]model.MessageVersionInfo, 0, len(versions))
+	for _, v := range versions {
+		infos = append(infos, model.MessageVersionInfo{
+			Version:    v.Version,
+			Content:    v.Content,
+			Sources:    toSourceInfos(v.Sources),
+			ReplacedAt: v.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(infos))
+}
+
+// writeRevisionError 返回编辑或重新生成消息失败的响应
+func (h *ChatHandler) writeRevisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMessageRole):
+		middleware.AbortWithError(c, middleware.NewValidationError("该消息不支持此操作", err.Error()))
+	case errors.Is(err, services.ErrChatMessageNotFound):
+		middleware.AbortWithError(c, middleware.NewNotFoundError("消息不存在"))
+	default:
+		h.logger.WithError(err).Error("Failed to revise chat message")
+		writeAnswerError(c, err)
+	}
+}
+
+// parseMessageID 解析路径中的消息ID
+func parseMessageID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的消息ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// toMessageInfo 将聊天消息转换为响应结构
+func toMessageInfo(msg *models.ChatMessage) model.MessageInfo {
+	return model.MessageInfo{
+		ID:        strconv.Itoa(int(msg.ID)),
+		Role:      string(msg.Role),
+		Content:   msg.Content,
+		CreatedAt: msg.CreatedAt,
+		Sources:   toSourceInfos(msg.Sources),
+		Version:   msg.Version,
+		EditedAt:  msg.EditedAt,
+		PinnedAt:  msg.PinnedAt,
+	}
+}
+
+// toSourceInfos 解析消息中以JSON保存的引用来源
+func toSourceInfos(data []byte) []model.QASourceInfo {
+	if len(data) == 0 {
+		return nil
+	}
+	var msgSources []models.Source
+	if err := json.Unmarshal(data, &msgSources); err != nil {
+		return nil
+	}
+	sources := make([]model.QASourceInfo, 0, len(msgSources))
+	for _, src := range msgSources {
+		sources = append(sources, model.NewSourceInfo(src.FileID, src.FileName, src.Text, src.Position))
+	}
+	return sources
+}
+
+// SummarizeChat 把会话整理成摘要，之后的提问以摘要作为对话记忆
+// POST /api/chats/:session_id/summarize
+func (h *ChatHandler) SummarizeChat(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	session, err := h.chatService.SummarizeChat(c.Request.Context(), sessionID)
+	if err != nil {
+		if errors.Is(err, services.ErrChatLLMDisabled) {
+			middleware.AbortWithError(c, middleware.NewNotImplementedError("未配置会话摘要"))
+			return
+		}
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to summarize chat")
+		middleware.AbortWithError(c, middleware.NewInternalError("生成会话摘要失败", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.ChatSummaryResponse{
+		SessionID:    session.ID,
+		Summary:      session.Summary,
+		SummarizedAt: session.SummarizedAt,
+	}))
+}
+
+// ExportChat 导出会话记录
+// GET /api/chats/:session_id/export?format=md|json
+// 导出内容包含全部消息的时间和引用的信息源，以附件形式下载
+func (h *ChatHandler) ExportChat(c *gin.Context) {
+	sessionID := c.Param("session_id")
+	format, err := services.ParseChatExportFormat(c.Query("format"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("不支持的导出格式，可选md或json"))
+		return
+	}
+
+	transcript, err := h.chatService.GetChatTranscript(c.Request.Context(), sessionID)
+	if err != nil {
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to get chat transcript")
+		middleware.AbortWithError(c, middleware.NewNotFoundError("聊天会话不存在"))
+		return
+	}
+
+	fileName := fmt.Sprintf("chat-%s-%s.%s", sessionID, time.Now().Format("20060102-150405"), format.Extension())
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", "attachment; filename="+fileName)
+	c.Status(http.StatusOK)
+	if err := transcript.Write(c.Writer, format); err != nil {
+		h.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to export chat")
+	}
+}
+
 // DeleteChat 删除聊天会话
 // DELETE /api/chats/:session_id
 func (h *ChatHandler) DeleteChat(c *gin.Context) {
@@ -375,10 +840,7 @@ func (h *ChatHandler) DeleteChat(c *gin.Context) {
 	var req model.DeleteChatRequest
 	if err := c.ShouldBindUri(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid delete chat request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的会话ID",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的会话ID"))
 		return
 	}
 
@@ -386,10 +848,7 @@ func (h *ChatHandler) DeleteChat(c *gin.Context) {
 	err := h.chatService.DeleteChatSession(c.Request.Context(), req.SessionID)
 	if err != nil {
 		h.logger.WithError(err).WithField("session_id", req.SessionID).Error("Failed to delete chat session")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"删除聊天会话失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("删除聊天会话失败", nil))
 		return
 	}
 
@@ -402,7 +861,7 @@ func (h *ChatHandler) DeleteChat(c *gin.Context) {
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
 }
 
-// RenameChat 重命名聊天会话
+// RenameChat 重命名聊天会话或修改系统提示词
 // PATCH /api/chats/:session_id
 func (h *ChatHandler) RenameChat(c *gin.Context) {
 	// 1. 首先只绑定URI参数
@@ -411,39 +870,39 @@ func (h *ChatHandler) RenameChat(c *gin.Context) {
 	}
 	if err := c.ShouldBindUri(&pathParams); err != nil {
 		h.logger.WithError(err).Warn("Invalid chat session ID")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的会话ID",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的会话ID"))
 		return
 	}
 
 	// 2. 然后再绑定JSON请求体
-	var req struct {
-		Title string `json:"title" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var req model.UpdateChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Title == "" && req.SystemPrompt == nil) {
 		h.logger.WithError(err).Warn("Invalid rename request body")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数，需提供title或system_prompt"))
 		return
 	}
 
 	// 3. 重命名会话
-	if err := h.chatService.RenameChatSession(c.Request.Context(), pathParams.SessionID, req.Title); err != nil {
-		h.logger.WithError(err).
-			WithFields(logrus.Fields{
-				"session_id": pathParams.SessionID,
-				"new_title":  req.Title,
-			}).
-			Error("Failed to rename chat session")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"重命名聊天会话失败",
-		))
-		return
+	if req.Title != "" {
+		if err := h.chatService.RenameChatSession(c.Request.Context(), pathParams.SessionID, req.Title); err != nil {
+			h.logger.WithError(err).
+				WithFields(logrus.Fields{
+					"session_id": pathParams.SessionID,
+					"new_title":  req.Title,
+				}).
+				Error("Failed to rename chat session")
+			middleware.AbortWithError(c, middleware.NewInternalError("重命名聊天会话失败", nil))
+			return
+		}
+	}
+
+	// 4. 修改系统提示词
+	if req.SystemPrompt != nil {
+		if _, err := h.chatService.SetSystemPrompt(c.Request.Context(), pathParams.SessionID, *req.SystemPrompt); err != nil {
+			h.logger.WithError(err).WithField("session_id", pathParams.SessionID).Error("Failed to update chat system prompt")
+			middleware.AbortWithError(c, middleware.NewInternalError("修改系统提示词失败", nil))
+			return
+		}
 	}
 
 	// 获取更新后的会话
@@ -460,10 +919,11 @@ func (h *ChatHandler) RenameChat(c *gin.Context) {
 
 	// 构建响应
 	resp := map[string]interface{}{
-		"success":    true,
-		"session_id": session.ID,
-		"title":      session.Title,
-		"updated_at": session.UpdatedAt,
+		"success":       true,
+		"session_id":    session.ID,
+		"title":         session.Title,
+		"system_prompt": session.SystemPrompt,
+		"updated_at":    session.UpdatedAt,
 	}
 
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
@@ -476,10 +936,7 @@ func (h *ChatHandler) GetRecentQuestions(c *gin.Context) {
 	var req model.GetRecentQuestionsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid recent questions request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
@@ -487,10 +944,7 @@ func (h *ChatHandler) GetRecentQuestions(c *gin.Context) {
 	questions, err := h.qaService.GetRecentQuestions(c.Request.Context(), req.Limit)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get recent questions")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"获取最近问题失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("获取最近问题失败", nil))
 		return
 	}
 
@@ -509,21 +963,13 @@ func (h *ChatHandler) CreateChatWithMessage(c *gin.Context) {
 	var req model.CreateChatWithMessageRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid create chat with message request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
 	// 创建聊天会话
-	session, err := h.chatService.CreateChat(c.Request.Context(), req.Title)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to create chat session")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"创建聊天会话失败",
-		))
+	session, ok := h.createChat(c, req.Title, req.DocumentIDs, req.GroupIDs, req.SystemPrompt)
+	if !ok {
 		return
 	}
 
@@ -537,15 +983,12 @@ func (h *ChatHandler) CreateChatWithMessage(c *gin.Context) {
 	// 添加用户消息
 	if err := h.chatService.AddMessage(c.Request.Context(), userMessage); err != nil {
 		h.logger.WithError(err).WithField("session_id", session.ID).Error("Failed to add user message")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"添加用户消息失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("添加用户消息失败", nil))
 		return
 	}
 
 	// 使用QA服务生成回答
-	answer, sources, err := h.qaService.Answer(c.Request.Context(), req.Content)
+	answer, sources, err := h.answer(c.Request.Context(), session, req.Content)
 	if err != nil {
 		h.logger.WithError(err).WithField("session_id", session.ID).Error("Failed to generate answer")
 
@@ -599,13 +1042,19 @@ func (h *ChatHandler) CreateChatWithMessage(c *gin.Context) {
 		modelSources,
 	); err != nil {
 		h.logger.WithError(err).WithField("session_id", session.ID).Error("Failed to add assistant message")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"添加助手回复失败",
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("添加助手回复失败", nil))
 		return
 	}
 
+	// 未指定标题时根据第一轮对话生成标题，失败时保留默认标题
+	if req.Title == "" {
+		if title, err := h.chatService.GenerateTitle(c.Request.Context(), session.ID, req.Content, answer); err == nil {
+			session.Title = title
+		} else if !errors.Is(err, services.ErrChatLLMDisabled) {
+			h.logger.WithError(err).WithField("session_id", session.ID).Warn("Failed to generate chat title")
+		}
+	}
+
 	// 获取最新的助手消息
 	messages, _, err := h.chatService.GetChatMessages(c.Request.Context(), session.ID, 0, 2)
 	if err != nil || len(messages) < 2 {
@@ -622,12 +1071,7 @@ func (h *ChatHandler) CreateChatWithMessage(c *gin.Context) {
 	// 构建QA源信息
 	var responseSources []model.QASourceInfo
 	for _, src := range sources {
-		responseSources = append(responseSources, model.QASourceInfo{
-			FileID:   src.FileID,
-			FileName: src.FileName,
-			Text:     src.Text,
-			Position: src.Position,
-		})
+		responseSources = append(responseSources, model.NewSourceInfo(src.FileID, src.FileName, src.Text, src.Position))
 	}
 
 	// 获取助手消息
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestDocumentHandlerAccessControl 测试修改文档的接口只允许上传者调用，读取类接口不向无权读取的用户暴露文档
+func TestDocumentHandlerAccessControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbName := fmt.Sprintf("file:memdb_handler_acl_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Document{}, &models.DocumentShare{}, &models.DocumentSegment{}))
+
+	repo := repository.NewDocumentRepositoryWithDB(db)
+	require.NoError(t, repo.Create(&models.Document{
+		ID:         "salary",
+		FileName:   "薪酬.pdf",
+		Status:     models.DocStatusCompleted,
+		UploadedAt: time.Now(),
+		Tenant:     "alice",
+	}))
+	require.NoError(t, repo.UpdateACL("salary", false, []*models.DocumentShare{
+		{Kind: models.ShareKindUser, Name: "carol"},
+	}))
+
+	docService := services.NewDocumentService(nil, nil, nil, nil, nil,
+		services.WithDocumentRepository(repo),
+		services.WithAccessPolicy(acl.NewPolicy()))
+	h := NewDocumentHandler(docService, nil)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(usage.WithTenant(c.Request.Context(), c.GetHeader("X-Test-User")))
+		c.Next()
+	})
+	router.DELETE("/api/documents/:id", h.DeleteDocument)
+	router.PATCH("/api/documents/:id/segments/:segmentId", h.UpdateSegment)
+	router.PUT("/api/documents/:id/tags", h.UpdateTags)
+	router.PUT("/api/documents/:id/retrieval", h.SetRetrieval)
+	router.POST("/api/documents/:id/summarize", h.Summarize)
+	router.POST("/api/documents/:id/suggest-tags", h.SuggestTags)
+
+	do := func(user, method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	mutations := []struct {
+		method, path, body string
+	}{
+		{http.MethodDelete, "/api/documents/salary", ""},
+		{http.MethodPatch, "/api/documents/salary/segments/salary_0", `{"text":"新的薪酬标准"}`},
+		{http.MethodPut, "/api/documents/salary/tags", `{"tags":["机密"]}`},
+		{http.MethodPut, "/api/documents/salary/retrieval", `{"no_retrieve":true}`},
+	}
+	for _, m := range mutations {
+		// 无权读取的用户看不到文档，共享的用户可以读取但不能修改
+		assert.Equal(t, http.StatusNotFound, do("bob", m.method, m.path, m.body), m.path)
+		assert.Equal(t, http.StatusForbidden, do("carol", m.method, m.path, m.body), m.path)
+	}
+
+	// 读取类接口对无权读取的用户返回404，可以读取的用户通过检查后才进入摘要和标签建议（此处未配置，返回501）
+	for _, path := range []string{"/api/documents/salary/summarize", "/api/documents/salary/suggest-tags"} {
+		assert.Equal(t, http.StatusNotFound, do("bob", http.MethodPost, path, ""), path)
+		assert.Equal(t, http.StatusNotImplemented, do("carol", http.MethodPost, path, ""), path)
+	}
+
+	// 上传者可以修改
+	assert.Equal(t, http.StatusOK, do("alice", http.MethodPut, "/api/documents/salary/tags", `{"tags":["机密"]}`))
+	doc, err := repo.GetByID("salary")
+	require.NoError(t, err)
+	assert.Equal(t, "机密", doc.Tags)
+}
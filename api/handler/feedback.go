@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// FeedbackHandler 处理回答反馈和检索抑制审核相关的API请求
+type FeedbackHandler struct {
+	feedbackService *services.FeedbackService // 反馈服务
+	logger          *logrus.Logger            // 日志记录器
+}
+
+// NewFeedbackHandler 创建反馈处理器
+func NewFeedbackHandler(feedbackService *services.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackService: feedbackService,
+		logger:          middleware.GetLogger(),
+	}
+}
+
+// SubmitFeedback 把回答的来源段落标记为错误
+// POST /api/qa/feedback
+func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
+	var req model.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	ctx := llm.WithPromptVariant(c.Request.Context(), llm.PromptVariant{Name: req.Variant})
+	result, err := h.feedbackService.SubmitFeedback(ctx, req.Question, req.SegmentIDs, req.Comment)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to submit answer feedback")
+		middleware.AbortWithError(c, middleware.NewValidationError("提交反馈失败", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.FeedbackResponse{
+		ClusterID: result.ClusterID,
+		Recorded:  nonNilStrings(result.Recorded),
+		Activated: nonNilStrings(result.Activated),
+	}))
+}
+
+// ListSuppressions 列出检索抑制记录供审核
+// GET /api/admin/suppressions?status=active&page=1&page_size=20
+func (h *FeedbackHandler) ListSuppressions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	status := models.SuppressionStatus(c.Query("status"))
+	switch status {
+	case "", models.SuppressionPending, models.SuppressionActive, models.SuppressionDismissed:
+	default:
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的status参数", string(status)))
+		return
+	}
+
+	ctx := c.Request.Context()
+	suppressions, total, err := h.feedbackService.ListSuppressions(ctx, status, page, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list suppressions")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取检索抑制列表失败", nil))
+		return
+	}
+	questions, err := h.feedbackService.ClusterQuestions(ctx)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to load question clusters")
+	}
+
+	resp := model.SuppressionListResponse{
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+		Suppressions: make([]model.SuppressionInfo, 0, len(suppressions)),
+	}
+	for _, sp := range suppressions {
+		resp.Suppressions = append(resp.Suppressions, toSuppressionInfo(sp, questions[sp.ClusterID]))
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ReviewSuppression 审核检索抑制，启用或驳回
+// PATCH /api/admin/suppressions/:id
+func (h *FeedbackHandler) ReviewSuppression(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的记录ID"))
+		return
+	}
+
+	var req model.ReviewSuppressionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("status必须为active或dismissed"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	suppression, err := h.feedbackService.ReviewSuppression(ctx, uint(id), models.SuppressionStatus(req.Status))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("检索抑制记录不存在"))
+		return
+	}
+
+	questions, _ := h.feedbackService.ClusterQuestions(ctx)
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toSuppressionInfo(suppression, questions[suppression.ClusterID])))
+}
+
+// toSuppressionInfo 将检索抑制模型转换为响应结构
+func toSuppressionInfo(sp *models.RetrievalSuppression, question string) model.SuppressionInfo {
+	return model.SuppressionInfo{
+		ID:         sp.ID,
+		ClusterID:  sp.ClusterID,
+		Question:   question,
+		SegmentID:  sp.SegmentID,
+		FileID:     sp.FileID,
+		FileName:   sp.FileName,
+		Text:       sp.Text,
+		WrongCount: sp.WrongCount,
+		Status:     string(sp.Status),
+		ReviewedAt: sp.ReviewedAt,
+		UpdatedAt:  sp.UpdatedAt,
+	}
+}
+
+// nonNilStrings 把nil切片转换为空切片，使JSON输出为[]
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
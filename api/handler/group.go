@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GroupHandler 处理文档组相关的API请求
+type GroupHandler struct {
+	groupService *services.GroupService // 文档组服务
+	qaService    *services.QAService    // 问答服务
+	quota        *quota.Manager         // 租户配额，为空时不限制
+	logger       *logrus.Logger         // 日志记录器
+}
+
+// GroupHandlerOption 文档组处理器配置选项
+type GroupHandlerOption func(*GroupHandler)
+
+// WithGroupQuota 设置租户配额，以文档组为范围的问答同样消耗当日问答配额
+func WithGroupQuota(manager *quota.Manager) GroupHandlerOption {
+	return func(h *GroupHandler) {
+		h.quota = manager
+	}
+}
+
+// NewGroupHandler 创建新的文档组处理器
+func NewGroupHandler(groupService *services.GroupService, qaService *services.QAService, opts ...GroupHandlerOption) *GroupHandler {
+	h := &GroupHandler{
+		groupService: groupService,
+		qaService:    qaService,
+		logger:       middleware.GetLogger(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// CreateGroup 创建文档组
+// POST /api/groups
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req model.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid create group request")
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	members := make([]services.GroupMemberInput, 0, len(req.Members))
+	for _, m := range req.Members {
+		members = append(members, services.GroupMemberInput{DocumentID: m.DocumentID, Label: m.Label})
+	}
+
+	group, err := h.groupService.CreateGroup(c.Request.Context(), req.Name, req.Description, members)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to create document group")
+		middleware.AbortWithError(c, middleware.NewValidationError("创建文档组失败", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toGroupInfo(group)))
+}
+
+// ListGroups 获取文档组列表
+// GET /api/groups
+func (h *GroupHandler) ListGroups(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	groups, total, err := h.groupService.ListGroups(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list document groups")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取文档组列表失败", nil))
+		return
+	}
+
+	resp := model.GroupListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Groups:   make([]model.GroupInfo, 0, len(groups)),
+	}
+	for _, group := range groups {
+		resp.Groups = append(resp.Groups, toGroupInfo(group))
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// GetGroup 获取文档组详情
+// GET /api/groups/:id
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	group, err := h.groupService.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("文档组不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toGroupInfo(group)))
+}
+
+// UpdateGroup 更新文档组名称和描述
+// PATCH /api/groups/:id
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	var req model.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	group, err := h.groupService.UpdateGroup(c.Request.Context(), c.Param("id"), req.Name, req.Description)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("文档组不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toGroupInfo(group)))
+}
+
+// DeleteGroup 删除文档组，不删除成员文档
+// DELETE /api/groups/:id
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.groupService.DeleteGroup(c.Request.Context(), id); err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("文档组不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"id": id, "deleted": true}))
+}
+
+// AddMember 向文档组添加成员
+// POST /api/groups/:id/members
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	var req model.GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	group, err := h.groupService.AddMember(c.Request.Context(), c.Param("id"), services.GroupMemberInput{
+		DocumentID: req.DocumentID,
+		Label:      req.Label,
+	})
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to add group member")
+		middleware.AbortWithError(c, middleware.NewValidationError("添加成员失败", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toGroupInfo(group)))
+}
+
+// RemoveMember 从文档组移除成员
+// DELETE /api/groups/:id/members/:document_id
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	if err := h.groupService.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("document_id")); err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("成员不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"removed": true}))
+}
+
+// AnswerQuestion 以文档组为范围回答问题
+// POST /api/groups/:id/qa
+func (h *GroupHandler) AnswerQuestion(c *gin.Context) {
+	var req model.GroupQARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("问题不能为空"))
+		return
+	}
+
+	groupID := c.Param("id")
+	ctx := c.Request.Context()
+
+	files, err := h.groupService.ResolveScope(ctx, groupID)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("文档组不存在或没有可用的文档"))
+		return
+	}
+
+	if err := h.quota.ConsumeQA(ctx); err != nil {
+		writeAnswerError(c, err)
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"question": req.Question,
+		"group_id": groupID,
+		"files":    len(files),
+	}).Info("Question with document group")
+
+	answer, sourceDocs, err := h.qaService.AnswerWithFiles(ctx, req.Question, files)
+	if err != nil {
+		h.logger.WithError(err).WithField("group_id", groupID).Error("Failed to answer group question")
+		writeAnswerError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.QAResponse{
+		Question: req.Question,
+		Answer:   answer,
+		Sources:  model.ConvertToSourceInfo(sourceDocs),
+	}))
+}
+
+// toGroupInfo 将文档组模型转换为响应结构
+func toGroupInfo(group *models.DocumentGroup) model.GroupInfo {
+	info := model.GroupInfo{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Members:     make([]model.GroupMemberInfo, 0, len(group.Members)),
+		CreatedAt:   group.CreatedAt,
+		UpdatedAt:   group.UpdatedAt,
+	}
+	for _, m := range group.Members {
+		info.Members = append(info.Members, model.GroupMemberInfo{
+			DocumentID: m.DocumentID,
+			Label:      m.Label,
+			Position:   m.Position,
+		})
+	}
+	return info
+}
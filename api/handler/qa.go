@@ -1,7 +1,14 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
 	"net/http"
 
@@ -14,16 +21,73 @@ import (
 
 // QAHandler 处理问答相关的API请求
 type QAHandler struct {
-	qaService *services.QAService // 问答服务
-	logger    *logrus.Logger      // 日志记录器
+	qaService     *services.QAService          // 问答服务
+	reviewService *services.ReviewService      // 回答审核服务，为空时不审核
+	guard         *services.GuardService       // 问答护栏，为空时不审核内容
+	quota         *quota.Manager               // 租户配额，为空时不限制
+	translator    *services.TranslationService // 跨语言问答，为空时不翻译
+	jobs          *services.QAJobService       // 异步问答，未启用任务队列时为空
+	models        *llm.ModelRouter             // 可按请求选择的模型，为空时不支持选择模型
+	logger        *logrus.Logger               // 日志记录器
+}
+
+// QAHandlerOption 问答处理器配置选项
+type QAHandlerOption func(*QAHandler)
+
+// WithReviewService 设置回答审核服务，命中审核话题的回答先保存为草稿，审核通过后才发布
+func WithReviewService(reviewService *services.ReviewService) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.reviewService = reviewService
+	}
+}
+
+// WithGuard 设置问答护栏，拦截或脱敏不安全的问题和回答
+func WithGuard(guard *services.GuardService) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.guard = guard
+	}
+}
+
+// WithQuota 设置租户配额，每次问答消耗一次当日问答配额
+func WithQuota(manager *quota.Manager) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.quota = manager
+	}
+}
+
+// WithTranslator 设置跨语言问答，问题与文档库语言不同时翻译问题和回答
+func WithTranslator(translator *services.TranslationService) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.translator = translator
+	}
+}
+
+// WithQAJobs 设置异步问答服务，耗时较长的问题通过任务队列执行
+func WithQAJobs(jobs *services.QAJobService) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.jobs = jobs
+	}
+}
+
+// WithModels 设置可按请求选择的模型，请求中的model不在可选列表中时返回400
+func WithModels(models *llm.ModelRouter) QAHandlerOption {
+	return func(h *QAHandler) {
+		h.models = models
+	}
 }
 
 // NewQAHandler 创建新的问答处理器
-func NewQAHandler(qaService *services.QAService) *QAHandler {
-	return &QAHandler{
+func NewQAHandler(qaService *services.QAService, opts ...QAHandlerOption) *QAHandler {
+	h := &QAHandler{
 		qaService: qaService,
 		logger:    middleware.GetLogger(),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // AnswerQuestion 处理问答请求
@@ -36,28 +100,35 @@ func (h *QAHandler) AnswerQuestion(c *gin.Context) {
 			"error": err.Error(),
 		}).Warn("Invalid question request")
 
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的请求参数",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
 		return
 	}
 
 	// 检查问题是否为空
 	if req.Question == "" {
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"问题不能为空",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("问题不能为空"))
+		return
+	}
+	format := llm.AnswerFormat{Type: req.Format, Schema: req.Schema}
+	if err := format.Validate(); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的回答格式", err.Error()))
 		return
 	}
+	ctx, modelName, err := h.models.Select(c.Request.Context(), req.Model)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的模型", err.Error()))
+		return
+	}
+	ctx, variant := h.qaService.AssignPromptVariant(ctx)
 
-	var answer string
-	var sources []model.QASourceInfo
+	// 消耗当日问答配额
+	if err := h.quota.ConsumeQA(ctx); err != nil {
+		writeAnswerError(c, err)
+		return
+	}
 
 	// 根据请求类型选择不同的处理方式
-	var err error
-	ctx := c.Request.Context()
+	var ask services.QuestionAnswerFunc
 
 	if req.FileID != "" {
 		// 从特定文件回答问题
@@ -66,20 +137,19 @@ func (h *QAHandler) AnswerQuestion(c *gin.Context) {
 			"file_id":  req.FileID,
 		}).Info("Question with specific file")
 
-		var sourceDocs []vectordb.Document
-		answer, sourceDocs, err = h.qaService.AnswerWithFile(ctx, req.Question, req.FileID)
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			answer, sourceDocs, err := h.qaService.AnswerWithFile(ctx, question, req.FileID)
 
-		// 添加这行调试日志
-		fmt.Printf("DEBUG: AnswerWithFile returned - err: %v, answer: %s\n", err, answer)
+			// 添加这行调试日志
+			fmt.Printf("DEBUG: AnswerWithFile returned - err: %v, answer: %s\n", err, answer)
 
-		h.logger.WithFields(logrus.Fields{
-			"error":             err,
-			"answer_received":   answer != "",
-			"source_docs_count": len(sourceDocs),
-		}).Debug("Response from AnswerWithFile")
+			h.logger.WithFields(logrus.Fields{
+				"error":             err,
+				"answer_received":   answer != "",
+				"source_docs_count": len(sourceDocs),
+			}).Debug("Response from AnswerWithFile")
 
-		if err == nil {
-			sources = model.ConvertToSourceInfo(sourceDocs)
+			return answer, sourceDocs, err
 		}
 	} else if len(req.Metadata) > 0 {
 		// 使用元数据过滤回答问题
@@ -88,22 +158,98 @@ func (h *QAHandler) AnswerQuestion(c *gin.Context) {
 			"metadata": req.Metadata,
 		}).Info("Question with metadata filter")
 
-		var sourceDocs []vectordb.Document
-		answer, sourceDocs, err = h.qaService.AnswerWithMetadata(ctx, req.Question, req.Metadata)
-		if err == nil {
-			sources = model.ConvertToSourceInfo(sourceDocs)
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return h.qaService.AnswerWithMetadata(ctx, question, req.Metadata)
 		}
 	} else {
 		// 普通问答
 		h.logger.WithField("question", req.Question).Info("General question")
 
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			return h.qaService.Answer(ctx, question)
+		}
+	}
+
+	// 构建响应
+	resp := model.QAResponse{Question: req.Question}
+
+	// 跨语言问答：用译文检索和生成，再把回答翻译回提问者的语言
+	// 护栏在外层审核原始问题和最终回答；JSON格式的回答翻译后无法保证满足Schema，不做跨语言问答
+	if h.translator.Enabled(req.Translate) && format.Type != llm.FormatJSON {
+		answerOriginal := ask
+		ask = func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			answer, sourceDocs, translation, err := h.translator.Answer(ctx, question, req.AnswerLanguage, answerOriginal)
+			resp.Translation = &model.TranslationInfo{
+				QuestionLanguage:   translation.QuestionLanguage,
+				TranslatedQuestion: translation.TranslatedQuestion,
+				AnswerLanguage:     translation.AnswerLanguage,
+				AnswerTranslated:   translation.AnswerTranslated,
+			}
+			return answer, sourceDocs, err
+		}
+	}
+
+	generate := func(ctx context.Context) (string, []vectordb.Document, error) {
+		if h.guard != nil {
+			return h.guard.Answer(ctx, req.Question, ask)
+		}
+		return ask(ctx, req.Question)
+	}
+
+	ctx, info := services.WithAnswerInfo(ctx)
+	ctx = llm.WithAnswerFormat(ctx, format)
+	ctx = llm.WithGenerationParams(ctx, llm.GenerationParams{MaxTokens: req.MaxTokens, Temperature: req.Temperature})
+	ctx = services.WithRetrievalParams(ctx, services.RetrievalParams{
+		SearchLimit:    req.SearchLimit,
+		MinScore:       req.MinScore,
+		Strategy:       req.Strategy,
+		MMRLambda:      req.MMRLambda,
+		MMRCandidates:  req.MMRCandidates,
+		ExcludeFileIDs: req.ExcludeFileIDs,
+		ExcludeTags:    req.ExcludeTags,
+	})
+
+	if h.reviewService != nil {
+		// 经过审核流程：已审核的FAQ直接返回，命中审核话题的回答保存为草稿
+		// 限定文件或元数据的问题与不限定范围的相同问题分别审核
+		scope := services.ReviewScope{FileID: req.FileID}
+		if req.FileID == "" {
+			scope.Metadata = req.Metadata
+		}
+		var outcome *services.ReviewOutcome
+		outcome, err = h.reviewService.Gate(ctx, req.Question, scope, generate)
+		if err == nil {
+			if outcome.Draft != nil {
+				resp.Sources = []model.QASourceInfo{}
+				resp.Review = &model.ReviewStatus{
+					DraftID: outcome.Draft.ID,
+					Status:  string(outcome.Draft.Status),
+					Topic:   outcome.Draft.Topic,
+				}
+				c.JSON(http.StatusAccepted, model.NewSuccessResponse(resp))
+				return
+			}
+			resp.Answer = outcome.Answer
+			resp.Sources = model.ConvertToSourceInfo(outcome.Sources)
+			resp.Curated = outcome.Curated
+		}
+	} else {
 		var sourceDocs []vectordb.Document
-		answer, sourceDocs, err = h.qaService.Answer(ctx, req.Question)
+		resp.Answer, sourceDocs, err = generate(ctx)
 		if err == nil {
-			sources = model.ConvertToSourceInfo(sourceDocs)
+			resp.Sources = model.ConvertToSourceInfo(sourceDocs)
 		}
 	}
 
+	// 被护栏拦截时返回结构化的拒绝回答
+	if blocked, ok := moderation.AsBlocked(err); ok {
+		resp.Answer = h.guard.Refusal()
+		resp.Sources = []model.QASourceInfo{}
+		resp.Refusal = &model.Refusal{Stage: string(blocked.Stage), Category: blocked.Category}
+		c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+		return
+	}
+
 	// 处理错误
 	if err != nil {
 		h.logger.WithFields(logrus.Fields{
@@ -115,23 +261,138 @@ func (h *QAHandler) AnswerQuestion(c *gin.Context) {
 		// 添加这行调试日志
 		fmt.Printf("DEBUG: Error handling triggered with error: %v\n", err)
 
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"处理问题时出错: "+err.Error(),
-		))
+		writeAnswerError(c, err)
 		return
 	}
 
-	// 构建响应
-	resp := model.QAResponse{
-		Question: req.Question,
-		Answer:   answer,
-		Sources:  sources,
+	resp.Confidence = info.Confidence
+	resp.LowConfidence = info.LowConfidence
+	if info.CacheAge != nil {
+		age := int64(info.CacheAge.Seconds())
+		resp.CacheAge = &age
+	}
+	resp.Refreshing = info.Refreshing
+	resp.Model = modelName
+	resp.PromptVariant = variant
+	if format.Type == llm.FormatJSON && json.Valid([]byte(resp.Answer)) {
+		resp.Data = json.RawMessage(resp.Answer)
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// writeAnswerError 返回问答失败的响应，生成预算用尽、并发问答超过上限或超出配额时返回429
+// CompareDocuments 针对问题对比多个文档
+// POST /api/qa/compare
+// 每个文档分别检索，返回逐方面的差异和各文档的来源
+func (h *QAHandler) CompareDocuments(c *gin.Context) {
+	var req model.CompareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("对比请求需要问题和2到5个不重复的文件ID", err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.quota.ConsumeQA(ctx); err != nil {
+		writeAnswerError(c, err)
+		return
+	}
+
+	files := make([]services.ScopedFile, len(req.FileIDs))
+	for i, id := range req.FileIDs {
+		files[i] = services.ScopedFile{FileID: id}
+	}
+
+	var comparison *services.DocumentComparison
+	compare := func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+		var err error
+		comparison, err = h.qaService.CompareDocuments(ctx, question, files)
+		if err != nil {
+			return "", nil, err
+		}
+		return comparison.Summary, nil, nil
+	}
+
+	// 护栏审核问题和对比结论
+	var summary string
+	var err error
+	if h.guard != nil {
+		summary, _, err = h.guard.Answer(ctx, req.Question, compare)
+	} else {
+		summary, _, err = compare(ctx, req.Question)
 	}
 
+	resp := model.CompareResponse{
+		Question:     req.Question,
+		Differences:  []model.CompareDifference{},
+		Similarities: []string{},
+		Documents:    []model.ComparedDocument{},
+	}
+	if blocked, ok := moderation.AsBlocked(err); ok {
+		resp.Summary = h.guard.Refusal()
+		resp.Refusal = &model.Refusal{Stage: string(blocked.Stage), Category: blocked.Category}
+		c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+		return
+	}
+	if err != nil {
+		h.logger.WithError(err).WithField("file_ids", req.FileIDs).Error("Failed to compare documents")
+		writeAnswerError(c, err)
+		return
+	}
+
+	resp.Summary = summary
+	resp.Similarities = comparison.Similarities
+	for _, d := range comparison.Differences {
+		diff := model.CompareDifference{Aspect: d.Aspect, Values: []model.CompareValue{}}
+		for _, doc := range comparison.Documents {
+			if value, ok := d.Values[doc.FileID]; ok {
+				diff.Values = append(diff.Values, model.CompareValue{FileID: doc.FileID, Value: value})
+			}
+		}
+		resp.Differences = append(resp.Differences, diff)
+	}
+	for _, doc := range comparison.Documents {
+		resp.Documents = append(resp.Documents, model.ComparedDocument{
+			FileID:   doc.FileID,
+			FileName: doc.FileName,
+			Sources:  model.ConvertToSourceInfo(doc.Sources),
+		})
+	}
 	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
 }
 
+func writeAnswerError(c *gin.Context, err error) {
+	if isAnswerLimitError(err) {
+		middleware.AbortWithError(c, err)
+		return
+	}
+	if errors.Is(err, llm.ErrInvalidStructuredOutput) {
+		middleware.AbortWithError(c, middleware.NewBadGatewayError("模型多次输出的内容都不满足指定的JSON Schema", err))
+		return
+	}
+
+	middleware.AbortWithError(c, middleware.NewInternalError("处理问题时出错", err))
+}
+
+// isAnswerLimitError 判断问答是否因生成预算、并发上限或配额而失败，这类错误可以原样告知调用方
+func isAnswerLimitError(err error) bool {
+	return llm.IsBudgetExceeded(err) || errors.Is(err, services.ErrTooManyConcurrentQuestions) || errors.Is(err, models.ErrQuotaExceeded)
+}
+
 func (h *QAHandler) GetQAService() *services.QAService {
 	return h.qaService
 }
+
+// GetModels 获取可按请求选择的模型
+func (h *QAHandler) GetModels() *llm.ModelRouter {
+	return h.models
+}
+
+// GetGuard 返回问答护栏，未配置时为nil
+func (h *QAHandler) GetGuard() *services.GuardService {
+	return h.guard
+}
+
+// GetQuota 返回租户配额，未启用时为nil
+func (h *QAHandler) GetQuota() *quota.Manager {
+	return h.quota
+}
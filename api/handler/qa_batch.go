@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AnswerBatch 批量回答问题，用于生成报告等需要一次回答多个问题的场景
+// POST /api/qa/batch
+// 问题并发回答，每个问题消耗一次问答配额并经过护栏审核；单个问题失败时在结果中返回错误，不影响其他问题
+func (h *QAHandler) AnswerBatch(c *gin.Context) {
+	var req model.BatchQARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("批量问答需要至少一个问题", err.Error()))
+		return
+	}
+	if limit := h.qaService.BatchMaxQuestions(); len(req.Questions) > limit {
+		middleware.AbortWithError(c, middleware.NewValidationError(fmt.Sprintf("单次最多提交%d个问题", limit)))
+		return
+	}
+
+	questions := make([]services.BatchQuestion, len(req.Questions))
+	for i, q := range req.Questions {
+		questions[i] = services.BatchQuestion{Question: q.Question, FileIDs: q.FileIDs}
+		if len(q.FileIDs) == 0 {
+			questions[i].FileIDs = req.FileIDs
+		}
+	}
+
+	answer := func(ctx context.Context, question string, ask services.QuestionAnswerFunc) (string, []vectordb.Document, error) {
+		if err := h.quota.ConsumeQA(ctx); err != nil {
+			return "", nil, err
+		}
+		if h.guard != nil {
+			return h.guard.Answer(ctx, question, ask)
+		}
+		return ask(ctx, question)
+	}
+
+	results, err := h.qaService.AnswerBatch(c.Request.Context(), questions, answer)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewInternalError("批量问答失败", err))
+		return
+	}
+
+	resp := model.BatchQAResponse{Results: make([]model.BatchQAResult, len(results))}
+	for i, result := range results {
+		item := model.BatchQAResult{
+			Question: questions[i].Question,
+			FileIDs:  questions[i].FileIDs,
+			Answer:   result.Answer,
+			Sources:  model.ConvertToSourceInfo(result.Sources),
+		}
+		if blocked, ok := moderation.AsBlocked(result.Err); ok {
+			// 被护栏拦截的问题返回拒绝回答，视为已回答
+			item.Answer = h.guard.Refusal()
+			item.Refusal = &model.Refusal{Stage: string(blocked.Stage), Category: blocked.Category}
+			resp.Succeeded++
+		} else if result.Err != nil {
+			h.logger.WithError(result.Err).WithFields(logrus.Fields{
+				"question": item.Question,
+				"file_ids": item.FileIDs,
+			}).Warn("Failed to answer question in batch")
+			item.Error = batchAnswerError(result.Err)
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// batchAnswerError 返回批量问答中单个问题失败的原因
+// 配额、并发和生成预算的错误原样返回，其他错误不暴露内部细节
+func batchAnswerError(err error) string {
+	if isAnswerLimitError(err) {
+		return err.Error()
+	}
+	return "处理问题时出错"
+}
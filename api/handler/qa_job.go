@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxQAJobWait 查询异步问答时最长的等待时间，需小于常见的负载均衡超时
+const maxQAJobWait = 30 * time.Second
+
+// SubmitQuestion 提交异步问答，立即返回任务ID
+// POST /api/qa/async
+// 请求体与 POST /api/qa 相同，跨语言问答和回答审核不适用于异步问答
+func (h *QAHandler) SubmitQuestion(c *gin.Context) {
+	if h.jobs == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用任务队列，不支持异步问答"))
+		return
+	}
+
+	var req model.QARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数", err.Error()))
+		return
+	}
+
+	ctx, _, err := h.models.Select(c.Request.Context(), req.Model)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的模型", err.Error()))
+		return
+	}
+	if err := h.quota.ConsumeQA(ctx); err != nil {
+		writeAnswerError(c, err)
+		return
+	}
+
+	id, err := h.jobs.Submit(ctx, services.QAJobRequest{
+		Question: req.Question,
+		FileID:   req.FileID,
+		Metadata: req.Metadata,
+		Params: services.RetrievalParams{
+			SearchLimit:    req.SearchLimit,
+			MinScore:       req.MinScore,
+			Strategy:       req.Strategy,
+			MMRLambda:      req.MMRLambda,
+			MMRCandidates:  req.MMRCandidates,
+			ExcludeFileIDs: req.ExcludeFileIDs,
+			ExcludeTags:    req.ExcludeTags,
+		},
+		Generation: llm.GenerationParams{MaxTokens: req.MaxTokens, Temperature: req.Temperature},
+		Model:      llm.ModelChoiceFromContext(ctx),
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("question", req.Question).Error("Failed to submit async question")
+		middleware.AbortWithError(c, middleware.NewInternalError("提交异步问答失败", err))
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{"job_id": id, "question": req.Question}).Info("Async question submitted")
+	c.JSON(http.StatusAccepted, model.NewSuccessResponse(model.QAJobSubmitResponse{JobID: id, Status: "pending"}))
+}
+
+// GetQuestionJob 查询异步问答的状态和结果
+// GET /api/qa/jobs/:id?wait=10s
+// wait不为空时等待任务结束后再返回（长轮询），最多等待30秒
+func (h *QAHandler) GetQuestionJob(c *gin.Context) {
+	if h.jobs == nil {
+		middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用任务队列，不支持异步问答"))
+		return
+	}
+
+	var wait time.Duration
+	if v := c.Query("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的wait参数", v))
+			return
+		}
+		wait = min(d, maxQAJobWait)
+	}
+
+	id := c.Param("id")
+	job, err := h.jobs.Get(c.Request.Context(), id, wait)
+	if errors.Is(err, services.ErrQAJobNotFound) {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("问答任务不存在", id))
+		return
+	}
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewInternalError("查询问答任务失败", err))
+		return
+	}
+
+	resp := model.QAJobResponse{
+		JobID:       job.ID,
+		Status:      string(job.Status),
+		Question:    job.Question,
+		Sources:     []model.QASourceInfo{},
+		Error:       job.Error,
+		Attempts:    job.Attempts,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+	}
+	if job.Done() && job.Result != nil {
+		resp.Answer = job.Result.Answer
+		resp.Sources = model.ConvertToSourceInfo(job.Result.Sources)
+		resp.Confidence = job.Result.Confidence
+		resp.LowConfidence = job.Result.LowConfidence
+		resp.Model = job.Result.Model
+		resp.PromptVariant = job.Result.PromptVariant
+		if resp.Model == "" && h.models != nil {
+			resp.Model = h.models.Default()
+		}
+		if job.Result.RefusalStage != "" {
+			resp.Refusal = &model.Refusal{Stage: job.Result.RefusalStage, Category: job.Result.RefusalReason}
+		}
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
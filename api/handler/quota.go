@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// QuotaHandler 处理租户配额相关的API请求
+type QuotaHandler struct {
+	manager *quota.Manager // 配额管理器
+	logger  *logrus.Logger // 日志记录器
+}
+
+// NewQuotaHandler 创建配额处理器
+func NewQuotaHandler(manager *quota.Manager) *QuotaHandler {
+	return &QuotaHandler{
+		manager: manager,
+		logger:  middleware.GetLogger(),
+	}
+}
+
+// GetQuota 查询当前租户的配额和剩余量
+// GET /api/quota
+func (h *QuotaHandler) GetQuota(c *gin.Context) {
+	tenant := usage.TenantFromContext(c.Request.Context())
+	status, err := h.manager.Status(c.Request.Context(), tenant)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).WithError(err).Error("Failed to query quota")
+		middleware.AbortWithError(c, middleware.NewInternalError("查询配额失败", nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.QuotaResponse{
+		Tenant:       status.Tenant,
+		Documents:    toQuotaAllowance(status.Documents),
+		StorageBytes: toQuotaAllowance(status.StorageBytes),
+		QACalls:      toQuotaAllowance(status.QACalls),
+		QAResetAt:    status.QAResetAt,
+	}))
+}
+
+// toQuotaAllowance 转换单个计量项的配额情况
+func toQuotaAllowance(a quota.Allowance) model.QuotaAllowance {
+	return model.QuotaAllowance{
+		Limit:     a.Limit,
+		Used:      a.Used,
+		Remaining: a.Remaining,
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/health"
+	"github.com/fyerfyer/doc-QA-system/internal/warmup"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessHandler 处理就绪探针请求
+type ReadinessHandler struct {
+	warmer  *warmup.Warmer  // 启动预热器，为空表示未启用预热
+	checker *health.Checker // 依赖检查器，为空表示不检查依赖
+}
+
+// ReadinessOption 就绪探针处理器配置选项
+type ReadinessOption func(*ReadinessHandler)
+
+// WithHealthChecker 设置依赖检查器，用于/healthz和/readyz
+func WithHealthChecker(checker *health.Checker) ReadinessOption {
+	return func(h *ReadinessHandler) {
+		h.checker = checker
+	}
+}
+
+// NewReadinessHandler 创建就绪探针处理器
+func NewReadinessHandler(warmer *warmup.Warmer, opts ...ReadinessOption) *ReadinessHandler {
+	h := &ReadinessHandler{warmer: warmer}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Ready 就绪探针，启动预热完成前返回503
+// GET /api/ready
+func (h *ReadinessHandler) Ready(c *gin.Context) {
+	if h.warmer == nil {
+		c.JSON(http.StatusOK, model.NewSuccessResponse(model.ReadinessResponse{Ready: true}))
+		return
+	}
+
+	status := h.warmer.Status()
+	resp := model.ReadinessResponse{
+		Ready: status.Ready,
+		Tasks: h.warmupTasks(),
+	}
+
+	if !status.Ready {
+		c.JSON(http.StatusServiceUnavailable, &model.Response{
+			Code:    http.StatusServiceUnavailable,
+			Message: "服务预热中",
+			Data:    resp,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// Healthz 存活探针，检查各依赖并返回状态，依赖不可用时仍返回200，
+// 避免依赖故障时Kubernetes反复重启本身正常的进程
+// GET /healthz
+func (h *ReadinessHandler) Healthz(c *gin.Context) {
+	resp := h.check(c)
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// Readyz 就绪探针，预热未完成或关键依赖不可用时返回503
+// GET /readyz
+func (h *ReadinessHandler) Readyz(c *gin.Context) {
+	resp := h.check(c)
+	if h.warmer != nil && !h.warmer.Status().Ready {
+		resp.Ready = false
+	}
+
+	if !resp.Ready {
+		c.JSON(http.StatusServiceUnavailable, &model.Response{
+			Code:    http.StatusServiceUnavailable,
+			Message: "服务未就绪",
+			Data:    resp,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// check 检查各依赖，生成健康检查响应
+func (h *ReadinessHandler) check(c *gin.Context) model.HealthResponse {
+	resp := model.HealthResponse{Status: "ok", Ready: true, Tasks: h.warmupTasks()}
+	if h.checker == nil {
+		return resp
+	}
+
+	report := h.checker.Check(c.Request.Context())
+	if !report.Healthy {
+		resp.Status = "degraded"
+		resp.Ready = false
+	}
+	resp.Dependencies = make([]model.DependencyStatus, 0, len(report.Results))
+	for _, r := range report.Results {
+		resp.Dependencies = append(resp.Dependencies, model.DependencyStatus{
+			Name:      r.Name,
+			Status:    r.Status,
+			Critical:  r.Critical,
+			LatencyMS: r.Latency.Milliseconds(),
+			Error:     r.Error,
+		})
+	}
+	return resp
+}
+
+// warmupTasks 获取启动预热任务的执行结果
+func (h *ReadinessHandler) warmupTasks() []model.WarmupTaskInfo {
+	if h.warmer == nil {
+		return nil
+	}
+	status := h.warmer.Status()
+	tasks := make([]model.WarmupTaskInfo, 0, len(status.Tasks))
+	for _, t := range status.Tasks {
+		tasks = append(tasks, model.WarmupTaskInfo{
+			Name:       t.Name,
+			Done:       t.Done,
+			DurationMS: t.Duration.Milliseconds(),
+			Error:      t.Error,
+		})
+	}
+	return tasks
+}
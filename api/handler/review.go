@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ReviewHandler 处理回答审核队列和FAQ相关的API请求
+type ReviewHandler struct {
+	reviewService *services.ReviewService // 回答审核服务
+	logger        *logrus.Logger          // 日志记录器
+}
+
+// NewReviewHandler 创建回答审核处理器
+func NewReviewHandler(reviewService *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		logger:        middleware.GetLogger(),
+	}
+}
+
+// GetDraftResult 提问者查询草稿的审核结果，审核通过后返回回答
+// GET /api/qa/drafts/:id
+func (h *ReviewHandler) GetDraftResult(c *gin.Context) {
+	draft, err := h.reviewService.GetDraft(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("回答草稿不存在"))
+		return
+	}
+
+	resp := model.DraftResultResponse{
+		DraftID:  draft.ID,
+		Question: draft.Question,
+		Status:   string(draft.Status),
+		Sources:  []model.QASourceInfo{},
+	}
+	if draft.Status == models.DraftApproved {
+		resp.Answer = draft.FinalAnswer
+		resp.Sources = model.ConvertToSourceInfo(h.reviewService.Sources(c.Request.Context(), draft.SegmentIDs))
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ListDrafts 列出回答草稿供审核
+// GET /api/admin/drafts?status=pending&page=1&page_size=20
+func (h *ReviewHandler) ListDrafts(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	status := models.DraftStatus(c.Query("status"))
+	switch status {
+	case "", models.DraftPending, models.DraftApproved, models.DraftRejected:
+	default:
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的status参数", string(status)))
+		return
+	}
+
+	drafts, total, err := h.reviewService.ListDrafts(c.Request.Context(), status, page, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list answer drafts")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取回答草稿列表失败", nil))
+		return
+	}
+
+	resp := model.DraftListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Drafts:   make([]model.DraftInfo, 0, len(drafts)),
+	}
+	for _, d := range drafts {
+		resp.Drafts = append(resp.Drafts, toDraftInfo(d))
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// ApproveDraft 审核通过草稿，发布回答并收录为FAQ
+// POST /api/admin/drafts/:id/approve
+func (h *ReviewHandler) ApproveDraft(c *gin.Context) {
+	var req model.ApproveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	draft, err := h.reviewService.Approve(c.Request.Context(), c.Param("id"), req.Answer, req.Reviewer)
+	if err != nil {
+		h.writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toDraftInfo(draft)))
+}
+
+// RejectDraft 驳回草稿
+// POST /api/admin/drafts/:id/reject
+func (h *ReviewHandler) RejectDraft(c *gin.Context) {
+	var req model.RejectDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	draft, err := h.reviewService.Reject(c.Request.Context(), c.Param("id"), req.Reviewer, req.Note)
+	if err != nil {
+		h.writeReviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toDraftInfo(draft)))
+}
+
+// ListFAQ 列出人工审核过的FAQ条目
+// GET /api/faq?page=1&page_size=20
+func (h *ReviewHandler) ListFAQ(c *gin.Context) {
+	page, pageSize := pageParams(c)
+
+	entries, total, err := h.reviewService.ListCurated(c.Request.Context(), page, pageSize)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list curated answers")
+		middleware.AbortWithError(c, middleware.NewInternalError("获取FAQ列表失败", nil))
+		return
+	}
+
+	resp := model.FAQListResponse{
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		Entries:  make([]model.FAQInfo, 0, len(entries)),
+	}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, model.FAQInfo{
+			ID:         e.ID,
+			Question:   e.Question,
+			Topic:      e.Topic,
+			Answer:     e.Answer,
+			SegmentIDs: nonNilStrings(e.SegmentIDs),
+			DraftID:    e.DraftID,
+			UpdatedAt:  e.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// DeleteFAQ 删除FAQ条目，之后同一问题重新进入审核流程
+// DELETE /api/admin/faq/:id
+func (h *ReviewHandler) DeleteFAQ(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的记录ID"))
+		return
+	}
+
+	if err := h.reviewService.DeleteCurated(c.Request.Context(), uint(id)); err != nil {
+		middleware.AbortWithError(c, middleware.NewNotFoundError("FAQ条目不存在"))
+		return
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"id": id, "deleted": true}))
+}
+
+// writeReviewError 返回审核失败的响应，重复审核返回409，回答为空返回400，其余视为草稿不存在
+func (h *ReviewHandler) writeReviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrDraftReviewed):
+		middleware.AbortWithError(c, err)
+		return
+	case errors.Is(err, services.ErrEmptyApprovedAnswer):
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	h.logger.WithError(err).Warn("Failed to review answer draft")
+	middleware.AbortWithError(c, middleware.NewNotFoundError("回答草稿不存在"))
+}
+
+// toDraftInfo 将回答草稿模型转换为响应结构
+func toDraftInfo(d *models.AnswerDraft) model.DraftInfo {
+	return model.DraftInfo{
+		ID:          d.ID,
+		Question:    d.Question,
+		Topic:       d.Topic,
+		Answer:      d.Answer,
+		FinalAnswer: d.FinalAnswer,
+		SegmentIDs:  nonNilStrings(d.SegmentIDs),
+		Status:      string(d.Status),
+		Reviewer:    d.Reviewer,
+		Note:        d.Note,
+		ReviewedAt:  d.ReviewedAt,
+		CreatedAt:   d.CreatedAt,
+	}
+}
+
+// pageParams 读取分页参数，非法值使用默认值
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
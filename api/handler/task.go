@@ -40,20 +40,14 @@ func (h *TaskHandler) HandleCallback(c *gin.Context) {
 	var req taskqueue.CallbackRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid callback request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"无效的回调请求",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的回调请求"))
 		return
 	}
 
 	// 添加必要字段验证
 	if req.TaskID == "" {
 		h.logger.Warn("Empty task_id in callback request")
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"任务ID不能为空",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("任务ID不能为空"))
 		return
 	}
 
@@ -76,10 +70,7 @@ func (h *TaskHandler) HandleCallback(c *gin.Context) {
 	resp, err := h.processor.HandleCallback(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to process callback")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"处理回调失败: "+err.Error(),
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("处理回调失败", err))
 		return
 	}
 
@@ -91,10 +82,7 @@ func (h *TaskHandler) HandleCallback(c *gin.Context) {
 func (h *TaskHandler) GetTaskStatus(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"任务ID不能为空",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("任务ID不能为空"))
 		return
 	}
 
@@ -102,26 +90,17 @@ func (h *TaskHandler) GetTaskStatus(c *gin.Context) {
 	if err != nil {
 		// 检查是否是任务不存在错误
 		if errors.Is(err, taskqueue.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, model.NewErrorResponse(
-				http.StatusNotFound,
-				"任务未找到",
-			))
+			middleware.AbortWithError(c, err)
 			return
 		}
 
 		h.logger.WithError(err).WithField("task_id", taskID).Error("Failed to get task")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"获取任务状态失败: "+err.Error(),
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("获取任务状态失败", err))
 		return
 	}
 
 	if task == nil {
-		c.JSON(http.StatusNotFound, model.NewErrorResponse(
-			http.StatusNotFound,
-			"任务未找到",
-		))
+		middleware.AbortWithError(c, taskqueue.ErrTaskNotFound)
 		return
 	}
 
@@ -156,20 +135,14 @@ func (h *TaskHandler) GetTaskStatus(c *gin.Context) {
 func (h *TaskHandler) GetDocumentTasks(c *gin.Context) {
 	documentID := c.Param("document_id")
 	if documentID == "" {
-		c.JSON(http.StatusBadRequest, model.NewErrorResponse(
-			http.StatusBadRequest,
-			"文档ID不能为空",
-		))
+		middleware.AbortWithError(c, middleware.NewValidationError("文档ID不能为空"))
 		return
 	}
 
 	tasks, err := h.queue.GetTasksByDocument(c.Request.Context(), documentID)
 	if err != nil {
 		h.logger.WithError(err).WithField("document_id", documentID).Error("Failed to get document tasks")
-		c.JSON(http.StatusInternalServerError, model.NewErrorResponse(
-			http.StatusInternalServerError,
-			"获取文档任务列表失败: "+err.Error(),
-		))
+		middleware.AbortWithError(c, middleware.NewInternalError("获取文档任务列表失败", err))
 		return
 	}
 
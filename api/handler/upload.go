@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// multipartOverhead 直接上传时multipart表单中除文件外的其他内容允许的字节数
+const multipartOverhead = 1 << 20
+
+// WithUploadService 设置分片上传服务，用于通过多个请求上传大文件并支持断点续传
+func WithUploadService(uploads *services.UploadService) DocumentHandlerOption {
+	return func(h *DocumentHandler) {
+		h.uploads = uploads
+	}
+}
+
+// InitUpload 创建分片上传
+// POST /api/documents/uploads
+func (h *DocumentHandler) InitUpload(c *gin.Context) {
+	if !h.requireUploads(c) {
+		return
+	}
+	var req model.InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数", err.Error()))
+		return
+	}
+	if err := h.validator.CheckName(req.FileName); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	upload, err := h.uploads.InitUpload(c.Request.Context(), req.FileName, req.Size, req.Tags)
+	if err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toUploadInfo(upload, []int{})))
+}
+
+// UploadPart 上传一个分片，请求体为分片的原始字节
+// PUT /api/documents/uploads/:upload_id/parts/:part
+func (h *DocumentHandler) UploadPart(c *gin.Context) {
+	if !h.requireUploads(c) {
+		return
+	}
+	part, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的分片编号"))
+		return
+	}
+
+	uploadID := c.Param("upload_id")
+	size, err := h.uploads.UploadPart(c.Request.Context(), uploadID, part, c.Request.Body)
+	if err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(model.UploadPartResponse{
+		UploadID: uploadID,
+		Part:     part,
+		Size:     size,
+	}))
+}
+
+// GetUpload 查询分片上传进度，断点续传时用于确定还需上传哪些分片
+// GET /api/documents/uploads/:upload_id
+func (h *DocumentHandler) GetUpload(c *gin.Context) {
+	if !h.requireUploads(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	uploadID := c.Param("upload_id")
+	upload, err := h.uploads.GetUpload(ctx, uploadID)
+	if err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+	received, err := h.uploads.ReceivedParts(ctx, uploadID)
+	if err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(toUploadInfo(upload, received)))
+}
+
+// CompleteUpload 合并全部分片并开始处理文档
+// POST /api/documents/uploads/:upload_id/complete
+func (h *DocumentHandler) CompleteUpload(c *gin.Context) {
+	if !h.requireUploads(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	uploadID := c.Param("upload_id")
+	upload, err := h.uploads.GetUpload(ctx, uploadID)
+	if err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+
+	// 占用文档数量和存储配额，合并失败时归还
+	if !h.reserveQuota(c, upload.Size) {
+		return
+	}
+	fileInfo, _, err := h.uploads.CompleteUpload(ctx, uploadID)
+	if err != nil {
+		h.releaseQuota(ctx, upload.Size)
+		h.writeUploadError(c, err)
+		return
+	}
+
+	h.startProcessing(c, fileInfo, upload.FileName, upload.Tags)
+}
+
+// AbortUpload 取消分片上传并删除已收到的分片
+// DELETE /api/documents/uploads/:upload_id
+func (h *DocumentHandler) AbortUpload(c *gin.Context) {
+	if !h.requireUploads(c) {
+		return
+	}
+	uploadID := c.Param("upload_id")
+	if err := h.uploads.AbortUpload(c.Request.Context(), uploadID); err != nil {
+		h.writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.NewSuccessResponse(gin.H{"upload_id": uploadID, "aborted": true}))
+}
+
+// requireUploads 检查是否配置了分片上传，未配置时返回501
+func (h *DocumentHandler) requireUploads(c *gin.Context) bool {
+	if h.uploads != nil {
+		return true
+	}
+	middleware.AbortWithError(c, middleware.NewNotImplementedError("未启用分片上传"))
+	return false
+}
+
+// writeUploadError 返回分片上传的错误，领域错误由错误中间件映射状态码
+func (h *DocumentHandler) writeUploadError(c *gin.Context, err error) {
+	// 分片上传、配额和文件校验等已知错误按领域错误返回，其余视为内部错误
+	if middleware.NewDomainError(err) != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+	h.logger.WithError(err).WithFields(logrus.Fields{
+		"upload_id": c.Param("upload_id"),
+	}).Error("Chunked upload failed")
+	middleware.AbortWithError(c, middleware.NewInternalError("分片上传失败", err))
+}
+
+// toUploadInfo 将上传会话转换为响应结构
+func toUploadInfo(upload *services.UploadSession, received []int) model.UploadInfo {
+	return model.UploadInfo{
+		UploadID:      upload.ID,
+		FileName:      upload.FileName,
+		Size:          upload.Size,
+		PartSize:      upload.PartSize,
+		TotalParts:    upload.TotalParts,
+		ReceivedParts: received,
+		ExpiresAt:     upload.ExpiresAt,
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// usageDateLayout 用量查询的日期格式
+const usageDateLayout = "2006-01-02"
+
+// UsageHandler 处理用量统计相关的API请求
+type UsageHandler struct {
+	repo   repository.UsageRepository // 用量仓储
+	logger *logrus.Logger             // 日志记录器
+}
+
+// NewUsageHandler 创建用量处理器
+func NewUsageHandler(repo repository.UsageRepository) *UsageHandler {
+	return &UsageHandler{
+		repo:   repo,
+		logger: middleware.GetLogger(),
+	}
+}
+
+// GetUsage 查询当前租户的用量统计，租户由请求携带的API密钥确定
+// GET /api/usage?from=2024-01-01&to=2024-01-31&provider=&kind=
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	var req model.UsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的请求参数"))
+		return
+	}
+
+	now := time.Now()
+	if req.To == "" {
+		req.To = now.Format(usageDateLayout)
+	}
+	if req.From == "" {
+		req.From = now.AddDate(0, 0, -30).Format(usageDateLayout)
+	}
+	for _, d := range []string{req.From, req.To} {
+		if _, err := time.Parse(usageDateLayout, d); err != nil {
+			middleware.AbortWithError(c, middleware.NewValidationError("无效的日期", d))
+			return
+		}
+	}
+	if req.Kind != "" && req.Kind != string(models.UsageKindLLM) && req.Kind != string(models.UsageKindEmbedding) {
+		middleware.AbortWithError(c, middleware.NewValidationError("无效的用量类型", req.Kind))
+		return
+	}
+
+	records, err := h.repo.WithContext(c.Request.Context()).List(repository.UsageFilter{
+		From:     req.From,
+		To:       req.To,
+		Tenant:   usage.TenantFromContext(c.Request.Context()),
+		Provider: req.Provider,
+		Kind:     models.UsageKind(req.Kind),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to query usage")
+		middleware.AbortWithError(c, middleware.NewInternalError("查询用量失败", nil))
+		return
+	}
+
+	resp := model.UsageResponse{
+		From:       req.From,
+		To:         req.To,
+		ByTenant:   make(map[string]model.UsageTotals),
+		ByProvider: make(map[string]model.UsageTotals),
+		Records:    make([]model.UsageItem, 0, len(records)),
+	}
+	for _, r := range records {
+		resp.Records = append(resp.Records, model.UsageItem{
+			Date:     r.Date,
+			Tenant:   r.Tenant,
+			Provider: r.Provider,
+			Model:    r.Model,
+			Kind:     string(r.Kind),
+			Requests: r.Requests,
+			Errors:   r.Errors,
+			Tokens:   r.Tokens,
+		})
+		resp.Totals = addUsage(resp.Totals, r)
+		resp.ByTenant[r.Tenant] = addUsage(resp.ByTenant[r.Tenant], r)
+		resp.ByProvider[r.Provider] = addUsage(resp.ByProvider[r.Provider], r)
+	}
+
+	c.JSON(http.StatusOK, model.NewSuccessResponse(resp))
+}
+
+// addUsage 将记录累加到合计
+func addUsage(t model.UsageTotals, r *models.UsageRecord) model.UsageTotals {
+	t.Requests += r.Requests
+	t.Errors += r.Errors
+	t.Tokens += r.Tokens
+	return t
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/api/model"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestUsageHandlerTenant 测试用量查询只返回调用方租户的记录，忽略请求中的tenant参数
+func TestUsageHandlerTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dbName := fmt.Sprintf("file:memdb_handler_usage_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UsageRecord{}))
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	repo := repository.NewUsageRepository()
+	today := time.Now().Format(usageDateLayout)
+	for tenant, tokens := range map[string]int64{"alice": 100, "bob": 200} {
+		require.NoError(t, repo.Increment(&models.UsageRecord{
+			Date: today, Tenant: tenant, Provider: "tongyi", Model: "qwen-turbo",
+			Kind: models.UsageKindLLM, Requests: 1, Tokens: tokens,
+		}))
+	}
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Request = c.Request.WithContext(usage.WithTenant(c.Request.Context(), user))
+		}
+		c.Next()
+	})
+	router.GET("/api/usage", NewUsageHandler(repo).GetUsage)
+
+	query := func(user, path string) model.UsageResponse {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Data model.UsageResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Data
+	}
+
+	resp := query("alice", "/api/usage?tenant=bob")
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, "alice", resp.Records[0].Tenant)
+	assert.Equal(t, int64(100), resp.Totals.Tokens)
+
+	// 匿名调用方只能看到匿名租户的用量
+	resp = query("", "/api/usage")
+	assert.Empty(t, resp.Records)
+}
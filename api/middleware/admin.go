@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminPathPrefix 管理接口的路径前缀，备份恢复、向量导入导出、清除缓存等运维操作都在其下
+const AdminPathPrefix = "/api/admin"
+
+// AdminAuth 校验管理接口的令牌，其他路径不受影响
+// 请求需在X-Admin-Token请求头中携带配置的管理员令牌，缺少时返回401，不匹配时返回403；
+// 未配置令牌时拒绝所有管理请求。令牌按哈希比较，避免通过响应时间猜测令牌
+func AdminAuth(token string) gin.HandlerFunc {
+	expected := sha256.Sum256([]byte(token))
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path != AdminPathPrefix && !strings.HasPrefix(path, AdminPathPrefix+"/") {
+			c.Next()
+			return
+		}
+
+		if token == "" {
+			AbortWithError(c, NewForbiddenError("管理接口未启用"))
+			return
+		}
+		provided := c.GetHeader("X-Admin-Token")
+		if provided == "" {
+			AbortWithError(c, NewUnauthorizedError("缺少管理员令牌"))
+			return
+		}
+		actual := sha256.Sum256([]byte(provided))
+		if subtle.ConstantTimeCompare(actual[:], expected[:]) != 1 {
+			AbortWithError(c, NewForbiddenError("管理员令牌无效"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminAuth 测试管理接口需要管理员令牌，其他接口不受影响
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(token string) *gin.Engine {
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.Use(AdminAuth(token))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		router.DELETE("/api/admin/cache", ok)
+		router.GET("/api/administrators", ok)
+		router.GET("/api/documents", ok)
+		return router
+	}
+	do := func(router *gin.Engine, method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("X-Admin-Token", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	router := newRouter("secret")
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"valid token", http.MethodDelete, "/api/admin/cache", "secret", http.StatusOK},
+		{"missing token", http.MethodDelete, "/api/admin/cache", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodDelete, "/api/admin/cache", "guess", http.StatusForbidden},
+		{"unregistered admin path", http.MethodGet, "/api/admin/unknown", "", http.StatusUnauthorized},
+		{"similar prefix", http.MethodGet, "/api/administrators", "", http.StatusOK},
+		{"other routes", http.MethodGet, "/api/documents", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, do(router, tt.method, tt.path, tt.token))
+		})
+	}
+
+	// 未配置令牌时拒绝所有管理请求
+	disabled := newRouter("")
+	assert.Equal(t, http.StatusForbidden, do(disabled, http.MethodDelete, "/api/admin/cache", ""))
+	assert.Equal(t, http.StatusForbidden, do(disabled, http.MethodDelete, "/api/admin/cache", "anything"))
+	assert.Equal(t, http.StatusOK, do(disabled, http.MethodGet, "/api/documents", ""))
+}
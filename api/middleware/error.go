@@ -1,13 +1,19 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/sirupsen/logrus"
@@ -33,18 +39,96 @@ const (
 	ErrorTypeTimeout ErrorType = "TIMEOUT_ERROR"
 	// ErrorTypeTooManyRequests 请求过多错误
 	ErrorTypeTooManyRequests ErrorType = "TOO_MANY_REQUESTS_ERROR"
+	// ErrorTypeConflict 资源状态冲突错误
+	ErrorTypeConflict ErrorType = "CONFLICT_ERROR"
+	// ErrorTypeNotImplemented 功能未启用错误
+	ErrorTypeNotImplemented ErrorType = "NOT_IMPLEMENTED_ERROR"
+	// ErrorTypeBadGateway 上游服务不可用错误
+	ErrorTypeBadGateway ErrorType = "BAD_GATEWAY_ERROR"
 )
 
+// 领域错误码，比通用错误类型更具体，前端可以据此区分处理
+const (
+	// ErrorTypeDocumentNotFound 文档不存在
+	ErrorTypeDocumentNotFound ErrorType = "DOCUMENT_NOT_FOUND"
+	// ErrorTypeSegmentNotFound 段落不存在
+	ErrorTypeSegmentNotFound ErrorType = "SEGMENT_NOT_FOUND"
+	// ErrorTypeInvalidDocumentStatus 文档状态不允许当前操作
+	ErrorTypeInvalidDocumentStatus ErrorType = "INVALID_DOCUMENT_STATUS"
+	// ErrorTypeUnsupportedFileType 不支持的文件类型
+	ErrorTypeUnsupportedFileType ErrorType = "UNSUPPORTED_FILE_TYPE"
+	// ErrorTypeQuotaExceeded 超出配额
+	ErrorTypeQuotaExceeded ErrorType = "QUOTA_EXCEEDED"
+	// ErrorTypeBudgetExceeded 单次请求的生成预算已用尽
+	ErrorTypeBudgetExceeded ErrorType = "BUDGET_EXCEEDED"
+	// ErrorTypeTaskNotFound 任务不存在
+	ErrorTypeTaskNotFound ErrorType = "TASK_NOT_FOUND"
+	// ErrorTypeTaskNotDeadLettered 任务不在死信队列中
+	ErrorTypeTaskNotDeadLettered ErrorType = "TASK_NOT_DEAD_LETTERED"
+	// ErrorTypeDraftReviewed 回答草稿已审核
+	ErrorTypeDraftReviewed ErrorType = "DRAFT_ALREADY_REVIEWED"
+	// ErrorTypeConcurrencyLimit 同时进行的问答数超过上限
+	ErrorTypeConcurrencyLimit ErrorType = "CONCURRENCY_LIMIT_EXCEEDED"
+	// ErrorTypeFileTooLarge 文件超过允许的大小
+	ErrorTypeFileTooLarge ErrorType = "FILE_TOO_LARGE"
+	// ErrorTypeUploadNotFound 分片上传不存在或已过期
+	ErrorTypeUploadNotFound ErrorType = "UPLOAD_NOT_FOUND"
+	// ErrorTypeUploadIncomplete 分片上传还有分片未上传
+	ErrorTypeUploadIncomplete ErrorType = "UPLOAD_INCOMPLETE"
+	// ErrorTypeEncryptedFile 文件已加密，无法解析
+	ErrorTypeEncryptedFile ErrorType = "ENCRYPTED_FILE"
+	// ErrorTypeFileInfected 病毒扫描发现威胁
+	ErrorTypeFileInfected ErrorType = "FILE_INFECTED"
+	// ErrorTypeAccessDenied 没有权限修改文档
+	ErrorTypeAccessDenied ErrorType = "ACCESS_DENIED"
+)
+
+// problemContentType RFC 7807错误响应的内容类型
+const problemContentType = "application/problem+json"
+
+// domainError 领域错误到错误码和HTTP状态码的映射
+type domainError struct {
+	target  error
+	errType ErrorType
+	status  int
+	title   string
+}
+
+// domainErrors 已知的领域错误，按顺序匹配，服务层返回的错误用errors.Is判断
+var domainErrors = []domainError{
+	{models.ErrDocumentNotFound, ErrorTypeDocumentNotFound, http.StatusNotFound, "文档不存在"},
+	{models.ErrSegmentNotFound, ErrorTypeSegmentNotFound, http.StatusNotFound, "段落不存在"},
+	{models.ErrInvalidDocumentStatus, ErrorTypeInvalidDocumentStatus, http.StatusConflict, "文档状态无效"},
+	{models.ErrUnsupportedFileType, ErrorTypeUnsupportedFileType, http.StatusUnsupportedMediaType, "不支持的文件类型"},
+	{models.ErrQuotaExceeded, ErrorTypeQuotaExceeded, http.StatusTooManyRequests, "超出配额"},
+	{models.ErrAccessDenied, ErrorTypeAccessDenied, http.StatusForbidden, "没有权限修改该文档"},
+	{llm.ErrBudgetExceeded, ErrorTypeBudgetExceeded, http.StatusTooManyRequests, "本次请求的生成预算已用尽"},
+	{taskqueue.ErrTaskNotFound, ErrorTypeTaskNotFound, http.StatusNotFound, "任务未找到"},
+	{taskqueue.ErrNotDeadLettered, ErrorTypeTaskNotDeadLettered, http.StatusNotFound, "死信队列中不存在该任务"},
+	{services.ErrDraftReviewed, ErrorTypeDraftReviewed, http.StatusConflict, "回答草稿已审核"},
+	{services.ErrEmptyApprovedAnswer, ErrorTypeValidation, http.StatusBadRequest, "发布的回答不能为空"},
+	{services.ErrTooManyConcurrentQuestions, ErrorTypeConcurrencyLimit, http.StatusTooManyRequests, "同时进行的问答过多，请稍后再试"},
+	{services.ErrFileTooLarge, ErrorTypeFileTooLarge, http.StatusRequestEntityTooLarge, "文件过大"},
+	{services.ErrUploadNotFound, ErrorTypeUploadNotFound, http.StatusNotFound, "分片上传不存在或已过期"},
+	{services.ErrInvalidUploadPart, ErrorTypeValidation, http.StatusBadRequest, "分片编号或大小不正确"},
+	{services.ErrUploadIncomplete, ErrorTypeUploadIncomplete, http.StatusConflict, "还有分片未上传"},
+	{filecheck.ErrTypeNotAllowed, ErrorTypeUnsupportedFileType, http.StatusUnsupportedMediaType, "不支持的文件类型"},
+	{filecheck.ErrTypeMismatch, ErrorTypeUnsupportedFileType, http.StatusUnsupportedMediaType, "文件内容与扩展名不符"},
+	{filecheck.ErrEncryptedPDF, ErrorTypeEncryptedFile, http.StatusUnprocessableEntity, "PDF已加密或设置了密码，请解除保护后重新上传"},
+	{filecheck.ErrInfected, ErrorTypeFileInfected, http.StatusUnprocessableEntity, "文件未通过病毒扫描"},
+	{context.DeadlineExceeded, ErrorTypeTimeout, http.StatusGatewayTimeout, "请求超时"},
+}
+
 // AppError 应用错误结构体
 type AppError struct {
-	Type      ErrorType           // 错误类型
-	Message   string              // 错误消息
-	Details   string              // 详细错误信息
-	Code      int                 // HTTP状态码
-	Timestamp time.Time           // 错误发生时间
-	Path      string              // 发生错误的路径
-	Fields    map[string]string   // 字段验证错误详情
-	Cause     error               // 原始错误
+	Type      ErrorType         // 错误类型
+	Message   string            // 错误消息
+	Details   string            // 详细错误信息
+	Code      int               // HTTP状态码
+	Timestamp time.Time         // 错误发生时间
+	Path      string            // 发生错误的路径
+	Fields    map[string]string // 字段验证错误详情
+	Cause     error             // 原始错误
 }
 
 // Error 实现error接口的方法
@@ -61,17 +145,23 @@ func (e AppError) Unwrap() error {
 	return e.Cause
 }
 
-// ResponseJSON 生成用于API响应的JSON表示
+// ResponseJSON 生成RFC 7807格式的错误响应
+// type为错误码对应的URN，code为可供前端判断的错误码，其余字段为扩展成员
 func (e AppError) ResponseJSON(traceID string) gin.H {
 	resp := gin.H{
-		"code":    e.Code,
-		"type":    e.Type,
-		"message": e.Message,
-		"time":    e.Timestamp.Format(time.RFC3339),
+		"type":   "urn:docqa:error:" + strings.ToLower(string(e.Type)),
+		"title":  e.Message,
+		"status": e.Code,
+		"code":   e.Type,
+		"time":   e.Timestamp.Format(time.RFC3339),
 	}
 
 	if e.Details != "" {
-		resp["details"] = e.Details
+		resp["detail"] = e.Details
+	}
+
+	if e.Path != "" {
+		resp["instance"] = e.Path
 	}
 
 	if len(e.Fields) > 0 {
@@ -94,6 +184,12 @@ func (e *AppError) SetCause(err error) *AppError {
 	return e
 }
 
+// SetDetails 设置详细错误信息
+func (e *AppError) SetDetails(details string) *AppError {
+	e.Details = details
+	return e
+}
+
 // SetPath 设置错误发生的路径
 func (e *AppError) SetPath(path string) *AppError {
 	e.Path = path
@@ -142,15 +238,58 @@ func NewForbiddenError(message string) *AppError {
 }
 
 // NewNotFoundError 创建资源不存在错误
-func NewNotFoundError(message string) *AppError {
+func NewNotFoundError(message string, details ...string) *AppError {
 	return &AppError{
 		Type:      ErrorTypeNotFound,
 		Message:   message,
+		Details:   strings.Join(details, "; "),
 		Code:      http.StatusNotFound,
 		Timestamp: time.Now(),
 	}
 }
 
+// NewConflictError 创建资源状态冲突错误
+func NewConflictError(message string, details ...string) *AppError {
+	return &AppError{
+		Type:      ErrorTypeConflict,
+		Message:   message,
+		Details:   strings.Join(details, "; "),
+		Code:      http.StatusConflict,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewNotImplementedError 创建功能未启用错误
+func NewNotImplementedError(message string) *AppError {
+	return &AppError{
+		Type:      ErrorTypeNotImplemented,
+		Message:   message,
+		Code:      http.StatusNotImplemented,
+		Timestamp: time.Now(),
+	}
+}
+
+// NewDomainError 将领域错误转换为对应错误码的AppError，未知错误返回nil
+func NewDomainError(err error) *AppError {
+	for _, d := range domainErrors {
+		if errors.Is(err, d.target) {
+			appErr := &AppError{
+				Type:      d.errType,
+				Message:   d.title,
+				Code:      d.status,
+				Cause:     err,
+				Timestamp: time.Now(),
+			}
+			// 包装后的错误携带了资源ID等上下文信息，作为详情返回
+			if err.Error() != d.target.Error() {
+				appErr.Details = err.Error()
+			}
+			return appErr
+		}
+	}
+	return nil
+}
+
 // NewInternalError 创建内部服务器错误
 func NewInternalError(message string, cause error) *AppError {
 	details := ""
@@ -199,6 +338,23 @@ func NewTooManyRequestsError(message string) *AppError {
 	}
 }
 
+// NewBadGatewayError 创建上游服务不可用错误
+func NewBadGatewayError(message string, cause error) *AppError {
+	details := ""
+	if cause != nil {
+		details = cause.Error()
+	}
+
+	return &AppError{
+		Type:      ErrorTypeBadGateway,
+		Message:   message,
+		Details:   details,
+		Cause:     cause,
+		Code:      http.StatusBadGateway,
+		Timestamp: time.Now(),
+	}
+}
+
 // FromValidationErrors 从验证错误创建AppError
 func FromValidationErrors(err validator.ValidationErrors) *AppError {
 	appErr := NewValidationError("请求参数验证失败")
@@ -248,57 +404,80 @@ func ErrorHandler() gin.HandlerFunc {
 				traceIDStr = traceID.(string)
 			}
 
-			// 获取最后一个错误
-			err := c.Errors.Last().Err
-
-			// 转换为AppError
-			var appErr *AppError
-
-			switch typed := err.(type) {
-			case *AppError:
-				// 已经是AppError类型
-				appErr = typed
-				appErr.SetPath(c.Request.URL.Path)
-
-			case validator.ValidationErrors:
-				// 处理验证错误
-				appErr = FromValidationErrors(typed)
-
-			default:
-				// 处理特定类型的错误
-				if errors.Is(err, models.ErrDocumentNotFound) {
-					appErr = NewNotFoundError("文档不存在")
-				} else if errors.Is(err, models.ErrInvalidDocumentStatus) {
-					appErr = NewBusinessError("文档状态无效")
-				} else {
-					// 将其他错误包装为内部错误
-					appErr = NewInternalError("处理请求时发生错误", err)
-				}
-			}
-
-			// 记录错误日志
-			logEntry := log.WithFields(logrus.Fields{
-				"trace_id": traceIDStr,
-				"path":     c.Request.URL.Path,
-				"method":   c.Request.Method,
-				"status":   appErr.Code,
-				"error":    appErr.Error(),
-			})
-
-			if appErr.Type == ErrorTypeInternal {
-				logEntry.Error("Internal server error")
-			} else {
-				logEntry.Warn("Request error")
+			// 处理器已经写出错误响应时不再重复写入
+			if c.Writer.Written() {
+				return
 			}
 
-			// 生成并返回错误响应
-			c.JSON(appErr.Code, appErr.ResponseJSON(traceIDStr))
+			// 获取最后一个错误并生成错误响应
+			writeProblem(c, FromError(c.Errors.Last().Err), traceIDStr)
 			c.Abort()
 			return
 		}
 	}
 }
 
+// FromError 将任意错误转换为AppError
+// 已知的领域错误映射为对应的错误码和状态码，其他错误视为内部错误
+func FromError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return FromValidationErrors(validationErrs)
+	}
+
+	if domainErr := NewDomainError(err); domainErr != nil {
+		return domainErr
+	}
+
+	// 将其他错误包装为内部错误
+	return NewInternalError("处理请求时发生错误", err)
+}
+
+// AbortWithError 将错误转换为RFC 7807格式的响应并中止请求
+// 不依赖ErrorHandler中间件，处理器可以直接调用
+func AbortWithError(c *gin.Context, err error) {
+	traceID, _ := c.Get("TraceID")
+	traceIDStr, _ := traceID.(string)
+
+	_ = c.Error(err)
+	writeProblem(c, FromError(err), traceIDStr)
+	c.Abort()
+}
+
+// writeProblem 记录错误日志并写出错误响应
+func writeProblem(c *gin.Context, appErr *AppError, traceID string) {
+	appErr.SetPath(c.Request.URL.Path)
+
+	// 记录错误日志
+	logEntry := log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+		"trace_id": traceID,
+		"path":     c.Request.URL.Path,
+		"method":   c.Request.Method,
+		"status":   appErr.Code,
+		"error":    appErr.Error(),
+	})
+
+	if appErr.Type == ErrorTypeInternal {
+		logEntry.Error("Internal server error")
+	} else {
+		logEntry.Warn("Request error")
+	}
+
+	resp := appErr.ResponseJSON(traceID)
+	if id := requestid.FromContext(c.Request.Context()); id != "" {
+		resp[requestid.Field] = id
+	}
+
+	// 先设置内容类型，gin渲染JSON时不会覆盖已有的Content-Type
+	c.Header("Content-Type", problemContentType)
+	c.JSON(appErr.Code, resp)
+}
+
 // Recovery 错误恢复中间件
 func Recovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -312,7 +491,7 @@ func Recovery() gin.HandlerFunc {
 				}
 
 				// 记录日志
-				log.WithFields(logrus.Fields{
+				log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
 					"trace_id": traceIDStr,
 					"path":     c.Request.URL.Path,
 					"method":   c.Request.Method,
@@ -331,7 +510,7 @@ func Recovery() gin.HandlerFunc {
 				}
 
 				appErr := NewInternalError(message, fmt.Errorf("%v", err))
-				c.JSON(http.StatusInternalServerError, appErr.ResponseJSON(traceIDStr))
+				writeProblem(c, appErr, traceIDStr)
 				c.Abort()
 			}
 		}()
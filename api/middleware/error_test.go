@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProblemResponse 测试领域错误映射为RFC 7807格式的响应
+func TestProblemResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	router.GET("/documents/:id", func(c *gin.Context) {
+		AbortWithError(c, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, c.Param("id")))
+	})
+	router.GET("/upload", func(c *gin.Context) {
+		AbortWithError(c, NewDomainError(models.ErrUnsupportedFileType).SetDetails("仅支持 .pdf, .md, .markdown, .txt"))
+	})
+	router.GET("/validation", func(c *gin.Context) {
+		AbortWithError(c, NewValidationError("无效的请求参数"))
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New("boom"))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+		title  string
+		detail string
+	}{
+		{"/documents/doc-1", http.StatusNotFound, "DOCUMENT_NOT_FOUND", "文档不存在", "document not found: doc-1"},
+		{"/upload", http.StatusUnsupportedMediaType, "UNSUPPORTED_FILE_TYPE", "不支持的文件类型", "仅支持 .pdf, .md, .markdown, .txt"},
+		{"/validation", http.StatusBadRequest, "VALIDATION_ERROR", "无效的请求参数", ""},
+		{"/internal", http.StatusInternalServerError, "INTERNAL_ERROR", "处理请求时发生错误", "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.code, body["code"])
+			assert.Equal(t, tt.title, body["title"])
+			assert.Equal(t, float64(tt.status), body["status"])
+			assert.Equal(t, tt.path, body["instance"])
+			assert.Contains(t, body["type"], "urn:docqa:error:")
+			if tt.detail != "" {
+				assert.Equal(t, tt.detail, body["detail"])
+			} else {
+				assert.NotContains(t, body, "detail")
+			}
+		})
+	}
+}
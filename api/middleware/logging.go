@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -130,6 +131,9 @@ func InitLogger(config LogConfig) {
 			logger.SetOutput(os.Stdout)
 		}
 
+		// 通过WithContext记录的日志自动带上请求ID
+		logger.AddHook(requestid.Hook{})
+
 		log = logger
 		log.Info("Logger initialized")
 	})
@@ -162,7 +166,7 @@ func WithTraceContext(ctx *gin.Context) *logrus.Entry {
 	}
 
 	// 构建带上下文的日志条目
-	return log.WithFields(logrus.Fields{
+	return log.WithContext(ctx.Request.Context()).WithFields(logrus.Fields{
 		"trace_id": traceID,
 		"user_id":  userID,
 		"path":     ctx.Request.URL.Path,
@@ -204,22 +208,27 @@ func Logger() gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
+		// 记录结构化的访问日志，request_id由日志钩子从请求上下文中添加
+		entry := log.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       path,
+			"route":      c.FullPath(),
+			"status":     statusCode,
+			"ip":         c.ClientIP(),
+			"latency_ms": latency.Milliseconds(),
+			"size":       c.Writer.Size(),
+			"trace_id":   traceID,
+		})
+
 		// 通过状态码确定日志级别
-		logFunc := log.Infof
-		if statusCode >= 400 && statusCode < 500 {
-			logFunc = log.Warnf
-		} else if statusCode >= 500 {
-			logFunc = log.Errorf
+		switch {
+		case statusCode >= 500:
+			entry.Error("Request completed")
+		case statusCode >= 400:
+			entry.Warn("Request completed")
+		default:
+			entry.Info("Request completed")
 		}
-
-		// 记录请求信息
-		logFunc("Request completed | %s | %d | %s | %s | %s",
-			c.Request.Method,
-			statusCode,
-			c.ClientIP(),
-			path,
-			latency.String(),
-		)
 	}
 }
 
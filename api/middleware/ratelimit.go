@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit 按客户端限制请求速率
+// 客户端按Tenant中间件认证的租户区分，匿名请求按来源IP区分，因此需要在Tenant之后使用；
+// exempt中的路径前缀（健康检查、任务回调等）不限流。限流器出错时放行请求，避免Redis故障导致服务不可用
+func RateLimit(limiter ratelimit.Limiter, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, prefix := range exempt {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		key := auth.ClientFromContext(c.Request.Context())
+		if key == "" {
+			key = "ip:" + c.ClientIP()
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			GetLogger().WithContext(c.Request.Context()).WithError(err).Warn("Rate limiter unavailable, request allowed")
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			AbortWithError(c, NewTooManyRequestsError("请求过于频繁，请稍后再试").
+				SetDetails("retry after "+strconv.Itoa(retryAfter)+"s"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingLimiter 总是返回错误的限流器
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("redis unavailable")
+}
+
+// TestRateLimit 测试按客户端限流和豁免路径
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(limiter ratelimit.Limiter) *gin.Engine {
+		router := gin.New()
+		router.Use(Tenant(auth.NewAuthenticator(
+			auth.Key{Key: "key-a", Tenant: "team-a"},
+			auth.Key{Key: "key-b", Tenant: "team-b"},
+		)))
+		router.Use(RateLimit(limiter, "/api/health"))
+		router.GET("/api/qa", func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.GET("/api/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+	do := func(router *gin.Engine, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := newRouter(ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: 0.001, Burst: 2}))
+
+	// 同一API密钥超过突发容量后返回429
+	assert.Equal(t, http.StatusOK, do(router, "/api/qa", "key-a").Code)
+	w := do(router, "/api/qa", "key-a")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	w = do(router, "/api/qa", "key-a")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 其他API密钥和未携带密钥的客户端分别计数
+	assert.Equal(t, http.StatusOK, do(router, "/api/qa", "key-b").Code)
+	assert.Equal(t, http.StatusOK, do(router, "/api/qa", "").Code)
+
+	// 豁免路径不限流
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, do(router, "/api/health", "key-a").Code)
+	}
+
+	// 匿名客户端伪造租户请求头或X-Forwarded-For不能获得新的令牌桶
+	spoofed := newRouter(ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: 0.001, Burst: 1}))
+	require.NoError(t, spoofed.SetTrustedProxies(nil))
+	for i, header := range []string{"", "X-Tenant-ID", "X-Forwarded-For"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/qa", nil)
+		if header != "" {
+			req.Header.Set(header, fmt.Sprintf("10.0.0.%d", i))
+		}
+		w := httptest.NewRecorder()
+		spoofed.ServeHTTP(w, req)
+		if i == 0 {
+			assert.Equal(t, http.StatusOK, w.Code)
+		} else {
+			assert.Equal(t, http.StatusTooManyRequests, w.Code, header)
+		}
+	}
+
+	// 限流器出错时放行
+	assert.Equal(t, http.StatusOK, do(newRouter(failingLimiter{}), "/api/qa", "key-a").Code)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadOnlyReplica 只读副本中间件，将写请求转发到主实例
+// GET、HEAD、OPTIONS请求和localRoutes中的路由（如问答，虽然是POST但只读取向量数据库）在本实例处理，
+// 其余请求原样转发到primary。localRoutes为注册路由时的路径模板，如/api/groups/:id/qa。
+// 转发的响应带有X-Served-By: primary响应头，主实例不可用时返回502
+func ReadOnlyReplica(primary *url.URL, localRoutes ...string) gin.HandlerFunc {
+	local := make(map[string]bool, len(localRoutes))
+	for _, route := range localRoutes {
+		local[route] = true
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if local[c.FullPath()] {
+			c.Next()
+			return
+		}
+
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(primary)
+				r.SetXForwarded()
+			},
+			// 立即刷新响应，流式回答不在副本上缓冲
+			FlushInterval: -1,
+			ModifyResponse: func(resp *http.Response) error {
+				resp.Header.Set("X-Served-By", "primary")
+				return nil
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				GetLogger().WithContext(r.Context()).WithError(err).Warn("Failed to forward write request to primary")
+				AbortWithError(c, NewBadGatewayError("主实例不可用，请稍后重试", err))
+			},
+		}
+		proxy.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
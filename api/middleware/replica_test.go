@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReadOnlyReplica 测试副本在本地处理读请求和问答，转发其余写请求
+func TestReadOnlyReplica(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var forwarded []string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = append(forwarded, r.Method+" "+r.URL.Path+" "+string(body))
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "from primary")
+	}))
+	defer primary.Close()
+	primaryURL, err := url.Parse(primary.URL)
+	require.NoError(t, err)
+
+	newRouter := func(target *url.URL) *gin.Engine {
+		router := gin.New()
+		router.Use(ReadOnlyReplica(target, "/api/qa", "/api/groups/:id/qa"))
+		local := func(c *gin.Context) { c.String(http.StatusOK, "from replica") }
+		router.GET("/api/documents", local)
+		router.POST("/api/documents", local)
+		router.POST("/api/qa", local)
+		router.POST("/api/groups/:id/qa", local)
+		return router
+	}
+	// ReverseProxy需要支持CloseNotify的ResponseWriter，通过真实的HTTP服务器发送请求
+	replica := httptest.NewServer(newRouter(primaryURL))
+	defer replica.Close()
+	type response struct {
+		Code   int
+		Header http.Header
+		Body   string
+	}
+	do := func(method, path string) response {
+		req, err := http.NewRequest(method, replica.URL+path, strings.NewReader("payload"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return response{Code: resp.StatusCode, Header: resp.Header, Body: string(body)}
+	}
+
+	// 读请求和问答在本地处理
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/documents"},
+		{http.MethodPost, "/api/qa"},
+		{http.MethodPost, "/api/groups/g1/qa"},
+	} {
+		w := do(tc.method, tc.path)
+		assert.Equal(t, http.StatusOK, w.Code, tc.path)
+		assert.Equal(t, "from replica", w.Body, tc.path)
+	}
+	assert.Empty(t, forwarded)
+
+	// 写请求连同请求体转发到主实例
+	w := do(http.MethodPost, "/api/documents")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "from primary", w.Body)
+	assert.Equal(t, "primary", w.Header.Get("X-Served-By"))
+	assert.Equal(t, []string{"POST /api/documents payload"}, forwarded)
+
+	// 主实例不可用时返回502
+	primary.Close()
+	w = do(http.MethodDelete, "/api/documents/doc1")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body, string(ErrorTypeBadGateway))
+}
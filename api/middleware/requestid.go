@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID 为请求分配请求ID并写入请求上下文和响应头
+// 优先沿用客户端或网关传入的X-Request-ID，格式不合法时重新生成；
+// 服务内通过logger.WithContext(ctx)记录的日志会自动带上request_id字段，异步任务和Python服务调用也会携带该ID
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		c.Set("RequestID", id)
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestIDMiddleware 测试请求ID的生成、沿用和上下文传递
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c.Request.Context()))
+	})
+
+	// 沿用合法的请求ID
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(requestid.Header, "upstream-id-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "upstream-id-1", w.Header().Get(requestid.Header))
+	assert.Equal(t, "upstream-id-1", w.Body.String())
+
+	// 缺失或非法的请求ID会重新生成
+	for _, incoming := range []string{"", "bad\nid"} {
+		req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+		if incoming != "" {
+			req.Header[requestid.Header] = []string{incoming}
+		}
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		id := w.Header().Get(requestid.Header)
+		assert.NotEmpty(t, id)
+		assert.NotEqual(t, incoming, id)
+		assert.Equal(t, id, w.Body.String())
+	}
+}
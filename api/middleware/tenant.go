@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant 识别请求所属的租户并写入请求上下文，用于用量统计、配额、限流和文档访问控制
+// 租户和用户组只来自登记的API密钥（X-API-Key或Bearer令牌）；未携带或携带未登记密钥的请求是匿名的，按来源IP限流。
+// 客户端自行设置的X-Tenant-ID、X-Tenant-Groups请求头无法验证，不被采信
+func Tenant(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := auth.WithClientAddress(c.Request.Context(), c.ClientIP())
+		key := auth.RequestKey(c.GetHeader("X-API-Key"), c.GetHeader("Authorization"))
+		if identity, ok := authenticator.Authenticate(key); ok {
+			ctx = auth.WithIdentity(ctx, identity)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
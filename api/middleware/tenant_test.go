@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTenant 测试租户和用户组只来自登记的API密钥，客户端自行设置的请求头不被采信
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Tenant(auth.NewAuthenticator(auth.Key{Key: "sk-alice", Tenant: "alice", Groups: []string{"finance"}})))
+	router.GET("/whoami", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		c.JSON(http.StatusOK, gin.H{"tenant": usage.TenantFromContext(ctx), "groups": acl.GroupsFromContext(ctx)})
+	})
+
+	do := func(headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"tenant":"alice","groups":["finance"]}`, do(map[string]string{"X-API-Key": "sk-alice"}))
+	assert.JSONEq(t, `{"tenant":"alice","groups":["finance"]}`, do(map[string]string{"Authorization": "Bearer sk-alice"}))
+
+	// 伪造的租户和用户组请求头、未登记的密钥都按匿名处理
+	anonymous := `{"tenant":"anonymous","groups":null}`
+	assert.JSONEq(t, anonymous, do(map[string]string{"X-Tenant-ID": "alice", "X-Tenant-Groups": "finance"}))
+	assert.JSONEq(t, anonymous, do(map[string]string{"X-API-Key": "sk-guess", "X-Tenant-ID": "alice"}))
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// AuditLogInfo 审计日志条目
+type AuditLogInfo struct {
+	ID        uint      `json:"id"`                   // 日志ID
+	Actor     string    `json:"actor"`                // 操作者，即租户或API密钥标识
+	Action    string    `json:"action"`               // 操作类型
+	Target    string    `json:"target,omitempty"`     // 操作对象
+	Detail    string    `json:"detail,omitempty"`     // 操作详情
+	RequestID string    `json:"request_id,omitempty"` // 请求ID
+	CreatedAt time.Time `json:"created_at"`           // 操作时间
+}
+
+// AuditLogListResponse 审计日志列表响应
+type AuditLogListResponse struct {
+	Total    int64          `json:"total"`     // 总记录数
+	Page     int            `json:"page"`      // 当前页码
+	PageSize int            `json:"page_size"` // 每页大小
+	Entries  []AuditLogInfo `json:"entries"`   // 审计日志
+}
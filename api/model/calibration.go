@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// CalibrateRequest 重新校准最低相似度请求
+type CalibrateRequest struct {
+	Questions  []string `json:"questions,omitempty"`  // 参与校准的问题，为空时使用最近的用户问题
+	Percentile float64  `json:"percentile,omitempty"` // 取检索分数分布的百分位，为空时使用配置值
+}
+
+// CalibrationInfo 单个嵌入模型的校准结果
+type CalibrationInfo struct {
+	Model        string    `json:"model"`         // 嵌入模型名称
+	Threshold    float32   `json:"threshold"`     // 校准得到的最低相似度
+	Percentile   float64   `json:"percentile"`    // 使用的百分位
+	Questions    int       `json:"questions"`     // 参与校准的问题数
+	Samples      int       `json:"samples"`       // 收集的检索分数数量
+	MinScore     float32   `json:"min_score"`     // 分数分布的最小值
+	MedianScore  float32   `json:"median_score"`  // 分数分布的中位数
+	MaxScore     float32   `json:"max_score"`     // 分数分布的最大值
+	CalibratedAt time.Time `json:"calibrated_at"` // 校准时间
+}
+
+// CalibrationListResponse 校准结果列表响应
+type CalibrationListResponse struct {
+	Model        string            `json:"model"`        // 当前使用的嵌入模型
+	MinScore     float32           `json:"min_score"`    // 当前模型实际使用的最低相似度
+	Calibrations []CalibrationInfo `json:"calibrations"` // 所有模型的校准结果
+}
@@ -6,15 +6,20 @@ import (
 
 // CreateChatRequest 创建聊天会话请求
 type CreateChatRequest struct {
-	Title string `json:"title,omitempty"` // 会话标题，可选，如果不提供将使用默认标题
+	Title       string   `json:"title,omitempty"`                                   // 会话标题，可选，如果不提供将使用默认标题
+	DocumentIDs []string `json:"document_ids,omitempty" binding:"omitempty,max=50"` // 绑定的文档ID，可选，绑定后只在这些文档中检索
+	GroupIDs    []string `json:"group_ids,omitempty" binding:"omitempty,max=20"`    // 绑定的文档组ID，可选
+
+	SystemPrompt string `json:"system_prompt,omitempty" binding:"omitempty,max=2000"` // 系统提示词，可选，设定助手在会话中的角色
 }
 
 // CreateMessageRequest 创建聊天消息请求
 type CreateMessageRequest struct {
-	SessionID string                 `json:"session_id" binding:"required"` // 会话ID
-	Role      string                 `json:"role" binding:"required"`       // 消息角色：user, system, assistant
-	Content   string                 `json:"content" binding:"required"`    // 消息内容
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`            // 消息元数据，可选
+	SessionID string                 `json:"session_id" binding:"required"`               // 会话ID
+	Role      string                 `json:"role" binding:"required"`                     // 消息角色：user, system, assistant
+	Content   string                 `json:"content" binding:"required"`                  // 消息内容
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`                          // 消息元数据，可选
+	Model     string                 `json:"model,omitempty" binding:"omitempty,max=100"` // 生成助手回复的模型，可选，为空时使用默认模型
 }
 
 // GetChatHistoryRequest 获取聊天历史请求
@@ -26,9 +31,16 @@ type GetChatHistoryRequest struct {
 // ChatListRequest 聊天会话列表请求
 type ChatListRequest struct {
 	PaginationRequest            // 嵌入分页请求
-	StartTime         *time.Time `form:"start_time" json:"start_time,omitempty"` // 开始时间
-	EndTime           *time.Time `form:"end_time" json:"end_time,omitempty"`     // 结束时间
-	Tags              string     `form:"tags" json:"tags,omitempty"`             // 标签过滤
+	StartTime         *time.Time `form:"start_time" json:"start_time,omitempty"`                     // 开始时间
+	EndTime           *time.Time `form:"end_time" json:"end_time,omitempty"`                         // 结束时间
+	Tags              string     `form:"tags" json:"tags,omitempty"`                                 // 标签过滤
+	Cursor            string     `form:"cursor" json:"cursor,omitempty" binding:"omitempty,max=512"` // 分页游标，取自上一页的next_cursor，提供时忽略page
+}
+
+// ChatSearchRequest 聊天记录搜索请求
+type ChatSearchRequest struct {
+	PaginationRequest        // 嵌入分页请求
+	Query             string `form:"q" binding:"required,max=200"` // 搜索内容，按空白拆分为关键词
 }
 
 // RenameChatRequest 重命名聊天会话请求
@@ -37,11 +49,63 @@ type RenameChatRequest struct {
 	Title     string `json:"title" binding:"required"`     // 新标题
 }
 
+// UpdateChatRequest 更新聊天会话请求，至少提供一项
+type UpdateChatRequest struct {
+	Title        string  `json:"title,omitempty"`                                      // 新标题，为空时不修改
+	SystemPrompt *string `json:"system_prompt,omitempty" binding:"omitempty,max=2000"` // 系统提示词，为空字符串时清除，不提供时不修改
+}
+
 // CreateChatWithMessageRequest 创建会话并添加首条消息的请求
 type CreateChatWithMessageRequest struct {
 	Title    string                 `json:"title,omitempty"`            // 会话标题，可选
 	Content  string                 `json:"content" binding:"required"` // 消息内容
 	Metadata map[string]interface{} `json:"metadata,omitempty"`         // 消息元数据，可选
+
+	DocumentIDs []string `json:"document_ids,omitempty" binding:"omitempty,max=50"` // 绑定的文档ID，可选
+	GroupIDs    []string `json:"group_ids,omitempty" binding:"omitempty,max=20"`    // 绑定的文档组ID，可选
+
+	SystemPrompt string `json:"system_prompt,omitempty" binding:"omitempty,max=2000"` // 系统提示词，可选
+}
+
+// ChatScopeRequest 设置会话范围请求，两者都为空时解除绑定
+type ChatScopeRequest struct {
+	DocumentIDs []string `json:"document_ids" binding:"omitempty,max=50"` // 绑定的文档ID
+	GroupIDs    []string `json:"group_ids" binding:"omitempty,max=20"`    // 绑定的文档组ID
+}
+
+// ChatScopeResponse 会话范围响应
+type ChatScopeResponse struct {
+	SessionID   string   `json:"session_id"`             // 会话ID
+	DocumentIDs []string `json:"document_ids,omitempty"` // 绑定的文档ID
+	GroupIDs    []string `json:"group_ids,omitempty"`    // 绑定的文档组ID
+}
+
+// EditMessageRequest 编辑用户消息请求
+type EditMessageRequest struct {
+	Content    string `json:"content" binding:"required"` // 新的消息内容
+	Regenerate *bool  `json:"regenerate,omitempty"`       // 是否根据新内容生成助手回复，默认生成
+}
+
+// EditMessageResponse 编辑用户消息响应
+type EditMessageResponse struct {
+	Message     MessageInfo  `json:"message"`         // 编辑后的用户消息
+	Invalidated int64        `json:"invalidated"`     // 失效并删除的后续消息数
+	Reply       *MessageInfo `json:"reply,omitempty"` // 新生成的助手回复
+}
+
+// MessageVersionInfo 消息历史版本信息
+type MessageVersionInfo struct {
+	Version    int            `json:"version"`           // 版本号
+	Content    string         `json:"content"`           // 该版本的内容
+	Sources    []QASourceInfo `json:"sources,omitempty"` // 该版本引用的来源
+	ReplacedAt time.Time      `json:"replaced_at"`       // 被替换的时间
+}
+
+// ChatSummaryResponse 会话摘要响应
+type ChatSummaryResponse struct {
+	SessionID    string     `json:"session_id"`              // 会话ID
+	Summary      string     `json:"summary"`                 // 会话摘要
+	SummarizedAt *time.Time `json:"summarized_at,omitempty"` // 生成摘要的时间
 }
 
 // DeleteChatRequest 删除聊天会话请求
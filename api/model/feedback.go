@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// FeedbackRequest 回答负反馈请求
+type FeedbackRequest struct {
+	Question   string   `json:"question" binding:"required"`                        // 用户问题
+	SegmentIDs []string `json:"segment_ids" binding:"required,min=1,dive,required"` // 被标记错误的来源段落ID，即来源信息中的segment_id
+	Comment    string   `json:"comment"`                                            // 备注
+	Variant    string   `json:"prompt_variant" binding:"omitempty,max=100"`         // 回答响应中的提示词变体，用于按变体统计负反馈
+}
+
+// FeedbackResponse 回答负反馈响应
+type FeedbackResponse struct {
+	ClusterID string   `json:"cluster_id"` // 问题归入的问题簇ID
+	Recorded  []string `json:"recorded"`   // 已记录的段落ID
+	Activated []string `json:"activated"`  // 本次反馈后开始降权的段落ID
+}
+
+// ReviewSuppressionRequest 审核检索抑制请求
+type ReviewSuppressionRequest struct {
+	Status string `json:"status" binding:"required,oneof=active dismissed"` // 审核结果：active启用，dismissed驳回
+}
+
+// SuppressionInfo 检索抑制信息
+type SuppressionInfo struct {
+	ID         uint       `json:"id"`                    // 记录ID
+	ClusterID  string     `json:"cluster_id"`            // 问题簇ID
+	Question   string     `json:"question"`              // 问题簇的代表问题
+	SegmentID  string     `json:"segment_id"`            // 段落ID
+	FileID     string     `json:"file_id"`               // 段落所属文档ID
+	FileName   string     `json:"filename"`              // 段落所属文件名
+	Text       string     `json:"text"`                  // 段落内容
+	WrongCount int        `json:"wrong_count"`           // 被标记错误的次数
+	Status     string     `json:"status"`                // 状态：pending、active或dismissed
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"` // 审核时间
+	UpdatedAt  time.Time  `json:"updated_at"`            // 更新时间
+}
+
+// SuppressionListResponse 检索抑制列表响应
+type SuppressionListResponse struct {
+	Total        int64             `json:"total"`        // 总记录数
+	Page         int               `json:"page"`         // 当前页码
+	PageSize     int               `json:"page_size"`    // 每页大小
+	Suppressions []SuppressionInfo `json:"suppressions"` // 检索抑制记录
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// GroupMemberRequest 文档组成员参数
+type GroupMemberRequest struct {
+	DocumentID string `json:"document_id" binding:"required"` // 文档ID
+	Label      string `json:"label"`                          // 成员标签，如"原合同"、"第一次修订"
+}
+
+// CreateGroupRequest 创建文档组请求
+type CreateGroupRequest struct {
+	Name        string               `json:"name" binding:"required"`          // 文档组名称
+	Description string               `json:"description"`                      // 描述
+	Members     []GroupMemberRequest `json:"members" binding:"omitempty,dive"` // 成员文档，按顺序排列
+}
+
+// UpdateGroupRequest 更新文档组请求
+type UpdateGroupRequest struct {
+	Name        string `json:"name"`        // 文档组名称
+	Description string `json:"description"` // 描述
+}
+
+// GroupQARequest 文档组问答请求
+type GroupQARequest struct {
+	Question string `json:"question" binding:"required"` // 问题内容
+}
+
+// GroupMemberInfo 文档组成员信息
+type GroupMemberInfo struct {
+	DocumentID string `json:"document_id"` // 文档ID
+	Label      string `json:"label"`       // 成员标签
+	Position   int    `json:"position"`    // 组内顺序
+}
+
+// GroupInfo 文档组信息
+type GroupInfo struct {
+	ID          string            `json:"id"`          // 文档组ID
+	Name        string            `json:"name"`        // 文档组名称
+	Description string            `json:"description"` // 描述
+	Members     []GroupMemberInfo `json:"members"`     // 成员
+	CreatedAt   time.Time         `json:"created_at"`  // 创建时间
+	UpdatedAt   time.Time         `json:"updated_at"`  // 更新时间
+}
+
+// GroupListResponse 文档组列表响应
+type GroupListResponse struct {
+	Total    int64       `json:"total"`     // 总数量
+	Page     int         `json:"page"`      // 当前页码
+	PageSize int         `json:"page_size"` // 每页大小
+	Groups   []GroupInfo `json:"groups"`    // 文档组列表
+}
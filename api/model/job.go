@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/events"
+)
+
+// JobInfo 定时任务信息
+type JobInfo struct {
+	Name     string     `json:"name"`               // 任务名称
+	Type     string     `json:"type"`               // 任务类型：recrawl或reembed
+	Schedule string     `json:"schedule"`           // cron表达式
+	Running  bool       `json:"running"`            // 是否正在运行
+	NextRun  *time.Time `json:"next_run,omitempty"` // 下次运行时间
+}
+
+// JobRunInfo 定时任务运行记录
+type JobRunInfo struct {
+	ID         uint       `json:"id"`                    // 记录ID
+	JobName    string     `json:"job_name"`              // 任务名称
+	JobType    string     `json:"job_type"`              // 任务类型
+	Trigger    string     `json:"trigger"`               // 触发方式：schedule或manual
+	Status     string     `json:"status"`                // 运行状态
+	Processed  int        `json:"processed"`             // 检查的文档数量
+	Enqueued   int        `json:"enqueued"`              // 投递或执行的任务数量
+	Failed     int        `json:"failed"`                // 失败数量
+	Message    string     `json:"message,omitempty"`     // 运行信息
+	StartedAt  time.Time  `json:"started_at"`            // 开始时间
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 结束时间
+}
+
+// JobRunListResponse 运行记录列表响应
+type JobRunListResponse struct {
+	Total    int64        `json:"total"`     // 总记录数
+	Page     int          `json:"page"`      // 当前页码
+	PageSize int          `json:"page_size"` // 每页大小
+	Runs     []JobRunInfo `json:"runs"`      // 运行记录
+}
+
+// EventListResponse 知识库变更事件列表
+type EventListResponse struct {
+	Events    []events.Entry `json:"events"`     // 序号大于after的事件，按序号递增
+	LatestSeq int64          `json:"latest_seq"` // 最新的事件序号
+	Published int64          `json:"published"`  // 所有发布者都发布成功的事件数
+	Dropped   int64          `json:"dropped"`    // 缓冲区满时丢弃的事件数
+	Failed    int64          `json:"failed"`     // 外部发布者发布失败的次数
+}
+
+// ConnectorInfo 外部来源连接器的同步状态
+type ConnectorInfo struct {
+	Name       string     `json:"name"`                   // 连接器名称
+	Type       string     `json:"type"`                   // 连接器类型：confluence、notion、gdrive或sharepoint
+	GroupID    string     `json:"group_id,omitempty"`     // 新建的文档加入的文档组
+	Running    bool       `json:"running"`                // 是否正在同步
+	Items      int64      `json:"items"`                  // 已同步的条目数
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"` // 最近一次同步完成的时间
+	LastError  string     `json:"last_error,omitempty"`   // 最近一次同步的错误信息
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// LLMLogInfo 大模型交互日志条目
+type LLMLogInfo struct {
+	ID         uint      `json:"id"`                    // 日志ID
+	Tenant     string    `json:"tenant"`                // 租户
+	RequestID  string    `json:"request_id,omitempty"`  // 请求ID
+	Model      string    `json:"model"`                 // 模型名称
+	Method     string    `json:"method"`                // 调用方式：generate或chat
+	Prompt     string    `json:"prompt"`                // 脱敏后的提示词
+	ContextIDs []string  `json:"context_ids,omitempty"` // 放入提示词的检索段落ID
+	Response   string    `json:"response,omitempty"`    // 脱敏后的回答
+	Error      string    `json:"error,omitempty"`       // 调用失败时的错误信息
+	LatencyMs  int64     `json:"latency_ms"`            // 耗时（毫秒）
+	Tokens     int       `json:"tokens"`                // 消耗的token数
+	CreatedAt  time.Time `json:"created_at"`            // 调用时间
+}
+
+// LLMLogListResponse 大模型交互日志列表响应
+type LLMLogListResponse struct {
+	Total    int64        `json:"total"`     // 总记录数
+	Page     int          `json:"page"`      // 当前页码
+	PageSize int          `json:"page_size"` // 每页大小
+	Entries  []LLMLogInfo `json:"entries"`   // 交互日志
+}
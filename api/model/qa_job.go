@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// QAJobSubmitResponse 提交异步问答的响应
+type QAJobSubmitResponse struct {
+	JobID  string `json:"job_id"` // 任务ID，用于查询结果
+	Status string `json:"status"` // 任务状态，提交后为pending
+}
+
+// QAJobResponse 异步问答任务的状态和结果
+type QAJobResponse struct {
+	JobID    string         `json:"job_id"`            // 任务ID
+	Status   string         `json:"status"`            // 任务状态：pending、processing、completed或failed
+	Question string         `json:"question"`          // 用户问题
+	Answer   string         `json:"answer,omitempty"`  // 完成后的回答
+	Sources  []QASourceInfo `json:"sources"`           // 来源信息
+	Refusal  *Refusal       `json:"refusal,omitempty"` // 问题或回答被护栏拦截时的说明
+	Error    string         `json:"error,omitempty"`   // 最近一次失败的错误信息，重试成功后仍保留
+
+	// 回答有检索上下文作为依据的置信度（0~1），仅启用依据校验时返回
+	Confidence    *float32 `json:"confidence,omitempty"`
+	LowConfidence bool     `json:"low_confidence,omitempty"` // 置信度是否低于阈值
+	Model         string   `json:"model,omitempty"`          // 生成回答的模型，服务端未配置可选模型时不返回
+	PromptVariant string   `json:"prompt_variant,omitempty"` // 生成回答的提示词变体，服务端未配置对比实验时不返回
+
+	Attempts    int        `json:"attempts"`               // 执行次数
+	CreatedAt   time.Time  `json:"created_at"`             // 提交时间
+	StartedAt   *time.Time `json:"started_at,omitempty"`   // 开始执行的时间
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // 结束时间
+}
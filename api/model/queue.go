@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// TaskAttemptInfo 任务单次执行失败的记录
+type TaskAttemptInfo struct {
+	Attempt  int       `json:"attempt"`   // 第几次执行
+	Error    string    `json:"error"`     // 错误信息
+	FailedAt time.Time `json:"failed_at"` // 失败时间
+}
+
+// DeadLetterTaskInfo 死信队列中的任务
+type DeadLetterTaskInfo struct {
+	TaskID         string            `json:"task_id"`                    // 任务ID
+	Type           string            `json:"type"`                       // 任务类型
+	DocumentID     string            `json:"document_id,omitempty"`      // 关联的文档ID
+	Attempts       int               `json:"attempts"`                   // 执行次数
+	Error          string            `json:"error"`                      // 最后一次错误
+	ErrorHistory   []TaskAttemptInfo `json:"error_history"`              // 每次失败的错误记录
+	CreatedAt      time.Time         `json:"created_at"`                 // 创建时间
+	DeadLetteredAt *time.Time        `json:"dead_lettered_at,omitempty"` // 进入死信队列的时间
+}
+
+// DeadLetterListResponse 死信任务列表响应
+type DeadLetterListResponse struct {
+	Total    int64                `json:"total"`     // 总任务数
+	Page     int                  `json:"page"`      // 当前页码
+	PageSize int                  `json:"page_size"` // 每页大小
+	Tasks    []DeadLetterTaskInfo `json:"tasks"`     // 死信任务
+}
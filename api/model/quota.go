@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// QuotaAllowance 单个计量项的配额情况
+type QuotaAllowance struct {
+	Limit     int64 `json:"limit"`     // 上限，0表示不限制
+	Used      int64 `json:"used"`      // 已用量
+	Remaining int64 `json:"remaining"` // 剩余量，不限制时为-1
+}
+
+// QuotaResponse 配额查询响应
+type QuotaResponse struct {
+	Tenant       string         `json:"tenant"`        // 租户
+	Documents    QuotaAllowance `json:"documents"`     // 文档数量
+	StorageBytes QuotaAllowance `json:"storage_bytes"` // 文件存储字节数
+	QACalls      QuotaAllowance `json:"qa_calls"`      // 当日问答次数
+	QAResetAt    time.Time      `json:"qa_reset_at"`   // 问答次数重置时间
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"mime/multipart"
 	"time"
 )
@@ -37,18 +38,61 @@ type DocumentUploadRequest struct {
 	Metadata map[string]string     `form:"metadata" json:"metadata" binding:"omitempty"` // 文档元数据
 }
 
+// DocumentURLRequest 网页导入请求
+type DocumentURLRequest struct {
+	URLs []string `json:"urls" binding:"required,min=1,max=20,dive,required"` // 网页地址列表
+	Tags string   `json:"tags" binding:"omitempty"`                           // 文档标签，逗号分隔
+}
+
 // DocumentStatusRequest 文档状态查询请求
 type DocumentStatusRequest struct {
 	ID string `uri:"id" binding:"required"` // 文档ID
 }
 
+// SegmentContextRequest 段落上下文解析请求
+type SegmentContextRequest struct {
+	ID       string `uri:"id" binding:"required"` // 文档ID
+	Position int    `uri:"position"`              // 段落位置
+}
+
+// SegmentContextQuery 段落上下文查询参数
+type SegmentContextQuery struct {
+	Window int `form:"window,default=2" binding:"min=0,max=20"` // 前后各取的段落数
+}
+
+// SegmentUpdateRequest 段落修改请求
+type SegmentUpdateRequest struct {
+	Text string `json:"text" binding:"required"` // 修改后的段落文本
+}
+
 // DocumentListRequest 文档列表请求
 type DocumentListRequest struct {
 	PaginationRequest
-	StartTime *time.Time `form:"start_time" json:"start_time" binding:"omitempty"` // 开始时间
-	EndTime   *time.Time `form:"end_time" json:"end_time" binding:"omitempty"`     // 结束时间
-	Status    string     `form:"status" json:"status" binding:"omitempty"`         // 文档状态
-	Tags      string     `form:"tags" json:"tags" binding:"omitempty"`             // 标签过滤
+	StartTime     *time.Time `form:"start_time" json:"start_time" binding:"omitempty"`         // 开始时间
+	EndTime       *time.Time `form:"end_time" json:"end_time" binding:"omitempty"`             // 结束时间
+	Status        string     `form:"status" json:"status" binding:"omitempty"`                 // 文档状态
+	Tags          string     `form:"tags" json:"tags" binding:"omitempty"`                     // 标签过滤
+	Q             string     `form:"q" json:"q" binding:"omitempty,max=200"`                   // 关键词，匹配文件名，多个关键词以空格分隔
+	SearchContent bool       `form:"search_content" json:"search_content" binding:"omitempty"` // 关键词是否同时匹配段落文本
+	ParentID      string     `form:"parent_id" json:"parent_id" binding:"omitempty,max=64"`    // 只列出该文档展开出的子文档
+	Cursor        string     `form:"cursor" json:"cursor" binding:"omitempty,max=512"`         // 分页游标，取自上一页的next_cursor，提供时忽略page
+}
+
+// DocumentTagsRequest 文档标签更新请求
+type DocumentTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=50,dive,max=50"` // 文档标签，替换已有标签
+}
+
+// DocumentRetrievalRequest 设置文档是否参与检索的请求
+type DocumentRetrievalRequest struct {
+	NoRetrieve *bool `json:"no_retrieve" binding:"required"` // 为true时文档不参与问答检索，如归档的草稿
+}
+
+// DocumentACLRequest 文档访问控制更新请求，共享的用户和用户组整体替换已有的共享
+type DocumentACLRequest struct {
+	Public bool     `json:"public"`                                      // 是否公开，公开的文档所有人都可以读取
+	Users  []string `json:"users" binding:"max=100,dive,min=1,max=100"`  // 共享的用户（租户）
+	Groups []string `json:"groups" binding:"max=100,dive,min=1,max=100"` // 共享的用户组
 }
 
 // DocumentDeleteRequest 文档删除请求
@@ -56,10 +100,55 @@ type DocumentDeleteRequest struct {
 	ID string `uri:"id" binding:"required"` // 文档ID
 }
 
+// CompareRequest 文档对比请求
+type CompareRequest struct {
+	Question string   `json:"question" binding:"required"`                                  // 对比问题，如"两个版本的报销政策有什么不同"
+	FileIDs  []string `json:"file_ids" binding:"required,min=2,max=5,unique,dive,required"` // 参与对比的文件ID，按顺序排列
+}
+
+// BatchQARequest 批量问答请求
+type BatchQARequest struct {
+	Questions []BatchQuestion `json:"questions" binding:"required,min=1,dive"`    // 问题列表，数量不超过服务端上限
+	FileIDs   []string        `json:"file_ids" binding:"omitempty,dive,required"` // 未单独指定范围的问题默认只从这些文件中回答
+}
+
+// BatchQuestion 批量问答中的一个问题
+type BatchQuestion struct {
+	Question string   `json:"question" binding:"required"`                // 问题内容
+	FileIDs  []string `json:"file_ids" binding:"omitempty,dive,required"` // 只从这些文件中回答，为空时使用请求的file_ids
+}
+
 // QARequest 问答请求
 type QARequest struct {
 	Question  string                 `json:"question" binding:"required"`          // 问题内容
 	FileID    string                 `json:"file_id" binding:"omitempty"`          // 可选的文件ID，指定从特定文件中回答
 	Metadata  map[string]interface{} `json:"metadata" binding:"omitempty"`         // 可选的元数据过滤
-	MaxTokens int                    `json:"max_tokens" binding:"omitempty,min=1"` // 可选的最大生成tokens数量
+	MaxTokens int                    `json:"max_tokens" binding:"omitempty,min=1"` // 可选的最大生成tokens数量，超过服务端上限时按上限处理
+	// Temperature 可选的采样温度，超过服务端上限时按上限处理
+	Temperature *float32 `json:"temperature,omitempty" binding:"omitempty,min=0,max=2"`
+
+	// Translate 是否跨语言问答：问题与文档库语言不同时翻译问题和回答，为空时使用服务端默认设置
+	Translate *bool `json:"translate,omitempty"`
+	// AnswerLanguage 回答的语言（ISO 639-1代码），为空时与问题语言相同，仅在跨语言问答时生效
+	AnswerLanguage string `json:"answer_language,omitempty" binding:"omitempty,max=10"`
+
+	// 以下检索参数为空时使用服务端配置，search_limit和mmr_candidates超过服务端上限时按上限处理
+	SearchLimit   int      `json:"search_limit,omitempty" binding:"omitempty,min=1"`                    // 检索的段落数量
+	MinScore      *float32 `json:"min_score,omitempty" binding:"omitempty,min=0,max=1"`                 // 最低相似度
+	Strategy      string   `json:"strategy,omitempty" binding:"omitempty,oneof=similarity mmr rewrite"` // 检索策略
+	MMRLambda     *float32 `json:"mmr_lambda,omitempty" binding:"omitempty,min=0,max=1"`                // MMR重排的相关性权重
+	MMRCandidates int      `json:"mmr_candidates,omitempty" binding:"omitempty,min=1"`                  // 参与MMR重排的候选数量
+
+	// ExcludeFileIDs 不参与检索的文件，与file_id相同时不会检索到任何段落
+	ExcludeFileIDs []string `json:"exclude_file_ids,omitempty" binding:"omitempty,max=100,dive,max=100"`
+	// ExcludeTags 不参与检索的文档标签，带有任一标签的文档都被排除
+	ExcludeTags []string `json:"exclude_tags,omitempty" binding:"omitempty,max=50,dive,max=50"`
+
+	// Format 回答格式：markdown、plain或json，为空时不限制格式
+	Format string `json:"format,omitempty" binding:"omitempty,oneof=markdown plain json"`
+	// Schema format为json时回答需要满足的JSON Schema，回答通过校验后以data字段返回
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// Model 生成回答的模型，须为服务端配置的可选模型之一，为空时使用默认模型
+	Model string `json:"model,omitempty" binding:"omitempty,max=100"`
 }
@@ -1,8 +1,15 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
 )
 
@@ -23,14 +30,6 @@ func NewSuccessResponse(data interface{}) *Response {
 	}
 }
 
-// NewErrorResponse 创建错误响应
-func NewErrorResponse(code int, message string) *Response {
-	return &Response{
-		Code:    code,
-		Message: message,
-	}
-}
-
 // DocumentUploadResponse 文档上传响应
 type DocumentUploadResponse struct {
 	FileID   string `json:"file_id"`  // 文件ID
@@ -38,6 +37,23 @@ type DocumentUploadResponse struct {
 	Status   string `json:"status"`   // 文档状态：uploaded、processing、completed、failed
 }
 
+// DocumentURLResult 单个网页的导入结果
+type DocumentURLResult struct {
+	URL      string `json:"url"`                // 网页地址
+	FileID   string `json:"file_id,omitempty"`  // 文件ID，导入失败时为空
+	FileName string `json:"filename,omitempty"` // 文件名
+	Title    string `json:"title,omitempty"`    // 网页标题
+	Status   string `json:"status"`             // 状态：uploaded或failed
+	Error    string `json:"error,omitempty"`    // 失败原因
+}
+
+// DocumentURLResponse 网页导入响应
+type DocumentURLResponse struct {
+	Documents []DocumentURLResult `json:"documents"` // 每个网页的导入结果
+	Succeeded int                 `json:"succeeded"` // 成功数量
+	Failed    int                 `json:"failed"`    // 失败数量
+}
+
 // DocumentStatusResponse 文档状态查询响应
 type DocumentStatusResponse struct {
 	FileID        string                 `json:"file_id"`                  // 文档ID
@@ -48,6 +64,9 @@ type DocumentStatusResponse struct {
 	CreatedAt     string                 `json:"created_at"`               // 创建时间
 	UpdatedAt     string                 `json:"updated_at"`               // 更新时间
 	Tags          string                 `json:"tags,omitempty"`           // 文档标签
+	Language      string                 `json:"language,omitempty"`       // 识别出的文档语言
+	ParentID      string                 `json:"parent_id,omitempty"`      // 从压缩包或邮件中展开的子文档所属的父文档ID
+	NoRetrieve    bool                   `json:"no_retrieve,omitempty"`    // 文档不参与问答检索
 	Size          int64                  `json:"size,omitempty"`           // 文件大小
 	Progress      int                    `json:"progress,omitempty"`       // 处理进度(0-100)
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`       // 元数据
@@ -60,6 +79,9 @@ type DocumentInfo struct {
 	FileName      string                 `json:"filename"`                 // 文件名
 	Status        string                 `json:"status"`                   // 状态
 	Tags          string                 `json:"tags,omitempty"`           // 标签
+	Language      string                 `json:"language,omitempty"`       // 识别出的文档语言
+	ParentID      string                 `json:"parent_id,omitempty"`      // 从压缩包或邮件中展开的子文档所属的父文档ID
+	NoRetrieve    bool                   `json:"no_retrieve,omitempty"`    // 文档不参与问答检索
 	UploadTime    time.Time              `json:"upload_time"`              // 上传时间
 	UpdatedAt     time.Time              `json:"updated_at"`               // 更新时间
 	Segments      int                    `json:"segments"`                 // 段落数量
@@ -72,10 +94,13 @@ type DocumentInfo struct {
 
 // DocumentListResponse 文档列表响应
 type DocumentListResponse struct {
-	Total     int64          `json:"total"`     // 总数量
-	Page      int            `json:"page"`      // 当前页码
-	PageSize  int            `json:"page_size"` // 每页大小
-	Documents []DocumentInfo `json:"documents"` // 文档列表
+	Total      int64          `json:"total"`                 // 总数量
+	Page       int            `json:"page"`                  // 当前页码
+	PageSize   int            `json:"page_size"`             // 每页大小
+	TotalPages int            `json:"total_pages"`           // 总页数
+	HasMore    bool           `json:"has_more"`              // 是否还有下一页
+	NextCursor string         `json:"next_cursor,omitempty"` // 下一页游标，没有更多记录时为空；使用游标翻页时不统计总数，total和page为0
+	Documents  []DocumentInfo `json:"documents"`             // 文档列表
 }
 
 // DocumentDeleteResponse 文档删除响应
@@ -105,17 +130,176 @@ type DocumentMetricsResponse struct {
 
 // QASourceInfo 问答来源信息
 type QASourceInfo struct {
-	Text     string `json:"text"`     // 相关文本段落
-	FileID   string `json:"file_id"`  // 文件ID
-	FileName string `json:"filename"` // 文件名
-	Position int    `json:"position"` // 段落位置
+	Text      string `json:"text"`                 // 相关文本段落
+	FileID    string `json:"file_id"`              // 文件ID
+	FileName  string `json:"filename"`             // 文件名
+	Position  int    `json:"position"`             // 段落位置
+	SegmentID string `json:"segment_id,omitempty"` // 段落唯一ID
+	Link      string `json:"link,omitempty"`       // 跳转到原文的深链接
+
+	Page        int    `json:"page,omitempty"`         // 所在页码，仅分页文档有
+	Section     string `json:"section,omitempty"`      // 所在章节标题
+	HeadingPath string `json:"heading_path,omitempty"` // 从顶层到所在章节的标题路径
+	Location    string `json:"location,omitempty"`     // 可读的引用位置，如"第12页，3.2 配置"
+
+	// 来源由同一文档中多个相邻段落合并而成时，合并的段落位置范围，Text为去重后拼接的文本
+	Merged *SegmentRange `json:"merged,omitempty"`
+
+	// 引用信息，范围按字符计算且相对Text，用于在前端高亮
+	Citations  []int          `json:"citations,omitempty"`  // 回答中引用该来源的标记编号，如[1]对应1
+	Spans      []llm.TextSpan `json:"spans,omitempty"`      // 实际提供给大模型的文本范围
+	Highlights []llm.TextSpan `json:"highlights,omitempty"` // 支撑回答的文本范围
+
+	Image *SourceImage `json:"image,omitempty"` // 来源是文档中的图片时，图片的引用
+}
+
+// SegmentRange 段落位置范围，包含两端
+type SegmentRange struct {
+	From int `json:"from"` // 第一个段落的位置
+	To   int `json:"to"`   // 最后一个段落的位置
+}
+
+// SourceImage 来源引用的图片，前端通过URL在回答旁展示图片
+type SourceImage struct {
+	ID   string `json:"id"`   // 图片ID
+	Name string `json:"name"` // 图片在原文档中的文件名
+	URL  string `json:"url"`  // 获取图片的地址
+}
+
+// ImageURL 返回获取文档图片的接口地址
+func ImageURL(fileID, imageID string) string {
+	return "/api/documents/" + url.PathEscape(fileID) + "/images/" + url.PathEscape(imageID)
+}
+
+// DefaultSourceLinkTemplate 默认的来源深链接模板，指向段落上下文解析接口
+const DefaultSourceLinkTemplate = "/api/documents/{file_id}/segments/{position}/context"
+
+// sourceLinkTemplate 当前使用的来源深链接模板
+var sourceLinkTemplate = DefaultSourceLinkTemplate
+
+// SetSourceLinkTemplate 设置来源深链接模板
+// 支持{file_id}、{position}、{segment_id}占位符，例如 https://viewer.example.com/docs/{file_id}#seg-{position}
+// 传入空字符串时恢复默认模板
+func SetSourceLinkTemplate(template string) {
+	if template == "" {
+		template = DefaultSourceLinkTemplate
+	}
+	sourceLinkTemplate = template
+}
+
+// SegmentID 返回段落的稳定ID，与向量数据库中的文档ID一致
+func SegmentID(fileID string, position int) string {
+	return fmt.Sprintf("%s_%d", fileID, position)
+}
+
+// SourceLink 按模板生成指向段落的深链接
+func SourceLink(fileID string, position int) string {
+	return strings.NewReplacer(
+		"{file_id}", url.PathEscape(fileID),
+		"{position}", strconv.Itoa(position),
+		"{segment_id}", url.PathEscape(SegmentID(fileID, position)),
+	).Replace(sourceLinkTemplate)
+}
+
+// NewSourceInfo 创建来源信息并填充段落ID和深链接
+func NewSourceInfo(fileID, fileName, text string, position int) QASourceInfo {
+	return QASourceInfo{
+		Text:      text,
+		FileID:    fileID,
+		FileName:  fileName,
+		Position:  position,
+		SegmentID: SegmentID(fileID, position),
+		Link:      SourceLink(fileID, position),
+	}
 }
 
 // QAResponse 问答响应
 type QAResponse struct {
-	Question string         `json:"question"` // 用户问题
-	Answer   string         `json:"answer"`   // AI生成的回答
-	Sources  []QASourceInfo `json:"sources"`  // 来源信息
+	Question string         `json:"question"`          // 用户问题
+	Answer   string         `json:"answer"`            // AI生成的回答
+	Sources  []QASourceInfo `json:"sources"`           // 来源信息
+	Curated  bool           `json:"curated,omitempty"` // 回答是否来自人工审核过的FAQ
+	Review   *ReviewStatus  `json:"review,omitempty"`  // 回答待审核时的草稿信息
+	Refusal  *Refusal       `json:"refusal,omitempty"` // 问题或回答被护栏拦截时的说明
+
+	// 回答有检索上下文作为依据的置信度（0~1），仅启用依据校验时返回
+	Confidence    *float32 `json:"confidence,omitempty"`
+	LowConfidence bool     `json:"low_confidence,omitempty"` // 置信度是否低于阈值
+
+	// 回答来自缓存时缓存的时长（秒），回答新生成时不返回
+	CacheAge   *int64 `json:"cache_age_seconds,omitempty"`
+	Refreshing bool   `json:"refreshing,omitempty"` // 缓存的回答是否正在后台刷新，稍后再问可得到新回答
+
+	// 请求format为json时满足schema的回答，回答为拒绝或兜底说明时不返回
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// 生成回答的模型，服务端未配置可选模型时不返回
+	Model string `json:"model,omitempty"`
+
+	// 生成回答的提示词变体，提交反馈时原样带上，服务端未配置对比实验时不返回
+	PromptVariant string `json:"prompt_variant,omitempty"`
+
+	Translation *TranslationInfo `json:"translation,omitempty"` // 跨语言问答的翻译信息
+}
+
+// BatchQAResponse 批量问答响应，结果与请求中的问题按顺序一一对应
+type BatchQAResponse struct {
+	Results   []BatchQAResult `json:"results"`   // 每个问题的结果
+	Succeeded int             `json:"succeeded"` // 回答成功的问题数
+	Failed    int             `json:"failed"`    // 回答失败的问题数
+}
+
+// BatchQAResult 批量问答中一个问题的结果
+type BatchQAResult struct {
+	Question string         `json:"question"`           // 用户问题
+	FileIDs  []string       `json:"file_ids,omitempty"` // 问答范围
+	Answer   string         `json:"answer,omitempty"`   // 回答
+	Sources  []QASourceInfo `json:"sources"`            // 来源信息
+	Refusal  *Refusal       `json:"refusal,omitempty"`  // 问题或回答被护栏拦截时的说明
+	Error    string         `json:"error,omitempty"`    // 回答失败的原因
+}
+
+// CompareResponse 文档对比响应
+type CompareResponse struct {
+	Question     string              `json:"question"`          // 对比问题
+	Summary      string              `json:"summary"`           // 对比的总体结论
+	Differences  []CompareDifference `json:"differences"`       // 逐方面列出的差异
+	Similarities []string            `json:"similarities"`      // 各文档相同的内容
+	Documents    []ComparedDocument  `json:"documents"`         // 参与对比的文档及各自的来源
+	Refusal      *Refusal            `json:"refusal,omitempty"` // 问题或结论被护栏拦截时的说明
+}
+
+// CompareDifference 文档之间的一处差异
+type CompareDifference struct {
+	Aspect string         `json:"aspect"` // 对比的方面
+	Values []CompareValue `json:"values"` // 各文档在该方面的内容，顺序与documents一致，未提及的文档不返回
+}
+
+// CompareValue 单个文档在某个方面的内容
+type CompareValue struct {
+	FileID string `json:"file_id"` // 文件ID
+	Value  string `json:"value"`   // 该文档的内容
+}
+
+// ComparedDocument 参与对比的文档
+type ComparedDocument struct {
+	FileID   string         `json:"file_id"`  // 文件ID
+	FileName string         `json:"filename"` // 文件名
+	Sources  []QASourceInfo `json:"sources"`  // 该文档中检索到的来源
+}
+
+// TranslationInfo 跨语言问答的翻译信息
+type TranslationInfo struct {
+	QuestionLanguage   string `json:"question_language"`             // 识别出的问题语言
+	TranslatedQuestion string `json:"translated_question,omitempty"` // 用于检索的问题译文
+	AnswerLanguage     string `json:"answer_language"`               // 回答的语言
+	AnswerTranslated   bool   `json:"answer_translated"`             // 回答是否经过翻译
+}
+
+// Refusal 护栏拒绝回答的结构化说明
+type Refusal struct {
+	Stage    string `json:"stage"`    // 拦截阶段：input表示问题被拦截，output表示回答被拦截
+	Category string `json:"category"` // 违规类别，如prompt_injection
 }
 
 // ConvertToSourceInfo 将向量数据库文档转换为来源信息
@@ -126,11 +310,19 @@ func ConvertToSourceInfo(docs []vectordb.Document) []QASourceInfo {
 
 	sources := make([]QASourceInfo, len(docs))
 	for i, doc := range docs {
-		sources[i] = QASourceInfo{
-			Text:     doc.Text,
-			FileID:   doc.FileID,
-			FileName: doc.FileName,
-			Position: doc.Position,
+		sources[i] = NewSourceInfo(doc.FileID, doc.FileName, doc.Text, doc.Position)
+		sources[i].Page, sources[i].Section, sources[i].HeadingPath = document.StructureFromMetadata(doc.Metadata)
+		sources[i].Location = document.FormatLocation(sources[i].Page, sources[i].Section)
+		if from, to, ok := document.MergedRangeFromMetadata(doc.Metadata); ok {
+			sources[i].Merged = &SegmentRange{From: from, To: to}
+		}
+		if citation, ok := llm.SourceCitationFromMetadata(doc.Metadata); ok {
+			sources[i].Citations = citation.Markers
+			sources[i].Spans = citation.Spans
+			sources[i].Highlights = citation.Highlights
+		}
+		if id, name := document.ImageFromMetadata(doc.Metadata); id != "" {
+			sources[i].Image = &SourceImage{ID: id, Name: name, URL: ImageURL(doc.FileID, id)}
 		}
 	}
 	return sources
@@ -154,31 +346,155 @@ type ChatInfo struct {
 
 // MessageInfo 聊天消息信息
 type MessageInfo struct {
-	ID        string         `json:"id"`                // 消息ID
-	Role      string         `json:"role"`              // 消息角色（用户/系统/助手）
-	Content   string         `json:"content"`           // 消息内容
-	CreatedAt time.Time      `json:"created_at"`        // 创建时间
-	Sources   []QASourceInfo `json:"sources,omitempty"` // 引用来源，可选
+	ID        string         `json:"id"`                  // 消息ID
+	Role      string         `json:"role"`                // 消息角色（用户/系统/助手）
+	Content   string         `json:"content"`             // 消息内容
+	CreatedAt time.Time      `json:"created_at"`          // 创建时间
+	Sources   []QASourceInfo `json:"sources,omitempty"`   // 引用来源，可选
+	Version   int            `json:"version,omitempty"`   // 版本号，编辑或重新生成后大于1
+	EditedAt  *time.Time     `json:"edited_at,omitempty"` // 最后一次编辑或重新生成的时间
+	PinnedAt  *time.Time     `json:"pinned_at,omitempty"` // 收藏时间，未收藏时为空
+}
+
+// PinnedMessageInfo 收藏的助手回复
+type PinnedMessageInfo struct {
+	SessionID string      `json:"session_id"`         // 会话ID
+	Title     string      `json:"title"`              // 会话标题
+	Question  string      `json:"question,omitempty"` // 回复对应的用户问题
+	Message   MessageInfo `json:"message"`            // 收藏的回复
+}
+
+// PinnedListResponse 收藏列表响应
+type PinnedListResponse struct {
+	Total    int64               `json:"total"`     // 总数量
+	Page     int                 `json:"page"`      // 当前页码
+	PageSize int                 `json:"page_size"` // 每页大小
+	Messages []PinnedMessageInfo `json:"messages"`  // 收藏的回复
 }
 
 // ChatListResponse 聊天列表响应
 type ChatListResponse struct {
-	Total    int64      `json:"total"`     // 总数量
-	Page     int        `json:"page"`      // 当前页码
-	PageSize int        `json:"page_size"` // 每页大小
-	Chats    []ChatInfo `json:"chats"`     // 会话列表
+	Total      int64      `json:"total"`                 // 总数量
+	Page       int        `json:"page"`                  // 当前页码
+	PageSize   int        `json:"page_size"`             // 每页大小
+	NextCursor string     `json:"next_cursor,omitempty"` // 下一页游标，没有更多记录时为空；使用游标翻页时不统计总数，total和page为0
+	Chats      []ChatInfo `json:"chats"`                 // 会话列表
+}
+
+// ChatSearchResponse 聊天记录搜索响应
+type ChatSearchResponse struct {
+	Total    int64           `json:"total"`     // 匹配的消息总数
+	Page     int             `json:"page"`      // 当前页码
+	PageSize int             `json:"page_size"` // 每页大小
+	Hits     []ChatSearchHit `json:"hits"`      // 匹配的消息
+}
+
+// ChatSearchHit 聊天记录搜索的一条结果
+type ChatSearchHit struct {
+	SessionID string    `json:"session_id"` // 会话ID
+	Title     string    `json:"title"`      // 会话标题
+	MessageID uint      `json:"message_id"` // 消息ID
+	Role      string    `json:"role"`       // 消息角色
+	Highlight string    `json:"highlight"`  // 匹配处的片段，已做HTML转义，关键词用<mark>标记
+	CreatedAt time.Time `json:"created_at"` // 消息创建时间
 }
 
 // ChatHistoryResponse 聊天历史响应
 type ChatHistoryResponse struct {
-	ChatID   string        `json:"chat_id"`  // 会话ID
-	Title    string        `json:"title"`    // 会话标题
-	Messages []MessageInfo `json:"messages"` // 消息列表
+	ChatID      string        `json:"chat_id"`                // 会话ID
+	Title       string        `json:"title"`                  // 会话标题
+	DocumentIDs []string      `json:"document_ids,omitempty"` // 会话绑定的文档ID
+	GroupIDs    []string      `json:"group_ids,omitempty"`    // 会话绑定的文档组ID
+	Summary     string        `json:"summary,omitempty"`      // 会话摘要
+	Messages    []MessageInfo `json:"messages"`               // 消息列表
+
+	SystemPrompt string `json:"system_prompt,omitempty"` // 会话的系统提示词
 }
 
 // CreateChatResponse 创建聊天响应
 type CreateChatResponse struct {
-	ChatID    string    `json:"chat_id"`    // 会话ID
-	Title     string    `json:"title"`      // 会话标题
-	CreatedAt time.Time `json:"created_at"` // 创建时间
+	ChatID      string    `json:"chat_id"`                // 会话ID
+	Title       string    `json:"title"`                  // 会话标题
+	CreatedAt   time.Time `json:"created_at"`             // 创建时间
+	DocumentIDs []string  `json:"document_ids,omitempty"` // 会话绑定的文档ID
+	GroupIDs    []string  `json:"group_ids,omitempty"`    // 会话绑定的文档组ID
+
+	SystemPrompt string `json:"system_prompt,omitempty"` // 会话的系统提示词
+}
+
+// SegmentInfo 文档段落信息
+type SegmentInfo struct {
+	SegmentID string                 `json:"segment_id"`         // 段落唯一ID
+	Position  int                    `json:"position"`           // 段落位置
+	Text      string                 `json:"text"`               // 段落文本
+	Metadata  map[string]interface{} `json:"metadata,omitempty"` // 段落元数据，如页码和章节
+	UpdatedAt time.Time              `json:"updated_at"`         // 更新时间
+}
+
+// DocumentSummaryResponse 文档摘要响应
+type DocumentSummaryResponse struct {
+	FileID       string    `json:"file_id"`       // 文件ID
+	Summary      string    `json:"summary"`       // 摘要正文
+	KeyPoints    []string  `json:"key_points"`    // 要点
+	SegmentCount int       `json:"segment_count"` // 生成摘要时的段落数量
+	GeneratedAt  time.Time `json:"generated_at"`  // 生成时间
+	Cached       bool      `json:"cached"`        // 是否为之前保存的摘要
+}
+
+// TagSuggestionResponse 标签建议响应
+type TagSuggestionResponse struct {
+	FileID      string   `json:"file_id"`     // 文件ID
+	Tags        []string `json:"tags"`        // 文档已有的标签
+	Suggestions []string `json:"suggestions"` // 建议的标签，不包含已有标签
+}
+
+// DocumentRetrievalResponse 设置文档是否参与检索的响应
+type DocumentRetrievalResponse struct {
+	FileID     string `json:"file_id"`     // 文件ID
+	NoRetrieve bool   `json:"no_retrieve"` // 文档是否不参与问答检索
+}
+
+// DocumentACLResponse 文档访问控制响应
+type DocumentACLResponse struct {
+	FileID string   `json:"file_id"` // 文件ID
+	Owner  string   `json:"owner"`   // 上传者（租户）
+	Public bool     `json:"public"`  // 是否公开
+	Users  []string `json:"users"`   // 共享的用户
+	Groups []string `json:"groups"`  // 共享的用户组
+}
+
+// DocumentTagsResponse 文档标签更新响应
+type DocumentTagsResponse struct {
+	FileID string   `json:"file_id"` // 文件ID
+	Tags   []string `json:"tags"`    // 更新后的标签
+}
+
+// SegmentListResponse 文档段落列表响应
+type SegmentListResponse struct {
+	FileID   string        `json:"file_id"`   // 文件ID
+	Total    int64         `json:"total"`     // 段落总数
+	Page     int           `json:"page"`      // 当前页码
+	PageSize int           `json:"page_size"` // 每页大小
+	Segments []SegmentInfo `json:"segments"`  // 段落列表
+}
+
+// SegmentContextInfo 上下文窗口中的段落信息
+type SegmentContextInfo struct {
+	SegmentID string `json:"segment_id"` // 段落唯一ID
+	Position  int    `json:"position"`   // 段落位置
+	Text      string `json:"text"`       // 段落文本
+	Start     int    `json:"start"`      // 在context中的起始偏移（按字符计）
+	End       int    `json:"end"`        // 在context中的结束偏移（不含）
+	Target    bool   `json:"target"`     // 是否为被引用的段落
+}
+
+// SegmentContextResponse 段落上下文解析响应
+type SegmentContextResponse struct {
+	FileID         string               `json:"file_id"`         // 文件ID
+	Position       int                  `json:"position"`        // 被引用段落的位置
+	Window         int                  `json:"window"`          // 前后各取的段落数
+	Context        string               `json:"context"`         // 拼接后的上下文文本
+	HighlightStart int                  `json:"highlight_start"` // 被引用段落在context中的起始偏移
+	HighlightEnd   int                  `json:"highlight_end"`   // 被引用段落在context中的结束偏移
+	Segments       []SegmentContextInfo `json:"segments"`        // 窗口内的段落
 }
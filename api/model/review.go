@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// ReviewStatus 问答响应中的审核状态，回答待审核时返回
+type ReviewStatus struct {
+	DraftID string `json:"draft_id"`        // 草稿ID，用于查询审核结果
+	Status  string `json:"status"`          // 草稿状态：pending、approved或rejected
+	Topic   string `json:"topic,omitempty"` // 命中的审核话题
+}
+
+// DraftResultResponse 提问者查询草稿审核结果的响应
+// 只有审核通过后才返回回答和来源
+type DraftResultResponse struct {
+	DraftID  string         `json:"draft_id"`         // 草稿ID
+	Question string         `json:"question"`         // 用户问题
+	Status   string         `json:"status"`           // 草稿状态
+	Answer   string         `json:"answer,omitempty"` // 审核通过的回答
+	Sources  []QASourceInfo `json:"sources"`          // 来源信息
+}
+
+// ApproveDraftRequest 审核通过草稿请求
+type ApproveDraftRequest struct {
+	Answer   string `json:"answer"`   // 修改后的回答，为空时发布原始回答
+	Reviewer string `json:"reviewer"` // 审核人
+}
+
+// RejectDraftRequest 驳回草稿请求
+type RejectDraftRequest struct {
+	Reviewer string `json:"reviewer"` // 审核人
+	Note     string `json:"note"`     // 驳回原因
+}
+
+// DraftInfo 回答草稿信息，供审核人查看
+type DraftInfo struct {
+	ID          string     `json:"id"`                     // 草稿ID
+	Question    string     `json:"question"`               // 用户问题
+	Topic       string     `json:"topic,omitempty"`        // 命中的审核话题
+	Answer      string     `json:"answer"`                 // 大模型生成的原始回答
+	FinalAnswer string     `json:"final_answer,omitempty"` // 审核通过后发布的回答
+	SegmentIDs  []string   `json:"segment_ids"`            // 回答来源段落ID
+	Status      string     `json:"status"`                 // 草稿状态
+	Reviewer    string     `json:"reviewer,omitempty"`     // 审核人
+	Note        string     `json:"note,omitempty"`         // 审核备注
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`  // 审核时间
+	CreatedAt   time.Time  `json:"created_at"`             // 提交时间
+}
+
+// DraftListResponse 回答草稿列表响应
+type DraftListResponse struct {
+	Total    int64       `json:"total"`     // 总记录数
+	Page     int         `json:"page"`      // 当前页码
+	PageSize int         `json:"page_size"` // 每页大小
+	Drafts   []DraftInfo `json:"drafts"`    // 回答草稿
+}
+
+// FAQInfo FAQ条目信息
+type FAQInfo struct {
+	ID         uint      `json:"id"`              // 条目ID
+	Question   string    `json:"question"`        // 问题
+	Topic      string    `json:"topic,omitempty"` // 所属审核话题
+	Answer     string    `json:"answer"`          // 审核通过的回答
+	SegmentIDs []string  `json:"segment_ids"`     // 回答来源段落ID
+	DraftID    string    `json:"draft_id"`        // 对应的草稿ID
+	UpdatedAt  time.Time `json:"updated_at"`      // 更新时间
+}
+
+// FAQListResponse FAQ列表响应
+type FAQListResponse struct {
+	Total    int64     `json:"total"`     // 总记录数
+	Page     int       `json:"page"`      // 当前页码
+	PageSize int       `json:"page_size"` // 每页大小
+	Entries  []FAQInfo `json:"entries"`   // FAQ条目
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// QADayStatsInfo 单日的问答统计
+type QADayStatsInfo struct {
+	Date         string  `json:"date"`           // 日期
+	Questions    int64   `json:"questions"`      // 问答次数
+	CacheHits    int64   `json:"cache_hits"`     // 命中回答缓存的次数
+	Errors       int64   `json:"errors"`         // 失败次数
+	AvgLatencyMs float64 `json:"avg_latency_ms"` // 平均耗时（毫秒）
+}
+
+// VectorDBInfoResponse 向量数据库配置和状态响应
+type VectorDBInfoResponse struct {
+	Type        string     `json:"type"`                // 数据库类型
+	IndexType   string     `json:"index_type"`          // 配置的索引类型
+	Dimension   int        `json:"dimension"`           // 向量维度
+	Distance    string     `json:"distance"`            // 距离度量方式
+	Normalized  bool       `json:"normalized"`          // 写入和查询前是否归一化向量
+	Vectors     int        `json:"vectors"`             // 向量总数
+	MemoryBytes int64      `json:"memory_bytes"`        // 内存或存储占用的字节数，未知时为0
+	LastSave    *time.Time `json:"last_save,omitempty"` // 索引最近一次持久化的时间
+	Segments    int64      `json:"segments"`            // 段落记录数
+	Suppressed  int64      `json:"suppressed_segments"` // 作为近似重复未写入向量库的段落数
+	Consistent  bool       `json:"consistent"`          // 向量数与应写入向量库的段落数是否一致
+
+	QueryCache *QueryCacheInfo `json:"query_cache,omitempty"` // 查询结果缓存统计，没有查询缓存时省略
+}
+
+// QueryCacheInfo 向量检索查询缓存的命中统计
+type QueryCacheInfo struct {
+	vectordb.QueryCacheStats
+	HitRate float64 `json:"hit_rate"` // 命中率
+}
+
+// AdminStatsResponse 运维看板统计响应
+type AdminStatsResponse struct {
+	Documents          int64            `json:"documents"`           // 文档总数
+	DocumentsByStatus  map[string]int64 `json:"documents_by_status"` // 各处理状态的文档数
+	Segments           int64            `json:"segments"`            // 段落总数
+	DuplicateSegments  int64            `json:"duplicate_segments"`  // 被标记为近似重复的段落数
+	SuppressedSegments int64            `json:"suppressed_segments"` // 作为重复段落未写入向量库的段落数
+	Vectors            int              `json:"vectors"`             // 向量总数
+	StorageBytes       int64            `json:"storage_bytes"`       // 文档文件总字节数
+	From               string           `json:"from"`                // 问答统计起始日期
+	To                 string           `json:"to"`                  // 问答统计结束日期
+	Questions          int64            `json:"questions"`           // 统计范围内的问答次数
+	AvgLatencyMs       float64          `json:"avg_latency_ms"`      // 统计范围内的平均耗时（毫秒）
+	CacheHitRate       float64          `json:"cache_hit_rate"`      // 统计范围内的回答缓存命中率
+	ErrorRate          float64          `json:"error_rate"`          // 统计范围内的失败率
+	QAByDay            []QADayStatsInfo `json:"qa_by_day"`           // 每日问答统计
+}
+
+// PromptVariantStatsInfo 单个提示词变体的对比统计
+type PromptVariantStatsInfo struct {
+	Variant          string  `json:"variant"`           // 变体名称
+	Weight           int     `json:"weight"`            // 当前配置的流量权重，已下线的变体为0
+	Questions        int64   `json:"questions"`         // 问答次数
+	Errors           int64   `json:"errors"`            // 失败次数
+	NegativeFeedback int64   `json:"negative_feedback"` // 负反馈数，每个被标记错误的段落计一次
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`    // 平均耗时（毫秒）
+	ErrorRate        float64 `json:"error_rate"`        // 失败率
+	FeedbackRate     float64 `json:"feedback_rate"`     // 每次问答的平均负反馈数
+}
+
+// PromptVariantListResponse 提示词变体对比统计响应
+type PromptVariantListResponse struct {
+	Days     int                      `json:"days"`     // 统计天数，包括今天
+	Variants []PromptVariantStatsInfo `json:"variants"` // 各变体的统计，先列当前配置的变体
+}
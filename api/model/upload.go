@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// InitUploadRequest 创建分片上传请求
+type InitUploadRequest struct {
+	FileName string `json:"file_name" binding:"required"` // 文件名
+	Size     int64  `json:"size" binding:"required,gt=0"` // 文件总字节数
+	Tags     string `json:"tags,omitempty"`               // 文档标签，逗号分隔
+}
+
+// UploadInfo 分片上传会话信息
+type UploadInfo struct {
+	UploadID      string    `json:"upload_id"`      // 上传ID
+	FileName      string    `json:"file_name"`      // 文件名
+	Size          int64     `json:"size"`           // 文件总字节数
+	PartSize      int64     `json:"part_size"`      // 分片大小，最后一个分片可以更小
+	TotalParts    int       `json:"total_parts"`    // 分片总数，分片编号从1开始
+	ReceivedParts []int     `json:"received_parts"` // 已收到的分片编号，续传时跳过这些分片
+	ExpiresAt     time.Time `json:"expires_at"`     // 过期时间
+}
+
+// UploadPartResponse 上传分片响应
+type UploadPartResponse struct {
+	UploadID string `json:"upload_id"` // 上传ID
+	Part     int    `json:"part"`      // 分片编号
+	Size     int64  `json:"size"`      // 分片字节数
+}
@@ -0,0 +1,38 @@
+package model
+
+// UsageRequest 用量查询请求
+type UsageRequest struct {
+	From     string `form:"from"`     // 起始日期（含），格式为2006-01-02，默认30天前
+	To       string `form:"to"`       // 结束日期（含），格式为2006-01-02，默认今天
+	Provider string `form:"provider"` // 按提供商过滤
+	Kind     string `form:"kind"`     // 按类型过滤：llm、embedding
+}
+
+// UsageItem 单条用量记录
+type UsageItem struct {
+	Date     string `json:"date"`     // 日期
+	Tenant   string `json:"tenant"`   // 租户
+	Provider string `json:"provider"` // 提供商
+	Model    string `json:"model"`    // 模型
+	Kind     string `json:"kind"`     // 类型
+	Requests int64  `json:"requests"` // 请求次数
+	Errors   int64  `json:"errors"`   // 失败次数
+	Tokens   int64  `json:"tokens"`   // token数
+}
+
+// UsageTotals 用量合计
+type UsageTotals struct {
+	Requests int64 `json:"requests"` // 请求次数
+	Errors   int64 `json:"errors"`   // 失败次数
+	Tokens   int64 `json:"tokens"`   // token数
+}
+
+// UsageResponse 用量查询响应
+type UsageResponse struct {
+	From       string                 `json:"from"`        // 起始日期
+	To         string                 `json:"to"`          // 结束日期
+	Totals     UsageTotals            `json:"totals"`      // 总计
+	ByTenant   map[string]UsageTotals `json:"by_tenant"`   // 按租户合计
+	ByProvider map[string]UsageTotals `json:"by_provider"` // 按提供商合计
+	Records    []UsageItem            `json:"records"`     // 明细
+}
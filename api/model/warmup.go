@@ -0,0 +1,32 @@
+package model
+
+// WarmupTaskInfo 单个预热任务的执行结果
+type WarmupTaskInfo struct {
+	Name       string `json:"name"`            // 任务名称
+	Done       bool   `json:"done"`            // 是否已执行完成
+	DurationMS int64  `json:"duration_ms"`     // 执行耗时（毫秒）
+	Error      string `json:"error,omitempty"` // 失败原因
+}
+
+// ReadinessResponse 就绪探针响应
+type ReadinessResponse struct {
+	Ready bool             `json:"ready"`           // 是否已就绪
+	Tasks []WarmupTaskInfo `json:"tasks,omitempty"` // 启动预热任务，未启用预热时为空
+}
+
+// DependencyStatus 单个依赖的检查结果
+type DependencyStatus struct {
+	Name      string `json:"name"`            // 依赖名称
+	Status    string `json:"status"`          // up或down
+	Critical  bool   `json:"critical"`        // 是否为关键依赖
+	LatencyMS int64  `json:"latency_ms"`      // 检查耗时（毫秒）
+	Error     string `json:"error,omitempty"` // 不可用的原因
+}
+
+// HealthResponse 健康检查和就绪探针响应
+type HealthResponse struct {
+	Status       string             `json:"status"`                 // ok表示关键依赖均可用，否则为degraded
+	Ready        bool               `json:"ready"`                  // 是否已就绪
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"` // 各依赖的检查结果
+	Tasks        []WarmupTaskInfo   `json:"tasks,omitempty"`        // 启动预热任务，未启用预热时为空
+}
@@ -0,0 +1,1564 @@
+// 文档问答系统的gRPC接口定义，与REST接口共用同一套服务层
+// 修改后在仓库根目录重新生成代码：
+//   protoc -I api/proto --go_out=. --go_opt=module=github.com/fyerfyer/doc-QA-system \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/fyerfyer/doc-QA-system \
+//     api/proto/docqa/v1/docqa.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: docqa/v1/docqa.proto
+
+package docqav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Document struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`      // uploaded、processing、completed或failed
+	Progress      int32                  `protobuf:"varint,4,opt,name=progress,proto3" json:"progress,omitempty"` // 处理进度（0-100）
+	Segments      int32                  `protobuf:"varint,5,opt,name=segments,proto3" json:"segments,omitempty"`
+	Size          int64                  `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	Tags          string                 `protobuf:"bytes,7,opt,name=tags,proto3" json:"tags,omitempty"`   // 逗号分隔
+	Error         string                 `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"` // 处理失败时的错误信息
+	UploadedAt    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=uploaded_at,json=uploadedAt,proto3" json:"uploaded_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Document) Reset() {
+	*x = Document{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Document) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Document) ProtoMessage() {}
+
+func (x *Document) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Document.ProtoReflect.Descriptor instead.
+func (*Document) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Document) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *Document) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Document) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Document) GetProgress() int32 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+func (x *Document) GetSegments() int32 {
+	if x != nil {
+		return x.Segments
+	}
+	return 0
+}
+
+func (x *Document) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Document) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+func (x *Document) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Document) GetUploadedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UploadedAt
+	}
+	return nil
+}
+
+func (x *Document) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type UploadDocumentRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*UploadDocumentRequest_Info
+	//	*UploadDocumentRequest_Chunk
+	Payload       isUploadDocumentRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDocumentRequest) Reset() {
+	*x = UploadDocumentRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDocumentRequest) ProtoMessage() {}
+
+func (x *UploadDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDocumentRequest.ProtoReflect.Descriptor instead.
+func (*UploadDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UploadDocumentRequest) GetPayload() isUploadDocumentRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *UploadDocumentRequest) GetInfo() *UploadDocumentRequest_FileInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadDocumentRequest_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+func (x *UploadDocumentRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*UploadDocumentRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isUploadDocumentRequest_Payload interface {
+	isUploadDocumentRequest_Payload()
+}
+
+type UploadDocumentRequest_Info struct {
+	Info *UploadDocumentRequest_FileInfo `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+}
+
+type UploadDocumentRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*UploadDocumentRequest_Info) isUploadDocumentRequest_Payload() {}
+
+func (*UploadDocumentRequest_Chunk) isUploadDocumentRequest_Payload() {}
+
+type UploadDocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDocumentResponse) Reset() {
+	*x = UploadDocumentResponse{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDocumentResponse) ProtoMessage() {}
+
+func (x *UploadDocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDocumentResponse.ProtoReflect.Descriptor instead.
+func (*UploadDocumentResponse) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UploadDocumentResponse) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *UploadDocumentResponse) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadDocumentResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type GetDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDocumentRequest) Reset() {
+	*x = GetDocumentRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDocumentRequest) ProtoMessage() {}
+
+func (x *GetDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDocumentRequest.ProtoReflect.Descriptor instead.
+func (*GetDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetDocumentRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type ListDocumentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`                         // 从1开始，默认1
+	PageSize      int32                  `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // 默认10，最大100
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Tags          string                 `protobuf:"bytes,4,opt,name=tags,proto3" json:"tags,omitempty"`
+	StartTime     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDocumentsRequest) Reset() {
+	*x = ListDocumentsRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDocumentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDocumentsRequest) ProtoMessage() {}
+
+func (x *ListDocumentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDocumentsRequest.ProtoReflect.Descriptor instead.
+func (*ListDocumentsRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListDocumentsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListDocumentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDocumentsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListDocumentsRequest) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+func (x *ListDocumentsRequest) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *ListDocumentsRequest) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+type ListDocumentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Documents     []*Document            `protobuf:"bytes,4,rep,name=documents,proto3" json:"documents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDocumentsResponse) Reset() {
+	*x = ListDocumentsResponse{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDocumentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDocumentsResponse) ProtoMessage() {}
+
+func (x *ListDocumentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDocumentsResponse.ProtoReflect.Descriptor instead.
+func (*ListDocumentsResponse) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListDocumentsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListDocumentsResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListDocumentsResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListDocumentsResponse) GetDocuments() []*Document {
+	if x != nil {
+		return x.Documents
+	}
+	return nil
+}
+
+type UpdateDocumentTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Tags          string                 `protobuf:"bytes,2,opt,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateDocumentTagsRequest) Reset() {
+	*x = UpdateDocumentTagsRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDocumentTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDocumentTagsRequest) ProtoMessage() {}
+
+func (x *UpdateDocumentTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDocumentTagsRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDocumentTagsRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateDocumentTagsRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *UpdateDocumentTagsRequest) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+type DeleteDocumentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDocumentRequest) Reset() {
+	*x = DeleteDocumentRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDocumentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDocumentRequest) ProtoMessage() {}
+
+func (x *DeleteDocumentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDocumentRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDocumentRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteDocumentRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type DeleteDocumentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FileId        string                 `protobuf:"bytes,1,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDocumentResponse) Reset() {
+	*x = DeleteDocumentResponse{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDocumentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDocumentResponse) ProtoMessage() {}
+
+func (x *DeleteDocumentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDocumentResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDocumentResponse) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DeleteDocumentResponse) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *DeleteDocumentResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type AskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Question      string                 `protobuf:"bytes,1,opt,name=question,proto3" json:"question,omitempty"`
+	FileId        string                 `protobuf:"bytes,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`                                                                 // 只在指定文件中检索
+	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 按元数据过滤检索结果，file_id为空时生效
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskRequest) Reset() {
+	*x = AskRequest{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskRequest) ProtoMessage() {}
+
+func (x *AskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskRequest.ProtoReflect.Descriptor instead.
+func (*AskRequest) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AskRequest) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+func (x *AskRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *AskRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type AskResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Question      string                 `protobuf:"bytes,1,opt,name=question,proto3" json:"question,omitempty"`
+	Answer        string                 `protobuf:"bytes,2,opt,name=answer,proto3" json:"answer,omitempty"`
+	Sources       []*Source              `protobuf:"bytes,3,rep,name=sources,proto3" json:"sources,omitempty"`
+	Status        *AnswerStatus          `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskResponse) Reset() {
+	*x = AskResponse{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskResponse) ProtoMessage() {}
+
+func (x *AskResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskResponse.ProtoReflect.Descriptor instead.
+func (*AskResponse) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AskResponse) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+func (x *AskResponse) GetAnswer() string {
+	if x != nil {
+		return x.Answer
+	}
+	return ""
+}
+
+func (x *AskResponse) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *AskResponse) GetStatus() *AnswerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type AskStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*AskStreamResponse_Delta
+	//	*AskStreamResponse_Sources_
+	//	*AskStreamResponse_Done
+	Event         isAskStreamResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskStreamResponse) Reset() {
+	*x = AskStreamResponse{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskStreamResponse) ProtoMessage() {}
+
+func (x *AskStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskStreamResponse.ProtoReflect.Descriptor instead.
+func (*AskStreamResponse) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AskStreamResponse) GetEvent() isAskStreamResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *AskStreamResponse) GetDelta() string {
+	if x != nil {
+		if x, ok := x.Event.(*AskStreamResponse_Delta); ok {
+			return x.Delta
+		}
+	}
+	return ""
+}
+
+func (x *AskStreamResponse) GetSources() *AskStreamResponse_Sources {
+	if x != nil {
+		if x, ok := x.Event.(*AskStreamResponse_Sources_); ok {
+			return x.Sources
+		}
+	}
+	return nil
+}
+
+func (x *AskStreamResponse) GetDone() *AnswerStatus {
+	if x != nil {
+		if x, ok := x.Event.(*AskStreamResponse_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isAskStreamResponse_Event interface {
+	isAskStreamResponse_Event()
+}
+
+type AskStreamResponse_Delta struct {
+	Delta string `protobuf:"bytes,1,opt,name=delta,proto3,oneof"` // 回答片段，按顺序拼接即为完整回答
+}
+
+type AskStreamResponse_Sources_ struct {
+	Sources *AskStreamResponse_Sources `protobuf:"bytes,2,opt,name=sources,proto3,oneof"`
+}
+
+type AskStreamResponse_Done struct {
+	Done *AnswerStatus `protobuf:"bytes,3,opt,name=done,proto3,oneof"` // 最后一条消息
+}
+
+func (*AskStreamResponse_Delta) isAskStreamResponse_Event() {}
+
+func (*AskStreamResponse_Sources_) isAskStreamResponse_Event() {}
+
+func (*AskStreamResponse_Done) isAskStreamResponse_Event() {}
+
+// AnswerStatus 回答的附加状态
+type AnswerStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Confidence    *float32               `protobuf:"fixed32,1,opt,name=confidence,proto3,oneof" json:"confidence,omitempty"` // 回答与来源的一致程度，未启用一致性校验时不返回
+	LowConfidence bool                   `protobuf:"varint,2,opt,name=low_confidence,json=lowConfidence,proto3" json:"low_confidence,omitempty"`
+	Curated       bool                   `protobuf:"varint,3,opt,name=curated,proto3" json:"curated,omitempty"` // 回答来自审核通过的FAQ
+	Refusal       *Refusal               `protobuf:"bytes,4,opt,name=refusal,proto3" json:"refusal,omitempty"`  // 被护栏拦截时返回
+	Review        *ReviewStatus          `protobuf:"bytes,5,opt,name=review,proto3" json:"review,omitempty"`    // 回答进入人工审核时返回，此时回答为空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AnswerStatus) Reset() {
+	*x = AnswerStatus{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AnswerStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnswerStatus) ProtoMessage() {}
+
+func (x *AnswerStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnswerStatus.ProtoReflect.Descriptor instead.
+func (*AnswerStatus) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *AnswerStatus) GetConfidence() float32 {
+	if x != nil && x.Confidence != nil {
+		return *x.Confidence
+	}
+	return 0
+}
+
+func (x *AnswerStatus) GetLowConfidence() bool {
+	if x != nil {
+		return x.LowConfidence
+	}
+	return false
+}
+
+func (x *AnswerStatus) GetCurated() bool {
+	if x != nil {
+		return x.Curated
+	}
+	return false
+}
+
+func (x *AnswerStatus) GetRefusal() *Refusal {
+	if x != nil {
+		return x.Refusal
+	}
+	return nil
+}
+
+func (x *AnswerStatus) GetReview() *ReviewStatus {
+	if x != nil {
+		return x.Review
+	}
+	return nil
+}
+
+type Source struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Text        string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	FileId      string                 `protobuf:"bytes,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Filename    string                 `protobuf:"bytes,3,opt,name=filename,proto3" json:"filename,omitempty"`
+	Position    int32                  `protobuf:"varint,4,opt,name=position,proto3" json:"position,omitempty"`
+	SegmentId   string                 `protobuf:"bytes,5,opt,name=segment_id,json=segmentId,proto3" json:"segment_id,omitempty"`
+	Link        string                 `protobuf:"bytes,6,opt,name=link,proto3" json:"link,omitempty"`
+	Page        int32                  `protobuf:"varint,7,opt,name=page,proto3" json:"page,omitempty"`
+	Section     string                 `protobuf:"bytes,8,opt,name=section,proto3" json:"section,omitempty"`
+	HeadingPath string                 `protobuf:"bytes,9,opt,name=heading_path,json=headingPath,proto3" json:"heading_path,omitempty"`
+	Location    string                 `protobuf:"bytes,10,opt,name=location,proto3" json:"location,omitempty"` // 可读的引用位置，如"第12页，3.2 配置"
+	// 引用信息，范围按字符计算且相对text
+	Citations     []int32     `protobuf:"varint,11,rep,packed,name=citations,proto3" json:"citations,omitempty"` // 回答中引用该来源的标记编号
+	Spans         []*TextSpan `protobuf:"bytes,12,rep,name=spans,proto3" json:"spans,omitempty"`                 // 实际提供给大模型的文本范围
+	Highlights    []*TextSpan `protobuf:"bytes,13,rep,name=highlights,proto3" json:"highlights,omitempty"`       // 支撑回答的文本范围
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Source) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Source) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *Source) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Source) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *Source) GetSegmentId() string {
+	if x != nil {
+		return x.SegmentId
+	}
+	return ""
+}
+
+func (x *Source) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *Source) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *Source) GetSection() string {
+	if x != nil {
+		return x.Section
+	}
+	return ""
+}
+
+func (x *Source) GetHeadingPath() string {
+	if x != nil {
+		return x.HeadingPath
+	}
+	return ""
+}
+
+func (x *Source) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Source) GetCitations() []int32 {
+	if x != nil {
+		return x.Citations
+	}
+	return nil
+}
+
+func (x *Source) GetSpans() []*TextSpan {
+	if x != nil {
+		return x.Spans
+	}
+	return nil
+}
+
+func (x *Source) GetHighlights() []*TextSpan {
+	if x != nil {
+		return x.Highlights
+	}
+	return nil
+}
+
+type TextSpan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int32                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextSpan) Reset() {
+	*x = TextSpan{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TextSpan) ProtoMessage() {}
+
+func (x *TextSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TextSpan.ProtoReflect.Descriptor instead.
+func (*TextSpan) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TextSpan) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *TextSpan) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type Refusal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stage         string                 `protobuf:"bytes,1,opt,name=stage,proto3" json:"stage,omitempty"` // input或output
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Refusal) Reset() {
+	*x = Refusal{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Refusal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Refusal) ProtoMessage() {}
+
+func (x *Refusal) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Refusal.ProtoReflect.Descriptor instead.
+func (*Refusal) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Refusal) GetStage() string {
+	if x != nil {
+		return x.Stage
+	}
+	return ""
+}
+
+func (x *Refusal) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type ReviewStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DraftId       string                 `protobuf:"bytes,1,opt,name=draft_id,json=draftId,proto3" json:"draft_id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Topic         string                 `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewStatus) Reset() {
+	*x = ReviewStatus{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewStatus) ProtoMessage() {}
+
+func (x *ReviewStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewStatus.ProtoReflect.Descriptor instead.
+func (*ReviewStatus) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReviewStatus) GetDraftId() string {
+	if x != nil {
+		return x.DraftId
+	}
+	return ""
+}
+
+func (x *ReviewStatus) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ReviewStatus) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+type UploadDocumentRequest_FileInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"` // 支持.pdf、.md、.markdown、.txt
+	Tags          string                 `protobuf:"bytes,2,opt,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDocumentRequest_FileInfo) Reset() {
+	*x = UploadDocumentRequest_FileInfo{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDocumentRequest_FileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDocumentRequest_FileInfo) ProtoMessage() {}
+
+func (x *UploadDocumentRequest_FileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDocumentRequest_FileInfo.ProtoReflect.Descriptor instead.
+func (*UploadDocumentRequest_FileInfo) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *UploadDocumentRequest_FileInfo) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *UploadDocumentRequest_FileInfo) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+type AskStreamResponse_Sources struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sources       []*Source              `protobuf:"bytes,1,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AskStreamResponse_Sources) Reset() {
+	*x = AskStreamResponse_Sources{}
+	mi := &file_docqa_v1_docqa_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AskStreamResponse_Sources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AskStreamResponse_Sources) ProtoMessage() {}
+
+func (x *AskStreamResponse_Sources) ProtoReflect() protoreflect.Message {
+	mi := &file_docqa_v1_docqa_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AskStreamResponse_Sources.ProtoReflect.Descriptor instead.
+func (*AskStreamResponse_Sources) Descriptor() ([]byte, []int) {
+	return file_docqa_v1_docqa_proto_rawDescGZIP(), []int{11, 0}
+}
+
+func (x *AskStreamResponse_Sources) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+var File_docqa_v1_docqa_proto protoreflect.FileDescriptor
+
+const file_docqa_v1_docqa_proto_rawDesc = "" +
+	"\n" +
+	"\x14docqa/v1/docqa.proto\x12\bdocqa.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc5\x02\n" +
+	"\bDocument\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1a\n" +
+	"\bprogress\x18\x04 \x01(\x05R\bprogress\x12\x1a\n" +
+	"\bsegments\x18\x05 \x01(\x05R\bsegments\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\x03R\x04size\x12\x12\n" +
+	"\x04tags\x18\a \x01(\tR\x04tags\x12\x14\n" +
+	"\x05error\x18\b \x01(\tR\x05error\x12;\n" +
+	"\vuploaded_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"uploadedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xb6\x01\n" +
+	"\x15UploadDocumentRequest\x12>\n" +
+	"\x04info\x18\x01 \x01(\v2(.docqa.v1.UploadDocumentRequest.FileInfoH\x00R\x04info\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunk\x1a:\n" +
+	"\bFileInfo\x12\x1a\n" +
+	"\bfilename\x18\x01 \x01(\tR\bfilename\x12\x12\n" +
+	"\x04tags\x18\x02 \x01(\tR\x04tagsB\t\n" +
+	"\apayload\"e\n" +
+	"\x16UploadDocumentResponse\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"-\n" +
+	"\x12GetDocumentRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\"\xe5\x01\n" +
+	"\x14ListDocumentsRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x12\n" +
+	"\x04tags\x18\x04 \x01(\tR\x04tags\x129\n" +
+	"\n" +
+	"start_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\"\x90\x01\n" +
+	"\x15ListDocumentsResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x120\n" +
+	"\tdocuments\x18\x04 \x03(\v2\x12.docqa.v1.DocumentR\tdocuments\"H\n" +
+	"\x19UpdateDocumentTagsRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x12\n" +
+	"\x04tags\x18\x02 \x01(\tR\x04tags\"0\n" +
+	"\x15DeleteDocumentRequest\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\"K\n" +
+	"\x16DeleteDocumentResponse\x12\x17\n" +
+	"\afile_id\x18\x01 \x01(\tR\x06fileId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\"\xbe\x01\n" +
+	"\n" +
+	"AskRequest\x12\x1a\n" +
+	"\bquestion\x18\x01 \x01(\tR\bquestion\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\tR\x06fileId\x12>\n" +
+	"\bmetadata\x18\x03 \x03(\v2\".docqa.v1.AskRequest.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9d\x01\n" +
+	"\vAskResponse\x12\x1a\n" +
+	"\bquestion\x18\x01 \x01(\tR\bquestion\x12\x16\n" +
+	"\x06answer\x18\x02 \x01(\tR\x06answer\x12*\n" +
+	"\asources\x18\x03 \x03(\v2\x10.docqa.v1.SourceR\asources\x12.\n" +
+	"\x06status\x18\x04 \x01(\v2\x16.docqa.v1.AnswerStatusR\x06status\"\xda\x01\n" +
+	"\x11AskStreamResponse\x12\x16\n" +
+	"\x05delta\x18\x01 \x01(\tH\x00R\x05delta\x12?\n" +
+	"\asources\x18\x02 \x01(\v2#.docqa.v1.AskStreamResponse.SourcesH\x00R\asources\x12,\n" +
+	"\x04done\x18\x03 \x01(\v2\x16.docqa.v1.AnswerStatusH\x00R\x04done\x1a5\n" +
+	"\aSources\x12*\n" +
+	"\asources\x18\x01 \x03(\v2\x10.docqa.v1.SourceR\asourcesB\a\n" +
+	"\x05event\"\xe0\x01\n" +
+	"\fAnswerStatus\x12#\n" +
+	"\n" +
+	"confidence\x18\x01 \x01(\x02H\x00R\n" +
+	"confidence\x88\x01\x01\x12%\n" +
+	"\x0elow_confidence\x18\x02 \x01(\bR\rlowConfidence\x12\x18\n" +
+	"\acurated\x18\x03 \x01(\bR\acurated\x12+\n" +
+	"\arefusal\x18\x04 \x01(\v2\x11.docqa.v1.RefusalR\arefusal\x12.\n" +
+	"\x06review\x18\x05 \x01(\v2\x16.docqa.v1.ReviewStatusR\x06reviewB\r\n" +
+	"\v_confidence\"\x89\x03\n" +
+	"\x06Source\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\tR\x06fileId\x12\x1a\n" +
+	"\bfilename\x18\x03 \x01(\tR\bfilename\x12\x1a\n" +
+	"\bposition\x18\x04 \x01(\x05R\bposition\x12\x1d\n" +
+	"\n" +
+	"segment_id\x18\x05 \x01(\tR\tsegmentId\x12\x12\n" +
+	"\x04link\x18\x06 \x01(\tR\x04link\x12\x12\n" +
+	"\x04page\x18\a \x01(\x05R\x04page\x12\x18\n" +
+	"\asection\x18\b \x01(\tR\asection\x12!\n" +
+	"\fheading_path\x18\t \x01(\tR\vheadingPath\x12\x1a\n" +
+	"\blocation\x18\n" +
+	" \x01(\tR\blocation\x12\x1c\n" +
+	"\tcitations\x18\v \x03(\x05R\tcitations\x12(\n" +
+	"\x05spans\x18\f \x03(\v2\x12.docqa.v1.TextSpanR\x05spans\x122\n" +
+	"\n" +
+	"highlights\x18\r \x03(\v2\x12.docqa.v1.TextSpanR\n" +
+	"highlights\"2\n" +
+	"\bTextSpan\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x05R\x03end\";\n" +
+	"\aRefusal\x12\x14\n" +
+	"\x05stage\x18\x01 \x01(\tR\x05stage\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\"W\n" +
+	"\fReviewStatus\x12\x19\n" +
+	"\bdraft_id\x18\x01 \x01(\tR\adraftId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic2\x9f\x03\n" +
+	"\x0fDocumentService\x12U\n" +
+	"\x0eUploadDocument\x12\x1f.docqa.v1.UploadDocumentRequest\x1a .docqa.v1.UploadDocumentResponse(\x01\x12?\n" +
+	"\vGetDocument\x12\x1c.docqa.v1.GetDocumentRequest\x1a\x12.docqa.v1.Document\x12P\n" +
+	"\rListDocuments\x12\x1e.docqa.v1.ListDocumentsRequest\x1a\x1f.docqa.v1.ListDocumentsResponse\x12M\n" +
+	"\x12UpdateDocumentTags\x12#.docqa.v1.UpdateDocumentTagsRequest\x1a\x12.docqa.v1.Document\x12S\n" +
+	"\x0eDeleteDocument\x12\x1f.docqa.v1.DeleteDocumentRequest\x1a .docqa.v1.DeleteDocumentResponse2\x81\x01\n" +
+	"\tQAService\x122\n" +
+	"\x03Ask\x12\x14.docqa.v1.AskRequest\x1a\x15.docqa.v1.AskResponse\x12@\n" +
+	"\tAskStream\x12\x14.docqa.v1.AskRequest\x1a\x1b.docqa.v1.AskStreamResponse0\x01B^\n" +
+	"\x1ccom.github.fyerfyer.docqa.v1P\x01Z<github.com/fyerfyer/doc-QA-system/api/proto/docqa/v1;docqav1b\x06proto3"
+
+var (
+	file_docqa_v1_docqa_proto_rawDescOnce sync.Once
+	file_docqa_v1_docqa_proto_rawDescData []byte
+)
+
+func file_docqa_v1_docqa_proto_rawDescGZIP() []byte {
+	file_docqa_v1_docqa_proto_rawDescOnce.Do(func() {
+		file_docqa_v1_docqa_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_docqa_v1_docqa_proto_rawDesc), len(file_docqa_v1_docqa_proto_rawDesc)))
+	})
+	return file_docqa_v1_docqa_proto_rawDescData
+}
+
+var file_docqa_v1_docqa_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_docqa_v1_docqa_proto_goTypes = []any{
+	(*Document)(nil),                       // 0: docqa.v1.Document
+	(*UploadDocumentRequest)(nil),          // 1: docqa.v1.UploadDocumentRequest
+	(*UploadDocumentResponse)(nil),         // 2: docqa.v1.UploadDocumentResponse
+	(*GetDocumentRequest)(nil),             // 3: docqa.v1.GetDocumentRequest
+	(*ListDocumentsRequest)(nil),           // 4: docqa.v1.ListDocumentsRequest
+	(*ListDocumentsResponse)(nil),          // 5: docqa.v1.ListDocumentsResponse
+	(*UpdateDocumentTagsRequest)(nil),      // 6: docqa.v1.UpdateDocumentTagsRequest
+	(*DeleteDocumentRequest)(nil),          // 7: docqa.v1.DeleteDocumentRequest
+	(*DeleteDocumentResponse)(nil),         // 8: docqa.v1.DeleteDocumentResponse
+	(*AskRequest)(nil),                     // 9: docqa.v1.AskRequest
+	(*AskResponse)(nil),                    // 10: docqa.v1.AskResponse
+	(*AskStreamResponse)(nil),              // 11: docqa.v1.AskStreamResponse
+	(*AnswerStatus)(nil),                   // 12: docqa.v1.AnswerStatus
+	(*Source)(nil),                         // 13: docqa.v1.Source
+	(*TextSpan)(nil),                       // 14: docqa.v1.TextSpan
+	(*Refusal)(nil),                        // 15: docqa.v1.Refusal
+	(*ReviewStatus)(nil),                   // 16: docqa.v1.ReviewStatus
+	(*UploadDocumentRequest_FileInfo)(nil), // 17: docqa.v1.UploadDocumentRequest.FileInfo
+	nil,                                    // 18: docqa.v1.AskRequest.MetadataEntry
+	(*AskStreamResponse_Sources)(nil),      // 19: docqa.v1.AskStreamResponse.Sources
+	(*timestamppb.Timestamp)(nil),          // 20: google.protobuf.Timestamp
+}
+var file_docqa_v1_docqa_proto_depIdxs = []int32{
+	20, // 0: docqa.v1.Document.uploaded_at:type_name -> google.protobuf.Timestamp
+	20, // 1: docqa.v1.Document.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 2: docqa.v1.UploadDocumentRequest.info:type_name -> docqa.v1.UploadDocumentRequest.FileInfo
+	20, // 3: docqa.v1.ListDocumentsRequest.start_time:type_name -> google.protobuf.Timestamp
+	20, // 4: docqa.v1.ListDocumentsRequest.end_time:type_name -> google.protobuf.Timestamp
+	0,  // 5: docqa.v1.ListDocumentsResponse.documents:type_name -> docqa.v1.Document
+	18, // 6: docqa.v1.AskRequest.metadata:type_name -> docqa.v1.AskRequest.MetadataEntry
+	13, // 7: docqa.v1.AskResponse.sources:type_name -> docqa.v1.Source
+	12, // 8: docqa.v1.AskResponse.status:type_name -> docqa.v1.AnswerStatus
+	19, // 9: docqa.v1.AskStreamResponse.sources:type_name -> docqa.v1.AskStreamResponse.Sources
+	12, // 10: docqa.v1.AskStreamResponse.done:type_name -> docqa.v1.AnswerStatus
+	15, // 11: docqa.v1.AnswerStatus.refusal:type_name -> docqa.v1.Refusal
+	16, // 12: docqa.v1.AnswerStatus.review:type_name -> docqa.v1.ReviewStatus
+	14, // 13: docqa.v1.Source.spans:type_name -> docqa.v1.TextSpan
+	14, // 14: docqa.v1.Source.highlights:type_name -> docqa.v1.TextSpan
+	13, // 15: docqa.v1.AskStreamResponse.Sources.sources:type_name -> docqa.v1.Source
+	1,  // 16: docqa.v1.DocumentService.UploadDocument:input_type -> docqa.v1.UploadDocumentRequest
+	3,  // 17: docqa.v1.DocumentService.GetDocument:input_type -> docqa.v1.GetDocumentRequest
+	4,  // 18: docqa.v1.DocumentService.ListDocuments:input_type -> docqa.v1.ListDocumentsRequest
+	6,  // 19: docqa.v1.DocumentService.UpdateDocumentTags:input_type -> docqa.v1.UpdateDocumentTagsRequest
+	7,  // 20: docqa.v1.DocumentService.DeleteDocument:input_type -> docqa.v1.DeleteDocumentRequest
+	9,  // 21: docqa.v1.QAService.Ask:input_type -> docqa.v1.AskRequest
+	9,  // 22: docqa.v1.QAService.AskStream:input_type -> docqa.v1.AskRequest
+	2,  // 23: docqa.v1.DocumentService.UploadDocument:output_type -> docqa.v1.UploadDocumentResponse
+	0,  // 24: docqa.v1.DocumentService.GetDocument:output_type -> docqa.v1.Document
+	5,  // 25: docqa.v1.DocumentService.ListDocuments:output_type -> docqa.v1.ListDocumentsResponse
+	0,  // 26: docqa.v1.DocumentService.UpdateDocumentTags:output_type -> docqa.v1.Document
+	8,  // 27: docqa.v1.DocumentService.DeleteDocument:output_type -> docqa.v1.DeleteDocumentResponse
+	10, // 28: docqa.v1.QAService.Ask:output_type -> docqa.v1.AskResponse
+	11, // 29: docqa.v1.QAService.AskStream:output_type -> docqa.v1.AskStreamResponse
+	23, // [23:30] is the sub-list for method output_type
+	16, // [16:23] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_docqa_v1_docqa_proto_init() }
+func file_docqa_v1_docqa_proto_init() {
+	if File_docqa_v1_docqa_proto != nil {
+		return
+	}
+	file_docqa_v1_docqa_proto_msgTypes[1].OneofWrappers = []any{
+		(*UploadDocumentRequest_Info)(nil),
+		(*UploadDocumentRequest_Chunk)(nil),
+	}
+	file_docqa_v1_docqa_proto_msgTypes[11].OneofWrappers = []any{
+		(*AskStreamResponse_Delta)(nil),
+		(*AskStreamResponse_Sources_)(nil),
+		(*AskStreamResponse_Done)(nil),
+	}
+	file_docqa_v1_docqa_proto_msgTypes[12].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_docqa_v1_docqa_proto_rawDesc), len(file_docqa_v1_docqa_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_docqa_v1_docqa_proto_goTypes,
+		DependencyIndexes: file_docqa_v1_docqa_proto_depIdxs,
+		MessageInfos:      file_docqa_v1_docqa_proto_msgTypes,
+	}.Build()
+	File_docqa_v1_docqa_proto = out.File
+	file_docqa_v1_docqa_proto_goTypes = nil
+	file_docqa_v1_docqa_proto_depIdxs = nil
+}
@@ -54,7 +54,7 @@ func setupIntegrationTest(t *testing.T) *integrationTestEnv {
 
 	// 执行数据迁移
 	err = db.AutoMigrate(&models.Document{}, &models.DocumentSegment{},
-		&models.ChatSession{}, &models.ChatMessage{})
+		&models.DocumentShare{}, &models.ChatSession{}, &models.ChatMessage{})
 	require.NoError(t, err, "Failed to run migrations")
 
 	// 保存原始数据库并替换为测试数据库
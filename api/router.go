@@ -1,26 +1,135 @@
 package api
 
 import (
+	"net/url"
+
 	"github.com/fyerfyer/doc-QA-system/api/handler"
 	"github.com/fyerfyer/doc-QA-system/api/middleware"
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
+// RouterOption 路由配置选项
+type RouterOption func(*routerOptions)
+
+// routerOptions 路由的可选配置
+type routerOptions struct {
+	limiter       ratelimit.Limiter      // 请求限流器，为空时不限流
+	limiterExempt []string               // 不限流的路径前缀
+	audit         *audit.Recorder        // 审计日志记录器，为空时不记录
+	groups        *services.GroupService // 文档组服务，聊天会话绑定文档组时使用
+	chatLLM       llm.Client             // 生成会话标题和摘要的大模型客户端，为空时不生成
+	primary       *url.URL               // 主实例地址，不为空时作为只读副本运行
+	authenticator *auth.Authenticator    // 按API密钥识别租户，为空时所有请求都是匿名的
+	proxies       []string               // 可信的反向代理，为空时不信任X-Forwarded-For
+	adminToken    string                 // 管理接口令牌，为空时拒绝所有管理请求
+}
+
+// replicaLocalRoutes 只读副本在本地处理的POST路由，问答只读取向量数据库
+var replicaLocalRoutes = []string{
+	"/api/qa",
+	"/api/qa/compare",
+	"/api/qa/batch",
+	"/api/groups/:id/qa",
+}
+
+// WithRateLimiter 按客户端（API密钥或来源IP）限制请求速率，exempt中的路径前缀不限流
+// 限流中间件是全局中间件，必须在注册路由之前应用，因此通过SetupRouter的选项启用
+func WithRateLimiter(limiter ratelimit.Limiter, exempt ...string) RouterOption {
+	return func(o *routerOptions) {
+		o.limiter = limiter
+		o.limiterExempt = exempt
+	}
+}
+
+// WithAuthenticator 设置认证器，请求携带的API密钥按登记的密钥识别租户和用户组
+func WithAuthenticator(authenticator *auth.Authenticator) RouterOption {
+	return func(o *routerOptions) {
+		o.authenticator = authenticator
+	}
+}
+
+// WithTrustedProxies 设置可信的反向代理，只有来自这些地址的X-Forwarded-For才用于识别客户端IP
+// 匿名请求按客户端IP限流，信任任意来源的X-Forwarded-For会让客户端伪造IP绕过限流
+func WithTrustedProxies(proxies []string) RouterOption {
+	return func(o *routerOptions) {
+		o.proxies = proxies
+	}
+}
+
+// WithAdminToken 设置管理接口（/api/admin下的所有路由）的令牌
+// 管理接口分散在多个路由注册函数中，令牌校验是全局中间件，未设置时拒绝所有管理请求
+func WithAdminToken(token string) RouterOption {
+	return func(o *routerOptions) {
+		o.adminToken = token
+	}
+}
+
+// WithAuditRecorder 设置审计日志记录器，记录删除聊天会话等在路由内部创建的服务的操作
+func WithAuditRecorder(recorder *audit.Recorder) RouterOption {
+	return func(o *routerOptions) {
+		o.audit = recorder
+	}
+}
+
+// WithGroupService 设置文档组服务，使聊天会话可以绑定文档组作为检索范围
+func WithGroupService(groups *services.GroupService) RouterOption {
+	return func(o *routerOptions) {
+		o.groups = groups
+	}
+}
+
+// WithChatLLM 设置生成会话标题和摘要的大模型客户端
+func WithChatLLM(client llm.Client) RouterOption {
+	return func(o *routerOptions) {
+		o.chatLLM = client
+	}
+}
+
+// WithPrimaryProxy 以只读副本运行，问答和查询在本地处理，其余写请求转发到主实例
+func WithPrimaryProxy(primary *url.URL) RouterOption {
+	return func(o *routerOptions) {
+		o.primary = primary
+	}
+}
+
 // SetupRouter 设置API路由
 // 配置所有的API端点并应用中间件
 func SetupRouter(
 	docHandler *handler.DocumentHandler,
 	qaHandler *handler.QAHandler,
+	opts ...RouterOption,
 ) *gin.Engine {
+	var options routerOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	// 创建默认的Gin路由引擎
 	router := gin.Default()
+	if err := router.SetTrustedProxies(options.proxies); err != nil {
+		middleware.GetLogger().WithError(err).Warn("Invalid trusted proxies, X-Forwarded-For is ignored")
+		router.SetTrustedProxies(nil)
+	}
 
 	// 应用全局中间件
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.SetTraceID())
+	router.Use(middleware.Tenant(options.authenticator))
+	router.Use(middleware.AdminAuth(options.adminToken))
+	if options.limiter != nil {
+		router.Use(middleware.RateLimit(options.limiter, options.limiterExempt...))
+	}
+	if options.primary != nil {
+		router.Use(middleware.ReadOnlyReplica(options.primary, replicaLocalRoutes...))
+	}
 
 	// 在调试模式下记录请求体和响应体
 	if gin.Mode() == gin.DebugMode {
@@ -29,8 +138,16 @@ func SetupRouter(
 
 	// 创建聊天处理器
 	chatRepo := repository.NewChatRepository()
-	chatService := services.NewChatService(chatRepo)
-	chatHandler := handler.NewChatHandler(chatService, qaHandler.GetQAService())
+	chatService := services.NewChatService(chatRepo,
+		services.WithChatAudit(options.audit),
+		services.WithChatLLM(options.chatLLM),
+	)
+	chatHandler := handler.NewChatHandler(chatService, qaHandler.GetQAService(),
+		handler.WithChatGuard(qaHandler.GetGuard()),
+		handler.WithChatQuota(qaHandler.GetQuota()),
+		handler.WithChatGroups(options.groups),
+		handler.WithChatModels(qaHandler.GetModels()),
+	)
 
 	// 创建API分组
 	api := router.Group("/api")
@@ -41,6 +158,16 @@ func SetupRouter(
 			// 上传文档 - POST /api/documents
 			docGroup.POST("", docHandler.UploadDocument)
 
+			// 通过网页地址导入文档 - POST /api/documents/url
+			docGroup.POST("/url", docHandler.UploadURLs)
+
+			// 大文件分片上传 - /api/documents/uploads
+			docGroup.POST("/uploads", docHandler.InitUpload)
+			docGroup.GET("/uploads/:upload_id", docHandler.GetUpload)
+			docGroup.PUT("/uploads/:upload_id/parts/:part", docHandler.UploadPart)
+			docGroup.POST("/uploads/:upload_id/complete", docHandler.CompleteUpload)
+			docGroup.DELETE("/uploads/:upload_id", docHandler.AbortUpload)
+
 			// 获取文档状态 - GET /api/documents/:id/status
 			docGroup.GET("/:id/status", docHandler.GetDocumentStatus)
 
@@ -52,6 +179,34 @@ func SetupRouter(
 
 			// 获取文档指标 - GET /api/documents/metrics
 			docGroup.GET("/metrics", docHandler.GetDocumentMetrics)
+
+			// 浏览文档段落 - GET /api/documents/:id/segments
+			docGroup.GET("/:id/segments", docHandler.ListSegments)
+
+			// 修改段落并重新向量化 - PATCH /api/documents/:id/segments/:segmentId
+			docGroup.PATCH("/:id/segments/:segmentId", docHandler.UpdateSegment)
+
+			// 根据内容建议标签 - POST /api/documents/:id/suggest-tags
+			docGroup.POST("/:id/suggest-tags", docHandler.SuggestTags)
+
+			// 更新文档标签 - PUT /api/documents/:id/tags
+			docGroup.PUT("/:id/tags", docHandler.UpdateTags)
+
+			// 设置文档是否参与问答检索 - PUT /api/documents/:id/retrieval
+			docGroup.PUT("/:id/retrieval", docHandler.SetRetrieval)
+
+			// 文档访问控制 - GET/PUT /api/documents/:id/acl
+			docGroup.GET("/:id/acl", docHandler.GetACL)
+			docGroup.PUT("/:id/acl", docHandler.UpdateACL)
+
+			// 生成文档摘要 - POST /api/documents/:id/summarize
+			docGroup.POST("/:id/summarize", docHandler.Summarize)
+
+			// 获取文档中提取的图片 - GET /api/documents/:id/images/:image_id
+			docGroup.GET("/:id/images/:image_id", docHandler.GetImage)
+
+			// 解析来源深链接 - GET /api/documents/:id/segments/:position/context
+			docGroup.GET("/:id/segments/:position/context", docHandler.GetSegmentContext)
 		}
 
 		// 问答API
@@ -59,6 +214,18 @@ func SetupRouter(
 		{
 			// 回答问题 - POST /api/qa
 			qaGroup.POST("", qaHandler.AnswerQuestion)
+
+			// 对比多个文档 - POST /api/qa/compare
+			qaGroup.POST("/compare", qaHandler.CompareDocuments)
+
+			// 批量问答 - POST /api/qa/batch
+			qaGroup.POST("/batch", qaHandler.AnswerBatch)
+
+			// 提交异步问答 - POST /api/qa/async
+			qaGroup.POST("/async", qaHandler.SubmitQuestion)
+
+			// 查询异步问答结果 - GET /api/qa/jobs/:id
+			qaGroup.GET("/jobs/:id", qaHandler.GetQuestionJob)
 		}
 
 		// 聊天API
@@ -70,6 +237,12 @@ func SetupRouter(
 			// 获取聊天会话列表 - GET /api/chats
 			chatGroup.GET("", chatHandler.ListChats)
 
+			// 搜索聊天记录 - GET /api/chats/search
+			chatGroup.GET("/search", chatHandler.SearchChats)
+
+			// 获取收藏的助手回复 - GET /api/chats/pinned
+			chatGroup.GET("/pinned", chatHandler.ListPinnedMessages)
+
 			// 创建聊天并添加消息 - POST /api/chats/with-message
 			chatGroup.POST("/with-message", chatHandler.CreateChatWithMessage)
 
@@ -79,9 +252,33 @@ func SetupRouter(
 			// 获取会话历史 - GET /api/chats/:session_id
 			chatGroup.GET("/:session_id", chatHandler.GetChatHistory)
 
-			// 更新聊天会话标题 - PATCH /api/chats/:session_id
+			// 更新聊天会话标题或系统提示词 - PATCH /api/chats/:session_id
 			chatGroup.PATCH("/:session_id", chatHandler.RenameChat)
 
+			// 设置会话绑定的文档和文档组 - PUT /api/chats/:session_id/scope
+			chatGroup.PUT("/:session_id/scope", chatHandler.SetChatScope)
+
+			// 编辑用户消息 - PATCH /api/chats/:session_id/messages/:id
+			chatGroup.PATCH("/:session_id/messages/:id", chatHandler.EditMessage)
+
+			// 重新生成助手回复 - POST /api/chats/:session_id/messages/:id/regenerate
+			chatGroup.POST("/:session_id/messages/:id/regenerate", chatHandler.RegenerateMessage)
+
+			// 获取消息历史版本 - GET /api/chats/:session_id/messages/:id/versions
+			chatGroup.GET("/:session_id/messages/:id/versions", chatHandler.ListMessageVersions)
+
+			// 收藏助手回复 - PUT /api/chats/:session_id/messages/:id/pin
+			chatGroup.PUT("/:session_id/messages/:id/pin", chatHandler.PinMessage)
+
+			// 取消收藏助手回复 - DELETE /api/chats/:session_id/messages/:id/pin
+			chatGroup.DELETE("/:session_id/messages/:id/pin", chatHandler.UnpinMessage)
+
+			// 生成会话摘要 - POST /api/chats/:session_id/summarize
+			chatGroup.POST("/:session_id/summarize", chatHandler.SummarizeChat)
+
+			// 导出会话记录 - GET /api/chats/:session_id/export
+			chatGroup.GET("/:session_id/export", chatHandler.ExportChat)
+
 			// 删除聊天会话 - DELETE /api/chats/:session_id
 			chatGroup.DELETE("/:session_id", chatHandler.DeleteChat)
 		}
@@ -115,6 +312,182 @@ func RegisterTaskRoutes(router *gin.Engine, taskHandler *handler.TaskHandler) {
 	}
 }
 
+// RegisterAdminRoutes 注册运维管理相关路由
+func RegisterAdminRoutes(router *gin.Engine, adminHandler *handler.AdminHandler) {
+	adminGroup := router.Group("/api/admin")
+	{
+		// 运维看板统计 - GET /api/admin/stats
+		adminGroup.GET("/stats", adminHandler.GetStats)
+		// 提示词变体对比统计 - GET /api/admin/prompt-variants
+		adminGroup.GET("/prompt-variants", adminHandler.ComparePromptVariants)
+		// 向量数据库配置和一致性检查 - GET /api/admin/vectordb/info
+		adminGroup.GET("/vectordb/info", adminHandler.GetVectorDBInfo)
+
+		// 审计日志 - GET /api/admin/audit
+		adminGroup.GET("/audit", adminHandler.ListAuditLogs)
+
+		// 大模型交互日志 - GET /api/admin/llm-logs
+		adminGroup.GET("/llm-logs", adminHandler.ListLLMLogs)
+
+		// 最低相似度校准结果 - GET /api/admin/calibration
+		adminGroup.GET("/calibration", adminHandler.ListCalibrations)
+		// 重新校准最低相似度 - POST /api/admin/calibration
+		adminGroup.POST("/calibration", adminHandler.Calibrate)
+
+		// 清除问答缓存 - DELETE /api/admin/cache
+		adminGroup.DELETE("/cache", adminHandler.ClearCache)
+
+		// 队列统计，供自动扩缩容使用 - GET /api/admin/queue/stats
+		adminGroup.GET("/queue/stats", adminHandler.GetQueueStats)
+
+		// 死信任务列表 - GET /api/admin/queue/dead-letter
+		adminGroup.GET("/queue/dead-letter", adminHandler.ListDeadLetter)
+
+		// 死信任务重新入队 - POST /api/admin/queue/dead-letter/:id/requeue
+		adminGroup.POST("/queue/dead-letter/:id/requeue", adminHandler.RequeueDeadLetter)
+
+		// 批量导入预先计算好的向量 - POST /api/admin/embeddings/import
+		adminGroup.POST("/embeddings/import", adminHandler.ImportEmbeddings)
+
+		// 导出向量数据库快照 - GET /api/admin/vectors/export
+		adminGroup.GET("/vectors/export", adminHandler.ExportVectors)
+
+		// 导入向量数据库快照 - POST /api/admin/vectors/import
+		adminGroup.POST("/vectors/import", adminHandler.ImportVectors)
+
+		// 备份文档元数据、段落和向量 - GET /api/admin/backup
+		adminGroup.GET("/backup", adminHandler.Backup)
+
+		// 从备份恢复 - POST /api/admin/restore
+		adminGroup.POST("/restore", adminHandler.Restore)
+
+		// 导出可移植的语料归档 - GET /api/admin/corpus/export
+		adminGroup.GET("/corpus/export", adminHandler.ExportCorpus)
+
+		// 导入语料归档 - POST /api/admin/corpus/import
+		adminGroup.POST("/corpus/import", adminHandler.ImportCorpus)
+
+		// 报告孤立的存储文件和向量 - GET /api/admin/storage/orphans
+		adminGroup.GET("/storage/orphans", adminHandler.ListOrphans)
+
+		// 清理孤立的存储文件和向量 - POST /api/admin/storage/gc
+		adminGroup.POST("/storage/gc", adminHandler.CollectGarbage)
+
+		// 定时任务列表 - GET /api/admin/jobs
+		adminGroup.GET("/jobs", adminHandler.ListJobs)
+
+		// 定时任务运行历史 - GET /api/admin/jobs/runs
+		adminGroup.GET("/jobs/runs", adminHandler.ListJobRuns)
+
+		// 手动触发定时任务 - POST /api/admin/jobs/:name/run
+		adminGroup.POST("/jobs/:name/run", adminHandler.RunJob)
+
+		// 外部来源连接器同步状态 - GET /api/admin/connectors
+		adminGroup.GET("/connectors", adminHandler.ListConnectors)
+
+		// 手动触发连接器同步 - POST /api/admin/connectors/:name/sync
+		adminGroup.POST("/connectors/:name/sync", adminHandler.SyncConnector)
+
+		// 增量读取知识库变更事件 - GET /api/admin/events
+		adminGroup.GET("/events", adminHandler.ListEvents)
+	}
+}
+
+// RegisterUsageRoutes 注册用量统计路由
+func RegisterUsageRoutes(router *gin.Engine, usageHandler *handler.UsageHandler) {
+	// 查询当前租户的用量 - GET /api/usage
+	router.GET("/api/usage", usageHandler.GetUsage)
+}
+
+// RegisterQuotaRoutes 注册租户配额路由
+func RegisterQuotaRoutes(router *gin.Engine, quotaHandler *handler.QuotaHandler) {
+	// 查询当前租户的配额和剩余量 - GET /api/quota
+	router.GET("/api/quota", quotaHandler.GetQuota)
+}
+
+// RegisterFeedbackRoutes 注册回答反馈和检索抑制审核路由
+func RegisterFeedbackRoutes(router *gin.Engine, feedbackHandler *handler.FeedbackHandler) {
+	// 标记回答来源错误 - POST /api/qa/feedback
+	router.POST("/api/qa/feedback", feedbackHandler.SubmitFeedback)
+
+	adminGroup := router.Group("/api/admin/suppressions")
+	{
+		// 检索抑制列表 - GET /api/admin/suppressions
+		adminGroup.GET("", feedbackHandler.ListSuppressions)
+
+		// 审核检索抑制 - PATCH /api/admin/suppressions/:id
+		adminGroup.PATCH("/:id", feedbackHandler.ReviewSuppression)
+	}
+}
+
+// RegisterReviewRoutes 注册回答审核队列和FAQ路由
+func RegisterReviewRoutes(router *gin.Engine, reviewHandler *handler.ReviewHandler) {
+	// 查询待审核回答的结果 - GET /api/qa/drafts/:id
+	router.GET("/api/qa/drafts/:id", reviewHandler.GetDraftResult)
+
+	// FAQ列表 - GET /api/faq
+	router.GET("/api/faq", reviewHandler.ListFAQ)
+
+	adminGroup := router.Group("/api/admin")
+	{
+		// 回答草稿审核队列 - GET /api/admin/drafts
+		adminGroup.GET("/drafts", reviewHandler.ListDrafts)
+
+		// 审核通过草稿 - POST /api/admin/drafts/:id/approve
+		adminGroup.POST("/drafts/:id/approve", reviewHandler.ApproveDraft)
+
+		// 驳回草稿 - POST /api/admin/drafts/:id/reject
+		adminGroup.POST("/drafts/:id/reject", reviewHandler.RejectDraft)
+
+		// 删除FAQ条目 - DELETE /api/admin/faq/:id
+		adminGroup.DELETE("/faq/:id", reviewHandler.DeleteFAQ)
+	}
+}
+
+// RegisterGroupRoutes 注册文档组相关路由
+func RegisterGroupRoutes(router *gin.Engine, groupHandler *handler.GroupHandler) {
+	groupGroup := router.Group("/api/groups")
+	{
+		// 创建文档组 - POST /api/groups
+		groupGroup.POST("", groupHandler.CreateGroup)
+
+		// 获取文档组列表 - GET /api/groups
+		groupGroup.GET("", groupHandler.ListGroups)
+
+		// 获取文档组详情 - GET /api/groups/:id
+		groupGroup.GET("/:id", groupHandler.GetGroup)
+
+		// 更新文档组 - PATCH /api/groups/:id
+		groupGroup.PATCH("/:id", groupHandler.UpdateGroup)
+
+		// 删除文档组 - DELETE /api/groups/:id
+		groupGroup.DELETE("/:id", groupHandler.DeleteGroup)
+
+		// 添加成员 - POST /api/groups/:id/members
+		groupGroup.POST("/:id/members", groupHandler.AddMember)
+
+		// 移除成员 - DELETE /api/groups/:id/members/:document_id
+		groupGroup.DELETE("/:id/members/:document_id", groupHandler.RemoveMember)
+
+		// 以文档组为范围问答 - POST /api/groups/:id/qa
+		groupGroup.POST("/:id/qa", groupHandler.AnswerQuestion)
+	}
+}
+
+// RegisterReadinessRoutes 注册就绪探针路由
+// /api/health只表示进程存活，/api/ready在启动预热完成后才返回200，
+// /healthz和/readyz额外返回各依赖的检查结果，供Kubernetes探针和监控面板使用
+func RegisterReadinessRoutes(router *gin.Engine, readinessHandler *handler.ReadinessHandler) {
+	// 就绪探针 - GET /api/ready
+	router.GET("/api/ready", readinessHandler.Ready)
+
+	// 依赖健康检查，依赖不可用时仍返回200 - GET /healthz
+	router.GET("/healthz", readinessHandler.Healthz)
+
+	// 依赖就绪检查，关键依赖不可用时返回503 - GET /readyz
+	router.GET("/readyz", readinessHandler.Readyz)
+}
+
 // RegisterSwagger 注册Swagger文档路由
 // TODO: 当集成Swagger文档后实现此函数
 func RegisterSwagger(router *gin.Engine) {
@@ -129,12 +502,6 @@ func RegisterWebUI(router *gin.Engine) {
 	// 示例：router.Static("/static", "./web/dist/static")
 }
 
-// RegisterRateLimiter 注册限流器
-// TODO: 当需要限流功能时实现此函数
-func RegisterRateLimiter(router *gin.Engine) {
-	// 待实现：添加API请求限流功能
-}
-
 // Cors 跨域资源共享中间件
 // 如果需要支持跨域请求，可以启用此中间件
 func Cors() gin.HandlerFunc {
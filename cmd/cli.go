@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/config"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// cliEnv 子命令使用的服务，与HTTP服务共用同一套创建逻辑
+type cliEnv struct {
+	cfg       *config.Config
+	documents *services.DocumentService
+	qa        *services.QAService
+	storage   storage.Storage
+	validator *filecheck.Validator
+	out       io.Writer
+}
+
+// cliRun 解析参数后的子命令
+type cliRun struct {
+	name string
+	// configure 在创建服务前调整配置，可为nil
+	configure func(cfg *config.Config)
+	// run 执行子命令
+	run func(ctx context.Context, env *cliEnv) error
+	// standalone 不需要数据库和文档服务的子命令，配置校验后直接执行，设置后不调用run
+	standalone func(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error
+}
+
+// cliCommand 命令行子命令
+type cliCommand struct {
+	name    string
+	usage   string // 参数格式
+	summary string // 一句话说明
+	// define 注册子命令的参数，返回解析后执行的子命令
+	define func(flags *flag.FlagSet) *cliRun
+}
+
+// cliCommands 支持的子命令，不带子命令时启动HTTP服务
+var cliCommands = []cliCommand{
+	{
+		name:    "ingest",
+		usage:   "[-tags a,b] <file|dir|glob>...",
+		summary: "上传并同步处理文档，目录递归导入允许的文件类型，glob支持**",
+		define:  defineIngest,
+	},
+	{
+		name:    "query",
+		usage:   "[-file id] [-json] <question>",
+		summary: "对文档库提问并输出回答和来源",
+		define:  defineQuery,
+	},
+	{
+		name:    "reindex",
+		usage:   "[-embedding-model name] [-all] [-dry-run]",
+		summary: "使用当前（或指定的）嵌入模型重新向量化文档",
+		define:  defineReindex,
+	},
+	{
+		name:    "export",
+		usage:   "-out backup.tar.gz",
+		summary: "把文档元数据、段落和向量导出为备份归档",
+		define:  defineExport,
+	},
+	{
+		name:    "faiss-server",
+		usage:   "[-addr 127.0.0.1:7070] [-path dir]",
+		summary: "以独立进程提供向量索引，API服务将vectordb.type设为faiss-server、path设为该服务地址",
+		define:  defineFaissServer,
+	},
+}
+
+// parseCommand 解析子命令及其参数
+func parseCommand(args []string) (*cliRun, error) {
+	for _, cmd := range cliCommands {
+		if cmd.name != args[0] {
+			continue
+		}
+		flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		flags.Usage = func() {
+			fmt.Fprintf(flags.Output(), "Usage: docqa [global flags] %s %s\n\n%s\n\n", cmd.name, cmd.usage, cmd.summary)
+			flags.PrintDefaults()
+		}
+		run := cmd.define(flags)
+		if err := flags.Parse(args[1:]); err != nil {
+			return nil, err
+		}
+		run.name = cmd.name
+		return run, nil
+	}
+	return nil, fmt.Errorf("unknown command %q, run with -h to list commands", args[0])
+}
+
+// printCommands 在帮助信息中列出子命令
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "\nCommands (without a command the HTTP server is started):")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range cliCommands {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.name, cmd.usage, cmd.summary)
+	}
+	tw.Flush()
+}
+
+// defineIngest 导入文档：校验、保存到存储并在本进程内同步处理
+func defineIngest(flags *flag.FlagSet) *cliRun {
+	tags := flags.String("tags", "", "Comma separated tags for the ingested documents")
+	return &cliRun{run: func(ctx context.Context, env *cliEnv) error {
+		if flags.NArg() == 0 {
+			return errors.New("no files given")
+		}
+		files, err := expandInputs(flags.Args(), func(name string) bool { return env.validator.CheckName(name) == nil })
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return errors.New("no matching files")
+		}
+
+		failed := 0
+		for _, file := range files {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			id, segments, err := ingestFile(ctx, env, file, *tags)
+			if err != nil {
+				failed++
+				fmt.Fprintf(env.out, "FAIL %s: %v\n", file, err)
+				continue
+			}
+			fmt.Fprintf(env.out, "OK   %s id=%s segments=%d\n", file, id, segments)
+		}
+		fmt.Fprintf(env.out, "%d ingested, %d failed\n", len(files)-failed, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d files failed", failed, len(files))
+		}
+		return nil
+	}}
+}
+
+// ingestFile 导入单个文件，流程与HTTP上传一致
+func ingestFile(ctx context.Context, env *cliEnv, file, tags string) (string, int, error) {
+	name := filepath.Base(file)
+	if err := env.validator.CheckName(name); err != nil {
+		return "", 0, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	if limit := env.cfg.Storage.MaxFileSize; limit > 0 && stat.Size() > limit {
+		return "", 0, fmt.Errorf("%w: %d bytes exceeds %d", services.ErrFileTooLarge, stat.Size(), limit)
+	}
+	if err := env.validator.Validate(ctx, name, f); err != nil {
+		return "", 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", 0, err
+	}
+
+	info, err := env.storage.Save(f, name)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := env.documents.Init(); err != nil {
+		return "", 0, err
+	}
+	statusManager := env.documents.GetStatusManager()
+	if err := statusManager.MarkAsUploaded(ctx, info.ID, name, info.Path, info.Size); err != nil {
+		return "", 0, err
+	}
+	if tags != "" {
+		doc, err := statusManager.GetDocument(ctx, info.ID)
+		if err != nil {
+			return "", 0, err
+		}
+		doc.Tags = tags
+		if err := statusManager.GetRepo().Update(doc); err != nil {
+			return "", 0, err
+		}
+	}
+
+	if err := env.documents.ProcessDocument(ctx, info.ID, info.Path); err != nil {
+		return info.ID, 0, err
+	}
+	segments, err := env.documents.CountDocumentSegments(ctx, info.ID)
+	if err != nil {
+		return info.ID, 0, err
+	}
+	return info.ID, segments, nil
+}
+
+// cliSource 回答来源
+type cliSource struct {
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+}
+
+// defineQuery 提问
+func defineQuery(flags *flag.FlagSet) *cliRun {
+	fileID := flags.String("file", "", "Only answer from the document with this ID")
+	asJSON := flags.Bool("json", false, "Print the answer and sources as JSON")
+	return &cliRun{run: func(ctx context.Context, env *cliEnv) error {
+		question := strings.TrimSpace(strings.Join(flags.Args(), " "))
+		if question == "" {
+			return errors.New("no question given")
+		}
+
+		var answer string
+		var sources []cliSource
+		var err error
+		if *fileID != "" {
+			answer, sources, err = toCLISources(env.qa.AnswerWithFile(ctx, question, *fileID))
+		} else {
+			answer, sources, err = toCLISources(env.qa.Answer(ctx, question))
+		}
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(env.out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]interface{}{"answer": answer, "sources": sources})
+		}
+		fmt.Fprintln(env.out, answer)
+		if len(sources) > 0 {
+			fmt.Fprintln(env.out, "\nSources:")
+			for i, s := range sources {
+				fmt.Fprintf(env.out, "  [%d] %s (segment %d, id=%s)\n", i+1, s.FileName, s.Position, s.FileID)
+			}
+		}
+		return nil
+	}}
+}
+
+// toCLISources 转换问答服务返回的来源
+func toCLISources(answer string, docs []vectordb.Document, err error) (string, []cliSource, error) {
+	if err != nil {
+		return "", nil, err
+	}
+	sources := make([]cliSource, 0, len(docs))
+	for _, doc := range docs {
+		sources = append(sources, cliSource{FileID: doc.FileID, FileName: doc.FileName, Position: doc.Position, Text: doc.Text})
+	}
+	return answer, sources, nil
+}
+
+// defineReindex 重新向量化文档
+// 默认只处理嵌入模型与当前模型不一致的文档，-all处理所有已完成的文档
+func defineReindex(flags *flag.FlagSet) *cliRun {
+	model := flags.String("embedding-model", "", "Embedding model to switch to, defaults to embed.model in the config")
+	all := flags.Bool("all", false, "Re-embed every completed document, not only those embedded with another model")
+	dryRun := flags.Bool("dry-run", false, "Only list the documents that would be re-embedded")
+	return &cliRun{
+		configure: func(cfg *config.Config) {
+			if *model != "" {
+				cfg.Embed.Model = *model
+			}
+		},
+		run: func(ctx context.Context, env *cliEnv) error {
+			var ids []string
+			var err error
+			if *all {
+				ids, err = completedDocumentIDs(ctx, env.documents)
+			} else {
+				ids, err = env.documents.DocumentsNeedingReembed(ctx, env.documents.EmbeddingModel())
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(env.out, "%d documents to re-embed with %s\n", len(ids), env.documents.EmbeddingModel())
+			if *dryRun {
+				for _, id := range ids {
+					fmt.Fprintln(env.out, id)
+				}
+				return nil
+			}
+
+			failed := 0
+			for i, id := range ids {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := env.documents.ReembedDocument(ctx, id); err != nil {
+					failed++
+					fmt.Fprintf(env.out, "[%d/%d] FAIL %s: %v\n", i+1, len(ids), id, err)
+					continue
+				}
+				fmt.Fprintf(env.out, "[%d/%d] OK   %s\n", i+1, len(ids), id)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d documents failed", failed, len(ids))
+			}
+			return nil
+		},
+	}
+}
+
+// completedDocumentIDs 列出所有已完成文档的ID
+func completedDocumentIDs(ctx context.Context, documents *services.DocumentService) ([]string, error) {
+	filters := map[string]interface{}{"status": models.DocStatusCompleted}
+	var ids []string
+	cursor := ""
+	for {
+		docs, next, err := documents.ListDocumentsAfter(ctx, cursor, 100, filters)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		if next == "" {
+			return ids, nil
+		}
+		cursor = next
+	}
+}
+
+// defineExport 导出备份归档，先写入临时文件，完成后再重命名，避免留下不完整的备份
+func defineExport(flags *flag.FlagSet) *cliRun {
+	out := flags.String("out", "", "Archive path (tar.gz), - writes to stdout")
+	return &cliRun{run: func(ctx context.Context, env *cliEnv) error {
+		if *out == "" {
+			return errors.New("-out is required")
+		}
+		if *out == "-" {
+			_, err := env.documents.Backup(ctx, os.Stdout)
+			return err
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(*out), filepath.Base(*out)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		manifest, err := env.documents.Backup(ctx, tmp)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), *out); err != nil {
+			return err
+		}
+		fmt.Fprintf(env.out, "exported %d documents, %d segments, %d vectors to %s\n",
+			manifest.Documents, manifest.Segments, manifest.Vectors, *out)
+		return nil
+	}}
+}
+
+// defineFaissServer 向量索引服务：加载本地faiss索引并通过HTTP提供给API服务
+// 索引内存与API服务隔离，可以部署在带GPU的机器上单独扩容。配置中的vectordb.type为faiss-server时
+// （与API服务共用配置文件）改为在-path（默认./vectordb）下使用faiss索引。
+// 服务没有认证，默认只监听本机回环地址，跨机器部署时需要显式指定-addr并限制在内网访问
+func defineFaissServer(flags *flag.FlagSet) *cliRun {
+	addr := flags.String("addr", "127.0.0.1:7070", "Listen address, the service has no authentication so bind it to an internal interface only")
+	dir := flags.String("path", "", "Index directory, defaults to vectordb.path")
+	return &cliRun{
+		configure: func(cfg *config.Config) {
+			if cfg.VectorDB.Type == "faiss-server" {
+				cfg.VectorDB.Type = "faiss"
+				cfg.VectorDB.Path = "./vectordb"
+			}
+			if *dir != "" {
+				cfg.VectorDB.Path = *dir
+			}
+		},
+		standalone: func(ctx context.Context, cfg *config.Config, logger *logrus.Logger) error {
+			repo, err := createVectorDB(cfg.VectorDB, logger)
+			if err != nil {
+				return fmt.Errorf("failed to open vector index: %w", err)
+			}
+			// 关闭时保存索引
+			defer repo.Close()
+
+			srv := &http.Server{Addr: *addr, Handler: vectordb.NewServer(repo)}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- srv.ListenAndServe()
+			}()
+			logger.Infof("Serving %s vector index at %s on %s", cfg.VectorDB.Type, cfg.VectorDB.Path, *addr)
+
+			select {
+			case err := <-errCh:
+				return err
+			case <-ctx.Done():
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		},
+	}
+}
+
+// expandInputs 展开命令行给出的文件、目录和glob
+// 目录递归查找accept接受的文件；glob中的**匹配任意层目录，如 docs/**.pdf、docs/**/*.md
+func expandInputs(args []string, accept func(name string) bool) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			stat, err := os.Stat(arg)
+			if err != nil {
+				return nil, err
+			}
+			if !stat.IsDir() {
+				add(arg)
+				continue
+			}
+			err = filepath.WalkDir(arg, func(p string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && accept(d.Name()) {
+					add(p)
+				}
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		matches, err := globFiles(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return files, nil
+}
+
+// globFiles 匹配glob，支持**跨目录匹配
+func globFiles(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(filepath.FromSlash(pattern))
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, m := range matches {
+			if stat, err := os.Stat(m); err == nil && !stat.IsDir() {
+				files = append(files, m)
+			}
+		}
+		return files, nil
+	}
+
+	// docs/**.pdf等价于docs/**/*.pdf
+	segments := strings.Split(pattern, "/")
+	var normalized []string
+	for _, seg := range segments {
+		if seg != "**" && strings.Contains(seg, "**") {
+			normalized = append(normalized, "**", strings.ReplaceAll(seg, "**", "*"))
+			continue
+		}
+		normalized = append(normalized, seg)
+	}
+
+	// 从第一个包含通配符的目录开始遍历
+	root := 0
+	for root < len(normalized) && !strings.ContainsAny(normalized[root], "*?[") {
+		root++
+	}
+	base := strings.Join(normalized[:root], "/")
+	if base == "" {
+		base = "."
+		if strings.HasPrefix(pattern, "/") {
+			base = "/"
+		}
+	}
+	rest := normalized[root:]
+
+	var files []string
+	err := filepath.WalkDir(filepath.FromSlash(base), func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, relErr := filepath.Rel(filepath.FromSlash(base), p)
+		if relErr != nil {
+			return relErr
+		}
+		if matchSegments(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchSegments 按路径段匹配，**匹配零个或多个路径段
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], parts[0])
+	return err == nil && ok && matchSegments(pattern[1:], parts[1:])
+}
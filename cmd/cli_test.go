@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCommand 测试子命令和参数解析
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantName   string
+		wantErr    error  // 期望的错误类型，为nil时只检查wantErrMsg
+		wantErrMsg string // 期望错误信息包含的内容
+		standalone bool
+	}{
+		{name: "ingest", args: []string{"ingest", "-tags", "a,b", "docs"}, wantName: "ingest"},
+		{name: "query", args: []string{"query", "-file", "doc1", "-json", "如何上传"}, wantName: "query"},
+		{name: "reindex", args: []string{"reindex", "-all", "-dry-run"}, wantName: "reindex"},
+		{name: "export", args: []string{"export", "-out", "backup.tar.gz"}, wantName: "export"},
+		{name: "faiss server", args: []string{"faiss-server", "-addr", "127.0.0.1:0"}, wantName: "faiss-server", standalone: true},
+		{name: "unknown command", args: []string{"serve"}, wantErrMsg: `unknown command "serve"`},
+		{name: "unknown flag", args: []string{"query", "-verbose", "问题"}, wantErrMsg: "flag provided but not defined: -verbose"},
+		{name: "missing flag value", args: []string{"export", "-out"}, wantErrMsg: "flag needs an argument: -out"},
+		{name: "invalid bool flag", args: []string{"reindex", "-all=maybe"}, wantErrMsg: "invalid boolean value"},
+		{name: "help", args: []string{"ingest", "-h"}, wantErr: flag.ErrHelp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := parseCommandQuiet(t, tt.args)
+			if tt.wantErr != nil || tt.wantErrMsg != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				assert.Nil(t, run)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, run.name)
+			assert.Equal(t, tt.standalone, run.standalone != nil)
+			assert.Equal(t, !tt.standalone, run.run != nil)
+		})
+	}
+}
+
+// parseCommandQuiet 解析子命令，丢弃参数错误时打印的用法说明
+func parseCommandQuiet(t *testing.T, args []string) (*cliRun, error) {
+	t.Helper()
+	stderr := os.Stderr
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	os.Stderr = devNull
+	defer func() {
+		os.Stderr = stderr
+		devNull.Close()
+	}()
+	return parseCommand(args)
+}
+
+// TestCommandArgumentErrors 测试缺少参数的子命令在使用服务之前返回错误
+func TestCommandArgumentErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"ingest without files", []string{"ingest", "-tags", "a"}, "no files given"},
+		{"query without question", []string{"query", "-file", "doc1"}, "no question given"},
+		{"query with blank question", []string{"query", " ", "  "}, "no question given"},
+		{"export without output", []string{"export"}, "-out is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := parseCommandQuiet(t, tt.args)
+			require.NoError(t, err)
+			// 未创建任何服务，参数检查之后访问服务会panic
+			err = run.run(context.Background(), &cliEnv{out: io.Discard})
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// TestCommandConfigure 测试子命令在创建服务前调整配置
+func TestCommandConfigure(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		cfg       config.Config
+		wantType  string
+		wantPath  string
+		wantModel string
+	}{
+		{
+			name:      "reindex keeps the configured model",
+			args:      []string{"reindex"},
+			cfg:       config.Config{Embed: config.EmbedConfig{Model: "text-embedding-v3"}},
+			wantModel: "text-embedding-v3",
+		},
+		{
+			name:      "reindex switches the model",
+			args:      []string{"reindex", "-embedding-model", "bge-m3"},
+			cfg:       config.Config{Embed: config.EmbedConfig{Model: "text-embedding-v3"}},
+			wantModel: "bge-m3",
+		},
+		{
+			name:     "faiss server replaces the remote index with a local one",
+			args:     []string{"faiss-server"},
+			cfg:      config.Config{VectorDB: config.VectorDBConfig{Type: "faiss-server", Path: "http://index:7070"}},
+			wantType: "faiss",
+			wantPath: "./vectordb",
+		},
+		{
+			name:     "faiss server uses the given path",
+			args:     []string{"faiss-server", "-path", "/data/index"},
+			cfg:      config.Config{VectorDB: config.VectorDBConfig{Type: "faiss", Path: "./vectordb"}},
+			wantType: "faiss",
+			wantPath: "/data/index",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := parseCommandQuiet(t, tt.args)
+			require.NoError(t, err)
+			require.NotNil(t, run.configure)
+			cfg := tt.cfg
+			run.configure(&cfg)
+			assert.Equal(t, tt.wantType, cfg.VectorDB.Type)
+			assert.Equal(t, tt.wantPath, cfg.VectorDB.Path)
+			assert.Equal(t, tt.wantModel, cfg.Embed.Model)
+		})
+	}
+}
+
+// TestExpandInputs 测试展开文件、目录和glob
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.pdf", "notes.exe", "sub/c.md", "sub/deep/d.pdf"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte("x"), 0o644))
+	}
+	accept := func(name string) bool { return filepath.Ext(name) != ".exe" }
+	join := func(names ...string) []string {
+		paths := make([]string, len(names))
+		for i, name := range names {
+			paths[i] = filepath.Join(dir, filepath.FromSlash(name))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr error
+	}{
+		{name: "file", args: join("a.md"), want: join("a.md")},
+		{name: "directory skips rejected types", args: join("."), want: join("a.md", "b.pdf", "sub/c.md", "sub/deep/d.pdf")},
+		{name: "duplicates", args: join("a.md", "a.md"), want: join("a.md")},
+		{name: "glob", args: join("*.md"), want: join("a.md")},
+		{name: "recursive glob", args: join("**/*.pdf"), want: join("b.pdf", "sub/deep/d.pdf")},
+		{name: "recursive glob shorthand", args: join("sub/**.md"), want: join("sub/c.md")},
+		{name: "no matches", args: join("*.txt"), want: nil},
+		{name: "missing file", args: join("missing.md"), wantErr: os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := expandInputs(tt.args, accept)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, files)
+		})
+	}
+}
+
+// TestMatchSegments 测试按路径段匹配glob
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern []string
+		parts   []string
+		want    bool
+	}{
+		{[]string{"*.md"}, []string{"a.md"}, true},
+		{[]string{"*.md"}, []string{"sub", "a.md"}, false},
+		{[]string{"**", "*.md"}, []string{"a.md"}, true},
+		{[]string{"**", "*.md"}, []string{"sub", "deep", "a.md"}, true},
+		{[]string{"sub", "**"}, []string{"sub"}, true},
+		{[]string{"**", "*.pdf"}, []string{"sub", "a.md"}, false},
+		{[]string{"[a-"}, []string{"a"}, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchSegments(tt.pattern, tt.parts), "%v %v", tt.pattern, tt.parts)
+	}
+}
@@ -2,30 +2,56 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/api"
+	"github.com/fyerfyer/doc-QA-system/api/grpcserver"
 	"github.com/fyerfyer/doc-QA-system/api/handler"
+	"github.com/fyerfyer/doc-QA-system/api/model"
 	"github.com/fyerfyer/doc-QA-system/config"
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
 	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/chaos"
+	"github.com/fyerfyer/doc-QA-system/internal/connector"
 	"github.com/fyerfyer/doc-QA-system/internal/database"
 	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/eval"
+	"github.com/fyerfyer/doc-QA-system/internal/events"
+	"github.com/fyerfyer/doc-QA-system/internal/health"
 	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/llmlog"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
+	"github.com/fyerfyer/doc-QA-system/internal/quota"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/scheduler"
 	"github.com/fyerfyer/doc-QA-system/internal/services"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/internal/warmup"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/ratelimit"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
 	"github.com/fyerfyer/doc-QA-system/pkg/storage"
 	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 var (
@@ -33,6 +59,9 @@ var (
 	showVersion bool
 	devMode     bool
 	logLevel    string
+	evalPath    string
+	evalKs      string
+	evalOutput  string
 	version     = "1.0.0" // 版本号，可通过构建时传入
 )
 
@@ -42,6 +71,18 @@ func init() {
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.BoolVar(&devMode, "dev", false, "Run in development mode")
 	flag.StringVar(&logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+	flag.StringVar(&evalPath, "eval", "", "Run retrieval evaluation on a golden set file (JSON or JSON Lines) and exit")
+	flag.StringVar(&evalKs, "eval-k", "1,3,5,10", "Comma separated cutoffs for recall@k and nDCG@k")
+	flag.StringVar(&evalOutput, "eval-output", "", "Write the full evaluation report as JSON to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: docqa [flags] [command [command flags] args...]\n\nFlags:\n")
+		flag.PrintDefaults()
+		printCommands(flag.CommandLine.Output())
+	}
+}
+
+func main() {
+	// 在main中而不是init中解析参数，测试二进制的-test.*参数不会被当作未知参数
 	flag.Parse()
 
 	// 显示版本信息
@@ -49,13 +90,29 @@ func init() {
 		fmt.Printf("DocQA System Version: %s\n", version)
 		os.Exit(0)
 	}
-}
 
-func main() {
 	// 初始化日志
 	logger := logrus.New()
+	logger.AddHook(requestid.Hook{})
 	setLogLevel(logger, logLevel)
 
+	// 命令行子命令：先解析参数，子命令可能在创建服务前调整配置
+	var command *cliRun
+	if flag.NArg() > 0 {
+		var err error
+		command, err = parseCommand(flag.Args())
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			logger.Fatal(err)
+		}
+		// 子命令的输出写到标准输出，未指定日志级别时只记录警告和错误
+		if !flagPassed("log-level") && command.standalone == nil {
+			logger.SetLevel(logrus.WarnLevel)
+		}
+	}
+
 	// 开发模式下启用更详细的日志
 	if devMode {
 		logger.SetLevel(logrus.DebugLevel)
@@ -71,6 +128,22 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
+	if command != nil && command.configure != nil {
+		command.configure(cfg)
+	}
+	// 启动前检查配置，一次列出所有问题
+	if err := cfg.Validate(); err != nil {
+		logger.Fatalf("Configuration check failed, fix config.yaml or the corresponding environment variables: %v", err)
+	}
+	if command != nil && command.standalone != nil {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := command.standalone(ctx, cfg, logger)
+		stop()
+		if err != nil {
+			logger.Fatalf("Command %s failed: %v", command.name, err)
+		}
+		return
+	}
 
 	// 设置数据库
 	err = setupDatabase(cfg, logger)
@@ -85,21 +158,56 @@ func main() {
 		logger.Fatalf("Failed to create storage: %v", err)
 	}
 
+	// 创建嵌入模型客户端
+	embedClient, err := createEmbeddingClient(cfg.Embed)
+	if err != nil {
+		logger.Fatalf("Failed to create embedding client: %v", err)
+	}
+	// 启动时预热嵌入模型，并按模型的实际输出校验或确定向量维度
+	if cfg.VectorDB.OnDimensionMismatch != "off" {
+		detectEmbedDimension(cfg, embedClient, logger)
+	}
+
 	// 创建向量数据库
-	vectorDB, err := createVectorDB(cfg.VectorDB)
+	vectorDB, err := createVectorDB(cfg.VectorDB, logger)
+	if errors.Is(err, vectordb.ErrInvalidDimension) {
+		// 已有索引中的向量由其他维度的模型生成，无法自动转换
+		logger.Fatalf("Existing vector index does not match the embedding model (%v); switch back to the previous embedding model, "+
+			"or remove the index and run `docqa reindex -all` to rebuild it with %d-dimensional vectors", err, cfg.VectorDB.Dim)
+	}
 	if err != nil {
 		logger.Fatalf("Failed to create vector database: %v", err)
 	}
 	defer vectorDB.Close()
+	if !cfg.VectorDB.StoreText {
+		vectorDB = vectordb.WithoutText(vectorDB)
+	}
 
-	// 创建嵌入模型客户端
-	embedClient, err := createEmbeddingClient(cfg.Embed)
-	if err != nil {
-		logger.Fatalf("Failed to create embedding client: %v", err)
+	// 按租户和提供商统计嵌入模型和大模型的用量
+	usageRepo := repository.NewUsageRepository()
+	usageRecorder := usage.NewRecorder(usageRepo, logger)
+	embedClient = usage.WrapEmbedding(embedClient, cfg.Embed.Provider, usageRecorder)
+
+	// 配置了按语言路由时，文档和问题按语言使用不同的嵌入模型
+	var embedRouter *embedding.Router
+	if len(cfg.Embed.Routes) > 0 {
+		embedRouter, err = createEmbeddingRouter(cfg.Embed, embedClient, usageRecorder)
+		if err != nil {
+			logger.Fatalf("Failed to create embedding router: %v", err)
+		}
+		embedClient = embedRouter
 	}
 
-	// 创建大语言模型客户端
-	llmClient, err := createLLMClient(cfg.LLM)
+	// 启用故障注入时包装各依赖
+	injector := createChaosInjector(cfg.Chaos)
+	if injector.Enabled() {
+		logger.Warn("Chaos mode enabled, dependency failures will be injected")
+		vectorDB = chaos.WrapRepository(vectorDB, injector)
+		embedClient = chaos.WrapEmbedding(embedClient, injector)
+	}
+
+	// 创建大语言模型客户端，故障注入作用于每个提供商以便验证故障转移
+	llmClient, err := createLLMClient(cfg.LLM, injector, usageRecorder, logger)
 	if err != nil {
 		logger.Fatalf("Failed to create LLM client: %v", err)
 	}
@@ -113,70 +221,409 @@ func main() {
 		})
 	}
 
+	// 配置了可选模型时按请求选择模型，每个模型分别统计用量
+	var models *llm.ModelRouter
+	if len(cfg.LLM.Models) > 0 {
+		models, err = createModelRouter(cfg.LLM, llmClient, injector, usageRecorder)
+		if err != nil {
+			logger.Fatalf("Failed to create LLM models: %v", err)
+		}
+		llmClient = models
+		logger.Infof("LLM models available per request: %v", models.Models())
+	}
+
+	// 记录大模型交互日志，缓存命中的调用不记录
+	interactionLog, llmLogRepo, err := setupLLMLog(cfg.LLMLog, logger)
+	if err != nil {
+		logger.Fatalf("Failed to setup LLM interaction log: %v", err)
+	}
+	llmClient = llmlog.WrapLLM(llmClient, interactionLog)
+
+	// 按请求预算限制大模型调用，包装在故障转移之外、响应缓存之内
+	llmClient = llm.NewBudgetedClient(llmClient)
+
+	// 缓存确定性内部调用的模型响应，避免为相同的工作重复付费
+	if cfg.LLM.Memoize {
+		llmClient = llm.NewMemoizedClient(llmClient, cacheService, cfg.LLM.MemoizeTTL)
+	}
+
 	// 创建RAG服务
-	ragService := createRAGService(llmClient)
+	ragService := createRAGService(llmClient, cfg.LLM)
 
 	// 创建文档仓储
 	docRepo := repository.NewDocumentRepository()
+	groupRepo := repository.NewDocumentGroupRepository()
 
 	// 创建文档状态管理器
 	statusManager := services.NewDocumentStatusManager(docRepo, logger)
 
+	// 创建知识库变更事件总线，子命令不发布事件
+	var eventBus *events.Bus
+	var eventLog *events.MemoryPublisher
+	if cfg.Events.Enable && command == nil {
+		eventBus, eventLog, err = setupEvents(cfg.Events, logger)
+		if err != nil {
+			logger.Fatalf("Failed to setup event publishers: %v", err)
+		}
+		statusManager.SetEventBus(eventBus)
+	}
+
 	// 创建任务队列（如果启用了异步处理）
-	var taskQueue taskqueue.Queue
-	if cfg.Queue.Enable {
-		taskQueue, err = setupTaskQueue(cfg.Queue, logger)
+	// 子命令在本进程内同步处理文档，不连接任务队列
+	var taskQueue, rawQueue taskqueue.Queue
+	if cfg.Queue.Enable && command == nil {
+		rawQueue, err = setupTaskQueue(cfg.Queue, logger)
 		if err != nil {
 			logger.Fatalf("Failed to setup task queue: %v", err)
 		}
+		taskQueue = chaos.WrapQueue(rawQueue, injector)
 		logger.Info("Task queue initialized successfully")
 	}
 
 	// 创建文档分段器配置
 	splitterCfg := document.DefaultSplitterConfig()
 	splitterCfg.ChunkSize = cfg.Document.ChunkSize
-	splitterCfg.ChunkOverlap = cfg.Document.ChunkOverlap
+	splitterCfg.Overlap = cfg.Document.ChunkOverlap
+	if cfg.Document.SplitType != "" {
+		splitterCfg.SplitType = cfg.Document.SplitType
+	}
+	if cfg.Document.BreakpointThreshold > 0 {
+		splitterCfg.BreakpointThreshold = cfg.Document.BreakpointThreshold
+	}
 
-	// 创建文档分段器
-	splitter := document.NewTextSplitter(splitterCfg)
+	// 创建文档分段器，本地语义分块使用与检索相同的嵌入模型
+	var splitter document.Splitter
+	if splitterCfg.SplitType == document.SplitTypeSemantic && cfg.Document.LocalSemantic {
+		splitter = document.NewSemanticSplitter(embedClient, splitterCfg)
+	} else {
+		splitter, err = document.NewTextSplitter(splitterCfg)
+		if err != nil {
+			logger.Fatalf("Failed to create text splitter: %v", err)
+		}
+	}
+
+	// 审计日志：记录删除文档、修改标签、清除缓存和删除会话等操作
+	auditRecorder := audit.NewRecorder(repository.NewAuditRepository(), logger)
 
 	// 创建文档服务
+	// Python服务连接配置，文档服务、回答依据校验和健康检查共用
+	pyConfig := newPyServiceConfig(cfg.PythonService)
+
+	// 文档图片提取：由视觉模型为嵌入的图片生成描述
+	imageCaptioner, err := newImageCaptioner(cfg)
+	if err != nil {
+		logger.Fatalf("Failed to create image captioner: %v", err)
+	}
+
+	// 上传文件校验：类型允许列表、内容识别、加密PDF和病毒扫描
+	// 压缩包和邮件中展开的文件使用同一个校验器
+	uploadValidator := newUploadValidator(cfg.Storage, logger)
+
+	documentOptions := []services.DocumentOption{
+		services.WithLogger(logger),
+		services.WithDocumentRepository(docRepo),
+		services.WithGroupRepository(groupRepo),
+		services.WithDocumentAudit(auditRecorder),
+		services.WithTagSuggester(llmClient),
+		services.WithDocumentSummarizer(llmClient),
+		services.WithImageCaptioner(imageCaptioner),
+		services.WithImageLimits(cfg.Document.Images.MinBytes, cfg.Document.Images.MaxPerDocument),
+		services.WithFileValidator(uploadValidator),
+		services.WithStatusManager(statusManager),
+		services.WithBatchSize(cfg.Embed.BatchSize),
+		services.WithEmbedParallelism(cfg.Embed.Parallelism),
+		services.WithNativeWorker(cfg.Queue.Worker == "go"),
+		services.WithPythonService(pyConfig),
+		services.WithGCMinAge(cfg.Storage.GCMinAge),
+	}
+	if cfg.Document.Dedup.Enable {
+		documentOptions = append(documentOptions,
+			services.WithDuplicateDetection(cfg.Document.Dedup.Mode, cfg.Document.Dedup.MaxDistance))
+		logger.Infof("Near-duplicate segment detection enabled (mode: %s)", cfg.Document.Dedup.Mode)
+	}
+	if cfg.Document.Clean.Enable {
+		cleaner, err := document.NewCleaner(cfg.Document.Clean.Rules, cfg.Document.Clean.Patterns, cfg.Document.Clean.MinRepeats)
+		if err != nil {
+			logger.Fatalf("Failed to create text cleaner: %v", err)
+		}
+		documentOptions = append(documentOptions, services.WithTextCleaner(cleaner))
+		logger.Infof("Text cleaning enabled (rules: %s)", strings.Join(cfg.Document.Clean.Rules, ", "))
+	}
+	// 启用文档访问控制时在文档列表、文档读取和问答检索中检查权限
+	var accessPolicy *acl.Policy
+	if cfg.ACL.Enable {
+		accessPolicy = acl.NewPolicy(acl.WithAdmins(cfg.ACL.Admins...))
+		documentOptions = append(documentOptions, services.WithAccessPolicy(accessPolicy))
+		logger.Info("Document access control enabled")
+	}
 	documentService := services.NewDocumentService(
 		fileStorage,
 		nil, // 使用ParserFactory
 		splitter,
 		embedClient,
 		vectorDB,
-		services.WithLogger(logger),
-		services.WithDocumentRepository(docRepo),
-		services.WithStatusManager(statusManager),
-		services.WithBatchSize(cfg.Embed.BatchSize),
+		documentOptions...,
 	)
 
+	if cfg.ACL.Enable && cfg.Server.Role != "replica" {
+		migrateOwnerlessDocuments(documentService, cfg.ACL, logger)
+	}
+
 	// 如果启用了任务队列，则启用异步处理
 	if cfg.Queue.Enable && taskQueue != nil {
 		documentService.EnableAsyncProcessing(taskQueue)
 		logger.Info("Async document processing enabled")
 	}
 
+	// 创建回答反馈服务，被多次标记错误的来源段落在同类问题的检索中降权
+	feedbackService := services.NewFeedbackService(
+		repository.NewFeedbackRepository(),
+		embedClient,
+		vectorDB,
+		services.WithFeedbackLogger(logger),
+		services.WithFeedbackCache(cacheService),
+		services.WithSuppressThreshold(cfg.Feedback.SuppressThreshold),
+		services.WithClusterSimilarity(cfg.Feedback.ClusterSimilarity),
+		services.WithSuppressPenalty(cfg.Feedback.SuppressPenalty),
+	)
+
+	// 记录问答耗时和缓存命中情况，供运维看板统计
+	statsService := services.NewStatsService(repository.NewStatsRepository(), vectorDB, services.WithStatsLogger(logger))
+
 	// 创建问答服务
+	qaServiceOptions := []services.QAOption{
+		services.WithQALogger(logger),
+		services.WithStatsRecorder(statsService),
+		services.WithQAAudit(auditRecorder),
+		services.WithQAEvents(eventBus),
+		services.WithCacheTTL(time.Duration(cfg.Cache.TTL) * time.Second),
+		services.WithStaleWhileRevalidate(time.Duration(cfg.Cache.RefreshAfter) * time.Second),
+		services.WithSearchLimit(cfg.Search.Limit),
+		services.WithMinScore(cfg.Search.MinScore),
+		services.WithRetrievalLimits(cfg.Search.MaxLimit, cfg.Search.MaxMMRCandidates),
+		services.WithSuppressor(feedbackService),
+		services.WithSegmentText(docRepo, cfg.VectorDB.TextCacheSize),
+		services.WithCommandHandler(services.CommandSummarize, documentService.SummaryCommand()),
+		services.WithRequestLimits(llm.RequestLimits{
+			MaxCalls:     cfg.LLM.MaxCallsPerRequest,
+			MaxToolSteps: cfg.LLM.MaxToolSteps,
+			MaxTokens:    cfg.LLM.MaxTokensPerRequest,
+		}),
+		services.WithGeneration(cfg.LLM.MaxTokens, cfg.LLM.Temperature),
+		services.WithGenerationLimits(generationLimits(cfg.LLM)),
+	}
+	if cfg.RateLimit.QAPerClient > 0 || cfg.RateLimit.QAMaxConcurrent > 0 {
+		qaServiceOptions = append(qaServiceOptions,
+			services.WithConcurrencyLimit(cfg.RateLimit.QAPerClient, cfg.RateLimit.QAMaxConcurrent))
+	}
+	qaServiceOptions = append(qaServiceOptions,
+		services.WithBatchLimits(cfg.RateLimit.QABatchMax, cfg.RateLimit.QABatchWorkers))
+	if embedRouter != nil {
+		qaServiceOptions = append(qaServiceOptions, services.WithLanguageRouting(embedRouter))
+	}
+	if cfg.Search.MMREnabled {
+		qaServiceOptions = append(qaServiceOptions, services.WithMMR(cfg.Search.MMRLambda, cfg.Search.MMRCandidates))
+	}
+	if cfg.Search.QueryRewrite {
+		qaServiceOptions = append(qaServiceOptions, services.WithQueryRewrite(cfg.Search.RewriteCount))
+	}
+	if cfg.Search.MergeAdjacent {
+		qaServiceOptions = append(qaServiceOptions, services.WithAdjacentMerge())
+	}
+	if b := cfg.Search.Boost; b.Enable {
+		qaServiceOptions = append(qaServiceOptions, services.WithRankBoost(services.RankBoost{
+			Decay:         b.Decay,
+			HalfLife:      b.HalfLife,
+			RecencyWeight: b.RecencyWeight,
+			DateKey:       b.DateKey,
+			CollectionKey: b.CollectionKey,
+			Collections:   b.Collections,
+		}))
+	}
+	if cfg.Search.ParentWindow > 0 {
+		qaServiceOptions = append(qaServiceOptions, services.WithParentExpansion(docRepo, cfg.Search.ParentWindow))
+	}
+	// 标记为不检索的文档和请求中排除的标签在检索时过滤
+	qaServiceOptions = append(qaServiceOptions, services.WithExclusions(docRepo))
+	if accessPolicy != nil {
+		qaServiceOptions = append(qaServiceOptions, services.WithAccessControl(accessPolicy, docRepo))
+	}
+	if cfg.Search.AutoMinScore {
+		calibrator := services.NewScoreCalibrator(repository.NewCalibrationRepository(),
+			services.WithCalibrationPercentile(cfg.Search.CalibrationPercentile),
+			services.WithCalibrationQuestions(cfg.Search.CalibrationQuestions),
+			services.WithCalibrationLogger(logger))
+		qaServiceOptions = append(qaServiceOptions, services.WithScoreCalibration(calibrator))
+	}
+	if cfg.Intent.Classifier == "llm" {
+		classifier, err := newLLMClassifier(cfg, llmClient, logger)
+		if err != nil {
+			logger.Fatalf("Failed to create intent classifier: %v", err)
+		}
+		qaServiceOptions = append(qaServiceOptions, services.WithIntentClassifier(classifier))
+	}
+	if cfg.Grounding.Enable {
+		verifier, err := newGroundingVerifier(cfg.Grounding, pyConfig, llmClient)
+		if err != nil {
+			logger.Fatalf("Failed to create grounding verifier: %v", err)
+		}
+		qaServiceOptions = append(qaServiceOptions,
+			services.WithGroundingCheck(verifier, cfg.Grounding.MinConfidence, cfg.Grounding.Fallback))
+	}
+	if len(cfg.LLM.PromptVariants) > 0 {
+		variants := make([]services.PromptVariant, 0, len(cfg.LLM.PromptVariants))
+		for _, v := range cfg.LLM.PromptVariants {
+			variants = append(variants, services.PromptVariant{
+				Name:          v.Name,
+				Weight:        v.Weight,
+				Template:      v.Template,
+				EmptyTemplate: v.EmptyTemplate,
+			})
+		}
+		experiment, err := services.NewPromptExperiment(variants)
+		if err != nil {
+			logger.Fatalf("Failed to create prompt experiment: %v", err)
+		}
+		qaServiceOptions = append(qaServiceOptions, services.WithPromptExperiment(experiment))
+		logger.Infof("Prompt experiment enabled with %d variants", len(variants))
+	}
 	qaService := services.NewQAService(
 		embedClient,
 		vectorDB,
 		llmClient,
 		ragService,
 		cacheService,
-		services.WithCacheTTL(time.Duration(cfg.Cache.TTL)*time.Second),
-		services.WithSearchLimit(cfg.Search.Limit),
-		services.WithMinScore(cfg.Search.MinScore),
+		qaServiceOptions...,
 	)
+	statusManager.OnDocumentsChanged(qaService.MarkDocumentsChanged)
+
+	// 检索评估模式：用当前的检索配置跑黄金集，输出指标后退出
+	if evalPath != "" {
+		if err := runEvaluation(qaService, evalPath, evalKs, evalOutput); err != nil {
+			logger.Fatalf("Retrieval evaluation failed: %v", err)
+		}
+		return
+	}
+
+	// 命令行子命令：复用上面创建的服务执行后退出
+	if command != nil {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := command.run(ctx, &cliEnv{
+			cfg:       cfg,
+			documents: documentService,
+			qa:        qaService,
+			storage:   fileStorage,
+			validator: uploadValidator,
+			out:       os.Stdout,
+		})
+		stop()
+		if err != nil {
+			// 退出前关闭向量数据库，保存已导入的向量
+			vectorDB.Close()
+			database.Close()
+			logger.Fatalf("Command %s failed: %v", command.name, err)
+		}
+		return
+	}
+
+	// 设置回答来源的深链接模板
+	model.SetSourceLinkTemplate(cfg.Server.SourceLinkTemplate)
+
+	// 启用租户配额时在上传和问答处理器中检查配额
+	var quotaManager *quota.Manager
+	if cfg.Quota.Enable {
+		quotaManager = newQuotaManager(cfg.Quota, logger)
+	}
 
 	// 创建API处理器
-	docHandler := handler.NewDocumentHandler(documentService, fileStorage)
-	qaHandler := handler.NewQAHandler(qaService)
+	uploadService, err := services.NewUploadService(fileStorage, cfg.Storage.StagingPath,
+		services.WithUploadPartSize(cfg.Storage.UploadPartSize),
+		services.WithMaxUploadSize(cfg.Storage.MaxFileSize),
+		services.WithUploadExpiry(cfg.Storage.UploadExpiry),
+		services.WithUploadValidator(uploadValidator),
+		services.WithUploadLogger(logger),
+	)
+	if err != nil {
+		logger.Fatalf("Failed to create upload service: %v", err)
+	}
+	docHandler := handler.NewDocumentHandler(documentService, fileStorage,
+		handler.WithDocumentQuota(quotaManager),
+		handler.WithMaxFileSize(cfg.Storage.MaxFileSize),
+		handler.WithUploadService(uploadService),
+		handler.WithUploadValidator(uploadValidator),
+	)
+	qaOptions := []handler.QAHandlerOption{handler.WithQuota(quotaManager), handler.WithModels(models)}
+	var reviewService *services.ReviewService
+	if cfg.Review.Enable {
+		// 命中审核话题的回答先保存为草稿，人工审核通过后才发布
+		reviewService = services.NewReviewService(
+			repository.NewReviewRepository(),
+			vectorDB,
+			services.WithReviewLogger(logger),
+			services.WithReviewTopics(cfg.Review.Topics),
+			services.WithReviewAccessPolicy(accessPolicy),
+			services.WithReviewDocuments(documentService),
+		)
+		qaOptions = append(qaOptions, handler.WithReviewService(reviewService))
+	}
+	var guard *services.GuardService
+	if cfg.Guardrail.Enable {
+		guard, err = newGuardService(cfg.Guardrail, llmClient, logger)
+		if err != nil {
+			logger.Fatalf("Failed to create guardrail: %v", err)
+		}
+		qaOptions = append(qaOptions, handler.WithGuard(guard))
+	}
+	if cfg.Translation.Enable {
+		qaOptions = append(qaOptions, handler.WithTranslator(services.NewTranslationService(llmClient,
+			services.WithIndexLanguage(cfg.Translation.IndexLanguage),
+			services.WithTranslateByDefault(cfg.Translation.Default),
+			services.WithTranslationLogger(logger),
+		)))
+	}
+	// 启用任务队列时支持异步问答，耗时较长的问题由问答worker执行
+	var qaJobs *services.QAJobService
+	if taskQueue != nil {
+		qaJobs = services.NewQAJobService(qaService, taskQueue,
+			services.WithQAJobGuard(guard),
+			services.WithQAJobLogger(logger),
+		)
+		qaOptions = append(qaOptions, handler.WithQAJobs(qaJobs))
+	}
+	qaHandler := handler.NewQAHandler(qaService, qaOptions...)
 
 	// 设置路由
-	router := api.SetupRouter(docHandler, qaHandler)
+	// 文档组服务，供文档组路由和聊天会话范围使用
+	groupService := services.NewGroupService(groupRepo, docRepo, services.WithGroupLogger(logger))
+
+	// gRPC接口与REST接口使用同一组API密钥、限流器和配额
+	authenticator := createAuthenticator(cfg.Auth)
+	grpcOptions := []grpcserver.Option{grpcserver.WithAuthenticator(authenticator), grpcserver.WithQuota(quotaManager)}
+	routerOptions := []api.RouterOption{
+		api.WithAuthenticator(authenticator),
+		api.WithTrustedProxies(cfg.Server.TrustedProxies),
+		api.WithAdminToken(cfg.Auth.AdminToken),
+		api.WithAuditRecorder(auditRecorder),
+		api.WithGroupService(groupService),
+		api.WithChatLLM(llmClient),
+	}
+	if cfg.RateLimit.Enable {
+		limiter, err := createRateLimiter(cfg.RateLimit, cfg.Queue)
+		if err != nil {
+			logger.Warnf("Failed to create %s rate limiter, using in-memory rate limiter: %v", cfg.RateLimit.Backend, err)
+			limiter = ratelimit.NewMemoryLimiter(ratelimit.Config{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst, MaxKeys: cfg.RateLimit.MaxClients})
+		}
+		routerOptions = append(routerOptions, api.WithRateLimiter(limiter, cfg.RateLimit.ExemptPaths...))
+		grpcOptions = append(grpcOptions, grpcserver.WithRateLimiter(limiter))
+	}
+	// 只读副本将写请求转发到主实例，地址已在配置校验时检查
+	replica := cfg.Server.Role == "replica"
+	if replica {
+		primary, _ := url.Parse(cfg.Server.PrimaryURL)
+		routerOptions = append(routerOptions, api.WithPrimaryProxy(primary))
+		logger.Infof("Running as read-only replica, write requests are forwarded to %s", cfg.Server.PrimaryURL)
+	}
+	router := api.SetupRouter(docHandler, qaHandler, routerOptions...)
 
 	// 注册任务回调路由
 	if cfg.Queue.Enable {
@@ -185,6 +632,99 @@ func main() {
 		logger.Info("Task callback routes registered")
 	}
 
+	// 使用Go worker时在本进程内消费文档处理任务，只读副本不处理文档
+	var documentWorker taskqueue.Worker
+	if cfg.Queue.Enable && cfg.Queue.Worker == "go" && !replica {
+		documentWorker, err = setupDocumentWorker(rawQueue, cfg.Queue, documentService, logger)
+		if err != nil {
+			logger.Fatalf("Failed to start document worker: %v", err)
+		}
+	}
+
+	// 在本进程内执行异步问答，问答只读取向量数据库，只读副本同样可以执行
+	var qaWorker taskqueue.Worker
+	if qaJobs != nil {
+		qaWorker, err = setupQAWorker(rawQueue, cfg.Queue, qaJobs, logger)
+		if err != nil {
+			logger.Fatalf("Failed to start QA worker: %v", err)
+		}
+	}
+
+	// 创建外部来源连接器，只读副本不同步
+	var connectorService *services.ConnectorService
+	if len(cfg.Connectors) > 0 && !replica {
+		connectorService, err = setupConnectors(cfg.Connectors, documentService, groupService, logger)
+		if err != nil {
+			logger.Fatalf("Failed to setup connectors: %v", err)
+		}
+	}
+
+	// 启动定时任务：重新抓取网页文档、嵌入模型变更后重新向量化、定时同步外部来源，只读副本不运行
+	var jobScheduler *scheduler.Scheduler
+	var maintenanceWorker taskqueue.Worker
+	if (cfg.Scheduler.Enable || hasConnectorSchedule(cfg.Connectors)) && !replica {
+		jobScheduler, err = setupScheduler(cfg.Scheduler, cfg.Connectors, connectorService, documentService, taskQueue, logger)
+		if err != nil {
+			logger.Fatalf("Failed to setup scheduler: %v", err)
+		}
+		if cfg.Scheduler.Enable && rawQueue != nil {
+			maintenanceWorker, err = setupMaintenanceWorker(rawQueue, cfg.Queue, documentService, logger)
+			if err != nil {
+				logger.Fatalf("Failed to start maintenance worker: %v", err)
+			}
+		}
+		jobScheduler.Start()
+	}
+
+	// 注册运维管理路由，未启用任务队列时队列统计接口返回501
+	adminHandler := handler.NewAdminHandler(taskQueue,
+		handler.WithAdminDocumentService(documentService),
+		handler.WithAdminScheduler(jobScheduler),
+		handler.WithAdminStats(statsService),
+		handler.WithAdminQAService(qaService),
+		handler.WithAdminAudit(auditRecorder),
+		handler.WithAdminLLMLogs(llmLogRepo),
+		handler.WithAdminConnectors(connectorService),
+		handler.WithAdminEvents(eventBus, eventLog),
+		handler.WithScalingThresholds(taskqueue.ScalingThresholds{
+			MaxLag:     cfg.Queue.ScaleMaxLag,
+			MaxBacklog: cfg.Queue.ScaleMaxBacklog,
+		}),
+	)
+	// 管理接口可以备份恢复、导入导出向量和清除缓存，未配置令牌时不注册
+	if cfg.Auth.AdminToken != "" {
+		api.RegisterAdminRoutes(router, adminHandler)
+	} else {
+		logger.Warn("auth.admin_token is not set, admin routes under /api/admin are disabled")
+	}
+
+	// 注册用量统计路由
+	api.RegisterUsageRoutes(router, handler.NewUsageHandler(usageRepo))
+
+	// 注册回答反馈路由
+	api.RegisterFeedbackRoutes(router, handler.NewFeedbackHandler(feedbackService))
+
+	// 注册回答审核路由
+	if reviewService != nil {
+		api.RegisterReviewRoutes(router, handler.NewReviewHandler(reviewService))
+	}
+
+	// 注册文档组路由
+	api.RegisterGroupRoutes(router, handler.NewGroupHandler(groupService, qaService, handler.WithGroupQuota(quotaManager)))
+
+	// 注册租户配额路由
+	if quotaManager != nil {
+		api.RegisterQuotaRoutes(router, handler.NewQuotaHandler(quotaManager))
+	}
+
+	// 启动预热：服务开始监听后在后台预热，完成前就绪探针返回503
+	var warmer *warmup.Warmer
+	if cfg.Warmup.Enable {
+		warmer = setupWarmer(cfg.Warmup, vectorDB, embedClient, llmClient, qaService, logger)
+	}
+	checker := setupHealthChecker(cfg.Health, pyConfig, vectorDB, taskQueue, fileStorage)
+	api.RegisterReadinessRoutes(router, handler.NewReadinessHandler(warmer, handler.WithHealthChecker(checker)))
+
 	// 配置HTTP服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	srv := &http.Server{
@@ -200,12 +740,30 @@ func main() {
 		}
 	}()
 
+	// 启动gRPC服务器，与REST接口共用同一套服务层；gRPC写请求无法转发，只读副本不启动
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort > 0 && replica {
+		logger.Warn("gRPC server is disabled on read-only replicas")
+	} else if cfg.Server.GRPCPort > 0 {
+		grpcServer, err = startGRPCServer(cfg.Server, documentService, fileStorage, qaService, reviewService, guard, uploadValidator, logger, grpcOptions...)
+		if err != nil {
+			logger.Fatalf("Failed to start gRPC server: %v", err)
+		}
+	}
+
+	warmupCtx, cancelWarmup := context.WithCancel(context.Background())
+	defer cancelWarmup()
+	if warmer != nil {
+		warmer.Start(warmupCtx)
+	}
+
 	// 等待中断信号优雅关闭服务器
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	cancelWarmup()
 
 	// 设置关闭超时上下文
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -214,10 +772,40 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if grpcServer != nil {
+		stopGRPCServer(ctx, grpcServer)
+	}
+
+	if jobScheduler != nil {
+		jobScheduler.Stop()
+	}
+	if documentWorker != nil {
+		documentWorker.Stop()
+	}
+	if qaWorker != nil {
+		qaWorker.Stop()
+	}
+	if maintenanceWorker != nil {
+		maintenanceWorker.Stop()
+	}
+	if err := eventBus.Close(ctx); err != nil {
+		logger.Warnf("Failed to close event publishers: %v", err)
+	}
 
 	logger.Info("Server exited")
 }
 
+// flagPassed 判断命令行是否显式指定了参数
+func flagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
 // 设置日志级别
 func setLogLevel(logger *logrus.Logger, level string) {
 	switch level {
@@ -237,10 +825,8 @@ func setLogLevel(logger *logrus.Logger, level string) {
 // 设置数据库
 func setupDatabase(cfg *config.Config, logger *logrus.Logger) error {
 	// 默认使用SQLite
-	dbConfig := &database.Config{
-		Type: "sqlite",
-		DSN:  "data/docqa.db", // 默认数据库路径
-	}
+	dbConfig := database.DefaultConfig()
+	dbConfig.DSN = "data/docqa.db" // 默认数据库路径
 
 	// 如果配置中指定了数据库设置，则使用配置中的设置
 	if cfg.Database.Type != "" {
@@ -249,6 +835,23 @@ func setupDatabase(cfg *config.Config, logger *logrus.Logger) error {
 	if cfg.Database.DSN != "" {
 		dbConfig.DSN = cfg.Database.DSN
 	}
+	if cfg.Database.MaxOpenConns > 0 {
+		dbConfig.MaxOpenConns = cfg.Database.MaxOpenConns
+	}
+	if cfg.Database.MaxIdleConns > 0 {
+		dbConfig.MaxIdleConns = cfg.Database.MaxIdleConns
+	}
+	if cfg.Database.ConnMaxLife > 0 {
+		dbConfig.MaxLifetime = cfg.Database.ConnMaxLife
+	}
+	if cfg.Database.ConnMaxIdle > 0 {
+		dbConfig.MaxIdleTime = cfg.Database.ConnMaxIdle
+	}
+	dbConfig.ConnectRetries = cfg.Database.ConnectRetries
+	if cfg.Database.RetryInterval > 0 {
+		dbConfig.RetryInterval = cfg.Database.RetryInterval
+	}
+	dbConfig.SlowThreshold = cfg.Database.SlowThreshold
 
 	// 初始化数据库
 	return database.Setup(dbConfig, logger)
@@ -277,13 +880,26 @@ func createStorage(cfg config.StorageConfig) (storage.Storage, error) {
 }
 
 // 创建向量数据库
-func createVectorDB(cfg config.VectorDBConfig) (vectordb.Repository, error) {
+func createVectorDB(cfg config.VectorDBConfig, logger *logrus.Logger) (vectordb.Repository, error) {
 	// 创建向量数据库配置
 	vectorConfig := vectordb.Config{
 		Type:              cfg.Type,
 		Path:              cfg.Path,
 		Dimension:         cfg.Dim,
 		CreateIfNotExists: true,
+
+		IndexType:          cfg.IndexType,
+		IVFLists:           cfg.IVFLists,
+		HNSWM:              cfg.HNSWM,
+		HNSWEfConstruction: cfg.HNSWEfConstruction,
+		HNSWEfRuntime:      cfg.HNSWEfRuntime,
+		IVFNProbe:          cfg.IVFNProbe,
+		IVFTrainSize:       cfg.IVFTrainSize,
+		PQM:                cfg.PQM,
+		TableName:          cfg.Table,
+		QueryCacheSize:     cfg.QueryCacheSize,
+		SaveInterval:       cfg.SaveInterval,
+		Logger:             logger,
 	}
 
 	// 设置距离计算方式
@@ -324,6 +940,13 @@ func createEmbeddingClient(cfg config.EmbedConfig) (embedding.Client, error) {
 		opts = append(opts, embedding.WithDimensions(cfg.Dimensions))
 	}
 
+	// 临时故障重试和按提供商配额的客户端限流
+	opts = append(opts,
+		embedding.WithMaxRetries(cfg.MaxRetries),
+		embedding.WithRetryBackoff(cfg.RetryDelay, cfg.MaxRetryDelay),
+		embedding.WithRateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.TokensPerMinute),
+	)
+
 	// 根据提供商创建客户端
 	switch cfg.Provider {
 	case "tongyi", "dashscope":
@@ -332,24 +955,132 @@ func createEmbeddingClient(cfg config.EmbedConfig) (embedding.Client, error) {
 		return embedding.NewClient("openai", opts...)
 	case "local", "huggingface":
 		return embedding.NewClient("huggingface", opts...)
+	case "ollama":
+		return embedding.NewClient("ollama", opts...)
+	case "onnx":
+		return embedding.NewClient("onnx", opts...)
 	default:
 		// 默认使用通义千问
 		return embedding.NewClient("tongyi", opts...)
 	}
 }
 
+// detectEmbedDimension 预热嵌入模型并探测向量维度
+// 与vectordb.dim不一致时按vectordb.on_dimension_mismatch处理：auto以探测结果作为向量数据库的维度，fail直接退出；
+// 配置了embed.dimensions但与模型输出不符时无法写入向量，总是退出。
+// 嵌入服务暂时不可用时沿用配置的维度，文档处理时由重试处理
+func detectEmbedDimension(cfg *config.Config, client embedding.Client, logger *logrus.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	dim, err := embedding.DetectDimension(ctx, client)
+	switch {
+	case err != nil:
+		logger.WithError(err).Warnf("Failed to probe embedding model, using vectordb.dim %d", cfg.VectorDB.Dim)
+	case cfg.Embed.Dimensions > 0 && dim != cfg.Embed.Dimensions:
+		logger.Fatalf("Embedding model %s produces %d-dimensional vectors but embed.dimensions is %d", cfg.Embed.Model, dim, cfg.Embed.Dimensions)
+	case dim == cfg.VectorDB.Dim:
+		logger.Infof("Embedding model %s warmed up, dimension %d", cfg.Embed.Model, dim)
+	case cfg.VectorDB.OnDimensionMismatch == "fail":
+		logger.Fatalf("Embedding model %s produces %d-dimensional vectors but vectordb.dim is %d; set vectordb.dim to %d, "+
+			"or set vectordb.on_dimension_mismatch to auto", cfg.Embed.Model, dim, cfg.VectorDB.Dim, dim)
+	default:
+		logger.Infof("Detected %d-dimensional embeddings from %s, overriding vectordb.dim %d", dim, cfg.Embed.Model, cfg.VectorDB.Dim)
+		cfg.VectorDB.Dim = dim
+	}
+}
+
+// 创建按语言路由的嵌入模型客户端
+// 各路由模型沿用主模型的批处理大小和向量维度，分别统计用量
+func createEmbeddingRouter(cfg config.EmbedConfig, defaultClient embedding.Client, recorder *usage.Recorder) (*embedding.Router, error) {
+	opts := []embedding.RouterOption{embedding.WithDefaultLanguages(cfg.Languages...)}
+	for _, route := range cfg.Routes {
+		client, err := createEmbeddingClient(config.EmbedConfig{
+			Provider:   route.Provider,
+			Model:      route.Model,
+			APIKey:     route.APIKey,
+			Endpoint:   route.Endpoint,
+			BatchSize:  cfg.BatchSize,
+			Dimensions: cfg.Dimensions,
+
+			MaxRetries:    cfg.MaxRetries,
+			RetryDelay:    cfg.RetryDelay,
+			MaxRetryDelay: cfg.MaxRetryDelay,
+			RateLimit:     route.RateLimit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding client for %v: %w", route.Languages, err)
+		}
+		opts = append(opts, embedding.WithRoute(usage.WrapEmbedding(client, route.Provider, recorder), route.Languages...))
+	}
+	return embedding.NewRouter(defaultClient, opts...), nil
+}
+
 // 创建大语言模型客户端
-func createLLMClient(cfg config.LLMConfig) (llm.Client, error) {
+// 配置了备用提供商时返回按顺序故障转移的组合客户端
+// 用量统计和故障注入作用于每个提供商，分别计量各提供商的实际调用
+func createLLMClient(cfg config.LLMConfig, injector *chaos.Injector, recorder *usage.Recorder, logger *logrus.Logger) (llm.Client, error) {
+	primary, err := createLLMProvider(cfg, cfg.Provider, cfg.Model, cfg.APIKey, cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	primary = chaos.WrapLLM(usage.WrapLLM(primary, cfg.Provider, recorder), injector)
+
+	if len(cfg.Fallbacks) == 0 {
+		return primary, nil
+	}
+
+	clients := []llm.Client{primary}
+	for _, fb := range cfg.Fallbacks {
+		client, err := createLLMProvider(cfg, fb.Provider, fb.Model, fb.APIKey, fb.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback provider %s: %w", fb.Provider, err)
+		}
+		clients = append(clients, chaos.WrapLLMFallback(usage.WrapLLM(client, fb.Provider, recorder), injector))
+	}
+
+	return llm.NewCompositeClient(clients,
+		llm.WithFailureThreshold(cfg.FailureThreshold),
+		llm.WithCooldown(cfg.Cooldown),
+		llm.WithProviderTimeout(cfg.ProviderTimeout),
+		llm.WithCompositeLogger(logger),
+	)
+}
+
+// 创建按请求选择模型的客户端，默认模型为主提供商（含备用提供商）
+func createModelRouter(cfg config.LLMConfig, defaultClient llm.Client, injector *chaos.Injector, recorder *usage.Recorder) (*llm.ModelRouter, error) {
+	defaultName := cfg.Model
+	if defaultName == "" {
+		defaultName = defaultClient.Name()
+	}
+
+	models := make(map[string]llm.Client, len(cfg.Models))
+	for _, m := range cfg.Models {
+		name := m.Name
+		if name == "" {
+			name = m.Model
+		}
+		client, err := createLLMProvider(cfg, m.Provider, m.Model, m.APIKey, m.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create model %s: %w", name, err)
+		}
+		models[name] = chaos.WrapLLM(usage.WrapLLM(client, m.Provider, recorder), injector)
+	}
+	return llm.NewModelRouter(defaultName, defaultClient, models), nil
+}
+
+// 创建单个大模型提供商客户端
+func createLLMProvider(cfg config.LLMConfig, provider, model, apiKey, endpoint string) (llm.Client, error) {
 	// 设置大模型选项
 	var opts []llm.Option
-	opts = append(opts, llm.WithAPIKey(cfg.APIKey))
+	opts = append(opts, llm.WithAPIKey(apiKey))
 
-	if cfg.Endpoint != "" {
-		opts = append(opts, llm.WithBaseURL(cfg.Endpoint))
+	if endpoint != "" {
+		opts = append(opts, llm.WithBaseURL(endpoint))
 	}
 
-	if cfg.Model != "" {
-		opts = append(opts, llm.WithModel(cfg.Model))
+	if model != "" {
+		opts = append(opts, llm.WithModel(model))
 	}
 
 	if cfg.MaxTokens > 0 {
@@ -360,12 +1091,20 @@ func createLLMClient(cfg config.LLMConfig) (llm.Client, error) {
 		opts = append(opts, llm.WithTemperature(cfg.Temperature))
 	}
 
+	if len(cfg.SafetySettings) > 0 {
+		opts = append(opts, llm.WithSafetySettings(cfg.SafetySettings))
+	}
+
 	// 根据提供商创建客户端
-	switch cfg.Provider {
+	switch provider {
 	case "tongyi", "dashscope":
 		return llm.NewClient("tongyi", opts...)
 	case "openai":
 		return llm.NewClient("openai", opts...)
+	case "anthropic", "claude":
+		return llm.NewClient("anthropic", opts...)
+	case "gemini", "google":
+		return llm.NewClient("gemini", opts...)
 	default:
 		// 默认使用通义千问
 		return llm.NewClient("tongyi", opts...)
@@ -391,15 +1130,136 @@ func createCache(cfg config.CacheConfig) (cache.Cache, error) {
 	return cache.NewCache(cacheConfig)
 }
 
+// 创建租户配额管理器
+func newQuotaManager(cfg config.QuotaConfig, logger *logrus.Logger) *quota.Manager {
+	opts := []quota.Option{
+		quota.WithLogger(logger),
+		quota.WithDefaultLimits(quota.Limits{
+			MaxDocuments:     cfg.MaxDocuments,
+			MaxStorageBytes:  cfg.MaxStorageBytes,
+			MaxQACallsPerDay: cfg.MaxQACallsPerDay,
+		}),
+	}
+	for _, t := range cfg.Tenants {
+		opts = append(opts, quota.WithTenantLimits(t.Tenant, quota.Limits{
+			MaxDocuments:     t.MaxDocuments,
+			MaxStorageBytes:  t.MaxStorageBytes,
+			MaxQACallsPerDay: t.MaxQACallsPerDay,
+		}))
+	}
+	return quota.NewManager(repository.NewQuotaRepository(), opts...)
+}
+
+// 迁移没有确定上传者的文档
+// 启用访问控制前上传和匿名上传的文档不属于任何用户，按配置设为公开或归属指定租户；未配置时只提示数量
+func migrateOwnerlessDocuments(documentService *services.DocumentService, cfg config.ACLConfig, logger *logrus.Logger) {
+	ctx := context.Background()
+	if cfg.LegacyOwner == "" && !cfg.LegacyPublic {
+		count, err := documentService.CountOwnerlessDocuments(ctx)
+		if err != nil {
+			logger.Warnf("Failed to count documents without owner: %v", err)
+		} else if count > 0 {
+			logger.Warnf("%d documents have no owner and are only readable by ACL admins, set acl.legacy_owner or acl.legacy_public to migrate them", count)
+		}
+		return
+	}
+
+	count, err := documentService.AssignOwnerlessDocuments(ctx, cfg.LegacyOwner, cfg.LegacyPublic)
+	if err != nil {
+		logger.Fatalf("Failed to migrate documents without owner: %v", err)
+	}
+	if count > 0 {
+		logger.Infof("Migrated %d documents without owner (owner: %q, public: %t)", count, cfg.LegacyOwner, cfg.LegacyPublic)
+	}
+}
+
+// 创建认证器，按登记的API密钥识别租户和用户组
+func createAuthenticator(cfg config.AuthConfig) *auth.Authenticator {
+	keys := make([]auth.Key, 0, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		keys = append(keys, auth.Key{Key: key.Key, Tenant: key.Tenant, Groups: key.Groups})
+	}
+	return auth.NewAuthenticator(keys...)
+}
+
+// 创建请求限流器
+// redis后端在多个副本间共享令牌桶，未单独配置Redis地址时使用任务队列的Redis
+func createRateLimiter(cfg config.RateLimitConfig, queueCfg config.QueueConfig) (ratelimit.Limiter, error) {
+	limitConfig := ratelimit.Config{Rate: cfg.Rate, Burst: cfg.Burst, MaxKeys: cfg.MaxClients}
+	if cfg.Backend != "redis" {
+		return ratelimit.NewMemoryLimiter(limitConfig), nil
+	}
+
+	opts := &redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	}
+	if opts.Addr == "" {
+		opts.Addr = queueCfg.RedisAddr
+		opts.Password = queueCfg.RedisPassword
+		opts.DB = queueCfg.RedisDB
+	}
+
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return ratelimit.NewRedisLimiter(client, limitConfig), nil
+}
+
+// 创建故障注入器
+func createChaosInjector(cfg config.ChaosConfig) *chaos.Injector {
+	opts := []chaos.Option{
+		chaos.WithEnabled(cfg.Enable),
+		chaos.WithSeed(cfg.Seed),
+	}
+
+	for target, fault := range cfg.Faults {
+		opts = append(opts, chaos.WithFault(chaos.Target(target), chaos.Fault{
+			ErrorRate: fault.ErrorRate,
+			Latency:   fault.Latency,
+			Jitter:    fault.Jitter,
+			Ops:       fault.Ops,
+		}))
+	}
+
+	return chaos.NewInjector(opts...)
+}
+
 // 创建RAG服务
-func createRAGService(llmClient llm.Client) *llm.RAGService {
+// 配置了备用提供商时取各模型中最小的上下文窗口，保证故障转移后提示词仍然放得下
+func createRAGService(llmClient llm.Client, cfg config.LLMConfig) *llm.RAGService {
+	window := llm.ContextWindowFor(cfg.Model, cfg.ContextWindows)
+	for _, fb := range cfg.Fallbacks {
+		if fb.Model == "" {
+			continue
+		}
+		if n := llm.ContextWindowFor(fb.Model, cfg.ContextWindows); n < window {
+			window = n
+		}
+	}
+
 	return llm.NewRAG(
 		llmClient,
 		llm.WithRAGMaxTokens(2048),
 		llm.WithRAGTemperature(0.7),
+		llm.WithContextWindow(window),
+		llm.WithGenerationLimits(generationLimits(cfg)),
 	)
 }
 
+// 问答请求可以覆盖的生成参数上限
+func generationLimits(cfg config.LLMConfig) llm.GenerationLimits {
+	return llm.GenerationLimits{
+		MaxTokens:      cfg.MaxTokensLimit,
+		MaxTemperature: cfg.MaxTemperature,
+	}
+}
+
 // 设置任务队列
 func setupTaskQueue(cfg config.QueueConfig, logger *logrus.Logger) (taskqueue.Queue, error) {
 	// 创建任务队列配置
@@ -434,3 +1294,485 @@ func setupTaskQueue(cfg config.QueueConfig, logger *logrus.Logger) (taskqueue.Qu
 
 	return queue, nil
 }
+
+// 设置定时任务调度器
+// 启用任务队列时每个文档投递为一个维护任务，否则在调度器中逐个处理；
+// 配置了同步周期的连接器注册为connector:<名称>任务，不受scheduler.enable影响
+func setupScheduler(cfg config.SchedulerConfig, connectors []config.ConnectorConfig, connectorService *services.ConnectorService, documentService *services.DocumentService, queue taskqueue.Queue, logger *logrus.Logger) (*scheduler.Scheduler, error) {
+	s := scheduler.New(
+		scheduler.WithLogger(logger),
+		scheduler.WithRunRepository(repository.NewJobRunRepository()),
+	)
+
+	if cfg.Enable {
+		for _, job := range cfg.Jobs {
+			fn, err := scheduler.NewJob(job.Type, documentService, queue)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			if err := s.Register(job.Name, job.Type, job.Schedule, fn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if connectorService != nil {
+		for _, c := range connectors {
+			if c.Schedule == "" {
+				continue
+			}
+			fn := scheduler.ConnectorJob(connectorService, c.Name)
+			if err := s.Register("connector:"+c.Name, scheduler.JobTypeConnector, c.Schedule, fn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// 创建外部来源连接器和同步服务
+func setupConnectors(cfgs []config.ConnectorConfig, documentService *services.DocumentService, groupService *services.GroupService, logger *logrus.Logger) (*services.ConnectorService, error) {
+	service := services.NewConnectorService(documentService, repository.NewConnectorRepository(),
+		services.WithConnectorLogger(logger),
+		services.WithConnectorGroups(groupService),
+	)
+
+	for _, c := range cfgs {
+		conn, err := connector.New(connector.Config{
+			Type:        c.Type,
+			BaseURL:     c.BaseURL,
+			Credentials: c.Credentials,
+			Options:     c.Options,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connector %s: %w", c.Name, err)
+		}
+		service.Register(c.Name, conn, services.ConnectorMapping{GroupID: c.GroupID, Tags: c.Tags})
+		logger.Infof("Connector %s (%s) registered", c.Name, c.Type)
+	}
+
+	return service, nil
+}
+
+// 创建事件总线，内存发布者保存最近的事件供管理接口读取，其余发布者转发到外部消息系统
+func setupEvents(cfg config.EventsConfig, logger *logrus.Logger) (*events.Bus, *events.MemoryPublisher, error) {
+	memory := events.NewMemoryPublisher(cfg.MemoryCapacity)
+	publishers := []events.Publisher{memory}
+
+	for _, c := range cfg.Publishers {
+		var publisher events.Publisher
+		var err error
+		switch c.Type {
+		case "nats":
+			publisher, err = events.NewNATSPublisher(events.NATSConfig{
+				URL:      c.URL,
+				Subject:  c.Subject,
+				Token:    c.Token,
+				Username: c.Username,
+				Password: c.Password,
+				Timeout:  cfg.Timeout,
+			})
+		case "kafka":
+			publisher, err = events.NewKafkaPublisher(events.KafkaConfig{
+				URL:      c.URL,
+				Topic:    c.Subject,
+				Token:    c.Token,
+				Username: c.Username,
+				Password: c.Password,
+				Timeout:  cfg.Timeout,
+			})
+		default:
+			err = fmt.Errorf("unsupported event publisher type: %s", c.Type)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		publishers = append(publishers, publisher)
+		logger.Infof("Event publisher %s enabled", c.Type)
+	}
+
+	bus := events.NewBus(publishers,
+		events.WithBufferSize(cfg.Buffer),
+		events.WithPublishTimeout(cfg.Timeout),
+		events.WithLogger(logger),
+	)
+	return bus, memory, nil
+}
+
+// setupLLMLog 创建大模型交互日志记录器，未启用时返回nil
+// 写入数据库时同时返回仓储，用于管理接口查询
+func setupLLMLog(cfg config.LLMLogConfig, logger *logrus.Logger) (*llmlog.Logger, repository.LLMLogRepository, error) {
+	if !cfg.Enable {
+		return nil, nil, nil
+	}
+
+	redactor, err := llmlog.NewRedactor(cfg.Redact, cfg.Patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sink llmlog.Sink
+	var repo repository.LLMLogRepository
+	switch cfg.Sink {
+	case "", "database":
+		repo = repository.NewLLMLogRepository()
+		sink = llmlog.NewRepositorySink(repo, cfg.Retention)
+	case "stdout":
+		sink = llmlog.NewWriterSink(os.Stdout)
+	case "file":
+		f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open LLM log file: %w", err)
+		}
+		sink = llmlog.NewWriterSink(f)
+	default:
+		return nil, nil, fmt.Errorf("unsupported LLM log sink: %s", cfg.Sink)
+	}
+
+	logger.Infof("LLM interaction log enabled, sink=%s, redact=%v", cfg.Sink, cfg.Redact)
+	return llmlog.New(sink,
+		llmlog.WithRedactor(redactor),
+		llmlog.WithMaxChars(cfg.MaxChars),
+		llmlog.WithLogger(logger),
+	), repo, nil
+}
+
+// hasConnectorSchedule 判断是否有连接器配置了同步周期
+func hasConnectorSchedule(cfgs []config.ConnectorConfig) bool {
+	for _, c := range cfgs {
+		if c.Schedule != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// 设置文档维护任务worker，消费定时任务投递到维护队列中的任务
+func setupMaintenanceWorker(queue taskqueue.Queue, cfg config.QueueConfig, documentService *services.DocumentService, logger *logrus.Logger) (taskqueue.Worker, error) {
+	redisQueue, ok := queue.(*taskqueue.RedisQueue)
+	if !ok {
+		return nil, fmt.Errorf("maintenance worker requires a redis queue")
+	}
+
+	worker := taskqueue.NewRedisWorker(redisQueue, &taskqueue.Config{
+		Concurrency: 1, // 维护任务会重新向量化整个文档，串行执行避免占满嵌入服务配额
+		RetryLimit:  cfg.RetryLimit,
+		RetryDelay:  time.Duration(cfg.RetryDelay) * time.Second,
+		Queues:      map[string]int{taskqueue.MaintenanceQueue: 1},
+	})
+	maintenanceHandler := services.NewMaintenanceTaskHandler(documentService)
+	for _, taskType := range maintenanceHandler.GetTaskTypes() {
+		worker.RegisterHandler(taskType, maintenanceHandler)
+	}
+
+	if err := worker.Start(); err != nil {
+		return nil, err
+	}
+	logger.Info("Maintenance worker started")
+	return worker, nil
+}
+
+// 设置异步问答worker，消费问答队列中的任务
+func setupQAWorker(queue taskqueue.Queue, cfg config.QueueConfig, qaJobs *services.QAJobService, logger *logrus.Logger) (taskqueue.Worker, error) {
+	redisQueue, ok := queue.(*taskqueue.RedisQueue)
+	if !ok {
+		return nil, fmt.Errorf("qa worker requires a redis queue")
+	}
+
+	worker := taskqueue.NewRedisWorker(redisQueue, &taskqueue.Config{
+		Concurrency: cfg.Concurrency,
+		RetryLimit:  cfg.RetryLimit,
+		RetryDelay:  time.Duration(cfg.RetryDelay) * time.Second,
+		Queues:      map[string]int{taskqueue.QAQueue: 1},
+	})
+	for _, taskType := range qaJobs.GetTaskTypes() {
+		worker.RegisterHandler(taskType, qaJobs)
+	}
+
+	if err := worker.Start(); err != nil {
+		return nil, err
+	}
+	logger.Info("QA worker started")
+	return worker, nil
+}
+
+// 设置文档处理任务worker，替代Python服务完成解析、分块、向量化和存储
+func setupDocumentWorker(queue taskqueue.Queue, cfg config.QueueConfig, documentService *services.DocumentService, logger *logrus.Logger) (taskqueue.Worker, error) {
+	redisQueue, ok := queue.(*taskqueue.RedisQueue)
+	if !ok {
+		return nil, fmt.Errorf("document worker requires a redis queue")
+	}
+
+	worker := taskqueue.NewRedisWorker(redisQueue, &taskqueue.Config{
+		Concurrency: cfg.Concurrency,
+		RetryLimit:  cfg.RetryLimit,
+		RetryDelay:  time.Duration(cfg.RetryDelay) * time.Second,
+		Queues:      map[string]int{"default": 1},
+	})
+	documentHandler := services.NewDocumentTaskHandler(documentService)
+	for _, taskType := range documentHandler.GetTaskTypes() {
+		worker.RegisterHandler(taskType, documentHandler)
+	}
+
+	if err := worker.Start(); err != nil {
+		return nil, err
+	}
+	logger.Info("Document worker started")
+	return worker, nil
+}
+
+// 设置启动预热器
+// 向量索引、模型服务连接和问答缓存的预热并行执行
+func setupWarmer(cfg config.WarmupConfig, vectorDB vectordb.Repository, embedClient embedding.Client, llmClient llm.Client, qaService *services.QAService, logger *logrus.Logger) *warmup.Warmer {
+	w := warmup.New(
+		warmup.WithLogger(logger),
+		warmup.WithTimeout(cfg.Timeout),
+	)
+
+	if cfg.VectorIndex {
+		w.Add("vector_index", warmup.VectorIndexTask(vectorDB))
+	}
+	if cfg.Providers {
+		w.Add("embedding", warmup.EmbeddingTask(embedClient))
+		w.Add("llm", warmup.LLMTask(llmClient))
+	}
+	if cfg.RecentQuestions > 0 {
+		w.Add("query_cache", warmup.QueryCacheTask(qaService, cfg.RecentQuestions, cfg.Concurrency))
+	}
+
+	return w
+}
+
+// 设置依赖检查器
+// 数据库、向量数据库、存储和任务队列为关键依赖，Python服务只在报告中体现
+func setupHealthChecker(cfg config.HealthConfig, pyConfig *pyprovider.PyServiceConfig, vectorDB vectordb.Repository, queue taskqueue.Queue, fileStorage storage.Storage) *health.Checker {
+	checker := health.New(health.WithTimeout(cfg.Timeout))
+
+	checker.Register("database", database.Ping)
+	checker.Register("vectordb", func(ctx context.Context) error {
+		return vectordb.Ping(ctx, vectorDB)
+	})
+	checker.Register("storage", func(ctx context.Context) error {
+		return storage.Ping(ctx, fileStorage)
+	})
+	if pinger, ok := queue.(taskqueue.Pinger); ok {
+		checker.Register("task_queue", pinger.Ping)
+	}
+	if cfg.PythonService {
+		// 探测请求不重试也不熔断，由检查超时控制耗时
+		probe := *pyConfig
+		probe.MaxRetries = 0
+		probe.BreakerThreshold = 0
+		if client, err := pyprovider.NewClient(&probe); err == nil {
+			checker.RegisterOptional("python_service", func(ctx context.Context) error {
+				return pyprovider.Ping(ctx, client)
+			})
+		}
+	}
+
+	return checker
+}
+
+// 创建问答护栏，按配置顺序组合各审核方式
+func newGuardService(cfg config.GuardrailConfig, llmClient llm.Client, logger *logrus.Logger) (*services.GuardService, error) {
+	var moderators []moderation.Moderator
+	for _, provider := range cfg.Providers {
+		switch provider {
+		case "keyword":
+			opts := []moderation.KeywordOption{
+				moderation.WithBlockedKeywords(moderation.CategoryKeyword, cfg.BlockedKeywords),
+				moderation.WithRedactedKeywords(cfg.RedactedKeywords),
+			}
+			if len(cfg.InjectionPatterns) > 0 {
+				opts = append(opts, moderation.WithInjectionPatterns(cfg.InjectionPatterns))
+			}
+			m, err := moderation.NewKeywordModerator(opts...)
+			if err != nil {
+				return nil, err
+			}
+			moderators = append(moderators, m)
+		case "api":
+			if cfg.APIBaseURL == "" {
+				return nil, fmt.Errorf("guardrail provider api requires api_base_url")
+			}
+			moderators = append(moderators, moderation.NewAPIModerator(cfg.APIBaseURL,
+				moderation.WithAPIKey(cfg.APIKey),
+				moderation.WithAPIModel(cfg.APIModel),
+				moderation.WithAPITimeout(cfg.Timeout),
+			))
+		case "llm_judge":
+			moderators = append(moderators, moderation.NewLLMJudge(llmClient))
+		default:
+			return nil, fmt.Errorf("unknown guardrail provider: %s", provider)
+		}
+	}
+	if len(moderators) == 0 {
+		return nil, fmt.Errorf("guardrail enabled without providers")
+	}
+
+	return services.NewGuardService(
+		moderation.Chain(moderators...),
+		services.WithRefusal(cfg.Refusal),
+		services.WithFailClosed(cfg.FailClosed),
+		services.WithGuardLogger(logger),
+	), nil
+}
+
+// newPyServiceConfig 根据配置文件创建Python服务连接配置
+func newPyServiceConfig(cfg config.PythonServiceConfig) *pyprovider.PyServiceConfig {
+	pyConfig := pyprovider.DefaultConfig()
+	if cfg.BaseURL != "" {
+		pyConfig.WithBaseURL(strings.TrimRight(cfg.BaseURL, "/"))
+	}
+	if cfg.Timeout > 0 {
+		pyConfig.WithTimeout(cfg.Timeout)
+	}
+	return pyConfig.
+		WithRetry(cfg.MaxRetries, cfg.RetryDelay).
+		WithBackoff(cfg.MaxRetryDelay, cfg.RetryJitter).
+		WithTLS(cfg.EnableTLS).
+		WithAuthToken(cfg.AuthToken).
+		WithCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+}
+
+// 创建回答依据校验器
+func newGroundingVerifier(cfg config.GroundingConfig, pyConfig *pyprovider.PyServiceConfig, llmClient llm.Client) (services.GroundingVerifier, error) {
+	switch cfg.Verifier {
+	case "", "llm":
+		return services.NewLLMVerifier(llmClient), nil
+	case "nli":
+		httpClient, err := pyprovider.NewClient(pyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Python client: %w", err)
+		}
+		return services.NewNLIVerifier(pyprovider.NewLLMClient(httpClient), cfg.NLIModel), nil
+	default:
+		return nil, fmt.Errorf("unknown grounding verifier: %s", cfg.Verifier)
+	}
+}
+
+// 创建大模型问题分类器，配置了单独的分类模型时使用该模型，否则使用问答的大模型
+func newLLMClassifier(cfg *config.Config, llmClient llm.Client, logger *logrus.Logger) (*services.LLMClassifier, error) {
+	if cfg.Intent.Model == "" || cfg.Intent.Model == cfg.LLM.Model {
+		return services.NewLLMClassifier(llmClient, services.WithClassifierLogger(logger)), nil
+	}
+	client, err := createLLMProvider(cfg.LLM, cfg.LLM.Provider, cfg.Intent.Model, cfg.LLM.APIKey, cfg.LLM.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewLLMClassifier(llm.NewBudgetedClient(client), services.WithClassifierLogger(logger)), nil
+}
+
+// 创建生成图片描述的视觉模型客户端，未启用图片提取时返回nil
+// 未单独配置视觉模型提供商时沿用问答大模型的提供商、地址，以及未配置的模型和密钥
+func newImageCaptioner(cfg *config.Config) (llm.Client, error) {
+	images := cfg.Document.Images
+	if !images.Enable {
+		return nil, nil
+	}
+	provider, model, apiKey, endpoint := images.Provider, images.Model, images.APIKey, ""
+	if provider == "" {
+		provider, endpoint = cfg.LLM.Provider, cfg.LLM.Endpoint
+		if model == "" {
+			model = cfg.LLM.Model
+		}
+		if apiKey == "" {
+			apiKey = cfg.LLM.APIKey
+		}
+	}
+	client, err := createLLMProvider(cfg.LLM, provider, model, apiKey, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if !llm.SupportsVision(client) {
+		return nil, fmt.Errorf("provider %s does not support image input", provider)
+	}
+	return client, nil
+}
+
+// 创建上传文件校验器，启用病毒扫描时通过clamd扫描上传的文件
+func newUploadValidator(cfg config.StorageConfig, logger *logrus.Logger) *filecheck.Validator {
+	opts := []filecheck.Option{filecheck.WithAllowedTypes(cfg.AllowedTypes...)}
+	if cfg.VirusScan.Enable {
+		opts = append(opts, filecheck.WithScanner(filecheck.NewClamdScanner(cfg.VirusScan.Address, cfg.VirusScan.Timeout)))
+		logger.Infof("Virus scanning enabled via clamd at %s", cfg.VirusScan.Address)
+	}
+	return filecheck.New(opts...)
+}
+
+// 启动gRPC服务器，在后台监听独立端口
+func startGRPCServer(cfg config.ServerConfig, documentService *services.DocumentService, fileStorage storage.Storage,
+	qaService *services.QAService, reviewService *services.ReviewService, guard *services.GuardService,
+	validator *filecheck.Validator, logger *logrus.Logger, extra ...grpcserver.Option) (*grpc.Server, error) {
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.GRPCPort)
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpcserver.Option{grpcserver.WithLogger(logger), grpcserver.WithGuard(guard), grpcserver.WithUploadValidator(validator)}
+	if reviewService != nil {
+		opts = append(opts, grpcserver.WithReviewService(reviewService))
+	}
+	opts = append(opts, extra...)
+	srv := grpcserver.NewServer(documentService, fileStorage, qaService, opts...)
+
+	go func() {
+		logger.Infof("gRPC server starting on %s", addr)
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			logger.Fatalf("Failed to start gRPC server: %v", err)
+		}
+	}()
+	return srv, nil
+}
+
+// stopGRPCServer 等待进行中的调用结束，超时后强制关闭
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+// 运行检索评估，在标准输出打印指标，指定输出文件时写入完整的JSON报告
+func runEvaluation(retriever eval.Retriever, goldenPath, ks, outputPath string) error {
+	cutoffs, err := eval.ParseKs(ks)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cases, err := eval.LoadGoldenSet(f)
+	if err != nil {
+		return err
+	}
+
+	report, err := eval.Evaluate(context.Background(), retriever, cases, cutoffs)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+
+	if outputPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(outputPath, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
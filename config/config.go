@@ -24,12 +24,41 @@ type Config struct {
 	Document      DocumentConfig      `mapstructure:"document"`
 	Search        SearchConfig        `mapstructure:"search"`
 	PythonService PythonServiceConfig `mapstructure:"python_service"` // 新增Python服务配置
+	Chaos         ChaosConfig         `mapstructure:"chaos"`          // 故障注入配置（仅用于测试和演练）
+	Scheduler     SchedulerConfig     `mapstructure:"scheduler"`      // 定时任务配置
+	Feedback      FeedbackConfig      `mapstructure:"feedback"`       // 回答负反馈配置
+	Warmup        WarmupConfig        `mapstructure:"warmup"`         // 启动预热配置
+	Review        ReviewConfig        `mapstructure:"review"`         // 回答审核配置
+	Guardrail     GuardrailConfig     `mapstructure:"guardrail"`      // 问答护栏配置
+	Grounding     GroundingConfig     `mapstructure:"grounding"`      // 回答依据校验配置
+	Health        HealthConfig        `mapstructure:"health"`         // 依赖健康检查配置
+	RateLimit     RateLimitConfig     `mapstructure:"rate_limit"`     // 请求限流和问答并发配置
+	Quota         QuotaConfig         `mapstructure:"quota"`          // 租户配额配置
+	ACL           ACLConfig           `mapstructure:"acl"`            // 文档访问控制配置
+	Auth          AuthConfig          `mapstructure:"auth"`           // 客户端认证配置
+	Translation   TranslationConfig   `mapstructure:"translation"`    // 跨语言问答配置
+	Intent        IntentConfig        `mapstructure:"intent"`         // 问题分类配置
+	Connectors    []ConnectorConfig   `mapstructure:"connectors"`     // 外部来源连接器配置
+	Events        EventsConfig        `mapstructure:"events"`         // 知识库变更事件配置
+	LLMLog        LLMLogConfig        `mapstructure:"llm_log"`        // 大模型交互日志配置
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Host string `mapstructure:"host"` // 服务器主机
 	Port int    `mapstructure:"port"` // 服务器端口
+	// GRPCPort gRPC服务端口，为0时不启动gRPC服务
+	GRPCPort int `mapstructure:"grpc_port"`
+	// SourceLinkTemplate 来源深链接模板，支持{file_id}、{position}、{segment_id}占位符
+	SourceLinkTemplate string `mapstructure:"source_link_template"`
+	// Replicas 部署的API实例数，大于1时要求使用外部向量数据库（pgvector或redis）
+	Replicas int `mapstructure:"replicas"`
+	// Role 实例角色：primary处理所有请求；replica只在本地处理读请求和问答，其余写请求转发到主实例
+	Role string `mapstructure:"role"`
+	// PrimaryURL 主实例地址，role为replica时必填
+	PrimaryURL string `mapstructure:"primary_url"`
+	// TrustedProxies 可信的反向代理地址或网段，只有来自这些地址的X-Forwarded-For才用于识别客户端IP；为空时不信任任何代理
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // StorageConfig 存储配置
@@ -41,24 +70,111 @@ type StorageConfig struct {
 	AccessKey string `mapstructure:"access_key"`
 	SecretKey string `mapstructure:"secret_key"`
 	UseSSL    bool   `mapstructure:"use_ssl"` // 是否使用SSL
+
+	MaxFileSize    int64         `mapstructure:"max_file_size"`    // 单个文件最大字节数，0表示不限制
+	StagingPath    string        `mapstructure:"staging_path"`     // 分片上传的暂存目录
+	UploadPartSize int64         `mapstructure:"upload_part_size"` // 分片上传的分片大小
+	UploadExpiry   time.Duration `mapstructure:"upload_expiry"`    // 分片上传会话有效期，过期后清理未完成的分片
+
+	AllowedTypes []string        `mapstructure:"allowed_types"` // 允许上传的扩展名，按文件内容校验是否与扩展名一致
+	VirusScan    VirusScanConfig `mapstructure:"virus_scan"`    // 上传文件病毒扫描
+	GCMinAge     time.Duration   `mapstructure:"gc_min_age"`    // 垃圾回收时没有文档记录的文件至少存在多久才删除
+}
+
+// VirusScanConfig 病毒扫描配置，通过clamd的INSTREAM命令扫描上传的文件
+type VirusScanConfig struct {
+	Enable  bool          `mapstructure:"enable"`  // 是否启用
+	Address string        `mapstructure:"address"` // clamd地址，如 localhost:3310 或 unix:/var/run/clamav/clamd.ctl
+	Timeout time.Duration `mapstructure:"timeout"` // 单个文件的扫描超时时间
 }
 
 // VectorDBConfig 向量数据库配置
 type VectorDBConfig struct {
-	Type     string `mapstructure:"type"`     // 向量数据库类型：faiss 或 qdrant
-	Path     string `mapstructure:"path"`     // 数据库文件路径或服务器地址
+	Type     string `mapstructure:"type"`     // 向量数据库类型：faiss、memory、pgvector、redis 或 faiss-server
+	Path     string `mapstructure:"path"`     // 数据库文件路径，pgvector为Postgres连接串，redis为Redis地址，faiss-server为服务地址
 	Dim      int    `mapstructure:"dim"`      // 向量维度
 	Distance string `mapstructure:"distance"` // 距离度量方式：cosine, l2, dot
+
+	Table              string `mapstructure:"table"`                // 向量表名（pgvector）或索引名（redis）
+	IndexType          string `mapstructure:"index_type"`           // 向量索引类型：flat, hnsw, ivfflat, ivfpq
+	IVFLists           int    `mapstructure:"ivf_lists"`            // IVFFlat索引聚类中心数量
+	HNSWM              int    `mapstructure:"hnsw_m"`               // HNSW索引每个节点的最大连接数
+	HNSWEfConstruction int    `mapstructure:"hnsw_ef_construction"` // HNSW索引构建时的候选列表大小
+	HNSWEfRuntime      int    `mapstructure:"hnsw_ef_runtime"`      // HNSW索引查询时的候选列表大小（faiss、redis）
+	IVFNProbe          int    `mapstructure:"ivf_nprobe"`           // IVF索引查询时探查的聚类数量（faiss）
+	IVFTrainSize       int    `mapstructure:"ivf_train_size"`       // 训练IVF索引所需的最少向量数（faiss）
+	PQM                int    `mapstructure:"pq_m"`                 // 乘积量化的子向量数量（faiss ivfpq）
+	QueryCacheSize     int    `mapstructure:"query_cache_size"`     // 查询结果缓存的最大条目数（faiss），0使用默认值，负数禁用
+
+	SaveInterval time.Duration `mapstructure:"save_interval"` // 后台保存索引文件的间隔（faiss）
+
+	// StoreText 是否在向量数据库中保存段落文本，关闭时只保存ID和位置，回答时从段落表读取文本
+	StoreText bool `mapstructure:"store_text"`
+	// TextCacheSize 从段落表读取的热点段落文本的缓存条目数，不大于0时不缓存
+	TextCacheSize int `mapstructure:"text_cache_size"`
+
+	// 启动时探测到的嵌入模型输出维度与dim不一致时的处理：auto（按模型维度建索引）、fail（启动失败）、off（不探测）
+	OnDimensionMismatch string `mapstructure:"on_dimension_mismatch"`
 }
 
 // LLMConfig 大语言模型配置
 type LLMConfig struct {
-	Provider    string  `mapstructure:"provider"`    // 提供商：openai, ollama, etc
-	Model       string  `mapstructure:"model"`       // 模型名称
-	APIKey      string  `mapstructure:"api_key"`     // API密钥
-	Endpoint    string  `mapstructure:"endpoint"`    // API端点
-	MaxTokens   int     `mapstructure:"max_tokens"`  // 最大生成token数量
-	Temperature float32 `mapstructure:"temperature"` // 采样温度
+	Provider       string            `mapstructure:"provider"`        // 提供商：tongyi, openai, anthropic, gemini, etc
+	Model          string            `mapstructure:"model"`           // 模型名称
+	APIKey         string            `mapstructure:"api_key"`         // API密钥
+	Endpoint       string            `mapstructure:"endpoint"`        // API端点
+	MaxTokens      int               `mapstructure:"max_tokens"`      // 最大生成token数量
+	Temperature    float32           `mapstructure:"temperature"`     // 采样温度
+	SafetySettings map[string]string `mapstructure:"safety_settings"` // 安全过滤设置（Gemini），类别到阈值的映射
+
+	// 问答请求可以覆盖max_tokens和temperature，超过上限时按上限处理
+	MaxTokensLimit int     `mapstructure:"max_tokens_limit"` // 请求可设置的最大生成token数上限，0表示不限制
+	MaxTemperature float32 `mapstructure:"max_temperature"`  // 请求可设置的采样温度上限
+
+	Fallbacks        []LLMFallbackConfig   `mapstructure:"fallbacks"`         // 备用提供商，主提供商失败时按顺序切换
+	Models           []LLMModelConfig      `mapstructure:"models"`            // 除默认模型外可按请求选择的模型
+	PromptVariants   []PromptVariantConfig `mapstructure:"prompt_variants"`   // 提示词对比实验的变体，为空时不做实验
+	FailureThreshold int                   `mapstructure:"failure_threshold"` // 连续失败多少次后熔断
+	Cooldown         time.Duration         `mapstructure:"cooldown"`          // 熔断冷却时间
+	ProviderTimeout  time.Duration         `mapstructure:"provider_timeout"`  // 单个提供商的请求超时
+
+	Memoize    bool          `mapstructure:"memoize"`     // 是否缓存确定性内部调用（标题、关键词、摘要）的响应
+	MemoizeTTL time.Duration `mapstructure:"memoize_ttl"` // 响应缓存时间
+
+	// ContextWindows 各模型的上下文窗口大小（token），覆盖内置值，RAG按此裁剪检索上下文
+	ContextWindows map[string]int `mapstructure:"context_windows"`
+
+	// 单个请求的生成预算，防止多路查询、工具调用等功能在一次请求中无限制地调用大模型，0表示不限制
+	MaxCallsPerRequest  int `mapstructure:"max_calls_per_request"`  // 最多调用大模型的次数
+	MaxToolSteps        int `mapstructure:"max_tool_steps"`         // 最多执行的工具步骤数
+	MaxTokensPerRequest int `mapstructure:"max_tokens_per_request"` // 最多消耗的token总数
+}
+
+// LLMFallbackConfig 备用大模型提供商配置
+// 未设置的生成参数（max_tokens、temperature等）沿用主提供商配置
+type LLMFallbackConfig struct {
+	Provider string `mapstructure:"provider"` // 提供商
+	Model    string `mapstructure:"model"`    // 模型名称
+	APIKey   string `mapstructure:"api_key"`  // API密钥
+	Endpoint string `mapstructure:"endpoint"` // API端点
+}
+
+// LLMModelConfig 可按请求选择的大模型配置
+// 请求中的model为name时使用该模型生成回答，未设置的生成参数沿用主提供商配置，不参与故障转移
+type LLMModelConfig struct {
+	Name     string `mapstructure:"name"`     // 请求中使用的模型名称，为空时使用model
+	Provider string `mapstructure:"provider"` // 提供商
+	Model    string `mapstructure:"model"`    // 模型名称
+	APIKey   string `mapstructure:"api_key"`  // API密钥
+	Endpoint string `mapstructure:"endpoint"` // API端点
+}
+
+// PromptVariantConfig 提示词变体配置
+type PromptVariantConfig struct {
+	Name          string `mapstructure:"name"`           // 变体名称，记录在回答和反馈中
+	Weight        int    `mapstructure:"weight"`         // 流量权重，为0时不再分配新请求
+	Template      string `mapstructure:"template"`       // 有上下文时的提示词模板，支持{{.Context}}和{{.Question}}，为空时使用默认模板
+	EmptyTemplate string `mapstructure:"empty_template"` // 无上下文时的提示词模板，支持{{.Question}}，为空时使用默认模板
 }
 
 // EmbedConfig 向量嵌入模型配置
@@ -69,6 +185,37 @@ type EmbedConfig struct {
 	Endpoint   string `mapstructure:"endpoint"`   // API端点
 	BatchSize  int    `mapstructure:"batch_size"` // 批处理大小
 	Dimensions int    `mapstructure:"dimensions"` // 向量维度
+
+	// Parallelism 处理单个文档时同时进行的批量嵌入请求数，1表示串行
+	Parallelism int `mapstructure:"parallelism"`
+
+	MaxRetries    int                  `mapstructure:"max_retries"`     // 临时故障（网络错误、429、5xx）的最大重试次数
+	RetryDelay    time.Duration        `mapstructure:"retry_delay"`     // 首次重试间隔，之后按指数退避并加随机抖动
+	MaxRetryDelay time.Duration        `mapstructure:"max_retry_delay"` // 重试间隔上限，服务端Retry-After要求的等待时间不受限制
+	RateLimit     EmbedRateLimitConfig `mapstructure:"rate_limit"`      // 主模型的客户端限流
+
+	// Languages 主模型覆盖的语言（ISO 639-1代码），只在配置了other路由时用于判断哪些语言交给兜底模型
+	Languages []string `mapstructure:"languages"`
+	// Routes 按语言路由的嵌入模型，未配置时所有语言都使用主模型
+	// 各模型的向量维度必须与主模型相同，批处理大小和维度沿用主模型配置
+	Routes []EmbedRouteConfig `mapstructure:"routes"`
+}
+
+// EmbedRouteConfig 按语言路由的嵌入模型配置
+type EmbedRouteConfig struct {
+	Languages []string `mapstructure:"languages"` // 路由到该模型的语言，other表示其他所有语言
+	Provider  string   `mapstructure:"provider"`  // 提供商
+	Model     string   `mapstructure:"model"`     // 模型名称
+	APIKey    string   `mapstructure:"api_key"`   // API密钥
+	Endpoint  string   `mapstructure:"endpoint"`  // API端点
+
+	RateLimit EmbedRateLimitConfig `mapstructure:"rate_limit"` // 该提供商的客户端限流，重试参数沿用主模型配置
+}
+
+// EmbedRateLimitConfig 嵌入服务的客户端限流配置，按提供商的配额设置，0表示不限制
+type EmbedRateLimitConfig struct {
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"` // 每秒请求数
+	TokensPerMinute   int     `mapstructure:"tokens_per_minute"`   // 每分钟token数（按字符估算）
 }
 
 // CacheConfig 缓存配置
@@ -79,6 +226,9 @@ type CacheConfig struct {
 	Password string `mapstructure:"password"` // Redis密码
 	DB       int    `mapstructure:"db"`       // Redis数据库
 	TTL      int    `mapstructure:"ttl"`      // 缓存TTL（秒）
+	// RefreshAfter 缓存的回答存在超过该时长（秒）或文档有变更后，命中时仍立即返回缓存的回答，
+	// 同时在后台重新生成，0表示不刷新
+	RefreshAfter int `mapstructure:"refresh_after"`
 }
 
 // QueueConfig 任务队列配置
@@ -92,34 +242,339 @@ type QueueConfig struct {
 	RetryLimit    int    `mapstructure:"retry_limit"`    // 任务最大重试次数
 	RetryDelay    int    `mapstructure:"retry_delay"`    // 重试延迟(秒)
 	CallbackURL   string `mapstructure:"callback_url"`   // 回调URL
+	// Worker 异步处理文档的worker：python（交给Python服务）或go（在本进程内处理）
+	Worker string `mapstructure:"worker"`
+
+	ScaleMaxLag     time.Duration `mapstructure:"scale_max_lag"`     // 扩容阈值：最老待处理任务的等待时间
+	ScaleMaxBacklog int           `mapstructure:"scale_max_backlog"` // 扩容阈值：积压任务数
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
 	Type string `mapstructure:"type"` // 数据库类型: sqlite, mysql, postgres
 	DSN  string `mapstructure:"dsn"`  // 数据源名称
+
+	MaxOpenConns   int           `mapstructure:"max_open_conns"`    // 最大打开连接数
+	MaxIdleConns   int           `mapstructure:"max_idle_conns"`    // 最大空闲连接数
+	ConnMaxLife    time.Duration `mapstructure:"conn_max_lifetime"` // 连接最大生命周期
+	ConnMaxIdle    time.Duration `mapstructure:"conn_max_idle"`     // 连接最大空闲时间
+	ConnectRetries int           `mapstructure:"connect_retries"`   // 启动时连接失败的重试次数
+	RetryInterval  time.Duration `mapstructure:"retry_interval"`    // 首次重试前的等待时间，之后每次翻倍
+	SlowThreshold  time.Duration `mapstructure:"slow_threshold"`    // 慢查询阈值，0表示不记录慢查询
 }
 
 // DocumentConfig 文档处理配置
 type DocumentConfig struct {
-	ChunkSize    int `mapstructure:"chunk_size"`    // 分块大小
-	ChunkOverlap int `mapstructure:"chunk_overlap"` // 分块重叠大小
+	ChunkSize    int    `mapstructure:"chunk_size"`    // 分块大小
+	ChunkOverlap int    `mapstructure:"chunk_overlap"` // 分块重叠大小
+	SplitType    string `mapstructure:"split_type"`    // 分割类型：paragraph, sentence, semantic
+
+	// BreakpointThreshold 语义分块的断点阈值（百分位，0-100），越小分块越细
+	BreakpointThreshold float64 `mapstructure:"breakpoint_threshold"`
+	// LocalSemantic 语义分块在本地使用配置的嵌入模型完成，否则交给Python服务
+	LocalSemantic bool `mapstructure:"local_semantic"`
+
+	Images ImageConfig `mapstructure:"images"` // 文档图片提取配置
+	Dedup  DedupConfig `mapstructure:"dedup"`  // 近似重复段落检测配置
+	Clean  CleanConfig `mapstructure:"clean"`  // 分块前的文本清洗配置
+}
+
+// CleanConfig 分块前的文本清洗配置
+// 启用后在分块前去掉页码、重复的页眉页脚、版权声明和自定义的模板文本，清洗前后的字符数记入文档元数据
+type CleanConfig struct {
+	Enable     bool     `mapstructure:"enable"`      // 是否启用清洗
+	Rules      []string `mapstructure:"rules"`       // 启用的内置规则：page_numbers、headers_footers、copyright、blank_lines
+	Patterns   []string `mapstructure:"patterns"`    // 自定义正则表达式，匹配的内容直接删除
+	MinRepeats int      `mapstructure:"min_repeats"` // 至少在多少页重复出现才视为页眉页脚
+}
+
+// DedupConfig 索引时的近似重复段落检测配置
+// 启用后按SimHash检测与已索引段落近似重复的段落（如页眉、页脚、免责声明），避免模板文本挤占检索结果
+type DedupConfig struct {
+	Enable      bool   `mapstructure:"enable"`       // 是否启用检测
+	Mode        string `mapstructure:"mode"`         // skip：重复段落不写入向量库；flag：照常写入并标记原段落
+	MaxDistance int    `mapstructure:"max_distance"` // 判定重复的最大汉明距离（0~63），越大越宽松
+}
+
+// ImageConfig 文档图片提取配置
+// 启用后从Word、PowerPoint和Excel文档中提取嵌入的图片，由视觉模型生成描述并索引，问答来源中带上图片引用
+type ImageConfig struct {
+	Enable         bool   `mapstructure:"enable"`           // 是否提取图片
+	Provider       string `mapstructure:"provider"`         // 视觉模型提供商，需支持图片输入（anthropic或gemini），为空时使用llm.provider
+	Model          string `mapstructure:"model"`            // 视觉模型名称，为空时使用提供商的默认模型，未配置provider时使用llm.model
+	APIKey         string `mapstructure:"api_key"`          // 视觉模型的API密钥，未配置provider时可以为空，使用llm.api_key
+	MinBytes       int    `mapstructure:"min_bytes"`        // 图片最小字节数，更小的图片多为图标等装饰，不提取
+	MaxPerDocument int    `mapstructure:"max_per_document"` // 单个文档最多提取的图片数量
 }
 
 // SearchConfig 搜索配置
 type SearchConfig struct {
-	Limit    int     `mapstructure:"limit"`     // 搜索结果数量限制
-	MinScore float32 `mapstructure:"min_score"` // 最低相似度分数
+	Limit         int     `mapstructure:"limit"`          // 搜索结果数量限制
+	MinScore      float32 `mapstructure:"min_score"`      // 最低相似度分数
+	MMREnabled    bool    `mapstructure:"mmr_enabled"`    // 是否启用MMR结果多样化
+	MMRLambda     float32 `mapstructure:"mmr_lambda"`     // MMR相关性权重（0~1），越小越偏向多样性
+	MMRCandidates int     `mapstructure:"mmr_candidates"` // MMR候选池大小
+	QueryRewrite  bool    `mapstructure:"query_rewrite"`  // 是否在检索前让大模型改写问题
+	RewriteCount  int     `mapstructure:"rewrite_count"`  // 改写问题数量（1~5）
+	MergeAdjacent bool    `mapstructure:"merge_adjacent"` // 是否合并同一文档中相邻的检索段落，去掉分块重叠造成的重复文本
+	ParentWindow  int     `mapstructure:"parent_window"`  // 命中段落向每侧扩展到所在章节的段落数（0~20），为0时不扩展
+
+	// 问答请求可以覆盖检索参数，以下为允许的上限，为0时使用默认上限
+	MaxLimit         int `mapstructure:"max_limit"`          // 单次请求检索数量上限
+	MaxMMRCandidates int `mapstructure:"max_mmr_candidates"` // 单次请求MMR候选数量上限
+
+	// 最低相似度自动校准：按嵌入模型统计检索分数分布，取百分位作为阈值，未校准的模型使用min_score
+	AutoMinScore          bool    `mapstructure:"auto_min_score"`         // 是否启用按模型校准的最低相似度
+	CalibrationPercentile float64 `mapstructure:"calibration_percentile"` // 默认百分位（0~100）
+	CalibrationQuestions  int     `mapstructure:"calibration_questions"`  // 校准时最多使用的最近问题数
+
+	Boost BoostConfig `mapstructure:"boost"` // 检索结果的时间和来源加权
+}
+
+// BoostConfig 检索结果的时间和来源加权配置
+// 最终得分 = 相似度 × ((1-recency_weight) + recency_weight×时间衰减) × 集合权重，加权后的得分同样与min_score比较
+type BoostConfig struct {
+	Enable        bool               `mapstructure:"enable"`         // 是否启用加权
+	Decay         string             `mapstructure:"decay"`          // 时间衰减函数：exponential、linear或gauss
+	HalfLife      time.Duration      `mapstructure:"half_life"`      // 时间系数衰减为0.5时的文档年龄
+	RecencyWeight float32            `mapstructure:"recency_weight"` // 时间衰减在得分中的权重（0~1），为0时不按时间加权
+	DateKey       string             `mapstructure:"date_key"`       // 元数据中文档日期的键，没有该键时使用段落的索引时间
+	CollectionKey string             `mapstructure:"collection_key"` // 元数据中标识文档所属集合的键
+	Collections   map[string]float32 `mapstructure:"collections"`    // 各集合的权重，未列出的集合权重为1
 }
 
 // PythonServiceConfig Python服务配置
 type PythonServiceConfig struct {
-	BaseURL       string        `mapstructure:"base_url"`       // Python服务基础URL
-	Timeout       time.Duration `mapstructure:"timeout"`        // 请求超时时间
-	MaxRetries    int           `mapstructure:"max_retries"`    // 最大重试次数
-	RetryDelay    time.Duration `mapstructure:"retry_delay"`    // 重试间隔
-	EnableTLS     bool          `mapstructure:"enable_tls"`     // 是否启用TLS
-	AllowInsecure bool          `mapstructure:"allow_insecure"` // 允许不安全的TLS连接
+	BaseURL       string        `mapstructure:"base_url"`        // Python服务基础URL（包含/api前缀）
+	Timeout       time.Duration `mapstructure:"timeout"`         // 请求超时时间
+	MaxRetries    int           `mapstructure:"max_retries"`     // 最大重试次数
+	RetryDelay    time.Duration `mapstructure:"retry_delay"`     // 首次重试间隔，之后按指数退避
+	MaxRetryDelay time.Duration `mapstructure:"max_retry_delay"` // 重试间隔上限
+	RetryJitter   float64       `mapstructure:"retry_jitter"`    // 重试间隔随机抖动比例（0~1）
+	AuthToken     string        `mapstructure:"auth_token"`      // 访问令牌，支持${ENV}形式引用环境变量
+	EnableTLS     bool          `mapstructure:"enable_tls"`      // 是否启用TLS
+	AllowInsecure bool          `mapstructure:"allow_insecure"`  // 允许不安全的TLS连接
+
+	BreakerThreshold int           `mapstructure:"breaker_threshold"` // 连续失败多少次后熔断，0表示不启用
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown"`  // 熔断后的冷却时间
+}
+
+// ChaosConfig 故障注入配置
+// 用于在测试或演练环境中验证降级、熔断和重试逻辑，生产环境不要开启
+type ChaosConfig struct {
+	Enable bool                        `mapstructure:"enable"` // 是否启用故障注入
+	Seed   int64                       `mapstructure:"seed"`   // 随机数种子，0表示随机
+	Faults map[string]ChaosFaultConfig `mapstructure:"faults"` // 各依赖的故障配置，键为embedding/llm/llm_fallback/vectordb/queue
+}
+
+// FeedbackConfig 回答负反馈配置
+// 同一类问题中被多次标记错误的来源段落会在检索时降权
+type FeedbackConfig struct {
+	SuppressThreshold int     `mapstructure:"suppress_threshold"` // 段落被标记错误多少次后开始降权
+	ClusterSimilarity float32 `mapstructure:"cluster_similarity"` // 问题归为同一类的最低相似度
+	SuppressPenalty   float32 `mapstructure:"suppress_penalty"`   // 降权系数，相似度得分乘以该值
+}
+
+// ReviewConfig 回答审核配置
+// 启用后命中审核话题的问题，回答先保存为草稿，人工审核通过后才发布给提问者
+type ReviewConfig struct {
+	Enable bool     `mapstructure:"enable"` // 是否启用回答审核
+	Topics []string `mapstructure:"topics"` // 需审核的话题关键词，为空表示所有问题都需审核
+}
+
+// GuardrailConfig 问答护栏配置
+// 启用后调用大模型前审核用户问题、返回前审核生成的回答，拦截或脱敏不安全的内容
+type GuardrailConfig struct {
+	Enable            bool          `mapstructure:"enable"`             // 是否启用问答护栏
+	Providers         []string      `mapstructure:"providers"`          // 审核方式，按顺序执行：keyword、api、llm_judge
+	BlockedKeywords   []string      `mapstructure:"blocked_keywords"`   // 命中即拦截的关键词
+	RedactedKeywords  []string      `mapstructure:"redacted_keywords"`  // 命中后替换为***的关键词
+	InjectionPatterns []string      `mapstructure:"injection_patterns"` // 提示词注入特征（正则），为空使用内置特征
+	APIBaseURL        string        `mapstructure:"api_base_url"`       // OpenAI兼容审核接口地址
+	APIKey            string        `mapstructure:"api_key"`            // 审核接口密钥
+	APIModel          string        `mapstructure:"api_model"`          // 审核模型
+	Timeout           time.Duration `mapstructure:"timeout"`            // 审核接口超时时间
+	FailClosed        bool          `mapstructure:"fail_closed"`        // 审核出错时是否拦截，默认放行
+	Refusal           string        `mapstructure:"refusal"`            // 拦截时的回答，为空使用默认回答
+}
+
+// GroundingConfig 回答依据校验配置
+// 启用后校验生成的回答是否有检索上下文作为依据，并在问答响应中返回置信度
+type GroundingConfig struct {
+	Enable        bool    `mapstructure:"enable"`         // 是否启用回答依据校验
+	Verifier      string  `mapstructure:"verifier"`       // 校验方式：llm（大模型判断）或nli（Python服务的NLI模型）
+	NLIModel      string  `mapstructure:"nli_model"`      // NLI模型，为空时使用Python服务的默认模型
+	MinConfidence float32 `mapstructure:"min_confidence"` // 最低置信度，低于该值标记为低置信度
+	Fallback      string  `mapstructure:"fallback"`       // 低置信度时的兜底回答，为空时只标记不替换
+}
+
+// TranslationConfig 跨语言问答配置
+// 启用后问题语言与文档库语言不同时，检索前翻译问题，生成后把回答翻译回提问者的语言
+type TranslationConfig struct {
+	Enable        bool   `mapstructure:"enable"`         // 是否允许跨语言问答
+	Default       bool   `mapstructure:"default"`        // 请求未指定translate时是否翻译
+	IndexLanguage string `mapstructure:"index_language"` // 文档库的主要语言（ISO 639-1代码）
+}
+
+// IntentConfig 问题分类配置
+// 问答前判断问题是问候、闲聊、事实性问题还是命令，闲聊直接由大模型回应，命令交给对应的处理器
+type IntentConfig struct {
+	Classifier string `mapstructure:"classifier"` // 分类方式：rule（关键词规则）或llm（大模型判断）
+	Model      string `mapstructure:"model"`      // llm分类使用的模型，为空时使用llm.model，建议使用更小更快的模型
+}
+
+// HealthConfig 依赖健康检查配置
+// /healthz和/readyz检查数据库、向量数据库、任务队列、存储和Python服务
+type HealthConfig struct {
+	Timeout       time.Duration `mapstructure:"timeout"`        // 单个依赖的检查超时时间
+	PythonService bool          `mapstructure:"python_service"` // 是否检查Python服务，Python服务不可用时不影响就绪状态
+}
+
+// RateLimitConfig 请求限流和问答并发配置
+// 按API密钥（未携带时按来源IP）使用令牌桶限流，避免单个客户端耗尽大模型配额
+type RateLimitConfig struct {
+	Enable        bool     `mapstructure:"enable"`         // 是否启用请求限流
+	Backend       string   `mapstructure:"backend"`        // 令牌桶存储：memory（单副本）或redis（多副本共享）
+	RedisAddr     string   `mapstructure:"redis_addr"`     // Redis地址，为空时使用队列的Redis
+	RedisPassword string   `mapstructure:"redis_password"` // Redis密码
+	RedisDB       int      `mapstructure:"redis_db"`       // Redis数据库编号
+	Rate          float64  `mapstructure:"rate"`           // 每个客户端每秒补充的请求数
+	Burst         int      `mapstructure:"burst"`          // 每个客户端允许的突发请求数
+	ExemptPaths   []string `mapstructure:"exempt_paths"`   // 不限流的路径前缀，如健康检查和任务回调
+	// MaxClients 进程内限流器最多跟踪的客户端数，达到后新客户端共用一个令牌桶，避免伪造的来源地址耗尽内存
+	MaxClients int `mapstructure:"max_clients"`
+	// QAPerClient 每个客户端同时进行的问答数，超过时返回429，0表示不限制
+	QAPerClient int `mapstructure:"qa_per_client"`
+	// QAMaxConcurrent 全局同时进行的问答数，占满时排队等待，0表示不限制
+	QAMaxConcurrent int `mapstructure:"qa_max_concurrent"`
+	// QABatchMax 单次批量问答（POST /api/qa/batch）的最大问题数
+	QABatchMax int `mapstructure:"qa_batch_max"`
+	// QABatchWorkers 单次批量问答同时回答的问题数，不超过qa_per_client
+	QABatchWorkers int `mapstructure:"qa_batch_workers"`
+}
+
+// ACLConfig 文档访问控制配置
+// 启用后只有上传者、公开文档和共享给用户或其所属用户组（auth.keys中登记的用户组）的文档可以读取，
+// 在文档列表、文档读取和问答检索中检查；启用前上传和匿名上传的文档不属于任何用户，
+// 只有管理员可以读取，通过legacy_owner或legacy_public在启动时迁移
+type ACLConfig struct {
+	Enable bool     `mapstructure:"enable"` // 是否启用文档访问控制
+	Admins []string `mapstructure:"admins"` // 管理员租户，可以读取所有文档并修改任何文档的访问控制
+	// LegacyOwner 启动时将没有确定上传者的文档（启用前上传和匿名上传的文档）归属该租户，为空时不修改
+	LegacyOwner string `mapstructure:"legacy_owner"`
+	// LegacyPublic 启动时将没有确定上传者的文档设为公开
+	LegacyPublic bool `mapstructure:"legacy_public"`
+}
+
+// AuthConfig 客户端认证配置
+// 租户和用户组只按登记的API密钥识别，用于用量统计、配额、限流和文档访问控制；未携带或携带未登记密钥的请求是匿名的
+type AuthConfig struct {
+	Keys []APIKeyConfig `mapstructure:"keys"` // 登记的API密钥
+	// AdminToken 管理接口（/api/admin）的令牌，请求通过X-Admin-Token请求头携带，支持${ENV}形式引用环境变量；
+	// 为空时不注册管理接口，备份恢复、向量导入导出、清除缓存等运维操作不可用
+	AdminToken string `mapstructure:"admin_token"`
+}
+
+// APIKeyConfig 登记的API密钥
+type APIKeyConfig struct {
+	Key    string   `mapstructure:"key"`    // 密钥，支持${ENV}形式引用环境变量
+	Tenant string   `mapstructure:"tenant"` // 密钥所属的租户
+	Groups []string `mapstructure:"groups"` // 租户所属的用户组，用于文档访问控制
+}
+
+// QuotaConfig 租户配额配置
+// 按租户（auth.keys中登记的API密钥所属的租户）限制文档数量、存储字节数和每日问答次数，超出时返回429；各项为0表示不限制
+type QuotaConfig struct {
+	Enable           bool                `mapstructure:"enable"`               // 是否启用租户配额
+	MaxDocuments     int64               `mapstructure:"max_documents"`        // 默认文档数量上限
+	MaxStorageBytes  int64               `mapstructure:"max_storage_bytes"`    // 默认文件存储字节数上限
+	MaxQACallsPerDay int64               `mapstructure:"max_qa_calls_per_day"` // 默认每日问答次数上限
+	Tenants          []TenantQuotaConfig `mapstructure:"tenants"`              // 单独配置的租户，整体替换默认配额
+}
+
+// TenantQuotaConfig 单个租户的配额
+type TenantQuotaConfig struct {
+	Tenant           string `mapstructure:"tenant"`               // 租户标识，即auth.keys中密钥所属的租户
+	MaxDocuments     int64  `mapstructure:"max_documents"`        // 文档数量上限
+	MaxStorageBytes  int64  `mapstructure:"max_storage_bytes"`    // 文件存储字节数上限
+	MaxQACallsPerDay int64  `mapstructure:"max_qa_calls_per_day"` // 每日问答次数上限
+}
+
+// WarmupConfig 启动预热配置
+// 启用后服务启动时并行预热，预热完成前就绪探针返回503
+type WarmupConfig struct {
+	Enable          bool          `mapstructure:"enable"`           // 是否启用启动预热
+	VectorIndex     bool          `mapstructure:"vector_index"`     // 是否预热向量索引
+	Providers       bool          `mapstructure:"providers"`        // 是否预先建立嵌入模型和大模型的连接
+	RecentQuestions int           `mapstructure:"recent_questions"` // 用最近多少个问题预热问答缓存，0表示不预热
+	Concurrency     int           `mapstructure:"concurrency"`      // 预热问答缓存的并发数
+	Timeout         time.Duration `mapstructure:"timeout"`          // 整体预热超时时间，超时后服务照常就绪
+}
+
+// SchedulerConfig 定时任务配置
+type SchedulerConfig struct {
+	Enable bool                 `mapstructure:"enable"` // 是否启用定时任务
+	Jobs   []SchedulerJobConfig `mapstructure:"jobs"`   // 任务列表
+}
+
+// SchedulerJobConfig 单个定时任务配置
+type SchedulerJobConfig struct {
+	Name     string `mapstructure:"name"`     // 任务名称，需唯一
+	Type     string `mapstructure:"type"`     // 任务类型：recrawl（重新抓取网页文档）、reembed（嵌入模型变更后重新向量化）或gc（清理孤立的存储文件和向量）
+	Schedule string `mapstructure:"schedule"` // 标准五段cron表达式，也支持@daily、@every 6h等写法
+}
+
+// ConnectorConfig 外部来源连接器配置
+// 连接器从Confluence、Notion、Google Drive或SharePoint增量同步文档，配置了schedule时定时同步
+type ConnectorConfig struct {
+	Name        string            `mapstructure:"name"`        // 连接器名称，需唯一
+	Type        string            `mapstructure:"type"`        // 连接器类型：confluence、notion、gdrive或sharepoint
+	BaseURL     string            `mapstructure:"base_url"`    // API地址，为空时使用官方地址；Confluence必须配置站点地址
+	Schedule    string            `mapstructure:"schedule"`    // 同步周期，cron表达式，为空时只能手动触发
+	GroupID     string            `mapstructure:"group_id"`    // 新建的文档加入的文档组
+	Tags        string            `mapstructure:"tags"`        // 新建的文档使用的标签
+	Credentials map[string]string `mapstructure:"credentials"` // 认证信息，支持${ENV}形式引用环境变量
+	Options     map[string]string `mapstructure:"options"`     // 其他选项，如Confluence的space、Drive的folder_id
+}
+
+// EventsConfig 知识库变更事件配置
+// 文档上传、处理结束、删除和问答结束时发布事件，最近的事件保存在内存中，可转发到NATS或Kafka
+type EventsConfig struct {
+	Enable         bool                   `mapstructure:"enable"`          // 是否发布事件
+	Buffer         int                    `mapstructure:"buffer"`          // 待发布事件的缓冲区大小，缓冲区满时丢弃事件
+	MemoryCapacity int                    `mapstructure:"memory_capacity"` // 内存中保存的最近事件数，通过 /api/admin/events 读取
+	Timeout        time.Duration          `mapstructure:"timeout"`         // 单次发布的超时时间
+	Publishers     []EventPublisherConfig `mapstructure:"publishers"`      // 外部发布者
+}
+
+// LLMLogConfig 大模型交互日志配置
+// 记录每次调用的提示词、检索段落ID、回答、耗时和token数，用于排查错误回答和调整提示词
+type LLMLogConfig struct {
+	Enable    bool          `mapstructure:"enable"`    // 是否记录交互日志
+	Sink      string        `mapstructure:"sink"`      // 写入目标：database、stdout或file
+	Path      string        `mapstructure:"path"`      // sink为file时的日志文件路径，以JSON Lines格式追加写入
+	Redact    []string      `mapstructure:"redact"`    // 启用的内置脱敏规则：email、id_card、phone、bank_card、ip
+	Patterns  []string      `mapstructure:"patterns"`  // 自定义脱敏正则表达式，命中内容替换为[REDACTED]
+	MaxChars  int           `mapstructure:"max_chars"` // 提示词和回答的最大字符数，超过时截断，为0时不截断
+	Retention time.Duration `mapstructure:"retention"` // sink为database时日志的保留时长，为0时不清理
+}
+
+// EventPublisherConfig 外部事件发布者配置
+type EventPublisherConfig struct {
+	Type     string `mapstructure:"type"`     // 发布者类型：nats或kafka（通过Kafka REST Proxy发布）
+	URL      string `mapstructure:"url"`      // NATS服务地址或Kafka REST Proxy地址
+	Subject  string `mapstructure:"subject"`  // NATS主题前缀或Kafka主题，默认docqa.events
+	Token    string `mapstructure:"token"`    // 认证令牌，支持${ENV}形式引用环境变量
+	Username string `mapstructure:"username"` // 用户名
+	Password string `mapstructure:"password"` // 密码，支持${ENV}形式引用环境变量
+}
+
+// ChaosFaultConfig 单个依赖的故障配置
+type ChaosFaultConfig struct {
+	ErrorRate float64       `mapstructure:"error_rate"` // 返回错误的概率(0-1)
+	Latency   time.Duration `mapstructure:"latency"`    // 固定附加延迟
+	Jitter    time.Duration `mapstructure:"jitter"`     // 随机抖动上限
+	Ops       []string      `mapstructure:"ops"`        // 只对指定操作生效，为空表示全部
 }
 
 // Load 从文件和环境变量加载配置
@@ -193,6 +648,67 @@ func processEnvironmentVariables(cfg *Config) *Config {
 		}
 	}
 
+	// 处理备用LLM提供商的API密钥
+	for i := range cfg.LLM.Fallbacks {
+		key := cfg.LLM.Fallbacks[i].APIKey
+		if strings.HasPrefix(key, "${") && strings.HasSuffix(key, "}") {
+			if envVal := os.Getenv(key[2 : len(key)-1]); envVal != "" {
+				cfg.LLM.Fallbacks[i].APIKey = envVal
+			}
+		}
+	}
+
+	// 处理按语言路由的嵌入模型的API密钥
+	for i := range cfg.Embed.Routes {
+		key := cfg.Embed.Routes[i].APIKey
+		if strings.HasPrefix(key, "${") && strings.HasSuffix(key, "}") {
+			if envVal := os.Getenv(key[2 : len(key)-1]); envVal != "" {
+				cfg.Embed.Routes[i].APIKey = envVal
+			}
+		}
+	}
+
+	// 处理Python服务访问令牌
+	if token := cfg.PythonService.AuthToken; strings.HasPrefix(token, "${") && strings.HasSuffix(token, "}") {
+		cfg.PythonService.AuthToken = os.Getenv(token[2 : len(token)-1])
+	}
+
+	// 处理管理接口令牌
+	if token := cfg.Auth.AdminToken; strings.HasPrefix(token, "${") && strings.HasSuffix(token, "}") {
+		cfg.Auth.AdminToken = os.Getenv(token[2 : len(token)-1])
+	}
+
+	// 处理登记的API密钥
+	for i := range cfg.Auth.Keys {
+		if key := cfg.Auth.Keys[i].Key; strings.HasPrefix(key, "${") && strings.HasSuffix(key, "}") {
+			cfg.Auth.Keys[i].Key = os.Getenv(key[2 : len(key)-1])
+		}
+	}
+
+	// 处理连接器的认证信息
+	for i := range cfg.Connectors {
+		for key, value := range cfg.Connectors[i].Credentials {
+			if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
+				cfg.Connectors[i].Credentials[key] = os.Getenv(value[2 : len(value)-1])
+			}
+		}
+	}
+
+	// 处理事件发布者的认证信息
+	for i := range cfg.Events.Publishers {
+		publisher := &cfg.Events.Publishers[i]
+		for _, value := range []*string{&publisher.Token, &publisher.Password} {
+			if strings.HasPrefix(*value, "${") && strings.HasSuffix(*value, "}") {
+				*value = os.Getenv((*value)[2 : len(*value)-1])
+			}
+		}
+	}
+
+	// 兼容旧的PYTHONSERVICE_URL环境变量，其值为不带/api前缀的服务地址
+	if url := os.Getenv("PYTHONSERVICE_URL"); url != "" && os.Getenv("PYTHON_SERVICE_BASE_URL") == "" {
+		cfg.PythonService.BaseURL = strings.TrimRight(url, "/") + "/api"
+	}
+
 	// 可以添加更多配置项的处理
 
 	return cfg
@@ -203,35 +719,68 @@ func setDefaults(v *viper.Viper) {
 	// 服务器默认配置
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.grpc_port", 0)
+	v.SetDefault("server.source_link_template", "/api/documents/{file_id}/segments/{position}/context")
+	v.SetDefault("server.replicas", 1)
+	v.SetDefault("server.role", "primary")
 
 	// 存储默认配置
 	v.SetDefault("storage.type", "local")
 	v.SetDefault("storage.path", "./uploads")
 	v.SetDefault("storage.bucket", "docqa")
 	v.SetDefault("storage.use_ssl", false)
+	v.SetDefault("storage.max_file_size", 512<<20)
+	v.SetDefault("storage.staging_path", "./data/upload-staging")
+	v.SetDefault("storage.upload_part_size", 8<<20)
+	v.SetDefault("storage.upload_expiry", "24h")
+	v.SetDefault("storage.allowed_types", []string{".pdf", ".md", ".markdown", ".txt"})
+	v.SetDefault("storage.virus_scan.enable", false)
+	v.SetDefault("storage.virus_scan.address", "localhost:3310")
+	v.SetDefault("storage.virus_scan.timeout", "60s")
+	v.SetDefault("storage.gc_min_age", "24h")
 
 	// 向量数据库默认配置
 	v.SetDefault("vectordb.type", "faiss")
 	v.SetDefault("vectordb.path", "./vectordb")
 	v.SetDefault("vectordb.dim", 1024) // Qwen embedding 维度
 	v.SetDefault("vectordb.distance", "cosine")
+	v.SetDefault("vectordb.on_dimension_mismatch", "auto")
+	v.SetDefault("vectordb.store_text", true)
+	v.SetDefault("vectordb.text_cache_size", 10000)
 
 	// LLM默认配置
 	v.SetDefault("llm.provider", "openai")
 	v.SetDefault("llm.model", "gpt-3.5-turbo")
 	v.SetDefault("llm.endpoint", "https://api.openai.com/v1")
 	v.SetDefault("llm.max_tokens", 1000)
+	v.SetDefault("llm.max_tokens_limit", 4096)
+	v.SetDefault("llm.max_temperature", 1.0)
+	v.SetDefault("llm.failure_threshold", 3)
+	v.SetDefault("llm.cooldown", "30s")
+	v.SetDefault("llm.memoize", true)
+	v.SetDefault("llm.memoize_ttl", "10m")
+	v.SetDefault("llm.max_calls_per_request", 8)
+	v.SetDefault("llm.max_tool_steps", 8)
+	v.SetDefault("llm.max_tokens_per_request", 60000)
 
 	// Embedding默认配置
 	v.SetDefault("embed.provider", "openai")
 	v.SetDefault("embed.model", "text-embedding-3-small")
 	v.SetDefault("embed.endpoint", "https://api.openai.com/v1")
 	v.SetDefault("embed.batch_size", 10)
+	v.SetDefault("embed.parallelism", 4)
+	v.SetDefault("embed.max_retries", 3)
+	v.SetDefault("embed.retry_delay", "500ms")
+	v.SetDefault("embed.max_retry_delay", "30s")
+	v.SetDefault("embed.languages", []string{"zh", "en"})
+	v.SetDefault("translation.index_language", "zh")
+	v.SetDefault("intent.classifier", "rule")
 
 	// 缓存默认配置
 	v.SetDefault("cache.enable", true)
 	v.SetDefault("cache.type", "memory")
 	v.SetDefault("cache.ttl", 3600) // 1小时
+	v.SetDefault("cache.refresh_after", 0)
 
 	// 队列默认配置
 	v.SetDefault("queue.enable", false)
@@ -241,24 +790,146 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("queue.concurrency", 10)
 	v.SetDefault("queue.retry_limit", 3)
 	v.SetDefault("queue.retry_delay", 60) // 60秒
+	v.SetDefault("queue.worker", "python")
+	v.SetDefault("queue.scale_max_lag", "30s")
+	v.SetDefault("queue.scale_max_backlog", 20)
 
 	// 数据库默认配置
 	v.SetDefault("database.type", "sqlite")
 	v.SetDefault("database.dsn", "data/docqa.db")
+	v.SetDefault("database.max_open_conns", 10)
+	v.SetDefault("database.max_idle_conns", 5)
+	v.SetDefault("database.conn_max_lifetime", "1h")
+	v.SetDefault("database.conn_max_idle", "10m")
+	v.SetDefault("database.connect_retries", 5)
+	v.SetDefault("database.retry_interval", "1s")
+	v.SetDefault("database.slow_threshold", "200ms")
 
 	// 文档处理默认配置
 	v.SetDefault("document.chunk_size", 1000)
 	v.SetDefault("document.chunk_overlap", 200)
+	v.SetDefault("document.split_type", "sentence")
+	v.SetDefault("document.breakpoint_threshold", 95)
+	v.SetDefault("document.local_semantic", true)
+	v.SetDefault("document.images.enable", false)
+	v.SetDefault("document.images.min_bytes", 4096)
+	v.SetDefault("document.images.max_per_document", 20)
+	v.SetDefault("document.dedup.enable", false)
+	v.SetDefault("document.dedup.mode", "skip")
+	v.SetDefault("document.dedup.max_distance", 3)
+	v.SetDefault("document.clean.enable", false)
+	v.SetDefault("document.clean.rules", []string{"page_numbers", "headers_footers", "copyright", "blank_lines"})
+	v.SetDefault("document.clean.min_repeats", 3)
 
 	// 搜索默认配置
 	v.SetDefault("search.limit", 10)
 	v.SetDefault("search.min_score", 0.5)
+	v.SetDefault("search.mmr_enabled", false)
+	v.SetDefault("search.mmr_lambda", 0.7)
+	v.SetDefault("search.mmr_candidates", 20)
+	v.SetDefault("search.query_rewrite", false)
+	v.SetDefault("search.rewrite_count", 3)
+	v.SetDefault("search.merge_adjacent", true)
+	v.SetDefault("search.parent_window", 0)
+	v.SetDefault("search.boost.enable", false)
+	v.SetDefault("search.boost.decay", "exponential")
+	v.SetDefault("search.boost.half_life", "4320h")
+	v.SetDefault("search.boost.recency_weight", 0.3)
+	v.SetDefault("search.boost.date_key", "published_at")
+	v.SetDefault("search.boost.collection_key", "connector")
+	v.SetDefault("search.max_limit", 50)
+	v.SetDefault("search.max_mmr_candidates", 200)
+	v.SetDefault("search.auto_min_score", false)
+	v.SetDefault("search.calibration_percentile", 50)
+	v.SetDefault("search.calibration_questions", 200)
 
 	// Python服务默认配置
-	v.SetDefault("python_service.base_url", "http://localhost:8000/api/python")
+	v.SetDefault("python_service.base_url", "http://localhost:8000/api")
 	v.SetDefault("python_service.timeout", "30s")
 	v.SetDefault("python_service.max_retries", 3)
 	v.SetDefault("python_service.retry_delay", "1s")
+	v.SetDefault("python_service.max_retry_delay", "10s")
+	v.SetDefault("python_service.retry_jitter", 0.2)
+	v.SetDefault("python_service.auth_token", "")
+	v.SetDefault("python_service.breaker_threshold", 5)
+	v.SetDefault("python_service.breaker_cooldown", "30s")
 	v.SetDefault("python_service.enable_tls", false)
 	v.SetDefault("python_service.allow_insecure", false)
+
+	// 故障注入默认关闭
+	v.SetDefault("chaos.enable", false)
+
+	// 回答负反馈默认配置
+	v.SetDefault("feedback.suppress_threshold", 3)
+	v.SetDefault("feedback.cluster_similarity", 0.85)
+	v.SetDefault("feedback.suppress_penalty", 0.5)
+
+	// 启动预热默认关闭
+	v.SetDefault("warmup.enable", false)
+	v.SetDefault("warmup.vector_index", true)
+	v.SetDefault("warmup.providers", true)
+	v.SetDefault("warmup.recent_questions", 20)
+	v.SetDefault("warmup.concurrency", 4)
+	v.SetDefault("warmup.timeout", "2m")
+
+	// 回答审核默认关闭
+	v.SetDefault("review.enable", false)
+
+	// 问答护栏默认关闭
+	v.SetDefault("guardrail.enable", false)
+	v.SetDefault("guardrail.providers", []string{"keyword"})
+	v.SetDefault("guardrail.timeout", "10s")
+
+	// 回答依据校验默认关闭
+	v.SetDefault("grounding.enable", false)
+	v.SetDefault("grounding.verifier", "llm")
+	v.SetDefault("grounding.min_confidence", 0.5)
+
+	// 依赖健康检查
+	v.SetDefault("health.timeout", "3s")
+	v.SetDefault("health.python_service", true)
+
+	// 请求限流默认关闭，问答并发限制默认开启
+	v.SetDefault("rate_limit.enable", false)
+	v.SetDefault("rate_limit.backend", "memory")
+	v.SetDefault("rate_limit.rate", 5)
+	v.SetDefault("rate_limit.burst", 20)
+	v.SetDefault("rate_limit.max_clients", 100000)
+	v.SetDefault("rate_limit.exempt_paths", []string{"/api/health", "/api/ready", "/healthz", "/readyz", "/api/tasks/callback"})
+	v.SetDefault("rate_limit.qa_per_client", 4)
+	v.SetDefault("rate_limit.qa_max_concurrent", 0)
+	v.SetDefault("rate_limit.qa_batch_max", 20)
+	v.SetDefault("rate_limit.qa_batch_workers", 4)
+
+	// 租户配额默认关闭
+	v.SetDefault("quota.enable", false)
+	v.SetDefault("quota.max_documents", 0)
+	v.SetDefault("quota.max_storage_bytes", 0)
+	v.SetDefault("quota.max_qa_calls_per_day", 0)
+
+	// 文档访问控制默认关闭
+	v.SetDefault("acl.enable", false)
+	v.SetDefault("acl.admins", []string{})
+	v.SetDefault("acl.legacy_owner", "")
+	v.SetDefault("acl.legacy_public", false)
+
+	// 默认不登记API密钥，所有请求都是匿名的
+	v.SetDefault("auth.keys", []APIKeyConfig{})
+	v.SetDefault("auth.admin_token", "")
+
+	// 定时任务默认关闭
+	v.SetDefault("scheduler.enable", false)
+
+	// 事件默认关闭
+	v.SetDefault("events.enable", false)
+	v.SetDefault("events.buffer", 1000)
+	v.SetDefault("events.memory_capacity", 1000)
+	v.SetDefault("events.timeout", 5*time.Second)
+
+	// 交互日志默认关闭，开启后写入数据库并脱敏常见个人信息
+	v.SetDefault("llm_log.enable", false)
+	v.SetDefault("llm_log.sink", "database")
+	v.SetDefault("llm_log.redact", []string{"email", "phone", "id_card", "bank_card"})
+	v.SetDefault("llm_log.max_chars", 8000)
+	v.SetDefault("llm_log.retention", 720*time.Hour)
 }
@@ -0,0 +1,675 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError 配置校验错误，包含发现的所有问题
+type ValidationError struct {
+	Problems []string
+}
+
+// Error 实现error接口，每个问题占一行
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// problems 收集校验过程中发现的问题
+type problems []string
+
+func (p *problems) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+// 各提供商的名称，与cmd/main.go中创建客户端时支持的名称一致
+var (
+	llmProviders   = []string{"tongyi", "dashscope", "openai", "anthropic", "claude", "gemini", "google"}
+	embedProviders = []string{"tongyi", "dashscope", "openai", "local", "huggingface", "ollama", "onnx"}
+	// keylessProviders 不需要API密钥的提供商
+	keylessProviders = []string{"local", "huggingface", "ollama", "onnx"}
+	// connectorTypes 支持的连接器类型，与internal/connector中的类型一致
+	connectorTypes = []string{"confluence", "notion", "gdrive", "sharepoint"}
+	// redactRules 交互日志的内置脱敏规则，与internal/llmlog中的规则一致
+	redactRules = []string{"email", "id_card", "phone", "bank_card", "ip"}
+
+	// boostDecays 检索加权支持的时间衰减函数
+	boostDecays = []string{"exponential", "linear", "gauss"}
+
+	// cleanRules 文本清洗的内置规则，与internal/document中的规则一致
+	cleanRules = []string{"page_numbers", "headers_footers", "copyright", "blank_lines"}
+)
+
+// embedModelDimensions 常见嵌入模型的默认向量维度，未配置embed.dimensions时用于检查与向量索引是否一致
+var embedModelDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+	"text-embedding-v1":      1536,
+	"text-embedding-v2":      1536,
+	"text-embedding-v3":      1024,
+}
+
+// Validate 按启用的功能检查配置是否完整、取值是否合法
+// 返回的错误列出所有问题，便于在启动时一次改完，而不是运行到相应功能时才失败
+func (c *Config) Validate() error {
+	var p problems
+	c.validateServer(&p)
+	c.validateStorage(&p)
+	c.validateVectorDB(&p)
+	c.validateLLM(&p)
+	c.validateEmbed(&p)
+	c.validateCache(&p)
+	c.validateQueue(&p)
+	c.validateDatabase(&p)
+	c.validateDocument(&p)
+	c.validateSearch(&p)
+	c.validateFeatures(&p)
+
+	if len(p) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p}
+}
+
+func (c *Config) validateServer(p *problems) {
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		p.add("server.port must be between 1 and 65535, got %d", c.Server.Port)
+	}
+	if c.Server.GRPCPort < 0 || c.Server.GRPCPort > 65535 {
+		p.add("server.grpc_port must be between 0 and 65535 (0 disables gRPC), got %d", c.Server.GRPCPort)
+	} else if c.Server.GRPCPort != 0 && c.Server.GRPCPort == c.Server.Port {
+		p.add("server.grpc_port must differ from server.port (%d)", c.Server.Port)
+	}
+	if c.Server.Replicas < 0 {
+		p.add("server.replicas must not be negative, got %d", c.Server.Replicas)
+	}
+	switch c.Server.Role {
+	case "", "primary":
+	case "replica":
+		if u, err := url.Parse(c.Server.PrimaryURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.add("server.primary_url must be an http(s) URL when server.role is replica, got %q", c.Server.PrimaryURL)
+		}
+	default:
+		p.add("server.role must be primary or replica, got %q", c.Server.Role)
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			p.add("server.trusted_proxies contains invalid IP or CIDR %q", proxy)
+		}
+	}
+	// faiss和内存向量数据库保存在进程内，多个实例各自持有一份数据，写入互不可见
+	if c.Server.Replicas > 1 || c.Server.Role == "replica" {
+		switch c.VectorDB.Type {
+		case "faiss", "memory":
+			p.add("vectordb.type %s keeps vectors in process and cannot be shared by multiple replicas, use pgvector or redis", c.VectorDB.Type)
+		}
+	}
+}
+
+func (c *Config) validateStorage(p *problems) {
+	s := c.Storage
+	switch s.Type {
+	case "local":
+		if s.Path == "" {
+			p.add("storage.path is required when storage.type is local")
+		}
+	case "minio":
+		required := [][2]string{
+			{"endpoint", s.Endpoint},
+			{"bucket", s.Bucket},
+			{"access_key", s.AccessKey},
+			{"secret_key", s.SecretKey},
+		}
+		for _, field := range required {
+			if field[1] == "" {
+				p.add("storage.%s is required when storage.type is minio", field[0])
+			}
+		}
+	default:
+		p.add("storage.type must be local or minio, got %q", s.Type)
+	}
+	if s.MaxFileSize < 0 {
+		p.add("storage.max_file_size must not be negative (0 means unlimited)")
+	}
+	if s.UploadPartSize <= 0 {
+		p.add("storage.upload_part_size must be positive")
+	}
+	if s.VirusScan.Enable && s.VirusScan.Address == "" {
+		p.add("storage.virus_scan.address is required when storage.virus_scan.enable is true")
+	}
+}
+
+func (c *Config) validateVectorDB(p *problems) {
+	v := c.VectorDB
+	var indexTypes []string
+	switch v.Type {
+	case "faiss":
+		indexTypes = []string{"", "none", "flat", "hnsw", "ivf", "ivfflat", "ivfpq"}
+	case "memory":
+	case "pgvector":
+		indexTypes = []string{"", "none", "flat", "hnsw", "ivfflat"}
+		if v.Path == "" {
+			p.add("vectordb.path must be a Postgres connection string when vectordb.type is pgvector")
+		}
+	case "redis":
+		indexTypes = []string{"", "none", "flat", "hnsw"}
+		if v.Path == "" {
+			p.add("vectordb.path must be a Redis Stack address when vectordb.type is redis")
+		}
+	case "faiss-server":
+		if u, err := url.Parse(v.Path); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			p.add("vectordb.path must be the http(s) address of the vector index service when vectordb.type is faiss-server, got %q", v.Path)
+		}
+	default:
+		p.add("vectordb.type must be one of faiss, memory, pgvector, redis, faiss-server, got %q", v.Type)
+	}
+	if indexTypes != nil && !contains(indexTypes, v.IndexType) {
+		p.add("vectordb.index_type %q is not supported by %s, use one of %s", v.IndexType, v.Type, strings.Join(indexTypes[1:], ", "))
+	}
+	if v.Dim <= 0 {
+		p.add("vectordb.dim must be positive, got %d", v.Dim)
+	}
+	if !contains([]string{"", "cosine", "l2", "dot"}, v.Distance) {
+		p.add("vectordb.distance must be cosine, l2 or dot, got %q", v.Distance)
+	}
+	if v.SaveInterval < 0 {
+		p.add("vectordb.save_interval must not be negative")
+	}
+	if !contains([]string{"", "auto", "fail", "off"}, v.OnDimensionMismatch) {
+		p.add("vectordb.on_dimension_mismatch must be auto, fail or off, got %q", v.OnDimensionMismatch)
+	}
+	if v.Type == "faiss" && v.IndexType == "ivfpq" && v.PQM > 0 && v.Dim > 0 && v.Dim%v.PQM != 0 {
+		p.add("vectordb.pq_m (%d) must divide vectordb.dim (%d)", v.PQM, v.Dim)
+	}
+}
+
+func (c *Config) validateLLM(p *problems) {
+	checkProvider(p, "llm", c.LLM.Provider, c.LLM.APIKey, c.LLM.Endpoint, llmProviders)
+	for i, fb := range c.LLM.Fallbacks {
+		checkProvider(p, fmt.Sprintf("llm.fallbacks[%d]", i), fb.Provider, fb.APIKey, fb.Endpoint, llmProviders)
+	}
+	names := map[string]bool{c.LLM.Model: true}
+	for i, m := range c.LLM.Models {
+		section := fmt.Sprintf("llm.models[%d]", i)
+		checkProvider(p, section, m.Provider, m.APIKey, m.Endpoint, llmProviders)
+		name := m.Name
+		if name == "" {
+			name = m.Model
+		}
+		switch {
+		case name == "":
+			p.add("%s.name or %s.model is required", section, section)
+		case names[name]:
+			p.add("llm.models contains duplicate name %q", name)
+		}
+		names[name] = true
+	}
+	variantNames := make(map[string]bool)
+	totalWeight := 0
+	for i, v := range c.LLM.PromptVariants {
+		section := fmt.Sprintf("llm.prompt_variants[%d]", i)
+		switch {
+		case v.Name == "":
+			p.add("%s.name must not be empty", section)
+		case variantNames[v.Name]:
+			p.add("llm.prompt_variants contains duplicate name %q", v.Name)
+		}
+		variantNames[v.Name] = true
+		if v.Weight < 0 {
+			p.add("%s.weight must not be negative", section)
+		}
+		totalWeight += max(v.Weight, 0)
+		if v.Template != "" && !strings.Contains(v.Template, "{{.Question}}") {
+			p.add("%s.template must contain {{.Question}}", section)
+		}
+		if v.EmptyTemplate != "" && !strings.Contains(v.EmptyTemplate, "{{.Question}}") {
+			p.add("%s.empty_template must contain {{.Question}}", section)
+		}
+	}
+	if len(c.LLM.PromptVariants) > 0 && totalWeight == 0 {
+		p.add("llm.prompt_variants must have at least one variant with a positive weight")
+	}
+	if c.LLM.MaxTokens < 0 {
+		p.add("llm.max_tokens must not be negative")
+	}
+	if c.LLM.Temperature < 0 || c.LLM.Temperature > 2 {
+		p.add("llm.temperature must be between 0 and 2, got %g", c.LLM.Temperature)
+	}
+	if c.LLM.MaxTokensLimit < 0 {
+		p.add("llm.max_tokens_limit must not be negative")
+	}
+	if c.LLM.MaxTemperature < 0 || c.LLM.MaxTemperature > 2 {
+		p.add("llm.max_temperature must be between 0 and 2, got %g", c.LLM.MaxTemperature)
+	}
+}
+
+func (c *Config) validateEmbed(p *problems) {
+	e := c.Embed
+	checkProvider(p, "embed", e.Provider, e.APIKey, e.Endpoint, embedProviders)
+	if e.Parallelism < 0 {
+		p.add("embed.parallelism must not be negative")
+	}
+	if e.MaxRetries < 0 || e.RetryDelay < 0 || e.MaxRetryDelay < 0 {
+		p.add("embed.max_retries, embed.retry_delay and embed.max_retry_delay must not be negative")
+	}
+	checkEmbedRateLimit(p, "embed.rate_limit", e.RateLimit)
+
+	// 嵌入模型的输出维度必须与向量索引一致，否则写入或检索时才会失败
+	if e.Dimensions > 0 {
+		if c.VectorDB.Dim > 0 && e.Dimensions != c.VectorDB.Dim {
+			p.add("embed.dimensions (%d) does not match vectordb.dim (%d)", e.Dimensions, c.VectorDB.Dim)
+		}
+	} else if dim, ok := embedModelDimensions[e.Model]; ok && c.VectorDB.Dim > 0 && dim != c.VectorDB.Dim {
+		p.add("embedding model %s produces %d-dimensional vectors but vectordb.dim is %d; set vectordb.dim to %d or embed.dimensions to %d",
+			e.Model, dim, c.VectorDB.Dim, dim, c.VectorDB.Dim)
+	}
+
+	otherRoutes := 0
+	for i, route := range e.Routes {
+		name := fmt.Sprintf("embed.routes[%d]", i)
+		checkProvider(p, name, route.Provider, route.APIKey, route.Endpoint, embedProviders)
+		checkEmbedRateLimit(p, name+".rate_limit", route.RateLimit)
+		if len(route.Languages) == 0 {
+			p.add("%s.languages must list at least one language code or other", name)
+		}
+		if contains(route.Languages, "other") {
+			otherRoutes++
+		}
+	}
+	if otherRoutes > 1 {
+		p.add("only one embed route may include the other language")
+	}
+}
+
+func (c *Config) validateCache(p *problems) {
+	if !c.Cache.Enable {
+		return
+	}
+	switch c.Cache.Type {
+	case "memory":
+	case "redis":
+		if c.Cache.Address == "" {
+			p.add("cache.address is required when cache.type is redis")
+		}
+	default:
+		p.add("cache.type must be memory or redis, got %q", c.Cache.Type)
+	}
+	if c.Cache.TTL < 0 {
+		p.add("cache.ttl must not be negative")
+	}
+	if c.Cache.RefreshAfter < 0 || (c.Cache.TTL > 0 && c.Cache.RefreshAfter >= c.Cache.TTL) {
+		p.add("cache.refresh_after must be at least 0 and smaller than cache.ttl (%d), got %d", c.Cache.TTL, c.Cache.RefreshAfter)
+	}
+}
+
+func (c *Config) validateQueue(p *problems) {
+	q := c.Queue
+	if !q.Enable {
+		return
+	}
+	// 目前只实现了Redis队列
+	if q.Type != "" && q.Type != "redis" {
+		p.add("queue.type must be redis, got %q", q.Type)
+	}
+	if q.RedisAddr == "" {
+		p.add("queue.redis_addr is required when queue.enable is true")
+	}
+	if q.Concurrency <= 0 {
+		p.add("queue.concurrency must be positive, got %d", q.Concurrency)
+	}
+	switch q.Worker {
+	case "", "python":
+		if c.PythonService.BaseURL == "" {
+			p.add("python_service.base_url is required when queue.worker is python")
+		}
+	case "go":
+	default:
+		p.add("queue.worker must be python or go, got %q", q.Worker)
+	}
+}
+
+func (c *Config) validateDatabase(p *problems) {
+	d := c.Database
+	switch strings.ToLower(d.Type) {
+	case "", "sqlite", "sqlite3", "mysql", "mariadb", "postgres", "postgresql", "pg":
+	default:
+		p.add("database.type must be sqlite, mysql or postgres, got %q", d.Type)
+	}
+	if d.DSN == "" {
+		p.add("database.dsn is required")
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 || d.ConnectRetries < 0 {
+		p.add("database.max_open_conns, max_idle_conns and connect_retries must not be negative")
+	}
+}
+
+func (c *Config) validateDocument(p *problems) {
+	d := c.Document
+	if d.ChunkSize <= 0 {
+		p.add("document.chunk_size must be positive, got %d", d.ChunkSize)
+	} else if d.ChunkOverlap < 0 || d.ChunkOverlap >= d.ChunkSize {
+		p.add("document.chunk_overlap must be at least 0 and smaller than document.chunk_size (%d), got %d", d.ChunkSize, d.ChunkOverlap)
+	}
+	switch d.SplitType {
+	case "paragraph", "sentence":
+	case "semantic":
+		if d.BreakpointThreshold < 0 || d.BreakpointThreshold > 100 {
+			p.add("document.breakpoint_threshold must be a percentile between 0 and 100, got %g", d.BreakpointThreshold)
+		}
+	default:
+		p.add("document.split_type must be paragraph, sentence or semantic, got %q", d.SplitType)
+	}
+
+	if d.Dedup.Enable {
+		if d.Dedup.Mode != "skip" && d.Dedup.Mode != "flag" {
+			p.add("document.dedup.mode must be skip or flag, got %q", d.Dedup.Mode)
+		}
+		if d.Dedup.MaxDistance < 0 || d.Dedup.MaxDistance > 63 {
+			p.add("document.dedup.max_distance must be between 0 and 63, got %d", d.Dedup.MaxDistance)
+		}
+	}
+	if d.Clean.Enable {
+		for _, rule := range d.Clean.Rules {
+			if !contains(cleanRules, rule) {
+				p.add("document.clean.rules contains unknown rule %q, use %s", rule, strings.Join(cleanRules, ", "))
+			}
+		}
+		for _, pattern := range d.Clean.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				p.add("document.clean.patterns contains invalid pattern %q: %v", pattern, err)
+			}
+		}
+		if d.Clean.MinRepeats < 2 {
+			p.add("document.clean.min_repeats must be at least 2, got %d", d.Clean.MinRepeats)
+		}
+	}
+	if d.Images.MinBytes < 0 || d.Images.MaxPerDocument < 0 {
+		p.add("document.images.min_bytes and max_per_document must not be negative")
+	}
+	if d.Images.Enable {
+		provider := d.Images.Provider
+		if provider == "" {
+			provider = c.LLM.Provider
+		}
+		switch provider {
+		case "anthropic", "claude", "gemini", "google":
+		default:
+			p.add("document.images requires a vision provider (anthropic or gemini), got %q", provider)
+		}
+	}
+}
+
+func (c *Config) validateSearch(p *problems) {
+	s := c.Search
+	if s.Limit <= 0 {
+		p.add("search.limit must be positive, got %d", s.Limit)
+	}
+	if s.MinScore < 0 || s.MinScore > 1 {
+		p.add("search.min_score must be between 0 and 1, got %g", s.MinScore)
+	}
+	if s.MMREnabled && (s.MMRLambda < 0 || s.MMRLambda > 1) {
+		p.add("search.mmr_lambda must be between 0 and 1, got %g", s.MMRLambda)
+	}
+	if s.QueryRewrite && (s.RewriteCount < 1 || s.RewriteCount > 5) {
+		p.add("search.rewrite_count must be between 1 and 5 when search.query_rewrite is true, got %d", s.RewriteCount)
+	}
+	if s.ParentWindow < 0 || s.ParentWindow > 20 {
+		p.add("search.parent_window must be between 0 and 20, got %d", s.ParentWindow)
+	}
+	if s.MaxLimit < 0 || (s.MaxLimit > 0 && s.MaxLimit < s.Limit) {
+		p.add("search.max_limit must be at least search.limit (%d), got %d", s.Limit, s.MaxLimit)
+	}
+	if s.MaxMMRCandidates < 0 {
+		p.add("search.max_mmr_candidates must not be negative, got %d", s.MaxMMRCandidates)
+	}
+	if s.AutoMinScore && (s.CalibrationPercentile <= 0 || s.CalibrationPercentile > 100) {
+		p.add("search.calibration_percentile must be between 0 and 100 when search.auto_min_score is true, got %g", s.CalibrationPercentile)
+	}
+	if s.CalibrationQuestions < 0 {
+		p.add("search.calibration_questions must not be negative, got %d", s.CalibrationQuestions)
+	}
+	if b := s.Boost; b.Enable {
+		if !contains(boostDecays, b.Decay) {
+			p.add("search.boost.decay must be one of %s, got %q", strings.Join(boostDecays, ", "), b.Decay)
+		}
+		if b.RecencyWeight < 0 || b.RecencyWeight > 1 {
+			p.add("search.boost.recency_weight must be between 0 and 1, got %g", b.RecencyWeight)
+		}
+		if b.RecencyWeight > 0 && b.HalfLife <= 0 {
+			p.add("search.boost.half_life must be positive when search.boost.recency_weight is set, got %s", b.HalfLife)
+		}
+		for name, weight := range b.Collections {
+			if weight < 0 {
+				p.add("search.boost.collections.%s must not be negative, got %g", name, weight)
+			}
+		}
+	}
+}
+
+// validateFeatures 检查可选功能启用时依赖的配置
+func (c *Config) validateFeatures(p *problems) {
+	if g := c.Guardrail; g.Enable {
+		if len(g.Providers) == 0 {
+			p.add("guardrail.providers must not be empty when guardrail.enable is true")
+		}
+		for _, provider := range g.Providers {
+			switch provider {
+			case "keyword", "llm_judge":
+			case "api":
+				if g.APIBaseURL == "" {
+					p.add("guardrail.api_base_url is required when guardrail.providers includes api")
+				}
+			default:
+				p.add("guardrail.providers contains unknown provider %q, use keyword, api or llm_judge", provider)
+			}
+		}
+	}
+
+	if g := c.Grounding; g.Enable {
+		if !contains([]string{"", "llm", "nli"}, g.Verifier) {
+			p.add("grounding.verifier must be llm or nli, got %q", g.Verifier)
+		}
+		if g.Verifier == "nli" && c.PythonService.BaseURL == "" {
+			p.add("python_service.base_url is required when grounding.verifier is nli")
+		}
+		if g.MinConfidence < 0 || g.MinConfidence > 1 {
+			p.add("grounding.min_confidence must be between 0 and 1, got %g", g.MinConfidence)
+		}
+	}
+
+	if r := c.RateLimit; r.Enable {
+		switch r.Backend {
+		case "", "memory":
+		case "redis":
+			if r.RedisAddr == "" && c.Queue.RedisAddr == "" {
+				p.add("rate_limit.redis_addr (or queue.redis_addr) is required when rate_limit.backend is redis")
+			}
+		default:
+			p.add("rate_limit.backend must be memory or redis, got %q", r.Backend)
+		}
+		if r.Rate <= 0 || r.Burst <= 0 {
+			p.add("rate_limit.rate and rate_limit.burst must be positive when rate_limit.enable is true")
+		}
+	}
+
+	if c.Translation.Enable && c.Translation.IndexLanguage == "" {
+		p.add("translation.index_language is required when translation.enable is true")
+	}
+
+	switch c.Intent.Classifier {
+	case "", "rule", "llm":
+	default:
+		p.add("intent.classifier must be rule or llm, got %q", c.Intent.Classifier)
+	}
+
+	if c.Quota.Enable {
+		seen := make(map[string]bool)
+		for i, t := range c.Quota.Tenants {
+			if t.Tenant == "" {
+				p.add("quota.tenants[%d].tenant must not be empty", i)
+			} else if seen[t.Tenant] {
+				p.add("quota.tenants contains duplicate tenant %q", t.Tenant)
+			}
+			seen[t.Tenant] = true
+		}
+	}
+
+	seenKeys := make(map[string]bool)
+	for i, key := range c.Auth.Keys {
+		name := fmt.Sprintf("auth.keys[%d]", i)
+		switch {
+		case strings.HasPrefix(key.Key, "${") && strings.HasSuffix(key.Key, "}"):
+			p.add("%s.key references environment variable %s which is not set", name, key.Key[2:len(key.Key)-1])
+		case key.Key == "":
+			p.add("%s.key must not be empty", name)
+		case seenKeys[key.Key]:
+			p.add("auth.keys contains a duplicate key at index %d", i)
+		}
+		seenKeys[key.Key] = true
+		if strings.TrimSpace(key.Tenant) == "" {
+			p.add("%s.tenant must not be empty", name)
+		} else if key.Tenant == "anonymous" {
+			// 未认证的请求归属anonymous租户，密钥不能使用这个租户
+			p.add("%s.tenant must not be \"anonymous\"", name)
+		}
+	}
+	if token := c.Auth.AdminToken; strings.HasPrefix(token, "${") && strings.HasSuffix(token, "}") {
+		p.add("auth.admin_token references environment variable %s which is not set", token[2:len(token)-1])
+	} else if token != "" && seenKeys[token] {
+		// 管理员令牌与API密钥相同时，持有该密钥的租户也能调用管理接口
+		p.add("auth.admin_token must not be the same as an API key")
+	}
+
+	if c.Scheduler.Enable {
+		seen := make(map[string]bool)
+		for i, job := range c.Scheduler.Jobs {
+			name := fmt.Sprintf("scheduler.jobs[%d]", i)
+			if job.Name == "" {
+				p.add("%s.name must not be empty", name)
+			} else if seen[job.Name] {
+				p.add("scheduler.jobs contains duplicate name %q", job.Name)
+			}
+			seen[job.Name] = true
+			if !contains([]string{"recrawl", "reembed", "gc"}, job.Type) {
+				p.add("%s.type must be recrawl, reembed or gc, got %q", name, job.Type)
+			}
+			if job.Schedule == "" {
+				p.add("%s.schedule must not be empty", name)
+			}
+		}
+	}
+
+	seenConnectors := make(map[string]bool)
+	for i, conn := range c.Connectors {
+		name := fmt.Sprintf("connectors[%d]", i)
+		if conn.Name == "" {
+			p.add("%s.name must not be empty", name)
+		} else if seenConnectors[conn.Name] {
+			p.add("connectors contains duplicate name %q", conn.Name)
+		}
+		seenConnectors[conn.Name] = true
+		if !contains(connectorTypes, conn.Type) {
+			p.add("%s.type must be one of %s, got %q", name, strings.Join(connectorTypes, ", "), conn.Type)
+		}
+		if conn.Type == "confluence" && conn.BaseURL == "" {
+			p.add("%s.base_url is required for confluence", name)
+		}
+	}
+
+	if c.Events.Enable {
+		for i, publisher := range c.Events.Publishers {
+			name := fmt.Sprintf("events.publishers[%d]", i)
+			if publisher.Type != "nats" && publisher.Type != "kafka" {
+				p.add("%s.type must be nats or kafka, got %q", name, publisher.Type)
+			}
+			if publisher.URL == "" {
+				p.add("%s.url must not be empty", name)
+			}
+		}
+	}
+
+	if l := c.LLMLog; l.Enable {
+		switch l.Sink {
+		case "database", "stdout":
+		case "file":
+			if l.Path == "" {
+				p.add("llm_log.path is required when llm_log.sink is file")
+			}
+		default:
+			p.add("llm_log.sink must be database, stdout or file, got %q", l.Sink)
+		}
+		for _, rule := range l.Redact {
+			if !contains(redactRules, rule) {
+				p.add("llm_log.redact contains unknown rule %q, use %s", rule, strings.Join(redactRules, ", "))
+			}
+		}
+		for _, pattern := range l.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				p.add("llm_log.patterns contains invalid pattern %q: %v", pattern, err)
+			}
+		}
+		if l.MaxChars < 0 || l.Retention < 0 {
+			p.add("llm_log.max_chars and llm_log.retention must not be negative")
+		}
+	}
+
+	if c.Chaos.Enable {
+		keys := make([]string, 0, len(c.Chaos.Faults))
+		for key := range c.Chaos.Faults {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fault := c.Chaos.Faults[key]
+			if !contains([]string{"embedding", "llm", "llm_fallback", "vectordb", "queue"}, key) {
+				p.add("chaos.faults contains unknown dependency %q", key)
+			}
+			if fault.ErrorRate < 0 || fault.ErrorRate > 1 {
+				p.add("chaos.faults.%s.error_rate must be between 0 and 1, got %g", key, fault.ErrorRate)
+			}
+		}
+	}
+}
+
+// checkEmbedRateLimit 检查嵌入服务的限流配置
+func checkEmbedRateLimit(p *problems, section string, rl EmbedRateLimitConfig) {
+	if rl.RequestsPerSecond < 0 || rl.TokensPerMinute < 0 {
+		p.add("%s values must not be negative", section)
+	}
+}
+
+// checkProvider 检查提供商名称是否受支持，以及需要密钥的提供商是否配置了API密钥
+func checkProvider(p *problems, section, provider, apiKey, endpoint string, known []string) {
+	if !contains(known, provider) {
+		p.add("%s.provider must be one of %s, got %q", section, strings.Join(known, ", "), provider)
+		return
+	}
+	if contains(keylessProviders, provider) {
+		return
+	}
+	// 指向自建OpenAI兼容服务时允许不配置密钥
+	if provider == "openai" && endpoint != "" && !strings.Contains(endpoint, "api.openai.com") {
+		return
+	}
+	switch {
+	case strings.HasPrefix(apiKey, "${") && strings.HasSuffix(apiKey, "}"):
+		p.add("%s.api_key references environment variable %s which is not set", section, apiKey[2:len(apiKey)-1])
+	case apiKey == "":
+		p.add("%s.api_key is required for provider %s", section, provider)
+	}
+}
+
+// contains 判断字符串切片是否包含指定值
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
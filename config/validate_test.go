@@ -0,0 +1,225 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateAuthKeys 测试登记的API密钥必须有密钥和租户，且不能重复
+func TestValidateAuthKeys(t *testing.T) {
+	cfg := validConfig()
+	cfg.Auth.Keys = []APIKeyConfig{
+		{Key: "sk-a", Tenant: "team-a"},
+		{Key: "sk-a", Tenant: "team-b"},
+		{Key: "${DOCQA_TEST_UNSET_KEY}", Tenant: "team-c"},
+		{Key: "sk-d", Tenant: "anonymous"},
+		{Key: "", Tenant: ""},
+	}
+	err := cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, []string{
+		"auth.keys contains a duplicate key at index 1",
+		"auth.keys[2].key references environment variable DOCQA_TEST_UNSET_KEY which is not set",
+		`auth.keys[3].tenant must not be "anonymous"`,
+		"auth.keys[4].key must not be empty",
+		"auth.keys[4].tenant must not be empty",
+	}, verr.Problems)
+
+	cfg.Auth.Keys = []APIKeyConfig{{Key: "sk-a", Tenant: "team-a", Groups: []string{"finance"}}}
+	assert.NoError(t, cfg.Validate())
+
+	// 管理员令牌不能引用未设置的环境变量，也不能与API密钥相同
+	for token, problem := range map[string]string{
+		"${DOCQA_TEST_UNSET_TOKEN}": "auth.admin_token references environment variable DOCQA_TEST_UNSET_TOKEN which is not set",
+		"sk-a":                      "auth.admin_token must not be the same as an API key",
+	} {
+		cfg.Auth.AdminToken = token
+		err = cfg.Validate()
+		require.True(t, errors.As(err, &verr))
+		assert.Equal(t, []string{problem}, verr.Problems)
+	}
+	cfg.Auth.AdminToken = "admin-secret"
+	assert.NoError(t, cfg.Validate())
+}
+
+// validConfig 返回一份能通过校验的配置
+func validConfig() *Config {
+	return &Config{
+		Server:        ServerConfig{Port: 8080, GRPCPort: 9090},
+		Storage:       StorageConfig{Type: "local", Path: "./uploads", UploadPartSize: 8 << 20},
+		VectorDB:      VectorDBConfig{Type: "faiss", Dim: 1024, Distance: "cosine"},
+		LLM:           LLMConfig{Provider: "tongyi", APIKey: "sk-llm", MaxTokens: 1000, Temperature: 0.7},
+		Embed:         EmbedConfig{Provider: "tongyi", Model: "text-embedding-v3", APIKey: "sk-embed"},
+		Cache:         CacheConfig{Enable: true, Type: "memory"},
+		Queue:         QueueConfig{Enable: true, Type: "redis", RedisAddr: "localhost:6379", Concurrency: 10, Worker: "python"},
+		Database:      DatabaseConfig{Type: "sqlite", DSN: "data/docqa.db"},
+		Document:      DocumentConfig{ChunkSize: 1000, ChunkOverlap: 200, SplitType: "sentence"},
+		Search:        SearchConfig{Limit: 10, MinScore: 0.5},
+		PythonService: PythonServiceConfig{BaseURL: "http://localhost:8000/api"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+
+	cfg := validConfig()
+	cfg.Queue.RedisAddr = ""
+	cfg.Embed.Dimensions = 768
+	cfg.Cache.TTL, cfg.Cache.RefreshAfter = 600, 600
+	cfg.LLM.APIKey = "${DOCQA_TEST_UNSET_KEY}"
+	cfg.LLM.Models = []LLMModelConfig{
+		{Name: "fast", Provider: "openai", Endpoint: "http://vllm:8000/v1"},
+		{Name: "fast", Provider: "anthropic", APIKey: "sk-ant"},
+	}
+	cfg.LLM.PromptVariants = []PromptVariantConfig{{Name: "concise", Template: "简洁回答"}}
+	cfg.Document.ChunkOverlap = 1000
+	cfg.Document.Dedup = DedupConfig{Enable: true, Mode: "drop", MaxDistance: 3}
+	cfg.Document.Clean = CleanConfig{Enable: true, Rules: []string{"footnotes"}, MinRepeats: 3}
+	cfg.Search.MaxLimit = 5
+	cfg.Search.AutoMinScore = true
+	cfg.Search.Boost = BoostConfig{Enable: true, Decay: "step", RecencyWeight: 0.3, HalfLife: 24 * time.Hour}
+	cfg.Guardrail = GuardrailConfig{Enable: true, Providers: []string{"api", "regex"}}
+	cfg.Scheduler = SchedulerConfig{Enable: true, Jobs: []SchedulerJobConfig{
+		{Name: "gc", Type: "gc", Schedule: "@daily"},
+		{Name: "gc", Type: "cleanup", Schedule: "@daily"},
+	}}
+	cfg.Connectors = []ConnectorConfig{
+		{Name: "wiki", Type: "confluence"},
+		{Name: "wiki", Type: "dropbox"},
+	}
+	cfg.Events = EventsConfig{Enable: true, Publishers: []EventPublisherConfig{
+		{Type: "nats", URL: "nats://localhost:4222"},
+		{Type: "kinesis"},
+	}}
+	cfg.LLMLog = LLMLogConfig{Enable: true, Sink: "file", Redact: []string{"email", "ssn"}}
+
+	err := cfg.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	// 所有问题一次返回
+	assert.Equal(t, []string{
+		"llm.api_key references environment variable DOCQA_TEST_UNSET_KEY which is not set",
+		`llm.models contains duplicate name "fast"`,
+		"llm.prompt_variants[0].template must contain {{.Question}}",
+		"llm.prompt_variants must have at least one variant with a positive weight",
+		"embed.dimensions (768) does not match vectordb.dim (1024)",
+		"cache.refresh_after must be at least 0 and smaller than cache.ttl (600), got 600",
+		"queue.redis_addr is required when queue.enable is true",
+		"document.chunk_overlap must be at least 0 and smaller than document.chunk_size (1000), got 1000",
+		`document.dedup.mode must be skip or flag, got "drop"`,
+		`document.clean.rules contains unknown rule "footnotes", use page_numbers, headers_footers, copyright, blank_lines`,
+		"search.max_limit must be at least search.limit (10), got 5",
+		"search.calibration_percentile must be between 0 and 100 when search.auto_min_score is true, got 0",
+		`search.boost.decay must be one of exponential, linear, gauss, got "step"`,
+		"guardrail.api_base_url is required when guardrail.providers includes api",
+		`guardrail.providers contains unknown provider "regex", use keyword, api or llm_judge`,
+		`scheduler.jobs contains duplicate name "gc"`,
+		`scheduler.jobs[1].type must be recrawl, reembed or gc, got "cleanup"`,
+		"connectors[0].base_url is required for confluence",
+		`connectors contains duplicate name "wiki"`,
+		`connectors[1].type must be one of confluence, notion, gdrive, sharepoint, got "dropbox"`,
+		`events.publishers[1].type must be nats or kafka, got "kinesis"`,
+		"events.publishers[1].url must not be empty",
+		"llm_log.path is required when llm_log.sink is file",
+		`llm_log.redact contains unknown rule "ssn", use email, id_card, phone, bank_card, ip`,
+	}, verr.Problems)
+	assert.Contains(t, err.Error(), "24 problems")
+}
+
+func TestValidateProviders(t *testing.T) {
+	// 未配置维度时按已知模型的默认维度检查
+	cfg := validConfig()
+	cfg.Embed.Provider = "openai"
+	cfg.Embed.Model = "text-embedding-3-small"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "produces 1536-dimensional vectors but vectordb.dim is 1024")
+
+	// 维度不一致时的处理方式只能是auto、fail或off
+	cfg = validConfig()
+	cfg.VectorDB.OnDimensionMismatch = "recreate"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `vectordb.on_dimension_mismatch must be auto, fail or off, got "recreate"`)
+
+	// 远程向量索引服务需要http地址
+	cfg = validConfig()
+	cfg.VectorDB.Type = "faiss-server"
+	cfg.VectorDB.Path = "./vectordb"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `vectordb.path must be the http(s) address of the vector index service when vectordb.type is faiss-server, got "./vectordb"`)
+	cfg.VectorDB.Path = "http://faiss:7070"
+	assert.NoError(t, cfg.Validate())
+
+	// 指向自建的OpenAI兼容服务时不要求密钥
+	cfg = validConfig()
+	cfg.LLM = LLMConfig{Provider: "openai", Endpoint: "http://vllm:8000/v1"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.LLM.Endpoint = "https://api.openai.com/v1"
+	cfg.LLM.Fallbacks = []LLMFallbackConfig{{Provider: "mistral"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "llm.api_key is required for provider openai")
+	assert.Contains(t, err.Error(), `llm.fallbacks[0].provider must be one of`)
+
+	// 本地嵌入模型不需要密钥
+	cfg = validConfig()
+	cfg.Embed = EmbedConfig{Provider: "local"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateReplicas(t *testing.T) {
+	// 多实例部署不能使用进程内的向量数据库
+	cfg := validConfig()
+	cfg.Server.Replicas = 3
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vectordb.type faiss keeps vectors in process and cannot be shared by multiple replicas")
+
+	cfg.VectorDB.Type = "pgvector"
+	cfg.VectorDB.Path = "postgres://localhost/docqa"
+	assert.NoError(t, cfg.Validate())
+
+	// 只读副本需要主实例地址
+	cfg.Server.Role = "replica"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server.primary_url must be an http(s) URL when server.role is replica, got ""`)
+
+	cfg.Server.PrimaryURL = "http://docqa-primary:8080"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.168.1.10", "proxy.internal"}
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server.trusted_proxies contains invalid IP or CIDR "proxy.internal"`)
+	cfg.Server.TrustedProxies = cfg.Server.TrustedProxies[:2]
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Server.Role = "standby"
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `server.role must be primary or replica, got "standby"`)
+}
+
+func TestValidateDefaultConfigFile(t *testing.T) {
+	// 仓库自带的配置在提供了API密钥后应能通过校验
+	t.Setenv("DASHSCOPE_API_KEY", "sk-test")
+	data, err := os.ReadFile("../config.yaml")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+}
@@ -43,9 +43,15 @@ api.interceptors.response.use(
         // Handle HTTP errors
         let message = 'Network error';
         if (error.response) {
-            // Server responded with error
+            // Server responded with an RFC 7807 problem: title is the summary,
+            // detail adds context and code is the machine-readable error code
             const { data } = error.response;
-            message = (data && data.message) || `Error: ${error.response.status}`;
+            message = (data && (data.detail ? `${data.title}: ${data.detail}` : data.title || data.message))
+                || `Error: ${error.response.status}`;
+            const err = new Error(message);
+            err.code = data && data.code;
+            err.status = error.response.status;
+            return Promise.reject(err);
         } else if (error.request) {
             // Request made but no response
             message = 'Server did not respond';
@@ -0,0 +1,148 @@
+// Package acl 文档访问控制
+// 用户即请求的租户，用户和用户组都来自认证时识别的API密钥（见auth包）
+package acl
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+)
+
+// groupsKey 上下文中保存用户组的键
+type groupsKey struct{}
+
+// WithGroups 返回带有用户所属用户组的上下文
+func WithGroups(ctx context.Context, groups []string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, groups)
+}
+
+// GroupsFromContext 从上下文读取用户所属的用户组
+func GroupsFromContext(ctx context.Context) []string {
+	if ctx != nil {
+		if groups, ok := ctx.Value(groupsKey{}).([]string); ok {
+			return groups
+		}
+	}
+	return nil
+}
+
+// ParseGroups 解析逗号分隔的用户组列表，去掉空白和重复的组
+func ParseGroups(value string) []string {
+	return Names(strings.Split(value, ","))
+}
+
+// Names 整理用户或用户组名称，去掉首尾空白、空名称和重复的名称
+func Names(names []string) []string {
+	var result []string
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// Policy 文档访问控制策略
+// 文档的上传者、公开的文档和共享给用户或其所属用户组的文档可以读取，只有上传者和管理员可以修改访问控制；
+// 方法对nil接收者安全，nil表示未启用访问控制，所有人都可以读取和修改
+type Policy struct {
+	admins map[string]bool
+}
+
+// Option 访问控制策略配置选项
+type Option func(*Policy)
+
+// WithAdmins 设置管理员，管理员可以读取所有文档并修改任何文档的访问控制
+func WithAdmins(admins ...string) Option {
+	return func(p *Policy) {
+		for _, admin := range admins {
+			if admin != "" {
+				p.admins[admin] = true
+			}
+		}
+	}
+}
+
+// NewPolicy 创建访问控制策略
+func NewPolicy(opts ...Option) *Policy {
+	p := &Policy{admins: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Reader 返回上下文中的用户，用户可以读取所有文档（未启用访问控制或是管理员）时ok为false
+// 匿名请求不是确定的用户，User为空，只能读取公开的文档
+func (p *Policy) Reader(ctx context.Context) (reader repository.DocumentReader, ok bool) {
+	if p == nil {
+		return repository.DocumentReader{}, false
+	}
+	user := usage.TenantFromContext(ctx)
+	if user == usage.AnonymousTenant {
+		return repository.DocumentReader{}, true
+	}
+	if p.admins[user] {
+		return repository.DocumentReader{}, false
+	}
+	return repository.DocumentReader{User: user, Groups: GroupsFromContext(ctx)}, true
+}
+
+// owns 判断用户是否是文档的上传者，没有记录上传者的文档和匿名用户都不匹配
+func owns(reader repository.DocumentReader, doc *models.Document) bool {
+	return reader.User != "" && doc.Tenant == reader.User
+}
+
+// CanRead 判断上下文中的用户能否读取文档，shares为文档的共享记录
+func (p *Policy) CanRead(ctx context.Context, doc *models.Document, shares []*models.DocumentShare) bool {
+	reader, ok := p.Reader(ctx)
+	if !ok {
+		return true
+	}
+	if doc.Public || owns(reader, doc) {
+		return true
+	}
+	for _, share := range shares {
+		switch share.Kind {
+		case models.ShareKindUser:
+			if reader.User != "" && share.Name == reader.User {
+				return true
+			}
+		case models.ShareKindGroup:
+			for _, group := range reader.Groups {
+				if share.Name == group {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CanManage 判断上下文中的用户能否修改文档的访问控制
+func (p *Policy) CanManage(ctx context.Context, doc *models.Document) bool {
+	reader, ok := p.Reader(ctx)
+	return !ok || owns(reader, doc)
+}
+
+// CacheKey 返回区分用户的缓存键片段，用户可以读取所有文档时返回空字符串
+// 不同用户可以读取的文档不同，问答缓存按用户和用户组分开
+func (p *Policy) CacheKey(ctx context.Context) string {
+	reader, ok := p.Reader(ctx)
+	if !ok {
+		return ""
+	}
+	key := "u_" + reader.User
+	if len(reader.Groups) > 0 {
+		groups := append([]string(nil), reader.Groups...)
+		sort.Strings(groups)
+		key += "_g_" + strings.Join(groups, ",")
+	}
+	return key
+}
@@ -0,0 +1,72 @@
+package acl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPolicy 测试上传者、公开文档和共享对象可以读取文档，只有上传者和管理员可以修改访问控制
+func TestPolicy(t *testing.T) {
+	policy := NewPolicy(WithAdmins("ops", ""))
+	doc := &models.Document{ID: "doc", Tenant: "alice"}
+	shares := []*models.DocumentShare{
+		{DocumentID: "doc", Kind: models.ShareKindUser, Name: "bob"},
+		{DocumentID: "doc", Kind: models.ShareKindGroup, Name: "finance"},
+	}
+	as := func(user string, groups ...string) context.Context {
+		return WithGroups(usage.WithTenant(context.Background(), user), groups)
+	}
+
+	assert.True(t, policy.CanRead(as("alice"), doc, shares))
+	assert.True(t, policy.CanRead(as("bob"), doc, shares))
+	assert.True(t, policy.CanRead(as("carol", "hr", "finance"), doc, shares))
+	assert.False(t, policy.CanRead(as("carol", "hr"), doc, shares))
+	assert.False(t, policy.CanRead(context.Background(), doc, shares))
+	assert.True(t, policy.CanRead(as("ops"), doc, nil))
+	assert.True(t, policy.CanRead(as("carol"), &models.Document{Tenant: "alice", Public: true}, nil))
+
+	assert.True(t, policy.CanManage(as("alice"), doc))
+	assert.True(t, policy.CanManage(as("ops"), doc))
+	assert.False(t, policy.CanManage(as("bob"), doc))
+
+	// 没有记录上传者的旧文档和匿名上传的文档不属于任何用户，匿名用户只能读取公开文档
+	legacy := &models.Document{ID: "legacy"}
+	anonymousDoc := &models.Document{ID: "anon", Tenant: usage.AnonymousTenant}
+	anonymousShare := []*models.DocumentShare{{DocumentID: "anon", Kind: models.ShareKindUser, Name: usage.AnonymousTenant}}
+	for _, ctx := range []context.Context{context.Background(), as("carol")} {
+		assert.False(t, policy.CanRead(ctx, legacy, nil))
+		assert.False(t, policy.CanManage(ctx, legacy))
+	}
+	assert.False(t, policy.CanRead(context.Background(), anonymousDoc, anonymousShare))
+	assert.False(t, policy.CanManage(context.Background(), anonymousDoc))
+	assert.True(t, policy.CanRead(context.Background(), &models.Document{Public: true}, nil))
+	reader, ok := policy.Reader(context.Background())
+	assert.True(t, ok)
+	assert.Empty(t, reader.User)
+
+	// 管理员不受限制，其他用户返回用户和用户组
+	_, ok = policy.Reader(as("ops"))
+	assert.False(t, ok)
+	reader, ok = policy.Reader(as("carol", "hr"))
+	assert.True(t, ok)
+	assert.Equal(t, "carol", reader.User)
+	assert.Equal(t, []string{"hr"}, reader.Groups)
+
+	// 缓存键按用户和排序后的用户组区分
+	assert.Equal(t, "u_carol_g_finance,hr", policy.CacheKey(as("carol", "hr", "finance")))
+	assert.Equal(t, "u_carol", policy.CacheKey(as("carol")))
+	assert.Empty(t, policy.CacheKey(as("ops")))
+
+	// 未启用访问控制时不限制
+	var disabled *Policy
+	assert.True(t, disabled.CanRead(context.Background(), doc, nil))
+	assert.True(t, disabled.CanManage(context.Background(), doc))
+	assert.Empty(t, disabled.CacheKey(as("carol")))
+
+	assert.Equal(t, []string{"hr", "finance"}, ParseGroups(" hr,,finance, hr "))
+	assert.Nil(t, ParseGroups(""))
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+// Recorder 审计日志记录器
+// 操作者取自上下文中的租户标识，写入失败只记录日志，不影响操作本身
+type Recorder struct {
+	repo   repository.AuditRepository
+	logger *logrus.Logger
+}
+
+// NewRecorder 创建审计日志记录器
+func NewRecorder(repo repository.AuditRepository, logger *logrus.Logger) *Recorder {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &Recorder{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record 记录一次操作，记录器为nil时不做任何事
+func (r *Recorder) Record(ctx context.Context, action models.AuditAction, target, detail string) {
+	if r == nil {
+		return
+	}
+
+	entry := &models.AuditLog{
+		Actor:     usage.TenantFromContext(ctx),
+		Action:    action,
+		Target:    target,
+		Detail:    detail,
+		RequestID: requestid.FromContext(ctx),
+	}
+	if err := r.repo.WithContext(ctx).Append(entry); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"actor":  entry.Actor,
+			"action": action,
+			"target": target,
+		}).Error("Failed to write audit log")
+	}
+}
+
+// List 按条件分页查询审计日志
+func (r *Recorder) List(ctx context.Context, filter repository.AuditFilter, offset, limit int) ([]*models.AuditLog, int64, error) {
+	return r.repo.WithContext(ctx).List(filter, offset, limit)
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupAuditTestDB 创建内存数据库并替换全局连接
+func setupAuditTestDB(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_audit_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AuditLog{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+}
+
+// TestRecorder 测试审计日志的记录、过滤和分页
+func TestRecorder(t *testing.T) {
+	setupAuditTestDB(t)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	recorder := NewRecorder(repository.NewAuditRepository(), logger)
+
+	teamA := requestid.WithRequestID(usage.WithTenant(context.Background(), "team-a"), "req-1")
+	recorder.Record(teamA, models.AuditActionDeleteDocument, "doc-1", "manual.pdf")
+	recorder.Record(teamA, models.AuditActionUpdateTags, "doc-2", `"a" -> "b"`)
+	recorder.Record(context.Background(), models.AuditActionClearCache, "qa", "")
+
+	ctx := context.Background()
+	entries, total, err := recorder.List(ctx, repository.AuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, entries, 3)
+	// 最新的在前
+	assert.Equal(t, models.AuditActionClearCache, entries[0].Action)
+	assert.Equal(t, usage.AnonymousTenant, entries[0].Actor)
+
+	entries, total, err = recorder.List(ctx, repository.AuditFilter{Actor: "team-a"}, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, entries, 1)
+	assert.Equal(t, models.AuditActionUpdateTags, entries[0].Action)
+
+	entries, _, err = recorder.List(ctx, repository.AuditFilter{Action: models.AuditActionDeleteDocument}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "team-a", entries[0].Actor)
+	assert.Equal(t, "doc-1", entries[0].Target)
+	assert.Equal(t, "manual.pdf", entries[0].Detail)
+	assert.Equal(t, "req-1", entries[0].RequestID)
+	assert.False(t, entries[0].CreatedAt.IsZero())
+
+	_, total, err = recorder.List(ctx, repository.AuditFilter{Since: time.Now().Add(time.Hour)}, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// 未配置记录器时不记录也不报错
+	var disabled *Recorder
+	disabled.Record(ctx, models.AuditActionDeleteChat, "session-1", "")
+}
@@ -0,0 +1,95 @@
+// Package auth 识别请求的客户端身份
+// 身份只来自服务端登记的API密钥：客户端自行设置的租户和用户组请求头无法验证，不用于访问控制、配额和限流
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+)
+
+// Key 登记的API密钥
+type Key struct {
+	Key    string   // 密钥
+	Tenant string   // 密钥所属的租户
+	Groups []string // 租户所属的用户组，用于文档访问控制
+}
+
+// Identity 已认证的客户端身份
+type Identity struct {
+	Tenant string   // 租户
+	Groups []string // 用户组
+}
+
+// Authenticator 按登记的API密钥识别客户端
+// 方法对nil接收者安全，nil表示没有登记密钥，所有请求都是匿名的
+type Authenticator struct {
+	keys map[[sha256.Size]byte]Identity
+}
+
+// NewAuthenticator 创建认证器，密钥或租户为空的条目被忽略
+// 密钥按哈希保存，查找时不逐字节比较明文，避免通过响应时间猜测密钥
+func NewAuthenticator(keys ...Key) *Authenticator {
+	a := &Authenticator{keys: make(map[[sha256.Size]byte]Identity, len(keys))}
+	for _, key := range keys {
+		tenant := strings.TrimSpace(key.Tenant)
+		if key.Key == "" || tenant == "" || tenant == usage.AnonymousTenant {
+			continue
+		}
+		a.keys[sha256.Sum256([]byte(key.Key))] = Identity{Tenant: tenant, Groups: acl.Names(key.Groups)}
+	}
+	return a
+}
+
+// Authenticate 返回API密钥对应的身份，密钥为空或未登记时ok为false
+func (a *Authenticator) Authenticate(key string) (Identity, bool) {
+	if a == nil || key == "" {
+		return Identity{}, false
+	}
+	identity, ok := a.keys[sha256.Sum256([]byte(key))]
+	return identity, ok
+}
+
+// RequestKey 返回请求携带的API密钥，优先使用X-API-Key，其次使用Authorization: Bearer令牌
+func RequestKey(apiKey, authorization string) string {
+	if apiKey != "" {
+		return apiKey
+	}
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// clientAddrKey 上下文中保存请求来源地址的键
+type clientAddrKey struct{}
+
+// WithClientAddress 返回带有请求来源地址的上下文，匿名请求按来源地址限流
+func WithClientAddress(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, clientAddrKey{}, addr)
+}
+
+// ClientFromContext 返回限流使用的客户端标识
+// 已认证的请求为租户，匿名请求为"ip:"加来源地址，都没有时返回空字符串
+func ClientFromContext(ctx context.Context) string {
+	if tenant := usage.TenantFromContext(ctx); tenant != usage.AnonymousTenant {
+		return tenant
+	}
+	if addr, ok := ctx.Value(clientAddrKey{}).(string); ok && addr != "" {
+		return "ip:" + addr
+	}
+	return ""
+}
+
+// WithIdentity 返回带有客户端身份的上下文
+// 租户用于用量统计、配额、限流和文档访问控制，用户组用于文档访问控制
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	ctx = usage.WithTenant(ctx, identity.Tenant)
+	if len(identity.Groups) > 0 {
+		ctx = acl.WithGroups(ctx, identity.Groups)
+	}
+	return ctx
+}
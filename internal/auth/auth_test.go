@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAuthenticate 测试只有登记的API密钥才能识别为租户
+func TestAuthenticate(t *testing.T) {
+	a := NewAuthenticator(
+		Key{Key: "sk-finance", Tenant: "alice", Groups: []string{" finance ", "finance"}},
+		Key{Key: "sk-empty", Tenant: " "},
+		Key{Key: "sk-anonymous", Tenant: usage.AnonymousTenant},
+	)
+
+	identity, ok := a.Authenticate("sk-finance")
+	assert.True(t, ok)
+	assert.Equal(t, Identity{Tenant: "alice", Groups: []string{"finance"}}, identity)
+
+	for _, key := range []string{"", "sk-unknown", "sk-empty", "sk-anonymous"} {
+		_, ok := a.Authenticate(key)
+		assert.False(t, ok, key)
+	}
+	_, ok = (*Authenticator)(nil).Authenticate("sk-finance")
+	assert.False(t, ok)
+
+	ctx := WithIdentity(context.Background(), identity)
+	assert.Equal(t, "alice", usage.TenantFromContext(ctx))
+	assert.Equal(t, []string{"finance"}, acl.GroupsFromContext(ctx))
+}
+
+// TestRequestKey 测试从请求头取出API密钥
+func TestRequestKey(t *testing.T) {
+	assert.Equal(t, "sk-a", RequestKey("sk-a", "Bearer sk-b"))
+	assert.Equal(t, "sk-b", RequestKey("", "Bearer sk-b"))
+	assert.Equal(t, "", RequestKey("", "Basic dXNlcjpwYXNz"))
+	assert.Equal(t, "", RequestKey("", ""))
+}
+
+// TestClientFromContext 测试限流使用的客户端标识优先取已认证的租户，匿名请求取来源地址
+func TestClientFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", ClientFromContext(ctx))
+
+	ctx = WithClientAddress(ctx, "10.0.0.1")
+	assert.Equal(t, "ip:10.0.0.1", ClientFromContext(ctx))
+
+	ctx = WithIdentity(ctx, Identity{Tenant: "team-a"})
+	assert.Equal(t, "team-a", ClientFromContext(ctx))
+}
@@ -0,0 +1,192 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Target 故障注入的目标依赖
+type Target string
+
+// 支持的注入目标
+const (
+	TargetEmbedding   Target = "embedding"    // 嵌入模型调用
+	TargetLLM         Target = "llm"          // 大模型调用（主提供商）
+	TargetLLMFallback Target = "llm_fallback" // 备用大模型提供商调用
+	TargetVectorDB    Target = "vectordb"     // 向量数据库调用
+	TargetQueue       Target = "queue"        // 任务队列调用
+)
+
+// ErrInjected 注入的故障错误
+// 包装后的错误可通过errors.Is(err, ErrInjected)识别
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Fault 单个目标的故障配置
+type Fault struct {
+	ErrorRate float64       // 返回错误的概率(0-1)
+	Latency   time.Duration // 固定附加延迟
+	Jitter    time.Duration // 在固定延迟上附加的随机抖动上限
+	Ops       []string      // 只对指定的操作生效，为空表示所有操作
+}
+
+// Config 故障注入配置
+type Config struct {
+	Enabled bool             // 是否启用故障注入
+	Seed    int64            // 随机数种子，0表示使用当前时间
+	Faults  map[Target]Fault // 各目标的故障配置
+}
+
+// Option 配置选项函数类型
+type Option func(*Config)
+
+// WithEnabled 设置是否启用故障注入
+func WithEnabled(enabled bool) Option {
+	return func(c *Config) {
+		c.Enabled = enabled
+	}
+}
+
+// WithSeed 设置随机数种子，便于复现
+func WithSeed(seed int64) Option {
+	return func(c *Config) {
+		c.Seed = seed
+	}
+}
+
+// WithFault 设置指定目标的故障配置
+func WithFault(target Target, fault Fault) Option {
+	return func(c *Config) {
+		if c.Faults == nil {
+			c.Faults = make(map[Target]Fault)
+		}
+		c.Faults[target] = fault
+	}
+}
+
+// Stats 注入统计
+type Stats struct {
+	Calls    int64 // 经过注入器的调用次数
+	Failures int64 // 注入的失败次数
+	Delays   int64 // 注入延迟的次数
+}
+
+// Injector 故障注入器
+// 根据配置对依赖调用随机注入错误和延迟
+type Injector struct {
+	config Config
+	rng    *rand.Rand
+	stats  map[Target]*Stats
+	mu     sync.Mutex
+}
+
+// NewInjector 创建故障注入器
+func NewInjector(opts ...Option) *Injector {
+	cfg := Config{
+		Faults: make(map[Target]Fault),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &Injector{
+		config: cfg,
+		rng:    rand.New(rand.NewSource(seed)),
+		stats:  make(map[Target]*Stats),
+	}
+}
+
+// Enabled 返回注入器是否启用
+func (i *Injector) Enabled() bool {
+	return i != nil && i.config.Enabled
+}
+
+// Inject 在一次依赖调用前执行故障注入
+// 先按配置等待延迟（可被ctx取消），再按概率返回注入的错误
+func (i *Injector) Inject(ctx context.Context, target Target, op string) error {
+	if !i.Enabled() {
+		return nil
+	}
+
+	fault, ok := i.config.Faults[target]
+	if !ok || !fault.matches(op) {
+		return nil
+	}
+
+	delay, fail := i.roll(target, fault)
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if fail {
+		return fmt.Errorf("%w: %s.%s", ErrInjected, target, op)
+	}
+	return nil
+}
+
+// Stats 返回指定目标的注入统计
+func (i *Injector) Stats(target Target) Stats {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if s, ok := i.stats[target]; ok {
+		return *s
+	}
+	return Stats{}
+}
+
+// roll 计算本次调用的延迟和是否失败，并更新统计
+func (i *Injector) roll(target Target, fault Fault) (time.Duration, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	s, ok := i.stats[target]
+	if !ok {
+		s = &Stats{}
+		i.stats[target] = s
+	}
+	s.Calls++
+
+	delay := fault.Latency
+	if fault.Jitter > 0 {
+		delay += time.Duration(i.rng.Int63n(int64(fault.Jitter)))
+	}
+	if delay > 0 {
+		s.Delays++
+	}
+
+	fail := fault.ErrorRate > 0 && i.rng.Float64() < fault.ErrorRate
+	if fail {
+		s.Failures++
+	}
+
+	return delay, fail
+}
+
+// matches 判断故障配置是否作用于指定操作
+func (f Fault) matches(op string) bool {
+	if len(f.Ops) == 0 {
+		return true
+	}
+	for _, o := range f.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
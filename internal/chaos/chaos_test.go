@@ -0,0 +1,112 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestInjectorDisabled 测试未启用时不注入故障
+func TestInjectorDisabled(t *testing.T) {
+	injector := NewInjector(WithFault(TargetLLM, Fault{ErrorRate: 1}))
+	assert.False(t, injector.Enabled())
+	assert.NoError(t, injector.Inject(context.Background(), TargetLLM, "generate"))
+
+	// 未启用时包装器应直接返回原客户端
+	client := llm.NewMockClient(t)
+	assert.Same(t, client, WrapLLM(client, injector))
+}
+
+// TestInjectorErrorRate 测试错误注入
+func TestInjectorErrorRate(t *testing.T) {
+	injector := NewInjector(
+		WithEnabled(true),
+		WithSeed(42),
+		WithFault(TargetLLM, Fault{ErrorRate: 1}),
+		WithFault(TargetEmbedding, Fault{ErrorRate: 0}),
+	)
+
+	ctx := context.Background()
+	err := injector.Inject(ctx, TargetLLM, "generate")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInjected))
+
+	assert.NoError(t, injector.Inject(ctx, TargetEmbedding, "embed"))
+	assert.NoError(t, injector.Inject(ctx, TargetVectorDB, "search"), "unconfigured target should pass")
+
+	stats := injector.Stats(TargetLLM)
+	assert.Equal(t, int64(1), stats.Calls)
+	assert.Equal(t, int64(1), stats.Failures)
+}
+
+// TestInjectorOps 测试只对指定操作注入
+func TestInjectorOps(t *testing.T) {
+	injector := NewInjector(
+		WithEnabled(true),
+		WithFault(TargetVectorDB, Fault{ErrorRate: 1, Ops: []string{"search"}}),
+	)
+
+	ctx := context.Background()
+	assert.Error(t, injector.Inject(ctx, TargetVectorDB, "search"))
+	assert.NoError(t, injector.Inject(ctx, TargetVectorDB, "add"))
+}
+
+// TestInjectorLatency 测试延迟注入及取消
+func TestInjectorLatency(t *testing.T) {
+	injector := NewInjector(
+		WithEnabled(true),
+		WithFault(TargetQueue, Fault{Latency: 20 * time.Millisecond}),
+	)
+
+	start := time.Now()
+	require.NoError(t, injector.Inject(context.Background(), TargetQueue, "enqueue"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// 上下文取消时应立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := injector.Inject(ctx, TargetQueue, "enqueue")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// TestWrappers 测试各依赖的包装器
+func TestWrappers(t *testing.T) {
+	injector := NewInjector(
+		WithEnabled(true),
+		WithFault(TargetLLM, Fault{ErrorRate: 1, Ops: []string{"chat"}}),
+		WithFault(TargetEmbedding, Fault{ErrorRate: 1}),
+		WithFault(TargetVectorDB, Fault{ErrorRate: 1, Ops: []string{"search"}}),
+	)
+	ctx := context.Background()
+
+	// LLM：generate放行，chat失败
+	mockLLM := llm.NewMockClient(t)
+	mockLLM.EXPECT().Generate(mock.Anything, "hi").Return(&llm.Response{Text: "ok"}, nil)
+	client := WrapLLM(mockLLM, injector)
+	resp, err := client.Generate(ctx, "hi")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Text)
+	_, err = client.Chat(ctx, []llm.Message{{Role: llm.RoleUser, Content: "hi"}})
+	assert.ErrorIs(t, err, ErrInjected)
+
+	// 嵌入：全部失败，不会调用底层客户端
+	embedder := WrapEmbedding(embedding.NewMockClient(t), injector)
+	_, err = embedder.Embed(ctx, "text")
+	assert.ErrorIs(t, err, ErrInjected)
+
+	// 向量数据库：写入放行，搜索失败
+	repo, err := vectordb.NewMemoryRepository(vectordb.Config{Dimension: 2, DistanceType: vectordb.Cosine})
+	require.NoError(t, err)
+	wrapped := WrapRepository(repo, injector)
+	require.NoError(t, wrapped.Add(vectordb.Document{ID: "a", FileID: "f", Vector: []float32{1, 0}}))
+	_, err = wrapped.Search([]float32{1, 0}, vectordb.SearchFilter{MaxResults: 1})
+	assert.ErrorIs(t, err, ErrInjected)
+}
@@ -0,0 +1,256 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+)
+
+// llmClient 注入故障的大模型客户端
+type llmClient struct {
+	llm.Client
+	injector *Injector
+	target   Target
+}
+
+// WrapLLM 为大模型客户端包装故障注入
+// 注入器未启用时直接返回原客户端
+func WrapLLM(client llm.Client, injector *Injector) llm.Client {
+	if !injector.Enabled() {
+		return client
+	}
+	return &llmClient{Client: client, injector: injector, target: TargetLLM}
+}
+
+// WrapLLMFallback 为备用大模型提供商包装故障注入，使用独立的llm_fallback目标
+func WrapLLMFallback(client llm.Client, injector *Injector) llm.Client {
+	if !injector.Enabled() {
+		return client
+	}
+	return &llmClient{Client: client, injector: injector, target: TargetLLMFallback}
+}
+
+// Generate 生成文本
+func (c *llmClient) Generate(ctx context.Context, prompt string, options ...llm.GenerateOption) (*llm.Response, error) {
+	if err := c.injector.Inject(ctx, c.target, "generate"); err != nil {
+		return nil, err
+	}
+	return c.Client.Generate(ctx, prompt, options...)
+}
+
+// Chat 进行对话
+func (c *llmClient) Chat(ctx context.Context, messages []llm.Message, options ...llm.ChatOption) (*llm.Response, error) {
+	if err := c.injector.Inject(ctx, c.target, "chat"); err != nil {
+		return nil, err
+	}
+	return c.Client.Chat(ctx, messages, options...)
+}
+
+// embeddingClient 注入故障的嵌入客户端
+type embeddingClient struct {
+	embedding.Client
+	injector *Injector
+}
+
+// WrapEmbedding 为嵌入客户端包装故障注入
+func WrapEmbedding(client embedding.Client, injector *Injector) embedding.Client {
+	if !injector.Enabled() {
+		return client
+	}
+	return &embeddingClient{Client: client, injector: injector}
+}
+
+// Embed 生成单条文本的向量表示
+func (c *embeddingClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := c.injector.Inject(ctx, TargetEmbedding, "embed"); err != nil {
+		return nil, err
+	}
+	return c.Client.Embed(ctx, text)
+}
+
+// EmbedBatch 批量生成向量表示
+func (c *embeddingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := c.injector.Inject(ctx, TargetEmbedding, "embed_batch"); err != nil {
+		return nil, err
+	}
+	return c.Client.EmbedBatch(ctx, texts)
+}
+
+// repository 注入故障的向量数据库
+// 向量数据库接口不带context，延迟无法被取消
+type repository struct {
+	vectordb.Repository
+	injector *Injector
+}
+
+// WrapRepository 为向量数据库包装故障注入
+func WrapRepository(repo vectordb.Repository, injector *Injector) vectordb.Repository {
+	if !injector.Enabled() {
+		return repo
+	}
+	return &repository{Repository: repo, injector: injector}
+}
+
+func (r *repository) inject(op string) error {
+	return r.injector.Inject(context.Background(), TargetVectorDB, op)
+}
+
+// Add 添加单个文档
+func (r *repository) Add(doc vectordb.Document) error {
+	if err := r.inject("add"); err != nil {
+		return err
+	}
+	return r.Repository.Add(doc)
+}
+
+// AddBatch 批量添加文档
+func (r *repository) AddBatch(docs []vectordb.Document) error {
+	if err := r.inject("add_batch"); err != nil {
+		return err
+	}
+	return r.Repository.AddBatch(docs)
+}
+
+// Get 获取单个文档
+func (r *repository) Get(id string) (vectordb.Document, error) {
+	if err := r.inject("get"); err != nil {
+		return vectordb.Document{}, err
+	}
+	return r.Repository.Get(id)
+}
+
+// Delete 删除单个文档
+func (r *repository) Delete(id string) error {
+	if err := r.inject("delete"); err != nil {
+		return err
+	}
+	return r.Repository.Delete(id)
+}
+
+// DeleteByFileID 删除指定文件的所有段落
+func (r *repository) DeleteByFileID(fileID string) error {
+	if err := r.inject("delete_by_file"); err != nil {
+		return err
+	}
+	return r.Repository.DeleteByFileID(fileID)
+}
+
+// Search 相似度搜索
+func (r *repository) Search(vector []float32, filter vectordb.SearchFilter) ([]vectordb.SearchResult, error) {
+	if err := r.inject("search"); err != nil {
+		return nil, err
+	}
+	return r.Repository.Search(vector, filter)
+}
+
+// SearchBatch 批量相似度搜索，与Search共用search操作的故障配置
+func (r *repository) SearchBatch(vectors [][]float32, filter vectordb.SearchFilter) ([][]vectordb.SearchResult, error) {
+	if err := r.inject("search"); err != nil {
+		return nil, err
+	}
+	return r.Repository.SearchBatch(vectors, filter)
+}
+
+// Count 获取文档总数
+func (r *repository) Count() (int, error) {
+	if err := r.inject("count"); err != nil {
+		return 0, err
+	}
+	return r.Repository.Count()
+}
+
+// Ping 检查底层向量数据库的连接，健康检查反映真实状态，不注入故障
+func (r *repository) Ping(ctx context.Context) error {
+	return vectordb.Ping(ctx, r.Repository)
+}
+
+// Describe 返回底层向量数据库的配置和状态，不注入故障
+func (r *repository) Describe() (vectordb.Info, error) {
+	return vectordb.Describe(r.Repository)
+}
+
+// queue 注入故障的任务队列
+type queue struct {
+	taskqueue.Queue
+	injector *Injector
+}
+
+// WrapQueue 为任务队列包装故障注入
+// 只对入队和查询操作注入，Close不受影响
+func WrapQueue(q taskqueue.Queue, injector *Injector) taskqueue.Queue {
+	if !injector.Enabled() {
+		return q
+	}
+	return &queue{Queue: q, injector: injector}
+}
+
+// Enqueue 将任务加入队列
+func (q *queue) Enqueue(ctx context.Context, taskType taskqueue.TaskType, documentID string, payload interface{}) (string, error) {
+	if err := q.injector.Inject(ctx, TargetQueue, "enqueue"); err != nil {
+		return "", err
+	}
+	return q.Queue.Enqueue(ctx, taskType, documentID, payload)
+}
+
+// EnqueueAt 在指定时间将任务加入队列
+func (q *queue) EnqueueAt(ctx context.Context, taskType taskqueue.TaskType, documentID string, payload interface{}, processAt time.Time) (string, error) {
+	if err := q.injector.Inject(ctx, TargetQueue, "enqueue"); err != nil {
+		return "", err
+	}
+	return q.Queue.EnqueueAt(ctx, taskType, documentID, payload, processAt)
+}
+
+// EnqueueIn 在指定延迟后将任务加入队列
+func (q *queue) EnqueueIn(ctx context.Context, taskType taskqueue.TaskType, documentID string, payload interface{}, delay time.Duration) (string, error) {
+	if err := q.injector.Inject(ctx, TargetQueue, "enqueue"); err != nil {
+		return "", err
+	}
+	return q.Queue.EnqueueIn(ctx, taskType, documentID, payload, delay)
+}
+
+// GetTask 获取任务信息
+func (q *queue) GetTask(ctx context.Context, taskID string) (*taskqueue.Task, error) {
+	if err := q.injector.Inject(ctx, TargetQueue, "get_task"); err != nil {
+		return nil, err
+	}
+	return q.Queue.GetTask(ctx, taskID)
+}
+
+// GetTasksByDocument 获取文档相关的所有任务
+func (q *queue) GetTasksByDocument(ctx context.Context, documentID string) ([]*taskqueue.Task, error) {
+	if err := q.injector.Inject(ctx, TargetQueue, "get_task"); err != nil {
+		return nil, err
+	}
+	return q.Queue.GetTasksByDocument(ctx, documentID)
+}
+
+// UpdateTaskStatus 更新任务状态和结果
+func (q *queue) UpdateTaskStatus(ctx context.Context, taskID string, status taskqueue.TaskStatus, result interface{}, errorMsg string) error {
+	if err := q.injector.Inject(ctx, TargetQueue, "update_status"); err != nil {
+		return err
+	}
+	return q.Queue.UpdateTaskStatus(ctx, taskID, status, result, errorMsg)
+}
+
+// Ping 检查底层队列的连接，健康检查反映真实状态，不注入故障
+func (q *queue) Ping(ctx context.Context) error {
+	pinger, ok := q.Queue.(taskqueue.Pinger)
+	if !ok {
+		return nil
+	}
+	return pinger.Ping(ctx)
+}
+
+// Stats 获取队列统计信息，透传给底层队列，统计接口本身不注入故障
+func (q *queue) Stats(ctx context.Context, window time.Duration) (*taskqueue.QueueStats, error) {
+	provider, ok := q.Queue.(taskqueue.StatsProvider)
+	if !ok {
+		return nil, errors.New("underlying queue does not provide stats")
+	}
+	return provider.Stats(ctx, window)
+}
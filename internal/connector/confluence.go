@@ -0,0 +1,165 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+)
+
+// confluencePageSize 每次列举的页面数量
+const confluencePageSize = 50
+
+// confluence Confluence连接器，同步一个空间（或整个站点）中的页面
+// 认证使用邮箱加API令牌（Cloud），或个人访问令牌（Server/Data Center）
+type confluence struct {
+	client   *http.Client
+	base     string // 站点地址，如https://example.atlassian.net/wiki
+	space    string // 空间键，为空时同步所有有权限的空间
+	username string
+	token    string
+	bearer   bool // 使用个人访问令牌
+}
+
+// newConfluence 创建Confluence连接器
+// 认证信息：username和api_token，或只配置token（个人访问令牌）；选项：space
+func newConfluence(cfg Config) (*confluence, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("confluence connector requires base_url")
+	}
+	c := &confluence{
+		client: cfg.HTTPClient,
+		base:   baseURL(cfg.BaseURL, ""),
+		space:  cfg.Options["space"],
+	}
+	if token := cfg.Credentials["token"]; token != "" {
+		c.token, c.bearer = token, true
+		return c, nil
+	}
+	if err := requireKeys("credentials", cfg.Credentials, "username", "api_token"); err != nil {
+		return nil, err
+	}
+	c.username, c.token = cfg.Credentials["username"], cfg.Credentials["api_token"]
+	return c, nil
+}
+
+// Type 返回连接器类型
+func (c *confluence) Type() string {
+	return TypeConfluence
+}
+
+// confluencePage 内容接口返回的页面
+type confluencePage struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int       `json:"number"`
+		When   time.Time `json:"when"`
+	} `json:"version"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+}
+
+// Changes 通过CQL搜索列出游标时间之后修改的页面
+// Confluence不报告删除的页面，只有全量同步时才能发现删除
+func (c *confluence) Changes(ctx context.Context, cursor string) (*Changes, error) {
+	started := time.Now().UTC()
+	since := timeCursor(cursor)
+
+	clauses := []string{"type=page"}
+	if c.space != "" {
+		clauses = append(clauses, fmt.Sprintf("space=%q", c.space))
+	}
+	if !since.IsZero() {
+		// CQL的时间精确到分钟，往前多取一分钟，重复的页面会按版本号跳过
+		clauses = append(clauses, fmt.Sprintf("lastmodified>=%q", since.Add(-time.Minute).Format("2006/01/02 15:04")))
+	}
+	cql := strings.Join(clauses, " and ") + " order by lastmodified"
+
+	changes := &Changes{Cursor: started.Format(time.RFC3339), Full: since.IsZero()}
+	for start := 0; ; start += confluencePageSize {
+		query := url.Values{
+			"cql":    {cql},
+			"expand": {"version"},
+			"limit":  {strconv.Itoa(confluencePageSize)},
+			"start":  {strconv.Itoa(start)},
+		}
+		var resp struct {
+			Results []confluencePage `json:"results"`
+		}
+		if err := c.get(ctx, "/rest/api/content/search?"+query.Encode(), &resp); err != nil {
+			return nil, err
+		}
+		for _, page := range resp.Results {
+			changes.Items = append(changes.Items, Item{
+				ID:         page.ID,
+				Title:      page.Title,
+				URL:        c.pageURL(page),
+				Version:    strconv.Itoa(page.Version.Number),
+				ModifiedAt: page.Version.When,
+			})
+		}
+		if len(resp.Results) < confluencePageSize {
+			return changes, nil
+		}
+	}
+}
+
+// Fetch 下载页面的存储格式正文并转换为Markdown
+func (c *confluence) Fetch(ctx context.Context, item Item) (*Content, error) {
+	var page confluencePage
+	if err := c.get(ctx, "/rest/api/content/"+url.PathEscape(item.ID)+"?expand=body.storage", &page); err != nil {
+		return nil, err
+	}
+
+	var text string
+	if body := page.Body.Storage.Value; body != "" {
+		extracted, err := document.ExtractHTML(bytes.NewReader([]byte("<html><body><article>" + body + "</article></body></html>")))
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert page %s: %w", item.ID, err)
+		}
+		text = extracted.Text
+	}
+	title := page.Title
+	if title == "" {
+		title = item.Title
+	}
+	return &Content{
+		FileName: fileName(title, ".md"),
+		Data:     []byte("# " + title + "\n\n" + text),
+	}, nil
+}
+
+// get 发送带认证信息的GET请求
+func (c *confluence) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.bearer {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.SetBasicAuth(c.username, c.token)
+	}
+	return doJSON(c.client, req, out)
+}
+
+// pageURL 返回页面的浏览链接
+func (c *confluence) pageURL(page confluencePage) string {
+	if page.Links.WebUI == "" {
+		return ""
+	}
+	return c.base + page.Links.WebUI
+}
@@ -0,0 +1,272 @@
+// Package connector 从外部知识库同步文档
+// 每种来源（Confluence、Notion、Google Drive、SharePoint）实现Connector接口，
+// 按游标增量列出变化的条目，再逐个下载内容交给文档服务建立索引
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 支持的连接器类型
+const (
+	TypeConfluence  = "confluence"
+	TypeNotion      = "notion"
+	TypeGoogleDrive = "gdrive"
+	TypeSharePoint  = "sharepoint"
+)
+
+// Types 支持的连接器类型列表
+var Types = []string{TypeConfluence, TypeNotion, TypeGoogleDrive, TypeSharePoint}
+
+const (
+	// maxContentBytes 单个条目内容的最大字节数
+	maxContentBytes = 50 * 1024 * 1024
+	// maxResponseBytes 列表等API响应的最大字节数
+	maxResponseBytes = 20 * 1024 * 1024
+	// maxFileNameLength 由标题生成的文件名最大长度
+	maxFileNameLength = 80
+)
+
+// Item 外部来源中的一个页面或文件
+type Item struct {
+	ID         string    // 条目在来源中的唯一ID
+	Title      string    // 标题，文件类来源为文件名
+	URL        string    // 在来源中查看条目的链接
+	Version    string    // 版本号或修改标记，变化时重新同步内容
+	ModifiedAt time.Time // 最后修改时间
+	MimeType   string    // 文件类来源的文件类型，页面类来源为空
+}
+
+// Changes 一次增量列举的结果
+type Changes struct {
+	Items   []Item   // 新增或修改的条目
+	Deleted []string // 已删除的条目ID，来源不支持报告删除时为空
+	Cursor  string   // 下次增量同步使用的游标
+	Full    bool     // 是否是全量结果，全量时没有出现的已同步条目视为已删除
+}
+
+// Content 条目的内容
+type Content struct {
+	FileName string // 文件名，扩展名决定解析方式
+	Data     []byte // 文件内容
+}
+
+// Connector 外部来源连接器
+type Connector interface {
+	// Type 返回连接器类型
+	Type() string
+
+	// Changes 列出游标之后变化的条目并返回新的游标，游标为空时列出全部条目
+	Changes(ctx context.Context, cursor string) (*Changes, error)
+
+	// Fetch 下载条目的内容
+	Fetch(ctx context.Context, item Item) (*Content, error)
+}
+
+// Config 连接器配置
+type Config struct {
+	Type        string            // 连接器类型
+	BaseURL     string            // API地址，为空时使用官方地址；Confluence必须配置站点地址
+	Credentials map[string]string // 认证信息，键随连接器类型不同
+	Options     map[string]string // 其他选项，如Confluence的空间、Drive的文件夹
+	HTTPClient  *http.Client      // HTTP客户端，为空时使用默认客户端
+}
+
+// New 根据类型创建连接器，缺少必需的认证信息或选项时返回错误
+func New(cfg Config) (Connector, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	if cfg.Credentials == nil {
+		cfg.Credentials = map[string]string{}
+	}
+	if cfg.Options == nil {
+		cfg.Options = map[string]string{}
+	}
+
+	switch cfg.Type {
+	case TypeConfluence:
+		return newConfluence(cfg)
+	case TypeNotion:
+		return newNotion(cfg)
+	case TypeGoogleDrive:
+		return newGoogleDrive(cfg)
+	case TypeSharePoint:
+		return newSharePoint(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported connector type: %q", cfg.Type)
+	}
+}
+
+// requireKeys 检查必需的配置项
+func requireKeys(section string, values map[string]string, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if strings.TrimSpace(values[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s: %s", section, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// baseURL 返回去掉末尾斜杠的API地址，未配置时使用默认地址
+func baseURL(configured, def string) string {
+	if configured == "" {
+		configured = def
+	}
+	return strings.TrimRight(configured, "/")
+}
+
+// timeCursor 解析基于时间的游标，游标为空或无法解析时返回零值，表示全量列举
+func timeCursor(cursor string) time.Time {
+	t, err := time.Parse(time.RFC3339, cursor)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// doJSON 发送请求并把JSON响应解码到out中
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s failed: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %w", req.URL.Path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{Code: resp.StatusCode, Body: truncate(string(body), 200)}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// download 发送请求并读取响应内容
+func download(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s failed: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &StatusError{Code: resp.StatusCode, Body: truncate(string(body), 200)}
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxContentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+	if len(data) > maxContentBytes {
+		return nil, fmt.Errorf("content exceeds %d bytes", maxContentBytes)
+	}
+	return data, nil
+}
+
+// StatusError 来源API返回了非2xx状态码
+type StatusError struct {
+	Code int    // HTTP状态码
+	Body string // 截断后的响应内容
+}
+
+// Error 实现error接口
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.Code, e.Body)
+}
+
+// truncate 截断过长的字符串
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return s
+}
+
+// fileName 由标题生成文件名，替换文件名中不允许的字符；ext为空时保留标题中的扩展名
+func fileName(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		case r < 0x20:
+			return -1
+		default:
+			return r
+		}
+	}, strings.TrimSpace(title))
+	if ext == "" {
+		ext = path.Ext(name)
+	}
+	name = strings.TrimSuffix(name, ext)
+	if runes := []rune(name); len(runes) > maxFileNameLength {
+		name = string(runes[:maxFileNameLength])
+	}
+	if name == "" {
+		name = "untitled"
+	}
+	return name + ext
+}
+
+// oauthToken 通过OAuth 2.0令牌端点获取并缓存访问令牌，过期前自动刷新
+type oauthToken struct {
+	client   *http.Client
+	tokenURL string
+	form     url.Values // 请求令牌的表单，如client_credentials或refresh_token授权
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Token 返回有效的访问令牌
+func (t *oauthToken) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, strings.NewReader(t.form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(t.client, req, &resp); err != nil {
+		return "", fmt.Errorf("failed to obtain access token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+
+	// 提前一分钟刷新，避免请求过程中令牌过期
+	ttl := time.Duration(resp.ExpiresIn)*time.Second - time.Minute
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	t.token = resp.AccessToken
+	t.expires = time.Now().Add(ttl)
+	return t.token, nil
+}
@@ -0,0 +1,265 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewValidation 测试创建连接器时的配置检查
+func TestNewValidation(t *testing.T) {
+	_, err := New(Config{Type: "dropbox"})
+	assert.Error(t, err)
+
+	_, err = New(Config{Type: TypeConfluence, Credentials: map[string]string{"token": "t"}})
+	assert.Error(t, err, "confluence requires base_url")
+
+	_, err = New(Config{Type: TypeNotion})
+	assert.ErrorContains(t, err, "token")
+
+	_, err = New(Config{Type: TypeSharePoint, Credentials: map[string]string{
+		"tenant_id": "t", "client_id": "c", "client_secret": "s",
+	}})
+	assert.ErrorContains(t, err, "drive_id")
+
+	c, err := New(Config{Type: TypeGoogleDrive, Credentials: map[string]string{"access_token": "a"}})
+	require.NoError(t, err)
+	assert.Equal(t, TypeGoogleDrive, c.Type())
+}
+
+// TestFileName 测试由标题生成文件名
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "a_b.md", fileName("a/b", ".md"))
+	assert.Equal(t, "report.pdf", fileName(" report.pdf ", ""))
+	assert.Equal(t, "untitled.md", fileName("", ".md"))
+}
+
+// TestConfluence 测试Confluence连接器的增量列举和内容转换
+func TestConfluence(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "me@example.com", user)
+		assert.Equal(t, "secret", pass)
+
+		switch r.URL.Path {
+		case "/rest/api/content/search":
+			cql := r.URL.Query().Get("cql")
+			assert.Contains(t, cql, `space="DOC"`)
+			assert.Contains(t, cql, "lastmodified>=")
+			_, _ = w.Write([]byte(`{"results":[{"id":"42","title":"Guide","version":{"number":3},"_links":{"webui":"/pages/42"}}]}`))
+		case "/rest/api/content/42":
+			_, _ = w.Write([]byte(`{"id":"42","title":"Guide","body":{"storage":{"value":"<h2>Setup</h2><p>Install it.</p>"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		Type:        TypeConfluence,
+		BaseURL:     server.URL,
+		Credentials: map[string]string{"username": "me@example.com", "api_token": "secret"},
+		Options:     map[string]string{"space": "DOC"},
+	})
+	require.NoError(t, err)
+
+	changes, err := c.Changes(context.Background(), time.Now().Add(-time.Hour).Format(time.RFC3339))
+	require.NoError(t, err)
+	assert.False(t, changes.Full)
+	assert.NotEmpty(t, changes.Cursor)
+	require.Len(t, changes.Items, 1)
+	assert.Equal(t, "3", changes.Items[0].Version)
+	assert.Equal(t, server.URL+"/pages/42", changes.Items[0].URL)
+
+	content, err := c.Fetch(context.Background(), changes.Items[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Guide.md", content.FileName)
+	assert.Contains(t, string(content.Data), "# Guide")
+	assert.Contains(t, string(content.Data), "Install it.")
+}
+
+// TestNotion 测试Notion连接器在游标时间处停止列举并渲染块
+func TestNotion(t *testing.T) {
+	cursor := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, notionVersion, r.Header.Get("Notion-Version"))
+
+		switch r.URL.Path {
+		case "/v1/search":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]interface{}{
+					{"id": "p1", "last_edited_time": cursor.Add(2 * time.Hour), "properties": map[string]interface{}{
+						"Name": map[string]interface{}{"type": "title", "title": []map[string]string{{"plain_text": "Roadmap"}}},
+					}},
+					{"id": "p2", "last_edited_time": cursor.Add(time.Hour), "archived": true},
+					{"id": "p3", "last_edited_time": cursor.Add(-time.Hour)},
+				},
+				"has_more":    true,
+				"next_cursor": "more",
+			})
+		case "/v1/blocks/p1/children":
+			_, _ = w.Write([]byte(`{"results":[
+				{"id":"b1","type":"heading_2","heading_2":{"rich_text":[{"plain_text":"Q3"}]}},
+				{"id":"b2","type":"bulleted_list_item","bulleted_list_item":{"rich_text":[{"plain_text":"Ship"}]}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{Type: TypeNotion, BaseURL: server.URL, Credentials: map[string]string{"token": "secret"}})
+	require.NoError(t, err)
+
+	changes, err := c.Changes(context.Background(), cursor.Format(time.RFC3339))
+	require.NoError(t, err)
+	require.Len(t, changes.Items, 1)
+	assert.Equal(t, "Roadmap", changes.Items[0].Title)
+	assert.Equal(t, []string{"p2"}, changes.Deleted)
+
+	content, err := c.Fetch(context.Background(), changes.Items[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Roadmap.md", content.FileName)
+	assert.Equal(t, "# Roadmap\n\n## Q3\n\n- Ship\n", string(content.Data))
+}
+
+// TestGoogleDrive 测试Google Drive连接器的令牌刷新、类型过滤和文档导出
+func TestGoogleDrive(t *testing.T) {
+	tokenRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			assert.Equal(t, "refresh_token", r.FormValue("grant_type"))
+			_, _ = w.Write([]byte(`{"access_token":"fresh","expires_in":3600}`))
+			return
+		}
+		assert.Equal(t, "Bearer fresh", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/drive/v3/files":
+			assert.Contains(t, r.URL.Query().Get("q"), "'folder' in parents")
+			_, _ = w.Write([]byte(`{"files":[
+				{"id":"d1","name":"Notes","mimeType":"application/vnd.google-apps.document","version":"7"},
+				{"id":"f1","name":"Form","mimeType":"application/vnd.google-apps.form"},
+				{"id":"x1","name":"spec.pdf","mimeType":"application/pdf","modifiedTime":"2024-05-01T10:00:00Z"}
+			]}`))
+		case "/drive/v3/files/d1/export":
+			assert.Equal(t, "text/plain", r.URL.Query().Get("mimeType"))
+			_, _ = w.Write([]byte("exported text"))
+		case "/drive/v3/files/x1":
+			assert.Equal(t, "media", r.URL.Query().Get("alt"))
+			_, _ = w.Write([]byte("%PDF"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		Type:    TypeGoogleDrive,
+		BaseURL: server.URL,
+		Credentials: map[string]string{
+			"client_id": "id", "client_secret": "s", "refresh_token": "r", "token_url": server.URL + "/token",
+		},
+		Options: map[string]string{"folder_id": "folder"},
+	})
+	require.NoError(t, err)
+
+	changes, err := c.Changes(context.Background(), "")
+	require.NoError(t, err)
+	assert.True(t, changes.Full)
+	require.Len(t, changes.Items, 2)
+	assert.Equal(t, "7", changes.Items[0].Version)
+	assert.Equal(t, "2024-05-01T10:00:00Z", changes.Items[1].Version)
+
+	content, err := c.Fetch(context.Background(), changes.Items[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Notes.txt", content.FileName)
+	assert.Equal(t, "exported text", string(content.Data))
+
+	content, err = c.Fetch(context.Background(), changes.Items[1])
+	require.NoError(t, err)
+	assert.Equal(t, "spec.pdf", content.FileName)
+	assert.Equal(t, 1, tokenRequests, "access token should be cached")
+}
+
+// TestSharePointDelta 测试SharePoint连接器的delta分页、删除和游标失效
+func TestSharePointDelta(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+			_, _ = w.Write([]byte(`{"access_token":"app","expires_in":3600}`))
+			return
+		case "/drives/d1/root/delta":
+			if r.URL.Query().Get("token") == "expired" {
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+			if r.URL.Query().Get("page") == "" {
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"value": []map[string]interface{}{
+						{"id": "root", "name": "root", "folder": map[string]int{"childCount": 2}},
+						{"id": "i1", "name": "a.docx", "cTag": "c1", "file": map[string]string{"mimeType": "application/msword"}},
+					},
+					"@odata.nextLink": server.URL + "/drives/d1/root/delta?page=2",
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"value": []map[string]interface{}{
+					{"id": "i2", "name": "old.txt", "deleted": map[string]string{}},
+				},
+				"@odata.deltaLink": server.URL + "/drives/d1/root/delta?token=next",
+			})
+		case "/drives/d1/items/i1/content":
+			assert.Equal(t, "Bearer app", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte("docx"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{
+		Type:    TypeSharePoint,
+		BaseURL: server.URL,
+		Credentials: map[string]string{
+			"tenant_id": "t", "client_id": "c", "client_secret": "s", "token_url": server.URL + "/token",
+		},
+		Options: map[string]string{"drive_id": "d1"},
+	})
+	require.NoError(t, err)
+
+	changes, err := c.Changes(context.Background(), "")
+	require.NoError(t, err)
+	assert.True(t, changes.Full)
+	require.Len(t, changes.Items, 1)
+	assert.Equal(t, "c1", changes.Items[0].Version)
+	assert.Equal(t, []string{"i2"}, changes.Deleted)
+	assert.Equal(t, server.URL+"/drives/d1/root/delta?token=next", changes.Cursor)
+
+	// 游标失效时重新全量列举
+	changes, err = c.Changes(context.Background(), server.URL+"/drives/d1/root/delta?token=expired")
+	require.NoError(t, err)
+	assert.True(t, changes.Full)
+	assert.Len(t, changes.Items, 1)
+
+	// 不接受指向其他主机的游标
+	_, err = c.Changes(context.Background(), "https://evil.example.com/delta")
+	assert.Error(t, err)
+
+	content, err := c.Fetch(context.Background(), changes.Items[0])
+	require.NoError(t, err)
+	assert.Equal(t, "a.docx", content.FileName)
+	assert.Equal(t, "docx", string(content.Data))
+}
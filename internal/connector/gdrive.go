@@ -0,0 +1,189 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// googleFolderMimeType Drive文件夹的类型
+const googleFolderMimeType = "application/vnd.google-apps.folder"
+
+// googleExports Google文档类型导出的格式和扩展名，其他Google应用类型（表单、绘图等）不同步
+var googleExports = map[string]struct{ mimeType, ext string }{
+	"application/vnd.google-apps.document":     {"text/plain", ".txt"},
+	"application/vnd.google-apps.presentation": {"text/plain", ".txt"},
+	"application/vnd.google-apps.spreadsheet":  {"text/csv", ".csv"},
+}
+
+// googleDrive Google Drive连接器，同步一个文件夹（或整个云端硬盘）中的文件
+type googleDrive struct {
+	client      *http.Client
+	base        string
+	folder      string      // 文件夹ID，为空时同步有权限的所有文件
+	accessToken string      // 固定的访问令牌
+	refresher   *oauthToken // 通过刷新令牌获取访问令牌，未配置时使用accessToken
+}
+
+// newGoogleDrive 创建Google Drive连接器
+// 认证信息：access_token，或client_id、client_secret和refresh_token（可选token_url）；选项：folder_id
+func newGoogleDrive(cfg Config) (*googleDrive, error) {
+	d := &googleDrive{
+		client: cfg.HTTPClient,
+		base:   baseURL(cfg.BaseURL, "https://www.googleapis.com"),
+		folder: cfg.Options["folder_id"],
+	}
+	creds := cfg.Credentials
+	if creds["refresh_token"] != "" {
+		if err := requireKeys("credentials", creds, "client_id", "client_secret"); err != nil {
+			return nil, err
+		}
+		tokenURL := creds["token_url"]
+		if tokenURL == "" {
+			tokenURL = "https://oauth2.googleapis.com/token"
+		}
+		d.refresher = &oauthToken{
+			client:   cfg.HTTPClient,
+			tokenURL: tokenURL,
+			form: url.Values{
+				"grant_type":    {"refresh_token"},
+				"client_id":     {creds["client_id"]},
+				"client_secret": {creds["client_secret"]},
+				"refresh_token": {creds["refresh_token"]},
+			},
+		}
+		return d, nil
+	}
+	if err := requireKeys("credentials", creds, "access_token"); err != nil {
+		return nil, fmt.Errorf("%w (or client_id, client_secret and refresh_token)", err)
+	}
+	d.accessToken = creds["access_token"]
+	return d, nil
+}
+
+// Type 返回连接器类型
+func (d *googleDrive) Type() string {
+	return TypeGoogleDrive
+}
+
+// googleFile 文件列表接口返回的文件
+type googleFile struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	MimeType     string    `json:"mimeType"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	WebViewLink  string    `json:"webViewLink"`
+	Version      string    `json:"version"`
+}
+
+// Changes 列出游标时间之后修改的文件，跳过文件夹和不支持导出的Google应用类型
+// 文件列表接口不报告删除，只有全量同步时才能发现删除
+func (d *googleDrive) Changes(ctx context.Context, cursor string) (*Changes, error) {
+	started := time.Now().UTC()
+	since := timeCursor(cursor)
+
+	clauses := []string{"trashed = false", fmt.Sprintf("mimeType != '%s'", googleFolderMimeType)}
+	if d.folder != "" {
+		clauses = append(clauses, fmt.Sprintf("'%s' in parents", strings.ReplaceAll(d.folder, "'", `\'`)))
+	}
+	if !since.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("modifiedTime > '%s'", since.Format(time.RFC3339)))
+	}
+
+	changes := &Changes{Cursor: started.Format(time.RFC3339), Full: since.IsZero()}
+	pageToken := ""
+	for {
+		query := url.Values{
+			"q":                         {strings.Join(clauses, " and ")},
+			"fields":                    {"nextPageToken,files(id,name,mimeType,modifiedTime,webViewLink,version)"},
+			"pageSize":                  {"100"},
+			"supportsAllDrives":         {"true"},
+			"includeItemsFromAllDrives": {"true"},
+		}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+		var resp struct {
+			Files         []googleFile `json:"files"`
+			NextPageToken string       `json:"nextPageToken"`
+		}
+		if err := d.get(ctx, "/drive/v3/files?"+query.Encode(), &resp); err != nil {
+			return nil, err
+		}
+
+		for _, f := range resp.Files {
+			if strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+				if _, ok := googleExports[f.MimeType]; !ok {
+					continue
+				}
+			}
+			version := f.Version
+			if version == "" {
+				version = f.ModifiedTime.UTC().Format(time.RFC3339)
+			}
+			changes.Items = append(changes.Items, Item{
+				ID:         f.ID,
+				Title:      f.Name,
+				URL:        f.WebViewLink,
+				Version:    version,
+				ModifiedAt: f.ModifiedTime,
+				MimeType:   f.MimeType,
+			})
+		}
+		if resp.NextPageToken == "" {
+			return changes, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// Fetch 下载文件内容，Google文档导出为文本或CSV
+func (d *googleDrive) Fetch(ctx context.Context, item Item) (*Content, error) {
+	path := "/drive/v3/files/" + url.PathEscape(item.ID)
+	name := fileName(item.Title, "")
+	if export, ok := googleExports[item.MimeType]; ok {
+		path += "/export?" + url.Values{"mimeType": {export.mimeType}}.Encode()
+		name = fileName(item.Title, export.ext)
+	} else {
+		path += "?alt=media&supportsAllDrives=true"
+	}
+
+	req, err := d.request(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := download(d.client, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Content{FileName: name, Data: data}, nil
+}
+
+// get 发送带认证信息的GET请求并解码JSON响应
+func (d *googleDrive) get(ctx context.Context, path string, out interface{}) error {
+	req, err := d.request(ctx, path)
+	if err != nil {
+		return err
+	}
+	return doJSON(d.client, req, out)
+}
+
+// request 创建带访问令牌的GET请求
+func (d *googleDrive) request(ctx context.Context, path string) (*http.Request, error) {
+	token := d.accessToken
+	if d.refresher != nil {
+		var err error
+		if token, err = d.refresher.Token(ctx); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
@@ -0,0 +1,265 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// notionVersion 使用的Notion API版本
+	notionVersion = "2022-06-28"
+	// notionMaxDepth 读取嵌套块的最大层数
+	notionMaxDepth = 3
+	// notionMaxBlocks 单个页面最多读取的块数量
+	notionMaxBlocks = 5000
+)
+
+// notion Notion连接器，同步集成（integration）有权访问的所有页面
+type notion struct {
+	client *http.Client
+	base   string
+	token  string
+}
+
+// newNotion 创建Notion连接器，认证信息：token（集成的内部令牌）
+func newNotion(cfg Config) (*notion, error) {
+	if err := requireKeys("credentials", cfg.Credentials, "token"); err != nil {
+		return nil, err
+	}
+	return &notion{
+		client: cfg.HTTPClient,
+		base:   baseURL(cfg.BaseURL, "https://api.notion.com"),
+		token:  cfg.Credentials["token"],
+	}, nil
+}
+
+// Type 返回连接器类型
+func (n *notion) Type() string {
+	return TypeNotion
+}
+
+// notionPage 搜索接口返回的页面
+type notionPage struct {
+	ID             string                     `json:"id"`
+	URL            string                     `json:"url"`
+	LastEditedTime time.Time                  `json:"last_edited_time"`
+	Archived       bool                       `json:"archived"`
+	InTrash        bool                       `json:"in_trash"`
+	Properties     map[string]json.RawMessage `json:"properties"`
+}
+
+// notionRichText 富文本
+type notionRichText []struct {
+	PlainText string `json:"plain_text"`
+}
+
+// String 返回富文本的纯文本
+func (t notionRichText) String() string {
+	var sb strings.Builder
+	for _, part := range t {
+		sb.WriteString(part.PlainText)
+	}
+	return sb.String()
+}
+
+// title 返回页面标题，标题属性的类型为title
+func (p notionPage) title() string {
+	for _, raw := range p.Properties {
+		var prop struct {
+			Type  string         `json:"type"`
+			Title notionRichText `json:"title"`
+		}
+		if json.Unmarshal(raw, &prop) == nil && prop.Type == "title" {
+			return prop.Title.String()
+		}
+	}
+	return ""
+}
+
+// Changes 按最后编辑时间倒序搜索页面，遇到游标时间之前的页面时停止
+// 归档或移入回收站的页面报告为已删除
+func (n *notion) Changes(ctx context.Context, cursor string) (*Changes, error) {
+	started := time.Now().UTC()
+	since := timeCursor(cursor)
+	changes := &Changes{Cursor: started.Format(time.RFC3339), Full: since.IsZero()}
+
+	next := ""
+	for {
+		body := map[string]interface{}{
+			"filter":    map[string]string{"property": "object", "value": "page"},
+			"sort":      map[string]string{"direction": "descending", "timestamp": "last_edited_time"},
+			"page_size": 100,
+		}
+		if next != "" {
+			body["start_cursor"] = next
+		}
+		var resp struct {
+			Results    []notionPage `json:"results"`
+			HasMore    bool         `json:"has_more"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		if err := n.do(ctx, http.MethodPost, "/v1/search", body, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, page := range resp.Results {
+			if !since.IsZero() && page.LastEditedTime.Before(since) {
+				return changes, nil
+			}
+			if page.Archived || page.InTrash {
+				changes.Deleted = append(changes.Deleted, page.ID)
+				continue
+			}
+			changes.Items = append(changes.Items, Item{
+				ID:         page.ID,
+				Title:      page.title(),
+				URL:        page.URL,
+				Version:    page.LastEditedTime.UTC().Format(time.RFC3339),
+				ModifiedAt: page.LastEditedTime,
+			})
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return changes, nil
+		}
+		next = resp.NextCursor
+	}
+}
+
+// notionBlock 页面中的块
+type notionBlock struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+}
+
+// Fetch 读取页面的所有块并渲染为Markdown
+func (n *notion) Fetch(ctx context.Context, item Item) (*Content, error) {
+	var sb strings.Builder
+	title := item.Title
+	if title == "" {
+		title = "Untitled"
+	}
+	sb.WriteString("# " + title + "\n\n")
+
+	count := 0
+	if err := n.renderBlocks(ctx, item.ID, 0, &count, &sb); err != nil {
+		return nil, err
+	}
+	return &Content{FileName: fileName(title, ".md"), Data: []byte(sb.String())}, nil
+}
+
+// renderBlocks 递归渲染块的子块，depth为嵌套层数，用于列表缩进
+func (n *notion) renderBlocks(ctx context.Context, blockID string, depth int, count *int, sb *strings.Builder) error {
+	next := ""
+	for {
+		query := url.Values{"page_size": {"100"}}
+		if next != "" {
+			query.Set("start_cursor", next)
+		}
+		var resp struct {
+			Results    []json.RawMessage `json:"results"`
+			HasMore    bool              `json:"has_more"`
+			NextCursor string            `json:"next_cursor"`
+		}
+		if err := n.do(ctx, http.MethodGet, "/v1/blocks/"+url.PathEscape(blockID)+"/children?"+query.Encode(), nil, &resp); err != nil {
+			return err
+		}
+
+		for _, raw := range resp.Results {
+			if *count++; *count > notionMaxBlocks {
+				return nil
+			}
+			var block notionBlock
+			if err := json.Unmarshal(raw, &block); err != nil {
+				continue
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				continue
+			}
+			renderNotionBlock(block.Type, fields[block.Type], depth, sb)
+			if block.HasChildren && depth+1 < notionMaxDepth {
+				if err := n.renderBlocks(ctx, block.ID, depth+1, count, sb); err != nil {
+					return err
+				}
+			}
+		}
+		if !resp.HasMore || resp.NextCursor == "" {
+			return nil
+		}
+		next = resp.NextCursor
+	}
+}
+
+// renderNotionBlock 把单个块渲染为Markdown，不支持的块类型忽略
+func renderNotionBlock(blockType string, raw json.RawMessage, depth int, sb *strings.Builder) {
+	var content struct {
+		RichText notionRichText `json:"rich_text"`
+		Checked  bool           `json:"checked"`
+		Language string         `json:"language"`
+	}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &content)
+	}
+	text := content.RichText.String()
+	indent := strings.Repeat("  ", depth)
+
+	switch blockType {
+	case "heading_1":
+		sb.WriteString("# " + text + "\n\n")
+	case "heading_2":
+		sb.WriteString("## " + text + "\n\n")
+	case "heading_3":
+		sb.WriteString("### " + text + "\n\n")
+	case "bulleted_list_item", "toggle":
+		sb.WriteString(indent + "- " + text + "\n")
+	case "numbered_list_item":
+		sb.WriteString(indent + "1. " + text + "\n")
+	case "to_do":
+		mark := " "
+		if content.Checked {
+			mark = "x"
+		}
+		sb.WriteString(indent + "- [" + mark + "] " + text + "\n")
+	case "quote", "callout":
+		sb.WriteString("> " + text + "\n\n")
+	case "code":
+		sb.WriteString("```" + content.Language + "\n" + text + "\n```\n\n")
+	case "divider":
+		sb.WriteString("---\n\n")
+	case "paragraph":
+		if text != "" {
+			sb.WriteString(indent + text + "\n\n")
+		}
+	}
+}
+
+// do 发送带认证信息的请求
+func (n *notion) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.base+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Notion-Version", notionVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doJSON(n.client, req, out)
+}
@@ -0,0 +1,175 @@
+package connector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sharePoint SharePoint（OneDrive）连接器，通过Microsoft Graph的delta接口增量同步文档库
+// delta接口直接报告新增、修改和删除的文件，游标是上次返回的deltaLink
+type sharePoint struct {
+	client *http.Client
+	base   string
+	drive  string // 文档库路径，如/drives/{drive-id}或/sites/{site-id}/drive
+	token  *oauthToken
+}
+
+// newSharePoint 创建SharePoint连接器
+// 认证信息：tenant_id、client_id和client_secret（应用的客户端凭据，可选token_url）；
+// 选项：drive_id或site_id，同时配置时使用drive_id
+func newSharePoint(cfg Config) (*sharePoint, error) {
+	creds := cfg.Credentials
+	if err := requireKeys("credentials", creds, "tenant_id", "client_id", "client_secret"); err != nil {
+		return nil, err
+	}
+
+	var drive string
+	switch {
+	case cfg.Options["drive_id"] != "":
+		drive = "/drives/" + url.PathEscape(cfg.Options["drive_id"])
+	case cfg.Options["site_id"] != "":
+		drive = "/sites/" + url.PathEscape(cfg.Options["site_id"]) + "/drive"
+	default:
+		return nil, fmt.Errorf("sharepoint connector requires options drive_id or site_id")
+	}
+
+	tokenURL := creds["token_url"]
+	if tokenURL == "" {
+		tokenURL = "https://login.microsoftonline.com/" + url.PathEscape(creds["tenant_id"]) + "/oauth2/v2.0/token"
+	}
+	return &sharePoint{
+		client: cfg.HTTPClient,
+		base:   baseURL(cfg.BaseURL, "https://graph.microsoft.com/v1.0"),
+		drive:  drive,
+		token: &oauthToken{
+			client:   cfg.HTTPClient,
+			tokenURL: tokenURL,
+			form: url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {creds["client_id"]},
+				"client_secret": {creds["client_secret"]},
+				"scope":         {"https://graph.microsoft.com/.default"},
+			},
+		},
+	}, nil
+}
+
+// Type 返回连接器类型
+func (s *sharePoint) Type() string {
+	return TypeSharePoint
+}
+
+// driveItem delta接口返回的条目
+type driveItem struct {
+	ID                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	WebURL               string    `json:"webUrl"`
+	CTag                 string    `json:"cTag"`
+	ETag                 string    `json:"eTag"`
+	LastModifiedDateTime time.Time `json:"lastModifiedDateTime"`
+	File                 *struct {
+		MimeType string `json:"mimeType"`
+	} `json:"file"`
+	Deleted *struct{} `json:"deleted"`
+}
+
+// Changes 调用delta接口列出变化的文件
+// 游标失效（410）时从头全量列举
+func (s *sharePoint) Changes(ctx context.Context, cursor string) (*Changes, error) {
+	changes, err := s.delta(ctx, cursor)
+	var statusErr *StatusError
+	if cursor != "" && errors.As(err, &statusErr) && statusErr.Code == http.StatusGone {
+		return s.delta(ctx, "")
+	}
+	return changes, err
+}
+
+// delta 从游标开始跟随nextLink读取所有变化，直到返回新的deltaLink
+func (s *sharePoint) delta(ctx context.Context, cursor string) (*Changes, error) {
+	changes := &Changes{Full: cursor == ""}
+	next := cursor
+	if next == "" {
+		next = s.base + s.drive + "/root/delta"
+	}
+
+	for next != "" {
+		// nextLink和deltaLink是完整的URL，只允许指向配置的API地址，避免把令牌发送到其他主机
+		if !strings.HasPrefix(next, s.base+"/") {
+			return nil, fmt.Errorf("unexpected delta link: %s", next)
+		}
+		var resp struct {
+			Value     []driveItem `json:"value"`
+			NextLink  string      `json:"@odata.nextLink"`
+			DeltaLink string      `json:"@odata.deltaLink"`
+		}
+		if err := s.get(ctx, next, &resp); err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Value {
+			switch {
+			case item.Deleted != nil:
+				changes.Deleted = append(changes.Deleted, item.ID)
+			case item.File != nil:
+				version := item.CTag
+				if version == "" {
+					version = item.ETag
+				}
+				changes.Items = append(changes.Items, Item{
+					ID:         item.ID,
+					Title:      item.Name,
+					URL:        item.WebURL,
+					Version:    version,
+					ModifiedAt: item.LastModifiedDateTime,
+					MimeType:   item.File.MimeType,
+				})
+			}
+		}
+		next = resp.NextLink
+		if resp.DeltaLink != "" {
+			changes.Cursor = resp.DeltaLink
+		}
+	}
+	return changes, nil
+}
+
+// Fetch 下载文件内容
+func (s *sharePoint) Fetch(ctx context.Context, item Item) (*Content, error) {
+	req, err := s.request(ctx, s.base+s.drive+"/items/"+url.PathEscape(item.ID)+"/content")
+	if err != nil {
+		return nil, err
+	}
+	data, err := download(s.client, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Content{FileName: fileName(item.Title, ""), Data: data}, nil
+}
+
+// get 发送带认证信息的GET请求并解码JSON响应
+func (s *sharePoint) get(ctx context.Context, rawURL string, out interface{}) error {
+	req, err := s.request(ctx, rawURL)
+	if err != nil {
+		return err
+	}
+	return doJSON(s.client, req, out)
+}
+
+// request 创建带访问令牌的GET请求
+func (s *sharePoint) request(ctx context.Context, rawURL string) (*http.Request, error) {
+	token, err := s.token.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
@@ -1,17 +1,20 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // DB 全局数据库连接
@@ -31,23 +34,55 @@ func InitLogger(logger *logrus.Logger) {
 	}
 }
 
+// 支持的数据库类型
+const (
+	TypeSQLite   = "sqlite"
+	TypeMySQL    = "mysql"
+	TypePostgres = "postgres"
+)
+
+// maxRetryInterval 启动时连接重试的最长等待时间
+const maxRetryInterval = 30 * time.Second
+
 // Config 数据库配置
 type Config struct {
-	Type         string        // 数据库类型：sqlite, mysql, postgres
-	DSN          string        // 数据源名称
-	MaxOpenConns int           // 最大打开连接数
-	MaxIdleConns int           // 最大空闲连接数
-	MaxLifetime  time.Duration // 连接最大生命周期
+	Type           string        // 数据库类型：sqlite, mysql, postgres
+	DSN            string        // 数据源名称
+	MaxOpenConns   int           // 最大打开连接数
+	MaxIdleConns   int           // 最大空闲连接数
+	MaxLifetime    time.Duration // 连接最大生命周期
+	MaxIdleTime    time.Duration // 连接最大空闲时间，0表示不限制
+	ConnectRetries int           // 启动时连接失败的重试次数，数据库与服务同时启动时等待数据库就绪
+	RetryInterval  time.Duration // 首次重试前的等待时间，之后每次翻倍，最长30秒
+	SlowThreshold  time.Duration // 慢查询阈值，超过时记录警告日志，0表示不记录
 }
 
 // DefaultConfig 返回默认数据库配置
 func DefaultConfig() *Config {
 	return &Config{
-		Type:         "sqlite",
-		DSN:          "data/database.db", // 默认SQLite数据库路径
-		MaxOpenConns: 10,
-		MaxIdleConns: 5,
-		MaxLifetime:  time.Hour,
+		Type:           TypeSQLite,
+		DSN:            "data/database.db", // 默认SQLite数据库路径
+		MaxOpenConns:   10,
+		MaxIdleConns:   5,
+		MaxLifetime:    time.Hour,
+		MaxIdleTime:    10 * time.Minute,
+		ConnectRetries: 5,
+		RetryInterval:  time.Second,
+		SlowThreshold:  200 * time.Millisecond,
+	}
+}
+
+// NormalizeType 规范化数据库类型名称，不支持的类型返回错误
+func NormalizeType(dbType string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(dbType)) {
+	case "", "sqlite", "sqlite3":
+		return TypeSQLite, nil
+	case "mysql", "mariadb":
+		return TypeMySQL, nil
+	case "postgres", "postgresql", "pg":
+		return TypePostgres, nil
+	default:
+		return "", fmt.Errorf("unsupported database type: %s", dbType)
 	}
 }
 
@@ -76,36 +111,13 @@ func MustDB() *gorm.DB {
 
 // setupDB 初始化数据库连接
 func setupDB(cfg *Config) error {
-	var err error
-	var dialector gorm.Dialector
-
-	// 根据数据库类型创建方言
-	switch cfg.Type {
-	case "sqlite":
-		// 确保目录存在
-		if err := ensureDir(cfg.DSN); err != nil {
-			return fmt.Errorf("failed to create database directory: %v", err)
-		}
-		dialector = sqlite.Open(cfg.DSN)
-	default:
-		return fmt.Errorf("unsupported database type: %s", cfg.Type)
-	}
-
-	// 创建GORM日志配置
-	gormLogger := logger.New(
-		&logrusWriter{log}, // 使用logrus作为日志输出
-		logger.Config{
-			SlowThreshold:             200 * time.Millisecond, // 慢查询阈值
-			LogLevel:                  logger.Warn,            // 日志级别
-			IgnoreRecordNotFoundError: true,                   // 忽略记录未找到错误
-			Colorful:                  false,                  // 无色彩输出
-		},
-	)
+	dialector, err := openDialector(cfg)
+	if err != nil {
+		return err
+	}
 
-	// 连接数据库
-	DB, err = gorm.Open(dialector, &gorm.Config{
-		Logger: gormLogger,
-	})
+	// 连接数据库，数据库尚未就绪时按配置重试
+	DB, err = connect(dialector, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
@@ -120,6 +132,7 @@ func setupDB(cfg *Config) error {
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.MaxIdleTime)
 
 	// 自动迁移模型
 	if err := autoMigrate(); err != nil {
@@ -127,11 +140,75 @@ func setupDB(cfg *Config) error {
 	}
 
 	if log != nil {
-		log.Info("Database connection established successfully")
+		log.WithField("type", dialector.Name()).Info("Database connection established successfully")
 	}
 	return nil
 }
 
+// openDialector 根据数据库类型创建GORM方言
+func openDialector(cfg *Config) (gorm.Dialector, error) {
+	dbType, err := NormalizeType(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+	switch dbType {
+	case TypeMySQL:
+		return mysql.Open(mysqlDSN(cfg.DSN)), nil
+	case TypePostgres:
+		return postgres.Open(cfg.DSN), nil
+	default:
+		// 确保目录存在
+		if err := ensureDir(cfg.DSN); err != nil {
+			return nil, fmt.Errorf("failed to create database directory: %v", err)
+		}
+		return sqlite.Open(cfg.DSN), nil
+	}
+}
+
+// mysqlDSN 补充MySQL连接参数，时间字段需要parseTime才能扫描为time.Time
+func mysqlDSN(dsn string) string {
+	if strings.Contains(dsn, "parseTime=") {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&parseTime=true"
+	}
+	return dsn + "?parseTime=true"
+}
+
+// connect 连接数据库并确认可用，失败时按指数退避重试
+func connect(dialector gorm.Dialector, cfg *Config) (*gorm.DB, error) {
+	wait := cfg.RetryInterval
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		db, err := gorm.Open(dialector, &gorm.Config{
+			Logger: newGormLogger(log, cfg.SlowThreshold),
+		})
+		if err == nil {
+			if err = ping(context.Background(), db); err == nil {
+				return db, nil
+			}
+			if sqlDB, dbErr := db.DB(); dbErr == nil {
+				sqlDB.Close()
+			}
+		}
+		if attempt >= cfg.ConnectRetries {
+			return nil, err
+		}
+		if log != nil {
+			log.WithError(err).WithFields(logrus.Fields{
+				"attempt": attempt + 1,
+				"retries": cfg.ConnectRetries,
+				"wait":    wait,
+			}).Warn("Database not ready, retrying")
+		}
+		time.Sleep(wait)
+		wait = min(wait*2, maxRetryInterval)
+	}
+}
+
 // Close 关闭数据库连接
 func Close() error {
 	if DB == nil {
@@ -146,14 +223,52 @@ func Close() error {
 	return sqlDB.Close()
 }
 
+// Ping 检查数据库连接是否可用
+func Ping(ctx context.Context) error {
+	return ping(ctx, DB)
+}
+
+// ping 检查指定的数据库连接是否可用
+func ping(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
 // autoMigrate 自动迁移数据库模型
 func autoMigrate() error {
 	// 这里添加所有需要迁移的模型
 	return DB.AutoMigrate(
 		&models.Document{},
 		&models.DocumentSegment{},
-		&models.ChatSession{}, // 添加聊天会话模型
-		&models.ChatMessage{}, // 添加聊天消息模型
+		&models.ChatSession{},          // 添加聊天会话模型
+		&models.ChatMessage{},          // 添加聊天消息模型
+		&models.ChatMessageVersion{},   // 聊天消息历史版本模型
+		&models.UsageRecord{},          // 用量统计模型
+		&models.DocumentGroup{},        // 文档组模型
+		&models.DocumentGroupMember{},  // 文档组成员模型
+		&models.JobRun{},               // 定时任务运行记录模型
+		&models.QuestionCluster{},      // 问题簇模型
+		&models.AnswerFeedback{},       // 回答负反馈模型
+		&models.RetrievalSuppression{}, // 检索抑制模型
+		&models.AnswerDraft{},          // 回答草稿模型
+		&models.CuratedAnswer{},        // 人工审核过的FAQ模型
+		&models.QuotaUsage{},           // 租户配额用量模型
+		&models.QADailyStat{},          // 问答统计模型
+		&models.PromptVariantStat{},    // 提示词变体统计模型
+		&models.ScoreCalibration{},     // 检索相关度阈值校准结果模型
+		&models.AuditLog{},             // 审计日志模型
+		&models.ConnectorState{},       // 连接器同步状态模型
+		&models.ConnectorItem{},        // 连接器已同步条目模型
+		&models.LLMInteraction{},       // 大模型交互日志模型
+		&models.DocumentShare{},        // 文档共享记录模型
 	)
 }
 
@@ -182,15 +297,3 @@ func createDirIfNotExists(dir string) error {
 
 	return nil
 }
-
-// logrusWriter 实现gorm.Writer接口，将日志输出到logrus
-type logrusWriter struct {
-	logger *logrus.Logger
-}
-
-// Printf 实现io.Writer接口，将GORM日志转发到logrus
-func (w *logrusWriter) Printf(format string, args ...interface{}) {
-	if w.logger != nil {
-		w.logger.Tracef(format, args...)
-	}
-}
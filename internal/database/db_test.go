@@ -0,0 +1,160 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
+)
+
+// TestNormalizeType 测试数据库类型别名的规范化
+func TestNormalizeType(t *testing.T) {
+	cases := map[string]string{
+		"":           TypeSQLite,
+		"sqlite3":    TypeSQLite,
+		"MySQL":      TypeMySQL,
+		"mariadb":    TypeMySQL,
+		"postgresql": TypePostgres,
+		" pg ":       TypePostgres,
+	}
+	for in, want := range cases {
+		got, err := NormalizeType(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := NormalizeType("oracle")
+	assert.Error(t, err)
+}
+
+// TestMySQLDSN 测试MySQL连接串自动补充parseTime
+func TestMySQLDSN(t *testing.T) {
+	assert.Equal(t, "u:p@tcp(db:3306)/docqa?parseTime=true", mysqlDSN("u:p@tcp(db:3306)/docqa"))
+	assert.Equal(t, "u:p@tcp(db:3306)/docqa?charset=utf8mb4&parseTime=true", mysqlDSN("u:p@tcp(db:3306)/docqa?charset=utf8mb4"))
+	assert.Equal(t, "u:p@tcp(db:3306)/docqa?parseTime=false", mysqlDSN("u:p@tcp(db:3306)/docqa?parseTime=false"))
+}
+
+// TestConnectSQLite 测试SQLite连接和目录创建
+func TestConnectSQLite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DSN = filepath.Join(t.TempDir(), "nested", "test.db")
+
+	dialector, err := openDialector(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", dialector.Name())
+
+	db, err := connect(dialector, cfg)
+	require.NoError(t, err)
+	assert.NoError(t, ping(context.Background(), db))
+	sqlDB, _ := db.DB()
+	sqlDB.Close()
+
+	_, err = openDialector(&Config{Type: "oracle"})
+	assert.Error(t, err)
+}
+
+// TestConnectRetry 测试数据库不可用时按配置重试后返回错误
+func TestConnectRetry(t *testing.T) {
+	buf := &bytes.Buffer{}
+	testLogger := logrus.New()
+	testLogger.SetOutput(buf)
+	InitLogger(testLogger)
+	defer InitLogger(nil)
+
+	cfg := &Config{
+		Type:           TypePostgres,
+		DSN:            "host=127.0.0.1 port=1 user=docqa dbname=docqa sslmode=disable connect_timeout=1",
+		ConnectRetries: 2,
+		RetryInterval:  time.Millisecond,
+	}
+	dialector, err := openDialector(cfg)
+	require.NoError(t, err)
+
+	_, err = connect(dialector, cfg)
+	assert.Error(t, err)
+	assert.Equal(t, 2, strings.Count(buf.String(), "Database not ready, retrying"))
+}
+
+// TestGormLoggerSlowQuery 测试慢查询日志
+func TestGormLoggerSlowQuery(t *testing.T) {
+	buf := &bytes.Buffer{}
+	testLogger := logrus.New()
+	testLogger.SetOutput(buf)
+	l := newGormLogger(testLogger, 10*time.Millisecond)
+	query := func() (string, int64) { return "SELECT * FROM documents", 3 }
+
+	// 未超过阈值不记录
+	l.Trace(context.Background(), time.Now(), query, nil)
+	assert.Empty(t, buf.String())
+
+	l.Trace(context.Background(), time.Now().Add(-50*time.Millisecond), query, nil)
+	assert.Contains(t, buf.String(), "Slow SQL query")
+	assert.Contains(t, buf.String(), "SELECT * FROM documents")
+	assert.Contains(t, buf.String(), "rows=3")
+
+	// Silent级别不记录任何内容
+	buf.Reset()
+	l.LogMode(logger.Silent).Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	assert.Empty(t, buf.String())
+
+	// 阈值为0时关闭慢查询日志
+	newGormLogger(testLogger, 0).Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	assert.Empty(t, buf.String())
+
+	long := strings.Repeat("x", maxLoggedSQLRunes+10)
+	assert.Equal(t, maxLoggedSQLRunes+3, len(truncateSQL(long)))
+}
+
+// integrationRecord 集成测试使用的表结构
+type integrationRecord struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"size:64"`
+	CreatedAt time.Time
+}
+
+// TestExternalDatabases 测试MySQL和PostgreSQL的连接、建表和时间字段读写
+// 需要设置DATABASE_TEST_MYSQL_DSN或DATABASE_TEST_POSTGRES_DSN
+func TestExternalDatabases(t *testing.T) {
+	for dbType, env := range map[string]string{
+		TypeMySQL:    "DATABASE_TEST_MYSQL_DSN",
+		TypePostgres: "DATABASE_TEST_POSTGRES_DSN",
+	} {
+		t.Run(dbType, func(t *testing.T) {
+			dsn := os.Getenv(env)
+			if dsn == "" {
+				t.Skipf("%s environment variable not set, skipping %s tests", env, dbType)
+			}
+
+			cfg := DefaultConfig()
+			cfg.Type = dbType
+			cfg.DSN = dsn
+			cfg.ConnectRetries = 0
+			dialector, err := openDialector(cfg)
+			require.NoError(t, err)
+			db, err := connect(dialector, cfg)
+			require.NoError(t, err)
+			defer func() {
+				db.Migrator().DropTable(&integrationRecord{})
+				sqlDB, _ := db.DB()
+				sqlDB.Close()
+			}()
+
+			require.NoError(t, db.AutoMigrate(&integrationRecord{}))
+			record := integrationRecord{Name: "测试"}
+			require.NoError(t, db.Create(&record).Error)
+
+			var got integrationRecord
+			require.NoError(t, db.First(&got, record.ID).Error)
+			assert.Equal(t, "测试", got.Name)
+			assert.WithinDuration(t, record.CreatedAt, got.CreatedAt, time.Second)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/logger"
+)
+
+// maxLoggedSQLRunes 慢查询日志中SQL语句的最大长度，避免把大段参数写入日志
+const maxLoggedSQLRunes = 500
+
+// gormLogger 将GORM日志输出到logrus
+// 超过阈值的慢查询以Warn级别记录，带上耗时、影响行数和SQL，便于在真实并发下定位问题
+type gormLogger struct {
+	logger        *logrus.Logger
+	level         logger.LogLevel
+	slowThreshold time.Duration // 慢查询阈值，0表示不记录慢查询
+}
+
+// newGormLogger 创建GORM日志记录器
+func newGormLogger(log *logrus.Logger, slowThreshold time.Duration) *gormLogger {
+	if log == nil {
+		log = logrus.StandardLogger()
+	}
+	return &gormLogger{logger: log, level: logger.Warn, slowThreshold: slowThreshold}
+}
+
+// LogMode 设置日志级别
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 记录信息日志
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.logger.WithContext(ctx).Infof(msg, args...)
+	}
+}
+
+// Warn 记录警告日志
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.logger.WithContext(ctx).Warnf(msg, args...)
+	}
+}
+
+// Error 记录错误日志
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.logger.WithContext(ctx).Errorf(msg, args...)
+	}
+}
+
+// Trace 记录SQL执行情况
+// 查询错误由调用方处理，只在Debug级别记录；慢查询以Warn级别记录
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+	failed := err != nil && !errors.Is(err, logger.ErrRecordNotFound)
+	if !slow && !failed && l.level < logger.Info {
+		return
+	}
+
+	sql, rows := fc()
+	entry := l.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"elapsed_ms": elapsed.Milliseconds(),
+		"rows":       rows,
+		"sql":        truncateSQL(sql),
+	})
+	switch {
+	case slow && l.level >= logger.Warn:
+		entry.Warn("Slow SQL query")
+	case failed:
+		entry.WithError(err).Debug("SQL query failed")
+	default:
+		entry.Debug("SQL query")
+	}
+}
+
+// truncateSQL 截断过长的SQL语句
+func truncateSQL(sql string) string {
+	if utf8.RuneCountInString(sql) <= maxLoggedSQLRunes {
+		return sql
+	}
+	return string([]rune(sql)[:maxLoggedSQLRunes]) + "..."
+}
@@ -0,0 +1,229 @@
+package document
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// 复合文档（Compound File Binary，OLE2）格式的常量，Outlook的.msg邮件使用该格式
+const (
+	cfbHeaderSize  = 512
+	cfbDirEntry    = 128
+	cfbEndOfChain  = 0xFFFFFFFE
+	cfbFreeSect    = 0xFFFFFFFF
+	cfbNoStream    = 0xFFFFFFFF
+	cfbHeaderDIFAT = 109
+
+	cfbTypeStorage = 1 // 存储（目录）
+	cfbTypeStream  = 2 // 流（文件）
+	cfbTypeRoot    = 5 // 根存储
+)
+
+// cfbSignature 复合文档的文件头
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// errCorruptCFB 复合文档结构损坏
+var errCorruptCFB = errors.New("corrupt compound file")
+
+// cfbEntry 复合文档的目录项
+type cfbEntry struct {
+	name  string
+	kind  byte
+	left  uint32
+	right uint32
+	child uint32
+	start uint32
+	size  uint64
+}
+
+// compoundFile 只读的复合文档，整个文件读入内存
+type compoundFile struct {
+	data       []byte
+	sectorSize int
+	miniSize   int
+	miniCutoff uint64
+	fat        []uint32
+	miniFAT    []uint32
+	miniStream []byte
+	entries    []cfbEntry
+}
+
+// openCompoundFile 解析复合文档的扇区分配表和目录
+func openCompoundFile(data []byte) (*compoundFile, error) {
+	if len(data) < cfbHeaderSize || !bytes.Equal(data[:8], cfbSignature) {
+		return nil, fmt.Errorf("not a compound file")
+	}
+	le := binary.LittleEndian
+	f := &compoundFile{
+		data:       data,
+		sectorSize: 1 << le.Uint16(data[0x1E:]),
+		miniSize:   1 << le.Uint16(data[0x20:]),
+		miniCutoff: uint64(le.Uint32(data[0x38:])),
+	}
+	if f.sectorSize != 512 && f.sectorSize != 4096 {
+		return nil, errCorruptCFB
+	}
+
+	// 主扇区分配表的位置记录在文件头和DIFAT扇区链中
+	var fatSectors []uint32
+	for i := 0; i < cfbHeaderDIFAT; i++ {
+		if sect := le.Uint32(data[0x4C+i*4:]); sect != cfbFreeSect {
+			fatSectors = append(fatSectors, sect)
+		}
+	}
+	perSector := f.sectorSize / 4
+	difat := le.Uint32(data[0x44:])
+	for n := le.Uint32(data[0x48:]); n > 0 && difat != cfbEndOfChain; n-- {
+		sector, err := f.sector(difat)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < perSector-1; i++ {
+			if sect := le.Uint32(sector[i*4:]); sect != cfbFreeSect {
+				fatSectors = append(fatSectors, sect)
+			}
+		}
+		difat = le.Uint32(sector[(perSector-1)*4:])
+	}
+	for _, sect := range fatSectors {
+		sector, err := f.sector(sect)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < perSector; i++ {
+			f.fat = append(f.fat, le.Uint32(sector[i*4:]))
+		}
+	}
+
+	dir, err := f.chain(le.Uint32(data[0x30:]), 0)
+	if err != nil {
+		return nil, err
+	}
+	for off := 0; off+cfbDirEntry <= len(dir); off += cfbDirEntry {
+		f.entries = append(f.entries, parseCFBEntry(dir[off:off+cfbDirEntry]))
+	}
+	if len(f.entries) == 0 || f.entries[0].kind != cfbTypeRoot {
+		return nil, errCorruptCFB
+	}
+
+	// 小于截断大小的流保存在迷你流中，迷你流本身是根目录项的数据
+	if miniFATStart := le.Uint32(data[0x3C:]); miniFATStart != cfbEndOfChain {
+		raw, err := f.chain(miniFATStart, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+4 <= len(raw); i += 4 {
+			f.miniFAT = append(f.miniFAT, le.Uint32(raw[i:]))
+		}
+		root := f.entries[0]
+		if f.miniStream, err = f.chain(root.start, root.size); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// parseCFBEntry 解析目录项
+func parseCFBEntry(raw []byte) cfbEntry {
+	le := binary.LittleEndian
+	nameLen := int(le.Uint16(raw[64:]))
+	if nameLen > 64 {
+		nameLen = 64
+	}
+	units := make([]uint16, 0, nameLen/2)
+	for i := 0; i+1 < nameLen; i += 2 {
+		if u := le.Uint16(raw[i:]); u != 0 {
+			units = append(units, u)
+		}
+	}
+	return cfbEntry{
+		name:  string(utf16.Decode(units)),
+		kind:  raw[66],
+		left:  le.Uint32(raw[68:]),
+		right: le.Uint32(raw[72:]),
+		child: le.Uint32(raw[76:]),
+		start: le.Uint32(raw[116:]),
+		size:  le.Uint64(raw[120:]),
+	}
+}
+
+// sector 返回扇区的内容，扇区n位于文件头之后
+func (f *compoundFile) sector(n uint32) ([]byte, error) {
+	off := (int64(n) + 1) * int64(f.sectorSize)
+	if off+int64(f.sectorSize) > int64(len(f.data)) {
+		return nil, errCorruptCFB
+	}
+	return f.data[off : off+int64(f.sectorSize)], nil
+}
+
+// chain 按扇区分配表读取从start开始的扇区链，size大于0时截断到该长度
+func (f *compoundFile) chain(start uint32, size uint64) ([]byte, error) {
+	var buf []byte
+	for sect, steps := start, 0; sect != cfbEndOfChain; steps++ {
+		if int(sect) >= len(f.fat) || steps > len(f.fat) {
+			return nil, errCorruptCFB
+		}
+		sector, err := f.sector(sect)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, sector...)
+		sect = f.fat[sect]
+	}
+	if size > 0 {
+		if uint64(len(buf)) < size {
+			return nil, errCorruptCFB
+		}
+		buf = buf[:size]
+	}
+	return buf, nil
+}
+
+// stream 读取流的内容
+func (f *compoundFile) stream(e cfbEntry) ([]byte, error) {
+	if e.size == 0 {
+		return nil, nil
+	}
+	if e.size >= f.miniCutoff {
+		return f.chain(e.start, e.size)
+	}
+
+	var buf []byte
+	for sect, steps := e.start, 0; sect != cfbEndOfChain; steps++ {
+		if int(sect) >= len(f.miniFAT) || steps > len(f.miniFAT) {
+			return nil, errCorruptCFB
+		}
+		off := int(sect) * f.miniSize
+		if off+f.miniSize > len(f.miniStream) {
+			return nil, errCorruptCFB
+		}
+		buf = append(buf, f.miniStream[off:off+f.miniSize]...)
+		sect = f.miniFAT[sect]
+	}
+	if uint64(len(buf)) < e.size {
+		return nil, errCorruptCFB
+	}
+	return buf[:e.size], nil
+}
+
+// children 返回存储下的直接子项，子项以红黑树组织，这里按中序遍历展开
+func (f *compoundFile) children(parent cfbEntry) []cfbEntry {
+	var result []cfbEntry
+	visited := make(map[uint32]bool)
+	var walk func(id uint32)
+	walk = func(id uint32) {
+		if id == cfbNoStream || int(id) >= len(f.entries) || visited[id] {
+			return
+		}
+		visited[id] = true
+		e := f.entries[id]
+		walk(e.left)
+		result = append(result, e)
+		walk(e.right)
+	}
+	walk(parent.child)
+	return result
+}
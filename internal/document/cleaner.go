@@ -0,0 +1,230 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 文本清洗的内置规则
+const (
+	CleanPageNumbers    = "page_numbers"    // 页码行，如"第 3 页"、"Page 3 of 10"、"- 3 -"
+	CleanHeadersFooters = "headers_footers" // 在多数页面的开头或结尾重复出现的页眉页脚
+	CleanCopyright      = "copyright"       // 版权声明行
+	CleanBlankLines     = "blank_lines"     // 连续的空行合并为一个，去掉行尾空白
+)
+
+// DefaultCleanMinRepeats 默认至少在多少页重复出现才视为页眉页脚
+const DefaultCleanMinRepeats = 3
+
+// cleanEdgeLines 每页开头和结尾各检查的非空行数，页眉页脚和页码只出现在这些位置
+const cleanEdgeLines = 3
+
+var (
+	// pageNumberPattern 明确的页码格式，在任何位置的独立行都视为页码
+	pageNumberPattern = regexp.MustCompile(`(?i)^(第\s*\d+\s*页(\s*[/,，]?\s*共\s*\d+\s*页)?|共\s*\d+\s*页\s*[,，]?\s*第\s*\d+\s*页|page\s+\d+(\s*(of|/)\s*\d+)?|[-–—]\s*\d+\s*[-–—]|\d+\s*/\s*\d+|\d+\s+of\s+\d+)$`)
+	// bareNumberPattern 单独的数字，只在分页文档每页的开头或结尾视为页码
+	bareNumberPattern = regexp.MustCompile(`^\d{1,4}$`)
+	// copyrightPattern 版权声明行
+	copyrightPattern = regexp.MustCompile(`(?i)^(copyright\b|©|\(c\)\s*\d{4}|版权所有)|all rights reserved\.?$`)
+	// blankLinesPattern 两个以上的连续空行
+	blankLinesPattern = regexp.MustCompile(`\n[ \t]*\n([ \t]*\n)+`)
+	// trailingSpacePattern 行尾空白
+	trailingSpacePattern = regexp.MustCompile(`(?m)[ \t]+$`)
+)
+
+// BuiltinCleanRules 返回所有内置清洗规则的名称
+func BuiltinCleanRules() []string {
+	return []string{CleanPageNumbers, CleanHeadersFooters, CleanCopyright, CleanBlankLines}
+}
+
+// CleanResult 文本清洗结果
+type CleanResult struct {
+	Text         string // 清洗后的文本
+	CharsBefore  int    // 清洗前的字符数
+	CharsAfter   int    // 清洗后的字符数
+	LinesRemoved int    // 删除的行数
+}
+
+// Cleaner 分块前的文本清洗器
+// 去掉页码、重复的页眉页脚、版权声明和自定义的模板文本，避免这些内容混入段落、降低检索质量。
+// 页面以PageBreak分隔，清洗后保留分页符，不影响按页标注段落页码
+type Cleaner struct {
+	rules      map[string]bool
+	patterns   []*regexp.Regexp
+	minRepeats int
+}
+
+// NewCleaner 创建文本清洗器
+// rules为启用的内置规则名称，patterns为自定义正则表达式，匹配的内容直接删除（多行匹配需要在表达式中加(?m)或(?s)）；
+// minRepeats为页眉页脚至少重复出现的页数，不大于0时使用默认值
+func NewCleaner(rules []string, patterns []string, minRepeats int) (*Cleaner, error) {
+	c := &Cleaner{rules: make(map[string]bool, len(rules)), minRepeats: minRepeats}
+	if c.minRepeats <= 0 {
+		c.minRepeats = DefaultCleanMinRepeats
+	}
+	builtin := BuiltinCleanRules()
+	for _, rule := range rules {
+		if !containsString(builtin, rule) {
+			return nil, fmt.Errorf("unknown clean rule %q, use %s", rule, strings.Join(builtin, ", "))
+		}
+		c.rules[rule] = true
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid clean pattern %q: %w", pattern, err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	return c, nil
+}
+
+// Clean 清洗文本，清洗器为nil时原样返回
+func (c *Cleaner) Clean(text string) CleanResult {
+	result := CleanResult{Text: text, CharsBefore: utf8.RuneCountInString(text)}
+	if c == nil {
+		result.CharsAfter = result.CharsBefore
+		return result
+	}
+
+	for _, re := range c.patterns {
+		text = re.ReplaceAllString(text, "")
+	}
+
+	pages := strings.Split(text, PageBreak)
+	paged := len(pages) > 1
+	lines := make([][]string, len(pages))
+	for i, page := range pages {
+		lines[i] = strings.Split(page, "\n")
+	}
+	var repeated map[string]bool
+	if c.rules[CleanHeadersFooters] {
+		repeated = c.repeatedEdgeLines(lines)
+	}
+
+	for i := range lines {
+		edges := edgeLineIndexes(lines[i])
+		kept := make([]string, 0, len(lines[i]))
+		for j, line := range lines[i] {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && c.removable(trimmed, paged && edges[j], repeated) {
+				result.LinesRemoved++
+				continue
+			}
+			kept = append(kept, line)
+		}
+		pages[i] = strings.Join(kept, "\n")
+	}
+	text = strings.Join(pages, PageBreak)
+
+	if c.rules[CleanBlankLines] {
+		text = trailingSpacePattern.ReplaceAllString(text, "")
+		text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+	}
+
+	result.Text = text
+	result.CharsAfter = utf8.RuneCountInString(text)
+	return result
+}
+
+// removable 判断一行是否应删除，edge表示该行位于分页文档某页的开头或结尾
+func (c *Cleaner) removable(line string, edge bool, repeated map[string]bool) bool {
+	if c.rules[CleanPageNumbers] {
+		if pageNumberPattern.MatchString(line) || (edge && bareNumberPattern.MatchString(line)) {
+			return true
+		}
+	}
+	if c.rules[CleanCopyright] && utf8.RuneCountInString(line) <= 200 && copyrightPattern.MatchString(line) {
+		return true
+	}
+	return edge && repeated[edgeLineKey(line)]
+}
+
+// repeatedEdgeLines 找出在多数页面的开头或结尾重复出现的行
+// 行中的数字统一处理，页码不同的页眉页脚视为同一行；页数少于minRepeats时不检测
+func (c *Cleaner) repeatedEdgeLines(pages [][]string) map[string]bool {
+	if len(pages) < c.minRepeats {
+		return nil
+	}
+	counts := make(map[string]int)
+	nonEmpty := 0
+	for _, lines := range pages {
+		seen := make(map[string]bool)
+		for j, edge := range edgeLineIndexes(lines) {
+			if !edge {
+				continue
+			}
+			if key := edgeLineKey(strings.TrimSpace(lines[j])); key != "" && !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+		if len(seen) > 0 {
+			nonEmpty++
+		}
+	}
+
+	threshold := max(c.minRepeats, (nonEmpty+1)/2)
+	repeated := make(map[string]bool)
+	for key, n := range counts {
+		if n >= threshold {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// edgeLineIndexes 标记一页中开头和结尾各cleanEdgeLines个非空行
+func edgeLineIndexes(lines []string) []bool {
+	edges := make([]bool, len(lines))
+	for i, n := 0, 0; i < len(lines) && n < cleanEdgeLines; i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			edges[i] = true
+			n++
+		}
+	}
+	for i, n := len(lines)-1, 0; i >= 0 && n < cleanEdgeLines; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			edges[i] = true
+			n++
+		}
+	}
+	return edges
+}
+
+// edgeLineKey 返回比较页眉页脚时使用的键，数字统一为0、去掉空白
+// 过长的行、标题行和不含文字的行不参与比较，返回空字符串
+func edgeLineKey(line string) string {
+	if utf8.RuneCountInString(line) > 100 || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	var b strings.Builder
+	hasText := false
+	for _, r := range line {
+		switch {
+		case unicode.IsSpace(r):
+		case unicode.IsDigit(r):
+			b.WriteRune('0')
+		default:
+			hasText = hasText || unicode.IsLetter(r)
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	if !hasText {
+		return ""
+	}
+	return b.String()
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
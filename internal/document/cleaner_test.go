@@ -0,0 +1,49 @@
+package document
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCleaner 测试去掉页码、重复的页眉页脚、版权声明和自定义模板文本，并保留分页符
+func TestCleaner(t *testing.T) {
+	cleaner, err := NewCleaner(BuiltinCleanRules(), []string{`(?m)^本邮件及其附件含有保密信息.*$`}, 3)
+	require.NoError(t, err)
+
+	pages := []string{
+		"示例公司 产品手册 v2.1\n\n# 安装\n\n安装前请确认服务器满足最低配置要求。\n\n\n\n本邮件及其附件含有保密信息，仅限收件人使用。\n\n第 1 页",
+		"示例公司 产品手册 v2.1\n\n## 配置\n\n修改config.yaml中的端口后重启服务。   \n\n- 2 -",
+		"示例公司 产品手册 v2.1\n\n## 升级\n\n升级时先备份数据库。\n\nCopyright 2024 Example Inc.\n3",
+	}
+	text := strings.Join(pages, PageBreak)
+
+	result := cleaner.Clean(text)
+	assert.Equal(t, "\n# 安装\n\n安装前请确认服务器满足最低配置要求。\n\n"+PageBreak+
+		"\n## 配置\n\n修改config.yaml中的端口后重启服务。\n"+PageBreak+
+		"\n## 升级\n\n升级时先备份数据库。\n", result.Text)
+	assert.Equal(t, utf8.RuneCountInString(text), result.CharsBefore)
+	assert.Equal(t, utf8.RuneCountInString(result.Text), result.CharsAfter)
+	assert.Equal(t, 7, result.LinesRemoved)
+
+	// 未分页的文本不检测页眉页脚，单独的数字也不视为页码
+	plain := "示例公司 产品手册\n\n步骤如下：\n42\nPage 3 of 10"
+	assert.Equal(t, "示例公司 产品手册\n\n步骤如下：\n42", cleaner.Clean(plain).Text)
+
+	// 只启用部分规则
+	onlyPages, err := NewCleaner([]string{CleanPageNumbers}, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "All rights reserved.\n\n\n\n正文", onlyPages.Clean("All rights reserved.\n第 2 页\n\n\n\n正文").Text)
+
+	// 清洗器为nil时原样返回
+	var none *Cleaner
+	assert.Equal(t, CleanResult{Text: "正文", CharsBefore: 2, CharsAfter: 2}, none.Clean("正文"))
+
+	_, err = NewCleaner([]string{"footnotes"}, nil, 3)
+	assert.ErrorContains(t, err, `unknown clean rule "footnotes"`)
+	_, err = NewCleaner(nil, []string{"("}, 3)
+	assert.ErrorContains(t, err, "invalid clean pattern")
+}
@@ -0,0 +1,189 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxArchiveEntries 单个压缩包最多展开的文件数量
+	maxArchiveEntries = 200
+	// maxEntryBytes 展开的单个文件的最大字节数
+	maxEntryBytes = 50 * 1024 * 1024
+	// maxArchiveBytes 单个压缩包展开后的最大总字节数，防止压缩炸弹
+	maxArchiveBytes = 500 * 1024 * 1024
+)
+
+// containerExts 需要展开为子文档的文件类型
+var containerExts = map[string]bool{
+	".zip": true,
+	".eml": true,
+	".msg": true,
+}
+
+// ContainerEntry 从压缩包或邮件中展开的文件
+type ContainerEntry struct {
+	Name  string // 文件名
+	Path  string // 在压缩包中的路径，邮件附件为附件文件名
+	Data  []byte // 文件内容
+	Email *Email // 展开自邮件时为所属的邮件
+	Body  bool   // 是否是由邮件正文生成的Markdown文件
+}
+
+// IsContainer 判断文件是否是需要展开的压缩包或邮件
+func IsContainer(filename string) bool {
+	return containerExts[strings.ToLower(filepath.Ext(filename))]
+}
+
+// ExpandContainer 展开压缩包或邮件，filename用于确定文件类型
+// 压缩包返回其中的文件，跳过目录、隐藏文件和macOS的元数据；
+// 邮件返回由正文生成的Markdown文件和全部附件。不支持的文件类型返回空
+func ExpandContainer(r io.Reader, filename string) ([]ContainerEntry, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".zip":
+		return expandZip(r)
+	case ".eml":
+		email, err := ParseEML(r)
+		if err != nil {
+			return nil, err
+		}
+		return expandEmail(email), nil
+	case ".msg":
+		email, err := ParseMSG(r)
+		if err != nil {
+			return nil, err
+		}
+		return expandEmail(email), nil
+	default:
+		return nil, nil
+	}
+}
+
+// expandEmail 把邮件展开为正文文件和附件
+func expandEmail(email *Email) []ContainerEntry {
+	entries := []ContainerEntry{{
+		Name:  safeFileName(email.Subject, "邮件") + ".md",
+		Data:  []byte(email.Markdown()),
+		Email: email,
+		Body:  true,
+	}}
+	for _, a := range email.Attachments {
+		entries = append(entries, ContainerEntry{
+			Name:  a.Name,
+			Path:  a.Name,
+			Data:  a.Data,
+			Email: email,
+		})
+	}
+	return entries
+}
+
+// expandZip 展开zip压缩包
+func expandZip(r io.Reader) ([]ContainerEntry, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPackageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if len(data) > maxPackageBytes {
+		return nil, fmt.Errorf("archive too large")
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	var (
+		entries []ContainerEntry
+		total   int64
+	)
+	for _, f := range reader.File {
+		name := zipEntryName(f)
+		if f.FileInfo().IsDir() || skipArchiveEntry(name) {
+			continue
+		}
+		if len(entries) >= maxArchiveEntries {
+			return nil, fmt.Errorf("archive contains more than %d files", maxArchiveEntries)
+		}
+		if f.UncompressedSize64 > maxEntryBytes {
+			return nil, fmt.Errorf("archive entry %s is larger than %d bytes", name, maxEntryBytes)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open archive entry %s: %w", name, err)
+		}
+		// 文件头中记录的大小不可信，读取时再限制一次
+		content, err := io.ReadAll(io.LimitReader(rc, maxEntryBytes+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive entry %s: %w", name, err)
+		}
+		if len(content) > maxEntryBytes {
+			return nil, fmt.Errorf("archive entry %s is larger than %d bytes", name, maxEntryBytes)
+		}
+		if total += int64(len(content)); total > maxArchiveBytes {
+			return nil, fmt.Errorf("archive expands to more than %d bytes", maxArchiveBytes)
+		}
+
+		entries = append(entries, ContainerEntry{
+			Name: path.Base(name),
+			Path: name,
+			Data: content,
+		})
+	}
+	return entries, nil
+}
+
+// zipEntryName 返回压缩包中的文件路径
+// Windows中文系统创建的压缩包文件名通常是GBK编码且未设置UTF-8标志，这里转换为UTF-8
+func zipEntryName(f *zip.File) string {
+	name := f.Name
+	if !utf8.ValidString(name) {
+		if enc, _ := charset.Lookup("gbk"); enc != nil {
+			if decoded, err := enc.NewDecoder().String(name); err == nil {
+				name = decoded
+			}
+		}
+	}
+	return strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "/")
+}
+
+// skipArchiveEntry 判断是否跳过压缩包中的文件：macOS元数据、隐藏文件和系统文件
+func skipArchiveEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == "__MACOSX" || strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	base := strings.ToLower(path.Base(name))
+	return base == "thumbs.db" || base == "desktop.ini"
+}
+
+// safeFileName 把任意文本转换为可用作文件名的字符串，为空时返回fallback
+func safeFileName(s, fallback string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		case r < 0x20:
+			return -1
+		default:
+			return r
+		}
+	}, strings.TrimSpace(s))
+	if runes := []rune(s); len(runes) > 80 {
+		s = string(runes[:80])
+	}
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpandContainer 测试展开压缩包和邮件
+func TestExpandContainer(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range []struct{ name, data string }{
+		{"docs/", ""},
+		{"docs/手册.md", "# 手册"},
+		{"docs/.DS_Store", "x"},
+		{"__MACOSX/docs/._手册.md", "x"},
+		{"notes.txt", "笔记"},
+	} {
+		f, err := w.Create(entry.name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	entries, err := ExpandContainer(bytes.NewReader(buf.Bytes()), "资料.ZIP")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "手册.md", entries[0].Name)
+	assert.Equal(t, "docs/手册.md", entries[0].Path)
+	assert.Equal(t, "# 手册", string(entries[0].Data))
+	assert.Equal(t, "notes.txt", entries[1].Name)
+	assert.Nil(t, entries[1].Email)
+
+	raw := "From: a@example.com\r\nSubject: Q3/Q4 plan\r\n\r\nbody text\r\n"
+	entries, err = ExpandContainer(strings.NewReader(raw), "mail.eml")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Body)
+	assert.Equal(t, "Q3_Q4 plan.md", entries[0].Name)
+	assert.Equal(t, "a@example.com", entries[0].Email.From)
+	assert.Contains(t, string(entries[0].Data), "body text")
+
+	assert.True(t, IsContainer("a.msg"))
+	assert.False(t, IsContainer("a.docx"))
+	entries, err = ExpandContainer(strings.NewReader("x"), "a.pdf")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
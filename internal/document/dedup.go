@@ -0,0 +1,201 @@
+package document
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// simhashShingle SimHash特征使用的字符窗口长度
+const simhashShingle = 3
+
+// SimHash 计算文本的64位SimHash指纹
+// 文本先归一化（转小写、数字统一为0、去掉空白和标点），再按3个字符的滑动窗口提取特征，
+// 使页码、日期不同而其余相同的页眉页脚得到相同或相近的指纹。空文本返回0
+func SimHash(text string) uint64 {
+	runes := normalizeForSimHash(text)
+	if len(runes) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	add := func(feature []rune) {
+		h := fnv.New64a()
+		h.Write([]byte(string(feature)))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(runes) < simhashShingle {
+		add(runes)
+	}
+	for i := 0; i+simhashShingle <= len(runes); i++ {
+		add(runes[i : i+simhashShingle])
+	}
+
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+// normalizeForSimHash 归一化文本，只保留字母和数字
+func normalizeForSimHash(text string) []rune {
+	runes := make([]rune, 0, len(text))
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsDigit(r):
+			runes = append(runes, '0')
+		case unicode.IsLetter(r):
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// HammingDistance 返回两个指纹不同的位数
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// DuplicateIndex 近似重复段落索引
+// 把64位指纹切成maxDistance+1段，两个指纹的汉明距离不超过maxDistance时至少有一段完全相同，
+// 查找时只需比较有相同分段的候选，不必遍历全部指纹
+type DuplicateIndex struct {
+	maxDistance int
+	bands       int
+
+	mu       sync.RWMutex
+	hashes   map[string]uint64     // 段落ID -> 指纹
+	docs     map[string][]string   // 文档ID -> 段落ID
+	segments map[string]string     // 段落ID -> 文档ID
+	buckets  []map[uint64][]string // 每段的取值 -> 段落ID，按加入顺序
+}
+
+// NewDuplicateIndex 创建近似重复段落索引，maxDistance为判定重复的最大汉明距离（0~63）
+func NewDuplicateIndex(maxDistance int) *DuplicateIndex {
+	maxDistance = min(max(maxDistance, 0), 63)
+	x := &DuplicateIndex{
+		maxDistance: maxDistance,
+		bands:       maxDistance + 1,
+		hashes:      make(map[string]uint64),
+		docs:        make(map[string][]string),
+		segments:    make(map[string]string),
+	}
+	x.buckets = make([]map[uint64][]string, x.bands)
+	for i := range x.buckets {
+		x.buckets[i] = make(map[uint64][]string)
+	}
+	return x
+}
+
+// band 返回指纹的第i段，最后一段包含剩余的位
+func (x *DuplicateIndex) band(hash uint64, i int) uint64 {
+	width := 64 / x.bands
+	shifted := hash >> uint(i*width)
+	if i == x.bands-1 {
+		return shifted
+	}
+	return shifted & (1<<uint(width) - 1)
+}
+
+// Add 加入段落指纹，段落已存在时先移除旧指纹
+func (x *DuplicateIndex) Add(docID, segmentID string, hash uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.hashes[segmentID]; ok {
+		x.removeLocked(segmentID)
+	}
+	x.hashes[segmentID] = hash
+	x.segments[segmentID] = docID
+	x.docs[docID] = append(x.docs[docID], segmentID)
+	for i := range x.buckets {
+		key := x.band(hash, i)
+		x.buckets[i][key] = append(x.buckets[i][key], segmentID)
+	}
+}
+
+// Find 查找与指纹近似重复的段落，有多个时返回汉明距离最小的段落
+func (x *DuplicateIndex) Find(hash uint64) (string, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	best, bestDistance := "", x.maxDistance+1
+	seen := make(map[string]bool)
+	for i := range x.buckets {
+		for _, id := range x.buckets[i][x.band(hash, i)] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if d := HammingDistance(hash, x.hashes[id]); d < bestDistance {
+				best, bestDistance = id, d
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// Remove 移除段落指纹
+func (x *DuplicateIndex) Remove(segmentID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(segmentID)
+}
+
+// RemoveDocument 移除文档的所有段落指纹
+func (x *DuplicateIndex) RemoveDocument(docID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, id := range append([]string(nil), x.docs[docID]...) {
+		x.removeLocked(id)
+	}
+}
+
+// Len 返回索引中的段落数量
+func (x *DuplicateIndex) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.hashes)
+}
+
+func (x *DuplicateIndex) removeLocked(segmentID string) {
+	hash, ok := x.hashes[segmentID]
+	if !ok {
+		return
+	}
+	delete(x.hashes, segmentID)
+	docID := x.segments[segmentID]
+	delete(x.segments, segmentID)
+	x.docs[docID] = removeString(x.docs[docID], segmentID)
+	if len(x.docs[docID]) == 0 {
+		delete(x.docs, docID)
+	}
+	for i := range x.buckets {
+		key := x.band(hash, i)
+		x.buckets[i][key] = removeString(x.buckets[i][key], segmentID)
+		if len(x.buckets[i][key]) == 0 {
+			delete(x.buckets[i], key)
+		}
+	}
+}
+
+// removeString 从切片中移除指定元素，保持其余元素的顺序
+func removeString(items []string, target string) []string {
+	out := items[:0]
+	for _, item := range items {
+		if item != target {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSimHash 测试页码不同的模板文本得到相同或相近的指纹，无关文本差异很大
+func TestSimHash(t *testing.T) {
+	footer := SimHash("本文档仅供内部参考，未经许可不得转载。版权所有 © 2024 示例公司 第 3 页")
+	assert.Equal(t, footer, SimHash("本文档仅供内部参考，未经许可不得转载。版权所有 © 2024 示例公司 第 8 页"))
+	assert.LessOrEqual(t, HammingDistance(footer,
+		SimHash("本文档仅供内部参考,未经许可不得转载. 版权所有 (c) 2025 示例公司 - 第 7 页")), 3)
+	assert.Greater(t, HammingDistance(footer, SimHash("安装前请确认服务器满足最低配置要求，并关闭防火墙的相关端口。")), 20)
+
+	assert.Zero(t, SimHash(" ，。 "))
+	assert.NotZero(t, SimHash("是"))
+}
+
+// TestDuplicateIndex 测试按汉明距离查找近似重复段落，以及移除段落和文档
+func TestDuplicateIndex(t *testing.T) {
+	index := NewDuplicateIndex(3)
+	base := uint64(0xF0F0_1234_ABCD_0001)
+	index.Add("a", "a_0", base)
+	index.Add("a", "a_1", ^base)
+	index.Add("b", "b_0", base^0b111) // 与a_0相差3位
+
+	// 有多个候选时返回距离最小的段落
+	id, ok := index.Find(base ^ 0b1)
+	assert.True(t, ok)
+	assert.Equal(t, "a_0", id)
+	id, ok = index.Find(base ^ 0b110)
+	assert.True(t, ok)
+	assert.Equal(t, "b_0", id)
+
+	// 距离超过阈值时不算重复，即使差异分散在不同分段中
+	_, ok = index.Find(base ^ (1 | 1<<20 | 1<<40 | 1<<60))
+	assert.False(t, ok)
+
+	index.RemoveDocument("a")
+	assert.Equal(t, 1, index.Len())
+	id, ok = index.Find(base)
+	assert.True(t, ok)
+	assert.Equal(t, "b_0", id)
+
+	index.Remove("b_0")
+	_, ok = index.Find(base)
+	assert.False(t, ok)
+	assert.Zero(t, index.Len())
+}
@@ -0,0 +1,456 @@
+package document
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// maxEmailParts 单封邮件最多解析的MIME部分数量
+const maxEmailParts = 500
+
+// Email 解析出的邮件
+type Email struct {
+	Subject     string       // 主题
+	From        string       // 发件人
+	To          string       // 收件人，多个收件人以逗号分隔
+	Date        time.Time    // 发送时间，未知时为零值
+	Body        string       // 正文纯文本，HTML正文会提取为文本
+	Attachments []Attachment // 附件
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Name string // 附件文件名
+	Data []byte // 附件内容
+}
+
+// Markdown 把邮件渲染为Markdown文本，包含主题、发件人、收件人、日期和正文
+func (e *Email) Markdown() string {
+	var sb strings.Builder
+	subject := e.Subject
+	if subject == "" {
+		subject = "（无主题）"
+	}
+	sb.WriteString("# " + subject + "\n\n")
+	if e.From != "" {
+		sb.WriteString("发件人：" + e.From + "\n")
+	}
+	if e.To != "" {
+		sb.WriteString("收件人：" + e.To + "\n")
+	}
+	if !e.Date.IsZero() {
+		sb.WriteString("日期：" + e.Date.Format("2006-01-02 15:04:05 -0700") + "\n")
+	}
+	if len(e.Attachments) > 0 {
+		names := make([]string, len(e.Attachments))
+		for i, a := range e.Attachments {
+			names[i] = a.Name
+		}
+		sb.WriteString("附件：" + strings.Join(names, "、") + "\n")
+	}
+	sb.WriteString("\n" + strings.TrimSpace(e.Body) + "\n")
+	return sb.String()
+}
+
+// emlDecoder 解码邮件头中的编码字（=?charset?B?...?=）
+var emlDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// ParseEML 解析RFC 5322格式的邮件（.eml）
+// 正文优先使用text/plain部分，没有时提取text/html部分的文本；带文件名的部分和内嵌的邮件作为附件
+func ParseEML(r io.Reader) (*Email, error) {
+	msg, err := mail.ReadMessage(io.LimitReader(r, maxPackageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+
+	email := &Email{
+		Subject: decodeHeader(msg.Header.Get("Subject")),
+		From:    formatAddresses(msg.Header.Get("From")),
+		To:      formatAddresses(msg.Header.Get("To")),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		email.Date = date
+	}
+
+	p := &emlParser{email: email}
+	if err := p.walk(msg.Header, msg.Body, 0); err != nil {
+		return nil, err
+	}
+	email.Body = p.plain
+	if email.Body == "" && p.html != "" {
+		if content, err := ExtractHTML(strings.NewReader(p.html)); err == nil {
+			email.Body = content.Text
+		}
+	}
+	return email, nil
+}
+
+// mimeHeader MIME部分的头部，邮件和multipart部分的头部类型不同，统一按该接口读取
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// emlParser 遍历邮件的MIME结构
+type emlParser struct {
+	email *Email
+	plain string // 第一个text/plain正文
+	html  string // 第一个text/html正文
+	parts int    // 已解析的部分数量
+}
+
+// walk 递归解析MIME部分
+func (p *emlParser) walk(header mimeHeader, body io.Reader, depth int) error {
+	p.parts++
+	if p.parts > maxEmailParts || depth > 10 {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				// 截断或格式错误的邮件保留已经解析出的部分
+				return nil
+			}
+			if err := p.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+	if err != nil {
+		return fmt.Errorf("failed to decode email part: %w", err)
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dispParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	name = path.Base(strings.ReplaceAll(decodeHeader(name), "\\", "/"))
+	if name == "." || name == "/" {
+		name = ""
+	}
+
+	switch {
+	case mediaType == "message/rfc822":
+		if name == "" {
+			name = "attached.eml"
+		}
+		p.email.Attachments = append(p.email.Attachments, Attachment{Name: name, Data: data})
+	case name != "" || disposition == "attachment":
+		if name == "" {
+			name = fmt.Sprintf("attachment%d", len(p.email.Attachments)+1)
+		}
+		p.email.Attachments = append(p.email.Attachments, Attachment{Name: name, Data: data})
+	case mediaType == "text/plain" && p.plain == "":
+		p.plain = decodeCharset(data, params["charset"])
+	case mediaType == "text/html" && p.html == "":
+		p.html = decodeCharset(data, params["charset"])
+	}
+	return nil
+}
+
+// decodeTransfer 按Content-Transfer-Encoding解码内容
+func decodeTransfer(encoding string, r io.Reader) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		// 去掉换行和空白，容忍缺少填充的内容
+		cleaned := strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				return -1
+			}
+			return r
+		}, string(raw))
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(cleaned, "="))
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(r))
+	default:
+		return io.ReadAll(r)
+	}
+}
+
+// decodeCharset 把指定字符集的内容转换为UTF-8
+func decodeCharset(data []byte, label string) string {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "utf-8" || label == "utf8" || label == "us-ascii" {
+		return string(data)
+	}
+	reader, err := charset.NewReaderLabel(label, bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// decodeHeader 解码邮件头中的编码字，解码失败时返回原文
+func decodeHeader(value string) string {
+	decoded, err := emlDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// formatAddresses 把地址列表格式化为"名称 <地址>"并以逗号分隔，无法解析时返回解码后的原文
+func formatAddresses(value string) string {
+	if value == "" {
+		return ""
+	}
+	parser := mail.AddressParser{WordDecoder: emlDecoder}
+	addrs, err := parser.ParseList(value)
+	if err != nil {
+		return decodeHeader(value)
+	}
+	formatted := make([]string, len(addrs))
+	for i, addr := range addrs {
+		formatted[i] = formatAddress(addr.Name, addr.Address)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatAddress 格式化单个地址
+func formatAddress(name, address string) string {
+	switch {
+	case name == "":
+		return address
+	case address == "" || name == address:
+		return name
+	default:
+		return name + " <" + address + ">"
+	}
+}
+
+// Outlook邮件（.msg）的MAPI属性标签
+const (
+	msgTagSubject          = 0x0037
+	msgTagSubmitTime       = 0x0039
+	msgTagTransportHeaders = 0x007D
+	msgTagDisplayTo        = 0x0E04
+	msgTagDeliveryTime     = 0x0E06
+	msgTagBody             = 0x1000
+	msgTagHTML             = 0x1013
+	msgTagSenderName       = 0x0C1A
+	msgTagSenderEmail      = 0x0C1F
+	msgTagSenderSMTP       = 0x5D01
+	msgTagAttachData       = 0x3701
+	msgTagAttachFilename   = 0x3704
+	msgTagAttachLongName   = 0x3707
+
+	msgTypeString  = 0x001F // UTF-16LE字符串
+	msgTypeString8 = 0x001E // 8位字符串
+	msgTypeBinary  = 0x0102
+	msgTypeTime    = 0x0040 // FILETIME
+
+	msgAttachPrefix = "__attach_version1.0_#"
+	msgPropsStream  = "__properties_version1.0"
+)
+
+// msgProperties .msg中一个存储（邮件或附件）的属性，按属性标签索引
+type msgProperties struct {
+	values map[uint16][]byte // 可变长度属性的原始内容
+	types  map[uint16]uint16 // 属性的类型
+	times  map[uint16]time.Time
+}
+
+// ParseMSG 解析Outlook的.msg邮件
+// .msg是复合文档，每个MAPI属性保存为名为__substg1.0_<标签><类型>的流，附件保存在子存储中
+func ParseMSG(r io.Reader) (*Email, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPackageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email: %w", err)
+	}
+	if len(data) > maxPackageBytes {
+		return nil, fmt.Errorf("email too large")
+	}
+	cf, err := openCompoundFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open msg file: %w", err)
+	}
+
+	root := cf.entries[0]
+	props := readMSGProperties(cf, root, 32)
+	email := &Email{
+		Subject: props.text(msgTagSubject),
+		To:      props.text(msgTagDisplayTo),
+		Body:    props.text(msgTagBody),
+	}
+
+	address := props.text(msgTagSenderSMTP)
+	if sender := props.text(msgTagSenderEmail); address == "" && !strings.HasPrefix(sender, "/") {
+		// Exchange内部地址以/O=开头，不作为发件人地址
+		address = sender
+	}
+	email.From = formatAddress(props.text(msgTagSenderName), address)
+
+	if headers := props.text(msgTagTransportHeaders); headers != "" {
+		if msg, err := mail.ReadMessage(strings.NewReader(headers + "\r\n\r\n")); err == nil {
+			if date, err := msg.Header.Date(); err == nil {
+				email.Date = date
+			}
+		}
+	}
+	if email.Date.IsZero() {
+		if t, ok := props.times[msgTagSubmitTime]; ok {
+			email.Date = t
+		} else if t, ok := props.times[msgTagDeliveryTime]; ok {
+			email.Date = t
+		}
+	}
+
+	if email.Body == "" {
+		if html := props.values[msgTagHTML]; len(html) > 0 {
+			if content, err := ExtractHTML(bytes.NewReader(html)); err == nil {
+				email.Body = content.Text
+			}
+		}
+	}
+
+	for _, child := range cf.children(root) {
+		if child.kind != cfbTypeStorage || !strings.HasPrefix(child.name, msgAttachPrefix) {
+			continue
+		}
+		// 附件存储的属性流头部为8字节
+		attach := readMSGProperties(cf, child, 8)
+		content, ok := attach.values[msgTagAttachData]
+		if !ok || attach.types[msgTagAttachData] != msgTypeBinary {
+			// 内嵌的邮件或OLE对象保存为子存储，不作为附件提取
+			continue
+		}
+		name := attach.text(msgTagAttachLongName)
+		if name == "" {
+			name = attach.text(msgTagAttachFilename)
+		}
+		if name == "" {
+			name = fmt.Sprintf("attachment%d", len(email.Attachments)+1)
+		}
+		email.Attachments = append(email.Attachments, Attachment{Name: path.Base(name), Data: content})
+	}
+	return email, nil
+}
+
+// readMSGProperties 读取存储下的属性流，headerSize是固定长度属性流的头部字节数
+func readMSGProperties(cf *compoundFile, storage cfbEntry, headerSize int) *msgProperties {
+	props := &msgProperties{
+		values: make(map[uint16][]byte),
+		types:  make(map[uint16]uint16),
+		times:  make(map[uint16]time.Time),
+	}
+	for _, e := range cf.children(storage) {
+		if e.kind != cfbTypeStream {
+			continue
+		}
+		if e.name == msgPropsStream {
+			if raw, err := cf.stream(e); err == nil {
+				props.readFixed(raw, headerSize)
+			}
+			continue
+		}
+		tag, typ, ok := msgStreamTag(e.name)
+		if !ok {
+			continue
+		}
+		raw, err := cf.stream(e)
+		if err != nil {
+			continue
+		}
+		props.values[tag] = raw
+		props.types[tag] = typ
+	}
+	return props
+}
+
+// msgStreamTag 从属性流名称__substg1.0_<标签><类型>中解析属性标签和类型
+func msgStreamTag(name string) (tag, typ uint16, ok bool) {
+	hex, found := strings.CutPrefix(name, "__substg1.0_")
+	if !found || len(hex) != 8 {
+		return 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint16(v >> 16), uint16(v), true
+}
+
+// readFixed 读取固定长度的属性，每项16字节：4字节标签、4字节标志、8字节值，这里只保留时间属性
+func (p *msgProperties) readFixed(raw []byte, headerSize int) {
+	le := binary.LittleEndian
+	for off := headerSize; off+16 <= len(raw); off += 16 {
+		tag := le.Uint32(raw[off:])
+		if uint16(tag) != msgTypeTime {
+			continue
+		}
+		if t, ok := fileTime(le.Uint64(raw[off+8:])); ok {
+			p.times[uint16(tag>>16)] = t
+		}
+	}
+}
+
+// text 返回字符串属性的值
+func (p *msgProperties) text(tag uint16) string {
+	raw, ok := p.values[tag]
+	if !ok {
+		return ""
+	}
+	var s string
+	switch p.types[tag] {
+	case msgTypeString:
+		units := make([]uint16, len(raw)/2)
+		for i := range units {
+			units[i] = binary.LittleEndian.Uint16(raw[i*2:])
+		}
+		s = string(utf16.Decode(units))
+	case msgTypeString8:
+		s = string(raw)
+		if !utf8.ValidString(s) {
+			s = decodeCharset(raw, "gbk")
+		}
+	default:
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
+
+// fileTime 把Windows FILETIME（自1601年起的100纳秒数）转换为时间
+func fileTime(ft uint64) (time.Time, bool) {
+	// 1601-01-01到1970-01-01之间的100纳秒数
+	const epochDelta = 116444736000000000
+	if ft <= epochDelta {
+		return time.Time{}, false
+	}
+	ticks := ft - epochDelta
+	return time.Unix(int64(ticks/1e7), int64(ticks%1e7)*100).UTC(), true
+}
@@ -0,0 +1,220 @@
+package document
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseEML 测试解析带编码主题、HTML正文和附件的邮件
+func TestParseEML(t *testing.T) {
+	raw := strings.Join([]string{
+		"From: =?UTF-8?B?5byg5LiJ?= <zhangsan@example.com>",
+		"To: lisi@example.com, =?UTF-8?B?546L5LqU?= <wangwu@example.com>",
+		"Subject: =?UTF-8?B?5Lqn5ZOB6K+E5a6h?=",
+		"Date: Mon, 02 Jun 2025 10:30:00 +0800",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		`Content-Type: text/html; charset="utf-8"`,
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"<html><body><p>=E8=AF=84=E5=AE=A1=E5=AE=89=E6=8E=92=E5=9C=A8=E5=91=A8=E4=B8=89</p></body></html>",
+		"--inner--",
+		"--outer",
+		`Content-Type: text/plain; name="notes.txt"`,
+		"Content-Disposition: attachment; filename*=UTF-8''%E7%BA%AA%E8%A6%81.txt",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"5Lya6K6u57qq6KaB",
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	email, err := ParseEML(strings.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "产品评审", email.Subject)
+	assert.Equal(t, "张三 <zhangsan@example.com>", email.From)
+	assert.Equal(t, "lisi@example.com, 王五 <wangwu@example.com>", email.To)
+	assert.Equal(t, time.Date(2025, 6, 2, 2, 30, 0, 0, time.UTC), email.Date.UTC())
+	assert.Contains(t, email.Body, "评审安排在周三")
+	require.Len(t, email.Attachments, 1)
+	assert.Equal(t, "纪要.txt", email.Attachments[0].Name)
+	assert.Equal(t, "会议纪要", string(email.Attachments[0].Data))
+
+	md := email.Markdown()
+	assert.True(t, strings.HasPrefix(md, "# 产品评审\n"))
+	assert.Contains(t, md, "发件人：张三 <zhangsan@example.com>")
+	assert.Contains(t, md, "附件：纪要.txt")
+}
+
+// TestParseMSG 测试解析Outlook邮件的属性和附件
+func TestParseMSG(t *testing.T) {
+	submitted := time.Date(2025, 6, 2, 2, 30, 0, 0, time.UTC)
+	props := make([]byte, 32+16)
+	binary.LittleEndian.PutUint32(props[32:], msgTagSubmitTime<<16|msgTypeTime)
+	binary.LittleEndian.PutUint64(props[40:], uint64(submitted.UnixNano()/100)+116444736000000000)
+
+	data := buildCompoundFile([]*cfbNode{
+		{name: "__substg1.0_0037001F", data: utf16le("季度报告")},
+		{name: "__substg1.0_0C1A001F", data: utf16le("张三")},
+		{name: "__substg1.0_0C1F001F", data: utf16le("/O=EXCHANGE/CN=ZHANGSAN")},
+		{name: "__substg1.0_5D01001F", data: utf16le("zhangsan@example.com")},
+		{name: "__substg1.0_0E04001F", data: utf16le("李四")},
+		{name: "__substg1.0_1000001F", data: utf16le("请查收附件中的报告。")},
+		{name: msgPropsStream, data: props},
+		{name: msgAttachPrefix + "00000000", children: []*cfbNode{
+			{name: "__substg1.0_3707001F", data: utf16le("报告.txt")},
+			{name: "__substg1.0_37010102", data: []byte(strings.Repeat("收入增长", 200))},
+		}},
+	})
+
+	email, err := ParseMSG(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "季度报告", email.Subject)
+	assert.Equal(t, "张三 <zhangsan@example.com>", email.From)
+	assert.Equal(t, "李四", email.To)
+	assert.Equal(t, "请查收附件中的报告。", email.Body)
+	assert.True(t, submitted.Equal(email.Date))
+	require.Len(t, email.Attachments, 1)
+	assert.Equal(t, "报告.txt", email.Attachments[0].Name)
+	assert.Equal(t, strings.Repeat("收入增长", 200), string(email.Attachments[0].Data))
+
+	_, err = ParseMSG(strings.NewReader("not a compound file"))
+	assert.Error(t, err)
+}
+
+// cfbNode 测试中构造复合文档的节点，有children时为存储
+type cfbNode struct {
+	name     string
+	data     []byte
+	children []*cfbNode
+}
+
+// buildCompoundFile 构造512字节扇区的复合文档，所有流都保存在普通扇区中
+func buildCompoundFile(nodes []*cfbNode) []byte {
+	const sectorSize = 512
+	le := binary.LittleEndian
+
+	// 按深度优先分配目录项编号，同一存储下的子项通过right指针串联
+	type dirEntry struct {
+		node  *cfbNode
+		kind  byte
+		child uint32
+		right uint32
+		start uint32
+	}
+	entries := []*dirEntry{{kind: cfbTypeRoot, child: cfbNoStream, right: cfbNoStream, start: cfbEndOfChain}}
+	var add func(parent *dirEntry, children []*cfbNode)
+	add = func(parent *dirEntry, children []*cfbNode) {
+		var prev *dirEntry
+		for _, n := range children {
+			e := &dirEntry{node: n, kind: cfbTypeStream, child: cfbNoStream, right: cfbNoStream, start: cfbEndOfChain}
+			if n.children != nil {
+				e.kind = cfbTypeStorage
+			}
+			id := uint32(len(entries))
+			entries = append(entries, e)
+			if prev == nil {
+				parent.child = id
+			} else {
+				prev.right = id
+			}
+			prev = e
+			if n.children != nil {
+				add(e, n.children)
+			}
+		}
+	}
+	add(entries[0], nodes)
+
+	// 扇区0为FAT，之后依次是目录和流数据
+	dirSectors := (len(entries) + 3) / 4
+	fat := []uint32{0xFFFFFFFD}
+	for i := 0; i < dirSectors; i++ {
+		fat = append(fat, uint32(len(fat)+1))
+	}
+	fat[len(fat)-1] = cfbEndOfChain
+	var body []byte
+	for _, e := range entries {
+		if e.kind != cfbTypeStream || len(e.node.data) == 0 {
+			continue
+		}
+		e.start = uint32(len(fat))
+		n := (len(e.node.data) + sectorSize - 1) / sectorSize
+		for i := 0; i < n; i++ {
+			fat = append(fat, uint32(len(fat)+1))
+		}
+		fat[len(fat)-1] = cfbEndOfChain
+		padded := make([]byte, n*sectorSize)
+		copy(padded, e.node.data)
+		body = append(body, padded...)
+	}
+
+	header := make([]byte, sectorSize)
+	copy(header, cfbSignature)
+	le.PutUint16(header[0x1E:], 9)
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1)
+	le.PutUint32(header[0x30:], 1)
+	le.PutUint32(header[0x3C:], cfbEndOfChain)
+	le.PutUint32(header[0x44:], cfbEndOfChain)
+	for i := 0; i < cfbHeaderDIFAT; i++ {
+		le.PutUint32(header[0x4C+i*4:], cfbFreeSect)
+	}
+	le.PutUint32(header[0x4C:], 0)
+
+	fatSector := make([]byte, sectorSize)
+	for i := range fatSector {
+		fatSector[i] = 0xFF
+	}
+	for i, v := range fat {
+		le.PutUint32(fatSector[i*4:], v)
+	}
+
+	dir := make([]byte, dirSectors*sectorSize)
+	for i, e := range entries {
+		raw := dir[i*cfbDirEntry : (i+1)*cfbDirEntry]
+		name := "Root Entry"
+		var size int
+		if e.node != nil {
+			name = e.node.name
+			size = len(e.node.data)
+		}
+		units := utf16.Encode([]rune(name))
+		for j, u := range units {
+			le.PutUint16(raw[j*2:], u)
+		}
+		le.PutUint16(raw[64:], uint16((len(units)+1)*2))
+		raw[66] = e.kind
+		le.PutUint32(raw[68:], cfbNoStream)
+		le.PutUint32(raw[72:], e.right)
+		le.PutUint32(raw[76:], e.child)
+		le.PutUint32(raw[116:], e.start)
+		le.PutUint64(raw[120:], uint64(size))
+	}
+
+	data := append(header, fatSector...)
+	data = append(data, dir...)
+	return append(data, body...)
+}
+
+// utf16le 把字符串编码为UTF-16LE
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[i*2:], u)
+	}
+	return buf
+}
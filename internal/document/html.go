@@ -0,0 +1,301 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLContent 从网页中提取的正文内容
+type HTMLContent struct {
+	Title string // 页面标题
+	Text  string // 正文文本，段落之间以空行分隔
+}
+
+var (
+	// noiseTags 不包含正文的标签，提取前直接移除
+	noiseTags = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+		atom.Svg: true, atom.Nav: true, atom.Header: true, atom.Footer: true,
+		atom.Aside: true, atom.Form: true, atom.Button: true, atom.Template: true,
+		atom.Select: true, atom.Object: true, atom.Embed: true,
+	}
+
+	// blockTags 块级标签，渲染时前后换段
+	blockTags = map[atom.Atom]bool{
+		atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+		atom.Blockquote: true, atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+		atom.Table: true, atom.Tr: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+		atom.Figure: true, atom.Figcaption: true, atom.Hr: true,
+		atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	}
+
+	// headingLevels 标题标签对应的级别
+	headingLevels = map[atom.Atom]int{
+		atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+	}
+
+	// negativePattern class或id命中时视为非正文区域
+	negativePattern = regexp.MustCompile(`(?i)comment|sidebar|menu|navbar|breadcrumb|footer|masthead|banner|sponsor|share|social|related|cookie|popup|modal|advert|\bads?\b|promo`)
+	// positivePattern class或id命中时视为正文区域，优先级高于negativePattern
+	positivePattern = regexp.MustCompile(`(?i)article|content|entry|main|post|body|text|story`)
+
+	// spacePattern 连续空白
+	spacePattern = regexp.MustCompile(`[ \t\r\n\f\v]+`)
+	// blankLinePattern 连续空行
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// minParagraphLength 参与正文打分的段落最小字符数
+const minParagraphLength = 25
+
+// ExtractHTML 提取网页的标题和正文
+// 采用类似readability的启发式方法：先移除脚本、导航、页脚等噪声节点，
+// 再按段落文本长度为其父节点打分，选出得分最高的节点作为正文容器
+func ExtractHTML(r io.Reader) (*HTMLContent, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	title := findTitle(root)
+	removeNoise(root)
+
+	container := findMainContent(root)
+	if container == nil {
+		return nil, fmt.Errorf("no content found in html")
+	}
+
+	var sb strings.Builder
+	renderText(&sb, container, false)
+	text := normalizeText(sb.String())
+	if text == "" {
+		return nil, fmt.Errorf("no content found in html")
+	}
+
+	if title == "" {
+		title = firstHeading(container)
+	}
+
+	return &HTMLContent{Title: title, Text: text}, nil
+}
+
+// findTitle 查找页面标题
+func findTitle(root *html.Node) string {
+	if n := findFirst(root, atom.Title); n != nil {
+		return collapseSpace(textContent(n))
+	}
+	return ""
+}
+
+// firstHeading 查找第一个一级或二级标题
+func firstHeading(root *html.Node) string {
+	for _, a := range []atom.Atom{atom.H1, atom.H2} {
+		if n := findFirst(root, a); n != nil {
+			return collapseSpace(textContent(n))
+		}
+	}
+	return ""
+}
+
+// removeNoise 移除噪声节点
+func removeNoise(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == html.CommentNode:
+			n.RemoveChild(c)
+		case c.Type == html.ElementNode && (noiseTags[c.DataAtom] || isNegative(c)):
+			n.RemoveChild(c)
+		default:
+			removeNoise(c)
+		}
+		c = next
+	}
+}
+
+// isNegative 根据class、id和role判断节点是否为非正文区域
+func isNegative(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html || n.DataAtom == atom.Main || n.DataAtom == atom.Article {
+		return false
+	}
+	role := attr(n, "role")
+	if role == "navigation" || role == "banner" || role == "contentinfo" || role == "complementary" {
+		return true
+	}
+	hint := attr(n, "class") + " " + attr(n, "id")
+	if strings.TrimSpace(hint) == "" {
+		return false
+	}
+	return negativePattern.MatchString(hint) && !positivePattern.MatchString(hint)
+}
+
+// findMainContent 选出正文容器
+// 优先使用article和main标签，否则按段落打分选出得分最高的节点，都没有时回退到body
+func findMainContent(root *html.Node) *html.Node {
+	body := findFirst(root, atom.Body)
+	if body == nil {
+		body = root
+	}
+
+	for _, a := range []atom.Atom{atom.Article, atom.Main} {
+		if n := findFirst(body, a); n != nil && utf8.RuneCountInString(collapseSpace(textContent(n))) >= minParagraphLength {
+			return n
+		}
+	}
+
+	scores := make(map[*html.Node]float64)
+	walk(body, func(n *html.Node) {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td) {
+			return
+		}
+		text := collapseSpace(textContent(n))
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return
+		}
+
+		// 段落越长、逗号越多，越可能是正文
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + float64(min(length/100, 3))
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+			if grand := parent.Parent; grand != nil {
+				scores[grand] += score / 2
+			}
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		// 链接密度高的节点多为导航或列表，按比例降低得分
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return body
+}
+
+// linkDensity 计算节点中链接文本占全部文本的比例
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(collapseSpace(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(c *html.Node) {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			links += utf8.RuneCountInString(collapseSpace(textContent(c)))
+		}
+	})
+	return float64(links) / float64(total)
+}
+
+// renderText 将节点渲染为纯文本
+// 块级元素之间以空行分隔，标题渲染为Markdown标题，列表项以"- "开头，pre中保留原始空白
+func renderText(sb *strings.Builder, n *html.Node, pre bool) {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			sb.WriteString(n.Data)
+		} else {
+			sb.WriteString(spacePattern.ReplaceAllString(n.Data, " "))
+		}
+		return
+	case html.ElementNode:
+		if n.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockTags[n.DataAtom]
+	if block {
+		sb.WriteString("\n\n")
+		if level, ok := headingLevels[n.DataAtom]; ok {
+			sb.WriteString(strings.Repeat("#", level) + " ")
+		} else if n.DataAtom == atom.Li {
+			sb.WriteString("- ")
+		}
+	}
+	if n.Type == html.ElementNode && (n.DataAtom == atom.Td || n.DataAtom == atom.Th) {
+		sb.WriteString(" ")
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderText(sb, c, pre || n.DataAtom == atom.Pre)
+	}
+
+	if block {
+		sb.WriteString("\n\n")
+	}
+}
+
+// normalizeText 整理渲染后的文本，去除行首尾空白并合并多余空行
+func normalizeText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+		if !strings.HasPrefix(line, "    ") {
+			lines[i] = strings.TrimLeft(lines[i], " \t")
+		}
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLinePattern.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// textContent 获取节点下的全部文本
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+			sb.WriteString(" ")
+		}
+	})
+	return sb.String()
+}
+
+// collapseSpace 合并连续空白
+func collapseSpace(s string) string {
+	return strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+}
+
+// findFirst 深度优先查找第一个指定标签的节点
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) {
+		if found == nil && c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+		}
+	})
+	return found
+}
+
+// walk 深度优先遍历节点
+func walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
+
+// attr 获取节点属性值
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
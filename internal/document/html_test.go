@@ -0,0 +1,58 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractHTML 测试网页正文提取
+func TestExtractHTML(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html>
+<head>
+	<title>RAG 入门</title>
+	<style>body { color: red; }</style>
+	<script>console.log("tracking")</script>
+</head>
+<body>
+	<header><a href="/">首页</a> <a href="/blog">博客</a></header>
+	<nav><ul><li><a href="/a">导航一</a></li><li><a href="/b">导航二</a></li></ul></nav>
+	<div class="sidebar"><p>热门文章推荐，点击查看更多精彩内容，不要错过。</p></div>
+	<div class="post-content">
+		<h2>什么是RAG</h2>
+		<p>检索增强生成先从知识库中检索相关段落，再把段落作为上下文交给大模型生成回答。</p>
+		<p>这种方式能够减少幻觉，并且让回答可以追溯到原始文档。</p>
+		<ul><li>检索</li><li>生成</li></ul>
+		<pre>answer = llm(question, contexts)</pre>
+	</div>
+	<footer>版权所有</footer>
+</body>
+</html>`
+
+	content, err := ExtractHTML(strings.NewReader(page))
+	require.NoError(t, err)
+	assert.Equal(t, "RAG 入门", content.Title)
+
+	assert.Contains(t, content.Text, "## 什么是RAG")
+	assert.Contains(t, content.Text, "检索增强生成先从知识库中检索相关段落")
+	assert.Contains(t, content.Text, "- 检索")
+	assert.Contains(t, content.Text, "answer = llm(question, contexts)")
+
+	for _, noise := range []string{"tracking", "color: red", "导航一", "首页", "热门文章推荐", "版权所有"} {
+		assert.NotContains(t, content.Text, noise)
+	}
+}
+
+// TestExtractHTMLFallback 测试没有明显正文容器时回退到整个页面
+func TestExtractHTMLFallback(t *testing.T) {
+	content, err := ExtractHTML(strings.NewReader(`<html><body><h1>标题</h1><div>简短内容</div></body></html>`))
+	require.NoError(t, err)
+	assert.Equal(t, "标题", content.Title)
+	assert.Equal(t, "# 标题\n\n简短内容", content.Text)
+
+	_, err = ExtractHTML(strings.NewReader(`<html><body><script>var a = 1;</script></body></html>`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 图片段落元数据的键
+const (
+	MetaImage     = "image"      // 段落对应图片的ID，有该键的段落内容是图片的描述
+	MetaImageName = "image_name" // 图片在原文档中的文件名
+)
+
+// DefaultMinImageBytes 默认的最小图片字节数，更小的图片多为图标、分隔线等装饰
+const DefaultMinImageBytes = 4 * 1024
+
+const (
+	// maxImageBytes 单张图片的最大字节数，超过的图片不提取
+	maxImageBytes = 10 * 1024 * 1024
+	// maxPackageBytes 提取图片时读取的文档最大字节数
+	maxPackageBytes = 200 * 1024 * 1024
+)
+
+// Image 从文档中提取的图片
+type Image struct {
+	Name     string // 图片在文档中的文件名
+	MimeType string // 图片类型，如image/png
+	Data     []byte // 图片内容
+}
+
+// imageMimeTypes 支持提取的图片扩展名及其类型，只保留视觉模型能识别的格式
+var imageMimeTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// officeMediaDirs Office文档中存放嵌入图片的目录
+var officeMediaDirs = map[string]string{
+	".docx": "word/media/",
+	".pptx": "ppt/media/",
+	".xlsx": "xl/media/",
+}
+
+// ImageFromMetadata 从向量文档元数据中读取图片ID和图片文件名，不是图片段落时返回空
+func ImageFromMetadata(meta map[string]interface{}) (id, name string) {
+	id, _ = meta[MetaImage].(string)
+	name, _ = meta[MetaImageName].(string)
+	return id, name
+}
+
+// SupportsImages 判断文件类型是否支持提取图片
+func SupportsImages(filename string) bool {
+	_, ok := officeMediaDirs[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+// ExtractImages 提取文档中嵌入的图片，filename用于确定文档类型
+// 目前支持Word、PowerPoint和Excel文档，按图片在文档包中的编号排序；
+// 小于minBytes的图片视为装饰图片跳过，最多返回limit张，limit为0时不限制。不支持的文件类型返回空
+func ExtractImages(r io.Reader, filename string, minBytes, limit int) ([]Image, error) {
+	dir, ok := officeMediaDirs[strings.ToLower(filepath.Ext(filename))]
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPackageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	if len(data) > maxPackageBytes {
+		return nil, fmt.Errorf("document too large to extract images")
+	}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	var files []*zip.File
+	for _, f := range reader.File {
+		if !strings.HasPrefix(f.Name, dir) || f.FileInfo().IsDir() {
+			continue
+		}
+		if _, ok := imageMimeTypes[strings.ToLower(path.Ext(f.Name))]; !ok {
+			continue
+		}
+		if f.UncompressedSize64 < uint64(minBytes) || f.UncompressedSize64 > maxImageBytes {
+			continue
+		}
+		files = append(files, f)
+	}
+	// 按文件名中的编号排序，image10排在image9之后
+	sort.Slice(files, func(i, j int) bool {
+		return mediaNumber(files[i].Name) < mediaNumber(files[j].Name)
+	})
+
+	var images []Image
+	for _, f := range files {
+		if limit > 0 && len(images) >= limit {
+			break
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image %s: %w", f.Name, err)
+		}
+		images = append(images, Image{
+			Name:     path.Base(f.Name),
+			MimeType: imageMimeTypes[strings.ToLower(path.Ext(f.Name))],
+			Data:     data,
+		})
+	}
+	return images, nil
+}
+
+// readZipFile 读取压缩包中的文件，限制读取的字节数
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, maxImageBytes))
+}
+
+// mediaNumber 返回媒体文件名中的编号，如word/media/image12.png返回12，没有编号时返回0
+func mediaNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	i := len(base)
+	for i > 0 && base[i-1] >= '0' && base[i-1] <= '9' {
+		i--
+	}
+	n, _ := strconv.Atoi(base[i:])
+	return n
+}
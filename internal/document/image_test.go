@@ -0,0 +1,46 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExtractImages 测试从Word文档中提取嵌入图片
+func TestExtractImages(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, size := range map[string]int{
+		"word/document.xml":      100,
+		"word/media/image10.png": 200,
+		"word/media/image2.jpeg": 200,
+		"word/media/image1.png":  10, // 装饰图片
+		"word/media/image3.emf":  200,
+	} {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write(bytes.Repeat([]byte("x"), size))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	images, err := ExtractImages(bytes.NewReader(buf.Bytes()), "手册.docx", 50, 0)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "image2.jpeg", images[0].Name)
+	assert.Equal(t, "image/jpeg", images[0].MimeType)
+	assert.Equal(t, "image10.png", images[1].Name)
+	assert.Len(t, images[1].Data, 200)
+
+	images, err = ExtractImages(bytes.NewReader(buf.Bytes()), "手册.docx", 50, 1)
+	require.NoError(t, err)
+	assert.Len(t, images, 1)
+
+	assert.True(t, SupportsImages("a.PPTX"))
+	images, err = ExtractImages(bytes.NewReader(buf.Bytes()), "a.pdf", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, images)
+}
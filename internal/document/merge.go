@@ -0,0 +1,69 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 合并相邻段落后记录合并范围的元数据键，范围为段落位置的闭区间
+const (
+	MetaMergedFrom = "merged_from" // 合并的第一个段落位置
+	MetaMergedTo   = "merged_to"   // 合并的最后一个段落位置
+)
+
+// minMergeOverlap 拼接相邻段落时去重的最小重叠字符数，更短的重叠可能只是巧合
+const minMergeOverlap = 4
+
+// MergeText 拼接两个相邻段落的文本，去掉分块重叠造成的重复部分
+// 前一段的结尾与后一段的开头重合时只保留一份，没有重叠时以换行连接
+func MergeText(prev, next string) string {
+	if overlap := textOverlap(prev, next); overlap > 0 {
+		return prev + next[overlap:]
+	}
+	if prev == "" || next == "" {
+		return prev + next
+	}
+	return prev + "\n" + next
+}
+
+// textOverlap 返回prev的后缀与next的前缀最长重合部分在next中的字节长度，不足minMergeOverlap个字符时返回0
+func textOverlap(prev, next string) int {
+	for n := min(len(prev), len(next)); n > 0; n-- {
+		if n < len(next) && !utf8.RuneStart(next[n]) {
+			continue
+		}
+		if strings.HasSuffix(prev, next[:n]) {
+			if utf8.RuneCountInString(next[:n]) < minMergeOverlap {
+				return 0
+			}
+			return n
+		}
+	}
+	return 0
+}
+
+// MergedRangeFromMetadata 从来源文档元数据中读取合并的段落位置范围，没有合并时ok为false
+// 元数据经过JSON缓存后数字会变为float64，这里统一处理
+func MergedRangeFromMetadata(meta map[string]interface{}) (from, to int, ok bool) {
+	from, okFrom := metadataInt(meta[MetaMergedFrom])
+	to, okTo := metadataInt(meta[MetaMergedTo])
+	if !okFrom || !okTo {
+		return 0, 0, false
+	}
+	return from, to, true
+}
+
+// metadataInt 将元数据中的数字转换为int
+func metadataInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	}
+	return 0, false
+}
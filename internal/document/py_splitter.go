@@ -14,6 +14,7 @@ type PythonSplitter struct {
 	chunkSize  int                        // 块大小
 	overlap    int                        // 重叠大小
 	splitType  string                     // 分割类型
+	threshold  float64                    // 语义分割断点阈值
 	documentID string                     // 文档ID,可选
 }
 
@@ -23,6 +24,10 @@ type SplitConfig struct {
 	Overlap    int    // 重叠大小
 	SplitType  string // 分割类型
 	DocumentID string // 文档ID,可选
+
+	// BreakpointThreshold 语义分割的断点阈值（百分位，0-100），仅SplitType为semantic时使用
+	// 相邻句子的语义距离超过全文该百分位的距离时断开，越小分块越细
+	BreakpointThreshold float64
 }
 
 // DefaultSplitterConfig 返回默认的分块器配置
@@ -30,8 +35,10 @@ func DefaultSplitterConfig() SplitConfig {
 	return SplitConfig{
 		ChunkSize:  1000,
 		Overlap:    200,
-		SplitType:  "sentence",
+		SplitType:  SplitTypeSentence,
 		DocumentID: "",
+
+		BreakpointThreshold: DefaultBreakpointThreshold,
 	}
 }
 
@@ -54,6 +61,7 @@ func NewPythonSplitter(client *pyprovider.DocumentClient, config SplitConfig) Sp
 		chunkSize:  config.ChunkSize,
 		overlap:    config.Overlap,
 		splitType:  config.SplitType,
+		threshold:  config.BreakpointThreshold,
 		documentID: config.DocumentID,
 	}
 }
@@ -75,7 +83,10 @@ func (s *PythonSplitter) Split(text string) ([]Content, error) {
 		ChunkSize:    s.chunkSize,
 		ChunkOverlap: s.overlap,
 		SplitType:    s.splitType,
-		StoreResult:  true,
+		StoreResult:  false, // 分块结果直接从响应读取，不在Python端保存，避免按临时ID残留数据
+	}
+	if s.splitType == SplitTypeSemantic {
+		options.BreakpointThreshold = s.threshold
 	}
 
 	// 调用Python服务分块文本
@@ -112,3 +123,8 @@ func (s *PythonSplitter) GetOverlap() int {
 func (s *PythonSplitter) GetSplitType() string {
 	return s.splitType
 }
+
+// GetBreakpointThreshold 返回语义分割断点阈值
+func (s *PythonSplitter) GetBreakpointThreshold() float64 {
+	return s.threshold
+}
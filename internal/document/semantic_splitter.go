@@ -0,0 +1,203 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+)
+
+// 分割类型
+const (
+	SplitTypeParagraph = "paragraph" // 按段落分割
+	SplitTypeSentence  = "sentence"  // 按句子分割
+	SplitTypeSemantic  = "semantic"  // 按句子间的语义相似度分割
+)
+
+// DefaultBreakpointThreshold 默认语义分割阈值（百分位）
+// 相邻句子的语义距离高于全文95%的相邻距离时断开，与Python服务的默认值一致
+const DefaultBreakpointThreshold = 95
+
+// semanticEmbedBatchSize 语义分割时每批向量化的句子数量
+const semanticEmbedBatchSize = 16
+
+// SemanticSplitter 语义分块器
+// 把文本切成句子后计算相邻句子的向量距离，在距离明显变大（话题切换）的位置断开，
+// 使每个分块尽量只包含一个话题；单个分块超过ChunkSize时也会强制断开
+type SemanticSplitter struct {
+	embedder  embedding.Client // 嵌入模型客户端
+	chunkSize int              // 分块最大字符数
+	threshold float64          // 断点阈值，相邻距离的百分位(0-100)
+}
+
+// NewSemanticSplitter 创建本地语义分块器
+func NewSemanticSplitter(embedder embedding.Client, config SplitConfig) Splitter {
+	threshold := config.BreakpointThreshold
+	if threshold <= 0 || threshold > 100 {
+		threshold = DefaultBreakpointThreshold
+	}
+	return &SemanticSplitter{
+		embedder:  embedder,
+		chunkSize: config.ChunkSize,
+		threshold: threshold,
+	}
+}
+
+// Split 将文本按语义分割成段落
+func (s *SemanticSplitter) Split(text string) ([]Content, error) {
+	if s.embedder == nil {
+		return nil, errors.New("embedding client uninitialized")
+	}
+
+	sentences := splitSentences(text)
+	if len(sentences) <= 1 {
+		return toContents(sentences), nil
+	}
+
+	vectors, err := s.embedWindows(context.Background(), sentences)
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosine(vectors[i], vectors[i+1])
+	}
+	cutoff := percentile(distances, s.threshold)
+
+	var chunks []string
+	var current strings.Builder
+	for i, sentence := range sentences {
+		if current.Len() > 0 {
+			breakpoint := distances[i-1] > cutoff
+			tooLong := s.chunkSize > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(sentence) > s.chunkSize
+			if breakpoint || tooLong {
+				chunks = append(chunks, strings.TrimSpace(current.String()))
+				current.Reset()
+			}
+		}
+		current.WriteString(sentence)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, strings.TrimSpace(current.String()))
+	}
+
+	return toContents(chunks), nil
+}
+
+// GetChunkSize 返回块大小
+func (s *SemanticSplitter) GetChunkSize() int {
+	return s.chunkSize
+}
+
+// GetBreakpointThreshold 返回断点阈值
+func (s *SemanticSplitter) GetBreakpointThreshold() float64 {
+	return s.threshold
+}
+
+// embedWindows 为每个句子连同前后各一句计算向量，减少短句带来的噪声
+func (s *SemanticSplitter) embedWindows(ctx context.Context, sentences []string) ([][]float32, error) {
+	windows := make([]string, len(sentences))
+	for i := range sentences {
+		lo, hi := i-1, i+2
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(sentences) {
+			hi = len(sentences)
+		}
+		windows[i] = strings.Join(sentences[lo:hi], "")
+	}
+
+	vectors := make([][]float32, 0, len(windows))
+	for start := 0; start < len(windows); start += semanticEmbedBatchSize {
+		end := start + semanticEmbedBatchSize
+		if end > len(windows) {
+			end = len(windows)
+		}
+		batch, err := s.embedder.EmbedBatch(ctx, windows[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed sentences: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embedding count mismatch: got %d, want %d", len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// splitSentences 按中英文句末标点和换行切分句子，标点保留在句子末尾
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if strings.TrimSpace(current.String()) != "" {
+			sentences = append(sentences, current.String())
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		current.WriteRune(r)
+		switch r {
+		case '。', '！', '？', '；', '\n':
+			flush()
+		case '.', '!', '?', ';':
+			// 英文标点后需跟空白才视为句末，避免切开小数和缩写
+			if i+1 == len(runes) || runes[i+1] == ' ' || runes[i+1] == '\n' {
+				flush()
+			}
+		}
+	}
+	flush()
+	return sentences
+}
+
+// toContents 把分块文本转换为带索引的段落
+func toContents(chunks []string) []Content {
+	contents := make([]Content, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk = strings.TrimSpace(chunk); chunk != "" {
+			contents = append(contents, Content{Text: chunk, Index: len(contents)})
+		}
+	}
+	return contents
+}
+
+// cosine 计算两个向量的余弦相似度
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// percentile 计算p百分位数（线性插值）
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
@@ -0,0 +1,75 @@
+package document
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbedder 按关键词生成向量的测试嵌入客户端，不依赖外部服务
+type topicEmbedder struct {
+	calls int
+}
+
+func (e *topicEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	v := make([]float32, 3)
+	v[0] = float32(strings.Count(text, "退款"))
+	v[1] = float32(strings.Count(text, "发票"))
+	v[2] = float32(strings.Count(text, "物流"))
+	return v, nil
+}
+
+func (e *topicEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i], _ = e.Embed(ctx, text)
+	}
+	return vectors, nil
+}
+
+func (e *topicEmbedder) Name() string {
+	return "topic"
+}
+
+// TestSemanticSplitter 测试在话题切换处分块
+func TestSemanticSplitter(t *testing.T) {
+	text := "退款需要在7天内申请。退款会原路返回。退款到账需要3个工作日。" +
+		"发票可以在订单页申请。发票抬头支持个人和企业。发票会发送到邮箱。"
+
+	embedder := &topicEmbedder{}
+	splitter := NewSemanticSplitter(embedder, SplitConfig{ChunkSize: 1000, BreakpointThreshold: 80})
+
+	contents, err := splitter.Split(text)
+	require.NoError(t, err)
+	require.Len(t, contents, 2)
+	assert.Equal(t, "退款需要在7天内申请。退款会原路返回。退款到账需要3个工作日。", contents[0].Text)
+	assert.Equal(t, "发票可以在订单页申请。发票抬头支持个人和企业。发票会发送到邮箱。", contents[1].Text)
+	assert.Equal(t, 1, contents[1].Index)
+
+	// 超过块大小时强制断开
+	splitter = NewSemanticSplitter(embedder, SplitConfig{ChunkSize: 25, BreakpointThreshold: 80})
+	contents, err = splitter.Split(text)
+	require.NoError(t, err)
+	assert.Greater(t, len(contents), 2)
+	for _, c := range contents {
+		assert.LessOrEqual(t, len([]rune(c.Text)), 25)
+	}
+
+	// 单句文本无需向量化
+	embedder.calls = 0
+	contents, err = splitter.Split("只有一句话")
+	require.NoError(t, err)
+	assert.Equal(t, []Content{{Text: "只有一句话", Index: 0}}, contents)
+	assert.Zero(t, embedder.calls)
+}
+
+// TestSplitSentences 测试中英文句子切分
+func TestSplitSentences(t *testing.T) {
+	sentences := splitSentences("Version 1.2 is out. It works!\n第一句。第二句？")
+	assert.Equal(t, []string{"Version 1.2 is out.", " It works!", "第一句。", "第二句？"}, sentences)
+	assert.Equal(t, float64(DefaultBreakpointThreshold), NewSemanticSplitter(&topicEmbedder{}, SplitConfig{}).(*SemanticSplitter).GetBreakpointThreshold())
+}
@@ -12,6 +12,9 @@ type Document struct {
 type Content struct {
     Text  string // 段落文本内容
     Index int    // 段落索引
+
+    // Metadata 段落结构元数据（页码、章节标题、标题路径等），键见MetaPage等常量
+    Metadata map[string]string
 }
 
 // Splitter 文本分段器接口
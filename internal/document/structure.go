@@ -0,0 +1,219 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 段落结构元数据的键
+const (
+	MetaPage        = "page"         // 所在页码，从1开始，仅分页文档（如PDF）有
+	MetaSection     = "section"      // 所在章节标题
+	MetaHeadingPath = "heading_path" // 从顶层到所在章节的标题路径
+	MetaParent      = "parent"       // 所在章节第一个段落的索引，同一章节的段落互为兄弟段落
+)
+
+// PageBreak 分页符，解析器用它分隔分页文档的各页
+const PageBreak = "\f"
+
+// headingPathSeparator 标题路径的分隔符
+const headingPathSeparator = " > "
+
+// locateProbeRunes 在原文中定位分块时使用的前缀长度
+const locateProbeRunes = 40
+
+var (
+	// markdownHeading Markdown标题，如"## 安装"
+	markdownHeading = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*$`)
+	// numberedHeading 多级编号标题，如"3.2 配置"、"3.2.1 环境变量"，也接受"3 安装"
+	numberedHeading = regexp.MustCompile(`^(\d{1,3}(?:\.\d{1,3}){0,4})\s+(\S.*)$`)
+	// chapterHeading 中文章节标题，如"第三章 安装"、"第2节 配置"
+	chapterHeading = regexp.MustCompile(`^第[0-9一二三四五六七八九十百]+([章节])\s*(.*)$`)
+)
+
+// heading 文档中的一个标题
+type heading struct {
+	offset int    // 标题在原文中的字节偏移
+	level  int    // 标题级别，1为最高级
+	title  string // 标题文本
+}
+
+// AnnotateStructure 为分块补充页码、章节标题和标题路径
+// 分块由分块器（可能是Python服务）生成，不带位置信息，这里按文本在原文中定位每个分块，
+// 再根据之前出现的分页符和标题推断所在页和章节。无法定位的分块保持原样
+func AnnotateStructure(text string, contents []Content) []Content {
+	if len(contents) == 0 {
+		return contents
+	}
+
+	headings := findHeadings(text)
+	paged := strings.Contains(text, PageBreak)
+	if len(headings) == 0 && !paged {
+		return contents
+	}
+
+	annotated := make([]Content, len(contents))
+	cursor := 0
+	for i, content := range contents {
+		annotated[i] = content
+
+		offset := locate(text, content.Text, cursor)
+		if offset < 0 {
+			continue
+		}
+		cursor = offset + 1
+
+		meta := make(map[string]string, len(content.Metadata)+3)
+		for k, v := range content.Metadata {
+			meta[k] = v
+		}
+		if paged {
+			meta[MetaPage] = strconv.Itoa(strings.Count(text[:offset], PageBreak) + 1)
+		}
+		if path := headingPath(headings, offset); len(path) > 0 {
+			meta[MetaSection] = path[len(path)-1]
+			meta[MetaHeadingPath] = strings.Join(path, headingPathSeparator)
+		}
+		annotated[i].Metadata = meta
+	}
+	return annotated
+}
+
+// LinkParents 为带有标题路径的分块记录所在章节，章节以连续的相同标题路径划分
+// 章节中第一个分块的索引作为父章节的标识，检索时据此把命中的小分块扩展为所在章节的上下文。
+// 没有标题路径的分块不属于任何章节，保持原样
+func LinkParents(contents []Content) []Content {
+	linked := make([]Content, len(contents))
+	path, parent := "", ""
+	for i, content := range contents {
+		linked[i] = content
+		current := content.Metadata[MetaHeadingPath]
+		if current == "" {
+			path, parent = "", ""
+			continue
+		}
+		if current != path {
+			path, parent = current, strconv.Itoa(content.Index)
+		}
+
+		meta := make(map[string]string, len(content.Metadata)+1)
+		for k, v := range content.Metadata {
+			meta[k] = v
+		}
+		meta[MetaParent] = parent
+		linked[i].Metadata = meta
+	}
+	return linked
+}
+
+// FormatLocation 把页码和章节格式化为引用位置，如"第12页，3.2 配置"
+func FormatLocation(page int, section string) string {
+	var parts []string
+	if page > 0 {
+		parts = append(parts, fmt.Sprintf("第%d页", page))
+	}
+	if section != "" {
+		parts = append(parts, section)
+	}
+	return strings.Join(parts, "，")
+}
+
+// StructureFromMetadata 从向量文档元数据中读取页码、章节和标题路径
+// 元数据经过持久化后数字可能变为float64，这里统一处理
+func StructureFromMetadata(meta map[string]interface{}) (page int, section, path string) {
+	switch v := meta[MetaPage].(type) {
+	case string:
+		page, _ = strconv.Atoi(v)
+	case int:
+		page = v
+	case float64:
+		page = int(v)
+	}
+	section, _ = meta[MetaSection].(string)
+	path, _ = meta[MetaHeadingPath].(string)
+	return page, section, path
+}
+
+// findHeadings 按出现顺序找出原文中的标题
+func findHeadings(text string) []heading {
+	var headings []heading
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		start := offset
+		offset += len(line)
+
+		// 分页符可能紧贴在行首
+		trimmed := strings.TrimSpace(strings.TrimLeft(line, PageBreak))
+		if trimmed == "" || utf8.RuneCountInString(trimmed) > 80 {
+			continue
+		}
+
+		if m := markdownHeading.FindStringSubmatch(trimmed); m != nil {
+			headings = append(headings, heading{offset: start, level: len(m[1]), title: m[2]})
+			continue
+		}
+		if m := chapterHeading.FindStringSubmatch(trimmed); m != nil {
+			level := 1
+			if m[1] == "节" {
+				level = 2
+			}
+			headings = append(headings, heading{offset: start, level: level, title: trimmed})
+			continue
+		}
+		if m := numberedHeading.FindStringSubmatch(trimmed); m != nil && !strings.ContainsAny(lastRune(trimmed), "。，；：！？.,;:!?") {
+			headings = append(headings, heading{offset: start, level: strings.Count(m[1], ".") + 1, title: trimmed})
+		}
+	}
+	return headings
+}
+
+// headingPath 返回偏移处所在的标题路径
+func headingPath(headings []heading, offset int) []string {
+	var stack []heading
+	for _, h := range headings {
+		if h.offset > offset {
+			break
+		}
+		for len(stack) > 0 && stack[len(stack)-1].level >= h.level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, h)
+	}
+
+	path := make([]string, len(stack))
+	for i, h := range stack {
+		path[i] = h.title
+	}
+	return path
+}
+
+// locate 在原文中查找分块的起始偏移，先从from开始找，找不到再从头找
+// 分块器可能调整空白字符，因此只用分块开头的一段文本定位
+func locate(text, chunk string, from int) int {
+	probe := strings.TrimSpace(chunk)
+	if probe == "" {
+		return -1
+	}
+	if utf8.RuneCountInString(probe) > locateProbeRunes {
+		probe = string([]rune(probe)[:locateProbeRunes])
+	}
+	if line := strings.IndexByte(probe, '\n'); line > 0 {
+		probe = strings.TrimSpace(probe[:line])
+	}
+
+	if from < len(text) {
+		if i := strings.Index(text[from:], probe); i >= 0 {
+			return from + i
+		}
+	}
+	return strings.Index(text, probe)
+}
+
+// lastRune 返回字符串的最后一个字符
+func lastRune(s string) string {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return string(r)
+}
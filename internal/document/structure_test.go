@@ -0,0 +1,91 @@
+package document
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnotateStructure 测试根据分页符和标题推断分块的页码和章节
+func TestAnnotateStructure(t *testing.T) {
+	text := "# 用户手册\n\n产品简介。\n\f\n3 安装\n\n安装前请检查环境。\n\n3.1 下载\n\n从官网下载安装包。\n\f\n3.2 配置\n\n修改config.yaml中的端口。\n\n1. 打开文件，\n\n4 常见问题\n\n请联系客服。"
+	contents := []Content{
+		{Text: "产品简介。", Index: 0},
+		{Text: "安装前请检查环境。", Index: 1},
+		{Text: "从官网下载安装包。", Index: 2},
+		{Text: "修改config.yaml中的端口。\n\n1. 打开文件，", Index: 3},
+		{Text: "请联系客服。", Index: 4},
+		{Text: "原文中不存在的内容", Index: 5},
+	}
+
+	annotated := AnnotateStructure(text, contents)
+	require.Len(t, annotated, len(contents))
+
+	assert.Equal(t, map[string]string{MetaPage: "1", MetaSection: "用户手册", MetaHeadingPath: "用户手册"}, annotated[0].Metadata)
+	assert.Equal(t, "2", annotated[1].Metadata[MetaPage])
+	assert.Equal(t, "3 安装", annotated[1].Metadata[MetaHeadingPath])
+	assert.Equal(t, "3.1 下载", annotated[2].Metadata[MetaSection])
+	assert.Equal(t, "3 安装 > 3.1 下载", annotated[2].Metadata[MetaHeadingPath])
+
+	// 同级标题（不论Markdown还是编号）替换前一个标题，以标点结尾的编号行不是标题
+	assert.Equal(t, "3", annotated[3].Metadata[MetaPage])
+	assert.Equal(t, "3 安装 > 3.2 配置", annotated[3].Metadata[MetaHeadingPath])
+	assert.Equal(t, "4 常见问题", annotated[4].Metadata[MetaHeadingPath])
+
+	assert.Nil(t, annotated[5].Metadata)
+	assert.Nil(t, contents[0].Metadata, "input contents must not be modified")
+}
+
+// TestAnnotateStructurePlainText 测试没有分页和标题的文本不添加元数据
+func TestAnnotateStructurePlainText(t *testing.T) {
+	contents := []Content{{Text: "普通文本", Index: 0}}
+	assert.Equal(t, contents, AnnotateStructure("普通文本", contents))
+
+	// 没有分页符时不推断页码
+	annotated := AnnotateStructure("第二章 部署\n使用Docker部署。", []Content{{Text: "使用Docker部署。"}})
+	assert.Equal(t, map[string]string{MetaSection: "第二章 部署", MetaHeadingPath: "第二章 部署"}, annotated[0].Metadata)
+}
+
+// TestLinkParents 测试按连续的标题路径划分章节，记录章节第一个分块的索引
+func TestLinkParents(t *testing.T) {
+	path := func(p string) map[string]string {
+		if p == "" {
+			return nil
+		}
+		return map[string]string{MetaHeadingPath: p}
+	}
+	contents := []Content{
+		{Text: "a", Index: 0, Metadata: path("安装")},
+		{Text: "b", Index: 1, Metadata: path("安装")},
+		{Text: "c", Index: 2, Metadata: path("安装 > 下载")},
+		{Text: "d", Index: 3},
+		{Text: "e", Index: 4, Metadata: path("安装 > 下载")},
+	}
+
+	linked := LinkParents(contents)
+	var parents []string
+	for _, c := range linked {
+		parents = append(parents, c.Metadata[MetaParent])
+	}
+	assert.Equal(t, []string{"0", "0", "2", "", "4"}, parents)
+	assert.Equal(t, "安装", linked[1].Metadata[MetaHeadingPath])
+	assert.NotContains(t, contents[0].Metadata, MetaParent, "原分块不应被修改")
+}
+
+// TestStructureFromMetadata 测试从向量文档元数据读取结构信息
+func TestStructureFromMetadata(t *testing.T) {
+	page, section, path := StructureFromMetadata(map[string]interface{}{
+		MetaPage:        "12",
+		MetaSection:     "3.2 配置",
+		MetaHeadingPath: "3 安装 > 3.2 配置",
+	})
+	assert.Equal(t, 12, page)
+	assert.Equal(t, "3.2 配置", section)
+	assert.Equal(t, "3 安装 > 3.2 配置", path)
+	assert.Equal(t, "第12页，3.2 配置", FormatLocation(page, section))
+
+	page, _, _ = StructureFromMetadata(map[string]interface{}{MetaPage: float64(7)})
+	assert.Equal(t, 7, page)
+	assert.Empty(t, FormatLocation(0, ""))
+}
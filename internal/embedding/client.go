@@ -28,6 +28,11 @@ type Config struct {
 	Dimensions  int           // 向量维度
 	BatchSize   int           // 批处理大小
 	EnableCache bool          // 是否启用缓存
+
+	RetryDelay        time.Duration // 首次重试间隔，之后按指数退避
+	MaxRetryDelay     time.Duration // 重试间隔上限，服务端通过Retry-After要求的等待时间不受此限制
+	RequestsPerSecond float64       // 每秒最多发起的请求数，0表示不限制
+	TokensPerMinute   int           // 每分钟最多发送的token数（估算值），0表示不限制
 }
 
 // Option 客户端配置选项函数类型
@@ -68,6 +73,22 @@ func WithMaxRetries(retries int) Option {
 	}
 }
 
+// WithRetryBackoff 设置首次重试间隔和重试间隔上限
+func WithRetryBackoff(delay, maxDelay time.Duration) Option {
+	return func(c *Config) {
+		c.RetryDelay = delay
+		c.MaxRetryDelay = maxDelay
+	}
+}
+
+// WithRateLimit 设置客户端限流：每秒请求数和每分钟token数，0表示不限制
+func WithRateLimit(requestsPerSecond float64, tokensPerMinute int) Option {
+	return func(c *Config) {
+		c.RequestsPerSecond = requestsPerSecond
+		c.TokensPerMinute = tokensPerMinute
+	}
+}
+
 // WithDimensions 设置向量维度
 func WithDimensions(dimensions int) Option {
 	return func(c *Config) {
@@ -99,6 +120,9 @@ func DefaultConfig() *Config {
 		Dimensions:  1024, // 通义千问模型默认维度，可能需要根据实际模型调整
 		BatchSize:   16,
 		EnableCache: false,
+
+		RetryDelay:    500 * time.Millisecond,
+		MaxRetryDelay: 30 * time.Second,
 	}
 }
 
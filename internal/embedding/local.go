@@ -0,0 +1,232 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
+)
+
+// 本地推理服务的默认地址和模型，未配置endpoint和model时使用
+const (
+	defaultOllamaURL   = "http://localhost:11434"
+	defaultOllamaModel = "nomic-embed-text"
+	defaultONNXURL     = "http://localhost:8080"
+)
+
+// warmupProbe 预热和探测向量维度时发送的文本
+const warmupProbe = "warmup"
+
+// localAPI 本地推理服务的接口协议
+type localAPI int
+
+const (
+	// ollamaAPI Ollama的/api/embed接口
+	ollamaAPI localAPI = iota
+	// onnxAPI ONNX推理sidecar的/embed接口，与text-embeddings-inference兼容
+	onnxAPI
+)
+
+// LocalClient 调用本地推理服务的嵌入客户端，用于无法访问外部API的离线部署
+// 支持Ollama和与text-embeddings-inference兼容的ONNX推理sidecar；
+// 第一次请求时记录向量维度，之后的响应维度不一致时返回错误
+type LocalClient struct {
+	api       localAPI
+	baseURL   string
+	model     string
+	batchSize int
+	client    *http.Client
+
+	mu        sync.RWMutex
+	dimension int // 探测到的向量维度，0表示尚未探测
+}
+
+// NewOllamaClient 创建调用Ollama的嵌入客户端
+func NewOllamaClient(opts ...Option) (Client, error) {
+	return newLocalClient(ollamaAPI, defaultOllamaURL, defaultOllamaModel, opts...)
+}
+
+// NewONNXClient 创建调用ONNX推理sidecar的嵌入客户端
+// sidecar加载单个模型，模型名称只用于记录和区分向量来源
+func NewONNXClient(opts ...Option) (Client, error) {
+	return newLocalClient(onnxAPI, defaultONNXURL, "", opts...)
+}
+
+// newLocalClient 创建本地推理客户端，未配置的地址和模型使用对应服务的默认值
+func newLocalClient(api localAPI, defaultURL, defaultModel string, opts ...Option) (Client, error) {
+	defaults := DefaultConfig()
+	cfg := NewConfig(opts...)
+	if cfg.BaseURL == "" || cfg.BaseURL == defaults.BaseURL {
+		cfg.BaseURL = defaultURL
+	}
+	if cfg.Model == "" || cfg.Model == defaults.Model {
+		cfg.Model = defaultModel
+	}
+	if api == ollamaAPI && cfg.Model == "" {
+		return nil, NewEmbeddingError(ErrCodeInvalidRequest, "ollama embedding model is required")
+	}
+
+	return Reliable(&LocalClient{
+		api:       api,
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		model:     cfg.Model,
+		batchSize: cfg.BatchSize,
+		client:    &http.Client{Timeout: cfg.Timeout},
+	}, cfg), nil
+}
+
+// Embed 生成单条文本的向量表示
+func (c *LocalClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	if text == "" {
+		return nil, NewEmbeddingError(ErrCodeEmptyInput, ErrMsgEmptyInput)
+	}
+	vectors, err := c.embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch 批量生成多条文本的向量表示，超过批处理大小时分多次请求
+func (c *LocalClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	size := c.batchSize
+	if size <= 0 {
+		size = len(texts)
+	}
+
+	result := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += size {
+		end := i + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		vectors, err := c.embed(ctx, texts[i:end])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, vectors...)
+	}
+	return result, nil
+}
+
+// Name 返回模型名称
+func (c *LocalClient) Name() string {
+	return c.model
+}
+
+// Dimension 返回探测到的向量维度，尚未请求过时为0
+func (c *LocalClient) Dimension() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.dimension
+}
+
+// embed 发送一次请求并校验向量数量和维度
+func (c *LocalClient) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var path string
+	var payload interface{}
+	switch c.api {
+	case ollamaAPI:
+		path = "/api/embed"
+		payload = map[string]interface{}{"model": c.model, "input": texts, "truncate": true}
+	default:
+		path = "/embed"
+		payload = map[string]interface{}{"inputs": texts, "truncate": true}
+	}
+	body, err := c.post(ctx, path, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var vectors [][]float32
+	if c.api == ollamaAPI {
+		var resp struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		err = json.Unmarshal(body, &resp)
+		vectors = resp.Embeddings
+	} else {
+		err = json.Unmarshal(body, &vectors)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for _, vector := range vectors {
+		if err := c.checkDimension(len(vector)); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// checkDimension 第一次请求时记录维度，之后校验维度一致
+func (c *LocalClient) checkDimension(dim int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if dim == 0 {
+		return fmt.Errorf("embedding response contains an empty vector")
+	}
+	if c.dimension == 0 {
+		c.dimension = dim
+		return nil
+	}
+	if c.dimension != dim {
+		return fmt.Errorf("embedding dimension changed from %d to %d, was the model replaced?", c.dimension, dim)
+	}
+	return nil
+}
+
+// post 发送JSON请求，错误响应转换为pyprovider.APIError以便按状态码和Retry-After重试
+func (c *LocalClient) post(ctx context.Context, path string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, NewEmbeddingError(ErrCodeNetworkError, err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewEmbeddingError(ErrCodeNetworkError, err.Error())
+	}
+	if resp.StatusCode >= 400 {
+		return nil, pyprovider.NewAPIError(resp, body)
+	}
+	return body, nil
+}
+
+// DetectDimension 预热嵌入模型并返回其输出的向量维度
+// 本地推理服务通常在第一次请求时才加载模型，启动时预热可以避免第一个文档的请求超时
+func DetectDimension(ctx context.Context, client Client) (int, error) {
+	vector, err := client.Embed(ctx, warmupProbe)
+	if err != nil {
+		return 0, err
+	}
+	return len(vector), nil
+}
+
+// 注册本地推理嵌入客户端
+func init() {
+	RegisterClient("ollama", NewOllamaClient)
+	RegisterClient("onnx", NewONNXClient)
+}
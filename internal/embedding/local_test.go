@@ -0,0 +1,93 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOllamaClient 测试Ollama接口的请求格式、分批和维度探测
+func TestOllamaClient(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/api/embed", r.URL.Path)
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "nomic-embed-text", req.Model)
+
+		embeddings := make([][]float32, len(req.Input))
+		for i, text := range req.Input {
+			embeddings[i] = []float32{float32(len(text)), 1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	defer server.Close()
+
+	client, err := NewClient("ollama", WithBaseURL(server.URL), WithBatchSize(2))
+	require.NoError(t, err)
+	assert.Equal(t, "nomic-embed-text", client.Name())
+
+	dim, err := DetectDimension(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, 3, dim)
+
+	vectors, err := client.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 1, 0}, {2, 1, 0}, {3, 1, 0}}, vectors)
+	// 1次预热 + 3条文本按每批2条分2次请求
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+// TestONNXClient 测试ONNX sidecar接口、错误重试和维度变化检测
+func TestONNXClient(t *testing.T) {
+	var requests int32
+	dim := 4
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/embed", r.URL.Path)
+		if n == 1 {
+			// 模型加载中
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"model is loading"}`))
+			return
+		}
+		var req struct {
+			Inputs []string `json:"inputs"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		embeddings := make([][]float32, len(req.Inputs))
+		for i := range embeddings {
+			embeddings[i] = make([]float32, dim)
+		}
+		json.NewEncoder(w).Encode(embeddings)
+	}))
+	defer server.Close()
+
+	client, err := NewClient("onnx", WithBaseURL(server.URL+"/"), WithModel("bge-small-zh-v1.5"),
+		WithRetryBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	vector, err := client.Embed(context.Background(), "你好")
+	require.NoError(t, err)
+	assert.Len(t, vector, 4)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	_, err = client.Embed(context.Background(), "")
+	assert.Error(t, err)
+
+	// 服务换了模型，维度与第一次探测的不同
+	dim = 8
+	_, err = client.Embed(context.Background(), "再见")
+	assert.ErrorContains(t, err, "dimension changed from 4 to 8")
+}
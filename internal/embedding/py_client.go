@@ -30,8 +30,8 @@ func NewPythonClient(opts ...Option) (Client, error) {
 	// 设置超时时间
 	pyConfig.WithTimeout(cfg.Timeout)
 
-	// 设置重试参数
-	pyConfig.WithRetry(cfg.MaxRetries, time.Second)
+	// 重试和限流由外层的Reliable统一处理，HTTP层不再重试，避免重试次数叠加
+	pyConfig.WithRetry(0, time.Second)
 
 	// 创建HTTP客户端
 	httpClient, err := pyprovider.NewClient(pyConfig)
@@ -42,10 +42,10 @@ func NewPythonClient(opts ...Option) (Client, error) {
 	// 创建嵌入客户端
 	embeddingClient := pyprovider.NewEmbeddingClient(httpClient)
 
-	return &PythonEmbeddingClient{
+	return Reliable(&PythonEmbeddingClient{
 		client:    embeddingClient,
 		modelName: cfg.Model,
-	}, nil
+	}, cfg), nil
 }
 
 // Embed 生成单条文本的向量表示
@@ -0,0 +1,241 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
+)
+
+// retryJitter 重试间隔的随机抖动比例，避免并发的批次同时重试
+const retryJitter = 0.2
+
+// reliableClient 为嵌入客户端增加重试和客户端限流
+type reliableClient struct {
+	Client
+	maxRetries int
+	delay      time.Duration
+	maxDelay   time.Duration
+	limiter    *rateLimiter // 未配置限流时只用于在服务端限流后暂停请求
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Reliable 为嵌入客户端增加重试和限流
+// 网络错误、限流和服务端错误按指数退避加随机抖动重试，服务端返回Retry-After时至少等待该时间；
+// 请求前按cfg中的每秒请求数和每分钟token数限流。服务端返回429后，同一客户端的所有请求
+// （包括文档处理中并发的其他批次）都暂停到限流解除，而不是各自重试加剧限流
+func Reliable(client Client, cfg *Config) Client {
+	return &reliableClient{
+		Client:     client,
+		maxRetries: cfg.MaxRetries,
+		delay:      cfg.RetryDelay,
+		maxDelay:   cfg.MaxRetryDelay,
+		limiter:    newRateLimiter(cfg.RequestsPerSecond, cfg.TokensPerMinute),
+		sleep:      sleepContext,
+	}
+}
+
+// Embed 生成单条文本的向量表示
+func (c *reliableClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	var vector []float32
+	err := c.do(ctx, estimateTokens(text), func() error {
+		var err error
+		vector, err = c.Client.Embed(ctx, text)
+		return err
+	})
+	return vector, err
+}
+
+// EmbedBatch 批量生成多条文本的向量表示
+func (c *reliableClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return c.Client.EmbedBatch(ctx, texts)
+	}
+	var vectors [][]float32
+	err := c.do(ctx, estimateTokens(texts...), func() error {
+		var err error
+		vectors, err = c.Client.EmbedBatch(ctx, texts)
+		return err
+	})
+	return vectors, err
+}
+
+// do 限流后执行请求，临时故障时重试
+func (c *reliableClient) do(ctx context.Context, tokens int, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.sleep(ctx, c.limiter.reserve(tokens)); err != nil {
+			return err
+		}
+
+		err := call()
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		wait := c.backoff(attempt + 1)
+		if d := pyprovider.RetryAfter(err); d > wait {
+			wait = d
+		}
+		if isRateLimited(err) {
+			// 服务端限流时暂停所有请求，等待时间计入下一次reserve
+			c.limiter.pause(wait)
+			wait = 0
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff 计算第attempt次重试（从1开始）前的等待时间
+func (c *reliableClient) backoff(attempt int) time.Duration {
+	delay := c.delay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if c.maxDelay > 0 && delay >= c.maxDelay {
+			break
+		}
+	}
+	if c.maxDelay > 0 && delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	return delay - time.Duration(retryJitter*rand.Float64()*float64(delay))
+}
+
+// isRetryable 判断嵌入请求的错误是否为临时故障
+func isRetryable(err error) bool {
+	var embedErr EmbeddingError
+	if errors.As(err, &embedErr) {
+		switch embedErr.Code {
+		case ErrCodeRateLimited, ErrCodeServerError, ErrCodeTimeout, ErrCodeNetworkError:
+			return true
+		}
+		return false
+	}
+	return pyprovider.IsRetryable(err)
+}
+
+// isRateLimited 判断错误是否为服务端限流
+func isRateLimited(err error) bool {
+	var embedErr EmbeddingError
+	if errors.As(err, &embedErr) {
+		return embedErr.Code == ErrCodeRateLimited
+	}
+	var apiErr *pyprovider.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 429
+}
+
+// estimateTokens 估算文本的token总数，用于按每分钟token数限流
+func estimateTokens(texts ...string) int {
+	tokenizer := llm.EstimateTokenizer{}
+	total := 0
+	for _, text := range texts {
+		total += tokenizer.Count(text)
+	}
+	return total
+}
+
+// sleepContext 等待指定时间，上下文取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenBucket 令牌桶，容量为一个周期（秒或分钟）的配额
+type tokenBucket struct {
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 容量
+	tokens float64
+	last   time.Time
+}
+
+// reserve 预留n个令牌，返回令牌可用前需要等待的时间
+// 令牌允许透支，等待期间到达的请求排在后面，因此单次请求超过容量时也能完成
+func (b *tokenBucket) reserve(now time.Time, n float64) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = b.burst
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// rateLimiter 按请求数和token数限流，并在服务端限流后暂停请求
+type rateLimiter struct {
+	mu          sync.Mutex
+	requests    *tokenBucket // 为空表示不限制请求数
+	tokens      *tokenBucket // 为空表示不限制token数
+	pausedUntil time.Time
+	now         func() time.Time
+}
+
+// newRateLimiter 创建限流器，参数为0表示对应维度不限制
+func newRateLimiter(requestsPerSecond float64, tokensPerMinute int) *rateLimiter {
+	l := &rateLimiter{now: time.Now}
+	if requestsPerSecond > 0 {
+		burst := requestsPerSecond
+		if burst < 1 {
+			burst = 1
+		}
+		l.requests = &tokenBucket{rate: requestsPerSecond, burst: burst}
+	}
+	if tokensPerMinute > 0 {
+		l.tokens = &tokenBucket{rate: float64(tokensPerMinute) / 60, burst: float64(tokensPerMinute)}
+	}
+	return l
+}
+
+// reserve 为一次请求预留配额，返回发起请求前需要等待的时间
+// 等待期间取消请求不会归还配额，限流只会偏保守
+func (l *rateLimiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	if l.pausedUntil.After(now) {
+		wait = l.pausedUntil.Sub(now)
+	}
+	if l.requests != nil {
+		if d := l.requests.reserve(now, 1); d > wait {
+			wait = d
+		}
+	}
+	if l.tokens != nil {
+		if d := l.tokens.reserve(now, float64(tokens)); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// pause 暂停发起请求，直到d之后
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
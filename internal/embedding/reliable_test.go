@@ -0,0 +1,91 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReliableRetry 测试临时故障重试、Retry-After和服务端限流后的暂停
+func TestReliableRetry(t *testing.T) {
+	ctx := context.Background()
+	limited := &pyprovider.APIError{StatusCode: 429, RetryAfter: 5 * time.Second}
+
+	inner := &MockClient{}
+	inner.On("EmbedBatch", ctx, []string{"a", "b"}).Return(nil, limited).Once()
+	inner.On("EmbedBatch", ctx, []string{"a", "b"}).Return(nil, NewEmbeddingError(ErrCodeServerError, ErrMsgServerError)).Once()
+	inner.On("EmbedBatch", ctx, []string{"a", "b"}).Return([][]float32{{1}, {2}}, nil).Once()
+
+	client := Reliable(inner, NewConfig(WithRetryBackoff(100*time.Millisecond, time.Second))).(*reliableClient)
+	now := time.Unix(0, 0)
+	client.limiter.now = func() time.Time { return now }
+	var waits []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			waits = append(waits, d)
+			now = now.Add(d)
+		}
+		return nil
+	}
+
+	vectors, err := client.EmbedBatch(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {2}}, vectors)
+	inner.AssertExpectations(t)
+
+	// 429等待Retry-After要求的5秒，5xx按第二次重试的退避间隔（带抖动）等待
+	require.Len(t, waits, 2)
+	assert.Equal(t, 5*time.Second, waits[0])
+	assert.InDelta(t, float64(180*time.Millisecond), float64(waits[1]), float64(20*time.Millisecond))
+
+	// 参数错误不重试
+	inner.On("Embed", ctx, "bad").Return(nil, NewEmbeddingError(ErrCodeInvalidRequest, ErrMsgInvalidRequest)).Once()
+	_, err = client.Embed(ctx, "bad")
+	assert.Error(t, err)
+	inner.AssertNumberOfCalls(t, "Embed", 1)
+
+	// 重试次数用尽后返回最后一次的错误
+	failing := &MockClient{}
+	failing.On("Embed", mock.Anything, "x").Return(nil, errors.New("boom"))
+	failing.On("Embed", mock.Anything, "y").Return(nil, &pyprovider.APIError{StatusCode: 503})
+	client = Reliable(failing, NewConfig(WithMaxRetries(2), WithRetryBackoff(time.Millisecond, time.Millisecond))).(*reliableClient)
+	_, err = client.Embed(ctx, "x")
+	assert.EqualError(t, err, "boom")
+	_, err = client.Embed(ctx, "y")
+	assert.Error(t, err)
+	failing.AssertNumberOfCalls(t, "Embed", 4)
+}
+
+// TestRateLimiter 测试按请求数和token数限流以及服务端限流后的暂停
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(2, 600)
+	l.now = func() time.Time { return now }
+
+	// 每秒2个请求，前两个请求不等待
+	assert.Zero(t, l.reserve(1))
+	assert.Zero(t, l.reserve(1))
+	assert.Equal(t, 500*time.Millisecond, l.reserve(1))
+
+	// 每分钟600个token，即每秒10个；透支后按补充速度等待
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, 10*time.Second, l.reserve(700))
+
+	now = now.Add(time.Minute)
+	l.pause(3 * time.Second)
+	assert.Equal(t, 3*time.Second, l.reserve(1))
+	// 较短的暂停不覆盖较长的暂停
+	l.pause(time.Second)
+	assert.Equal(t, 3*time.Second, l.reserve(1))
+
+	unlimited := newRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		assert.Zero(t, unlimited.reserve(10000))
+	}
+}
@@ -0,0 +1,133 @@
+package embedding
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/pkg/langdetect"
+)
+
+// OtherLanguages 路由配置中表示"默认模型和其他路由都不覆盖的语言"的特殊值
+const OtherLanguages = "other"
+
+// languageKey 上下文中保存文本语言的键
+type languageKey struct{}
+
+// WithLanguage 返回带有文本语言的上下文
+// 文档处理时按整篇文档的语言设置，使同一文档的所有段落使用同一个模型
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// LanguageFromContext 从上下文读取文本语言，未设置时返回空字符串
+func LanguageFromContext(ctx context.Context) string {
+	lang, _ := ctx.Value(languageKey{}).(string)
+	return lang
+}
+
+// Router 按语言路由的嵌入客户端
+// 语言优先从上下文读取，未设置时根据文本识别；
+// 不同模型生成的向量不在同一个向量空间中，检索时只应比较同一模型生成的向量，
+// 因此各模型的向量维度必须相同，问答时也需按问题语言对应的模型过滤检索结果
+type Router struct {
+	defaultClient Client
+	defaultLangs  map[string]bool   // 默认模型覆盖的语言
+	routes        map[string]Client // 语言 -> 客户端
+	other         Client            // 默认模型和其他路由都不覆盖的语言使用的客户端，为空时使用默认模型
+}
+
+// RouterOption 路由配置选项
+type RouterOption func(*Router)
+
+// WithDefaultLanguages 设置默认模型覆盖的语言，只在配置了OtherLanguages路由时有意义
+func WithDefaultLanguages(langs ...string) RouterOption {
+	return func(r *Router) {
+		for _, lang := range langs {
+			r.defaultLangs[strings.ToLower(lang)] = true
+		}
+	}
+}
+
+// WithRoute 将指定语言路由到client，语言为OtherLanguages时作为其他语言的兜底模型
+func WithRoute(client Client, langs ...string) RouterOption {
+	return func(r *Router) {
+		for _, lang := range langs {
+			lang = strings.ToLower(lang)
+			if lang == OtherLanguages {
+				r.other = client
+				continue
+			}
+			r.routes[lang] = client
+		}
+	}
+}
+
+// NewRouter 创建按语言路由的嵌入客户端
+func NewRouter(defaultClient Client, opts ...RouterOption) *Router {
+	r := &Router{
+		defaultClient: defaultClient,
+		defaultLangs:  make(map[string]bool),
+		routes:        make(map[string]Client),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ClientFor 返回语言对应的客户端
+// 无法识别的语言使用默认模型
+func (r *Router) ClientFor(lang string) Client {
+	lang = strings.ToLower(lang)
+	if client, ok := r.routes[lang]; ok {
+		return client
+	}
+	if r.other != nil && lang != "" && lang != langdetect.Unknown && !r.defaultLangs[lang] {
+		return r.other
+	}
+	return r.defaultClient
+}
+
+// ModelFor 返回语言对应的模型名称
+func (r *Router) ModelFor(lang string) string {
+	return r.ClientFor(lang).Name()
+}
+
+// Embed 使用语言对应的模型生成向量
+func (r *Router) Embed(ctx context.Context, text string) ([]float32, error) {
+	return r.clientForText(ctx, text).Embed(ctx, text)
+}
+
+// EmbedBatch 使用语言对应的模型批量生成向量
+// 上下文中没有语言时按整批文本识别语言，同一批次使用同一个模型
+func (r *Router) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return r.clientForText(ctx, strings.Join(texts, "\n")).EmbedBatch(ctx, texts)
+}
+
+// Name 返回路由配置的签名，由默认模型和各语言的模型组成
+// 用于判断文档是否需要重新向量化：路由配置变化后，已有文档的向量可能来自其他模型
+func (r *Router) Name() string {
+	if len(r.routes) == 0 && r.other == nil {
+		return r.defaultClient.Name()
+	}
+
+	parts := make([]string, 0, len(r.routes)+1)
+	for lang, client := range r.routes {
+		parts = append(parts, lang+"="+client.Name())
+	}
+	sort.Strings(parts)
+	if r.other != nil {
+		parts = append(parts, OtherLanguages+"="+r.other.Name())
+	}
+	return r.defaultClient.Name() + "+" + strings.Join(parts, ",")
+}
+
+// clientForText 根据上下文或文本的语言选择客户端
+func (r *Router) clientForText(ctx context.Context, text string) Client {
+	lang := LanguageFromContext(ctx)
+	if lang == "" {
+		lang = langdetect.Detect(text)
+	}
+	return r.ClientFor(lang)
+}
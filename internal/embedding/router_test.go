@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namedClient 返回固定向量的嵌入客户端，向量第一维用于区分模型
+type namedClient struct {
+	name string
+	mark float32
+}
+
+func (c *namedClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	return []float32{c.mark}, nil
+}
+
+func (c *namedClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{c.mark}
+	}
+	return vectors, nil
+}
+
+func (c *namedClient) Name() string { return c.name }
+
+// TestRouter 测试按语言选择嵌入模型
+func TestRouter(t *testing.T) {
+	base := &namedClient{name: "base", mark: 1}
+	ja := &namedClient{name: "ja-model", mark: 2}
+	multi := &namedClient{name: "multi", mark: 3}
+
+	// 未配置路由时与默认模型完全一致
+	plain := NewRouter(base)
+	assert.Equal(t, "base", plain.Name())
+	assert.Same(t, Client(base), plain.ClientFor("ja"))
+
+	router := NewRouter(base,
+		WithDefaultLanguages("zh", "EN"),
+		WithRoute(ja, "ja"),
+		WithRoute(multi, OtherLanguages),
+	)
+	assert.Equal(t, "base+ja=ja-model,other=multi", router.Name())
+	assert.Equal(t, "base", router.ModelFor("zh"))
+	assert.Equal(t, "base", router.ModelFor("en"))
+	assert.Equal(t, "ja-model", router.ModelFor("JA"))
+	assert.Equal(t, "multi", router.ModelFor("ru"))
+	// 无法识别语言时使用默认模型
+	assert.Equal(t, "base", router.ModelFor(""))
+	assert.Equal(t, "base", router.ModelFor("und"))
+
+	ctx := context.Background()
+
+	// 未设置语言时按文本识别
+	vector, err := router.Embed(ctx, "データベースの接続を設定する")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{2}, vector)
+
+	vector, err = router.Embed(ctx, "Как настроить подключение к базе данных?")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{3}, vector)
+
+	// 上下文中的文档语言优先于文本本身，同一文档的段落使用同一个模型
+	vectors, err := router.EmbedBatch(WithLanguage(ctx, "zh"), []string{"Kubernetes Helm", "データベース"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {1}}, vectors)
+	assert.Equal(t, "zh", LanguageFromContext(WithLanguage(ctx, "zh")))
+	assert.Empty(t, LanguageFromContext(ctx))
+}
@@ -0,0 +1,230 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// DefaultKs 默认评估的截断位置
+var DefaultKs = []int{1, 3, 5, 10}
+
+// Retriever 待评估的检索器，services.QAService实现了该接口
+type Retriever interface {
+	// Retrieve 检索与问题相关的前k个段落
+	Retrieve(ctx context.Context, question string, k int) ([]vectordb.SearchResult, error)
+}
+
+// CaseResult 单条用例的评估结果
+type CaseResult struct {
+	Question  string          `json:"question"`
+	Retrieved []string        `json:"retrieved"`       // 检索到的段落ID，按排名排序
+	Ranks     []int           `json:"ranks"`           // 相关结果的排名，从1开始
+	Recall    map[int]float64 `json:"recall"`          // 各截断位置的召回率
+	NDCG      map[int]float64 `json:"ndcg"`            // 各截断位置的nDCG
+	RR        float64         `json:"reciprocal_rank"` // 第一个相关结果排名的倒数
+	Error     string          `json:"error,omitempty"` // 检索失败时的错误信息
+}
+
+// Report 评估报告，各指标为所有用例的平均值
+type Report struct {
+	Cases   int             `json:"cases"`  // 用例数
+	Failed  int             `json:"failed"` // 检索失败的用例数，按指标为0计入平均值
+	Ks      []int           `json:"ks"`     // 截断位置
+	Recall  map[int]float64 `json:"recall"` // 平均召回率（recall@k）
+	NDCG    map[int]float64 `json:"ndcg"`   // 平均nDCG@k
+	MRR     float64         `json:"mrr"`    // 平均倒数排名
+	Results []CaseResult    `json:"results"`
+}
+
+// Evaluate 对黄金集逐条检索并计算recall@k、MRR和nDCG@k
+// 检索一次取最大的k，再按各截断位置计算指标；ks为空时使用DefaultKs
+func Evaluate(ctx context.Context, retriever Retriever, cases []Case, ks []int) (*Report, error) {
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("golden set is empty")
+	}
+	ks = normalizeKs(ks)
+	maxK := ks[len(ks)-1]
+
+	report := &Report{
+		Cases:   len(cases),
+		Ks:      ks,
+		Recall:  make(map[int]float64, len(ks)),
+		NDCG:    make(map[int]float64, len(ks)),
+		Results: make([]CaseResult, 0, len(cases)),
+	}
+	for _, c := range cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		results, err := retriever.Retrieve(ctx, c.Question, maxK)
+		if err != nil {
+			report.Failed++
+			report.Results = append(report.Results, CaseResult{
+				Question: c.Question,
+				Recall:   zeroMetrics(ks),
+				NDCG:     zeroMetrics(ks),
+				Error:    err.Error(),
+			})
+			continue
+		}
+
+		result := score(c, results, ks)
+		for _, k := range ks {
+			report.Recall[k] += result.Recall[k]
+			report.NDCG[k] += result.NDCG[k]
+		}
+		report.MRR += result.RR
+		report.Results = append(report.Results, result)
+	}
+
+	n := float64(len(cases))
+	for _, k := range ks {
+		report.Recall[k] /= n
+		report.NDCG[k] /= n
+	}
+	report.MRR /= n
+	return report, nil
+}
+
+// score 计算单条用例的指标
+// 每个期望条目只在第一次命中时计为相关，同一文档的其他段落不重复计分
+func score(c Case, results []vectordb.SearchResult, ks []int) CaseResult {
+	segments := toSet(c.SegmentIDs)
+	documents := toSet(c.DocumentIDs)
+	found := make(map[string]bool)
+
+	result := CaseResult{
+		Question: c.Question,
+		Recall:   make(map[int]float64, len(ks)),
+		NDCG:     make(map[int]float64, len(ks)),
+	}
+	for i, r := range results {
+		result.Retrieved = append(result.Retrieved, r.Document.ID)
+
+		var key string
+		if segments[r.Document.ID] {
+			key = "segment:" + r.Document.ID
+		} else if documents[r.Document.FileID] {
+			key = "document:" + r.Document.FileID
+		}
+		if key == "" || found[key] {
+			continue
+		}
+		found[key] = true
+		result.Ranks = append(result.Ranks, i+1)
+	}
+
+	if len(result.Ranks) > 0 {
+		result.RR = 1 / float64(result.Ranks[0])
+	}
+	expected := c.expected()
+	for _, k := range ks {
+		hits := 0
+		dcg := 0.0
+		for _, rank := range result.Ranks {
+			if rank > k {
+				break
+			}
+			hits++
+			dcg += 1 / math.Log2(float64(rank)+1)
+		}
+		idcg := 0.0
+		for i := 1; i <= expected && i <= k; i++ {
+			idcg += 1 / math.Log2(float64(i)+1)
+		}
+		result.Recall[k] = float64(hits) / float64(expected)
+		result.NDCG[k] = dcg / idcg
+	}
+	return result
+}
+
+// WriteText 以表格形式输出评估报告
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "cases\t%d\n", r.Cases)
+	if r.Failed > 0 {
+		fmt.Fprintf(tw, "failed\t%d\n", r.Failed)
+	}
+	fmt.Fprintf(tw, "MRR\t%.4f\n", r.MRR)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "k\trecall@k\tnDCG@k")
+	for _, k := range r.Ks {
+		fmt.Fprintf(tw, "%d\t%.4f\t%.4f\n", k, r.Recall[k], r.NDCG[k])
+	}
+
+	// 列出完全没有命中的问题，便于排查
+	var misses []string
+	for _, res := range r.Results {
+		if len(res.Ranks) == 0 {
+			misses = append(misses, res.Question)
+		}
+	}
+	if len(misses) > 0 {
+		fmt.Fprintf(tw, "\nmissed (%d):\n", len(misses))
+		for _, q := range misses {
+			fmt.Fprintf(tw, "  - %s\n", q)
+		}
+	}
+	return tw.Flush()
+}
+
+// ParseKs 解析逗号分隔的截断位置，如 "1,3,5,10"
+func ParseKs(s string) ([]int, error) {
+	var ks []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var k int
+		if _, err := fmt.Sscanf(part, "%d", &k); err != nil || k <= 0 {
+			return nil, fmt.Errorf("invalid k: %q", part)
+		}
+		ks = append(ks, k)
+	}
+	return normalizeKs(ks), nil
+}
+
+// normalizeKs 去重并升序排列截断位置，为空时使用DefaultKs
+func normalizeKs(ks []int) []int {
+	seen := make(map[int]bool)
+	var out []int
+	for _, k := range ks {
+		if k > 0 && !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, DefaultKs...)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// zeroMetrics 返回各截断位置均为0的指标
+func zeroMetrics(ks []int) map[int]float64 {
+	m := make(map[int]float64, len(ks))
+	for _, k := range ks {
+		m[k] = 0
+	}
+	return m
+}
+
+// toSet 将列表转换为集合
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
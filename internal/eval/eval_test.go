@@ -0,0 +1,92 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRetriever 按问题返回预设结果的检索器
+type fakeRetriever map[string][]string
+
+func (f fakeRetriever) Retrieve(_ context.Context, question string, k int) ([]vectordb.SearchResult, error) {
+	ids, ok := f[question]
+	if !ok {
+		return nil, errors.New("retrieval failed")
+	}
+	var results []vectordb.SearchResult
+	for _, id := range ids {
+		if len(results) == k {
+			break
+		}
+		fileID := id[:strings.LastIndex(id, "_")]
+		results = append(results, vectordb.SearchResult{Document: vectordb.Document{ID: id, FileID: fileID}})
+	}
+	return results, nil
+}
+
+// TestEvaluate 测试recall@k、MRR和nDCG的计算
+func TestEvaluate(t *testing.T) {
+	retriever := fakeRetriever{
+		"q1": {"b_0", "a_0", "c_0"},
+		"q2": {"d_0", "d_1"},
+	}
+	cases := []Case{
+		{Question: "q1", SegmentIDs: []string{"a_0"}},
+		{Question: "q2", DocumentIDs: []string{"d"}},
+		{Question: "q3", SegmentIDs: []string{"x_0"}},
+	}
+
+	report, err := Evaluate(context.Background(), retriever, cases, []int{3, 1, 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, report.Ks)
+	assert.Equal(t, 3, report.Cases)
+	assert.Equal(t, 1, report.Failed)
+
+	assert.InDelta(t, 1.0/3, report.Recall[1], 1e-9)
+	assert.InDelta(t, 2.0/3, report.Recall[3], 1e-9)
+	assert.InDelta(t, 0.5, report.MRR, 1e-9)
+	assert.InDelta(t, (1/math.Log2(3)+1)/3, report.NDCG[3], 1e-9)
+
+	// 同一文档的第二个段落不重复计分
+	assert.Equal(t, []int{1}, report.Results[1].Ranks)
+	assert.Equal(t, "retrieval failed", report.Results[2].Error)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteText(&buf))
+	assert.Contains(t, buf.String(), "MRR")
+	assert.Contains(t, buf.String(), "q3")
+}
+
+// TestLoadGoldenSet 测试读取黄金集
+func TestLoadGoldenSet(t *testing.T) {
+	jsonl := `# 安装相关
+{"question": "如何安装？", "segment_ids": ["install_0"]}
+
+{"question": "支持哪些数据库？", "document_ids": ["db"]}
+`
+	cases, err := LoadGoldenSet(strings.NewReader(jsonl))
+	require.NoError(t, err)
+	require.Len(t, cases, 2)
+	assert.Equal(t, []string{"db"}, cases[1].DocumentIDs)
+
+	cases, err = LoadGoldenSet(strings.NewReader(`[{"question": "如何安装？", "segment_ids": ["install_0"]}]`))
+	require.NoError(t, err)
+	require.Len(t, cases, 1)
+
+	_, err = LoadGoldenSet(strings.NewReader(`{"question": "没有期望结果"}`))
+	assert.Error(t, err)
+
+	ks, err := ParseKs("10, 1,5")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 5, 10}, ks)
+	_, err = ParseKs("0")
+	assert.Error(t, err)
+}
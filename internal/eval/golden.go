@@ -0,0 +1,68 @@
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Case 黄金集中的一条评估用例
+// 检索结果命中SegmentIDs中的段落或DocumentIDs中的文档即视为相关，两者至少填写一项
+type Case struct {
+	Question    string   `json:"question"`               // 问题
+	DocumentIDs []string `json:"document_ids,omitempty"` // 期望命中的文档ID
+	SegmentIDs  []string `json:"segment_ids,omitempty"`  // 期望命中的段落ID，与向量数据库中的文档ID一致，如 "{file_id}_{position}"
+}
+
+// expected 返回用例期望命中的条目数
+func (c Case) expected() int {
+	return len(c.DocumentIDs) + len(c.SegmentIDs)
+}
+
+// LoadGoldenSet 读取黄金集，支持JSON数组和JSON Lines两种格式
+// JSON Lines中的空行和以#开头的注释行会被跳过
+func LoadGoldenSet(r io.Reader) ([]Case, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &cases); err != nil {
+			return nil, fmt.Errorf("invalid golden set: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(text), &c); err != nil {
+				return nil, fmt.Errorf("invalid golden set at line %d: %w", line, err)
+			}
+			cases = append(cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, c := range cases {
+		if strings.TrimSpace(c.Question) == "" {
+			return nil, fmt.Errorf("case %d: question cannot be empty", i+1)
+		}
+		if c.expected() == 0 {
+			return nil, fmt.Errorf("case %d: document_ids or segment_ids is required", i+1)
+		}
+	}
+	return cases, nil
+}
@@ -0,0 +1,216 @@
+// Package events 知识库变更事件总线
+// 文档上传、处理完成、删除和问答等操作发布为事件，由可插拔的发布者（内存、NATS、Kafka）
+// 转发给其他系统消费。发布是异步的，缓冲区满时丢弃事件，不阻塞业务操作
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 事件类型
+const (
+	TypeDocumentUploaded  = "document.uploaded"  // 文档已上传，等待处理
+	TypeDocumentProcessed = "document.processed" // 文档处理结束，data.status为completed或failed
+	TypeDocumentDeleted   = "document.deleted"   // 文档已删除
+	TypeQuestionAnswered  = "question.answered"  // 问答结束
+)
+
+// Event 知识库变更事件
+type Event struct {
+	ID        string                 `json:"id"`                   // 事件ID
+	Type      string                 `json:"type"`                 // 事件类型
+	Time      time.Time              `json:"time"`                 // 发生时间（UTC）
+	Subject   string                 `json:"subject,omitempty"`    // 事件对象，如文档ID
+	Tenant    string                 `json:"tenant,omitempty"`     // 触发事件的租户
+	RequestID string                 `json:"request_id,omitempty"` // 触发事件的请求ID
+	Data      map[string]interface{} `json:"data,omitempty"`       // 事件内容
+}
+
+// Publisher 事件发布者
+type Publisher interface {
+	// Name 返回发布者名称，用于日志
+	Name() string
+
+	// Publish 发布一个事件
+	Publish(ctx context.Context, event Event) error
+
+	// Close 释放连接等资源
+	Close() error
+}
+
+// Stats 事件总线的统计
+type Stats struct {
+	Published int64 // 所有发布者都发布成功的事件数
+	Dropped   int64 // 缓冲区满时丢弃的事件数
+	Failed    int64 // 发布失败的次数（按发布者计）
+}
+
+// Bus 事件总线
+// 事件先进入缓冲区，由后台协程依次交给每个发布者
+type Bus struct {
+	publishers []Publisher
+	logger     *logrus.Logger
+	timeout    time.Duration // 单次发布的超时时间
+	buffer     int           // 缓冲区大小
+
+	events chan Event
+	done   chan struct{}
+	mu     sync.RWMutex
+	closed bool
+
+	published atomic.Int64
+	dropped   atomic.Int64
+	failed    atomic.Int64
+}
+
+// BusOption 事件总线配置选项
+type BusOption func(*Bus)
+
+// WithBufferSize 设置缓冲区大小
+func WithBufferSize(size int) BusOption {
+	return func(b *Bus) {
+		if size > 0 {
+			b.buffer = size
+		}
+	}
+}
+
+// WithPublishTimeout 设置单次发布的超时时间
+func WithPublishTimeout(timeout time.Duration) BusOption {
+	return func(b *Bus) {
+		if timeout > 0 {
+			b.timeout = timeout
+		}
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *logrus.Logger) BusOption {
+	return func(b *Bus) {
+		if logger != nil {
+			b.logger = logger
+		}
+	}
+}
+
+// NewBus 创建事件总线并启动后台发布协程
+func NewBus(publishers []Publisher, opts ...BusOption) *Bus {
+	b := &Bus{
+		publishers: publishers,
+		logger:     logrus.StandardLogger(),
+		timeout:    5 * time.Second,
+		buffer:     1000,
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.events = make(chan Event, b.buffer)
+	go b.run()
+	return b
+}
+
+// Publish 发布事件，总线为nil或已关闭时不做任何事
+// 租户和请求ID取自上下文；缓冲区满时丢弃事件并计数
+func (b *Bus) Publish(ctx context.Context, eventType, subject string, data map[string]interface{}) {
+	if b == nil {
+		return
+	}
+
+	event := Event{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Time:      time.Now().UTC(),
+		Subject:   subject,
+		Tenant:    usage.TenantFromContext(ctx),
+		RequestID: requestid.FromContext(ctx),
+		Data:      data,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	select {
+	case b.events <- event:
+	default:
+		if b.dropped.Add(1)%100 == 1 {
+			b.logger.WithField("type", eventType).Warn("Event buffer is full, dropping events")
+		}
+	}
+}
+
+// Stats 返回事件总线的统计
+func (b *Bus) Stats() Stats {
+	if b == nil {
+		return Stats{}
+	}
+	return Stats{
+		Published: b.published.Load(),
+		Dropped:   b.dropped.Load(),
+		Failed:    b.failed.Load(),
+	}
+}
+
+// Close 停止接收事件，等待缓冲区中的事件发布完成（或ctx结束）后关闭所有发布者
+func (b *Bus) Close(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.events)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		b.logger.Warn("Timed out waiting for pending events to be published")
+	}
+
+	var firstErr error
+	for _, p := range b.publishers {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// run 依次把缓冲区中的事件交给每个发布者
+func (b *Bus) run() {
+	defer close(b.done)
+	for event := range b.events {
+		ok := true
+		for _, p := range b.publishers {
+			ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+			err := p.Publish(ctx, event)
+			cancel()
+			if err != nil {
+				ok = false
+				b.failed.Add(1)
+				b.logger.WithError(err).WithFields(logrus.Fields{
+					"publisher": p.Name(),
+					"type":      event.Type,
+					"event_id":  event.ID,
+				}).Warn("Failed to publish event")
+			}
+		}
+		if ok {
+			b.published.Add(1)
+		}
+	}
+}
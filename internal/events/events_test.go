@@ -0,0 +1,224 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingPublisher 在收到信号前阻塞发布
+type blockingPublisher struct {
+	release chan struct{}
+	mu      sync.Mutex
+	events  []Event
+}
+
+func (p *blockingPublisher) Name() string { return "blocking" }
+
+func (p *blockingPublisher) Publish(ctx context.Context, event Event) error {
+	<-p.release
+	p.mu.Lock()
+	p.events = append(p.events, event)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *blockingPublisher) Close() error { return nil }
+
+// failingPublisher 总是发布失败
+type failingPublisher struct{}
+
+func (failingPublisher) Name() string                               { return "failing" }
+func (failingPublisher) Publish(ctx context.Context, e Event) error { return errors.New("boom") }
+func (failingPublisher) Close() error                               { return nil }
+
+// TestBusMemoryPublisher 测试事件经总线进入内存发布者，并能增量读取和订阅
+func TestBusMemoryPublisher(t *testing.T) {
+	memory := NewMemoryPublisher(2)
+	events, cancel := memory.Subscribe(10)
+	defer cancel()
+	bus := NewBus([]Publisher{memory})
+
+	ctx := usage.WithTenant(context.Background(), "team-a")
+	bus.Publish(ctx, TypeDocumentUploaded, "doc1", map[string]interface{}{"file_name": "a.md"})
+	bus.Publish(ctx, TypeDocumentProcessed, "doc1", map[string]interface{}{"status": "completed"})
+	bus.Publish(ctx, TypeDocumentDeleted, "doc1", nil)
+	require.NoError(t, bus.Close(context.Background()))
+
+	// 容量为2，只保留最近的两个事件
+	entries, latest := memory.Since(0, 0)
+	assert.Equal(t, int64(3), latest)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(2), entries[0].Seq)
+	assert.Equal(t, TypeDocumentProcessed, entries[0].Event.Type)
+	assert.Equal(t, "team-a", entries[0].Event.Tenant)
+	assert.Equal(t, "doc1", entries[0].Event.Subject)
+	assert.NotEmpty(t, entries[0].Event.ID)
+
+	entries, _ = memory.Since(2, 10)
+	require.Len(t, entries, 1)
+	assert.Equal(t, TypeDocumentDeleted, entries[0].Event.Type)
+
+	var received []string
+	for event := range events {
+		received = append(received, event.Type)
+	}
+	assert.Equal(t, []string{TypeDocumentUploaded, TypeDocumentProcessed, TypeDocumentDeleted}, received)
+	assert.Equal(t, Stats{Published: 3}, bus.Stats())
+
+	// 关闭后发布被忽略，nil总线也可以安全调用
+	bus.Publish(ctx, TypeDocumentDeleted, "doc2", nil)
+	var nilBus *Bus
+	nilBus.Publish(ctx, TypeDocumentDeleted, "doc2", nil)
+	assert.NoError(t, nilBus.Close(context.Background()))
+}
+
+// TestBusDropsWhenFull 测试缓冲区满时丢弃事件而不阻塞，发布失败时计数
+func TestBusDropsWhenFull(t *testing.T) {
+	blocking := &blockingPublisher{release: make(chan struct{})}
+	bus := NewBus([]Publisher{blocking, failingPublisher{}}, WithBufferSize(1))
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			bus.Publish(context.Background(), TypeQuestionAnswered, "", nil)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("publish should not block when the buffer is full")
+	}
+
+	close(blocking.release)
+	require.NoError(t, bus.Close(context.Background()))
+
+	stats := bus.Stats()
+	delivered := int64(len(blocking.events))
+	assert.GreaterOrEqual(t, delivered, int64(1))
+	assert.Equal(t, int64(5), delivered+stats.Dropped)
+	assert.Equal(t, delivered, stats.Failed)
+	assert.Zero(t, stats.Published)
+}
+
+// TestNATSPublisher 测试NATS握手和发布
+func TestNATSPublisher(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	type message struct {
+		subject string
+		payload []byte
+	}
+	connects := make(chan string, 1)
+	messages := make(chan message, 1)
+
+	// 模拟NATS服务端：发送INFO，校验CONNECT，回复PONG并读取PUB
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576}` + "\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(line, "CONNECT "):
+				connects <- strings.TrimPrefix(line, "CONNECT ")
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				fields := strings.Fields(line)
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(r, payload); err != nil {
+					return
+				}
+				messages <- message{subject: fields[1], payload: payload[:size]}
+			}
+		}
+	}()
+
+	publisher, err := NewNATSPublisher(NATSConfig{
+		URL:     "nats://alice:secret@" + lis.Addr().String(),
+		Subject: "kb",
+		Timeout: 2 * time.Second,
+	})
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	event := Event{ID: "e1", Type: TypeDocumentDeleted, Subject: "doc1"}
+	require.NoError(t, publisher.Publish(context.Background(), event))
+
+	var options map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(<-connects), &options))
+	assert.Equal(t, "alice", options["user"])
+	assert.Equal(t, "secret", options["pass"])
+
+	msg := <-messages
+	assert.Equal(t, "kb.document.deleted", msg.subject)
+	var got Event
+	require.NoError(t, json.Unmarshal(msg.payload, &got))
+	assert.Equal(t, "e1", got.ID)
+	assert.Equal(t, "doc1", got.Subject)
+
+	_, err = NewNATSPublisher(NATSConfig{URL: "tls://localhost:4222"})
+	assert.Error(t, err)
+}
+
+// TestKafkaPublisher 测试通过Kafka REST Proxy发布事件
+func TestKafkaPublisher(t *testing.T) {
+	var body map[string][]struct {
+		Key   string `json:"key"`
+		Value Event  `json:"value"`
+	}
+	reject := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/kb-events", r.URL.Path)
+		assert.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if reject {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"broker unavailable"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":12}]}`))
+	}))
+	defer server.Close()
+
+	publisher, err := NewKafkaPublisher(KafkaConfig{URL: server.URL + "/", Topic: "kb-events", Token: "token"})
+	require.NoError(t, err)
+
+	event := Event{ID: "e1", Type: TypeDocumentUploaded, Subject: "doc1"}
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	require.Len(t, body["records"], 1)
+	assert.Equal(t, "doc1", body["records"][0].Key)
+	assert.Equal(t, "e1", body["records"][0].Value.ID)
+
+	reject = true
+	err = publisher.Publish(context.Background(), event)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broker unavailable")
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaContentType Kafka REST Proxy v2的JSON消息格式
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// KafkaConfig Kafka发布者配置
+type KafkaConfig struct {
+	URL      string        // Kafka REST Proxy地址，如http://localhost:8082
+	Topic    string        // 主题名称
+	Username string        // Basic认证用户名（可选）
+	Password string        // Basic认证密码（可选）
+	Token    string        // Bearer令牌（可选），与Basic认证二选一
+	Timeout  time.Duration // 请求超时时间
+}
+
+// KafkaPublisher 通过Kafka REST Proxy（v2 API）发布事件
+// 消息键为事件对象（如文档ID），同一文档的事件进入同一分区，保证顺序
+type KafkaPublisher struct {
+	cfg      KafkaConfig
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaPublisher 创建Kafka发布者
+func NewKafkaPublisher(cfg KafkaConfig) (*KafkaPublisher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("kafka rest proxy url is required")
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = "docqa.events"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &KafkaPublisher{
+		cfg:      cfg,
+		endpoint: strings.TrimRight(cfg.URL, "/") + "/topics/" + url.PathEscape(cfg.Topic),
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Name 返回发布者名称
+func (p *KafkaPublisher) Name() string {
+	return "kafka"
+}
+
+// Publish 把事件写入主题
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	key := event.Subject
+	if key == "" {
+		key = event.Type
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": key, "value": event}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	switch {
+	case p.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+p.cfg.Token)
+	case p.cfg.Username != "":
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to publish to kafka: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	// 请求成功时每条消息仍可能单独失败
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(data, &result); err == nil {
+		for _, offset := range result.Offsets {
+			if offset.ErrorCode != nil || offset.Error != "" {
+				return fmt.Errorf("kafka rejected event: %s", offset.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// Close 无需释放资源
+func (p *KafkaPublisher) Close() error {
+	return nil
+}
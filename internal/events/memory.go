@@ -0,0 +1,105 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Entry 内存发布者保存的事件，Seq单调递增，用于增量读取
+type Entry struct {
+	Seq   int64 `json:"seq"`   // 事件序号
+	Event Event `json:"event"` // 事件
+}
+
+// MemoryPublisher 内存事件发布者
+// 保存最近的事件供增量读取，并把事件推送给进程内的订阅者，适合单实例部署和测试
+type MemoryPublisher struct {
+	mu          sync.Mutex
+	capacity    int
+	entries     []Entry // 环形缓冲区，按序号递增
+	seq         int64
+	subscribers map[chan Event]struct{}
+}
+
+// NewMemoryPublisher 创建内存事件发布者，capacity为保存的最近事件数
+func NewMemoryPublisher(capacity int) *MemoryPublisher {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &MemoryPublisher{
+		capacity:    capacity,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Name 返回发布者名称
+func (m *MemoryPublisher) Name() string {
+	return "memory"
+}
+
+// Publish 保存事件并推送给订阅者，订阅者处理不过来时丢弃该订阅者的事件
+func (m *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	if len(m.entries) >= m.capacity {
+		m.entries = m.entries[1:]
+	}
+	m.entries = append(m.entries, Entry{Seq: m.seq, Event: event})
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Since 返回序号大于after的事件，最多limit个，以及最新的序号
+func (m *MemoryPublisher) Since(after int64, limit int) ([]Entry, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Entry, 0)
+	for _, entry := range m.entries {
+		if entry.Seq <= after {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, entry)
+	}
+	return result, m.seq
+}
+
+// Subscribe 订阅之后发布的事件，返回事件通道和取消订阅的函数
+func (m *MemoryPublisher) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	m.mu.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// 发布者关闭时已经关闭了所有订阅的通道
+		if _, ok := m.subscribers[ch]; ok {
+			delete(m.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close 关闭所有订阅
+func (m *MemoryPublisher) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for ch := range m.subscribers {
+		delete(m.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSConfig NATS发布者配置
+type NATSConfig struct {
+	URL      string        // 服务地址，如nats://localhost:4222，可在地址中携带用户名和密码
+	Subject  string        // 主题前缀，事件发布到<前缀>.<事件类型>，如docqa.events.document.uploaded
+	Token    string        // 认证令牌
+	Username string        // 用户名
+	Password string        // 密码
+	Timeout  time.Duration // 连接和写入超时时间
+}
+
+// NATSPublisher 通过NATS核心协议发布事件
+// 只实现发布所需的文本协议（INFO/CONNECT/PUB/PING/PONG），连接断开后在下次发布时重连。
+// 核心NATS不保证送达，需要持久化时在服务端为主题配置JetStream流
+type NATSPublisher struct {
+	cfg  NATSConfig
+	addr string
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+}
+
+// NewNATSPublisher 创建NATS发布者，首次发布时建立连接
+func NewNATSPublisher(cfg NATSConfig) (*NATSPublisher, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("nats url is required")
+	}
+	if !strings.Contains(cfg.URL, "://") {
+		cfg.URL = "nats://" + cfg.URL
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats url: %w", err)
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported nats url scheme %q", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil && cfg.Username == "" {
+		cfg.Username = u.User.Username()
+		cfg.Password, _ = u.User.Password()
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = "docqa.events"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &NATSPublisher{cfg: cfg, addr: addr}, nil
+}
+
+// Name 返回发布者名称
+func (p *NATSPublisher) Name() string {
+	return "nats"
+}
+
+// Publish 把事件发布到<主题前缀>.<事件类型>，写入失败时重连一次
+func (p *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	subject := p.cfg.Subject + "." + event.Type
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		if p.conn == nil {
+			if err := p.connect(ctx); err != nil {
+				return err
+			}
+		}
+		err := p.write(ctx, fmt.Sprintf("PUB %s %d\r\n", subject, len(payload)), payload, []byte("\r\n"))
+		if err == nil {
+			return nil
+		}
+		p.closeConn()
+		if attempt > 0 {
+			return fmt.Errorf("failed to publish to nats: %w", err)
+		}
+	}
+}
+
+// Close 关闭连接
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		_ = p.w.Flush()
+	}
+	p.closeConn()
+	return nil
+}
+
+// connect 建立连接并完成握手：读取INFO，发送CONNECT，再用PING/PONG确认认证通过
+// 调用方需持有锁
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(p.cfg.Timeout))
+	r := bufio.NewReader(conn)
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to read nats info: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected nats greeting: %s", strings.TrimSpace(line))
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if info.TLSRequired {
+		conn.Close()
+		return errors.New("nats server requires tls, which is not supported")
+	}
+
+	options, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"name":       "doc-qa-system",
+		"lang":       "go",
+		"version":    "1.0",
+		"protocol":   0,
+		"auth_token": p.cfg.Token,
+		"user":       p.cfg.Username,
+		"pass":       p.cfg.Password,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", options); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to send nats connect: %w", err)
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to read nats reply: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("nats connect rejected: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	p.conn = conn
+	p.w = bufio.NewWriter(conn)
+	go p.readLoop(conn, r)
+	return nil
+}
+
+// readLoop 响应服务端的PING，连接出错或被服务端拒绝时关闭连接，下次发布时重连
+func (p *NATSPublisher) readLoop(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "PING" {
+			p.mu.Lock()
+			if p.conn == conn {
+				_, _ = p.w.WriteString("PONG\r\n")
+				_ = p.w.Flush()
+			}
+			p.mu.Unlock()
+		} else if strings.HasPrefix(line, "-ERR") {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	if p.conn == conn {
+		p.closeConn()
+	}
+	p.mu.Unlock()
+}
+
+// write 写入并刷新数据，调用方需持有锁
+func (p *NATSPublisher) write(ctx context.Context, header string, parts ...[]byte) error {
+	deadline := time.Now().Add(p.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = p.conn.SetWriteDeadline(deadline)
+	if _, err := p.w.WriteString(header); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if _, err := p.w.Write(part); err != nil {
+			return err
+		}
+	}
+	return p.w.Flush()
+}
+
+// closeConn 关闭当前连接，调用方需持有锁
+func (p *NATSPublisher) closeConn() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+		p.w = nil
+	}
+}
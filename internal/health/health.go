@@ -0,0 +1,130 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 依赖状态
+const (
+	StatusUp   = "up"   // 依赖可用
+	StatusDown = "down" // 依赖不可用
+)
+
+// CheckFunc 依赖检查函数，返回nil表示依赖可用
+type CheckFunc func(ctx context.Context) error
+
+// Result 单个依赖的检查结果
+type Result struct {
+	Name     string        // 依赖名称
+	Status   string        // up或down
+	Critical bool          // 是否为关键依赖，关键依赖不可用时服务不就绪
+	Latency  time.Duration // 检查耗时
+	Error    string        // 不可用的原因
+}
+
+// Report 依赖检查报告
+type Report struct {
+	Healthy bool     // 所有关键依赖均可用
+	Results []Result // 各依赖的检查结果，按注册顺序排列
+}
+
+// check 已注册的依赖检查
+type check struct {
+	name     string
+	fn       CheckFunc
+	critical bool
+}
+
+// Checker 依赖检查器
+// 并行检查数据库、向量数据库、任务队列、存储和Python服务等依赖，
+// 每个检查有独立的超时时间，单个依赖卡住不会拖慢整个探针
+type Checker struct {
+	checks  []check
+	timeout time.Duration // 单个依赖的检查超时时间
+}
+
+// Option 检查器配置选项
+type Option func(*Checker)
+
+// WithTimeout 设置单个依赖的检查超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+// New 创建依赖检查器
+func New(opts ...Option) *Checker {
+	c := &Checker{timeout: 3 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Register 注册关键依赖，不可用时服务不就绪
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn, critical: true})
+}
+
+// RegisterOptional 注册非关键依赖，不可用时只在报告中体现，不影响就绪状态
+func (c *Checker) RegisterOptional(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Check 并行检查所有依赖
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Healthy: true, Results: make([]Result, len(c.checks))}
+
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			report.Results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	for _, result := range report.Results {
+		if result.Critical && result.Status != StatusUp {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+// run 在超时时间内执行单个检查，检查函数不响应取消时按超时处理
+func (c *Checker) run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:     chk.name,
+		Status:   StatusUp,
+		Critical: chk.critical,
+		Latency:  time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChecker 测试依赖检查结果和就绪判断
+func TestChecker(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	c := New(WithTimeout(50 * time.Millisecond))
+	c.Register("database", up)
+	c.RegisterOptional("python", down)
+
+	report := c.Check(context.Background())
+	assert.True(t, report.Healthy, "optional dependency should not affect readiness")
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, "database", report.Results[0].Name)
+	assert.Equal(t, StatusUp, report.Results[0].Status)
+	assert.True(t, report.Results[0].Critical)
+	assert.Equal(t, StatusDown, report.Results[1].Status)
+	assert.Equal(t, "connection refused", report.Results[1].Error)
+	assert.False(t, report.Results[1].Critical)
+
+	// 关键依赖超时视为不可用，不会等到检查函数返回
+	c.Register("storage", hang)
+	start := time.Now()
+	report = c.Check(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.False(t, report.Healthy)
+	assert.Equal(t, StatusDown, report.Results[2].Status)
+	assert.Contains(t, report.Results[2].Error, "deadline exceeded")
+}
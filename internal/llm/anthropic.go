@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// AnthropicBaseURL Anthropic API默认地址
+	AnthropicBaseURL = "https://api.anthropic.com/v1"
+	// AnthropicVersion Anthropic API版本
+	AnthropicVersion = "2023-06-01"
+
+	// anthropicStructuredTool 结构化输出时强制调用的工具名称
+	anthropicStructuredTool = "structured_output"
+)
+
+// AnthropicClient Anthropic Claude客户端
+type AnthropicClient struct {
+	config *Config
+	http   *httpDoer
+}
+
+// NewAnthropicClient 创建Anthropic Claude客户端
+func NewAnthropicClient(opts ...Option) (Client, error) {
+	// 先设置Anthropic的默认值，再应用用户选项
+	defaults := []Option{
+		WithBaseURL(AnthropicBaseURL),
+		WithModel(ModelClaudeSonnet),
+	}
+	cfg := NewConfig(append(defaults, opts...)...)
+
+	if cfg.APIKey == "" {
+		return nil, NewLLMError(ErrCodeInvalidAPIKey, ErrMsgInvalidAPIKey)
+	}
+
+	return &AnthropicClient{
+		config: cfg,
+		http:   newHTTPDoer(cfg),
+	}, nil
+}
+
+// Name 返回模型名称
+func (c *AnthropicClient) Name() string {
+	return c.config.Model
+}
+
+// Generate 根据提示词生成回答
+func (c *AnthropicClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	if prompt == "" {
+		return nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt)
+	}
+
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	return c.Chat(ctx, messages, func(o *ChatOptions) {
+		o.MaxTokens = opts.MaxTokens
+		o.Temperature = opts.Temperature
+		o.TopP = opts.TopP
+		o.TopK = opts.TopK
+		o.Stop = opts.Stop
+		o.JSONSchema = opts.JSONSchema
+	})
+}
+
+// Chat 进行多轮对话
+// 系统消息会合并到请求的system字段，Anthropic不支持在消息列表中出现system角色
+func (c *AnthropicClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages cannot be empty")
+	}
+
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if err := unsupportedTools(opts, c.config.Model); err != nil {
+		return nil, err
+	}
+
+	req := AnthropicRequest{
+		Model:     c.config.Model,
+		MaxTokens: c.config.MaxTokens,
+	}
+
+	var system []string
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			system = append(system, msg.Content)
+		case RoleAssistant:
+			req.Messages = append(req.Messages, AnthropicMessage{Role: "assistant", Content: msg.Content})
+		default:
+			req.Messages = append(req.Messages, AnthropicMessage{Role: "user", Content: msg.Content})
+		}
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	if len(req.Messages) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages must contain at least one non-system message")
+	}
+	return c.send(ctx, req, opts)
+}
+
+// DescribeImage 根据提示词和图片生成回答，图片以base64内容块放在提示词之前
+func (c *AnthropicClient) DescribeImage(ctx context.Context, prompt string, image Image, options ...GenerateOption) (*Response, error) {
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	req := AnthropicRequest{
+		Model:     c.config.Model,
+		MaxTokens: c.config.MaxTokens,
+		Messages: []AnthropicMessage{{Role: "user", Content: []AnthropicInputBlock{
+			{Type: "image", Source: &AnthropicImageSource{Type: "base64", MediaType: image.MimeType, Data: image.base64Data()}},
+			{Type: "text", Text: prompt},
+		}}},
+	}
+	return c.send(ctx, req, &ChatOptions{
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		Stop:        opts.Stop,
+	})
+}
+
+// send 应用请求级参数并发送请求
+func (c *AnthropicClient) send(ctx context.Context, req AnthropicRequest, opts *ChatOptions) (*Response, error) {
+	// 应用请求级参数，未设置时使用客户端配置
+	if opts.MaxTokens != nil {
+		req.MaxTokens = *opts.MaxTokens
+	}
+	if opts.Temperature != nil {
+		req.Temperature = opts.Temperature
+	} else if c.config.Temperature > 0 {
+		temp := c.config.Temperature
+		req.Temperature = &temp
+	}
+	// Anthropic不建议同时设置temperature和top_p，只在显式指定时传递
+	if opts.TopP != nil {
+		req.TopP = opts.TopP
+	}
+	if opts.TopK != nil {
+		req.TopK = opts.TopK
+	}
+	if opts.Stop != nil {
+		req.StopSequences = *opts.Stop
+	}
+	// Anthropic没有JSON输出模式，强制调用一个参数为目标Schema的工具，工具参数即结构化输出
+	if len(opts.JSONSchema) > 0 {
+		req.Tools = []AnthropicTool{{
+			Name:        anthropicStructuredTool,
+			Description: "按要求的结构输出回答",
+			InputSchema: opts.JSONSchema,
+		}}
+		req.ToolChoice = &AnthropicToolChoice{Type: "tool", Name: anthropicStructuredTool}
+	}
+
+	headers := map[string]string{
+		"x-api-key":         c.config.APIKey,
+		"anthropic-version": AnthropicVersion,
+	}
+
+	url := strings.TrimRight(c.config.BaseURL, "/") + "/messages"
+	data, err := c.http.postJSON(ctx, url, headers, req, parseAnthropicError)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp AnthropicResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, NewLLMError(ErrCodeServerError, fmt.Sprintf("failed to parse response: %v", err))
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	// 结构化输出时只返回工具参数，忽略模型在调用工具前的说明文字
+	for _, block := range resp.Content {
+		if block.Type == "tool_use" && block.Name == anthropicStructuredTool {
+			text.Reset()
+			text.Write(block.Input)
+			break
+		}
+	}
+
+	return &Response{
+		Text:       text.String(),
+		Messages:   []Message{{Role: RoleAssistant, Content: text.String()}},
+		TokenCount: resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		ModelName:  resp.Model,
+		FinishTime: time.Now(),
+	}, nil
+}
+
+// parseAnthropicError 从错误响应中提取错误信息
+func parseAnthropicError(data []byte) string {
+	var e AnthropicError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return ""
+	}
+	return e.Error.Message
+}
+
+// 在包初始化时注册Anthropic客户端
+func init() {
+	RegisterClient("anthropic", NewAnthropicClient)
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnthropicClientChat 测试Anthropic客户端的请求转换和响应解析
+func TestAnthropicClientChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, AnthropicVersion, r.Header.Get("anthropic-version"))
+
+		var req AnthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "你是一个助手", req.System)
+		assert.Equal(t, 256, req.MaxTokens)
+		require.Len(t, req.Messages, 1)
+		assert.Equal(t, "user", req.Messages[0].Role)
+
+		_ = json.NewEncoder(w).Encode(AnthropicResponse{
+			Model:   ModelClaudeHaiku,
+			Content: []AnthropicContentBlock{{Type: "text", Text: "你好"}},
+			Usage:   AnthropicUsage{InputTokens: 10, OutputTokens: 2},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient("anthropic",
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithModel(ModelClaudeHaiku),
+		WithMaxTokens(512),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, ModelClaudeHaiku, client.Name())
+
+	resp, err := client.Chat(context.Background(), []Message{
+		{Role: RoleSystem, Content: "你是一个助手"},
+		{Role: RoleUser, Content: "你好"},
+	}, WithChatMaxTokens(256))
+	require.NoError(t, err)
+	assert.Equal(t, "你好", resp.Text)
+	assert.Equal(t, 12, resp.TokenCount)
+}
+
+// TestAnthropicClientErrors 测试Anthropic客户端的错误处理
+func TestAnthropicClientErrors(t *testing.T) {
+	_, err := NewAnthropicClient()
+	assert.Error(t, err, "api key is required")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewAnthropicClient(WithAPIKey("bad"), WithBaseURL(server.URL), WithMaxRetries(0))
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), "你好")
+	require.Error(t, err)
+	llmErr, ok := err.(LLMError)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeInvalidAPIKey, llmErr.Code)
+	assert.Contains(t, llmErr.Message, "invalid x-api-key")
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultContextWindow 未知模型使用的默认上下文窗口大小（token）
+const DefaultContextWindow = 8192
+
+// ModelContextWindows 常用模型的上下文窗口大小（token）
+// 可通过配置中的llm.context_windows覆盖或补充
+var ModelContextWindows = map[string]int{
+	ModelQwenTurbo:    131072,
+	ModelQwenPlus:     131072,
+	ModelQwenMax:      32768,
+	ModelQwenLong:     1000000,
+	ModelQwenVLPlus:   32768,
+	ModelDeepSeek:     65536,
+	ModelClaudeSonnet: 200000,
+	ModelClaudeHaiku:  200000,
+	ModelGeminiFlash:  1048576,
+	ModelGeminiPro:    1048576,
+	"gpt-3.5-turbo":   16385,
+	"gpt-4":           8192,
+	"gpt-4o":          128000,
+	"gpt-4o-mini":     128000,
+}
+
+// ContextWindowFor 返回模型的上下文窗口大小
+// 优先使用overrides中的配置，未知模型返回DefaultContextWindow
+func ContextWindowFor(model string, overrides map[string]int) int {
+	if n, ok := overrides[model]; ok && n > 0 {
+		return n
+	}
+	if n, ok := ModelContextWindows[model]; ok {
+		return n
+	}
+	return DefaultContextWindow
+}
+
+// Tokenizer 文本token计数器
+type Tokenizer interface {
+	// Count 返回文本的token数量
+	Count(text string) int
+}
+
+// EstimateTokenizer 基于字符统计的token估算器
+// 中日韩字符按每字一个token计，其余文本按约4个字符一个token计
+// 估算值偏保守，用于预算控制而非计费
+type EstimateTokenizer struct{}
+
+// Count 估算文本的token数量
+func (EstimateTokenizer) Count(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r),
+			unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+			cjk++
+		default:
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// TokenBudget 上下文token预算管理器
+// 按相关度顺序挑选检索到的上下文，保证提示词加上回答不超过模型窗口
+type TokenBudget struct {
+	tokenizer     Tokenizer
+	contextWindow int
+}
+
+// NewTokenBudget 创建token预算管理器，tokenizer为nil时使用EstimateTokenizer
+func NewTokenBudget(contextWindow int, tokenizer Tokenizer) *TokenBudget {
+	if tokenizer == nil {
+		tokenizer = EstimateTokenizer{}
+	}
+	if contextWindow <= 0 {
+		contextWindow = DefaultContextWindow
+	}
+	return &TokenBudget{
+		tokenizer:     tokenizer,
+		contextWindow: contextWindow,
+	}
+}
+
+// Fit 在预算内挑选上下文，返回保留的上下文及其在原切片中的下标
+// contexts应按相关度从高到低排列；overhead为提示词中上下文以外部分的token数，
+// reserve为留给回答的token数。放不下的上下文会被跳过，继续尝试更短的后续上下文；
+// 若最相关的上下文本身就超出预算，则截断后保留
+func (b *TokenBudget) Fit(contexts []string, overhead, reserve int) ([]string, []int) {
+	available := b.contextWindow - overhead - reserve
+	if available <= 0 || len(contexts) == 0 {
+		return nil, nil
+	}
+
+	var kept []string
+	var indices []int
+	for i, ctx := range contexts {
+		// 每个上下文在格式化时会带上编号和换行
+		cost := b.tokenizer.Count(formatContext([]string{ctx}))
+		if cost <= available {
+			kept = append(kept, ctx)
+			indices = append(indices, i)
+			available -= cost
+			continue
+		}
+		if len(kept) == 0 {
+			if truncated := b.truncate(ctx, available); truncated != "" {
+				kept = append(kept, truncated)
+				indices = append(indices, i)
+				available -= b.tokenizer.Count(formatContext([]string{truncated}))
+			}
+		}
+	}
+	return kept, indices
+}
+
+// truncate 按token预算截断文本，尽量在句子边界处截断
+func (b *TokenBudget) truncate(text string, limit int) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	// 二分查找能放入预算的最长前缀
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if b.tokenizer.Count(formatContext([]string{string(runes[:mid])})) <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	prefix := string(runes[:lo])
+
+	if cut := strings.LastIndexAny(prefix, "。！？.!?\n"); cut > len(prefix)/2 {
+		_, size := utf8.DecodeRuneInString(prefix[cut:])
+		prefix = prefix[:cut+size]
+	}
+	return prefix
+}
@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEstimateTokenizer 测试token估算
+func TestEstimateTokenizer(t *testing.T) {
+	tokenizer := EstimateTokenizer{}
+	assert.Equal(t, 0, tokenizer.Count(""))
+	assert.Equal(t, 4, tokenizer.Count("向量检索"))
+	assert.Equal(t, 2, tokenizer.Count("hello go"))
+}
+
+// TestTokenBudgetFit 测试按预算挑选上下文
+func TestTokenBudgetFit(t *testing.T) {
+	budget := NewTokenBudget(100, nil)
+	contexts := []string{
+		strings.Repeat("甲", 30),
+		strings.Repeat("乙", 60),
+		strings.Repeat("丙", 20),
+	}
+
+	// 可用预算为100-10-20=70，第二段放不下被跳过，第三段仍能放入
+	kept, indices := budget.Fit(contexts, 10, 20)
+	assert.Equal(t, []int{0, 2}, indices)
+	assert.Equal(t, []string{contexts[0], contexts[2]}, kept)
+}
+
+// TestTokenBudgetTruncate 测试最相关的上下文超出预算时被截断
+func TestTokenBudgetTruncate(t *testing.T) {
+	budget := NewTokenBudget(50, nil)
+	long := strings.Repeat("这是一句话。", 20)
+
+	kept, indices := budget.Fit([]string{long}, 0, 0)
+	require.Len(t, kept, 1)
+	assert.Equal(t, []int{0}, indices)
+	assert.True(t, strings.HasSuffix(kept[0], "。"), "should cut at sentence boundary")
+	assert.LessOrEqual(t, budget.tokenizer.Count(formatContext(kept)), 50)
+}
+
+// TestRAGContextBudget 测试RAG服务按上下文窗口裁剪上下文
+func TestRAGContextBudget(t *testing.T) {
+	mockClient := NewMockClient(t)
+	contexts := []string{"相关段落一", strings.Repeat("很长的段落", 200), "相关段落二"}
+
+	mockClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "相关段落一") &&
+				strings.Contains(prompt, "相关段落二") &&
+				!strings.Contains(prompt, "很长的段落")
+		}), mock.Anything, mock.Anything).
+		Return(&Response{Text: "回答"}, nil)
+
+	rag := NewRAG(mockClient, WithContextWindow(1000), WithRAGMaxTokens(200))
+	resp, err := rag.Answer(context.Background(), "问题", contexts)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, resp.ContextIndices)
+	assert.Len(t, resp.Sources, 2)
+}
+
+// TestContextWindowFor 测试模型上下文窗口查找
+func TestContextWindowFor(t *testing.T) {
+	assert.Equal(t, 4096, ContextWindowFor(ModelQwenTurbo, map[string]int{ModelQwenTurbo: 4096}))
+	assert.Equal(t, ModelContextWindows[ModelClaudeSonnet], ContextWindowFor(ModelClaudeSonnet, nil))
+	assert.Equal(t, DefaultContextWindow, ContextWindowFor("unknown-model", nil))
+}
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MetaCitation 来源文档元数据中保存引用信息的键
+const MetaCitation = "citation"
+
+// highlightThreshold 来源句子与回答句子的字符二元组重合比例达到该值才高亮
+const highlightThreshold = 0.5
+
+// citationPattern 匹配回答中的引用标记，如[1]、【2】
+var citationPattern = regexp.MustCompile(`\[(\d+)\]|【(\d+)】`)
+
+// TextSpan 文本范围，按字符（rune）计算的左闭右开区间
+type TextSpan struct {
+	Start int `json:"start"` // 起始偏移
+	End   int `json:"end"`   // 结束偏移
+}
+
+// Citation 回答中的一个引用标记
+type Citation struct {
+	Marker int      // 标记中的编号，从1开始，对应提示词中上下文的编号
+	Index  int      // 引用的上下文在RAG输入中的下标
+	Span   TextSpan // 标记在回答中的位置
+}
+
+// SourceCitation 单个来源的引用信息
+type SourceCitation struct {
+	Markers    []int      `json:"markers,omitempty"`    // 回答中引用该来源的标记编号
+	Spans      []TextSpan `json:"spans,omitempty"`      // 实际放入提示词的文本范围
+	Highlights []TextSpan `json:"highlights,omitempty"` // 支撑回答的文本范围，用于前端高亮
+}
+
+// ParseCitations 解析回答中的引用标记
+// indices为实际放入提示词的上下文在输入中的下标，标记[n]对应indices[n-1]；
+// indices为nil表示上下文没有被裁剪，标记[n]对应第n个输入；超出范围的标记被忽略
+func ParseCitations(answer string, indices []int, total int) []Citation {
+	var citations []Citation
+	for _, m := range citationPattern.FindAllStringSubmatchIndex(answer, -1) {
+		var digits string
+		if m[2] >= 0 {
+			digits = answer[m[2]:m[3]]
+		} else {
+			digits = answer[m[4]:m[5]]
+		}
+		marker, err := strconv.Atoi(digits)
+		if err != nil || marker < 1 {
+			continue
+		}
+
+		index := marker - 1
+		if indices != nil {
+			if marker > len(indices) {
+				continue
+			}
+			index = indices[marker-1]
+		} else if index >= total {
+			continue
+		}
+
+		start := utf8.RuneCountInString(answer[:m[0]])
+		citations = append(citations, Citation{
+			Marker: marker,
+			Index:  index,
+			Span:   TextSpan{Start: start, End: start + utf8.RuneCountInString(answer[m[0]:m[1]])},
+		})
+	}
+	return citations
+}
+
+// CitedClaim 返回引用标记所支撑的回答内容，即标记前的那句话
+func CitedClaim(answer string, citation Citation) string {
+	runes := []rune(answer)
+	if citation.Span.Start > len(runes) {
+		return ""
+	}
+	end := citation.Span.Start
+	start := end
+	for start > 0 {
+		r := runes[start-1]
+		if isSentenceEnd(r) {
+			break
+		}
+		start--
+	}
+	claim := string(runes[start:end])
+	// 去掉同一句话中更早的引用标记
+	return strings.TrimSpace(citationPattern.ReplaceAllString(claim, ""))
+}
+
+// Highlight 在text的span范围内找出与claim内容重合的句子
+// 使用字符二元组重合比例判断，适用于中文和英文，返回的范围相对text
+func Highlight(text string, span TextSpan, claim string) []TextSpan {
+	claimGrams := bigrams([]rune(claim))
+	if len(claimGrams) == 0 {
+		return nil
+	}
+
+	runes := []rune(text)
+	if span.End > len(runes) {
+		span.End = len(runes)
+	}
+	var spans []TextSpan
+	start := span.Start
+	for i := span.Start; i < span.End; i++ {
+		if !isSentenceEnd(runes[i]) && i != span.End-1 {
+			continue
+		}
+		sentence := runes[start : i+1]
+		if grams := bigrams(sentence); len(grams) > 0 {
+			shared := 0
+			for g := range grams {
+				if claimGrams[g] {
+					shared++
+				}
+			}
+			smaller := len(grams)
+			if len(claimGrams) < smaller {
+				smaller = len(claimGrams)
+			}
+			if float64(shared)/float64(smaller) >= highlightThreshold {
+				spans = append(spans, trimSpan(runes, TextSpan{Start: start, End: i + 1}))
+			}
+		}
+		start = i + 1
+	}
+	return spans
+}
+
+// MergeSpans 合并重叠或相邻的文本范围
+func MergeSpans(spans []TextSpan) []TextSpan {
+	if len(spans) <= 1 {
+		return spans
+	}
+	sorted := append([]TextSpan(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	merged := []TextSpan{sorted[0]}
+	for _, s := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if s.Start <= last.End {
+			if s.End > last.End {
+				last.End = s.End
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// WithSourceCitation 返回附加了引用信息的元数据副本
+// 元数据可能与向量数据库共享，不能原地修改
+func WithSourceCitation(meta map[string]interface{}, citation SourceCitation) map[string]interface{} {
+	copied := make(map[string]interface{}, len(meta)+1)
+	for k, v := range meta {
+		copied[k] = v
+	}
+	copied[MetaCitation] = citation
+	return copied
+}
+
+// SourceCitationFromMetadata 从来源文档元数据中读取引用信息
+// 元数据经过JSON缓存后会变为通用的map，这里统一处理
+func SourceCitationFromMetadata(meta map[string]interface{}) (SourceCitation, bool) {
+	switch v := meta[MetaCitation].(type) {
+	case SourceCitation:
+		return v, true
+	case nil:
+		return SourceCitation{}, false
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return SourceCitation{}, false
+		}
+		var citation SourceCitation
+		if err := json.Unmarshal(data, &citation); err != nil {
+			return SourceCitation{}, false
+		}
+		return citation, true
+	}
+}
+
+// isSentenceEnd 判断字符是否为句子结尾
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '。', '！', '？', '；', '!', '?', ';', '.', '\n':
+		return true
+	}
+	return false
+}
+
+// bigrams 计算文本的字符二元组集合，忽略空白和标点
+func bigrams(runes []rune) map[string]bool {
+	var chars []rune
+	for _, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			chars = append(chars, unicode.ToLower(r))
+		}
+	}
+	grams := make(map[string]bool)
+	for i := 0; i+1 < len(chars); i++ {
+		grams[string(chars[i:i+2])] = true
+	}
+	return grams
+}
+
+// trimSpan 去掉范围两端的空白
+func trimSpan(runes []rune, span TextSpan) TextSpan {
+	for span.Start < span.End && unicode.IsSpace(runes[span.Start]) {
+		span.Start++
+	}
+	for span.End > span.Start && unicode.IsSpace(runes[span.End-1]) {
+		span.End--
+	}
+	return span
+}
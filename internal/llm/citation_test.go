@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseCitations 测试解析回答中的引用标记
+func TestParseCitations(t *testing.T) {
+	answer := "向量数据库用于相似度检索[1]。它针对高维向量做了优化【2】[5]。"
+
+	// 上下文未被裁剪时标记直接对应输入下标，超出范围的标记被忽略
+	citations := ParseCitations(answer, nil, 2)
+	require.Len(t, citations, 2)
+	assert.Equal(t, 1, citations[0].Marker)
+	assert.Equal(t, 0, citations[0].Index)
+	assert.Equal(t, TextSpan{Start: 12, End: 15}, citations[0].Span)
+	assert.Equal(t, 2, citations[1].Marker)
+	assert.Equal(t, 1, citations[1].Index)
+
+	// 上下文被裁剪时按实际放入提示词的下标映射
+	citations = ParseCitations(answer, []int{0, 3}, 4)
+	require.Len(t, citations, 2)
+	assert.Equal(t, 3, citations[1].Index)
+
+	assert.Equal(t, "它针对高维向量做了优化", CitedClaim(answer, citations[1]))
+}
+
+// TestHighlight 测试按回答内容高亮来源句子
+func TestHighlight(t *testing.T) {
+	text := "向量数据库是一种数据库系统。它针对高维向量的相似度搜索进行了优化。常见的实现有Faiss。"
+	spans := Highlight(text, TextSpan{End: len([]rune(text))}, "向量数据库针对高维向量的相似度搜索做了优化")
+	require.Len(t, spans, 1)
+	assert.Equal(t, "它针对高维向量的相似度搜索进行了优化。", string([]rune(text)[spans[0].Start:spans[0].End]))
+
+	// 只在实际使用的范围内高亮
+	assert.Empty(t, Highlight(text, TextSpan{End: 14}, "针对高维向量的相似度搜索进行了优化"))
+
+	assert.Equal(t, []TextSpan{{Start: 0, End: 8}, {Start: 10, End: 12}},
+		MergeSpans([]TextSpan{{Start: 10, End: 12}, {Start: 0, End: 5}, {Start: 3, End: 8}}))
+}
+
+// TestSourceCitationMetadata 测试引用信息在元数据中的读写
+func TestSourceCitationMetadata(t *testing.T) {
+	meta := map[string]interface{}{"page": "3"}
+	citation := SourceCitation{Markers: []int{1}, Spans: []TextSpan{{End: 10}}, Highlights: []TextSpan{{Start: 2, End: 6}}}
+	annotated := WithSourceCitation(meta, citation)
+	assert.NotContains(t, meta, MetaCitation, "原元数据不应被修改")
+
+	got, ok := SourceCitationFromMetadata(annotated)
+	require.True(t, ok)
+	assert.Equal(t, citation, got)
+
+	// 经过JSON缓存后仍能读取
+	data, err := json.Marshal(annotated)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	got, ok = SourceCitationFromMetadata(decoded)
+	require.True(t, ok)
+	assert.Equal(t, citation, got)
+
+	_, ok = SourceCitationFromMetadata(meta)
+	assert.False(t, ok)
+}
+
+// TestRAGCitations 测试RAG响应中的引用和上下文范围
+func TestRAGCitations(t *testing.T) {
+	client := NewMockClient(t)
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&Response{Text: "Faiss是常见的实现[2]。"}, nil,
+	)
+
+	rag := NewRAG(client)
+	resp, err := rag.Answer(context.Background(), "向量数据库有哪些实现？", []string{"向量数据库用于检索。", "常见的实现有Faiss。"})
+	require.NoError(t, err)
+	assert.Equal(t, []TextSpan{{End: 10}, {End: 12}}, resp.ContextSpans)
+	require.Len(t, resp.Citations, 1)
+	assert.Equal(t, 1, resp.Citations[0].Index)
+}
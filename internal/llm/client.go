@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -31,6 +32,8 @@ type Config struct {
 	MaxTokens   int           // 最大生成Token数
 	Temperature float32       // 采样温度(0.0-2.0)
 	TopP        float32       // 核采样概率阈值(0.0-1.0)
+
+	SafetySettings map[string]string // 安全过滤设置，类别到阈值的映射（Gemini）
 }
 
 // DefaultConfig 返回默认配置
@@ -105,6 +108,14 @@ func WithTopP(topP float32) Option {
 	}
 }
 
+// WithSafetySettings 设置安全过滤设置
+// 键为安全类别（如HARM_CATEGORY_HARASSMENT），值为拦截阈值（如BLOCK_ONLY_HIGH）
+func WithSafetySettings(settings map[string]string) Option {
+	return func(c *Config) {
+		c.SafetySettings = settings
+	}
+}
+
 // NewConfig 创建一个新的配置并应用选项
 func NewConfig(opts ...Option) *Config {
 	cfg := DefaultConfig()
@@ -125,6 +136,10 @@ type GenerateOptions struct {
 	TopK        *int      // 生成候选集大小
 	Stream      bool      // 是否流式输出
 	Stop        *[]string // 停止序列
+	Cacheable   bool      // 结果是否可缓存（确定性调用）
+	// JSONSchema 要求输出满足该JSON Schema的JSON，支持结构化输出的模型使用原生模式，
+	// 其他模型只能依赖提示词，调用方需要自行校验
+	JSONSchema json.RawMessage
 }
 
 // WithGenerateMaxTokens 设置生成请求的最大Token数
@@ -169,17 +184,28 @@ func WithGenerateStop(stop []string) GenerateOption {
 	}
 }
 
+// WithGenerateJSONSchema 要求生成请求输出满足JSON Schema的JSON
+func WithGenerateJSONSchema(schema json.RawMessage) GenerateOption {
+	return func(o *GenerateOptions) {
+		o.JSONSchema = schema
+	}
+}
+
 // ChatOption 聊天请求的选项
 type ChatOption func(*ChatOptions)
 
 // ChatOptions 聊天请求的选项集合
 type ChatOptions struct {
-    MaxTokens   *int      // 最大生成Token数
-    Temperature *float32  // 采样温度
-    TopP        *float32  // 核采样概率阈值
-    TopK        *int      // 生成候选集大小
-    Stream      bool      // 是否流式输出
-    Stop        *[]string // 停止序列
+	MaxTokens   *int            // 最大生成Token数
+	Temperature *float32        // 采样温度
+	TopP        *float32        // 核采样概率阈值
+	TopK        *int            // 生成候选集大小
+	Stream      bool            // 是否流式输出
+	Stop        *[]string       // 停止序列
+	Cacheable   bool            // 结果是否可缓存（确定性调用）
+	JSONSchema  json.RawMessage // 要求输出满足该JSON Schema的JSON
+	Tools       []Tool          // 可调用的工具
+	ToolChoice  string          // 工具选择方式
 }
 
 // WithChatStop 设置聊天请求的停止序列
@@ -224,6 +250,13 @@ func WithChatStream(stream bool) ChatOption {
 	}
 }
 
+// WithChatJSONSchema 要求聊天请求输出满足JSON Schema的JSON
+func WithChatJSONSchema(schema json.RawMessage) ChatOption {
+	return func(o *ChatOptions) {
+		o.JSONSchema = schema
+	}
+}
+
 // Factory 大模型客户端工厂函数类型
 type Factory func(opts ...Option) (Client, error)
 
@@ -357,6 +390,9 @@ func (c *PythonClient) Chat(ctx context.Context, messages []Message, options ...
 	for _, opt := range options {
 		opt(opts)
 	}
+	if err := unsupportedTools(opts, c.modelName); err != nil {
+		return nil, err
+	}
 
 	// 转换消息格式
 	pyMessages := make([]pyprovider.Message, len(messages))
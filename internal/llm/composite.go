@@ -0,0 +1,289 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// breakerState 熔断器状态
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // 正常放行
+	breakerOpen                         // 熔断中，直接跳过
+	breakerHalfOpen                     // 冷却结束，放行一次试探请求
+)
+
+// String 返回状态名称
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker 单个提供商的熔断器
+// 连续失败达到阈值后熔断，冷却期结束后进入半开状态放行一次试探请求
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow 判断是否放行请求
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		// 半开状态只允许一个试探请求
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success 记录成功，关闭熔断器
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure 记录失败，必要时打开熔断器
+func (b *circuitBreaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
+
+// current 返回当前状态
+func (b *circuitBreaker) current() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// CompositeConfig 组合客户端配置
+type CompositeConfig struct {
+	FailureThreshold int            // 连续失败多少次后熔断
+	Cooldown         time.Duration  // 熔断后的冷却时间
+	Timeout          time.Duration  // 单个提供商的请求超时，0表示不单独设置
+	Logger           *logrus.Logger // 日志记录器
+}
+
+// CompositeOption 组合客户端配置选项
+type CompositeOption func(*CompositeConfig)
+
+// WithFailureThreshold 设置熔断的连续失败阈值
+func WithFailureThreshold(n int) CompositeOption {
+	return func(c *CompositeConfig) {
+		if n > 0 {
+			c.FailureThreshold = n
+		}
+	}
+}
+
+// WithCooldown 设置熔断冷却时间
+func WithCooldown(d time.Duration) CompositeOption {
+	return func(c *CompositeConfig) {
+		if d > 0 {
+			c.Cooldown = d
+		}
+	}
+}
+
+// WithProviderTimeout 设置单个提供商的请求超时
+func WithProviderTimeout(d time.Duration) CompositeOption {
+	return func(c *CompositeConfig) {
+		c.Timeout = d
+	}
+}
+
+// WithCompositeLogger 设置日志记录器
+func WithCompositeLogger(logger *logrus.Logger) CompositeOption {
+	return func(c *CompositeConfig) {
+		if logger != nil {
+			c.Logger = logger
+		}
+	}
+}
+
+// ErrAllProvidersFailed 所有提供商都不可用
+var ErrAllProvidersFailed = NewLLMError(ErrCodeServerError, "all llm providers failed")
+
+// CompositeClient 按顺序故障转移的组合客户端
+// 主提供商出错或超时时自动切换到下一个提供商，每个提供商有独立的熔断器
+type CompositeClient struct {
+	clients  []Client
+	breakers []*circuitBreaker
+	config   CompositeConfig
+	now      func() time.Time
+}
+
+// NewCompositeClient 创建组合客户端，clients按优先级排列
+func NewCompositeClient(clients []Client, opts ...CompositeOption) (*CompositeClient, error) {
+	if len(clients) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "composite client requires at least one provider")
+	}
+
+	cfg := CompositeConfig{
+		FailureThreshold: 3,
+		Cooldown:         30 * time.Second,
+		Logger:           logrus.StandardLogger(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	breakers := make([]*circuitBreaker, len(clients))
+	for i := range clients {
+		breakers[i] = &circuitBreaker{
+			threshold: cfg.FailureThreshold,
+			cooldown:  cfg.Cooldown,
+		}
+	}
+
+	return &CompositeClient{
+		clients:  clients,
+		breakers: breakers,
+		config:   cfg,
+		now:      time.Now,
+	}, nil
+}
+
+// Name 返回所有提供商的模型名称
+func (c *CompositeClient) Name() string {
+	names := make([]string, len(c.clients))
+	for i, client := range c.clients {
+		names[i] = client.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Generate 根据提示词生成回答，失败时按顺序故障转移
+func (c *CompositeClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	return c.call(ctx, "generate", func(ctx context.Context, client Client) (*Response, error) {
+		return client.Generate(ctx, prompt, options...)
+	})
+}
+
+// Chat 进行多轮对话，失败时按顺序故障转移
+func (c *CompositeClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	return c.call(ctx, "chat", func(ctx context.Context, client Client) (*Response, error) {
+		return client.Chat(ctx, messages, options...)
+	})
+}
+
+// ProviderStates 返回各提供商的熔断器状态，键为模型名称
+func (c *CompositeClient) ProviderStates() map[string]string {
+	states := make(map[string]string, len(c.clients))
+	for i, client := range c.clients {
+		states[client.Name()] = c.breakers[i].current().String()
+	}
+	return states
+}
+
+// call 按优先级依次调用提供商
+func (c *CompositeClient) call(ctx context.Context, op string, fn func(context.Context, Client) (*Response, error)) (*Response, error) {
+	var errs []string
+
+	for i, client := range c.clients {
+		// 调用方已取消，不再尝试后续提供商
+		if err := ctx.Err(); err != nil {
+			return nil, NewLLMError(ErrCodeTimeout, err.Error())
+		}
+
+		breaker := c.breakers[i]
+		if !breaker.allow(c.now()) {
+			errs = append(errs, fmt.Sprintf("%s: circuit open", client.Name()))
+			continue
+		}
+
+		resp, err := c.invoke(ctx, client, fn)
+		if err == nil {
+			breaker.success()
+			c.config.Logger.WithFields(logrus.Fields{
+				"provider": client.Name(),
+				"op":       op,
+				"fallback": i > 0,
+			}).Info("LLM request served")
+			return resp, nil
+		}
+
+		// 请求本身有问题时换提供商也无济于事，直接返回
+		if !isFailoverError(err) {
+			breaker.success()
+			return nil, err
+		}
+
+		breaker.failure(c.now())
+		errs = append(errs, fmt.Sprintf("%s: %v", client.Name(), err))
+		c.config.Logger.WithFields(logrus.Fields{
+			"provider": client.Name(),
+			"op":       op,
+			"breaker":  breaker.current().String(),
+		}).WithError(err).Warn("LLM provider failed, trying next provider")
+	}
+
+	return nil, NewLLMError(ErrAllProvidersFailed.Code, ErrAllProvidersFailed.Message+": "+strings.Join(errs, "; "))
+}
+
+// invoke 调用单个提供商，按需设置超时
+func (c *CompositeClient) invoke(ctx context.Context, client Client, fn func(context.Context, Client) (*Response, error)) (*Response, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+	return fn(ctx, client)
+}
+
+// isFailoverError 判断错误是否应该触发故障转移
+// 提示词为空、参数错误、内容过滤、上下文过长属于请求本身的问题，不切换提供商
+func isFailoverError(err error) bool {
+	var llmErr LLMError
+	if errors.As(err, &llmErr) {
+		switch llmErr.Code {
+		case ErrCodeEmptyPrompt, ErrCodeInvalidRequest, ErrCodeContentFilter, ErrCodeContextTooLong:
+			return false
+		}
+	}
+	return true
+}
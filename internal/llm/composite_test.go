@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestComposite 创建用于测试的组合客户端，日志静默
+func newTestComposite(t *testing.T, clients []Client, opts ...CompositeOption) *CompositeClient {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	composite, err := NewCompositeClient(clients, append(opts, WithCompositeLogger(logger))...)
+	require.NoError(t, err)
+	return composite
+}
+
+// TestCompositeClientFailover 测试主提供商失败时切换到备用提供商
+func TestCompositeClientFailover(t *testing.T) {
+	primary := NewMockClient(t)
+	secondary := NewMockClient(t)
+	primary.EXPECT().Name().Return("primary").Maybe()
+	secondary.EXPECT().Name().Return("secondary").Maybe()
+
+	primary.EXPECT().Generate(mock.Anything, "问题").Return(nil, NewLLMError(ErrCodeServerError, ErrMsgServerError))
+	secondary.EXPECT().Generate(mock.Anything, "问题").Return(&Response{Text: "备用回答"}, nil)
+
+	composite := newTestComposite(t, []Client{primary, secondary})
+	resp, err := composite.Generate(context.Background(), "问题")
+	require.NoError(t, err)
+	assert.Equal(t, "备用回答", resp.Text)
+}
+
+// TestCompositeClientNoFailoverOnBadRequest 测试请求错误不触发故障转移
+func TestCompositeClientNoFailoverOnBadRequest(t *testing.T) {
+	primary := NewMockClient(t)
+	secondary := NewMockClient(t)
+	primary.EXPECT().Name().Return("primary").Maybe()
+	secondary.EXPECT().Name().Return("secondary").Maybe()
+
+	primary.EXPECT().Generate(mock.Anything, "").Return(nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt))
+
+	composite := newTestComposite(t, []Client{primary, secondary})
+	_, err := composite.Generate(context.Background(), "")
+	require.Error(t, err)
+
+	var llmErr LLMError
+	require.True(t, errors.As(err, &llmErr))
+	assert.Equal(t, ErrCodeEmptyPrompt, llmErr.Code)
+}
+
+// TestCompositeClientCircuitBreaker 测试熔断与冷却后恢复
+func TestCompositeClientCircuitBreaker(t *testing.T) {
+	primary := NewMockClient(t)
+	secondary := NewMockClient(t)
+	primary.EXPECT().Name().Return("primary").Maybe()
+	secondary.EXPECT().Name().Return("secondary").Maybe()
+
+	composite := newTestComposite(t, []Client{primary, secondary},
+		WithFailureThreshold(2),
+		WithCooldown(time.Minute),
+	)
+	now := time.Now()
+	composite.now = func() time.Time { return now }
+
+	// 主提供商连续失败两次后熔断
+	primary.EXPECT().Generate(mock.Anything, mock.Anything).Return(nil, errors.New("connection reset")).Times(2)
+	secondary.EXPECT().Generate(mock.Anything, mock.Anything).Return(&Response{Text: "ok"}, nil).Times(3)
+
+	for i := 0; i < 3; i++ {
+		_, err := composite.Generate(context.Background(), "问题")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "open", composite.ProviderStates()["primary"])
+
+	// 冷却结束后放行试探请求，成功则恢复
+	now = now.Add(2 * time.Minute)
+	primary.EXPECT().Generate(mock.Anything, mock.Anything).Return(&Response{Text: "primary"}, nil).Once()
+
+	resp, err := composite.Generate(context.Background(), "问题")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", resp.Text)
+	assert.Equal(t, "closed", composite.ProviderStates()["primary"])
+}
+
+// TestCompositeClientAllFailed 测试所有提供商都失败
+func TestCompositeClientAllFailed(t *testing.T) {
+	primary := NewMockClient(t)
+	primary.EXPECT().Name().Return("primary").Maybe()
+	primary.EXPECT().Chat(mock.Anything, mock.Anything).Return(nil, NewLLMError(ErrCodeTimeout, ErrMsgTimeout))
+
+	composite := newTestComposite(t, []Client{primary})
+	_, err := composite.Chat(context.Background(), []Message{{Role: RoleUser, Content: "你好"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all llm providers failed")
+}
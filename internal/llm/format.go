@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 回答格式
+const (
+	// FormatMarkdown 使用Markdown组织回答，如标题、列表和代码块
+	FormatMarkdown = "markdown"
+	// FormatPlain 纯文本回答，不含Markdown标记，适合短信、语音等渠道
+	FormatPlain = "plain"
+	// FormatJSON 回答为满足调用方JSON Schema的JSON
+	FormatJSON = "json"
+)
+
+// AnswerFormat 回答的输出格式，零值表示不限制格式
+type AnswerFormat struct {
+	Type   string          // markdown、plain或json，为空时不限制格式
+	Schema json.RawMessage // Type为json时回答需要满足的JSON Schema
+}
+
+// Validate 检查回答格式是否有效
+func (f AnswerFormat) Validate() error {
+	switch f.Type {
+	case "", FormatMarkdown, FormatPlain:
+		if len(f.Schema) > 0 {
+			return fmt.Errorf("schema is only supported for %s format", FormatJSON)
+		}
+		return nil
+	case FormatJSON:
+		if len(f.Schema) == 0 {
+			return fmt.Errorf("schema is required for %s format", FormatJSON)
+		}
+		return ValidateJSONSchema(f.Schema)
+	default:
+		return fmt.Errorf("format must be %s, %s or %s, got %q", FormatMarkdown, FormatPlain, FormatJSON, f.Type)
+	}
+}
+
+// CacheKey 返回区分回答格式的缓存键片段，不限制格式时为空
+func (f AnswerFormat) CacheKey() string {
+	if f.Type == "" {
+		return ""
+	}
+	if len(f.Schema) == 0 {
+		return "f_" + f.Type
+	}
+	sum := sha256.Sum256(f.Schema)
+	return "f_" + f.Type + "_" + hex.EncodeToString(sum[:8])
+}
+
+// answerFormatKey 上下文中保存回答格式的键
+type answerFormatKey struct{}
+
+// WithAnswerFormat 返回带有回答格式的上下文
+// RAG生成回答时按格式调整提示词，JSON格式使用结构化输出并校验
+func WithAnswerFormat(ctx context.Context, format AnswerFormat) context.Context {
+	if format.Type == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, answerFormatKey{}, format)
+}
+
+// AnswerFormatFromContext 读取上下文中的回答格式，未设置时返回零值
+func AnswerFormatFromContext(ctx context.Context) AnswerFormat {
+	format, _ := ctx.Value(answerFormatKey{}).(AnswerFormat)
+	return format
+}
+
+// 回答格式的提示词后缀
+const (
+	markdownFormatPrompt = "\n\n请使用Markdown格式组织回答，可以使用标题、列表、加粗和代码块。"
+	plainFormatPrompt    = "\n\n请使用纯文本回答，不要使用Markdown标记（如#、*、`、表格），分点时用“1.”这样的序号。"
+)
+
+// formatPrompt 按回答格式在提示词末尾附加要求，JSON格式由GenerateJSON附加Schema
+func formatPrompt(format AnswerFormat, prompt string) string {
+	switch format.Type {
+	case FormatMarkdown:
+		return prompt + markdownFormatPrompt
+	case FormatPlain:
+		return prompt + plainFormatPrompt
+	}
+	return prompt
+}
+
+var (
+	markdownFence    = regexp.MustCompile("(?m)^\\s*```.*$\\n?")
+	markdownHeading  = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	markdownBullet   = regexp.MustCompile(`(?m)^(\s*)[*+]\s+`)
+	markdownEmphasis = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	markdownCode     = regexp.MustCompile("`([^`]+)`")
+	markdownLink     = regexp.MustCompile(`!?\[([^\]]+)\]\([^)]+\)`)
+)
+
+// StripMarkdown 去掉回答中常见的Markdown标记，模型没有遵守纯文本要求时兜底
+// 引用标注如[1]不是链接，会被保留
+func StripMarkdown(text string) string {
+	text = markdownFence.ReplaceAllString(text, "")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownBullet.ReplaceAllString(text, "$1- ")
+	text = markdownEmphasis.ReplaceAllString(text, "$2")
+	text = markdownCode.ReplaceAllString(text, "$1")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(text)
+}
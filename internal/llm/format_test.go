@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnswerSchema 测试用的回答Schema
+const testAnswerSchema = `{
+	"type": "object",
+	"properties": {
+		"summary": {"type": "string", "minLength": 1},
+		"steps": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+		"risk": {"type": "string", "enum": ["low", "high"]}
+	},
+	"required": ["summary", "steps"],
+	"additionalProperties": false
+}`
+
+// TestAnswerFormatValidate 测试回答格式和Schema的校验
+func TestAnswerFormatValidate(t *testing.T) {
+	assert.NoError(t, AnswerFormat{}.Validate())
+	assert.NoError(t, AnswerFormat{Type: FormatPlain}.Validate())
+	assert.NoError(t, AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(testAnswerSchema)}.Validate())
+
+	assert.Error(t, AnswerFormat{Type: "html"}.Validate())
+	assert.Error(t, AnswerFormat{Type: FormatJSON}.Validate())
+	assert.Error(t, AnswerFormat{Type: FormatMarkdown, Schema: json.RawMessage(testAnswerSchema)}.Validate())
+	assert.Error(t, AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(`{"type":"text"}`)}.Validate())
+	assert.Error(t, AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(`not json`)}.Validate())
+
+	// 不同Schema的缓存键不同
+	a := AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(testAnswerSchema)}.CacheKey()
+	b := AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(`{"type":"string"}`)}.CacheKey()
+	assert.NotEqual(t, a, b)
+	assert.Empty(t, AnswerFormat{}.CacheKey())
+}
+
+// TestJSONSchemaValidate 测试JSON Schema校验的错误信息带有值的路径
+func TestJSONSchemaValidate(t *testing.T) {
+	var s jsonSchema
+	require.NoError(t, json.Unmarshal([]byte(testAnswerSchema), &s))
+
+	assert.NoError(t, s.validate(json.RawMessage(`{"summary":"重启服务","steps":["停止","启动"],"risk":"low"}`)))
+	cases := map[string]string{
+		`{"steps":["a"]}`:                               `$: missing required property "summary"`,
+		`{"summary":"a","steps":[]}`:                    `$.steps: expected at least 1 items, got 0`,
+		`{"summary":"a","steps":[1]}`:                   `$.steps[0]: expected string, got integer`,
+		`{"summary":"a","steps":["a"],"risk":"medium"}`: `$.risk: value is not one of the allowed values`,
+		`{"summary":"a","steps":["a"],"extra":true}`:    `$: unexpected property "extra"`,
+		`["a"]`: `$: expected object, got array`,
+	}
+	for data, want := range cases {
+		assert.EqualError(t, s.validate(json.RawMessage(data)), want, data)
+	}
+}
+
+// TestGenerateJSON 测试结构化输出不合法时把错误反馈给模型重试
+func TestGenerateJSON(t *testing.T) {
+	schema := json.RawMessage(testAnswerSchema)
+	outputs := []string{
+		"好的，答案如下",
+		`{"summary":"重启服务"}`,
+		"```json\n{\"summary\": \"重启服务\", \"steps\": [\"停止\", \"启动\"]}\n```",
+	}
+	var prompts []string
+	client := NewMockClient(t)
+	client.EXPECT().Generate(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+			opts := &GenerateOptions{}
+			for _, opt := range options {
+				opt(opts)
+			}
+			assert.JSONEq(t, testAnswerSchema, string(opts.JSONSchema))
+			prompts = append(prompts, prompt)
+			return &Response{Text: outputs[len(prompts)-1]}, nil
+		}).Times(3)
+
+	data, _, err := GenerateJSON(context.Background(), client, "如何重启服务？", schema, 2)
+	require.NoError(t, err)
+	assert.Equal(t, `{"summary":"重启服务","steps":["停止","启动"]}`, string(data))
+
+	require.Len(t, prompts, 3)
+	assert.Contains(t, prompts[0], `"additionalProperties": false`)
+	assert.Contains(t, prompts[1], "output is not json")
+	assert.Contains(t, prompts[2], `missing required property "steps"`)
+
+	// 重试用尽后返回错误
+	client = NewMockClient(t)
+	client.EXPECT().Generate(mock.Anything, mock.Anything, mock.Anything).
+		Return(&Response{Text: `{"summary":""}`}, nil).Times(2)
+	_, _, err = GenerateJSON(context.Background(), client, "如何重启服务？", schema, 1)
+	assert.ErrorIs(t, err, ErrInvalidStructuredOutput)
+}
+
+// TestRAGAnswerFormat 测试RAG按上下文中的回答格式生成回答
+func TestRAGAnswerFormat(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "纯文本")
+	}), mock.Anything, mock.Anything).
+		Return(&Response{Text: "## 步骤\n**先**停止服务，再执行`systemctl start`[1]"}, nil).Once()
+
+	rag := NewRAG(client)
+	ctx := WithAnswerFormat(context.Background(), AnswerFormat{Type: FormatPlain})
+	resp, err := rag.Answer(ctx, "如何重启服务？", []string{"停止服务后再启动"})
+	require.NoError(t, err)
+	assert.Equal(t, "步骤\n先停止服务，再执行systemctl start[1]", resp.Answer)
+
+	client.EXPECT().Generate(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&Response{Text: `{"summary":"重启服务","steps":["停止","启动"]}`}, nil).Once()
+	ctx = WithAnswerFormat(context.Background(), AnswerFormat{Type: FormatJSON, Schema: json.RawMessage(testAnswerSchema)})
+	resp, err = rag.Answer(ctx, "如何重启服务？", []string{"停止服务后再启动"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"summary":"重启服务","steps":["停止","启动"]}`, resp.Answer)
+}
+
+// TestAnthropicStructuredOutput 测试Anthropic通过强制调用工具实现结构化输出
+func TestAnthropicStructuredOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AnthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Tools, 1)
+		assert.JSONEq(t, `{"type":"string"}`, string(req.Tools[0].InputSchema))
+		require.NotNil(t, req.ToolChoice)
+		assert.Equal(t, "tool", req.ToolChoice.Type)
+		assert.Equal(t, req.Tools[0].Name, req.ToolChoice.Name)
+
+		_ = json.NewEncoder(w).Encode(AnthropicResponse{
+			Model: ModelClaudeHaiku,
+			Content: []AnthropicContentBlock{
+				{Type: "text", Text: "我来整理一下"},
+				{Type: "tool_use", Name: req.Tools[0].Name, Input: json.RawMessage(`"重启服务"`)},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewAnthropicClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	resp, err := client.Generate(context.Background(), "如何重启服务？", WithGenerateJSONSchema(json.RawMessage(`{"type":"string"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, `"重启服务"`, resp.Text)
+}
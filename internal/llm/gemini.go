@@ -0,0 +1,240 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GeminiBaseURL Gemini API默认地址
+const GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiClient Google Gemini客户端
+type GeminiClient struct {
+	config *Config
+	http   *httpDoer
+}
+
+// NewGeminiClient 创建Google Gemini客户端
+func NewGeminiClient(opts ...Option) (Client, error) {
+	// 先设置Gemini的默认值，再应用用户选项
+	defaults := []Option{
+		WithBaseURL(GeminiBaseURL),
+		WithModel(ModelGeminiFlash),
+	}
+	cfg := NewConfig(append(defaults, opts...)...)
+
+	if cfg.APIKey == "" {
+		return nil, NewLLMError(ErrCodeInvalidAPIKey, ErrMsgInvalidAPIKey)
+	}
+
+	return &GeminiClient{
+		config: cfg,
+		http:   newHTTPDoer(cfg),
+	}, nil
+}
+
+// Name 返回模型名称
+func (c *GeminiClient) Name() string {
+	return c.config.Model
+}
+
+// Generate 根据提示词生成回答
+func (c *GeminiClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	if prompt == "" {
+		return nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt)
+	}
+
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	return c.Chat(ctx, messages, func(o *ChatOptions) {
+		o.MaxTokens = opts.MaxTokens
+		o.Temperature = opts.Temperature
+		o.TopP = opts.TopP
+		o.TopK = opts.TopK
+		o.Stop = opts.Stop
+		o.JSONSchema = opts.JSONSchema
+	})
+}
+
+// Chat 进行多轮对话
+// 系统消息转换为systemInstruction，助手消息的角色转换为model
+func (c *GeminiClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages cannot be empty")
+	}
+
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if err := unsupportedTools(opts, c.config.Model); err != nil {
+		return nil, err
+	}
+
+	req := GeminiRequest{}
+	var system []GeminiPart
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			system = append(system, GeminiPart{Text: msg.Content})
+		case RoleAssistant:
+			req.Contents = append(req.Contents, GeminiContent{Role: "model", Parts: []GeminiPart{{Text: msg.Content}}})
+		default:
+			req.Contents = append(req.Contents, GeminiContent{Role: "user", Parts: []GeminiPart{{Text: msg.Content}}})
+		}
+	}
+	if len(system) > 0 {
+		req.SystemInstruction = &GeminiContent{Parts: system}
+	}
+
+	if len(req.Contents) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages must contain at least one non-system message")
+	}
+	return c.send(ctx, req, opts)
+}
+
+// DescribeImage 根据提示词和图片生成回答，图片作为内联数据放在提示词之前
+func (c *GeminiClient) DescribeImage(ctx context.Context, prompt string, image Image, options ...GenerateOption) (*Response, error) {
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	req := GeminiRequest{Contents: []GeminiContent{{Role: "user", Parts: []GeminiPart{
+		{InlineData: &GeminiInlineData{MimeType: image.MimeType, Data: image.base64Data()}},
+		{Text: prompt},
+	}}}}
+	return c.send(ctx, req, &ChatOptions{
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		TopK:        opts.TopK,
+		Stop:        opts.Stop,
+	})
+}
+
+// send 应用请求级参数并发送请求
+func (c *GeminiClient) send(ctx context.Context, req GeminiRequest, opts *ChatOptions) (*Response, error) {
+	// 应用请求级参数，未设置时使用客户端配置
+	gen := &GeminiGenerationConfig{}
+	if opts.MaxTokens != nil {
+		gen.MaxOutputTokens = opts.MaxTokens
+	} else if c.config.MaxTokens > 0 {
+		maxTokens := c.config.MaxTokens
+		gen.MaxOutputTokens = &maxTokens
+	}
+	if opts.Temperature != nil {
+		gen.Temperature = opts.Temperature
+	} else if c.config.Temperature > 0 {
+		temp := c.config.Temperature
+		gen.Temperature = &temp
+	}
+	if opts.TopP != nil {
+		gen.TopP = opts.TopP
+	} else if c.config.TopP > 0 {
+		topP := c.config.TopP
+		gen.TopP = &topP
+	}
+	if opts.TopK != nil {
+		gen.TopK = opts.TopK
+	}
+	if opts.Stop != nil {
+		gen.StopSequences = *opts.Stop
+	}
+	if len(opts.JSONSchema) > 0 {
+		gen.ResponseMimeType = "application/json"
+		gen.ResponseJSONSchema = opts.JSONSchema
+	}
+	req.GenerationConfig = gen
+	req.SafetySettings = buildSafetySettings(c.config.SafetySettings)
+
+	headers := map[string]string{
+		"x-goog-api-key": c.config.APIKey,
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", strings.TrimRight(c.config.BaseURL, "/"), c.config.Model)
+	data, err := c.http.postJSON(ctx, url, headers, req, parseGeminiError)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp GeminiResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, NewLLMError(ErrCodeServerError, fmt.Sprintf("failed to parse response: %v", err))
+	}
+
+	// 提示词被安全策略拦截时没有候选结果
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		return nil, NewLLMError(ErrCodeContentFilter, fmt.Sprintf("%s: %s", ErrMsgContentFilter, resp.PromptFeedback.BlockReason))
+	}
+	if len(resp.Candidates) == 0 {
+		return nil, NewLLMError(ErrCodeServerError, "no candidates in response")
+	}
+
+	candidate := resp.Candidates[0]
+	if candidate.FinishReason == "SAFETY" {
+		return nil, NewLLMError(ErrCodeContentFilter, ErrMsgContentFilter)
+	}
+
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		text.WriteString(part.Text)
+	}
+
+	modelName := resp.ModelVersion
+	if modelName == "" {
+		modelName = c.config.Model
+	}
+
+	return &Response{
+		Text:       text.String(),
+		Messages:   []Message{{Role: RoleAssistant, Content: text.String()}},
+		TokenCount: resp.UsageMetadata.TotalTokenCount,
+		ModelName:  modelName,
+		FinishTime: time.Now(),
+	}, nil
+}
+
+// buildSafetySettings 将安全设置映射转换为请求结构
+// 配置文件中的键可能被转为小写，这里统一转为大写
+func buildSafetySettings(settings map[string]string) []GeminiSafetySetting {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	result := make([]GeminiSafetySetting, 0, len(settings))
+	for category, threshold := range settings {
+		result = append(result, GeminiSafetySetting{
+			Category:  strings.ToUpper(category),
+			Threshold: strings.ToUpper(threshold),
+		})
+	}
+
+	// 保证请求内容稳定
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Category < result[j].Category
+	})
+	return result
+}
+
+// parseGeminiError 从错误响应中提取错误信息
+func parseGeminiError(data []byte) string {
+	var e GeminiError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return ""
+	}
+	return e.Error.Message
+}
+
+// 在包初始化时注册Gemini客户端
+func init() {
+	RegisterClient("gemini", NewGeminiClient)
+}
@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeminiClientChat 测试Gemini客户端的请求转换和响应解析
+func TestGeminiClientChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/"+ModelGeminiFlash+":generateContent", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+
+		var req GeminiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NotNil(t, req.SystemInstruction)
+		require.Len(t, req.Contents, 2)
+		assert.Equal(t, "model", req.Contents[0].Role)
+		require.Len(t, req.SafetySettings, 1)
+		assert.Equal(t, "HARM_CATEGORY_HARASSMENT", req.SafetySettings[0].Category)
+		assert.Equal(t, "BLOCK_ONLY_HIGH", req.SafetySettings[0].Threshold)
+
+		_ = json.NewEncoder(w).Encode(GeminiResponse{
+			Candidates: []GeminiCandidate{{
+				Content:      GeminiContent{Role: "model", Parts: []GeminiPart{{Text: "回答"}}},
+				FinishReason: "STOP",
+			}},
+			UsageMetadata: GeminiUsage{TotalTokenCount: 8},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient("gemini",
+		WithAPIKey("test-key"),
+		WithBaseURL(server.URL),
+		WithSafetySettings(map[string]string{"harm_category_harassment": "block_only_high"}),
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Chat(context.Background(), []Message{
+		{Role: RoleSystem, Content: "系统提示"},
+		{Role: RoleAssistant, Content: "之前的回答"},
+		{Role: RoleUser, Content: "问题"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "回答", resp.Text)
+	assert.Equal(t, 8, resp.TokenCount)
+	assert.Equal(t, ModelGeminiFlash, resp.ModelName)
+}
+
+// TestGeminiClientBlocked 测试提示词被安全策略拦截
+func TestGeminiClientBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"promptFeedback":{"blockReason":"SAFETY"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewGeminiClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	_, err = client.Generate(context.Background(), "问题")
+	require.Error(t, err)
+	llmErr, ok := err.(LLMError)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeContentFilter, llmErr.Code)
+}
@@ -0,0 +1,72 @@
+package llm
+
+import "context"
+
+// maxTemperature 采样温度的上限，各提供商接受的范围都不超过2
+const maxTemperature = 2
+
+// GenerationParams 单次请求覆盖的生成参数，零值字段使用服务端默认值
+type GenerationParams struct {
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // 最大生成token数，为0时使用默认值
+	Temperature *float32 `json:"temperature,omitempty"` // 采样温度，为空时使用默认值
+}
+
+// IsZero 是否未覆盖任何参数
+func (p GenerationParams) IsZero() bool {
+	return p.MaxTokens <= 0 && p.Temperature == nil
+}
+
+// Resolve 返回实际使用的最大生成token数和采样温度，未覆盖的参数使用默认值
+func (p GenerationParams) Resolve(defaultMaxTokens int, defaultTemperature float32) (int, float32) {
+	maxTokens, temperature := defaultMaxTokens, defaultTemperature
+	if p.MaxTokens > 0 {
+		maxTokens = p.MaxTokens
+	}
+	if p.Temperature != nil {
+		temperature = *p.Temperature
+	}
+	return maxTokens, temperature
+}
+
+// GenerationLimits 请求可以覆盖的生成参数上限，由服务端配置
+type GenerationLimits struct {
+	MaxTokens      int     // 最大生成token数的上限，为0时不限制
+	MaxTemperature float32 // 采样温度的上限，为0时使用2
+}
+
+// Clamp 把请求覆盖的生成参数裁剪到上限以内
+func (l GenerationLimits) Clamp(p GenerationParams) GenerationParams {
+	if p.MaxTokens < 0 {
+		p.MaxTokens = 0
+	}
+	if l.MaxTokens > 0 && p.MaxTokens > l.MaxTokens {
+		p.MaxTokens = l.MaxTokens
+	}
+	if p.Temperature != nil {
+		upper := l.MaxTemperature
+		if upper <= 0 {
+			upper = maxTemperature
+		}
+		temperature := min(max(*p.Temperature, 0), upper)
+		p.Temperature = &temperature
+	}
+	return p
+}
+
+// generationParamsKey 上下文中保存生成参数的键
+type generationParamsKey struct{}
+
+// WithGenerationParams 返回带有单次请求生成参数的上下文
+// RAG生成回答时按上限裁剪后使用，未覆盖的参数使用RAG配置
+func WithGenerationParams(ctx context.Context, params GenerationParams) context.Context {
+	if params.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, generationParamsKey{}, params)
+}
+
+// GenerationParamsFromContext 读取上下文中的生成参数，未设置时返回零值
+func GenerationParamsFromContext(ctx context.Context) GenerationParams {
+	params, _ := ctx.Value(generationParamsKey{}).(GenerationParams)
+	return params
+}
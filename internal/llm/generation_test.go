@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGenerationLimitsClamp 测试请求覆盖的生成参数按服务端上限裁剪
+func TestGenerationLimitsClamp(t *testing.T) {
+	limits := GenerationLimits{MaxTokens: 2000, MaxTemperature: 1}
+	hot, cold := float32(1.8), float32(-0.5)
+
+	p := limits.Clamp(GenerationParams{MaxTokens: 8000, Temperature: &hot})
+	assert.Equal(t, 2000, p.MaxTokens)
+	assert.Equal(t, float32(1), *p.Temperature)
+	assert.Equal(t, float32(1.8), hot, "clamp should not modify the caller's value")
+
+	p = limits.Clamp(GenerationParams{MaxTokens: 500, Temperature: &cold})
+	assert.Equal(t, 500, p.MaxTokens)
+	assert.Equal(t, float32(0), *p.Temperature)
+
+	// 未设置上限时温度不超过2，token数不限制
+	p = GenerationLimits{}.Clamp(GenerationParams{MaxTokens: 8000, Temperature: &hot})
+	assert.Equal(t, 8000, p.MaxTokens)
+	assert.Equal(t, hot, *p.Temperature)
+
+	maxTokens, temperature := GenerationParams{}.Resolve(1000, 0.7)
+	assert.Equal(t, 1000, maxTokens)
+	assert.Equal(t, float32(0.7), temperature)
+	maxTokens, temperature = p.Resolve(1000, 0.7)
+	assert.Equal(t, 8000, maxTokens)
+	assert.Equal(t, hot, temperature)
+}
+
+// TestRAGGenerationParams 测试RAG使用上下文中裁剪后的生成参数
+func TestRAGGenerationParams(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().Generate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+			opts := &GenerateOptions{}
+			for _, opt := range options {
+				opt(opts)
+			}
+			require.NotNil(t, opts.MaxTokens)
+			require.NotNil(t, opts.Temperature)
+			assert.Equal(t, 1024, *opts.MaxTokens)
+			assert.Equal(t, float32(0.5), *opts.Temperature)
+			return &Response{Text: "先停止服务再启动[1]"}, nil
+		}).Once()
+
+	rag := NewRAG(client, WithGenerationLimits(GenerationLimits{MaxTokens: 1024, MaxTemperature: 0.5}))
+	temperature := float32(1.5)
+	ctx := WithGenerationParams(context.Background(), GenerationParams{MaxTokens: 4096, Temperature: &temperature})
+	resp, err := rag.Answer(ctx, "如何重启服务？", []string{"停止服务后再启动"})
+	require.NoError(t, err)
+	assert.Equal(t, "先停止服务再启动[1]", resp.Answer)
+}
@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrBudgetExceeded 单个请求的生成预算已用尽
+var ErrBudgetExceeded = errors.New("request generation budget exceeded")
+
+// RequestLimits 单个请求的生成预算，0表示不限制
+// 多路查询、查询改写、工具调用等功能在一次请求中会多次调用大模型，
+// 预算保证单个请求的花费有上限
+type RequestLimits struct {
+	MaxCalls     int // 最多调用大模型的次数
+	MaxToolSteps int // 最多执行的工具步骤数
+	MaxTokens    int // 最多消耗的token总数（提示词加回答）
+}
+
+// IsZero 是否未设置任何限制
+func (l RequestLimits) IsZero() bool {
+	return l.MaxCalls <= 0 && l.MaxToolSteps <= 0 && l.MaxTokens <= 0
+}
+
+// BudgetUsage 预算使用情况
+type BudgetUsage struct {
+	Calls     int // 已调用次数
+	ToolSteps int // 已执行的工具步骤数
+	Tokens    int // 已消耗的token数
+}
+
+// RequestBudget 单个请求的生成预算
+// 软限制：每次调用前检查，已经发出的调用不会被中断，因此token数可能略超过上限，
+// 但超限后的后续调用会被拒绝。可在多个goroutine间共享
+type RequestBudget struct {
+	limits RequestLimits
+
+	mu    sync.Mutex
+	usage BudgetUsage
+}
+
+// NewRequestBudget 创建请求预算
+func NewRequestBudget(limits RequestLimits) *RequestBudget {
+	return &RequestBudget{limits: limits}
+}
+
+// Limits 返回预算上限
+func (b *RequestBudget) Limits() RequestLimits {
+	return b.limits
+}
+
+// Usage 返回当前使用情况
+func (b *RequestBudget) Usage() BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.usage
+}
+
+// BeginCall 在调用大模型前占用一次调用额度，超出预算时返回ErrBudgetExceeded
+func (b *RequestBudget) BeginCall() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits.MaxCalls > 0 && b.usage.Calls >= b.limits.MaxCalls {
+		return fmt.Errorf("%w: %d LLM calls used", ErrBudgetExceeded, b.usage.Calls)
+	}
+	if b.limits.MaxTokens > 0 && b.usage.Tokens >= b.limits.MaxTokens {
+		return fmt.Errorf("%w: %d tokens used", ErrBudgetExceeded, b.usage.Tokens)
+	}
+	b.usage.Calls++
+	return nil
+}
+
+// AddTokens 记录一次调用消耗的token数
+func (b *RequestBudget) AddTokens(tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.usage.Tokens += tokens
+	b.mu.Unlock()
+}
+
+// StepTool 在执行工具步骤前占用一次步骤额度，超出预算时返回ErrBudgetExceeded
+func (b *RequestBudget) StepTool() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits.MaxToolSteps > 0 && b.usage.ToolSteps >= b.limits.MaxToolSteps {
+		return fmt.Errorf("%w: %d tool steps used", ErrBudgetExceeded, b.usage.ToolSteps)
+	}
+	b.usage.ToolSteps++
+	return nil
+}
+
+// budgetKey 上下文中保存请求预算的键
+type budgetKey struct{}
+
+// WithRequestBudget 返回带有请求预算的上下文
+func WithRequestBudget(ctx context.Context, budget *RequestBudget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetFromContext 从上下文读取请求预算，未设置时返回nil
+func BudgetFromContext(ctx context.Context) *RequestBudget {
+	if ctx == nil {
+		return nil
+	}
+	budget, _ := ctx.Value(budgetKey{}).(*RequestBudget)
+	return budget
+}
+
+// IsBudgetExceeded 判断错误是否由预算用尽引起
+func IsBudgetExceeded(err error) bool {
+	return errors.Is(err, ErrBudgetExceeded)
+}
+
+// budgetedClient 按上下文中的请求预算限制调用的大模型客户端
+type budgetedClient struct {
+	Client
+	tokenizer Tokenizer
+}
+
+// NewBudgetedClient 包装大模型客户端，按上下文中的请求预算限制调用次数和token数
+// 上下文中没有预算时不做限制。应包装在故障转移组合客户端之外，使一次逻辑调用只计一次；
+// 并包装在响应缓存之内，使缓存命中不消耗预算
+func NewBudgetedClient(client Client) Client {
+	return &budgetedClient{Client: client, tokenizer: EstimateTokenizer{}}
+}
+
+// Generate 根据提示词生成回答
+func (c *budgetedClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	budget := BudgetFromContext(ctx)
+	if budget == nil {
+		return c.Client.Generate(ctx, prompt, options...)
+	}
+	if err := budget.BeginCall(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Generate(ctx, prompt, options...)
+	budget.AddTokens(c.tokens(resp, prompt))
+	return resp, err
+}
+
+// Chat 进行多轮对话
+func (c *budgetedClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	budget := BudgetFromContext(ctx)
+	if budget == nil {
+		return c.Client.Chat(ctx, messages, options...)
+	}
+	if err := budget.BeginCall(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Chat(ctx, messages, options...)
+	var prompt strings.Builder
+	for _, m := range messages {
+		prompt.WriteString(m.Content)
+	}
+	budget.AddTokens(c.tokens(resp, prompt.String()))
+	return resp, err
+}
+
+// tokens 返回一次调用消耗的token数，提供商未返回时按提示词和回答估算
+func (c *budgetedClient) tokens(resp *Response, prompt string) int {
+	if resp != nil && resp.TokenCount > 0 {
+		return resp.TokenCount
+	}
+	n := c.tokenizer.Count(prompt)
+	if resp != nil {
+		n += c.tokenizer.Count(resp.Text)
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBudgetedClientLimitsCalls 测试超过调用次数后拒绝后续调用
+func TestBudgetedClientLimitsCalls(t *testing.T) {
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().
+		Generate(mock.Anything, "改写问题").
+		Return(&Response{Text: "改写后的问题", TokenCount: 30}, nil).
+		Twice()
+
+	client := NewBudgetedClient(mockClient)
+	budget := NewRequestBudget(RequestLimits{MaxCalls: 2})
+	ctx := WithRequestBudget(context.Background(), budget)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Generate(ctx, "改写问题")
+		require.NoError(t, err)
+	}
+	_, err := client.Generate(ctx, "改写问题")
+	assert.True(t, IsBudgetExceeded(err))
+	assert.Equal(t, BudgetUsage{Calls: 2, Tokens: 60}, budget.Usage())
+}
+
+// TestBudgetedClientLimitsTokens 测试token软限制：超限的调用完成后才拒绝后续调用
+func TestBudgetedClientLimitsTokens(t *testing.T) {
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().
+		Chat(mock.Anything, mock.Anything).
+		Return(&Response{Text: "回答"}, nil).
+		Once()
+
+	client := NewBudgetedClient(mockClient)
+	budget := NewRequestBudget(RequestLimits{MaxTokens: 5})
+	ctx := WithRequestBudget(context.Background(), budget)
+
+	// 提供商未返回token数时按提示词和回答估算
+	_, err := client.Chat(ctx, []Message{{Role: RoleUser, Content: "请总结这份文档的内容"}})
+	require.NoError(t, err)
+	assert.Equal(t, 12, budget.Usage().Tokens)
+
+	_, err = client.Chat(ctx, []Message{{Role: RoleUser, Content: "继续"}})
+	assert.ErrorIs(t, err, ErrBudgetExceeded)
+}
+
+// TestBudgetedClientWithoutBudget 测试上下文中没有预算时不做限制
+func TestBudgetedClientWithoutBudget(t *testing.T) {
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().
+		Generate(mock.Anything, "问题").
+		Return(&Response{Text: "回答"}, nil).
+		Times(3)
+
+	client := NewBudgetedClient(mockClient)
+	for i := 0; i < 3; i++ {
+		_, err := client.Generate(context.Background(), "问题")
+		require.NoError(t, err)
+	}
+}
+
+// TestRequestBudgetToolSteps 测试工具步骤预算
+func TestRequestBudgetToolSteps(t *testing.T) {
+	budget := NewRequestBudget(RequestLimits{MaxToolSteps: 1})
+	require.NoError(t, budget.StepTool())
+	assert.ErrorIs(t, budget.StepTool(), ErrBudgetExceeded)
+	assert.Equal(t, 1, budget.Usage().ToolSteps)
+
+	assert.True(t, RequestLimits{}.IsZero())
+	assert.False(t, budget.Limits().IsZero())
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpDoer 发送JSON请求的HTTP客户端，供直连API的提供商复用
+type httpDoer struct {
+	client     *http.Client
+	maxRetries int
+}
+
+// newHTTPDoer 创建HTTP请求执行器
+func newHTTPDoer(cfg *Config) *httpDoer {
+	return &httpDoer{
+		client:     &http.Client{Timeout: cfg.Timeout},
+		maxRetries: cfg.MaxRetries,
+	}
+}
+
+// postJSON 发送JSON POST请求并返回响应体
+// 对网络错误、429和5xx响应按指数退避重试，parseErr用于从错误响应体中提取错误信息
+func (d *httpDoer) postJSON(ctx context.Context, url string, headers map[string]string, body interface{}, parseErr func([]byte) string) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, NewLLMError(ErrCodeInvalidRequest, fmt.Sprintf("failed to marshal request: %v", err))
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= d.maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := time.Duration(1<<uint(attempt-1)) * 500 * time.Millisecond
+			select {
+			case <-ctx.Done():
+				return nil, NewLLMError(ErrCodeTimeout, ctx.Err().Error())
+			case <-time.After(backoff):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, NewLLMError(ErrCodeInvalidRequest, fmt.Sprintf("failed to create request: %v", err))
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := d.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, NewLLMError(ErrCodeTimeout, ErrMsgTimeout)
+			}
+			lastErr = NewLLMError(ErrCodeNetworkError, fmt.Sprintf("%s: %v", ErrMsgNetworkError, err))
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = NewLLMError(ErrCodeNetworkError, fmt.Sprintf("failed to read response: %v", err))
+			continue
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return data, nil
+		}
+
+		msg := parseErr(data)
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		lastErr = statusToError(resp.StatusCode, msg)
+
+		// 只有限流和服务端错误值得重试
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return nil, lastErr
+		}
+	}
+
+	return nil, lastErr
+}
+
+// statusToError 将HTTP状态码转换为LLM错误
+func statusToError(status int, msg string) LLMError {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return NewLLMError(ErrCodeInvalidAPIKey, msg)
+	case status == http.StatusTooManyRequests:
+		return NewLLMError(ErrCodeRateLimited, msg)
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return NewLLMError(ErrCodeTimeout, msg)
+	case status == http.StatusServiceUnavailable || status == 529:
+		return NewLLMError(ErrCodeModelOverload, msg)
+	case status >= 500:
+		return NewLLMError(ErrCodeServerError, msg)
+	default:
+		return NewLLMError(ErrCodeInvalidRequest, msg)
+	}
+}
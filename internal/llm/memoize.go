@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+)
+
+// DefaultMemoizeTTL 默认的响应缓存时间
+// 只缓存确定性的内部调用，保持较短的TTL即可避免重复付费
+const DefaultMemoizeTTL = 10 * time.Minute
+
+// WithGenerateCacheable 标记生成请求的结果可以缓存
+// 适用于标题生成、关键词提取、摘要等相同输入应得到相同输出的内部调用
+func WithGenerateCacheable() GenerateOption {
+	return func(o *GenerateOptions) {
+		o.Cacheable = true
+	}
+}
+
+// WithChatCacheable 标记聊天请求的结果可以缓存
+func WithChatCacheable() ChatOption {
+	return func(o *ChatOptions) {
+		o.Cacheable = true
+	}
+}
+
+// MemoizedClient 带响应缓存的大模型客户端
+// 只缓存显式标记为可缓存的请求，缓存键由模型名称、完整提示词和生成参数的哈希组成
+type MemoizedClient struct {
+	Client
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewMemoizedClient 创建带响应缓存的客户端
+func NewMemoizedClient(client Client, c cache.Cache, ttl time.Duration) *MemoizedClient {
+	if ttl <= 0 {
+		ttl = DefaultMemoizeTTL
+	}
+	return &MemoizedClient{
+		Client: client,
+		cache:  c,
+		ttl:    ttl,
+	}
+}
+
+// memoizedResponse 缓存中保存的响应
+type memoizedResponse struct {
+	Text       string `json:"text"`
+	TokenCount int    `json:"token_count"`
+	ModelName  string `json:"model_name"`
+}
+
+// Generate 根据提示词生成回答，可缓存的请求优先读取缓存
+func (c *MemoizedClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if !opts.Cacheable {
+		return c.Client.Generate(ctx, prompt, options...)
+	}
+
+	key := c.key(ctx, "generate", prompt, opts)
+	if resp, ok := c.load(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.Client.Generate(ctx, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, resp)
+	return resp, nil
+}
+
+// Chat 进行多轮对话，可缓存的请求优先读取缓存
+func (c *MemoizedClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+	if !opts.Cacheable {
+		return c.Client.Chat(ctx, messages, options...)
+	}
+
+	key := c.key(ctx, "chat", messages, opts)
+	if resp, ok := c.load(key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.Client.Chat(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, resp)
+	return resp, nil
+}
+
+// key 计算缓存键：llm:<方法>:<模型>:<sha256(输入+参数)>
+// 请求选择了模型时使用所选模型，不同模型的响应分开缓存
+func (c *MemoizedClient) key(ctx context.Context, method string, input interface{}, params interface{}) string {
+	payload, _ := json.Marshal(struct {
+		Input  interface{} `json:"input"`
+		Params interface{} `json:"params"`
+	}{input, params})
+
+	sum := sha256.Sum256(payload)
+	model := ModelChoiceFromContext(ctx)
+	if model == "" {
+		model = c.Client.Name()
+	}
+	return cache.GenerateCacheKey("llm", method, model, hex.EncodeToString(sum[:]))
+}
+
+// load 从缓存读取响应，读取失败视为未命中
+func (c *MemoizedClient) load(key string) (*Response, bool) {
+	data, found, err := c.cache.Get(key)
+	if err != nil || !found {
+		return nil, false
+	}
+
+	var cached memoizedResponse
+	if err := json.Unmarshal([]byte(data), &cached); err != nil {
+		return nil, false
+	}
+
+	return &Response{
+		Text:       cached.Text,
+		Messages:   []Message{{Role: RoleAssistant, Content: cached.Text}},
+		TokenCount: 0, // 命中缓存不消耗token
+		ModelName:  cached.ModelName,
+		FinishTime: time.Now(),
+	}, true
+}
+
+// store 写入缓存，写入失败不影响本次请求
+func (c *MemoizedClient) store(key string, resp *Response) {
+	data, err := json.Marshal(memoizedResponse{
+		Text:       resp.Text,
+		TokenCount: resp.TokenCount,
+		ModelName:  resp.ModelName,
+	})
+	if err != nil {
+		return
+	}
+	_ = c.cache.Set(key, string(data), c.ttl)
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoizedClient 测试可缓存请求只调用一次底层模型
+func TestMemoizedClient(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().Name().Return("mock-model")
+	mockClient.EXPECT().
+		Generate(mock.Anything, "为以下对话生成标题", mock.Anything, mock.Anything).
+		Return(&Response{Text: "标题", TokenCount: 20, ModelName: "mock-model"}, nil).
+		Once()
+
+	client := NewMemoizedClient(mockClient, memCache, time.Minute)
+	ctx := context.Background()
+
+	first, err := client.Generate(ctx, "为以下对话生成标题", WithGenerateCacheable(), WithGenerateTemperature(0))
+	require.NoError(t, err)
+	second, err := client.Generate(ctx, "为以下对话生成标题", WithGenerateCacheable(), WithGenerateTemperature(0))
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Text, second.Text)
+	assert.Equal(t, 0, second.TokenCount, "cache hit should not report token usage")
+}
+
+// TestMemoizedClientParamsInKey 测试参数不同的请求不会共用缓存
+func TestMemoizedClientParamsInKey(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().Name().Return("mock-model")
+	mockClient.EXPECT().
+		Generate(mock.Anything, "提取关键词", mock.Anything, mock.Anything).
+		Return(&Response{Text: "关键词"}, nil).
+		Twice()
+
+	client := NewMemoizedClient(mockClient, memCache, time.Minute)
+	ctx := context.Background()
+
+	_, err = client.Generate(ctx, "提取关键词", WithGenerateCacheable(), WithGenerateMaxTokens(10))
+	require.NoError(t, err)
+	_, err = client.Generate(ctx, "提取关键词", WithGenerateCacheable(), WithGenerateMaxTokens(20))
+	require.NoError(t, err)
+}
+
+// TestMemoizedClientSkipsUncacheable 测试未标记的请求不走缓存
+func TestMemoizedClientSkipsUncacheable(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+
+	mockClient := NewMockClient(t)
+	mockClient.EXPECT().
+		Chat(mock.Anything, mock.Anything).
+		Return(&Response{Text: "回答"}, nil).
+		Twice()
+
+	client := NewMemoizedClient(mockClient, memCache, time.Minute)
+	messages := []Message{{Role: RoleUser, Content: "你好"}}
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Chat(context.Background(), messages)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"strings"
+)
+
+// memoryKey 上下文中保存对话记忆的键
+type memoryKey struct{}
+
+// conversationMemoryPrefix 对话记忆在提示词中的前缀
+const conversationMemoryPrefix = "以下是与用户之前对话的摘要，回答追问时可以参考其中提到的对象和结论，但事实仍以参考上下文为准：\n"
+
+// WithConversationMemory 返回带有对话记忆的上下文
+// 聊天会话的摘要作为对话记忆，RAG生成回答时放在提示词开头，使追问中的指代能被理解
+func WithConversationMemory(ctx context.Context, memory string) context.Context {
+	memory = strings.TrimSpace(memory)
+	if memory == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, memoryKey{}, memory)
+}
+
+// ConversationMemoryFromContext 读取上下文中的对话记忆，未设置时返回空字符串
+func ConversationMemoryFromContext(ctx context.Context) string {
+	memory, _ := ctx.Value(memoryKey{}).(string)
+	return memory
+}
+
+// withMemory 把对话记忆加在提示词开头
+func withMemory(memory, prompt string) string {
+	if memory == "" {
+		return prompt
+	}
+	return conversationMemoryPrefix + memory + "\n\n" + prompt
+}
+
+// systemPromptKey 上下文中保存系统提示词的键
+type systemPromptKey struct{}
+
+// systemPromptPrefix 系统提示词在提示词中的前缀
+const systemPromptPrefix = "系统设定（请按以下角色和要求回答，但不得违背参考上下文中的事实）：\n"
+
+// WithSystemPrompt 返回带有系统提示词的上下文
+// 聊天会话的系统提示词用于设定助手的角色，如客服或法务审核，放在所有提示词的最前面
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, systemPromptKey{}, prompt)
+}
+
+// SystemPromptFromContext 读取上下文中的系统提示词，未设置时返回空字符串
+func SystemPromptFromContext(ctx context.Context) string {
+	prompt, _ := ctx.Value(systemPromptKey{}).(string)
+	return prompt
+}
+
+// SessionPrompt 把上下文中的系统提示词和对话记忆加在提示词开头
+// 没有设置时返回原提示词
+func SessionPrompt(ctx context.Context, prompt string) string {
+	prompt = withMemory(ConversationMemoryFromContext(ctx), prompt)
+	if system := SystemPromptFromContext(ctx); system != "" {
+		prompt = systemPromptPrefix + system + "\n\n" + prompt
+	}
+	return prompt
+}
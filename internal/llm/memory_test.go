@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRAGConversationMemory 测试对话记忆放在RAG提示词开头
+func TestRAGConversationMemory(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.HasPrefix(prompt, conversationMemoryPrefix+"用户在询问采购合同的付款条款") &&
+				strings.Contains(prompt, "用户问题: 可以延期吗？")
+		}), mock.Anything, mock.Anything).
+		Return(&Response{Text: "可以延期30天[1]"}, nil).Once()
+	client.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return !strings.Contains(prompt, conversationMemoryPrefix)
+		}), mock.Anything, mock.Anything).
+		Return(&Response{Text: "可以"}, nil).Once()
+
+	rag := NewRAG(client)
+	ctx := WithConversationMemory(context.Background(), " 用户在询问采购合同的付款条款 ")
+	assert.Equal(t, "用户在询问采购合同的付款条款", ConversationMemoryFromContext(ctx))
+
+	resp, err := rag.Answer(ctx, "可以延期吗？", []string{"付款可延期30天"})
+	require.NoError(t, err)
+	assert.Equal(t, "可以延期30天[1]", resp.Answer)
+
+	// 空记忆不改变提示词
+	ctx = WithConversationMemory(context.Background(), "  ")
+	assert.Empty(t, ConversationMemoryFromContext(ctx))
+	_, err = rag.Answer(ctx, "可以延期吗？", []string{"付款可延期30天"})
+	require.NoError(t, err)
+}
+
+// TestRAGSystemPrompt 测试会话的系统提示词放在对话记忆之前
+func TestRAGSystemPrompt(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.HasPrefix(prompt, systemPromptPrefix+"你是法务审核助手，指出条款中的风险") &&
+				strings.Contains(prompt, conversationMemoryPrefix+"用户在审核采购合同") &&
+				strings.Index(prompt, systemPromptPrefix) < strings.Index(prompt, conversationMemoryPrefix)
+		}), mock.Anything, mock.Anything).
+		Return(&Response{Text: "付款条款对买方不利[1]"}, nil).Once()
+
+	ctx := WithSystemPrompt(context.Background(), "你是法务审核助手，指出条款中的风险")
+	ctx = WithConversationMemory(ctx, "用户在审核采购合同")
+	resp, err := NewRAG(client).Answer(ctx, "付款条款有问题吗？", []string{"买方应在收货后7天内付款"})
+	require.NoError(t, err)
+	assert.Equal(t, "付款条款对买方不利[1]", resp.Answer)
+
+	assert.Equal(t, "问题", SessionPrompt(WithSystemPrompt(context.Background(), " "), "问题"))
+}
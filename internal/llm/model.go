@@ -1,6 +1,9 @@
 package llm
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // MessageRole 消息角色类型
 type MessageRole string
@@ -21,6 +24,9 @@ type Message struct {
 	Role    MessageRole `json:"role"`           // 角色
 	Content string      `json:"content"`        // 内容
 	Name    string      `json:"name,omitempty"` // 可选名称标识
+
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // 助手消息中模型发起的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // 工具消息对应的调用ID
 }
 
 // TongyiRequest 通义千问请求结构
@@ -32,7 +38,7 @@ type TongyiRequest struct {
 
 // TongyiRequestInput 请求输入内容
 type TongyiRequestInput struct {
-	Messages []Message `json:"messages"` // 消息列表
+	Messages []ChatMessage `json:"messages"` // 消息列表，格式与OpenAI兼容
 }
 
 // TongyiParameters 请求参数
@@ -47,6 +53,10 @@ type TongyiParameters struct {
 	ResultFormat      string   `json:"result_format,omitempty"`      // 返回格式，message或text
 	Stream            bool     `json:"stream,omitempty"`             // 是否流式输出
 	IncrementalOutput bool     `json:"incremental_output,omitempty"` // 是否增量输出
+	Stop              []string `json:"stop,omitempty"`               // 停止序列
+
+	Tools      []ChatTool  `json:"tools,omitempty"`       // 可调用的工具，需要result_format为message
+	ToolChoice interface{} `json:"tool_choice,omitempty"` // 工具选择方式
 }
 
 // TongyiResponse 通义千问响应结构
@@ -68,8 +78,91 @@ type TongyiOutput struct {
 
 // TongyiChoice 输出选择
 type TongyiChoice struct {
-	FinishReason string  `json:"finish_reason"` // 结束原因
-	Message      Message `json:"message"`       // 消息内容
+	FinishReason string      `json:"finish_reason"` // 结束原因
+	Message      ChatMessage `json:"message"`       // 消息内容
+}
+
+// TongyiError 错误响应结构
+type TongyiError struct {
+	Code      string `json:"code"`       // 错误码
+	Message   string `json:"message"`    // 错误消息
+	RequestID string `json:"request_id"` // 请求ID
+}
+
+// ChatMessage OpenAI兼容格式的消息，通义千问的message格式与其相同
+type ChatMessage struct {
+	Role       string         `json:"role"`                   // 角色
+	Content    *string        `json:"content"`                // 内容，只有工具调用时可以为null
+	Name       string         `json:"name,omitempty"`         // 可选名称标识
+	ToolCalls  []ChatToolCall `json:"tool_calls,omitempty"`   // 模型发起的工具调用
+	ToolCallID string         `json:"tool_call_id,omitempty"` // 工具消息对应的调用ID
+}
+
+// ChatTool OpenAI兼容格式的工具定义
+type ChatTool struct {
+	Type     string       `json:"type"`     // 固定为function
+	Function ChatFunction `json:"function"` // 函数定义
+}
+
+// ChatFunction 函数定义，parameters为参数的JSON Schema
+type ChatFunction struct {
+	Name        string          `json:"name"`                  // 函数名称
+	Description string          `json:"description,omitempty"` // 函数说明
+	Parameters  json.RawMessage `json:"parameters,omitempty"`  // 参数的JSON Schema
+}
+
+// ChatToolCall OpenAI兼容格式的工具调用
+type ChatToolCall struct {
+	ID       string `json:"id,omitempty"` // 调用ID
+	Type     string `json:"type"`         // 固定为function
+	Function struct {
+		Name      string `json:"name"`      // 函数名称
+		Arguments string `json:"arguments"` // 参数，JSON字符串
+	} `json:"function"`
+}
+
+// OpenAIRequest OpenAI兼容的chat/completions请求结构
+type OpenAIRequest struct {
+	Model          string        `json:"model"`                     // 模型名称
+	Messages       []ChatMessage `json:"messages"`                  // 消息列表
+	MaxTokens      *int          `json:"max_tokens,omitempty"`      // 最大生成Token数
+	Temperature    *float32      `json:"temperature,omitempty"`     // 采样温度
+	TopP           *float32      `json:"top_p,omitempty"`           // 核采样概率阈值
+	Stop           []string      `json:"stop,omitempty"`            // 停止序列
+	Tools          []ChatTool    `json:"tools,omitempty"`           // 可调用的工具
+	ToolChoice     interface{}   `json:"tool_choice,omitempty"`     // 工具选择方式
+	ResponseFormat *OpenAIFormat `json:"response_format,omitempty"` // 结构化输出格式
+}
+
+// OpenAIFormat 结构化输出格式
+type OpenAIFormat struct {
+	Type       string `json:"type"` // json_schema
+	JSONSchema struct {
+		Name   string          `json:"name"`   // Schema名称
+		Schema json.RawMessage `json:"schema"` // JSON Schema
+	} `json:"json_schema"`
+}
+
+// OpenAIResponse OpenAI兼容的chat/completions响应结构
+type OpenAIResponse struct {
+	Model   string `json:"model"` // 模型名称
+	Choices []struct {
+		Message      ChatMessage `json:"message"`       // 生成的消息
+		FinishReason string      `json:"finish_reason"` // 结束原因
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`     // 输入token数
+		CompletionTokens int `json:"completion_tokens"` // 输出token数
+		TotalTokens      int `json:"total_tokens"`      // 总token数
+	} `json:"usage"`
+}
+
+// OpenAIError 错误响应结构
+type OpenAIError struct {
+	Error struct {
+		Message string `json:"message"` // 错误消息
+		Type    string `json:"type"`    // 错误类型
+	} `json:"error"`
 }
 
 // TongyiUsage 资源使用情况
@@ -79,6 +172,160 @@ type TongyiUsage struct {
 	TotalTokens  int `json:"total_tokens"`  // 总token数
 }
 
+// AnthropicRequest Anthropic Messages API请求结构
+type AnthropicRequest struct {
+	Model         string               `json:"model"`                    // 模型名称
+	Messages      []AnthropicMessage   `json:"messages"`                 // 消息列表
+	System        string               `json:"system,omitempty"`         // 系统提示词
+	MaxTokens     int                  `json:"max_tokens"`               // 最大生成Token数（必填）
+	Temperature   *float32             `json:"temperature,omitempty"`    // 采样温度
+	TopP          *float32             `json:"top_p,omitempty"`          // 核采样概率阈值
+	TopK          *int                 `json:"top_k,omitempty"`          // 生成候选集大小
+	StopSequences []string             `json:"stop_sequences,omitempty"` // 停止序列
+	Tools         []AnthropicTool      `json:"tools,omitempty"`          // 可调用的工具
+	ToolChoice    *AnthropicToolChoice `json:"tool_choice,omitempty"`    // 工具选择方式
+}
+
+// AnthropicTool 工具定义，input_schema为工具参数的JSON Schema
+type AnthropicTool struct {
+	Name        string          `json:"name"`                  // 工具名称
+	Description string          `json:"description,omitempty"` // 工具说明
+	InputSchema json.RawMessage `json:"input_schema"`          // 参数的JSON Schema
+}
+
+// AnthropicToolChoice 工具选择方式，type为tool时强制调用name指定的工具
+type AnthropicToolChoice struct {
+	Type string `json:"type"`           // auto、any或tool
+	Name string `json:"name,omitempty"` // 强制调用的工具名称
+}
+
+// AnthropicMessage Anthropic消息结构，角色只能是user或assistant
+type AnthropicMessage struct {
+	Role    string      `json:"role"`    // 角色
+	Content interface{} `json:"content"` // 内容，纯文本为字符串，包含图片时为[]AnthropicInputBlock
+}
+
+// AnthropicInputBlock 请求内容块
+type AnthropicInputBlock struct {
+	Type   string                `json:"type"`             // 内容类型，text或image
+	Text   string                `json:"text,omitempty"`   // 文本内容
+	Source *AnthropicImageSource `json:"source,omitempty"` // 图片内容
+}
+
+// AnthropicImageSource 图片来源
+type AnthropicImageSource struct {
+	Type      string `json:"type"`       // 来源类型，固定为base64
+	MediaType string `json:"media_type"` // 图片类型，如image/png
+	Data      string `json:"data"`       // base64编码的图片内容
+}
+
+// AnthropicResponse Anthropic Messages API响应结构
+type AnthropicResponse struct {
+	ID         string                  `json:"id"`          // 响应ID
+	Model      string                  `json:"model"`       // 模型名称
+	Content    []AnthropicContentBlock `json:"content"`     // 内容块列表
+	StopReason string                  `json:"stop_reason"` // 结束原因
+	Usage      AnthropicUsage          `json:"usage"`       // 资源使用情况
+}
+
+// AnthropicContentBlock 响应内容块
+type AnthropicContentBlock struct {
+	Type  string          `json:"type"`            // 内容类型
+	Text  string          `json:"text"`            // 文本内容
+	Name  string          `json:"name,omitempty"`  // 调用的工具名称，仅tool_use块
+	Input json.RawMessage `json:"input,omitempty"` // 工具参数，仅tool_use块
+}
+
+// AnthropicUsage 资源使用情况
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`  // 输入token数
+	OutputTokens int `json:"output_tokens"` // 输出token数
+}
+
+// AnthropicError 错误响应结构
+type AnthropicError struct {
+	Error struct {
+		Type    string `json:"type"`    // 错误类型
+		Message string `json:"message"` // 错误消息
+	} `json:"error"`
+}
+
+// GeminiRequest Gemini generateContent请求结构
+type GeminiRequest struct {
+	Contents          []GeminiContent         `json:"contents"`                    // 对话内容
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"` // 系统指令
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`  // 生成参数
+	SafetySettings    []GeminiSafetySetting   `json:"safetySettings,omitempty"`    // 安全过滤设置
+}
+
+// GeminiContent Gemini内容结构，角色为user或model
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // 角色
+	Parts []GeminiPart `json:"parts"`          // 内容片段
+}
+
+// GeminiPart 内容片段，文本和内联数据二选一
+type GeminiPart struct {
+	Text       string            `json:"text,omitempty"`       // 文本内容
+	InlineData *GeminiInlineData `json:"inlineData,omitempty"` // 内联数据，如图片
+}
+
+// GeminiInlineData 内联数据
+type GeminiInlineData struct {
+	MimeType string `json:"mimeType"` // 数据类型，如image/png
+	Data     string `json:"data"`     // base64编码的内容
+}
+
+// GeminiGenerationConfig 生成参数
+type GeminiGenerationConfig struct {
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"` // 最大生成Token数
+	Temperature     *float32 `json:"temperature,omitempty"`     // 采样温度
+	TopP            *float32 `json:"topP,omitempty"`            // 核采样概率阈值
+	TopK            *int     `json:"topK,omitempty"`            // 生成候选集大小
+	StopSequences   []string `json:"stopSequences,omitempty"`   // 停止序列
+
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`   // 输出类型，结构化输出时为application/json
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"` // 结构化输出的JSON Schema
+}
+
+// GeminiSafetySetting 安全过滤设置
+type GeminiSafetySetting struct {
+	Category  string `json:"category"`  // 安全类别
+	Threshold string `json:"threshold"` // 拦截阈值
+}
+
+// GeminiResponse Gemini generateContent响应结构
+type GeminiResponse struct {
+	Candidates     []GeminiCandidate `json:"candidates"`    // 候选结果
+	UsageMetadata  GeminiUsage       `json:"usageMetadata"` // 资源使用情况
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"` // 提示词被拦截的原因
+	} `json:"promptFeedback,omitempty"`
+	ModelVersion string `json:"modelVersion"` // 模型版本
+}
+
+// GeminiCandidate 候选结果
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`      // 生成内容
+	FinishReason string        `json:"finishReason"` // 结束原因
+}
+
+// GeminiUsage 资源使用情况
+type GeminiUsage struct {
+	PromptTokenCount     int `json:"promptTokenCount"`     // 输入token数
+	CandidatesTokenCount int `json:"candidatesTokenCount"` // 输出token数
+	TotalTokenCount      int `json:"totalTokenCount"`      // 总token数
+}
+
+// GeminiError 错误响应结构
+type GeminiError struct {
+	Error struct {
+		Code    int    `json:"code"`    // 错误码
+		Message string `json:"message"` // 错误消息
+		Status  string `json:"status"`  // 错误状态
+	} `json:"error"`
+}
+
 // ChatCompletionRequest 通用聊天请求结构
 type ChatCompletionRequest struct {
 	Model       string    // 模型名称
@@ -91,18 +338,22 @@ type ChatCompletionRequest struct {
 
 // Response 统一的响应结构
 type Response struct {
-	Text       string    // 生成的文本
-	Messages   []Message // 消息列表（如果是对话）
-	TokenCount int       // 使用的token数
-	ModelName  string    // 使用的模型名称
-	FinishTime time.Time // 完成时间
-	Error      error     // 如果出错，则包含错误信息
+	Text       string     // 生成的文本
+	ToolCalls  []ToolCall // 模型要求调用的工具，为空表示已给出最终回答
+	Messages   []Message  // 消息列表（如果是对话）
+	TokenCount int        // 使用的token数
+	ModelName  string     // 使用的模型名称
+	FinishTime time.Time  // 完成时间
+	Error      error      // 如果出错，则包含错误信息
 }
 
 // RAGResponse RAG响应结构
 type RAGResponse struct {
-	Answer  string            // 回答内容
-	Sources []SourceReference // 引用来源
+	Answer         string            // 回答内容
+	Sources        []SourceReference // 引用来源
+	ContextIndices []int             // 实际放入提示词的上下文在输入中的下标
+	ContextSpans   []TextSpan        // 与ContextIndices对应，每个上下文实际放入提示词的文本范围
+	Citations      []Citation        // 回答中的引用标记
 }
 
 // SourceReference 引用来源
@@ -122,4 +373,11 @@ const (
 	ModelQwenLong   = "qwen-long"    // 通义千问-Long模型（支持长上下文）
 	ModelQwenVLPlus = "qwen-vl-plus" // 通义千问VL-Plus模型（支持图像）
 	ModelDeepSeek   = "deepseek"     // DeepSeek模型
+
+	ModelGPT4oMini = "gpt-4o-mini" // OpenAI GPT-4o mini模型
+
+	ModelClaudeSonnet = "claude-sonnet-4-5" // Anthropic Claude Sonnet模型
+	ModelClaudeHaiku  = "claude-haiku-4-5"  // Anthropic Claude Haiku模型（较快）
+	ModelGeminiFlash  = "gemini-2.5-flash"  // Google Gemini Flash模型（较快）
+	ModelGeminiPro    = "gemini-2.5-pro"    // Google Gemini Pro模型
 )
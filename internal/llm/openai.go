@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OpenAIBaseURL OpenAI API默认地址
+const OpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIClient OpenAI兼容接口的客户端
+// 适用于OpenAI以及vLLM、DeepSeek等提供chat/completions接口的服务
+type OpenAIClient struct {
+	config *Config
+	http   *httpDoer
+}
+
+// NewOpenAIClient 创建OpenAI兼容接口的客户端
+// 指向自建服务时允许不配置密钥
+func NewOpenAIClient(opts ...Option) (Client, error) {
+	// 先设置OpenAI的默认值，再应用用户选项
+	defaults := []Option{
+		WithBaseURL(OpenAIBaseURL),
+		WithModel(ModelGPT4oMini),
+	}
+	cfg := NewConfig(append(defaults, opts...)...)
+
+	if cfg.APIKey == "" && strings.Contains(cfg.BaseURL, "api.openai.com") {
+		return nil, NewLLMError(ErrCodeInvalidAPIKey, ErrMsgInvalidAPIKey)
+	}
+
+	return &OpenAIClient{
+		config: cfg,
+		http:   newHTTPDoer(cfg),
+	}, nil
+}
+
+// Name 返回模型名称
+func (c *OpenAIClient) Name() string {
+	return c.config.Model
+}
+
+// Generate 根据提示词生成回答
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	if prompt == "" {
+		return nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt)
+	}
+
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	return c.Chat(ctx, messages, func(o *ChatOptions) {
+		o.MaxTokens = opts.MaxTokens
+		o.Temperature = opts.Temperature
+		o.TopP = opts.TopP
+		o.Stop = opts.Stop
+		o.JSONSchema = opts.JSONSchema
+	})
+}
+
+// Chat 进行多轮对话，设置了工具时模型可以在响应中要求调用工具
+func (c *OpenAIClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages cannot be empty")
+	}
+
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	req := OpenAIRequest{
+		Model:       c.config.Model,
+		Messages:    toChatMessages(messages),
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		Tools:       toChatTools(opts.Tools),
+		ToolChoice:  toChatToolChoice(opts.ToolChoice),
+	}
+	// 应用请求级参数，未设置时使用客户端配置
+	if req.MaxTokens == nil && c.config.MaxTokens > 0 {
+		maxTokens := c.config.MaxTokens
+		req.MaxTokens = &maxTokens
+	}
+	if req.Temperature == nil && c.config.Temperature > 0 {
+		temp := c.config.Temperature
+		req.Temperature = &temp
+	}
+	if req.TopP == nil && c.config.TopP > 0 {
+		topP := c.config.TopP
+		req.TopP = &topP
+	}
+	if opts.Stop != nil {
+		req.Stop = *opts.Stop
+	}
+	if len(opts.JSONSchema) > 0 {
+		req.ResponseFormat = &OpenAIFormat{Type: "json_schema"}
+		req.ResponseFormat.JSONSchema.Name = "answer"
+		req.ResponseFormat.JSONSchema.Schema = opts.JSONSchema
+	}
+
+	var headers map[string]string
+	if c.config.APIKey != "" {
+		headers = map[string]string{"Authorization": "Bearer " + c.config.APIKey}
+	}
+
+	url := strings.TrimRight(c.config.BaseURL, "/") + "/chat/completions"
+	data, err := c.http.postJSON(ctx, url, headers, req, parseOpenAIError)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp OpenAIResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, NewLLMError(ErrCodeServerError, fmt.Sprintf("failed to parse response: %v", err))
+	}
+	if len(resp.Choices) == 0 {
+		return nil, NewLLMError(ErrCodeServerError, "no choices in response")
+	}
+
+	choice := resp.Choices[0]
+	if choice.FinishReason == "content_filter" {
+		return nil, NewLLMError(ErrCodeContentFilter, ErrMsgContentFilter)
+	}
+	modelName := resp.Model
+	if modelName == "" {
+		modelName = c.config.Model
+	}
+	return fromChatMessage(choice.Message, resp.Usage.TotalTokens, modelName), nil
+}
+
+// parseOpenAIError 从错误响应中提取错误信息
+func parseOpenAIError(data []byte) string {
+	var e OpenAIError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return ""
+	}
+	return e.Error.Message
+}
+
+// 在包初始化时注册OpenAI兼容客户端
+func init() {
+	RegisterClient("openai", NewOpenAIClient)
+}
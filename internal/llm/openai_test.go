@@ -0,0 +1,85 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOpenAIClientToolCalls 测试OpenAI兼容客户端的工具定义转换和工具调用解析
+func TestOpenAIClientToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, ModelGPT4oMini, req["model"])
+		tools := req["tools"].([]interface{})
+		require.Len(t, tools, 1)
+		function := tools[0].(map[string]interface{})["function"].(map[string]interface{})
+		assert.Equal(t, "calculator", function["name"])
+		assert.Equal(t, map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "calculator"}}, req["tool_choice"])
+
+		// 工具结果消息带有调用ID，助手消息带有之前的工具调用
+		messages := req["messages"].([]interface{})
+		require.Len(t, messages, 3)
+		assistant := messages[1].(map[string]interface{})
+		assert.Nil(t, assistant["content"])
+		assert.Len(t, assistant["tool_calls"], 1)
+		assert.Equal(t, "call_0", messages[2].(map[string]interface{})["tool_call_id"])
+
+		_, _ = w.Write([]byte(`{
+			"model": "gpt-4o-mini",
+			"choices": [{"finish_reason": "tool_calls", "message": {"role": "assistant", "content": null,
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "calculator", "arguments": "{\"expr\":\"6*7\"}"}}]}}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 5, "total_tokens": 25}
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient("openai", WithAPIKey("test-key"), WithBaseURL(server.URL), WithModel(ModelGPT4oMini))
+	require.NoError(t, err)
+
+	resp, err := client.Chat(context.Background(), []Message{
+		{Role: RoleUser, Content: "6乘7等于多少？"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call_0", Name: "calculator", Arguments: `{"expr":"6"}`}}},
+		{Role: RoleTool, Name: "calculator", Content: "6", ToolCallID: "call_0"},
+	}, WithChatTools(Tool{
+		Name:        "calculator",
+		Description: "计算算术表达式",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"expr":{"type":"string"}},"required":["expr"]}`),
+	}), WithChatToolChoice("calculator"))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Text)
+	assert.Equal(t, 25, resp.TokenCount)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, ToolCall{ID: "call_1", Name: "calculator", Arguments: `{"expr":"6*7"}`}, resp.ToolCalls[0])
+}
+
+// TestOpenAIClientErrors 测试OpenAI兼容客户端的密钥检查和错误处理
+func TestOpenAIClientErrors(t *testing.T) {
+	_, err := NewOpenAIClient()
+	assert.Error(t, err, "api key is required for api.openai.com")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 自建服务不需要密钥
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"model not found","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIClient(WithBaseURL(server.URL), WithMaxRetries(0))
+	require.NoError(t, err)
+	_, err = client.Generate(context.Background(), "你好")
+	llmErr, ok := err.(LLMError)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeInvalidRequest, llmErr.Code)
+	assert.Equal(t, "model not found", llmErr.Message)
+}
@@ -0,0 +1,28 @@
+package llm
+
+import "context"
+
+// PromptVariant 提示词模板变体，用于对比不同提示词的效果
+// 模板为空时使用RAG配置的模板
+type PromptVariant struct {
+	Name          string // 变体名称，记录在回答和反馈中
+	Template      string // 有上下文时的提示词模板
+	EmptyTemplate string // 无上下文时的提示词模板
+}
+
+// promptVariantKey 上下文中保存提示词变体的键
+type promptVariantKey struct{}
+
+// WithPromptVariant 返回带有提示词变体的上下文，RAG生成回答时使用变体的模板
+func WithPromptVariant(ctx context.Context, variant PromptVariant) context.Context {
+	if variant.Name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, promptVariantKey{}, variant)
+}
+
+// PromptVariantFromContext 读取上下文中的提示词变体，未设置时返回false
+func PromptVariantFromContext(ctx context.Context) (PromptVariant, bool) {
+	variant, ok := ctx.Value(promptVariantKey{}).(PromptVariant)
+	return variant, ok
+}
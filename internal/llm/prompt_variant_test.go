@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRAGPromptVariant 测试上下文中的提示词变体覆盖RAG配置的模板，变体模板为空时使用配置的模板
+func TestRAGPromptVariant(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().Generate(mock.Anything, "简洁版：如何重启服务？\n【1】停止服务后再启动\n\n", mock.Anything, mock.Anything).
+		Return(&Response{Text: "先停止再启动"}, nil).Once()
+	client.EXPECT().Generate(mock.Anything, "无资料：如何重启服务？", mock.Anything, mock.Anything).
+		Return(&Response{Text: "不知道"}, nil).Once()
+
+	rag := NewRAG(client, WithTemplate("默认：{{.Question}}\n{{.Context}}"), WithEmptyContextTemplate("无资料：{{.Question}}"))
+	ctx := WithPromptVariant(context.Background(), PromptVariant{Name: "concise", Template: "简洁版：{{.Question}}\n{{.Context}}"})
+
+	resp, err := rag.Answer(ctx, "如何重启服务？", []string{"停止服务后再启动"})
+	require.NoError(t, err)
+	assert.Equal(t, "先停止再启动", resp.Answer)
+
+	resp, err = rag.Answer(ctx, "如何重启服务？", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "不知道", resp.Answer)
+
+	// 没有名称的变体不写入上下文
+	_, ok := PromptVariantFromContext(WithPromptVariant(context.Background(), PromptVariant{Template: "x"}))
+	assert.False(t, ok)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
 )
@@ -21,6 +22,7 @@ const DefaultRAGTemplate = `请你作为一个智能问答助手，基于下面
 1. 即使参考上下文中只有部分相关信息，仍然需要尝试回答问题
 2. 如果参考上下文中有多个片段包含相关信息，请整合这些信息提供完整回答
 3. 回答应当简洁、准确、全面
+4. 在使用了参考上下文的句子末尾用方括号标注来源编号，如[1]、[2]
 
 参考上下文:
 {{.Context}}
@@ -43,6 +45,7 @@ const DeepThinkingRAGTemplate = `请你作为一个智能问答助手，基于
 最后，组织合理的语言回答问题。
 
 如果参考上下文中没有足够信息回答问题，请直接说"抱歉，我没有找到相关信息"，不要猜测或编造信息。
+在使用了参考上下文的句子末尾用方括号标注来源编号，如[1]、[2]。
 
 参考上下文:
 {{.Context}}
@@ -80,6 +83,14 @@ type RAGConfig struct {
 	Timeout time.Duration
 	// 是否带上引用来源
 	IncludeSources bool
+	// 模型上下文窗口大小（token），检索上下文会被裁剪以适应窗口
+	ContextWindow int
+	// token计数器，为空时使用EstimateTokenizer
+	Tokenizer Tokenizer
+	// 要求JSON格式回答时，输出不满足Schema的重试次数
+	StructuredRetries int
+	// 请求可以覆盖的生成参数上限
+	Limits GenerationLimits
 }
 
 // DefaultRAGConfig 默认RAG配置
@@ -91,6 +102,9 @@ func DefaultRAGConfig() *RAGConfig {
 		Temperature:    0.7,
 		Timeout:        30 * time.Second,
 		IncludeSources: true,
+		ContextWindow:  DefaultContextWindow,
+
+		StructuredRetries: DefaultStructuredRetries,
 	}
 }
 
@@ -166,7 +180,41 @@ func WithSources(include bool) RAGOption {
 	}
 }
 
+// WithContextWindow 设置模型上下文窗口大小
+func WithContextWindow(tokens int) RAGOption {
+	return func(c *RAGConfig) {
+		if tokens > 0 {
+			c.ContextWindow = tokens
+		}
+	}
+}
+
+// WithTokenizer 设置用于预算控制的token计数器
+func WithTokenizer(tokenizer Tokenizer) RAGOption {
+	return func(c *RAGConfig) {
+		c.Tokenizer = tokenizer
+	}
+}
+
+// WithStructuredRetries 设置JSON格式回答不满足Schema时的重试次数
+func WithStructuredRetries(retries int) RAGOption {
+	return func(c *RAGConfig) {
+		if retries >= 0 {
+			c.StructuredRetries = retries
+		}
+	}
+}
+
+// WithGenerationLimits 设置请求可以覆盖的生成参数上限
+func WithGenerationLimits(limits GenerationLimits) RAGOption {
+	return func(c *RAGConfig) {
+		c.Limits = limits
+	}
+}
+
 // Answer 根据上下文和问题生成回答
+// 上下文中设置了回答格式时按格式生成，JSON格式的回答是满足Schema的紧凑JSON；
+// 上下文中的生成参数按上限裁剪后覆盖配置的最大token数和温度
 func (r *RAGService) Answer(ctx context.Context, question string, contexts []string) (*RAGResponse, error) {
 	if question == "" {
 		return nil, NewLLMError(ErrCodeEmptyPrompt, "question cannot be empty")
@@ -180,29 +228,66 @@ func (r *RAGService) Answer(ctx context.Context, question string, contexts []str
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, cfg.Timeout)
 	defer cancel()
 
+	maxTokens, temperature := cfg.Limits.Clamp(GenerationParamsFromContext(ctx)).Resolve(cfg.MaxTokens, cfg.Temperature)
+
+	// 按token预算裁剪上下文，避免超出模型窗口
+	format := AnswerFormatFromContext(ctx)
+	var indices []int
+	if len(contexts) > 0 {
+		budget := NewTokenBudget(cfg.ContextWindow, cfg.Tokenizer)
+		overhead := budget.tokenizer.Count(SessionPrompt(ctx, formatPrompt(format, r.buildPrompt(ctx, question, nil))) + string(format.Schema))
+		contexts, indices = budget.Fit(contexts, overhead, maxTokens)
+		if indices == nil {
+			// 没有任何上下文放得下，按无上下文处理
+			indices = []int{}
+		}
+	}
+
 	// 构建提示词，区分有上下文和无上下文情况
 	var prompt string
 	if len(contexts) == 0 {
-		prompt = r.buildEmptyPrompt(question)
+		prompt = r.buildEmptyPrompt(ctx, question)
 	} else {
-		prompt = r.buildPrompt(question, contexts)
+		prompt = r.buildPrompt(ctx, question, contexts)
 	}
+	// 聊天会话的系统提示词和对话记忆，放在提示词开头
+	prompt = formatPrompt(format, SessionPrompt(ctx, prompt))
 
 	// 调用大模型生成回答
-	response, err := r.Client.Generate(
-		ctxWithTimeout,
-		prompt,
-		WithGenerateMaxTokens(cfg.MaxTokens),
-		WithGenerateTemperature(cfg.Temperature),
-	)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate response: %v", err)
+	options := []GenerateOption{
+		WithGenerateMaxTokens(maxTokens),
+		WithGenerateTemperature(temperature),
+	}
+	var answer string
+	if format.Type == FormatJSON {
+		data, _, err := GenerateJSON(ctxWithTimeout, r.Client, prompt, format.Schema, cfg.StructuredRetries, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate structured response: %w", err)
+		}
+		answer = string(data)
+	} else {
+		response, err := r.Client.Generate(ctxWithTimeout, prompt, options...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate response: %v", err)
+		}
+		answer = response.Text
+		if format.Type == FormatPlain {
+			answer = StripMarkdown(answer)
+		}
 	}
 
 	// 构建RAG响应
 	ragResponse := &RAGResponse{
-		Answer: response.Text,
+		Answer:         answer,
+		ContextIndices: indices,
+		Citations:      ParseCitations(answer, indices, len(contexts)),
+	}
+	if len(contexts) > 0 {
+		// 上下文可能被截断，记录实际放入提示词的范围
+		ragResponse.ContextSpans = make([]TextSpan, len(contexts))
+		for i, ctx := range contexts {
+			ragResponse.ContextSpans[i] = TextSpan{End: utf8.RuneCountInString(ctx)}
+		}
 	}
 
 	// 如果需要包含引用来源，添加到响应中
@@ -221,11 +306,14 @@ func (r *RAGService) Answer(ctx context.Context, question string, contexts []str
 	return ragResponse, nil
 }
 
-// buildPrompt 构建增强提示词
-func (r *RAGService) buildPrompt(question string, contexts []string) string {
+// buildPrompt 构建增强提示词，上下文中设置了提示词变体时使用变体的模板
+func (r *RAGService) buildPrompt(ctx context.Context, question string, contexts []string) string {
 	r.mu.RLock()
 	template := r.config.Template
 	r.mu.RUnlock()
+	if variant, ok := PromptVariantFromContext(ctx); ok && variant.Template != "" {
+		template = variant.Template
+	}
 
 	// 格式化上下文
 	formattedContext := formatContext(contexts)
@@ -238,11 +326,14 @@ func (r *RAGService) buildPrompt(question string, contexts []string) string {
 	return prompt
 }
 
-// buildEmptyPrompt 构建无上下文提示词
-func (r *RAGService) buildEmptyPrompt(question string) string {
+// buildEmptyPrompt 构建无上下文提示词，上下文中设置了提示词变体时使用变体的模板
+func (r *RAGService) buildEmptyPrompt(ctx context.Context, question string) string {
 	r.mu.RLock()
 	template := r.config.EmptyTemplate
 	r.mu.RUnlock()
+	if variant, ok := PromptVariantFromContext(ctx); ok && variant.EmptyTemplate != "" {
+		template = variant.EmptyTemplate
+	}
 
 	// 简单的模板替换
 	prompt := template
@@ -303,11 +394,12 @@ func (r *PythonRAGService) Answer(ctx context.Context, question string, contexts
 
 	// 准备RAG选项
 	var options []pyprovider.RAGOption
+	maxTokens, temperature := cfg.Limits.Clamp(GenerationParamsFromContext(ctx)).Resolve(cfg.MaxTokens, cfg.Temperature)
 
 	// 设置基本选项
 	options = append(options,
-		pyprovider.WithRAGMaxTokens(cfg.MaxTokens),
-		pyprovider.WithRAGTemperature(float64(cfg.Temperature)),
+		pyprovider.WithRAGMaxTokens(maxTokens),
+		pyprovider.WithRAGTemperature(float64(temperature)),
 		pyprovider.WithTopK(5), // 默认值
 	)
 
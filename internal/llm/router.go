@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownModel 请求选择的模型不在可选模型列表中
+var ErrUnknownModel = errors.New("unknown model")
+
+// modelChoiceKey 上下文中保存所选模型的键
+type modelChoiceKey struct{}
+
+// WithModelChoice 返回带有所选模型的上下文，ModelRouter按此选择生成回答的模型
+func WithModelChoice(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelChoiceKey{}, name)
+}
+
+// ModelChoiceFromContext 读取上下文中所选的模型，未选择时返回空字符串
+func ModelChoiceFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(modelChoiceKey{}).(string)
+	return name
+}
+
+// ModelRouter 按请求选择模型的客户端
+// 客户端可以在配置的模型中为单次请求选择模型（如快速便宜或慢而准确），未选择时使用默认模型
+type ModelRouter struct {
+	defaultName string
+	clients     map[string]Client
+}
+
+// NewModelRouter 创建按请求选择模型的客户端
+// models为除默认模型外可供选择的模型，键为请求中使用的模型名称
+func NewModelRouter(defaultName string, defaultClient Client, models map[string]Client) *ModelRouter {
+	clients := map[string]Client{defaultName: defaultClient}
+	for name, client := range models {
+		if name != defaultName {
+			clients[name] = client
+		}
+	}
+	return &ModelRouter{defaultName: defaultName, clients: clients}
+}
+
+// Name 返回默认模型的名称
+func (r *ModelRouter) Name() string {
+	return r.clients[r.defaultName].Name()
+}
+
+// Default 返回默认模型在请求中使用的名称
+func (r *ModelRouter) Default() string {
+	return r.defaultName
+}
+
+// Models 返回可供选择的模型名称，默认模型在前，其余按名称排序
+func (r *ModelRouter) Models() []string {
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		if name != r.defaultName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{r.defaultName}, names...)
+}
+
+// Select 校验请求选择的模型并写入上下文，返回实际使用的模型名称
+// name为空或为默认模型时不修改上下文，使其与未选择模型的请求共享回答缓存；
+// 不在可选列表中时返回ErrUnknownModel。未配置ModelRouter（nil）时只接受空的name
+func (r *ModelRouter) Select(ctx context.Context, name string) (context.Context, string, error) {
+	if r == nil {
+		if name != "" {
+			return ctx, "", fmt.Errorf("%w %q, model selection is not enabled", ErrUnknownModel, name)
+		}
+		return ctx, "", nil
+	}
+	if name == "" || name == r.defaultName {
+		return ctx, r.defaultName, nil
+	}
+	if _, ok := r.clients[name]; !ok {
+		return ctx, "", fmt.Errorf("%w %q, available models: %s", ErrUnknownModel, name, strings.Join(r.Models(), ", "))
+	}
+	return WithModelChoice(ctx, name), name, nil
+}
+
+// client 返回上下文中所选模型的客户端
+func (r *ModelRouter) client(ctx context.Context) (Client, error) {
+	name := ModelChoiceFromContext(ctx)
+	if name == "" {
+		name = r.defaultName
+	}
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, NewLLMError(ErrCodeInvalidRequest, fmt.Sprintf("%v %q", ErrUnknownModel, name))
+	}
+	return client, nil
+}
+
+// Generate 使用所选模型根据提示词生成回答
+func (r *ModelRouter) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	client, err := r.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Generate(ctx, prompt, options...)
+}
+
+// Chat 使用所选模型进行多轮对话
+func (r *ModelRouter) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	client, err := r.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Chat(ctx, messages, options...)
+}
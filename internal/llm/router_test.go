@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestModelRouter 测试按请求选择模型，未选择时使用默认模型
+func TestModelRouter(t *testing.T) {
+	qwen := NewMockClient(t)
+	qwen.EXPECT().Generate(mock.Anything, "你好").Return(&Response{Text: "默认", ModelName: ModelQwenPlus}, nil).Once()
+	claude := NewMockClient(t)
+	claude.EXPECT().Generate(mock.Anything, "你好").Return(&Response{Text: "精确", ModelName: ModelClaudeSonnet}, nil).Once()
+	gemini := NewMockClient(t)
+
+	router := NewModelRouter("qwen", qwen, map[string]Client{"accurate": claude, "fast": gemini})
+	assert.Equal(t, []string{"qwen", "accurate", "fast"}, router.Models())
+
+	ctx, name, err := router.Select(context.Background(), "")
+	require.NoError(t, err)
+	assert.Equal(t, "qwen", name)
+	resp, err := router.Generate(ctx, "你好")
+	require.NoError(t, err)
+	assert.Equal(t, "默认", resp.Text)
+
+	// 选择默认模型时不写入上下文，与未选择的请求共享缓存
+	ctx, name, err = router.Select(context.Background(), "qwen")
+	require.NoError(t, err)
+	assert.Equal(t, "qwen", name)
+	assert.Empty(t, ModelChoiceFromContext(ctx))
+
+	ctx, name, err = router.Select(context.Background(), "accurate")
+	require.NoError(t, err)
+	assert.Equal(t, "accurate", name)
+	resp, err = router.Generate(ctx, "你好")
+	require.NoError(t, err)
+	assert.Equal(t, "精确", resp.Text)
+
+	_, _, err = router.Select(context.Background(), "gpt-5")
+	assert.ErrorIs(t, err, ErrUnknownModel)
+	assert.ErrorContains(t, err, "available models: qwen, accurate, fast")
+	_, err = router.Chat(WithModelChoice(context.Background(), "gpt-5"), []Message{{Role: RoleUser, Content: "你好"}})
+	assert.Error(t, err)
+
+	// 未配置可选模型时只接受空的模型名称
+	var none *ModelRouter
+	_, name, err = none.Select(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, name)
+	_, _, err = none.Select(context.Background(), "accurate")
+	assert.ErrorIs(t, err, ErrUnknownModel)
+}
+
+// TestMemoizedClientModelChoice 测试不同模型的响应分开缓存
+func TestMemoizedClientModelChoice(t *testing.T) {
+	memCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+
+	qwen := NewMockClient(t)
+	qwen.EXPECT().Name().Return(ModelQwenPlus)
+	qwen.EXPECT().Generate(mock.Anything, "提取关键词", mock.Anything).Return(&Response{Text: "默认"}, nil).Once()
+	claude := NewMockClient(t)
+	claude.EXPECT().Generate(mock.Anything, "提取关键词", mock.Anything).Return(&Response{Text: "精确"}, nil).Once()
+
+	client := NewMemoizedClient(NewModelRouter("qwen", qwen, map[string]Client{"accurate": claude}), memCache, time.Minute)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Generate(context.Background(), "提取关键词", WithGenerateCacheable())
+		require.NoError(t, err)
+		assert.Equal(t, "默认", resp.Text)
+		resp, err = client.Generate(WithModelChoice(context.Background(), "accurate"), "提取关键词", WithGenerateCacheable())
+		require.NoError(t, err)
+		assert.Equal(t, "精确", resp.Text)
+	}
+}
@@ -0,0 +1,283 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultStructuredRetries 结构化输出不合法时默认的重试次数
+const DefaultStructuredRetries = 2
+
+// ErrInvalidStructuredOutput 重试后模型输出仍不是满足Schema的JSON
+var ErrInvalidStructuredOutput = errors.New("model output does not match the json schema")
+
+// structuredPrompt 要求模型输出JSON的提示词后缀
+const structuredPrompt = `
+
+请只输出一个满足下面JSON Schema的JSON值，不要输出解释、Markdown代码块或其他任何内容：
+%s`
+
+// structuredRetryPrompt 上一次输出不合法时附加的提示词
+const structuredRetryPrompt = `
+
+你上一次的输出不符合要求：%s
+请重新输出，只输出满足JSON Schema的JSON。`
+
+// GenerateJSON 生成满足JSON Schema的JSON
+// 提示词中附带Schema，并通过WithGenerateJSONSchema请求模型的结构化输出模式；
+// 输出不是合法JSON或不满足Schema时把错误反馈给模型重试，最多重试retries次。
+// 返回紧凑格式的JSON和最后一次调用的响应
+func GenerateJSON(ctx context.Context, client Client, prompt string, schema json.RawMessage, retries int, options ...GenerateOption) (json.RawMessage, *Response, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+
+	base := prompt + fmt.Sprintf(structuredPrompt, string(schema))
+	options = append(options, WithGenerateJSONSchema(schema))
+	current := base
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		resp, err := client.Generate(ctx, current, options...)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, err := extractJSON(resp.Text)
+		if err == nil {
+			err = s.validate(data)
+		}
+		if err == nil {
+			return data, resp, nil
+		}
+		lastErr = err
+		current = base + fmt.Sprintf(structuredRetryPrompt, err)
+	}
+	return nil, nil, fmt.Errorf("%w: %v", ErrInvalidStructuredOutput, lastErr)
+}
+
+// ValidateJSONSchema 检查JSON Schema本身是否有效，用于在调用模型之前拒绝错误的请求
+func ValidateJSONSchema(schema json.RawMessage) error {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid json schema: %w", err)
+	}
+	return s.check("$")
+}
+
+// extractJSON 从模型输出中取出JSON，去掉代码块标记和前后的说明文字，返回紧凑格式
+func extractJSON(text string) (json.RawMessage, error) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimPrefix(text, "json")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+	if !json.Valid([]byte(text)) {
+		// 模型在JSON前后加了说明文字时，取第一个{或[到最后一个}或]之间的内容
+		start := strings.IndexAny(text, "{[")
+		end := strings.LastIndexAny(text, "}]")
+		if start < 0 || end < start {
+			return nil, errors.New("output is not json")
+		}
+		text = text[start : end+1]
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(text)); err != nil {
+		return nil, fmt.Errorf("output is not valid json: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonSchema 支持的JSON Schema子集：type、enum、properties、required、
+// additionalProperties、items、minItems、maxItems、minLength、maxLength、minimum、maximum
+// 不认识的关键字忽略
+type jsonSchema struct {
+	Type                 schemaType             `json:"type"`
+	Enum                 []interface{}          `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+}
+
+// schemaType type关键字，可以是单个类型或类型列表
+type schemaType []string
+
+// UnmarshalJSON 同时支持"string"和["string","null"]两种写法
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaType{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings")
+	}
+	*t = list
+	return nil
+}
+
+// knownTypes JSON Schema定义的类型
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// check 检查Schema中的类型是否都有效
+func (s *jsonSchema) check(path string) error {
+	for _, t := range s.Type {
+		if !knownTypes[t] {
+			return fmt.Errorf("%s: unknown type %q", path, t)
+		}
+	}
+	for name, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.%s: schema must be an object", path, name)
+		}
+		if err := prop.check(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.check(path + "[]")
+	}
+	return nil
+}
+
+// validate 校验JSON是否满足Schema
+func (s *jsonSchema) validate(data json.RawMessage) error {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("output is not valid json: %v", err)
+	}
+	return s.validateValue("$", value)
+}
+
+// validateValue 递归校验一个值，错误信息带有值的路径，便于模型修正
+func (s *jsonSchema) validateValue(path string, value interface{}) error {
+	if s == nil {
+		return nil
+	}
+	if len(s.Type) > 0 && !s.typeMatches(value) {
+		return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), jsonTypeOf(value))
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		return fmt.Errorf("%s: value is not one of the allowed values", path)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := prop.validateValue(path+"."+name, v[name]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items, got %d", path, *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items, got %d", path, *s.MaxItems, len(v))
+		}
+		for i, item := range v {
+			if err := s.Items.validateValue(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters", path, *s.MaxLength)
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			return fmt.Errorf("%s: must be at least %g", path, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return fmt.Errorf("%s: must be at most %g", path, *s.Maximum)
+		}
+	}
+	return nil
+}
+
+// typeMatches 判断值是否属于Schema允许的类型之一
+func (s *jsonSchema) typeMatches(value interface{}) bool {
+	actual := jsonTypeOf(value)
+	for _, t := range s.Type {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf 返回值的JSON类型，没有小数部分的数字视为integer
+func jsonTypeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// enumContains 判断值是否在枚举中，按JSON编码比较
+func enumContains(enum []interface{}, value interface{}) bool {
+	actual, _ := json.Marshal(value)
+	for _, allowed := range enum {
+		if data, _ := json.Marshal(allowed); bytes.Equal(data, actual) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TongyiClient 通义千问（DashScope）客户端
+type TongyiClient struct {
+	config *Config
+	http   *httpDoer
+}
+
+// NewTongyiClient 创建通义千问客户端，BaseURL为DashScope文本生成接口的完整地址
+func NewTongyiClient(opts ...Option) (Client, error) {
+	cfg := NewConfig(opts...)
+
+	if cfg.APIKey == "" {
+		return nil, NewLLMError(ErrCodeInvalidAPIKey, ErrMsgInvalidAPIKey)
+	}
+
+	return &TongyiClient{
+		config: cfg,
+		http:   newHTTPDoer(cfg),
+	}, nil
+}
+
+// Name 返回模型名称
+func (c *TongyiClient) Name() string {
+	return c.config.Model
+}
+
+// Generate 根据提示词生成回答
+func (c *TongyiClient) Generate(ctx context.Context, prompt string, options ...GenerateOption) (*Response, error) {
+	if prompt == "" {
+		return nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt)
+	}
+
+	opts := &GenerateOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	messages := []Message{{Role: RoleUser, Content: prompt}}
+	return c.Chat(ctx, messages, func(o *ChatOptions) {
+		o.MaxTokens = opts.MaxTokens
+		o.Temperature = opts.Temperature
+		o.TopP = opts.TopP
+		o.TopK = opts.TopK
+		o.Stop = opts.Stop
+	})
+}
+
+// Chat 进行多轮对话，设置了工具时模型可以在响应中要求调用工具
+func (c *TongyiClient) Chat(ctx context.Context, messages []Message, options ...ChatOption) (*Response, error) {
+	if len(messages) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "messages cannot be empty")
+	}
+
+	opts := &ChatOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	// 工具调用只在message格式的返回中提供
+	params := &TongyiParameters{
+		Temperature:  opts.Temperature,
+		TopP:         opts.TopP,
+		TopK:         opts.TopK,
+		MaxTokens:    opts.MaxTokens,
+		ResultFormat: "message",
+		Tools:        toChatTools(opts.Tools),
+		ToolChoice:   toChatToolChoice(opts.ToolChoice),
+	}
+	// 应用请求级参数，未设置时使用客户端配置
+	if params.MaxTokens == nil && c.config.MaxTokens > 0 {
+		maxTokens := c.config.MaxTokens
+		params.MaxTokens = &maxTokens
+	}
+	if params.Temperature == nil && c.config.Temperature > 0 {
+		temp := c.config.Temperature
+		params.Temperature = &temp
+	}
+	if params.TopP == nil && c.config.TopP > 0 {
+		topP := c.config.TopP
+		params.TopP = &topP
+	}
+	if opts.Stop != nil {
+		params.Stop = *opts.Stop
+	}
+
+	req := TongyiRequest{
+		Model:      c.config.Model,
+		Input:      &TongyiRequestInput{Messages: toChatMessages(messages)},
+		Parameters: params,
+	}
+	headers := map[string]string{"Authorization": "Bearer " + c.config.APIKey}
+	data, err := c.http.postJSON(ctx, c.config.BaseURL, headers, req, parseTongyiError)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TongyiResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, NewLLMError(ErrCodeServerError, fmt.Sprintf("failed to parse response: %v", err))
+	}
+	if resp.Code != "" {
+		return nil, NewLLMError(ErrCodeServerError, fmt.Sprintf("%s: %s", resp.Code, resp.Message))
+	}
+	if len(resp.Output.Choices) == 0 {
+		return nil, NewLLMError(ErrCodeServerError, "no choices in response")
+	}
+
+	return fromChatMessage(resp.Output.Choices[0].Message, resp.Usage.TotalTokens, c.config.Model), nil
+}
+
+// parseTongyiError 从错误响应中提取错误信息
+func parseTongyiError(data []byte) string {
+	var e TongyiError
+	if err := json.Unmarshal(data, &e); err != nil {
+		return ""
+	}
+	if e.Code == "DataInspectionFailed" {
+		return ErrMsgContentFilter + ": " + e.Message
+	}
+	return e.Message
+}
+
+// 在包初始化时注册通义千问客户端
+func init() {
+	RegisterClient("tongyi", NewTongyiClient)
+}
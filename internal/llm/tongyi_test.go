@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTongyiClientChat 测试通义千问客户端的请求转换、文本回复和工具调用解析
+func TestTongyiClientChat(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var req TongyiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, ModelQwenPlus, req.Model)
+		require.NotNil(t, req.Parameters)
+		assert.Equal(t, "message", req.Parameters.ResultFormat)
+		require.Len(t, req.Input.Messages, 1)
+
+		if len(req.Parameters.Tools) == 0 {
+			_, _ = w.Write([]byte(`{"output":{"choices":[{"finish_reason":"stop","message":{"role":"assistant","content":"你好"}}]},
+				"usage":{"input_tokens":5,"output_tokens":2,"total_tokens":7},"request_id":"r1"}`))
+			return
+		}
+		assert.Equal(t, "search", req.Parameters.Tools[0].Function.Name)
+		assert.Equal(t, ToolChoiceAuto, req.Parameters.ToolChoice)
+		_, _ = w.Write([]byte(`{"output":{"choices":[{"finish_reason":"tool_calls","message":{"role":"assistant","content":"",
+			"tool_calls":[{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"query\":\"天气\"}"}}]}}]},
+			"usage":{"input_tokens":30,"output_tokens":10,"total_tokens":40},"request_id":"r2"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient("tongyi", WithAPIKey("test-key"), WithBaseURL(server.URL), WithModel(ModelQwenPlus))
+	require.NoError(t, err)
+
+	resp, err := client.Generate(context.Background(), "你好")
+	require.NoError(t, err)
+	assert.Equal(t, "你好", resp.Text)
+	assert.Equal(t, 7, resp.TokenCount)
+	assert.Empty(t, resp.ToolCalls)
+
+	resp, err = client.Chat(context.Background(), []Message{{Role: RoleUser, Content: "今天天气如何？"}},
+		WithChatTools(Tool{Name: "search", Description: "实时搜索"}), WithChatToolChoice(ToolChoiceAuto))
+	require.NoError(t, err)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "search", resp.ToolCalls[0].Name)
+	assert.JSONEq(t, `{"query":"天气"}`, resp.ToolCalls[0].Arguments)
+	assert.Equal(t, 2, calls)
+}
+
+// TestTongyiClientErrors 测试通义千问客户端的错误处理
+func TestTongyiClientErrors(t *testing.T) {
+	_, err := NewTongyiClient()
+	assert.Error(t, err, "api key is required")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"InvalidApiKey","message":"Invalid API-key provided.","request_id":"r1"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewTongyiClient(WithAPIKey("bad"), WithBaseURL(server.URL), WithMaxRetries(0))
+	require.NoError(t, err)
+	_, err = client.Generate(context.Background(), "你好")
+	llmErr, ok := err.(LLMError)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeInvalidAPIKey, llmErr.Code)
+	assert.Contains(t, llmErr.Message, "Invalid API-key")
+
+	// 不支持工具调用的提供商拒绝带工具的请求
+	anthropic, err := NewAnthropicClient(WithAPIKey("test-key"), WithBaseURL(server.URL))
+	require.NoError(t, err)
+	_, err = anthropic.Chat(context.Background(), []Message{{Role: RoleUser, Content: "你好"}}, WithChatTools(Tool{Name: "search"}))
+	assert.ErrorContains(t, err, "does not support tool calling")
+}
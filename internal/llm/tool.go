@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 工具选择方式，也可以直接传入工具名称强制调用该工具
+const (
+	ToolChoiceAuto     = "auto"     // 模型自行决定是否调用工具
+	ToolChoiceNone     = "none"     // 不调用工具，直接回答
+	ToolChoiceRequired = "required" // 必须调用至少一个工具
+)
+
+// Tool 可供模型调用的工具定义
+type Tool struct {
+	Name        string          // 工具名称，只能包含字母、数字、下划线和连字符
+	Description string          // 工具说明，模型据此决定何时调用
+	Parameters  json.RawMessage // 参数的JSON Schema，为空表示没有参数
+}
+
+// ToolCall 模型发起的一次工具调用
+type ToolCall struct {
+	ID        string `json:"id"`        // 调用ID，回传工具结果时放在工具消息的ToolCallID中
+	Name      string `json:"name"`      // 工具名称
+	Arguments string `json:"arguments"` // 参数，JSON字符串
+}
+
+// WithChatTools 设置聊天请求可调用的工具，只有支持工具调用的提供商接受该选项
+func WithChatTools(tools ...Tool) ChatOption {
+	return func(o *ChatOptions) {
+		o.Tools = tools
+	}
+}
+
+// WithChatToolChoice 设置工具选择方式：auto、none、required或工具名称
+func WithChatToolChoice(choice string) ChatOption {
+	return func(o *ChatOptions) {
+		o.ToolChoice = choice
+	}
+}
+
+// ToolFunc 执行一次工具调用，返回回传给模型的工具结果
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
+// RunTools 让模型按需调用工具，直到给出不含工具调用的最终回答
+// 每轮把模型发起的工具调用交给handlers执行，结果以工具消息回传；工具执行失败或不存在时把错误作为结果回传，
+// 由模型决定如何继续。每次执行工具前占用请求预算中的一个工具步骤，最多进行maxSteps轮，为0时只受预算限制。
+// 返回最终响应和包含工具调用过程的完整消息列表
+func RunTools(ctx context.Context, client Client, messages []Message, tools []Tool, handlers map[string]ToolFunc, maxSteps int, options ...ChatOption) (*Response, []Message, error) {
+	options = append(options, WithChatTools(tools...))
+	budget := BudgetFromContext(ctx)
+	for step := 0; maxSteps <= 0 || step < maxSteps; step++ {
+		resp, err := client.Chat(ctx, messages, options...)
+		if err != nil {
+			return nil, messages, err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return resp, append(messages, Message{Role: RoleAssistant, Content: resp.Text}), nil
+		}
+
+		messages = append(messages, Message{Role: RoleAssistant, Content: resp.Text, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			if budget != nil {
+				if err := budget.StepTool(); err != nil {
+					return nil, messages, err
+				}
+			}
+			messages = append(messages, Message{
+				Role:       RoleTool,
+				Name:       call.Name,
+				Content:    runTool(ctx, handlers, call),
+				ToolCallID: call.ID,
+			})
+		}
+	}
+	return nil, messages, fmt.Errorf("tool calling did not finish within %d steps", maxSteps)
+}
+
+// runTool 执行一次工具调用，失败时返回错误说明作为工具结果
+func runTool(ctx context.Context, handlers map[string]ToolFunc, call ToolCall) string {
+	handler, ok := handlers[call.Name]
+	if !ok {
+		return fmt.Sprintf("error: unknown tool %q", call.Name)
+	}
+	result, err := handler(ctx, call.Arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return result
+}
+
+// unsupportedTools 不支持工具调用的提供商收到工具时返回的错误
+func unsupportedTools(opts *ChatOptions, model string) error {
+	if len(opts.Tools) == 0 {
+		return nil
+	}
+	return NewLLMError(ErrCodeInvalidRequest, "model "+model+" does not support tool calling")
+}
+
+// toChatTools 把工具定义转换为OpenAI兼容格式
+func toChatTools(tools []Tool) []ChatTool {
+	if len(tools) == 0 {
+		return nil
+	}
+	out := make([]ChatTool, len(tools))
+	for i, tool := range tools {
+		params := tool.Parameters
+		if len(params) == 0 {
+			params = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		out[i] = ChatTool{Type: "function", Function: ChatFunction{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  params,
+		}}
+	}
+	return out
+}
+
+// toChatToolChoice 把工具选择方式转换为OpenAI兼容格式，工具名称转换为强制调用该函数
+func toChatToolChoice(choice string) interface{} {
+	switch choice {
+	case "":
+		return nil
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return choice
+	default:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": choice},
+		}
+	}
+}
+
+// toChatMessages 把消息转换为OpenAI兼容格式
+func toChatMessages(messages []Message) []ChatMessage {
+	out := make([]ChatMessage, len(messages))
+	for i, msg := range messages {
+		content := msg.Content
+		out[i] = ChatMessage{
+			Role:       string(msg.Role),
+			Content:    &content,
+			Name:       msg.Name,
+			ToolCallID: msg.ToolCallID,
+		}
+		if len(msg.ToolCalls) > 0 {
+			if content == "" {
+				out[i].Content = nil
+			}
+			out[i].ToolCalls = make([]ChatToolCall, len(msg.ToolCalls))
+			for j, call := range msg.ToolCalls {
+				out[i].ToolCalls[j].ID = call.ID
+				out[i].ToolCalls[j].Type = "function"
+				out[i].ToolCalls[j].Function.Name = call.Name
+				out[i].ToolCalls[j].Function.Arguments = call.Arguments
+			}
+		}
+	}
+	return out
+}
+
+// fromChatMessage 把OpenAI兼容格式的回复转换为响应
+func fromChatMessage(msg ChatMessage, tokens int, model string) *Response {
+	var text string
+	if msg.Content != nil {
+		text = *msg.Content
+	}
+	resp := &Response{
+		Text:       text,
+		TokenCount: tokens,
+		ModelName:  model,
+		FinishTime: time.Now(),
+	}
+	for _, call := range msg.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	resp.Messages = []Message{{Role: RoleAssistant, Content: text, ToolCalls: resp.ToolCalls}}
+	return resp
+}
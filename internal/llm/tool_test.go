@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunTools 测试执行模型发起的工具调用并回传结果，直到模型给出最终回答
+func TestRunTools(t *testing.T) {
+	client := NewMockClient(t)
+	client.EXPECT().Chat(mock.Anything, mock.MatchedBy(func(messages []Message) bool {
+		return len(messages) == 1
+	}), mock.Anything).Return(&Response{ToolCalls: []ToolCall{
+		{ID: "call_1", Name: "calculator", Arguments: `{"expr":"6*7"}`},
+		{ID: "call_2", Name: "weather", Arguments: `{}`},
+	}}, nil).Once()
+	client.EXPECT().Chat(mock.Anything, mock.MatchedBy(func(messages []Message) bool {
+		return len(messages) == 4
+	}), mock.Anything).Return(&Response{Text: "6乘7等于42"}, nil).Once()
+
+	handlers := map[string]ToolFunc{
+		"calculator": func(ctx context.Context, arguments string) (string, error) {
+			assert.JSONEq(t, `{"expr":"6*7"}`, arguments)
+			return "42", nil
+		},
+	}
+	ctx := WithRequestBudget(context.Background(), NewRequestBudget(RequestLimits{MaxToolSteps: 5}))
+	resp, messages, err := RunTools(ctx, client, []Message{{Role: RoleUser, Content: "6乘7等于多少？"}},
+		[]Tool{{Name: "calculator"}}, handlers, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "6乘7等于42", resp.Text)
+
+	require.Len(t, messages, 5)
+	assert.Len(t, messages[1].ToolCalls, 2)
+	assert.Equal(t, Message{Role: RoleTool, Name: "calculator", Content: "42", ToolCallID: "call_1"}, messages[2])
+	// 不存在的工具把错误回传给模型
+	assert.Equal(t, `error: unknown tool "weather"`, messages[3].Content)
+	assert.Equal(t, 2, BudgetFromContext(ctx).Usage().ToolSteps)
+
+	// 工具步骤超出预算时停止
+	client = NewMockClient(t)
+	client.EXPECT().Chat(mock.Anything, mock.Anything, mock.Anything).
+		Return(&Response{ToolCalls: []ToolCall{{ID: "c", Name: "calculator"}}}, nil)
+	handlers["calculator"] = func(ctx context.Context, arguments string) (string, error) {
+		return "", errors.New("bad expression")
+	}
+	ctx = WithRequestBudget(context.Background(), NewRequestBudget(RequestLimits{MaxToolSteps: 2}))
+	_, messages, err = RunTools(ctx, client, []Message{{Role: RoleUser, Content: "算一下"}}, []Tool{{Name: "calculator"}}, handlers, 0)
+	assert.True(t, IsBudgetExceeded(err))
+	assert.Equal(t, "error: bad expression", messages[2].Content)
+
+	// 超过轮数上限时返回错误
+	_, _, err = RunTools(context.Background(), client, []Message{{Role: RoleUser, Content: "算一下"}}, []Tool{{Name: "calculator"}}, handlers, 2)
+	assert.ErrorContains(t, err, "did not finish within 2 steps")
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+)
+
+// Image 输入给大模型的图片
+type Image struct {
+	Data     []byte // 图片内容
+	MimeType string // 图片类型，如image/png
+}
+
+// VisionClient 支持图片输入的大模型客户端实现的可选接口
+type VisionClient interface {
+	// DescribeImage 根据提示词和图片生成回答
+	DescribeImage(ctx context.Context, prompt string, image Image, options ...GenerateOption) (*Response, error)
+}
+
+// SupportsVision 判断客户端是否支持图片输入
+func SupportsVision(client Client) bool {
+	_, ok := client.(VisionClient)
+	return ok
+}
+
+// DescribeImage 让客户端根据提示词和图片生成回答，客户端不支持图片输入时返回错误
+func DescribeImage(ctx context.Context, client Client, prompt string, image Image, options ...GenerateOption) (*Response, error) {
+	vision, ok := client.(VisionClient)
+	if !ok {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "model "+client.Name()+" does not support image input")
+	}
+	if len(image.Data) == 0 {
+		return nil, NewLLMError(ErrCodeInvalidRequest, "image cannot be empty")
+	}
+	if prompt == "" {
+		return nil, NewLLMError(ErrCodeEmptyPrompt, ErrMsgEmptyPrompt)
+	}
+	return vision.DescribeImage(ctx, prompt, image, options...)
+}
+
+// base64Data 返回图片内容的base64编码
+func (img Image) base64Data() string {
+	return base64.StdEncoding.EncodeToString(img.Data)
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDescribeImage 测试各提供商的图片输入请求格式
+func TestDescribeImage(t *testing.T) {
+	image := Image{Data: []byte("png"), MimeType: "image/png"}
+
+	anthropic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content []AnthropicInputBlock `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Messages, 1)
+		require.Len(t, req.Messages[0].Content, 2)
+		assert.Equal(t, "image", req.Messages[0].Content[0].Type)
+		assert.Equal(t, &AnthropicImageSource{Type: "base64", MediaType: "image/png", Data: "cG5n"}, req.Messages[0].Content[0].Source)
+		assert.Equal(t, "描述这张图", req.Messages[0].Content[1].Text)
+
+		_ = json.NewEncoder(w).Encode(AnthropicResponse{Content: []AnthropicContentBlock{{Type: "text", Text: "一张架构图"}}})
+	}))
+	defer anthropic.Close()
+
+	client, err := NewAnthropicClient(WithAPIKey("key"), WithBaseURL(anthropic.URL))
+	require.NoError(t, err)
+	require.True(t, SupportsVision(client))
+	resp, err := DescribeImage(context.Background(), client, "描述这张图", image)
+	require.NoError(t, err)
+	assert.Equal(t, "一张架构图", resp.Text)
+
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GeminiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Contents, 1)
+		require.Len(t, req.Contents[0].Parts, 2)
+		assert.Equal(t, &GeminiInlineData{MimeType: "image/png", Data: "cG5n"}, req.Contents[0].Parts[0].InlineData)
+		assert.Equal(t, "描述这张图", req.Contents[0].Parts[1].Text)
+
+		_ = json.NewEncoder(w).Encode(GeminiResponse{Candidates: []GeminiCandidate{{Content: GeminiContent{Parts: []GeminiPart{{Text: "流程图"}}}}}})
+	}))
+	defer gemini.Close()
+
+	client, err = NewGeminiClient(WithAPIKey("key"), WithBaseURL(gemini.URL))
+	require.NoError(t, err)
+	resp, err = DescribeImage(context.Background(), client, "描述这张图", image)
+	require.NoError(t, err)
+	assert.Equal(t, "流程图", resp.Text)
+
+	// 不支持图片输入的客户端
+	mockClient := NewMockClient(t)
+	mockClient.On("Name").Return("qwen-turbo")
+	_, err = DescribeImage(context.Background(), mockClient, "描述这张图", image)
+	assert.Error(t, err)
+}
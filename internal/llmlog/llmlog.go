@@ -0,0 +1,179 @@
+// Package llmlog 记录大模型交互日志（提示词、检索上下文、回答、耗时和token数），
+// 用于排查错误回答和调整提示词。写入前按规则脱敏个人信息，可以写入数据库或外部日志
+package llmlog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/sirupsen/logrus"
+)
+
+// truncatedSuffix 文本超过长度上限被截断时追加的标记
+const truncatedSuffix = "…[truncated]"
+
+// pruneInterval 数据库中过期日志的清理间隔
+const pruneInterval = time.Hour
+
+// Sink 交互日志的写入目标
+type Sink interface {
+	// Write 写入一条交互日志
+	Write(ctx context.Context, entry *models.LLMInteraction) error
+}
+
+// RepositorySink 把交互日志写入数据库
+// 设置了保留时长时，写入时顺带删除过期日志，每小时最多清理一次
+type RepositorySink struct {
+	repo      repository.LLMLogRepository
+	retention time.Duration
+	now       func() time.Time
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewRepositorySink 创建写入数据库的日志目标，retention不大于0时不清理
+func NewRepositorySink(repo repository.LLMLogRepository, retention time.Duration) *RepositorySink {
+	return &RepositorySink{repo: repo, retention: retention, now: time.Now}
+}
+
+// Write 写入一条交互日志
+func (s *RepositorySink) Write(ctx context.Context, entry *models.LLMInteraction) error {
+	if err := s.repo.WithContext(ctx).Append(entry); err != nil {
+		return err
+	}
+	if s.retention <= 0 {
+		return nil
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	due := now.Sub(s.lastPruned) >= pruneInterval
+	if due {
+		s.lastPruned = now
+	}
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+	_, err := s.repo.WithContext(ctx).DeleteBefore(now.Add(-s.retention))
+	return err
+}
+
+// WriterSink 把交互日志以JSON Lines格式写入外部日志，如标准输出或文件，由日志采集系统收集
+type WriterSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSink 创建写入w的日志目标
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(w)}
+}
+
+// Write 写入一条交互日志
+func (s *WriterSink) Write(ctx context.Context, entry *models.LLMInteraction) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(entry)
+}
+
+// Logger 大模型交互日志记录器
+// 写入失败只记录日志，不影响调用本身
+type Logger struct {
+	sink     Sink
+	redactor *Redactor
+	maxChars int
+	logger   *logrus.Logger
+}
+
+// Option 交互日志记录器配置选项
+type Option func(*Logger)
+
+// WithRedactor 设置脱敏器，提示词、回答和错误信息写入前脱敏
+func WithRedactor(redactor *Redactor) Option {
+	return func(l *Logger) {
+		l.redactor = redactor
+	}
+}
+
+// WithMaxChars 设置提示词和回答的最大字符数，超过时截断，不大于0时不截断
+func WithMaxChars(maxChars int) Option {
+	return func(l *Logger) {
+		l.maxChars = maxChars
+	}
+}
+
+// WithLogger 设置写入失败时使用的日志记录器
+func WithLogger(logger *logrus.Logger) Option {
+	return func(l *Logger) {
+		l.logger = logger
+	}
+}
+
+// New 创建交互日志记录器
+func New(sink Sink, opts ...Option) *Logger {
+	l := &Logger{
+		sink:   sink,
+		logger: logrus.StandardLogger(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Record 记录一次交互，租户、请求ID和检索段落ID从上下文中读取，记录器为nil时不做任何事
+func (l *Logger) Record(ctx context.Context, entry *models.LLMInteraction) {
+	if l == nil {
+		return
+	}
+
+	entry.Tenant = usage.TenantFromContext(ctx)
+	entry.RequestID = requestid.FromContext(ctx)
+	entry.ContextIDs = strings.Join(ContextIDsFromContext(ctx), ",")
+	entry.Prompt = l.truncate(l.redactor.Redact(entry.Prompt))
+	entry.Response = l.truncate(l.redactor.Redact(entry.Response))
+	entry.Error = l.redactor.Redact(entry.Error)
+
+	if err := l.sink.Write(ctx, entry); err != nil {
+		l.logger.WithContext(ctx).WithError(err).WithField("model", entry.Model).Warn("Failed to write LLM interaction log")
+	}
+}
+
+// truncate 截断超过长度上限的文本
+func (l *Logger) truncate(text string) string {
+	if l.maxChars <= 0 || utf8.RuneCountInString(text) <= l.maxChars {
+		return text
+	}
+	return string([]rune(text)[:l.maxChars]) + truncatedSuffix
+}
+
+// contextIDsKey 上下文中保存检索段落ID的键
+type contextIDsKey struct{}
+
+// WithContextIDs 返回带有检索段落ID的上下文，随后的大模型调用在交互日志中记录这些ID
+func WithContextIDs(ctx context.Context, ids []string) context.Context {
+	if len(ids) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, contextIDsKey{}, ids)
+}
+
+// ContextIDsFromContext 读取上下文中的检索段落ID，未设置时返回nil
+func ContextIDsFromContext(ctx context.Context) []string {
+	ids, _ := ctx.Value(contextIDsKey{}).([]string)
+	return ids
+}
@@ -0,0 +1,146 @@
+package llmlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupLLMLogTestDB 创建内存数据库并替换全局连接
+func setupLLMLogTestDB(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_llmlog_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.LLMInteraction{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+}
+
+// decodeEntries 解析WriterSink写出的JSON Lines
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []models.LLMInteraction {
+	var entries []models.LLMInteraction
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry models.LLMInteraction
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestRedactor 测试内置规则和自定义规则的脱敏效果
+func TestRedactor(t *testing.T) {
+	redactor, err := NewRedactor(BuiltinRules(), []string{`sk-[A-Za-z0-9]{8,}`})
+	require.NoError(t, err)
+
+	text := "联系张三 zhangsan@example.com，手机+86 13812345678，身份证11010519491231002X，" +
+		"银行卡6222 0212 3456 7890 123，来自192.168.1.10，密钥sk-abcdef123456"
+	assert.Equal(t, "联系张三 [EMAIL]，手机[PHONE]，身份证[ID_CARD]，"+
+		"银行卡[BANK_CARD]，来自[IP]，密钥[REDACTED]", redactor.Redact(text))
+
+	// 只启用部分规则时其余内容保持不变
+	redactor, err = NewRedactor([]string{"email"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[EMAIL] 13812345678", redactor.Redact("a@b.cn 13812345678"))
+
+	var none *Redactor
+	assert.Equal(t, "a@b.cn", none.Redact("a@b.cn"))
+
+	_, err = NewRedactor([]string{"ssn"}, nil)
+	assert.ErrorContains(t, err, `unknown redaction rule "ssn"`)
+	_, err = NewRedactor(nil, []string{"("})
+	assert.ErrorContains(t, err, "invalid redaction pattern")
+}
+
+// TestWrapLLM 测试包装后的客户端记录租户、请求ID、检索段落ID，并脱敏和截断提示词
+func TestWrapLLM(t *testing.T) {
+	mockClient := llm.NewMockClient(t)
+	mockClient.EXPECT().Name().Return(llm.ModelQwenPlus)
+	mockClient.EXPECT().Generate(mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "请联系 help@example.com", TokenCount: 42, ModelName: llm.ModelQwenTurbo}, nil).Once()
+	mockClient.EXPECT().Chat(mock.Anything, mock.Anything).Return(nil, errors.New("rate limited")).Once()
+
+	var buf bytes.Buffer
+	redactor, err := NewRedactor([]string{"email"}, nil)
+	require.NoError(t, err)
+	client := WrapLLM(mockClient, New(NewWriterSink(&buf), WithRedactor(redactor), WithMaxChars(30)))
+
+	ctx := usage.WithTenant(context.Background(), "team-a")
+	ctx = requestid.WithRequestID(ctx, "req-1")
+	ctx = WithContextIDs(ctx, []string{"doc1_0", "doc2_3"})
+	resp, err := client.Generate(ctx, "我的邮箱是 me@example.com，"+strings.Repeat("问", 30))
+	require.NoError(t, err)
+	// 调用方拿到的回答不受脱敏影响
+	assert.Equal(t, "请联系 help@example.com", resp.Text)
+
+	_, err = client.Chat(context.Background(), []llm.Message{
+		{Role: llm.RoleSystem, Content: "你是助手"},
+		{Role: llm.RoleUser, Content: "你好"},
+	})
+	assert.Error(t, err)
+
+	entries := decodeEntries(t, &buf)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "team-a", entries[0].Tenant)
+	assert.Equal(t, "req-1", entries[0].RequestID)
+	assert.Equal(t, llm.ModelQwenTurbo, entries[0].Model)
+	assert.Equal(t, MethodGenerate, entries[0].Method)
+	assert.Equal(t, "doc1_0,doc2_3", entries[0].ContextIDs)
+	assert.Equal(t, "我的邮箱是 [EMAIL]，"+strings.Repeat("问", 16)+truncatedSuffix, entries[0].Prompt)
+	assert.Equal(t, "请联系 [EMAIL]", entries[0].Response)
+	assert.Equal(t, 42, entries[0].Tokens)
+
+	assert.Equal(t, usage.AnonymousTenant, entries[1].Tenant)
+	assert.Equal(t, llm.ModelQwenPlus, entries[1].Model)
+	assert.Equal(t, MethodChat, entries[1].Method)
+	assert.Equal(t, "system: 你是助手\nuser: 你好", entries[1].Prompt)
+	assert.Equal(t, "rate limited", entries[1].Error)
+	assert.Empty(t, entries[1].ContextIDs)
+
+	// 未启用时返回原客户端
+	assert.Same(t, mockClient, WrapLLM(mockClient, nil))
+}
+
+// TestRepositorySink 测试写入数据库并清理过期日志
+func TestRepositorySink(t *testing.T) {
+	setupLLMLogTestDB(t)
+	repo := repository.NewLLMLogRepository()
+
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Append(&models.LLMInteraction{Tenant: "team-a", Model: "old", CreatedAt: now.AddDate(0, 0, -40)}))
+
+	sink := NewRepositorySink(repo, 30*24*time.Hour)
+	sink.now = func() time.Time { return now }
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	New(sink, WithLogger(logger)).Record(usage.WithTenant(context.Background(), "team-a"),
+		&models.LLMInteraction{Model: llm.ModelQwenPlus, Method: MethodGenerate, Prompt: "问题", CreatedAt: now})
+
+	entries, total, err := repo.List(repository.LLMLogFilter{Tenant: "team-a"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, entries, 1)
+	assert.Equal(t, llm.ModelQwenPlus, entries[0].Model)
+}
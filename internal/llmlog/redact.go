@@ -0,0 +1,87 @@
+package llmlog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactRule 脱敏规则，命中的内容替换为占位符
+type redactRule struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// builtinRules 内置的个人信息脱敏规则，按顺序执行
+// 身份证号在银行卡号之前匹配，避免18位身份证号被当作银行卡号
+var builtinRules = []redactRule{
+	{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{"id_card", regexp.MustCompile(`\b\d{17}[\dXx]\b`), "[ID_CARD]"},
+	{"phone", regexp.MustCompile(`(?:\+?86[- ]?)?\b1[3-9]\d{9}\b`), "[PHONE]"},
+	{"bank_card", regexp.MustCompile(`\b(?:\d[ -]?){15,18}\d\b`), "[BANK_CARD]"},
+	{"ip", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "[IP]"},
+}
+
+// customReplacement 自定义规则命中内容的占位符
+const customReplacement = "[REDACTED]"
+
+// BuiltinRules 返回内置脱敏规则的名称
+func BuiltinRules() []string {
+	names := make([]string, len(builtinRules))
+	for i, rule := range builtinRules {
+		names[i] = rule.name
+	}
+	return names
+}
+
+// Redactor 按规则脱敏提示词和回答中的个人信息
+type Redactor struct {
+	rules []redactRule
+}
+
+// NewRedactor 创建脱敏器
+// rules为启用的内置规则名称（email、id_card、phone、bank_card、ip），patterns为自定义正则表达式，
+// 命中自定义规则的内容替换为[REDACTED]
+func NewRedactor(rules []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, rule := range builtinRules {
+		if contains(rules, rule.name) {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	for _, name := range rules {
+		if !contains(BuiltinRules(), name) {
+			return nil, fmt.Errorf("unknown redaction rule %q, use %s", name, strings.Join(BuiltinRules(), ", "))
+		}
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.rules = append(r.rules, redactRule{name: "custom", pattern: re, replacement: customReplacement})
+	}
+	return r, nil
+}
+
+// Redact 返回脱敏后的文本，脱敏器为nil时原样返回
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	return text
+}
+
+// contains 判断字符串切片是否包含指定值
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
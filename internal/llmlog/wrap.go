@@ -0,0 +1,81 @@
+package llmlog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+)
+
+// 交互日志中的调用方式
+const (
+	MethodGenerate = "generate"
+	MethodChat     = "chat"
+)
+
+// WrapLLM 包装大模型客户端，记录每次调用的提示词、回答、耗时和token数
+// 记录器为nil时直接返回原客户端
+func WrapLLM(client llm.Client, logger *Logger) llm.Client {
+	if logger == nil {
+		return client
+	}
+	return &llmClient{Client: client, logger: logger}
+}
+
+// llmClient 记录交互日志的大模型客户端
+type llmClient struct {
+	llm.Client
+	logger *Logger
+}
+
+func (c *llmClient) Generate(ctx context.Context, prompt string, options ...llm.GenerateOption) (*llm.Response, error) {
+	start := time.Now()
+	resp, err := c.Client.Generate(ctx, prompt, options...)
+	c.record(ctx, MethodGenerate, prompt, start, resp, err)
+	return resp, err
+}
+
+func (c *llmClient) Chat(ctx context.Context, messages []llm.Message, options ...llm.ChatOption) (*llm.Response, error) {
+	start := time.Now()
+	resp, err := c.Client.Chat(ctx, messages, options...)
+	c.record(ctx, MethodChat, formatMessages(messages), start, resp, err)
+	return resp, err
+}
+
+func (c *llmClient) record(ctx context.Context, method, prompt string, start time.Time, resp *llm.Response, err error) {
+	entry := &models.LLMInteraction{
+		Model:     c.Client.Name(),
+		Method:    method,
+		Prompt:    prompt,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if resp != nil {
+		entry.Response = resp.Text
+		entry.Tokens = resp.TokenCount
+		if resp.ModelName != "" {
+			entry.Model = resp.ModelName
+		}
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.logger.Record(ctx, entry)
+}
+
+// formatMessages 把对话消息按行拼接为“角色: 内容”，工具调用记录工具名称和参数
+func formatMessages(messages []llm.Message) string {
+	var b strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s: %s", msg.Role, msg.Content)
+		for _, call := range msg.ToolCalls {
+			fmt.Fprintf(&b, " [tool_call %s(%s)]", call.Name, call.Arguments)
+		}
+	}
+	return b.String()
+}
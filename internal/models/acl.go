@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// 文档共享对象的类型
+const (
+	ShareKindUser  = "user"  // 共享给用户（租户）
+	ShareKindGroup = "group" // 共享给用户组
+)
+
+// DocumentShare 文档共享记录
+// 文档的上传者、公开的文档和共享对象可以读取文档，启用访问控制后在列表、读取和问答检索中检查
+type DocumentShare struct {
+	DocumentID string    `gorm:"primaryKey;size:64"`        // 文档ID
+	Kind       string    `gorm:"primaryKey;size:10"`        // 共享对象类型：user或group
+	Name       string    `gorm:"primaryKey;size:100;index"` // 用户（租户）标识或用户组名称
+	CreatedAt  time.Time `gorm:"not null"`                  // 共享时间
+}
+
+// TableName 明确指定表名
+func (DocumentShare) TableName() string {
+	return "document_shares"
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditAction 审计操作类型
+type AuditAction string
+
+const (
+	// AuditActionDeleteDocument 删除文档
+	AuditActionDeleteDocument AuditAction = "document.delete"
+	// AuditActionUpdateTags 修改文档标签
+	AuditActionUpdateTags AuditAction = "document.update_tags"
+	// AuditActionUpdateRetrieval 修改文档是否参与检索
+	AuditActionUpdateRetrieval AuditAction = "document.update_retrieval"
+	// AuditActionUpdateACL 修改文档访问控制
+	AuditActionUpdateACL AuditAction = "document.update_acl"
+	// AuditActionClearCache 清除问答缓存
+	AuditActionClearCache AuditAction = "cache.clear"
+	// AuditActionDeleteChat 删除聊天会话
+	AuditActionDeleteChat AuditAction = "chat.delete"
+	// AuditActionStorageGC 清理孤立的存储文件和向量
+	AuditActionStorageGC AuditAction = "storage.gc"
+	// AuditActionCalibrate 重新校准最低相似度
+	AuditActionCalibrate AuditAction = "search.calibrate"
+)
+
+// AuditLog 审计日志模型
+// 记录删除、清理等破坏性和管理类操作，只追加不修改
+type AuditLog struct {
+	ID        uint        `gorm:"primaryKey;autoIncrement"` // 主键ID
+	Actor     string      `gorm:"size:100;not null;index"`  // 操作者，即租户或API密钥标识
+	Action    AuditAction `gorm:"size:50;not null;index"`   // 操作类型
+	Target    string      `gorm:"size:255;index"`           // 操作对象，如文档ID、会话ID
+	Detail    string      `gorm:"type:text"`                // 操作详情
+	RequestID string      `gorm:"size:64"`                  // 请求ID，用于关联请求日志
+	CreatedAt time.Time   `gorm:"not null;index"`           // 操作时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName 明确指定表名
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
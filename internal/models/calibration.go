@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ScoreCalibration 检索相关度阈值的校准结果
+// 每个嵌入模型一行，不同模型的相似度分布差异很大，最低相似度按模型分别校准
+type ScoreCalibration struct {
+	Model        string    `gorm:"primaryKey;size:200"` // 嵌入模型名称
+	Threshold    float32   `gorm:"not null"`            // 校准得到的最低相似度
+	Percentile   float64   `gorm:"not null"`            // 取阈值时使用的百分位
+	Questions    int       `gorm:"not null;default:0"`  // 参与校准的问题数
+	Samples      int       `gorm:"not null;default:0"`  // 参与校准的相似度分数个数
+	MinScore     float32   // 分数分布的最小值
+	MedianScore  float32   // 分数分布的中位数
+	MaxScore     float32   // 分数分布的最大值
+	CalibratedAt time.Time `gorm:"not null"` // 校准时间
+}
+
+// TableName 明确指定表名
+func (ScoreCalibration) TableName() string {
+	return "score_calibrations"
+}
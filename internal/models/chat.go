@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
@@ -29,6 +30,68 @@ type ChatSession struct {
 	UserID    string         `gorm:"index"`             // 用户标识，可选
 	Tags      string         `gorm:"type:varchar(255)"` // 标签，逗号分隔
 	Metadata  datatypes.JSON `gorm:"type:json"`         // 元数据，JSON格式
+
+	// DocumentIDs 会话绑定的文档ID，逗号分隔，为空时在全部文档中检索
+	DocumentIDs string `gorm:"type:text"`
+	// GroupIDs 会话绑定的文档组ID，逗号分隔
+	GroupIDs string `gorm:"type:text"`
+
+	Summary          string     `gorm:"type:text"` // 会话摘要，作为追问时的对话记忆
+	SummaryMessageID uint       // 摘要覆盖到的最后一条消息ID，之后的消息在下次摘要时合并
+	SummarizedAt     *time.Time // 最近一次生成摘要的时间
+
+	// SystemPrompt 会话的系统提示词，设定助手在该会话中的角色，加在所有回答的提示词开头
+	SystemPrompt string `gorm:"type:text"`
+}
+
+// MaxSystemPromptRunes 会话系统提示词的最大字符数
+const MaxSystemPromptRunes = 2000
+
+// ScopeDocumentIDs 返回会话绑定的文档ID
+func (cs *ChatSession) ScopeDocumentIDs() []string {
+	return splitIDs(cs.DocumentIDs)
+}
+
+// ScopeGroupIDs 返回会话绑定的文档组ID
+func (cs *ChatSession) ScopeGroupIDs() []string {
+	return splitIDs(cs.GroupIDs)
+}
+
+// Scoped 判断会话是否绑定了文档或文档组
+func (cs *ChatSession) Scoped() bool {
+	return strings.TrimSpace(cs.DocumentIDs) != "" || strings.TrimSpace(cs.GroupIDs) != ""
+}
+
+// SetScope 设置会话绑定的文档和文档组，去掉空白和重复的ID，两者都为空时解除绑定
+func (cs *ChatSession) SetScope(documentIDs, groupIDs []string) {
+	cs.DocumentIDs = joinIDs(documentIDs)
+	cs.GroupIDs = joinIDs(groupIDs)
+}
+
+// splitIDs 拆分逗号分隔的ID
+func splitIDs(ids string) []string {
+	var result []string
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// joinIDs 以逗号连接ID，去掉空白和重复的ID
+func joinIDs(ids []string) string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return strings.Join(result, ",")
 }
 
 // BeforeCreate GORM的钩子函数，创建记录前自动设置时间
@@ -62,6 +125,9 @@ type ChatMessage struct {
 	CreatedAt time.Time      `gorm:"not null"`                  // 创建时间
 	Metadata  datatypes.JSON `gorm:"type:json"`                 // 元数据
 	Sources   datatypes.JSON `gorm:"type:json"`                 // 引用的信息源
+	Version   int            `gorm:"not null;default:1"`        // 版本号，每次编辑或重新生成后加1
+	EditedAt  *time.Time     // 最后一次编辑或重新生成的时间
+	PinnedAt  *time.Time     `gorm:"index"` // 收藏时间，为空时未收藏
 }
 
 // BeforeCreate GORM的钩子函数，创建记录前自动设置时间
@@ -77,6 +143,23 @@ func (ChatMessage) TableName() string {
 	return "chat_messages"
 }
 
+// ChatMessageVersion 聊天消息的历史版本
+// 编辑用户消息或重新生成助手回复时，保存被替换的内容
+type ChatMessageVersion struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement"` // 主键ID
+	MessageID uint           `gorm:"not null;index"`           // 所属消息ID
+	SessionID string         `gorm:"not null;index"`           // 所属会话ID，删除会话时一并删除
+	Version   int            `gorm:"not null"`                 // 被替换内容的版本号
+	Content   string         `gorm:"type:text;not null"`       // 被替换的内容
+	Sources   datatypes.JSON `gorm:"type:json"`                // 被替换内容引用的信息源
+	CreatedAt time.Time      `gorm:"not null"`                 // 被替换的时间
+}
+
+// TableName 明确指定表名
+func (ChatMessageVersion) TableName() string {
+	return "chat_message_versions"
+}
+
 // Source 表示消息引用的信息源
 type Source struct {
 	FileID   string  `json:"file_id"`         // 文件ID
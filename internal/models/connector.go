@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ConnectorState 外部来源连接器的同步状态
+// 每个配置的连接器一行，保存增量同步的游标和最近一次同步的结果
+type ConnectorState struct {
+	Name       string     `gorm:"primaryKey;size:100"` // 连接器名称
+	Cursor     string     `gorm:"type:text"`           // 增量同步游标，为空时下次全量同步
+	LastSyncAt *time.Time // 最近一次同步完成的时间
+	LastError  string     `gorm:"type:text"` // 最近一次同步的错误信息，成功时为空
+	UpdatedAt  time.Time  // 更新时间
+}
+
+// TableName 明确指定表名
+func (ConnectorState) TableName() string {
+	return "connector_states"
+}
+
+// ConnectorItem 已同步的外部条目与文档的对应关系
+// 版本号用于判断条目内容是否变化，未变化时跳过下载
+type ConnectorItem struct {
+	Connector  string    `gorm:"primaryKey;size:100"`    // 连接器名称
+	ItemID     string    `gorm:"primaryKey;size:255"`    // 条目在来源中的ID
+	DocumentID string    `gorm:"size:64;not null;index"` // 对应的文档ID
+	Version    string    `gorm:"size:255"`               // 已同步的条目版本
+	SyncedAt   time.Time // 最近一次同步内容的时间
+}
+
+// TableName 明确指定表名
+func (ConnectorItem) TableName() string {
+	return "connector_items"
+}
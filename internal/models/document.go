@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
@@ -38,25 +40,44 @@ const (
 // Document 文档数据模型
 // 用于存储文档的元数据信息
 type Document struct {
-	ID             string         `gorm:"primaryKey"`         // 文档ID，主键
-	FileName       string         `gorm:"not null"`           // 文件名
-	FileType       string         `gorm:"not null"`           // 文件类型
-	FilePath       string         `gorm:"not null"`           // 文件路径
-	FileSize       int64          `gorm:"not null"`           // 文件大小（字节）
-	Status         DocumentStatus `gorm:"not null;index"`     // 处理状态
-	UploadedAt     time.Time      `gorm:"not null;index"`     // 上传时间
-	ProcessedAt    *time.Time     `gorm:"index"`              // 处理完成时间
-	UpdatedAt      time.Time      `gorm:"not null;index"`     // 更新时间
-	Progress       int            `gorm:"not null;default:0"` // 处理进度（0-100）
-	Error          string         `gorm:"type:text"`          // 错误信息
-	SegmentCount   int            `gorm:"not null;default:0"` // 文档分段数量
-	Tags           string         `gorm:"type:varchar(255)"`  // 标签，逗号分隔
-	Metadata       datatypes.JSON `gorm:"type:json"`          // 元数据，JSON格式
-	CurrentStage   ProcessStage   `gorm:"size:20"`            // 当前处理阶段
-	CurrentTaskID  string         `gorm:"size:50;index"`      // 当前关联的任务ID
-	PythonService  string         `gorm:"size:50"`            // 处理的Python服务名称
-	LastTaskStatus string         `gorm:"size:20"`            // 最后任务的状态
-	RetryCount     int            `gorm:"default:0"`          // 重试次数
+	ID             string         `gorm:"primaryKey"`                   // 文档ID，主键
+	FileName       string         `gorm:"not null"`                     // 文件名
+	FileType       string         `gorm:"not null"`                     // 文件类型
+	FilePath       string         `gorm:"not null"`                     // 文件路径
+	FileSize       int64          `gorm:"not null"`                     // 文件大小（字节）
+	Status         DocumentStatus `gorm:"not null;index"`               // 处理状态
+	UploadedAt     time.Time      `gorm:"not null;index"`               // 上传时间
+	ProcessedAt    *time.Time     `gorm:"index"`                        // 处理完成时间
+	UpdatedAt      time.Time      `gorm:"not null;index"`               // 更新时间
+	Progress       int            `gorm:"not null;default:0"`           // 处理进度（0-100）
+	Error          string         `gorm:"type:text"`                    // 错误信息
+	SegmentCount   int            `gorm:"not null;default:0"`           // 文档分段数量
+	Tags           string         `gorm:"type:varchar(255)"`            // 标签，逗号分隔
+	Metadata       datatypes.JSON `gorm:"type:json"`                    // 元数据，JSON格式
+	CurrentStage   ProcessStage   `gorm:"size:20"`                      // 当前处理阶段
+	CurrentTaskID  string         `gorm:"size:50;index"`                // 当前关联的任务ID
+	PythonService  string         `gorm:"size:50"`                      // 处理的Python服务名称
+	LastTaskStatus string         `gorm:"size:20"`                      // 最后任务的状态
+	RetryCount     int            `gorm:"default:0"`                    // 重试次数
+	Tenant         string         `gorm:"size:100;index"`               // 上传文档的租户，用于配额统计
+	Summary        datatypes.JSON `gorm:"type:json"`                    // 文档摘要（DocumentSummary），未生成时为空
+	Images         datatypes.JSON `gorm:"type:json"`                    // 从文档中提取的图片（[]DocumentImage），未提取时为空
+	ParentID       string         `gorm:"size:64;index"`                // 从压缩包或邮件中展开的子文档所属的父文档ID
+	NoRetrieve     bool           `gorm:"not null;default:false;index"` // 为true时不参与问答检索，如归档的草稿
+	Public         bool           `gorm:"not null;default:false;index"` // 启用访问控制后所有人都可以读取
+}
+
+// HasTag 判断文档是否带有指定标签
+func (d *Document) HasTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	for _, t := range strings.Split(d.Tags, ",") {
+		if strings.TrimSpace(t) == tag {
+			return true
+		}
+	}
+	return false
 }
 
 // BeforeCreate GORM的钩子函数，创建记录前自动设置时间
@@ -81,6 +102,66 @@ func (Document) TableName() string {
 	return "documents"
 }
 
+// MetaLanguage 文档元数据中保存识别出的语言（ISO 639-1代码）的键
+const MetaLanguage = "language"
+
+// Language 返回处理时识别出的文档语言，未识别时返回空字符串
+func (d *Document) Language() string {
+	if len(d.Metadata) == 0 {
+		return ""
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(d.Metadata, &metadata); err != nil {
+		return ""
+	}
+	lang, _ := metadata[MetaLanguage].(string)
+	return lang
+}
+
+// DocumentSummary 大模型生成的文档摘要
+type DocumentSummary struct {
+	Summary      string    `json:"summary"`       // 摘要正文
+	KeyPoints    []string  `json:"key_points"`    // 要点
+	SegmentCount int       `json:"segment_count"` // 生成摘要时的段落数量
+	GeneratedAt  time.Time `json:"generated_at"`  // 生成时间
+}
+
+// StoredSummary 返回保存的文档摘要，文档在生成摘要后重新处理过时视为过期，返回nil
+func (d *Document) StoredSummary() *DocumentSummary {
+	if len(d.Summary) == 0 {
+		return nil
+	}
+	var summary DocumentSummary
+	if err := json.Unmarshal(d.Summary, &summary); err != nil || summary.Summary == "" {
+		return nil
+	}
+	if summary.SegmentCount != d.SegmentCount || (d.ProcessedAt != nil && d.ProcessedAt.After(summary.GeneratedAt)) {
+		return nil
+	}
+	return &summary
+}
+
+// DocumentImage 从文档中提取并保存到存储的图片
+type DocumentImage struct {
+	ID       string `json:"id"`        // 图片在存储中的ID
+	Name     string `json:"name"`      // 图片在原文档中的文件名
+	MimeType string `json:"mime_type"` // 图片类型
+	Hash     string `json:"hash"`      // 图片内容的SHA-256，重新处理时复用未变化的图片和描述
+	Caption  string `json:"caption"`   // 视觉模型生成的图片描述
+}
+
+// StoredImages 返回文档中提取的图片
+func (d *Document) StoredImages() []DocumentImage {
+	if len(d.Images) == 0 {
+		return nil
+	}
+	var images []DocumentImage
+	if err := json.Unmarshal(d.Images, &images); err != nil {
+		return nil
+	}
+	return images
+}
+
 // DocumentSegment 文档分段数据模型
 // 用于在数据库中跟踪文档的文本段落
 type DocumentSegment struct {
@@ -89,11 +170,21 @@ type DocumentSegment struct {
 	SegmentID  string         `gorm:"not null;uniqueIndex"`     // 段落唯一ID
 	Position   int            `gorm:"not null"`                 // 段落位置
 	Text       string         `gorm:"type:text;not null"`       // 段落文本内容
+	TextHash   string         `gorm:"size:64;index"`            // 段落文本的SHA-256，重新处理时用于判断段落是否变化
 	CreatedAt  time.Time      `gorm:"not null"`                 // 创建时间
 	UpdatedAt  time.Time      `gorm:"not null"`                 // 更新时间
 	Metadata   datatypes.JSON `gorm:"type:json"`                // 段落元数据
 	TaskID     string         `gorm:"size:50;index"`            // 处理此段落的任务ID
 	VectorID   string         `gorm:"size:50"`                  // 向量数据库中的ID
+
+	// 近似重复检测：Fingerprint为段落文本的SimHash（按位转为int64保存），
+	// DuplicateOf为近似重复的原段落ID，Suppressed表示作为重复段落未写入向量库
+	Fingerprint int64  `gorm:"index"`
+	DuplicateOf string `gorm:"size:100;index"`
+	Suppressed  bool   `gorm:"not null;default:false"`
+
+	// ParentID 所在章节第一个段落的ID，同一章节的段落互为兄弟段落，为空表示不属于任何章节
+	ParentID string `gorm:"size:100;index"`
 }
 
 // BeforeCreate GORM的钩子函数，创建记录前自动设置时间
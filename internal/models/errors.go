@@ -8,4 +8,16 @@ var (
 
 	// ErrInvalidDocumentStatus 无效的文档状态错误
 	ErrInvalidDocumentStatus = errors.New("invalid document status")
+
+	// ErrSegmentNotFound 段落不存在错误
+	ErrSegmentNotFound = errors.New("segment not found")
+
+	// ErrUnsupportedFileType 不支持的文件类型错误
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+
+	// ErrQuotaExceeded 超出配额错误
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrAccessDenied 没有权限修改文档错误
+	ErrAccessDenied = errors.New("access denied")
 )
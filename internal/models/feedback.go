@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SuppressionStatus 检索抑制状态
+type SuppressionStatus string
+
+const (
+	// SuppressionPending 已收到负反馈，但次数未达到阈值，不影响检索
+	SuppressionPending SuppressionStatus = "pending"
+	// SuppressionActive 生效中，检索时对段落降权
+	SuppressionActive SuppressionStatus = "active"
+	// SuppressionDismissed 管理员审核后驳回，不再自动生效
+	SuppressionDismissed SuppressionStatus = "dismissed"
+)
+
+// QuestionCluster 问题簇模型
+// 语义相近的问题归为同一簇，负反馈和检索抑制都以簇为单位统计
+type QuestionCluster struct {
+	ID            string    `gorm:"primaryKey"`                // 问题簇ID，主键
+	Question      string    `gorm:"type:text;not null"`        // 代表问题，即簇中的第一个问题
+	Embedding     []float32 `gorm:"type:text;serializer:json"` // 簇中心向量，为所有问题向量的均值
+	QuestionCount int       `gorm:"not null;default:0"`        // 归入该簇的反馈次数
+	CreatedAt     time.Time `gorm:"not null"`                  // 创建时间
+	UpdatedAt     time.Time `gorm:"not null"`                  // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (c *QuestionCluster) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate GORM的钩子函数，更新记录前自动设置更新时间
+func (c *QuestionCluster) BeforeUpdate(tx *gorm.DB) (err error) {
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// TableName 明确指定表名
+func (QuestionCluster) TableName() string {
+	return "question_clusters"
+}
+
+// AnswerFeedback 回答负反馈模型
+// 每条记录表示用户把某个回答来源段落标记为错误
+type AnswerFeedback struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"` // 主键ID
+	ClusterID string    `gorm:"size:50;not null;index"`   // 所属问题簇ID
+	Question  string    `gorm:"type:text;not null"`       // 用户问题
+	SegmentID string    `gorm:"size:200;not null;index"`  // 被标记错误的段落ID
+	Comment   string    `gorm:"type:text"`                // 用户备注
+	Variant   string    `gorm:"size:100;index"`           // 生成回答时使用的提示词变体，未做对比实验时为空
+	CreatedAt time.Time `gorm:"not null;index"`           // 创建时间
+}
+
+// TableName 明确指定表名
+func (AnswerFeedback) TableName() string {
+	return "answer_feedback"
+}
+
+// RetrievalSuppression 检索抑制模型
+// 同一问题簇中被多次标记错误的段落，在后续检索该簇的问题时会被降权
+type RetrievalSuppression struct {
+	ID         uint              `gorm:"primaryKey;autoIncrement"`                      // 主键ID
+	ClusterID  string            `gorm:"size:50;not null;uniqueIndex:idx_suppression"`  // 问题簇ID
+	SegmentID  string            `gorm:"size:200;not null;uniqueIndex:idx_suppression"` // 段落ID
+	FileID     string            `gorm:"size:100;index"`                                // 段落所属文档ID
+	FileName   string            `gorm:"size:255"`                                      // 段落所属文件名
+	Text       string            `gorm:"type:text"`                                     // 段落内容，便于审核
+	WrongCount int               `gorm:"not null;default:0"`                            // 被标记错误的次数
+	Status     SuppressionStatus `gorm:"size:20;not null;index"`                        // 抑制状态
+	ReviewedAt *time.Time        // 管理员审核时间
+	CreatedAt  time.Time         `gorm:"not null"` // 创建时间
+	UpdatedAt  time.Time         `gorm:"not null"` // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (s *RetrievalSuppression) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (RetrievalSuppression) TableName() string {
+	return "retrieval_suppressions"
+}
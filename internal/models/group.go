@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DocumentGroup 文档组模型
+// 把相关的多个文件（如合同及其补充协议）组织为一个逻辑文档，问答时以组为检索范围
+type DocumentGroup struct {
+	ID          string                `gorm:"primaryKey"`         // 文档组ID，主键
+	Name        string                `gorm:"not null"`           // 文档组名称
+	Description string                `gorm:"type:text"`          // 描述
+	CreatedAt   time.Time             `gorm:"not null"`           // 创建时间
+	UpdatedAt   time.Time             `gorm:"not null;index"`     // 更新时间
+	Members     []DocumentGroupMember `gorm:"foreignKey:GroupID"` // 组成员，按Position排序
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (g *DocumentGroup) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = now
+	}
+	g.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate GORM的钩子函数，更新记录前自动设置更新时间
+func (g *DocumentGroup) BeforeUpdate(tx *gorm.DB) (err error) {
+	g.UpdatedAt = time.Now()
+	return nil
+}
+
+// TableName 明确指定表名
+func (DocumentGroup) TableName() string {
+	return "document_groups"
+}
+
+// DocumentGroupMember 文档组成员
+// 一个文档可以属于多个文档组
+type DocumentGroupMember struct {
+	GroupID    string    `gorm:"primaryKey;size:64"`       // 所属文档组ID
+	DocumentID string    `gorm:"primaryKey;size:64;index"` // 成员文档ID
+	Label      string    `gorm:"size:255"`                 // 成员标签，如"原合同"、"第一次修订"，回答时用于标注出处
+	Position   int       `gorm:"not null;default:0"`       // 成员在组内的顺序
+	CreatedAt  time.Time `gorm:"not null"`                 // 加入时间
+}
+
+// TableName 明确指定表名
+func (DocumentGroupMember) TableName() string {
+	return "document_group_members"
+}
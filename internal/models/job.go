@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JobRunStatus 定时任务运行状态
+type JobRunStatus string
+
+const (
+	// JobRunRunning 运行中
+	JobRunRunning JobRunStatus = "running"
+	// JobRunSucceeded 运行成功
+	JobRunSucceeded JobRunStatus = "succeeded"
+	// JobRunFailed 运行失败
+	JobRunFailed JobRunStatus = "failed"
+	// JobRunSkipped 上一次运行尚未结束，本次跳过
+	JobRunSkipped JobRunStatus = "skipped"
+)
+
+// JobRun 定时任务运行记录模型
+// 每次触发（定时或手动）记录一行，用于查看任务的执行历史
+type JobRun struct {
+	ID         uint         `gorm:"primaryKey;autoIncrement"` // 主键ID
+	JobName    string       `gorm:"size:100;not null;index"`  // 任务名称
+	JobType    string       `gorm:"size:50;not null"`         // 任务类型
+	Trigger    string       `gorm:"size:20;not null"`         // 触发方式：schedule或manual
+	Status     JobRunStatus `gorm:"size:20;not null;index"`   // 运行状态
+	Processed  int          `gorm:"not null;default:0"`       // 检查的文档数量
+	Enqueued   int          `gorm:"not null;default:0"`       // 投递或执行的任务数量
+	Failed     int          `gorm:"not null;default:0"`       // 失败数量
+	Message    string       `gorm:"type:text"`                // 运行信息或错误信息
+	StartedAt  time.Time    `gorm:"not null;index"`           // 开始时间
+	FinishedAt *time.Time   // 结束时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置开始时间
+func (r *JobRun) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.StartedAt.IsZero() {
+		r.StartedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName 明确指定表名
+func (JobRun) TableName() string {
+	return "job_runs"
+}
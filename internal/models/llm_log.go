@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LLMInteraction 大模型交互日志模型
+// 记录一次大模型调用的提示词、检索上下文、回答、耗时和token数，用于排查错误回答和调整提示词；
+// 提示词和回答按配置的规则脱敏后写入
+type LLMInteraction struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`        // 主键ID
+	Tenant     string    `gorm:"size:100;not null;index" json:"tenant"`     // 租户
+	RequestID  string    `gorm:"size:64;index" json:"request_id,omitempty"` // 请求ID，用于关联请求日志
+	Model      string    `gorm:"size:100;index" json:"model"`               // 模型名称
+	Method     string    `gorm:"size:20" json:"method"`                     // 调用方式：generate或chat
+	Prompt     string    `gorm:"type:text" json:"prompt"`                   // 提示词，对话为按行拼接的各条消息
+	ContextIDs string    `gorm:"type:text" json:"context_ids,omitempty"`    // 放入提示词的检索段落ID，逗号分隔
+	Response   string    `gorm:"type:text" json:"response,omitempty"`       // 回答
+	Error      string    `gorm:"type:text" json:"error,omitempty"`          // 调用失败时的错误信息
+	LatencyMs  int64     `gorm:"not null;default:0" json:"latency_ms"`      // 耗时（毫秒）
+	Tokens     int       `gorm:"not null;default:0" json:"tokens"`          // 消耗的token数
+	CreatedAt  time.Time `gorm:"not null;index" json:"created_at"`          // 调用时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (l *LLMInteraction) BeforeCreate(tx *gorm.DB) (err error) {
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now()
+	}
+	return nil
+}
+
+// TableName 明确指定表名
+func (LLMInteraction) TableName() string {
+	return "llm_interactions"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QuotaMetric 配额计量项
+type QuotaMetric string
+
+const (
+	// QuotaMetricDocuments 文档数量
+	QuotaMetricDocuments QuotaMetric = "documents"
+	// QuotaMetricStorageBytes 文件存储字节数
+	QuotaMetricStorageBytes QuotaMetric = "storage_bytes"
+	// QuotaMetricQACalls 问答次数
+	QuotaMetricQACalls QuotaMetric = "qa_calls"
+)
+
+// QuotaPeriodTotal 累计型计量项（文档数、存储字节数）使用的周期标识
+const QuotaPeriodTotal = "total"
+
+// QuotaUsage 租户配额用量模型
+// 每个租户、计量项和周期一行；文档数和存储字节数为累计值，删除文档时扣减；问答次数按天计
+type QuotaUsage struct {
+	ID        uint        `gorm:"primaryKey;autoIncrement"`                    // 主键ID
+	Tenant    string      `gorm:"size:100;not null;uniqueIndex:idx_quota_key"` // 租户或API密钥标识
+	Metric    QuotaMetric `gorm:"size:20;not null;uniqueIndex:idx_quota_key"`  // 计量项
+	Period    string      `gorm:"size:10;not null;uniqueIndex:idx_quota_key"`  // 周期，累计型为total，按天计为2006-01-02
+	Used      int64       `gorm:"not null;default:0"`                          // 已用量
+	CreatedAt time.Time   `gorm:"not null"`                                    // 创建时间
+	UpdatedAt time.Time   `gorm:"not null"`                                    // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (q *QuotaUsage) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	q.CreatedAt = now
+	q.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (QuotaUsage) TableName() string {
+	return "quota_usages"
+}
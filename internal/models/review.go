@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DraftStatus 回答草稿状态
+type DraftStatus string
+
+const (
+	// DraftPending 待审核，回答尚未发布给提问者
+	DraftPending DraftStatus = "pending"
+	// DraftApproved 审核通过，回答已发布并收录为FAQ
+	DraftApproved DraftStatus = "approved"
+	// DraftRejected 审核驳回，回答不会发布
+	DraftRejected DraftStatus = "rejected"
+)
+
+// AnswerDraft 回答草稿模型
+// 命中需审核话题的问题，大模型生成的回答先保存为草稿，人工审核通过后才发布
+type AnswerDraft struct {
+	ID          string      `gorm:"primaryKey"`                // 草稿ID，主键
+	Question    string      `gorm:"type:text;not null"`        // 用户问题
+	QuestionKey string      `gorm:"size:500;not null;index"`   // 规范化后的问题，用于合并重复提问
+	Reader      string      `gorm:"size:500;index"`            // 提问者的读者键，为空表示提问者可以读取所有文档
+	Topic       string      `gorm:"size:100;index"`            // 命中的审核话题
+	Answer      string      `gorm:"type:text"`                 // 大模型生成的原始回答
+	FinalAnswer string      `gorm:"type:text"`                 // 审核通过后发布的回答，可能经过人工修改
+	SegmentIDs  []string    `gorm:"type:text;serializer:json"` // 回答来源段落ID
+	Status      DraftStatus `gorm:"size:20;not null;index"`    // 草稿状态
+	Reviewer    string      `gorm:"size:100"`                  // 审核人
+	Note        string      `gorm:"type:text"`                 // 审核备注，如驳回原因
+	ReviewedAt  *time.Time  // 审核时间
+	CreatedAt   time.Time   `gorm:"not null;index"` // 创建时间
+	UpdatedAt   time.Time   `gorm:"not null"`       // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (d *AnswerDraft) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	return nil
+}
+
+// BeforeUpdate GORM的钩子函数，更新记录前自动设置更新时间
+func (d *AnswerDraft) BeforeUpdate(tx *gorm.DB) (err error) {
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// TableName 明确指定表名
+func (AnswerDraft) TableName() string {
+	return "answer_drafts"
+}
+
+// CuratedAnswer 人工审核过的FAQ条目
+// 同一问题再次提问时直接返回，不再调用大模型
+type CuratedAnswer struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`  // 主键ID
+	Question    string    `gorm:"type:text;not null"`        // 问题
+	QuestionKey string    `gorm:"size:500;not null;unique"`  // 规范化后的问题，用于匹配
+	Reader      string    `gorm:"size:500;index"`            // 提问者的读者键，只有相同读者可以看到该条目
+	Topic       string    `gorm:"size:100;index"`            // 所属审核话题
+	Answer      string    `gorm:"type:text;not null"`        // 审核通过的回答
+	SegmentIDs  []string  `gorm:"type:text;serializer:json"` // 回答来源段落ID
+	DraftID     string    `gorm:"size:50"`                   // 最近一次审核通过的草稿ID
+	CreatedAt   time.Time `gorm:"not null"`                  // 创建时间
+	UpdatedAt   time.Time `gorm:"not null"`                  // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (a *CuratedAnswer) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (CuratedAnswer) TableName() string {
+	return "curated_answers"
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QADailyStat 问答统计模型
+// 每天一行，问答完成时累加，用于运维看板统计问答量、平均耗时和缓存命中率
+type QADailyStat struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`     // 主键ID
+	Date      string    `gorm:"size:10;not null;uniqueIndex"` // 日期，格式为2006-01-02
+	Questions int64     `gorm:"not null;default:0"`           // 问答次数
+	CacheHits int64     `gorm:"not null;default:0"`           // 命中回答缓存的次数
+	Errors    int64     `gorm:"not null;default:0"`           // 失败次数
+	LatencyMs int64     `gorm:"not null;default:0"`           // 累计耗时（毫秒）
+	CreatedAt time.Time `gorm:"not null"`                     // 创建时间
+	UpdatedAt time.Time `gorm:"not null"`                     // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (s *QADailyStat) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (QADailyStat) TableName() string {
+	return "qa_daily_stats"
+}
+
+// PromptVariantStat 提示词变体统计模型
+// 每天每个变体一行，问答完成时累加，用于对比各变体的耗时和失败率
+type PromptVariantStat struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`                       // 主键ID
+	Date      string    `gorm:"size:10;not null;uniqueIndex:idx_variant_date"`  // 日期，格式为2006-01-02
+	Variant   string    `gorm:"size:100;not null;uniqueIndex:idx_variant_date"` // 提示词变体名称
+	Questions int64     `gorm:"not null;default:0"`                             // 问答次数
+	Errors    int64     `gorm:"not null;default:0"`                             // 失败次数
+	LatencyMs int64     `gorm:"not null;default:0"`                             // 累计耗时（毫秒）
+	CreatedAt time.Time `gorm:"not null"`                                       // 创建时间
+	UpdatedAt time.Time `gorm:"not null"`                                       // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (s *PromptVariantStat) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (PromptVariantStat) TableName() string {
+	return "prompt_variant_stats"
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UsageKind 用量类型
+type UsageKind string
+
+const (
+	// UsageKindLLM 大模型调用
+	UsageKindLLM UsageKind = "llm"
+	// UsageKindEmbedding 嵌入模型调用
+	UsageKindEmbedding UsageKind = "embedding"
+)
+
+// UsageRecord 用量记录模型
+// 按天、租户、提供商、模型和类型聚合，每个组合一行，调用时累加
+type UsageRecord struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`                    // 主键ID
+	Date      string    `gorm:"size:10;not null;uniqueIndex:idx_usage_key"`  // 日期，格式为2006-01-02
+	Tenant    string    `gorm:"size:100;not null;uniqueIndex:idx_usage_key"` // 租户或API密钥标识
+	Provider  string    `gorm:"size:50;not null;uniqueIndex:idx_usage_key"`  // 提供商
+	Model     string    `gorm:"size:100;not null;uniqueIndex:idx_usage_key"` // 模型名称
+	Kind      UsageKind `gorm:"size:20;not null;uniqueIndex:idx_usage_key"`  // 用量类型
+	Requests  int64     `gorm:"not null;default:0"`                          // 请求次数
+	Errors    int64     `gorm:"not null;default:0"`                          // 失败次数
+	Tokens    int64     `gorm:"not null;default:0"`                          // 消耗的token数
+	CreatedAt time.Time `gorm:"not null"`                                    // 创建时间
+	UpdatedAt time.Time `gorm:"not null"`                                    // 更新时间
+}
+
+// BeforeCreate GORM的钩子函数，创建记录前自动设置时间
+func (u *UsageRecord) BeforeCreate(tx *gorm.DB) (err error) {
+	now := time.Now()
+	u.CreatedAt = now
+	u.UpdatedAt = now
+	return nil
+}
+
+// TableName 明确指定表名
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// APIModerator 调用OpenAI兼容的审核接口（POST {base_url}/moderations）的审核器
+type APIModerator struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// APIOption 审核接口配置选项
+type APIOption func(*APIModerator)
+
+// WithAPIKey 设置接口密钥
+func WithAPIKey(key string) APIOption {
+	return func(m *APIModerator) {
+		m.apiKey = key
+	}
+}
+
+// WithAPIModel 设置审核模型，为空时使用接口默认模型
+func WithAPIModel(model string) APIOption {
+	return func(m *APIModerator) {
+		m.model = model
+	}
+}
+
+// WithAPITimeout 设置请求超时时间
+func WithAPITimeout(timeout time.Duration) APIOption {
+	return func(m *APIModerator) {
+		if timeout > 0 {
+			m.client.Timeout = timeout
+		}
+	}
+}
+
+// NewAPIModerator 创建审核接口审核器，baseURL如 https://api.openai.com/v1
+func NewAPIModerator(baseURL string, opts ...APIOption) *APIModerator {
+	m := &APIModerator{
+		endpoint: strings.TrimRight(baseURL, "/") + "/moderations",
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// moderationRequest 审核接口请求体
+type moderationRequest struct {
+	Input string `json:"input"`
+	Model string `json:"model,omitempty"`
+}
+
+// moderationResponse 审核接口响应体
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Moderate 审核文本，接口标记为违规时拦截
+func (m *APIModerator) Moderate(ctx context.Context, _ Stage, text string) (*Verdict, error) {
+	payload, err := json.Marshal(moderationRequest{Input: text, Model: m.model})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var result moderationResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		var categories []string
+		for name, hit := range r.Categories {
+			if hit {
+				categories = append(categories, name)
+			}
+		}
+		sort.Strings(categories)
+		category := CategoryUnsafe
+		if len(categories) > 0 {
+			category = categories[0]
+		}
+		return &Verdict{Action: ActionBlock, Category: category, Reason: strings.Join(categories, ",")}, nil
+	}
+	return Allow, nil
+}
+
+// Name 返回审核器名称
+func (m *APIModerator) Name() string {
+	return "api"
+}
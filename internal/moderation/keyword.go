@@ -0,0 +1,127 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// redactMask 脱敏时替换关键词的字符
+const redactMask = "***"
+
+// DefaultInjectionPatterns 默认的提示词注入特征
+var DefaultInjectionPatterns = []string{
+	`(?i)ignore\s+(all\s+)?(the\s+)?(previous|prior|above)\s+(instructions|prompts?|rules)`,
+	`(?i)disregard\s+(all\s+)?(the\s+)?(previous|prior|above)`,
+	`(?i)(reveal|show|print|repeat)\s+.{0,20}(system\s+prompt|your\s+instructions)`,
+	`忽略(掉)?(之前|以上|上面|前面|先前)(的)?(所有)?(指令|提示|要求|规则)`,
+	`(输出|告诉我|显示|重复).{0,10}(系统提示词|系统提示|你的指令)`,
+}
+
+// KeywordModerator 基于关键词和正则的审核器
+// 命中拦截关键词时拦截，命中脱敏关键词时替换为***，提示词注入特征只用于审核用户问题
+type KeywordModerator struct {
+	blocked   map[string][]string // 类别 -> 拦截关键词
+	redacted  []string            // 脱敏关键词
+	patterns  []string            // 提示词注入特征的正则表达式
+	injection []*regexp.Regexp    // 编译后的提示词注入特征
+}
+
+// KeywordOption 关键词审核器配置选项
+type KeywordOption func(*KeywordModerator)
+
+// WithBlockedKeywords 设置拦截关键词，命中时按category归类
+func WithBlockedKeywords(category string, keywords []string) KeywordOption {
+	return func(m *KeywordModerator) {
+		m.blocked[category] = append(m.blocked[category], nonEmpty(keywords)...)
+	}
+}
+
+// WithRedactedKeywords 设置脱敏关键词
+func WithRedactedKeywords(keywords []string) KeywordOption {
+	return func(m *KeywordModerator) {
+		m.redacted = append(m.redacted, nonEmpty(keywords)...)
+	}
+}
+
+// WithInjectionPatterns 设置提示词注入特征（正则表达式），替换默认特征，传入空列表表示不检测
+func WithInjectionPatterns(patterns []string) KeywordOption {
+	return func(m *KeywordModerator) {
+		m.patterns = patterns
+	}
+}
+
+// NewKeywordModerator 创建关键词审核器，注入特征不是合法的正则表达式时返回错误
+func NewKeywordModerator(opts ...KeywordOption) (*KeywordModerator, error) {
+	m := &KeywordModerator{
+		blocked:  make(map[string][]string),
+		patterns: DefaultInjectionPatterns,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	for _, p := range m.patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid injection pattern %q: %w", p, err)
+		}
+		m.injection = append(m.injection, re)
+	}
+	return m, nil
+}
+
+// Moderate 审核文本
+func (m *KeywordModerator) Moderate(_ context.Context, stage Stage, text string) (*Verdict, error) {
+	if stage == StageInput {
+		for _, re := range m.injection {
+			if match := re.FindString(text); match != "" {
+				return &Verdict{Action: ActionBlock, Category: CategoryInjection, Reason: match}, nil
+			}
+		}
+	}
+
+	lower := strings.ToLower(text)
+	for category, keywords := range m.blocked {
+		for _, kw := range keywords {
+			if strings.Contains(lower, strings.ToLower(kw)) {
+				return &Verdict{Action: ActionBlock, Category: category, Reason: kw}, nil
+			}
+		}
+	}
+
+	redacted := text
+	var hits []string
+	for _, kw := range m.redacted {
+		re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(kw))
+		if re.MatchString(redacted) {
+			redacted = re.ReplaceAllString(redacted, redactMask)
+			hits = append(hits, kw)
+		}
+	}
+	if len(hits) > 0 {
+		return &Verdict{
+			Action:   ActionRedact,
+			Category: CategoryKeyword,
+			Reason:   strings.Join(hits, ","),
+			Text:     redacted,
+		}, nil
+	}
+	return Allow, nil
+}
+
+// Name 返回审核器名称
+func (m *KeywordModerator) Name() string {
+	return "keyword"
+}
+
+// nonEmpty 过滤空字符串
+func nonEmpty(items []string) []string {
+	var out []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
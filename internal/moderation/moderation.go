@@ -0,0 +1,121 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Stage 审核阶段
+type Stage string
+
+const (
+	// StageInput 调用大模型前审核用户问题
+	StageInput Stage = "input"
+	// StageOutput 返回前审核大模型生成的回答
+	StageOutput Stage = "output"
+)
+
+// Action 审核结果的处理方式
+type Action string
+
+const (
+	// ActionAllow 放行
+	ActionAllow Action = "allow"
+	// ActionRedact 脱敏后放行
+	ActionRedact Action = "redact"
+	// ActionBlock 拦截
+	ActionBlock Action = "block"
+)
+
+// 常用的违规类别
+const (
+	CategoryInjection = "prompt_injection" // 提示词注入
+	CategoryKeyword   = "keyword"          // 命中关键词
+	CategoryUnsafe    = "unsafe"           // 其他不安全内容
+)
+
+// Verdict 审核结果
+type Verdict struct {
+	Action   Action // 处理方式
+	Category string // 违规类别，放行时为空
+	Reason   string // 判定原因
+	Text     string // 脱敏后的文本，仅Action为ActionRedact时有效
+}
+
+// Allow 放行的审核结果
+var Allow = &Verdict{Action: ActionAllow}
+
+// Moderator 内容审核器
+type Moderator interface {
+	// Moderate 审核文本，stage区分用户问题和模型回答
+	Moderate(ctx context.Context, stage Stage, text string) (*Verdict, error)
+
+	// Name 返回审核器名称，用于审计日志
+	Name() string
+}
+
+// BlockedError 内容被拦截
+type BlockedError struct {
+	Stage     Stage  // 拦截阶段
+	Category  string // 违规类别
+	Reason    string // 拦截原因
+	Moderator string // 作出判定的审核器
+}
+
+// Error 实现error接口
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s blocked by %s moderation: %s (%s)", e.Stage, e.Moderator, e.Category, e.Reason)
+}
+
+// AsBlocked 判断错误是否由内容拦截引起
+func AsBlocked(err error) (*BlockedError, bool) {
+	var blocked *BlockedError
+	if errors.As(err, &blocked) {
+		return blocked, true
+	}
+	return nil, false
+}
+
+// chain 按顺序组合多个审核器
+type chain struct {
+	moderators []Moderator
+}
+
+// Chain 按顺序组合多个审核器
+// 任一审核器拦截即返回拦截结果；脱敏结果会作为后续审核器的输入，最终返回脱敏后的文本
+func Chain(moderators ...Moderator) Moderator {
+	if len(moderators) == 1 {
+		return moderators[0]
+	}
+	return &chain{moderators: moderators}
+}
+
+// Moderate 依次执行各审核器
+func (c *chain) Moderate(ctx context.Context, stage Stage, text string) (*Verdict, error) {
+	result := Allow
+	for _, m := range c.moderators {
+		verdict, err := m.Moderate(ctx, stage, text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name(), err)
+		}
+		switch verdict.Action {
+		case ActionBlock:
+			return verdict, nil
+		case ActionRedact:
+			text = verdict.Text
+			result = verdict
+		}
+	}
+	if result.Action == ActionRedact {
+		redacted := *result
+		redacted.Text = text
+		return &redacted, nil
+	}
+	return result, nil
+}
+
+// Name 返回审核器名称
+func (c *chain) Name() string {
+	return "chain"
+}
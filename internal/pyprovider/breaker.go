@@ -0,0 +1,131 @@
+package pyprovider
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// ErrCircuitOpen Python服务熔断中，请求未发送直接失败
+var ErrCircuitOpen = errors.New("python service circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+    BreakerClosed   BreakerState = iota // 正常放行
+    BreakerOpen                         // 熔断中，请求直接失败
+    BreakerHalfOpen                     // 冷却结束，放行一次试探请求
+)
+
+// String 返回状态名称
+func (s BreakerState) String() string {
+    switch s {
+    case BreakerOpen:
+        return "open"
+    case BreakerHalfOpen:
+        return "half-open"
+    default:
+        return "closed"
+    }
+}
+
+// circuitBreaker Python服务熔断器
+// 连续失败达到阈值后熔断，冷却期结束后进入半开状态放行一次试探请求，
+// 试探成功则恢复，失败则重新熔断
+type circuitBreaker struct {
+    threshold int
+    cooldown  time.Duration
+
+    mu       sync.Mutex
+    state    BreakerState
+    failures int
+    openedAt time.Time
+    probing  bool
+}
+
+// newCircuitBreaker 创建熔断器，阈值不大于0时返回nil表示不启用
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+    if threshold <= 0 {
+        return nil
+    }
+    return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow 判断是否放行请求
+func (b *circuitBreaker) allow(now time.Time) bool {
+    if b == nil {
+        return true
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    switch b.state {
+    case BreakerOpen:
+        if now.Sub(b.openedAt) < b.cooldown {
+            return false
+        }
+        b.state = BreakerHalfOpen
+        b.probing = true
+        return true
+    case BreakerHalfOpen:
+        // 半开状态只允许一个试探请求
+        if b.probing {
+            return false
+        }
+        b.probing = true
+        return true
+    default:
+        return true
+    }
+}
+
+// success 记录成功，关闭熔断器
+func (b *circuitBreaker) success() {
+    if b == nil {
+        return
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.state = BreakerClosed
+    b.failures = 0
+    b.probing = false
+}
+
+// failure 记录失败，必要时打开熔断器
+func (b *circuitBreaker) failure(now time.Time) {
+    if b == nil {
+        return
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.failures++
+    b.probing = false
+    if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+        b.state = BreakerOpen
+        b.openedAt = now
+    }
+}
+
+// release 结束一次不反映服务状态的请求（如调用方取消），释放试探名额
+func (b *circuitBreaker) release() {
+    if b == nil {
+        return
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.probing = false
+}
+
+// current 返回当前状态
+func (b *circuitBreaker) current() BreakerState {
+    if b == nil {
+        return BreakerClosed
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.state
+}
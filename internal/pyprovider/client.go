@@ -4,10 +4,15 @@ import (
     "bytes"
     "context"
     "encoding/json"
+    "errors"
     "fmt"
+    "net"
     "io"
     "net/http"
+    "strconv"
     "time"
+
+    "github.com/fyerfyer/doc-QA-system/pkg/requestid"
 )
 
 // Client 是Python服务的HTTP客户端接口
@@ -25,19 +30,47 @@ type HTTPClient struct {
     client  *http.Client
     config  *PyServiceConfig
     headers map[string]string
+    breaker *circuitBreaker
 }
 
 // APIError 表示API调用返回的错误
 type APIError struct {
-    StatusCode int    `json:"status_code"`
-    Message    string `json:"message"`
-    Detail     string `json:"detail"`
+    StatusCode int           `json:"status_code"`
+    Message    string        `json:"message"`
+    Detail     string        `json:"detail"`
+    RetryAfter time.Duration `json:"-"` // 响应Retry-After头要求的等待时间，未设置时为0
 }
 
 func (e *APIError) Error() string {
     return fmt.Sprintf("API error (status code: %d): %s - %s", e.StatusCode, e.Message, e.Detail)
 }
 
+// NewAPIError 根据错误响应创建APIError
+// 响应体为{"detail": ...}或{"error": ...}时取其中的错误信息，并解析Retry-After头；
+// 其他调用本地HTTP服务的客户端也使用它，以便按同样的规则判断是否重试
+func NewAPIError(resp *http.Response, body []byte) *APIError {
+    apiErr := &APIError{
+        StatusCode: resp.StatusCode,
+        Message:    "API call failed",
+        RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+    }
+
+    // 尝试解析错误详情
+    var errResp struct {
+        Detail string `json:"detail"`
+        Error  string `json:"error"`
+    }
+    switch err := json.Unmarshal(body, &errResp); {
+    case err == nil && errResp.Detail != "":
+        apiErr.Detail = errResp.Detail
+    case err == nil && errResp.Error != "":
+        apiErr.Detail = errResp.Error
+    default:
+        apiErr.Detail = string(body)
+    }
+    return apiErr
+}
+
 // NewClient 创建一个新的Python服务HTTP客户端
 func NewClient(config *PyServiceConfig) (Client, error) {
     if config == nil {
@@ -47,6 +80,7 @@ func NewClient(config *PyServiceConfig) (Client, error) {
     client := &http.Client{
         Timeout: config.Timeout,
         Transport: &http.Transport{
+            DialContext:         (&net.Dialer{Timeout: config.DialTimeout}).DialContext,
             MaxIdleConns:        100,
             MaxIdleConnsPerHost: 20,
             IdleConnTimeout:     90 * time.Second,
@@ -61,6 +95,7 @@ func NewClient(config *PyServiceConfig) (Client, error) {
             "Accept":       "application/json",
             "User-Agent":   "Doc-QA-Go-Client/1.0",
         },
+        breaker: newCircuitBreaker(config.BreakerThreshold, config.BreakerCooldown),
     }, nil
 }
 
@@ -73,13 +108,12 @@ func (c *HTTPClient) Get(ctx context.Context, path string, result interface{}) e
         return fmt.Errorf("failed to create request: %w", err)
     }
 
-    // 添加请求头
-    for key, value := range c.headers {
-        req.Header.Set(key, value)
-    }
+    c.setHeaders(req)
 
-    // 执行带重试的请求
-    return c.doRequestWithRetry(req, result)
+    // 在熔断器保护下执行带重试的请求
+    return c.guard(ctx, func() error {
+        return c.doRequestWithRetry(req, result)
+    })
 }
 
 // Post 发送POST请求到Python服务
@@ -102,41 +136,177 @@ func (c *HTTPClient) Post(ctx context.Context, path string, data interface{}, re
         return fmt.Errorf("failed to create request: %w", err)
     }
 
-    // 添加请求头
+    c.setHeaders(req)
+
+    // 在熔断器保护下执行带重试的请求
+    return c.guard(ctx, func() error {
+        return c.doRequestWithRetry(req, result)
+    })
+}
+
+// guard 在熔断器保护下执行请求
+// 熔断期间直接返回ErrCircuitOpen，调用方可以立即回退；网络错误和5xx响应计为服务故障，
+// 4xx响应说明服务能够正常处理请求，不计为故障
+func (c *HTTPClient) guard(ctx context.Context, fn func() error) error {
+    if !c.breaker.allow(time.Now()) {
+        return ErrCircuitOpen
+    }
+
+    err := fn()
+    switch {
+    case err == nil:
+        c.breaker.success()
+    case ctx.Err() != nil:
+        c.breaker.release()
+    case isServiceFailure(err):
+        c.breaker.failure(time.Now())
+    default:
+        c.breaker.success()
+    }
+    return err
+}
+
+// isServiceFailure 判断错误是否说明Python服务不可用
+func isServiceFailure(err error) bool {
+    var apiErr *APIError
+    if errors.As(err, &apiErr) {
+        return apiErr.StatusCode >= 500
+    }
+    var transportErr *transportError
+    return errors.As(err, &transportErr)
+}
+
+// BreakerState 返回熔断器当前状态
+func (c *HTTPClient) BreakerState() BreakerState {
+    return c.breaker.current()
+}
+
+// setHeaders 设置公共请求头、访问令牌和请求ID
+func (c *HTTPClient) setHeaders(req *http.Request) {
     for key, value := range c.headers {
         req.Header.Set(key, value)
     }
+    c.config.ApplyAuth(req)
+    setRequestID(req)
+}
 
-    // 执行带重试的请求
-    return c.doRequestWithRetry(req, result)
+// setRequestID 将上下文中的请求ID透传给Python服务，便于跨服务关联日志
+func setRequestID(req *http.Request) {
+    if id := requestid.FromContext(req.Context()); id != "" {
+        req.Header.Set(requestid.Header, id)
+    }
 }
 
 // doRequestWithRetry 执行HTTP请求并支持重试
+// 网络错误、408、429和5xx（501除外）视为临时故障，按指数退避加随机抖动重试
 func (c *HTTPClient) doRequestWithRetry(req *http.Request, result interface{}) error {
     var lastErr error
-    var resp *http.Response
 
-    // 重试逻辑
     for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
         if attempt > 0 {
+            // 服务通过Retry-After要求更长的等待时间时以服务端为准
+            wait := c.config.retryDelay(attempt)
+            if d := RetryAfter(lastErr); d > wait {
+                wait = d
+            }
             select {
             case <-req.Context().Done():
                 return fmt.Errorf("request context canceled: %w", req.Context().Err())
-            case <-time.After(c.config.RetryDelay * time.Duration(attempt)):
-                // 增加退避时间
+            case <-time.After(wait):
+            }
+
+            // 请求体在上一次发送时已被读取，重试前需要重新获取
+            if req.GetBody != nil {
+                body, err := req.GetBody()
+                if err != nil {
+                    return fmt.Errorf("failed to reset request body: %w", err)
+                }
+                req.Body = body
             }
         }
 
-        resp, lastErr = c.client.Do(req)
-        if lastErr == nil {
-            break
+        lastErr = c.doRequest(req, result)
+        if lastErr == nil || !isRetryable(req.Context(), lastErr) {
+            return lastErr
+        }
+    }
+
+    return lastErr
+}
+
+// IsRetryable 判断错误是否为可以重试的临时故障：网络错误、408、429和5xx（501除外）
+func IsRetryable(err error) bool {
+    return isRetryable(context.Background(), err)
+}
+
+// RetryAfter 返回错误响应中Retry-After要求的等待时间，没有时返回0
+func RetryAfter(err error) time.Duration {
+    var apiErr *APIError
+    if errors.As(err, &apiErr) {
+        return apiErr.RetryAfter
+    }
+    return 0
+}
+
+// parseRetryAfter 解析Retry-After头，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string, now time.Time) time.Duration {
+    if value == "" {
+        return 0
+    }
+    if seconds, err := strconv.Atoi(value); err == nil {
+        if seconds <= 0 {
+            return 0
         }
+        return time.Duration(seconds) * time.Second
+    }
+    if at, err := http.ParseTime(value); err == nil && at.After(now) {
+        return at.Sub(now)
+    }
+    return 0
+}
+
+// isRetryable 判断请求错误是否可以重试
+func isRetryable(ctx context.Context, err error) bool {
+    if ctx.Err() != nil {
+        return false
+    }
 
-        fmt.Printf("Request attempt %d failed: %v\n", attempt+1, lastErr)
+    var apiErr *APIError
+    if errors.As(err, &apiErr) {
+        switch {
+        case apiErr.StatusCode == http.StatusRequestTimeout,
+            apiErr.StatusCode == http.StatusTooManyRequests:
+            return true
+        case apiErr.StatusCode == http.StatusNotImplemented:
+            return false
+        default:
+            return apiErr.StatusCode >= 500
+        }
     }
 
-    if lastErr != nil {
-        return fmt.Errorf("HTTP request failed: %w", lastErr)
+    // 网络错误可以重试，响应解析等本地错误重试也无济于事
+    var transportErr *transportError
+    return errors.As(err, &transportErr)
+}
+
+// transportError 表示请求未能得到响应的网络错误
+type transportError struct {
+    err error
+}
+
+func (e *transportError) Error() string {
+    return fmt.Sprintf("HTTP request failed: %v", e.err)
+}
+
+func (e *transportError) Unwrap() error {
+    return e.err
+}
+
+// doRequest 执行一次HTTP请求并解析响应
+func (c *HTTPClient) doRequest(req *http.Request, result interface{}) error {
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return &transportError{err: err}
     }
     defer resp.Body.Close()
 
@@ -148,22 +318,7 @@ func (c *HTTPClient) doRequestWithRetry(req *http.Request, result interface{}) e
 
     // 检查状态码
     if resp.StatusCode >= 400 {
-        apiErr := &APIError{
-            StatusCode: resp.StatusCode,
-            Message:    "API call failed",
-        }
-
-        // 尝试解析错误详情
-        var errResp struct {
-            Detail string `json:"detail"`
-        }
-        if err := json.Unmarshal(body, &errResp); err == nil && errResp.Detail != "" {
-            apiErr.Detail = errResp.Detail
-        } else {
-            apiErr.Detail = string(body)
-        }
-
-        return apiErr
+        return NewAPIError(resp, body)
     }
 
     // 解析响应体到结果对象
@@ -176,6 +331,18 @@ func (c *HTTPClient) doRequestWithRetry(req *http.Request, result interface{}) e
     return nil
 }
 
+// Ping 调用Python服务的健康检查接口，服务返回pong时视为可用
+func Ping(ctx context.Context, client Client) error {
+    var response map[string]interface{}
+    if err := client.Get(ctx, "/health/ping", &response); err != nil {
+        return err
+    }
+    if response["ping"] != "pong" {
+        return fmt.Errorf("unexpected ping response: %v", response)
+    }
+    return nil
+}
+
 // GetConfig 返回客户端配置
 func (c *HTTPClient) GetConfig() *PyServiceConfig {
     return c.config
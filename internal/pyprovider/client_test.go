@@ -2,6 +2,11 @@ package pyprovider
 
 import (
     "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
     "testing"
     "time"
 
@@ -124,4 +129,153 @@ func TestRagEndpoint(t *testing.T) {
     assert.NotEmpty(t, ragResp.Text)
     assert.NotEmpty(t, ragResp.Model)
     assert.Greater(t, ragResp.TotalTokens, 0)
-}
\ No newline at end of file
+}
+
+// TestRequestRetry 测试临时故障自动重试和访问令牌
+func TestRequestRetry(t *testing.T) {
+    var attempts int
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        attempts++
+        assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+        // 每次重试都应该收到完整的请求体
+        var body map[string]string
+        require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+        assert.Equal(t, "hello", body["text"])
+
+        switch r.URL.Path {
+        case "/api/flaky":
+            if attempts < 3 {
+                w.WriteHeader(http.StatusServiceUnavailable)
+                return
+            }
+            w.Write([]byte(`{"ok":"yes"}`))
+        default:
+            w.WriteHeader(http.StatusBadRequest)
+            w.Write([]byte(`{"detail":"bad request"}`))
+        }
+    }))
+    defer server.Close()
+
+    config := DefaultConfig().
+        WithBaseURL(server.URL+"/api").
+        WithRetry(3, time.Millisecond).
+        WithAuthToken("secret")
+    client, err := NewClient(config)
+    require.NoError(t, err)
+
+    // 5xx响应重试后成功
+    var result map[string]string
+    err = client.Post(context.Background(), "/flaky", map[string]string{"text": "hello"}, &result)
+    require.NoError(t, err)
+    assert.Equal(t, "yes", result["ok"])
+    assert.Equal(t, 3, attempts)
+
+    // 4xx响应不重试
+    attempts = 0
+    err = client.Post(context.Background(), "/invalid", map[string]string{"text": "hello"}, nil)
+    var apiErr *APIError
+    require.ErrorAs(t, err, &apiErr)
+    assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+    assert.Equal(t, "bad request", apiErr.Detail)
+    assert.Equal(t, 1, attempts)
+}
+
+// TestRetryDelay 测试指数退避、间隔上限和随机抖动
+func TestRetryDelay(t *testing.T) {
+    config := DefaultConfig().WithRetry(5, 100*time.Millisecond).WithBackoff(time.Second, 0)
+    assert.Equal(t, 100*time.Millisecond, config.retryDelay(1))
+    assert.Equal(t, 200*time.Millisecond, config.retryDelay(2))
+    assert.Equal(t, 800*time.Millisecond, config.retryDelay(4))
+    assert.Equal(t, time.Second, config.retryDelay(5))
+
+    config.WithBackoff(time.Second, 0.5)
+    for i := 0; i < 20; i++ {
+        delay := config.retryDelay(2)
+        assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+        assert.LessOrEqual(t, delay, 200*time.Millisecond)
+    }
+}
+
+// TestRetryAfter 测试解析Retry-After头并在重试前至少等待该时间
+func TestRetryAfter(t *testing.T) {
+    now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+    assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+    assert.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+    assert.Zero(t, parseRetryAfter("", now))
+    assert.Zero(t, parseRetryAfter("-1", now))
+    assert.Zero(t, parseRetryAfter("soon", now))
+
+    var attempts int
+    var first time.Time
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        attempts++
+        if attempts == 1 {
+            first = time.Now()
+            w.Header().Set("Retry-After", "1")
+            w.WriteHeader(http.StatusTooManyRequests)
+            return
+        }
+        w.Write([]byte(`{}`))
+    }))
+    defer server.Close()
+
+    client, err := NewClient(DefaultConfig().WithBaseURL(server.URL).WithRetry(1, time.Millisecond))
+    require.NoError(t, err)
+    require.NoError(t, client.Get(context.Background(), "/limited", nil))
+    assert.Equal(t, 2, attempts)
+    assert.GreaterOrEqual(t, time.Since(first), time.Second)
+
+    err = &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second}
+    assert.True(t, IsRetryable(err))
+    assert.Equal(t, 2*time.Second, RetryAfter(fmt.Errorf("wrapped: %w", err)))
+    assert.False(t, IsRetryable(&APIError{StatusCode: http.StatusBadRequest}))
+}
+
+// TestCircuitBreaker 测试连续失败后熔断、冷却后试探恢复
+func TestCircuitBreaker(t *testing.T) {
+    var attempts int32
+    var healthy atomic.Bool
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&attempts, 1)
+        if r.URL.Path == "/api/invalid" {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        if !healthy.Load() {
+            w.WriteHeader(http.StatusInternalServerError)
+            return
+        }
+        w.Write([]byte(`{"ping":"pong"}`))
+    }))
+    defer server.Close()
+
+    config := DefaultConfig().
+        WithBaseURL(server.URL+"/api").
+        WithRetry(0, 0).
+        WithCircuitBreaker(2, 50*time.Millisecond)
+    client, err := NewClient(config)
+    require.NoError(t, err)
+    httpClient := client.(*HTTPClient)
+    ctx := context.Background()
+
+    // 4xx响应不计为服务故障
+    require.Error(t, client.Get(ctx, "/invalid", nil))
+    require.Error(t, client.Get(ctx, "/health/ping", nil))
+    require.Error(t, client.Get(ctx, "/invalid", nil))
+    assert.Equal(t, BreakerClosed, httpClient.BreakerState())
+
+    // 连续失败达到阈值后熔断，之后的请求不再发送
+    require.Error(t, client.Get(ctx, "/health/ping", nil))
+    require.Error(t, client.Get(ctx, "/health/ping", nil))
+    assert.Equal(t, BreakerOpen, httpClient.BreakerState())
+    sent := atomic.LoadInt32(&attempts)
+    assert.ErrorIs(t, client.Get(ctx, "/health/ping", nil), ErrCircuitOpen)
+    assert.Equal(t, sent, atomic.LoadInt32(&attempts))
+
+    // 冷却结束后试探请求成功，熔断器恢复
+    healthy.Store(true)
+    time.Sleep(60 * time.Millisecond)
+    require.NoError(t, Ping(ctx, client))
+    assert.Equal(t, BreakerClosed, httpClient.BreakerState())
+}
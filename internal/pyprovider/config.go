@@ -1,28 +1,39 @@
 package pyprovider
 
 import (
+    "math/rand"
+    "net/http"
     "time"
 )
 
 // PyServiceConfig 存储Python服务连接配置
 type PyServiceConfig struct {
-    BaseURL     string        // Python服务基础URL
-    Timeout     time.Duration // 请求超时时间
-    MaxRetries  int           // 最大重试次数
-    RetryDelay  time.Duration // 重试间隔
-    DialTimeout time.Duration // 连接超时
-    EnableTLS   bool          // 是否启用TLS
+    BaseURL          string        // Python服务基础URL
+    Timeout          time.Duration // 请求超时时间
+    MaxRetries       int           // 最大重试次数
+    RetryDelay       time.Duration // 首次重试间隔，之后按指数退避
+    MaxRetryDelay    time.Duration // 重试间隔上限，0表示不限制
+    RetryJitter      float64       // 重试间隔随机抖动比例（0~1），避免多个请求同时重试
+    DialTimeout      time.Duration // 连接超时
+    EnableTLS        bool          // 是否启用TLS
+    AuthToken        string        // 访问令牌，非空时以Bearer方式放入Authorization请求头
+    BreakerThreshold int           // 连续失败多少次后熔断，0表示不启用熔断
+    BreakerCooldown  time.Duration // 熔断后的冷却时间，冷却结束后放行一次试探请求
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *PyServiceConfig {
     return &PyServiceConfig{
-        BaseURL:     "http://localhost:8000/api",
-        Timeout:     30 * time.Second,
-        MaxRetries:  3,
-        RetryDelay:  time.Second,
-        DialTimeout: 5 * time.Second,
-        EnableTLS:   false,
+        BaseURL:          "http://localhost:8000/api",
+        Timeout:          30 * time.Second,
+        MaxRetries:       3,
+        RetryDelay:       time.Second,
+        MaxRetryDelay:    10 * time.Second,
+        RetryJitter:      0.2,
+        DialTimeout:      5 * time.Second,
+        EnableTLS:        false,
+        BreakerThreshold: 5,
+        BreakerCooldown:  30 * time.Second,
     }
 }
 
@@ -45,6 +56,57 @@ func (c *PyServiceConfig) WithRetry(maxRetries int, retryDelay time.Duration) *P
     return c
 }
 
+// WithBackoff 设置重试间隔上限和随机抖动比例
+func (c *PyServiceConfig) WithBackoff(maxDelay time.Duration, jitter float64) *PyServiceConfig {
+    c.MaxRetryDelay = maxDelay
+    c.RetryJitter = jitter
+    return c
+}
+
+// WithAuthToken 设置访问令牌
+func (c *PyServiceConfig) WithAuthToken(token string) *PyServiceConfig {
+    c.AuthToken = token
+    return c
+}
+
+// retryDelay 计算第attempt次重试（从1开始）前的等待时间
+// 间隔按RetryDelay指数增长并受MaxRetryDelay限制，再在[1-RetryJitter, 1]范围内随机缩放
+func (c *PyServiceConfig) retryDelay(attempt int) time.Duration {
+    delay := c.RetryDelay
+    for i := 1; i < attempt; i++ {
+        delay *= 2
+        if c.MaxRetryDelay > 0 && delay >= c.MaxRetryDelay {
+            break
+        }
+    }
+    if c.MaxRetryDelay > 0 && delay > c.MaxRetryDelay {
+        delay = c.MaxRetryDelay
+    }
+
+    jitter := c.RetryJitter
+    if jitter > 1 {
+        jitter = 1
+    }
+    if jitter > 0 {
+        delay -= time.Duration(jitter * rand.Float64() * float64(delay))
+    }
+    return delay
+}
+
+// WithCircuitBreaker 设置熔断阈值和冷却时间，阈值为0时不启用熔断
+func (c *PyServiceConfig) WithCircuitBreaker(threshold int, cooldown time.Duration) *PyServiceConfig {
+    c.BreakerThreshold = threshold
+    c.BreakerCooldown = cooldown
+    return c
+}
+
+// ApplyAuth 在请求上设置访问令牌，未配置令牌时不做任何修改
+func (c *PyServiceConfig) ApplyAuth(req *http.Request) {
+    if c.AuthToken != "" {
+        req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+    }
+}
+
 // WithTLS 设置是否启用TLS
 func (c *PyServiceConfig) WithTLS(enable bool) *PyServiceConfig {
     c.EnableTLS = enable
@@ -75,25 +75,12 @@ func (c *DocumentClient) ParseDocument(ctx context.Context, filePath string) (*D
 
     // 设置请求头
     req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    c.client.GetConfig().ApplyAuth(req)
 
     // 执行请求
     var response DocumentParseResponse
-    httpClient := &http.Client{Timeout: c.client.GetConfig().Timeout}
-    resp, err := httpClient.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("failed to send request: %w", err)
-    }
-    defer resp.Body.Close()
-
-    // 检查状态码
-    if resp.StatusCode != http.StatusOK {
-        body, _ := io.ReadAll(resp.Body)
-        return nil, fmt.Errorf("API call failed (status code: %d): %s", resp.StatusCode, string(body))
-    }
-
-    // 解析响应
-    if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-        return nil, fmt.Errorf("failed to parse response: %w", err)
+    if err := c.send(req, &response); err != nil {
+        return nil, err
     }
 
     // 检查API响应是否成功
@@ -104,6 +91,36 @@ func (c *DocumentClient) ParseDocument(ctx context.Context, filePath string) (*D
     return &response.Result, nil
 }
 
+// send 发送自定义请求并解析响应，底层客户端为HTTPClient时同样受熔断器保护
+func (c *DocumentClient) send(req *http.Request, result interface{}) error {
+    setRequestID(req)
+    do := func() error {
+        httpClient := &http.Client{Timeout: c.client.GetConfig().Timeout}
+        resp, err := httpClient.Do(req)
+        if err != nil {
+            return &transportError{err: err}
+        }
+        defer resp.Body.Close()
+
+        // 检查状态码
+        if resp.StatusCode != http.StatusOK {
+            body, _ := io.ReadAll(resp.Body)
+            return &APIError{StatusCode: resp.StatusCode, Message: "API call failed", Detail: string(body)}
+        }
+
+        // 解析响应
+        if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+            return fmt.Errorf("failed to parse response: %w", err)
+        }
+        return nil
+    }
+
+    if httpClient, ok := c.client.(*HTTPClient); ok {
+        return httpClient.guard(req.Context(), do)
+    }
+    return do()
+}
+
 // ParseDocumentWithReader 从io.Reader解析文档
 func (c *DocumentClient) ParseDocumentWithReader(ctx context.Context, reader io.Reader, fileName string) (*DocumentParseResult, error) {
     // 生成唯一文档ID
@@ -151,25 +168,12 @@ func (c *DocumentClient) ParseDocumentWithReader(ctx context.Context, reader io.
 
     // 设置请求头
     req.Header.Set("Content-Type", writer.FormDataContentType())
+    c.client.GetConfig().ApplyAuth(req)
 
     // 执行请求
     var response DocumentParseResponse
-    httpClient := &http.Client{Timeout: c.client.GetConfig().Timeout}
-    resp, err := httpClient.Do(req)
-    if err != nil {
-        return nil, fmt.Errorf("failed to send request: %w", err)
-    }
-    defer resp.Body.Close()
-
-    // 检查状态码
-    if resp.StatusCode != http.StatusOK {
-        body, _ := io.ReadAll(resp.Body)
-        return nil, fmt.Errorf("API call failed (status code: %d): %s", resp.StatusCode, string(body))
-    }
-
-    // 解析响应
-    if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-        return nil, fmt.Errorf("failed to parse response: %w", err)
+    if err := c.send(req, &response); err != nil {
+        return nil, err
     }
 
     // 检查API响应是否成功
@@ -228,6 +232,9 @@ type SplitOptions struct {
     SplitType    string         `json:"split_type"`    // 分块策略：paragraph, sentence, length, semantic
     StoreResult  bool           `json:"store_result"`  // 是否存储结果
     Metadata     map[string]any `json:"metadata"`      // 附加元数据
+
+    // BreakpointThreshold 语义分块的断点阈值（百分位，0-100），为0时使用Python服务的默认值
+    BreakpointThreshold float64 `json:"breakpoint_threshold,omitempty"`
 }
 
 // SplitTextRequest 表示分块API请求体
@@ -239,6 +246,8 @@ type SplitTextRequest struct {
     SplitType    string         `json:"split_type"`    // 分割策略
     StoreResult  bool           `json:"store_result"`  // 是否存储结果
     Metadata     map[string]any `json:"metadata"`      // 附加元数据
+
+    BreakpointThreshold float64 `json:"breakpoint_threshold,omitempty"` // 语义分块断点阈值
 }
 
 // ChunkResponse 表示分块API的响应
@@ -278,6 +287,8 @@ func (c *DocumentClient) SplitText(ctx context.Context, text string, documentID
         SplitType:    options.SplitType,
         StoreResult:  options.StoreResult,
         Metadata:     options.Metadata,
+
+        BreakpointThreshold: options.BreakpointThreshold,
     }
 
     // 构建请求路径
@@ -318,6 +318,42 @@ func (c *LLMClient) Answer(ctx context.Context, query string, options ...RAGOpti
 	return &response, nil
 }
 
+// VerifyRequest 表示回答依据校验请求
+type VerifyRequest struct {
+	Claim     string   `json:"claim"`               // 待校验的回答
+	Contexts  []string `json:"contexts"`            // 检索到的上下文
+	Model     string   `json:"model,omitempty"`     // NLI模型，为空时使用服务默认模型
+	Threshold float64  `json:"threshold,omitempty"` // 判定为有依据的最低蕴含概率
+}
+
+// VerifyResponse 表示回答依据校验响应
+type VerifyResponse struct {
+	Supported      bool    `json:"supported"`       // 回答是否有上下文作为依据
+	Score          float64 `json:"score"`           // 蕴含概率，取各上下文中的最大值
+	Model          string  `json:"model"`           // 使用的NLI模型
+	ProcessingTime float64 `json:"processing_time"` // 处理耗时（秒）
+}
+
+// Verify 使用NLI模型校验回答是否有检索上下文作为依据
+func (c *LLMClient) Verify(ctx context.Context, claim string, contexts []string, model string) (*VerifyResponse, error) {
+	if claim == "" || len(contexts) == 0 {
+		return nil, fmt.Errorf("claim and contexts cannot be empty")
+	}
+
+	req := VerifyRequest{
+		Claim:    claim,
+		Contexts: contexts,
+		Model:    model,
+	}
+
+	var response VerifyResponse
+	if err := c.client.Post(ctx, "/python/llm/verify", req, &response); err != nil {
+		return nil, fmt.Errorf("failed to verify answer: %w", err)
+	}
+
+	return &response, nil
+}
+
 // NewUserMessage 创建用户消息
 func NewUserMessage(content string) Message {
 	return Message{
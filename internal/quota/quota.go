@@ -0,0 +1,193 @@
+// Package quota 按租户限制文档数量、存储字节数和每日问答次数
+// 用量计数保存在数据库中，多个副本共享同一份计数
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/sirupsen/logrus"
+)
+
+// dayLayout 按天计数的周期格式
+const dayLayout = "2006-01-02"
+
+// Limits 租户配额，各项为0表示不限制
+type Limits struct {
+	MaxDocuments     int64 // 文档数量上限
+	MaxStorageBytes  int64 // 文件存储字节数上限
+	MaxQACallsPerDay int64 // 每日问答次数上限
+}
+
+// Allowance 单个计量项的配额情况
+type Allowance struct {
+	Limit     int64 // 上限，0表示不限制
+	Used      int64 // 已用量
+	Remaining int64 // 剩余量，不限制时为-1
+}
+
+// Status 租户的配额情况
+type Status struct {
+	Tenant       string    // 租户
+	Documents    Allowance // 文档数量
+	StorageBytes Allowance // 文件存储字节数
+	QACalls      Allowance // 当日问答次数
+	QAResetAt    time.Time // 问答次数重置时间
+}
+
+// Manager 配额管理器
+// 方法对nil接收者安全，未启用配额时调用方无需判空
+type Manager struct {
+	repo     repository.QuotaRepository
+	defaults Limits
+	tenants  map[string]Limits
+	logger   *logrus.Logger
+	now      func() time.Time
+}
+
+// Option 配额管理器配置选项
+type Option func(*Manager)
+
+// WithDefaultLimits 设置默认配额，未单独配置的租户使用默认配额
+func WithDefaultLimits(limits Limits) Option {
+	return func(m *Manager) {
+		m.defaults = limits
+	}
+}
+
+// WithTenantLimits 为指定租户设置配额，整体替换默认配额
+func WithTenantLimits(tenant string, limits Limits) Option {
+	return func(m *Manager) {
+		m.tenants[tenant] = limits
+	}
+}
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *logrus.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// NewManager 创建配额管理器
+func NewManager(repo repository.QuotaRepository, opts ...Option) *Manager {
+	m := &Manager{
+		repo:    repo,
+		tenants: make(map[string]Limits),
+		logger:  logrus.StandardLogger(),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Limits 返回租户的配额
+func (m *Manager) Limits(tenant string) Limits {
+	if m == nil {
+		return Limits{}
+	}
+	if limits, ok := m.tenants[tenant]; ok {
+		return limits
+	}
+	return m.defaults
+}
+
+// ReserveDocument 为上下文中的租户预占一个文档和size字节的存储配额
+// 超出任一配额时不占用并返回models.ErrQuotaExceeded；文件保存失败或文档删除时应调用ReleaseDocument归还
+func (m *Manager) ReserveDocument(ctx context.Context, size int64) error {
+	if m == nil {
+		return nil
+	}
+	tenant := usage.TenantFromContext(ctx)
+	limits := m.Limits(tenant)
+	return m.repo.WithContext(ctx).Consume(tenant,
+		repository.QuotaDelta{Metric: models.QuotaMetricDocuments, Period: models.QuotaPeriodTotal, Amount: 1, Limit: limits.MaxDocuments},
+		repository.QuotaDelta{Metric: models.QuotaMetricStorageBytes, Period: models.QuotaPeriodTotal, Amount: size, Limit: limits.MaxStorageBytes},
+	)
+}
+
+// ReleaseDocument 归还租户的一个文档和size字节的存储配额
+// 租户为空说明文档在记录租户之前上传，从未计入配额，不需要归还
+func (m *Manager) ReleaseDocument(ctx context.Context, tenant string, size int64) {
+	if m == nil || tenant == "" {
+		return
+	}
+
+	repo := m.repo.WithContext(ctx)
+	for metric, amount := range map[models.QuotaMetric]int64{
+		models.QuotaMetricDocuments:    1,
+		models.QuotaMetricStorageBytes: size,
+	} {
+		if err := repo.Release(tenant, metric, models.QuotaPeriodTotal, amount); err != nil {
+			m.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+				"tenant": tenant,
+				"metric": metric,
+			}).Warn("Failed to release quota")
+		}
+	}
+}
+
+// ConsumeQA 为上下文中的租户消耗一次当日问答配额，超出时返回models.ErrQuotaExceeded
+func (m *Manager) ConsumeQA(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	tenant := usage.TenantFromContext(ctx)
+	return m.repo.WithContext(ctx).Consume(tenant, repository.QuotaDelta{
+		Metric: models.QuotaMetricQACalls,
+		Period: m.now().Format(dayLayout),
+		Amount: 1,
+		Limit:  m.Limits(tenant).MaxQACallsPerDay,
+	})
+}
+
+// Status 查询租户的配额和用量，未启用配额时各项均不限制
+func (m *Manager) Status(ctx context.Context, tenant string) (*Status, error) {
+	if m == nil {
+		return &Status{
+			Tenant:       tenant,
+			Documents:    newAllowance(0, 0),
+			StorageBytes: newAllowance(0, 0),
+			QACalls:      newAllowance(0, 0),
+		}, nil
+	}
+
+	now := m.now()
+	today := now.Format(dayLayout)
+	usages, err := m.repo.WithContext(ctx).List(tenant, models.QuotaPeriodTotal, today)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[models.QuotaMetric]int64, len(usages))
+	for _, u := range usages {
+		used[u.Metric] = u.Used
+	}
+
+	limits := m.Limits(tenant)
+	year, month, day := now.Date()
+	return &Status{
+		Tenant:       tenant,
+		Documents:    newAllowance(limits.MaxDocuments, used[models.QuotaMetricDocuments]),
+		StorageBytes: newAllowance(limits.MaxStorageBytes, used[models.QuotaMetricStorageBytes]),
+		QACalls:      newAllowance(limits.MaxQACallsPerDay, used[models.QuotaMetricQACalls]),
+		QAResetAt:    time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()),
+	}, nil
+}
+
+// newAllowance 计算单个计量项的剩余量
+func newAllowance(limit, used int64) Allowance {
+	a := Allowance{Limit: limit, Used: used, Remaining: -1}
+	if limit > 0 {
+		a.Remaining = limit - used
+		if a.Remaining < 0 {
+			a.Remaining = 0
+		}
+	}
+	return a
+}
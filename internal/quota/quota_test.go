@@ -0,0 +1,135 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupQuotaTestDB 创建内存数据库并替换全局连接
+func setupQuotaTestDB(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_quota_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.QuotaUsage{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+}
+
+// newTestManager 创建日期固定、日志静默的配额管理器
+func newTestManager(now time.Time, opts ...Option) *Manager {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	m := NewManager(repository.NewQuotaRepository(), append([]Option{WithLogger(logger)}, opts...)...)
+	m.now = func() time.Time { return now }
+	return m
+}
+
+// TestDocumentQuota 测试文档数量和存储字节数配额
+func TestDocumentQuota(t *testing.T) {
+	setupQuotaTestDB(t)
+	m := newTestManager(time.Now(),
+		WithDefaultLimits(Limits{MaxDocuments: 2, MaxStorageBytes: 1000}),
+		WithTenantLimits("vip", Limits{}),
+	)
+	ctx := usage.WithTenant(context.Background(), "team-a")
+
+	require.NoError(t, m.ReserveDocument(ctx, 400))
+
+	// 超出存储配额时不占用文档配额
+	err := m.ReserveDocument(ctx, 700)
+	assert.ErrorIs(t, err, models.ErrQuotaExceeded)
+
+	require.NoError(t, m.ReserveDocument(ctx, 600))
+
+	// 超出文档数量配额
+	err = m.ReserveDocument(ctx, 0)
+	assert.ErrorIs(t, err, models.ErrQuotaExceeded)
+
+	status, err := m.Status(ctx, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, Allowance{Limit: 2, Used: 2, Remaining: 0}, status.Documents)
+	assert.Equal(t, Allowance{Limit: 1000, Used: 1000, Remaining: 0}, status.StorageBytes)
+
+	// 删除文档后归还配额
+	m.ReleaseDocument(ctx, "team-a", 600)
+	require.NoError(t, m.ReserveDocument(ctx, 500))
+
+	// 单独配置的租户不受默认配额限制，匿名租户使用默认配额
+	vip := usage.WithTenant(context.Background(), "vip")
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.ReserveDocument(vip, 1000))
+	}
+	status, err = m.Status(vip, "vip")
+	require.NoError(t, err)
+	assert.Equal(t, Allowance{Limit: 0, Used: 3, Remaining: -1}, status.Documents)
+
+	// 归还不会使用量小于0，未记录租户的文档不归还
+	anonymous := context.Background()
+	require.NoError(t, m.ReserveDocument(anonymous, 100))
+	m.ReleaseDocument(anonymous, "", 100)
+	m.ReleaseDocument(anonymous, usage.AnonymousTenant, 5000)
+	status, err = m.Status(anonymous, usage.AnonymousTenant)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.StorageBytes.Used)
+	assert.Equal(t, int64(0), status.Documents.Used)
+}
+
+// TestQAQuota 测试每日问答次数配额
+func TestQAQuota(t *testing.T) {
+	setupQuotaTestDB(t)
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	m := newTestManager(day, WithDefaultLimits(Limits{MaxQACallsPerDay: 5}))
+	ctx := usage.WithTenant(context.Background(), "team-a")
+
+	// 并发消耗也不会超出上限
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.ConsumeQA(ctx) == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, succeeded)
+	assert.ErrorIs(t, m.ConsumeQA(ctx), models.ErrQuotaExceeded)
+
+	status, err := m.Status(ctx, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, Allowance{Limit: 5, Used: 5, Remaining: 0}, status.QACalls)
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), status.QAResetAt)
+
+	// 第二天重新计数
+	m.now = func() time.Time { return day.AddDate(0, 0, 1) }
+	assert.NoError(t, m.ConsumeQA(ctx))
+
+	// 未启用配额时不限制
+	var disabled *Manager
+	assert.NoError(t, disabled.ConsumeQA(ctx))
+	assert.NoError(t, disabled.ReserveDocument(ctx, 1<<40))
+	status, err = disabled.Status(ctx, "team-a")
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), status.QACalls.Remaining)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditFilter 审计日志查询条件，空字段表示不过滤
+type AuditFilter struct {
+	Actor  string             // 操作者
+	Action models.AuditAction // 操作类型
+	Target string             // 操作对象
+	Since  time.Time          // 起始时间（含）
+	Until  time.Time          // 结束时间（不含）
+}
+
+// AuditRepository 审计日志仓储接口
+// 审计日志只追加，因此只提供写入和查询，不提供修改和删除
+type AuditRepository interface {
+	// Append 追加一条审计日志
+	Append(entry *models.AuditLog) error
+
+	// List 按条件分页查询审计日志，最新的在前，同时返回总数
+	List(filter AuditFilter, offset, limit int) ([]*models.AuditLog, int64, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) AuditRepository
+}
+
+// auditRepo 审计日志仓储实现
+type auditRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewAuditRepository 创建审计日志仓储实例
+func NewAuditRepository() AuditRepository {
+	return &auditRepo{
+		db: database.MustDB(),
+	}
+}
+
+// Append 追加一条审计日志
+func (r *auditRepo) Append(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// List 按条件分页查询审计日志
+func (r *auditRepo) List(filter AuditFilter, offset, limit int) ([]*models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*models.AuditLog
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *auditRepo) WithContext(ctx context.Context) AuditRepository {
+	return &auditRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// CalibrationRepository 检索相关度阈值校准结果仓储接口
+type CalibrationRepository interface {
+	// Get 获取嵌入模型的校准结果，不存在时返回nil
+	Get(model string) (*models.ScoreCalibration, error)
+
+	// Save 保存校准结果，同一模型的旧结果被覆盖
+	Save(calibration *models.ScoreCalibration) error
+
+	// List 列出所有模型的校准结果，按模型名称排序
+	List() ([]*models.ScoreCalibration, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) CalibrationRepository
+}
+
+// calibrationRepo 校准结果仓储实现
+type calibrationRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewCalibrationRepository 创建校准结果仓储实例
+func NewCalibrationRepository() CalibrationRepository {
+	return &calibrationRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *calibrationRepo) WithContext(ctx context.Context) CalibrationRepository {
+	return &calibrationRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// Get 获取嵌入模型的校准结果
+func (r *calibrationRepo) Get(model string) (*models.ScoreCalibration, error) {
+	var calibration models.ScoreCalibration
+	err := r.db.Where("model = ?", model).First(&calibration).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &calibration, nil
+}
+
+// Save 保存校准结果
+func (r *calibrationRepo) Save(calibration *models.ScoreCalibration) error {
+	if calibration.Model == "" {
+		return errors.New("embedding model name cannot be empty")
+	}
+	return r.db.Save(calibration).Error
+}
+
+// List 列出所有模型的校准结果
+func (r *calibrationRepo) List() ([]*models.ScoreCalibration, error) {
+	var calibrations []*models.ScoreCalibration
+	err := r.db.Order("model").Find(&calibrations).Error
+	return calibrations, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/database"
@@ -24,6 +25,9 @@ type ChatRepository interface {
 	// ListSessions 列出聊天会话，支持分页和筛选
 	ListSessions(offset, limit int, filters map[string]interface{}) ([]*models.ChatSession, int64, error)
 
+	// ListSessionsAfter 使用游标分页列出聊天会话，返回下一页游标，为空表示没有更多记录
+	ListSessionsAfter(cursor string, limit int, filters map[string]interface{}) ([]*models.ChatSession, string, error)
+
 	// UpdateSession 更新聊天会话
 	UpdateSession(session *models.ChatSession) error
 
@@ -42,6 +46,33 @@ type ChatRepository interface {
 	// CountMessages 统计会话消息数量
 	CountMessages(sessionID string) (int64, error)
 
+	// GetMessage 获取会话中的一条消息
+	GetMessage(sessionID string, id uint) (*models.ChatMessage, error)
+
+	// GetPreviousMessage 获取会话中指定消息之前最近的一条指定角色的消息
+	GetPreviousMessage(sessionID string, id uint, role models.MessageRole) (*models.ChatMessage, error)
+
+	// ReviseMessage 保存消息的新内容，并把被替换的内容写入历史版本
+	ReviseMessage(message *models.ChatMessage, previous *models.ChatMessageVersion) error
+
+	// DeleteMessagesAfter 删除会话中指定消息之后的所有消息及其历史版本，返回删除的消息数
+	DeleteMessagesAfter(sessionID string, id uint) (int64, error)
+
+	// GetMessageVersions 获取消息的历史版本，按版本号升序
+	GetMessageVersions(messageID uint) ([]*models.ChatMessageVersion, error)
+
+	// GetMessagesAfter 获取会话中指定消息之后最近的limit条消息，按先后顺序排列
+	GetMessagesAfter(sessionID string, afterID uint, limit int) ([]*models.ChatMessage, error)
+
+	// SearchMessages 在userID的会话中搜索内容包含全部关键词的消息，userID为空时只搜索匿名会话，按时间倒序
+	SearchMessages(terms []string, userID string, offset, limit int) ([]*models.ChatMessage, int64, error)
+
+	// SetMessagePinned 设置消息的收藏时间，pinnedAt为nil时取消收藏
+	SetMessagePinned(sessionID string, id uint, pinnedAt *time.Time) error
+
+	// ListPinnedMessages 列出userID的会话中收藏的消息，userID为空时只列出匿名会话，按收藏时间倒序
+	ListPinnedMessages(userID string, offset, limit int) ([]*models.ChatMessage, int64, error)
+
 	// WithContext 创建带有上下文的仓储
 	WithContext(ctx context.Context) ChatRepository
 }
@@ -113,32 +144,7 @@ func (r *chatRepo) ListSessions(offset, limit int, filters map[string]interface{
 	query := r.db.Model(&models.ChatSession{})
 
 	// 应用筛选条件
-	if filters != nil {
-		// 用户ID过滤
-		if userID, ok := filters["user_id"].(string); ok && userID != "" {
-			query = query.Where("user_id = ?", userID)
-		}
-
-		// 标签过滤
-		if tags, ok := filters["tags"].(string); ok && tags != "" {
-			// 使用LIKE查询匹配包含指定标签的会话
-			query = query.Where("tags LIKE ?", "%"+tags+"%")
-		}
-
-		// 时间范围过滤
-		if startTime, ok := filters["start_time"].(time.Time); ok {
-			query = query.Where("created_at >= ?", startTime)
-		}
-
-		if endTime, ok := filters["end_time"].(time.Time); ok {
-			query = query.Where("created_at <= ?", endTime)
-		}
-
-		// 标题关键词搜索
-		if title, ok := filters["title"].(string); ok && title != "" {
-			query = query.Where("title LIKE ?", "%"+title+"%")
-		}
-	}
+	query = applySessionFilters(query, filters)
 
 	// 获取总数
 	err := query.Count(&total).Error
@@ -147,7 +153,8 @@ func (r *chatRepo) ListSessions(offset, limit int, filters map[string]interface{
 	}
 
 	// 应用排序和分页
-	err = query.Order("updated_at DESC").
+	// 更新时间相同时按ID排序，与游标分页的顺序一致
+	err = query.Order("updated_at DESC").Order("id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&sessions).Error
@@ -159,6 +166,66 @@ func (r *chatRepo) ListSessions(offset, limit int, filters map[string]interface{
 	return sessions, total, nil
 }
 
+// ListSessionsAfter 使用游标分页列出聊天会话，按更新时间倒序
+// cursor为空时从第一页开始，返回的下一页游标为空表示没有更多记录
+func (r *chatRepo) ListSessionsAfter(cursor string, limit int, filters map[string]interface{}) ([]*models.ChatSession, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	after, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := applySessionFilters(r.db.Model(&models.ChatSession{}), filters)
+
+	// 多取一条判断是否还有下一页
+	var sessions []*models.ChatSession
+	if err := applyCursor(query, "updated_at", after).Limit(limit + 1).Find(&sessions).Error; err != nil {
+		return nil, "", err
+	}
+	if len(sessions) <= limit {
+		return sessions, "", nil
+	}
+	sessions = sessions[:limit]
+	last := sessions[limit-1]
+	return sessions, Cursor{Time: last.UpdatedAt, ID: last.ID}.Encode(), nil
+}
+
+// applySessionFilters 应用聊天会话列表的筛选条件
+func applySessionFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	if filters == nil {
+		return query
+	}
+
+	// 用户ID过滤
+	if userID, ok := filters["user_id"].(string); ok && userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	// 标签过滤
+	if tags, ok := filters["tags"].(string); ok && tags != "" {
+		// 使用LIKE查询匹配包含指定标签的会话
+		query = query.Where("tags LIKE ?", "%"+tags+"%")
+	}
+
+	// 时间范围过滤
+	if startTime, ok := filters["start_time"].(time.Time); ok {
+		query = query.Where("created_at >= ?", startTime)
+	}
+
+	if endTime, ok := filters["end_time"].(time.Time); ok {
+		query = query.Where("created_at <= ?", endTime)
+	}
+
+	// 标题关键词搜索
+	if title, ok := filters["title"].(string); ok && title != "" {
+		query = query.Where("title LIKE ?", "%"+title+"%")
+	}
+
+	return query
+}
+
 // UpdateSession 更新聊天会话
 func (r *chatRepo) UpdateSession(session *models.ChatSession) error {
 	if session.ID == "" {
@@ -175,7 +242,10 @@ func (r *chatRepo) UpdateSession(session *models.ChatSession) error {
 func (r *chatRepo) DeleteSession(id string) error {
 	// 开启事务
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// 1. 删除会话的所有消息
+		// 1. 删除会话的所有消息及其历史版本
+		if err := tx.Where("session_id = ?", id).Delete(&models.ChatMessageVersion{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("session_id = ?", id).Delete(&models.ChatMessage{}).Error; err != nil {
 			return err
 		}
@@ -277,3 +347,160 @@ func (r *chatRepo) CountMessages(sessionID string) (int64, error) {
 
 	return count, err
 }
+
+// GetMessage 获取会话中的一条消息
+func (r *chatRepo) GetMessage(sessionID string, id uint) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	if err := r.db.Where("session_id = ? AND id = ?", sessionID, id).First(&message).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("chat message not found: %d", id)
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// GetPreviousMessage 获取会话中指定消息之前最近的一条指定角色的消息
+// 消息ID自增，按ID排序即为消息的先后顺序
+func (r *chatRepo) GetPreviousMessage(sessionID string, id uint, role models.MessageRole) (*models.ChatMessage, error) {
+	var message models.ChatMessage
+	err := r.db.Where("session_id = ? AND id < ? AND role = ?", sessionID, id, role).
+		Order("id DESC").
+		First(&message).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("no %s message before %d", role, id)
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ReviseMessage 保存消息的新内容，并把被替换的内容写入历史版本
+func (r *chatRepo) ReviseMessage(message *models.ChatMessage, previous *models.ChatMessageVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ChatMessage{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+			"content":   message.Content,
+			"sources":   message.Sources,
+			"version":   message.Version,
+			"edited_at": message.EditedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ChatSession{}).
+			Where("id = ?", message.SessionID).
+			Update("updated_at", time.Now()).Error
+	})
+}
+
+// DeleteMessagesAfter 删除会话中指定消息之后的所有消息及其历史版本，返回删除的消息数
+func (r *chatRepo) DeleteMessagesAfter(sessionID string, id uint) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		later := tx.Model(&models.ChatMessage{}).Select("id").Where("session_id = ? AND id > ?", sessionID, id)
+		if err := tx.Where("message_id IN (?)", later).Delete(&models.ChatMessageVersion{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("session_id = ? AND id > ?", sessionID, id).Delete(&models.ChatMessage{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// GetMessageVersions 获取消息的历史版本，按版本号升序
+func (r *chatRepo) GetMessageVersions(messageID uint) ([]*models.ChatMessageVersion, error) {
+	var versions []*models.ChatMessageVersion
+	err := r.db.Where("message_id = ?", messageID).Order("version ASC").Find(&versions).Error
+	return versions, err
+}
+
+// GetMessagesAfter 获取会话中指定消息之后最近的limit条消息，按先后顺序排列
+func (r *chatRepo) GetMessagesAfter(sessionID string, afterID uint, limit int) ([]*models.ChatMessage, error) {
+	var messages []*models.ChatMessage
+	err := r.db.Where("session_id = ? AND id > ?", sessionID, afterID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// SearchMessages 搜索内容包含全部关键词的消息
+// 使用LIKE匹配，兼容SQLite、MySQL和PostgreSQL；关键词中的通配符按字面匹配
+func (r *chatRepo) SearchMessages(terms []string, userID string, offset, limit int) ([]*models.ChatMessage, int64, error) {
+	var messages []*models.ChatMessage
+	var total int64
+
+	query := r.db.Model(&models.ChatMessage{})
+	for _, term := range terms {
+		query = query.Where("chat_messages.content LIKE ? ESCAPE '!'", "%"+escapeLike(term)+"%")
+	}
+	query = query.Joins("JOIN chat_sessions ON chat_sessions.id = chat_messages.session_id").
+		Where("chat_sessions.user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Select("chat_messages.*").
+		Order("chat_messages.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// escapeLike 转义LIKE模式中的通配符，配合ESCAPE '!'使用
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// SetMessagePinned 设置消息的收藏时间，pinnedAt为nil时取消收藏
+func (r *chatRepo) SetMessagePinned(sessionID string, id uint, pinnedAt *time.Time) error {
+	result := r.db.Model(&models.ChatMessage{}).
+		Where("session_id = ? AND id = ?", sessionID, id).
+		Update("pinned_at", pinnedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("chat message not found: %d", id)
+	}
+	return nil
+}
+
+// ListPinnedMessages 列出收藏的消息，按收藏时间倒序
+func (r *chatRepo) ListPinnedMessages(userID string, offset, limit int) ([]*models.ChatMessage, int64, error) {
+	var messages []*models.ChatMessage
+	var total int64
+
+	query := r.db.Model(&models.ChatMessage{}).Where("chat_messages.pinned_at IS NOT NULL").
+		Joins("JOIN chat_sessions ON chat_sessions.id = chat_messages.session_id").
+		Where("chat_sessions.user_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Select("chat_messages.*").
+		Order("chat_messages.pinned_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
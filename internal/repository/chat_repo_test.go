@@ -21,7 +21,7 @@ func setupChatTestDB(t *testing.T) (*gorm.DB, func()) {
 	require.NoError(t, err, "Failed to open in-memory database")
 
 	// Run migrations
-	err = db.AutoMigrate(&models.ChatSession{}, &models.ChatMessage{})
+	err = db.AutoMigrate(&models.ChatSession{}, &models.ChatMessage{}, &models.ChatMessageVersion{})
 	require.NoError(t, err, "Failed to run migrations")
 
 	// Save original DB reference
@@ -149,6 +149,41 @@ func TestChatRepository_ListSessions(t *testing.T) {
 	assert.Len(t, resultSessions, 2, "Should return 2 sessions with important tag")
 }
 
+func TestChatRepository_ListSessionsAfter(t *testing.T) {
+	_, cleanup := setupChatTestDB(t)
+	defer cleanup()
+
+	repo := NewChatRepository()
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.CreateSession(&models.ChatSession{
+			ID:        fmt.Sprintf("cursor-session-%d", i),
+			Title:     fmt.Sprintf("Session %d", i),
+			CreatedAt: base,
+			UpdatedAt: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	var ids []string
+	cursor := ""
+	for {
+		page, next, err := repo.ListSessionsAfter(cursor, 2, nil)
+		require.NoError(t, err)
+		for _, session := range page {
+			ids = append(ids, session.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"cursor-session-4", "cursor-session-3", "cursor-session-2", "cursor-session-1", "cursor-session-0"}, ids)
+
+	_, _, err := repo.ListSessionsAfter("", 0, nil)
+	assert.Error(t, err)
+}
+
 func TestChatRepository_UpdateSession(t *testing.T) {
 	_, cleanup := setupChatTestDB(t)
 	defer cleanup()
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// ConnectorRepository 连接器同步状态仓储接口
+type ConnectorRepository interface {
+	// GetState 获取连接器的同步状态，不存在时返回nil
+	GetState(name string) (*models.ConnectorState, error)
+
+	// SaveState 保存连接器的同步状态
+	SaveState(state *models.ConnectorState) error
+
+	// ListItems 列出连接器已同步的条目
+	ListItems(connector string) ([]*models.ConnectorItem, error)
+
+	// SaveItem 保存已同步的条目
+	SaveItem(item *models.ConnectorItem) error
+
+	// DeleteItem 删除已同步的条目
+	DeleteItem(connector, itemID string) error
+
+	// CountItems 统计连接器已同步的条目数量
+	CountItems(connector string) (int64, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) ConnectorRepository
+}
+
+// connectorRepo 连接器同步状态仓储实现
+type connectorRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewConnectorRepository 创建连接器同步状态仓储实例
+func NewConnectorRepository() ConnectorRepository {
+	return &connectorRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *connectorRepo) WithContext(ctx context.Context) ConnectorRepository {
+	return &connectorRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// GetState 获取连接器的同步状态
+func (r *connectorRepo) GetState(name string) (*models.ConnectorState, error) {
+	var state models.ConnectorState
+	err := r.db.Where("name = ?", name).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveState 保存连接器的同步状态
+func (r *connectorRepo) SaveState(state *models.ConnectorState) error {
+	if state.Name == "" {
+		return errors.New("connector name cannot be empty")
+	}
+	return r.db.Save(state).Error
+}
+
+// ListItems 列出连接器已同步的条目
+func (r *connectorRepo) ListItems(connector string) ([]*models.ConnectorItem, error) {
+	var items []*models.ConnectorItem
+	err := r.db.Where("connector = ?", connector).Order("item_id").Find(&items).Error
+	return items, err
+}
+
+// SaveItem 保存已同步的条目
+func (r *connectorRepo) SaveItem(item *models.ConnectorItem) error {
+	if item.Connector == "" || item.ItemID == "" {
+		return errors.New("connector item key cannot be empty")
+	}
+	return r.db.Save(item).Error
+}
+
+// DeleteItem 删除已同步的条目
+func (r *connectorRepo) DeleteItem(connector, itemID string) error {
+	return r.db.Where("connector = ? AND item_id = ?", connector, itemID).
+		Delete(&models.ConnectorItem{}).Error
+}
+
+// CountItems 统计连接器已同步的条目数量
+func (r *connectorRepo) CountItems(connector string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ConnectorItem{}).Where("connector = ?", connector).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor 分页游标无法解析
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor 键集分页游标，记录上一页最后一条记录的排序时间和ID
+// 下一页从严格排在它之后的记录开始，翻页期间有记录增删时不会跳过或重复
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// Encode 把游标编码为URL安全的字符串
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解析游标字符串，空字符串表示从第一页开始
+func DecodeCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Time.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// applyCursor 按"时间列 DESC, id DESC"排序，并只保留排在游标之后的记录
+func applyCursor(query *gorm.DB, column string, cursor *Cursor) *gorm.DB {
+	if cursor != nil {
+		query = query.Where("("+column+" < ? OR ("+column+" = ? AND id < ?))", cursor.Time, cursor.Time, cursor.ID)
+	}
+	return query.Order(column + " DESC").Order("id DESC")
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Time: time.Date(2024, 5, 1, 8, 30, 0, 123456789, time.UTC), ID: "doc-1"}
+	decoded, err := DecodeCursor(c.Encode())
+	require.NoError(t, err)
+	assert.True(t, c.Time.Equal(decoded.Time))
+	assert.Equal(t, "doc-1", decoded.ID)
+
+	// 空游标表示第一页
+	decoded, err = DecodeCursor("")
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	for _, token := range []string{"!!!", "bm90IGpzb24", Cursor{ID: "doc-1"}.Encode()} {
+		_, err := DecodeCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
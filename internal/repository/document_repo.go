@@ -2,14 +2,19 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/database"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // docRepository 文档仓储实现
@@ -90,48 +95,7 @@ func (r *docRepository) List(offset, limit int, filters map[string]interface{})
 	query := r.db.Model(&models.Document{})
 
 	// 应用筛选条件
-	if filters != nil {
-		// 状态过滤
-		if status, ok := filters["status"]; ok {
-			// 处理不同类型的status
-			switch s := status.(type) {
-			case models.DocumentStatus:
-				// 如果是DocumentStatus类型，转换为string
-				query = query.Where("status = ?", string(s))
-			case string:
-				// 如果已经是string，直接使用
-				if s != "" {
-					query = query.Where("status = ?", s)
-				}
-			default:
-				// 其他类型，尝试转换为string
-				statusStr := fmt.Sprintf("%v", status)
-				if statusStr != "" {
-					query = query.Where("status = ?", statusStr)
-				}
-			}
-		}
-
-		// 标签过滤
-		if tags, ok := filters["tags"].(string); ok && tags != "" {
-			// 使用LIKE查询匹配包含指定标签的文档
-			query = query.Where("tags LIKE ?", "%"+tags+"%")
-		}
-
-		// 时间范围过滤
-		if startTime, ok := filters["start_time"].(string); ok && startTime != "" {
-			query = query.Where("uploaded_at >= ?", startTime)
-		}
-
-		if endTime, ok := filters["end_time"].(string); ok && endTime != "" {
-			query = query.Where("uploaded_at <= ?", endTime)
-		}
-
-		// 文件名过滤
-		if fileName, ok := filters["file_name"].(string); ok && fileName != "" {
-			query = query.Where("file_name LIKE ?", "%"+fileName+"%")
-		}
-	}
+	query = r.applyFilters(query, filters)
 
 	// 获取总数
 	err := query.Count(&total).Error
@@ -139,8 +103,8 @@ func (r *docRepository) List(offset, limit int, filters map[string]interface{})
 		return nil, 0, err
 	}
 
-	// 应用排序、分页并执行查询
-	err = query.Order("uploaded_at DESC").
+	// 应用排序、分页并执行查询，上传时间相同时按ID排序，与游标分页的顺序一致
+	err = query.Order("uploaded_at DESC").Order("id DESC").
 		Offset(offset).
 		Limit(limit).
 		Find(&docs).Error
@@ -152,6 +116,111 @@ func (r *docRepository) List(offset, limit int, filters map[string]interface{})
 	return docs, total, nil
 }
 
+// ListAfter 使用游标分页列出文档，按上传时间倒序
+// cursor为空时从第一页开始，返回的下一页游标为空表示没有更多记录；不统计总数，避免大表上的全表计数
+func (r *docRepository) ListAfter(cursor string, limit int, filters map[string]interface{}) ([]*models.Document, string, error) {
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+	after, err := DecodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	query := r.applyFilters(r.db.Model(&models.Document{}), filters)
+
+	// 多取一条判断是否还有下一页
+	var docs []*models.Document
+	if err := applyCursor(query, "uploaded_at", after).Limit(limit + 1).Find(&docs).Error; err != nil {
+		return nil, "", err
+	}
+	if len(docs) <= limit {
+		return docs, "", nil
+	}
+	docs = docs[:limit]
+	last := docs[limit-1]
+	return docs, Cursor{Time: last.UploadedAt, ID: last.ID}.Encode(), nil
+}
+
+// applyFilters 应用文档列表的筛选条件
+func (r *docRepository) applyFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	if filters == nil {
+		return query
+	}
+
+	// 状态过滤
+	if status, ok := filters["status"]; ok {
+		// 处理不同类型的status
+		switch s := status.(type) {
+		case models.DocumentStatus:
+			// 如果是DocumentStatus类型，转换为string
+			query = query.Where("status = ?", string(s))
+		case string:
+			// 如果已经是string，直接使用
+			if s != "" {
+				query = query.Where("status = ?", s)
+			}
+		default:
+			// 其他类型，尝试转换为string
+			statusStr := fmt.Sprintf("%v", status)
+			if statusStr != "" {
+				query = query.Where("status = ?", statusStr)
+			}
+		}
+	}
+
+	// 标签过滤
+	if tags, ok := filters["tags"].(string); ok && tags != "" {
+		// 使用LIKE查询匹配包含指定标签的文档
+		query = query.Where("tags LIKE ?", "%"+tags+"%")
+	}
+
+	// 时间范围过滤
+	if startTime, ok := filters["start_time"].(string); ok && startTime != "" {
+		query = query.Where("uploaded_at >= ?", startTime)
+	}
+
+	if endTime, ok := filters["end_time"].(string); ok && endTime != "" {
+		query = query.Where("uploaded_at <= ?", endTime)
+	}
+
+	// 文件名过滤
+	if fileName, ok := filters["file_name"].(string); ok && fileName != "" {
+		query = query.Where("file_name LIKE ?", "%"+fileName+"%")
+	}
+
+	// 父文档过滤，列出从压缩包或邮件中展开的子文档
+	if parentID, ok := filters["parent_id"].(string); ok && parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	}
+
+	// 访问控制过滤，只列出用户可以读取的文档
+	if reader, ok := filters["reader"].(DocumentReader); ok {
+		condition, args := r.readableCondition(reader)
+		query = query.Where(condition, args...)
+	}
+
+	// 关键词过滤，按空白拆分为多个关键词，每个关键词都要匹配文件名
+	// search_content为true时，匹配任一段落文本也算命中
+	if q, ok := filters["q"].(string); ok {
+		searchContent, _ := filters["search_content"].(bool)
+		for _, term := range strings.Fields(q) {
+			pattern := likePattern(term)
+			if searchContent {
+				query = query.Where(
+					"(file_name LIKE ? ESCAPE '!' OR id IN (?))",
+					pattern,
+					r.db.Model(&models.DocumentSegment{}).Select("document_id").Where("text LIKE ? ESCAPE '!'", pattern),
+				)
+			} else {
+				query = query.Where("file_name LIKE ? ESCAPE '!'", pattern)
+			}
+		}
+	}
+
+	return query
+}
+
 // Delete 删除文档记录
 func (r *docRepository) Delete(id string) error {
 	// 开启事务
@@ -161,10 +230,13 @@ func (r *docRepository) Delete(id string) error {
 			return err
 		}
 
-		// 2. 删除文档记录
+		// 2. 删除文档记录和共享记录
 		if err := tx.Where("id = ?", id).Delete(&models.Document{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("document_id = ?", id).Delete(&models.DocumentShare{}).Error; err != nil {
+			return err
+		}
 
 		// 3. 如果任务队列已初始化，尝试获取并删除相关任务
 		if r.taskQueue != nil {
@@ -223,6 +295,35 @@ func (r *docRepository) UpdateProgress(id string, progress int) error {
 		}).Error
 }
 
+// UpdateSummary 保存文档摘要
+// 摘要不是文档内容的变更，使用UpdateColumn跳过更新钩子，保留原有的更新时间
+func (r *docRepository) UpdateSummary(id string, summary []byte) error {
+	result := r.db.Model(&models.Document{}).
+		Where("id = ?", id).
+		UpdateColumn("summary", datatypes.JSON(summary))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found: %s", id)
+	}
+	return nil
+}
+
+// UpdateImages 保存从文档中提取的图片列表
+func (r *docRepository) UpdateImages(id string, images []byte) error {
+	result := r.db.Model(&models.Document{}).
+		Where("id = ?", id).
+		UpdateColumn("images", datatypes.JSON(images))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document not found: %s", id)
+	}
+	return nil
+}
+
 // SaveSegment 保存文档段落
 func (r *docRepository) SaveSegment(segment *models.DocumentSegment) error {
 	return r.db.Create(segment).Error
@@ -250,6 +351,209 @@ func (r *docRepository) GetSegments(docID string) ([]*models.DocumentSegment, er
 	return segments, err
 }
 
+// ListSegments 按位置分页列出文档的段落
+func (r *docRepository) ListSegments(docID string, offset, limit int) ([]*models.DocumentSegment, int64, error) {
+	var segments []*models.DocumentSegment
+	var total int64
+
+	query := r.db.Model(&models.DocumentSegment{}).Where("document_id = ?", docID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("position ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&segments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return segments, total, nil
+}
+
+// GetSegment 根据段落ID获取段落
+func (r *docRepository) GetSegment(segmentID string) (*models.DocumentSegment, error) {
+	var segment models.DocumentSegment
+	err := r.db.Where("segment_id = ?", segmentID).First(&segment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("segment not found: %s", segmentID)
+		}
+		return nil, err
+	}
+	return &segment, nil
+}
+
+// GetSegmentsByID 按段落ID批量获取段落，不存在的ID忽略
+func (r *docRepository) GetSegmentsByID(segmentIDs []string) ([]*models.DocumentSegment, error) {
+	if len(segmentIDs) == 0 {
+		return nil, nil
+	}
+	var segments []*models.DocumentSegment
+	err := r.db.Where("segment_id IN ?", segmentIDs).Find(&segments).Error
+	return segments, err
+}
+
+// ListSegmentRange 按位置顺序列出文档中位置在[from, to]范围内的段落
+func (r *docRepository) ListSegmentRange(docID string, from, to int) ([]*models.DocumentSegment, error) {
+	var segments []*models.DocumentSegment
+	err := r.db.Where("document_id = ? AND position BETWEEN ? AND ?", docID, from, to).
+		Order("position ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// ListExcludedFileIDs 列出不参与检索的文档ID，包括标记为不检索的文档和带有任一指定标签的文档
+// 标签以逗号分隔保存，先用LIKE粗筛，再逐个比较标签避免部分匹配
+func (r *docRepository) ListExcludedFileIDs(tags []string) ([]string, error) {
+	query := r.db.Model(&models.Document{}).Select("id", "tags", "no_retrieve").Where("no_retrieve = ?", true)
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			query = query.Or("tags LIKE ? ESCAPE '!'", likePattern(tag))
+		}
+	}
+
+	var docs []*models.Document
+	if err := query.Order("id ASC").Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if doc.NoRetrieve || hasAnyTag(doc, tags) {
+			ids = append(ids, doc.ID)
+		}
+	}
+	return ids, nil
+}
+
+// ListShares 列出文档的共享记录
+func (r *docRepository) ListShares(docID string) ([]*models.DocumentShare, error) {
+	var shares []*models.DocumentShare
+	err := r.db.Where("document_id = ?", docID).Order("kind ASC, name ASC").Find(&shares).Error
+	return shares, err
+}
+
+// UpdateACL 设置文档是否公开，并整体替换共享记录
+func (r *docRepository) UpdateACL(docID string, public bool, shares []*models.DocumentShare) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Document{}).Where("id = ?", docID).Updates(map[string]interface{}{
+			"public":     public,
+			"updated_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrDocumentNotFound
+		}
+
+		if err := tx.Where("document_id = ?", docID).Delete(&models.DocumentShare{}).Error; err != nil {
+			return err
+		}
+		if len(shares) == 0 {
+			return nil
+		}
+		for _, share := range shares {
+			share.DocumentID = docID
+		}
+		return tx.Create(&shares).Error
+	})
+}
+
+// ListReadableFileIDs 列出用户可以读取的文档ID，fileIDs不为空时只在其中查找
+func (r *docRepository) ListReadableFileIDs(reader DocumentReader, fileIDs []string) ([]string, error) {
+	ids := []string{}
+	condition, args := r.readableCondition(reader)
+	query := r.db.Model(&models.Document{}).Where(condition, args...)
+	if len(fileIDs) > 0 {
+		query = query.Where("id IN ?", fileIDs)
+	}
+	err := query.Order("id ASC").Pluck("id", &ids).Error
+	return ids, err
+}
+
+// readableCondition 用户可以读取文档的条件及其参数：公开、上传者或共享给用户及其所属用户组
+// 匿名用户（User为空）只能读取公开和共享给其用户组的文档；旧版本创建的文档没有记录上传者，
+// tenant可能为NULL，只按非空的上传者匹配，不会因用户为空而匹配，条件结果也不会为NULL
+func (r *docRepository) readableCondition(reader DocumentReader) (string, []interface{}) {
+	conditions := []string{"public = ?"}
+	args := []interface{}{true}
+	if reader.User != "" {
+		conditions = append(conditions, "COALESCE(tenant, '') = ?", "id IN (?)")
+		args = append(args, reader.User, r.sharedWith("kind = ? AND name = ?", models.ShareKindUser, reader.User))
+	}
+	if len(reader.Groups) > 0 {
+		conditions = append(conditions, "id IN (?)")
+		args = append(args, r.sharedWith("kind = ? AND name IN ?", models.ShareKindGroup, reader.Groups))
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// sharedWith 返回满足条件的共享记录所属文档ID的子查询
+func (r *docRepository) sharedWith(query string, args ...interface{}) *gorm.DB {
+	return r.db.Model(&models.DocumentShare{}).Select("document_id").Where(query, args...)
+}
+
+// ownerlessCondition 上传者为空或为ownerless之一的条件
+func ownerlessCondition(ownerless []string) (string, []interface{}) {
+	return "COALESCE(tenant, '') IN ?", []interface{}{append([]string{""}, ownerless...)}
+}
+
+// CountOwnerless 统计上传者为空或为ownerless之一的文档数量
+func (r *docRepository) CountOwnerless(ownerless []string) (int64, error) {
+	condition, args := ownerlessCondition(ownerless)
+	var count int64
+	err := r.db.Model(&models.Document{}).Where(condition, args...).Count(&count).Error
+	return count, err
+}
+
+// AssignOwnerless 将上传者为空或为ownerless之一的文档归属owner（为空时不修改上传者），public为true时同时设为公开
+func (r *docRepository) AssignOwnerless(ownerless []string, owner string, public bool) (int64, error) {
+	updates := map[string]interface{}{}
+	if owner != "" {
+		updates["tenant"] = owner
+	}
+	if public {
+		updates["public"] = true
+	}
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	condition, args := ownerlessCondition(ownerless)
+	result := r.db.Model(&models.Document{}).Where(condition, args...).Updates(updates)
+	return result.RowsAffected, result.Error
+}
+
+// hasAnyTag 判断文档是否带有任一指定标签
+func hasAnyTag(doc *models.Document, tags []string) bool {
+	for _, tag := range tags {
+		if doc.HasTag(strings.TrimSpace(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateSegmentText 更新段落文本，同时更新文本哈希，重新处理文档时修改过的段落按新文本比较
+func (r *docRepository) UpdateSegmentText(segmentID, text string) error {
+	sum := sha256.Sum256([]byte(text))
+	result := r.db.Model(&models.DocumentSegment{}).
+		Where("segment_id = ?", segmentID).
+		Updates(map[string]interface{}{
+			"text":       text,
+			"text_hash":  hex.EncodeToString(sum[:]),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("segment not found: %s", segmentID)
+	}
+	return nil
+}
+
 // CountSegments 统计文档的段落数量
 func (r *docRepository) CountSegments(docID string) (int, error) {
 	var count int64
@@ -265,6 +569,47 @@ func (r *docRepository) DeleteSegments(docID string) error {
 		Delete(&models.DocumentSegment{}).Error
 }
 
+// UpsertSegments 批量保存段落，段落ID已存在时覆盖位置、文本、元数据、近似重复标记和所在章节
+func (r *docRepository) UpsertSegments(segments []*models.DocumentSegment) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "segment_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "text", "text_hash", "metadata", "updated_at",
+			"fingerprint", "duplicate_of", "suppressed", "parent_id"}),
+	}).CreateInBatches(segments, 100).Error
+}
+
+// ListSegmentFingerprints 列出所有记录了指纹且不是重复段落的段落，只读取文档ID、段落ID和指纹
+func (r *docRepository) ListSegmentFingerprints() ([]*models.DocumentSegment, error) {
+	var segments []*models.DocumentSegment
+	err := r.db.Select("document_id", "segment_id", "fingerprint").
+		Where("fingerprint <> 0 AND duplicate_of = ''").
+		Order("id ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// ListSuppressedDuplicates 列出作为指定文档段落的重复而未写入向量库的段落
+func (r *docRepository) ListSuppressedDuplicates(docID string) ([]*models.DocumentSegment, error) {
+	prefix := strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(docID) + "!_%"
+	var segments []*models.DocumentSegment
+	err := r.db.Where("suppressed = ? AND duplicate_of LIKE ? ESCAPE '!'", true, prefix).
+		Order("id ASC").
+		Find(&segments).Error
+	return segments, err
+}
+
+// DeleteSegmentsByID 按段落ID删除段落
+func (r *docRepository) DeleteSegmentsByID(segmentIDs []string) error {
+	if len(segmentIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("segment_id IN ?", segmentIDs).
+		Delete(&models.DocumentSegment{}).Error
+}
+
 // WithContext 创建带有上下文的仓储
 func (r *docRepository) WithContext(ctx context.Context) DocumentRepository {
 	return &docRepository{
@@ -387,3 +732,10 @@ func (r *docRepository) DeleteTask(ctx context.Context, taskID string) error {
 
 	return r.taskQueue.DeleteTask(ctx, taskID)
 }
+
+// likePattern 将关键词转换为包含匹配的LIKE模式，以!转义其中的通配符
+// 不使用反斜杠作为转义符，因为MySQL会把字符串字面量中的反斜杠当作转义符
+func likePattern(term string) string {
+	escaped := strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(term)
+	return "%" + escaped + "%"
+}
@@ -21,7 +21,7 @@ func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 	require.NoError(t, err, "Failed to open in-memory database")
 
 	// 运行迁移以创建所需的表
-	err = db.AutoMigrate(&models.Document{}, &models.DocumentSegment{})
+	err = db.AutoMigrate(&models.Document{}, &models.DocumentSegment{}, &models.DocumentShare{})
 	require.NoError(t, err, "Failed to run migrations")
 
 	// 保存原始全局DB引用
@@ -200,6 +200,226 @@ func TestDocumentRepository_List(t *testing.T) {
 	assert.Len(t, resultDocs, 2, "Should return 2 documents with report tag")
 }
 
+func TestDocumentRepository_ListAfter(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewDocumentRepository()
+
+	// 两篇文档的上传时间相同，按ID排序
+	base := time.Now().Add(-time.Hour)
+	for i, offset := range []time.Duration{0, time.Minute, time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		status := models.DocStatusCompleted
+		if i%2 == 1 {
+			status = models.DocStatusFailed
+		}
+		require.NoError(t, repo.Create(&models.Document{
+			ID:         fmt.Sprintf("cursor-doc-%d", i),
+			FileName:   fmt.Sprintf("doc%d.txt", i),
+			Status:     status,
+			UploadedAt: base.Add(offset),
+		}))
+	}
+
+	page, next, err := repo.ListAfter("", 2, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, next)
+	assert.Equal(t, "cursor-doc-4", page[0].ID)
+	assert.Equal(t, "cursor-doc-3", page[1].ID)
+
+	// 翻页期间新上传的文档不影响后续页
+	require.NoError(t, repo.Create(&models.Document{ID: "cursor-doc-new", FileName: "new.txt", Status: models.DocStatusCompleted, UploadedAt: time.Now()}))
+
+	var ids []string
+	for _, doc := range page {
+		ids = append(ids, doc.ID)
+	}
+	for next != "" {
+		page, next, err = repo.ListAfter(next, 2, nil)
+		require.NoError(t, err)
+		for _, doc := range page {
+			ids = append(ids, doc.ID)
+		}
+	}
+	assert.Equal(t, []string{"cursor-doc-4", "cursor-doc-3", "cursor-doc-2", "cursor-doc-1", "cursor-doc-0"}, ids)
+
+	// 游标分页与偏移分页顺序一致，可以从偏移分页的最后一条记录继续
+	offsetPage, _, err := repo.List(0, 3, nil)
+	require.NoError(t, err)
+	last := offsetPage[2]
+	page, _, err = repo.ListAfter(Cursor{Time: last.UploadedAt, ID: last.ID}.Encode(), 10, nil)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, "cursor-doc-2", page[0].ID)
+
+	// 筛选条件与List相同
+	page, next, err = repo.ListAfter("", 10, map[string]interface{}{"status": models.DocStatusFailed})
+	require.NoError(t, err)
+	assert.Empty(t, next)
+	require.Len(t, page, 2)
+	assert.Equal(t, "cursor-doc-3", page[0].ID)
+	assert.Equal(t, "cursor-doc-1", page[1].ID)
+
+	_, _, err = repo.ListAfter("not-a-cursor", 2, nil)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestDocumentRepository_ListKeyword(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewDocumentRepository()
+
+	docs := []*models.Document{
+		{ID: "kw-1", FileName: "deploy_guide.md", Status: models.DocStatusCompleted, Tags: "ops", UploadedAt: time.Now().Add(-2 * time.Hour)},
+		{ID: "kw-2", FileName: "deploy-notes.txt", Status: models.DocStatusFailed, Tags: "ops", UploadedAt: time.Now().Add(-1 * time.Hour)},
+		{ID: "kw-3", FileName: "faq.txt", Status: models.DocStatusCompleted, Tags: "support", UploadedAt: time.Now()},
+	}
+	for _, doc := range docs {
+		require.NoError(t, repo.Create(doc))
+	}
+	require.NoError(t, repo.SaveSegments([]*models.DocumentSegment{
+		{DocumentID: "kw-3", SegmentID: "kw-3_0", Position: 0, Text: "How to deploy with Docker"},
+		{DocumentID: "kw-1", SegmentID: "kw-1_0", Position: 0, Text: "Kubernetes manifests"},
+	}))
+
+	// 只匹配文件名
+	resultDocs, total, err := repo.List(0, 10, map[string]interface{}{"q": "deploy"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, resultDocs, 2)
+
+	// 同时匹配段落文本，并与状态过滤组合
+	resultDocs, total, err = repo.List(0, 10, map[string]interface{}{
+		"q":              "deploy",
+		"search_content": true,
+		"status":         string(models.DocStatusCompleted),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, resultDocs, 2)
+	assert.Equal(t, "kw-3", resultDocs[0].ID)
+	assert.Equal(t, "kw-1", resultDocs[1].ID)
+
+	// 多个关键词都要匹配
+	_, total, err = repo.List(0, 10, map[string]interface{}{"q": "deploy kubernetes", "search_content": true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// 分页时总数仍是全部匹配的数量
+	resultDocs, total, err = repo.List(1, 1, map[string]interface{}{"q": "deploy", "search_content": true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, resultDocs, 1)
+	assert.Equal(t, "kw-2", resultDocs[0].ID)
+
+	// 通配符按字面匹配
+	resultDocs, total, err = repo.List(0, 10, map[string]interface{}{"q": "y_g"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, resultDocs, 1)
+	assert.Equal(t, "kw-1", resultDocs[0].ID)
+	_, total, err = repo.List(0, 10, map[string]interface{}{"q": "y_n"})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, total, err = repo.List(0, 10, map[string]interface{}{"q": "%"})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestDocumentRepository_ACL(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo := NewDocumentRepository()
+
+	docs := []*models.Document{
+		{ID: "acl-1", FileName: "alice.md", Status: models.DocStatusCompleted, Tenant: "alice"},
+		{ID: "acl-2", FileName: "public.md", Status: models.DocStatusCompleted, Tenant: "alice"},
+		{ID: "acl-3", FileName: "shared.md", Status: models.DocStatusCompleted, Tenant: "alice"},
+		{ID: "acl-4", FileName: "legacy.md", Status: models.DocStatusCompleted},
+	}
+	for _, doc := range docs {
+		require.NoError(t, repo.Create(doc))
+	}
+	// 旧版本创建的文档没有记录上传者
+	require.NoError(t, db.Exec("UPDATE documents SET tenant = NULL WHERE id = ?", "acl-4").Error)
+
+	require.NoError(t, repo.UpdateACL("acl-2", true, nil))
+	require.NoError(t, repo.UpdateACL("acl-3", false, []*models.DocumentShare{
+		{Kind: models.ShareKindUser, Name: "bob"},
+		{Kind: models.ShareKindGroup, Name: "finance"},
+	}))
+	assert.ErrorIs(t, repo.UpdateACL("missing", true, nil), models.ErrDocumentNotFound)
+
+	shares, err := repo.ListShares("acl-3")
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	assert.Equal(t, models.ShareKindGroup, shares[0].Kind)
+	assert.Equal(t, "finance", shares[0].Name)
+
+	listed := func(reader DocumentReader) []string {
+		docs, total, err := repo.List(0, 10, map[string]interface{}{"reader": reader})
+		require.NoError(t, err)
+		ids := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			ids = append(ids, doc.ID)
+		}
+		assert.Equal(t, int64(len(ids)), total)
+		return ids
+	}
+	assert.ElementsMatch(t, []string{"acl-1", "acl-2", "acl-3"}, listed(DocumentReader{User: "alice"}))
+	assert.ElementsMatch(t, []string{"acl-2", "acl-3"}, listed(DocumentReader{User: "bob"}))
+	assert.ElementsMatch(t, []string{"acl-2", "acl-3"}, listed(DocumentReader{User: "carol", Groups: []string{"hr", "finance"}}))
+	assert.ElementsMatch(t, []string{"acl-2"}, listed(DocumentReader{User: "carol", Groups: []string{"hr"}}))
+
+	readable, err := repo.ListReadableFileIDs(DocumentReader{User: "carol"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acl-2"}, readable)
+	readable, err = repo.ListReadableFileIDs(DocumentReader{User: "bob"}, []string{"acl-1", "acl-3", "missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acl-3"}, readable)
+
+	// 匿名用户（User为空）不匹配没有记录上传者的文档，也不匹配空的共享用户
+	require.NoError(t, repo.Create(&models.Document{ID: "acl-5", FileName: "empty.md", Status: models.DocStatusCompleted}))
+	assert.ElementsMatch(t, []string{"acl-2"}, listed(DocumentReader{}))
+	readable, err = repo.ListReadableFileIDs(DocumentReader{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acl-2"}, readable)
+
+	// 迁移没有确定上传者的文档：上传者为NULL、空或为指定的匿名租户
+	require.NoError(t, repo.Create(&models.Document{ID: "acl-6", FileName: "anon.md", Status: models.DocStatusCompleted, Tenant: "anonymous"}))
+	count, err := repo.CountOwnerless([]string{"anonymous"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	count, err = repo.AssignOwnerless([]string{"anonymous"}, "", false)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	count, err = repo.AssignOwnerless([]string{"anonymous"}, "ops", true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	for _, id := range []string{"acl-4", "acl-5", "acl-6"} {
+		doc, err := repo.GetByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, "ops", doc.Tenant)
+		assert.True(t, doc.Public)
+	}
+	count, err = repo.CountOwnerless([]string{"anonymous"})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// 替换共享记录，删除文档时一并删除
+	require.NoError(t, repo.UpdateACL("acl-3", false, nil))
+	shares, err = repo.ListShares("acl-3")
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+	require.NoError(t, repo.UpdateACL("acl-1", false, []*models.DocumentShare{{Kind: models.ShareKindUser, Name: "bob"}}))
+	require.NoError(t, repo.Delete("acl-1"))
+	shares, err = repo.ListShares("acl-1")
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+}
+
 func TestDocumentRepository_Delete(t *testing.T) {
 	_, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -370,6 +590,12 @@ func TestDocumentRepository_SegmentOperations(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, count, "Should count 2 segments")
 
+	// 测试按位置范围获取段落
+	segments, err = repo.ListSegmentRange(doc.ID, 2, 5)
+	assert.NoError(t, err)
+	require.Len(t, segments, 1, "Should return segments in position range")
+	assert.Equal(t, "seg-2", segments[0].SegmentID)
+
 	// 测试删除段落
 	err = repo.DeleteSegments(doc.ID)
 	assert.NoError(t, err, "DeleteSegments should succeed")
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeedbackRepository 回答反馈仓储接口
+// 负责问题簇、负反馈记录和检索抑制列表的存储
+type FeedbackRepository interface {
+	// ListClusters 列出所有问题簇
+	ListClusters() ([]*models.QuestionCluster, error)
+
+	// CreateCluster 创建问题簇
+	CreateCluster(cluster *models.QuestionCluster) error
+
+	// UpdateCluster 更新问题簇的中心向量和问题数量
+	UpdateCluster(cluster *models.QuestionCluster) error
+
+	// CreateFeedback 保存负反馈记录
+	CreateFeedback(feedback *models.AnswerFeedback) error
+
+	// RecordWrongSource 累加段落在问题簇中的错误次数，不存在时创建，返回最新记录
+	RecordWrongSource(suppression *models.RetrievalSuppression) (*models.RetrievalSuppression, error)
+
+	// GetSuppression 获取检索抑制记录
+	GetSuppression(id uint) (*models.RetrievalSuppression, error)
+
+	// UpdateSuppressionStatus 更新检索抑制状态
+	UpdateSuppressionStatus(id uint, status models.SuppressionStatus, reviewed bool) error
+
+	// ListSuppressions 按状态分页列出检索抑制记录，status为空时返回全部
+	ListSuppressions(status models.SuppressionStatus, offset, limit int) ([]*models.RetrievalSuppression, int64, error)
+
+	// ActiveSuppressions 列出所有生效中的检索抑制记录
+	ActiveSuppressions() ([]*models.RetrievalSuppression, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) FeedbackRepository
+}
+
+// feedbackRepo 回答反馈仓储实现
+type feedbackRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewFeedbackRepository 创建回答反馈仓储实例
+func NewFeedbackRepository() FeedbackRepository {
+	return &feedbackRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *feedbackRepo) WithContext(ctx context.Context) FeedbackRepository {
+	return &feedbackRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// ListClusters 列出所有问题簇
+func (r *feedbackRepo) ListClusters() ([]*models.QuestionCluster, error) {
+	var clusters []*models.QuestionCluster
+	if err := r.db.Order("created_at ASC").Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// CreateCluster 创建问题簇
+func (r *feedbackRepo) CreateCluster(cluster *models.QuestionCluster) error {
+	if cluster.ID == "" {
+		return errors.New("cluster ID cannot be empty")
+	}
+	return r.db.Create(cluster).Error
+}
+
+// UpdateCluster 更新问题簇的中心向量和问题数量
+func (r *feedbackRepo) UpdateCluster(cluster *models.QuestionCluster) error {
+	if cluster.ID == "" {
+		return errors.New("cluster ID cannot be empty")
+	}
+	return r.db.Model(cluster).Select("embedding", "question_count", "updated_at").Updates(cluster).Error
+}
+
+// CreateFeedback 保存负反馈记录
+func (r *feedbackRepo) CreateFeedback(feedback *models.AnswerFeedback) error {
+	return r.db.Create(feedback).Error
+}
+
+// RecordWrongSource 累加段落在问题簇中的错误次数
+func (r *feedbackRepo) RecordWrongSource(suppression *models.RetrievalSuppression) (*models.RetrievalSuppression, error) {
+	if suppression.ClusterID == "" || suppression.SegmentID == "" {
+		return nil, errors.New("cluster ID and segment ID cannot be empty")
+	}
+	if suppression.Status == "" {
+		suppression.Status = models.SuppressionPending
+	}
+	suppression.WrongCount = 1
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "cluster_id"}, {Name: "segment_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"wrong_count": gorm.Expr("wrong_count + 1"),
+			"updated_at":  time.Now(),
+		}),
+	}).Create(suppression).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var current models.RetrievalSuppression
+	err = r.db.Where("cluster_id = ? AND segment_id = ?", suppression.ClusterID, suppression.SegmentID).
+		First(&current).Error
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// GetSuppression 获取检索抑制记录
+func (r *feedbackRepo) GetSuppression(id uint) (*models.RetrievalSuppression, error) {
+	var suppression models.RetrievalSuppression
+	if err := r.db.First(&suppression, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("suppression not found: %d", id)
+		}
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// UpdateSuppressionStatus 更新检索抑制状态，reviewed为true时记录审核时间
+func (r *feedbackRepo) UpdateSuppressionStatus(id uint, status models.SuppressionStatus, reviewed bool) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     status,
+		"updated_at": now,
+	}
+	if reviewed {
+		updates["reviewed_at"] = now
+	}
+
+	result := r.db.Model(&models.RetrievalSuppression{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("suppression not found: %d", id)
+	}
+	return nil
+}
+
+// ListSuppressions 按状态分页列出检索抑制记录，错误次数多的排在前面
+func (r *feedbackRepo) ListSuppressions(status models.SuppressionStatus, offset, limit int) ([]*models.RetrievalSuppression, int64, error) {
+	var suppressions []*models.RetrievalSuppression
+	var total int64
+
+	query := r.db.Model(&models.RetrievalSuppression{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("wrong_count DESC, updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&suppressions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return suppressions, total, nil
+}
+
+// ActiveSuppressions 列出所有生效中的检索抑制记录
+func (r *feedbackRepo) ActiveSuppressions() ([]*models.RetrievalSuppression, error) {
+	var suppressions []*models.RetrievalSuppression
+	err := r.db.Where("status = ?", models.SuppressionActive).Find(&suppressions).Error
+	if err != nil {
+		return nil, err
+	}
+	return suppressions, nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DocumentGroupRepository 文档组仓储接口
+// 负责文档组及其成员关系的存储和检索
+type DocumentGroupRepository interface {
+	// Create 创建文档组，同时保存成员
+	Create(group *models.DocumentGroup) error
+
+	// GetByID 获取文档组及其成员
+	GetByID(id string) (*models.DocumentGroup, error)
+
+	// List 列出文档组
+	List(offset, limit int) ([]*models.DocumentGroup, int64, error)
+
+	// Update 更新文档组名称和描述
+	Update(group *models.DocumentGroup) error
+
+	// Delete 删除文档组及其成员关系，不删除文档本身
+	Delete(id string) error
+
+	// AddMember 添加或更新成员
+	AddMember(member *models.DocumentGroupMember) error
+
+	// RemoveMember 移除成员
+	RemoveMember(groupID, documentID string) error
+
+	// RemoveDocument 从所有文档组中移除指定文档
+	RemoveDocument(documentID string) error
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) DocumentGroupRepository
+}
+
+// groupRepo 文档组仓储实现
+type groupRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewDocumentGroupRepository 创建文档组仓储实例
+func NewDocumentGroupRepository() DocumentGroupRepository {
+	return &groupRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *groupRepo) WithContext(ctx context.Context) DocumentGroupRepository {
+	return &groupRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// Create 创建文档组，同时保存成员
+func (r *groupRepo) Create(group *models.DocumentGroup) error {
+	if group.ID == "" {
+		group.ID = uuid.New().String()
+	}
+	for i := range group.Members {
+		group.Members[i].GroupID = group.ID
+	}
+	return r.db.Create(group).Error
+}
+
+// GetByID 获取文档组及其成员
+func (r *groupRepo) GetByID(id string) (*models.DocumentGroup, error) {
+	var group models.DocumentGroup
+	err := r.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, created_at ASC")
+	}).Where("id = ?", id).First(&group).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("document group not found: %s", id)
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List 列出文档组
+func (r *groupRepo) List(offset, limit int) ([]*models.DocumentGroup, int64, error) {
+	var groups []*models.DocumentGroup
+	var total int64
+
+	query := r.db.Model(&models.DocumentGroup{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC, created_at ASC")
+	}).Order("updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&groups).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+// Update 更新文档组名称和描述
+func (r *groupRepo) Update(group *models.DocumentGroup) error {
+	if group.ID == "" {
+		return errors.New("group ID cannot be empty")
+	}
+	return r.db.Model(group).Select("name", "description", "updated_at").Updates(group).Error
+}
+
+// Delete 删除文档组及其成员关系，不删除文档本身
+func (r *groupRepo) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", id).Delete(&models.DocumentGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.DocumentGroup{}).Error
+	})
+}
+
+// AddMember 添加或更新成员
+func (r *groupRepo) AddMember(member *models.DocumentGroupMember) error {
+	if member.GroupID == "" || member.DocumentID == "" {
+		return errors.New("group ID and document ID cannot be empty")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "group_id"}, {Name: "document_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"label", "position"}),
+	}).Create(member).Error
+}
+
+// RemoveMember 移除成员
+func (r *groupRepo) RemoveMember(groupID, documentID string) error {
+	result := r.db.Where("group_id = ? AND document_id = ?", groupID, documentID).Delete(&models.DocumentGroupMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("document %s is not a member of group %s", documentID, groupID)
+	}
+	return nil
+}
+
+// RemoveDocument 从所有文档组中移除指定文档
+func (r *groupRepo) RemoveDocument(documentID string) error {
+	return r.db.Where("document_id = ?", documentID).Delete(&models.DocumentGroupMember{}).Error
+}
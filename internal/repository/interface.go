@@ -22,8 +22,12 @@ type DocumentRepository interface {
 	GetByID(id string) (*models.Document, error)
 
 	// List 列出文档列表，支持分页和筛选
+	// 筛选条件支持status、tags、start_time、end_time、file_name，以及关键词q和是否搜索段落文本的search_content
 	List(offset, limit int, filters map[string]interface{}) ([]*models.Document, int64, error)
 
+	// ListAfter 使用游标分页列出文档，筛选条件与List相同，返回下一页游标，为空表示没有更多记录
+	ListAfter(cursor string, limit int, filters map[string]interface{}) ([]*models.Document, string, error)
+
 	// Delete 删除文档记录
 	Delete(id string) error
 
@@ -35,6 +39,12 @@ type DocumentRepository interface {
 	// UpdateProgress 更新文档处理进度
 	UpdateProgress(id string, progress int) error
 
+	// UpdateSummary 保存文档摘要（JSON），不改变文档的更新时间
+	UpdateSummary(id string, summary []byte) error
+
+	// UpdateImages 保存从文档中提取的图片列表（JSON），不改变文档的更新时间
+	UpdateImages(id string, images []byte) error
+
 	// 文档段落相关
 
 	// SaveSegment 保存文档段落
@@ -46,12 +56,59 @@ type DocumentRepository interface {
 	// GetSegments 获取文档的所有段落
 	GetSegments(docID string) ([]*models.DocumentSegment, error)
 
+	// ListSegments 按位置分页列出文档的段落
+	ListSegments(docID string, offset, limit int) ([]*models.DocumentSegment, int64, error)
+
+	// GetSegment 根据段落ID获取段落
+	GetSegment(segmentID string) (*models.DocumentSegment, error)
+
+	// GetSegmentsByID 按段落ID批量获取段落，不存在的ID忽略
+	GetSegmentsByID(segmentIDs []string) ([]*models.DocumentSegment, error)
+
+	// ListSegmentRange 按位置顺序列出文档中位置在[from, to]范围内的段落
+	ListSegmentRange(docID string, from, to int) ([]*models.DocumentSegment, error)
+
+	// ListExcludedFileIDs 列出不参与检索的文档ID，包括标记为不检索的文档和带有任一指定标签的文档
+	ListExcludedFileIDs(tags []string) ([]string, error)
+
+	// 访问控制相关
+
+	// ListShares 列出文档的共享记录
+	ListShares(docID string) ([]*models.DocumentShare, error)
+
+	// UpdateACL 设置文档是否公开，并整体替换共享记录
+	UpdateACL(docID string, public bool, shares []*models.DocumentShare) error
+
+	// ListReadableFileIDs 列出用户可以读取的文档ID，fileIDs不为空时只在其中查找
+	ListReadableFileIDs(reader DocumentReader, fileIDs []string) ([]string, error)
+
+	// CountOwnerless 统计上传者为空或为ownerless之一的文档数量
+	CountOwnerless(ownerless []string) (int64, error)
+
+	// AssignOwnerless 将上传者为空或为ownerless之一的文档归属owner（为空时不修改上传者），public为true时同时设为公开
+	AssignOwnerless(ownerless []string, owner string, public bool) (int64, error)
+
+	// UpdateSegmentText 更新段落文本
+	UpdateSegmentText(segmentID, text string) error
+
 	// CountSegments 统计文档的段落数量
 	CountSegments(docID string) (int, error)
 
 	// DeleteSegments 删除文档的所有段落
 	DeleteSegments(docID string) error
 
+	// UpsertSegments 批量保存段落，段落ID已存在时覆盖
+	UpsertSegments(segments []*models.DocumentSegment) error
+
+	// DeleteSegmentsByID 按段落ID删除段落
+	DeleteSegmentsByID(segmentIDs []string) error
+
+	// ListSegmentFingerprints 列出所有记录了指纹且不是重复段落的段落，用于重建近似重复索引
+	ListSegmentFingerprints() ([]*models.DocumentSegment, error)
+
+	// ListSuppressedDuplicates 列出作为指定文档段落的重复而未写入向量库的段落
+	ListSuppressedDuplicates(docID string) ([]*models.DocumentSegment, error)
+
 	// 任务相关
 
 	// GetDocumentTasks 获取文档相关的所有任务
@@ -76,6 +133,13 @@ type DocumentRepository interface {
 	WithContext(ctx context.Context) DocumentRepository
 }
 
+// DocumentReader 读取文档的用户，文档列表的reader筛选条件只返回该用户可以读取的文档
+// 上传者、公开文档和共享给该用户或其所属用户组的文档可以读取
+type DocumentReader struct {
+	User   string   // 用户（租户）标识，匿名用户为空，不匹配任何上传者和共享用户
+	Groups []string // 用户所属的用户组
+}
+
 // TaskQueueAdapter 任务队列适配器
 // 连接文档仓储和任务队列
 type TaskQueueAdapter interface {
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// JobRunRepository 定时任务运行记录仓储接口
+type JobRunRepository interface {
+	// Create 创建运行记录
+	Create(run *models.JobRun) error
+
+	// Update 更新运行记录
+	Update(run *models.JobRun) error
+
+	// List 按开始时间倒序列出运行记录，jobName为空时返回所有任务的记录
+	List(jobName string, offset, limit int) ([]*models.JobRun, int64, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) JobRunRepository
+}
+
+// jobRunRepo 定时任务运行记录仓储实现
+type jobRunRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewJobRunRepository 创建定时任务运行记录仓储实例
+func NewJobRunRepository() JobRunRepository {
+	return &jobRunRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *jobRunRepo) WithContext(ctx context.Context) JobRunRepository {
+	return &jobRunRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// Create 创建运行记录
+func (r *jobRunRepo) Create(run *models.JobRun) error {
+	return r.db.Create(run).Error
+}
+
+// Update 更新运行记录
+func (r *jobRunRepo) Update(run *models.JobRun) error {
+	if run.ID == 0 {
+		return errors.New("job run ID cannot be empty")
+	}
+	return r.db.Save(run).Error
+}
+
+// List 按开始时间倒序列出运行记录
+func (r *jobRunRepo) List(jobName string, offset, limit int) ([]*models.JobRun, int64, error) {
+	var runs []*models.JobRun
+	var total int64
+
+	query := r.db.Model(&models.JobRun{})
+	if jobName != "" {
+		query = query.Where("job_name = ?", jobName)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("started_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return runs, total, nil
+}
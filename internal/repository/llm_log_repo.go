@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// LLMLogFilter 大模型交互日志查询条件，空字段表示不过滤
+type LLMLogFilter struct {
+	Tenant    string    // 租户
+	Model     string    // 模型名称
+	RequestID string    // 请求ID
+	Since     time.Time // 起始时间（含）
+	Until     time.Time // 结束时间（不含）
+}
+
+// LLMLogRepository 大模型交互日志仓储接口
+type LLMLogRepository interface {
+	// Append 追加一条交互日志
+	Append(entry *models.LLMInteraction) error
+
+	// List 按条件分页查询交互日志，最新的在前，同时返回总数
+	List(filter LLMLogFilter, offset, limit int) ([]*models.LLMInteraction, int64, error)
+
+	// DeleteBefore 删除指定时间之前的交互日志，返回删除的条数
+	DeleteBefore(before time.Time) (int64, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) LLMLogRepository
+}
+
+// llmLogRepo 大模型交互日志仓储实现
+type llmLogRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewLLMLogRepository 创建大模型交互日志仓储实例
+func NewLLMLogRepository() LLMLogRepository {
+	return &llmLogRepo{
+		db: database.MustDB(),
+	}
+}
+
+// Append 追加一条交互日志
+func (r *llmLogRepo) Append(entry *models.LLMInteraction) error {
+	return r.db.Create(entry).Error
+}
+
+// List 按条件分页查询交互日志
+func (r *llmLogRepo) List(filter LLMLogFilter, offset, limit int) ([]*models.LLMInteraction, int64, error) {
+	query := r.db.Model(&models.LLMInteraction{})
+	if filter.Tenant != "" {
+		query = query.Where("tenant = ?", filter.Tenant)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where("created_at < ?", filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*models.LLMInteraction
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// DeleteBefore 删除指定时间之前的交互日志
+func (r *llmLogRepo) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.LLMInteraction{})
+	return result.RowsAffected, result.Error
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *llmLogRepo) WithContext(ctx context.Context) LLMLogRepository {
+	return &llmLogRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
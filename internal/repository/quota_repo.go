@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaDelta 一次配额消耗中单个计量项的增量
+type QuotaDelta struct {
+	Metric models.QuotaMetric // 计量项
+	Period string             // 周期
+	Amount int64              // 增量
+	Limit  int64              // 上限，0表示不限制
+}
+
+// QuotaRepository 配额用量仓储接口
+// 负责租户配额计数的原子累加、扣减和查询
+type QuotaRepository interface {
+	// Consume 在一个事务中累加多个计量项，任一计量项超过上限时全部回滚并返回models.ErrQuotaExceeded
+	Consume(tenant string, deltas ...QuotaDelta) error
+
+	// Release 扣减计量项的用量，扣减后不小于0
+	Release(tenant string, metric models.QuotaMetric, period string, amount int64) error
+
+	// List 查询租户的配额用量，periods为空时返回所有周期
+	List(tenant string, periods ...string) ([]*models.QuotaUsage, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) QuotaRepository
+}
+
+// quotaRepo 配额用量仓储实现
+type quotaRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewQuotaRepository 创建配额用量仓储实例
+func NewQuotaRepository() QuotaRepository {
+	return &quotaRepo{
+		db: database.MustDB(),
+	}
+}
+
+// Consume 累加配额用量
+// 累加和上限判断在同一条UPDATE语句中完成，并发请求不会超出上限
+func (r *quotaRepo) Consume(tenant string, deltas ...QuotaDelta) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, d := range deltas {
+			row := &models.QuotaUsage{Tenant: tenant, Metric: d.Metric, Period: d.Period}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+				return err
+			}
+
+			query := tx.Model(&models.QuotaUsage{}).
+				Where("tenant = ? AND metric = ? AND period = ?", tenant, d.Metric, d.Period)
+			if d.Limit > 0 {
+				query = query.Where("used + ? <= ?", d.Amount, d.Limit)
+			}
+			result := query.UpdateColumns(map[string]interface{}{
+				"used":       gorm.Expr("used + ?", d.Amount),
+				"updated_at": time.Now(),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: %s limit %d", models.ErrQuotaExceeded, d.Metric, d.Limit)
+			}
+		}
+		return nil
+	})
+}
+
+// Release 扣减配额用量
+func (r *quotaRepo) Release(tenant string, metric models.QuotaMetric, period string, amount int64) error {
+	return r.db.Model(&models.QuotaUsage{}).
+		Where("tenant = ? AND metric = ? AND period = ?", tenant, metric, period).
+		UpdateColumns(map[string]interface{}{
+			"used":       gorm.Expr("CASE WHEN used > ? THEN used - ? ELSE 0 END", amount, amount),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// List 查询租户的配额用量
+func (r *quotaRepo) List(tenant string, periods ...string) ([]*models.QuotaUsage, error) {
+	query := r.db.Model(&models.QuotaUsage{}).Where("tenant = ?", tenant)
+	if len(periods) > 0 {
+		query = query.Where("period IN ?", periods)
+	}
+
+	var usages []*models.QuotaUsage
+	err := query.Order("metric ASC, period ASC").Find(&usages).Error
+	return usages, err
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *quotaRepo) WithContext(ctx context.Context) QuotaRepository {
+	return &quotaRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
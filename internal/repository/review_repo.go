@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReviewRepository 回答审核仓储接口
+// 负责回答草稿和人工审核过的FAQ条目的存储
+type ReviewRepository interface {
+	// CreateDraft 创建回答草稿
+	CreateDraft(draft *models.AnswerDraft) error
+
+	// GetDraft 获取回答草稿
+	GetDraft(id string) (*models.AnswerDraft, error)
+
+	// FindPendingDraft 查找同一问题的待审核草稿，不存在时返回nil
+	FindPendingDraft(questionKey string) (*models.AnswerDraft, error)
+
+	// ReviewDraft 保存草稿的审核结果，只有待审核的草稿可以审核，返回是否更新成功
+	ReviewDraft(draft *models.AnswerDraft) (bool, error)
+
+	// ListDrafts 按状态分页列出回答草稿，status为空时返回全部
+	ListDrafts(status models.DraftStatus, offset, limit int) ([]*models.AnswerDraft, int64, error)
+
+	// SaveCurated 保存FAQ条目，同一问题已存在时覆盖回答
+	SaveCurated(answer *models.CuratedAnswer) error
+
+	// FindCurated 按规范化问题查找FAQ条目，不存在时返回nil
+	FindCurated(questionKey string) (*models.CuratedAnswer, error)
+
+	// ListCurated 分页列出FAQ条目，reader不为空时只列出该读者的条目
+	ListCurated(reader string, offset, limit int) ([]*models.CuratedAnswer, int64, error)
+
+	// DeleteCurated 删除FAQ条目
+	DeleteCurated(id uint) error
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) ReviewRepository
+}
+
+// reviewRepo 回答审核仓储实现
+type reviewRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewReviewRepository 创建回答审核仓储实例
+func NewReviewRepository() ReviewRepository {
+	return &reviewRepo{
+		db: database.MustDB(),
+	}
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *reviewRepo) WithContext(ctx context.Context) ReviewRepository {
+	return &reviewRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
+
+// CreateDraft 创建回答草稿
+func (r *reviewRepo) CreateDraft(draft *models.AnswerDraft) error {
+	if draft.ID == "" {
+		return errors.New("draft ID cannot be empty")
+	}
+	if draft.Status == "" {
+		draft.Status = models.DraftPending
+	}
+	return r.db.Create(draft).Error
+}
+
+// GetDraft 获取回答草稿
+func (r *reviewRepo) GetDraft(id string) (*models.AnswerDraft, error) {
+	var draft models.AnswerDraft
+	if err := r.db.Where("id = ?", id).First(&draft).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("answer draft not found: %s", id)
+		}
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// FindPendingDraft 查找同一问题最早的待审核草稿
+func (r *reviewRepo) FindPendingDraft(questionKey string) (*models.AnswerDraft, error) {
+	var draft models.AnswerDraft
+	err := r.db.Where("question_key = ? AND status = ?", questionKey, models.DraftPending).
+		Order("created_at ASC").
+		First(&draft).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// ReviewDraft 保存草稿的审核结果
+// 以待审核状态为条件更新，避免两个审核人同时审核同一草稿
+func (r *reviewRepo) ReviewDraft(draft *models.AnswerDraft) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&models.AnswerDraft{}).
+		Where("id = ? AND status = ?", draft.ID, models.DraftPending).
+		Updates(map[string]interface{}{
+			"status":       draft.Status,
+			"final_answer": draft.FinalAnswer,
+			"reviewer":     draft.Reviewer,
+			"note":         draft.Note,
+			"reviewed_at":  now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	draft.ReviewedAt = &now
+	draft.UpdatedAt = now
+	return true, nil
+}
+
+// ListDrafts 按状态分页列出回答草稿，最早提交的排在前面
+func (r *reviewRepo) ListDrafts(status models.DraftStatus, offset, limit int) ([]*models.AnswerDraft, int64, error) {
+	var drafts []*models.AnswerDraft
+	var total int64
+
+	query := r.db.Model(&models.AnswerDraft{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&drafts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return drafts, total, nil
+}
+
+// SaveCurated 保存FAQ条目，同一问题已存在时覆盖回答
+func (r *reviewRepo) SaveCurated(answer *models.CuratedAnswer) error {
+	if answer.QuestionKey == "" {
+		return errors.New("question key cannot be empty")
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "question_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"question", "reader", "topic", "answer", "segment_ids", "draft_id", "updated_at"}),
+	}).Create(answer).Error
+}
+
+// FindCurated 按规范化问题查找FAQ条目
+func (r *reviewRepo) FindCurated(questionKey string) (*models.CuratedAnswer, error) {
+	var answer models.CuratedAnswer
+	if err := r.db.Where("question_key = ?", questionKey).First(&answer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &answer, nil
+}
+
+// ListCurated 分页列出FAQ条目，最近更新的排在前面
+func (r *reviewRepo) ListCurated(reader string, offset, limit int) ([]*models.CuratedAnswer, int64, error) {
+	var answers []*models.CuratedAnswer
+	var total int64
+
+	query := r.db.Model(&models.CuratedAnswer{})
+	if reader != "" {
+		query = query.Where("reader = ?", reader)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&answers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return answers, total, nil
+}
+
+// DeleteCurated 删除FAQ条目
+func (r *reviewRepo) DeleteCurated(id uint) error {
+	result := r.db.Delete(&models.CuratedAnswer{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("curated answer not found: %d", id)
+	}
+	return nil
+}
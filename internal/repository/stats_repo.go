@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StatsRepository 运维统计仓储接口
+// 负责问答统计的累加，以及文档、段落和存储用量的聚合查询
+type StatsRepository interface {
+	// IncrementQA 累加当天的问答统计，当天的记录不存在时创建
+	IncrementQA(stat *models.QADailyStat) error
+
+	// ListQA 查询日期范围内（含两端）的问答统计，按日期排序
+	ListQA(from, to string) ([]*models.QADailyStat, error)
+
+	// IncrementPromptVariant 累加当天某个提示词变体的问答统计，记录不存在时创建
+	IncrementPromptVariant(stat *models.PromptVariantStat) error
+
+	// ListPromptVariants 查询日期范围内（含两端）的提示词变体统计
+	ListPromptVariants(from, to string) ([]*models.PromptVariantStat, error)
+
+	// CountFeedbackByVariant 按提示词变体统计时间范围内（含起点，不含终点）的负反馈数
+	CountFeedbackByVariant(since, until time.Time) (map[string]int64, error)
+
+	// CountDocumentsByStatus 按处理状态统计文档数
+	CountDocumentsByStatus() (map[models.DocumentStatus]int64, error)
+
+	// CountSegments 统计段落总数
+	CountSegments() (int64, error)
+
+	// CountDuplicateSegments 统计被标记为近似重复的段落数，以及其中未写入向量库的段落数
+	CountDuplicateSegments() (duplicates, suppressed int64, err error)
+
+	// SumStorageBytes 统计文档文件的总字节数
+	SumStorageBytes() (int64, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) StatsRepository
+}
+
+// statsRepo 运维统计仓储实现
+type statsRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewStatsRepository 创建运维统计仓储实例
+func NewStatsRepository() StatsRepository {
+	return &statsRepo{
+		db: database.MustDB(),
+	}
+}
+
+// IncrementQA 累加当天的问答统计
+func (r *statsRepo) IncrementQA(stat *models.QADailyStat) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"questions":  gorm.Expr("questions + ?", stat.Questions),
+			"cache_hits": gorm.Expr("cache_hits + ?", stat.CacheHits),
+			"errors":     gorm.Expr("errors + ?", stat.Errors),
+			"latency_ms": gorm.Expr("latency_ms + ?", stat.LatencyMs),
+			"updated_at": time.Now(),
+		}),
+	}).Create(stat).Error
+}
+
+// ListQA 查询日期范围内的问答统计
+func (r *statsRepo) ListQA(from, to string) ([]*models.QADailyStat, error) {
+	var stats []*models.QADailyStat
+	err := r.db.Where("date >= ? AND date <= ?", from, to).Order("date ASC").Find(&stats).Error
+	return stats, err
+}
+
+// IncrementPromptVariant 累加当天某个提示词变体的问答统计
+func (r *statsRepo) IncrementPromptVariant(stat *models.PromptVariantStat) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}, {Name: "variant"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"questions":  gorm.Expr("questions + ?", stat.Questions),
+			"errors":     gorm.Expr("errors + ?", stat.Errors),
+			"latency_ms": gorm.Expr("latency_ms + ?", stat.LatencyMs),
+			"updated_at": time.Now(),
+		}),
+	}).Create(stat).Error
+}
+
+// ListPromptVariants 查询日期范围内的提示词变体统计
+func (r *statsRepo) ListPromptVariants(from, to string) ([]*models.PromptVariantStat, error) {
+	var stats []*models.PromptVariantStat
+	err := r.db.Where("date >= ? AND date <= ?", from, to).Order("date ASC, variant ASC").Find(&stats).Error
+	return stats, err
+}
+
+// CountFeedbackByVariant 按提示词变体统计负反馈数
+func (r *statsRepo) CountFeedbackByVariant(since, until time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Variant string
+		Count   int64
+	}
+	err := r.db.Model(&models.AnswerFeedback{}).
+		Select("variant, COUNT(*) AS count").
+		Where("variant <> '' AND created_at >= ? AND created_at < ?", since, until).
+		Group("variant").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Variant] = row.Count
+	}
+	return counts, nil
+}
+
+// CountDocumentsByStatus 按处理状态统计文档数
+func (r *statsRepo) CountDocumentsByStatus() (map[models.DocumentStatus]int64, error) {
+	var rows []struct {
+		Status models.DocumentStatus
+		Count  int64
+	}
+	err := r.db.Model(&models.Document{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.DocumentStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountSegments 统计段落总数
+func (r *statsRepo) CountSegments() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.DocumentSegment{}).Count(&count).Error
+	return count, err
+}
+
+// CountDuplicateSegments 统计被标记为近似重复的段落数，以及其中未写入向量库的段落数
+func (r *statsRepo) CountDuplicateSegments() (duplicates, suppressed int64, err error) {
+	var row struct {
+		Duplicates int64
+		Suppressed int64
+	}
+	err = r.db.Model(&models.DocumentSegment{}).
+		Select("COUNT(*) AS duplicates, COALESCE(SUM(CASE WHEN suppressed THEN 1 ELSE 0 END), 0) AS suppressed").
+		Where("duplicate_of <> ''").
+		Scan(&row).Error
+	return row.Duplicates, row.Suppressed, err
+}
+
+// SumStorageBytes 统计文档文件的总字节数
+func (r *statsRepo) SumStorageBytes() (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Document{}).Select("COALESCE(SUM(file_size), 0)").Scan(&total).Error
+	return total, err
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *statsRepo) WithContext(ctx context.Context) StatsRepository {
+	return &statsRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
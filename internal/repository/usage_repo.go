@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageFilter 用量查询条件，空字段表示不过滤
+type UsageFilter struct {
+	From     string           // 起始日期（含），格式为2006-01-02
+	To       string           // 结束日期（含），格式为2006-01-02
+	Tenant   string           // 租户
+	Provider string           // 提供商
+	Kind     models.UsageKind // 用量类型
+}
+
+// UsageRepository 用量仓储接口
+// 负责LLM和嵌入调用用量的累加和查询
+type UsageRepository interface {
+	// Increment 累加用量，对应的日期/租户/提供商/模型/类型组合不存在时创建
+	Increment(record *models.UsageRecord) error
+
+	// List 按条件查询用量记录，按日期、租户、提供商排序
+	List(filter UsageFilter) ([]*models.UsageRecord, error)
+
+	// WithContext 创建带有上下文的仓储
+	WithContext(ctx context.Context) UsageRepository
+}
+
+// usageRepo 用量仓储实现
+type usageRepo struct {
+	db *gorm.DB // 数据库连接
+}
+
+// NewUsageRepository 创建用量仓储实例
+func NewUsageRepository() UsageRepository {
+	return &usageRepo{
+		db: database.MustDB(),
+	}
+}
+
+// Increment 累加用量
+func (r *usageRepo) Increment(record *models.UsageRecord) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "date"}, {Name: "tenant"}, {Name: "provider"}, {Name: "model"}, {Name: "kind"},
+		},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":   gorm.Expr("requests + ?", record.Requests),
+			"errors":     gorm.Expr("errors + ?", record.Errors),
+			"tokens":     gorm.Expr("tokens + ?", record.Tokens),
+			"updated_at": time.Now(),
+		}),
+	}).Create(record).Error
+}
+
+// List 按条件查询用量记录
+func (r *usageRepo) List(filter UsageFilter) ([]*models.UsageRecord, error) {
+	query := r.db.Model(&models.UsageRecord{})
+	if filter.From != "" {
+		query = query.Where("date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("date <= ?", filter.To)
+	}
+	if filter.Tenant != "" {
+		query = query.Where("tenant = ?", filter.Tenant)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+
+	var records []*models.UsageRecord
+	err := query.Order("date ASC, tenant ASC, provider ASC, model ASC").Find(&records).Error
+	return records, err
+}
+
+// WithContext 创建带有上下文的仓储
+func (r *usageRepo) WithContext(ctx context.Context) UsageRepository {
+	return &usageRepo{
+		db: r.db.WithContext(ctx),
+	}
+}
@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+)
+
+// 任务类型
+const (
+	JobTypeRecrawl   = "recrawl"   // 重新抓取网页来源的文档
+	JobTypeReembed   = "reembed"   // 嵌入模型变更后重新向量化文档
+	JobTypeGC        = "gc"        // 清理没有文档记录的存储文件和向量
+	JobTypeConnector = "connector" // 从外部来源增量同步文档
+)
+
+// DocumentMaintainer 文档维护操作，由文档服务实现
+type DocumentMaintainer interface {
+	// URLSourcedDocuments 列出来自网页的文档，返回文档ID到来源URL的映射
+	URLSourcedDocuments(ctx context.Context) (map[string]string, error)
+
+	// DocumentsNeedingReembed 列出嵌入模型与指定模型不一致的文档
+	DocumentsNeedingReembed(ctx context.Context, model string) ([]string, error)
+
+	// EmbeddingModel 返回当前使用的嵌入模型名称
+	EmbeddingModel() string
+
+	// RecrawlDocument 重新抓取文档，返回内容是否变化
+	RecrawlDocument(ctx context.Context, fileID string) (bool, error)
+
+	// ReembedDocument 重新向量化文档
+	ReembedDocument(ctx context.Context, fileID string) error
+}
+
+// GarbageCollector 存储垃圾回收操作，由文档服务实现
+type GarbageCollector interface {
+	// CollectGarbage 删除没有文档记录的存储文件和向量，返回发现的孤立对象数、删除数和失败数
+	CollectGarbage(ctx context.Context) (found, deleted, failed int, err error)
+}
+
+// ConnectorSyncer 外部来源同步操作，由连接器服务实现
+type ConnectorSyncer interface {
+	// SyncConnector 增量同步指定的连接器，返回列出的条目数、新增或更新的文档数和失败数
+	SyncConnector(ctx context.Context, name string) (listed, changed, failed int, err error)
+}
+
+// NewJob 根据任务类型创建执行函数
+// queue不为空时每个文档投递为一个队列任务，由worker执行；否则在调度器中逐个执行
+func NewJob(jobType string, maintainer DocumentMaintainer, queue taskqueue.Queue) (JobFunc, error) {
+	switch jobType {
+	case JobTypeRecrawl:
+		return RecrawlJob(maintainer, queue), nil
+	case JobTypeReembed:
+		return ReembedJob(maintainer, queue), nil
+	case JobTypeGC:
+		collector, ok := maintainer.(GarbageCollector)
+		if !ok {
+			return nil, fmt.Errorf("job type %s is not supported by the document maintainer", jobType)
+		}
+		return GCJob(collector), nil
+	default:
+		return nil, fmt.Errorf("unsupported job type: %s", jobType)
+	}
+}
+
+// RecrawlJob 创建重新抓取网页文档的任务
+func RecrawlJob(maintainer DocumentMaintainer, queue taskqueue.Queue) JobFunc {
+	return func(ctx context.Context) (*RunResult, error) {
+		docs, err := maintainer.URLSourcedDocuments(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &RunResult{Processed: len(docs)}
+		changed := 0
+		for id, sourceURL := range docs {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			if queue != nil {
+				payload := taskqueue.DocumentRecrawlPayload{DocumentID: id, URL: sourceURL}
+				if _, err := queue.Enqueue(ctx, taskqueue.TaskDocumentRecrawl, id, payload); err != nil {
+					result.Failed++
+					continue
+				}
+				result.Enqueued++
+				continue
+			}
+
+			updated, err := maintainer.RecrawlDocument(ctx, id)
+			if err != nil {
+				result.Failed++
+				continue
+			}
+			result.Enqueued++
+			if updated {
+				changed++
+			}
+		}
+
+		if queue == nil {
+			result.Message = fmt.Sprintf("%d of %d documents changed", changed, len(docs))
+		}
+		return result, nil
+	}
+}
+
+// ReembedJob 创建重新向量化文档的任务
+// 只处理记录的嵌入模型与当前模型不一致的文档
+func ReembedJob(maintainer DocumentMaintainer, queue taskqueue.Queue) JobFunc {
+	return func(ctx context.Context) (*RunResult, error) {
+		model := maintainer.EmbeddingModel()
+		if model == "" {
+			return nil, fmt.Errorf("embedding model is not configured")
+		}
+
+		ids, err := maintainer.DocumentsNeedingReembed(ctx, model)
+		if err != nil {
+			return nil, err
+		}
+
+		result := &RunResult{Processed: len(ids), Message: "target model: " + model}
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			if queue != nil {
+				payload := taskqueue.DocumentReembedPayload{DocumentID: id, Model: model}
+				if _, err := queue.Enqueue(ctx, taskqueue.TaskDocumentReembed, id, payload); err != nil {
+					result.Failed++
+					continue
+				}
+				result.Enqueued++
+				continue
+			}
+
+			if err := maintainer.ReembedDocument(ctx, id); err != nil {
+				result.Failed++
+				continue
+			}
+			result.Enqueued++
+		}
+		return result, nil
+	}
+}
+
+// GCJob 创建存储垃圾回收任务
+// 垃圾回收只涉及存储和向量数据库的删除操作，不投递到队列，直接在调度器中执行
+func GCJob(collector GarbageCollector) JobFunc {
+	return func(ctx context.Context) (*RunResult, error) {
+		found, deleted, failed, err := collector.CollectGarbage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &RunResult{
+			Processed: found,
+			Enqueued:  deleted,
+			Failed:    failed,
+			Message:   fmt.Sprintf("deleted %d of %d orphaned objects", deleted, found),
+		}, nil
+	}
+}
+
+// ConnectorJob 创建同步外部来源的任务
+// 同步在调度器中直接执行，下载的内容由文档服务处理（异步处理开启时投递到队列）
+func ConnectorJob(syncer ConnectorSyncer, name string) JobFunc {
+	return func(ctx context.Context) (*RunResult, error) {
+		listed, changed, failed, err := syncer.SyncConnector(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return &RunResult{
+			Processed: listed,
+			Enqueued:  changed,
+			Failed:    failed,
+			Message:   fmt.Sprintf("%d of %d items created or updated", changed, listed),
+		}, nil
+	}
+}
@@ -0,0 +1,344 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// 触发方式
+const (
+	TriggerSchedule = "schedule" // 按cron表达式定时触发
+	TriggerManual   = "manual"   // 通过管理接口手动触发
+)
+
+// RunResult 单次运行的统计结果
+type RunResult struct {
+	Processed int    // 检查的文档数量
+	Enqueued  int    // 投递或执行的任务数量
+	Failed    int    // 失败数量
+	Message   string // 运行信息
+}
+
+// JobFunc 定时任务的执行函数
+type JobFunc func(ctx context.Context) (*RunResult, error)
+
+// JobInfo 定时任务信息
+type JobInfo struct {
+	Name     string     // 任务名称
+	Type     string     // 任务类型
+	Schedule string     // cron表达式
+	Running  bool       // 是否正在运行
+	NextRun  *time.Time // 下次运行时间，调度器未启动时为空
+}
+
+// job 已注册的定时任务
+type job struct {
+	name     string
+	jobType  string
+	spec     string
+	schedule cron.Schedule
+	fn       JobFunc
+	entryID  cron.EntryID
+	running  bool
+}
+
+// Scheduler 定时任务调度器
+// 按cron表达式周期性执行任务，同一任务的运行不会重叠，每次运行都会记录到运行历史中
+type Scheduler struct {
+	cron    *cron.Cron
+	runRepo repository.JobRunRepository // 运行记录仓储，为空时只记录日志
+	logger  *logrus.Logger
+	timeout time.Duration // 单次运行的超时时间，0表示不限制
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	started bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Option 调度器配置选项
+type Option func(*Scheduler)
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *logrus.Logger) Option {
+	return func(s *Scheduler) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithRunRepository 设置运行记录仓储
+func WithRunRepository(repo repository.JobRunRepository) Option {
+	return func(s *Scheduler) {
+		s.runRepo = repo
+	}
+}
+
+// WithTimeout 设置单次运行的超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Scheduler) {
+		s.timeout = timeout
+	}
+}
+
+// New 创建定时任务调度器
+func New(opts ...Option) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		logger: logrus.New(),
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.cron = cron.New(cron.WithLogger(cron.PrintfLogger(s.logger)))
+	return s
+}
+
+// Register 注册定时任务
+// spec为标准五段cron表达式，也支持@daily、@every 1h等描述符
+func (s *Scheduler) Register(name, jobType, spec string, fn JobFunc) error {
+	if name == "" {
+		return fmt.Errorf("job name cannot be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("job %s has no function", name)
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[name]; exists {
+		return fmt.Errorf("job %s already registered", name)
+	}
+
+	j := &job{name: name, jobType: jobType, spec: spec, schedule: schedule, fn: fn}
+	j.entryID = s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.run(s.ctx, j, TriggerSchedule)
+	}))
+	s.jobs[name] = j
+
+	s.logger.WithFields(logrus.Fields{
+		"job":      name,
+		"type":     jobType,
+		"schedule": spec,
+	}).Info("Scheduled job registered")
+	return nil
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	s.cron.Start()
+	s.logger.WithField("jobs", len(s.jobs)).Info("Scheduler started")
+}
+
+// Stop 停止调度器，取消正在运行的任务并等待其退出
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	started := s.started
+	s.started = false
+	s.mu.Unlock()
+
+	if started {
+		<-s.cron.Stop().Done()
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Scheduler stopped")
+}
+
+// Jobs 列出已注册的任务，按名称排序
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		info := JobInfo{
+			Name:     j.name,
+			Type:     j.jobType,
+			Schedule: j.spec,
+			Running:  j.running,
+		}
+		if s.started {
+			next := s.cron.Entry(j.entryID).Next
+			if !next.IsZero() {
+				info.NextRun = &next
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Name < infos[b].Name })
+	return infos
+}
+
+// RunNow 立即同步运行指定任务，返回本次运行记录
+func (s *Scheduler) RunNow(ctx context.Context, name string) (*models.JobRun, error) {
+	j, err := s.job(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.run(ctx, j, TriggerManual), nil
+}
+
+// Trigger 在后台立即运行指定任务，调度器停止时任务会被取消
+func (s *Scheduler) Trigger(name string) error {
+	j, err := s.job(name)
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(s.ctx, j, TriggerManual)
+	}()
+	return nil
+}
+
+// Runs 查询运行历史，name为空时返回所有任务的记录
+func (s *Scheduler) Runs(ctx context.Context, name string, offset, limit int) ([]*models.JobRun, int64, error) {
+	if s.runRepo == nil {
+		return nil, 0, fmt.Errorf("job run history is not configured")
+	}
+	return s.runRepo.WithContext(ctx).List(name, offset, limit)
+}
+
+// job 按名称查找任务
+func (s *Scheduler) job(name string) (*job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[name]
+	if !ok {
+		return nil, fmt.Errorf("job not found: %s", name)
+	}
+	return j, nil
+}
+
+// run 执行一次任务并记录运行历史
+// 上一次运行尚未结束时跳过本次运行
+func (s *Scheduler) run(ctx context.Context, j *job, trigger string) *models.JobRun {
+	record := &models.JobRun{
+		JobName:   j.name,
+		JobType:   j.jobType,
+		Trigger:   trigger,
+		Status:    models.JobRunRunning,
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	if j.running {
+		s.mu.Unlock()
+		now := time.Now()
+		record.Status = models.JobRunSkipped
+		record.Message = "previous run still in progress"
+		record.FinishedAt = &now
+		s.saveRun(record, true)
+		s.logger.WithField("job", j.name).Warn("Skipping scheduled job, previous run still in progress")
+		return record
+	}
+	j.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	s.saveRun(record, true)
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"job":     j.name,
+		"trigger": trigger,
+	})
+	logger.Info("Running scheduled job")
+
+	result, err := s.safeCall(ctx, j.fn)
+	if result != nil {
+		record.Processed = result.Processed
+		record.Enqueued = result.Enqueued
+		record.Failed = result.Failed
+		record.Message = result.Message
+	}
+	record.Status = models.JobRunSucceeded
+	if err != nil {
+		record.Status = models.JobRunFailed
+		record.Message = err.Error()
+	}
+	now := time.Now()
+	record.FinishedAt = &now
+	s.saveRun(record, false)
+
+	entry := logger.WithFields(logrus.Fields{
+		"processed": record.Processed,
+		"enqueued":  record.Enqueued,
+		"failed":    record.Failed,
+		"duration":  now.Sub(record.StartedAt).String(),
+	})
+	if err != nil {
+		entry.WithError(err).Error("Scheduled job failed")
+	} else {
+		entry.Info("Scheduled job finished")
+	}
+	return record
+}
+
+// safeCall 调用任务函数，将panic转换为错误，避免影响调度器
+func (s *Scheduler) safeCall(ctx context.Context, fn JobFunc) (result *RunResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// saveRun 保存运行记录，失败时只记录日志
+func (s *Scheduler) saveRun(record *models.JobRun, create bool) {
+	if s.runRepo == nil {
+		return
+	}
+
+	var err error
+	if create {
+		err = s.runRepo.Create(record)
+	} else {
+		err = s.runRepo.Update(record)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("job", record.JobName).Warn("Failed to save job run record")
+	}
+}
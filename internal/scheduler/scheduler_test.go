@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupRunRepo 创建使用内存数据库的运行记录仓储
+func setupRunRepo(t *testing.T) repository.JobRunRepository {
+	dbName := fmt.Sprintf("file:memdb_scheduler_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.JobRun{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	return repository.NewJobRunRepository()
+}
+
+// TestSchedulerRunHistory 测试任务运行结果被记录到运行历史
+func TestSchedulerRunHistory(t *testing.T) {
+	s := New(WithRunRepository(setupRunRepo(t)))
+	defer s.Stop()
+
+	require.NoError(t, s.Register("ok", JobTypeRecrawl, "@daily", func(ctx context.Context) (*RunResult, error) {
+		return &RunResult{Processed: 3, Enqueued: 2, Failed: 1}, nil
+	}))
+	require.NoError(t, s.Register("broken", JobTypeReembed, "0 3 * * *", func(ctx context.Context) (*RunResult, error) {
+		return nil, errors.New("embedding service down")
+	}))
+
+	run, err := s.RunNow(context.Background(), "ok")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunSucceeded, run.Status)
+	assert.Equal(t, TriggerManual, run.Trigger)
+	assert.NotNil(t, run.FinishedAt)
+
+	run, err = s.RunNow(context.Background(), "broken")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunFailed, run.Status)
+	assert.Equal(t, "embedding service down", run.Message)
+
+	_, err = s.RunNow(context.Background(), "missing")
+	assert.Error(t, err)
+
+	runs, total, err := s.Runs(context.Background(), "ok", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, runs, 1)
+	assert.Equal(t, 3, runs[0].Processed)
+	assert.Equal(t, 2, runs[0].Enqueued)
+	assert.Equal(t, 1, runs[0].Failed)
+
+	_, total, err = s.Runs(context.Background(), "", 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	jobs := s.Jobs()
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "broken", jobs[0].Name)
+	assert.Nil(t, jobs[0].NextRun)
+
+	s.Start()
+	jobs = s.Jobs()
+	require.NotNil(t, jobs[0].NextRun)
+	assert.True(t, jobs[0].NextRun.After(time.Now()))
+}
+
+// TestSchedulerRegister 测试任务注册的参数校验
+func TestSchedulerRegister(t *testing.T) {
+	s := New()
+	defer s.Stop()
+
+	noop := func(ctx context.Context) (*RunResult, error) { return &RunResult{}, nil }
+
+	assert.Error(t, s.Register("bad", JobTypeRecrawl, "every day", noop))
+	assert.Error(t, s.Register("", JobTypeRecrawl, "@daily", noop))
+	assert.Error(t, s.Register("nil", JobTypeRecrawl, "@daily", nil))
+	require.NoError(t, s.Register("every", JobTypeRecrawl, "@every 30m", noop))
+	assert.Error(t, s.Register("every", JobTypeRecrawl, "@hourly", noop))
+}
+
+// TestSchedulerSkipsOverlappingRun 测试上一次运行未结束时跳过新的运行
+func TestSchedulerSkipsOverlappingRun(t *testing.T) {
+	s := New(WithRunRepository(setupRunRepo(t)))
+	defer s.Stop()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, s.Register("slow", JobTypeReembed, "@daily", func(ctx context.Context) (*RunResult, error) {
+		close(started)
+		<-release
+		return &RunResult{}, nil
+	}))
+
+	require.NoError(t, s.Trigger("slow"))
+	<-started
+
+	run, err := s.RunNow(context.Background(), "slow")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobRunSkipped, run.Status)
+
+	close(release)
+}
+
+// fakeMaintainer 记录调用的文档维护实现
+type fakeMaintainer struct {
+	mu         sync.Mutex
+	urls       map[string]string
+	stale      []string
+	model      string
+	recrawled  []string
+	reembedded []string
+}
+
+func (f *fakeMaintainer) URLSourcedDocuments(ctx context.Context) (map[string]string, error) {
+	return f.urls, nil
+}
+
+func (f *fakeMaintainer) DocumentsNeedingReembed(ctx context.Context, model string) ([]string, error) {
+	return f.stale, nil
+}
+
+func (f *fakeMaintainer) EmbeddingModel() string {
+	return f.model
+}
+
+func (f *fakeMaintainer) RecrawlDocument(ctx context.Context, fileID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recrawled = append(f.recrawled, fileID)
+	return fileID == "changed", nil
+}
+
+func (f *fakeMaintainer) ReembedDocument(ctx context.Context, fileID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if fileID == "broken" {
+		return errors.New("parse failed")
+	}
+	f.reembedded = append(f.reembedded, fileID)
+	return nil
+}
+
+// fakeCollector 支持垃圾回收的文档维护实现
+type fakeCollector struct {
+	*fakeMaintainer
+}
+
+func (f *fakeCollector) CollectGarbage(ctx context.Context) (int, int, int, error) {
+	return 3, 2, 1, nil
+}
+
+// TestMaintenanceJobs 测试重新抓取和重新向量化任务
+func TestMaintenanceJobs(t *testing.T) {
+	maintainer := &fakeMaintainer{
+		urls:  map[string]string{"changed": "https://example.com/a", "same": "https://example.com/b"},
+		stale: []string{"doc-1", "broken"},
+		model: "text-embedding-v3",
+	}
+
+	t.Run("inline", func(t *testing.T) {
+		result, err := RecrawlJob(maintainer, nil)(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Processed)
+		assert.Equal(t, 2, result.Enqueued)
+		assert.Equal(t, "1 of 2 documents changed", result.Message)
+
+		result, err = ReembedJob(maintainer, nil)(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Processed)
+		assert.Equal(t, 1, result.Enqueued)
+		assert.Equal(t, 1, result.Failed)
+		assert.Equal(t, []string{"doc-1"}, maintainer.reembedded)
+	})
+
+	t.Run("queued", func(t *testing.T) {
+		queue := taskqueue.NewMockQueue(t)
+		queue.EXPECT().
+			Enqueue(mock.Anything, taskqueue.TaskDocumentReembed, mock.Anything, mock.MatchedBy(func(p taskqueue.DocumentReembedPayload) bool {
+				return p.Model == "text-embedding-v3"
+			})).
+			Return("task-id", nil).Twice()
+
+		fn, err := NewJob(JobTypeReembed, maintainer, queue)
+		require.NoError(t, err)
+		result, err := fn(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Enqueued)
+	})
+
+	t.Run("gc", func(t *testing.T) {
+		_, err := NewJob(JobTypeGC, maintainer, nil)
+		assert.Error(t, err, "maintainer without garbage collection")
+
+		fn, err := NewJob(JobTypeGC, &fakeCollector{fakeMaintainer: maintainer}, nil)
+		require.NoError(t, err)
+		result, err := fn(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Processed)
+		assert.Equal(t, 2, result.Enqueued)
+		assert.Equal(t, 1, result.Failed)
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		_, err := NewJob("cleanup", maintainer, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("no embedding model", func(t *testing.T) {
+		_, err := ReembedJob(&fakeMaintainer{}, nil)(context.Background())
+		assert.Error(t, err)
+	})
+}
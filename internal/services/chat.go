@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/google/uuid"
@@ -18,6 +22,8 @@ import (
 type ChatService struct {
 	repo   repository.ChatRepository // 聊天仓储接口
 	logger *logrus.Logger            // 日志记录器
+	audit  *audit.Recorder           // 审计日志记录器，为空时不记录
+	llm    llm.Client                // 用于生成会话标题和摘要的大模型客户端，为空时不生成
 }
 
 // ChatOption 聊天服务配置选项
@@ -55,6 +61,13 @@ func WithChatRepository(repo repository.ChatRepository) ChatOption {
 	}
 }
 
+// WithChatAudit 设置审计日志记录器，记录删除聊天会话的操作
+func WithChatAudit(recorder *audit.Recorder) ChatOption {
+	return func(s *ChatService) {
+		s.audit = recorder
+	}
+}
+
 // CreateChat 创建新的聊天会话
 func (s *ChatService) CreateChat(ctx context.Context, title string) (*models.ChatSession, error) {
 	if title == "" {
@@ -67,16 +80,17 @@ func (s *ChatService) CreateChat(ctx context.Context, title string) (*models.Cha
 		Title:     title,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+		UserID:    chatOwner(ctx),
 	}
 
 	// 保存到数据库
 	err := s.repo.CreateSession(session)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to create chat session")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to create chat session")
 		return nil, fmt.Errorf("failed to create chat session: %w", err)
 	}
 
-	s.logger.WithField("session_id", session.ID).Info("Chat session created")
+	s.logger.WithContext(ctx).WithField("session_id", session.ID).Info("Chat session created")
 	return session, nil
 }
 
@@ -89,7 +103,7 @@ func (s *ChatService) GetChatSession(ctx context.Context, sessionID string) (*mo
 	// 从仓储获取会话
 	session, err := s.repo.GetSession(sessionID)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session")
 		return nil, fmt.Errorf("failed to get chat session: %w", err)
 	}
 
@@ -101,7 +115,7 @@ func (s *ChatService) ListChatSessions(ctx context.Context, offset, limit int, f
 	// 从仓储获取会话列表
 	sessions, total, err := s.repo.ListSessions(offset, limit, filters)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to list chat sessions")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to list chat sessions")
 		return nil, 0, fmt.Errorf("failed to list chat sessions: %w", err)
 	}
 
@@ -120,11 +134,11 @@ func (s *ChatService) UpdateChatSession(ctx context.Context, session *models.Cha
 	// 保存到数据库
 	err := s.repo.UpdateSession(session)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", session.ID).Error("Failed to update chat session")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", session.ID).Error("Failed to update chat session")
 		return fmt.Errorf("failed to update chat session: %w", err)
 	}
 
-	s.logger.WithField("session_id", session.ID).Info("Chat session updated")
+	s.logger.WithContext(ctx).WithField("session_id", session.ID).Info("Chat session updated")
 	return nil
 }
 
@@ -137,11 +151,12 @@ func (s *ChatService) DeleteChatSession(ctx context.Context, sessionID string) e
 	// 从数据库删除
 	err := s.repo.DeleteSession(sessionID)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to delete chat session")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to delete chat session")
 		return fmt.Errorf("failed to delete chat session: %w", err)
 	}
 
-	s.logger.WithField("session_id", sessionID).Info("Chat session deleted")
+	s.audit.Record(ctx, models.AuditActionDeleteChat, sessionID, "")
+	s.logger.WithContext(ctx).WithField("session_id", sessionID).Info("Chat session deleted")
 	return nil
 }
 
@@ -170,7 +185,7 @@ func (s *ChatService) AddMessage(ctx context.Context, message *models.ChatMessag
 	// 保存到数据库
 	err := s.repo.CreateMessage(message)
 	if err != nil {
-		s.logger.WithError(err).
+		s.logger.WithContext(ctx).WithError(err).
 			WithFields(logrus.Fields{
 				"session_id": message.SessionID,
 				"role":       message.Role,
@@ -178,7 +193,7 @@ func (s *ChatService) AddMessage(ctx context.Context, message *models.ChatMessag
 		return fmt.Errorf("failed to add chat message: %w", err)
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"session_id": message.SessionID,
 		"role":       message.Role,
 	}).Info("Chat message added")
@@ -194,7 +209,7 @@ func (s *ChatService) GetChatMessages(ctx context.Context, sessionID string, off
 	// 从仓储获取消息
 	messages, total, err := s.repo.GetMessages(sessionID, offset, limit)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to get chat messages")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to get chat messages")
 		return nil, 0, fmt.Errorf("failed to get chat messages: %w", err)
 	}
 
@@ -210,7 +225,7 @@ func (s *ChatService) GetRecentMessages(ctx context.Context, limit int) ([]*mode
 	// 从仓储获取最近消息
 	messages, err := s.repo.GetRecentMessages(limit)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to get recent messages")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to get recent messages")
 		return nil, fmt.Errorf("failed to get recent messages: %w", err)
 	}
 
@@ -226,7 +241,7 @@ func (s *ChatService) CountChatMessages(ctx context.Context, sessionID string) (
 	// 统计消息数量
 	count, err := s.repo.CountMessages(sessionID)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to count chat messages")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to count chat messages")
 		return 0, fmt.Errorf("failed to count chat messages: %w", err)
 	}
 
@@ -247,7 +262,7 @@ func (s *ChatService) SaveMessageWithSources(ctx context.Context, message *model
 	if len(sources) > 0 {
 		sourcesJSON, err := json.Marshal(sources)
 		if err != nil {
-			s.logger.WithError(err).Error("Failed to marshal sources to JSON")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to marshal sources to JSON")
 			return fmt.Errorf("failed to marshal sources: %w", err)
 		}
 
@@ -258,11 +273,11 @@ func (s *ChatService) SaveMessageWithSources(ctx context.Context, message *model
 	// 保存到数据库
 	err := s.repo.CreateMessage(message)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", message.SessionID).Error("Failed to save message with sources")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", message.SessionID).Error("Failed to save message with sources")
 		return fmt.Errorf("failed to save message with sources: %w", err)
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"session_id":    message.SessionID,
 		"sources_count": len(sources),
 	}).Info("Message with sources saved")
@@ -282,7 +297,7 @@ func (s *ChatService) RenameChatSession(ctx context.Context, sessionID string, n
 	// 获取会话
 	session, err := s.repo.GetSession(sessionID)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session for rename")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session for rename")
 		return fmt.Errorf("failed to get chat session: %w", err)
 	}
 
@@ -293,17 +308,74 @@ func (s *ChatService) RenameChatSession(ctx context.Context, sessionID string, n
 	// 保存更新
 	err = s.repo.UpdateSession(session)
 	if err != nil {
-		s.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to rename chat session")
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to rename chat session")
 		return fmt.Errorf("failed to rename chat session: %w", err)
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"session_id": sessionID,
 		"new_title":  newTitle,
 	}).Info("Chat session renamed")
 	return nil
 }
 
+// SetChatScope 设置会话绑定的文档和文档组
+// 绑定后会话中的提问只在这些文档中检索，两者都为空时解除绑定
+func (s *ChatService) SetChatScope(ctx context.Context, sessionID string, documentIDs, groupIDs []string) (*models.ChatSession, error) {
+	if sessionID == "" {
+		return nil, errors.New("session ID cannot be empty")
+	}
+
+	session, err := s.repo.GetSession(sessionID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session for scope")
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+
+	session.SetScope(documentIDs, groupIDs)
+	if err := s.repo.UpdateSession(session); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to update chat scope")
+		return nil, fmt.Errorf("failed to update chat scope: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"documents":  session.DocumentIDs,
+		"groups":     session.GroupIDs,
+	}).Info("Chat scope updated")
+	return session, nil
+}
+
+// SetSystemPrompt 设置会话的系统提示词
+// 系统提示词设定助手在该会话中的角色，加在会话中所有回答的提示词开头，为空时清除
+func (s *ChatService) SetSystemPrompt(ctx context.Context, sessionID, prompt string) (*models.ChatSession, error) {
+	if sessionID == "" {
+		return nil, errors.New("session ID cannot be empty")
+	}
+	prompt = strings.TrimSpace(prompt)
+	if utf8.RuneCountInString(prompt) > models.MaxSystemPromptRunes {
+		return nil, fmt.Errorf("system prompt exceeds %d characters", models.MaxSystemPromptRunes)
+	}
+
+	session, err := s.repo.GetSession(sessionID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to get chat session for system prompt")
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+
+	session.SystemPrompt = prompt
+	if err := s.repo.UpdateSession(session); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Error("Failed to update system prompt")
+		return nil, fmt.Errorf("failed to update system prompt: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"length":     utf8.RuneCountInString(prompt),
+	}).Info("Chat system prompt updated")
+	return session, nil
+}
+
 // GetChatsWithMessageCount 获取带消息数量的聊天会话列表
 func (s *ChatService) GetChatsWithMessageCount(ctx context.Context, offset, limit int) ([]map[string]interface{}, int64, error) {
 	// 获取会话列表
@@ -312,6 +384,21 @@ func (s *ChatService) GetChatsWithMessageCount(ctx context.Context, offset, limi
 		return nil, 0, fmt.Errorf("failed to list chat sessions: %w", err)
 	}
 
+	return s.withMessageCount(ctx, sessions), total, nil
+}
+
+// GetChatsWithMessageCountAfter 使用游标分页获取带消息数量的聊天会话列表，返回下一页游标
+func (s *ChatService) GetChatsWithMessageCountAfter(ctx context.Context, cursor string, limit int) ([]map[string]interface{}, string, error) {
+	sessions, next, err := s.repo.ListSessionsAfter(cursor, limit, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list chat sessions: %w", err)
+	}
+
+	return s.withMessageCount(ctx, sessions), next, nil
+}
+
+// withMessageCount 为会话列表补充消息数量
+func (s *ChatService) withMessageCount(ctx context.Context, sessions []*models.ChatSession) []map[string]interface{} {
 	// 准备返回结果
 	result := make([]map[string]interface{}, len(sessions))
 
@@ -320,7 +407,7 @@ func (s *ChatService) GetChatsWithMessageCount(ctx context.Context, offset, limi
 		// 获取消息数量
 		count, err := s.repo.CountMessages(session.ID)
 		if err != nil {
-			s.logger.WithError(err).WithField("session_id", session.ID).Warn("Failed to count messages")
+			s.logger.WithContext(ctx).WithError(err).WithField("session_id", session.ID).Warn("Failed to count messages")
 			count = 0 // 出错时默认为0
 		}
 
@@ -334,5 +421,5 @@ func (s *ChatService) GetChatsWithMessageCount(ctx context.Context, offset, limi
 		}
 	}
 
-	return result, total, nil
+	return result
 }
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+)
+
+// ChatExportFormat 会话导出格式
+type ChatExportFormat string
+
+const (
+	// ChatExportMarkdown Markdown格式，便于阅读和分享
+	ChatExportMarkdown ChatExportFormat = "md"
+	// ChatExportJSON JSON格式，便于归档和程序处理
+	ChatExportJSON ChatExportFormat = "json"
+)
+
+// ErrUnsupportedExportFormat 不支持的导出格式
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// exportTimeLayout 导出Markdown中的时间格式
+const exportTimeLayout = "2006-01-02 15:04:05"
+
+// ParseChatExportFormat 解析导出格式，为空时使用Markdown
+func ParseChatExportFormat(format string) (ChatExportFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "md", "markdown":
+		return ChatExportMarkdown, nil
+	case "json":
+		return ChatExportJSON, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedExportFormat, format)
+	}
+}
+
+// Extension 返回导出文件的扩展名
+func (f ChatExportFormat) Extension() string {
+	return string(f)
+}
+
+// ContentType 返回导出文件的MIME类型
+func (f ChatExportFormat) ContentType() string {
+	if f == ChatExportJSON {
+		return "application/json; charset=utf-8"
+	}
+	return "text/markdown; charset=utf-8"
+}
+
+// ChatTranscript 会话的完整记录，用于导出
+type ChatTranscript struct {
+	SessionID   string              `json:"session_id"`
+	Title       string              `json:"title"`
+	Summary     string              `json:"summary,omitempty"`
+	DocumentIDs []string            `json:"document_ids,omitempty"`
+	GroupIDs    []string            `json:"group_ids,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	ExportedAt  time.Time           `json:"exported_at"`
+	Messages    []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptMessage 会话记录中的一条消息
+type TranscriptMessage struct {
+	ID        uint               `json:"id"`
+	Role      models.MessageRole `json:"role"`
+	Content   string             `json:"content"`
+	Sources   []models.Source    `json:"sources,omitempty"`
+	Version   int                `json:"version"`
+	CreatedAt time.Time          `json:"created_at"`
+	EditedAt  *time.Time         `json:"edited_at,omitempty"`
+}
+
+// GetChatTranscript 获取会话的完整记录，包含全部消息和引用的信息源
+func (s *ChatService) GetChatTranscript(ctx context.Context, sessionID string) (*ChatTranscript, error) {
+	repo := s.repo.WithContext(ctx)
+	session, err := repo.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+	messages, _, err := repo.GetMessages(sessionID, 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat messages: %w", err)
+	}
+
+	transcript := &ChatTranscript{
+		SessionID:   session.ID,
+		Title:       session.Title,
+		Summary:     session.Summary,
+		DocumentIDs: session.ScopeDocumentIDs(),
+		GroupIDs:    session.ScopeGroupIDs(),
+		CreatedAt:   session.CreatedAt,
+		UpdatedAt:   session.UpdatedAt,
+		ExportedAt:  time.Now(),
+		Messages:    make([]TranscriptMessage, 0, len(messages)),
+	}
+	for _, msg := range messages {
+		var sources []models.Source
+		if len(msg.Sources) > 0 {
+			if err := json.Unmarshal(msg.Sources, &sources); err != nil {
+				s.logger.WithContext(ctx).WithError(err).WithField("message_id", msg.ID).Warn("Failed to parse message sources")
+			}
+		}
+		transcript.Messages = append(transcript.Messages, TranscriptMessage{
+			ID:        msg.ID,
+			Role:      msg.Role,
+			Content:   msg.Content,
+			Sources:   sources,
+			Version:   msg.Version,
+			CreatedAt: msg.CreatedAt,
+			EditedAt:  msg.EditedAt,
+		})
+	}
+	return transcript, nil
+}
+
+// Write 按指定格式写出会话记录
+func (t *ChatTranscript) Write(w io.Writer, format ChatExportFormat) error {
+	switch format {
+	case ChatExportJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t)
+	case ChatExportMarkdown:
+		return t.writeMarkdown(w)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedExportFormat, format)
+	}
+}
+
+// writeMarkdown 以Markdown格式写出会话记录，引用的信息源按回答中的编号列出
+func (t *ChatTranscript) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", t.Title)
+	fmt.Fprintf(&b, "- 会话ID：%s\n", t.SessionID)
+	fmt.Fprintf(&b, "- 创建时间：%s\n", t.CreatedAt.Format(exportTimeLayout))
+	fmt.Fprintf(&b, "- 导出时间：%s\n", t.ExportedAt.Format(exportTimeLayout))
+	if len(t.DocumentIDs) > 0 {
+		fmt.Fprintf(&b, "- 绑定文档：%s\n", strings.Join(t.DocumentIDs, ", "))
+	}
+	if len(t.GroupIDs) > 0 {
+		fmt.Fprintf(&b, "- 绑定文档组：%s\n", strings.Join(t.GroupIDs, ", "))
+	}
+	if t.Summary != "" {
+		fmt.Fprintf(&b, "\n> 摘要：%s\n", t.Summary)
+	}
+
+	for _, msg := range t.Messages {
+		role := "用户"
+		switch msg.Role {
+		case models.RoleAssistant:
+			role = "助手"
+		case models.RoleSystem:
+			role = "系统"
+		}
+		fmt.Fprintf(&b, "\n## %s · %s", role, msg.CreatedAt.Format(exportTimeLayout))
+		if msg.EditedAt != nil {
+			fmt.Fprintf(&b, "（第%d版，编辑于%s）", msg.Version, msg.EditedAt.Format(exportTimeLayout))
+		}
+		fmt.Fprintf(&b, "\n\n%s\n", strings.TrimSpace(msg.Content))
+
+		if len(msg.Sources) > 0 {
+			b.WriteString("\n**引用来源：**\n\n")
+			for i, source := range msg.Sources {
+				name := source.FileName
+				if name == "" {
+					name = source.FileID
+				}
+				fmt.Fprintf(&b, "%d. %s（段落 %d）", i+1, name, source.Position)
+				if text := strings.Join(strings.Fields(source.Text), " "); text != "" {
+					fmt.Fprintf(&b, "：%s", truncateRunes(text, 200))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
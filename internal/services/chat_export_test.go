@@ -0,0 +1,54 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChatService_ExportChat 测试以Markdown和JSON导出会话记录
+func TestChatService_ExportChat(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := chatService.CreateChat(ctx, "合同问答")
+	require.NoError(t, err)
+	require.NoError(t, chatService.AddMessage(ctx, &models.ChatMessage{
+		SessionID: session.ID, Role: models.RoleUser, Content: "付款期限是多久？",
+	}))
+	require.NoError(t, chatService.SaveMessageWithSources(ctx, &models.ChatMessage{
+		SessionID: session.ID, Role: models.RoleAssistant, Content: "30天[1]",
+	}, []models.Source{{FileID: "file-1", FileName: "采购合同.pdf", Position: 3, Text: "买方应在\n30天内付款"}}))
+
+	transcript, err := chatService.GetChatTranscript(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, transcript.Messages, 2)
+
+	var md bytes.Buffer
+	require.NoError(t, transcript.Write(&md, ChatExportMarkdown))
+	assert.Contains(t, md.String(), "# 合同问答")
+	assert.Contains(t, md.String(), "付款期限是多久？")
+	assert.Contains(t, md.String(), "1. 采购合同.pdf（段落 3）：买方应在 30天内付款")
+
+	var out bytes.Buffer
+	require.NoError(t, transcript.Write(&out, ChatExportJSON))
+	var decoded ChatTranscript
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, session.ID, decoded.SessionID)
+	assert.Equal(t, "file-1", decoded.Messages[1].Sources[0].FileID)
+
+	format, err := ParseChatExportFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, ChatExportMarkdown, format)
+	_, err = ParseChatExportFormat("pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat)
+
+	_, err = chatService.GetChatTranscript(ctx, "missing")
+	assert.Error(t, err)
+}
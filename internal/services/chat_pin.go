@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// PinnedMessage 收藏的助手回复及其所属会话
+type PinnedMessage struct {
+	Session  *models.ChatSession // 消息所属的会话
+	Question *models.ChatMessage // 回复对应的用户问题，找不到时为空
+	Message  *models.ChatMessage // 收藏的回复
+}
+
+// PinMessage 收藏或取消收藏助手回复，用于把好的回答整理成知识片段
+func (s *ChatService) PinMessage(ctx context.Context, sessionID string, messageID uint, pinned bool) (*models.ChatMessage, error) {
+	repo := s.repo.WithContext(ctx)
+	message, err := repo.GetMessage(sessionID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChatMessageNotFound, err)
+	}
+	if message.Role != models.RoleAssistant {
+		return nil, fmt.Errorf("%w: only assistant messages can be pinned", ErrInvalidMessageRole)
+	}
+
+	// 重复收藏保留原来的收藏时间
+	if pinned == (message.PinnedAt != nil) {
+		return message, nil
+	}
+	message.PinnedAt = nil
+	if pinned {
+		now := time.Now()
+		message.PinnedAt = &now
+	}
+	if err := repo.SetMessagePinned(sessionID, messageID, message.PinnedAt); err != nil {
+		return nil, fmt.Errorf("failed to pin chat message: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"message_id": messageID,
+		"pinned":     pinned,
+	}).Info("Chat message pin updated")
+	return message, nil
+}
+
+// ListPinnedMessages 列出调用者收藏的助手回复，按收藏时间倒序
+// 匿名请求只列出匿名创建的会话中收藏的回复
+func (s *ChatService) ListPinnedMessages(ctx context.Context, offset, limit int) ([]*PinnedMessage, int64, error) {
+	repo := s.repo.WithContext(ctx)
+	messages, total, err := repo.ListPinnedMessages(chatOwner(ctx), offset, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to list pinned messages")
+		return nil, 0, fmt.Errorf("failed to list pinned messages: %w", err)
+	}
+
+	sessions := make(map[string]*models.ChatSession)
+	pinned := make([]*PinnedMessage, 0, len(messages))
+	for _, msg := range messages {
+		session, ok := sessions[msg.SessionID]
+		if !ok {
+			if session, err = repo.GetSession(msg.SessionID); err != nil {
+				continue
+			}
+			sessions[msg.SessionID] = session
+		}
+		item := &PinnedMessage{Session: session, Message: msg}
+		if question, err := repo.GetPreviousMessage(msg.SessionID, msg.ID, models.RoleUser); err == nil {
+			item.Question = question
+		}
+		pinned = append(pinned, item)
+	}
+	return pinned, total, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChatService_PinMessage 测试收藏助手回复并按调用者列出收藏
+func TestChatService_PinMessage(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	alice := usage.WithTenant(context.Background(), "alice")
+	bob := usage.WithTenant(context.Background(), "bob")
+
+	session, err := chatService.CreateChat(alice, "合同问答")
+	require.NoError(t, err)
+	question := &models.ChatMessage{SessionID: session.ID, Role: models.RoleUser, Content: "付款期限是多久？"}
+	require.NoError(t, chatService.AddMessage(alice, question))
+	reply := &models.ChatMessage{SessionID: session.ID, Role: models.RoleAssistant, Content: "30天"}
+	require.NoError(t, chatService.AddMessage(alice, reply))
+
+	_, err = chatService.PinMessage(alice, session.ID, question.ID, true)
+	assert.ErrorIs(t, err, ErrInvalidMessageRole)
+	_, err = chatService.PinMessage(alice, session.ID, 9999, true)
+	assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+	pinned, err := chatService.PinMessage(alice, session.ID, reply.ID, true)
+	require.NoError(t, err)
+	require.NotNil(t, pinned.PinnedAt)
+	pinnedAt := *pinned.PinnedAt
+
+	// 重复收藏保留原来的收藏时间
+	again, err := chatService.PinMessage(alice, session.ID, reply.ID, true)
+	require.NoError(t, err)
+	assert.True(t, pinnedAt.Equal(*again.PinnedAt))
+
+	items, total, err := chatService.ListPinnedMessages(alice, 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, items, 1)
+	assert.Equal(t, "合同问答", items[0].Session.Title)
+	assert.Equal(t, "付款期限是多久？", items[0].Question.Content)
+	assert.Equal(t, "30天", items[0].Message.Content)
+
+	// 其他租户和匿名请求看不到
+	_, total, err = chatService.ListPinnedMessages(bob, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	_, total, err = chatService.ListPinnedMessages(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// 匿名请求只列出匿名会话中收藏的回复
+	anonSession, err := chatService.CreateChat(context.Background(), "匿名问答")
+	require.NoError(t, err)
+	anonReply := &models.ChatMessage{SessionID: anonSession.ID, Role: models.RoleAssistant, Content: "请联系财务"}
+	require.NoError(t, chatService.AddMessage(context.Background(), anonReply))
+	_, err = chatService.PinMessage(context.Background(), anonSession.ID, anonReply.ID, true)
+	require.NoError(t, err)
+	items, total, err = chatService.ListPinnedMessages(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, items, 1)
+	assert.Equal(t, anonReply.ID, items[0].Message.ID)
+	_, total, err = chatService.ListPinnedMessages(alice, 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	unpinned, err := chatService.PinMessage(alice, session.ID, reply.ID, false)
+	require.NoError(t, err)
+	assert.Nil(t, unpinned.PinnedAt)
+	_, total, err = chatService.ListPinnedMessages(alice, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidMessageRole 消息角色不支持该操作，如编辑助手回复或重新生成用户消息
+	ErrInvalidMessageRole = errors.New("invalid message role for this operation")
+	// ErrChatMessageNotFound 会话中不存在该消息
+	ErrChatMessageNotFound = errors.New("chat message not found")
+)
+
+// MessageSources 把问答返回的来源文档转换为消息引用的信息源
+func MessageSources(docs []vectordb.Document) []models.Source {
+	sources := make([]models.Source, 0, len(docs))
+	for _, doc := range docs {
+		sources = append(sources, models.Source{
+			FileID:   doc.FileID,
+			FileName: doc.FileName,
+			Position: doc.Position,
+			Text:     doc.Text,
+		})
+	}
+	return sources
+}
+
+// EditMessage 编辑用户消息
+// 原内容写入历史版本；之后的消息基于旧问题生成，全部删除，返回删除的消息数
+func (s *ChatService) EditMessage(ctx context.Context, sessionID string, messageID uint, content string) (*models.ChatMessage, int64, error) {
+	if content == "" {
+		return nil, 0, errors.New("message content cannot be empty")
+	}
+
+	repo := s.repo.WithContext(ctx)
+	message, err := repo.GetMessage(sessionID, messageID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrChatMessageNotFound, err)
+	}
+	if message.Role != models.RoleUser {
+		return nil, 0, fmt.Errorf("%w: only user messages can be edited", ErrInvalidMessageRole)
+	}
+	if message.Content == content {
+		return message, 0, nil
+	}
+
+	if err := s.revise(repo, message, content, nil); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("message_id", messageID).Error("Failed to edit chat message")
+		return nil, 0, fmt.Errorf("failed to edit chat message: %w", err)
+	}
+
+	invalidated, err := repo.DeleteMessagesAfter(sessionID, messageID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to delete messages after edited message: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id":  sessionID,
+		"message_id":  messageID,
+		"version":     message.Version,
+		"invalidated": invalidated,
+	}).Info("Chat message edited")
+	return message, invalidated, nil
+}
+
+// RegenerateReply 根据前一条用户消息重新生成助手回复
+// 重新检索并调用大模型，不使用缓存的回答；原回复写入历史版本后替换为新回复
+func (s *ChatService) RegenerateReply(ctx context.Context, sessionID string, messageID uint, answer QuestionAnswerFunc) (*models.ChatMessage, error) {
+	repo := s.repo.WithContext(ctx)
+	message, err := repo.GetMessage(sessionID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChatMessageNotFound, err)
+	}
+	if message.Role != models.RoleAssistant {
+		return nil, fmt.Errorf("%w: only assistant messages can be regenerated", ErrInvalidMessageRole)
+	}
+	question, err := repo.GetPreviousMessage(sessionID, messageID, models.RoleUser)
+	if err != nil {
+		return nil, fmt.Errorf("%w: no question before reply %d", ErrChatMessageNotFound, messageID)
+	}
+
+	text, docs, err := answer(WithFreshAnswer(ctx), question.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.revise(repo, message, text, MessageSources(docs)); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("message_id", messageID).Error("Failed to save regenerated reply")
+		return nil, fmt.Errorf("failed to save regenerated reply: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"message_id": messageID,
+		"version":    message.Version,
+	}).Info("Chat reply regenerated")
+	return message, nil
+}
+
+// GetMessageVersions 获取消息的历史版本，按版本号升序
+func (s *ChatService) GetMessageVersions(ctx context.Context, sessionID string, messageID uint) ([]*models.ChatMessageVersion, error) {
+	repo := s.repo.WithContext(ctx)
+	if _, err := repo.GetMessage(sessionID, messageID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChatMessageNotFound, err)
+	}
+	return repo.GetMessageVersions(messageID)
+}
+
+// revise 把消息的当前内容保存为历史版本，再替换为新内容
+func (s *ChatService) revise(repo repository.ChatRepository, message *models.ChatMessage, content string, sources []models.Source) error {
+	previous := &models.ChatMessageVersion{
+		MessageID: message.ID,
+		SessionID: message.SessionID,
+		Version:   message.Version,
+		Content:   message.Content,
+		Sources:   message.Sources,
+		CreatedAt: time.Now(),
+	}
+	if previous.Version == 0 {
+		previous.Version = 1
+	}
+
+	message.Sources = nil
+	if len(sources) > 0 {
+		sourcesJSON, err := json.Marshal(sources)
+		if err != nil {
+			return fmt.Errorf("failed to marshal sources: %w", err)
+		}
+		message.Sources = sourcesJSON
+	}
+	now := time.Now()
+	message.Content = content
+	message.Version = previous.Version + 1
+	message.EditedAt = &now
+	return repo.ReviseMessage(message, previous)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChatService_RegenerateAndEdit 测试重新生成助手回复和编辑用户消息
+func TestChatService_RegenerateAndEdit(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := chatService.CreateChat(ctx, "版本测试")
+	require.NoError(t, err)
+
+	add := func(role models.MessageRole, content string) *models.ChatMessage {
+		msg := &models.ChatMessage{SessionID: session.ID, Role: role, Content: content}
+		require.NoError(t, chatService.AddMessage(ctx, msg))
+		return msg
+	}
+	question := add(models.RoleUser, "付款期限是多久？")
+	reply := add(models.RoleAssistant, "30天")
+	add(models.RoleUser, "可以延期吗？")
+	add(models.RoleAssistant, "可以")
+
+	// 重新生成时使用前一条用户消息，并跳过回答缓存
+	var asked string
+	regenerated, err := chatService.RegenerateReply(ctx, session.ID, reply.ID,
+		func(ctx context.Context, q string) (string, []vectordb.Document, error) {
+			asked = q
+			fresh, _ := ctx.Value(freshAnswerKey{}).(bool)
+			assert.True(t, fresh)
+			return "60天", []vectordb.Document{{FileID: "contract", FileName: "contract.pdf", Text: "付款期限60天"}}, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "付款期限是多久？", asked)
+	assert.Equal(t, "60天", regenerated.Content)
+	assert.Equal(t, 2, regenerated.Version)
+	assert.NotNil(t, regenerated.EditedAt)
+
+	versions, err := chatService.GetMessageVersions(ctx, session.ID, reply.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "30天", versions[0].Content)
+
+	// 生成失败时保留原回复
+	_, err = chatService.RegenerateReply(ctx, session.ID, reply.ID,
+		func(ctx context.Context, q string) (string, []vectordb.Document, error) {
+			return "", nil, errors.New("llm unavailable")
+		})
+	assert.Error(t, err)
+
+	// 只能重新生成助手回复、编辑用户消息
+	_, err = chatService.RegenerateReply(ctx, session.ID, question.ID, nil)
+	assert.ErrorIs(t, err, ErrInvalidMessageRole)
+	_, _, err = chatService.EditMessage(ctx, session.ID, reply.ID, "改写")
+	assert.ErrorIs(t, err, ErrInvalidMessageRole)
+	_, _, err = chatService.EditMessage(ctx, "other-session", question.ID, "改写")
+	assert.ErrorIs(t, err, ErrChatMessageNotFound)
+
+	// 编辑用户消息后，之后的消息全部失效
+	edited, invalidated, err := chatService.EditMessage(ctx, session.ID, question.ID, "补充协议里的付款期限是多久？")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), invalidated)
+	assert.Equal(t, 2, edited.Version)
+
+	messages, total, err := chatService.GetChatMessages(ctx, session.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "补充协议里的付款期限是多久？", messages[0].Content)
+
+	versions, err = chatService.GetMessageVersions(ctx, session.ID, question.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, "付款期限是多久？", versions[0].Content)
+
+	// 被删除消息的历史版本一并删除
+	_, err = chatService.GetMessageVersions(ctx, session.ID, reply.ID)
+	assert.ErrorIs(t, err, ErrChatMessageNotFound)
+	require.NoError(t, chatService.DeleteChatSession(ctx, session.ID))
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+)
+
+const (
+	// maxSearchTerms 搜索关键词的最大数量
+	maxSearchTerms = 5
+	// snippetRunes 高亮片段在第一个匹配前后各保留的字符数
+	snippetRunes = 40
+)
+
+// ChatSearchHit 聊天记录搜索的一条结果
+type ChatSearchHit struct {
+	Session   *models.ChatSession // 消息所属的会话
+	Message   *models.ChatMessage // 匹配的消息
+	Highlight string              // 匹配处前后的片段，已做HTML转义，关键词用<mark>标记
+}
+
+// chatOwner 返回会话归属的用户，匿名请求返回空，对应user_id为空的匿名会话
+func chatOwner(ctx context.Context) string {
+	if tenant := usage.TenantFromContext(ctx); tenant != usage.AnonymousTenant {
+		return tenant
+	}
+	return ""
+}
+
+// SearchChats 在调用者的聊天记录中搜索消息内容
+// 查询按空白拆分为关键词，消息需包含全部关键词；匿名请求只搜索匿名创建的会话
+func (s *ChatService) SearchChats(ctx context.Context, query string, offset, limit int) ([]*ChatSearchHit, int64, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, 0, errors.New("search query cannot be empty")
+	}
+
+	repo := s.repo.WithContext(ctx)
+	messages, total, err := repo.SearchMessages(terms, chatOwner(ctx), offset, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to search chat messages")
+		return nil, 0, fmt.Errorf("failed to search chat messages: %w", err)
+	}
+
+	sessions := make(map[string]*models.ChatSession)
+	hits := make([]*ChatSearchHit, 0, len(messages))
+	for _, msg := range messages {
+		session, ok := sessions[msg.SessionID]
+		if !ok {
+			if session, err = repo.GetSession(msg.SessionID); err != nil {
+				continue
+			}
+			sessions[msg.SessionID] = session
+		}
+		hits = append(hits, &ChatSearchHit{
+			Session:   session,
+			Message:   msg,
+			Highlight: highlight(msg.Content, terms),
+		})
+	}
+	return hits, total, nil
+}
+
+// searchTerms 拆分搜索关键词，去掉重复的关键词，最多保留maxSearchTerms个
+func searchTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// highlight 截取第一个匹配前后的片段，并用<mark>标记其中的关键词，匹配不区分大小写
+func highlight(content string, terms []string) string {
+	runes := []rune(content)
+	lower := []rune(strings.ToLower(content))
+
+	// 记录每个匹配的区间，按字符位置计算
+	type span struct{ start, end int }
+	var spans []span
+	for _, term := range terms {
+		needle := []rune(strings.ToLower(term))
+		if len(needle) == 0 || len(lower) != len(runes) {
+			continue
+		}
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if string(lower[i:i+len(needle)]) == string(needle) {
+				spans = append(spans, span{i, i + len(needle)})
+				i += len(needle) - 1
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	from, to := 0, len(runes)
+	if len(spans) > 0 {
+		from = max(spans[0].start-snippetRunes, 0)
+		to = min(spans[0].end+snippetRunes, len(runes))
+	} else if len(runes) > 2*snippetRunes {
+		to = 2 * snippetRunes
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("...")
+	}
+	pos := from
+	for _, sp := range spans {
+		if sp.start < pos || sp.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:sp.start])))
+		b.WriteString("<mark>" + html.EscapeString(string(runes[sp.start:sp.end])) + "</mark>")
+		pos = sp.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("...")
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChatService_SearchChats 测试在调用者的聊天记录中搜索消息
+func TestChatService_SearchChats(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	alice := usage.WithTenant(context.Background(), "alice")
+	bob := usage.WithTenant(context.Background(), "bob")
+
+	aliceChat, err := chatService.CreateChat(alice, "合同问答")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", aliceChat.UserID)
+	bobChat, err := chatService.CreateChat(bob, "报销问答")
+	require.NoError(t, err)
+
+	add := func(sessionID string, role models.MessageRole, content string) {
+		require.NoError(t, chatService.AddMessage(context.Background(), &models.ChatMessage{SessionID: sessionID, Role: role, Content: content}))
+	}
+	add(aliceChat.ID, models.RoleUser, "采购合同的付款期限是多久？")
+	add(aliceChat.ID, models.RoleAssistant, "根据<采购合同>，付款期限为30天。")
+	add(aliceChat.ID, models.RoleUser, "折扣是100%吗")
+	add(bobChat.ID, models.RoleUser, "差旅报销的付款期限是多久？")
+
+	hits, total, err := chatService.SearchChats(alice, "付款期限", 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	require.Len(t, hits, 2)
+	for _, hit := range hits {
+		assert.Equal(t, aliceChat.ID, hit.Session.ID)
+	}
+	assert.Contains(t, hits[0].Highlight+hits[1].Highlight, "根据&lt;采购合同&gt;，<mark>付款期限</mark>为30天。")
+
+	// 多个关键词需全部匹配
+	hits, _, err = chatService.SearchChats(alice, "采购 30天", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	assert.Equal(t, models.RoleAssistant, hits[0].Message.Role)
+
+	// 通配符按字面匹配
+	hits, _, err = chatService.SearchChats(alice, "100%", 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+	hits, _, err = chatService.SearchChats(alice, "%", 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, hits, 1)
+
+	// 匿名请求只搜索匿名创建的会话
+	_, total, err = chatService.SearchChats(context.Background(), "付款期限", 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	anonChat, err := chatService.CreateChat(context.Background(), "匿名问答")
+	require.NoError(t, err)
+	assert.Empty(t, anonChat.UserID)
+	add(anonChat.ID, models.RoleUser, "付款期限可以延长吗？")
+	hits, total, err = chatService.SearchChats(context.Background(), "付款期限", 0, 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	require.Len(t, hits, 1)
+	assert.Equal(t, anonChat.ID, hits[0].Session.ID)
+
+	_, _, err = chatService.SearchChats(alice, "  ", 0, 10)
+	assert.Error(t, err)
+}
+
+// TestHighlight 测试搜索结果片段的截取和高亮
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "Use the <mark>API</mark> key", highlight("Use the API key", []string{"api"}))
+
+	long := ""
+	for i := 0; i < 60; i++ {
+		long += "一"
+	}
+	got := highlight(long+"关键词"+long, []string{"关键词"})
+	assert.Equal(t, "..."+long[:len("一")*snippetRunes]+"<mark>关键词</mark>"+long[:len("一")*snippetRunes]+"...", got)
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxTitleRunes 生成标题的最大字符数
+	maxTitleRunes = 30
+	// titleSampleRunes 生成标题时问题和回答各取的最大字符数
+	titleSampleRunes = 500
+	// summaryMessageLimit 一次摘要最多合并的消息数，只取最近的消息
+	summaryMessageLimit = 100
+	// summaryMessageRunes 摘要时每条消息取的最大字符数
+	summaryMessageRunes = 800
+)
+
+// ErrChatLLMDisabled 未配置大模型时无法生成标题和摘要
+var ErrChatLLMDisabled = errors.New("chat title and summary generation is not configured")
+
+// chatTitlePrompt 生成会话标题的提示词模板
+const chatTitlePrompt = `请根据下面的第一轮对话，为这次会话生成一个简洁的标题。
+要求：不超过15个字，概括用户想了解的主题；只输出标题，不要加引号和标点。
+
+用户：%s
+助手：%s`
+
+// chatSummaryPrompt 生成会话摘要的提示词模板
+const chatSummaryPrompt = `请把下面的对话整理成一段摘要，用于后续回答用户追问时理解上下文。
+要求：
+1. 保留用户关心的对象（文档、条款、产品、人物等）、已经得到的结论和尚未解决的问题
+2. 不超过300字，使用陈述句，不要逐条复述对话
+3. 只输出摘要内容
+%s
+对话：
+%s`
+
+// WithChatLLM 设置用于生成会话标题和摘要的大模型客户端
+func WithChatLLM(client llm.Client) ChatOption {
+	return func(s *ChatService) {
+		s.llm = client
+	}
+}
+
+// WithChatContext 返回带有会话系统提示词和对话记忆（会话摘要）的上下文
+// 两者都会改变回答，设置任一项时不读取按问题缓存的回答
+func WithChatContext(ctx context.Context, session *models.ChatSession) context.Context {
+	if session == nil || (strings.TrimSpace(session.Summary) == "" && strings.TrimSpace(session.SystemPrompt) == "") {
+		return ctx
+	}
+	ctx = llm.WithConversationMemory(ctx, session.Summary)
+	ctx = llm.WithSystemPrompt(ctx, session.SystemPrompt)
+	return WithFreshAnswer(ctx)
+}
+
+// GenerateTitle 根据第一轮对话生成会话标题并保存
+func (s *ChatService) GenerateTitle(ctx context.Context, sessionID, question, answer string) (string, error) {
+	if s.llm == nil {
+		return "", ErrChatLLMDisabled
+	}
+
+	response, err := s.llm.Generate(ctx, fmt.Sprintf(chatTitlePrompt,
+		truncateRunes(question, titleSampleRunes), truncateRunes(answer, titleSampleRunes)),
+		llm.WithGenerateMaxTokens(50),
+		llm.WithGenerateTemperature(0.3),
+		llm.WithGenerateCacheable())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate chat title: %w", err)
+	}
+
+	title := cleanTitle(response.Text)
+	if title == "" {
+		return "", errors.New("generated chat title is empty")
+	}
+	if err := s.RenameChatSession(ctx, sessionID, title); err != nil {
+		return "", err
+	}
+	return title, nil
+}
+
+// SummarizeChat 把会话整理成摘要并保存
+// 已有摘要时只合并摘要之后的新消息；没有新消息时直接返回会话
+func (s *ChatService) SummarizeChat(ctx context.Context, sessionID string) (*models.ChatSession, error) {
+	if s.llm == nil {
+		return nil, ErrChatLLMDisabled
+	}
+
+	repo := s.repo.WithContext(ctx)
+	session, err := repo.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat session: %w", err)
+	}
+	messages, err := repo.GetMessagesAfter(sessionID, session.SummaryMessageID, summaryMessageLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return session, nil
+	}
+
+	var transcript strings.Builder
+	for _, msg := range messages {
+		role := "用户"
+		switch msg.Role {
+		case models.RoleAssistant:
+			role = "助手"
+		case models.RoleSystem:
+			role = "系统"
+		}
+		fmt.Fprintf(&transcript, "%s：%s\n", role, truncateRunes(msg.Content, summaryMessageRunes))
+	}
+	previous := ""
+	if session.Summary != "" {
+		previous = "\n已有摘要（请与新对话合并）：\n" + session.Summary + "\n"
+	}
+
+	response, err := s.llm.Generate(ctx, fmt.Sprintf(chatSummaryPrompt, previous, transcript.String()),
+		llm.WithGenerateMaxTokens(600),
+		llm.WithGenerateTemperature(0.3),
+		llm.WithGenerateCacheable())
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize chat: %w", err)
+	}
+	summary := strings.TrimSpace(response.Text)
+	if summary == "" {
+		return nil, errors.New("generated chat summary is empty")
+	}
+
+	now := time.Now()
+	session.Summary = summary
+	session.SummaryMessageID = messages[len(messages)-1].ID
+	session.SummarizedAt = &now
+	if err := repo.UpdateSession(session); err != nil {
+		return nil, fmt.Errorf("failed to save chat summary: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"session_id": sessionID,
+		"messages":   len(messages),
+	}).Info("Chat summarized")
+	return session, nil
+}
+
+// cleanTitle 清理大模型生成的标题，只取第一行，去掉引号、书名号和"标题："前缀
+func cleanTitle(text string) string {
+	title := strings.TrimSpace(text)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = title[:i]
+	}
+	title = strings.TrimPrefix(strings.TrimPrefix(title, "标题："), "标题:")
+	title = strings.Trim(title, " \"'“”‘’《》「」【】。.")
+	return truncateRunes(title, maxTitleRunes)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChatService_GenerateTitle 测试根据第一轮对话生成会话标题
+func TestChatService_GenerateTitle(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := chatService.CreateChat(ctx, "")
+	require.NoError(t, err)
+
+	_, err = chatService.GenerateTitle(ctx, session.ID, "付款期限是多久？", "30天")
+	assert.ErrorIs(t, err, ErrChatLLMDisabled)
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "用户：付款期限是多久？") && strings.Contains(prompt, "助手：30天")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "标题：《合同付款期限》\n说明：……"}, nil).Once()
+	WithChatLLM(llmClient)(chatService)
+
+	title, err := chatService.GenerateTitle(ctx, session.ID, "付款期限是多久？", "30天")
+	require.NoError(t, err)
+	assert.Equal(t, "合同付款期限", title)
+
+	stored, err := chatService.GetChatSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "合同付款期限", stored.Title)
+}
+
+// TestChatService_SummarizeChat 测试生成会话摘要，并在之后只合并新消息
+func TestChatService_SummarizeChat(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := chatService.CreateChat(ctx, "合同问答")
+	require.NoError(t, err)
+	add := func(role models.MessageRole, content string) {
+		require.NoError(t, chatService.AddMessage(ctx, &models.ChatMessage{SessionID: session.ID, Role: role, Content: content}))
+	}
+	add(models.RoleUser, "采购合同的付款期限是多久？")
+	add(models.RoleAssistant, "30天")
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "用户：采购合同的付款期限是多久？") && !strings.Contains(prompt, "已有摘要")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "用户在询问采购合同，付款期限为30天。"}, nil).Once()
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "已有摘要") &&
+				strings.Contains(prompt, "用户：可以延期吗？") &&
+				!strings.Contains(prompt, "用户：采购合同的付款期限是多久？")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "用户在询问采购合同，付款期限为30天，可延期一次。"}, nil).Once()
+	WithChatLLM(llmClient)(chatService)
+
+	summarized, err := chatService.SummarizeChat(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "用户在询问采购合同，付款期限为30天。", summarized.Summary)
+	assert.NotNil(t, summarized.SummarizedAt)
+
+	// 没有新消息时不调用大模型
+	_, err = chatService.SummarizeChat(ctx, session.ID)
+	require.NoError(t, err)
+
+	add(models.RoleUser, "可以延期吗？")
+	add(models.RoleAssistant, "可以延期一次")
+	summarized, err = chatService.SummarizeChat(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "用户在询问采购合同，付款期限为30天，可延期一次。", summarized.Summary)
+
+	// 摘要作为对话记忆，并跳过按问题缓存的回答
+	memoryCtx := WithChatContext(ctx, summarized)
+	assert.Equal(t, summarized.Summary, llm.ConversationMemoryFromContext(memoryCtx))
+	fresh, _ := memoryCtx.Value(freshAnswerKey{}).(bool)
+	assert.True(t, fresh)
+	assert.Equal(t, ctx, WithChatContext(ctx, &models.ChatSession{}))
+}
+
+// TestChatService_SetSystemPrompt 测试设置会话的系统提示词并注入到问答上下文
+func TestChatService_SetSystemPrompt(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session, err := chatService.CreateChat(ctx, "合同审核")
+	require.NoError(t, err)
+
+	session, err = chatService.SetSystemPrompt(ctx, session.ID, "  你是法务审核助手  ")
+	require.NoError(t, err)
+	assert.Equal(t, "你是法务审核助手", session.SystemPrompt)
+
+	stored, err := chatService.GetChatSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "你是法务审核助手", stored.SystemPrompt)
+
+	chatCtx := WithChatContext(ctx, stored)
+	assert.Equal(t, "你是法务审核助手", llm.SystemPromptFromContext(chatCtx))
+	assert.Empty(t, llm.ConversationMemoryFromContext(chatCtx))
+	fresh, _ := chatCtx.Value(freshAnswerKey{}).(bool)
+	assert.True(t, fresh)
+
+	_, err = chatService.SetSystemPrompt(ctx, session.ID, strings.Repeat("长", models.MaxSystemPromptRunes+1))
+	assert.Error(t, err)
+
+	session, err = chatService.SetSystemPrompt(ctx, session.ID, "")
+	require.NoError(t, err)
+	assert.Empty(t, session.SystemPrompt)
+}
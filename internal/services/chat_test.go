@@ -23,7 +23,7 @@ func setupChatTestEnv(t *testing.T) (*ChatService, func()) {
 	require.NoError(t, err, "Failed to open in-memory database")
 
 	// 运行数据库迁移
-	err = db.AutoMigrate(&models.ChatSession{}, &models.ChatMessage{})
+	err = db.AutoMigrate(&models.ChatSession{}, &models.ChatMessage{}, &models.ChatMessageVersion{})
 	require.NoError(t, err, "Failed to run migrations")
 
 	// 保存原始数据库引用
@@ -369,6 +369,37 @@ func TestChatService_RenameChatSession(t *testing.T) {
 	assert.Equal(t, newTitle, updatedSession.Title, "Session title should be updated")
 }
 
+// TestChatService_SetChatScope 测试绑定和解除会话的文档范围
+func TestChatService_SetChatScope(t *testing.T) {
+	chatService, cleanup := setupChatTestEnv(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	session, err := chatService.CreateChat(ctx, "合同问答")
+	require.NoError(t, err)
+	assert.False(t, session.Scoped())
+
+	// 去掉空白和重复的ID
+	_, err = chatService.SetChatScope(ctx, session.ID, []string{"contract", " amendment-1", "contract", ""}, []string{"group-1"})
+	require.NoError(t, err)
+
+	stored, err := chatService.GetChatSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Scoped())
+	assert.Equal(t, []string{"contract", "amendment-1"}, stored.ScopeDocumentIDs())
+	assert.Equal(t, []string{"group-1"}, stored.ScopeGroupIDs())
+
+	// 两者都为空时解除绑定
+	stored, err = chatService.SetChatScope(ctx, session.ID, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, stored.Scoped())
+	assert.Empty(t, stored.ScopeDocumentIDs())
+
+	_, err = chatService.SetChatScope(ctx, "missing", []string{"contract"}, nil)
+	assert.Error(t, err)
+}
+
 func TestChatService_GetChatsWithMessageCount(t *testing.T) {
 	chatService, cleanup := setupChatTestEnv(t)
 	defer cleanup()
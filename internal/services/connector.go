@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/connector"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrConnectorNotFound 连接器未配置
+	ErrConnectorNotFound = errors.New("connector not found")
+	// ErrConnectorBusy 连接器正在同步
+	ErrConnectorBusy = errors.New("connector sync already running")
+)
+
+// ConnectorMapping 外部来源同步到文档库的方式
+type ConnectorMapping struct {
+	GroupID string // 新建的文档加入的文档组，为空时不加入
+	Tags    string // 新建的文档使用的标签
+}
+
+// ConnectorSyncResult 一次同步的结果
+type ConnectorSyncResult struct {
+	Listed    int  // 来源报告变化的条目数
+	Created   int  // 新建的文档数
+	Updated   int  // 内容变化后重新索引的文档数
+	Unchanged int  // 版本或内容未变化而跳过的条目数
+	Deleted   int  // 来源中已删除而删除的文档数
+	Skipped   int  // 未通过文件校验的条目数
+	Failed    int  // 下载或处理失败的条目数
+	Full      bool // 是否是全量同步
+}
+
+// ConnectorStatus 连接器的同步状态
+type ConnectorStatus struct {
+	Name       string     // 连接器名称
+	Type       string     // 连接器类型
+	GroupID    string     // 映射的文档组
+	Running    bool       // 是否正在同步
+	Items      int64      // 已同步的条目数
+	LastSyncAt *time.Time // 最近一次同步完成的时间
+	LastError  string     // 最近一次同步的错误信息
+}
+
+// registeredConnector 已注册的连接器
+type registeredConnector struct {
+	connector connector.Connector
+	mapping   ConnectorMapping
+}
+
+// ConnectorService 外部来源同步服务
+// 按游标从连接器增量列出变化的条目，下载内容后创建或替换对应的文档，
+// 并删除来源中已删除的条目对应的文档，使文档库与外部来源保持一致
+type ConnectorService struct {
+	docs   *DocumentService               // 文档服务，负责存储和处理内容
+	repo   repository.ConnectorRepository // 同步状态仓储
+	groups *GroupService                  // 文档组服务，为空时忽略映射的文档组
+	logger *logrus.Logger                 // 日志记录器
+
+	mu         sync.Mutex
+	connectors map[string]*registeredConnector
+	running    map[string]bool
+}
+
+// ConnectorOption 连接器服务配置选项
+type ConnectorOption func(*ConnectorService)
+
+// NewConnectorService 创建外部来源同步服务实例
+func NewConnectorService(docs *DocumentService, repo repository.ConnectorRepository, opts ...ConnectorOption) *ConnectorService {
+	service := &ConnectorService{
+		docs:       docs,
+		repo:       repo,
+		logger:     logrus.New(),
+		connectors: make(map[string]*registeredConnector),
+		running:    make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
+}
+
+// WithConnectorLogger 设置日志记录器
+func WithConnectorLogger(logger *logrus.Logger) ConnectorOption {
+	return func(s *ConnectorService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithConnectorGroups 设置文档组服务，用于把新建的文档加入映射的文档组
+func WithConnectorGroups(groups *GroupService) ConnectorOption {
+	return func(s *ConnectorService) {
+		s.groups = groups
+	}
+}
+
+// Register 注册连接器，名称重复时覆盖
+func (s *ConnectorService) Register(name string, conn connector.Connector, mapping ConnectorMapping) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connectors[name] = &registeredConnector{connector: conn, mapping: mapping}
+}
+
+// Names 返回已注册的连接器名称
+func (s *ConnectorService) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.connectors))
+	for name := range s.connectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Statuses 返回所有连接器的同步状态
+func (s *ConnectorService) Statuses(ctx context.Context) ([]ConnectorStatus, error) {
+	repo := s.repo.WithContext(ctx)
+	statuses := make([]ConnectorStatus, 0)
+	for _, name := range s.Names() {
+		s.mu.Lock()
+		reg := s.connectors[name]
+		running := s.running[name]
+		s.mu.Unlock()
+
+		status := ConnectorStatus{
+			Name:    name,
+			Type:    reg.connector.Type(),
+			GroupID: reg.mapping.GroupID,
+			Running: running,
+		}
+		state, err := repo.GetState(name)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			status.LastSyncAt = state.LastSyncAt
+			status.LastError = state.LastError
+		}
+		if status.Items, err = repo.CountItems(name); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Start 标记连接器开始同步，用于在后台执行同步前检查连接器是否存在和是否正在同步
+// 成功时调用方必须调用返回的函数释放标记
+func (s *ConnectorService) Start(name string) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.connectors[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConnectorNotFound, name)
+	}
+	if s.running[name] {
+		return nil, fmt.Errorf("%w: %s", ErrConnectorBusy, name)
+	}
+	s.running[name] = true
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.running, name)
+	}, nil
+}
+
+// Sync 同步指定的连接器，full为true时忽略游标全量同步
+func (s *ConnectorService) Sync(ctx context.Context, name string, full bool) (*ConnectorSyncResult, error) {
+	release, err := s.Start(name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.SyncStarted(ctx, name, full)
+}
+
+// SyncConnector 增量同步连接器，供定时任务调用
+func (s *ConnectorService) SyncConnector(ctx context.Context, name string) (int, int, int, error) {
+	result, err := s.Sync(ctx, name, false)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return result.Listed, result.Created + result.Updated, result.Failed, nil
+}
+
+// SyncStarted 同步已通过Start标记的连接器
+// 有条目失败时不推进游标，下次同步重新列出这些条目
+func (s *ConnectorService) SyncStarted(ctx context.Context, name string, full bool) (*ConnectorSyncResult, error) {
+	s.mu.Lock()
+	reg, ok := s.connectors[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrConnectorNotFound, name)
+	}
+
+	repo := s.repo.WithContext(ctx)
+	state, err := repo.GetState(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load connector state: %w", err)
+	}
+	if state == nil {
+		state = &models.ConnectorState{Name: name}
+	}
+	cursor := state.Cursor
+	if full {
+		cursor = ""
+	}
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{"connector": name, "type": reg.connector.Type()})
+
+	changes, err := reg.connector.Changes(ctx, cursor)
+	if err != nil {
+		s.saveState(ctx, state, fmt.Sprintf("failed to list changes: %v", err))
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	knownItems, err := repo.ListItems(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list synced items: %w", err)
+	}
+	known := make(map[string]*models.ConnectorItem, len(knownItems))
+	for _, item := range knownItems {
+		known[item.ItemID] = item
+	}
+
+	result := &ConnectorSyncResult{Listed: len(changes.Items), Full: changes.Full}
+	seen := make(map[string]bool, len(changes.Items))
+	for _, item := range changes.Items {
+		if err := ctx.Err(); err != nil {
+			s.saveState(ctx, state, err.Error())
+			return result, err
+		}
+		seen[item.ID] = true
+		s.syncItem(ctx, name, reg, item, known[item.ID], result)
+	}
+
+	// 删除来源中已删除的条目，全量同步时没有出现的条目也视为已删除
+	removed := changes.Deleted
+	if changes.Full {
+		for id := range known {
+			if !seen[id] {
+				removed = append(removed, id)
+			}
+		}
+	}
+	for _, id := range removed {
+		item, ok := known[id]
+		if !ok {
+			continue
+		}
+		delete(known, id)
+		if err := s.removeItem(ctx, item); err != nil {
+			logger.WithError(err).WithField("item_id", id).Warn("Failed to delete document of removed item")
+			result.Failed++
+			continue
+		}
+		result.Deleted++
+	}
+
+	// 有失败的条目时不推进游标，下次同步重新列出失败的条目；全量同步失败时下次仍全量同步
+	lastError := ""
+	switch {
+	case result.Failed == 0:
+		state.Cursor = changes.Cursor
+	case changes.Full:
+		state.Cursor = ""
+	}
+	if result.Failed > 0 {
+		lastError = fmt.Sprintf("%d items failed", result.Failed)
+	}
+	s.saveState(ctx, state, lastError)
+
+	logger.WithFields(logrus.Fields{
+		"full":      result.Full,
+		"listed":    result.Listed,
+		"created":   result.Created,
+		"updated":   result.Updated,
+		"unchanged": result.Unchanged,
+		"deleted":   result.Deleted,
+		"skipped":   result.Skipped,
+		"failed":    result.Failed,
+	}).Info("Connector sync finished")
+	return result, nil
+}
+
+// syncItem 同步单个条目，结果累计到result中
+// 已同步过且版本未变化的条目直接跳过；对应的文档已被删除时重新创建
+func (s *ConnectorService) syncItem(ctx context.Context, name string, reg *registeredConnector, item connector.Item, known *models.ConnectorItem, result *ConnectorSyncResult) {
+	logger := s.logger.WithContext(ctx).WithFields(logrus.Fields{"connector": name, "item_id": item.ID})
+	if known != nil && !s.documentExists(known.DocumentID) {
+		known = nil
+	}
+	if known != nil && item.Version != "" && known.Version == item.Version {
+		result.Unchanged++
+		return
+	}
+
+	content, err := reg.connector.Fetch(ctx, item)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to fetch connector item")
+		result.Failed++
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"connector":         name,
+		"connector_type":    reg.connector.Type(),
+		"connector_item":    item.ID,
+		"connector_version": item.Version,
+	}
+	if item.URL != "" {
+		metadata["connector_url"] = item.URL
+	}
+	if item.Title != "" {
+		metadata["title"] = item.Title
+	}
+
+	var documentID string
+	if known != nil {
+		documentID = known.DocumentID
+		changed, err := s.docs.ReplaceContent(ctx, documentID, content.FileName, content.Data, metadata)
+		switch {
+		case errors.Is(err, ErrContentRejected):
+			logger.WithError(err).Info("Skipping connector item")
+			result.Skipped++
+			return
+		case err != nil:
+			logger.WithError(err).Warn("Failed to update document from connector item")
+			result.Failed++
+			return
+		case changed:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	} else {
+		id, path, err := s.docs.IngestContent(ctx, content.FileName, content.Data, metadata, reg.mapping.Tags)
+		if errors.Is(err, ErrContentRejected) {
+			logger.WithError(err).Info("Skipping connector item")
+			result.Skipped++
+			return
+		}
+		if err != nil {
+			logger.WithError(err).Warn("Failed to create document from connector item")
+			result.Failed++
+			return
+		}
+		if err := s.docs.ProcessDocument(ctx, id, path); err != nil {
+			// 删除处理失败的文档，下次同步重新创建
+			logger.WithError(err).WithField("file_id", id).Warn("Failed to process document from connector item")
+			if err := s.docs.DeleteDocument(ctx, id); err != nil {
+				logger.WithError(err).WithField("file_id", id).Warn("Failed to delete unprocessed document")
+			}
+			result.Failed++
+			return
+		}
+		if s.groups != nil && reg.mapping.GroupID != "" {
+			if _, err := s.groups.AddMember(ctx, reg.mapping.GroupID, GroupMemberInput{DocumentID: id}); err != nil {
+				logger.WithError(err).WithField("group_id", reg.mapping.GroupID).Warn("Failed to add document to group")
+			}
+		}
+		documentID = id
+		result.Created++
+	}
+
+	if err := s.repo.WithContext(ctx).SaveItem(&models.ConnectorItem{
+		Connector:  name,
+		ItemID:     item.ID,
+		DocumentID: documentID,
+		Version:    item.Version,
+		SyncedAt:   time.Now(),
+	}); err != nil {
+		logger.WithError(err).Warn("Failed to save connector item")
+	}
+}
+
+// removeItem 删除条目对应的文档和同步记录
+func (s *ConnectorService) removeItem(ctx context.Context, item *models.ConnectorItem) error {
+	if s.documentExists(item.DocumentID) {
+		if err := s.docs.DeleteDocument(ctx, item.DocumentID); err != nil {
+			return err
+		}
+	}
+	return s.repo.WithContext(ctx).DeleteItem(item.Connector, item.ItemID)
+}
+
+// documentExists 判断文档是否存在
+func (s *ConnectorService) documentExists(fileID string) bool {
+	if err := s.docs.Init(); err != nil {
+		return false
+	}
+	doc, err := s.docs.repo.GetByID(fileID)
+	return err == nil && doc != nil
+}
+
+// saveState 保存同步状态和最近一次同步的结果
+func (s *ConnectorService) saveState(ctx context.Context, state *models.ConnectorState, lastError string) {
+	now := time.Now()
+	state.LastSyncAt = &now
+	state.LastError = lastError
+	if err := s.repo.WithContext(context.WithoutCancel(ctx)).SaveState(state); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("connector", state.Name).Warn("Failed to save connector state")
+	}
+}
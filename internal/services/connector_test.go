@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/connector"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConnector 返回预设变化的连接器
+type fakeConnector struct {
+	changes  *connector.Changes
+	contents map[string]string
+	cursors  []string // 每次列举时收到的游标
+	fetched  []string // 下载过的条目ID
+}
+
+func (f *fakeConnector) Type() string { return "fake" }
+
+func (f *fakeConnector) Changes(ctx context.Context, cursor string) (*connector.Changes, error) {
+	f.cursors = append(f.cursors, cursor)
+	return f.changes, nil
+}
+
+func (f *fakeConnector) Fetch(ctx context.Context, item connector.Item) (*connector.Content, error) {
+	f.fetched = append(f.fetched, item.ID)
+	content, ok := f.contents[item.ID]
+	if !ok {
+		return nil, errors.New("fetch failed")
+	}
+	return &connector.Content{FileName: item.Title + ".md", Data: []byte(content)}, nil
+}
+
+// TestConnectorSync 测试连接器同步创建、跳过、更新和删除文档，以及失败时保留游标
+func TestConnectorSync(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-connector-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, statusManager := setupDocumentTestEnv(t, tempDir)
+	docService.splitter = lineSplitter{}
+	require.NoError(t, database.DB.AutoMigrate(
+		&models.ConnectorState{}, &models.ConnectorItem{},
+		&models.DocumentGroup{}, &models.DocumentGroupMember{},
+	))
+	ctx := context.Background()
+
+	groups := NewGroupService(repository.NewDocumentGroupRepository(), repository.NewDocumentRepository())
+	group, err := groups.CreateGroup(ctx, "Wiki", "", nil)
+	require.NoError(t, err)
+
+	fake := &fakeConnector{
+		changes: &connector.Changes{
+			Items: []connector.Item{
+				{ID: "p1", Title: "Setup", Version: "1", URL: "https://wiki/p1"},
+				{ID: "p2", Title: "FAQ", Version: "1"},
+			},
+			Cursor: "c1",
+			Full:   true,
+		},
+		contents: map[string]string{"p1": "# Setup\n安装步骤", "p2": "# FAQ\n常见问题"},
+	}
+	service := NewConnectorService(docService, repository.NewConnectorRepository(), WithConnectorGroups(groups))
+	service.Register("wiki", fake, ConnectorMapping{GroupID: group.ID, Tags: "wiki"})
+
+	_, err = service.Sync(ctx, "missing", false)
+	assert.ErrorIs(t, err, ErrConnectorNotFound)
+
+	// 首次同步创建文档并加入文档组
+	result, err := service.Sync(ctx, "wiki", false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	items, err := service.repo.ListItems("wiki")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	setupID, faqID := items[0].DocumentID, items[1].DocumentID
+
+	doc, err := statusManager.GetDocument(ctx, setupID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+	assert.Equal(t, "wiki", doc.Tags)
+	metadata := docService.documentMetadata(setupID)
+	assert.Equal(t, "wiki", metadata["connector"])
+	assert.Equal(t, "https://wiki/p1", metadata["connector_url"])
+	assert.Nil(t, metadata["source_url"], "connector documents must not be recrawled")
+	group, err = groups.GetGroup(ctx, group.ID)
+	require.NoError(t, err)
+	assert.Len(t, group.Members, 2)
+
+	// 增量同步：版本未变化的条目不下载，内容变化的条目原地更新
+	fake.changes = &connector.Changes{
+		Items: []connector.Item{
+			{ID: "p1", Title: "Setup", Version: "1"},
+			{ID: "p2", Title: "FAQ", Version: "2"},
+		},
+		Cursor: "c2",
+	}
+	fake.contents["p2"] = "# FAQ\n更新后的常见问题"
+	fake.fetched = nil
+	result, err = service.Sync(ctx, "wiki", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, []string{"p2"}, fake.fetched)
+	assert.Equal(t, "c1", fake.cursors[len(fake.cursors)-1])
+	doc, err = statusManager.GetDocument(ctx, faqID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+
+	// 下载失败时不推进游标
+	fake.changes = &connector.Changes{
+		Items:   []connector.Item{{ID: "p3", Title: "Broken", Version: "1"}},
+		Deleted: []string{"p2"},
+		Cursor:  "c3",
+	}
+	result, err = service.Sync(ctx, "wiki", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 1, result.Deleted)
+	_, err = statusManager.GetDocument(ctx, faqID)
+	assert.Error(t, err, "deleted item's document should be removed")
+
+	statuses, err := service.Statuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, int64(1), statuses[0].Items)
+	assert.NotEmpty(t, statuses[0].LastError)
+
+	// 全量同步时没有出现的条目视为已删除
+	fake.changes = &connector.Changes{Cursor: "c4", Full: true}
+	result, err = service.Sync(ctx, "wiki", true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+	assert.Equal(t, []string{"", "c1", "c2", ""}, fake.cursors)
+	count, err := service.repo.CountItems("wiki")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	state, err := service.repo.GetState("wiki")
+	require.NoError(t, err)
+	assert.Equal(t, "c4", state.Cursor)
+	assert.Empty(t, state.LastError)
+}
+
+// TestConnectorSyncBusy 测试同一连接器不能并发同步
+func TestConnectorSyncBusy(t *testing.T) {
+	service := NewConnectorService(nil, nil)
+	service.Register("wiki", &fakeConnector{}, ConnectorMapping{})
+
+	release, err := service.Start("wiki")
+	require.NoError(t, err)
+	_, err = service.Start("wiki")
+	assert.ErrorIs(t, err, ErrConnectorBusy)
+	release()
+
+	release, err = service.Start("wiki")
+	require.NoError(t, err)
+	release()
+}
@@ -4,16 +4,23 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
 	"github.com/fyerfyer/doc-QA-system/internal/document"
 	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
 	"github.com/fyerfyer/doc-QA-system/pkg/storage"
 	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
 	"github.com/google/uuid"
@@ -23,20 +30,36 @@ import (
 // DocumentService 文档服务
 // 负责协调文档解析、分段、嵌入和存储
 type DocumentService struct {
-	storage       storage.Storage               // 文件存储服务
-	parser        document.Parser               // 文档解析器
-	splitter      document.Splitter             // 文本分段器
-	embedder      embedding.Client              // 嵌入模型客户端
-	vectorDB      vectordb.Repository           // 向量数据库
-	repo          repository.DocumentRepository // 文档元数据存储
-	statusManager *DocumentStatusManager        // 文档状态管理器
-	taskQueue     taskqueue.Queue               // 任务队列
-	asyncEnabled  bool                          // 是否启用异步处理
-	batchSize     int                           // 批处理大小
-	timeout       time.Duration                 // 处理超时时间
-	logger        *logrus.Logger                // 日志记录器
-	pythonClient  *pyprovider.DocumentClient    // Python文档解析客户端
-	usePythonAPI  bool                          // 是否使用Python API
+	storage          storage.Storage                    // 文件存储服务
+	parser           document.Parser                    // 文档解析器
+	splitter         document.Splitter                  // 文本分段器
+	embedder         embedding.Client                   // 嵌入模型客户端
+	vectorDB         vectordb.Repository                // 向量数据库
+	repo             repository.DocumentRepository      // 文档元数据存储
+	statusManager    *DocumentStatusManager             // 文档状态管理器
+	taskQueue        taskqueue.Queue                    // 任务队列
+	asyncEnabled     bool                               // 是否启用异步处理
+	nativeWorker     bool                               // 异步任务是否由Go worker处理，否则交给Python服务
+	batchSize        int                                // 批处理大小
+	timeout          time.Duration                      // 处理超时时间
+	logger           *logrus.Logger                     // 日志记录器
+	pythonClient     *pyprovider.DocumentClient         // Python文档解析客户端
+	pyService        pyprovider.Client                  // Python服务客户端，用于投递异步处理和清理任务
+	usePythonAPI     bool                               // 是否使用Python API
+	httpClient       *http.Client                       // 抓取网页使用的HTTP客户端
+	groupRepo        repository.DocumentGroupRepository // 文档组仓储，删除文档时移除其成员关系
+	audit            *audit.Recorder                    // 审计日志记录器，为空时不记录
+	tagLLM           llm.Client                         // 建议标签使用的大模型客户端，为空时不支持建议标签
+	summaryLLM       llm.Client                         // 生成文档摘要使用的大模型客户端，为空时不支持摘要
+	visionLLM        llm.Client                         // 生成图片描述使用的视觉模型客户端，为空时不提取图片
+	imageMinBytes    int                                // 提取图片的最小字节数，更小的图片视为装饰
+	maxImages        int                                // 单个文档最多提取的图片数量
+	fileValidator    *filecheck.Validator               // 校验压缩包和邮件中展开的文件
+	gcMinAge         time.Duration                      // 定时垃圾回收中孤立文件的最短存在时间
+	embedParallelism int                                // 处理单个文档时同时进行的嵌入请求数
+	dedup            *duplicateDetector                 // 近似重复段落检测，为空时不检测
+	cleaner          *document.Cleaner                  // 分块前的文本清洗器，为空时不清洗
+	acl              *acl.Policy                        // 文档访问控制策略，为空时不检查
 }
 
 // DocumentOption 文档服务配置选项
@@ -53,16 +76,21 @@ func NewDocumentService(
 ) *DocumentService {
 	// 创建服务实例
 	srv := &DocumentService{
-		storage:      storage,
-		parser:       parser,
-		splitter:     splitter,
-		embedder:     embedder,
-		vectorDB:     vectorDB,
-		batchSize:    16,              // 默认批处理大小
-		timeout:      time.Minute * 5, // 默认超时时间
-		logger:       logrus.New(),    // 默认日志记录器
-		asyncEnabled: false,           // 默认不启用异步处理
-		usePythonAPI: false,           // 默认不使用Python API
+		storage:          storage,
+		parser:           parser,
+		splitter:         splitter,
+		embedder:         embedder,
+		vectorDB:         vectorDB,
+		batchSize:        16, // 默认批处理大小
+		embedParallelism: 1,  // 默认串行向量化
+		imageMinBytes:    document.DefaultMinImageBytes,
+		maxImages:        DefaultMaxDocumentImages,
+		fileValidator:    filecheck.New(),
+		timeout:          time.Minute * 5, // 默认超时时间
+		logger:           logrus.New(),    // 默认日志记录器
+		asyncEnabled:     false,           // 默认不启用异步处理
+		usePythonAPI:     false,           // 默认不使用Python API
+		httpClient:       newURLFetchClient(30 * time.Second),
 	}
 
 	// 应用配置选项
@@ -70,6 +98,15 @@ func NewDocumentService(
 		opt(srv)
 	}
 
+	// 未配置Python服务时使用默认配置，兼容PYTHONSERVICE_URL环境变量
+	if srv.pyService == nil {
+		config := pyprovider.DefaultConfig()
+		if url := os.Getenv("PYTHONSERVICE_URL"); url != "" {
+			config.WithBaseURL(strings.TrimRight(url, "/") + "/api")
+		}
+		srv.pyService, _ = pyprovider.NewClient(config)
+	}
+
 	return srv
 }
 
@@ -127,6 +164,15 @@ func WithAsyncProcessing(enabled bool) DocumentOption {
 	}
 }
 
+// WithNativeWorker 设置异步处理是否由Go worker完成
+// 启用后文档处理任务投递到任务队列，由DocumentTaskHandler在Go进程内解析、分块、向量化和存储，
+// 不再依赖Python服务
+func WithNativeWorker(enabled bool) DocumentOption {
+	return func(s *DocumentService) {
+		s.nativeWorker = enabled
+	}
+}
+
 // WithPythonClient 配置Python文档解析客户端
 func WithPythonClient(client *pyprovider.DocumentClient) DocumentOption {
 	return func(s *DocumentService) {
@@ -135,6 +181,15 @@ func WithPythonClient(client *pyprovider.DocumentClient) DocumentOption {
 	}
 }
 
+// WithPythonService 配置Python服务的地址、超时、重试策略和访问令牌
+func WithPythonService(config *pyprovider.PyServiceConfig) DocumentOption {
+	return func(s *DocumentService) {
+		if client, err := pyprovider.NewClient(config); err == nil {
+			s.pyService = client
+		}
+	}
+}
+
 // WithUsePythonAPI 设置是否使用Python API
 func WithUsePythonAPI(enabled bool) DocumentOption {
 	return func(s *DocumentService) {
@@ -142,6 +197,30 @@ func WithUsePythonAPI(enabled bool) DocumentOption {
 	}
 }
 
+// WithHTTPClient 设置抓取网页使用的HTTP客户端
+// 默认客户端拒绝连接内部网络地址，替换后由传入的客户端负责防范SSRF
+func WithHTTPClient(client *http.Client) DocumentOption {
+	return func(s *DocumentService) {
+		if client != nil {
+			s.httpClient = client
+		}
+	}
+}
+
+// WithGroupRepository 设置文档组仓储
+func WithGroupRepository(repo repository.DocumentGroupRepository) DocumentOption {
+	return func(s *DocumentService) {
+		s.groupRepo = repo
+	}
+}
+
+// WithDocumentAudit 设置审计日志记录器，记录删除文档和修改标签的操作
+func WithDocumentAudit(recorder *audit.Recorder) DocumentOption {
+	return func(s *DocumentService) {
+		s.audit = recorder
+	}
+}
+
 // Init 初始化文档服务
 // 确保必要的依赖都已设置
 func (s *DocumentService) Init() error {
@@ -157,16 +236,8 @@ func (s *DocumentService) Init() error {
 
 	// 如果启用了Python API但没有设置客户端，尝试创建默认的Python客户端
 	if s.usePythonAPI && s.pythonClient == nil {
-		s.logger.Info("Attempting to create default Python document client")
-		config := pyprovider.DefaultConfig()
-		baseClient, err := pyprovider.NewClient(config)
-		if err != nil {
-			s.logger.WithError(err).Warn("Failed to create Python client, disabling Python API")
-			s.usePythonAPI = false
-		} else {
-			s.pythonClient = pyprovider.NewDocumentClient(baseClient)
-			s.logger.Info("Successfully created default Python document client")
-		}
+		s.logger.Info("Creating default Python document client")
+		s.pythonClient = pyprovider.NewDocumentClient(s.pyService)
 	}
 
 	return nil
@@ -179,9 +250,14 @@ func (s *DocumentService) processDocumentSync(ctx context.Context, fileID string
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
+	// 压缩包和邮件展开为子文档，本身不建立索引
+	if document.IsContainer(filePath) {
+		return s.processContainer(ctx, fileID, filePath)
+	}
+
 	// 更新文档状态为处理中
 	if err := s.statusManager.MarkAsProcessing(ctx, fileID); err != nil {
-		s.logger.WithError(err).Error("Failed to mark document as processing")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as processing")
 		// 继续处理，不中断
 	}
 
@@ -192,6 +268,9 @@ func (s *DocumentService) processDocumentSync(ctx context.Context, fileID string
 		return fmt.Errorf("failed to parse document: %w", err)
 	}
 
+	// 分块前去掉页码、页眉页脚和模板文本
+	content = s.cleanContent(ctx, fileID, content)
+
 	// 文本分段
 	segments, err := s.splitContent(content)
 	if err != nil {
@@ -199,9 +278,19 @@ func (s *DocumentService) processDocumentSync(ctx context.Context, fileID string
 		return fmt.Errorf("failed to split content: %w", err)
 	}
 
+	// 根据原文中的分页符和标题补充每个段落的页码和章节
+	segments = document.AnnotateStructure(content, segments)
+	segments = document.LinkParents(segments)
+
+	// 提取文档中的图片，图片描述作为额外的段落排在正文之后
+	segments = append(segments, s.extractImages(ctx, fileID, filePath, len(segments))...)
+
+	// 识别文档语言，整篇文档按该语言选择嵌入模型
+	ctx = s.detectLanguage(ctx, fileID, content)
+
 	// 更新进度到20%
 	if err := s.statusManager.UpdateProgress(ctx, fileID, 20); err != nil {
-		s.logger.WithError(err).Warn("Failed to update document progress")
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to update document progress")
 	}
 
 	// 批量处理文本段落
@@ -213,11 +302,12 @@ func (s *DocumentService) processDocumentSync(ctx context.Context, fileID string
 
 	// 文档处理完成，更新状态
 	if err := s.statusManager.MarkAsCompleted(ctx, fileID, len(segments)); err != nil {
-		s.logger.WithError(err).Error("Failed to mark document as completed")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as completed")
 		// 虽然状态更新失败，但文档处理成功，所以不返回错误
 	}
+	s.recordEmbeddingModel(ctx, fileID, s.EmbeddingModel())
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"file_id":       fileID,
 		"segment_count": len(segments),
 	}).Info("Document processing completed successfully")
@@ -252,19 +342,9 @@ func (s *DocumentService) parseDocument(filePath string) (string, error) {
 	// 回退到本地解析逻辑
 	s.logger.Debug("falling back to local parser")
 
-	// 首先尝试从存储获取文件
-	fileID := filepath.Base(filePath)
-	// 移除扩展名
-	fileID = strings.TrimSuffix(fileID, filepath.Ext(fileID))
-
-	// 尝试获取文件
-	reader, err := s.storage.Get(fileID)
+	reader, err := s.openDocumentFile(filePath)
 	if err != nil {
-		// 尝试将整个路径作为ID
-		reader, err = s.storage.Get(filePath)
-		if err != nil {
-			return "", fmt.Errorf("failed to read file: %w", err)
-		}
+		return "", err
 	}
 	defer reader.Close()
 
@@ -288,6 +368,22 @@ func (s *DocumentService) parseDocument(filePath string) (string, error) {
 	return content, nil
 }
 
+// openDocumentFile 从存储中打开文档文件
+// 先按去掉扩展名的文件名作为存储ID获取，失败时把整个路径作为ID
+func (s *DocumentService) openDocumentFile(filePath string) (io.ReadCloser, error) {
+	fileID := filepath.Base(filePath)
+	fileID = strings.TrimSuffix(fileID, filepath.Ext(fileID))
+
+	reader, err := s.storage.Get(fileID)
+	if err != nil {
+		reader, err = s.storage.Get(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	return reader, nil
+}
+
 // parseDocumentWithReader 从reader解析文档
 // 优先使用Python API解析，如果不可用或失败则回退到本地解析
 func (s *DocumentService) parseDocumentWithReader(reader io.Reader, fileName string) (string, error) {
@@ -351,7 +447,9 @@ func (s *DocumentService) parseDocumentWithReader(reader io.Reader, fileName str
 
 // splitContent 使用python API或本地分块器进行文本分块
 func (s *DocumentService) splitContent(content string) ([]document.Content, error) {
-	if s.usePythonAPI && s.pythonClient != nil {
+	// 配置了本地分块器（如本地语义分块）时不经过Python服务
+	pySplitter, ok := s.splitter.(*document.PythonSplitter)
+	if s.usePythonAPI && s.pythonClient != nil && ok {
 		s.logger.Debug("using Python text chunker")
 
 		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
@@ -361,10 +459,15 @@ func (s *DocumentService) splitContent(content string) ([]document.Content, erro
 		tempDocID := fmt.Sprintf("temp_%s", uuid.New().String()[:8])
 
 		options := &pyprovider.SplitOptions{
-			ChunkSize:    s.splitter.(*document.PythonSplitter).GetChunkSize(),
-			ChunkOverlap: s.splitter.(*document.PythonSplitter).GetOverlap(),
-			SplitType:    "sentence", 
-			StoreResult:  false,      // 临时分块不需要存储
+			ChunkSize:    pySplitter.GetChunkSize(),
+			ChunkOverlap: pySplitter.GetOverlap(),
+			SplitType:    document.SplitTypeSentence,
+			StoreResult:  false, // 临时分块不需要存储
+		}
+		// 语义分块需要把分割类型和断点阈值一起传给Python服务
+		if pySplitter.GetSplitType() == document.SplitTypeSemantic {
+			options.SplitType = document.SplitTypeSemantic
+			options.BreakpointThreshold = pySplitter.GetBreakpointThreshold()
 		}
 
 		// 调用python API进行文本分块
@@ -401,74 +504,56 @@ func (s *DocumentService) splitContentLocal(content string) ([]document.Content,
 
 // processBatches 批量处理文本段落
 func (s *DocumentService) processBatches(ctx context.Context, fileID string, filePath string, segments []document.Content) error {
-	// 获取文件名
-	fileName := filepath.Base(filePath)
+	// 文档已有段落时（重新处理）只向量化新增或变化的段落
+	existing, err := s.repo.GetSegments(fileID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to load existing segments")
+	}
+	if len(existing) > 0 {
+		return s.processIncremental(ctx, fileID, filePath, segments, existing)
+	}
 
 	// 检查是否有段落需要处理
 	if len(segments) == 0 {
 		return nil
 	}
 
-	totalBatches := (len(segments) + s.batchSize - 1) / s.batchSize
-	processedBatches := 0
+	// 文档级元数据（如网页来源URL）会写入每个段落
+	docMetadata := s.documentMetadata(fileID)
 
-	// 按批次处理
-	for i := 0; i < len(segments); i += s.batchSize {
-		// 检查上下文是否已取消
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// 继续处理
-		}
+	// 近似重复检测，跳过模式下重复段落不向量化
+	dups := s.detectDuplicates(ctx, fileID, segments)
+	segments = s.suppressDuplicates(ctx, fileID, filePath, segments, dups)
 
-		// 计算当前批次结束位置
+	// 按批次切分段落并提取文本内容
+	var batches [][]document.Content
+	var texts [][]string
+	for i := 0; i < len(segments); i += s.batchSize {
 		end := i + s.batchSize
 		if end > len(segments) {
 			end = len(segments)
 		}
-
-		// 获取当前批次的段落
 		batch := segments[i:end]
-
-		// 提取文本内容
-		texts := make([]string, len(batch))
+		batchTexts := make([]string, len(batch))
 		for j, segment := range batch {
-			texts[j] = segment.Text
+			batchTexts[j] = segment.Text
 		}
+		batches = append(batches, batch)
+		texts = append(texts, batchTexts)
+	}
 
-		// 生成向量嵌入
-		vectors, err := s.embedder.EmbedBatch(ctx, texts)
-		if err != nil {
-			return fmt.Errorf("failed to generate embeddings: %w", err)
-		}
+	// 各批次并发生成向量嵌入，按批次顺序写入
+	return s.embedBatches(ctx, texts, func(i int, vectors [][]float32) error {
+		batch := batches[i]
 
 		// 构建文档对象并存入向量数据库
 		docs := make([]vectordb.Document, len(batch))
 		dbSegments := make([]*models.DocumentSegment, len(batch))
 
 		for j := range batch {
-			// 创建向量数据库文档
-			docs[j] = vectordb.Document{
-				ID:        fmt.Sprintf("%s_%d", fileID, batch[j].Index),
-				FileID:    fileID,
-				FileName:  fileName,
-				Position:  batch[j].Index,
-				Text:      batch[j].Text,
-				Vector:    vectors[j],
-				CreatedAt: time.Now(),
-				Metadata: map[string]interface{}{
-					"source": filePath,
-					"index":  batch[j].Index,
-				},
-			}
-
-			// 创建数据库段落记录
-			dbSegments[j] = &models.DocumentSegment{
-				DocumentID: fileID,
-				SegmentID:  fmt.Sprintf("%s_%d", fileID, batch[j].Index),
-				Position:   batch[j].Index,
-				Text:       batch[j].Text,
+			docs[j], dbSegments[j] = buildSegment(fileID, filePath, batch[j], vectors[j], docMetadata)
+			if dups != nil {
+				s.markDuplicate(&docs[j], dbSegments[j], dups[batch[j].Index])
 			}
 		}
 
@@ -479,19 +564,17 @@ func (s *DocumentService) processBatches(ctx context.Context, fileID string, fil
 
 		// 批量保存段落到数据库
 		if err := s.repo.SaveSegments(dbSegments); err != nil {
-			s.logger.WithError(err).Error("Failed to save segments to database")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to save segments to database")
 			// 不中断处理
 		}
 
-		processedBatches++
 		// 计算并更新进度（20%到90%的范围）
-		progress := 20 + int(float64(processedBatches)/float64(totalBatches)*70)
+		progress := 20 + int(float64(i+1)/float64(len(batches))*70)
 		if err := s.statusManager.UpdateProgress(ctx, fileID, progress); err != nil {
-			s.logger.WithError(err).Warn("Failed to update document progress")
+			s.logger.WithContext(ctx).WithError(err).Warn("Failed to update document progress")
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // ProcessDocument 处理文档
@@ -502,21 +585,26 @@ func (s *DocumentService) ProcessDocument(ctx context.Context, fileID string, fi
 		return fmt.Errorf("failed to initialize document service: %w", err)
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"file_id":       fileID,
 		"file_path":     filePath,
 		"async_enabled": s.asyncEnabled,
 	}).Info("Processing document")
 
+	// 压缩包和邮件直接展开，展开出的子文档再按配置同步或异步处理
+	if document.IsContainer(filePath) {
+		return s.processContainer(ctx, fileID, filePath)
+	}
+
 	// 如果启用了异步处理，将任务加入队列
 	if s.asyncEnabled && s.taskQueue != nil {
-		s.logger.Info("Using async processing for document")
+		s.logger.WithContext(ctx).Info("Using async processing for document")
 		// 使用默认的异步处理选项
 		return s.ProcessDocumentAsync(ctx, fileID, filePath)
 	}
 
 	// 否则，使用同步处理
-	s.logger.Info("Using sync processing for document")
+	s.logger.WithContext(ctx).Info("Using sync processing for document")
 	return s.processDocumentSync(ctx, fileID, filePath)
 }
 
@@ -527,25 +615,36 @@ func (s *DocumentService) DeleteDocument(ctx context.Context, fileID string) err
 		return err
 	}
 
-	s.logger.WithField("file_id", fileID).Info("Deleting document")
+	s.logger.WithContext(ctx).WithField("file_id", fileID).Info("Deleting document")
+
+	// 0. 删除从压缩包或邮件中展开的子文档
+	s.deleteChildDocuments(ctx, fileID)
 
 	// 1. 从向量数据库中删除
 	if err := s.vectorDB.DeleteByFileID(fileID); err != nil {
-		s.logger.WithError(err).Error("Failed to delete document vectors")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to delete document vectors")
 		return fmt.Errorf("failed to delete document vectors: %w", err)
 	}
 
-	// 2. 从存储中删除文件
-	if err := s.storage.Delete(fileID); err != nil {
+	// 2. 从存储中删除文件，重新抓取过的文档存储ID与文档ID不同
+	storageID := fileID
+	var fileName string
+	if doc, err := s.repo.GetByID(fileID); err == nil && doc != nil {
+		storageID = storageIDFromPath(doc.FilePath, fileID)
+		fileName = doc.FileName
+		s.deleteDocumentImages(ctx, doc)
+	}
+	if err := s.storage.Delete(storageID); err != nil {
 		// 文件可能已被删除，记录错误但不中断流程
-		s.logger.WithError(err).Warn("Failed to delete file from storage")
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to delete file from storage")
 	}
 
 	// 3. 删除文档状态记录
 	if err := s.statusManager.DeleteDocument(ctx, fileID); err != nil {
-		s.logger.WithError(err).Error("Failed to delete document status record")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to delete document status record")
 		return fmt.Errorf("failed to delete document status record: %w", err)
 	}
+	s.releaseDuplicates(ctx, fileID)
 
 	// 4. 如果任务队列已配置，删除相关任务
 	if s.taskQueue != nil {
@@ -553,13 +652,29 @@ func (s *DocumentService) DeleteDocument(ctx context.Context, fileID string) err
 		if err == nil && len(tasks) > 0 {
 			for _, task := range tasks {
 				if err := s.repo.DeleteTask(ctx, task.ID); err != nil {
-					s.logger.WithError(err).WithField("task_id", task.ID).Warn("Failed to delete document task")
+					s.logger.WithContext(ctx).WithError(err).WithField("task_id", task.ID).Warn("Failed to delete document task")
 				}
 			}
 		}
 	}
 
-	s.logger.WithField("file_id", fileID).Info("Document deleted successfully")
+	// 5. 从所属的文档组中移除
+	if s.groupRepo != nil {
+		if err := s.groupRepo.WithContext(ctx).RemoveDocument(fileID); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to remove document from groups")
+		}
+	}
+
+	// 6. 文档经过Python服务处理时（异步处理或同步调用Python解析分块），
+	// 通知Python端清理按文档ID保存的结果和任务记录，失败不影响删除；Go worker不缓存中间结果，无需清理
+	if s.usesPythonService() {
+		if err := s.enqueueDocumentCleanup(ctx, fileID); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to enqueue document cleanup task")
+		}
+	}
+
+	s.audit.Record(ctx, models.AuditActionDeleteDocument, fileID, fileName)
+	s.logger.WithContext(ctx).WithField("file_id", fileID).Info("Document deleted successfully")
 	return nil
 }
 
@@ -602,6 +717,21 @@ func (s *DocumentService) GetDocumentInfo(ctx context.Context, fileID string) (m
 		info["tags"] = doc.Tags
 	}
 
+	// 如果识别了语言，添加到返回结果
+	if lang := doc.Language(); lang != "" {
+		info["language"] = lang
+	}
+
+	// 展开自压缩包或邮件的子文档，添加父文档ID
+	if doc.ParentID != "" {
+		info["parent_id"] = doc.ParentID
+	}
+
+	// 不参与检索的文档，添加标记
+	if doc.NoRetrieve {
+		info["no_retrieve"] = true
+	}
+
 	// 如果启用了异步处理，尝试获取相关任务信息
 	if s.asyncEnabled && s.taskQueue != nil {
 		tasks, err := s.repo.GetDocumentTasks(ctx, fileID)
@@ -646,7 +776,7 @@ func (s *DocumentService) GetDocumentStatus(ctx context.Context, fileID string)
 
 // WaitForDocumentProcessing 等待文档处理完成
 func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID string, timeout time.Duration) error {
-	// s.logger.WithFields(logrus.Fields{
+	// s.logger.WithContext(ctx).WithFields(logrus.Fields{
 	//     "file_id": fileID,
 	//     "timeout": timeout,
 	//     "async_enabled": s.asyncEnabled,
@@ -656,13 +786,13 @@ func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID
 
 	// 确保初始化完成
 	if err := s.Init(); err != nil {
-		s.logger.WithError(err).Error("Failed to initialize document service")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to initialize document service")
 		return err
 	}
 
 	if !s.asyncEnabled || s.taskQueue == nil {
 		// 如果未启用异步处理，直接检查文档状态
-		// s.logger.Info("Async processing not enabled, checking document status directly")
+		// s.logger.WithContext(ctx).Info("Async processing not enabled, checking document status directly")
 		status, err := s.statusManager.GetStatus(ctx, fileID)
 		if err != nil {
 			return err
@@ -684,7 +814,7 @@ func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID
 	}
 
 	// 获取文档相关的任务
-	// s.logger.WithField("document_id", fileID).Info("Getting document tasks")
+	// s.logger.WithContext(ctx).WithField("document_id", fileID).Info("Getting document tasks")
 	tasks, err := s.repo.GetDocumentTasks(ctx, fileID)
 	if err != nil {
 		return fmt.Errorf("failed to get document tasks: %w", err)
@@ -697,7 +827,7 @@ func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID
 	// 找到最新的处理任务
 	var latestTask *taskqueue.Task
 	for _, task := range tasks {
-		// s.logger.WithFields(logrus.Fields{
+		// s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		//     "task_id": task.ID,
 		//     "task_type": task.Type,
 		//     "task_status": task.Status,
@@ -715,7 +845,7 @@ func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID
 		return fmt.Errorf("no complete processing task found for document %s", fileID)
 	}
 
-	// s.logger.WithFields(logrus.Fields{
+	// s.logger.WithContext(ctx).WithFields(logrus.Fields{
 	//     "task_id": latestTask.ID,
 	//     "task_status": latestTask.Status,
 	// }).Info("Waiting for task to complete")
@@ -723,30 +853,30 @@ func (s *DocumentService) WaitForDocumentProcessing(ctx context.Context, fileID
 	// 等待任务完成
 	_, err = s.taskQueue.WaitForTask(ctx, latestTask.ID, timeout)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to wait for task")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to wait for task")
 		return fmt.Errorf("failed to wait for document processing: %w", err)
 	}
 
 	// 再次检查文档状态
 	status, err := s.statusManager.GetStatus(ctx, fileID)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to get document status after waiting")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to get document status after waiting")
 		return err
 	}
 
-	// s.logger.WithField("status", status).Info("Document status after waiting")
+	// s.logger.WithContext(ctx).WithField("status", status).Info("Document status after waiting")
 
 	if status == models.DocStatusFailed {
-		s.logger.Error("Document processing failed after waiting")
+		s.logger.WithContext(ctx).Error("Document processing failed after waiting")
 		return fmt.Errorf("document processing failed")
 	}
 
 	if status != models.DocStatusCompleted {
-		s.logger.WithField("status", status).Error("Document processing incomplete after waiting")
+		s.logger.WithContext(ctx).WithField("status", status).Error("Document processing incomplete after waiting")
 		return fmt.Errorf("document processing incomplete")
 	}
 
-	// s.logger.Info("Document processing completed successfully")
+	// s.logger.WithContext(ctx).Info("Document processing completed successfully")
 
 	return nil
 }
@@ -762,6 +892,108 @@ func (s *DocumentService) CountDocumentSegments(ctx context.Context, fileID stri
 	return s.repo.CountSegments(fileID)
 }
 
+// ContextSegment 上下文窗口中的单个段落
+type ContextSegment struct {
+	SegmentID string // 段落唯一ID
+	Position  int    // 段落位置
+	Text      string // 段落文本
+	Start     int    // 在拼接后上下文中的起始偏移（按字符计）
+	End       int    // 在拼接后上下文中的结束偏移（不含）
+	Target    bool   // 是否为被引用的段落
+}
+
+// SegmentContext 引用段落及其前后若干段落
+type SegmentContext struct {
+	DocumentID string           // 文档ID
+	Position   int              // 被引用段落的位置
+	Window     int              // 前后各取的段落数
+	Context    string           // 按顺序拼接后的上下文文本
+	Segments   []ContextSegment // 窗口内的段落
+}
+
+// contextSeparator 拼接上下文时段落之间的分隔符
+const contextSeparator = "\n"
+
+// GetSegmentContext 获取引用段落及其前后window个段落
+// 优先从段落表读取，异步处理的文档没有段落记录时回退到向量数据库
+func (s *DocumentService) GetSegmentContext(ctx context.Context, fileID string, position, window int) (*SegmentContext, error) {
+	// 确保初始化完成
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	if position < 0 {
+		return nil, fmt.Errorf("invalid segment position: %d", position)
+	}
+	if window < 0 {
+		window = 0
+	}
+
+	from, to := position-window, position+window
+	if from < 0 {
+		from = 0
+	}
+
+	var segments []ContextSegment
+	dbSegments, err := s.repo.GetSegments(fileID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to load segments from database")
+	}
+	for _, seg := range dbSegments {
+		if seg.Position >= from && seg.Position <= to {
+			segments = append(segments, ContextSegment{
+				SegmentID: seg.SegmentID,
+				Position:  seg.Position,
+				Text:      seg.Text,
+			})
+		}
+	}
+
+	// 段落表中没有记录时从向量数据库按ID读取
+	if len(segments) == 0 {
+		for pos := from; pos <= to; pos++ {
+			id := fmt.Sprintf("%s_%d", fileID, pos)
+			doc, err := s.vectorDB.Get(id)
+			if err != nil {
+				continue
+			}
+			segments = append(segments, ContextSegment{
+				SegmentID: id,
+				Position:  doc.Position,
+				Text:      doc.Text,
+			})
+		}
+	}
+
+	result := &SegmentContext{
+		DocumentID: fileID,
+		Position:   position,
+		Window:     window,
+	}
+
+	found := false
+	var sb strings.Builder
+	offset := 0
+	for i := range segments {
+		if i > 0 {
+			sb.WriteString(contextSeparator)
+			offset += utf8.RuneCountInString(contextSeparator)
+		}
+		segments[i].Start = offset
+		offset += utf8.RuneCountInString(segments[i].Text)
+		segments[i].End = offset
+		segments[i].Target = segments[i].Position == position
+		found = found || segments[i].Target
+		sb.WriteString(segments[i].Text)
+	}
+	if !found {
+		return nil, fmt.Errorf("segment %d of document %s not found", position, fileID)
+	}
+
+	result.Context = sb.String()
+	result.Segments = segments
+	return result, nil
+}
+
 // ListDocuments 获取文档列表
 func (s *DocumentService) ListDocuments(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*models.Document, int64, error) {
 	// 确保初始化完成
@@ -769,8 +1001,17 @@ func (s *DocumentService) ListDocuments(ctx context.Context, offset, limit int,
 		return nil, 0, err
 	}
 
-	// 使用状态管理器获取文档列表
-	return s.statusManager.ListDocuments(ctx, offset, limit, filters)
+	// 使用状态管理器获取文档列表，启用访问控制时只列出用户可以读取的文档
+	return s.statusManager.ListDocuments(ctx, offset, limit, s.readableFilters(ctx, filters))
+}
+
+// ListDocumentsAfter 使用游标分页获取文档列表，返回下一页游标，为空表示没有更多记录
+func (s *DocumentService) ListDocumentsAfter(ctx context.Context, cursor string, limit int, filters map[string]interface{}) ([]*models.Document, string, error) {
+	if err := s.Init(); err != nil {
+		return nil, "", err
+	}
+
+	return s.statusManager.ListDocumentsAfter(ctx, cursor, limit, s.readableFilters(ctx, filters))
 }
 
 // UpdateDocumentTags 更新文档标签
@@ -783,25 +1024,57 @@ func (s *DocumentService) UpdateDocumentTags(ctx context.Context, fileID string,
 	// 获取文档
 	doc, err := s.statusManager.GetDocument(ctx, fileID)
 	if err != nil {
-		return fmt.Errorf("failed to get document: %w", err)
+		return fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
 	}
 
 	// 更新标签
+	oldTags := doc.Tags
 	doc.Tags = tags
 
 	// 保存更新
-	return s.repo.Update(doc)
+	if err := s.repo.Update(doc); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdateTags, fileID, fmt.Sprintf("%q -> %q", oldTags, tags))
+	return nil
+}
+
+// SetDocumentRetrieval 设置文档是否参与问答检索，noRetrieve为true时文档不会出现在检索结果中
+// 文档的段落和向量保持不变，恢复检索后无需重新处理
+func (s *DocumentService) SetDocumentRetrieval(ctx context.Context, fileID string, noRetrieve bool) error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+
+	doc, err := s.statusManager.GetDocument(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+	if doc.NoRetrieve == noRetrieve {
+		return nil
+	}
+
+	old := doc.NoRetrieve
+	doc.NoRetrieve = noRetrieve
+	if err := s.repo.Update(doc); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdateRetrieval, fileID, fmt.Sprintf("no_retrieve %t -> %t", old, noRetrieve))
+	s.statusManager.documentsChanged(ctx)
+	return nil
 }
 
 // failDocument 将文档标记为失败状态
 func (s *DocumentService) failDocument(ctx context.Context, fileID string, errorMsg string) {
 	if s.statusManager == nil {
-		s.logger.Error("Cannot mark document as failed: status manager not initialized")
+		s.logger.WithContext(ctx).Error("Cannot mark document as failed: status manager not initialized")
 		return
 	}
 
 	if err := s.statusManager.MarkAsFailed(ctx, fileID, errorMsg); err != nil {
-		s.logger.WithFields(logrus.Fields{
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
 			"file_id": fileID,
 			"error":   err,
 		}).Error("Failed to mark document as failed")
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+)
+
+// DocumentACL 文档的访问控制
+type DocumentACL struct {
+	Owner  string   // 上传者（租户）
+	Public bool     // 是否公开
+	Users  []string // 共享的用户
+	Groups []string // 共享的用户组
+}
+
+// WithAccessPolicy 启用文档访问控制，文档列表只返回用户可以读取的文档，读取无权访问的文档时按文档不存在处理
+func WithAccessPolicy(policy *acl.Policy) DocumentOption {
+	return func(s *DocumentService) {
+		s.acl = policy
+	}
+}
+
+// readableFilters 在文档列表的筛选条件中加入访问控制，不修改调用方的筛选条件
+func (s *DocumentService) readableFilters(ctx context.Context, filters map[string]interface{}) map[string]interface{} {
+	reader, ok := s.acl.Reader(ctx)
+	if !ok {
+		return filters
+	}
+	scoped := make(map[string]interface{}, len(filters)+1)
+	for k, v := range filters {
+		scoped[k] = v
+	}
+	scoped["reader"] = reader
+	return scoped
+}
+
+// CheckReadable 检查上下文中的用户能否读取文档，无权读取时返回ErrDocumentNotFound，不暴露文档是否存在
+func (s *DocumentService) CheckReadable(ctx context.Context, fileID string) error {
+	if s.acl == nil {
+		return nil
+	}
+	_, _, err := s.readableDocument(ctx, fileID)
+	return err
+}
+
+// CheckManageable 检查上下文中的用户能否修改或删除文档，只有上传者和管理员可以修改
+// 无权读取时返回ErrDocumentNotFound，可以读取但无权修改时返回ErrAccessDenied
+func (s *DocumentService) CheckManageable(ctx context.Context, fileID string) error {
+	if s.acl == nil {
+		return nil
+	}
+	doc, _, err := s.readableDocument(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	if !s.acl.CanManage(ctx, doc) {
+		return fmt.Errorf("%w: %s", models.ErrAccessDenied, fileID)
+	}
+	return nil
+}
+
+// readableDocument 读取文档及其共享记录，文档不存在或上下文中的用户无权读取时返回ErrDocumentNotFound
+func (s *DocumentService) readableDocument(ctx context.Context, fileID string) (*models.Document, []*models.DocumentShare, error) {
+	if err := s.Init(); err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+	shares, err := s.repo.ListShares(fileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load document shares: %w", err)
+	}
+	if !s.acl.CanRead(ctx, doc, shares) {
+		return nil, nil, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+	return doc, shares, nil
+}
+
+// GetDocumentACL 获取文档的访问控制，需要有读取权限
+func (s *DocumentService) GetDocumentACL(ctx context.Context, fileID string) (*DocumentACL, error) {
+	doc, shares, err := s.readableDocument(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return toDocumentACL(doc, shares), nil
+}
+
+// UpdateDocumentACL 设置文档是否公开并替换共享的用户和用户组，只有上传者和管理员可以修改
+// 修改后通知问答服务文档发生了变更，缓存的回答会重新生成
+func (s *DocumentService) UpdateDocumentACL(ctx context.Context, fileID string, public bool, users, groups []string) (*DocumentACL, error) {
+	doc, _, err := s.readableDocument(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.acl.CanManage(ctx, doc) {
+		return nil, fmt.Errorf("%w: %s", models.ErrAccessDenied, fileID)
+	}
+
+	shares := make([]*models.DocumentShare, 0, len(users)+len(groups))
+	for _, user := range users {
+		shares = append(shares, &models.DocumentShare{Kind: models.ShareKindUser, Name: user})
+	}
+	for _, group := range groups {
+		shares = append(shares, &models.DocumentShare{Kind: models.ShareKindGroup, Name: group})
+	}
+	if err := s.repo.UpdateACL(fileID, public, copyShares(shares)); err != nil {
+		return nil, err
+	}
+	doc.Public = public
+	// 从压缩包或邮件中展开的子文档与父文档使用相同的访问控制
+	if err := s.updateChildACL(fileID, public, shares); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditActionUpdateACL, fileID, fmt.Sprintf("public=%t users=%q groups=%q", public, users, groups))
+	s.statusManager.documentsChanged(ctx)
+	return toDocumentACL(doc, shares), nil
+}
+
+// updateChildACL 将访问控制递归应用到从fileID展开的子文档
+func (s *DocumentService) updateChildACL(fileID string, public bool, shares []*models.DocumentShare) error {
+	filters := map[string]interface{}{"parent_id": fileID}
+	for offset := 0; ; offset += 100 {
+		children, _, err := s.repo.List(offset, 100, filters)
+		if err != nil {
+			return fmt.Errorf("failed to list child documents: %w", err)
+		}
+		for _, child := range children {
+			if err := s.repo.UpdateACL(child.ID, public, copyShares(shares)); err != nil {
+				return fmt.Errorf("failed to update child document %s: %w", child.ID, err)
+			}
+			if err := s.updateChildACL(child.ID, public, shares); err != nil {
+				return err
+			}
+		}
+		if len(children) < 100 {
+			return nil
+		}
+	}
+}
+
+// copyShares 复制共享记录的类型和名称，用于写入其他文档
+// 写入时会设置记录的主键和文档ID，同一组记录不能写入多个文档
+func copyShares(shares []*models.DocumentShare) []*models.DocumentShare {
+	copied := make([]*models.DocumentShare, len(shares))
+	for i, share := range shares {
+		copied[i] = &models.DocumentShare{Kind: share.Kind, Name: share.Name}
+	}
+	return copied
+}
+
+// CountOwnerlessDocuments 统计没有确定上传者的文档数量
+// 包括启用访问控制前上传（没有记录上传者）和匿名上传的文档，启用访问控制后这些文档只有管理员可以读取
+func (s *DocumentService) CountOwnerlessDocuments(ctx context.Context) (int64, error) {
+	if err := s.Init(); err != nil {
+		return 0, err
+	}
+	return s.repo.CountOwnerless([]string{usage.AnonymousTenant})
+}
+
+// AssignOwnerlessDocuments 将没有确定上传者的文档归属owner（为空时不修改上传者），public为true时同时设为公开，返回修改的文档数
+func (s *DocumentService) AssignOwnerlessDocuments(ctx context.Context, owner string, public bool) (int64, error) {
+	if err := s.Init(); err != nil {
+		return 0, err
+	}
+	count, err := s.repo.AssignOwnerless([]string{usage.AnonymousTenant}, owner, public)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign ownerless documents: %w", err)
+	}
+	if count > 0 {
+		s.statusManager.documentsChanged(ctx)
+	}
+	return count, nil
+}
+
+// toDocumentACL 将文档和共享记录转换为访问控制
+func toDocumentACL(doc *models.Document, shares []*models.DocumentShare) *DocumentACL {
+	result := &DocumentACL{Owner: doc.Tenant, Public: doc.Public, Users: []string{}, Groups: []string{}}
+	for _, share := range shares {
+		switch share.Kind {
+		case models.ShareKindUser:
+			result.Users = append(result.Users, share.Name)
+		case models.ShareKindGroup:
+			result.Groups = append(result.Groups, share.Name)
+		}
+	}
+	return result
+}
@@ -1,18 +1,17 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
 
@@ -74,7 +73,7 @@ func (s *DocumentService) DisableAsyncProcessing() {
 // processDocumentAsync 异步处理文档
 // 将任务加入队列并立即返回
 func (s *DocumentService) processDocumentAsync(ctx context.Context, fileID string, filePath string, options *AsyncDocumentOptions) error {
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"file_id":   fileID,
 		"file_path": filePath,
 	}).Info("Enqueuing document for async processing")
@@ -85,12 +84,12 @@ func (s *DocumentService) processDocumentAsync(ctx context.Context, fileID strin
 
 	// 确保有选项
 	if options == nil {
-		options = DefaultAsyncOptions()
+		options = s.asyncOptions()
 	}
 
 	// 更新文档状态为处理中
 	if err := s.statusManager.MarkAsProcessing(ctx, fileID); err != nil {
-		s.logger.WithError(err).Error("Failed to mark document as processing")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as processing")
 		return fmt.Errorf("failed to update document status: %w", err)
 	}
 
@@ -101,10 +100,9 @@ func (s *DocumentService) processDocumentAsync(ctx context.Context, fileID strin
 		fileType = fileType[1:] // 去掉开头的点号
 	}
 
-	// 修改为HTTP调用Python API
-	pythonServiceURL := os.Getenv("PYTHONSERVICE_URL")
-	if pythonServiceURL == "" {
-		pythonServiceURL = "http://localhost:8000"
+	// Go worker直接消费任务队列中的完整处理任务
+	if s.nativeWorker {
+		return s.enqueueProcessTask(ctx, fileID, filePath, fileName, fileType, options)
 	}
 
 	// 准备API请求参数
@@ -120,64 +118,40 @@ func (s *DocumentService) processDocumentAsync(ctx context.Context, fileID strin
 		"metadata":    options.Metadata,
 	}
 
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to marshal document processing request")
-		return fmt.Errorf("failed to marshal document processing request: %w", err)
-	}
-
-	// 发送HTTP请求到Python服务
-	req, err := http.NewRequestWithContext(ctx, "POST", pythonServiceURL+"/api/tasks/process", bytes.NewBuffer(jsonBody))
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to create document processing request")
-		return fmt.Errorf("failed to create document processing request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		s.logger.WithError(err).WithField("document_id", fileID).Error("Failed to send request to Python service")
-		return fmt.Errorf("failed to send request to Python service: %w", err)
+	// 发送HTTP请求到Python服务，临时故障由客户端按配置自动重试
+	var respBody struct {
+		TaskID string `json:"task_id"`
+		Status string `json:"status"`
 	}
-	defer resp.Body.Close()
+	if err := s.pyService.Post(ctx, "/tasks/process", requestBody, &respBody); err != nil {
+		var apiErr *pyprovider.APIError
+		if !errors.As(err, &apiErr) {
+			s.logger.WithContext(ctx).WithError(err).WithField("document_id", fileID).Error("Failed to send request to Python service")
+			return fmt.Errorf("failed to send request to Python service: %w", err)
+		}
 
-	// 检查失败的响应状态
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		errMsg := fmt.Sprintf("python service returned status %d: %s", resp.StatusCode, string(respBody))
-		s.logger.WithFields(logrus.Fields{
-			"status_code": resp.StatusCode,
+		errMsg := fmt.Sprintf("python service returned status %d: %s", apiErr.StatusCode, apiErr.Detail)
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"status_code": apiErr.StatusCode,
 			"document_id": fileID,
-			"response":    string(respBody),
+			"response":    apiErr.Detail,
 		}).Error("Python service returned error response")
 
 		// 将文档标记为失败
 		if err := s.statusManager.MarkAsFailed(ctx, fileID, errMsg); err != nil {
-			s.logger.WithError(err).Error("Failed to mark document as failed")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as failed")
 		}
 
-		return fmt.Errorf(errMsg)
-	}
-
-	// 处理响应
-	var respBody struct {
-		TaskID string `json:"task_id"`
-		Status string `json:"status"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-		s.logger.WithError(err).WithField("document_id", fileID).Error("Failed to decode response from Python service")
-		return fmt.Errorf("failed to decode response: %w", err)
+		return errors.New(errMsg)
 	}
 
 	// 使用响应的任务ID
 	taskID := respBody.TaskID
 	if taskID == "" {
-		s.logger.WithField("document_id", fileID).Warn("Python service returned empty task ID")
+		s.logger.WithContext(ctx).WithField("document_id", fileID).Warn("Python service returned empty task ID")
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"file_id": fileID,
 		"task_id": taskID,
 	}).Info("Document processing task created successfully")
@@ -185,9 +159,60 @@ func (s *DocumentService) processDocumentAsync(ctx context.Context, fileID strin
 	return nil
 }
 
+// enqueueProcessTask 投递由Go worker处理的完整处理任务
+func (s *DocumentService) enqueueProcessTask(ctx context.Context, fileID, filePath, fileName, fileType string, options *AsyncDocumentOptions) error {
+	payload := &taskqueue.ProcessCompletePayload{
+		DocumentID: fileID,
+		FilePath:   filePath,
+		FileName:   fileName,
+		FileType:   fileType,
+		ChunkSize:  options.ChunkSize,
+		Overlap:    options.ChunkOverlap,
+		SplitType:  options.SplitType,
+		Model:      options.Model,
+		Metadata:   options.Metadata,
+	}
+
+	taskID, err := s.taskQueue.Enqueue(ctx, taskqueue.TaskProcessComplete, fileID, payload)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("document_id", fileID).Error("Failed to enqueue document processing task")
+		if err := s.statusManager.MarkAsFailed(ctx, fileID, err.Error()); err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as failed")
+		}
+		return fmt.Errorf("failed to enqueue document processing task: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id": fileID,
+		"task_id": taskID,
+	}).Info("Document processing task enqueued for Go worker")
+
+	return nil
+}
+
+// usesPythonService 判断文档是否经过Python服务处理：异步处理交给Python worker，或同步处理调用Python解析和分块
+func (s *DocumentService) usesPythonService() bool {
+	if s.asyncEnabled && s.taskQueue != nil {
+		return !s.nativeWorker
+	}
+	return s.usePythonAPI && s.pythonClient != nil
+}
+
+// enqueueDocumentCleanup 通知Python worker清理文档的缓存数据
+// Python端按document_id缓存了解析结果和分块结果，文档删除后需要一并清理，避免残留孤立数据
+func (s *DocumentService) enqueueDocumentCleanup(ctx context.Context, fileID string) error {
+	payload := taskqueue.DocumentCleanupPayload{DocumentID: fileID}
+	if err := s.pyService.Post(ctx, "/tasks/cleanup", payload, nil); err != nil {
+		return fmt.Errorf("failed to send cleanup request to Python service: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithField("document_id", fileID).Info("Document cleanup task enqueued")
+	return nil
+}
+
 // ProcessDocumentAsync 异步处理文档
 func (s *DocumentService) ProcessDocumentAsync(ctx context.Context, fileID string, filePath string, opts ...AsyncOption) error {
-	options := DefaultAsyncOptions()
+	options := s.asyncOptions()
 
 	// 应用选项
 	for _, opt := range opts {
@@ -197,6 +222,28 @@ func (s *DocumentService) ProcessDocumentAsync(ctx context.Context, fileID strin
 	return s.processDocumentAsync(ctx, fileID, filePath, options)
 }
 
+// asyncOptions 返回异步处理的默认选项，分块参数与服务配置的分段器一致
+// 否则交给Python服务的文档总是按默认的段落方式分块，配置的语义分块等不会生效
+func (s *DocumentService) asyncOptions() *AsyncDocumentOptions {
+	options := DefaultAsyncOptions()
+	switch splitter := s.splitter.(type) {
+	case *document.PythonSplitter:
+		if splitter.GetChunkSize() > 0 {
+			options.ChunkSize = splitter.GetChunkSize()
+			options.ChunkOverlap = splitter.GetOverlap()
+		}
+		if splitType := splitter.GetSplitType(); splitType != "" {
+			options.SplitType = splitType
+		}
+	case *document.SemanticSplitter:
+		if splitter.GetChunkSize() > 0 {
+			options.ChunkSize = splitter.GetChunkSize()
+		}
+		options.SplitType = document.SplitTypeSemantic
+	}
+	return options
+}
+
 // AsyncOption 异步选项函数类型
 type AsyncOption func(*AsyncDocumentOptions)
 
@@ -264,6 +311,9 @@ func (s *DocumentService) registerTaskHandlers() {
 	// 注册完整流程任务处理器
 	processor.RegisterHandler(taskqueue.TaskProcessComplete, s.handleProcessCompleteResult)
 
+	// 注册文档缓存清理任务处理器
+	processor.RegisterHandler(taskqueue.TaskDocumentCleanup, s.handleDocumentCleanupResult)
+
 	s.logger.Info("Registered task handlers")
 }
 
@@ -336,13 +386,14 @@ func (s *DocumentService) registerCustomizedTaskHandlers() {
 	processor.RegisterHandler(taskqueue.TaskDocumentParse, s.handleDocumentParseResult)
 	processor.RegisterHandler(taskqueue.TaskTextChunk, s.handleTextChunkResult)
 	processor.RegisterHandler(taskqueue.TaskVectorize, s.handleVectorizeResult)
+	processor.RegisterHandler(taskqueue.TaskDocumentCleanup, s.handleDocumentCleanupResult)
 
 	s.logger.Info("Registered customized task handlers")
 }
 
 // handleDocumentParseResult 处理文档解析任务结果
 func (s *DocumentService) handleDocumentParseResult(ctx context.Context, task *taskqueue.Task, result json.RawMessage) error {
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"task_id":     task.ID,
 		"document_id": task.DocumentID,
 	}).Info("Handling document parse result")
@@ -355,7 +406,7 @@ func (s *DocumentService) handleDocumentParseResult(ctx context.Context, task *t
 
 	// 更新文档处理进度
 	if err := s.statusManager.UpdateProgress(ctx, task.DocumentID, 30); err != nil {
-		s.logger.WithError(err).Warn("Failed to update document progress")
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to update document progress")
 	}
 
 	// 检查内容是否为空
@@ -370,7 +421,7 @@ func (s *DocumentService) handleDocumentParseResult(ctx context.Context, task *t
 
 // handleTextChunkResult 处理文本分块任务结果
 func (s *DocumentService) handleTextChunkResult(ctx context.Context, task *taskqueue.Task, result json.RawMessage) error {
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"task_id":     task.ID,
 		"document_id": task.DocumentID,
 	}).Info("Handling text chunk result")
@@ -383,7 +434,7 @@ func (s *DocumentService) handleTextChunkResult(ctx context.Context, task *taskq
 
 	// 更新文档处理进度
 	if err := s.statusManager.UpdateProgress(ctx, task.DocumentID, 60); err != nil {
-		s.logger.WithError(err).Warn("Failed to update document progress")
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to update document progress")
 	}
 
 	return nil
@@ -391,7 +442,7 @@ func (s *DocumentService) handleTextChunkResult(ctx context.Context, task *taskq
 
 // handleVectorizeResult 处理向量化任务结果
 func (s *DocumentService) handleVectorizeResult(ctx context.Context, task *taskqueue.Task, result json.RawMessage) error {
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"task_id":     task.ID,
 		"document_id": task.DocumentID,
 	}).Info("Handling vectorize result")
@@ -406,23 +457,51 @@ func (s *DocumentService) handleVectorizeResult(ctx context.Context, task *taskq
 	if len(vectorizeResult.Vectors) > 0 {
 		// 更新文档信息
 		if err := s.saveVectorsToDatabase(ctx, task.DocumentID, &vectorizeResult); err != nil {
-			s.logger.WithError(err).Error("Failed to save vectors to database")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to save vectors to database")
 			return err
 		}
 	}
 
 	// 更新文档完成状态
 	if err := s.statusManager.MarkAsCompleted(ctx, task.DocumentID, vectorizeResult.VectorCount); err != nil {
-		s.logger.WithError(err).Error("Failed to mark document as completed")
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as completed")
 		return err
 	}
+	// Python服务返回的模型名是请求中的"default"，不是实际的模型名称；
+	// 记录当前嵌入模型的名称，与同步处理一致，定时重新向量化时按同一名称比较
+	s.recordEmbeddingModel(ctx, task.DocumentID, s.EmbeddingModel())
 
 	return nil
 }
 
+// handleDocumentCleanupResult 处理文档缓存清理任务结果
+// 文档记录此时已被删除，这里只记录清理情况
+func (s *DocumentService) handleDocumentCleanupResult(ctx context.Context, task *taskqueue.Task, result json.RawMessage) error {
+	var cleanupResult taskqueue.DocumentCleanupResult
+	if err := json.Unmarshal(result, &cleanupResult); err != nil {
+		return fmt.Errorf("failed to unmarshal document cleanup result: %w", err)
+	}
+
+	if cleanupResult.Error != "" {
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
+			"task_id":     task.ID,
+			"document_id": task.DocumentID,
+			"error":       cleanupResult.Error,
+		}).Warn("Python worker failed to clean up document cache")
+		return nil
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"task_id":      task.ID,
+		"document_id":  task.DocumentID,
+		"deleted_keys": cleanupResult.DeletedKeys,
+	}).Info("Python worker cleaned up document cache")
+	return nil
+}
+
 // handleProcessCompleteResult 处理完整流程任务结果
 func (s *DocumentService) handleProcessCompleteResult(ctx context.Context, task *taskqueue.Task, result json.RawMessage) error {
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"task_id":     task.ID,
 		"document_id": task.DocumentID,
 	}).Info("Handling process complete result")
@@ -435,14 +514,14 @@ func (s *DocumentService) handleProcessCompleteResult(ctx context.Context, task
 
 	// 检查处理状态
 	if completeResult.Error != "" {
-		s.logger.WithFields(logrus.Fields{
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
 			"document_id": task.DocumentID,
 			"error":       completeResult.Error,
 		}).Error("Document processing failed")
 
 		// 标记文档为失败状态
 		if err := s.statusManager.MarkAsFailed(ctx, task.DocumentID, completeResult.Error); err != nil {
-			s.logger.WithError(err).Error("Failed to mark document as failed")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as failed")
 		}
 		return fmt.Errorf("document processing failed: %s", completeResult.Error)
 	}
@@ -459,7 +538,7 @@ func (s *DocumentService) handleProcessCompleteResult(ctx context.Context, task
 		}
 
 		if err := s.saveVectorsToDatabase(ctx, task.DocumentID, &vectorResult); err != nil {
-			s.logger.WithError(err).Error("Failed to save vectors to database")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to save vectors to database")
 			// 继续处理，不影响文档完成状态
 		}
 	}
@@ -469,18 +548,18 @@ func (s *DocumentService) handleProcessCompleteResult(ctx context.Context, task
 	if completeResult.ParseStatus == "completed" && completeResult.ChunkStatus == "completed" {
 		// 标记文档为已完成状态
 		if err := s.statusManager.MarkAsCompleted(ctx, task.DocumentID, completeResult.ChunkCount); err != nil {
-			s.logger.WithError(err).Error("Failed to mark document as completed")
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to mark document as completed")
 			return err
 		}
 
 		// 如果向量化失败，仅使用日志警告
 		if completeResult.VectorStatus == "failed" {
-			s.logger.WithField("document_id", task.DocumentID).Warn(
+			s.logger.WithContext(ctx).WithField("document_id", task.DocumentID).Warn(
 				"Document marked as completed but vectorization failed. Search functionality may be limited.")
 		}
 	}
 
-	s.logger.WithFields(logrus.Fields{
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"document_id":  task.DocumentID,
 		"chunk_count":  completeResult.ChunkCount,
 		"vector_count": completeResult.VectorCount,
@@ -497,12 +576,15 @@ func (s *DocumentService) saveVectorsToDatabase(ctx context.Context, documentID
 		return fmt.Errorf("failed to get document info: %w", err)
 	}
 
+	// 文档级元数据（如网页来源URL）会写入每个段落
+	docMetadata := s.documentMetadata(documentID)
+
 	// 构建文档对象批量列表
 	docs := make([]vectordb.Document, 0, len(result.Vectors))
 	for _, vector := range result.Vectors {
 		// 检查向量数据有效性
 		if vector.ChunkIndex < 0 || len(vector.Vector) == 0 {
-			s.logger.WithFields(logrus.Fields{
+			s.logger.WithContext(ctx).WithFields(logrus.Fields{
 				"chunk_index": vector.ChunkIndex,
 				"document_id": documentID,
 			}).Warn("Invalid vector data, skipping")
@@ -521,9 +603,9 @@ func (s *DocumentService) saveVectorsToDatabase(ctx context.Context, documentID
 			Position:  vector.ChunkIndex,
 			Vector:    vectorData,
 			CreatedAt: time.Now(),
-			Metadata: map[string]interface{}{
+			Metadata: mergeMetadata(map[string]interface{}{
 				"file_type": doc.FileType,
-			},
+			}, docMetadata),
 		}
 
 		docs = append(docs, vectorDoc)
@@ -534,7 +616,7 @@ func (s *DocumentService) saveVectorsToDatabase(ctx context.Context, documentID
 		if err := s.vectorDB.AddBatch(docs); err != nil {
 			return fmt.Errorf("failed to add vectors to database: %w", err)
 		}
-		s.logger.WithFields(logrus.Fields{
+		s.logger.WithContext(ctx).WithFields(logrus.Fields{
 			"document_id":  documentID,
 			"vector_count": len(docs),
 		}).Info("Vectors saved to database")
@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/document"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
 	"github.com/fyerfyer/doc-QA-system/pkg/storage"
@@ -592,3 +596,110 @@ func TestWaitForTaskResult(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, taskqueue.StatusCompleted, task.Status)
 }
+
+// TestDeleteDocumentCleansPythonCache 测试同步调用Python解析和分块的文档删除时同样通知Python端清理
+func TestDeleteDocumentCleansPythonCache(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "docqa-sync-cleanup-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	var cleaned []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload taskqueue.DocumentCleanupPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		cleaned = append(cleaned, payload.DocumentID)
+		w.Write([]byte(`{"task_id":"cleanup_1","status":"pending"}`))
+	}))
+	defer server.Close()
+	t.Setenv("PYTHONSERVICE_URL", server.URL)
+
+	docService, _, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "local-doc", "local.txt", "/tmp/local.txt", 10))
+	require.NoError(t, docService.DeleteDocument(ctx, "local-doc"))
+	assert.Empty(t, cleaned, "documents processed locally leave nothing in the Python service")
+
+	WithUsePythonAPI(true)(docService)
+	require.NoError(t, docService.Init())
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "python-doc", "python.txt", "/tmp/python.txt", 10))
+	require.NoError(t, docService.DeleteDocument(ctx, "python-doc"))
+	assert.Equal(t, []string{"python-doc"}, cleaned)
+}
+
+// TestEnqueueDocumentCleanup 测试文档删除后通知Python worker清理缓存
+func TestEnqueueDocumentCleanup(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "docqa-cleanup-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	var received taskqueue.DocumentCleanupPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tasks/cleanup", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"task_id":"cleanup_1","status":"pending"}`))
+	}))
+	defer server.Close()
+	t.Setenv("PYTHONSERVICE_URL", server.URL)
+
+	docService, _, _ := setupDocumentTestEnv(t, tempDir)
+	err = docService.enqueueDocumentCleanup(context.Background(), "doc-to-clean")
+	require.NoError(t, err)
+	assert.Equal(t, "doc-to-clean", received.DocumentID)
+
+	// Python服务持续返回5xx时按配置重试后返回错误，每次请求都携带访问令牌
+	var attempts int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	WithPythonService(pyprovider.DefaultConfig().
+		WithBaseURL(failing.URL+"/api").
+		WithRetry(2, time.Millisecond).
+		WithAuthToken("secret"))(docService)
+	assert.Error(t, docService.enqueueDocumentCleanup(context.Background(), "doc-to-clean"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+// TestAsyncOptionsFollowSplitter 测试异步处理的分块参数与配置的分段器一致
+func TestAsyncOptionsFollowSplitter(t *testing.T) {
+	tests := []struct {
+		name      string
+		splitter  document.Splitter
+		chunkSize int
+		overlap   int
+		splitType string
+	}{
+		{
+			name:      "default",
+			chunkSize: 1000,
+			overlap:   200,
+			splitType: document.SplitTypeParagraph,
+		},
+		{
+			name:      "python semantic",
+			splitter:  document.NewPythonSplitter(nil, document.SplitConfig{ChunkSize: 800, Overlap: 100, SplitType: document.SplitTypeSemantic}),
+			chunkSize: 800,
+			overlap:   100,
+			splitType: document.SplitTypeSemantic,
+		},
+		{
+			name:      "local semantic",
+			splitter:  document.NewSemanticSplitter(nil, document.SplitConfig{ChunkSize: 600}),
+			chunkSize: 600,
+			overlap:   200,
+			splitType: document.SplitTypeSemantic,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &DocumentService{splitter: tt.splitter}
+			options := s.asyncOptions()
+			assert.Equal(t, tt.chunkSize, options.ChunkSize)
+			assert.Equal(t, tt.overlap, options.ChunkOverlap)
+			assert.Equal(t, tt.splitType, options.SplitType)
+		})
+	}
+}
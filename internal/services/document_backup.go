@@ -0,0 +1,342 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupVersion 备份归档格式版本
+const BackupVersion = 1
+
+// 备份归档中的文件名
+const (
+	backupManifestFile  = "manifest.json"
+	backupDocumentsFile = "documents.jsonl"
+	backupSegmentsFile  = "segments.jsonl"
+	backupVectorsFile   = "vectors.jsonl.gz"
+)
+
+// backupPageSize 备份时遍历文档列表的分页大小
+const backupPageSize = 500
+
+// BackupManifest 备份清单，位于归档的第一个文件
+type BackupManifest struct {
+	Version        int       `json:"version"`                   // 归档格式版本
+	CreatedAt      time.Time `json:"created_at"`                // 备份时间
+	Documents      int       `json:"documents"`                 // 文档数量
+	Segments       int       `json:"segments"`                  // 段落数量
+	Vectors        int       `json:"vectors"`                   // 向量数量
+	Dimension      int       `json:"dimension"`                 // 向量维度
+	EmbeddingModel string    `json:"embedding_model,omitempty"` // 备份时使用的嵌入模型
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	Documents int `json:"documents"` // 恢复的文档数量
+	Segments  int `json:"segments"`  // 恢复的段落数量
+	Vectors   int `json:"vectors"`   // 恢复的向量数量
+}
+
+// ExportVectors 导出向量数据库快照，快照与后端无关，可导入到其他类型的向量数据库
+func (s *DocumentService) ExportVectors(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.vectorDB.Export(w)
+}
+
+// ImportVectors 导入向量数据库快照，返回导入的向量数量
+func (s *DocumentService) ImportVectors(ctx context.Context, r io.Reader) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := s.vectorDB.Import(r)
+	if err != nil {
+		return n, err
+	}
+	// 批量导入后立即持久化，不等待向量数据库的后台保存
+	return n, s.vectorDB.Flush()
+}
+
+// Backup 将文档元数据、段落和向量写入tar.gz归档
+// 先读取SQL中的元数据再导出向量，备份期间新写入的向量可能比元数据多，
+// 但不会出现元数据指向缺失向量的情况；恢复时多出的向量会在重新处理或删除文档时被清理
+func (s *DocumentService) Backup(ctx context.Context, w io.Writer) (*BackupManifest, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	var docs []*models.Document
+	for offset := 0; ; offset += backupPageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		page, _, err := s.repo.List(offset, backupPageSize, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		docs = append(docs, page...)
+		if len(page) < backupPageSize {
+			break
+		}
+	}
+
+	var segments []*models.DocumentSegment
+	for _, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		segs, err := s.repo.GetSegments(doc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get segments of %s: %w", doc.ID, err)
+		}
+		segments = append(segments, segs...)
+	}
+
+	// 向量快照可能很大，先写入临时文件以便在清单中记录数量
+	tmp, err := os.CreateTemp("", "docqa-vectors-*.jsonl.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	if err := s.vectorDB.Export(tmp); err != nil {
+		return nil, fmt.Errorf("failed to export vectors: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header, err := vectordb.ReadSnapshotHeader(tmp)
+	if err != nil {
+		return nil, err
+	}
+	size, err := tmp.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	manifest := &BackupManifest{
+		Version:        BackupVersion,
+		CreatedAt:      time.Now(),
+		Documents:      len(docs),
+		Segments:       len(segments),
+		Vectors:        header.Count,
+		Dimension:      header.Dimension,
+		EmbeddingModel: s.EmbeddingModel(),
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeTarJSON(tw, backupManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	if err := writeTarJSONL(tw, backupDocumentsFile, docs); err != nil {
+		return nil, err
+	}
+	if err := writeTarJSONL(tw, backupSegmentsFile, segments); err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    backupVectorsFile,
+		Mode:    0644,
+		Size:    size,
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tw, tmp); err != nil {
+		return nil, fmt.Errorf("failed to write vectors: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"documents": manifest.Documents,
+		"segments":  manifest.Segments,
+		"vectors":   manifest.Vectors,
+	}).Info("Backup created")
+	return manifest, nil
+}
+
+// Restore 从Backup生成的归档恢复文档元数据、段落和向量
+// 同ID的文档和段落会被覆盖，归档中不存在的数据保持不变
+func (s *DocumentService) Restore(ctx context.Context, r io.Reader) (*RestoreResult, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	// 清单必须是第一个文件，在写入任何数据前完成校验
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup archive: %w", err)
+	}
+	if hdr.Name != backupManifestFile {
+		return nil, fmt.Errorf("invalid backup archive: expected %s, got %s", backupManifestFile, hdr.Name)
+	}
+	var manifest BackupManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Version > BackupVersion {
+		return nil, fmt.Errorf("unsupported backup version: %d", manifest.Version)
+	}
+	if dim := s.vectorDB.GetDimension(); dim > 0 && manifest.Dimension > 0 && manifest.Dimension != dim {
+		return nil, fmt.Errorf("%w: backup has dimension %d, vector database %d",
+			vectordb.ErrInvalidDimension, manifest.Dimension, dim)
+	}
+
+	result := &RestoreResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("invalid backup archive: %w", err)
+		}
+
+		switch hdr.Name {
+		case backupDocumentsFile:
+			err = readJSONL(tr, func(doc *models.Document) error {
+				if _, getErr := s.repo.GetByID(doc.ID); getErr == nil {
+					if err := s.repo.Update(doc); err != nil {
+						return err
+					}
+				} else if err := s.repo.Create(doc); err != nil {
+					return err
+				}
+				result.Documents++
+				return nil
+			})
+		case backupSegmentsFile:
+			batch := make([]*models.DocumentSegment, 0, backupPageSize)
+			err = readJSONL(tr, func(seg *models.DocumentSegment) error {
+				// 自增主键在不同数据库之间没有意义，按段落ID覆盖
+				seg.ID = 0
+				batch = append(batch, seg)
+				if len(batch) < backupPageSize {
+					return nil
+				}
+				if err := s.repo.UpsertSegments(batch); err != nil {
+					return err
+				}
+				result.Segments += len(batch)
+				batch = batch[:0]
+				return nil
+			})
+			if err == nil && len(batch) > 0 {
+				if err = s.repo.UpsertSegments(batch); err == nil {
+					result.Segments += len(batch)
+				}
+			}
+		case backupVectorsFile:
+			var n int
+			n, err = s.vectorDB.Import(tr)
+			result.Vectors += n
+		default:
+			s.logger.WithField("file", hdr.Name).Warn("Skipping unknown file in backup archive")
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to restore %s: %w", hdr.Name, err)
+		}
+	}
+	if err := s.vectorDB.Flush(); err != nil {
+		return result, fmt.Errorf("failed to flush vector database: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"documents": result.Documents,
+		"segments":  result.Segments,
+		"vectors":   result.Vectors,
+	}).Info("Backup restored")
+	return result, nil
+}
+
+// writeTarJSON 将单个JSON对象写入归档
+func writeTarJSON(tw *tar.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, name, data)
+}
+
+// writeTarJSONL 将列表按JSON Lines写入归档
+func writeTarJSONL[T any](tw *tar.Writer, name string, items []T) error {
+	var buf []byte
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+	return writeTarFile(tw, name, buf)
+}
+
+// writeTarFile 写入归档中的一个文件
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// readJSONL 逐行解码JSON Lines并回调
+func readJSONL[T any](r io.Reader, fn func(*T) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		item := new(T)
+		if err := json.Unmarshal(scanner.Bytes(), item); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(item); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
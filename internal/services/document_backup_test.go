@@ -0,0 +1,71 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackupRestore 测试备份后删除文档，再从备份恢复元数据、段落和向量
+func TestBackupRestore(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-backup-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	input := strings.Join([]string{
+		`{"file_id":"backup-1","file_name":"guide.md","text":"第一段","vector":[0.1,0.2,0.3,0.4]}`,
+		`{"file_id":"backup-1","text":"第二段","vector":[0.2,0.3,0.4,0.5]}`,
+		`{"file_id":"backup-2","file_name":"faq.md","text":"常见问题","vector":[0.5,0.5,0.5,0.5]}`,
+	}, "\n")
+	_, err = docService.ImportEmbeddings(ctx, strings.NewReader(input), ImportOptions{})
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	manifest, err := docService.Backup(ctx, &archive)
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.Documents)
+	assert.Equal(t, 3, manifest.Segments)
+	assert.Equal(t, 3, manifest.Vectors)
+	assert.Equal(t, 4, manifest.Dimension)
+
+	require.NoError(t, docService.DeleteDocument(ctx, "backup-1"))
+	_, err = vectorDB.Get("backup-1_0")
+	require.Error(t, err)
+
+	result, err := docService.Restore(ctx, bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, &RestoreResult{Documents: 2, Segments: 3, Vectors: 3}, result)
+
+	record, err := statusManager.GetDocument(ctx, "backup-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, record.Status)
+	assert.Equal(t, "guide.md", record.FileName)
+
+	segments, err := docService.repo.GetSegments("backup-1")
+	require.NoError(t, err)
+	assert.Len(t, segments, 2)
+
+	doc, err := vectorDB.Get("backup-1_1")
+	require.NoError(t, err)
+	assert.Equal(t, "第二段", doc.Text)
+
+	// 维度不一致时在写入任何数据前拒绝恢复
+	other, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 8})
+	require.NoError(t, err)
+	otherService := NewDocumentService(nil, nil, nil, nil, other, WithDocumentRepository(docService.repo))
+	_, err = otherService.Restore(ctx, bytes.NewReader(archive.Bytes()))
+	assert.ErrorIs(t, err, vectordb.ErrInvalidDimension)
+	count, err := other.Count()
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/sirupsen/logrus"
+)
+
+// WithTextCleaner 设置分块前的文本清洗器，为空时不清洗
+func WithTextCleaner(cleaner *document.Cleaner) DocumentOption {
+	return func(s *DocumentService) {
+		s.cleaner = cleaner
+	}
+}
+
+// cleanContent 分块前清洗文档文本，清洗前后的字符数记入文档元数据
+func (s *DocumentService) cleanContent(ctx context.Context, fileID, content string) string {
+	if s.cleaner == nil {
+		return content
+	}
+
+	result := s.cleaner.Clean(content)
+	update := map[string]interface{}{
+		"clean_chars_before": result.CharsBefore,
+		"clean_chars_after":  result.CharsAfter,
+	}
+	if err := s.updateDocumentMetadata(ctx, fileID, update, ""); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to record text cleaning result")
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id":       fileID,
+		"chars_before":  result.CharsBefore,
+		"chars_after":   result.CharsAfter,
+		"lines_removed": result.LinesRemoved,
+	}).Info("Document text cleaned")
+	return result.Text
+}
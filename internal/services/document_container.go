@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/sirupsen/logrus"
+)
+
+// maxContainerDepth 压缩包和邮件最多嵌套展开的层数，更深的压缩包或邮件跳过
+const maxContainerDepth = 2
+
+// 子文档元数据的键
+const (
+	metaParentFile     = "parent_file"     // 父文档的文件名
+	metaArchivePath    = "archive_path"    // 在压缩包中的路径或邮件附件名
+	metaContainerDepth = "container_depth" // 展开的层数，直接展开自上传文件的子文档为1
+	metaEmailSubject   = "email_subject"   // 所属邮件的主题
+	metaEmailFrom      = "email_from"      // 所属邮件的发件人
+	metaEmailTo        = "email_to"        // 所属邮件的收件人
+	metaEmailDate      = "email_date"      // 所属邮件的发送时间，RFC3339格式
+)
+
+// WithFileValidator 设置校验压缩包和邮件中展开文件的校验器，通常与上传接口使用同一个
+// 未通过校验的文件（如类型不允许）跳过，不创建子文档
+func WithFileValidator(validator *filecheck.Validator) DocumentOption {
+	return func(s *DocumentService) {
+		s.fileValidator = validator
+	}
+}
+
+// processContainer 展开压缩包或邮件
+// 其中的文件保存为子文档并分别处理，子文档记录父文档ID、邮件的发件人和日期等元数据；
+// 父文档本身不建立索引，标记为完成后在元数据中记录子文档和跳过的文件数量。重新处理时先删除已有的子文档
+func (s *DocumentService) processContainer(ctx context.Context, fileID, filePath string) error {
+	logger := s.logger.WithContext(ctx).WithField("file_id", fileID)
+	if err := s.statusManager.MarkAsProcessing(ctx, fileID); err != nil {
+		logger.WithError(err).Error("Failed to mark document as processing")
+	}
+
+	reader, err := s.openDocumentFile(filePath)
+	if err != nil {
+		s.failDocument(ctx, fileID, fmt.Sprintf("failed to open file: %v", err))
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	entries, err := document.ExpandContainer(reader, filePath)
+	reader.Close()
+	if err != nil {
+		s.failDocument(ctx, fileID, fmt.Sprintf("failed to expand file: %v", err))
+		return fmt.Errorf("failed to expand file: %w", err)
+	}
+
+	parent, err := s.statusManager.GetDocument(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+	depth := 1
+	if d, ok := s.documentMetadata(fileID)[metaContainerDepth].(float64); ok {
+		depth = int(d) + 1
+	}
+	s.deleteChildDocuments(ctx, fileID)
+
+	type child struct{ id, path string }
+	var (
+		children []child
+		skipped  []string
+	)
+	for _, entry := range entries {
+		if document.IsContainer(entry.Name) && depth >= maxContainerDepth {
+			skipped = append(skipped, entry.Name)
+			continue
+		}
+		// 由邮件正文生成的文件不需要校验
+		if !entry.Body {
+			if err := s.fileValidator.Validate(ctx, entry.Name, bytes.NewReader(entry.Data)); err != nil {
+				logger.WithError(err).WithField("entry", entry.Name).Info("Skipping expanded file")
+				skipped = append(skipped, entry.Name)
+				continue
+			}
+		}
+
+		id, path, err := s.createChildDocument(ctx, parent, entry, depth)
+		if err != nil {
+			logger.WithError(err).WithField("entry", entry.Name).Warn("Failed to create child document")
+			skipped = append(skipped, entry.Name)
+			continue
+		}
+		children = append(children, child{id, path})
+	}
+
+	if err := s.statusManager.MarkAsCompleted(ctx, fileID, 0); err != nil {
+		logger.WithError(err).Error("Failed to mark document as completed")
+	}
+	metadata := map[string]interface{}{"children": len(children), "skipped_files": skipped}
+	if err := s.updateDocumentMetadata(ctx, fileID, metadata, ""); err != nil {
+		logger.WithError(err).Warn("Failed to update document metadata")
+	}
+	logger.WithFields(logrus.Fields{
+		"children": len(children),
+		"skipped":  len(skipped),
+	}).Info("Document expanded into child documents")
+
+	// 子文档各自使用完整的处理超时，不受父文档的上下文取消影响
+	childCtx := context.WithoutCancel(ctx)
+	for _, c := range children {
+		if err := s.ProcessDocument(childCtx, c.id, c.path); err != nil {
+			logger.WithError(err).WithField("child_id", c.id).Warn("Failed to process child document")
+		}
+	}
+	return nil
+}
+
+// createChildDocument 保存展开的文件并创建子文档记录
+// 子文档继承父文档的标签、上传者、公开状态和共享记录，与父文档有相同的读取权限；
+// 配额只统计在父文档上，删除父文档时一并删除
+func (s *DocumentService) createChildDocument(ctx context.Context, parent *models.Document, entry document.ContainerEntry, depth int) (string, string, error) {
+	info, err := s.storage.Save(bytes.NewReader(entry.Data), entry.Name)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := s.statusManager.MarkAsUploaded(ctx, info.ID, entry.Name, info.Path, info.Size); err != nil {
+		s.storage.Delete(info.ID)
+		return "", "", fmt.Errorf("failed to create document record: %w", err)
+	}
+
+	metadata := map[string]interface{}{
+		metaParentFile:     parent.FileName,
+		metaContainerDepth: depth,
+	}
+	if entry.Path != "" {
+		metadata[metaArchivePath] = entry.Path
+	}
+	if email := entry.Email; email != nil {
+		metadata[metaEmailSubject] = email.Subject
+		metadata[metaEmailFrom] = email.From
+		metadata[metaEmailTo] = email.To
+		if !email.Date.IsZero() {
+			metadata[metaEmailDate] = email.Date.Format(time.RFC3339)
+		}
+	}
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return "", "", err
+	}
+
+	doc, err := s.statusManager.GetDocument(ctx, info.ID)
+	if err != nil {
+		return "", "", err
+	}
+	doc.ParentID = parent.ID
+	doc.Tags = parent.Tags
+	doc.Tenant = parent.Tenant
+	doc.Public = parent.Public
+	doc.Metadata = raw
+	if err := s.repo.Update(doc); err != nil {
+		return "", "", fmt.Errorf("failed to update document record: %w", err)
+	}
+
+	shares, err := s.repo.ListShares(parent.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to load parent document shares: %w", err)
+	}
+	if len(shares) > 0 {
+		if err := s.repo.UpdateACL(info.ID, parent.Public, copyShares(shares)); err != nil {
+			return "", "", fmt.Errorf("failed to copy parent document shares: %w", err)
+		}
+	}
+	return info.ID, info.Path, nil
+}
+
+// deleteChildDocuments 删除从压缩包或邮件中展开的子文档，子文档的子文档递归删除
+func (s *DocumentService) deleteChildDocuments(ctx context.Context, fileID string) {
+	filters := map[string]interface{}{"parent_id": fileID}
+	for {
+		children, _, err := s.repo.List(0, 100, filters)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to list child documents")
+			return
+		}
+		if len(children) == 0 {
+			return
+		}
+		for _, child := range children {
+			if err := s.DeleteDocument(ctx, child.ID); err != nil {
+				// 删除失败时停止，避免反复列出同一个子文档
+				s.logger.WithContext(ctx).WithError(err).WithField("child_id", child.ID).Warn("Failed to delete child document")
+				return
+			}
+		}
+	}
+}
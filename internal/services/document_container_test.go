@@ -0,0 +1,159 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessContainer 测试把压缩包和其中的邮件展开为子文档，并随父文档一起删除
+func TestProcessContainer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-container-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, statusManager := setupDocumentTestEnv(t, tempDir)
+	docService.splitter = lineSplitter{}
+	WithFileValidator(filecheck.New(filecheck.WithAllowedTypes(".md", ".txt", ".eml", ".zip")))(docService)
+	ctx := context.Background()
+
+	eml := strings.Join([]string{
+		"From: 张三 <zhangsan@example.com>",
+		"To: lisi@example.com",
+		"Subject: Weekly report",
+		"Date: Mon, 02 Jun 2025 10:30:00 +0800",
+		`Content-Type: multipart/mixed; boundary="b"`,
+		"",
+		"--b",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"本周完成了检索模块的重构。",
+		"--b",
+		`Content-Disposition: attachment; filename="data.txt"`,
+		"",
+		"附件里的详细数据。",
+		"--b--",
+		"",
+	}, "\r\n")
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range []struct{ name, data string }{
+		{"guide/readme.md", "# 说明\n\n压缩包中的说明文档。"},
+		{"tool.exe", "MZ binary"},
+		{"mail/report.eml", eml},
+	} {
+		f, err := w.Create(entry.name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	info, err := docService.storage.Save(bytes.NewReader(buf.Bytes()), "资料.zip")
+	require.NoError(t, err)
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, info.ID, "资料.zip", info.Path, info.Size))
+	require.NoError(t, docService.UpdateDocumentTags(ctx, info.ID, "项目"))
+
+	require.NoError(t, docService.ProcessDocument(ctx, info.ID, info.Path))
+
+	parent, err := statusManager.GetDocument(ctx, info.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, parent.Status)
+	assert.Equal(t, 0, parent.SegmentCount)
+
+	children, _, err := docService.ListDocuments(ctx, 0, 10, map[string]interface{}{"parent_id": info.ID})
+	require.NoError(t, err)
+	require.Len(t, children, 2, "tool.exe should be skipped")
+	var mailID string
+	for _, child := range children {
+		assert.Equal(t, "项目", child.Tags)
+		assert.Equal(t, models.DocStatusCompleted, child.Status)
+		if child.FileName == "report.eml" {
+			mailID = child.ID
+		}
+	}
+	require.NotEmpty(t, mailID)
+
+	// 邮件继续展开为正文和附件，保留发件人和日期
+	mailChildren, _, err := docService.ListDocuments(ctx, 0, 10, map[string]interface{}{"parent_id": mailID})
+	require.NoError(t, err)
+	require.Len(t, mailChildren, 2)
+	for _, child := range mailChildren {
+		var metadata map[string]interface{}
+		require.NoError(t, json.Unmarshal(child.Metadata, &metadata))
+		assert.Equal(t, "张三 <zhangsan@example.com>", metadata[metaEmailFrom])
+		assert.Equal(t, "2025-06-02T10:30:00+08:00", metadata[metaEmailDate])
+		assert.Equal(t, "report.eml", metadata[metaParentFile])
+		assert.Greater(t, child.SegmentCount, 0)
+	}
+
+	// 删除父文档时一并删除全部子文档
+	require.NoError(t, docService.DeleteDocument(ctx, info.ID))
+	all, total, err := docService.ListDocuments(ctx, 0, 10, nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, all)
+}
+
+// TestContainerChildrenInheritACL 测试展开的子文档继承父文档的上传者和共享记录
+func TestContainerChildrenInheritACL(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-container-acl-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, statusManager := setupDocumentTestEnv(t, tempDir)
+	docService.splitter = lineSplitter{}
+	WithFileValidator(filecheck.New(filecheck.WithAllowedTypes(".md", ".zip")))(docService)
+	WithAccessPolicy(acl.NewPolicy())(docService)
+	alice := usage.WithTenant(context.Background(), "alice")
+	bob := usage.WithTenant(context.Background(), "bob")
+	carol := usage.WithTenant(context.Background(), "carol")
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("readme.md")
+	require.NoError(t, err)
+	_, err = f.Write([]byte("# 说明\n\n压缩包中的说明文档。"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	info, err := docService.storage.Save(bytes.NewReader(buf.Bytes()), "资料.zip")
+	require.NoError(t, err)
+	require.NoError(t, statusManager.MarkAsUploaded(alice, info.ID, "资料.zip", info.Path, info.Size))
+	// 后台任务处理文档时没有调用方身份
+	require.NoError(t, docService.ProcessDocument(context.Background(), info.ID, info.Path))
+
+	children, _, err := docService.ListDocuments(alice, 0, 10, map[string]interface{}{"parent_id": info.ID})
+	require.NoError(t, err)
+	require.Len(t, children, 1)
+	childID := children[0].ID
+	assert.Equal(t, "alice", children[0].Tenant)
+
+	assert.NoError(t, docService.CheckReadable(alice, childID))
+	assert.ErrorIs(t, docService.CheckReadable(bob, childID), models.ErrDocumentNotFound)
+	visible, _, err := docService.ListDocuments(bob, 0, 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, visible)
+
+	// 修改父文档的共享设置同时应用到子文档
+	_, err = docService.UpdateDocumentACL(alice, info.ID, false, []string{"carol"}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, docService.CheckReadable(carol, childID))
+	assert.ErrorIs(t, docService.CheckReadable(bob, childID), models.ErrDocumentNotFound)
+
+	_, err = docService.UpdateDocumentACL(alice, info.ID, true, nil, nil)
+	require.NoError(t, err)
+	assert.NoError(t, docService.CheckReadable(bob, childID))
+}
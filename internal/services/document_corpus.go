@@ -0,0 +1,597 @@
+package services
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+)
+
+const (
+	// CorpusFormat 语料归档格式标识
+	CorpusFormat = "docqa-corpus"
+	// CorpusVersion 语料归档格式版本
+	CorpusVersion = 1
+)
+
+// 语料归档中的文件名，原始文件位于corpusFilesDir/<文档ID>/<文件名>
+const (
+	corpusManifestFile  = "manifest.json"
+	corpusDocumentsFile = "documents.jsonl"
+	corpusSegmentsFile  = "segments.jsonl"
+	corpusFilesDir      = "files/"
+)
+
+// CorpusManifest 语料归档清单，位于归档的第一个文件
+type CorpusManifest struct {
+	Format         string    `json:"format"`                    // 格式标识，固定为docqa-corpus
+	Version        int       `json:"version"`                   // 格式版本
+	CreatedAt      time.Time `json:"created_at"`                // 导出时间
+	Documents      int       `json:"documents"`                 // 文档数量
+	Segments       int       `json:"segments"`                  // 段落数量
+	Vectors        int       `json:"vectors"`                   // 带向量的段落数量
+	Files          int       `json:"files"`                     // 原始文件数量
+	Dimension      int       `json:"dimension,omitempty"`       // 向量维度
+	EmbeddingModel string    `json:"embedding_model,omitempty"` // 生成向量的嵌入模型
+}
+
+// CorpusDocument 语料归档中的文档，documents.jsonl每行一个
+// 字段与数据库表结构解耦，只包含在其他环境中重建文档需要的信息
+type CorpusDocument struct {
+	ID          string                 `json:"id"`
+	FileName    string                 `json:"file_name"`
+	FileType    string                 `json:"file_type,omitempty"`
+	FileSize    int64                  `json:"file_size,omitempty"`
+	Status      models.DocumentStatus  `json:"status"`
+	Tags        []string               `json:"tags,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	UploadedAt  time.Time              `json:"uploaded_at"`
+	ProcessedAt *time.Time             `json:"processed_at,omitempty"`
+	File        string                 `json:"file,omitempty"` // 原始文件在归档中的路径，未导出文件时为空
+}
+
+// CorpusSegment 语料归档中的段落，segments.jsonl每行一个
+type CorpusSegment struct {
+	DocumentID string                 `json:"document_id"`
+	SegmentID  string                 `json:"segment_id"`
+	Position   int                    `json:"position"`
+	Text       string                 `json:"text"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Vector     []float32              `json:"vector,omitempty"`
+}
+
+// CorpusExportOptions 语料导出选项
+type CorpusExportOptions struct {
+	Filters        map[string]interface{} // 文档过滤条件，与文档列表相同
+	Limit          int                    // 最多导出的文档数，0表示不限制，用于抽样生成测试数据
+	WithoutFiles   bool                   // 不导出原始文件
+	WithoutVectors bool                   // 不导出向量，导入时按目标环境的嵌入模型重新向量化
+}
+
+// CorpusImportOptions 语料导入选项
+type CorpusImportOptions struct {
+	Overwrite bool // 文档已存在时覆盖，否则跳过该文档
+	Reembed   bool // 忽略归档中的向量，使用当前嵌入模型重新向量化段落
+}
+
+// CorpusImportResult 语料导入结果
+type CorpusImportResult struct {
+	Documents  int      `json:"documents"`         // 导入的文档数量
+	Segments   int      `json:"segments"`          // 导入的段落数量
+	Reembedded int      `json:"reembedded"`        // 其中重新向量化的段落数量
+	Files      int      `json:"files"`             // 写入存储的原始文件数量
+	Skipped    []string `json:"skipped,omitempty"` // 已存在而跳过的文档ID
+}
+
+// ExportCorpus 将文档、段落、向量和原始文件导出为可移植的语料归档
+// 归档为tar.gz，文档和段落使用与表结构无关的JSON Lines，可导入到使用不同数据库、
+// 向量数据库或存储的环境中，用于环境迁移和用生产数据生成测试环境的种子数据
+func (s *DocumentService) ExportCorpus(ctx context.Context, w io.Writer, opts CorpusExportOptions) (*CorpusManifest, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	var docs []*models.Document
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		limit := backupPageSize
+		if opts.Limit > 0 && opts.Limit-len(docs) < limit {
+			limit = opts.Limit - len(docs)
+		}
+		page, next, err := s.repo.ListAfter(cursor, limit, opts.Filters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		docs = append(docs, page...)
+		if next == "" || (opts.Limit > 0 && len(docs) >= opts.Limit) {
+			break
+		}
+		cursor = next
+	}
+
+	manifest := &CorpusManifest{
+		Format:    CorpusFormat,
+		Version:   CorpusVersion,
+		CreatedAt: time.Now(),
+		Documents: len(docs),
+	}
+	if !opts.WithoutVectors {
+		manifest.Dimension = s.vectorDB.GetDimension()
+		manifest.EmbeddingModel = s.EmbeddingModel()
+	}
+
+	// 段落可能很多，先写入临时文件以便在清单中记录数量
+	tmp, err := os.CreateTemp("", "docqa-corpus-*.jsonl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+	buffered := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(buffered)
+
+	records := make([]CorpusDocument, len(docs))
+	for i, doc := range docs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		records[i] = toCorpusDocument(doc)
+		if !opts.WithoutFiles && doc.FilePath != "" {
+			if ok, _ := s.storage.Exists(storageIDFromPath(doc.FilePath, doc.ID)); ok {
+				records[i].File = corpusFilesDir + doc.ID + "/" + path.Base(doc.FileName)
+				manifest.Files++
+			}
+		}
+
+		segments, err := s.repo.GetSegments(doc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get segments of %s: %w", doc.ID, err)
+		}
+		for _, seg := range segments {
+			record := CorpusSegment{
+				DocumentID: seg.DocumentID,
+				SegmentID:  seg.SegmentID,
+				Position:   seg.Position,
+				Text:       seg.Text,
+				Metadata:   decodeJSONMap(seg.Metadata),
+			}
+			if !opts.WithoutVectors && seg.VectorID != "" {
+				if vec, err := s.vectorDB.Get(seg.VectorID); err == nil {
+					record.Vector = vec.Vector
+					manifest.Vectors++
+				}
+			}
+			if err := enc.Encode(record); err != nil {
+				return nil, fmt.Errorf("failed to encode segment %s: %w", seg.SegmentID, err)
+			}
+			manifest.Segments++
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, err
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	// 原始文件写在文档列表之前，导入时先把文件写入存储，再创建指向新路径的文档记录
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeTarJSON(tw, corpusManifestFile, manifest); err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		if records[i].File == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := s.writeCorpusFile(tw, records[i].File, storageIDFromPath(doc.FilePath, doc.ID)); err != nil {
+			return nil, fmt.Errorf("failed to export file of %s: %w", doc.ID, err)
+		}
+	}
+	if err := writeTarJSONL(tw, corpusDocumentsFile, records); err != nil {
+		return nil, err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    corpusSegmentsFile,
+		Mode:    0644,
+		Size:    size,
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(tw, tmp); err != nil {
+		return nil, fmt.Errorf("failed to write segments: %w", err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"documents": manifest.Documents,
+		"segments":  manifest.Segments,
+		"vectors":   manifest.Vectors,
+		"files":     manifest.Files,
+	}).Info("Corpus exported")
+	return manifest, nil
+}
+
+// writeCorpusFile 把存储中的原始文件写入归档
+func (s *DocumentService) writeCorpusFile(tw *tar.Writer, name, storageID string) error {
+	reader, err := s.storage.Get(storageID)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	// 上传文件受大小限制，直接读入内存以确定tar头部的长度
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, name, data)
+}
+
+// ImportCorpus 导入ExportCorpus生成的语料归档
+// 归档的嵌入模型和向量维度与当前环境一致时直接写入归档中的向量，
+// 否则（或指定Reembed时）使用当前嵌入模型按原有分段重新向量化，分段结果保持不变
+func (s *DocumentService) ImportCorpus(ctx context.Context, r io.Reader, opts CorpusImportOptions) (*CorpusImportResult, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid corpus archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("invalid corpus archive: %w", err)
+	}
+	if hdr.Name != corpusManifestFile {
+		return nil, fmt.Errorf("invalid corpus archive: expected %s, got %s", corpusManifestFile, hdr.Name)
+	}
+	var manifest CorpusManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid corpus manifest: %w", err)
+	}
+	if manifest.Format != CorpusFormat {
+		return nil, fmt.Errorf("invalid corpus format: %q", manifest.Format)
+	}
+	if manifest.Version > CorpusVersion {
+		return nil, fmt.Errorf("unsupported corpus version: %d", manifest.Version)
+	}
+
+	// 嵌入模型或维度不同的向量不能和本环境的向量混用
+	dimension := s.vectorDB.GetDimension()
+	useVectors := !opts.Reembed &&
+		(dimension == 0 || manifest.Dimension == dimension) &&
+		(manifest.EmbeddingModel == "" || manifest.EmbeddingModel == s.EmbeddingModel())
+
+	imp := &corpusImport{
+		service:    s,
+		opts:       opts,
+		useVectors: useVectors,
+		dimension:  dimension,
+		files:      make(map[string]string),
+		decided:    make(map[string]bool),
+		imported:   make(map[string]*models.Document),
+		counts:     make(map[string]int),
+		result:     &CorpusImportResult{},
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return imp.result, err
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imp.result, fmt.Errorf("invalid corpus archive: %w", err)
+		}
+
+		switch {
+		case strings.HasPrefix(hdr.Name, corpusFilesDir):
+			err = imp.importFile(hdr.Name, tr)
+		case hdr.Name == corpusDocumentsFile:
+			err = readJSONL(tr, func(doc *CorpusDocument) error {
+				return imp.importDocument(ctx, doc)
+			})
+		case hdr.Name == corpusSegmentsFile:
+			err = readJSONL(tr, func(seg *CorpusSegment) error {
+				return imp.addSegment(ctx, seg)
+			})
+			if err == nil {
+				err = imp.flush(ctx)
+			}
+		default:
+			s.logger.WithField("file", hdr.Name).Warn("Skipping unknown file in corpus archive")
+		}
+		if err != nil {
+			return imp.result, fmt.Errorf("failed to import %s: %w", hdr.Name, err)
+		}
+	}
+
+	// 以实际导入的段落数为准更新文档记录
+	for id, doc := range imp.imported {
+		doc.SegmentCount = imp.counts[id]
+		if err := s.repo.Update(doc); err != nil {
+			return imp.result, fmt.Errorf("failed to update document %s: %w", id, err)
+		}
+	}
+	if err := s.vectorDB.Flush(); err != nil {
+		return imp.result, fmt.Errorf("failed to flush vector database: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"documents":  imp.result.Documents,
+		"segments":   imp.result.Segments,
+		"reembedded": imp.result.Reembedded,
+		"files":      imp.result.Files,
+		"skipped":    len(imp.result.Skipped),
+	}).Info("Corpus imported")
+	return imp.result, nil
+}
+
+// corpusImport 导入语料归档过程中的状态
+type corpusImport struct {
+	service    *DocumentService
+	opts       CorpusImportOptions
+	useVectors bool
+	dimension  int
+
+	files    map[string]string           // 归档中的文件路径到存储路径
+	decided  map[string]bool             // 文档ID到是否导入
+	imported map[string]*models.Document // 已导入的文档记录
+	counts   map[string]int              // 每个文档导入的段落数
+	pending  []*CorpusSegment            // 等待写入的段落
+	result   *CorpusImportResult
+}
+
+// shouldImport 判断文档是否需要导入，同一文档只判断一次
+// 已存在且允许覆盖时清除旧的向量和段落，旧文件由存储垃圾回收清理
+func (imp *corpusImport) shouldImport(id string) (bool, error) {
+	if ok, seen := imp.decided[id]; seen {
+		return ok, nil
+	}
+	s := imp.service
+	existing, err := s.repo.GetByID(id)
+	ok := err != nil || existing == nil || imp.opts.Overwrite
+	if ok && err == nil && existing != nil {
+		if err := s.vectorDB.DeleteByFileID(id); err != nil {
+			return false, fmt.Errorf("failed to delete existing vectors of %s: %w", id, err)
+		}
+		if err := s.repo.DeleteSegments(id); err != nil {
+			return false, fmt.Errorf("failed to delete existing segments of %s: %w", id, err)
+		}
+	}
+	if !ok {
+		imp.result.Skipped = append(imp.result.Skipped, id)
+	}
+	imp.decided[id] = ok
+	return ok, nil
+}
+
+// importFile 把归档中的原始文件写入存储
+func (imp *corpusImport) importFile(name string, r io.Reader) error {
+	parts := strings.SplitN(strings.TrimPrefix(name, corpusFilesDir), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid file path")
+	}
+	ok, err := imp.shouldImport(parts[0])
+	if err != nil || !ok {
+		return err
+	}
+	info, err := imp.service.storage.Save(r, parts[1])
+	if err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+	imp.files[name] = info.Path
+	imp.result.Files++
+	return nil
+}
+
+// importDocument 创建或覆盖文档记录
+func (imp *corpusImport) importDocument(ctx context.Context, record *CorpusDocument) error {
+	if record.ID == "" {
+		return fmt.Errorf("document id is required")
+	}
+	ok, err := imp.shouldImport(record.ID)
+	if err != nil || !ok {
+		return err
+	}
+
+	s := imp.service
+	doc := fromCorpusDocument(record)
+	doc.FilePath = imp.files[record.File]
+	if _, err := s.repo.GetByID(doc.ID); err == nil {
+		err = s.repo.Update(doc)
+	} else {
+		err = s.repo.Create(doc)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+	}
+	imp.imported[doc.ID] = doc
+	imp.result.Documents++
+	return nil
+}
+
+// addSegment 缓存段落，凑满一批后写入
+func (imp *corpusImport) addSegment(ctx context.Context, seg *CorpusSegment) error {
+	if _, ok := imp.imported[seg.DocumentID]; !ok {
+		// 被跳过的文档或归档中没有记录的文档
+		return nil
+	}
+	if seg.SegmentID == "" || seg.Text == "" {
+		return fmt.Errorf("segment_id and text are required")
+	}
+	imp.pending = append(imp.pending, seg)
+	if len(imp.pending) >= imp.service.batchSize {
+		return imp.flush(ctx)
+	}
+	return nil
+}
+
+// flush 写入缓存的段落，缺少可用向量的段落使用当前嵌入模型重新向量化
+func (imp *corpusImport) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	s := imp.service
+
+	var texts []string
+	var missing []int
+	vectors := make([][]float32, len(imp.pending))
+	for i, seg := range imp.pending {
+		if imp.useVectors && len(seg.Vector) > 0 && (imp.dimension == 0 || len(seg.Vector) == imp.dimension) {
+			vectors[i] = seg.Vector
+			continue
+		}
+		texts = append(texts, seg.Text)
+		missing = append(missing, i)
+	}
+	if len(texts) > 0 {
+		embeddings, err := s.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		for j, i := range missing {
+			vectors[i] = embeddings[j]
+		}
+		imp.result.Reembedded += len(texts)
+	}
+
+	docs := make([]vectordb.Document, len(imp.pending))
+	segments := make([]*models.DocumentSegment, len(imp.pending))
+	for i, seg := range imp.pending {
+		if seg.Metadata == nil {
+			seg.Metadata = make(map[string]interface{})
+		}
+		metaJSON, _ := json.Marshal(seg.Metadata)
+		docs[i] = vectordb.Document{
+			ID:        seg.SegmentID,
+			FileID:    seg.DocumentID,
+			FileName:  imp.imported[seg.DocumentID].FileName,
+			Position:  seg.Position,
+			Text:      seg.Text,
+			Vector:    vectors[i],
+			CreatedAt: time.Now(),
+			Metadata:  seg.Metadata,
+		}
+		segments[i] = &models.DocumentSegment{
+			DocumentID: seg.DocumentID,
+			SegmentID:  seg.SegmentID,
+			Position:   seg.Position,
+			Text:       seg.Text,
+			TextHash:   contentHash([]byte(seg.Text)),
+			Metadata:   metaJSON,
+			VectorID:   seg.SegmentID,
+		}
+		imp.counts[seg.DocumentID]++
+	}
+	if err := s.vectorDB.AddBatch(docs); err != nil {
+		return fmt.Errorf("failed to store vectors: %w", err)
+	}
+	if err := s.repo.UpsertSegments(segments); err != nil {
+		return fmt.Errorf("failed to save segments: %w", err)
+	}
+	imp.result.Segments += len(segments)
+	imp.pending = imp.pending[:0]
+	return nil
+}
+
+// toCorpusDocument 转换为归档中的文档
+func toCorpusDocument(doc *models.Document) CorpusDocument {
+	record := CorpusDocument{
+		ID:          doc.ID,
+		FileName:    doc.FileName,
+		FileType:    doc.FileType,
+		FileSize:    doc.FileSize,
+		Status:      doc.Status,
+		Tenant:      doc.Tenant,
+		Metadata:    decodeJSONMap(doc.Metadata),
+		UploadedAt:  doc.UploadedAt,
+		ProcessedAt: doc.ProcessedAt,
+	}
+	for _, tag := range strings.Split(doc.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			record.Tags = append(record.Tags, tag)
+		}
+	}
+	return record
+}
+
+// fromCorpusDocument 由归档中的文档创建文档记录
+func fromCorpusDocument(record *CorpusDocument) *models.Document {
+	doc := &models.Document{
+		ID:          record.ID,
+		FileName:    record.FileName,
+		FileType:    record.FileType,
+		FileSize:    record.FileSize,
+		Status:      record.Status,
+		Tags:        strings.Join(record.Tags, ","),
+		Tenant:      record.Tenant,
+		UploadedAt:  record.UploadedAt,
+		ProcessedAt: record.ProcessedAt,
+	}
+	if doc.FileName == "" {
+		doc.FileName = doc.ID
+	}
+	if doc.Status == "" {
+		doc.Status = models.DocStatusCompleted
+	}
+	if doc.Status == models.DocStatusCompleted {
+		doc.Progress = 100
+		doc.CurrentStage = models.StageCompleted
+	}
+	if len(record.Metadata) > 0 {
+		if data, err := json.Marshal(record.Metadata); err == nil {
+			doc.Metadata = datatypes.JSON(data)
+		}
+	}
+	return doc
+}
+
+// decodeJSONMap 解码JSON对象，为空或格式错误时返回nil
+func decodeJSONMap(data datatypes.JSON) map[string]interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCorpusExportImport 测试导出语料归档后在清空的环境中导入，以及模型不同时重新向量化
+func TestCorpusExportImport(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-corpus-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	input := strings.Join([]string{
+		`{"file_id":"corpus-1","file_name":"guide.md","text":"第一段","vector":[0.1,0.2,0.3,0.4]}`,
+		`{"file_id":"corpus-1","text":"第二段","vector":[0.2,0.3,0.4,0.5]}`,
+		`{"file_id":"corpus-2","file_name":"faq.md","text":"常见问题","vector":[0.5,0.5,0.5,0.5]}`,
+	}, "\n")
+	_, err = docService.ImportEmbeddings(ctx, strings.NewReader(input), ImportOptions{})
+	require.NoError(t, err)
+
+	// corpus-1带原始文件和标签
+	info, err := docService.storage.Save(strings.NewReader("# guide"), "guide.md")
+	require.NoError(t, err)
+	doc, err := docService.repo.GetByID("corpus-1")
+	require.NoError(t, err)
+	doc.FilePath = info.Path
+	doc.Tags = "ops,guide"
+	require.NoError(t, docService.repo.Update(doc))
+
+	original, err := vectorDB.Get("corpus-1_1")
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	manifest, err := docService.ExportCorpus(ctx, &archive, CorpusExportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, CorpusFormat, manifest.Format)
+	assert.Equal(t, 2, manifest.Documents)
+	assert.Equal(t, 3, manifest.Segments)
+	assert.Equal(t, 3, manifest.Vectors)
+	assert.Equal(t, 1, manifest.Files)
+	assert.Equal(t, 4, manifest.Dimension)
+	assert.Equal(t, []string{"manifest.json", "files/corpus-1/guide.md", "documents.jsonl", "segments.jsonl"}, corpusEntries(t, archive.Bytes()))
+
+	// 按标签筛选并且不导出向量和文件
+	var sample bytes.Buffer
+	manifest, err = docService.ExportCorpus(ctx, &sample, CorpusExportOptions{
+		Filters:        map[string]interface{}{"tags": "ops"},
+		WithoutFiles:   true,
+		WithoutVectors: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, manifest.Documents)
+	assert.Equal(t, 2, manifest.Segments)
+	assert.Zero(t, manifest.Vectors)
+	assert.Zero(t, manifest.Files)
+
+	var limited bytes.Buffer
+	manifest, err = docService.ExportCorpus(ctx, &limited, CorpusExportOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, manifest.Documents)
+
+	require.NoError(t, docService.DeleteDocument(ctx, "corpus-1"))
+	require.NoError(t, docService.DeleteDocument(ctx, "corpus-2"))
+
+	result, err := docService.ImportCorpus(ctx, bytes.NewReader(archive.Bytes()), CorpusImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &CorpusImportResult{Documents: 2, Segments: 3, Files: 1}, result)
+
+	record, err := statusManager.GetDocument(ctx, "corpus-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, record.Status)
+	assert.Equal(t, "ops,guide", record.Tags)
+	assert.Equal(t, 2, record.SegmentCount)
+	reader, err := docService.storage.Get(storageIDFromPath(record.FilePath, ""))
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "# guide", string(content))
+
+	// 归档中的向量原样写入
+	vec, err := vectorDB.Get("corpus-1_1")
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, original.Vector, vec.Vector, 1e-6)
+
+	// 已存在的文档默认跳过
+	result, err = docService.ImportCorpus(ctx, bytes.NewReader(archive.Bytes()), CorpusImportOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, result.Documents)
+	assert.ElementsMatch(t, []string{"corpus-1", "corpus-2"}, result.Skipped)
+
+	// 没有向量的归档使用当前嵌入模型重新向量化
+	result, err = docService.ImportCorpus(ctx, bytes.NewReader(sample.Bytes()), CorpusImportOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, &CorpusImportResult{Documents: 1, Segments: 2, Reembedded: 2}, result)
+	vec, err = vectorDB.Get("corpus-1_1")
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Vector, vec.Vector)
+
+	// 向量维度不同的环境重新向量化全部段落
+	other, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 8})
+	require.NoError(t, err)
+	otherService := NewDocumentService(docService.storage, nil, nil, &testEmbeddingClient{dimension: 8}, other,
+		WithDocumentRepository(docService.repo))
+	result, err = otherService.ImportCorpus(ctx, bytes.NewReader(archive.Bytes()), CorpusImportOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Reembedded)
+	vec, err = other.Get("corpus-2_0")
+	require.NoError(t, err)
+	assert.Len(t, vec.Vector, 8)
+}
+
+// corpusEntries 返回归档中的文件名
+func corpusEntries(t *testing.T, data []byte) []string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// 近似重复段落的处理方式
+const (
+	DuplicateModeSkip = "skip" // 重复段落只保存段落记录，不向量化也不写入向量库
+	DuplicateModeFlag = "flag" // 重复段落照常写入向量库，在元数据中记录原段落ID
+)
+
+// DefaultDuplicateDistance 默认判定近似重复的最大SimHash汉明距离
+const DefaultDuplicateDistance = 3
+
+// duplicateDetector 索引时的近似重复段落检测
+// 页眉、页脚、免责声明等模板文本在大量文档中重复出现，会挤占检索结果；
+// 按段落文本的SimHash与已索引的段落比较，第一次出现的段落作为原段落，之后的近似重复段落按mode跳过或标记
+type duplicateDetector struct {
+	mode  string
+	index *document.DuplicateIndex
+
+	mu     sync.Mutex
+	loaded bool // 是否已从段落表加载已有指纹
+}
+
+// segmentDuplicate 单个段落的检测结果
+type segmentDuplicate struct {
+	fingerprint uint64
+	duplicateOf string // 近似重复的原段落ID，为空表示不是重复段落
+}
+
+// WithDuplicateDetection 启用索引时的近似重复段落检测
+// mode为skip或flag，其他值按skip处理；maxDistance为判定重复的最大汉明距离
+func WithDuplicateDetection(mode string, maxDistance int) DocumentOption {
+	return func(s *DocumentService) {
+		if mode != DuplicateModeFlag {
+			mode = DuplicateModeSkip
+		}
+		s.dedup = &duplicateDetector{mode: mode, index: document.NewDuplicateIndex(maxDistance)}
+	}
+}
+
+// loadDuplicateIndex 首次检测时从段落表加载已有段落的指纹，加载失败时下次重试
+func (s *DocumentService) loadDuplicateIndex() error {
+	d := s.dedup
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.loaded {
+		return nil
+	}
+	segments, err := s.repo.ListSegmentFingerprints()
+	if err != nil {
+		return fmt.Errorf("failed to load segment fingerprints: %w", err)
+	}
+	for _, seg := range segments {
+		d.index.Add(seg.DocumentID, seg.SegmentID, uint64(seg.Fingerprint))
+	}
+	d.loaded = true
+	return nil
+}
+
+// detectDuplicates 计算段落指纹并查找近似重复，返回按段落序号索引的结果，未启用检测时返回nil
+// 不是重复的段落立即加入索引，同一文档中重复出现的段落也会被检测到。检测出错时不影响文档处理
+func (s *DocumentService) detectDuplicates(ctx context.Context, fileID string, segments []document.Content) map[int]segmentDuplicate {
+	if s.dedup == nil {
+		return nil
+	}
+	if err := s.loadDuplicateIndex(); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Near-duplicate detection skipped")
+		return nil
+	}
+
+	// 重新处理时文档自己的旧段落不能作为原段落
+	s.dedup.index.RemoveDocument(fileID)
+
+	result := make(map[int]segmentDuplicate, len(segments))
+	for _, content := range segments {
+		hash := document.SimHash(content.Text)
+		dup := segmentDuplicate{fingerprint: hash}
+		if hash != 0 {
+			if original, ok := s.findOriginal(fileID, hash); ok {
+				dup.duplicateOf = original
+			} else {
+				s.dedup.index.Add(fileID, segmentID(fileID, content.Index), hash)
+			}
+		}
+		result[content.Index] = dup
+	}
+	return result
+}
+
+// findOriginal 查找近似重复的原段落
+// 其他文档的段落需要仍在向量库中，处理失败或已删除的段落从索引中移除后继续查找
+func (s *DocumentService) findOriginal(fileID string, hash uint64) (string, bool) {
+	for {
+		id, ok := s.dedup.index.Find(hash)
+		if !ok {
+			return "", false
+		}
+		if strings.HasPrefix(id, fileID+"_") {
+			return id, true
+		}
+		if _, err := s.vectorDB.Get(id); err == nil {
+			return id, true
+		}
+		s.dedup.index.Remove(id)
+	}
+}
+
+// markDuplicate 在段落记录和向量库文档上记录检测结果
+func (s *DocumentService) markDuplicate(doc *vectordb.Document, segment *models.DocumentSegment, dup segmentDuplicate) {
+	segment.Fingerprint = int64(dup.fingerprint)
+	if dup.duplicateOf == "" {
+		return
+	}
+	segment.DuplicateOf = dup.duplicateOf
+	doc.Metadata["duplicate_of"] = dup.duplicateOf
+}
+
+// suppressDuplicates 跳过模式下从待向量化的段落中去掉重复段落，重复段落只保存段落记录
+// 返回需要向量化的段落
+func (s *DocumentService) suppressDuplicates(ctx context.Context, fileID, filePath string, segments []document.Content, dups map[int]segmentDuplicate) []document.Content {
+	if s.dedup == nil || s.dedup.mode != DuplicateModeSkip || len(dups) == 0 {
+		return segments
+	}
+
+	indexed := make([]document.Content, 0, len(segments))
+	var suppressed []*models.DocumentSegment
+	for _, content := range segments {
+		dup := dups[content.Index]
+		if dup.duplicateOf == "" {
+			indexed = append(indexed, content)
+			continue
+		}
+		_, segment := buildSegment(fileID, filePath, content, nil, nil)
+		segment.Fingerprint = int64(dup.fingerprint)
+		segment.DuplicateOf = dup.duplicateOf
+		segment.Suppressed = true
+		suppressed = append(suppressed, segment)
+	}
+	if len(suppressed) == 0 {
+		return segments
+	}
+
+	if err := s.repo.SaveSegments(suppressed); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to save suppressed duplicate segments")
+	}
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id":    fileID,
+		"suppressed": len(suppressed),
+		"segments":   len(segments),
+	}).Info("Suppressed near-duplicate segments")
+	return indexed
+}
+
+// releaseDuplicates 文档的段落被删除后，以其为原段落而未写入向量库的重复段落重新向量化，避免内容从检索中消失
+// 同一原段落的多个重复段落只恢复第一个，其余改为指向恢复的段落
+func (s *DocumentService) releaseDuplicates(ctx context.Context, fileID string) {
+	if s.dedup == nil {
+		return
+	}
+	s.dedup.index.RemoveDocument(fileID)
+
+	segments, err := s.repo.ListSuppressedDuplicates(fileID)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to list suppressed duplicates")
+		return
+	}
+	if len(segments) == 0 {
+		return
+	}
+
+	promoted := make(map[string]string) // 原段落ID -> 恢复的段落ID
+	byDocument := make(map[string][]*models.DocumentSegment)
+	var order []string
+	var repointed []*models.DocumentSegment
+	for _, seg := range segments {
+		if id, ok := promoted[seg.DuplicateOf]; ok {
+			seg.DuplicateOf = id
+			repointed = append(repointed, seg)
+			continue
+		}
+		promoted[seg.DuplicateOf] = seg.SegmentID
+		if _, ok := byDocument[seg.DocumentID]; !ok {
+			order = append(order, seg.DocumentID)
+		}
+		byDocument[seg.DocumentID] = append(byDocument[seg.DocumentID], seg)
+	}
+
+	// 按所属文档分组向量化，使段落路由到文档语言对应的嵌入模型
+	restored := 0
+	for _, docID := range order {
+		if err := s.restoreSegments(ctx, docID, byDocument[docID]); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("file_id", docID).Warn("Failed to restore suppressed duplicates")
+			continue
+		}
+		restored += len(byDocument[docID])
+	}
+	if err := s.repo.UpsertSegments(repointed); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to update duplicate segments")
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id":  fileID,
+		"restored": restored,
+	}).Info("Restored suppressed duplicate segments")
+}
+
+// restoreSegments 向量化文档中被跳过的重复段落并写入向量库，之后作为原段落参与检测
+func (s *DocumentService) restoreSegments(ctx context.Context, docID string, segments []*models.DocumentSegment) error {
+	doc, err := s.repo.GetByID(docID)
+	if err != nil || doc == nil {
+		return fmt.Errorf("failed to get document %s: %v", docID, err)
+	}
+	ctx = s.withDocumentLanguage(ctx, docID)
+	docMetadata := s.documentMetadata(docID)
+
+	for i := 0; i < len(segments); i += s.batchSize {
+		batch := segments[i:min(i+s.batchSize, len(segments))]
+		texts := make([]string, len(batch))
+		for j, seg := range batch {
+			texts[j] = seg.Text
+		}
+		vectors, err := s.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+
+		docs := make([]vectordb.Document, len(batch))
+		for j, seg := range batch {
+			content := document.Content{Text: seg.Text, Index: seg.Position}
+			if len(seg.Metadata) > 0 {
+				_ = json.Unmarshal(seg.Metadata, &content.Metadata)
+			}
+			docs[j], _ = buildSegment(docID, doc.FilePath, content, vectors[j], docMetadata)
+		}
+		if err := s.vectorDB.AddBatch(docs); err != nil {
+			return fmt.Errorf("failed to store vectors: %w", err)
+		}
+
+		for _, seg := range batch {
+			seg.DuplicateOf = ""
+			seg.Suppressed = false
+			if seg.Fingerprint != 0 {
+				s.dedup.index.Add(seg.DocumentID, seg.SegmentID, uint64(seg.Fingerprint))
+			}
+		}
+		if err := s.repo.UpsertSegments(batch); err != nil {
+			return fmt.Errorf("failed to update segments: %w", err)
+		}
+	}
+	return nil
+}
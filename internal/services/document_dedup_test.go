@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDuplicateSuppression 测试跳过模式下近似重复的段落不向量化，原段落删除后重复段落重新写入向量库
+func TestDuplicateSuppression(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-dedup-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	WithDuplicateDetection(DuplicateModeSkip, DefaultDuplicateDistance)(docService)
+	embedder := &countingEmbeddingClient{testEmbeddingClient: testEmbeddingClient{dimension: 4}}
+	docService.embedder = embedder
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b"} {
+		require.NoError(t, docService.repo.Create(&models.Document{
+			ID:         id,
+			FileName:   id + ".md",
+			FileType:   "markdown",
+			FilePath:   "/tmp/" + id + ".md",
+			Status:     models.DocStatusCompleted,
+			UploadedAt: time.Now(),
+		}))
+	}
+
+	footer := "本文档仅供内部参考，未经许可不得转载。版权所有 © 2024 示例公司 第 %d 页"
+	require.NoError(t, docService.processBatches(ctx, "a", "/tmp/a.md", []document.Content{
+		{Text: "安装前请确认服务器满足最低配置要求", Index: 0},
+		{Text: fmt.Sprintf(footer, 1), Index: 1},
+		{Text: fmt.Sprintf(footer, 2), Index: 2}, // 同一文档中重复出现的页脚
+	}))
+	require.NoError(t, docService.processBatches(ctx, "b", "/tmp/b.md", []document.Content{
+		{Text: "升级时先备份数据库再替换可执行文件", Index: 0},
+		{Text: fmt.Sprintf(footer, 9), Index: 1},
+	}))
+	assert.Equal(t, []string{"安装前请确认服务器满足最低配置要求", fmt.Sprintf(footer, 1), "升级时先备份数据库再替换可执行文件"}, embedder.texts)
+
+	_, err = vectorDB.Get("b_1")
+	assert.Error(t, err)
+	segment, err := docService.repo.GetSegment("b_1")
+	require.NoError(t, err)
+	assert.True(t, segment.Suppressed)
+	assert.Equal(t, "a_1", segment.DuplicateOf)
+	assert.Equal(t, int64(document.SimHash(fmt.Sprintf(footer, 9))), segment.Fingerprint)
+
+	duplicates, suppressed, err := repository.NewStatsRepository().CountDuplicateSegments()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), duplicates)
+	assert.Equal(t, int64(2), suppressed)
+
+	// 删除原段落所在的文档后，重复段落重新写入向量库并作为新的原段落
+	require.NoError(t, vectorDB.DeleteByFileID("a"))
+	require.NoError(t, docService.repo.DeleteSegments("a"))
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID: "c", FileName: "c.md", FileType: "markdown", FilePath: "/tmp/c.md",
+		Status: models.DocStatusCompleted, UploadedAt: time.Now(),
+	}))
+	docService.releaseDuplicates(ctx, "a")
+
+	restored, err := vectorDB.Get("b_1")
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf(footer, 9), restored.Text)
+	segment, err = docService.repo.GetSegment("b_1")
+	require.NoError(t, err)
+	assert.False(t, segment.Suppressed)
+	assert.Empty(t, segment.DuplicateOf)
+
+	// 新文档中的页脚成为恢复段落的重复
+	require.NoError(t, docService.processBatches(ctx, "c", "/tmp/c.md", []document.Content{
+		{Text: fmt.Sprintf(footer, 4), Index: 0},
+	}))
+	segment, err = docService.repo.GetSegment("c_0")
+	require.NoError(t, err)
+	assert.Equal(t, "b_1", segment.DuplicateOf)
+}
+
+// TestDuplicateFlagging 测试标记模式下重复段落照常写入向量库并记录原段落
+func TestDuplicateFlagging(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-dedup-flag-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	WithDuplicateDetection(DuplicateModeFlag, DefaultDuplicateDistance)(docService)
+	ctx := context.Background()
+
+	require.NoError(t, docService.processBatches(ctx, "a", "/tmp/a.md", []document.Content{
+		{Text: "Confidential: for internal use only. Page 1", Index: 0},
+		{Text: "Confidential: for internal use only. Page 2", Index: 1},
+	}))
+
+	flagged, err := vectorDB.Get("a_1")
+	require.NoError(t, err)
+	assert.Equal(t, "a_0", flagged.Metadata["duplicate_of"])
+	segment, err := docService.repo.GetSegment("a_1")
+	require.NoError(t, err)
+	assert.Equal(t, "a_0", segment.DuplicateOf)
+	assert.False(t, segment.Suppressed)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// WithEmbedParallelism 设置处理单个文档时同时进行的EmbedBatch请求数，默认为1（串行）
+func WithEmbedParallelism(n int) DocumentOption {
+	return func(s *DocumentService) {
+		if n > 0 {
+			s.embedParallelism = n
+		}
+	}
+}
+
+// embedResult 单个批次的向量化结果
+type embedResult struct {
+	vectors [][]float32
+	err     error
+}
+
+// embedBatches 为各批次文本生成向量，按批次顺序回调store
+// 最多embedParallelism个批次同时向量化，store在调用方goroutine中按批次顺序执行，
+// 向量数据库写入和进度更新与串行处理的顺序相同，不要求向量数据库支持并发写入。
+// 文本为空的批次不调用嵌入模型，store收到nil。任一批次失败时取消其余请求并返回错误
+func (s *DocumentService) embedBatches(ctx context.Context, batches [][]string, store func(i int, vectors [][]float32) error) error {
+	embed := func(ctx context.Context, texts []string) ([][]float32, error) {
+		if len(texts) == 0 {
+			return nil, nil
+		}
+		vectors, err := s.embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		return vectors, nil
+	}
+
+	parallelism := s.embedParallelism
+	if parallelism > len(batches) {
+		parallelism = len(batches)
+	}
+	if parallelism <= 1 {
+		for i, texts := range batches {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			vectors, err := embed(ctx, texts)
+			if err != nil {
+				return err
+			}
+			if err := store(i, vectors); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan embedResult, len(batches))
+	for i := range results {
+		results[i] = make(chan embedResult, 1)
+	}
+	// 槽位在批次被store消费后才释放，已完成但未写入的批次也计入并发数，内存占用有上限
+	slots := make(chan struct{}, parallelism)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i, texts := range batches {
+			select {
+			case <-ctx.Done():
+				return
+			case slots <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(i int, texts []string) {
+				defer wg.Done()
+				vectors, err := embed(ctx, texts)
+				results[i] <- embedResult{vectors: vectors, err: err}
+			}(i, texts)
+		}
+	}()
+
+	err := func() error {
+		for i := range batches {
+			var res embedResult
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case res = <-results[i]:
+			}
+			<-slots
+			if res.err != nil {
+				return res.err
+			}
+			if err := store(i, res.vectors); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	cancel()
+	wg.Wait()
+	return err
+}
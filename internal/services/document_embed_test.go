@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowEmbeddingClient 记录同时进行的请求数，后面的批次先完成以检查写入顺序
+type slowEmbeddingClient struct {
+	testEmbeddingClient
+	mu      sync.Mutex
+	active  int
+	peak    int
+	failOn  string
+	started int
+}
+
+func (c *slowEmbeddingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.mu.Lock()
+	c.active++
+	c.started++
+	if c.active > c.peak {
+		c.peak = c.active
+	}
+	order := c.started
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+
+	if texts[0] == c.failOn {
+		return nil, errors.New("embedding service unavailable")
+	}
+	select {
+	case <-time.After(time.Duration(10-order%5) * 5 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return c.testEmbeddingClient.EmbedBatch(ctx, texts)
+}
+
+func TestEmbedBatches(t *testing.T) {
+	batches := make([][]string, 12)
+	for i := range batches {
+		batches[i] = []string{fmt.Sprintf("batch-%d", i)}
+	}
+	batches[5] = nil
+
+	for _, parallelism := range []int{1, 4} {
+		t.Run(fmt.Sprintf("parallelism=%d", parallelism), func(t *testing.T) {
+			client := &slowEmbeddingClient{testEmbeddingClient: testEmbeddingClient{dimension: 4}}
+			s := NewDocumentService(nil, nil, nil, client, nil, WithEmbedParallelism(parallelism))
+
+			var stored []int
+			err := s.embedBatches(context.Background(), batches, func(i int, vectors [][]float32) error {
+				stored = append(stored, i)
+				if batches[i] == nil {
+					assert.Nil(t, vectors)
+				} else {
+					assert.Equal(t, generateTestVector(4, batches[i][0]), vectors[0])
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, stored)
+			assert.Equal(t, parallelism, client.peak)
+			assert.Equal(t, 11, client.started)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		client := &slowEmbeddingClient{testEmbeddingClient: testEmbeddingClient{dimension: 4}, failOn: "batch-3"}
+		s := NewDocumentService(nil, nil, nil, client, nil, WithEmbedParallelism(4))
+
+		var stored []int
+		err := s.embedBatches(context.Background(), batches, func(i int, vectors [][]float32) error {
+			stored = append(stored, i)
+			return nil
+		})
+		assert.ErrorContains(t, err, "embedding service unavailable")
+		// 失败批次之前的批次已按顺序写入，之后的不再写入
+		assert.Equal(t, []int{0, 1, 2}, stored)
+		assert.Zero(t, client.active)
+	})
+}
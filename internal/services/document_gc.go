@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultGCMinAge 没有文档记录的文件至少存在多久才视为孤立文件
+// 上传时先保存文件再创建文档记录，宽限期避免误删正在上传的文件
+const DefaultGCMinAge = 24 * time.Hour
+
+// StorageGCOptions 存储垃圾回收选项
+type StorageGCOptions struct {
+	DryRun bool          // 只报告不删除
+	MinAge time.Duration // 孤立文件的最短存在时间，0表示使用DefaultGCMinAge
+}
+
+// OrphanedFile 存储中没有对应文档记录的文件
+type OrphanedFile struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// DanglingVectors 向量数据库中没有对应文档记录的文件的段落
+type DanglingVectors struct {
+	FileID   string `json:"file_id"`
+	Segments int    `json:"segments"`
+}
+
+// StorageGCReport 存储垃圾回收报告
+type StorageGCReport struct {
+	DryRun          bool              `json:"dry_run"`
+	Documents       int               `json:"documents"`         // 文档记录数
+	StoredFiles     int               `json:"stored_files"`      // 存储中的文件数
+	IndexedFiles    int               `json:"indexed_files"`     // 向量数据库中的文件数
+	OrphanedFiles   []OrphanedFile    `json:"orphaned_files"`    // 没有文档记录的文件
+	OrphanedBytes   int64             `json:"orphaned_bytes"`    // 孤立文件的总字节数
+	DanglingVectors []DanglingVectors `json:"dangling_vectors"`  // 没有文档记录的向量
+	MissingFiles    []string          `json:"missing_files"`     // 文件已丢失的文档ID，只报告不处理
+	Deleted         int               `json:"deleted"`           // 删除的文件和向量组数量
+	Failed          int               `json:"failed"`            // 删除失败的数量
+	Warning         string            `json:"warning,omitempty"` // 跳过删除的原因
+	Duration        string            `json:"duration"`          // 耗时
+	Errors          []string          `json:"errors,omitempty"`  // 删除失败的原因
+}
+
+// WithGCMinAge 设置定时垃圾回收中孤立文件的最短存在时间
+func WithGCMinAge(age time.Duration) DocumentOption {
+	return func(s *DocumentService) {
+		s.gcMinAge = age
+	}
+}
+
+// CollectStorageGarbage 比对存储中的文件、文档记录和向量数据库，清理孤立的文件和向量
+// 没有文档记录且超过宽限期的文件视为孤立文件，没有文档记录的向量视为悬空向量；
+// 有文档记录但文件丢失的文档只报告，由管理员决定是否删除。
+// 没有任何文档记录但存储中有文件时，很可能是连接了错误的数据库，只报告不删除
+func (s *DocumentService) CollectStorageGarbage(ctx context.Context, opts StorageGCOptions) (*StorageGCReport, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	if opts.MinAge <= 0 {
+		opts.MinAge = DefaultGCMinAge
+	}
+	start := time.Now()
+	report := &StorageGCReport{
+		DryRun:          opts.DryRun,
+		OrphanedFiles:   []OrphanedFile{},
+		DanglingVectors: []DanglingVectors{},
+		MissingFiles:    []string{},
+	}
+
+	// 先列出存储和向量，再读取文档记录：期间新上传的文档已有记录，不会被误判
+	files, err := s.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	segments, err := vectordb.FileSegmentCounts(s.vectorDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed files: %w", err)
+	}
+	report.StoredFiles = len(files)
+	report.IndexedFiles = len(segments)
+
+	// 文档ID和存储ID，重新抓取过的文档两者不同；文档提取的图片也保存在存储中
+	documents := make(map[string]bool)
+	storageIDs := make(map[string]string)
+	imageIDs := make(map[string]bool)
+	for offset := 0; ; offset += maintenancePageSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		docs, _, err := s.repo.List(offset, maintenancePageSize, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			documents[doc.ID] = true
+			storageIDs[storageIDFromPath(doc.FilePath, doc.ID)] = doc.ID
+			for _, img := range doc.StoredImages() {
+				imageIDs[img.ID] = true
+			}
+		}
+		if len(docs) < maintenancePageSize {
+			break
+		}
+	}
+	report.Documents = len(documents)
+
+	stored := make(map[string]bool, len(files))
+	cutoff := start.Add(-opts.MinAge)
+	for _, file := range files {
+		stored[file.ID] = true
+		if _, ok := storageIDs[file.ID]; ok || documents[file.ID] || imageIDs[file.ID] {
+			continue
+		}
+		// 修改时间未知的文件按已过宽限期处理
+		if !file.ModTime.IsZero() && file.ModTime.After(cutoff) {
+			continue
+		}
+		report.OrphanedFiles = append(report.OrphanedFiles, OrphanedFile{
+			ID:      file.ID,
+			Name:    file.Name,
+			Size:    file.Size,
+			ModTime: file.ModTime,
+		})
+		report.OrphanedBytes += file.Size
+	}
+	for fileID, count := range segments {
+		if !documents[fileID] {
+			report.DanglingVectors = append(report.DanglingVectors, DanglingVectors{FileID: fileID, Segments: count})
+		}
+	}
+	for storageID, docID := range storageIDs {
+		if !stored[storageID] {
+			report.MissingFiles = append(report.MissingFiles, docID)
+		}
+	}
+	sort.Slice(report.OrphanedFiles, func(i, j int) bool { return report.OrphanedFiles[i].ID < report.OrphanedFiles[j].ID })
+	sort.Slice(report.DanglingVectors, func(i, j int) bool { return report.DanglingVectors[i].FileID < report.DanglingVectors[j].FileID })
+	sort.Strings(report.MissingFiles)
+
+	garbage := len(report.OrphanedFiles) + len(report.DanglingVectors)
+	if !opts.DryRun && garbage > 0 && report.Documents == 0 {
+		report.Warning = "no document records found, refusing to delete"
+		report.DryRun = true
+	}
+	if !report.DryRun {
+		s.deleteGarbage(ctx, report)
+		if report.Deleted > 0 {
+			s.audit.Record(ctx, models.AuditActionStorageGC, "storage",
+				fmt.Sprintf("deleted %d orphaned files (%d bytes) and vectors of %d files",
+					len(report.OrphanedFiles), report.OrphanedBytes, len(report.DanglingVectors)))
+		}
+	}
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"dry_run":          report.DryRun,
+		"orphaned_files":   len(report.OrphanedFiles),
+		"orphaned_bytes":   report.OrphanedBytes,
+		"dangling_vectors": len(report.DanglingVectors),
+		"missing_files":    len(report.MissingFiles),
+		"deleted":          report.Deleted,
+		"failed":           report.Failed,
+	}).Info("Storage garbage collection finished")
+	return report, nil
+}
+
+// deleteGarbage 删除报告中的孤立文件和悬空向量
+func (s *DocumentService) deleteGarbage(ctx context.Context, report *StorageGCReport) {
+	fail := func(kind, id string, err error) {
+		report.Failed++
+		report.Errors = append(report.Errors, fmt.Sprintf("%s %s: %v", kind, id, err))
+		s.logger.WithContext(ctx).WithError(err).WithField("id", id).Warnf("Failed to delete orphaned %s", kind)
+	}
+	for _, file := range report.OrphanedFiles {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.storage.Delete(file.ID); err != nil {
+			fail("file", file.ID, err)
+			continue
+		}
+		report.Deleted++
+	}
+	for _, dangling := range report.DanglingVectors {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.vectorDB.DeleteByFileID(dangling.FileID); err != nil {
+			fail("vectors", dangling.FileID, err)
+			continue
+		}
+		report.Deleted++
+	}
+}
+
+// CollectGarbage 按配置的宽限期清理孤立的文件和向量，供定时任务调用
+// 返回发现的孤立对象数、删除数和失败数
+func (s *DocumentService) CollectGarbage(ctx context.Context) (found, deleted, failed int, err error) {
+	report, err := s.CollectStorageGarbage(ctx, StorageGCOptions{MinAge: s.gcMinAge})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	found = len(report.OrphanedFiles) + len(report.DanglingVectors)
+	if report.Warning != "" {
+		return found, 0, 0, fmt.Errorf("storage gc skipped: %s", report.Warning)
+	}
+	return found, report.Deleted, report.Failed, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCollectStorageGarbage 测试报告和清理孤立文件与悬空向量
+func TestCollectStorageGarbage(t *testing.T) {
+	tempDir := t.TempDir()
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	paths := make(map[string]string)
+	save := func(name string) string {
+		info, err := docService.storage.Save(strings.NewReader("内容"), name)
+		require.NoError(t, err)
+		paths[info.ID] = filepath.Join(tempDir, info.Path)
+		return info.ID
+	}
+	addVectors := func(fileID string, n int) {
+		for i := 0; i < n; i++ {
+			require.NoError(t, vectorDB.Add(vectordb.Document{
+				ID:       fileID + "_" + string(rune('a'+i)),
+				FileID:   fileID,
+				Position: i,
+				Vector:   []float32{1, 0, 0, 0},
+			}))
+		}
+	}
+
+	// 没有文档记录时只报告不删除
+	orphan := save("orphan.txt")
+	report, err := docService.CollectStorageGarbage(ctx, StorageGCOptions{MinAge: time.Nanosecond})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.NotEmpty(t, report.Warning)
+	assert.Len(t, report.OrphanedFiles, 1)
+
+	kept := save("kept.txt")
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, kept, "kept.txt", paths[kept], 6))
+	addVectors(kept, 2)
+	addVectors("ghost", 3)
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "lost", "lost.txt", filepath.Join(tempDir, "lost.txt"), 6))
+	fresh := save("fresh.txt")
+
+	// 宽限期内的文件不视为孤立文件
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(paths[orphan], old, old))
+
+	report, err = docService.CollectStorageGarbage(ctx, StorageGCOptions{DryRun: true, MinAge: time.Hour})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Documents)
+	assert.Equal(t, 3, report.StoredFiles)
+	require.Len(t, report.OrphanedFiles, 1)
+	assert.Equal(t, orphan, report.OrphanedFiles[0].ID)
+	assert.EqualValues(t, 6, report.OrphanedBytes)
+	assert.Equal(t, []DanglingVectors{{FileID: "ghost", Segments: 3}}, report.DanglingVectors)
+	assert.Equal(t, []string{"lost"}, report.MissingFiles)
+	assert.Zero(t, report.Deleted)
+
+	docService.gcMinAge = time.Hour
+	found, deleted, failed, err := docService.CollectGarbage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found)
+	assert.Equal(t, 2, deleted)
+	assert.Zero(t, failed)
+
+	for id, exists := range map[string]bool{orphan: false, kept: true, fresh: true} {
+		ok, err := docService.storage.Exists(id)
+		require.NoError(t, err)
+		assert.Equal(t, exists, ok, id)
+	}
+	counts, err := vectordb.FileSegmentCounts(vectorDB)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{kept: 2}, counts)
+}
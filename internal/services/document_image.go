@@ -0,0 +1,187 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+)
+
+// DefaultMaxDocumentImages 单个文档默认最多提取的图片数量
+const DefaultMaxDocumentImages = 20
+
+// ErrImageNotFound 文档中不存在该图片
+var ErrImageNotFound = errors.New("image not found")
+
+// imageCaptionPrompt 生成图片描述的提示词模板
+const imageCaptionPrompt = `这是文档《%s》中的一张图片，请描述图片内容，描述会用于检索这张图片。
+要求：说明图片的类型（如架构图、流程图、表格、截图、照片），列出图中的关键文字、数据和结论，不超过200字，只输出描述。`
+
+// WithImageCaptioner 设置为文档图片生成描述的视觉模型客户端
+// 设置后处理文档时提取嵌入的图片保存到存储，并把图片描述作为段落索引，问答来源中会带上图片引用
+func WithImageCaptioner(client llm.Client) DocumentOption {
+	return func(s *DocumentService) {
+		s.visionLLM = client
+	}
+}
+
+// WithImageLimits 设置提取图片的最小字节数和单个文档的图片数量上限，小于等于0时使用默认值
+func WithImageLimits(minBytes, maxPerDocument int) DocumentOption {
+	return func(s *DocumentService) {
+		if minBytes > 0 {
+			s.imageMinBytes = minBytes
+		}
+		if maxPerDocument > 0 {
+			s.maxImages = maxPerDocument
+		}
+	}
+}
+
+// extractImages 提取文档中的图片，保存到存储并生成描述，返回作为段落索引的图片描述
+// 段落序号从start开始，排在正文段落之后。重新处理时内容未变的图片复用已有的存储和描述，
+// 文档中已不存在的图片从存储中删除。图片处理失败不影响文档处理，只记录日志
+func (s *DocumentService) extractImages(ctx context.Context, fileID, filePath string, start int) []document.Content {
+	if s.visionLLM == nil || !document.SupportsImages(filePath) {
+		return nil
+	}
+	logger := s.logger.WithContext(ctx).WithField("file_id", fileID)
+
+	reader, err := s.openDocumentFile(filePath)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to open document for image extraction")
+		return nil
+	}
+	images, err := document.ExtractImages(reader, filePath, s.imageMinBytes, s.maxImages)
+	reader.Close()
+	if err != nil {
+		logger.WithError(err).Warn("Failed to extract images")
+		return nil
+	}
+
+	fileName := filepath.Base(filePath)
+	previous := make(map[string]models.DocumentImage)
+	if doc, err := s.repo.GetByID(fileID); err == nil {
+		fileName = doc.FileName
+		for _, img := range doc.StoredImages() {
+			previous[img.Hash] = img
+		}
+	}
+
+	stored := make([]models.DocumentImage, 0, len(images))
+	seen := make(map[string]bool, len(images))
+	for _, img := range images {
+		hash := contentHash(img.Data)
+		// 同一张图片在文档中出现多次时只保留一份
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if prev, ok := previous[hash]; ok {
+			stored = append(stored, prev)
+			delete(previous, hash)
+			continue
+		}
+
+		caption, err := s.captionImage(ctx, fileName, img)
+		if err != nil {
+			logger.WithError(err).WithField("image", img.Name).Warn("Failed to caption image")
+			continue
+		}
+		info, err := s.storage.Save(bytes.NewReader(img.Data), img.Name)
+		if err != nil {
+			logger.WithError(err).WithField("image", img.Name).Warn("Failed to save image")
+			continue
+		}
+		stored = append(stored, models.DocumentImage{
+			ID:       info.ID,
+			Name:     img.Name,
+			MimeType: img.MimeType,
+			Hash:     hash,
+			Caption:  caption,
+		})
+	}
+
+	for _, img := range previous {
+		if err := s.storage.Delete(img.ID); err != nil {
+			logger.WithError(err).WithField("image_id", img.ID).Warn("Failed to delete removed image")
+		}
+	}
+	if data, err := json.Marshal(stored); err == nil {
+		if err := s.repo.UpdateImages(fileID, data); err != nil {
+			logger.WithError(err).Warn("Failed to save document images")
+		}
+	}
+
+	contents := make([]document.Content, len(stored))
+	for i, img := range stored {
+		contents[i] = document.Content{
+			Text:  "【图片】" + img.Caption,
+			Index: start + i,
+			Metadata: map[string]string{
+				document.MetaImage:     img.ID,
+				document.MetaImageName: img.Name,
+			},
+		}
+	}
+	if len(contents) > 0 {
+		logger.WithField("images", len(contents)).Debug("Document images captioned")
+	}
+	return contents
+}
+
+// captionImage 让视觉模型生成图片描述
+func (s *DocumentService) captionImage(ctx context.Context, fileName string, img document.Image) (string, error) {
+	response, err := llm.DescribeImage(ctx, s.visionLLM, fmt.Sprintf(imageCaptionPrompt, fileName),
+		llm.Image{Data: img.Data, MimeType: img.MimeType},
+		llm.WithGenerateMaxTokens(400),
+		llm.WithGenerateTemperature(0.2))
+	if err != nil {
+		return "", err
+	}
+	caption := strings.TrimSpace(response.Text)
+	if caption == "" {
+		return "", fmt.Errorf("empty caption")
+	}
+	return caption, nil
+}
+
+// OpenDocumentImage 打开文档中提取的图片，调用方负责关闭返回的reader
+func (s *DocumentService) OpenDocumentImage(ctx context.Context, fileID, imageID string) (io.ReadCloser, *models.DocumentImage, error) {
+	if err := s.Init(); err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+	// 只允许访问属于该文档的图片，避免通过图片接口读取存储中的其他文件
+	for _, img := range doc.StoredImages() {
+		if img.ID != imageID {
+			continue
+		}
+		reader, err := s.storage.Get(img.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		return reader, &img, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrImageNotFound, imageID)
+}
+
+// deleteDocumentImages 从存储中删除文档提取的图片
+func (s *DocumentService) deleteDocumentImages(ctx context.Context, doc *models.Document) {
+	for _, img := range doc.StoredImages() {
+		if err := s.storage.Delete(img.ID); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("image_id", img.ID).Warn("Failed to delete document image")
+		}
+	}
+}
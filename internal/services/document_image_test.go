@@ -0,0 +1,101 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVisionClient 支持图片输入的测试大模型客户端
+type testVisionClient struct {
+	*llm.MockClient
+	calls int
+}
+
+// DescribeImage 返回包含图片内容的描述
+func (c *testVisionClient) DescribeImage(ctx context.Context, prompt string, image llm.Image, options ...llm.GenerateOption) (*llm.Response, error) {
+	c.calls++
+	return &llm.Response{Text: " 架构图" + string(image.Data[:1]) + " "}, nil
+}
+
+// TestExtractDocumentImages 测试提取文档图片、生成描述以及重新处理和删除时的图片管理
+func TestExtractDocumentImages(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-image-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, _ := setupDocumentTestEnv(t, tempDir)
+	vision := &testVisionClient{MockClient: llm.NewMockClient(t)}
+	WithImageCaptioner(vision)(docService)
+	WithImageLimits(10, 0)(docService)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range []struct{ name, data string }{
+		{"word/document.xml", "<w:document/>"},
+		{"word/media/image1.png", "A-image-content"},
+		{"word/media/image2.png", "B-image-content"},
+		{"word/media/image3.png", "A-image-content"}, // 重复的图片
+	} {
+		f, err := w.Create(entry.name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(entry.data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	info, err := docService.storage.Save(bytes.NewReader(buf.Bytes()), "手册.docx")
+	require.NoError(t, err)
+	filePath := info.ID + ".docx"
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:         "manual",
+		FileName:   "手册.docx",
+		FileType:   "docx",
+		FilePath:   filePath,
+		Status:     models.DocStatusCompleted,
+		UploadedAt: time.Now(),
+	}))
+
+	contents := docService.extractImages(ctx, "manual", filePath, 3)
+	require.Len(t, contents, 2)
+	assert.Equal(t, 2, vision.calls)
+	assert.Equal(t, "【图片】架构图A", contents[0].Text)
+	assert.Equal(t, 3, contents[0].Index)
+	assert.Equal(t, "image2.png", contents[1].Metadata[document.MetaImageName])
+	imageID := contents[0].Metadata[document.MetaImage]
+
+	reader, image, err := docService.OpenDocumentImage(ctx, "manual", imageID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "A-image-content", string(data))
+	assert.Equal(t, "image/png", image.MimeType)
+
+	// 只能读取属于该文档的图片
+	_, _, err = docService.OpenDocumentImage(ctx, "manual", info.ID)
+	assert.ErrorIs(t, err, ErrImageNotFound)
+
+	// 重新处理时复用已有的图片和描述
+	contents = docService.extractImages(ctx, "manual", filePath, 3)
+	require.Len(t, contents, 2)
+	assert.Equal(t, 2, vision.calls)
+	assert.Equal(t, imageID, contents[0].Metadata[document.MetaImage])
+
+	// 删除文档时一并删除图片
+	require.NoError(t, docService.DeleteDocument(ctx, "manual"))
+	exists, err := docService.storage.Exists(imageID)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
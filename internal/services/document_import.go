@@ -0,0 +1,275 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// maxImportLineSize 导入文件单行的最大长度，高维向量的JSON行可能很长
+const maxImportLineSize = 16 * 1024 * 1024
+
+// ImportRecord 导入文件中的一行记录
+// 每行是一个JSON对象，对应一个已经切分并向量化的段落
+type ImportRecord struct {
+	FileID   string                 `json:"file_id"`            // 文档ID
+	FileName string                 `json:"file_name"`          // 文件名
+	Position *int                   `json:"position,omitempty"` // 段落位置，缺省时按文档内出现顺序编号
+	Text     string                 `json:"text"`               // 段落文本
+	Vector   []float32              `json:"vector"`             // 预先计算好的向量
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // 段落元数据
+}
+
+// ImportOptions 导入选项
+type ImportOptions struct {
+	Overwrite bool // 文档已存在时是否覆盖已有段落
+}
+
+// ImportError 单行导入错误
+type ImportError struct {
+	Line  int    `json:"line"`  // 行号，从1开始
+	Error string `json:"error"` // 错误信息
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Imported  int           `json:"imported"`         // 成功导入的段落数
+	Skipped   int           `json:"skipped"`          // 跳过的行数
+	Documents []string      `json:"documents"`        // 涉及的文档ID
+	Errors    []ImportError `json:"errors,omitempty"` // 跳过的行及原因
+}
+
+// importDoc 导入过程中单个文档的状态
+type importDoc struct {
+	fileName  string
+	existing  bool         // 导入前文档记录已存在
+	next      int          // 下一个自动编号的位置
+	count     int          // 本次导入的段落数
+	positions map[int]bool // 已导入的段落位置
+}
+
+// ImportEmbeddings 从JSONL批量导入预先计算好的向量
+// 跳过解析和嵌入步骤，直接写入向量数据库和段落表，用于从其他系统迁移数据。
+// 格式错误或维度不匹配的行会被跳过并记录在结果中，不影响其他行
+func (s *DocumentService) ImportEmbeddings(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	// 确保初始化完成
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Documents: []string{}}
+	docs := make(map[string]*importDoc)
+	dimension := s.vectorDB.GetDimension()
+
+	var vectors []vectordb.Document
+	var segments []*models.DocumentSegment
+
+	flush := func() error {
+		if len(vectors) == 0 {
+			return nil
+		}
+		if err := s.vectorDB.AddBatch(vectors); err != nil {
+			return fmt.Errorf("failed to store vectors: %w", err)
+		}
+		if err := s.repo.SaveSegments(segments); err != nil {
+			return fmt.Errorf("failed to save segments: %w", err)
+		}
+		result.Imported += len(vectors)
+		vectors, segments = nil, nil
+		return nil
+	}
+
+	skip := func(line int, err error) {
+		result.Skipped++
+		result.Errors = append(result.Errors, ImportError{Line: line, Error: err.Error()})
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		raw := scanner.Bytes()
+		if len(raw) == 0 {
+			continue
+		}
+
+		var rec ImportRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			skip(line, fmt.Errorf("invalid json: %w", err))
+			continue
+		}
+		if err := validateImportRecord(&rec, dimension); err != nil {
+			skip(line, err)
+			continue
+		}
+
+		doc, seen := docs[rec.FileID]
+		if !seen {
+			var err error
+			doc, err = s.prepareImportDoc(ctx, rec, opts)
+			if err != nil {
+				skip(line, err)
+				continue
+			}
+			docs[rec.FileID] = doc
+			if doc != nil {
+				result.Documents = append(result.Documents, rec.FileID)
+			}
+		}
+		if doc == nil {
+			skip(line, fmt.Errorf("document %s already exists, set overwrite to replace it", rec.FileID))
+			continue
+		}
+
+		position := doc.next
+		if rec.Position != nil {
+			position = *rec.Position
+		}
+		if doc.positions[position] {
+			skip(line, fmt.Errorf("duplicate position %d for document %s", position, rec.FileID))
+			continue
+		}
+		doc.positions[position] = true
+		if position >= doc.next {
+			doc.next = position + 1
+		}
+		doc.count++
+
+		metadata := rec.Metadata
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+		metadata["source"] = "import"
+		metadata["index"] = position
+		metaJSON, _ := json.Marshal(metadata)
+
+		segmentID := fmt.Sprintf("%s_%d", rec.FileID, position)
+		vectors = append(vectors, vectordb.Document{
+			ID:        segmentID,
+			FileID:    rec.FileID,
+			FileName:  doc.fileName,
+			Position:  position,
+			Text:      rec.Text,
+			Vector:    rec.Vector,
+			CreatedAt: time.Now(),
+			Metadata:  metadata,
+		})
+		segments = append(segments, &models.DocumentSegment{
+			DocumentID: rec.FileID,
+			SegmentID:  segmentID,
+			Position:   position,
+			Text:       rec.Text,
+			Metadata:   metaJSON,
+			VectorID:   segmentID,
+		})
+
+		if len(vectors) >= s.batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read import file at line %d: %w", line+1, err)
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	// 更新文档记录的段落数和状态
+	for fileID, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		if err := s.finishImportDoc(ctx, fileID, doc); err != nil {
+			s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to update imported document")
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"imported":  result.Imported,
+		"skipped":   result.Skipped,
+		"documents": len(result.Documents),
+	}).Info("Embeddings import completed")
+
+	return result, nil
+}
+
+// validateImportRecord 校验导入记录
+func validateImportRecord(rec *ImportRecord, dimension int) error {
+	if rec.FileID == "" {
+		return fmt.Errorf("file_id is required")
+	}
+	if rec.Text == "" {
+		return fmt.Errorf("text is required")
+	}
+	if len(rec.Vector) == 0 {
+		return fmt.Errorf("vector is required")
+	}
+	if dimension > 0 && len(rec.Vector) != dimension {
+		return fmt.Errorf("vector dimension mismatch: got %d, expected %d", len(rec.Vector), dimension)
+	}
+	if rec.Position != nil && *rec.Position < 0 {
+		return fmt.Errorf("invalid position: %d", *rec.Position)
+	}
+	if rec.FileName == "" {
+		rec.FileName = rec.FileID
+	}
+	return nil
+}
+
+// prepareImportDoc 在文档第一次出现时准备文档记录
+// 文档不存在时创建记录；已存在且允许覆盖时清除旧段落；已存在且不允许覆盖时返回nil，该文档的所有行都会被跳过
+func (s *DocumentService) prepareImportDoc(ctx context.Context, rec ImportRecord, opts ImportOptions) (*importDoc, error) {
+	existing, err := s.repo.GetByID(rec.FileID)
+	if err != nil || existing == nil {
+		if err := s.statusManager.MarkAsUploaded(ctx, rec.FileID, rec.FileName, "", 0); err != nil {
+			return nil, fmt.Errorf("failed to create document record: %w", err)
+		}
+		return &importDoc{fileName: rec.FileName, positions: make(map[int]bool)}, nil
+	}
+
+	if !opts.Overwrite {
+		return nil, nil
+	}
+
+	if err := s.vectorDB.DeleteByFileID(rec.FileID); err != nil {
+		return nil, fmt.Errorf("failed to delete existing vectors: %w", err)
+	}
+	if err := s.repo.DeleteSegments(rec.FileID); err != nil {
+		return nil, fmt.Errorf("failed to delete existing segments: %w", err)
+	}
+	return &importDoc{fileName: existing.FileName, existing: true, positions: make(map[int]bool)}, nil
+}
+
+// finishImportDoc 导入完成后更新文档记录
+func (s *DocumentService) finishImportDoc(ctx context.Context, fileID string, doc *importDoc) error {
+	if !doc.existing {
+		return s.statusManager.MarkAsCompleted(ctx, fileID, doc.count)
+	}
+
+	existing, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	existing.Status = models.DocStatusCompleted
+	existing.SegmentCount = doc.count
+	existing.Progress = 100
+	existing.ProcessedAt = &now
+	existing.CurrentStage = models.StageCompleted
+	return s.repo.Update(existing)
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportEmbeddings 测试从JSONL导入预先计算好的向量
+func TestImportEmbeddings(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-import-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	input := strings.Join([]string{
+		`{"file_id":"legacy-1","file_name":"guide.md","text":"第一段","vector":[0.1,0.2,0.3,0.4],"metadata":{"page":1}}`,
+		`{"file_id":"legacy-1","text":"第二段","vector":[0.2,0.3,0.4,0.5]}`,
+		`{"file_id":"legacy-1","text":"维度不对","vector":[0.1,0.2]}`,
+		`not json`,
+		``,
+		`{"file_id":"legacy-2","position":5,"text":"另一个文档","vector":[0.5,0.5,0.5,0.5]}`,
+	}, "\n")
+
+	result, err := docService.ImportEmbeddings(ctx, strings.NewReader(input), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, []string{"legacy-1", "legacy-2"}, result.Documents)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	assert.Equal(t, 4, result.Errors[1].Line)
+
+	// 位置缺省时按出现顺序编号
+	doc, err := vectorDB.Get("legacy-1_1")
+	require.NoError(t, err)
+	assert.Equal(t, "第二段", doc.Text)
+	assert.Equal(t, "guide.md", doc.FileName)
+
+	_, err = vectorDB.Get("legacy-2_5")
+	require.NoError(t, err)
+
+	record, err := statusManager.GetDocument(ctx, "legacy-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, record.Status)
+	assert.Equal(t, 2, record.SegmentCount)
+
+	segments, err := docService.repo.GetSegments("legacy-1")
+	require.NoError(t, err)
+	assert.Len(t, segments, 2)
+
+	// 已存在的文档默认跳过，开启覆盖后替换原有段落
+	again := `{"file_id":"legacy-1","text":"新内容","vector":[0.1,0.1,0.1,0.1]}`
+	result, err = docService.ImportEmbeddings(ctx, strings.NewReader(again), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Imported)
+	assert.Equal(t, 1, result.Skipped)
+
+	result, err = docService.ImportEmbeddings(ctx, strings.NewReader(again), ImportOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	segments, err = docService.repo.GetSegments("legacy-1")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	assert.Equal(t, "新内容", segments[0].Text)
+
+	_, err = vectorDB.Get("legacy-1_1")
+	assert.Error(t, err, "old vectors should be removed on overwrite")
+}
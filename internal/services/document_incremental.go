@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+	"gorm.io/datatypes"
+)
+
+// segmentID 返回段落ID，与向量数据库中的文档ID一致
+func segmentID(fileID string, index int) string {
+	return fmt.Sprintf("%s_%d", fileID, index)
+}
+
+// buildSegment 构建段落的向量数据库文档和段落记录
+// 段落结构元数据（页码、章节）同时写入向量库和段落记录，便于引用时定位
+func buildSegment(fileID, filePath string, content document.Content, vector []float32, docMetadata map[string]interface{}) (vectordb.Document, *models.DocumentSegment) {
+	metadata := mergeMetadata(map[string]interface{}{
+		"source": filePath,
+		"index":  content.Index,
+	}, docMetadata)
+	for k, v := range content.Metadata {
+		metadata[k] = v
+	}
+
+	id := segmentID(fileID, content.Index)
+	doc := vectordb.Document{
+		ID:        id,
+		FileID:    fileID,
+		FileName:  filepath.Base(filePath),
+		Position:  content.Index,
+		Text:      content.Text,
+		Vector:    vector,
+		CreatedAt: time.Now(),
+		Metadata:  metadata,
+	}
+	segment := &models.DocumentSegment{
+		DocumentID: fileID,
+		SegmentID:  id,
+		Position:   content.Index,
+		Text:       content.Text,
+		TextHash:   contentHash([]byte(content.Text)),
+		Metadata:   segmentMetadata(content),
+	}
+	if parent, err := strconv.Atoi(content.Metadata[document.MetaParent]); err == nil {
+		segment.ParentID = segmentID(fileID, parent)
+	}
+	return doc, segment
+}
+
+// segmentMetadata 把段落结构元数据编码为JSON，没有元数据时返回nil
+func segmentMetadata(content document.Content) datatypes.JSON {
+	if len(content.Metadata) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(content.Metadata)
+	return data
+}
+
+// storedTextHash 返回已有段落的文本哈希，旧数据没有记录哈希时按文本计算
+func storedTextHash(segment *models.DocumentSegment) string {
+	if segment.TextHash != "" {
+		return segment.TextHash
+	}
+	return contentHash([]byte(segment.Text))
+}
+
+// sameMetadata 比较两段JSON元数据，空值和null视为相同
+func sameMetadata(a, b datatypes.JSON) bool {
+	normalize := func(j datatypes.JSON) string {
+		s := strings.TrimSpace(string(j))
+		if s == "null" {
+			return ""
+		}
+		return s
+	}
+	return normalize(a) == normalize(b)
+}
+
+// processIncremental 重新处理已有段落的文档，只向量化新增或变化的段落
+// 段落按位置对应：同一位置文本和结构元数据都未变化的段落保持不动；
+// 变化的段落优先复用相同文本的已有向量（例如插入内容导致后续段落后移），否则重新向量化；
+// 新内容中不存在的位置上的旧段落被删除。
+// 未变化段落在向量库中的文档级元数据（如网页标题）不会刷新
+func (s *DocumentService) processIncremental(ctx context.Context, fileID, filePath string, segments []document.Content, existing []*models.DocumentSegment) error {
+	oldByID := make(map[string]*models.DocumentSegment, len(existing))
+	oldByHash := make(map[string]string, len(existing)) // 文本哈希 -> 旧段落ID
+	for _, seg := range existing {
+		oldByID[seg.SegmentID] = seg
+		hash := storedTextHash(seg)
+		if _, ok := oldByHash[hash]; !ok {
+			oldByHash[hash] = seg.SegmentID
+		}
+	}
+
+	// 找出变化的段落，并在覆盖任何旧向量之前读出可复用的向量
+	var changed []document.Content
+	vectors := make(map[int][]float32)
+	newIDs := make(map[string]bool, len(segments))
+	reused := 0
+	for _, content := range segments {
+		id := segmentID(fileID, content.Index)
+		newIDs[id] = true
+
+		hash := contentHash([]byte(content.Text))
+		if old := oldByID[id]; old != nil && storedTextHash(old) == hash && sameMetadata(old.Metadata, segmentMetadata(content)) {
+			continue
+		}
+		changed = append(changed, content)
+
+		if oldID, ok := oldByHash[hash]; ok {
+			if doc, err := s.vectorDB.Get(oldID); err == nil && len(doc.Vector) > 0 {
+				vectors[content.Index] = doc.Vector
+				reused++
+			}
+		}
+	}
+
+	docMetadata := s.documentMetadata(fileID)
+	embedded := 0
+	var batches [][]document.Content
+	var texts [][]string
+	var pending [][]int
+	for i := 0; i < len(changed); i += s.batchSize {
+		end := i + s.batchSize
+		if end > len(changed) {
+			end = len(changed)
+		}
+		batch := changed[i:end]
+
+		// 只为没有可复用向量的段落生成嵌入
+		var batchTexts []string
+		var batchPending []int
+		for j, content := range batch {
+			if _, ok := vectors[content.Index]; !ok {
+				batchTexts = append(batchTexts, content.Text)
+				batchPending = append(batchPending, j)
+			}
+		}
+		batches = append(batches, batch)
+		texts = append(texts, batchTexts)
+		pending = append(pending, batchPending)
+	}
+
+	done := 0
+	err := s.embedBatches(ctx, texts, func(i int, embeddings [][]float32) error {
+		batch := batches[i]
+		for k, j := range pending[i] {
+			vectors[batch[j].Index] = embeddings[k]
+		}
+		embedded += len(pending[i])
+
+		docs := make([]vectordb.Document, len(batch))
+		dbSegments := make([]*models.DocumentSegment, len(batch))
+		for j, content := range batch {
+			docs[j], dbSegments[j] = buildSegment(fileID, filePath, content, vectors[content.Index], docMetadata)
+
+			// 向量数据库没有更新接口，先删除同ID的旧向量
+			if oldByID[docs[j].ID] != nil {
+				if err := s.vectorDB.Delete(docs[j].ID); err != nil && err != vectordb.ErrDocumentNotFound {
+					return fmt.Errorf("failed to delete stale vector: %w", err)
+				}
+			}
+		}
+
+		if err := s.vectorDB.AddBatch(docs); err != nil {
+			return fmt.Errorf("failed to store vectors: %w", err)
+		}
+		if err := s.repo.UpsertSegments(dbSegments); err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to save segments to database")
+			// 不中断处理
+		}
+
+		done += len(batch)
+		progress := 20 + int(float64(done)/float64(len(changed))*70)
+		if err := s.statusManager.UpdateProgress(ctx, fileID, progress); err != nil {
+			s.logger.WithContext(ctx).WithError(err).Warn("Failed to update document progress")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 删除新内容中已不存在的段落
+	var removed []string
+	for _, seg := range existing {
+		if newIDs[seg.SegmentID] {
+			continue
+		}
+		if err := s.vectorDB.Delete(seg.SegmentID); err != nil && err != vectordb.ErrDocumentNotFound {
+			return fmt.Errorf("failed to delete removed vector: %w", err)
+		}
+		removed = append(removed, seg.SegmentID)
+	}
+	if err := s.repo.DeleteSegmentsByID(removed); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to delete removed segments from database")
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id":   fileID,
+		"unchanged": len(segments) - len(changed),
+		"reused":    reused,
+		"embedded":  embedded,
+		"removed":   len(removed),
+	}).Info("Document segments updated incrementally")
+
+	return nil
+}
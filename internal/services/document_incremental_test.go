@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbeddingClient 记录被向量化的文本
+type countingEmbeddingClient struct {
+	testEmbeddingClient
+	texts []string
+}
+
+func (c *countingEmbeddingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts = append(c.texts, texts...)
+	return c.testEmbeddingClient.EmbedBatch(ctx, texts)
+}
+
+// TestProcessIncremental 测试重新处理文档时只向量化变化的段落
+func TestProcessIncremental(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-incremental-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	embedder := &countingEmbeddingClient{testEmbeddingClient: testEmbeddingClient{dimension: 4}}
+	docService.embedder = embedder
+	ctx := context.Background()
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:         "guide",
+		FileName:   "guide.md",
+		FileType:   "markdown",
+		FilePath:   "/tmp/guide.md",
+		Status:     models.DocStatusCompleted,
+		UploadedAt: time.Now(),
+	}))
+	require.NoError(t, docService.processBatches(ctx, "guide", "/tmp/guide.md", []document.Content{
+		{Text: "安装步骤", Index: 0},
+		{Text: "配置说明", Index: 1},
+		{Text: "常见问题", Index: 2},
+		{Text: "附录", Index: 3},
+	}))
+	require.Len(t, embedder.texts, 4)
+	embedder.texts = nil
+	original, err := vectorDB.Get("guide_1")
+	require.NoError(t, err)
+
+	// 修改第一段，在第二段前插入新段落，删除附录
+	require.NoError(t, docService.processBatches(ctx, "guide", "/tmp/guide.md", []document.Content{
+		{Text: "更新后的安装步骤", Index: 0},
+		{Text: "新增的升级说明", Index: 1},
+		{Text: "配置说明", Index: 2},
+		{Text: "常见问题", Index: 3},
+	}))
+	assert.ElementsMatch(t, []string{"更新后的安装步骤", "新增的升级说明"}, embedder.texts,
+		"shifted chunks reuse their existing vectors")
+
+	segments, err := docService.repo.GetSegments("guide")
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+		assert.Equal(t, contentHash([]byte(seg.Text)), seg.TextHash)
+	}
+	assert.Equal(t, []string{"更新后的安装步骤", "新增的升级说明", "配置说明", "常见问题"}, texts)
+
+	shifted, err := vectorDB.Get("guide_2")
+	require.NoError(t, err)
+	assert.Equal(t, "配置说明", shifted.Text)
+	assert.Equal(t, 2, shifted.Position)
+	assert.InDeltaSlice(t, original.Vector, shifted.Vector, 1e-6)
+
+	results, err := vectorDB.Search(generateTestVector(4, "配置说明"), vectordb.SearchFilter{FileIDs: []string{"guide"}, MaxResults: 10})
+	require.NoError(t, err)
+	assert.Len(t, results, 4)
+
+	// 内容未变化时不调用嵌入模型
+	embedder.texts = nil
+	require.NoError(t, docService.processBatches(ctx, "guide", "/tmp/guide.md", []document.Content{
+		{Text: "更新后的安装步骤", Index: 0},
+		{Text: "新增的升级说明", Index: 1},
+	}))
+	assert.Empty(t, embedder.texts)
+	_, err = vectorDB.Get("guide_3")
+	assert.Error(t, err)
+	segments, err = docService.repo.GetSegments("guide")
+	require.NoError(t, err)
+	assert.Len(t, segments, 2)
+}
+
+// TestProcessIncrementalAfterEdit 测试手动修改过的段落在重新处理时按修改后的文本比较
+func TestProcessIncrementalAfterEdit(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-incremental-edit-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	embedder := &countingEmbeddingClient{testEmbeddingClient: testEmbeddingClient{dimension: 4}}
+	docService.embedder = embedder
+	ctx := context.Background()
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:         "manual",
+		FileName:   "manual.md",
+		FileType:   "markdown",
+		FilePath:   "/tmp/manual.md",
+		Status:     models.DocStatusCompleted,
+		UploadedAt: time.Now(),
+	}))
+	contents := []document.Content{
+		{Text: "安装步骤", Index: 0},
+		{Text: "配置说明", Index: 1},
+	}
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.md", contents))
+	original, err := vectorDB.Get("manual_0")
+	require.NoError(t, err)
+
+	segment, err := docService.UpdateSegment(ctx, "manual", "manual_0", "手动修改的安装步骤")
+	require.NoError(t, err)
+	assert.Equal(t, contentHash([]byte("手动修改的安装步骤")), segment.TextHash)
+	stored, err := docService.repo.GetSegment("manual_0")
+	require.NoError(t, err)
+	assert.Equal(t, contentHash([]byte("手动修改的安装步骤")), stored.TextHash)
+
+	// 重新处理原文件时，修改过的段落与原文不同，需要重新向量化，不能沿用修改后文本的向量
+	embedder.texts = nil
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.md", contents))
+	assert.Equal(t, []string{"安装步骤"}, embedder.texts)
+
+	restored, err := vectorDB.Get("manual_0")
+	require.NoError(t, err)
+	assert.Equal(t, "安装步骤", restored.Text)
+	assert.InDeltaSlice(t, original.Vector, restored.Vector, 1e-6)
+	stored, err = docService.repo.GetSegment("manual_0")
+	require.NoError(t, err)
+	assert.Equal(t, "安装步骤", stored.Text)
+	assert.Equal(t, contentHash([]byte("安装步骤")), stored.TextHash)
+}
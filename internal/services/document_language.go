@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/pkg/langdetect"
+)
+
+// detectLanguage 识别文档语言并写入文档元数据，返回带有该语言的上下文
+// 语言在向量化之前写入，随文档级元数据写入每个段落，问答时据此只比较同一模型生成的向量；
+// 上下文中的语言使整篇文档的段落都路由到同一个嵌入模型
+func (s *DocumentService) detectLanguage(ctx context.Context, fileID, content string) context.Context {
+	lang := langdetect.Detect(content)
+	if err := s.updateDocumentMetadata(ctx, fileID, map[string]interface{}{models.MetaLanguage: lang}, ""); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to record document language")
+	}
+	return embedding.WithLanguage(ctx, lang)
+}
+
+// withDocumentLanguage 返回带有文档已识别语言的上下文，文档没有记录语言时原样返回
+func (s *DocumentService) withDocumentLanguage(ctx context.Context, fileID string) context.Context {
+	if lang, _ := s.documentMetadata(fileID)[models.MetaLanguage].(string); lang != "" {
+		return embedding.WithLanguage(ctx, lang)
+	}
+	return ctx
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+)
+
+// maintenancePageSize 遍历文档列表时的分页大小
+const maintenancePageSize = 100
+
+// EmbeddingModel 返回当前使用的嵌入模型名称
+func (s *DocumentService) EmbeddingModel() string {
+	if s.embedder == nil {
+		return ""
+	}
+	return s.embedder.Name()
+}
+
+// URLSourcedDocuments 列出所有来自网页的已完成文档
+func (s *DocumentService) URLSourcedDocuments(ctx context.Context) (map[string]string, error) {
+	result := make(map[string]string)
+	err := s.eachCompletedDocument(ctx, func(doc *models.Document, metadata map[string]interface{}) {
+		if sourceURL, ok := metadata["source_url"].(string); ok && sourceURL != "" {
+			result[doc.ID] = sourceURL
+		}
+	})
+	return result, err
+}
+
+// DocumentsNeedingReembed 列出向量化模型与指定模型不一致的已完成文档
+// 没有记录嵌入模型的文档（如从外部导入的向量）会被跳过，避免覆盖无法重建的数据
+func (s *DocumentService) DocumentsNeedingReembed(ctx context.Context, model string) ([]string, error) {
+	var ids []string
+	err := s.eachCompletedDocument(ctx, func(doc *models.Document, metadata map[string]interface{}) {
+		current, ok := metadata["embedding_model"].(string)
+		if ok && current != "" && current != model {
+			ids = append(ids, doc.ID)
+		}
+	})
+	return ids, err
+}
+
+// eachCompletedDocument 分页遍历所有已完成的文档
+func (s *DocumentService) eachCompletedDocument(ctx context.Context, fn func(doc *models.Document, metadata map[string]interface{})) error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+
+	filters := map[string]interface{}{"status": models.DocStatusCompleted}
+	for offset := 0; ; offset += maintenancePageSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		docs, _, err := s.repo.List(offset, maintenancePageSize, filters)
+		if err != nil {
+			return fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			metadata := make(map[string]interface{})
+			if len(doc.Metadata) > 0 {
+				_ = json.Unmarshal(doc.Metadata, &metadata)
+			}
+			fn(doc, metadata)
+		}
+		if len(docs) < maintenancePageSize {
+			return nil
+		}
+	}
+}
+
+// RecrawlDocument 重新抓取网页来源的文档
+// 内容未变化时只更新抓取时间；内容变化时替换存储的文件并重新分块向量化。
+// 返回内容是否发生变化
+func (s *DocumentService) RecrawlDocument(ctx context.Context, fileID string) (bool, error) {
+	if err := s.Init(); err != nil {
+		return false, err
+	}
+
+	metadata := s.documentMetadata(fileID)
+	sourceURL, _ := metadata["source_url"].(string)
+	if sourceURL == "" {
+		return false, fmt.Errorf("document %s has no source url", fileID)
+	}
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid source url %s: %w", sourceURL, err)
+	}
+
+	page, err := s.fetchPage(ctx, u)
+	if err != nil {
+		return false, err
+	}
+
+	update := map[string]interface{}{
+		"content_type": page.contentType,
+		"fetched_at":   time.Now().Format(time.RFC3339),
+	}
+	if page.title != "" {
+		update["title"] = page.title
+	}
+	return s.ReplaceContent(ctx, fileID, page.fileName, page.data, update)
+}
+
+// ReembedDocument 使用当前嵌入模型重新分块和向量化文档
+func (s *DocumentService) ReembedDocument(ctx context.Context, fileID string) error {
+	if err := s.Init(); err != nil {
+		return err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+	return s.reindexDocument(ctx, fileID, doc.FilePath, false)
+}
+
+// reindexDocument 重新处理文档
+// incremental为true且嵌入模型未变化时保留已有段落，同步处理只重新向量化变化的段落；
+// 否则清除文档已有的向量和段落后完整重新处理
+func (s *DocumentService) reindexDocument(ctx context.Context, fileID, filePath string, incremental bool) error {
+	if err := s.statusManager.MarkForReprocessing(ctx, fileID); err != nil {
+		return err
+	}
+	if incremental && !(s.asyncEnabled && s.taskQueue != nil) {
+		if model, _ := s.documentMetadata(fileID)["embedding_model"].(string); model == s.EmbeddingModel() {
+			return s.ProcessDocument(ctx, fileID, filePath)
+		}
+	}
+	if err := s.vectorDB.DeleteByFileID(fileID); err != nil {
+		return fmt.Errorf("failed to delete document vectors: %w", err)
+	}
+	if err := s.repo.DeleteSegments(fileID); err != nil {
+		return fmt.Errorf("failed to delete document segments: %w", err)
+	}
+	s.releaseDuplicates(ctx, fileID)
+	return s.ProcessDocument(ctx, fileID, filePath)
+}
+
+// recordEmbeddingModel 在文档元数据中记录向量化使用的嵌入模型
+func (s *DocumentService) recordEmbeddingModel(ctx context.Context, fileID, model string) {
+	if model == "" {
+		return
+	}
+	if err := s.updateDocumentMetadata(ctx, fileID, map[string]interface{}{"embedding_model": model}, ""); err != nil {
+		s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to record embedding model")
+	}
+}
+
+// storageIDFromPath 从存储路径推断存储ID
+// 存储实现以"<ID><扩展名>"命名文件，无法推断时返回fallback
+func storageIDFromPath(filePath, fallback string) string {
+	if filePath == "" {
+		return fallback
+	}
+	base := filepath.Base(filePath)
+	if id := strings.TrimSuffix(base, filepath.Ext(base)); id != "" && id != "." {
+		return id
+	}
+	return fallback
+}
+
+// MaintenanceTaskHandler 文档维护任务处理器
+// 处理定时任务投递到队列中的重新抓取和重新向量化任务
+type MaintenanceTaskHandler struct {
+	docService *DocumentService
+}
+
+// NewMaintenanceTaskHandler 创建文档维护任务处理器
+func NewMaintenanceTaskHandler(docService *DocumentService) *MaintenanceTaskHandler {
+	return &MaintenanceTaskHandler{docService: docService}
+}
+
+// GetTaskTypes 返回支持的任务类型
+func (h *MaintenanceTaskHandler) GetTaskTypes() []taskqueue.TaskType {
+	return []taskqueue.TaskType{taskqueue.TaskDocumentRecrawl, taskqueue.TaskDocumentReembed}
+}
+
+// ProcessTask 处理文档维护任务
+func (h *MaintenanceTaskHandler) ProcessTask(ctx context.Context, task *taskqueue.Task) error {
+	switch task.Type {
+	case taskqueue.TaskDocumentRecrawl:
+		_, err := h.docService.RecrawlDocument(ctx, task.DocumentID)
+		return err
+	case taskqueue.TaskDocumentReembed:
+		return h.docService.ReembedDocument(ctx, task.DocumentID)
+	default:
+		return fmt.Errorf("unsupported task type: %s", task.Type)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineSplitter 按行分段的测试分段器，不依赖Python服务
+type lineSplitter struct{}
+
+func (lineSplitter) Split(text string) ([]document.Content, error) {
+	var contents []document.Content
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			contents = append(contents, document.Content{Text: line, Index: len(contents)})
+		}
+	}
+	return contents, nil
+}
+
+// TestRecrawlAndReembedDocument 测试重新抓取网页文档和按嵌入模型重新向量化
+func TestRecrawlAndReembedDocument(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-maintenance-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	body := "<html><head><title>发布说明</title></head><body><article><p>第一版支持文档上传和问答功能。</p></article></body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	docService.splitter = lineSplitter{}
+	// 测试服务器监听回环地址，默认客户端会拒绝连接
+	docService.httpClient = server.Client()
+	ctx := context.Background()
+
+	result, err := docService.IngestURL(ctx, server.URL, "")
+	require.NoError(t, err)
+	require.NoError(t, docService.ProcessDocument(ctx, result.FileID, result.FilePath))
+
+	doc, err := statusManager.GetDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+	assert.Equal(t, "test-embedding", docService.documentMetadata(result.FileID)["embedding_model"])
+
+	sources, err := docService.URLSourcedDocuments(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{result.FileID: server.URL}, sources)
+
+	// 内容未变化时不重新处理
+	changed, err := docService.RecrawlDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// 内容变化时替换文件并重新向量化，文档ID保持不变
+	body = "<html><head><title>发布说明</title></head><body><article><p>第二版新增网页导入和定时重新抓取。</p></article></body></html>"
+	changed, err = docService.RecrawlDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	doc, err = statusManager.GetDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+	assert.NotEqual(t, result.FilePath, doc.FilePath)
+	_, err = os.Stat(result.FilePath)
+	assert.True(t, os.IsNotExist(err), "previous content should be removed from storage")
+
+	docs, err := vectorDB.Search(make([]float32, 4), vectordb.SearchFilter{FileIDs: []string{result.FileID}, MaxResults: 100})
+	require.NoError(t, err)
+	require.NotEmpty(t, docs)
+	for _, d := range docs {
+		assert.NotContains(t, d.Document.Text, "第一版")
+	}
+
+	// 记录的嵌入模型与当前模型一致时无需重新向量化
+	ids, err := docService.DocumentsNeedingReembed(ctx, docService.EmbeddingModel())
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	ids, err = docService.DocumentsNeedingReembed(ctx, "new-embedding")
+	require.NoError(t, err)
+	assert.Equal(t, []string{result.FileID}, ids)
+
+	require.NoError(t, docService.ReembedDocument(ctx, result.FileID))
+	doc, err = statusManager.GetDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+
+	// 处理中的文档不能重新处理
+	doc.Status = models.DocStatusProcessing
+	require.NoError(t, statusManager.GetRepo().Update(doc))
+	assert.Error(t, docService.ReembedDocument(ctx, result.FileID))
+}
+
+// TestAsyncVectorizeRecordsEmbeddingModel 测试异步处理完成的文档记录当前嵌入模型，不会被定时任务重新向量化
+func TestAsyncVectorizeRecordsEmbeddingModel(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-async-model-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, statusManager := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "async-doc", "async.txt", "/tmp/async.txt", 10))
+	require.NoError(t, statusManager.MarkAsProcessing(ctx, "async-doc"))
+
+	// Python服务原样返回请求中的"default"模型名
+	result, err := json.Marshal(taskqueue.VectorizeResult{
+		DocumentID: "async-doc",
+		Vectors: []taskqueue.VectorInfo{
+			{ChunkIndex: 0, Vector: []float32{0.1, 0.2, 0.3, 0.4}},
+		},
+		VectorCount: 1,
+		Model:       "default",
+		Dimension:   4,
+	})
+	require.NoError(t, err)
+	task := &taskqueue.Task{ID: "vectorize-task", Type: taskqueue.TaskVectorize, DocumentID: "async-doc"}
+	require.NoError(t, docService.handleVectorizeResult(ctx, task, result))
+
+	require.NotEmpty(t, docService.EmbeddingModel())
+	ids, err := docService.DocumentsNeedingReembed(ctx, docService.EmbeddingModel())
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	ids, err = docService.DocumentsNeedingReembed(ctx, "new-embedding")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"async-doc"}, ids)
+}
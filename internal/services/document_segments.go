@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// ListSegments 按位置分页列出文档的段落
+// 异步处理的文档没有段落记录，此时按文档的段落数量从向量数据库读取
+func (s *DocumentService) ListSegments(ctx context.Context, fileID string, offset, limit int) ([]*models.DocumentSegment, int64, error) {
+	if err := s.Init(); err != nil {
+		return nil, 0, err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+
+	segments, total, err := s.repo.ListSegments(fileID, offset, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list segments: %w", err)
+	}
+	if total > 0 || doc.SegmentCount == 0 {
+		return segments, total, nil
+	}
+
+	// 段落表中没有记录时从向量数据库按ID读取
+	for pos := offset; pos < offset+limit && pos < doc.SegmentCount; pos++ {
+		id := fmt.Sprintf("%s_%d", fileID, pos)
+		vec, err := s.vectorDB.Get(id)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, &models.DocumentSegment{
+			DocumentID: fileID,
+			SegmentID:  id,
+			Position:   vec.Position,
+			Text:       vec.Text,
+			CreatedAt:  vec.CreatedAt,
+			UpdatedAt:  vec.CreatedAt,
+		})
+	}
+	return segments, int64(doc.SegmentCount), nil
+}
+
+// UpdateSegment 修改段落文本，只重新向量化该段落并原地替换向量数据库中的记录
+// 用于修正OCR错误或切分不当的段落，段落ID和位置保持不变，已有的引用链接仍然有效
+func (s *DocumentService) UpdateSegment(ctx context.Context, fileID, segmentID, text string) (*models.DocumentSegment, error) {
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("segment text cannot be empty")
+	}
+
+	old, err := s.vectorDB.Get(segmentID)
+	if err != nil || old.FileID != fileID {
+		return nil, fmt.Errorf("%w: %s", models.ErrSegmentNotFound, segmentID)
+	}
+
+	// 使用文档语言对应的嵌入模型，与文档的其他段落保持在同一个向量空间
+	vector, err := s.embedder.Embed(s.withDocumentLanguage(ctx, fileID), text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	updated := old
+	updated.Text = text
+	updated.Vector = vector
+	updated.Metadata = make(map[string]interface{}, len(old.Metadata)+1)
+	for k, v := range old.Metadata {
+		updated.Metadata[k] = v
+	}
+	updated.Metadata["edited_at"] = time.Now().Format(time.RFC3339)
+
+	// 向量数据库没有更新接口，先删除再添加，添加失败时恢复原记录
+	if err := s.vectorDB.Delete(segmentID); err != nil {
+		return nil, fmt.Errorf("failed to delete segment vector: %w", err)
+	}
+	if err := s.vectorDB.Add(updated); err != nil {
+		if restoreErr := s.vectorDB.Add(old); restoreErr != nil {
+			s.logger.WithContext(ctx).WithError(restoreErr).WithField("segment_id", segmentID).Error("Failed to restore segment vector")
+		}
+		return nil, fmt.Errorf("failed to save segment vector: %w", err)
+	}
+
+	// 异步处理的文档没有段落记录，只更新向量数据库
+	segment, err := s.repo.GetSegment(segmentID)
+	if err != nil {
+		segment = &models.DocumentSegment{
+			DocumentID: fileID,
+			SegmentID:  segmentID,
+			Position:   old.Position,
+			CreatedAt:  old.CreatedAt,
+		}
+	} else if err := s.repo.UpdateSegmentText(segmentID, text); err != nil {
+		return nil, fmt.Errorf("failed to update segment: %w", err)
+	}
+	segment.Text = text
+	segment.TextHash = contentHash([]byte(text))
+	segment.UpdatedAt = time.Now()
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"file_id":    fileID,
+		"segment_id": segmentID,
+	}).Info("Segment edited and re-embedded")
+
+	return segment, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListAndUpdateSegments 测试分页浏览段落，以及修改段落后只重新向量化该段落
+func TestListAndUpdateSegments(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-segments-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:           "manual",
+		FileName:     "manual.pdf",
+		FileType:     "pdf",
+		FilePath:     "/tmp/manual.pdf",
+		Status:       models.DocStatusCompleted,
+		UploadedAt:   time.Now(),
+		SegmentCount: 3,
+	}))
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.pdf", []document.Content{
+		{Text: "第一段内容", Index: 0},
+		{Text: "0CR识别错误的第二段", Index: 1, Metadata: map[string]string{document.MetaPage: "2"}},
+		{Text: "第三段内容", Index: 2},
+	}))
+
+	segments, total, err := docService.ListSegments(ctx, "manual", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, segments, 2)
+	assert.Equal(t, "manual_1", segments[0].SegmentID)
+	assert.Equal(t, 2, segments[1].Position)
+
+	_, _, err = docService.ListSegments(ctx, "missing", 0, 10)
+	assert.ErrorIs(t, err, models.ErrDocumentNotFound)
+
+	// 修改后段落ID不变，向量和文本原地替换，其他段落不受影响
+	untouched, err := vectorDB.Get("manual_0")
+	require.NoError(t, err)
+
+	segment, err := docService.UpdateSegment(ctx, "manual", "manual_1", "OCR识别修正后的第二段")
+	require.NoError(t, err)
+	assert.Equal(t, "OCR识别修正后的第二段", segment.Text)
+	assert.Equal(t, 1, segment.Position)
+
+	stored, err := vectorDB.Get("manual_1")
+	require.NoError(t, err)
+	assert.Equal(t, "OCR识别修正后的第二段", stored.Text)
+	assert.Equal(t, "2", stored.Metadata[document.MetaPage])
+	assert.NotEmpty(t, stored.Metadata["edited_at"])
+	after, err := vectorDB.Get("manual_0")
+	require.NoError(t, err)
+	assert.Equal(t, untouched.Vector, after.Vector)
+
+	dbSegment, err := docService.repo.GetSegment("manual_1")
+	require.NoError(t, err)
+	assert.Equal(t, "OCR识别修正后的第二段", dbSegment.Text)
+
+	results, err := vectorDB.Search(generateTestVector(4, "OCR识别修正后的第二段"), vectordb.SearchFilter{FileIDs: []string{"manual"}, MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "manual_1", results[0].Document.ID)
+
+	// 段落不属于该文档或不存在
+	_, err = docService.UpdateSegment(ctx, "other", "manual_1", "新内容")
+	assert.ErrorIs(t, err, models.ErrSegmentNotFound)
+	_, err = docService.UpdateSegment(ctx, "manual", "manual_9", "新内容")
+	assert.ErrorIs(t, err, models.ErrSegmentNotFound)
+	_, err = docService.UpdateSegment(ctx, "manual", "manual_1", "  ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrContentRejected 内容未通过文件校验（类型不允许、内容与扩展名不符等）
+var ErrContentRejected = errors.New("content rejected by file validation")
+
+// IngestContent 保存从外部来源获取的内容并创建文档记录
+// 内容先经过与上传文件相同的校验，metadata合并到文档元数据中，并记录内容摘要用于判断后续是否变化。
+// 该方法只负责存储，调用方需要再调用ProcessDocument处理文档
+func (s *DocumentService) IngestContent(ctx context.Context, fileName string, data []byte, metadata map[string]interface{}, tags string) (string, string, error) {
+	if err := s.Init(); err != nil {
+		return "", "", err
+	}
+	if err := s.validateContent(ctx, fileName, data); err != nil {
+		return "", "", err
+	}
+
+	info, err := s.storage.Save(bytes.NewReader(data), fileName)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to save content: %w", err)
+	}
+	if err := s.statusManager.MarkAsUploaded(ctx, info.ID, fileName, info.Path, info.Size); err != nil {
+		s.storage.Delete(info.ID)
+		return "", "", fmt.Errorf("failed to create document record: %w", err)
+	}
+
+	update := map[string]interface{}{"content_hash": contentHash(data)}
+	for k, v := range metadata {
+		update[k] = v
+	}
+	if err := s.updateDocumentMetadata(ctx, info.ID, update, tags); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", info.ID).Warn("Failed to save document source metadata")
+	}
+	return info.ID, info.Path, nil
+}
+
+// ReplaceContent 用外部来源的新内容替换文档，文档ID保持不变
+// 内容摘要未变化时只合并元数据；变化时替换存储的文件并增量重新分块向量化。
+// 返回内容是否发生变化
+func (s *DocumentService) ReplaceContent(ctx context.Context, fileID, fileName string, data []byte, metadata map[string]interface{}) (bool, error) {
+	if err := s.Init(); err != nil {
+		return false, err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	hash := contentHash(data)
+	if hash == s.documentMetadata(fileID)["content_hash"] {
+		if err := s.updateDocumentMetadata(ctx, fileID, metadata, ""); err != nil {
+			s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to update document source metadata")
+		}
+		return false, nil
+	}
+	if err := s.validateContent(ctx, fileName, data); err != nil {
+		return false, err
+	}
+
+	// 保存新内容，文档ID保持不变
+	fileInfo, err := s.storage.Save(bytes.NewReader(data), fileName)
+	if err != nil {
+		return false, fmt.Errorf("failed to save content: %w", err)
+	}
+
+	oldStorageID := storageIDFromPath(doc.FilePath, fileID)
+	doc.FileName = fileName
+	doc.FileType = getFileType(fileName)
+	doc.FilePath = fileInfo.Path
+	doc.FileSize = fileInfo.Size
+	if err := s.repo.Update(doc); err != nil {
+		return false, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	update := map[string]interface{}{"content_hash": hash}
+	for k, v := range metadata {
+		update[k] = v
+	}
+	if err := s.updateDocumentMetadata(ctx, fileID, update, ""); err != nil {
+		s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to update document source metadata")
+	}
+
+	if err := s.storage.Delete(oldStorageID); err != nil {
+		s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to delete previous content")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"file_id":   fileID,
+		"file_name": fileName,
+	}).Info("Document content changed, re-indexing")
+
+	if err := s.reindexDocument(ctx, fileID, fileInfo.Path, true); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// validateContent 使用文件校验器检查内容，未通过时返回包装了ErrContentRejected的错误
+func (s *DocumentService) validateContent(ctx context.Context, fileName string, data []byte) error {
+	if s.fileValidator == nil {
+		return nil
+	}
+	if err := s.fileValidator.Validate(ctx, fileName, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrContentRejected, fileName, err)
+	}
+	return nil
+}
@@ -7,8 +7,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/internal/events"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,7 +19,10 @@ import (
 type DocumentStatusManager struct {
 	repo   repository.DocumentRepository // 文档仓储接口
 	logger *logrus.Logger                // 日志记录器
-	mu     sync.Mutex                    // 互斥锁，保证状态转换的原子性
+	events *events.Bus                   // 事件总线（可选）
+	// onChange 文档处理完成或删除后调用（可选），用于让缓存的回答感知文档变更
+	onChange func(ctx context.Context)
+	mu       sync.Mutex // 互斥锁，保证状态转换的原子性
 }
 
 // NewDocumentStatusManager 创建文档状态管理器
@@ -33,12 +38,29 @@ func NewDocumentStatusManager(repo repository.DocumentRepository, logger *logrus
 	}
 }
 
+// SetEventBus 设置事件总线，文档上传、处理结束和删除时发布事件
+func (m *DocumentStatusManager) SetEventBus(bus *events.Bus) {
+	m.events = bus
+}
+
+// OnDocumentsChanged 设置文档处理完成或删除后的回调
+func (m *DocumentStatusManager) OnDocumentsChanged(fn func(ctx context.Context)) {
+	m.onChange = fn
+}
+
+// documentsChanged 通知文档发生了变更
+func (m *DocumentStatusManager) documentsChanged(ctx context.Context) {
+	if m.onChange != nil {
+		m.onChange(ctx)
+	}
+}
+
 // MarkAsUploaded 将文档标记为已上传状态
 func (m *DocumentStatusManager) MarkAsUploaded(ctx context.Context, docID string, fileName string, filePath string, fileSize int64) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":   docID,
 		"filename": fileName,
 	}).Info("Marking document as uploaded")
@@ -51,6 +73,7 @@ func (m *DocumentStatusManager) MarkAsUploaded(ctx context.Context, docID string
 		FilePath:   filePath,
 		FileSize:   fileSize,
 		Status:     models.DocStatusUploaded,
+		Tenant:     usage.TenantFromContext(ctx),
 		UploadedAt: time.Now(),
 		UpdatedAt:  time.Now(),
 		Progress:   0,
@@ -58,14 +81,22 @@ func (m *DocumentStatusManager) MarkAsUploaded(ctx context.Context, docID string
 		CurrentStage: models.StageParsing,
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":   docID,
 		"filename": fileName,
 		"tags":     doc.Tags,
 	}).Debug("Creating document record with tags")
 
 	// 保存到仓储
-	return m.repo.Create(doc)
+	if err := m.repo.Create(doc); err != nil {
+		return err
+	}
+	m.events.Publish(ctx, events.TypeDocumentUploaded, docID, map[string]interface{}{
+		"file_name": fileName,
+		"file_type": doc.FileType,
+		"file_size": fileSize,
+	})
+	return nil
 }
 
 // MarkAsProcessing 将文档标记为处理中状态
@@ -85,7 +116,7 @@ func (m *DocumentStatusManager) MarkAsProcessing(ctx context.Context, docID stri
 			docID, doc.Status, models.DocStatusUploaded)
 	}
 
-	m.logger.WithField("doc_id", docID).Info("Marking document as processing")
+	m.logger.WithContext(ctx).WithField("doc_id", docID).Info("Marking document as processing")
 
 	// 更新状态
 	doc.Status = models.DocStatusProcessing
@@ -115,7 +146,7 @@ func (m *DocumentStatusManager) MarkAsCompleted(ctx context.Context, docID strin
 			docID, doc.Status, models.DocStatusProcessing, models.DocStatusUploaded)
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":        docID,
 		"segment_count": segmentCount,
 	}).Info("Marking document as completed")
@@ -129,7 +160,16 @@ func (m *DocumentStatusManager) MarkAsCompleted(ctx context.Context, docID strin
 	doc.UpdatedAt = now
 	doc.CurrentStage = models.StageCompleted
 
-	return m.repo.Update(doc)
+	if err := m.repo.Update(doc); err != nil {
+		return err
+	}
+	m.events.Publish(ctx, events.TypeDocumentProcessed, docID, map[string]interface{}{
+		"status":    string(models.DocStatusCompleted),
+		"file_name": doc.FileName,
+		"segments":  segmentCount,
+	})
+	m.documentsChanged(ctx)
+	return nil
 }
 
 // MarkAsFailed 将文档标记为处理失败状态
@@ -143,7 +183,7 @@ func (m *DocumentStatusManager) MarkAsFailed(ctx context.Context, docID string,
 		return fmt.Errorf("failed to get document: %w", err)
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id": docID,
 		"error":  errorMsg,
 	}).Error("Marking document as failed")
@@ -155,6 +195,40 @@ func (m *DocumentStatusManager) MarkAsFailed(ctx context.Context, docID string,
 	doc.ProcessedAt = &now
 	doc.UpdatedAt = now
 
+	if err := m.repo.Update(doc); err != nil {
+		return err
+	}
+	m.events.Publish(ctx, events.TypeDocumentProcessed, docID, map[string]interface{}{
+		"status":    string(models.DocStatusFailed),
+		"file_name": doc.FileName,
+		"error":     errorMsg,
+	})
+	return nil
+}
+
+// MarkForReprocessing 将已完成或失败的文档重置为已上传状态，用于重新抓取或重新向量化
+// 完成和失败在正常流程中是终态，只有维护任务通过这里把文档放回处理流程
+func (m *DocumentStatusManager) MarkForReprocessing(ctx context.Context, docID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	doc, err := m.repo.GetByID(docID)
+	if err != nil {
+		return fmt.Errorf("failed to get document: %w", err)
+	}
+
+	if doc.Status != models.DocStatusCompleted && doc.Status != models.DocStatusFailed {
+		return fmt.Errorf("cannot reprocess document %s in %s state", docID, doc.Status)
+	}
+
+	m.logger.WithContext(ctx).WithField("doc_id", docID).Info("Marking document for reprocessing")
+
+	doc.Status = models.DocStatusUploaded
+	doc.Progress = 0
+	doc.Error = ""
+	doc.CurrentStage = ""
+	doc.UpdatedAt = time.Now()
+
 	return m.repo.Update(doc)
 }
 
@@ -199,7 +273,7 @@ func (m *DocumentStatusManager) UpdateStage(ctx context.Context, docID string, s
 		return fmt.Errorf("cannot update stage: document %s is not in processing state", docID)
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":     docID,
 		"stage":      stage,
 		"prev_stage": doc.CurrentStage,
@@ -235,7 +309,7 @@ func (m *DocumentStatusManager) UpdateCurrentTask(ctx context.Context, docID str
 		return fmt.Errorf("failed to get document: %w", err)
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":       docID,
 		"task_id":      taskID,
 		"task_status":  taskStatus,
@@ -261,7 +335,7 @@ func (m *DocumentStatusManager) UpdatePythonService(ctx context.Context, docID s
 		return fmt.Errorf("failed to get document: %w", err)
 	}
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":       docID,
 		"service_name": serviceName,
 		"prev_service": doc.PythonService,
@@ -289,7 +363,7 @@ func (m *DocumentStatusManager) IncrementRetryCount(ctx context.Context, docID s
 	doc.RetryCount++
 	doc.UpdatedAt = time.Now()
 
-	m.logger.WithFields(logrus.Fields{
+	m.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"doc_id":      docID,
 		"retry_count": doc.RetryCount,
 	}).Info("Incrementing document retry count")
@@ -320,13 +394,23 @@ func (m *DocumentStatusManager) ListDocuments(ctx context.Context, offset, limit
 	return m.repo.List(offset, limit, filters)
 }
 
+// ListDocumentsAfter 使用游标分页获取文档列表
+func (m *DocumentStatusManager) ListDocumentsAfter(ctx context.Context, cursor string, limit int, filters map[string]interface{}) ([]*models.Document, string, error) {
+	return m.repo.ListAfter(cursor, limit, filters)
+}
+
 // DeleteDocument 删除文档状态记录
 func (m *DocumentStatusManager) DeleteDocument(ctx context.Context, docID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.logger.WithField("doc_id", docID).Info("Deleting document status record")
-	return m.repo.Delete(docID)
+	m.logger.WithContext(ctx).WithField("doc_id", docID).Info("Deleting document status record")
+	if err := m.repo.Delete(docID); err != nil {
+		return err
+	}
+	m.events.Publish(ctx, events.TypeDocumentDeleted, docID, nil)
+	m.documentsChanged(ctx)
+	return nil
 }
 
 // ValidateStateTransition 验证状态转换的有效性
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProcessBatchesStructureMetadata 测试段落的页码和章节写入向量库和段落记录
+func TestProcessBatchesStructureMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-structure-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	text := "# 部署指南\n\n使用Docker部署服务。\n\f\n## 配置\n\n修改端口号后重启。"
+	segments := document.AnnotateStructure(text, []document.Content{
+		{Text: "使用Docker部署服务。", Index: 0},
+		{Text: "修改端口号后重启。", Index: 1},
+	})
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.pdf", segments))
+
+	stored, err := vectorDB.Get("manual_1")
+	require.NoError(t, err)
+	assert.Equal(t, "2", stored.Metadata[document.MetaPage])
+	assert.Equal(t, "配置", stored.Metadata[document.MetaSection])
+	assert.Equal(t, "部署指南 > 配置", stored.Metadata[document.MetaHeadingPath])
+	assert.Equal(t, "/tmp/manual.pdf", stored.Metadata["source"])
+
+	dbSegments, err := docService.repo.GetSegments("manual")
+	require.NoError(t, err)
+	require.Len(t, dbSegments, 2)
+	var meta map[string]string
+	require.NoError(t, json.Unmarshal(dbSegments[0].Metadata, &meta))
+	assert.Equal(t, "1", meta[document.MetaPage])
+	assert.Equal(t, "部署指南", meta[document.MetaSection])
+
+	// 检索结果中的结构信息用于生成带位置的引用上下文
+	assert.Equal(t, "【第2页，配置】\n修改端口号后重启。", sourceContext(vectordb.Document{
+		Text:     stored.Text,
+		Metadata: stored.Metadata,
+	}))
+}
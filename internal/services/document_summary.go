@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+const (
+	// summaryBatchChars 摘要时一次放入提示词的文档内容最大字符数，超过时分批生成局部摘要再合并
+	summaryBatchChars = 6000
+	// summaryPageSize 读取段落的分页大小
+	summaryPageSize = 200
+	// maxSummaryKeyPoints 摘要要点数量上限
+	maxSummaryKeyPoints = 10
+	// maxSummaryRounds 合并局部摘要的最大轮数，超过后截断剩余内容直接生成最终摘要
+	maxSummaryRounds = 3
+)
+
+// ErrSummaryDisabled 未配置大模型时无法生成文档摘要
+var ErrSummaryDisabled = errors.New("document summary is not configured")
+
+// summaryMapPrompt 生成局部摘要的提示词模板
+const summaryMapPrompt = `下面是文档《%s》的一部分内容，请概括这部分的主要信息。
+要求：保留关键事实、数据、结论和操作步骤，不超过300字，只输出概括内容。
+
+内容：
+%s`
+
+// summaryReducePrompt 生成最终摘要的提示词模板
+const summaryReducePrompt = `请根据下面文档《%s》的内容，生成结构化的文档摘要。
+要求：
+1. summary为200字以内的整体概述，说明文档的主题、用途和主要结论
+2. key_points列出3到%d条要点，每条一句话
+3. 只输出一个JSON对象，不要输出其他内容，格式如下：
+{"summary": "整体概述", "key_points": ["要点1", "要点2"]}
+
+内容：
+%s`
+
+// WithDocumentSummarizer 设置用于生成文档摘要的大模型客户端
+func WithDocumentSummarizer(client llm.Client) DocumentOption {
+	return func(s *DocumentService) {
+		s.summaryLLM = client
+	}
+}
+
+// SummarizeDocument 生成文档的结构化摘要并保存到文档记录
+// 文档内容较长时先分批生成局部摘要，再合并为最终摘要（map-reduce）。
+// 已保存的摘要在文档重新处理前一直有效，refresh为true时重新生成；返回的cached表示是否使用了保存的摘要
+func (s *DocumentService) SummarizeDocument(ctx context.Context, fileID string, refresh bool) (_ *models.DocumentSummary, cached bool, err error) {
+	if s.summaryLLM == nil {
+		return nil, false, ErrSummaryDisabled
+	}
+	if err := s.Init(); err != nil {
+		return nil, false, err
+	}
+
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+	if doc.Status != models.DocStatusCompleted {
+		return nil, false, fmt.Errorf("%w: document %s is %s", models.ErrInvalidDocumentStatus, fileID, doc.Status)
+	}
+	if !refresh {
+		if summary := doc.StoredSummary(); summary != nil {
+			return summary, true, nil
+		}
+	}
+
+	var texts []string
+	for offset := 0; ; offset += summaryPageSize {
+		segments, total, err := s.ListSegments(ctx, fileID, offset, summaryPageSize)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, seg := range segments {
+			if text := strings.TrimSpace(seg.Text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		if len(segments) == 0 || int64(offset+summaryPageSize) >= total {
+			break
+		}
+	}
+	if len(texts) == 0 {
+		return nil, false, fmt.Errorf("%w: document %s has no content yet", models.ErrInvalidDocumentStatus, fileID)
+	}
+
+	// 局部摘要合并后仍然过长时继续分批概括
+	for round := 0; round < maxSummaryRounds; round++ {
+		batches := batchTexts(texts, summaryBatchChars)
+		if len(batches) <= 1 {
+			break
+		}
+		partials := make([]string, 0, len(batches))
+		for _, batch := range batches {
+			response, err := s.summaryLLM.Generate(ctx, fmt.Sprintf(summaryMapPrompt, doc.FileName, batch),
+				llm.WithGenerateMaxTokens(600),
+				llm.WithGenerateTemperature(0.3),
+				llm.WithGenerateCacheable())
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to summarize document section: %w", err)
+			}
+			partials = append(partials, strings.TrimSpace(response.Text))
+		}
+		texts = partials
+	}
+
+	response, err := s.summaryLLM.Generate(ctx,
+		fmt.Sprintf(summaryReducePrompt, doc.FileName, maxSummaryKeyPoints, truncateRunes(strings.Join(texts, "\n\n"), summaryBatchChars)),
+		llm.WithGenerateMaxTokens(1000),
+		llm.WithGenerateTemperature(0.3),
+		llm.WithGenerateCacheable())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to summarize document: %w", err)
+	}
+
+	summary := parseDocumentSummary(response.Text)
+	summary.SegmentCount = doc.SegmentCount
+	summary.GeneratedAt = time.Now()
+	if data, err := json.Marshal(summary); err == nil {
+		if err := s.repo.UpdateSummary(fileID, data); err != nil {
+			s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileID).Warn("Failed to save document summary")
+		}
+	}
+
+	s.logger.WithContext(ctx).WithField("file_id", fileID).Debug("Document summarized")
+	return summary, false, nil
+}
+
+// batchTexts 按字符数把文本分批，单段超过上限时截断
+func batchTexts(texts []string, maxChars int) []string {
+	var batches []string
+	var sb strings.Builder
+	chars := 0
+	for _, text := range texts {
+		text = truncateRunes(text, maxChars)
+		n := len([]rune(text))
+		if chars > 0 && chars+n > maxChars {
+			batches = append(batches, sb.String())
+			sb.Reset()
+			chars = 0
+		}
+		if chars > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(text)
+		chars += n
+	}
+	if chars > 0 {
+		batches = append(batches, sb.String())
+	}
+	return batches
+}
+
+// parseDocumentSummary 解析大模型返回的摘要，不是JSON时把整段输出作为摘要正文
+func parseDocumentSummary(text string) *models.DocumentSummary {
+	text = strings.TrimSpace(text)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start >= 0 && end > start {
+		var result struct {
+			Summary   string   `json:"summary"`
+			KeyPoints []string `json:"key_points"`
+		}
+		if err := json.Unmarshal([]byte(text[start:end+1]), &result); err == nil && strings.TrimSpace(result.Summary) != "" {
+			summary := &models.DocumentSummary{Summary: strings.TrimSpace(result.Summary), KeyPoints: []string{}}
+			for _, point := range result.KeyPoints {
+				if point = strings.TrimSpace(point); point != "" && len(summary.KeyPoints) < maxSummaryKeyPoints {
+					summary.KeyPoints = append(summary.KeyPoints, point)
+				}
+			}
+			return summary
+		}
+	}
+	return &models.DocumentSummary{Summary: text, KeyPoints: []string{}}
+}
+
+// SummaryCommand 返回处理问答中"总结文档"命令的处理器
+// target可以是文档ID或文件名，按文件名匹配到多个文档时要求用户指明；
+// 启用访问控制时只查找用户可以读取的文档，无权读取的文档按不存在回答
+func (s *DocumentService) SummaryCommand() CommandHandler {
+	return func(ctx context.Context, question, target string) (string, []vectordb.Document, error) {
+		if target == "" {
+			return "请指明要总结的文档，例如：总结文档《文件名》。", nil, nil
+		}
+
+		fileID := target
+		if _, _, err := s.readableDocument(ctx, target); err != nil {
+			if !errors.Is(err, models.ErrDocumentNotFound) {
+				return "", nil, err
+			}
+			docs, _, err := s.repo.List(0, 10, s.readableFilters(ctx, map[string]interface{}{"file_name": target}))
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to find document: %w", err)
+			}
+			fileID = ""
+			for _, doc := range docs {
+				if doc.FileName == target || strings.TrimSuffix(doc.FileName, filepath.Ext(doc.FileName)) == target {
+					fileID = doc.ID
+					break
+				}
+			}
+			switch {
+			case fileID != "":
+			case len(docs) == 0:
+				return fmt.Sprintf("没有找到文档《%s》。", target), nil, nil
+			case len(docs) == 1:
+				fileID = docs[0].ID
+			default:
+				return fmt.Sprintf("找到多个名称包含“%s”的文档，请使用完整的文件名或文档ID。", target), nil, nil
+			}
+		}
+
+		summary, _, err := s.SummarizeDocument(ctx, fileID, false)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidDocumentStatus) {
+				return "该文档尚未处理完成，暂时无法总结。", nil, nil
+			}
+			return "", nil, err
+		}
+		return formatSummaryAnswer(summary), nil, nil
+	}
+}
+
+// formatSummaryAnswer 把摘要整理为问答的回答文本
+func formatSummaryAnswer(summary *models.DocumentSummary) string {
+	var sb strings.Builder
+	sb.WriteString(summary.Summary)
+	if len(summary.KeyPoints) > 0 {
+		sb.WriteString("\n\n要点：")
+		for i, point := range summary.KeyPoints {
+			fmt.Fprintf(&sb, "\n%d. %s", i+1, point)
+		}
+	}
+	return sb.String()
+}
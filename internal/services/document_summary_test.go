@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSummarizeDocument 测试分批生成文档摘要、保存摘要以及问答中的总结命令
+func TestSummarizeDocument(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-summary-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, _ := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	_, _, err = docService.SummarizeDocument(ctx, "manual", false)
+	assert.ErrorIs(t, err, ErrSummaryDisabled)
+
+	llmClient := llm.NewMockClient(t)
+	WithDocumentSummarizer(llmClient)(docService)
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:           "manual",
+		FileName:     "运维手册.pdf",
+		FileType:     "pdf",
+		FilePath:     "/tmp/manual.pdf",
+		Status:       models.DocStatusCompleted,
+		UploadedAt:   time.Now(),
+		SegmentCount: 2,
+		Tenant:       "alice",
+	}))
+	// 两个段落超过一批的长度，先分别生成局部摘要
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.pdf", []document.Content{
+		{Text: "Redis部署" + strings.Repeat("甲", summaryBatchChars-100), Index: 0},
+		{Text: "备份策略" + strings.Repeat("乙", summaryBatchChars-100), Index: 1},
+	}))
+
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "一部分内容") && strings.Contains(prompt, "Redis部署")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "介绍了Redis部署"}, nil).Once()
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "一部分内容") && strings.Contains(prompt, "备份策略")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "介绍了备份策略"}, nil).Once()
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "结构化的文档摘要") &&
+				strings.Contains(prompt, "介绍了Redis部署\n\n介绍了备份策略")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "```json\n{\"summary\": \"Redis运维手册\", \"key_points\": [\"部署\", \" \", \"备份\"]}\n```"}, nil).Once()
+
+	summary, cached, err := docService.SummarizeDocument(ctx, "manual", false)
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "Redis运维手册", summary.Summary)
+	assert.Equal(t, []string{"部署", "备份"}, summary.KeyPoints)
+
+	// 摘要保存在文档记录中，再次请求不调用大模型
+	summary, cached, err = docService.SummarizeDocument(ctx, "manual", false)
+	require.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "Redis运维手册", summary.Summary)
+
+	// 问答中的总结命令按文件名找到文档
+	answer, _, err := docService.SummaryCommand()(ctx, "总结文档《运维手册》", "运维手册")
+	require.NoError(t, err)
+	assert.Equal(t, "Redis运维手册\n\n要点：\n1. 部署\n2. 备份", answer)
+
+	answer, _, err = docService.SummaryCommand()(ctx, "总结文档《不存在》", "不存在")
+	require.NoError(t, err)
+	assert.Contains(t, answer, "没有找到文档")
+
+	// 启用访问控制后，无权读取的用户按文档ID或文件名都找不到文档
+	WithAccessPolicy(acl.NewPolicy())(docService)
+	bob := usage.WithTenant(ctx, "bob")
+	for _, target := range []string{"manual", "运维手册"} {
+		answer, _, err = docService.SummaryCommand()(bob, "总结文档", target)
+		require.NoError(t, err)
+		assert.Contains(t, answer, "没有找到文档")
+	}
+	answer, _, err = docService.SummaryCommand()(usage.WithTenant(ctx, "alice"), "总结文档", "manual")
+	require.NoError(t, err)
+	assert.Contains(t, answer, "Redis运维手册")
+
+	// 文档重新处理后保存的摘要失效
+	doc, err := docService.repo.GetByID("manual")
+	require.NoError(t, err)
+	processedAt := time.Now().Add(time.Minute)
+	doc.ProcessedAt = &processedAt
+	assert.Nil(t, doc.StoredSummary())
+
+	_, _, err = docService.SummarizeDocument(ctx, "missing", false)
+	assert.ErrorIs(t, err, models.ErrDocumentNotFound)
+}
+
+// TestParseDocumentSummary 测试解析大模型返回的摘要
+func TestParseDocumentSummary(t *testing.T) {
+	summary := parseDocumentSummary("这是一段普通文本")
+	assert.Equal(t, "这是一段普通文本", summary.Summary)
+	assert.Empty(t, summary.KeyPoints)
+
+	assert.Equal(t, []string{"ab", "cd\ne"}, batchTexts([]string{"ab", "cd", "e"}, 3))
+}
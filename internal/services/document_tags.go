@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+)
+
+const (
+	// defaultTagSuggestions 默认建议的标签数量
+	defaultTagSuggestions = 5
+	// maxTagSuggestions 建议的标签数量上限
+	maxTagSuggestions = 10
+	// tagSampleSegments 建议标签时读取的段落数，取文档开头的段落
+	tagSampleSegments = 8
+	// tagSampleChars 放入提示词的文档内容最大字符数
+	tagSampleChars = 3000
+	// maxTagLength 单个标签的最大字符数，过长的通常是模型输出的句子而不是标签
+	maxTagLength = 20
+)
+
+// ErrTagSuggestionDisabled 未配置大模型时无法建议标签
+var ErrTagSuggestionDisabled = errors.New("tag suggestion is not configured")
+
+// tagListMarker 标签前的编号或列表符号，如"1."、"2）"、"-"、"#"
+var tagListMarker = regexp.MustCompile(`^(\d+[.)）]|[-*•·#]+)\s*`)
+
+// suggestTagsPrompt 建议标签的提示词模板
+const suggestTagsPrompt = `请根据下面的文档内容，为文档建议%d个标签，用于文档分类和筛选。
+要求：
+1. 标签简短，一般为2到6个字，概括文档的主题、领域或类型
+2. 不要重复已有标签：%s
+3. 只输出标签，用逗号分隔，不要编号，不要输出其他内容
+
+文件名：%s
+文档内容：
+%s`
+
+// TagSuggestion 文档的标签建议
+type TagSuggestion struct {
+	Tags        []string // 文档已有的标签
+	Suggestions []string // 建议的标签，不包含已有标签
+}
+
+// WithTagSuggester 设置用于建议标签的大模型客户端
+func WithTagSuggester(client llm.Client) DocumentOption {
+	return func(s *DocumentService) {
+		s.tagLLM = client
+	}
+}
+
+// SuggestTags 让大模型根据文档开头的段落建议标签
+// 建议的标签不包含文档已有的标签，由用户确认后再通过UpdateDocumentTags写入
+func (s *DocumentService) SuggestTags(ctx context.Context, fileID string, limit int) (*TagSuggestion, error) {
+	if s.tagLLM == nil {
+		return nil, ErrTagSuggestionDisabled
+	}
+	if limit < 1 || limit > maxTagSuggestions {
+		limit = defaultTagSuggestions
+	}
+
+	segments, _, err := s.ListSegments(ctx, fileID, 0, tagSampleSegments)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrDocumentNotFound, fileID)
+	}
+
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		texts = append(texts, seg.Text)
+	}
+	sample := truncateRunes(strings.TrimSpace(strings.Join(texts, "\n")), tagSampleChars)
+	if sample == "" {
+		return nil, fmt.Errorf("%w: document %s has no content yet", models.ErrInvalidDocumentStatus, fileID)
+	}
+
+	existing := SplitTags(doc.Tags)
+	existingText := strings.Join(existing, "，")
+	if existingText == "" {
+		existingText = "无"
+	}
+
+	response, err := s.tagLLM.Generate(ctx, fmt.Sprintf(suggestTagsPrompt, limit, existingText, doc.FileName, sample),
+		llm.WithGenerateMaxTokens(100),
+		llm.WithGenerateTemperature(0.3),
+		llm.WithGenerateCacheable())
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest tags: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithField("file_id", fileID).Debug("Tags suggested")
+	return &TagSuggestion{
+		Tags:        existing,
+		Suggestions: parseTagSuggestions(response.Text, existing, limit),
+	}, nil
+}
+
+// SplitTags 拆分以逗号分隔的标签，去掉空白
+func SplitTags(tags string) []string {
+	var result []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// parseTagSuggestions 解析大模型返回的标签
+// 按中英文逗号、顿号和换行拆分，去掉编号和#号，跳过已有、重复和过长的标签，最多保留limit个
+func parseTagSuggestions(text string, existing []string, limit int) []string {
+	seen := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		seen[strings.ToLower(tag)] = true
+	}
+
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '，' || r == '、' || r == '\n' || r == ';' || r == '；'
+	})
+	tags := make([]string, 0, limit)
+	for _, field := range fields {
+		tag := tagListMarker.ReplaceAllString(strings.TrimSpace(field), "")
+		tag = strings.Trim(tag, "\"'“”‘’「」【】[] ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] || utf8.RuneCountInString(tag) > maxTagLength {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+		if len(tags) >= limit {
+			break
+		}
+	}
+	return tags
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSuggestTags 测试根据文档内容建议标签，并将选中的标签写入文档
+func TestSuggestTags(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-tags-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, _, _ := setupDocumentTestEnv(t, tempDir)
+	ctx := context.Background()
+
+	_, err = docService.SuggestTags(ctx, "manual", 0)
+	assert.ErrorIs(t, err, ErrTagSuggestionDisabled)
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "Redis集群部署") && strings.Contains(prompt, "运维")
+		}), mock.Anything, mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "1. Redis，运维\n#缓存、部署指南, 这是一个非常非常非常非常非常非常长的句子而不是标签"}, nil).Once()
+	WithTagSuggester(llmClient)(docService)
+
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:           "manual",
+		FileName:     "manual.pdf",
+		FileType:     "pdf",
+		FilePath:     "/tmp/manual.pdf",
+		Status:       models.DocStatusCompleted,
+		Tags:         "运维",
+		UploadedAt:   time.Now(),
+		SegmentCount: 1,
+	}))
+	require.NoError(t, docService.processBatches(ctx, "manual", "/tmp/manual.pdf", []document.Content{
+		{Text: "Redis集群部署步骤", Index: 0},
+	}))
+
+	suggestion, err := docService.SuggestTags(ctx, "manual", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"运维"}, suggestion.Tags)
+	assert.Equal(t, []string{"Redis", "缓存", "部署指南"}, suggestion.Suggestions)
+
+	// 选中的标签写入已有的Tags字段
+	require.NoError(t, docService.UpdateDocumentTags(ctx, "manual", "运维,Redis"))
+	doc, err := docService.repo.GetByID("manual")
+	require.NoError(t, err)
+	assert.Equal(t, "运维,Redis", doc.Tags)
+
+	_, err = docService.SuggestTags(ctx, "missing", 0)
+	assert.ErrorIs(t, err, models.ErrDocumentNotFound)
+	assert.ErrorIs(t, docService.UpdateDocumentTags(ctx, "missing", "a"), models.ErrDocumentNotFound)
+
+	// 还没有段落的文档无法建议标签
+	require.NoError(t, docService.repo.Create(&models.Document{
+		ID:         "pending",
+		FileName:   "pending.pdf",
+		FileType:   "pdf",
+		FilePath:   "/tmp/pending.pdf",
+		Status:     models.DocStatusProcessing,
+		UploadedAt: time.Now(),
+	}))
+	_, err = docService.SuggestTags(ctx, "pending", 0)
+	assert.ErrorIs(t, err, models.ErrInvalidDocumentStatus)
+}
+
+// TestParseTagSuggestions 测试解析大模型返回的标签
+func TestParseTagSuggestions(t *testing.T) {
+	tags := parseTagSuggestions("1. 5G网络\n2) redis\n- Redis\n「合同」；发票", []string{"发票"}, 5)
+	assert.Equal(t, []string{"5G网络", "redis", "合同"}, tags)
+
+	tags = parseTagSuggestions("a,b,c,d", nil, 2)
+	assert.Equal(t, []string{"a", "b"}, tags)
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/events"
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
@@ -83,7 +85,7 @@ func setupTestDB(t *testing.T) (*gorm.DB, func()) {
 	require.NoError(t, err, "Failed to connect to test database")
 
 	// 运行迁移
-	err = db.AutoMigrate(&models.Document{}, &models.DocumentSegment{})
+	err = db.AutoMigrate(&models.Document{}, &models.DocumentSegment{}, &models.DocumentShare{})
 	require.NoError(t, err, "Failed to run migrations")
 
 	// 保存原始DB引用并替换
@@ -141,6 +143,60 @@ func TestDocumentService(t *testing.T) {
 	assert.Equal(t, 3, len(results), "There should be 3 paragraphs saved")
 }
 
+// TestGetSegmentContext 测试解析来源段落的上下文窗口
+func TestGetSegmentContext(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-context-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	docService, vectorDB, _ := setupDocumentTestEnv(t, tempDir)
+	require.NoError(t, docService.Init())
+
+	ctx := context.Background()
+	fileID := "context-file-id"
+	texts := []string{"第一段落。", "第二段落。", "第三段落。"}
+	for i, text := range texts {
+		segmentID := fmt.Sprintf("%s_%d", fileID, i)
+		require.NoError(t, docService.repo.SaveSegment(&models.DocumentSegment{
+			DocumentID: fileID,
+			SegmentID:  segmentID,
+			Position:   i,
+			Text:       text,
+		}))
+		require.NoError(t, vectorDB.Add(vectordb.Document{
+			ID:       segmentID,
+			FileID:   fileID,
+			Position: i,
+			Text:     text,
+			Vector:   generateTestVector(4, text),
+		}))
+	}
+
+	segCtx, err := docService.GetSegmentContext(ctx, fileID, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, segCtx.Segments, 3)
+
+	target := segCtx.Segments[1]
+	assert.True(t, target.Target)
+	assert.Equal(t, fileID+"_1", target.SegmentID)
+	assert.Equal(t, target.Text, string([]rune(segCtx.Context)[target.Start:target.End]))
+
+	// 窗口在文档开头处截断
+	segCtx, err = docService.GetSegmentContext(ctx, fileID, 0, 1)
+	require.NoError(t, err)
+	assert.Len(t, segCtx.Segments, 2)
+
+	// 段落表没有记录时从向量数据库读取
+	require.NoError(t, docService.repo.DeleteSegments(fileID))
+	segCtx, err = docService.GetSegmentContext(ctx, fileID, 2, 1)
+	require.NoError(t, err)
+	require.Len(t, segCtx.Segments, 2)
+	assert.True(t, segCtx.Segments[1].Target)
+
+	_, err = docService.GetSegmentContext(ctx, fileID, 10, 1)
+	assert.Error(t, err, "missing segment should not resolve")
+}
+
 // TestProcessDocumentWithDifferentTypes 测试处理不同类型的文档
 func TestProcessDocumentWithDifferentTypes(t *testing.T) {
 	tempDir, err := ioutil.TempDir("", "docqa-multitype-*")
@@ -438,6 +494,50 @@ func TestDocumentStatusManager_DeleteDocument(t *testing.T) {
 	assert.Error(t, err, "Document should be deleted")
 }
 
+// TestDocumentStatusManager_Events 测试状态变更成功后发布文档事件
+func TestDocumentStatusManager_Events(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	statusManager := NewDocumentStatusManager(repository.NewDocumentRepository(), logrus.New())
+	memory := events.NewMemoryPublisher(10)
+	bus := events.NewBus([]events.Publisher{memory})
+	statusManager.SetEventBus(bus)
+	changes := 0
+	statusManager.OnDocumentsChanged(func(context.Context) { changes++ })
+
+	ctx := context.Background()
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "doc-ok", "ok.md", "/path/to/ok.md", 10))
+	require.NoError(t, statusManager.MarkAsCompleted(ctx, "doc-ok", 3))
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "doc-bad", "bad.pdf", "/path/to/bad.pdf", 20))
+	require.NoError(t, statusManager.MarkAsFailed(ctx, "doc-bad", "parse error"))
+	require.NoError(t, statusManager.DeleteDocument(ctx, "doc-ok"))
+	// 状态转换失败时不发布事件
+	assert.Error(t, statusManager.MarkAsCompleted(ctx, "missing", 1))
+	require.NoError(t, bus.Close(ctx))
+
+	entries, _ := memory.Since(0, 0)
+	require.Len(t, entries, 5)
+	var types []string
+	for _, entry := range entries {
+		types = append(types, entry.Event.Type+":"+entry.Event.Subject)
+	}
+	assert.Equal(t, []string{
+		"document.uploaded:doc-ok",
+		"document.processed:doc-ok",
+		"document.uploaded:doc-bad",
+		"document.processed:doc-bad",
+		"document.deleted:doc-ok",
+	}, types)
+	assert.Equal(t, "md", entries[0].Event.Data["file_type"])
+	assert.Equal(t, "completed", entries[1].Event.Data["status"])
+	assert.Equal(t, 3, entries[1].Event.Data["segments"])
+	assert.Equal(t, "failed", entries[3].Event.Data["status"])
+	assert.Equal(t, "parse error", entries[3].Event.Data["error"])
+	// 只有处理完成和删除改变了可检索的文档
+	assert.Equal(t, 2, changes)
+}
+
 // TestDocumentStatusManager_EdgeCases 测试边缘情况
 func TestDocumentStatusManager_EdgeCases(t *testing.T) {
 	_, cleanup := setupTestDB(t)
@@ -704,9 +804,9 @@ func TestDocumentServiceWithPythonClient(t *testing.T) {
 
 	// 设置文档服务依赖
 	splitterConfig := document.DefaultSplitterConfig()
-	splitterConfig.ChunkSize = 200        
-	splitterConfig.Overlap = 50           
-	splitterConfig.SplitType = "sentence" 
+	splitterConfig.ChunkSize = 200
+	splitterConfig.Overlap = 50
+	splitterConfig.SplitType = "sentence"
 	textSplitter, err := document.NewTextSplitter(splitterConfig)
 	require.NoError(t, err)
 	embeddingClient := &testEmbeddingClient{dimension: 4}
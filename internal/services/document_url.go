@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// maxURLFetchSize 单个网页的最大抓取大小
+	maxURLFetchSize = 20 * 1024 * 1024
+	// maxURLFileNameLength 由网页标题生成的文件名最大长度
+	maxURLFileNameLength = 80
+	// urlFetchUserAgent 抓取网页时使用的User-Agent
+	urlFetchUserAgent = "doc-QA-system/1.0 (+https://github.com/fyerfyer/doc-QA-system)"
+)
+
+// fileNameUnsafePattern 文件名中不允许出现的字符
+var fileNameUnsafePattern = regexp.MustCompile(`[\\/:*?"<>|\s]+`)
+
+// maxURLRedirects 抓取网页时最多跟随的重定向次数
+const maxURLRedirects = 10
+
+// errInternalAddress 抓取的URL指向内部网络地址
+var errInternalAddress = errors.New("address is not publicly routable")
+
+// newURLFetchClient 创建抓取网页使用的HTTP客户端
+// URL由用户提交，为避免借服务端访问内部服务（SSRF），DNS解析后、建立连接前检查目标地址，
+// 拒绝回环、私有、链路本地和未指定地址；每次重定向重新检查目标URL。不使用环境变量中的代理，
+// 否则连接检查的是代理地址而不是目标地址
+func newURLFetchClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: rejectInternalDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: checkFetchRedirect}
+}
+
+// rejectInternalDial 在建立连接前检查DNS解析后的目标地址
+// address为已解析的IP和端口，主机名解析到内部地址（包括DNS重绑定）时同样被拒绝
+func rejectInternalDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if isInternalAddress(addr) {
+		return fmt.Errorf("refusing to connect to %s: %w", address, errInternalAddress)
+	}
+	return nil
+}
+
+// checkFetchRedirect 检查重定向的目标URL，只允许跳转到公网的http和https地址
+func checkFetchRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxURLRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxURLRedirects)
+	}
+	u := req.URL
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("refusing to follow redirect to %s", u.Redacted())
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(req.Context(), "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve redirect target %s: %w", u.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if isInternalAddress(addr) {
+			return fmt.Errorf("refusing to follow redirect to %s: %w", u.Redacted(), errInternalAddress)
+		}
+	}
+	return nil
+}
+
+// isInternalAddress 判断地址是否为回环、私有、链路本地、未指定或组播地址
+func isInternalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+}
+
+// URLIngestResult 网页导入结果
+type URLIngestResult struct {
+	URL         string // 原始URL
+	FileID      string // 生成的文档ID
+	FileName    string // 存储的文件名
+	FilePath    string // 存储路径
+	Title       string // 网页标题
+	ContentType string // 网页内容类型
+	Size        int64  // 存储的内容大小
+}
+
+// IngestURL 抓取网页并保存为文档
+// HTML页面会提取正文后以Markdown形式保存，纯文本和PDF按原样保存。
+// 来源URL记录在文档元数据中，后续分块向量化时写入每个段落的元数据。
+// 该方法只负责抓取和存储，调用方需要再调用ProcessDocument处理文档
+func (s *DocumentService) IngestURL(ctx context.Context, rawURL string, tags string) (*URLIngestResult, error) {
+	// 确保初始化完成
+	if err := s.Init(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", rawURL)
+	}
+
+	page, err := s.fetchPage(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	// 保存到存储
+	fileInfo, err := s.storage.Save(bytes.NewReader(page.data), page.fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save fetched content: %w", err)
+	}
+
+	// 记录文档，并把来源信息写入文档元数据
+	if err := s.statusManager.MarkAsUploaded(ctx, fileInfo.ID, page.fileName, fileInfo.Path, fileInfo.Size); err != nil {
+		return nil, fmt.Errorf("failed to create document record: %w", err)
+	}
+	metadata := map[string]interface{}{
+		"source_url":   u.String(),
+		"content_type": page.contentType,
+		"content_hash": contentHash(page.data),
+		"fetched_at":   time.Now().Format(time.RFC3339),
+	}
+	if page.title != "" {
+		metadata["title"] = page.title
+	}
+	if err := s.updateDocumentMetadata(ctx, fileInfo.ID, metadata, tags); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("file_id", fileInfo.ID).Warn("Failed to save document source metadata")
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"url":     u.String(),
+		"file_id": fileInfo.ID,
+		"title":   page.title,
+		"size":    fileInfo.Size,
+	}).Info("Web page ingested")
+
+	return &URLIngestResult{
+		URL:         u.String(),
+		FileID:      fileInfo.ID,
+		FileName:    page.fileName,
+		FilePath:    fileInfo.Path,
+		Title:       page.title,
+		ContentType: page.contentType,
+		Size:        fileInfo.Size,
+	}, nil
+}
+
+// fetchedPage 抓取并转换后的网页
+type fetchedPage struct {
+	data        []byte // 待存储的内容
+	title       string // 网页标题
+	fileName    string // 存储的文件名
+	contentType string // 媒体类型
+}
+
+// fetchPage 抓取网页并转换为可存储的文档内容
+// HTML页面提取正文并转换为Markdown，纯文本和PDF保持原样
+func (s *DocumentService) fetchPage(ctx context.Context, u *url.URL) (*fetchedPage, error) {
+	data, contentType, err := s.fetchURL(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &fetchedPage{data: data, contentType: contentType}
+	switch contentType {
+	case "text/html", "application/xhtml+xml":
+		extracted, err := document.ExtractHTML(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract content from %s: %w", u, err)
+		}
+		page.title = extracted.Title
+		text := extracted.Text
+		if page.title != "" && !strings.HasPrefix(text, "# ") {
+			text = "# " + page.title + "\n\n" + text
+		}
+		page.data = []byte(text)
+		page.fileName = urlFileName(page.title, u, ".md")
+	case "text/plain", "text/markdown", "text/x-markdown":
+		ext := strings.ToLower(path.Ext(u.Path))
+		if ext != ".md" && ext != ".markdown" && ext != ".txt" {
+			ext = ".txt"
+		}
+		page.fileName = urlFileName("", u, ext)
+	case "application/pdf":
+		page.fileName = urlFileName("", u, ".pdf")
+	default:
+		return nil, fmt.Errorf("unsupported content type %q from %s", contentType, u)
+	}
+	return page, nil
+}
+
+// contentHash 计算内容的SHA-256摘要，用于判断网页内容是否变化
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fetchURL 下载网页内容，返回UTF-8编码的内容和媒体类型
+func (s *DocumentService) fetchURL(ctx context.Context, u *url.URL) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", urlFetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain,text/markdown,application/pdf;q=0.9,*/*;q=0.5")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("failed to fetch %s: status %d", u, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxURLFetchSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	if len(data) > maxURLFetchSize {
+		return nil, "", fmt.Errorf("content of %s exceeds %d bytes", u, maxURLFetchSize)
+	}
+
+	header := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+
+	// 文本内容统一转换为UTF-8
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/xhtml+xml" {
+		reader, err := charset.NewReader(bytes.NewReader(data), header)
+		if err == nil {
+			if converted, err := io.ReadAll(reader); err == nil {
+				data = converted
+			}
+		}
+	}
+
+	return data, mediaType, nil
+}
+
+// updateDocumentMetadata 合并文档元数据并更新标签
+func (s *DocumentService) updateDocumentMetadata(ctx context.Context, fileID string, metadata map[string]interface{}, tags string) error {
+	doc, err := s.statusManager.GetDocument(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	merged := s.documentMetadata(fileID)
+	for k, v := range metadata {
+		merged[k] = v
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	doc.Metadata = raw
+	if tags != "" {
+		doc.Tags = tags
+	}
+	return s.repo.Update(doc)
+}
+
+// documentMetadata 读取文档级元数据
+// 返回的map可以直接修改，读取失败时返回空map
+func (s *DocumentService) documentMetadata(fileID string) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	doc, err := s.repo.GetByID(fileID)
+	if err != nil || doc == nil || len(doc.Metadata) == 0 {
+		return metadata
+	}
+	if err := json.Unmarshal(doc.Metadata, &metadata); err != nil {
+		s.logger.WithError(err).WithField("file_id", fileID).Warn("Failed to decode document metadata")
+		return make(map[string]interface{})
+	}
+	return metadata
+}
+
+// urlFileName 根据网页标题或URL路径生成文件名
+func urlFileName(title string, u *url.URL, ext string) string {
+	name := title
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	}
+	if name == "" || name == "." || name == "/" {
+		name = u.Hostname()
+	}
+
+	name = strings.Trim(fileNameUnsafePattern.ReplaceAllString(name, "_"), "_.")
+	if runes := []rune(name); len(runes) > maxURLFileNameLength {
+		name = string(runes[:maxURLFileNameLength])
+	}
+	if name == "" {
+		name = "page"
+	}
+	return name + ext
+}
+
+// mergeMetadata 将文档级元数据合并到段落元数据中，段落自身的字段优先
+func mergeMetadata(base map[string]interface{}, extra map[string]interface{}) map[string]interface{} {
+	for k, v := range extra {
+		if _, ok := base[k]; !ok {
+			base[k] = v
+		}
+	}
+	return base
+}
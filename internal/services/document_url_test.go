@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIngestURL 测试抓取网页并保留来源URL元数据
+func TestIngestURL(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "docqa-url-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><title>向量检索指南</title></head><body>
+				<nav><a href="/">首页</a></nav>
+				<article><p>向量检索通过比较嵌入向量之间的相似度来查找相关的文档段落。</p></article>
+			</body></html>`))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	// 测试服务器监听回环地址，默认客户端会拒绝连接
+	docService.httpClient = server.Client()
+	ctx := context.Background()
+
+	result, err := docService.IngestURL(ctx, server.URL+"/article", "web")
+	require.NoError(t, err)
+	assert.Equal(t, "向量检索指南", result.Title)
+	assert.Equal(t, "向量检索指南.md", result.FileName)
+
+	doc, err := statusManager.GetDocument(ctx, result.FileID)
+	require.NoError(t, err)
+	assert.Equal(t, "web", doc.Tags)
+
+	var metadata map[string]interface{}
+	require.NoError(t, json.Unmarshal(doc.Metadata, &metadata))
+	assert.Equal(t, server.URL+"/article", metadata["source_url"])
+
+	// 分块向量化后，来源URL写入每个段落的元数据
+	segments := []document.Content{{Text: "向量检索通过比较嵌入向量之间的相似度", Index: 0}}
+	require.NoError(t, docService.processBatches(ctx, result.FileID, result.FilePath, segments))
+	stored, err := vectorDB.Get(result.FileID + "_0")
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/article", stored.Metadata["source_url"])
+	assert.Equal(t, result.FilePath, stored.Metadata["source"])
+
+	// 不支持的内容类型、错误状态码和非法URL
+	_, err = docService.IngestURL(ctx, server.URL+"/image", "")
+	assert.Error(t, err)
+	_, err = docService.IngestURL(ctx, server.URL+"/missing", "")
+	assert.Error(t, err)
+	_, err = docService.IngestURL(ctx, "file:///etc/passwd", "")
+	assert.Error(t, err)
+}
+
+// TestRejectInternalDial 测试建立连接前拒绝内部网络地址
+func TestRejectInternalDial(t *testing.T) {
+	tests := []struct {
+		address string
+		blocked bool
+	}{
+		{"127.0.0.1:80", true},
+		{"[::1]:443", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:8080", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"[fd00::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"[::]:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"93.184.216.34:443", false},
+		{"[2606:4700:4700::1111]:443", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := rejectInternalDial("tcp", tt.address, nil)
+			if tt.blocked {
+				assert.ErrorIs(t, err, errInternalAddress)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// TestURLFetchClientRejectsInternalAddress 测试默认客户端拒绝抓取内部网络地址
+func TestURLFetchClientRejectsInternalAddress(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("内部服务"))
+	}))
+	defer server.Close()
+
+	resp, err := newURLFetchClient(5 * time.Second).Get(server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	assert.ErrorIs(t, err, errInternalAddress)
+	assert.Zero(t, hits.Load(), "request must not reach the internal server")
+}
+
+// TestCheckFetchRedirect 测试每次重定向重新检查目标URL
+func TestCheckFetchRedirect(t *testing.T) {
+	redirect := func(target string, hops int) error {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		return checkFetchRedirect(req, make([]*http.Request, hops))
+	}
+
+	assert.NoError(t, redirect("http://93.184.216.34/page", 1))
+	assert.ErrorIs(t, redirect("http://127.0.0.1/admin", 1), errInternalAddress)
+	assert.ErrorIs(t, redirect("http://169.254.169.254/latest/meta-data/", 1), errInternalAddress)
+	assert.ErrorIs(t, redirect("http://localhost:8080/", 1), errInternalAddress)
+	assert.Error(t, redirect("ftp://93.184.216.34/file", 1))
+	assert.Error(t, redirect("http://93.184.216.34/page", maxURLRedirects))
+
+	// 公网页面重定向到内部地址时不跟随
+	var internalHits atomic.Int32
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalHits.Add(1)
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/secret", http.StatusFound)
+	}))
+	defer public.Close()
+
+	// 测试服务器都监听回环地址，这里只使用重定向检查，不检查首次连接的地址
+	client := public.Client()
+	client.CheckRedirect = checkFetchRedirect
+	resp, err := client.Get(public.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	assert.ErrorIs(t, err, errInternalAddress)
+	assert.Zero(t, internalHits.Load())
+}
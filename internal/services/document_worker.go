@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/sirupsen/logrus"
+)
+
+// DocumentTaskHandler 文档处理任务处理器
+// 在Go进程内完成解析、分块、向量化和存储，替代Python服务处理异步任务，
+// 解析器、分段器和嵌入模型与同步处理使用的相同
+type DocumentTaskHandler struct {
+	docService *DocumentService
+}
+
+// NewDocumentTaskHandler 创建文档处理任务处理器
+func NewDocumentTaskHandler(docService *DocumentService) *DocumentTaskHandler {
+	return &DocumentTaskHandler{docService: docService}
+}
+
+// GetTaskTypes 返回支持的任务类型
+func (h *DocumentTaskHandler) GetTaskTypes() []taskqueue.TaskType {
+	return []taskqueue.TaskType{taskqueue.TaskProcessComplete}
+}
+
+// ProcessTask 处理文档
+// 分块参数以服务配置的分段器为准，载荷中的分块参数只对Python服务生效
+func (h *DocumentTaskHandler) ProcessTask(ctx context.Context, task *taskqueue.Task) error {
+	if task.Type != taskqueue.TaskProcessComplete {
+		return fmt.Errorf("unsupported task type: %s", task.Type)
+	}
+
+	var payload taskqueue.ProcessCompletePayload
+	if err := taskqueue.UnmarshalPayload(task.Payload, &payload); err != nil {
+		return fmt.Errorf("%w: %v", taskqueue.ErrInvalidPayload, err)
+	}
+	documentID := payload.DocumentID
+	if documentID == "" {
+		documentID = task.DocumentID
+	}
+	if documentID == "" || payload.FilePath == "" {
+		return fmt.Errorf("%w: missing document id or file path", taskqueue.ErrInvalidPayload)
+	}
+
+	if err := h.docService.Init(); err != nil {
+		return err
+	}
+
+	h.docService.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"task_id":     task.ID,
+		"document_id": documentID,
+	}).Info("Processing document in Go worker")
+
+	return h.docService.processDocumentSync(ctx, documentID, payload.FilePath)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDocumentTaskHandler 测试由Go worker处理异步文档任务
+func TestDocumentTaskHandler(t *testing.T) {
+	tempDir := t.TempDir()
+	docService, vectorDB, statusManager := setupDocumentTestEnv(t, tempDir)
+	docService.splitter = lineSplitter{}
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+	queue, err := taskqueue.NewRedisQueue(&taskqueue.Config{RedisAddr: mr.Addr(), RetryLimit: 1})
+	require.NoError(t, err)
+	defer queue.Close()
+
+	WithNativeWorker(true)(docService)
+	docService.EnableAsyncProcessing(queue)
+
+	ctx := context.Background()
+	content := "第一段介绍文档上传。\n第二段介绍问答流程。\n"
+	filePath := filepath.Join(tempDir, "guide.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+	require.NoError(t, statusManager.MarkAsUploaded(ctx, "doc-native", "guide.txt", filePath, int64(len(content))))
+
+	// 启用Go worker时投递完整处理任务，不调用Python服务
+	require.NoError(t, docService.ProcessDocument(ctx, "doc-native", filePath))
+	tasks, err := queue.GetTasksByDocument(ctx, "doc-native")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, taskqueue.TaskProcessComplete, tasks[0].Type)
+
+	status, err := statusManager.GetStatus(ctx, "doc-native")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusProcessing, status)
+
+	handler := NewDocumentTaskHandler(docService)
+	assert.Equal(t, []taskqueue.TaskType{taskqueue.TaskProcessComplete}, handler.GetTaskTypes())
+	require.NoError(t, handler.ProcessTask(ctx, tasks[0]))
+
+	doc, err := statusManager.GetDocument(ctx, "doc-native")
+	require.NoError(t, err)
+	assert.Equal(t, models.DocStatusCompleted, doc.Status)
+	assert.Equal(t, 2, doc.SegmentCount)
+
+	results, err := vectorDB.Search(make([]float32, 4), vectordb.SearchFilter{FileIDs: []string{"doc-native"}, MaxResults: 10})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	// 载荷无效的任务不再重试
+	err = handler.ProcessTask(ctx, &taskqueue.Task{Type: taskqueue.TaskProcessComplete, Payload: []byte("{")})
+	assert.ErrorIs(t, err, taskqueue.ErrInvalidPayload)
+}
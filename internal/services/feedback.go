@@ -0,0 +1,412 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// RetrievalSuppressor 检索结果调整接口
+// 问答服务在检索之后、过滤低分结果之前调用，用于对被负反馈的段落降权
+type RetrievalSuppressor interface {
+	// Suppress 根据问题向量调整检索结果的得分，返回按得分重新排序的结果
+	Suppress(ctx context.Context, vector []float32, results []vectordb.SearchResult) []vectordb.SearchResult
+}
+
+// FeedbackResult 提交负反馈的结果
+type FeedbackResult struct {
+	ClusterID string   // 问题归入的问题簇ID
+	Recorded  []string // 已记录的段落ID
+	Activated []string // 本次反馈后开始生效抑制的段落ID
+}
+
+// FeedbackService 回答反馈服务
+// 把问题按语义归入问题簇，统计每个簇中被标记错误的来源段落，
+// 错误次数达到阈值后在该簇问题的检索中对段落降权
+type FeedbackService struct {
+	repo       repository.FeedbackRepository // 反馈仓储
+	embedder   embedding.Client              // 嵌入模型客户端，用于问题聚类
+	vectorDB   vectordb.Repository           // 向量数据库，用于校验段落和保存段落快照
+	cache      cache.Cache                   // 问答缓存，抑制状态变化时清除
+	logger     *logrus.Logger                // 日志记录器
+	threshold  int                           // 抑制生效所需的错误次数
+	similarity float32                       // 问题归入同一簇的最低相似度
+	penalty    float32                       // 降权系数，得分乘以该系数
+
+	mu       sync.RWMutex
+	loaded   bool
+	clusters []*models.QuestionCluster  // 所有问题簇
+	active   map[string]map[string]bool // 问题簇ID -> 生效中的段落ID集合
+}
+
+// FeedbackOption 反馈服务配置选项
+type FeedbackOption func(*FeedbackService)
+
+// NewFeedbackService 创建回答反馈服务实例
+func NewFeedbackService(repo repository.FeedbackRepository, embedder embedding.Client, vectorDB vectordb.Repository, opts ...FeedbackOption) *FeedbackService {
+	service := &FeedbackService{
+		repo:       repo,
+		embedder:   embedder,
+		vectorDB:   vectorDB,
+		logger:     logrus.New(),
+		threshold:  3,
+		similarity: 0.85,
+		penalty:    0.5,
+		active:     make(map[string]map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
+}
+
+// WithFeedbackLogger 设置日志记录器
+func WithFeedbackLogger(logger *logrus.Logger) FeedbackOption {
+	return func(s *FeedbackService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithFeedbackCache 设置问答缓存，抑制状态变化时使缓存的回答失效
+func WithFeedbackCache(c cache.Cache) FeedbackOption {
+	return func(s *FeedbackService) {
+		s.cache = c
+	}
+}
+
+// WithSuppressThreshold 设置抑制生效所需的错误次数
+func WithSuppressThreshold(threshold int) FeedbackOption {
+	return func(s *FeedbackService) {
+		if threshold > 0 {
+			s.threshold = threshold
+		}
+	}
+}
+
+// WithClusterSimilarity 设置问题归入同一簇的最低相似度
+func WithClusterSimilarity(similarity float32) FeedbackOption {
+	return func(s *FeedbackService) {
+		if similarity > 0 && similarity <= 1 {
+			s.similarity = similarity
+		}
+	}
+}
+
+// WithSuppressPenalty 设置降权系数，取值范围(0, 1]
+func WithSuppressPenalty(penalty float32) FeedbackOption {
+	return func(s *FeedbackService) {
+		if penalty > 0 && penalty <= 1 {
+			s.penalty = penalty
+		}
+	}
+}
+
+// SubmitFeedback 提交负反馈，把回答中的来源段落标记为错误
+// 上下文中设置了提示词变体时同时记录变体名称，用于对比各变体的回答质量
+func (s *FeedbackService) SubmitFeedback(ctx context.Context, question string, segmentIDs []string, comment string) (*FeedbackResult, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if len(segmentIDs) == 0 {
+		return nil, fmt.Errorf("no source segments to mark as wrong")
+	}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	vector, err := s.embedder.Embed(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	cluster, err := s.assignCluster(ctx, question, vector)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	variant, _ := llm.PromptVariantFromContext(ctx)
+	result := &FeedbackResult{ClusterID: cluster.ID}
+	seen := make(map[string]bool, len(segmentIDs))
+	for _, segmentID := range segmentIDs {
+		if segmentID == "" || seen[segmentID] {
+			continue
+		}
+		seen[segmentID] = true
+
+		doc, err := s.vectorDB.Get(segmentID)
+		if err != nil {
+			return result, fmt.Errorf("source segment not found: %s", segmentID)
+		}
+
+		if err := repo.CreateFeedback(&models.AnswerFeedback{
+			ClusterID: cluster.ID,
+			Question:  question,
+			SegmentID: segmentID,
+			Comment:   comment,
+			Variant:   variant.Name,
+		}); err != nil {
+			return result, fmt.Errorf("failed to save feedback: %w", err)
+		}
+
+		suppression, err := repo.RecordWrongSource(&models.RetrievalSuppression{
+			ClusterID: cluster.ID,
+			SegmentID: segmentID,
+			FileID:    doc.FileID,
+			FileName:  doc.FileName,
+			Text:      doc.Text,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to record wrong source: %w", err)
+		}
+		result.Recorded = append(result.Recorded, segmentID)
+
+		// 被驳回的抑制不会因为新的反馈重新生效
+		if suppression.Status == models.SuppressionPending && suppression.WrongCount >= s.threshold {
+			if err := repo.UpdateSuppressionStatus(suppression.ID, models.SuppressionActive, false); err != nil {
+				return result, fmt.Errorf("failed to activate suppression: %w", err)
+			}
+			result.Activated = append(result.Activated, segmentID)
+			s.logger.WithFields(logrus.Fields{
+				"cluster_id":  cluster.ID,
+				"segment_id":  segmentID,
+				"wrong_count": suppression.WrongCount,
+			}).Info("Retrieval suppression activated")
+		}
+	}
+
+	if len(result.Activated) > 0 {
+		s.markActive(cluster.ID, result.Activated...)
+		s.clearCache()
+	}
+
+	return result, nil
+}
+
+// ListSuppressions 分页列出检索抑制记录，status为空时返回全部
+func (s *FeedbackService) ListSuppressions(ctx context.Context, status models.SuppressionStatus, page, pageSize int) ([]*models.RetrievalSuppression, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.repo.WithContext(ctx).ListSuppressions(status, (page-1)*pageSize, pageSize)
+}
+
+// ClusterQuestions 返回问题簇的代表问题，用于审核时展示
+func (s *FeedbackService) ClusterQuestions(ctx context.Context) (map[string]string, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	questions := make(map[string]string, len(s.clusters))
+	for _, c := range s.clusters {
+		questions[c.ID] = c.Question
+	}
+	return questions, nil
+}
+
+// ReviewSuppression 审核检索抑制，可以提前启用或驳回
+func (s *FeedbackService) ReviewSuppression(ctx context.Context, id uint, status models.SuppressionStatus) (*models.RetrievalSuppression, error) {
+	if status != models.SuppressionActive && status != models.SuppressionDismissed {
+		return nil, fmt.Errorf("invalid suppression status: %s", status)
+	}
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if err := repo.UpdateSuppressionStatus(id, status, true); err != nil {
+		return nil, err
+	}
+	suppression, err := repo.GetSuppression(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.SuppressionActive {
+		s.markActive(suppression.ClusterID, suppression.SegmentID)
+	} else {
+		s.mu.Lock()
+		delete(s.active[suppression.ClusterID], suppression.SegmentID)
+		s.mu.Unlock()
+	}
+	s.clearCache()
+
+	return suppression, nil
+}
+
+// Suppress 对与问题所在簇相关的被抑制段落降权
+// 问题可能与多个簇相近，所有匹配簇中生效的抑制都会被应用
+func (s *FeedbackService) Suppress(ctx context.Context, vector []float32, results []vectordb.SearchResult) []vectordb.SearchResult {
+	if len(results) == 0 || len(vector) == 0 {
+		return results
+	}
+	if err := s.load(ctx); err != nil {
+		s.logger.WithError(err).Warn("Failed to load retrieval suppressions")
+		return results
+	}
+
+	s.mu.RLock()
+	suppressed := make(map[string]bool)
+	for _, c := range s.clusters {
+		segments := s.active[c.ID]
+		if len(segments) == 0 || cosineSimilarity(vector, c.Embedding) < s.similarity {
+			continue
+		}
+		for id := range segments {
+			suppressed[id] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(suppressed) == 0 {
+		return results
+	}
+
+	adjusted := make([]vectordb.SearchResult, len(results))
+	copy(adjusted, results)
+	for i := range adjusted {
+		if suppressed[adjusted[i].Document.ID] {
+			adjusted[i].Score *= s.penalty
+		}
+	}
+	sort.SliceStable(adjusted, func(a, b int) bool {
+		return adjusted[a].Score > adjusted[b].Score
+	})
+	return adjusted
+}
+
+// load 首次使用时从数据库加载问题簇和生效中的抑制
+func (s *FeedbackService) load(ctx context.Context) error {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	repo := s.repo.WithContext(ctx)
+	clusters, err := repo.ListClusters()
+	if err != nil {
+		return fmt.Errorf("failed to load question clusters: %w", err)
+	}
+	suppressions, err := repo.ActiveSuppressions()
+	if err != nil {
+		return fmt.Errorf("failed to load suppressions: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return nil
+	}
+	s.clusters = clusters
+	for _, sp := range suppressions {
+		if s.active[sp.ClusterID] == nil {
+			s.active[sp.ClusterID] = make(map[string]bool)
+		}
+		s.active[sp.ClusterID][sp.SegmentID] = true
+	}
+	s.loaded = true
+	return nil
+}
+
+// assignCluster 把问题归入最相近的问题簇，没有足够相近的簇时创建新簇
+// 归入已有簇时用增量均值更新簇中心
+func (s *FeedbackService) assignCluster(ctx context.Context, question string, vector []float32) (*models.QuestionCluster, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *models.QuestionCluster
+	bestScore := s.similarity
+	for _, c := range s.clusters {
+		if score := cosineSimilarity(vector, c.Embedding); score >= bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if best == nil {
+		cluster := &models.QuestionCluster{
+			ID:            uuid.New().String(),
+			Question:      question,
+			Embedding:     append([]float32(nil), vector...),
+			QuestionCount: 1,
+		}
+		if err := repo.CreateCluster(cluster); err != nil {
+			return nil, fmt.Errorf("failed to create question cluster: %w", err)
+		}
+		s.clusters = append(s.clusters, cluster)
+		return cluster, nil
+	}
+
+	n := float32(best.QuestionCount)
+	centroid := make([]float32, len(best.Embedding))
+	for i := range centroid {
+		centroid[i] = (best.Embedding[i]*n + vector[i]) / (n + 1)
+	}
+	updated := *best
+	updated.Embedding = centroid
+	updated.QuestionCount++
+	if err := repo.UpdateCluster(&updated); err != nil {
+		return nil, fmt.Errorf("failed to update question cluster: %w", err)
+	}
+	*best = updated
+	return best, nil
+}
+
+// markActive 在内存中标记生效的抑制
+func (s *FeedbackService) markActive(clusterID string, segmentIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active[clusterID] == nil {
+		s.active[clusterID] = make(map[string]bool)
+	}
+	for _, id := range segmentIDs {
+		s.active[clusterID][id] = true
+	}
+}
+
+// clearCache 使缓存的回答失效，使抑制变化立即反映到回答中
+func (s *FeedbackService) clearCache() {
+	if err := InvalidateAnswers(s.cache); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate cached answers after suppression change")
+	}
+}
+
+// cosineSimilarity 计算两个向量的余弦相似度，维度不一致时返回0
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
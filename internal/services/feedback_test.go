@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestFeedbackSuppression 测试负反馈累计到阈值后对同类问题的检索结果降权
+func TestFeedbackSuppression(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_feedback_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.QuestionCluster{}, &models.AnswerFeedback{}, &models.RetrievalSuppression{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 3})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "refund_0", FileID: "refund", FileName: "退款政策.md", Text: "旧版退款政策：7天内可退款", Vector: []float32{1, 0, 0}},
+		{ID: "refund_1", FileID: "refund", FileName: "退款政策.md", Text: "新版退款政策：15天内可退款", Vector: []float32{0.9, 0.1, 0}},
+	}))
+
+	embedder := embedding.NewMockClient(t)
+	embedder.On("Embed", mock.Anything, "如何退款").Return([]float32{1, 0, 0}, nil).Maybe()
+	embedder.On("Embed", mock.Anything, "怎么申请退款").Return([]float32{0.98, 0.1, 0}, nil).Maybe()
+	embedder.On("Embed", mock.Anything, "发票怎么开").Return([]float32{0, 1, 0}, nil).Maybe()
+
+	repo := repository.NewFeedbackRepository()
+	service := NewFeedbackService(repo, embedder, vectorDB, WithSuppressThreshold(2))
+	ctx := context.Background()
+
+	results := []vectordb.SearchResult{
+		{Document: vectordb.Document{ID: "refund_0"}, Score: 0.9},
+		{Document: vectordb.Document{ID: "refund_1"}, Score: 0.8},
+	}
+
+	// 第一次反馈只记录，不影响检索
+	first, err := service.SubmitFeedback(ctx, "如何退款", []string{"refund_0"}, "这是旧政策")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"refund_0"}, first.Recorded)
+	assert.Empty(t, first.Activated)
+	assert.Equal(t, results, service.Suppress(ctx, []float32{1, 0, 0}, results))
+
+	// 相近问题归入同一簇，达到阈值后生效
+	second, err := service.SubmitFeedback(ctx, "怎么申请退款", []string{"refund_0"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, first.ClusterID, second.ClusterID)
+	assert.Equal(t, []string{"refund_0"}, second.Activated)
+
+	adjusted := service.Suppress(ctx, []float32{1, 0, 0}, results)
+	require.Len(t, adjusted, 2)
+	assert.Equal(t, "refund_1", adjusted[0].Document.ID)
+	assert.InDelta(t, 0.45, adjusted[1].Score, 1e-6)
+	assert.Equal(t, float32(0.9), results[0].Score, "input results must not be modified")
+
+	// 不相关的问题不受影响，也会形成新的簇
+	assert.Equal(t, results, service.Suppress(ctx, []float32{0, 1, 0}, results))
+	other, err := service.SubmitFeedback(ctx, "发票怎么开", []string{"refund_1"}, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ClusterID, other.ClusterID)
+
+	_, err = service.SubmitFeedback(ctx, "如何退款", []string{"missing_0"}, "")
+	assert.Error(t, err)
+
+	// 审核列表中带有代表问题和段落快照
+	active, total, err := service.ListSuppressions(ctx, models.SuppressionActive, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, active, 1)
+	assert.Equal(t, 2, active[0].WrongCount)
+	assert.Equal(t, "旧版退款政策：7天内可退款", active[0].Text)
+	questions, err := service.ClusterQuestions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "如何退款", questions[active[0].ClusterID])
+
+	// 驳回后不再降权，后续反馈也不会自动恢复
+	_, err = service.ReviewSuppression(ctx, active[0].ID, models.SuppressionDismissed)
+	require.NoError(t, err)
+	assert.Equal(t, results, service.Suppress(ctx, []float32{1, 0, 0}, results))
+	third, err := service.SubmitFeedback(ctx, "如何退款", []string{"refund_0"}, "")
+	require.NoError(t, err)
+	assert.Empty(t, third.Activated)
+
+	_, err = service.ReviewSuppression(ctx, active[0].ID, models.SuppressionPending)
+	assert.Error(t, err)
+
+	// 管理员重新启用后，新的服务实例从数据库加载生效的抑制
+	_, err = service.ReviewSuppression(ctx, active[0].ID, models.SuppressionActive)
+	require.NoError(t, err)
+	reloaded := NewFeedbackService(repo, embedder, vectorDB)
+	adjusted = reloaded.Suppress(ctx, []float32{1, 0, 0}, results)
+	assert.Equal(t, "refund_1", adjusted[0].Document.ID)
+}
+
+// noFlushCache 清空时使测试失败的缓存，缓存可能与任务队列共用同一个Redis库，请求路径上不能清空
+type noFlushCache struct {
+	cache.Cache
+	t *testing.T
+}
+
+func (c noFlushCache) Clear() error {
+	c.t.Error("cache must not be flushed")
+	return nil
+}
+
+// TestFeedbackInvalidatesCachedAnswers 测试抑制生效后只让缓存的回答失效，不清空缓存中的其他数据
+func TestFeedbackInvalidatesCachedAnswers(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_feedback_cache_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.QuestionCluster{}, &models.AnswerFeedback{}, &models.RetrievalSuppression{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 3})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.Add(vectordb.Document{ID: "refund_0", FileID: "refund", Text: "旧版退款政策", Vector: []float32{1, 0, 0}}))
+
+	embedder := embedding.NewMockClient(t)
+	embedder.On("Embed", mock.Anything, "如何退款").Return([]float32{1, 0, 0}, nil).Maybe()
+
+	memoryCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+	shared := noFlushCache{Cache: memoryCache, t: t}
+	require.NoError(t, shared.Set("asynq:queue", "pending", 0))
+
+	qaService := &QAService{cache: shared}
+	ctx := context.Background()
+	staleKey := qaService.retrievalSettings(ctx).cacheKey("qa", "如何退款")
+	qaService.cacheAnswer(staleKey, "7天内可退款")
+
+	service := NewFeedbackService(repository.NewFeedbackRepository(), embedder, vectorDB,
+		WithSuppressThreshold(1), WithFeedbackCache(shared))
+	result, err := service.SubmitFeedback(ctx, "如何退款", []string{"refund_0"}, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"refund_0"}, result.Activated)
+
+	// 新的缓存键不再命中旧回答，其他数据保留
+	freshKey := qaService.retrievalSettings(ctx).cacheKey("qa", "如何退款")
+	assert.NotEqual(t, staleKey, freshKey)
+	_, found, err := shared.Get(freshKey)
+	require.NoError(t, err)
+	assert.False(t, found)
+	value, found, err := shared.Get("asynq:queue")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "pending", value)
+}
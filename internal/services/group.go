@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// GroupMemberInput 文档组成员参数
+type GroupMemberInput struct {
+	DocumentID string // 文档ID
+	Label      string // 成员标签，为空时使用文件名
+}
+
+// GroupService 文档组服务
+// 负责管理文档组，并把文档组解析为问答范围
+type GroupService struct {
+	repo    repository.DocumentGroupRepository // 文档组仓储
+	docRepo repository.DocumentRepository      // 文档仓储，用于校验成员文档
+	logger  *logrus.Logger                     // 日志记录器
+}
+
+// GroupOption 文档组服务配置选项
+type GroupOption func(*GroupService)
+
+// NewGroupService 创建文档组服务实例
+func NewGroupService(repo repository.DocumentGroupRepository, docRepo repository.DocumentRepository, opts ...GroupOption) *GroupService {
+	service := &GroupService{
+		repo:    repo,
+		docRepo: docRepo,
+		logger:  logrus.New(),
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
+}
+
+// WithGroupLogger 设置日志记录器
+func WithGroupLogger(logger *logrus.Logger) GroupOption {
+	return func(s *GroupService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// CreateGroup 创建文档组
+// 成员按传入顺序排列，所有成员文档必须已存在
+func (s *GroupService) CreateGroup(ctx context.Context, name, description string, members []GroupMemberInput) (*models.DocumentGroup, error) {
+	if name == "" {
+		return nil, fmt.Errorf("group name cannot be empty")
+	}
+
+	group := &models.DocumentGroup{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+	}
+
+	seen := make(map[string]bool, len(members))
+	for i, m := range members {
+		if seen[m.DocumentID] {
+			return nil, fmt.Errorf("duplicate document in group: %s", m.DocumentID)
+		}
+		seen[m.DocumentID] = true
+
+		if err := s.checkDocument(m.DocumentID); err != nil {
+			return nil, err
+		}
+		group.Members = append(group.Members, models.DocumentGroupMember{
+			DocumentID: m.DocumentID,
+			Label:      m.Label,
+			Position:   i,
+		})
+	}
+
+	if err := s.repo.WithContext(ctx).Create(group); err != nil {
+		return nil, fmt.Errorf("failed to create document group: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"group_id": group.ID,
+		"members":  len(group.Members),
+	}).Info("Document group created")
+	return group, nil
+}
+
+// GetGroup 获取文档组
+func (s *GroupService) GetGroup(ctx context.Context, id string) (*models.DocumentGroup, error) {
+	return s.repo.WithContext(ctx).GetByID(id)
+}
+
+// ListGroups 分页列出文档组
+func (s *GroupService) ListGroups(ctx context.Context, page, pageSize int) ([]*models.DocumentGroup, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	return s.repo.WithContext(ctx).List((page-1)*pageSize, pageSize)
+}
+
+// UpdateGroup 更新文档组名称和描述
+func (s *GroupService) UpdateGroup(ctx context.Context, id, name, description string) (*models.DocumentGroup, error) {
+	repo := s.repo.WithContext(ctx)
+	group, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		group.Name = name
+	}
+	group.Description = description
+	if err := repo.Update(group); err != nil {
+		return nil, fmt.Errorf("failed to update document group: %w", err)
+	}
+	return group, nil
+}
+
+// DeleteGroup 删除文档组，成员文档本身不受影响
+func (s *GroupService) DeleteGroup(ctx context.Context, id string) error {
+	repo := s.repo.WithContext(ctx)
+	if _, err := repo.GetByID(id); err != nil {
+		return err
+	}
+	return repo.Delete(id)
+}
+
+// AddMember 向文档组添加成员，新成员排在最后；成员已存在时更新其标签
+func (s *GroupService) AddMember(ctx context.Context, groupID string, member GroupMemberInput) (*models.DocumentGroup, error) {
+	repo := s.repo.WithContext(ctx)
+	group, err := repo.GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDocument(member.DocumentID); err != nil {
+		return nil, err
+	}
+
+	position := 0
+	for _, m := range group.Members {
+		if m.Position >= position {
+			position = m.Position + 1
+		}
+	}
+	for _, m := range group.Members {
+		if m.DocumentID == member.DocumentID {
+			position = m.Position
+		}
+	}
+
+	if err := repo.AddMember(&models.DocumentGroupMember{
+		GroupID:    groupID,
+		DocumentID: member.DocumentID,
+		Label:      member.Label,
+		Position:   position,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add group member: %w", err)
+	}
+	return repo.GetByID(groupID)
+}
+
+// RemoveMember 从文档组中移除成员
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, documentID string) error {
+	return s.repo.WithContext(ctx).RemoveMember(groupID, documentID)
+}
+
+// ResolveScope 把文档组解析为问答范围
+// 已被删除的成员文档会被跳过，成员标签为空时使用文件名
+func (s *GroupService) ResolveScope(ctx context.Context, groupID string) ([]ScopedFile, error) {
+	group, err := s.repo.WithContext(ctx).GetByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]ScopedFile, 0, len(group.Members))
+	for _, m := range group.Members {
+		doc, err := s.docRepo.GetByID(m.DocumentID)
+		if err != nil || doc == nil {
+			s.logger.WithFields(logrus.Fields{
+				"group_id":    groupID,
+				"document_id": m.DocumentID,
+			}).Warn("Skipping missing document in group")
+			continue
+		}
+		label := m.Label
+		if label == "" {
+			label = doc.FileName
+		}
+		files = append(files, ScopedFile{FileID: m.DocumentID, Label: label})
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("document group %s has no available documents", groupID)
+	}
+	return files, nil
+}
+
+// ResolveFiles 把文档和文档组合并解析为问答范围
+// 单独绑定的文档以文件名作为标签，同一文档只保留第一次出现；
+// 不存在的文档或文档组返回错误，用于在绑定前校验聊天会话的范围
+func (s *GroupService) ResolveFiles(ctx context.Context, documentIDs, groupIDs []string) ([]ScopedFile, error) {
+	var files []ScopedFile
+	seen := make(map[string]bool)
+	add := func(f ScopedFile) {
+		if !seen[f.FileID] {
+			seen[f.FileID] = true
+			files = append(files, f)
+		}
+	}
+
+	for _, id := range documentIDs {
+		doc, err := s.docRepo.GetByID(id)
+		if err != nil || doc == nil {
+			return nil, fmt.Errorf("document not found: %s", id)
+		}
+		add(ScopedFile{FileID: id, Label: doc.FileName})
+	}
+	for _, id := range groupIDs {
+		groupFiles, err := s.ResolveScope(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range groupFiles {
+			add(f)
+		}
+	}
+	return files, nil
+}
+
+// checkDocument 校验文档是否存在
+func (s *GroupService) checkDocument(documentID string) error {
+	if documentID == "" {
+		return fmt.Errorf("document ID cannot be empty")
+	}
+	doc, err := s.docRepo.GetByID(documentID)
+	if err != nil || doc == nil {
+		return fmt.Errorf("document not found: %s", documentID)
+	}
+	return nil
+}
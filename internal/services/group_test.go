@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupGroupTestEnv 创建文档组测试环境，包含合同和两份补充协议
+func setupGroupTestEnv(t *testing.T) (*GroupService, repository.DocumentRepository) {
+	dbName := fmt.Sprintf("file:memdb_group_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Document{}, &models.DocumentSegment{}, &models.DocumentGroup{}, &models.DocumentGroupMember{},
+		&models.DocumentShare{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	docRepo := repository.NewDocumentRepository()
+	for _, id := range []string{"contract", "amendment-1", "amendment-2"} {
+		require.NoError(t, docRepo.Create(&models.Document{
+			ID:       id,
+			FileName: id + ".pdf",
+			FileType: "pdf",
+			FilePath: "/tmp/" + id + ".pdf",
+			Status:   models.DocStatusCompleted,
+		}))
+	}
+
+	return NewGroupService(repository.NewDocumentGroupRepository(), docRepo), docRepo
+}
+
+// TestGroupService 测试文档组的创建、成员管理和范围解析
+func TestGroupService(t *testing.T) {
+	groupService, docRepo := setupGroupTestEnv(t)
+	ctx := context.Background()
+
+	group, err := groupService.CreateGroup(ctx, "采购合同", "", []GroupMemberInput{
+		{DocumentID: "contract", Label: "原合同"},
+		{DocumentID: "amendment-1", Label: "第一次修订"},
+	})
+	require.NoError(t, err)
+	require.Len(t, group.Members, 2)
+
+	// 不存在的文档不能加入文档组
+	_, err = groupService.CreateGroup(ctx, "无效", "", []GroupMemberInput{{DocumentID: "missing"}})
+	assert.Error(t, err)
+
+	// 新成员排在最后，标签为空时使用文件名
+	group, err = groupService.AddMember(ctx, group.ID, GroupMemberInput{DocumentID: "amendment-2"})
+	require.NoError(t, err)
+	require.Len(t, group.Members, 3)
+	assert.Equal(t, "amendment-2", group.Members[2].DocumentID)
+	assert.Equal(t, 2, group.Members[2].Position)
+
+	files, err := groupService.ResolveScope(ctx, group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []ScopedFile{
+		{FileID: "contract", Label: "原合同"},
+		{FileID: "amendment-1", Label: "第一次修订"},
+		{FileID: "amendment-2", Label: "amendment-2.pdf"},
+	}, files)
+
+	// 已删除的文档在解析范围时被跳过
+	require.NoError(t, docRepo.Delete("amendment-2"))
+	files, err = groupService.ResolveScope(ctx, group.ID)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// 单独绑定的文档与文档组合并，重复的文档只保留第一次出现
+	files, err = groupService.ResolveFiles(ctx, []string{"amendment-1"}, []string{group.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []ScopedFile{
+		{FileID: "amendment-1", Label: "amendment-1.pdf"},
+		{FileID: "contract", Label: "原合同"},
+	}, files)
+	_, err = groupService.ResolveFiles(ctx, []string{"amendment-2"}, nil)
+	assert.Error(t, err)
+	_, err = groupService.ResolveFiles(ctx, nil, []string{"missing"})
+	assert.Error(t, err)
+
+	require.NoError(t, groupService.RemoveMember(ctx, group.ID, "amendment-1"))
+	assert.Error(t, groupService.RemoveMember(ctx, group.ID, "amendment-1"))
+
+	require.NoError(t, groupService.DeleteGroup(ctx, group.ID))
+	_, err = groupService.GetGroup(ctx, group.ID)
+	assert.Error(t, err)
+}
+
+// TestAnswerWithFiles 测试以多个文件为范围问答时只检索范围内的文件并标注出处
+func TestAnswerWithFiles(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	vector := []float32{1, 0, 0, 0}
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "contract_0", FileID: "contract", FileName: "contract.pdf", Text: "第7条：付款期限为30天。", Vector: vector},
+		{ID: "amendment-1_0", FileID: "amendment-1", FileName: "amendment-1.pdf", Text: "第7条修改为：付款期限为60天。", Vector: vector},
+		{ID: "other_0", FileID: "other", FileName: "other.pdf", Text: "无关文件的内容。", Vector: vector},
+	}))
+
+	embedder := &testEmbeddingClient{dimension: 4}
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "【原合同】") &&
+				strings.Contains(prompt, "【第一次修订】") &&
+				strings.Contains(prompt, "指明信息出自哪个文件") &&
+				!strings.Contains(prompt, "无关文件")
+		}), mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "第一次修订把第7条的付款期限改为60天。"}, nil).Once()
+
+	memoryCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+	qaService := NewQAService(embedder, vectorDB, llmClient, llm.NewRAG(llmClient), memoryCache, WithMinScore(0))
+
+	files := []ScopedFile{{FileID: "contract", Label: "原合同"}, {FileID: "amendment-1", Label: "第一次修订"}}
+	answer, sources, err := qaService.AnswerWithFiles(context.Background(), "哪个修订改了第7条？", files)
+	require.NoError(t, err)
+	assert.Contains(t, answer, "第一次修订")
+	require.Len(t, sources, 2)
+	for _, src := range sources {
+		assert.NotEqual(t, "other", src.FileID)
+	}
+
+	// 范围相同但顺序不同时命中缓存
+	reversed := []ScopedFile{files[1], files[0]}
+	cachedAnswer, _, err := qaService.AnswerWithFiles(context.Background(), "哪个修订改了第7条？", reversed)
+	require.NoError(t, err)
+	assert.Equal(t, answer, cachedAnswer)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRefusal 内容被拦截时返回给提问者的默认回答
+const DefaultRefusal = "抱歉，这个问题涉及不适合回答的内容，我无法提供帮助。"
+
+// auditTextLimit 审计日志中记录的文本长度上限（字符数）
+const auditTextLimit = 200
+
+// QuestionAnswerFunc 根据问题生成回答的函数
+// 问题可能被脱敏，因此由护栏传入实际使用的问题
+type QuestionAnswerFunc func(ctx context.Context, question string) (string, []vectordb.Document, error)
+
+// GuardService 问答护栏
+// 调用大模型前审核用户问题，返回前审核生成的回答，可以拦截或脱敏不安全的内容，
+// 被拦截的请求写入审计日志
+type GuardService struct {
+	moderator  moderation.Moderator // 内容审核器
+	refusal    string               // 拦截时的回答
+	failClosed bool                 // 审核器出错时是否拦截
+	logger     *logrus.Logger       // 日志记录器
+}
+
+// GuardOption 护栏配置选项
+type GuardOption func(*GuardService)
+
+// WithRefusal 设置内容被拦截时的回答
+func WithRefusal(refusal string) GuardOption {
+	return func(g *GuardService) {
+		if refusal != "" {
+			g.refusal = refusal
+		}
+	}
+}
+
+// WithFailClosed 设置审核器出错时是否拦截，默认放行并记录警告
+func WithFailClosed(failClosed bool) GuardOption {
+	return func(g *GuardService) {
+		g.failClosed = failClosed
+	}
+}
+
+// WithGuardLogger 设置日志记录器
+func WithGuardLogger(logger *logrus.Logger) GuardOption {
+	return func(g *GuardService) {
+		g.logger = logger
+	}
+}
+
+// NewGuardService 创建问答护栏
+func NewGuardService(moderator moderation.Moderator, opts ...GuardOption) *GuardService {
+	g := &GuardService{
+		moderator: moderator,
+		refusal:   DefaultRefusal,
+		logger:    logrus.New(),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Refusal 返回内容被拦截时的回答
+func (g *GuardService) Refusal() string {
+	return g.refusal
+}
+
+// Answer 经过护栏生成回答
+// 问题或回答被拦截时返回*moderation.BlockedError，可用moderation.AsBlocked判断；
+// 回答被脱敏时返回脱敏后的回答
+func (g *GuardService) Answer(ctx context.Context, question string, answer QuestionAnswerFunc) (string, []vectordb.Document, error) {
+	checked, err := g.check(ctx, moderation.StageInput, question, question)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, sources, err := answer(ctx, checked)
+	if err != nil {
+		return "", nil, err
+	}
+
+	text, err = g.check(ctx, moderation.StageOutput, question, text)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, sources, nil
+}
+
+// check 审核文本，返回放行或脱敏后的文本
+func (g *GuardService) check(ctx context.Context, stage moderation.Stage, question, text string) (string, error) {
+	verdict, err := g.moderator.Moderate(ctx, stage, text)
+	if err != nil {
+		entry := g.logger.WithFields(logrus.Fields{
+			"stage":     stage,
+			"moderator": g.moderator.Name(),
+			"error":     err.Error(),
+		})
+		if !g.failClosed {
+			entry.Warn("Moderation failed, allowing content")
+			return text, nil
+		}
+		entry.Error("Moderation failed, blocking content")
+		verdict = &moderation.Verdict{
+			Action:   moderation.ActionBlock,
+			Category: moderation.CategoryUnsafe,
+			Reason:   "moderation unavailable",
+		}
+	}
+
+	switch verdict.Action {
+	case moderation.ActionBlock:
+		g.audit(stage, question, text, verdict).Warn("Content blocked by guardrail")
+		return "", &moderation.BlockedError{
+			Stage:     stage,
+			Category:  verdict.Category,
+			Reason:    verdict.Reason,
+			Moderator: g.moderator.Name(),
+		}
+	case moderation.ActionRedact:
+		g.audit(stage, question, text, verdict).Info("Content redacted by guardrail")
+		return verdict.Text, nil
+	default:
+		return text, nil
+	}
+}
+
+// audit 构建审计日志条目
+func (g *GuardService) audit(stage moderation.Stage, question, text string, verdict *moderation.Verdict) *logrus.Entry {
+	fields := logrus.Fields{
+		"audit":     "guardrail",
+		"stage":     stage,
+		"action":    verdict.Action,
+		"category":  verdict.Category,
+		"reason":    verdict.Reason,
+		"moderator": g.moderator.Name(),
+		"question":  truncateRunes(question, auditTextLimit),
+	}
+	if stage == moderation.StageOutput {
+		fields["answer"] = truncateRunes(text, auditTextLimit)
+	}
+	return g.logger.WithFields(fields)
+}
+
+// truncateRunes 按字符数截断文本
+func truncateRunes(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return fmt.Sprintf("%s...", string([]rune(text)[:limit]))
+}
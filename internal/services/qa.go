@@ -5,28 +5,76 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fyerfyer/doc-QA-system/internal/models"
 	"github.com/fyerfyer/doc-QA-system/internal/repository"
 
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/audit"
 	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/document"
 	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/events"
 	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/llmlog"
 	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
 )
 
 // QAService 问答服务
 // 负责协调向量检索和大模型生成答案
 type QAService struct {
-	embedder    embedding.Client    // 嵌入模型客户端
-	vectorDB    vectordb.Repository // 向量数据库
-	llm         llm.Client          // 大模型客户端
-	rag         *llm.RAGService     // RAG服务
-	cache       cache.Cache         // 缓存
-	cacheTTL    time.Duration       // 缓存有效期
-	searchLimit int                 // 搜索结果数量限制
-	minScore    float32             // 最低相似度分数
+	embedder     embedding.Client          // 嵌入模型客户端
+	vectorDB     vectordb.Repository       // 向量数据库
+	llm          llm.Client                // 大模型客户端
+	rag          *llm.RAGService           // RAG服务
+	cache        cache.Cache               // 缓存
+	cacheTTL     time.Duration             // 缓存有效期
+	refreshAfter time.Duration             // 缓存的回答存在超过该时长后在后台刷新，为0时不刷新
+	refreshing   sync.Map                  // 正在后台刷新的缓存键
+	searchLimit  int                       // 搜索结果数量限制
+	minScore     float32                   // 最低相似度分数
+	suppressor   RetrievalSuppressor       // 检索抑制，为空时不调整检索结果
+	limits       llm.RequestLimits         // 单个请求的生成预算，为零值时不限制
+	concurrency  *concurrencyLimiter       // 问答并发限制，为空时不限制
+	stats        *StatsService             // 运维统计，为空时不记录
+	audit        *audit.Recorder           // 审计日志记录器，为空时不记录
+	events       *events.Bus               // 事件总线，为空时不发布
+	router       *embedding.Router         // 按语言路由的嵌入客户端，为空时不按语言过滤检索结果
+	segments     SegmentReader             // 段落表，检索结果不带文本时从中读取，为空时不补全
+	segmentText  *segmentTextCache         // 热点段落文本缓存
+	parents      SegmentRangeReader        // 段落表，扩展命中段落到所在章节时读取，为空时不扩展
+	classifier   IntentClassifier          // 问题分类器，为空时所有问题都走检索增强生成
+	experiment   *PromptExperiment         // 提示词对比实验，为空时使用RAG配置的模板
+	calibrator   *ScoreCalibrator          // 按嵌入模型校准的最低相似度，为空时使用配置的最低相似度
+	boost        *RankBoost                // 检索结果的时间和来源加权，为空时按相似度排序
+	exclusions   ExclusionSource           // 文档表，检索时排除标记为不检索的文档和指定标签的文档，为空时只排除请求中的文件
+	acl          *acl.Policy               // 文档访问控制策略，为空时不检查
+	readable     ReadableSource            // 文档表，检索只在用户可以读取的文档中进行
+	commands     map[string]CommandHandler // 命令处理器
+	logger       *logrus.Logger            // 日志记录器
+
+	maxSearchLimit   int // 单次请求可覆盖的检索数量上限
+	maxMMRCandidates int // 单次请求可覆盖的MMR候选数量上限
+	batchMax         int // 单次批量问答的最大问题数
+	batchWorkers     int // 单次批量问答同时回答的问题数
+
+	maxTokens        int                  // 直接调用大模型回答时的最大生成token数
+	temperature      float32              // 直接调用大模型回答时的采样温度
+	generationLimits llm.GenerationLimits // 单次请求可覆盖的生成参数上限
+
+	mmrEnabled    bool    // 是否启用MMR结果多样化
+	mmrLambda     float32 // MMR相关性权重
+	mmrCandidates int     // MMR候选池大小
+	rewriteCount  int     // 检索前生成的改写问题数量，为0时不改写
+	mergeEnabled  bool    // 是否合并同一文档中相邻的检索段落
+	parentWindow  int     // 命中段落向每侧扩展到所在章节的段落数，为0时不扩展
+
+	verifier          GroundingVerifier // 回答依据校验，为空时不校验
+	minConfidence     float32           // 回答有依据的最低置信度
+	groundingFallback string            // 回答缺乏依据时的兜底回答，为空时只标记低置信度
 }
 
 // QAOption 问答服务配置选项
@@ -51,6 +99,15 @@ func NewQAService(
 		cacheTTL:    24 * time.Hour, // 默认缓存24小时
 		searchLimit: 5,              // 默认检索5个相关文档
 		minScore:    0.5,            // 默认最低相似度分数
+		classifier:  RuleClassifier{},
+		logger:      logrus.New(),
+
+		maxSearchLimit:   DefaultMaxSearchLimit,
+		maxMMRCandidates: DefaultMaxMMRCandidates,
+		batchMax:         DefaultBatchMaxQuestions,
+		batchWorkers:     DefaultBatchWorkers,
+		maxTokens:        DefaultAnswerMaxTokens,
+		temperature:      DefaultAnswerTemperature,
 	}
 
 	// 应用配置选项
@@ -68,6 +125,15 @@ func WithCacheTTL(ttl time.Duration) QAOption {
 	}
 }
 
+// WithQALogger 设置日志记录器
+func WithQALogger(logger *logrus.Logger) QAOption {
+	return func(s *QAService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
 // WithSearchLimit 设置搜索结果数量
 func WithSearchLimit(limit int) QAOption {
 	return func(s *QAService) {
@@ -82,40 +148,52 @@ func WithMinScore(score float32) QAOption {
 	}
 }
 
-// isGreeting 检查问题是否为简单问候语
-func isGreeting(question string) bool {
-	// 转为小写并去除空格以便更准确匹配
-	q := strings.ToLower(strings.TrimSpace(question))
-
-	// 常见问候语列表
-	greetings := []string{
-		"你好", "您好", "早上好", "下午好", "晚上好", "嗨", "hi", "hello",
-		"hey", "嘿", "哈喽", "喂", "在吗", "在么", "在不在",
+// WithSuppressor 设置检索抑制，对被负反馈的段落降权
+func WithSuppressor(suppressor RetrievalSuppressor) QAOption {
+	return func(s *QAService) {
+		s.suppressor = suppressor
 	}
+}
 
-	// 检查是否完全匹配
-	for _, g := range greetings {
-		if q == g {
-			return true
-		}
+// WithRequestLimits 设置单个请求的生成预算（大模型调用次数、工具步骤数、token总数）
+// 需要配合llm.NewBudgetedClient包装的大模型客户端才会生效
+func WithRequestLimits(limits llm.RequestLimits) QAOption {
+	return func(s *QAService) {
+		s.limits = limits
 	}
+}
 
-	// 检查是否为有附加内容的问候语
-	// 仅对非常短的内容进行匹配，并且必须以问候语开头
-	if len(q) < 8 { // 降低长度限制，从15改为8
-		for _, g := range greetings {
-			if strings.HasPrefix(q, g+" ") {
-				return true
-			}
-		}
+// WithQAAudit 设置审计日志记录器，记录清除问答缓存的操作
+func WithQAAudit(recorder *audit.Recorder) QAOption {
+	return func(s *QAService) {
+		s.audit = recorder
 	}
+}
 
-	// 如果包含问号，基本可以确定不是问候语
-	if strings.Contains(q, "?") || strings.Contains(q, "？") {
-		return false
+// withBudget 为请求附加生成预算
+// 上下文中已有预算时沿用，使同一请求内的多次问答共享同一份预算
+func (s *QAService) withBudget(ctx context.Context) context.Context {
+	if s.limits.IsZero() || llm.BudgetFromContext(ctx) != nil {
+		return ctx
 	}
+	return llm.WithRequestBudget(ctx, llm.NewRequestBudget(s.limits))
+}
+
+// freshAnswerKey 上下文中要求跳过回答缓存的键
+type freshAnswerKey struct{}
+
+// WithFreshAnswer 返回要求重新生成回答的上下文
+// 问答时不读取缓存的回答，生成的新回答照常写入缓存，用于重新生成聊天回复
+func WithFreshAnswer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshAnswerKey{}, true)
+}
 
-	return false
+// suppress 应用检索抑制，未配置时原样返回
+func (s *QAService) suppress(ctx context.Context, vector []float32, results []vectordb.SearchResult) []vectordb.SearchResult {
+	if s.suppressor == nil {
+		return results
+	}
+	return s.suppressor.Suppress(ctx, vector, results)
 }
 
 // handleGreeting 处理问候语
@@ -139,30 +217,40 @@ func (s *QAService) handleGreeting(ctx context.Context, question string) (string
 }
 
 // Answer 回答问题
-func (s *QAService) Answer(ctx context.Context, question string) (string, []vectordb.Document, error) {
+func (s *QAService) Answer(ctx context.Context, question string) (_ string, _ []vectordb.Document, err error) {
+	ctx = s.withBudget(ctx)
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	ctx, _ = s.AssignPromptVariant(ctx)
+	ctx, done := s.observe(ctx, question)
+	defer func() { done(err) }()
+
 	if question == "" {
 		//fmt.Println("DEBUG: Question is empty")
 		return "", nil, fmt.Errorf("question cannot be empty")
 	}
 
-	// 检查是否是问候语
-	if isGreeting(question) {
-		//fmt.Println("DEBUG: Question is a greeting")
-		greeting, err := s.handleGreeting(ctx, question)
-		if err != nil {
-			//fmt.Printf("DEBUG: Failed to generate greeting response: %v\n", err)
-			return "", nil, err
-		}
-		return greeting, nil, nil
+	// 问候、闲聊和命令不走检索增强生成
+	if answer, sources, handled, err := s.route(ctx, question, ""); handled {
+		return answer, sources, err
 	}
 
 	// 1. 尝试从缓存获取
-	cacheKey := cache.GenerateCacheKey("qa", question)
-	cachedAnswer, found, err := s.cache.Get(cacheKey)
+	rs := s.retrievalSettings(ctx)
+	cacheKey := rs.cacheKey("qa", question)
+	cachedAnswer, found, err := s.cachedAnswer(ctx, cacheKey, func(ctx context.Context) error {
+		_, _, err := s.Answer(ctx, question)
+		return err
+	})
 	if err == nil && found {
+		markCacheHit(ctx)
 		fmt.Println("DEBUG: Cache hit for answer")
 		// 从缓存中同时获取相关文档
-		docsCacheKey := cache.GenerateCacheKey("qa_docs", question)
+		docsCacheKey := rs.cacheKey("qa_docs", question)
 		docsJson, docsFound, docsErr := s.cache.Get(docsCacheKey)
 
 		var sources []vectordb.Document
@@ -178,6 +266,7 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 			//fmt.Println("DEBUG: No cache hit for documents")
 		}
 
+		s.cachedConfidence(ctx, cacheKey)
 		return cachedAnswer, sources, nil
 	}
 
@@ -192,11 +281,11 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 
 	// 3. 检索相关文档
 	filter := vectordb.SearchFilter{
-		MinScore:   s.minScore,
-		MaxResults: s.searchLimit,
+		MinScore:   rs.minScore,
+		MaxResults: rs.limit,
 	}
 	//fmt.Printf("DEBUG: Searching with filter - MinScore: %f, MaxResults: %d\n", filter.MinScore, filter.MaxResults)
-	results, err := s.vectorDB.Search(vector, filter)
+	results, err := s.retrieve(ctx, question, vector, filter)
 	if err != nil {
 		//fmt.Printf("DEBUG: Search failed: %v\n", err)
 		return "", nil, fmt.Errorf("search failed: %w", err)
@@ -207,8 +296,8 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 	// 检查是否有高相关度的文档
 	hasRelevantDocs := false
 	for _, result := range results {
-		fmt.Printf("DEBUG: Document score: %f, minScore: %f\n", result.Score, s.minScore)
-		if result.Score >= s.minScore {
+		fmt.Printf("DEBUG: Document score: %f, minScore: %f\n", result.Score, rs.minScore)
+		if result.Score >= rs.minScore {
 			hasRelevantDocs = true
 			break
 		}
@@ -219,12 +308,11 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 	// 如果没有找到高相关度文档，直接用LLM回答
 	if len(results) == 0 || !hasRelevantDocs {
 		// 构建一个通用知识问答提示词
-		prompt := fmt.Sprintf("请基于你的已有知识，回答下面的问题： %s\n如果你不知道问题的答案，回答\"不知道\"", question)
+		prompt := llm.SessionPrompt(ctx, fmt.Sprintf("请基于你的已有知识，回答下面的问题： %s\n如果你不知道问题的答案，回答\"不知道\"", question))
 
 		// 获取LLM的回答
 		response, err := s.llm.Generate(ctx, prompt,
-			llm.WithGenerateMaxTokens(1000),
-			llm.WithGenerateTemperature(0.7))
+			s.generateOptions(ctx)...)
 
 		if err != nil {
 			return "", nil, err
@@ -237,7 +325,7 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 	// 4. 提取相关文本内容，只保留相关度高于阈值的文档
 	var filteredResults []vectordb.SearchResult
 	for _, result := range results {
-		if result.Score >= s.minScore {
+		if result.Score >= rs.minScore {
 			filteredResults = append(filteredResults, result)
 		}
 	}
@@ -246,38 +334,52 @@ func (s *QAService) Answer(ctx context.Context, question string) (string, []vect
 	if len(filteredResults) == 0 {
 		noContextAnswer := "抱歉，我没有找到相关信息可以回答您的问题。"
 		// 缓存此结果
-		s.cache.Set(cacheKey, noContextAnswer, s.cacheTTL)
+		s.cacheAnswer(cacheKey, noContextAnswer)
 		return noContextAnswer, nil, nil
 	}
 
+	filteredResults = s.contextResults(filteredResults)
 	contexts := make([]string, len(filteredResults))
 	sources := make([]vectordb.Document, len(filteredResults))
 	for i, result := range filteredResults {
-		contexts[i] = result.Document.Text
+		contexts[i] = sourceContext(result.Document)
 		sources[i] = result.Document
 	}
 
 	// 5. 使用RAG生成回答
-	ragResponse, err := s.rag.Answer(ctx, question, contexts)
+	ragResponse, err := s.rag.Answer(withContextIDs(ctx, sources), question, contexts)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to generate answer: %w", err)
 	}
+	sources = citeSources(sources, contexts, ragResponse)
+	answer, sources := s.ground(ctx, cacheKey, question, ragResponse.Answer, contexts, sources)
 
 	// 6. 缓存结果
-	s.cache.Set(cacheKey, ragResponse.Answer, s.cacheTTL)
+	s.cacheAnswer(cacheKey, answer)
 
 	// 缓存文档列表
-	docsCacheKey := cache.GenerateCacheKey("qa_docs", question)
+	docsCacheKey := rs.cacheKey("qa_docs", question)
 	docsJson, err := json.Marshal(sources)
 	if err == nil {
 		s.cache.Set(docsCacheKey, string(docsJson), s.cacheTTL)
 	}
 
-	return ragResponse.Answer, sources, nil
+	return answer, sources, nil
 }
 
 // AnswerWithFile 针对特定文件回答问题
-func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID string) (string, []vectordb.Document, error) {
+func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID string) (_ string, _ []vectordb.Document, err error) {
+	ctx = s.withBudget(ctx)
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	ctx, _ = s.AssignPromptVariant(ctx)
+	ctx, done := s.observe(ctx, question)
+	defer func() { done(err) }()
+
 	if question == "" {
 		return "", nil, fmt.Errorf("question cannot be empty")
 	}
@@ -288,16 +390,22 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 
 	//fmt.Printf("DEBUG: AnswerWithFile - checking if file exists: %s\n", fileID)
 
-	// 验证文件是否存在的逻辑
-	filter := vectordb.SearchFilter{
+	// 验证文件是否存在的逻辑，用户无权读取的文件与不存在的文件返回相同的错误
+	filter, readable, err := s.restrictReadable(ctx, vectordb.SearchFilter{
 		FileIDs:    []string{fileID},
 		MaxResults: 1,
+	})
+	if err != nil {
+		return "", nil, err
 	}
 
 	// 检查文件是否存在
-	results, err := s.vectorDB.Search(make([]float32, s.vectorDB.GetDimension()), filter)
-	if err != nil {
-		return "", nil, err
+	var results []vectordb.SearchResult
+	if readable {
+		results, err = s.vectorDB.Search(make([]float32, s.vectorDB.GetDimension()), filter)
+		if err != nil {
+			return "", nil, err
+		}
 	}
 
 	if len(results) == 0 {
@@ -305,30 +413,24 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 		return "", nil, fmt.Errorf("document with ID %s not found", fileID)
 	}
 
-	// 检查是否是问候语
-	if isGreeting(question) {
-		greeting, err := s.handleGreeting(ctx, question)
-		if err != nil {
-			return "", nil, err
-		}
-		return greeting, nil, nil
+	// 问候、闲聊和命令不走检索增强生成
+	if answer, sources, handled, err := s.route(ctx, question, fileID); handled {
+		return answer, sources, err
 	}
 
 	// 特定文件的缓存键
-	cacheKey := cache.GenerateCacheKey("qa_file", fileID, question)
-	cachedAnswer, found, err := s.cache.Get(cacheKey)
+	rs := s.retrievalSettings(ctx)
+	cacheKey := rs.cacheKey("qa_file", fileID, question)
+	cachedAnswer, found, err := s.cachedAnswer(ctx, cacheKey, func(ctx context.Context) error {
+		_, _, err := s.AnswerWithFile(ctx, question, fileID)
+		return err
+	})
 	if err == nil && found {
+		markCacheHit(ctx)
 		// 从缓存中获取文档
-		docsCacheKey := cache.GenerateCacheKey("qa_file_docs", fileID, question)
-		docsJson, docsFound, docsErr := s.cache.Get(docsCacheKey)
-
-		var sources []vectordb.Document
-		if docsErr == nil && docsFound {
-			if err := json.Unmarshal([]byte(docsJson), &sources); err != nil {
-				fmt.Printf("Failed to unmarshal cached file documents: %v\n", err)
-			}
-		}
+		sources := s.cachedSources(ctx, rs.cacheKey("qa_file_docs", fileID, question))
 
+		s.cachedConfidence(ctx, cacheKey)
 		return cachedAnswer, sources, nil
 	}
 
@@ -341,10 +443,10 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 	// 检索特定文件中的相关文档
 	filter = vectordb.SearchFilter{
 		FileIDs:    []string{fileID},
-		MinScore:   s.minScore,
-		MaxResults: s.searchLimit,
+		MinScore:   rs.minScore,
+		MaxResults: rs.limit,
 	}
-	results, err = s.vectorDB.Search(vector, filter)
+	results, err = s.retrieve(ctx, question, vector, filter)
 	if err != nil {
 		return "", nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -352,7 +454,7 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 	// 检查是否有高相关度的文档
 	hasRelevantDocs := false
 	for _, result := range results {
-		if result.Score >= s.minScore {
+		if result.Score >= rs.minScore {
 			hasRelevantDocs = true
 			break
 		}
@@ -361,12 +463,11 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 	// 如果没有找到高相关度文档，使用LLM直接回答
 	if len(results) == 0 || !hasRelevantDocs {
 		// 构建一个通用知识问答提示词
-		prompt := fmt.Sprintf("请基于你的已有知识，回答下面的问题： %s\n如果你不知道问题的答案，回答\"不知道\"", question)
+		prompt := llm.SessionPrompt(ctx, fmt.Sprintf("请基于你的已有知识，回答下面的问题： %s\n如果你不知道问题的答案，回答\"不知道\"", question))
 
 		// 获取LLM的回答
 		response, err := s.llm.Generate(ctx, prompt,
-			llm.WithGenerateMaxTokens(1000),
-			llm.WithGenerateTemperature(0.7))
+			s.generateOptions(ctx)...)
 
 		if err != nil {
 			return "", nil, err
@@ -379,7 +480,7 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 	// 提取相关文本内容，只保留相关度高于阈值的文档
 	var filteredResults []vectordb.SearchResult
 	for _, result := range results {
-		if result.Score >= s.minScore {
+		if result.Score >= rs.minScore {
 			filteredResults = append(filteredResults, result)
 		}
 	}
@@ -390,82 +491,90 @@ func (s *QAService) AnswerWithFile(ctx context.Context, question string, fileID
 		response, err := s.llm.Generate(
 			ctx,
 			prompt,
-			llm.WithGenerateMaxTokens(512),
+			s.generateOptions(ctx)...,
 		)
 
 		if err != nil {
 			// 如果LLM调用失败，返回默认消息
 			defaultMsg := "抱歉，在指定文件中没有找到能回答您问题的相关信息。"
-			s.cache.Set(cacheKey, defaultMsg, s.cacheTTL)
+			s.cacheAnswer(cacheKey, defaultMsg)
 			return defaultMsg, nil, nil
 		}
 
 		// 缓存LLM回答
-		s.cache.Set(cacheKey, response.Text, s.cacheTTL)
+		s.cacheAnswer(cacheKey, response.Text)
 		return response.Text, nil, nil
 	}
 
+	filteredResults = s.contextResults(filteredResults)
 	contexts := make([]string, len(filteredResults))
 	sources := make([]vectordb.Document, len(filteredResults))
 	for i, result := range filteredResults {
-		contexts[i] = result.Document.Text
+		contexts[i] = sourceContext(result.Document)
 		sources[i] = result.Document
 	}
 
 	// 使用RAG生成回答
-	ragResponse, err := s.rag.Answer(ctx, question, contexts)
+	ragResponse, err := s.rag.Answer(withContextIDs(ctx, sources), question, contexts)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to generate answer: %w", err)
 	}
+	sources = citeSources(sources, contexts, ragResponse)
+	answer, sources := s.ground(ctx, cacheKey, question, ragResponse.Answer, contexts, sources)
 
 	// 缓存结果
-	s.cache.Set(cacheKey, ragResponse.Answer, s.cacheTTL)
+	s.cacheAnswer(cacheKey, answer)
 
 	// 缓存文档列表
-	docsCacheKey := cache.GenerateCacheKey("qa_file_docs", fileID, question)
+	docsCacheKey := rs.cacheKey("qa_file_docs", fileID, question)
 	docsJson, err := json.Marshal(sources)
 	if err == nil {
 		s.cache.Set(docsCacheKey, string(docsJson), s.cacheTTL)
 	}
 
-	return ragResponse.Answer, sources, nil
+	return answer, sources, nil
 }
 
 // AnswerWithMetadata 使用元数据过滤回答问题
-func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, metadata map[string]interface{}) (string, []vectordb.Document, error) {
+func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, metadata map[string]interface{}) (_ string, _ []vectordb.Document, err error) {
+	ctx = s.withBudget(ctx)
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	ctx, _ = s.AssignPromptVariant(ctx)
+	ctx, done := s.observe(ctx, question)
+	defer func() { done(err) }()
+
 	if question == "" {
 		return "", nil, fmt.Errorf("question cannot be empty")
 	}
 
-	// 检查是否是问候语
-	if isGreeting(question) {
-		greeting, err := s.handleGreeting(ctx, question)
-		if err != nil {
-			return "", nil, err
-		}
-		return greeting, nil, nil
+	// 问候、闲聊和命令不走检索增强生成
+	if answer, sources, handled, err := s.route(ctx, question, ""); handled {
+		return answer, sources, err
 	}
 
 	// 创建元数据缓存键
+	rs := s.retrievalSettings(ctx)
 	metadataKey := ""
 	for k, v := range metadata {
 		metadataKey += fmt.Sprintf("%s:%v;", k, v)
 	}
-	cacheKey := cache.GenerateCacheKey("qa_meta", metadataKey, question)
+	cacheKey := rs.cacheKey("qa_meta", metadataKey, question)
 
-	cachedAnswer, found, err := s.cache.Get(cacheKey)
+	cachedAnswer, found, err := s.cachedAnswer(ctx, cacheKey, func(ctx context.Context) error {
+		_, _, err := s.AnswerWithMetadata(ctx, question, metadata)
+		return err
+	})
 	if err == nil && found {
+		markCacheHit(ctx)
 		// 从缓存中获取文档
-		docsCacheKey := cache.GenerateCacheKey("qa_meta_docs", metadataKey, question)
-		docsJson, docsFound, docsErr := s.cache.Get(docsCacheKey)
-
-		var sources []vectordb.Document
-		if docsErr == nil && docsFound {
-			if err := json.Unmarshal([]byte(docsJson), &sources); err != nil {
-				fmt.Printf("Failed to unmarshal cached metadata documents: %v\n", err)
-			}
-		}
+		sources := s.cachedSources(ctx, rs.cacheKey("qa_meta_docs", metadataKey, question))
 
+		s.cachedConfidence(ctx, cacheKey)
 		return cachedAnswer, sources, nil
 	}
 
@@ -478,10 +587,10 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 	// 检索带元数据过滤的相关文档
 	filter := vectordb.SearchFilter{
 		Metadata:   metadata,
-		MinScore:   s.minScore,
-		MaxResults: s.searchLimit,
+		MinScore:   rs.minScore,
+		MaxResults: rs.limit,
 	}
-	results, err := s.vectorDB.Search(vector, filter)
+	results, err := s.retrieve(ctx, question, vector, filter)
 	if err != nil {
 		return "", nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -489,7 +598,7 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 	// 检查是否有高相关度的文档
 	hasRelevantDocs := false
 	for _, result := range results {
-		if result.Score >= s.minScore {
+		if result.Score >= rs.minScore {
 			hasRelevantDocs = true
 			break
 		}
@@ -504,8 +613,7 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 		metaResponse, err := s.llm.Generate(
 			ctx,
 			metaPrompt,
-			llm.WithGenerateMaxTokens(512),
-			llm.WithGenerateTemperature(0.7),
+			s.generateOptions(ctx)...,
 		)
 
 		if err != nil {
@@ -513,7 +621,7 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 		}
 
 		// 缓存此结果
-		s.cache.Set(cacheKey, metaResponse.Text, s.cacheTTL)
+		s.cacheAnswer(cacheKey, metaResponse.Text)
 
 		return metaResponse.Text, nil, nil
 	}
@@ -521,7 +629,7 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 	// 提取相关文本内容，只保留相关度高于阈值的文档
 	var filteredResults []vectordb.SearchResult
 	for _, result := range results {
-		if result.Score >= s.minScore {
+		if result.Score >= rs.minScore {
 			filteredResults = append(filteredResults, result)
 		}
 	}
@@ -532,45 +640,48 @@ func (s *QAService) AnswerWithMetadata(ctx context.Context, question string, met
 		response, err := s.llm.Generate(
 			ctx,
 			prompt,
-			llm.WithGenerateMaxTokens(512),
+			s.generateOptions(ctx)...,
 		)
 
 		if err != nil {
 			// 如果LLM调用失败，返回默认消息
 			defaultMsg := "抱歉，根据您的筛选条件，我没有找到相关信息。"
-			s.cache.Set(cacheKey, defaultMsg, s.cacheTTL)
+			s.cacheAnswer(cacheKey, defaultMsg)
 			return defaultMsg, nil, nil
 		}
 
 		// 缓存LLM回答
-		s.cache.Set(cacheKey, response.Text, s.cacheTTL)
+		s.cacheAnswer(cacheKey, response.Text)
 		return response.Text, nil, nil
 	}
 
+	filteredResults = s.contextResults(filteredResults)
 	contexts := make([]string, len(filteredResults))
 	sources := make([]vectordb.Document, len(filteredResults))
 	for i, result := range filteredResults {
-		contexts[i] = result.Document.Text
+		contexts[i] = sourceContext(result.Document)
 		sources[i] = result.Document
 	}
 
 	// 使用RAG生成回答
-	ragResponse, err := s.rag.Answer(ctx, question, contexts)
+	ragResponse, err := s.rag.Answer(withContextIDs(ctx, sources), question, contexts)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to generate answer: %w", err)
 	}
+	sources = citeSources(sources, contexts, ragResponse)
+	answer, sources := s.ground(ctx, cacheKey, question, ragResponse.Answer, contexts, sources)
 
 	// 缓存结果
-	s.cache.Set(cacheKey, ragResponse.Answer, s.cacheTTL)
+	s.cacheAnswer(cacheKey, answer)
 
 	// 缓存文档列表
-	docsCacheKey := cache.GenerateCacheKey("qa_meta_docs", metadataKey, question)
+	docsCacheKey := rs.cacheKey("qa_meta_docs", metadataKey, question)
 	docsJson, err := json.Marshal(sources)
 	if err == nil {
 		s.cache.Set(docsCacheKey, string(docsJson), s.cacheTTL)
 	}
 
-	return ragResponse.Answer, sources, nil
+	return answer, sources, nil
 }
 
 // GetRecentQuestions 获取最近的问题（从聊天历史中获取）
@@ -611,6 +722,44 @@ func (s *QAService) GetRecentQuestions(ctx context.Context, limit int) ([]string
 }
 
 // ClearCache 清除问答缓存
-func (s *QAService) ClearCache() error {
-	return s.cache.Clear()
+func (s *QAService) ClearCache(ctx context.Context) error {
+	if err := s.cache.Clear(); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditActionClearCache, "qa", "")
+	return nil
+}
+
+// sourceContext 返回放入提示词的上下文
+// 段落带有页码或章节时在开头标注，使回答可以引用"第12页，3.2 配置"这样的具体位置
+func sourceContext(doc vectordb.Document) string {
+	page, section, _ := document.StructureFromMetadata(doc.Metadata)
+	if location := document.FormatLocation(page, section); location != "" {
+		return fmt.Sprintf("【%s】\n%s", location, doc.Text)
+	}
+	return doc.Text
+}
+
+// withContextIDs 在上下文中记录检索到的段落ID，供大模型交互日志关联回答依据的段落
+func withContextIDs(ctx context.Context, sources []vectordb.Document) context.Context {
+	ids := make([]string, len(sources))
+	for i, doc := range sources {
+		ids[i] = doc.ID
+	}
+	return llmlog.WithContextIDs(ctx, ids)
+}
+
+// usedSources 只保留实际放入提示词的来源文档
+// indices为nil表示RAG服务没有裁剪上下文，全部保留
+func usedSources(sources []vectordb.Document, indices []int) []vectordb.Document {
+	if indices == nil {
+		return sources
+	}
+	used := make([]vectordb.Document, 0, len(indices))
+	for _, i := range indices {
+		if i >= 0 && i < len(sources) {
+			used = append(used, sources[i])
+		}
+	}
+	return used
 }
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// ReadableSource 列出用户可以读取的文档，repository.DocumentRepository满足该接口
+type ReadableSource interface {
+	ListReadableFileIDs(reader repository.DocumentReader, fileIDs []string) ([]string, error)
+}
+
+// WithAccessControl 在检索时执行文档访问控制
+// 检索只在用户可以读取的文档中进行，没有文档记录的向量（如导入的向量）同样不会被检索到；
+// 所有问答（包括不指定文件的问答）都受此限制。回答缓存按用户和用户组分开，避免一个用户命中另一个用户的回答
+func WithAccessControl(policy *acl.Policy, source ReadableSource) QAOption {
+	return func(s *QAService) {
+		s.acl = policy
+		s.readable = source
+	}
+}
+
+// restrictReadable 把过滤条件限制在上下文中的用户可以读取的文档内
+// 过滤条件指定了文件时只保留其中可以读取的文件；返回false表示用户没有可以读取的文档，检索结果为空。
+// 用户可以读取所有文档时不修改过滤条件；读取文档表失败时返回错误，避免检索到无权读取的文档
+func (s *QAService) restrictReadable(ctx context.Context, filter vectordb.SearchFilter) (vectordb.SearchFilter, bool, error) {
+	if s.readable == nil {
+		return filter, true, nil
+	}
+	reader, ok := s.acl.Reader(ctx)
+	if !ok {
+		return filter, true, nil
+	}
+	ids, err := s.readable.ListReadableFileIDs(reader, filter.FileIDs)
+	if err != nil {
+		return filter, false, fmt.Errorf("failed to list readable documents: %w", err)
+	}
+	if len(ids) == 0 {
+		return filter, false, nil
+	}
+	filter.FileIDs = ids
+	return filter, true, nil
+}
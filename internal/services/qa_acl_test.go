@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReadable 按用户返回可以读取的文档
+type fakeReadable map[string][]string
+
+func (f fakeReadable) ListReadableFileIDs(reader repository.DocumentReader, fileIDs []string) ([]string, error) {
+	if len(fileIDs) == 0 {
+		return f[reader.User], nil
+	}
+	var ids []string
+	for _, id := range f[reader.User] {
+		if slices.Contains(fileIDs, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// TestAccessControl 测试不指定文件的问答也不会检索到用户无权读取的文档和没有文档记录的向量，回答缓存按用户分开
+func TestAccessControl(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "salary_0", FileID: "salary", Text: "薪酬标准", Vector: []float32{1, 0, 0, 0}},
+		{ID: "handbook_0", FileID: "handbook", Text: "员工手册", Vector: []float32{1, 0.1, 0, 0}},
+		{ID: "imported_0", FileID: "imported", Text: "导入的向量", Vector: []float32{1, 0.2, 0, 0}},
+	}))
+
+	policy := acl.NewPolicy(acl.WithAdmins("ops"))
+	svc := NewQAService(nil, vectorDB, nil, nil, nil,
+		WithAccessControl(policy, fakeReadable{"alice": {"handbook", "salary"}, "bob": {"handbook"}}))
+	query := []float32{1, 0, 0, 0}
+	filter := vectordb.SearchFilter{MinScore: 0.1, MaxResults: 10}
+	files := func(ctx context.Context, filter vectordb.SearchFilter) []string {
+		results, err := svc.retrieve(ctx, "", query, filter)
+		require.NoError(t, err)
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Document.FileID)
+		}
+		return out
+	}
+
+	alice := usage.WithTenant(context.Background(), "alice")
+	bob := usage.WithTenant(context.Background(), "bob")
+	ops := usage.WithTenant(context.Background(), "ops")
+	assert.Equal(t, []string{"salary", "handbook"}, files(alice, filter))
+	assert.Equal(t, []string{"handbook"}, files(bob, filter))
+	assert.Equal(t, []string{"salary", "handbook", "imported"}, files(ops, filter))
+	assert.Empty(t, files(usage.WithTenant(context.Background(), "carol"), filter))
+
+	// 指定了无权读取的文件时同样排除
+	scoped := filter
+	scoped.FileIDs = []string{"salary"}
+	assert.Empty(t, files(bob, scoped))
+
+	// 按文件问答时，无权读取的文件与不存在的文件返回相同的错误
+	_, _, err = svc.AnswerWithFile(bob, "薪酬标准是什么", "salary")
+	assert.EqualError(t, err, "document with ID salary not found")
+	_, _, err = svc.AnswerWithFile(bob, "薪酬标准是什么", "missing")
+	assert.EqualError(t, err, "document with ID missing not found")
+	_, _, err = svc.AnswerWithFile(alice, "导入的向量是什么", "imported")
+	assert.EqualError(t, err, "document with ID imported not found")
+
+	// 回答缓存键按用户区分
+	aliceKey := svc.retrievalSettings(alice).cacheKey("qa", "q")
+	assert.NotEqual(t, aliceKey, svc.retrievalSettings(bob).cacheKey("qa", "q"))
+	assert.Equal(t, aliceKey, svc.retrievalSettings(alice).cacheKey("qa", "q"))
+	assert.Equal(t, NewQAService(nil, vectorDB, nil, nil, nil).retrievalSettings(ops).cacheKey("qa", "q"),
+		svc.retrievalSettings(ops).cacheKey("qa", "q"))
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+const (
+	// DefaultBatchMaxQuestions 单次批量问答默认的最大问题数
+	DefaultBatchMaxQuestions = 20
+	// DefaultBatchWorkers 单次批量问答默认同时回答的问题数
+	DefaultBatchWorkers = 4
+)
+
+// ErrBatchTooLarge 批量问答的问题数超过上限
+var ErrBatchTooLarge = errors.New("too many questions in batch")
+
+// BatchQuestion 批量问答中的一个问题
+type BatchQuestion struct {
+	Question string   // 问题
+	FileIDs  []string // 只从这些文件中回答，为空时检索全部文档
+}
+
+// BatchAnswer 批量问答中一个问题的结果，与问题按下标一一对应
+type BatchAnswer struct {
+	Answer  string              // 回答
+	Sources []vectordb.Document // 来源段落
+	Err     error               // 回答失败的原因，不影响其他问题
+}
+
+// BatchAnswerFunc 回答批量问答中的一个问题，ask按问题的文件范围检索
+// 调用方可以在外层做护栏审核、扣减配额等处理
+type BatchAnswerFunc func(ctx context.Context, question string, ask QuestionAnswerFunc) (string, []vectordb.Document, error)
+
+// WithBatchLimits 设置批量问答的最大问题数和同时回答的问题数，为0时使用默认值
+func WithBatchLimits(maxQuestions, workers int) QAOption {
+	return func(s *QAService) {
+		if maxQuestions > 0 {
+			s.batchMax = maxQuestions
+		}
+		if workers > 0 {
+			s.batchWorkers = workers
+		}
+	}
+}
+
+// BatchMaxQuestions 返回单次批量问答的最大问题数
+func (s *QAService) BatchMaxQuestions() int {
+	return s.batchMax
+}
+
+// AnswerBatch 用有界的并发回答一批问题，返回的结果与问题按下标一一对应
+// 每个问题独立计入问答统计和并发限制；同时回答的问题数不超过每个客户端的问答并发上限，
+// 避免批量请求自己触发429。answer为空时直接回答
+func (s *QAService) AnswerBatch(ctx context.Context, questions []BatchQuestion, answer BatchAnswerFunc) ([]BatchAnswer, error) {
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no questions in batch")
+	}
+	if len(questions) > s.batchMax {
+		return nil, fmt.Errorf("%w: %d questions, at most %d", ErrBatchTooLarge, len(questions), s.batchMax)
+	}
+
+	workers := s.batchWorkers
+	if s.concurrency != nil && s.concurrency.perClient > 0 && workers > s.concurrency.perClient {
+		workers = s.concurrency.perClient
+	}
+	workers = min(workers, len(questions))
+
+	results := make([]BatchAnswer, len(questions))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = s.answerBatchQuestion(ctx, questions[i], answer)
+			}
+		}()
+	}
+
+	for i := range questions {
+		if ctx.Err() != nil {
+			// 请求已取消，未开始的问题不再回答
+			results[i].Err = ctx.Err()
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, nil
+}
+
+// answerBatchQuestion 按文件范围回答批量问答中的一个问题
+func (s *QAService) answerBatchQuestion(ctx context.Context, q BatchQuestion, answer BatchAnswerFunc) BatchAnswer {
+	ask := func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+		switch len(q.FileIDs) {
+		case 0:
+			return s.Answer(ctx, question)
+		case 1:
+			return s.AnswerWithFile(ctx, question, q.FileIDs[0])
+		default:
+			files := make([]ScopedFile, len(q.FileIDs))
+			for i, id := range q.FileIDs {
+				files[i] = ScopedFile{FileID: id}
+			}
+			return s.AnswerWithFiles(ctx, question, files)
+		}
+	}
+
+	var result BatchAnswer
+	if answer != nil {
+		result.Answer, result.Sources, result.Err = answer(ctx, q.Question, ask)
+	} else {
+		result.Answer, result.Sources, result.Err = ask(ctx, q.Question)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnswerBatch 测试批量问答按文件范围回答、单个问题失败不影响其他问题，并限制并发数
+func TestAnswerBatch(t *testing.T) {
+	service, cleanup := setupQATestEnv(t)
+	defer cleanup()
+	WithBatchLimits(3, 8)(service)
+	WithConcurrencyLimit(2, 0)(service)
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+	answer := func(ctx context.Context, question string, ask QuestionAnswerFunc) (string, []vectordb.Document, error) {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		if question == "失败的问题" {
+			return "", nil, errors.New("guard unavailable")
+		}
+		return ask(ctx, question)
+	}
+
+	ctx := usage.WithTenant(context.Background(), "team-a")
+	results, err := service.AnswerBatch(ctx, []BatchQuestion{
+		{Question: "什么是向量数据库？", FileIDs: []string{"test-file-1"}},
+		{Question: "失败的问题"},
+		{Question: "什么是RAG？", FileIDs: []string{"test-file-1", "test-file-2"}},
+	}, answer)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "这是测试回答", results[0].Answer)
+	require.NotEmpty(t, results[0].Sources)
+	for _, doc := range results[0].Sources {
+		assert.Equal(t, "test-file-1", doc.FileID)
+	}
+	assert.EqualError(t, results[1].Err, "guard unavailable")
+	assert.NoError(t, results[2].Err)
+	assert.NotEmpty(t, results[2].Answer)
+
+	// 同时回答的问题数不超过每个客户端的并发上限
+	assert.LessOrEqual(t, peak, 2)
+
+	_, err = service.AnswerBatch(ctx, make([]BatchQuestion, 4), nil)
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+	_, err = service.AnswerBatch(ctx, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// 时间衰减函数，文档年龄等于半衰期时衰减为0.5
+const (
+	DecayExponential = "exponential" // 按半衰期指数衰减
+	DecayLinear      = "linear"      // 线性衰减，两倍半衰期后衰减为0
+	DecayGauss       = "gauss"       // 高斯衰减，较新的文档几乎不衰减，超过半衰期后快速衰减
+)
+
+// DefaultBoostHalfLife 默认的时间衰减半衰期
+const DefaultBoostHalfLife = 180 * 24 * time.Hour
+
+// RankBoost 检索结果的时间和来源加权配置
+// 最终得分 = 相似度 × ((1-RecencyWeight) + RecencyWeight×时间衰减) × 集合权重，
+// 用户希望最新版本的制度排在已被取代的旧版本之前，同时可以提高或降低特定来源的排名
+type RankBoost struct {
+	Decay         string        // 时间衰减函数：exponential、linear或gauss
+	HalfLife      time.Duration // 衰减为0.5时的文档年龄
+	RecencyWeight float32       // 时间衰减在得分中的权重（0~1），为0时不按时间加权
+
+	// DateKey 元数据中文档日期（RFC 3339或2006-01-02格式）的键，没有该键时使用段落的索引时间
+	DateKey string
+	// CollectionKey 元数据中标识文档所属集合的键，如connector
+	CollectionKey string
+	// Collections 各集合的权重，名称不区分大小写，未列出的集合权重为1
+	Collections map[string]float32
+}
+
+// WithRankBoost 启用检索结果的时间和来源加权，加权在截取前k个结果之前进行
+func WithRankBoost(boost RankBoost) QAOption {
+	return func(s *QAService) {
+		if boost.HalfLife <= 0 {
+			boost.HalfLife = DefaultBoostHalfLife
+		}
+		boost.RecencyWeight = min(max(boost.RecencyWeight, 0), 1)
+		collections := make(map[string]float32, len(boost.Collections))
+		for name, weight := range boost.Collections {
+			collections[strings.ToLower(name)] = weight
+		}
+		boost.Collections = collections
+		s.boost = &boost
+	}
+}
+
+// boostResults 按时间和来源调整检索结果的得分并重新排序，未启用时原样返回
+func (s *QAService) boostResults(results []vectordb.SearchResult) []vectordb.SearchResult {
+	if s.boost == nil || len(results) == 0 {
+		return results
+	}
+
+	now := time.Now()
+	boosted := make([]vectordb.SearchResult, len(results))
+	for i, result := range results {
+		result.Score *= s.boost.factor(result.Document, now)
+		boosted[i] = result
+	}
+	sort.SliceStable(boosted, func(i, j int) bool {
+		return boosted[i].Score > boosted[j].Score
+	})
+	return boosted
+}
+
+// factor 返回文档的得分系数
+func (b *RankBoost) factor(doc vectordb.Document, now time.Time) float32 {
+	factor := float32(1)
+	if b.RecencyWeight > 0 {
+		if date := b.documentDate(doc); !date.IsZero() {
+			decay := decayFactor(b.Decay, now.Sub(date), b.HalfLife)
+			factor = (1 - b.RecencyWeight) + b.RecencyWeight*decay
+		}
+	}
+	if b.CollectionKey != "" {
+		if name, ok := doc.Metadata[b.CollectionKey].(string); ok {
+			if weight, ok := b.Collections[strings.ToLower(name)]; ok {
+				factor *= weight
+			}
+		}
+	}
+	return factor
+}
+
+// documentDate 返回文档的日期，元数据中没有可解析的日期时使用段落的索引时间
+func (b *RankBoost) documentDate(doc vectordb.Document) time.Time {
+	if b.DateKey != "" {
+		if value, ok := doc.Metadata[b.DateKey].(string); ok {
+			for _, layout := range []string{time.RFC3339, time.DateOnly} {
+				if date, err := time.Parse(layout, value); err == nil {
+					return date
+				}
+			}
+		}
+	}
+	return doc.CreatedAt
+}
+
+// decayFactor 按衰减函数计算年龄为age的文档的时间系数（0~1），未来的日期视为刚发布
+func decayFactor(decay string, age, halfLife time.Duration) float32 {
+	if age <= 0 {
+		return 1
+	}
+	x := float64(age) / float64(halfLife)
+	switch decay {
+	case DecayLinear:
+		return float32(math.Max(0, 1-x/2))
+	case DecayGauss:
+		return float32(math.Exp(-math.Ln2 * x * x))
+	default:
+		return float32(math.Pow(0.5, x))
+	}
+}
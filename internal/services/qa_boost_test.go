@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRankBoost 测试较新的文档和权重较高的集合在最终排名中靠前
+func TestRankBoost(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "v1_0", FileID: "v1", Text: "报销制度（2022版）", Vector: []float32{1, 0.05, 0, 0}, CreatedAt: now,
+			Metadata: map[string]interface{}{"published_at": now.AddDate(-2, 0, 0).Format(time.DateOnly)}},
+		{ID: "v2_0", FileID: "v2", Text: "报销制度（2024版）", Vector: []float32{1, 0.1, 0, 0}, CreatedAt: now,
+			Metadata: map[string]interface{}{"published_at": now.AddDate(0, -1, 0).Format(time.RFC3339)}},
+		{ID: "wiki_0", FileID: "wiki", Text: "报销流程说明", Vector: []float32{1, 0.2, 0, 0}, CreatedAt: now,
+			Metadata: map[string]interface{}{"connector": "Policy-Wiki"}},
+		{ID: "old_0", FileID: "old", Text: "报销制度草案", Vector: []float32{1, 0, 0, 0}, CreatedAt: now.AddDate(-3, 0, 0)},
+	}))
+
+	query := []float32{1, 0, 0, 0}
+	filter := vectordb.SearchFilter{MinScore: 0.1, MaxResults: 3}
+	ids := func(results []vectordb.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Document.ID)
+		}
+		return out
+	}
+
+	// 不加权时按相似度排序
+	plain := NewQAService(nil, vectorDB, nil, nil, nil)
+	results, err := plain.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old_0", "v1_0", "v2_0"}, ids(results))
+
+	// 加权后新版本排在旧版本之前，权重较高的集合进入前k个，索引时间很早的草案被挤出
+	boosted := NewQAService(nil, vectorDB, nil, nil, nil, WithRankBoost(RankBoost{
+		Decay:         DecayExponential,
+		HalfLife:      180 * 24 * time.Hour,
+		RecencyWeight: 0.5,
+		DateKey:       "published_at",
+		CollectionKey: "connector",
+		Collections:   map[string]float32{"policy-wiki": 1.2},
+	}))
+	results, err = boosted.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wiki_0", "v2_0", "v1_0"}, ids(results))
+	assert.Greater(t, results[0].Score, float32(1))
+}
+
+// TestDecayFactor 测试各衰减函数在半衰期处为0.5
+func TestDecayFactor(t *testing.T) {
+	halfLife := 10 * 24 * time.Hour
+	for _, decay := range []string{DecayExponential, DecayLinear, DecayGauss} {
+		assert.InDelta(t, 0.5, decayFactor(decay, halfLife, halfLife), 1e-6, decay)
+		assert.Equal(t, float32(1), decayFactor(decay, -time.Hour, halfLife), decay)
+		assert.Less(t, decayFactor(decay, 2*halfLife, halfLife), float32(0.5), decay)
+	}
+	assert.Zero(t, decayFactor(DecayLinear, 3*halfLife, halfLife))
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// 阈值校准的默认参数
+const (
+	DefaultCalibrationPercentile = 50  // 默认取检索分数分布的中位数作为最低相似度
+	DefaultCalibrationQuestions  = 200 // 默认最多使用的问题数
+	MinCalibrationQuestions      = 5   // 校准至少需要的问题数
+)
+
+var (
+	// ErrCalibrationDisabled 未启用最低相似度自动校准
+	ErrCalibrationDisabled = errors.New("min score calibration is not enabled")
+	// ErrNotEnoughCalibrationData 参与校准的问题或检索结果太少
+	ErrNotEnoughCalibrationData = errors.New("not enough data to calibrate min score")
+)
+
+// ScoreCalibrator 按嵌入模型保存校准得到的最低相似度
+// 不同嵌入模型的相似度分布差异很大，同一个全局阈值在换模型后往往过严或过松；
+// 校准时用一批问题检索，收集前k个结果的相似度分布，取指定百分位作为该模型的阈值
+type ScoreCalibrator struct {
+	repo         repository.CalibrationRepository
+	percentile   float64
+	maxQuestions int
+	logger       *logrus.Logger
+	now          func() time.Time
+
+	mu         sync.RWMutex
+	loaded     bool
+	thresholds map[string]float32 // 嵌入模型名称 -> 最低相似度
+}
+
+// CalibratorOption 阈值校准配置选项
+type CalibratorOption func(*ScoreCalibrator)
+
+// WithCalibrationPercentile 设置默认的百分位，取值范围(0, 100]
+func WithCalibrationPercentile(percentile float64) CalibratorOption {
+	return func(c *ScoreCalibrator) {
+		if percentile > 0 && percentile <= 100 {
+			c.percentile = percentile
+		}
+	}
+}
+
+// WithCalibrationQuestions 设置校准时最多使用的问题数
+func WithCalibrationQuestions(n int) CalibratorOption {
+	return func(c *ScoreCalibrator) {
+		if n > 0 {
+			c.maxQuestions = n
+		}
+	}
+}
+
+// WithCalibrationLogger 设置日志记录器
+func WithCalibrationLogger(logger *logrus.Logger) CalibratorOption {
+	return func(c *ScoreCalibrator) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// NewScoreCalibrator 创建阈值校准器
+func NewScoreCalibrator(repo repository.CalibrationRepository, opts ...CalibratorOption) *ScoreCalibrator {
+	c := &ScoreCalibrator{
+		repo:         repo,
+		percentile:   DefaultCalibrationPercentile,
+		maxQuestions: DefaultCalibrationQuestions,
+		logger:       logrus.StandardLogger(),
+		now:          time.Now,
+		thresholds:   make(map[string]float32),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Threshold 返回嵌入模型校准得到的最低相似度，模型尚未校准时返回false
+func (c *ScoreCalibrator) Threshold(ctx context.Context, model string) (float32, bool) {
+	if c == nil {
+		return 0, false
+	}
+	if err := c.load(ctx); err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("Failed to load score calibrations")
+		return 0, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	threshold, ok := c.thresholds[model]
+	return threshold, ok
+}
+
+// List 列出所有模型的校准结果
+func (c *ScoreCalibrator) List(ctx context.Context) ([]*models.ScoreCalibration, error) {
+	return c.repo.WithContext(ctx).List()
+}
+
+// load 首次使用时从数据库加载所有模型的阈值，加载失败时下次重试
+func (c *ScoreCalibrator) load(ctx context.Context) error {
+	c.mu.RLock()
+	loaded := c.loaded
+	c.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	calibrations, err := c.repo.WithContext(ctx).List()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cal := range calibrations {
+		c.thresholds[cal.Model] = cal.Threshold
+	}
+	c.loaded = true
+	return nil
+}
+
+// save 保存校准结果并立即生效
+func (c *ScoreCalibrator) save(ctx context.Context, calibration *models.ScoreCalibration) error {
+	if err := c.repo.WithContext(ctx).Save(calibration); err != nil {
+		return fmt.Errorf("failed to save score calibration: %w", err)
+	}
+	c.mu.Lock()
+	c.thresholds[calibration.Model] = calibration.Threshold
+	c.mu.Unlock()
+	return nil
+}
+
+// WithScoreCalibration 设置阈值校准器，当前嵌入模型校准过时用校准的阈值代替配置的最低相似度
+func WithScoreCalibration(calibrator *ScoreCalibrator) QAOption {
+	return func(s *QAService) {
+		s.calibrator = calibrator
+	}
+}
+
+// baseMinScore 返回未被请求覆盖时使用的最低相似度
+// 按语言路由嵌入模型时以路由配置的签名作为模型名称
+func (s *QAService) baseMinScore(ctx context.Context) float32 {
+	if s.calibrator != nil && s.embedder != nil {
+		if threshold, ok := s.calibrator.Threshold(ctx, s.embedder.Name()); ok {
+			return threshold
+		}
+	}
+	return s.minScore
+}
+
+// ScoreCalibrations 列出所有模型的校准结果和当前嵌入模型实际使用的最低相似度
+func (s *QAService) ScoreCalibrations(ctx context.Context) (calibrations []*models.ScoreCalibration, model string, minScore float32, err error) {
+	if s.calibrator == nil {
+		return nil, "", 0, ErrCalibrationDisabled
+	}
+	calibrations, err = s.calibrator.List(ctx)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return calibrations, s.embedder.Name(), s.baseMinScore(ctx), nil
+}
+
+// CalibrateMinScore 为当前嵌入模型重新校准最低相似度
+// 用给定的问题（为空时使用最近的用户问题）不设阈值地检索前k个结果，
+// 取所有结果相似度的指定百分位（不大于0时使用默认值）作为阈值；校准后清除问答缓存，使新阈值立即生效
+func (s *QAService) CalibrateMinScore(ctx context.Context, questions []string, percentile float64) (*models.ScoreCalibration, error) {
+	if s.calibrator == nil {
+		return nil, ErrCalibrationDisabled
+	}
+	if percentile <= 0 {
+		percentile = s.calibrator.percentile
+	}
+	if percentile > 100 {
+		return nil, fmt.Errorf("percentile must be between 0 and 100, got %g", percentile)
+	}
+
+	if len(questions) == 0 {
+		recent, err := s.GetRecentQuestions(ctx, s.calibrator.maxQuestions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get recent questions: %w", err)
+		}
+		questions = recent
+	}
+	if len(questions) > s.calibrator.maxQuestions {
+		questions = questions[:s.calibrator.maxQuestions]
+	}
+	if len(questions) < MinCalibrationQuestions {
+		return nil, fmt.Errorf("%w: need at least %d questions, got %d", ErrNotEnoughCalibrationData, MinCalibrationQuestions, len(questions))
+	}
+
+	vectors, err := s.embedder.EmbedBatch(ctx, questions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+	var scores []float32
+	for _, vector := range vectors {
+		results, err := s.vectorDB.Search(vector, vectordb.SearchFilter{MaxResults: s.searchLimit})
+		if err != nil {
+			return nil, fmt.Errorf("search failed: %w", err)
+		}
+		for _, r := range results {
+			scores = append(scores, r.Score)
+		}
+	}
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("%w: no search results", ErrNotEnoughCalibrationData)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i] < scores[j] })
+
+	calibration := &models.ScoreCalibration{
+		Model:        s.embedder.Name(),
+		Threshold:    scorePercentile(scores, percentile),
+		Percentile:   percentile,
+		Questions:    len(questions),
+		Samples:      len(scores),
+		MinScore:     scores[0],
+		MedianScore:  scorePercentile(scores, 50),
+		MaxScore:     scores[len(scores)-1],
+		CalibratedAt: s.calibrator.now(),
+	}
+	if err := s.calibrator.save(ctx, calibration); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditActionCalibrate, calibration.Model,
+		fmt.Sprintf("p%g -> %g", percentile, calibration.Threshold))
+	if err := InvalidateAnswers(s.cache); err != nil {
+		s.calibrator.logger.WithContext(ctx).WithError(err).Warn("Failed to invalidate cached answers after calibration")
+	}
+
+	s.calibrator.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"model":      calibration.Model,
+		"threshold":  calibration.Threshold,
+		"percentile": percentile,
+		"samples":    calibration.Samples,
+	}).Info("Min score calibrated")
+	return calibration, nil
+}
+
+// scorePercentile 按最近秩法返回升序分数中的百分位值
+func scorePercentile(sorted []float32, percentile float64) float32 {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestCalibrateMinScore 测试按检索分数分布的百分位校准最低相似度，并按嵌入模型分别生效
+func TestCalibrateMinScore(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_calibration_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.ScoreCalibration{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 2})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.Add(vectordb.Document{ID: "a_0", FileID: "a", Text: "安装步骤", Vector: []float32{1, 0}}))
+
+	// 五个问题与段落的余弦相似度依次为1、0.8、0.6、0.4、0.2
+	questions := []string{"q1", "q2", "q3", "q4", "q5"}
+	embedder := embedding.NewMockClient(t)
+	embedder.On("Name").Return("model-a")
+	embedder.On("EmbedBatch", mock.Anything, questions).Return([][]float32{
+		{1, 0}, {0.8, 0.6}, {0.6, 0.8}, {0.4, 0.9165151}, {0.2, 0.9797959},
+	}, nil)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	repo := repository.NewCalibrationRepository()
+	calibrator := NewScoreCalibrator(repo, WithCalibrationLogger(logger))
+	memoryCache, err := cache.NewMemoryCache(cache.DefaultConfig())
+	require.NoError(t, err)
+	shared := noFlushCache{Cache: memoryCache, t: t}
+	require.NoError(t, shared.Set("asynq:queue", "pending", 0))
+	service := NewQAService(embedder, vectorDB, nil, nil, shared,
+		WithSearchLimit(1), WithMinScore(0.7), WithScoreCalibration(calibrator))
+	ctx := context.Background()
+	staleKey := service.retrievalSettings(ctx).cacheKey("qa", "q1")
+	service.cacheAnswer(staleKey, "旧阈值下的回答")
+
+	// 未校准时使用配置的最低相似度
+	assert.Equal(t, float32(0.7), service.baseMinScore(ctx))
+
+	_, err = service.CalibrateMinScore(ctx, questions[:3], 0)
+	assert.ErrorIs(t, err, ErrNotEnoughCalibrationData)
+
+	calibration, err := service.CalibrateMinScore(ctx, questions, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "model-a", calibration.Model)
+	assert.InDelta(t, 0.6, calibration.Threshold, 1e-4)
+	assert.Equal(t, float64(DefaultCalibrationPercentile), calibration.Percentile)
+	assert.Equal(t, 5, calibration.Samples)
+	assert.InDelta(t, 0.2, calibration.MinScore, 1e-4)
+	assert.InDelta(t, 1, calibration.MaxScore, 1e-4)
+	assert.InDelta(t, 0.6, service.baseMinScore(ctx), 1e-4)
+	assert.InDelta(t, 0.6, service.retrievalSettings(ctx).minScore, 1e-4)
+
+	// 校准后之前缓存的回答失效，缓存中的其他数据保留
+	assert.NotEqual(t, staleKey, service.retrievalSettings(ctx).cacheKey("qa", "q1"))
+	_, found, err := shared.Get(service.retrievalSettings(ctx).cacheKey("qa", "q1"))
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = shared.Get("asynq:queue")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	calibration, err = service.CalibrateMinScore(ctx, questions, 20)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.2, calibration.Threshold, 1e-4)
+
+	// 新的校准器从数据库加载已保存的阈值，其他模型仍使用配置的最低相似度
+	reloaded := NewScoreCalibrator(repo)
+	threshold, ok := reloaded.Threshold(ctx, "model-a")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.2, threshold, 1e-4)
+	_, ok = reloaded.Threshold(ctx, "model-b")
+	assert.False(t, ok)
+
+	other := embedding.NewMockClient(t)
+	other.On("Name").Return("model-b")
+	assert.Equal(t, float32(0.7), NewQAService(other, vectorDB, nil, nil, nil,
+		WithMinScore(0.7), WithScoreCalibration(reloaded)).baseMinScore(ctx))
+
+	// 未启用校准时返回错误
+	_, err = NewQAService(embedder, vectorDB, nil, nil, nil).CalibrateMinScore(ctx, questions, 0)
+	assert.ErrorIs(t, err, ErrCalibrationDisabled)
+}
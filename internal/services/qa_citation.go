@@ -0,0 +1,69 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// citeSources 只保留实际放入提示词的来源，并附加引用信息
+// contexts为传给RAG服务的上下文，与sources一一对应；
+// 引用信息记录回答中引用该来源的标记、实际使用的文本范围和支撑回答的高亮范围，
+// 范围均相对来源的Text，写入元数据副本以便随来源一起缓存和保存
+func citeSources(sources []vectordb.Document, contexts []string, resp *llm.RAGResponse) []vectordb.Document {
+	used := usedSources(sources, resp.ContextIndices)
+	if len(resp.ContextSpans) != len(used) {
+		return used
+	}
+
+	cited := make([]vectordb.Document, len(used))
+	for i, doc := range used {
+		index := i
+		if resp.ContextIndices != nil {
+			index = resp.ContextIndices[i]
+		}
+
+		// 上下文开头可能带有位置等前缀，换算为相对段落文本的范围
+		textLen := utf8.RuneCountInString(doc.Text)
+		prefix := 0
+		if index < len(contexts) && strings.HasSuffix(contexts[index], doc.Text) {
+			prefix = utf8.RuneCountInString(contexts[index]) - textLen
+		}
+		end := resp.ContextSpans[i].End - prefix
+		if end > textLen {
+			end = textLen
+		}
+		citation := llm.SourceCitation{}
+		if end > 0 {
+			citation.Spans = []llm.TextSpan{{Start: 0, End: end}}
+		}
+
+		var highlights []llm.TextSpan
+		for _, c := range resp.Citations {
+			if c.Index != index {
+				continue
+			}
+			citation.Markers = appendMarker(citation.Markers, c.Marker)
+			if end > 0 {
+				highlights = append(highlights, llm.Highlight(doc.Text, citation.Spans[0], llm.CitedClaim(resp.Answer, c))...)
+			}
+		}
+		citation.Highlights = llm.MergeSpans(highlights)
+
+		doc.Metadata = llm.WithSourceCitation(doc.Metadata, citation)
+		cited[i] = doc
+	}
+	return cited
+}
+
+// appendMarker 追加不重复的引用编号
+func appendMarker(markers []int, marker int) []int {
+	for _, m := range markers {
+		if m == marker {
+			return markers
+		}
+	}
+	return append(markers, marker)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCiteSources 测试来源的引用标记、使用范围和高亮
+func TestCiteSources(t *testing.T) {
+	meta := map[string]interface{}{"section": "安装"}
+	sources := []vectordb.Document{
+		{ID: "a_0", Text: "系统需要Go 1.23。安装前请先配置数据库连接。", Metadata: meta},
+		{ID: "b_0", Text: "无关内容。"},
+		{ID: "c_0", Text: "部署时使用Docker Compose启动全部服务。"},
+	}
+	contexts := make([]string, len(sources))
+	for i, doc := range sources {
+		contexts[i] = sourceContext(doc)
+	}
+
+	// 第二个上下文被裁剪，第三个上下文被截断
+	resp := &llm.RAGResponse{
+		Answer:         "安装前需要先配置数据库连接[1]，部署使用Docker Compose[2]。",
+		ContextIndices: []int{0, 2},
+		ContextSpans: []llm.TextSpan{
+			{End: len([]rune(contexts[0]))},
+			{End: 10},
+		},
+	}
+	resp.Citations = llm.ParseCitations(resp.Answer, resp.ContextIndices, 3)
+
+	cited := citeSources(sources, contexts, resp)
+	require.Len(t, cited, 2)
+	assert.NotContains(t, meta, llm.MetaCitation, "不应修改原来源的元数据")
+
+	first, ok := llm.SourceCitationFromMetadata(cited[0].Metadata)
+	require.True(t, ok)
+	assert.Equal(t, []int{1}, first.Markers)
+	// 上下文开头的位置前缀不计入范围
+	assert.Equal(t, []llm.TextSpan{{End: len([]rune(sources[0].Text))}}, first.Spans)
+	require.Len(t, first.Highlights, 1)
+	assert.Equal(t, "安装前请先配置数据库连接。",
+		string([]rune(sources[0].Text)[first.Highlights[0].Start:first.Highlights[0].End]))
+
+	second, ok := llm.SourceCitationFromMetadata(cited[1].Metadata)
+	require.True(t, ok)
+	assert.Equal(t, []int{2}, second.Markers)
+	assert.Equal(t, []llm.TextSpan{{End: 10}}, second.Spans)
+}
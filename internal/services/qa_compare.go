@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// MaxCompareFiles 一次对比的文档数量上限
+const MaxCompareFiles = 5
+
+// compareNoContent 文档中没有检索到相关内容时放入提示词的说明
+const compareNoContent = "（该文档中没有找到与问题相关的内容）"
+
+// comparePrompt 文档对比的提示词模板
+const comparePrompt = `请根据下面几份文档中检索到的内容，回答用户的对比问题。
+每份文档用【D1】【D2】等编号标识，只能使用给出的内容，不要编造；文档中没有提到的方面写"未提及"。
+
+只输出一个JSON对象，不要输出其他内容，格式如下：
+{"summary": "对比的总体结论", "differences": [{"aspect": "对比的方面", "values": {"D1": "D1在该方面的内容", "D2": "D2在该方面的内容"}}], "similarities": ["各文档相同的内容"]}
+
+%s
+用户问题: %s`
+
+// ComparedDocument 参与对比的文档及其检索到的来源
+type ComparedDocument struct {
+	FileID   string              `json:"file_id"`
+	FileName string              `json:"file_name"`
+	Sources  []vectordb.Document `json:"sources"`
+}
+
+// ComparisonDifference 文档之间的一处差异
+type ComparisonDifference struct {
+	Aspect string            `json:"aspect"` // 对比的方面
+	Values map[string]string `json:"values"` // 文档ID到该文档在此方面的内容
+}
+
+// DocumentComparison 多个文档的对比结果
+type DocumentComparison struct {
+	Summary      string                 `json:"summary"`      // 总体结论
+	Differences  []ComparisonDifference `json:"differences"`  // 差异
+	Similarities []string               `json:"similarities"` // 相同之处
+	Documents    []ComparedDocument     `json:"documents"`    // 参与对比的文档，顺序与请求一致
+}
+
+// CompareDocuments 针对问题对比多个文档
+// 每个文档分别检索，避免相关度高的文档占满上下文；再让大模型逐方面列出各文档的差异
+func (s *QAService) CompareDocuments(ctx context.Context, question string, files []ScopedFile) (_ *DocumentComparison, err error) {
+	ctx = s.withBudget(ctx)
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, done := s.observe(ctx, question)
+	defer func() { done(err) }()
+
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if len(files) < 2 || len(files) > MaxCompareFiles {
+		return nil, fmt.Errorf("compare requires 2 to %d files, got %d", MaxCompareFiles, len(files))
+	}
+
+	// 缓存键与文件顺序无关，文件顺序只影响结果中文档的排列
+	sortedIDs := make([]string, 0, len(files))
+	for _, f := range files {
+		sortedIDs = append(sortedIDs, f.FileID)
+	}
+	sort.Strings(sortedIDs)
+	for i := 1; i < len(sortedIDs); i++ {
+		if sortedIDs[i] == sortedIDs[i-1] {
+			return nil, fmt.Errorf("duplicate file %s in comparison", sortedIDs[i])
+		}
+	}
+	rs := s.retrievalSettings(ctx)
+	cacheKey := rs.cacheKey("qa_compare", strings.Join(sortedIDs, ","), question)
+	cached, hit, err := s.cachedAnswer(ctx, cacheKey, func(ctx context.Context) error {
+		_, err := s.CompareDocuments(ctx, question, files)
+		return err
+	})
+	if err == nil && hit {
+		var comparison DocumentComparison
+		if err := json.Unmarshal([]byte(cached), &comparison); err == nil {
+			markCacheHit(ctx)
+			return orderComparison(&comparison, files), nil
+		}
+	}
+
+	vector, err := s.embedder.Embed(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+
+	comparison := &DocumentComparison{Documents: make([]ComparedDocument, 0, len(files))}
+	var sb strings.Builder
+	found := false
+	for i, f := range files {
+		results, err := s.retrieve(ctx, question, vector, vectordb.SearchFilter{
+			FileIDs:    []string{f.FileID},
+			MinScore:   rs.minScore,
+			MaxResults: rs.limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("search failed for %s: %w", f.FileID, err)
+		}
+
+		compared := ComparedDocument{FileID: f.FileID, FileName: f.Label, Sources: []vectordb.Document{}}
+		var contexts []string
+		for _, result := range s.contextResults(results) {
+			if result.Score < rs.minScore {
+				continue
+			}
+			if compared.FileName == "" {
+				compared.FileName = result.Document.FileName
+			}
+			text := result.Document.Text
+			page, section, _ := document.StructureFromMetadata(result.Document.Metadata)
+			if location := document.FormatLocation(page, section); location != "" {
+				text = "（" + location + "）" + text
+			}
+			contexts = append(contexts, text)
+			compared.Sources = append(compared.Sources, result.Document)
+		}
+		if compared.FileName == "" {
+			compared.FileName = f.FileID
+		}
+		comparison.Documents = append(comparison.Documents, compared)
+
+		fmt.Fprintf(&sb, "【D%d】文档：%s\n", i+1, compared.FileName)
+		if len(contexts) == 0 {
+			sb.WriteString(compareNoContent + "\n\n")
+			continue
+		}
+		found = true
+		for _, c := range contexts {
+			sb.WriteString(c + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	// 所有文档都没有相关内容时不调用大模型
+	if !found {
+		comparison.Summary = "抱歉，在指定的文档中没有找到与该问题相关的内容，无法进行对比。"
+		comparison.Differences = []ComparisonDifference{}
+		comparison.Similarities = []string{}
+		return comparison, nil
+	}
+
+	response, err := s.llm.Generate(ctx, fmt.Sprintf(comparePrompt, sb.String(), question),
+		llm.WithGenerateMaxTokens(1500),
+		llm.WithGenerateTemperature(0.2))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate comparison: %w", err)
+	}
+	parseComparison(response.Text, comparison)
+
+	if data, err := json.Marshal(comparison); err == nil {
+		s.cacheAnswer(cacheKey, string(data))
+	}
+	return comparison, nil
+}
+
+// parseComparison 解析大模型返回的对比结果，把D1、D2等编号换回文档ID
+// 输出不是JSON时把整段输出作为总体结论
+func parseComparison(text string, comparison *DocumentComparison) {
+	comparison.Differences = []ComparisonDifference{}
+	comparison.Similarities = []string{}
+
+	text = strings.TrimSpace(text)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	var result struct {
+		Summary     string `json:"summary"`
+		Differences []struct {
+			Aspect string            `json:"aspect"`
+			Values map[string]string `json:"values"`
+		} `json:"differences"`
+		Similarities []string `json:"similarities"`
+	}
+	if start < 0 || end < start || json.Unmarshal([]byte(text[start:end+1]), &result) != nil {
+		comparison.Summary = text
+		return
+	}
+
+	comparison.Summary = strings.TrimSpace(result.Summary)
+	for _, d := range result.Differences {
+		diff := ComparisonDifference{Aspect: strings.TrimSpace(d.Aspect), Values: make(map[string]string, len(comparison.Documents))}
+		for i, doc := range comparison.Documents {
+			if value, ok := d.Values[fmt.Sprintf("D%d", i+1)]; ok {
+				diff.Values[doc.FileID] = strings.TrimSpace(value)
+			}
+		}
+		if diff.Aspect != "" && len(diff.Values) > 0 {
+			comparison.Differences = append(comparison.Differences, diff)
+		}
+	}
+	for _, sim := range result.Similarities {
+		if sim = strings.TrimSpace(sim); sim != "" {
+			comparison.Similarities = append(comparison.Similarities, sim)
+		}
+	}
+}
+
+// orderComparison 按本次请求的文件顺序和标签排列缓存的对比结果
+func orderComparison(comparison *DocumentComparison, files []ScopedFile) *DocumentComparison {
+	byID := make(map[string]ComparedDocument, len(comparison.Documents))
+	for _, doc := range comparison.Documents {
+		byID[doc.FileID] = doc
+	}
+	ordered := make([]ComparedDocument, 0, len(files))
+	for _, f := range files {
+		doc, ok := byID[f.FileID]
+		if !ok {
+			doc = ComparedDocument{FileID: f.FileID, FileName: f.FileID, Sources: []vectordb.Document{}}
+		}
+		if f.Label != "" {
+			doc.FileName = f.Label
+		}
+		ordered = append(ordered, doc)
+	}
+	comparison.Documents = ordered
+	return comparison
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCompareDocuments 测试按文档分别检索并生成结构化的对比结果
+func TestCompareDocuments(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		// v1的段落与问题更相关，统一检索时会占满上下文
+		{ID: "v1_0", FileID: "v1", FileName: "报销政策v1.pdf", Text: "差旅住宿上限300元", Vector: []float32{1, 0, 0, 0}},
+		{ID: "v1_1", FileID: "v1", FileName: "报销政策v1.pdf", Text: "发票需在30天内提交", Vector: []float32{1, 0.05, 0, 0}},
+		{ID: "v2_0", FileID: "v2", FileName: "报销政策v2.pdf", Text: "差旅住宿上限500元", Vector: []float32{0.8, 0.6, 0, 0}},
+	}))
+
+	embedder := embedding.NewMockClient(t)
+	embedder.On("Embed", mock.Anything, mock.Anything).Return([]float32{1, 0, 0, 0}, nil)
+	llmClient := llm.NewMockClient(t)
+	llmClient.On("Generate", mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "【D1】文档：报销政策v1.pdf") && strings.Contains(prompt, "【D2】文档：报销政策v2.pdf") &&
+			strings.Contains(prompt, "差旅住宿上限500元")
+	}), mock.Anything, mock.Anything).Return(&llm.Response{Text: `{"summary": "v2提高了住宿上限",
+		"differences": [{"aspect": "住宿上限", "values": {"D1": "300元", "D2": "500元"}}, {"aspect": "", "values": {"D1": "x"}}],
+		"similarities": ["都需要发票"]}`}, nil).Once()
+	memCache, err := cache.NewMemoryCache(cache.Config{})
+	require.NoError(t, err)
+
+	service := NewQAService(embedder, vectorDB, llmClient, llm.NewRAG(llmClient), memCache, WithSearchLimit(2))
+	files := []ScopedFile{{FileID: "v1"}, {FileID: "v2"}}
+	comparison, err := service.CompareDocuments(context.Background(), "两个版本有什么不同？", files)
+	require.NoError(t, err)
+	assert.Equal(t, "v2提高了住宿上限", comparison.Summary)
+	assert.Equal(t, []ComparisonDifference{{Aspect: "住宿上限", Values: map[string]string{"v1": "300元", "v2": "500元"}}}, comparison.Differences)
+	assert.Equal(t, []string{"都需要发票"}, comparison.Similarities)
+	require.Len(t, comparison.Documents, 2)
+	assert.Len(t, comparison.Documents[0].Sources, 2)
+	assert.Len(t, comparison.Documents[1].Sources, 1)
+
+	// 调换顺序命中缓存，文档按请求顺序排列
+	comparison, err = service.CompareDocuments(context.Background(), "两个版本有什么不同？", []ScopedFile{{FileID: "v2", Label: "新版"}, {FileID: "v1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "新版", comparison.Documents[0].FileName)
+	assert.Equal(t, "v1", comparison.Documents[1].FileID)
+
+	_, err = service.CompareDocuments(context.Background(), "问题", []ScopedFile{{FileID: "v1"}})
+	assert.Error(t, err)
+	_, err = service.CompareDocuments(context.Background(), "问题", []ScopedFile{{FileID: "v1"}, {FileID: "v1"}})
+	assert.Error(t, err)
+}
+
+// TestParseComparison 测试对比结果不是JSON时的处理
+func TestParseComparison(t *testing.T) {
+	comparison := &DocumentComparison{Documents: []ComparedDocument{{FileID: "a"}, {FileID: "b"}}}
+	parseComparison("两份文档没有明显差异", comparison)
+	assert.Equal(t, "两份文档没有明显差异", comparison.Summary)
+	assert.Empty(t, comparison.Differences)
+	assert.NotNil(t, comparison.Similarities)
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// ExclusionSource 列出不参与检索的文档，repository.DocumentRepository满足该接口
+type ExclusionSource interface {
+	ListExcludedFileIDs(tags []string) ([]string, error)
+}
+
+// WithExclusions 启用文档级的检索排除
+// 标记为不检索的文档（如归档的草稿）和带有请求中exclude_tags标签的文档不会出现在任何问答的检索结果中。
+// 修改标记后，已缓存的回答在缓存过期前仍可能引用这些文档
+func WithExclusions(source ExclusionSource) QAOption {
+	return func(s *QAService) {
+		s.exclusions = source
+	}
+}
+
+// excludedFileIDs 合并请求中排除的文件、标记为不检索的文档和带有排除标签的文档
+// 读取文档表失败时返回错误，避免检索到本应排除的文档
+func (s *QAService) excludedFileIDs(rs retrievalSettings) ([]string, error) {
+	if s.exclusions == nil {
+		return rs.excludeFiles, nil
+	}
+	flagged, err := s.exclusions.ListExcludedFileIDs(rs.excludeTags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list excluded documents: %w", err)
+	}
+	if len(flagged) == 0 {
+		return rs.excludeFiles, nil
+	}
+	return append(append([]string(nil), rs.excludeFiles...), flagged...), nil
+}
+
+// applyExclusions 把不参与检索的文件写入过滤条件，并把检索范围限制在用户可以读取的文件内，排除优先于过滤条件中指定的文件
+// 返回false表示没有可以检索的文档
+func (s *QAService) applyExclusions(ctx context.Context, rs retrievalSettings, filter vectordb.SearchFilter) (vectordb.SearchFilter, bool, error) {
+	excluded, err := s.excludedFileIDs(rs)
+	if err != nil {
+		return filter, false, err
+	}
+	if len(excluded) > 0 {
+		filter.ExcludeFileIDs = append(append([]string(nil), filter.ExcludeFileIDs...), excluded...)
+	}
+	return s.restrictReadable(ctx, filter)
+}
+
+// exclusionKey 生成排除条件的缓存键片段，没有排除条件时返回空字符串
+// 排除的文件和标签排序后拼接，顺序不同的相同条件共用缓存
+func exclusionKey(files, tags []string) string {
+	key := ""
+	if len(files) > 0 {
+		sorted := append([]string(nil), files...)
+		sort.Strings(sorted)
+		key += "_xf" + strings.Join(sorted, ",")
+	}
+	if len(tags) > 0 {
+		sorted := append([]string(nil), tags...)
+		sort.Strings(sorted)
+		key += "_xt" + strings.Join(sorted, ",")
+	}
+	return key
+}
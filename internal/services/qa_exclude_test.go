@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExclusions 按标签返回文档的排除来源
+type fakeExclusions struct {
+	flagged []string            // 标记为不检索的文档
+	tagged  map[string][]string // 标签 -> 文档
+	err     error
+}
+
+func (f *fakeExclusions) ListExcludedFileIDs(tags []string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	ids := append([]string(nil), f.flagged...)
+	for _, tag := range tags {
+		ids = append(ids, f.tagged[tag]...)
+	}
+	return ids, nil
+}
+
+// TestExclusions 测试请求中排除的文件和标签以及标记为不检索的文档不出现在检索结果中
+func TestExclusions(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "guide_0", FileID: "guide", Text: "部署指南", Vector: []float32{1, 0, 0, 0}},
+		{ID: "draft_0", FileID: "draft", Text: "部署指南草稿", Vector: []float32{1, 0.05, 0, 0}},
+		{ID: "legacy_0", FileID: "legacy", Text: "旧版部署说明", Vector: []float32{1, 0.1, 0, 0}},
+		{ID: "faq_0", FileID: "faq", Text: "常见问题", Vector: []float32{1, 0.2, 0, 0}},
+	}))
+
+	query := []float32{1, 0, 0, 0}
+	filter := vectordb.SearchFilter{MinScore: 0.1, MaxResults: 10}
+	files := func(results []vectordb.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Document.FileID)
+		}
+		return out
+	}
+	source := &fakeExclusions{flagged: []string{"draft"}, tagged: map[string][]string{"deprecated": {"legacy"}}}
+	svc := NewQAService(nil, vectorDB, nil, nil, nil, WithExclusions(source))
+
+	// 标记为不检索的文档在所有问答中都被排除
+	results, err := svc.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guide", "legacy", "faq"}, files(results))
+
+	// 请求中排除的文件和标签
+	ctx := WithRetrievalParams(context.Background(), RetrievalParams{ExcludeFileIDs: []string{"faq", "missing"}, ExcludeTags: []string{"deprecated"}})
+	results, err = svc.retrieve(ctx, "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guide"}, files(results))
+
+	// 排除优先于指定的文件
+	scoped := filter
+	scoped.FileIDs = []string{"guide", "faq"}
+	results, err = svc.retrieve(ctx, "", query, scoped)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guide"}, files(results))
+
+	// 排除条件加入回答缓存键，顺序不影响缓存键
+	reordered := WithRetrievalParams(context.Background(), RetrievalParams{ExcludeFileIDs: []string{"missing", "faq"}, ExcludeTags: []string{"deprecated"}})
+	assert.NotEqual(t, svc.retrievalSettings(context.Background()).key, svc.retrievalSettings(ctx).key)
+	assert.Equal(t, svc.retrievalSettings(ctx).key, svc.retrievalSettings(reordered).key)
+	assert.Equal(t, "_xfa,b_xtx", exclusionKey([]string{"b", "a"}, []string{"x"}))
+
+	// 未启用时只排除请求中的文件
+	plain := NewQAService(nil, vectorDB, nil, nil, nil)
+	results, err = plain.retrieve(ctx, "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"guide", "draft", "legacy"}, files(results))
+
+	// 读取排除的文档失败时不检索
+	source.err = errors.New("db down")
+	_, err = svc.retrieve(context.Background(), "", query, filter)
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+)
+
+// PromptVariant 参与对比实验的提示词变体
+// 模板为空时使用RAG配置的模板，可以作为对照组
+type PromptVariant struct {
+	Name          string // 变体名称，记录在回答和反馈中
+	Weight        int    // 流量权重，为0时不再分配新请求
+	Template      string // 有上下文时的提示词模板
+	EmptyTemplate string // 无上下文时的提示词模板
+}
+
+// PromptExperiment 提示词对比实验，按流量权重为每次问答随机分配变体
+type PromptExperiment struct {
+	variants []PromptVariant
+	total    int
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewPromptExperiment 创建提示词对比实验
+// 变体名称不能为空或重复，权重不能为负，且至少一个变体的权重大于0
+func NewPromptExperiment(variants []PromptVariant) (*PromptExperiment, error) {
+	e := &PromptExperiment{rand: rand.New(rand.NewSource(rand.Int63()))}
+	seen := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if v.Name == "" {
+			return nil, fmt.Errorf("prompt variant name cannot be empty")
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate prompt variant %q", v.Name)
+		}
+		if v.Weight < 0 {
+			return nil, fmt.Errorf("prompt variant %q has negative weight", v.Name)
+		}
+		seen[v.Name] = true
+		e.total += v.Weight
+	}
+	if e.total == 0 {
+		return nil, fmt.Errorf("at least one prompt variant must have a positive weight")
+	}
+	e.variants = variants
+	return e, nil
+}
+
+// Variants 返回所有变体，包括权重为0的变体
+func (e *PromptExperiment) Variants() []PromptVariant {
+	if e == nil {
+		return nil
+	}
+	return e.variants
+}
+
+// pick 按权重随机选择一个变体
+func (e *PromptExperiment) pick() PromptVariant {
+	e.mu.Lock()
+	n := e.rand.Intn(e.total)
+	e.mu.Unlock()
+	for _, v := range e.variants {
+		if n < v.Weight {
+			return v
+		}
+		n -= v.Weight
+	}
+	return e.variants[len(e.variants)-1]
+}
+
+// WithPromptExperiment 设置提示词对比实验，每次问答按权重分配一个变体
+func WithPromptExperiment(experiment *PromptExperiment) QAOption {
+	return func(s *QAService) {
+		s.experiment = experiment
+	}
+}
+
+// PromptVariants 返回对比实验的所有变体，未设置实验时返回nil
+func (s *QAService) PromptVariants() []PromptVariant {
+	return s.experiment.Variants()
+}
+
+// AssignPromptVariant 为本次问答分配提示词变体，返回带有变体的上下文和变体名称
+// 上下文中已有变体时沿用，未设置对比实验时返回空名称
+func (s *QAService) AssignPromptVariant(ctx context.Context) (context.Context, string) {
+	if variant, ok := llm.PromptVariantFromContext(ctx); ok {
+		return ctx, variant.Name
+	}
+	if s.experiment == nil {
+		return ctx, ""
+	}
+	v := s.experiment.pick()
+	return llm.WithPromptVariant(ctx, llm.PromptVariant{
+		Name:          v.Name,
+		Template:      v.Template,
+		EmptyTemplate: v.EmptyTemplate,
+	}), v.Name
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPromptExperiment 测试按权重分配提示词变体，已分配的变体沿用，不同变体的回答分开缓存
+func TestPromptExperiment(t *testing.T) {
+	_, err := NewPromptExperiment([]PromptVariant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}})
+	assert.ErrorContains(t, err, `duplicate prompt variant "a"`)
+	_, err = NewPromptExperiment([]PromptVariant{{Name: "a"}})
+	assert.ErrorContains(t, err, "positive weight")
+
+	experiment, err := NewPromptExperiment([]PromptVariant{
+		{Name: "control", Weight: 9},
+		{Name: "concise", Weight: 1, Template: "简洁回答：{{.Question}}\n{{.Context}}"},
+		{Name: "retired", Weight: 0},
+	})
+	require.NoError(t, err)
+	experiment.rand = rand.New(rand.NewSource(1))
+	service := NewQAService(nil, nil, nil, nil, nil, WithPromptExperiment(experiment))
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		ctx, name := service.AssignPromptVariant(context.Background())
+		variant, ok := llm.PromptVariantFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, name, variant.Name)
+		counts[name]++
+	}
+	assert.Zero(t, counts["retired"])
+	assert.InDelta(t, 900, counts["control"], 50)
+	assert.InDelta(t, 100, counts["concise"], 50)
+
+	// 上下文中已有变体时沿用，重试和后台刷新保持同一个变体
+	ctx := llm.WithPromptVariant(context.Background(), llm.PromptVariant{Name: "concise"})
+	_, name := service.AssignPromptVariant(ctx)
+	assert.Equal(t, "concise", name)
+
+	assert.NotEqual(t,
+		service.retrievalSettings(ctx).cacheKey("qa", "问题"),
+		service.retrievalSettings(llm.WithPromptVariant(context.Background(), llm.PromptVariant{Name: "control"})).cacheKey("qa", "问题"))
+
+	// 未设置对比实验时不分配变体
+	plain := NewQAService(nil, nil, nil, nil, nil)
+	_, name = plain.AssignPromptVariant(context.Background())
+	assert.Empty(t, name)
+	assert.Nil(t, plain.PromptVariants())
+}
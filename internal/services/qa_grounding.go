@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/pyprovider"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// DefaultGroundingFallback 回答缺乏依据时的默认回答
+const DefaultGroundingFallback = "抱歉，我在文档中没有找到足够的依据来回答这个问题。"
+
+// GroundingVerifier 校验回答是否有检索上下文作为依据
+type GroundingVerifier interface {
+	// Verify 返回回答有依据的置信度，取值0~1
+	Verify(ctx context.Context, question, answer string, contexts []string) (float32, error)
+}
+
+// AnswerInfo 问答过程中产生的附加信息
+// 调用方通过WithAnswerInfo放入上下文，问答服务在生成回答时填充
+type AnswerInfo struct {
+	Confidence    *float32 // 回答有依据的置信度，未校验时为nil
+	LowConfidence bool     // 置信度是否低于阈值
+
+	CacheAge   *time.Duration // 命中回答缓存时缓存的时长，未命中或缓存时间未知时为nil
+	Refreshing bool           // 命中的缓存回答是否正在后台刷新
+}
+
+// answerInfoKey 上下文中保存问答附加信息的键
+type answerInfoKey struct{}
+
+// WithAnswerInfo 返回带有问答附加信息收集器的上下文
+func WithAnswerInfo(ctx context.Context) (context.Context, *AnswerInfo) {
+	info := &AnswerInfo{}
+	return context.WithValue(ctx, answerInfoKey{}, info), info
+}
+
+// answerInfoFromContext 读取上下文中的问答附加信息收集器，未设置时返回nil
+func answerInfoFromContext(ctx context.Context) *AnswerInfo {
+	info, _ := ctx.Value(answerInfoKey{}).(*AnswerInfo)
+	return info
+}
+
+// record 记录置信度
+func (i *AnswerInfo) record(confidence, threshold float32) {
+	if i == nil {
+		return
+	}
+	i.Confidence = &confidence
+	i.LowConfidence = confidence < threshold
+}
+
+// WithGroundingCheck 启用回答依据校验
+// 回答生成后用verifier校验是否有检索上下文作为依据，置信度低于minConfidence时标记为低置信度；
+// fallback不为空时改为返回fallback且不附带来源
+func WithGroundingCheck(verifier GroundingVerifier, minConfidence float32, fallback string) QAOption {
+	return func(s *QAService) {
+		s.verifier = verifier
+		s.minConfidence = minConfidence
+		s.groundingFallback = fallback
+	}
+}
+
+// ground 校验回答的依据并记录置信度，缺乏依据且配置了兜底回答时替换回答
+// 校验失败时不影响回答，只是不记录置信度
+func (s *QAService) ground(ctx context.Context, cacheKey, question, answer string, contexts []string, sources []vectordb.Document) (string, []vectordb.Document) {
+	if s.verifier == nil || len(contexts) == 0 {
+		return answer, sources
+	}
+
+	confidence, err := s.verifier.Verify(ctx, question, answer, contexts)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to verify answer grounding")
+		return answer, sources
+	}
+	answerInfoFromContext(ctx).record(confidence, s.minConfidence)
+	s.cache.Set(confidenceCacheKey(cacheKey), strconv.FormatFloat(float64(confidence), 'f', 4, 32), s.cacheTTL)
+
+	if confidence < s.minConfidence && s.groundingFallback != "" {
+		return s.groundingFallback, nil
+	}
+	return answer, sources
+}
+
+// cachedConfidence 回答命中缓存时恢复缓存的置信度
+func (s *QAService) cachedConfidence(ctx context.Context, cacheKey string) {
+	info := answerInfoFromContext(ctx)
+	if s.verifier == nil || info == nil {
+		return
+	}
+	value, found, err := s.cache.Get(confidenceCacheKey(cacheKey))
+	if err != nil || !found {
+		return
+	}
+	if confidence, err := strconv.ParseFloat(value, 32); err == nil {
+		info.record(float32(confidence), s.minConfidence)
+	}
+}
+
+// confidenceCacheKey 回答置信度的缓存键
+func confidenceCacheKey(cacheKey string) string {
+	return cacheKey + ":confidence"
+}
+
+// groundingPrompt 大模型校验回答依据的提示词模板
+const groundingPrompt = `请判断下面的回答是否完全有参考上下文作为依据。
+如果回答中的关键信息都能在参考上下文中找到，则有依据；如果回答包含上下文中没有的信息或与上下文矛盾，则依据不足。
+
+只输出一个JSON对象，不要输出其他内容，格式如下：
+{"supported": true或false, "confidence": 回答有依据的程度，0到1之间的数字，0表示完全没有依据}
+
+参考上下文:
+%s
+用户问题: %s
+
+回答: %s`
+
+// LLMVerifier 使用大模型校验回答依据
+type LLMVerifier struct {
+	client llm.Client
+}
+
+// NewLLMVerifier 创建大模型依据校验器
+func NewLLMVerifier(client llm.Client) *LLMVerifier {
+	return &LLMVerifier{client: client}
+}
+
+// Verify 让大模型判断回答是否有上下文作为依据
+func (v *LLMVerifier) Verify(ctx context.Context, question, answer string, contexts []string) (float32, error) {
+	var sb strings.Builder
+	for i, c := range contexts {
+		fmt.Fprintf(&sb, "【%d】%s\n\n", i+1, c)
+	}
+	resp, err := v.client.Generate(ctx, fmt.Sprintf(groundingPrompt, sb.String(), question, answer),
+		llm.WithGenerateMaxTokens(100),
+		llm.WithGenerateTemperature(0))
+	if err != nil {
+		return 0, err
+	}
+
+	raw := resp.Text
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("invalid verification response: %q", raw)
+	}
+	var result struct {
+		Supported  bool     `json:"supported"`
+		Confidence *float32 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &result); err != nil {
+		return 0, fmt.Errorf("invalid verification response: %w", err)
+	}
+
+	// 未给出置信度时按判定结果取0或1
+	confidence := float32(0)
+	if result.Supported {
+		confidence = 1
+	}
+	if result.Confidence != nil && *result.Confidence >= 0 && *result.Confidence <= 1 {
+		confidence = *result.Confidence
+	}
+	return confidence, nil
+}
+
+// NLIVerifier 通过Python服务的NLI模型校验回答依据
+type NLIVerifier struct {
+	client *pyprovider.LLMClient
+	model  string
+}
+
+// NewNLIVerifier 创建NLI依据校验器，model为空时使用Python服务的默认模型
+func NewNLIVerifier(client *pyprovider.LLMClient, model string) *NLIVerifier {
+	return &NLIVerifier{client: client, model: model}
+}
+
+// Verify 以上下文为前提、回答为假设计算蕴含概率
+func (v *NLIVerifier) Verify(ctx context.Context, _ string, answer string, contexts []string) (float32, error) {
+	resp, err := v.client.Verify(ctx, answer, contexts, v.model)
+	if err != nil {
+		return 0, err
+	}
+	return float32(resp.Score), nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// stubVerifier 返回固定置信度的依据校验器
+type stubVerifier struct {
+	confidence float32
+	calls      int
+}
+
+func (v *stubVerifier) Verify(ctx context.Context, question, answer string, contexts []string) (float32, error) {
+	v.calls++
+	return v.confidence, nil
+}
+
+// newGroundingTestService 创建带依据校验的问答服务
+func newGroundingTestService(t *testing.T, verifier GroundingVerifier, fallback string) *QAService {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.Add(vectordb.Document{
+		ID: "install_0", FileID: "install", Text: "安装前请先配置数据库连接。", Vector: []float32{1, 0, 0, 0},
+	}))
+
+	embedder := embedding.NewMockClient(t)
+	embedder.On("Embed", mock.Anything, mock.Anything).Maybe().Return([]float32{1, 0, 0, 0}, nil)
+	llmClient := llm.NewMockClient(t)
+	llmClient.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return(
+		&llm.Response{Text: "安装前需要配置Redis[1]。"}, nil,
+	)
+	memCache, err := cache.NewMemoryCache(cache.Config{})
+	require.NoError(t, err)
+
+	return NewQAService(embedder, vectorDB, llmClient, llm.NewRAG(llmClient), memCache,
+		WithGroundingCheck(verifier, 0.5, fallback))
+}
+
+// TestGroundingCheck 测试低置信度回答的标记和兜底
+func TestGroundingCheck(t *testing.T) {
+	verifier := &stubVerifier{confidence: 0.2}
+	service := newGroundingTestService(t, verifier, "")
+
+	ctx, info := WithAnswerInfo(context.Background())
+	answer, sources, err := service.Answer(ctx, "安装前要做什么？")
+	require.NoError(t, err)
+	assert.Equal(t, "安装前需要配置Redis[1]。", answer)
+	assert.Len(t, sources, 1)
+	require.NotNil(t, info.Confidence)
+	assert.InDelta(t, 0.2, *info.Confidence, 1e-6)
+	assert.True(t, info.LowConfidence)
+
+	// 命中缓存时恢复置信度，不再重复校验
+	ctx, info = WithAnswerInfo(context.Background())
+	_, _, err = service.Answer(ctx, "安装前要做什么？")
+	require.NoError(t, err)
+	assert.Equal(t, 1, verifier.calls)
+	require.NotNil(t, info.Confidence)
+	assert.True(t, info.LowConfidence)
+
+	// 配置兜底回答时替换回答并去掉来源
+	fallback := newGroundingTestService(t, &stubVerifier{confidence: 0.2}, DefaultGroundingFallback)
+	answer, sources, err = fallback.Answer(context.Background(), "安装前要做什么？")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultGroundingFallback, answer)
+	assert.Empty(t, sources)
+
+	// 有依据的回答不受影响
+	grounded := newGroundingTestService(t, &stubVerifier{confidence: 0.9}, DefaultGroundingFallback)
+	ctx, info = WithAnswerInfo(context.Background())
+	answer, _, err = grounded.Answer(ctx, "安装前要做什么？")
+	require.NoError(t, err)
+	assert.Equal(t, "安装前需要配置Redis[1]。", answer)
+	assert.False(t, info.LowConfidence)
+}
+
+// TestLLMVerifier 测试大模型依据校验结果解析
+func TestLLMVerifier(t *testing.T) {
+	client := llm.NewMockClient(t)
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: "```json\n{\"supported\": false, \"confidence\": 0.1}\n```"}, nil,
+	).Once()
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: `{"supported": true}`}, nil,
+	).Once()
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: "无法判断"}, nil,
+	).Once()
+
+	verifier := NewLLMVerifier(client)
+	contexts := []string{"安装前请先配置数据库连接。"}
+
+	confidence, err := verifier.Verify(context.Background(), "安装前要做什么？", "需要配置Redis", contexts)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.1, confidence, 1e-6)
+
+	confidence, err = verifier.Verify(context.Background(), "安装前要做什么？", "需要配置数据库连接", contexts)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1), confidence)
+
+	_, err = verifier.Verify(context.Background(), "安装前要做什么？", "需要配置数据库连接", contexts)
+	assert.Error(t, err)
+}
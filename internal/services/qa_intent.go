@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// Intent 问题意图
+type Intent string
+
+const (
+	IntentGreeting Intent = "greeting" // 问候语，直接由大模型回应
+	IntentChitchat Intent = "chitchat" // 闲聊，直接由大模型回应，不检索文档
+	IntentFactual  Intent = "factual"  // 事实性问题，走检索增强生成
+	IntentCommand  Intent = "command"  // 元命令（如总结某个文档），交给对应的命令处理器
+)
+
+// CommandSummarize 总结文档的命令名
+const CommandSummarize = "summarize"
+
+// Classification 问题分类结果
+type Classification struct {
+	Intent  Intent
+	Command string // 命令名，仅IntentCommand时有效
+	Target  string // 命令作用的对象（文档名称或ID），可以为空
+}
+
+// IntentClassifier 问题分类器，问答前判断问题应交给哪个处理流程
+type IntentClassifier interface {
+	Classify(ctx context.Context, question string) (Classification, error)
+}
+
+// CommandHandler 命令处理器，target为命令作用的对象，问题没有指明时为问答范围内的文件ID或空
+type CommandHandler func(ctx context.Context, question, target string) (string, []vectordb.Document, error)
+
+// WithIntentClassifier 设置问题分类器，默认使用RuleClassifier
+func WithIntentClassifier(classifier IntentClassifier) QAOption {
+	return func(s *QAService) {
+		if classifier != nil {
+			s.classifier = classifier
+		}
+	}
+}
+
+// WithCommandHandler 注册命令处理器，分类为该命令的问题交给handler处理
+// 没有注册处理器的命令按事实性问题回答
+func WithCommandHandler(command string, handler CommandHandler) QAOption {
+	return func(s *QAService) {
+		if s.commands == nil {
+			s.commands = make(map[string]CommandHandler)
+		}
+		s.commands[command] = handler
+	}
+}
+
+// route 按问题意图分流，问候、闲聊和已注册的命令在这里处理，返回handled为false时继续检索增强生成
+// 分类失败时按事实性问题处理
+func (s *QAService) route(ctx context.Context, question, target string) (_ string, _ []vectordb.Document, handled bool, err error) {
+	if s.classifier == nil {
+		return "", nil, false, nil
+	}
+	c, err := s.classifier.Classify(ctx, question)
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to classify question")
+		return "", nil, false, nil
+	}
+
+	switch c.Intent {
+	case IntentGreeting:
+		answer, err := s.handleGreeting(ctx, question)
+		return answer, nil, true, err
+	case IntentChitchat:
+		answer, err := s.handleChitchat(ctx, question)
+		return answer, nil, true, err
+	case IntentCommand:
+		handler, ok := s.commands[c.Command]
+		if !ok {
+			return "", nil, false, nil
+		}
+		if c.Target == "" {
+			c.Target = target
+		}
+		answer, sources, err := handler(ctx, question, c.Target)
+		return answer, sources, true, err
+	}
+	return "", nil, false, nil
+}
+
+// handleChitchat 处理闲聊，不检索文档直接由大模型回应
+func (s *QAService) handleChitchat(ctx context.Context, question string) (string, error) {
+	prompt := "用户对文档问答助手说：\"" + question + "\"。这不是关于文档内容的问题，请用简短友善的语言回应，不要编造文档内容。"
+
+	response, err := s.llm.Generate(
+		ctx,
+		prompt,
+		llm.WithGenerateMaxTokens(256),
+		llm.WithGenerateTemperature(0.7),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate chitchat response: %w", err)
+	}
+	return response.Text, nil
+}
+
+// 规则分类使用的关键词
+var (
+	// greetings 常见问候语
+	greetings = []string{
+		"你好", "您好", "早上好", "下午好", "晚上好", "嗨", "hi", "hello",
+		"hey", "嘿", "哈喽", "喂", "在吗", "在么", "在不在",
+	}
+	// chitchats 常见闲聊，去掉句末标点后完全匹配
+	chitchats = []string{
+		"谢谢", "谢谢你", "多谢", "感谢", "thanks", "thank you", "好的", "ok", "okay", "再见", "拜拜", "bye",
+		"你是谁", "你叫什么", "你叫什么名字", "你能做什么", "你会什么", "who are you", "what can you do",
+	}
+	// commandPatterns 命令的匹配规则，第一个分组为命令作用的对象
+	commandPatterns = []struct {
+		command string
+		re      *regexp.Regexp
+	}{
+		{CommandSummarize, regexp.MustCompile(`(?i)^(?:请|请你|帮我|请帮我|please)?\s*(?:总结|概括|summari[sz]e)(?:一下)?\s*(?:这个|这篇|这份|the|this)?\s*(?:文档|文件|document|doc)\s*(.*)$`)},
+		{CommandSummarize, regexp.MustCompile(`^(?:请|请你|帮我|请帮我)?\s*(?:总结|概括)(?:一下)?\s*(《.+》.*)$`)},
+	}
+)
+
+// RuleClassifier 基于关键词和命令模式的问题分类器
+type RuleClassifier struct{}
+
+// Classify 按关键词识别问候和闲聊，按命令模式识别元命令，其余视为事实性问题
+func (RuleClassifier) Classify(ctx context.Context, question string) (Classification, error) {
+	if isGreeting(question) {
+		return Classification{Intent: IntentGreeting}, nil
+	}
+	q := strings.ToLower(strings.TrimSpace(question))
+	q = strings.TrimRight(q, "!！。.~～?？ ")
+	for _, c := range chitchats {
+		if q == c {
+			return Classification{Intent: IntentChitchat}, nil
+		}
+	}
+	// 命令对象可能是区分大小写的文件名，使用原始输入匹配
+	command := strings.TrimRight(strings.TrimSpace(question), "!！。.~～?？ ")
+	for _, p := range commandPatterns {
+		if m := p.re.FindStringSubmatch(command); m != nil {
+			return Classification{Intent: IntentCommand, Command: p.command, Target: commandTarget(m[1])}, nil
+		}
+	}
+	return Classification{Intent: IntentFactual}, nil
+}
+
+// commandTarget 清理命令对象两侧的引号、书名号和多余的描述
+func commandTarget(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "的内容")
+	s = strings.TrimSuffix(s, "的主要内容")
+	return strings.Trim(s, "《》\"“”'‘’「」 ")
+}
+
+// isGreeting 检查问题是否为简单问候语
+func isGreeting(question string) bool {
+	// 转为小写并去除空格以便更准确匹配
+	q := strings.ToLower(strings.TrimSpace(question))
+
+	// 检查是否完全匹配
+	for _, g := range greetings {
+		if q == g {
+			return true
+		}
+	}
+
+	// 检查是否为有附加内容的问候语
+	// 仅对非常短的内容进行匹配，并且必须以问候语开头
+	if len(q) < 8 {
+		for _, g := range greetings {
+			if strings.HasPrefix(q, g+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// intentPrompt 大模型分类问题的提示词模板
+const intentPrompt = `你是文档问答系统的问题分类器，请判断用户输入属于哪一类：
+- greeting：问候语
+- chitchat：与文档内容无关的闲聊，如道谢、询问助手身份
+- factual：需要查阅文档回答的问题
+- command：要求对文档执行操作，可用的命令有：%s
+
+只输出一个JSON对象，不要输出其他内容，格式如下：
+{"intent": "greeting、chitchat、factual或command", "command": "命令名，仅command时填写", "target": "命令作用的文档名称，没有指明时为空"}
+
+用户输入: %s`
+
+// LLMClassifier 使用大模型分类问题
+// 规则能识别的问候和命令直接返回，不调用大模型；大模型出错或输出无法解析时退回规则分类的结果
+type LLMClassifier struct {
+	client   llm.Client
+	commands []string
+	rules    RuleClassifier
+	logger   *logrus.Logger
+}
+
+// LLMClassifierOption 大模型问题分类器配置选项
+type LLMClassifierOption func(*LLMClassifier)
+
+// WithClassifierLogger 设置日志记录器
+func WithClassifierLogger(logger *logrus.Logger) LLMClassifierOption {
+	return func(c *LLMClassifier) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// NewLLMClassifier 创建大模型问题分类器，client可以是单独配置的小模型
+func NewLLMClassifier(client llm.Client, opts ...LLMClassifierOption) *LLMClassifier {
+	c := &LLMClassifier{client: client, commands: []string{CommandSummarize}, logger: logrus.StandardLogger()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Classify 让大模型判断问题意图
+func (c *LLMClassifier) Classify(ctx context.Context, question string) (Classification, error) {
+	ruled, _ := c.rules.Classify(ctx, question)
+	if ruled.Intent != IntentFactual {
+		return ruled, nil
+	}
+
+	resp, err := c.client.Generate(ctx, fmt.Sprintf(intentPrompt, strings.Join(c.commands, "、"), question),
+		llm.WithGenerateMaxTokens(100),
+		llm.WithGenerateTemperature(0),
+		llm.WithGenerateCacheable())
+	if err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warn("Failed to classify question with LLM")
+		return ruled, nil
+	}
+
+	raw := resp.Text
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return ruled, nil
+	}
+	var result struct {
+		Intent  string `json:"intent"`
+		Command string `json:"command"`
+		Target  string `json:"target"`
+	}
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &result); err != nil {
+		return ruled, nil
+	}
+
+	switch intent := Intent(strings.ToLower(strings.TrimSpace(result.Intent))); intent {
+	case IntentGreeting, IntentChitchat:
+		return Classification{Intent: intent}, nil
+	case IntentCommand:
+		command := strings.ToLower(strings.TrimSpace(result.Command))
+		for _, known := range c.commands {
+			if command == known {
+				return Classification{Intent: IntentCommand, Command: command, Target: commandTarget(result.Target)}, nil
+			}
+		}
+	}
+	return Classification{Intent: IntentFactual}, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRuleClassifier 测试规则分类
+func TestRuleClassifier(t *testing.T) {
+	tests := []struct {
+		question string
+		want     Classification
+	}{
+		{"你好", Classification{Intent: IntentGreeting}},
+		{"Hello", Classification{Intent: IntentGreeting}},
+		{"谢谢！", Classification{Intent: IntentChitchat}},
+		{"你是谁？", Classification{Intent: IntentChitchat}},
+		{"如何安装系统？", Classification{Intent: IntentFactual}},
+		{"总结一下安装步骤有哪些", Classification{Intent: IntentFactual}},
+		{"总结一下这个文档", Classification{Intent: IntentCommand, Command: CommandSummarize}},
+		{"请总结文档《安装指南》的内容", Classification{Intent: IntentCommand, Command: CommandSummarize, Target: "安装指南"}},
+		{"概括一下《部署手册》", Classification{Intent: IntentCommand, Command: CommandSummarize, Target: "部署手册"}},
+		{"Summarize doc Install-Guide.md", Classification{Intent: IntentCommand, Command: CommandSummarize, Target: "Install-Guide.md"}},
+	}
+	for _, tt := range tests {
+		got, err := RuleClassifier{}.Classify(context.Background(), tt.question)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.question)
+	}
+}
+
+// TestLLMClassifier 测试大模型分类及出错时退回规则分类
+func TestLLMClassifier(t *testing.T) {
+	client := llm.NewMockClient(t)
+	classifier := NewLLMClassifier(client)
+
+	// 规则能识别的问题不调用大模型
+	c, err := classifier.Classify(context.Background(), "你好")
+	require.NoError(t, err)
+	assert.Equal(t, IntentGreeting, c.Intent)
+
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: `{"intent": "command", "command": "summarize", "target": "《运维手册》"}`}, nil).Once()
+	c, err = classifier.Classify(context.Background(), "帮我把运维手册的要点列出来")
+	require.NoError(t, err)
+	assert.Equal(t, Classification{Intent: IntentCommand, Command: CommandSummarize, Target: "运维手册"}, c)
+
+	// 未知命令按事实性问题处理
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: `{"intent": "command", "command": "delete"}`}, nil).Once()
+	c, err = classifier.Classify(context.Background(), "删除运维手册")
+	require.NoError(t, err)
+	assert.Equal(t, IntentFactual, c.Intent)
+
+	client.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, errors.New("timeout")).Once()
+	c, err = classifier.Classify(context.Background(), "如何安装？")
+	require.NoError(t, err)
+	assert.Equal(t, IntentFactual, c.Intent)
+}
+
+// TestAnswerRoutesByIntent 测试问答按问题意图分流
+func TestAnswerRoutesByIntent(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	llmClient := llm.NewMockClient(t)
+	llmClient.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: "不客气！"}, nil).Once()
+	memCache, err := cache.NewMemoryCache(cache.Config{})
+	require.NoError(t, err)
+
+	var target string
+	service := NewQAService(nil, vectorDB, llmClient, llm.NewRAG(llmClient), memCache,
+		WithCommandHandler(CommandSummarize, func(ctx context.Context, question, t string) (string, []vectordb.Document, error) {
+			target = t
+			return "这是文档摘要", nil, nil
+		}))
+
+	// 闲聊直接由大模型回应，不需要向量化
+	answer, sources, err := service.Answer(context.Background(), "谢谢")
+	require.NoError(t, err)
+	assert.Equal(t, "不客气！", answer)
+	assert.Empty(t, sources)
+
+	// 命令交给处理器，未指明对象时作用于问答范围内的文件
+	answer, _, err = service.AnswerWithFiles(context.Background(), "总结这个文档", []ScopedFile{{FileID: "file-1", Label: "安装指南"}})
+	require.NoError(t, err)
+	assert.Equal(t, "这是文档摘要", answer)
+	assert.Equal(t, "file-1", target)
+}
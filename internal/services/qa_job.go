@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/moderation"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrQAJobNotFound 异步问答任务不存在，或属于其他租户
+var ErrQAJobNotFound = errors.New("qa job not found")
+
+// QAJobRequest 异步问答请求
+type QAJobRequest struct {
+	Question string                 `json:"question"`           // 问题
+	FileID   string                 `json:"file_id,omitempty"`  // 只从指定文件中回答
+	Metadata map[string]interface{} `json:"metadata,omitempty"` // 元数据过滤
+	Params   RetrievalParams        `json:"params"`             // 单次问答的检索参数
+	Model    string                 `json:"model,omitempty"`    // 选择的模型，为空时使用默认模型
+	// Generation 单次问答覆盖的生成参数
+	Generation llm.GenerationParams `json:"generation"`
+	Tenant     string               `json:"tenant,omitempty"` // 提交任务的租户，由Submit填写
+	Groups     []string             `json:"groups,omitempty"` // 提交任务的用户所属的用户组，由Submit填写，用于文档访问控制
+}
+
+// QAJobResult 异步问答的结果
+type QAJobResult struct {
+	Answer        string              `json:"answer"`                   // 回答
+	Sources       []vectordb.Document `json:"sources"`                  // 来源段落，不含向量
+	Confidence    *float32            `json:"confidence,omitempty"`     // 回答有依据的置信度
+	LowConfidence bool                `json:"low_confidence,omitempty"` // 置信度是否低于阈值
+	RefusalStage  string              `json:"refusal_stage,omitempty"`  // 被护栏拦截的阶段
+	RefusalReason string              `json:"refusal_reason,omitempty"` // 被护栏拦截的类别
+	Model         string              `json:"model,omitempty"`          // 生成回答的模型，为空表示默认模型
+	PromptVariant string              `json:"prompt_variant,omitempty"` // 生成回答的提示词变体
+}
+
+// QAJob 异步问答任务的状态
+type QAJob struct {
+	ID          string
+	Status      taskqueue.TaskStatus
+	Question    string
+	Result      *QAJobResult // 完成后的结果
+	Error       string       // 最近一次失败的错误信息
+	Attempts    int
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+}
+
+// Done 任务是否已结束（完成或最终失败）
+func (j *QAJob) Done() bool {
+	return j.Status == taskqueue.StatusCompleted || j.Status == taskqueue.StatusFailed
+}
+
+// QAJobService 异步问答服务
+// 问题作为任务投递到任务队列的问答队列，由worker执行问答并把结果写回任务，
+// 适合多文档综合等耗时超过HTTP或负载均衡超时的问题
+type QAJobService struct {
+	qaService *QAService
+	queue     taskqueue.Queue
+	guard     *GuardService // 问答护栏，为空时不审核内容
+	logger    *logrus.Logger
+}
+
+// QAJobOption 异步问答服务配置选项
+type QAJobOption func(*QAJobService)
+
+// WithQAJobGuard 设置问答护栏，worker执行问答时审核问题和回答
+func WithQAJobGuard(guard *GuardService) QAJobOption {
+	return func(s *QAJobService) {
+		s.guard = guard
+	}
+}
+
+// WithQAJobLogger 设置日志记录器
+func WithQAJobLogger(logger *logrus.Logger) QAJobOption {
+	return func(s *QAJobService) {
+		s.logger = logger
+	}
+}
+
+// NewQAJobService 创建异步问答服务
+func NewQAJobService(qaService *QAService, queue taskqueue.Queue, opts ...QAJobOption) *QAJobService {
+	s := &QAJobService{
+		qaService: qaService,
+		queue:     queue,
+		logger:    logrus.StandardLogger(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Submit 提交异步问答，返回任务ID
+func (s *QAJobService) Submit(ctx context.Context, req QAJobRequest) (string, error) {
+	if req.Question == "" {
+		return "", fmt.Errorf("question cannot be empty")
+	}
+	req.Tenant = usage.TenantFromContext(ctx)
+	req.Groups = acl.GroupsFromContext(ctx)
+	return s.queue.Enqueue(ctx, taskqueue.TaskQAAnswer, "", req)
+}
+
+// Get 查询异步问答任务，任务不存在或属于其他租户时返回ErrQAJobNotFound
+// wait大于0时等待任务结束，最多等待wait
+func (s *QAJobService) Get(ctx context.Context, id string, wait time.Duration) (*QAJob, error) {
+	task, err := s.queue.GetTask(ctx, id)
+	if errors.Is(err, taskqueue.ErrTaskNotFound) {
+		return nil, ErrQAJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.Type != taskqueue.TaskQAAnswer {
+		return nil, ErrQAJobNotFound
+	}
+
+	var req QAJobRequest
+	if err := json.Unmarshal(task.Payload, &req); err != nil {
+		return nil, fmt.Errorf("invalid qa job payload: %w", err)
+	}
+	if req.Tenant != usage.TenantFromContext(ctx) {
+		return nil, ErrQAJobNotFound
+	}
+
+	if wait > 0 && task.Status != taskqueue.StatusCompleted && task.Status != taskqueue.StatusFailed {
+		// 等待超时不算错误，返回当前状态
+		if waited, err := s.queue.WaitForTask(ctx, id, wait); err == nil {
+			task = waited
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else if latest, err := s.queue.GetTask(ctx, id); err == nil {
+			task = latest
+		}
+	}
+
+	job := &QAJob{
+		ID:          task.ID,
+		Status:      task.Status,
+		Question:    req.Question,
+		Error:       task.Error,
+		Attempts:    task.Attempts,
+		CreatedAt:   task.CreatedAt,
+		StartedAt:   task.StartedAt,
+		CompletedAt: task.CompletedAt,
+	}
+	if len(task.Result) > 0 && string(task.Result) != "null" {
+		var result QAJobResult
+		if err := json.Unmarshal(task.Result, &result); err != nil {
+			return nil, fmt.Errorf("invalid qa job result: %w", err)
+		}
+		job.Result = &result
+	}
+	return job, nil
+}
+
+// GetTaskTypes 返回支持的任务类型
+func (s *QAJobService) GetTaskTypes() []taskqueue.TaskType {
+	return []taskqueue.TaskType{taskqueue.TaskQAAnswer}
+}
+
+// ProcessTask 执行异步问答，结果写回任务，由worker把任务标记为完成
+// 问题或回答被护栏拦截时把拒绝说明作为结果，不重试
+func (s *QAJobService) ProcessTask(ctx context.Context, task *taskqueue.Task) error {
+	var req QAJobRequest
+	if err := json.Unmarshal(task.Payload, &req); err != nil || req.Question == "" {
+		return fmt.Errorf("%w: qa job %s", taskqueue.ErrInvalidPayload, task.ID)
+	}
+
+	ctx = usage.WithTenant(ctx, req.Tenant)
+	ctx = acl.WithGroups(ctx, req.Groups)
+	ctx = WithRetrievalParams(ctx, req.Params)
+	ctx = llm.WithModelChoice(ctx, req.Model)
+	ctx = llm.WithGenerationParams(ctx, req.Generation)
+	ctx, variant := s.qaService.AssignPromptVariant(ctx)
+	ctx, info := WithAnswerInfo(ctx)
+
+	ask := func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+		switch {
+		case req.FileID != "":
+			return s.qaService.AnswerWithFile(ctx, question, req.FileID)
+		case len(req.Metadata) > 0:
+			return s.qaService.AnswerWithMetadata(ctx, question, req.Metadata)
+		default:
+			return s.qaService.Answer(ctx, question)
+		}
+	}
+
+	var answer string
+	var sources []vectordb.Document
+	var err error
+	if s.guard != nil {
+		answer, sources, err = s.guard.Answer(ctx, req.Question, ask)
+	} else {
+		answer, sources, err = ask(ctx, req.Question)
+	}
+
+	result := QAJobResult{Sources: []vectordb.Document{}, Model: req.Model, PromptVariant: variant}
+	if blocked, ok := moderation.AsBlocked(err); ok {
+		result.Answer = s.guard.Refusal()
+		result.RefusalStage = string(blocked.Stage)
+		result.RefusalReason = blocked.Category
+	} else if err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("job_id", task.ID).Warn("Async question failed")
+		return err
+	} else {
+		result.Answer = answer
+		result.Confidence = info.Confidence
+		result.LowConfidence = info.LowConfidence
+		for _, doc := range sources {
+			doc.Vector = nil
+			result.Sources = append(result.Sources, doc)
+		}
+	}
+
+	// 结果随处理中状态写入，worker随后把任务标记为完成时保留结果
+	return s.queue.UpdateTaskStatus(ctx, task.ID, taskqueue.StatusProcessing, result, "")
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/taskqueue"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQAJobs 测试提交异步问答、执行任务并查询结果
+func TestQAJobs(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	queue, err := taskqueue.NewRedisQueue(&taskqueue.Config{RedisAddr: mr.Addr(), RetryLimit: 2, RetryDelay: time.Second})
+	require.NoError(t, err)
+	defer queue.Close()
+
+	qaService, cleanup := setupQATestEnv(t)
+	defer cleanup()
+	jobs := NewQAJobService(qaService, queue)
+
+	ctx := usage.WithTenant(context.Background(), "team-a")
+	_, err = jobs.Submit(ctx, QAJobRequest{})
+	assert.Error(t, err)
+
+	id, err := jobs.Submit(ctx, QAJobRequest{Question: "什么是RAG？"})
+	require.NoError(t, err)
+
+	// 其他租户看不到任务
+	_, err = jobs.Get(usage.WithTenant(context.Background(), "team-b"), id, 0)
+	assert.ErrorIs(t, err, ErrQAJobNotFound)
+	_, err = jobs.Get(ctx, "missing", 0)
+	assert.ErrorIs(t, err, ErrQAJobNotFound)
+
+	job, err := jobs.Get(ctx, id, 0)
+	require.NoError(t, err)
+	assert.Equal(t, taskqueue.StatusPending, job.Status)
+	assert.Equal(t, "什么是RAG？", job.Question)
+	assert.False(t, job.Done())
+	assert.Nil(t, job.Result)
+
+	// 模拟worker：执行任务后标记为完成
+	task, err := queue.GetTask(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, jobs.ProcessTask(context.Background(), task))
+	require.NoError(t, queue.UpdateTaskStatus(ctx, id, taskqueue.StatusCompleted, nil, ""))
+
+	job, err = jobs.Get(ctx, id, time.Second)
+	require.NoError(t, err)
+	assert.True(t, job.Done())
+	require.NotNil(t, job.Result)
+	assert.Equal(t, "这是测试回答", job.Result.Answer)
+	require.NotEmpty(t, job.Result.Sources)
+	assert.Nil(t, job.Result.Sources[0].Vector)
+	assert.NotNil(t, job.CompletedAt)
+
+	// 载荷无效的任务不重试
+	err = jobs.ProcessTask(ctx, &taskqueue.Task{ID: "bad", Type: taskqueue.TaskQAAnswer, Payload: []byte(`{}`)})
+	assert.ErrorIs(t, err, taskqueue.ErrInvalidPayload)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/langdetect"
+)
+
+// WithLanguageRouting 设置按语言路由的嵌入客户端
+// 问题按识别出的语言路由到对应的嵌入模型，检索结果只保留由同一模型向量化的段落，
+// 不同模型的向量不可比较，混在一起排序没有意义
+func WithLanguageRouting(router *embedding.Router) QAOption {
+	return func(s *QAService) {
+		s.router = router
+	}
+}
+
+// withQuestionLanguage 识别问题的语言并写入上下文，使改写问题与原问题使用同一个嵌入模型
+// 未启用语言路由时原样返回
+func (s *QAService) withQuestionLanguage(ctx context.Context, question string) (context.Context, string) {
+	if s.router == nil {
+		return ctx, ""
+	}
+	lang := embedding.LanguageFromContext(ctx)
+	if lang == "" {
+		lang = langdetect.Detect(question)
+		ctx = embedding.WithLanguage(ctx, lang)
+	}
+	return ctx, lang
+}
+
+// filterByLanguage 只保留与问题使用同一嵌入模型的段落
+// 没有记录语言的段落（语言识别上线前处理的文档）视为由默认模型向量化
+func (s *QAService) filterByLanguage(lang string, results []vectordb.SearchResult) []vectordb.SearchResult {
+	if s.router == nil {
+		return results
+	}
+	model := s.router.ModelFor(lang)
+	filtered := results[:0]
+	for _, r := range results {
+		docLang, _ := r.Document.Metadata[models.MetaLanguage].(string)
+		if s.router.ModelFor(docLang) == model {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilterByLanguage 测试检索结果只保留与问题使用同一嵌入模型的段落
+func TestFilterByLanguage(t *testing.T) {
+	base := embedding.NewMockClient(t)
+	base.On("Name").Return("base").Maybe()
+	multi := embedding.NewMockClient(t)
+	multi.On("Name").Return("multi").Maybe()
+
+	segment := func(id, lang string) vectordb.SearchResult {
+		metadata := map[string]interface{}{}
+		if lang != "" {
+			metadata[models.MetaLanguage] = lang
+		}
+		return vectordb.SearchResult{Document: vectordb.Document{ID: id, Metadata: metadata}}
+	}
+	ids := func(results []vectordb.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Document.ID)
+		}
+		return out
+	}
+	results := func() []vectordb.SearchResult {
+		return []vectordb.SearchResult{segment("zh", "zh"), segment("old", ""), segment("ru", "ru"), segment("ja", "ja")}
+	}
+
+	// 未启用语言路由时不过滤
+	s := &QAService{}
+	ctx, lang := s.withQuestionLanguage(context.Background(), "Как настроить?")
+	assert.Empty(t, lang)
+	assert.Empty(t, embedding.LanguageFromContext(ctx))
+	assert.Len(t, s.filterByLanguage("ru", results()), 4)
+
+	s.router = embedding.NewRouter(base,
+		embedding.WithDefaultLanguages("zh", "en"),
+		embedding.WithRoute(multi, embedding.OtherLanguages))
+
+	ctx, lang = s.withQuestionLanguage(context.Background(), "Как настроить подключение к базе данных?")
+	assert.Equal(t, "ru", lang)
+	assert.Equal(t, "ru", embedding.LanguageFromContext(ctx))
+	assert.Equal(t, []string{"ru", "ja"}, ids(s.filterByLanguage(lang, results())))
+
+	// 没有记录语言的旧文档视为默认模型向量化
+	assert.Equal(t, []string{"zh", "old"}, ids(s.filterByLanguage("zh", results())))
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+)
+
+// ErrTooManyConcurrentQuestions 同一客户端同时进行的问答数超过上限
+var ErrTooManyConcurrentQuestions = errors.New("too many concurrent questions")
+
+// concurrencyLimiter 问答并发限制
+// 每个客户端同时进行的问答数超过上限时立即拒绝；全局上限用信号量实现，占满时排队等待直到上下文结束
+type concurrencyLimiter struct {
+	perClient int
+	mu        sync.Mutex
+	active    map[string]int
+	slots     chan struct{} // 全局信号量，为空时不限制
+}
+
+// WithConcurrencyLimit 设置问答并发上限，避免单个客户端大量并发提问耗尽大模型配额
+// perClient为每个客户端（租户或匿名请求的来源地址）同时进行的问答数；total为全局同时进行的问答数；为0时不限制
+func WithConcurrencyLimit(perClient, total int) QAOption {
+	return func(s *QAService) {
+		if perClient <= 0 && total <= 0 {
+			return
+		}
+		limiter := &concurrencyLimiter{
+			perClient: perClient,
+			active:    make(map[string]int),
+		}
+		if total > 0 {
+			limiter.slots = make(chan struct{}, total)
+		}
+		s.concurrency = limiter
+	}
+}
+
+// acquire 为请求所属的客户端占用一个问答名额，返回释放函数
+// 客户端为认证的租户，匿名请求为来源地址，都没有时只受全局上限约束
+func (s *QAService) acquire(ctx context.Context) (func(), error) {
+	if s.concurrency == nil {
+		return func() {}, nil
+	}
+	return s.concurrency.acquire(ctx, auth.ClientFromContext(ctx))
+}
+
+// acquire 为客户端占用一个名额
+func (l *concurrencyLimiter) acquire(ctx context.Context, client string) (func(), error) {
+	limitClient := l.perClient > 0 && client != ""
+	if limitClient {
+		l.mu.Lock()
+		if l.active[client] >= l.perClient {
+			l.mu.Unlock()
+			return nil, ErrTooManyConcurrentQuestions
+		}
+		l.active[client]++
+		l.mu.Unlock()
+	}
+
+	releaseClient := func() {
+		if !limitClient {
+			return
+		}
+		l.mu.Lock()
+		if l.active[client]--; l.active[client] <= 0 {
+			delete(l.active, client)
+		}
+		l.mu.Unlock()
+	}
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			releaseClient()
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if l.slots != nil {
+				<-l.slots
+			}
+			releaseClient()
+		})
+	}, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/auth"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConcurrencyLimit 测试问答并发限制
+func TestConcurrencyLimit(t *testing.T) {
+	s := &QAService{}
+	WithConcurrencyLimit(2, 3)(s)
+	require.NotNil(t, s.concurrency)
+
+	tenantA := usage.WithTenant(context.Background(), "tenant-a")
+	tenantB := usage.WithTenant(context.Background(), "tenant-b")
+
+	// 单个租户超过上限时立即拒绝
+	releaseA1, err := s.acquire(tenantA)
+	require.NoError(t, err)
+	releaseA2, err := s.acquire(tenantA)
+	require.NoError(t, err)
+	_, err = s.acquire(tenantA)
+	assert.ErrorIs(t, err, ErrTooManyConcurrentQuestions)
+
+	// 其他租户不受影响，但受全局上限约束
+	releaseB, err := s.acquire(tenantB)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = s.acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 释放后名额可以复用，重复释放不会多释放名额
+	releaseA1()
+	releaseA1()
+	releaseA3, err := s.acquire(tenantA)
+	require.NoError(t, err)
+	_, err = s.acquire(tenantA)
+	assert.ErrorIs(t, err, ErrTooManyConcurrentQuestions)
+
+	// 匿名请求排队等待全局名额
+	acquired := make(chan func(), 1)
+	go func() {
+		release, err := s.acquire(context.Background())
+		if err == nil {
+			acquired <- release
+		}
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("anonymous request should wait for a global slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+	releaseB()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("anonymous request should acquire the released slot")
+	}
+
+	releaseA2()
+	releaseA3()
+	assert.Empty(t, s.concurrency.active)
+
+	// 匿名请求按来源地址分别限制，没有来源地址时只受全局上限约束
+	perIP := &QAService{}
+	WithConcurrencyLimit(1, 0)(perIP)
+	fromA := auth.WithClientAddress(context.Background(), "10.0.0.1")
+	releaseIP, err := perIP.acquire(fromA)
+	require.NoError(t, err)
+	_, err = perIP.acquire(fromA)
+	assert.ErrorIs(t, err, ErrTooManyConcurrentQuestions)
+	releaseOther, err := perIP.acquire(auth.WithClientAddress(context.Background(), "10.0.0.2"))
+	require.NoError(t, err)
+	releaseIP()
+	releaseOther()
+
+	// 未配置时不限制
+	unlimited := &QAService{}
+	WithConcurrencyLimit(0, 0)(unlimited)
+	assert.Nil(t, unlimited.concurrency)
+	release, err := unlimited.acquire(tenantA)
+	require.NoError(t, err)
+	release()
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// WithAdjacentMerge 启用相邻段落合并
+// 分块之间有重叠，同一文档中位置相邻的段落同时被检索到时，提示词中会出现重复的文本；
+// 启用后在构建提示词前将这些段落合并为一个上下文，来源中记录合并的段落位置范围
+func WithAdjacentMerge() QAOption {
+	return func(s *QAService) {
+		s.mergeEnabled = true
+	}
+}
+
+// mergeResults 未启用相邻段落合并时原样返回检索结果
+func (s *QAService) mergeResults(results []vectordb.SearchResult) []vectordb.SearchResult {
+	if !s.mergeEnabled {
+		return results
+	}
+	return mergeAdjacent(results)
+}
+
+// mergeAdjacent 合并检索结果中同一文档位置连续的段落
+// 合并后的结果放在其中排名最靠前的段落处，得分取最高分；ID、位置和元数据沿用位置最小的段落，
+// 文本按位置顺序拼接并去掉重叠部分。图片段落和没有文本的段落不参与合并
+func mergeAdjacent(results []vectordb.SearchResult) []vectordb.SearchResult {
+	if len(results) < 2 {
+		return results
+	}
+
+	groups := make(map[string][]int)
+	for i, result := range results {
+		doc := result.Document
+		if doc.FileID == "" || doc.Text == "" {
+			continue
+		}
+		if _, ok := doc.Metadata[document.MetaImage]; ok {
+			continue
+		}
+		key := doc.Namespace + "\x00" + doc.FileID
+		groups[key] = append(groups[key], i)
+	}
+
+	merged := make([]vectordb.SearchResult, len(results))
+	copy(merged, results)
+	removed := make([]bool, len(results))
+	for _, indexes := range groups {
+		if len(indexes) < 2 {
+			continue
+		}
+		sort.Slice(indexes, func(a, b int) bool {
+			return results[indexes[a]].Document.Position < results[indexes[b]].Document.Position
+		})
+		for start := 0; start < len(indexes); {
+			end := start + 1
+			for end < len(indexes) && results[indexes[end]].Document.Position == results[indexes[end-1]].Document.Position+1 {
+				end++
+			}
+			if end-start > 1 {
+				run := indexes[start:end]
+				leader := topRanked(run)
+				for _, i := range run {
+					removed[i] = i != leader
+				}
+				merged[leader] = mergedResult(results, run, leader)
+			}
+			start = end
+		}
+	}
+
+	kept := merged[:0]
+	for i, result := range merged {
+		if !removed[i] {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// topRanked 返回一组连续段落中排名最靠前的段落下标
+func topRanked(run []int) int {
+	leader := run[0]
+	for _, i := range run[1:] {
+		if i < leader {
+			leader = i
+		}
+	}
+	return leader
+}
+
+// mergedResult 将按位置排序的连续段落合并为一个检索结果，距离沿用排名最靠前的段落
+func mergedResult(results []vectordb.SearchResult, run []int, leader int) vectordb.SearchResult {
+	first := results[run[0]]
+	last := results[run[len(run)-1]]
+
+	doc := first.Document
+	score := first.Score
+	for _, i := range run[1:] {
+		doc.Text = document.MergeText(doc.Text, results[i].Document.Text)
+		score = max(score, results[i].Score)
+	}
+
+	// 写入元数据副本，避免修改向量数据库返回的原始元数据
+	meta := make(map[string]interface{}, len(doc.Metadata)+2)
+	for k, v := range doc.Metadata {
+		meta[k] = v
+	}
+	meta[document.MetaMergedFrom] = first.Document.Position
+	meta[document.MetaMergedTo] = last.Document.Position
+	doc.Metadata = meta
+
+	result := results[leader]
+	result.Document = doc
+	result.Score = score
+	return result
+}
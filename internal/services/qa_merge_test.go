@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMergeAdjacent 测试同一文档中位置连续的段落合并为一个结果，并记录合并范围
+func TestMergeAdjacent(t *testing.T) {
+	segment := func(fileID string, position int, text string, score float32) vectordb.SearchResult {
+		return vectordb.SearchResult{
+			Document: vectordb.Document{
+				ID:       fileID + "_" + string(rune('0'+position)),
+				FileID:   fileID,
+				Position: position,
+				Text:     text,
+				Metadata: map[string]interface{}{document.MetaPage: "2"},
+			},
+			Score: score,
+		}
+	}
+	results := []vectordb.SearchResult{
+		segment("a", 3, "修改端口后需要重启服务才能生效。", 0.9),
+		segment("b", 1, "升级前先备份数据库。", 0.85),
+		segment("a", 2, "打开config.yaml，修改端口后需要重启", 0.8),
+		segment("a", 5, "日志默认写入logs目录。", 0.7),
+		segment("b", 2, "备份完成后替换可执行文件。", 0.6),
+		segment("a", 4, "重启后访问健康检查接口确认。", 0.5),
+	}
+
+	merged := mergeAdjacent(results)
+	require.Len(t, merged, 2)
+
+	// a的2~5合并，放在排名最靠前的a_3处，重叠的文本只保留一份
+	a := merged[0]
+	assert.Equal(t, "a_2", a.Document.ID)
+	assert.Equal(t, 2, a.Document.Position)
+	assert.Equal(t, float32(0.9), a.Score)
+	assert.Equal(t, "打开config.yaml，修改端口后需要重启服务才能生效。\n重启后访问健康检查接口确认。\n日志默认写入logs目录。", a.Document.Text)
+	from, to, ok := document.MergedRangeFromMetadata(a.Document.Metadata)
+	assert.True(t, ok)
+	assert.Equal(t, []int{2, 5}, []int{from, to})
+	assert.Equal(t, "2", a.Document.Metadata[document.MetaPage])
+
+	b := merged[1]
+	assert.Equal(t, "升级前先备份数据库。\n备份完成后替换可执行文件。", b.Document.Text)
+	from, to, _ = document.MergedRangeFromMetadata(b.Document.Metadata)
+	assert.Equal(t, []int{1, 2}, []int{from, to})
+
+	// 原始结果的元数据不被修改
+	_, _, ok = document.MergedRangeFromMetadata(results[0].Document.Metadata)
+	assert.False(t, ok)
+
+	// 不相邻或图片段落不合并
+	image := segment("a", 4, "架构图：网关、服务和数据库", 0.4)
+	image.Document.Metadata = map[string]interface{}{document.MetaImage: "img1"}
+	separate := []vectordb.SearchResult{results[0], results[3], image}
+	assert.Equal(t, separate, mergeAdjacent(separate))
+
+	// 未启用时原样返回
+	plain := NewQAService(nil, nil, nil, nil, nil)
+	assert.Equal(t, results, plain.mergeResults(results))
+	assert.Len(t, NewQAService(nil, nil, nil, nil, nil, WithAdjacentMerge()).mergeResults(results), 2)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+const (
+	// defaultMMRLambda MMR默认的相关性权重
+	defaultMMRLambda = 0.7
+	// defaultMMRPoolFactor 未指定候选数量时，候选池为返回数量的倍数
+	defaultMMRPoolFactor = 4
+)
+
+// WithMMR 启用MMR（最大边际相关性）结果多样化
+// lambda为相关性权重，取值0~1，越小越偏向多样性；candidates为参与重排的候选数量，
+// 不大于检索数量时使用检索数量的4倍
+func WithMMR(lambda float32, candidates int) QAOption {
+	return func(s *QAService) {
+		if lambda < 0 || lambda > 1 {
+			lambda = defaultMMRLambda
+		}
+		s.mmrEnabled = true
+		s.mmrLambda = lambda
+		s.mmrCandidates = candidates
+	}
+}
+
+// mmrSelect 按最大边际相关性从候选中选出k个结果
+// 每一步选择 lambda*相关性 - (1-lambda)*与已选结果的最大相似度 最高的候选，
+// 相关性使用检索得分，段落间相似度使用向量的余弦相似度
+func mmrSelect(candidates []vectordb.SearchResult, k int, lambda float32) []vectordb.SearchResult {
+	if k <= 0 || len(candidates) <= 1 {
+		return candidates
+	}
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+
+	selected := make([]vectordb.SearchResult, 0, k)
+	used := make([]bool, len(candidates))
+	// maxSim[i] 为候选i与已选结果的最大相似度
+	maxSim := make([]float32, len(candidates))
+
+	for len(selected) < k {
+		best := -1
+		var bestScore float32
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda*candidate.Score - (1-lambda)*maxSim[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		used[best] = true
+		chosen := candidates[best]
+		selected = append(selected, chosen)
+		for i, candidate := range candidates {
+			if used[i] {
+				continue
+			}
+			if sim := cosineSimilarity(candidate.Document.Vector, chosen.Document.Vector); sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+
+	return selected
+}
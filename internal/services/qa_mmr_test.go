@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMMRRetrieve 测试MMR避免返回近似重复的段落
+func TestMMRRetrieve(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "a_0", FileID: "a", Text: "安装步骤", Vector: []float32{1, 0.1, 0, 0}},
+		{ID: "a_0_copy", FileID: "a", Text: "安装步骤（重复）", Vector: []float32{1, 0.12, 0, 0}},
+		{ID: "b_0", FileID: "b", Text: "升级步骤", Vector: []float32{0.8, 0, 0.6, 0}},
+		{ID: "c_0", FileID: "c", Text: "无关内容", Vector: []float32{0, 0, 0, 1}},
+	}))
+
+	query := []float32{1, 0, 0, 0}
+	filter := vectordb.SearchFilter{MinScore: 0.5, MaxResults: 2}
+	ids := func(results []vectordb.SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Document.ID)
+		}
+		return out
+	}
+
+	// 未启用MMR时按相关性返回，两个结果几乎相同
+	plain := NewQAService(nil, vectorDB, nil, nil, nil)
+	results, err := plain.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a_0", "a_0_copy"}, ids(results))
+
+	// 启用MMR后第二个结果换成内容不同的段落
+	diverse := NewQAService(nil, vectorDB, nil, nil, nil, WithMMR(0.3, 10))
+	results, err = diverse.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a_0", "b_0"}, ids(results))
+
+	// lambda为1时退化为按相关性排序
+	relevance := NewQAService(nil, vectorDB, nil, nil, nil, WithMMR(1, 10))
+	results, err = relevance.retrieve(context.Background(), "", query, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a_0", "a_0_copy"}, ids(results))
+}
+
+// TestMMRSelect 测试MMR选择的边界情况
+func TestMMRSelect(t *testing.T) {
+	assert.Empty(t, mmrSelect(nil, 3, 0.5))
+
+	candidates := []vectordb.SearchResult{
+		{Document: vectordb.Document{ID: "a"}, Score: 0.9},
+		{Document: vectordb.Document{ID: "b"}, Score: 0.8},
+	}
+	// 缺少向量时只按相关性排序
+	selected := mmrSelect(candidates, 5, 0.5)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "a", selected[0].Document.ID)
+	assert.Equal(t, "b", selected[1].Document.ID)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+)
+
+// 检索策略
+const (
+	// StrategySimilarity 只按向量相似度取前k个段落
+	StrategySimilarity = "similarity"
+	// StrategyMMR 按最大边际相关性重排，避免返回近似重复的段落
+	StrategyMMR = "mmr"
+	// StrategyRewrite 检索前改写问题，多路检索后按RRF融合
+	StrategyRewrite = "rewrite"
+)
+
+// 单次请求可覆盖的检索参数的默认上限
+const (
+	DefaultMaxSearchLimit   = 50
+	DefaultMaxMMRCandidates = 200
+)
+
+// 直接调用大模型回答（没有检索到相关文档）时的默认生成参数
+const (
+	DefaultAnswerMaxTokens   = 1000
+	DefaultAnswerTemperature = 0.7
+)
+
+// RetrievalParams 单次问答覆盖的检索参数，零值字段使用服务端配置
+// 检索数量和MMR候选数量超过服务端上限时按上限处理
+type RetrievalParams struct {
+	SearchLimit   int      // 检索的段落数量
+	MinScore      *float32 // 最低相似度
+	Strategy      string   // 检索策略：similarity、mmr或rewrite
+	MMRLambda     *float32 // MMR重排的相关性权重（0~1）
+	MMRCandidates int      // 参与MMR重排的候选数量
+
+	ExcludeFileIDs []string // 不参与检索的文件
+	ExcludeTags    []string // 不参与检索的文档标签，需要启用WithExclusions
+}
+
+// retrievalParamsKey 上下文中保存检索参数的键
+type retrievalParamsKey struct{}
+
+// WithRetrievalParams 返回携带单次问答检索参数的上下文
+func WithRetrievalParams(ctx context.Context, params RetrievalParams) context.Context {
+	return context.WithValue(ctx, retrievalParamsKey{}, params)
+}
+
+// WithRetrievalLimits 设置单次请求可覆盖的检索数量和MMR候选数量上限，不大于0时使用默认上限
+func WithRetrievalLimits(maxSearchLimit, maxMMRCandidates int) QAOption {
+	return func(s *QAService) {
+		if maxSearchLimit > 0 {
+			s.maxSearchLimit = maxSearchLimit
+		}
+		if maxMMRCandidates > 0 {
+			s.maxMMRCandidates = maxMMRCandidates
+		}
+	}
+}
+
+// WithGeneration 设置直接调用大模型回答时的最大生成token数和采样温度，不大于0的值使用默认值
+func WithGeneration(maxTokens int, temperature float32) QAOption {
+	return func(s *QAService) {
+		if maxTokens > 0 {
+			s.maxTokens = maxTokens
+		}
+		if temperature > 0 {
+			s.temperature = temperature
+		}
+	}
+}
+
+// WithGenerationLimits 设置单次请求可覆盖的生成参数上限，应与RAG服务的上限一致
+func WithGenerationLimits(limits llm.GenerationLimits) QAOption {
+	return func(s *QAService) {
+		s.generationLimits = limits
+	}
+}
+
+// generateOptions 返回直接调用大模型回答时的生成选项，上下文中的生成参数按上限裁剪后覆盖服务端配置
+func (s *QAService) generateOptions(ctx context.Context) []llm.GenerateOption {
+	maxTokens, temperature := s.generationLimits.Clamp(llm.GenerationParamsFromContext(ctx)).Resolve(s.maxTokens, s.temperature)
+	return []llm.GenerateOption{
+		llm.WithGenerateMaxTokens(maxTokens),
+		llm.WithGenerateTemperature(temperature),
+	}
+}
+
+// retrievalSettings 一次问答实际使用的检索参数
+type retrievalSettings struct {
+	limit      int
+	minScore   float32
+	mmr        bool
+	lambda     float32
+	candidates int
+	rewrite    int    // 改写问题数量，为0时不改写
+	key        string // 覆盖了服务端配置时加入回答缓存键，使不同参数的回答分开缓存
+	format     string // 指定了回答格式时加入回答缓存键，使不同格式的回答分开缓存
+	model      string // 选择了非默认模型时加入回答缓存键，使不同模型的回答分开缓存
+	generation string // 覆盖了生成参数时加入回答缓存键，使不同参数的回答分开缓存
+	variant    string // 分配了提示词变体时加入回答缓存键，使不同变体的回答分开缓存
+
+	excludeFiles []string // 请求中排除的文件
+	excludeTags  []string // 请求中排除的文档标签
+	reader       string   // 启用访问控制时加入回答缓存键，使不同用户的回答分开缓存
+	epoch        string   // 回答缓存代数，失效后更换，使之前缓存的回答不再命中
+}
+
+// retrievalSettings 合并服务端配置和上下文中的单次请求参数
+func (s *QAService) retrievalSettings(ctx context.Context) retrievalSettings {
+	base := s.baseMinScore(ctx)
+	rs := retrievalSettings{
+		limit:      s.searchLimit,
+		minScore:   base,
+		mmr:        s.mmrEnabled,
+		lambda:     s.mmrLambda,
+		candidates: s.mmrCandidates,
+		rewrite:    s.rewriteCount,
+		format:     llm.AnswerFormatFromContext(ctx).CacheKey(),
+		model:      llm.ModelChoiceFromContext(ctx),
+	}
+	if variant, ok := llm.PromptVariantFromContext(ctx); ok {
+		rs.variant = variant.Name
+	}
+	if gen := s.generationLimits.Clamp(llm.GenerationParamsFromContext(ctx)); !gen.IsZero() {
+		rs.generation = fmt.Sprintf("g%d", gen.MaxTokens)
+		if gen.Temperature != nil {
+			rs.generation += fmt.Sprintf("_t%g", *gen.Temperature)
+		}
+	}
+	rs.reader = s.acl.CacheKey(ctx)
+	rs.epoch = s.answerGeneration()
+	params, ok := ctx.Value(retrievalParamsKey{}).(RetrievalParams)
+	if !ok {
+		return rs
+	}
+
+	if params.SearchLimit > 0 {
+		rs.limit = clampMax(params.SearchLimit, s.maxSearchLimit)
+	}
+	if params.MinScore != nil && *params.MinScore >= 0 && *params.MinScore <= 1 {
+		rs.minScore = *params.MinScore
+	}
+	switch params.Strategy {
+	case StrategySimilarity:
+		rs.mmr, rs.rewrite = false, 0
+	case StrategyMMR:
+		rs.mmr, rs.rewrite = true, 0
+		if !s.mmrEnabled {
+			rs.lambda = defaultMMRLambda
+		}
+	case StrategyRewrite:
+		rs.mmr = false
+		if rs.rewrite == 0 {
+			rs.rewrite = defaultRewriteCount
+		}
+	}
+	if params.MMRLambda != nil && *params.MMRLambda >= 0 && *params.MMRLambda <= 1 {
+		rs.lambda = *params.MMRLambda
+	}
+	if params.MMRCandidates > 0 {
+		rs.candidates = clampMax(params.MMRCandidates, s.maxMMRCandidates)
+	}
+
+	rs.excludeFiles = params.ExcludeFileIDs
+	rs.excludeTags = params.ExcludeTags
+
+	if rs.limit != s.searchLimit || rs.minScore != base || rs.mmr != s.mmrEnabled || rs.rewrite != s.rewriteCount ||
+		(rs.mmr && (rs.lambda != s.mmrLambda || rs.candidates != s.mmrCandidates)) {
+		rs.key = fmt.Sprintf("k%d_s%g_m%t_l%g_c%d_r%d", rs.limit, rs.minScore, rs.mmr, rs.lambda, rs.candidates, rs.rewrite)
+	}
+	if exclusion := exclusionKey(rs.excludeFiles, rs.excludeTags); exclusion != "" {
+		rs.key += exclusion
+	}
+	return rs
+}
+
+// cacheKey 生成回答缓存键，覆盖了检索或生成参数、指定了回答格式、选择了模型、分配了提示词变体或启用了访问控制时附加对应标识，
+// 缓存的回答失效过时附加当前的缓存代数
+func (rs retrievalSettings) cacheKey(prefix string, parts ...string) string {
+	if rs.key != "" {
+		parts = append([]string{rs.key}, parts...)
+	}
+	if rs.format != "" {
+		parts = append([]string{rs.format}, parts...)
+	}
+	if rs.model != "" {
+		parts = append([]string{"m_" + rs.model}, parts...)
+	}
+	if rs.generation != "" {
+		parts = append([]string{rs.generation}, parts...)
+	}
+	if rs.variant != "" {
+		parts = append([]string{"p_" + rs.variant}, parts...)
+	}
+	if rs.reader != "" {
+		parts = append([]string{rs.reader}, parts...)
+	}
+	if rs.epoch != "" {
+		parts = append([]string{"v_" + rs.epoch}, parts...)
+	}
+	return cache.GenerateCacheKey(prefix, parts...)
+}
+
+// clampMax 返回不超过上限的值
+func clampMax(v, max int) int {
+	if v > max {
+		return max
+	}
+	return v
+}
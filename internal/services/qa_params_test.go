@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetrievalSettings 测试单次请求的检索参数与服务端配置合并
+func TestRetrievalSettings(t *testing.T) {
+	service := NewQAService(nil, nil, nil, nil, nil,
+		WithSearchLimit(5), WithMinScore(0.5), WithRetrievalLimits(20, 50))
+
+	// 没有请求参数时使用服务端配置，缓存键不变
+	rs := service.retrievalSettings(context.Background())
+	assert.Equal(t, 5, rs.limit)
+	assert.Equal(t, float32(0.5), rs.minScore)
+	assert.False(t, rs.mmr)
+	assert.Empty(t, rs.key)
+	assert.Equal(t, rs.cacheKey("qa", "问题"), service.retrievalSettings(
+		WithRetrievalParams(context.Background(), RetrievalParams{Strategy: StrategySimilarity})).cacheKey("qa", "问题"))
+
+	// 超过上限的检索数量和候选数量按上限处理
+	minScore, lambda := float32(0.2), float32(0.4)
+	rs = service.retrievalSettings(WithRetrievalParams(context.Background(), RetrievalParams{
+		SearchLimit:   100,
+		MinScore:      &minScore,
+		Strategy:      StrategyMMR,
+		MMRLambda:     &lambda,
+		MMRCandidates: 1000,
+	}))
+	assert.Equal(t, 20, rs.limit)
+	assert.Equal(t, minScore, rs.minScore)
+	assert.True(t, rs.mmr)
+	assert.Equal(t, lambda, rs.lambda)
+	assert.Equal(t, 50, rs.candidates)
+	assert.Equal(t, 0, rs.rewrite)
+	// 不同参数的回答分开缓存
+	assert.NotEmpty(t, rs.key)
+	assert.NotEqual(t, service.retrievalSettings(context.Background()).cacheKey("qa", "问题"), rs.cacheKey("qa", "问题"))
+
+	// 改写策略在服务端未启用改写时使用默认改写数量
+	rs = service.retrievalSettings(WithRetrievalParams(context.Background(), RetrievalParams{Strategy: StrategyRewrite}))
+	assert.Equal(t, defaultRewriteCount, rs.rewrite)
+	assert.False(t, rs.mmr)
+
+	// 服务端启用MMR时，请求可以改回只按相似度检索
+	diverse := NewQAService(nil, nil, nil, nil, nil, WithMMR(0.3, 10))
+	rs = diverse.retrievalSettings(WithRetrievalParams(context.Background(), RetrievalParams{Strategy: StrategySimilarity}))
+	assert.False(t, rs.mmr)
+	assert.NotEmpty(t, rs.key)
+
+	// 选择了模型的回答分开缓存
+	rs = service.retrievalSettings(llm.WithModelChoice(context.Background(), "accurate"))
+	assert.Equal(t, "accurate", rs.model)
+	assert.NotEqual(t, service.retrievalSettings(context.Background()).cacheKey("qa", "问题"), rs.cacheKey("qa", "问题"))
+}
+
+// TestGenerateOptions 测试请求覆盖的生成参数按服务端上限裁剪后使用，并分开缓存
+func TestGenerateOptions(t *testing.T) {
+	service := NewQAService(nil, nil, nil, nil, nil,
+		WithGeneration(800, 0.3), WithGenerationLimits(llm.GenerationLimits{MaxTokens: 2000, MaxTemperature: 1}))
+
+	apply := func(ctx context.Context) *llm.GenerateOptions {
+		opts := &llm.GenerateOptions{}
+		for _, opt := range service.generateOptions(ctx) {
+			opt(opts)
+		}
+		return opts
+	}
+
+	opts := apply(context.Background())
+	assert.Equal(t, 800, *opts.MaxTokens)
+	assert.Equal(t, float32(0.3), *opts.Temperature)
+
+	temperature := float32(1.5)
+	ctx := llm.WithGenerationParams(context.Background(), llm.GenerationParams{MaxTokens: 5000, Temperature: &temperature})
+	opts = apply(ctx)
+	assert.Equal(t, 2000, *opts.MaxTokens)
+	assert.Equal(t, float32(1), *opts.Temperature)
+
+	rs := service.retrievalSettings(ctx)
+	assert.Equal(t, "g2000_t1", rs.generation)
+	assert.NotEqual(t, service.retrievalSettings(context.Background()).cacheKey("qa", "问题"), rs.cacheKey("qa", "问题"))
+}
+
+// TestRetrieveWithRequestParams 测试请求参数改变检索策略
+func TestRetrieveWithRequestParams(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "a_0", FileID: "a", Text: "安装步骤", Vector: []float32{1, 0.1, 0, 0}},
+		{ID: "a_0_copy", FileID: "a", Text: "安装步骤（重复）", Vector: []float32{1, 0.12, 0, 0}},
+		{ID: "b_0", FileID: "b", Text: "升级步骤", Vector: []float32{0.8, 0, 0.6, 0}},
+	}))
+
+	service := NewQAService(nil, vectorDB, nil, nil, nil)
+	filter := vectordb.SearchFilter{MinScore: 0.5, MaxResults: 2}
+	lambda := float32(0.3)
+	ctx := WithRetrievalParams(context.Background(), RetrievalParams{Strategy: StrategyMMR, MMRLambda: &lambda})
+
+	results, err := service.retrieve(ctx, "", []float32{1, 0, 0, 0}, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "a_0", results[0].Document.ID)
+	assert.Equal(t, "b_0", results[1].Document.ID)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"encoding/json"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxParentWindow 命中段落向每侧扩展的最大段落数
+const MaxParentWindow = 20
+
+// SegmentRangeReader 按位置范围读取文档的段落，repository.DocumentRepository满足该接口
+type SegmentRangeReader interface {
+	ListSegmentRange(docID string, from, to int) ([]*models.DocumentSegment, error)
+}
+
+// WithParentExpansion 启用父章节扩展（small-to-big检索）
+// 索引和检索使用较小的分块以提高精度，生成回答时把每个命中的段落扩展为所在章节中前后各window个段落，
+// 为大模型提供完整的上下文；段落不属于任何章节时按位置扩展。window不大于0时不扩展
+func WithParentExpansion(reader SegmentRangeReader, window int) QAOption {
+	return func(s *QAService) {
+		if window > MaxParentWindow {
+			window = MaxParentWindow
+		}
+		s.parents = reader
+		s.parentWindow = window
+	}
+}
+
+// contextResults 整理放入提示词的检索结果：先扩展到父章节，再合并相邻段落
+func (s *QAService) contextResults(results []vectordb.SearchResult) []vectordb.SearchResult {
+	return s.mergeResults(s.expandParents(results))
+}
+
+// parentExpansion 一个扩展后的上下文
+type parentExpansion struct {
+	index  int    // 在结果中的下标
+	key    string // 所属文档
+	lo, hi int    // 包含的段落位置范围
+}
+
+// expandParents 把命中的段落扩展为所在章节中的相邻段落
+// 同一文档中扩展范围重叠或相接的结果合并为一个，放在排名最靠前的结果处，得分取最高分；
+// ID和位置沿用命中的段落，文本按位置顺序拼接并去掉重叠部分，元数据记录包含的段落位置范围。
+// 读取段落失败时保留原结果
+func (s *QAService) expandParents(results []vectordb.SearchResult) []vectordb.SearchResult {
+	if s.parents == nil || s.parentWindow <= 0 || len(results) == 0 {
+		return results
+	}
+
+	expanded := make([]vectordb.SearchResult, 0, len(results))
+	var expansions []*parentExpansion
+	texts := make(map[string]map[int]string) // 文档 -> 段落位置 -> 文本
+	for _, result := range results {
+		doc := result.Document
+		if _, ok := doc.Metadata[document.MetaImage]; ok || doc.FileID == "" {
+			expanded = append(expanded, result)
+			continue
+		}
+
+		segments, err := s.parents.ListSegmentRange(doc.FileID, doc.Position-s.parentWindow, doc.Position+s.parentWindow)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"segment_id": doc.ID,
+				"file_id":    doc.FileID,
+			}).WithError(err).Warn("Failed to expand segment to parent section")
+			expanded = append(expanded, result)
+			continue
+		}
+		lo, hi, ok := siblingRange(segments, doc.Position)
+		if !ok {
+			expanded = append(expanded, result)
+			continue
+		}
+
+		key := doc.Namespace + "\x00" + doc.FileID
+		if texts[key] == nil {
+			texts[key] = make(map[int]string)
+		}
+		for _, seg := range segments {
+			if seg.Position >= lo && seg.Position <= hi {
+				texts[key][seg.Position] = seg.Text
+			}
+		}
+
+		// 与排名更靠前的结果范围重叠或相接时并入该结果
+		var into *parentExpansion
+		for _, e := range expansions {
+			if e.key == key && lo <= e.hi+1 && e.lo <= hi+1 {
+				into = e
+				break
+			}
+		}
+		if into != nil {
+			into.lo, into.hi = min(into.lo, lo), max(into.hi, hi)
+			expanded[into.index].Score = max(expanded[into.index].Score, result.Score)
+			continue
+		}
+		expansions = append(expansions, &parentExpansion{index: len(expanded), key: key, lo: lo, hi: hi})
+		expanded = append(expanded, result)
+	}
+
+	for _, e := range expansions {
+		doc := &expanded[e.index].Document
+		text := ""
+		for pos := e.lo; pos <= e.hi; pos++ {
+			if t, ok := texts[e.key][pos]; ok {
+				text = document.MergeText(text, t)
+			}
+		}
+		if e.lo == e.hi || text == "" {
+			continue
+		}
+		doc.Text = text
+
+		// 写入元数据副本，避免修改向量数据库返回的原始元数据
+		meta := make(map[string]interface{}, len(doc.Metadata)+2)
+		for k, v := range doc.Metadata {
+			meta[k] = v
+		}
+		meta[document.MetaMergedFrom] = e.lo
+		meta[document.MetaMergedTo] = e.hi
+		doc.Metadata = meta
+	}
+	return expanded
+}
+
+// siblingRange 在按位置排序的段落中找出命中段落所在章节内位置连续的范围
+// 命中段落不属于任何章节时，范围内同样不属于任何章节的段落视为兄弟段落；找不到命中段落时ok为false
+func siblingRange(segments []*models.DocumentSegment, position int) (lo, hi int, ok bool) {
+	hit := -1
+	for i, seg := range segments {
+		if seg.Position == position {
+			hit = i
+			break
+		}
+	}
+	if hit < 0 {
+		return 0, 0, false
+	}
+
+	parent := segments[hit].ParentID
+	sibling := func(i, next int) bool {
+		return segments[i].ParentID == parent && segments[i].Position == next && !isImageSegment(segments[i])
+	}
+	first, last := hit, hit
+	for first > 0 && sibling(first-1, segments[first].Position-1) {
+		first--
+	}
+	for last < len(segments)-1 && sibling(last+1, segments[last].Position+1) {
+		last++
+	}
+	return segments[first].Position, segments[last].Position, true
+}
+
+// isImageSegment 判断段落内容是否为图片描述
+func isImageSegment(segment *models.DocumentSegment) bool {
+	if len(segment.Metadata) == 0 {
+		return false
+	}
+	var meta map[string]string
+	if err := json.Unmarshal(segment.Metadata, &meta); err != nil {
+		return false
+	}
+	_, ok := meta[document.MetaImage]
+	return ok
+}
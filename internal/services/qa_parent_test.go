@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSegmentRange 按位置范围读取的段落表
+type fakeSegmentRange struct {
+	segments []*models.DocumentSegment
+	err      error
+}
+
+func (r *fakeSegmentRange) ListSegmentRange(docID string, from, to int) ([]*models.DocumentSegment, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var result []*models.DocumentSegment
+	for _, seg := range r.segments {
+		if seg.DocumentID == docID && seg.Position >= from && seg.Position <= to {
+			result = append(result, seg)
+		}
+	}
+	return result, nil
+}
+
+// TestExpandParents 测试命中段落扩展为所在章节中的相邻段落，重叠的扩展范围合并为一个上下文
+func TestExpandParents(t *testing.T) {
+	section := func(pos int, parent, text string) *models.DocumentSegment {
+		seg := &models.DocumentSegment{DocumentID: "a", SegmentID: fmt.Sprintf("a_%d", pos), Position: pos, Text: text}
+		if parent != "" {
+			seg.ParentID = "a_" + parent
+		}
+		return seg
+	}
+	reader := &fakeSegmentRange{segments: []*models.DocumentSegment{
+		section(0, "0", "安装前检查环境。"),
+		section(1, "1", "下载安装包。"),
+		section(2, "1", "解压安装包后运行install.sh"),
+		section(3, "1", "运行install.sh时需要root权限。"),
+		section(4, "1", "安装完成后启动服务。"),
+		section(5, "5", "配置端口。"),
+		section(6, "", "附录。"),
+		section(7, "", "联系方式。"),
+	}}
+	hit := func(pos int, score float32) vectordb.SearchResult {
+		return vectordb.SearchResult{
+			Document: vectordb.Document{ID: fmt.Sprintf("a_%d", pos), FileID: "a", Position: pos, Text: reader.segments[pos].Text},
+			Score:    score,
+		}
+	}
+
+	s := NewQAService(nil, nil, nil, nil, nil, WithParentExpansion(reader, 1))
+	expanded := s.contextResults([]vectordb.SearchResult{hit(2, 0.9), hit(6, 0.8), hit(4, 0.7)})
+	require.Len(t, expanded, 2)
+
+	// 2和4的扩展范围相接，合并后不超出章节边界，重叠的文本只保留一份
+	assert.Equal(t, "a_2", expanded[0].Document.ID)
+	assert.Equal(t, float32(0.9), expanded[0].Score)
+	assert.Equal(t, "下载安装包。\n解压安装包后运行install.sh时需要root权限。\n安装完成后启动服务。", expanded[0].Document.Text)
+	from, to, ok := document.MergedRangeFromMetadata(expanded[0].Document.Metadata)
+	require.True(t, ok)
+	assert.Equal(t, []int{1, 4}, []int{from, to})
+
+	// 不属于章节的段落按位置扩展到同样不属于章节的段落
+	assert.Equal(t, "附录。\n联系方式。", expanded[1].Document.Text)
+	from, to, _ = document.MergedRangeFromMetadata(expanded[1].Document.Metadata)
+	assert.Equal(t, []int{6, 7}, []int{from, to})
+
+	// 章节只有一个段落时保持原样
+	single := s.contextResults([]vectordb.SearchResult{hit(5, 0.9)})
+	assert.Equal(t, []vectordb.SearchResult{hit(5, 0.9)}, single)
+
+	// 读取失败或未启用时保留原结果
+	reader.err = fmt.Errorf("database is locked")
+	assert.Equal(t, []vectordb.SearchResult{hit(2, 0.9)}, s.contextResults([]vectordb.SearchResult{hit(2, 0.9)}))
+	plain := NewQAService(nil, nil, nil, nil, nil)
+	assert.Equal(t, []vectordb.SearchResult{hit(2, 0.9)}, plain.contextResults([]vectordb.SearchResult{hit(2, 0.9)}))
+}
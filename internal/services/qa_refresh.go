@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/cache"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// docsChangedKey 缓存中记录文档最近一次变更时间的键，使用共享缓存时多个实例都能看到
+const docsChangedKey = "qa_docs_changed_at"
+
+// answerGenerationKey 缓存中记录回答缓存代数的键，代数加入所有回答缓存键，更换后之前缓存的回答不再命中
+const answerGenerationKey = "qa_answer_generation"
+
+// revalidateTimeout 后台刷新一个缓存回答的最长时间
+const revalidateTimeout = 2 * time.Minute
+
+// WithStaleWhileRevalidate 启用缓存回答的后台刷新
+// 命中的缓存回答存在超过refreshAfter，或缓存之后文档有变更时，仍立即返回缓存的回答，
+// 同时在后台重新生成并写入缓存，热门问题不会因缓存过期而突然变慢。refreshAfter为0时不刷新
+func WithStaleWhileRevalidate(refreshAfter time.Duration) QAOption {
+	return func(s *QAService) {
+		s.refreshAfter = refreshAfter
+	}
+}
+
+// MarkDocumentsChanged 记录文档发生了变更，之前缓存的回答在下次命中时会在后台刷新
+func (s *QAService) MarkDocumentsChanged(ctx context.Context) {
+	s.cache.Set(docsChangedKey, strconv.FormatInt(time.Now().UnixNano(), 10), s.cacheTTL)
+}
+
+// InvalidateAnswers 使之前缓存的所有回答失效
+// 只更换回答缓存键的代数而不清空缓存：缓存可能与任务队列共用同一个Redis库，清空会丢失排队中的任务。
+// 旧代数的缓存项到期后自然淘汰
+func InvalidateAnswers(c cache.Cache) error {
+	if c == nil {
+		return nil
+	}
+	return c.Set(answerGenerationKey, strconv.FormatInt(time.Now().UnixNano(), 36), 0)
+}
+
+// answerGeneration 返回当前的回答缓存代数，从未失效过时为空
+func (s *QAService) answerGeneration() string {
+	if s.cache == nil {
+		return ""
+	}
+	value, found, err := s.cache.Get(answerGenerationKey)
+	if err != nil || !found {
+		return ""
+	}
+	return value
+}
+
+// cacheAnswer 缓存回答并记录缓存时间
+func (s *QAService) cacheAnswer(cacheKey, answer string) {
+	s.cache.Set(cacheKey, answer, s.cacheTTL)
+	s.cache.Set(cachedAtCacheKey(cacheKey), strconv.FormatInt(time.Now().UnixNano(), 10), s.cacheTTL)
+}
+
+// cacheSources 缓存回答引用的文档列表
+func (s *QAService) cacheSources(docsCacheKey string, sources []vectordb.Document) {
+	if docsJson, err := json.Marshal(sources); err == nil {
+		s.cache.Set(docsCacheKey, string(docsJson), s.cacheTTL)
+	}
+}
+
+// cachedSources 读取缓存的回答引用的文档列表，未命中或解析失败时返回空列表
+func (s *QAService) cachedSources(ctx context.Context, docsCacheKey string) []vectordb.Document {
+	docsJson, found, err := s.cache.Get(docsCacheKey)
+	if err != nil || !found {
+		return nil
+	}
+	var sources []vectordb.Document
+	if err := json.Unmarshal([]byte(docsJson), &sources); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to unmarshal cached documents")
+		return nil
+	}
+	return sources
+}
+
+// cachedAnswer 读取缓存的回答，上下文要求重新生成时视为未命中
+// 命中时记录缓存时长，缓存的回答需要刷新时调用refresh在后台重新生成
+func (s *QAService) cachedAnswer(ctx context.Context, cacheKey string, refresh func(ctx context.Context) error) (string, bool, error) {
+	if fresh, _ := ctx.Value(freshAnswerKey{}).(bool); fresh {
+		return "", false, nil
+	}
+	answer, found, err := s.cache.Get(cacheKey)
+	if err != nil || !found {
+		return answer, found, err
+	}
+
+	cachedAt, known := s.cachedTime(cachedAtCacheKey(cacheKey))
+	info := answerInfoFromContext(ctx)
+	if known && info != nil {
+		age := time.Since(cachedAt)
+		info.CacheAge = &age
+	}
+	if s.needsRefresh(cachedAt, known) {
+		s.revalidate(ctx, cacheKey, refresh)
+		if info != nil {
+			info.Refreshing = true
+		}
+	}
+	return answer, true, nil
+}
+
+// needsRefresh 判断缓存的回答是否需要后台刷新
+// 缓存时间未知的回答写入于启用刷新之前，同样刷新一次
+func (s *QAService) needsRefresh(cachedAt time.Time, known bool) bool {
+	if s.refreshAfter <= 0 {
+		return false
+	}
+	if !known || time.Since(cachedAt) >= s.refreshAfter {
+		return true
+	}
+	changedAt, changed := s.cachedTime(docsChangedKey)
+	return changed && cachedAt.Before(changedAt)
+}
+
+// revalidate 在后台重新生成缓存的回答，同一个缓存键同时只刷新一次
+// 刷新失败时保留原来的缓存回答，下次命中时再次刷新
+func (s *QAService) revalidate(ctx context.Context, cacheKey string, refresh func(ctx context.Context) error) {
+	if _, running := s.refreshing.LoadOrStore(cacheKey, struct{}{}); running {
+		return
+	}
+
+	// 刷新不随请求结束而取消，也不占用请求的生成预算和附加信息
+	ctx = context.WithoutCancel(ctx)
+	ctx = context.WithValue(ctx, answerInfoKey{}, (*AnswerInfo)(nil))
+	ctx = llm.WithRequestBudget(ctx, nil)
+	ctx, cancel := context.WithTimeout(WithFreshAnswer(ctx), revalidateTimeout)
+	go func() {
+		defer cancel()
+		defer s.refreshing.Delete(cacheKey)
+		_ = refresh(ctx)
+	}()
+}
+
+// cachedTime 读取缓存中记录的时间
+func (s *QAService) cachedTime(key string) (time.Time, bool) {
+	value, found, err := s.cache.Get(key)
+	if err != nil || !found {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// cachedAtCacheKey 回答缓存时间的缓存键
+func cachedAtCacheKey(cacheKey string) string {
+	return cacheKey + ":cached_at"
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStaleWhileRevalidate 测试缓存的回答过旧或文档变更后先返回缓存的回答，再在后台刷新
+func TestStaleWhileRevalidate(t *testing.T) {
+	service, cleanup := setupQATestEnv(t)
+	defer cleanup()
+	WithStaleWhileRevalidate(time.Hour)(service)
+
+	question := "什么是向量数据库？"
+	cacheKey := service.retrievalSettings(context.Background()).cacheKey("qa", question)
+	ask := func() *AnswerInfo {
+		ctx, info := WithAnswerInfo(context.Background())
+		answer, _, err := service.Answer(ctx, question)
+		require.NoError(t, err)
+		assert.Equal(t, "这是测试回答", answer)
+		return info
+	}
+	// waitRefreshed 等待后台刷新结束并返回新的缓存时间
+	waitRefreshed := func(before time.Time) time.Time {
+		var cachedAt time.Time
+		require.Eventually(t, func() bool {
+			var known bool
+			cachedAt, known = service.cachedTime(cachedAtCacheKey(cacheKey))
+			_, running := service.refreshing.Load(cacheKey)
+			return known && cachedAt.After(before) && !running
+		}, 5*time.Second, 10*time.Millisecond)
+		return cachedAt
+	}
+
+	// 新生成的回答不返回缓存时长
+	info := ask()
+	assert.Nil(t, info.CacheAge)
+	assert.False(t, info.Refreshing)
+
+	// 缓存时间不久的回答直接返回，不刷新
+	info = ask()
+	require.NotNil(t, info.CacheAge)
+	assert.Less(t, *info.CacheAge, time.Minute)
+	assert.False(t, info.Refreshing)
+
+	// 缓存的回答超过刷新时间后仍立即返回，同时在后台刷新
+	stale := time.Now().Add(-2 * time.Hour)
+	service.cache.Set(cachedAtCacheKey(cacheKey), strconv.FormatInt(stale.UnixNano(), 10), time.Hour)
+	info = ask()
+	require.NotNil(t, info.CacheAge)
+	assert.GreaterOrEqual(t, *info.CacheAge, 2*time.Hour)
+	assert.True(t, info.Refreshing)
+	cachedAt := waitRefreshed(stale)
+
+	// 文档变更后缓存的回答同样在后台刷新
+	service.MarkDocumentsChanged(context.Background())
+	info = ask()
+	assert.True(t, info.Refreshing)
+	waitRefreshed(cachedAt)
+
+	info = ask()
+	assert.False(t, info.Refreshing)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// Retrieve 按当前的检索配置检索与问题相关的前k个段落，不调用大模型生成回答
+// 用于检索效果评估，k不大于0时使用配置的检索数量
+func (s *QAService) Retrieve(ctx context.Context, question string, k int) ([]vectordb.SearchResult, error) {
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if k <= 0 {
+		k = s.searchLimit
+	}
+	ctx = s.withBudget(ctx)
+
+	return s.search(ctx, question, vectordb.SearchFilter{
+		MinScore:   s.baseMinScore(ctx),
+		MaxResults: k,
+	})
+}
+
+// search 将问题转换为向量后检索，检索范围（文件、元数据）由filter指定
+func (s *QAService) search(ctx context.Context, question string, filter vectordb.SearchFilter) ([]vectordb.SearchResult, error) {
+	vector, err := s.embedder.Embed(ctx, question)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	results, err := s.retrieve(ctx, question, vector, filter)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	return results, nil
+}
+
+// retrieve 检索相关段落并应用检索抑制
+// 启用问题改写时同时使用改写问题检索并按RRF融合；
+// 启用MMR时先取更大的候选池，再从中选出相关且互不重复的段落；
+// 启用时间和来源加权时同样先取更大的候选池，加权后再截取；
+// 上下文中带有单次请求的检索参数时按其选择策略，并排除请求中指定的文件和标签；
+// 标记为不检索的文档在所有问答中都被排除
+func (s *QAService) retrieve(ctx context.Context, question string, vector []float32, filter vectordb.SearchFilter) ([]vectordb.SearchResult, error) {
+	ctx, lang := s.withQuestionLanguage(ctx, question)
+	rs := s.retrievalSettings(ctx)
+	filter, ok, err := s.applyExclusions(ctx, rs, filter)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	limit := filter.MaxResults
+	if rs.mmr && limit > 0 {
+		pool := rs.candidates
+		if pool <= limit {
+			pool = limit * defaultMMRPoolFactor
+		}
+		filter.MaxResults = pool
+	} else if s.boost != nil && limit > 0 {
+		filter.MaxResults = limit * defaultMMRPoolFactor
+	}
+
+	var results []vectordb.SearchResult
+	if rs.rewrite > 0 {
+		results, err = s.searchRewrites(ctx, question, vector, filter, rs.rewrite)
+	} else {
+		results, err = s.vectorDB.Search(vector, filter)
+		if err == nil {
+			results = s.suppress(ctx, vector, results)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	results = s.boostResults(s.filterByLanguage(lang, results))
+
+	if rs.mmr && limit > 0 {
+		results = mmrSelect(results, limit, rs.lambda)
+	} else if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	// 只为最终选出的段落读取文本
+	return s.loadText(results)
+}
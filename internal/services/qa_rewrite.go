@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+const (
+	// defaultRewriteCount 默认生成的改写问题数量
+	defaultRewriteCount = 3
+	// maxRewriteCount 改写问题数量上限
+	maxRewriteCount = 5
+	// rrfK RRF融合的平滑常数，取常用的60
+	rrfK = 60
+)
+
+// rewritePrompt 改写问题的提示词模板
+const rewritePrompt = `请将下面的用户问题改写成%d个不同的检索问题，用于在文档库中检索相关内容。
+要求：
+1. 保持原问题的含义，可以补充同义词、展开缩写或换一种表达方式
+2. 每行一个问题，不要编号，不要输出其他内容
+
+用户问题：%s`
+
+// WithQueryRewrite 启用检索前的问题改写
+// 检索前让大模型生成count个改写问题，与原问题分别检索后按RRF融合结果，
+// count不在1~5之间时使用默认值3
+func WithQueryRewrite(count int) QAOption {
+	return func(s *QAService) {
+		if count < 1 || count > maxRewriteCount {
+			count = defaultRewriteCount
+		}
+		s.rewriteCount = count
+	}
+}
+
+// rewriteQueries 让大模型生成count个改写问题
+// 改写只用于提高召回，失败时返回空列表，只使用原问题检索
+func (s *QAService) rewriteQueries(ctx context.Context, question string, count int) []string {
+	if count <= 0 || s.llm == nil || strings.TrimSpace(question) == "" {
+		return nil
+	}
+
+	response, err := s.llm.Generate(ctx, fmt.Sprintf(rewritePrompt, count, question),
+		llm.WithGenerateMaxTokens(300),
+		llm.WithGenerateTemperature(0.7))
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to rewrite question")
+		return nil
+	}
+	return parseRewrites(response.Text, question, count)
+}
+
+// parseRewrites 解析大模型返回的改写问题
+// 去掉编号和列表符号，跳过与原问题相同或重复的行，最多保留limit个
+func parseRewrites(text, question string, limit int) []string {
+	seen := map[string]bool{strings.TrimSpace(question): true}
+	var queries []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "0123456789.、)）-*•· ")
+		line = strings.TrimSpace(line)
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		queries = append(queries, line)
+		if len(queries) >= limit {
+			break
+		}
+	}
+	return queries
+}
+
+// searchRewrites 使用原问题和改写问题分别检索，并按RRF融合
+// 改写问题的向量化或检索失败时退回只使用原问题的检索结果
+func (s *QAService) searchRewrites(ctx context.Context, question string, vector []float32, filter vectordb.SearchFilter, count int) ([]vectordb.SearchResult, error) {
+	vectors := [][]float32{vector}
+	if queries := s.rewriteQueries(ctx, question, count); len(queries) > 0 {
+		rewritten, err := s.embedder.EmbedBatch(ctx, queries)
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Warn("Failed to embed rewritten questions")
+		} else {
+			vectors = append(vectors, rewritten...)
+		}
+	}
+	if len(vectors) == 1 {
+		return s.vectorDB.Search(vector, filter)
+	}
+
+	lists, err := s.vectorDB.SearchBatch(vectors, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		lists[i] = s.suppress(ctx, vectors[i], lists[i])
+	}
+	return rrfMerge(lists), nil
+}
+
+// rrfMerge 按倒数排名融合（Reciprocal Rank Fusion）合并多组检索结果
+// 排序使用各组排名的倒数之和，结果分数保留段落在各组中的最高相似度，
+// 以便后续仍可按最低相似度过滤
+func rrfMerge(lists [][]vectordb.SearchResult) []vectordb.SearchResult {
+	type fused struct {
+		result vectordb.SearchResult
+		rank   float64
+	}
+	byID := make(map[string]*fused)
+	var order []*fused
+	for _, list := range lists {
+		for rank, result := range list {
+			f, ok := byID[result.Document.ID]
+			if !ok {
+				f = &fused{result: result}
+				byID[result.Document.ID] = f
+				order = append(order, f)
+			} else if result.Score > f.result.Score {
+				f.result.Score = result.Score
+			}
+			f.rank += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].rank > order[j].rank
+	})
+	merged := make([]vectordb.SearchResult, len(order))
+	for i, f := range order {
+		merged[i] = f.result
+	}
+	return merged
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryRewriteRetrieve 测试改写问题能召回原问题检索不到的段落
+func TestQueryRewriteRetrieve(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "install_0", FileID: "install", Text: "安装步骤", Vector: []float32{1, 0, 0, 0}},
+		{ID: "deploy_0", FileID: "deploy", Text: "部署步骤", Vector: []float32{0, 1, 0, 0}},
+		{ID: "other_0", FileID: "other", Text: "无关内容", Vector: []float32{0, 0, 0, 1}},
+	}))
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		&llm.Response{Text: "1. 如何部署系统\n2. 如何安装\n如何部署系统\n"}, nil,
+	).Once()
+	embedder := embedding.NewMockClient(t)
+	embedder.On("EmbedBatch", mock.Anything, []string{"如何部署系统"}).Return(
+		[][]float32{{0, 1, 0, 0}}, nil,
+	).Once()
+
+	query := []float32{1, 0, 0, 0}
+	filter := vectordb.SearchFilter{MinScore: 0.5, MaxResults: 3}
+
+	plain := NewQAService(embedder, vectorDB, llmClient, nil, nil)
+	results, err := plain.retrieve(context.Background(), "如何安装", query, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "install_0", results[0].Document.ID)
+
+	rewriting := NewQAService(embedder, vectorDB, llmClient, nil, nil, WithQueryRewrite(2))
+	results, err = rewriting.retrieve(context.Background(), "如何安装", query, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "install_0", results[0].Document.ID)
+	assert.Equal(t, "deploy_0", results[1].Document.ID)
+	assert.InDelta(t, 1.0, results[1].Score, 1e-5)
+}
+
+// TestQueryRewriteFallback 测试改写失败时退回原问题检索
+func TestQueryRewriteFallback(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.Add(vectordb.Document{
+		ID: "install_0", FileID: "install", Text: "安装步骤", Vector: []float32{1, 0, 0, 0},
+	}))
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.On("Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, errors.New("llm unavailable"),
+	).Once()
+
+	service := NewQAService(nil, vectorDB, llmClient, nil, nil, WithQueryRewrite(3))
+	results, err := service.retrieve(context.Background(), "如何安装", []float32{1, 0, 0, 0},
+		vectordb.SearchFilter{MinScore: 0.5, MaxResults: 3})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "install_0", results[0].Document.ID)
+}
+
+// TestParseRewrites 测试改写结果解析
+func TestParseRewrites(t *testing.T) {
+	text := "1. 如何安装系统\n- 安装教程\n\n2、如何安装\n安装教程\n* 系统安装要求\n额外的一行"
+	assert.Equal(t, []string{"如何安装系统", "安装教程", "系统安装要求"}, parseRewrites(text, "如何安装", 3))
+	assert.Empty(t, parseRewrites("", "如何安装", 3))
+}
+
+// TestRRFMerge 测试RRF融合排序
+func TestRRFMerge(t *testing.T) {
+	result := func(id string, score float32) vectordb.SearchResult {
+		return vectordb.SearchResult{Document: vectordb.Document{ID: id}, Score: score}
+	}
+	merged := rrfMerge([][]vectordb.SearchResult{
+		{result("a", 0.9), result("b", 0.8), result("c", 0.7)},
+		{result("c", 0.95), result("b", 0.6), result("d", 0.5)},
+	})
+
+	var ids []string
+	for _, r := range merged {
+		ids = append(ids, r.Document.ID)
+	}
+	// b和c都出现两次，c的排名之和更好；a只出现在第一组的首位
+	assert.Equal(t, []string{"c", "b", "a", "d"}, ids)
+	assert.InDelta(t, 0.95, merged[0].Score, 1e-6)
+	assert.InDelta(t, 0.8, merged[1].Score, 1e-6)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/document"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// scopedCitationHint 多文件范围问答时附加在问题后的提示，要求回答注明出处
+const scopedCitationHint = "\n\n（参考资料来自同一组中的多个文件，每段资料开头的【】内是所属文件（有时还有页码和章节）。回答时请指明信息出自哪个文件；如果不同文件的内容有冲突或修改关系，请说明是哪个文件做了修改。）"
+
+// ScopedFile 问答范围内的文件
+type ScopedFile struct {
+	FileID string // 文档ID
+	Label  string // 文件标签，用于在上下文中标注出处，为空时使用文件名
+}
+
+// AnswerWithFiles 在多个文件组成的范围内回答问题
+// 检索只在给定文件中进行，每段上下文都会标注所属文件，使回答能引用具体的成员文件
+func (s *QAService) AnswerWithFiles(ctx context.Context, question string, files []ScopedFile) (_ string, _ []vectordb.Document, err error) {
+	ctx = s.withBudget(ctx)
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	defer release()
+
+	ctx, _ = s.AssignPromptVariant(ctx)
+	ctx, done := s.observe(ctx, question)
+	defer func() { done(err) }()
+
+	if question == "" {
+		return "", nil, fmt.Errorf("question cannot be empty")
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no files in scope")
+	}
+
+	// 问候、闲聊和命令不走检索增强生成，只有一个文件时命令默认作用于该文件
+	target := ""
+	if len(files) == 1 {
+		target = files[0].FileID
+	}
+	if answer, sources, handled, err := s.route(ctx, question, target); handled {
+		return answer, sources, err
+	}
+
+	fileIDs := make([]string, 0, len(files))
+	labels := make(map[string]string, len(files))
+	for _, f := range files {
+		fileIDs = append(fileIDs, f.FileID)
+		labels[f.FileID] = f.Label
+	}
+
+	// 缓存键与文件顺序无关
+	sortedIDs := append([]string(nil), fileIDs...)
+	sort.Strings(sortedIDs)
+	scopeKey := strings.Join(sortedIDs, ",")
+
+	rs := s.retrievalSettings(ctx)
+	cacheKey := rs.cacheKey("qa_files", scopeKey, question)
+	docsCacheKey := rs.cacheKey("qa_files_docs", scopeKey, question)
+	cachedAnswer, found, err := s.cachedAnswer(ctx, cacheKey, func(ctx context.Context) error {
+		_, _, err := s.AnswerWithFiles(ctx, question, files)
+		return err
+	})
+	if err == nil && found {
+		markCacheHit(ctx)
+		s.cachedConfidence(ctx, cacheKey)
+		return cachedAnswer, s.cachedSources(ctx, docsCacheKey), nil
+	}
+
+	// 只在范围内的文件中检索
+	results, err := s.search(ctx, question, vectordb.SearchFilter{
+		FileIDs:    fileIDs,
+		MinScore:   rs.minScore,
+		MaxResults: rs.limit,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	var contexts []string
+	var sources []vectordb.Document
+	for _, result := range s.contextResults(results) {
+		if result.Score < rs.minScore {
+			continue
+		}
+		label := labels[result.Document.FileID]
+		if label == "" {
+			label = result.Document.FileName
+		}
+		page, section, _ := document.StructureFromMetadata(result.Document.Metadata)
+		if location := document.FormatLocation(page, section); location != "" {
+			label += "，" + location
+		}
+		contexts = append(contexts, fmt.Sprintf("【%s】\n%s", label, result.Document.Text))
+		sources = append(sources, result.Document)
+	}
+
+	// 范围内没有相关内容时不回退到通用知识，避免给出与文档无关的答案
+	if len(contexts) == 0 {
+		noContextAnswer := "抱歉，在指定的文件中没有找到能回答您问题的相关信息。"
+		s.cacheAnswer(cacheKey, noContextAnswer)
+		return noContextAnswer, nil, nil
+	}
+
+	// 只有一个文件时无需额外提示出处
+	ragQuestion := question
+	if len(files) > 1 {
+		ragQuestion += scopedCitationHint
+	}
+
+	ragResponse, err := s.rag.Answer(withContextIDs(ctx, sources), ragQuestion, contexts)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate answer: %w", err)
+	}
+	sources = citeSources(sources, contexts, ragResponse)
+	answer, sources := s.ground(ctx, cacheKey, question, ragResponse.Answer, contexts, sources)
+
+	// 缓存结果
+	s.cacheAnswer(cacheKey, answer)
+	s.cacheSources(docsCacheKey, sources)
+
+	return answer, sources, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/events"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+)
+
+// qaObservation 单次问答的观测结果，命中回答缓存时由问答流程标记
+type qaObservation struct {
+	cacheHit bool
+}
+
+// qaObservationKey 上下文中保存问答观测结果的键
+type qaObservationKey struct{}
+
+// WithStatsRecorder 设置运维统计服务，记录每次问答的耗时、缓存命中和失败情况
+func WithStatsRecorder(stats *StatsService) QAOption {
+	return func(s *QAService) {
+		s.stats = stats
+	}
+}
+
+// WithQAEvents 设置事件总线，每次问答结束时发布question.answered事件
+func WithQAEvents(bus *events.Bus) QAOption {
+	return func(s *QAService) {
+		s.events = bus
+	}
+}
+
+// observe 开始观测一次问答，返回的函数在问答结束时记录统计并发布事件
+// 分配了提示词变体时同时按变体记录耗时和失败情况
+func (s *QAService) observe(ctx context.Context, question string) (context.Context, func(err error)) {
+	if s.stats == nil && s.events == nil {
+		return ctx, func(error) {}
+	}
+
+	obs := &qaObservation{}
+	start := time.Now()
+	return context.WithValue(ctx, qaObservationKey{}, obs), func(err error) {
+		latency := time.Since(start)
+		variant, _ := llm.PromptVariantFromContext(ctx)
+		if s.stats != nil {
+			s.stats.RecordQA(ctx, latency, obs.cacheHit, err != nil)
+			if variant.Name != "" {
+				s.stats.RecordPromptVariant(ctx, variant.Name, latency, err != nil)
+			}
+		}
+
+		data := map[string]interface{}{
+			"question":   question,
+			"cache_hit":  obs.cacheHit,
+			"latency_ms": latency.Milliseconds(),
+		}
+		if variant.Name != "" {
+			data["prompt_variant"] = variant.Name
+		}
+		if err != nil {
+			data["error"] = err.Error()
+		}
+		s.events.Publish(ctx, events.TypeQuestionAnswered, "", data)
+	}
+}
+
+// markCacheHit 标记本次问答命中了回答缓存
+func markCacheHit(ctx context.Context) {
+	if obs, ok := ctx.Value(qaObservationKey{}).(*qaObservation); ok {
+		obs.cacheHit = true
+	}
+}
@@ -121,7 +121,7 @@ func TestQAServiceCacheOperations(t *testing.T) {
 	t.Logf("First query took %v, second (cached) query took %v", firstQueryTime, secondQueryTime)
 
 	// 清除缓存
-	err = qaService.ClearCache()
+	err = qaService.ClearCache(ctx)
 	require.NoError(t, err)
 
 	// 清除后的查询应该不命中缓存
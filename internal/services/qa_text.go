@@ -0,0 +1,141 @@
+package services
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// DefaultSegmentTextCacheSize 热点段落文本缓存的默认条目数
+const DefaultSegmentTextCacheSize = 10000
+
+// SegmentReader 按段落ID批量读取段落，repository.DocumentRepository满足该接口
+type SegmentReader interface {
+	GetSegmentsByID(segmentIDs []string) ([]*models.DocumentSegment, error)
+}
+
+// WithSegmentText 检索结果不带文本时（向量数据库不保存文本或由Python服务写入）按段落ID从段落表读取
+// cacheSize为热点段落文本的LRU缓存条目数，不大于0时每次都读取段落表
+func WithSegmentText(reader SegmentReader, cacheSize int) QAOption {
+	return func(s *QAService) {
+		s.segments = reader
+		s.segmentText = newSegmentTextCache(cacheSize)
+	}
+}
+
+// loadText 为没有文本的检索结果补全段落文本
+// 段落表中已不存在的段落（文档删除后向量尚未清理）从结果中去掉
+func (s *QAService) loadText(results []vectordb.SearchResult) ([]vectordb.SearchResult, error) {
+	if s.segments == nil {
+		return results, nil
+	}
+
+	var missing []string
+	for i := range results {
+		doc := &results[i].Document
+		if doc.Text != "" {
+			continue
+		}
+		if text, ok := s.segmentText.get(segmentTextKey(*doc)); ok {
+			doc.Text = text
+			continue
+		}
+		missing = append(missing, doc.ID)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	segments, err := s.segments.GetSegmentsByID(missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load segment text: %w", err)
+	}
+	texts := make(map[string]*models.DocumentSegment, len(segments))
+	for _, seg := range segments {
+		texts[seg.SegmentID] = seg
+	}
+
+	loaded := results[:0]
+	for _, result := range results {
+		doc := &result.Document
+		if doc.Text == "" {
+			seg, ok := texts[doc.ID]
+			if !ok || seg.Text == "" {
+				continue
+			}
+			doc.Text = seg.Text
+			s.segmentText.set(segmentTextKey(*doc), seg.Text)
+		}
+		loaded = append(loaded, result)
+	}
+	return loaded, nil
+}
+
+// segmentTextKey 段落文本的缓存键
+// 向量数据库记录了文本哈希时加入缓存键，段落文本修改后重新写入的向量带有新的哈希，旧缓存自然失效
+func segmentTextKey(doc vectordb.Document) string {
+	if hash, ok := doc.Metadata[vectordb.MetaTextHash].(string); ok && hash != "" {
+		return doc.ID + "@" + hash
+	}
+	return doc.ID
+}
+
+// segmentTextCache 并发安全的段落文本LRU缓存
+type segmentTextCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List               // 按最近使用排序，表头为最近使用
+	entries    map[string]*list.Element // 缓存键到链表节点的映射
+}
+
+// segmentTextEntry 段落文本缓存条目
+type segmentTextEntry struct {
+	key  string
+	text string
+}
+
+// newSegmentTextCache 创建段落文本缓存，maxEntries不大于0时不缓存
+func newSegmentTextCache(maxEntries int) *segmentTextCache {
+	return &segmentTextCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// get 查询缓存，命中时将条目移到表头
+func (c *segmentTextCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*segmentTextEntry).text, true
+}
+
+// set 写入缓存，超出容量时淘汰最久未使用的条目
+func (c *segmentTextCache) set(key, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries <= 0 {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*segmentTextEntry).text = text
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.ll.PushFront(&segmentTextEntry{key: key, text: text})
+	for c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.entries, oldest.Value.(*segmentTextEntry).key)
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSegmentReader 记录读取次数的段落表
+type fakeSegmentReader struct {
+	segments map[string]string
+	calls    int
+}
+
+func (r *fakeSegmentReader) GetSegmentsByID(ids []string) ([]*models.DocumentSegment, error) {
+	r.calls++
+	var result []*models.DocumentSegment
+	for _, id := range ids {
+		if text, ok := r.segments[id]; ok {
+			result = append(result, &models.DocumentSegment{SegmentID: id, Text: text})
+		}
+	}
+	return result, nil
+}
+
+// TestLoadSegmentText 测试向量数据库不保存文本时检索结果从段落表补全文本
+func TestLoadSegmentText(t *testing.T) {
+	memory, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	vectorDB := vectordb.WithoutText(memory)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "install_0", FileID: "install", Text: "安装步骤", Vector: []float32{1, 0, 0, 0}},
+		{ID: "install_1", FileID: "install", Text: "安装要求", Vector: []float32{1, 0.1, 0, 0}},
+		{ID: "deleted_0", FileID: "deleted", Text: "已删除", Vector: []float32{1, 0.2, 0, 0}},
+	}))
+
+	// 向量数据库中只保留文本哈希
+	stored, err := memory.Get("install_0")
+	require.NoError(t, err)
+	assert.Empty(t, stored.Text)
+	assert.NotEmpty(t, stored.Metadata[vectordb.MetaTextHash])
+
+	reader := &fakeSegmentReader{segments: map[string]string{"install_0": "安装步骤", "install_1": "安装要求"}}
+	service := NewQAService(nil, vectorDB, nil, nil, nil, WithSegmentText(reader, 10))
+	filter := vectordb.SearchFilter{MinScore: 0.5, MaxResults: 3}
+
+	results, err := service.retrieve(context.Background(), "如何安装", []float32{1, 0, 0, 0}, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "段落表中不存在的段落应被去掉")
+	assert.Equal(t, "安装步骤", results[0].Document.Text)
+	assert.Equal(t, "安装要求", results[1].Document.Text)
+	assert.Equal(t, 1, reader.calls)
+
+	// 热点段落从缓存读取，只有缓存中没有的段落查询段落表
+	_, err = service.retrieve(context.Background(), "如何安装", []float32{1, 0, 0, 0}, filter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reader.calls)
+	filter.MaxResults = 2
+	results, err = service.retrieve(context.Background(), "如何安装", []float32{1, 0, 0, 0}, filter)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 2, reader.calls)
+
+	// 修改段落后重新写入的向量带有新的文本哈希，不会读到旧缓存
+	reader.segments["install_0"] = "新的安装步骤"
+	require.NoError(t, vectorDB.Add(vectordb.Document{ID: "install_0", FileID: "install", Text: "新的安装步骤", Vector: []float32{1, 0, 0, 0}}))
+	// 内存向量数据库的查询缓存不随写入失效，换一个查询向量
+	results, err = service.retrieve(context.Background(), "如何安装", []float32{1, 0, 0, 0.01}, filter)
+	require.NoError(t, err)
+	assert.Equal(t, "新的安装步骤", results[0].Document.Text)
+	assert.Equal(t, 3, reader.calls)
+}
+
+// TestSegmentTextCacheEviction 测试段落文本缓存按最近使用淘汰
+func TestSegmentTextCacheEviction(t *testing.T) {
+	cache := newSegmentTextCache(2)
+	cache.set("a", "A")
+	cache.set("b", "B")
+	_, ok := cache.get("a")
+	require.True(t, ok)
+	cache.set("c", "C")
+
+	_, ok = cache.get("b")
+	assert.False(t, ok, "最久未使用的条目应被淘汰")
+	text, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "A", text)
+}
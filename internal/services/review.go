@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrDraftReviewed 草稿已经审核过，不能重复审核
+	ErrDraftReviewed = errors.New("answer draft already reviewed")
+
+	// ErrEmptyApprovedAnswer 审核通过的回答为空
+	ErrEmptyApprovedAnswer = errors.New("approved answer cannot be empty")
+)
+
+// AnswerFunc 生成回答的函数，通常包装问答服务的某个Answer方法
+type AnswerFunc func(ctx context.Context) (string, []vectordb.Document, error)
+
+// ReviewOutcome 经过审核流程后的问答结果
+type ReviewOutcome struct {
+	Answer  string              // 可以返回给提问者的回答，待审核时为空
+	Sources []vectordb.Document // 回答来源
+	Curated bool                // 是否来自人工审核过的FAQ
+	Draft   *models.AnswerDraft // 非空表示回答已保存为草稿，等待审核
+}
+
+// ReviewService 回答审核服务
+// 命中需审核话题的问题，生成的回答先保存为草稿进入审核队列，审核通过后发布给提问者，
+// 并收录为FAQ条目，之后同一问题直接返回审核过的回答
+type ReviewService struct {
+	repo      repository.ReviewRepository // 审核仓储
+	vectorDB  vectordb.Repository         // 向量数据库，用于读取来源段落
+	topics    []string                    // 需审核的话题关键词，为空表示所有问题都需审核
+	acl       *acl.Policy                 // 文档访问控制策略，可以读取的文档不同的用户分别审核
+	documents ReadableChecker             // 检查来源文档能否读取，为空时不检查
+	logger    *logrus.Logger              // 日志记录器
+}
+
+// ReadableChecker 检查用户能否读取文档，DocumentService满足该接口
+type ReadableChecker interface {
+	CheckReadable(ctx context.Context, fileID string) error
+}
+
+// ReviewScope 问答的检索范围
+// 限定文件或元数据的问题，回答只来自范围内的文档，与不限定范围的相同问题分别审核和收录FAQ
+type ReviewScope struct {
+	FileID   string                 // 限定的文件
+	Metadata map[string]interface{} // 元数据过滤条件
+}
+
+// key 生成检索范围的键片段，不限定范围时返回空字符串
+func (sc ReviewScope) key() string {
+	var parts []string
+	if sc.FileID != "" {
+		parts = append(parts, "f_"+sc.FileID)
+	}
+	names := make([]string, 0, len(sc.Metadata))
+	for name := range sc.Metadata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("m_%s=%v", name, sc.Metadata[name]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// ReviewOption 审核服务配置选项
+type ReviewOption func(*ReviewService)
+
+// NewReviewService 创建回答审核服务实例
+func NewReviewService(repo repository.ReviewRepository, vectorDB vectordb.Repository, opts ...ReviewOption) *ReviewService {
+	service := &ReviewService{
+		repo:     repo,
+		vectorDB: vectorDB,
+		logger:   logrus.New(),
+	}
+
+	for _, opt := range opts {
+		opt(service)
+	}
+
+	return service
+}
+
+// WithReviewLogger 设置日志记录器
+func WithReviewLogger(logger *logrus.Logger) ReviewOption {
+	return func(s *ReviewService) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithReviewTopics 设置需审核的话题关键词，问题包含任一关键词（不区分大小写）即需审核
+func WithReviewTopics(topics []string) ReviewOption {
+	return func(s *ReviewService) {
+		s.topics = s.topics[:0]
+		for _, topic := range topics {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				s.topics = append(s.topics, topic)
+			}
+		}
+	}
+}
+
+// WithReviewAccessPolicy 设置文档访问控制策略
+// 回答可能引用只有提问者可以读取的文档，草稿和FAQ按读者区分，不会返回给无权读取来源文档的用户
+func WithReviewAccessPolicy(policy *acl.Policy) ReviewOption {
+	return func(s *ReviewService) {
+		s.acl = policy
+	}
+}
+
+// WithReviewDocuments 设置来源文档的读取权限检查
+// 草稿审核期间或FAQ收录之后文档的共享设置可能变化，返回来源时跳过提问者已经无权读取的文档
+func WithReviewDocuments(documents ReadableChecker) ReviewOption {
+	return func(s *ReviewService) {
+		s.documents = documents
+	}
+}
+
+// MatchTopic 判断问题是否需要审核，返回命中的话题
+// 未配置话题时所有问题都需审核，此时话题为空
+func (s *ReviewService) MatchTopic(question string) (string, bool) {
+	if len(s.topics) == 0 {
+		return "", true
+	}
+	lower := strings.ToLower(question)
+	for _, topic := range s.topics {
+		if strings.Contains(lower, strings.ToLower(topic)) {
+			return topic, true
+		}
+	}
+	return "", false
+}
+
+// Gate 让问答经过审核流程
+// 已有FAQ条目的问题直接返回审核过的回答；命中审核话题的问题生成回答后保存为草稿，
+// 同一问题已有待审核草稿时不再重复生成；其余问题照常生成回答。
+// 问题按检索范围和读者区分，FAQ和草稿只在范围和读者都相同时复用
+func (s *ReviewService) Gate(ctx context.Context, question string, scope ReviewScope, generate AnswerFunc) (*ReviewOutcome, error) {
+	key := s.reviewKey(ctx, question, scope)
+	repo := s.repo.WithContext(ctx)
+
+	curated, err := repo.FindCurated(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find curated answer: %w", err)
+	}
+	if curated != nil {
+		return &ReviewOutcome{
+			Answer:  curated.Answer,
+			Sources: s.Sources(ctx, curated.SegmentIDs),
+			Curated: true,
+		}, nil
+	}
+
+	topic, flagged := s.MatchTopic(question)
+	if !flagged {
+		answer, sources, err := generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &ReviewOutcome{Answer: answer, Sources: sources}, nil
+	}
+
+	pending, err := repo.FindPendingDraft(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pending draft: %w", err)
+	}
+	if pending != nil {
+		return &ReviewOutcome{Draft: pending}, nil
+	}
+
+	answer, sources, err := generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	segmentIDs := make([]string, 0, len(sources))
+	for _, doc := range sources {
+		segmentIDs = append(segmentIDs, doc.ID)
+	}
+	draft := &models.AnswerDraft{
+		ID:          uuid.New().String(),
+		Question:    question,
+		QuestionKey: key,
+		Reader:      s.acl.CacheKey(ctx),
+		Topic:       topic,
+		Answer:      answer,
+		SegmentIDs:  segmentIDs,
+		Status:      models.DraftPending,
+	}
+	if err := repo.CreateDraft(draft); err != nil {
+		return nil, fmt.Errorf("failed to save answer draft: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"draft_id": draft.ID,
+		"topic":    topic,
+	}).Info("Answer saved as draft pending review")
+
+	return &ReviewOutcome{Draft: draft}, nil
+}
+
+// GetDraft 提问者获取自己的回答草稿，其他读者的草稿视为不存在
+func (s *ReviewService) GetDraft(ctx context.Context, id string) (*models.AnswerDraft, error) {
+	draft, err := s.repo.WithContext(ctx).GetDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Reader != s.acl.CacheKey(ctx) {
+		return nil, fmt.Errorf("answer draft not found: %s", id)
+	}
+	return draft, nil
+}
+
+// ListDrafts 分页列出回答草稿，status为空时返回全部
+func (s *ReviewService) ListDrafts(ctx context.Context, status models.DraftStatus, page, pageSize int) ([]*models.AnswerDraft, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.repo.WithContext(ctx).ListDrafts(status, (page-1)*pageSize, pageSize)
+}
+
+// Approve 审核通过草稿，发布回答并收录为FAQ条目
+// answer不为空时用它替换大模型生成的回答
+func (s *ReviewService) Approve(ctx context.Context, id, answer, reviewer string) (*models.AnswerDraft, error) {
+	repo := s.repo.WithContext(ctx)
+	draft, err := repo.GetDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Status != models.DraftPending {
+		return nil, ErrDraftReviewed
+	}
+
+	final := strings.TrimSpace(answer)
+	if final == "" {
+		final = draft.Answer
+	}
+	if final == "" {
+		return nil, ErrEmptyApprovedAnswer
+	}
+
+	draft.Status = models.DraftApproved
+	draft.FinalAnswer = final
+	draft.Reviewer = reviewer
+	ok, err := repo.ReviewDraft(draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve draft: %w", err)
+	}
+	if !ok {
+		return nil, ErrDraftReviewed
+	}
+
+	if err := repo.SaveCurated(&models.CuratedAnswer{
+		Question:    draft.Question,
+		QuestionKey: draft.QuestionKey,
+		Reader:      draft.Reader,
+		Topic:       draft.Topic,
+		Answer:      final,
+		SegmentIDs:  draft.SegmentIDs,
+		DraftID:     draft.ID,
+	}); err != nil {
+		return draft, fmt.Errorf("failed to save curated answer: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"draft_id": draft.ID,
+		"reviewer": reviewer,
+	}).Info("Answer draft approved")
+
+	return draft, nil
+}
+
+// Reject 驳回草稿，回答不会发布
+func (s *ReviewService) Reject(ctx context.Context, id, reviewer, note string) (*models.AnswerDraft, error) {
+	repo := s.repo.WithContext(ctx)
+	draft, err := repo.GetDraft(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Status != models.DraftPending {
+		return nil, ErrDraftReviewed
+	}
+
+	draft.Status = models.DraftRejected
+	draft.Reviewer = reviewer
+	draft.Note = note
+	ok, err := repo.ReviewDraft(draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject draft: %w", err)
+	}
+	if !ok {
+		return nil, ErrDraftReviewed
+	}
+
+	return draft, nil
+}
+
+// ListCurated 分页列出调用方可以看到的FAQ条目
+// 启用访问控制时只列出与调用方读者相同的条目，可以读取所有文档的调用方看到全部条目
+func (s *ReviewService) ListCurated(ctx context.Context, page, pageSize int) ([]*models.CuratedAnswer, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	return s.repo.WithContext(ctx).ListCurated(s.acl.CacheKey(ctx), (page-1)*pageSize, pageSize)
+}
+
+// DeleteCurated 删除FAQ条目，之后同一问题重新进入审核流程
+func (s *ReviewService) DeleteCurated(ctx context.Context, id uint) error {
+	return s.repo.WithContext(ctx).DeleteCurated(id)
+}
+
+// Sources 读取来源段落，已被删除的段落和调用方无权读取的文档中的段落会被跳过
+func (s *ReviewService) Sources(ctx context.Context, segmentIDs []string) []vectordb.Document {
+	sources := make([]vectordb.Document, 0, len(segmentIDs))
+	readable := make(map[string]bool)
+	for _, id := range segmentIDs {
+		doc, err := s.vectorDB.Get(id)
+		if err != nil {
+			continue
+		}
+		if s.documents != nil {
+			ok, checked := readable[doc.FileID]
+			if !checked {
+				ok = s.documents.CheckReadable(ctx, doc.FileID) == nil
+				readable[doc.FileID] = ok
+			}
+			if !ok {
+				continue
+			}
+		}
+		sources = append(sources, doc)
+	}
+	return sources
+}
+
+// questionKey 规范化问题，忽略大小写、空白和结尾的标点
+func questionKey(question string) string {
+	return truncateKey(normalizeQuestion(question), maxQuestionKeyLength)
+}
+
+// maxQuestionKeyLength 规范化问题的最大字符数，与数据库字段长度一致
+const maxQuestionKeyLength = 500
+
+// normalizeQuestion 合并空白、转为小写并去掉末尾的标点
+func normalizeQuestion(question string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(question), " "))
+	return strings.TrimRightFunc(key, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
+
+// truncateKey 截断到最多n个字符
+func truncateKey(key string, n int) string {
+	if len([]rune(key)) > n {
+		key = string([]rune(key)[:n])
+	}
+	return key
+}
+
+// reviewKey 生成审核使用的问题键
+// 不限定范围且读者可以读取所有文档时为规范化问题；否则追加范围和读者的哈希，总长度不超过数据库字段长度
+func (s *ReviewService) reviewKey(ctx context.Context, question string, scope ReviewScope) string {
+	var parts []string
+	if reader := s.acl.CacheKey(ctx); reader != "" {
+		parts = append(parts, reader)
+	}
+	if scoped := scope.key(); scoped != "" {
+		parts = append(parts, scoped)
+	}
+	if len(parts) == 0 {
+		return questionKey(question)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	suffix := "#" + hex.EncodeToString(sum[:8])
+	return truncateKey(normalizeQuestion(question), maxQuestionKeyLength-len(suffix)) + suffix
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/acl"
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestReviewWorkflow 测试命中审核话题的回答保存为草稿，审核通过后发布并收录为FAQ
+func TestReviewWorkflow(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_review_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AnswerDraft{}, &models.CuratedAnswer{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 3})
+	require.NoError(t, err)
+	refund := vectordb.Document{ID: "refund_0", FileID: "refund", FileName: "退款政策.md", Text: "15天内可退款", Vector: []float32{1, 0, 0}}
+	require.NoError(t, vectorDB.Add(refund))
+
+	service := NewReviewService(repository.NewReviewRepository(), vectorDB, WithReviewTopics([]string{"退款", " Price "}))
+	ctx := context.Background()
+
+	calls := 0
+	generate := func(answer string) AnswerFunc {
+		return func(ctx context.Context) (string, []vectordb.Document, error) {
+			calls++
+			return answer, []vectordb.Document{refund}, nil
+		}
+	}
+
+	// 未命中审核话题的问题照常返回
+	outcome, err := service.Gate(ctx, "如何上传文档", ReviewScope{}, generate("点击上传按钮"))
+	require.NoError(t, err)
+	assert.Nil(t, outcome.Draft)
+	assert.Equal(t, "点击上传按钮", outcome.Answer)
+
+	// 命中话题的回答保存为草稿，不返回给提问者
+	outcome, err = service.Gate(ctx, "如何申请退款？", ReviewScope{}, generate("7天内可退款"))
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Draft)
+	assert.Empty(t, outcome.Answer)
+	assert.Equal(t, "退款", outcome.Draft.Topic)
+	assert.Equal(t, []string{"refund_0"}, outcome.Draft.SegmentIDs)
+	draftID := outcome.Draft.ID
+
+	// 同一问题已有待审核草稿时不再重复生成
+	outcome, err = service.Gate(ctx, "如何申请退款", ReviewScope{}, generate("不应调用"))
+	require.NoError(t, err)
+	assert.Equal(t, draftID, outcome.Draft.ID)
+	assert.Equal(t, 2, calls)
+
+	drafts, total, err := service.ListDrafts(ctx, models.DraftPending, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "7天内可退款", drafts[0].Answer)
+
+	// 审核人修改回答后通过，之后同一问题直接返回FAQ
+	approved, err := service.Approve(ctx, draftID, "15天内可退款", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, models.DraftApproved, approved.Status)
+	assert.Equal(t, "15天内可退款", approved.FinalAnswer)
+	require.NotNil(t, approved.ReviewedAt)
+
+	_, err = service.Approve(ctx, draftID, "", "bob")
+	assert.ErrorIs(t, err, ErrDraftReviewed)
+
+	outcome, err = service.Gate(ctx, "如何申请退款!", ReviewScope{}, generate("不应调用"))
+	require.NoError(t, err)
+	assert.True(t, outcome.Curated)
+	assert.Equal(t, "15天内可退款", outcome.Answer)
+	require.Len(t, outcome.Sources, 1)
+	assert.Equal(t, "15天内可退款", outcome.Sources[0].Text)
+	assert.Equal(t, 2, calls)
+
+	entries, total, err := service.ListCurated(ctx, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, draftID, entries[0].DraftID)
+
+	// 驳回的草稿不会发布，关键词匹配不区分大小写
+	outcome, err = service.Gate(ctx, "What is the PRICE?", ReviewScope{}, generate("$10"))
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Draft)
+	assert.Equal(t, "Price", outcome.Draft.Topic)
+
+	rejected, err := service.Reject(ctx, outcome.Draft.ID, "alice", "价格以官网为准")
+	require.NoError(t, err)
+	assert.Equal(t, models.DraftRejected, rejected.Status)
+	assert.Empty(t, rejected.FinalAnswer)
+
+	// 删除FAQ后同一问题重新进入审核
+	require.NoError(t, service.DeleteCurated(ctx, entries[0].ID))
+	outcome, err = service.Gate(ctx, "如何申请退款", ReviewScope{}, generate("新的回答"))
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Draft)
+	assert.NotEqual(t, draftID, outcome.Draft.ID)
+}
+
+// TestReviewScope 测试FAQ和草稿按检索范围和读者区分，不会返回给范围或读者不同的提问
+func TestReviewScope(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_review_scope_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.AnswerDraft{}, &models.CuratedAnswer{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	service := NewReviewService(repository.NewReviewRepository(), nil,
+		WithReviewTopics([]string{"薪酬"}), WithReviewAccessPolicy(acl.NewPolicy()))
+	alice := usage.WithTenant(context.Background(), "alice")
+	bob := usage.WithTenant(context.Background(), "bob")
+
+	calls := 0
+	generate := func(ctx context.Context) (string, []vectordb.Document, error) {
+		calls++
+		return "按职级确定", nil, nil
+	}
+
+	outcome, err := service.Gate(alice, "薪酬标准是什么", ReviewScope{}, generate)
+	require.NoError(t, err)
+	require.NotNil(t, outcome.Draft)
+	draftID := outcome.Draft.ID
+
+	// 其他读者查不到该草稿
+	_, err = service.GetDraft(bob, draftID)
+	assert.Error(t, err)
+	draft, err := service.GetDraft(alice, draftID)
+	require.NoError(t, err)
+	assert.Equal(t, "u_alice", draft.Reader)
+
+	_, err = service.Approve(alice, draftID, "", "admin")
+	require.NoError(t, err)
+
+	// FAQ列表只包含调用方自己的条目
+	entries, total, err := service.ListCurated(bob, 1, 20)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, entries)
+	entries, total, err = service.ListCurated(alice, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, draftID, entries[0].DraftID)
+
+	outcome, err = service.Gate(alice, "薪酬标准是什么？", ReviewScope{}, generate)
+	require.NoError(t, err)
+	assert.True(t, outcome.Curated)
+
+	// 其他读者和限定范围的相同问题重新生成并进入审核
+	for _, tc := range []struct {
+		ctx   context.Context
+		scope ReviewScope
+	}{
+		{bob, ReviewScope{}},
+		{alice, ReviewScope{FileID: "salary"}},
+		{alice, ReviewScope{Metadata: map[string]interface{}{"department": "hr"}}},
+	} {
+		outcome, err = service.Gate(tc.ctx, "薪酬标准是什么", tc.scope, generate)
+		require.NoError(t, err)
+		assert.False(t, outcome.Curated)
+		require.NotNil(t, outcome.Draft)
+	}
+	assert.Equal(t, 4, calls)
+
+	// 元数据条件与顺序无关，范围键不超过数据库字段长度
+	scope := ReviewScope{Metadata: map[string]interface{}{"a": 1, "b": "x"}}
+	assert.Equal(t, service.reviewKey(alice, "问题", scope),
+		service.reviewKey(alice, "问题", ReviewScope{Metadata: map[string]interface{}{"b": "x", "a": 1}}))
+	long := strings.Repeat("薪", 600)
+	assert.Len(t, []rune(service.reviewKey(alice, long, scope)), maxQuestionKeyLength)
+	assert.Equal(t, questionKey("问题"), NewReviewService(nil, nil).reviewKey(alice, "问题", ReviewScope{}))
+}
+
+// readableFiles 只允许读取指定文档的ReadableChecker
+type readableFiles map[string]bool
+
+func (r readableFiles) CheckReadable(ctx context.Context, fileID string) error {
+	if !r[fileID] {
+		return models.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// TestReviewSourcesReadable 测试返回来源时跳过调用方无权读取的文档
+func TestReviewSourcesReadable(t *testing.T) {
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 3})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "public_0", FileID: "public", Text: "公开内容", Vector: []float32{1, 0, 0}},
+		{ID: "salary_0", FileID: "salary", Text: "薪酬内容", Vector: []float32{0, 1, 0}},
+		{ID: "salary_1", FileID: "salary", Text: "薪酬内容", Vector: []float32{0, 0, 1}},
+	}))
+
+	service := NewReviewService(nil, vectorDB, WithReviewDocuments(readableFiles{"public": true}))
+	sources := service.Sources(context.Background(), []string{"public_0", "salary_0", "salary_1", "missing_0"})
+	require.Len(t, sources, 1)
+	assert.Equal(t, "public_0", sources[0].ID)
+
+	// 未设置读取权限检查时只跳过已删除的段落
+	sources = NewReviewService(nil, vectorDB).Sources(context.Background(), []string{"public_0", "salary_0", "missing_0"})
+	assert.Len(t, sources, 2)
+}
+
+// TestQuestionKey 测试问题规范化
+func TestQuestionKey(t *testing.T) {
+	assert.Equal(t, "how to get a refund", questionKey("  How to   get a Refund?! "))
+	assert.Equal(t, "如何申请退款", questionKey("如何申请退款？"))
+
+	service := NewReviewService(nil, nil)
+	topic, flagged := service.MatchTopic("任意问题")
+	assert.True(t, flagged, "all questions need review when no topics are configured")
+	assert.Empty(t, topic)
+}
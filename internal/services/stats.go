@@ -0,0 +1,303 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/sirupsen/logrus"
+)
+
+// statsDateLayout 问答统计的日期格式
+const statsDateLayout = "2006-01-02"
+
+// QADayStats 单日的问答统计
+type QADayStats struct {
+	Date         string  // 日期
+	Questions    int64   // 问答次数
+	CacheHits    int64   // 命中回答缓存的次数
+	Errors       int64   // 失败次数
+	AvgLatencyMs float64 // 平均耗时（毫秒）
+}
+
+// AdminStats 运维看板统计
+type AdminStats struct {
+	DocumentsByStatus  map[models.DocumentStatus]int64 // 各处理状态的文档数
+	Documents          int64                           // 文档总数
+	Segments           int64                           // 段落总数
+	DuplicateSegments  int64                           // 被标记为近似重复的段落数
+	SuppressedSegments int64                           // 作为重复段落未写入向量库的段落数
+	Vectors            int                             // 向量总数
+	StorageBytes       int64                           // 文档文件总字节数
+	From               string                          // 问答统计起始日期
+	To                 string                          // 问答统计结束日期
+	QAByDay            []QADayStats                    // 每日问答统计，没有问答的日期计为0
+	Questions          int64                           // 统计范围内的问答次数
+	AvgLatencyMs       float64                         // 统计范围内的平均耗时（毫秒）
+	CacheHitRate       float64                         // 统计范围内的回答缓存命中率
+	ErrorRate          float64                         // 统计范围内的失败率
+}
+
+// StatsService 运维统计服务
+// 记录每次问答的耗时和缓存命中情况，并汇总文档、段落、向量和存储用量
+type StatsService struct {
+	repo     repository.StatsRepository
+	vectorDB vectordb.Repository
+	logger   *logrus.Logger
+	now      func() time.Time
+}
+
+// StatsOption 运维统计服务配置选项
+type StatsOption func(*StatsService)
+
+// WithStatsLogger 设置日志记录器
+func WithStatsLogger(logger *logrus.Logger) StatsOption {
+	return func(s *StatsService) {
+		s.logger = logger
+	}
+}
+
+// NewStatsService 创建运维统计服务
+func NewStatsService(repo repository.StatsRepository, vectorDB vectordb.Repository, opts ...StatsOption) *StatsService {
+	s := &StatsService{
+		repo:     repo,
+		vectorDB: vectorDB,
+		logger:   logrus.StandardLogger(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// RecordQA 记录一次问答，写入失败只记录日志，不影响问答本身
+func (s *StatsService) RecordQA(ctx context.Context, latency time.Duration, cacheHit bool, failed bool) {
+	stat := &models.QADailyStat{
+		Date:      s.now().Format(statsDateLayout),
+		Questions: 1,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if cacheHit {
+		stat.CacheHits = 1
+	}
+	if failed {
+		stat.Errors = 1
+	}
+
+	if err := s.repo.WithContext(ctx).IncrementQA(stat); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to record QA stats")
+	}
+}
+
+// RecordPromptVariant 记录一次使用提示词变体的问答，写入失败只记录日志
+func (s *StatsService) RecordPromptVariant(ctx context.Context, variant string, latency time.Duration, failed bool) {
+	stat := &models.PromptVariantStat{
+		Date:      s.now().Format(statsDateLayout),
+		Variant:   variant,
+		Questions: 1,
+		LatencyMs: latency.Milliseconds(),
+	}
+	if failed {
+		stat.Errors = 1
+	}
+
+	if err := s.repo.WithContext(ctx).IncrementPromptVariant(stat); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to record prompt variant stats")
+	}
+}
+
+// PromptVariantStats 单个提示词变体的对比统计
+type PromptVariantStats struct {
+	Variant          string  // 变体名称
+	Weight           int     // 当前配置的流量权重，已下线的变体为0
+	Questions        int64   // 问答次数
+	Errors           int64   // 失败次数
+	NegativeFeedback int64   // 负反馈数，每个被标记错误的段落计一次
+	AvgLatencyMs     float64 // 平均耗时（毫秒）
+	ErrorRate        float64 // 失败率
+	FeedbackRate     float64 // 每次问答的平均负反馈数
+}
+
+// ComparePromptVariants 汇总包括今天在内的最近days天各提示词变体的问答量、耗时、失败率和负反馈
+// 先按配置顺序列出当前的变体，再按名称列出统计范围内出现过的已下线变体
+func (s *StatsService) ComparePromptVariants(ctx context.Context, days int, variants []PromptVariant) ([]PromptVariantStats, error) {
+	if days <= 0 {
+		days = 1
+	}
+	repo := s.repo.WithContext(ctx)
+
+	today := s.now()
+	start := today.AddDate(0, 0, -(days - 1))
+	records, err := repo.ListPromptVariants(start.Format(statsDateLayout), today.Format(statsDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompt variant stats: %w", err)
+	}
+	since := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	feedback, err := repo.CountFeedbackByVariant(since, since.AddDate(0, 0, days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count feedback by prompt variant: %w", err)
+	}
+
+	byName := make(map[string]*PromptVariantStats)
+	var result []*PromptVariantStats
+	get := func(name string) *PromptVariantStats {
+		if stats, ok := byName[name]; ok {
+			return stats
+		}
+		stats := &PromptVariantStats{Variant: name}
+		byName[name] = stats
+		result = append(result, stats)
+		return stats
+	}
+	for _, v := range variants {
+		get(v.Name).Weight = v.Weight
+	}
+	configured := len(result)
+
+	latencyMs := make(map[string]int64)
+	for _, r := range records {
+		stats := get(r.Variant)
+		stats.Questions += r.Questions
+		stats.Errors += r.Errors
+		latencyMs[r.Variant] += r.LatencyMs
+	}
+	for name, count := range feedback {
+		get(name).NegativeFeedback = count
+	}
+	retired := result[configured:]
+	sort.Slice(retired, func(i, j int) bool { return retired[i].Variant < retired[j].Variant })
+
+	out := make([]PromptVariantStats, 0, len(result))
+	for _, stats := range result {
+		stats.AvgLatencyMs = ratio(latencyMs[stats.Variant], stats.Questions)
+		stats.ErrorRate = ratio(stats.Errors, stats.Questions)
+		stats.FeedbackRate = ratio(stats.NegativeFeedback, stats.Questions)
+		out = append(out, *stats)
+	}
+	return out, nil
+}
+
+// Collect 汇总运维看板统计，问答统计覆盖包括今天在内的最近days天
+func (s *StatsService) Collect(ctx context.Context, days int) (*AdminStats, error) {
+	if days <= 0 {
+		days = 1
+	}
+	repo := s.repo.WithContext(ctx)
+
+	byStatus, err := repo.CountDocumentsByStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	segments, err := repo.CountSegments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count segments: %w", err)
+	}
+	duplicates, suppressed, err := repo.CountDuplicateSegments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count duplicate segments: %w", err)
+	}
+	storageBytes, err := repo.SumStorageBytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum storage usage: %w", err)
+	}
+	vectors, err := s.vectorDB.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count vectors: %w", err)
+	}
+
+	today := s.now()
+	from := today.AddDate(0, 0, -(days - 1)).Format(statsDateLayout)
+	to := today.Format(statsDateLayout)
+	records, err := repo.ListQA(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list qa stats: %w", err)
+	}
+
+	stats := &AdminStats{
+		DocumentsByStatus:  byStatus,
+		Segments:           segments,
+		DuplicateSegments:  duplicates,
+		SuppressedSegments: suppressed,
+		Vectors:            vectors,
+		StorageBytes:       storageBytes,
+		From:               from,
+		To:                 to,
+		QAByDay:            make([]QADayStats, 0, days),
+	}
+	for _, count := range byStatus {
+		stats.Documents += count
+	}
+
+	byDate := make(map[string]*models.QADailyStat, len(records))
+	for _, r := range records {
+		byDate[r.Date] = r
+	}
+
+	var cacheHits, errors, latencyMs int64
+	for i := days - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(statsDateLayout)
+		day := QADayStats{Date: date}
+		if r, ok := byDate[date]; ok {
+			day.Questions = r.Questions
+			day.CacheHits = r.CacheHits
+			day.Errors = r.Errors
+			day.AvgLatencyMs = ratio(r.LatencyMs, r.Questions)
+			cacheHits += r.CacheHits
+			errors += r.Errors
+			latencyMs += r.LatencyMs
+			stats.Questions += r.Questions
+		}
+		stats.QAByDay = append(stats.QAByDay, day)
+	}
+	stats.AvgLatencyMs = ratio(latencyMs, stats.Questions)
+	stats.CacheHitRate = ratio(cacheHits, stats.Questions)
+	stats.ErrorRate = ratio(errors, stats.Questions)
+
+	return stats, nil
+}
+
+// VectorDBInfo 向量数据库的配置、状态和一致性检查结果
+type VectorDBInfo struct {
+	vectordb.Info
+	Segments   int64 // 数据库中的段落记录数
+	Suppressed int64 // 作为近似重复未写入向量库的段落数
+	Consistent bool  // 向量数与应写入向量库的段落数是否一致
+}
+
+// DescribeVectorDB 返回向量数据库的配置和状态，并比对向量数与段落记录数
+// 处理中的文档会造成短暂的不一致；持续不一致说明有悬空向量或缺失的向量，
+// 可以通过存储垃圾回收或重新向量化修复
+func (s *StatsService) DescribeVectorDB(ctx context.Context) (*VectorDBInfo, error) {
+	info, err := vectordb.Describe(s.vectorDB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe vector database: %w", err)
+	}
+	repo := s.repo.WithContext(ctx)
+	segments, err := repo.CountSegments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count segments: %w", err)
+	}
+	_, suppressed, err := repo.CountDuplicateSegments()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count duplicate segments: %w", err)
+	}
+	return &VectorDBInfo{
+		Info:       info,
+		Segments:   segments,
+		Suppressed: suppressed,
+		Consistent: int64(info.Count) == segments-suppressed,
+	}, nil
+}
+
+// ratio 计算比值，分母为0时返回0
+func ratio(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
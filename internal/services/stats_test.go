@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/events"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupStatsTestEnv 创建运维统计测试环境，包含不同状态的文档、段落和向量
+func setupStatsTestEnv(t *testing.T) *StatsService {
+	dbName := fmt.Sprintf("file:memdb_stats_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Document{}, &models.DocumentSegment{}, &models.QADailyStat{},
+		&models.PromptVariantStat{}, &models.AnswerFeedback{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	docRepo := repository.NewDocumentRepository()
+	docs := []struct {
+		id     string
+		status models.DocumentStatus
+		size   int64
+	}{
+		{"manual", models.DocStatusCompleted, 1000},
+		{"faq", models.DocStatusCompleted, 500},
+		{"draft", models.DocStatusProcessing, 200},
+		{"broken", models.DocStatusFailed, 0},
+	}
+	for _, d := range docs {
+		require.NoError(t, docRepo.Create(&models.Document{
+			ID:       d.id,
+			FileName: d.id + ".pdf",
+			FileType: "pdf",
+			FilePath: "/tmp/" + d.id + ".pdf",
+			FileSize: d.size,
+			Status:   d.status,
+		}))
+	}
+	require.NoError(t, docRepo.SaveSegments([]*models.DocumentSegment{
+		{DocumentID: "manual", SegmentID: "manual_0", Position: 0, Text: "第一段"},
+		{DocumentID: "manual", SegmentID: "manual_1", Position: 1, Text: "第二段"},
+		{DocumentID: "faq", SegmentID: "faq_0", Position: 0, Text: "常见问题"},
+	}))
+
+	vectorDB, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, vectorDB.AddBatch([]vectordb.Document{
+		{ID: "manual_0", FileID: "manual", Text: "第一段", Vector: []float32{1, 0, 0, 0}},
+		{ID: "manual_1", FileID: "manual", Text: "第二段", Vector: []float32{0, 1, 0, 0}},
+	}))
+
+	return NewStatsService(repository.NewStatsRepository(), vectorDB)
+}
+
+// TestStatsService 测试问答统计的累加和运维看板汇总
+func TestStatsService(t *testing.T) {
+	service := setupStatsTestEnv(t)
+	ctx := context.Background()
+
+	today := time.Date(2024, 3, 10, 15, 0, 0, 0, time.Local)
+	service.now = func() time.Time { return today.AddDate(0, 0, -2) }
+	service.RecordQA(ctx, 300*time.Millisecond, false, false)
+
+	service.now = func() time.Time { return today }
+	service.RecordQA(ctx, 100*time.Millisecond, false, false)
+	service.RecordQA(ctx, 10*time.Millisecond, true, false)
+	service.RecordQA(ctx, 50*time.Millisecond, false, true)
+
+	// 统计范围之外的问答不计入汇总
+	service.now = func() time.Time { return today.AddDate(0, 0, -7) }
+	service.RecordQA(ctx, time.Second, false, true)
+	service.now = func() time.Time { return today }
+
+	stats, err := service.Collect(ctx, 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), stats.Documents)
+	assert.Equal(t, int64(2), stats.DocumentsByStatus[models.DocStatusCompleted])
+	assert.Equal(t, int64(1), stats.DocumentsByStatus[models.DocStatusProcessing])
+	assert.Equal(t, int64(1), stats.DocumentsByStatus[models.DocStatusFailed])
+	assert.Equal(t, int64(3), stats.Segments)
+	assert.Equal(t, 2, stats.Vectors)
+	assert.Equal(t, int64(1700), stats.StorageBytes)
+
+	assert.Equal(t, "2024-03-08", stats.From)
+	assert.Equal(t, "2024-03-10", stats.To)
+	require.Len(t, stats.QAByDay, 3)
+	assert.Equal(t, QADayStats{Date: "2024-03-08", Questions: 1, AvgLatencyMs: 300}, stats.QAByDay[0])
+	assert.Equal(t, QADayStats{Date: "2024-03-09"}, stats.QAByDay[1])
+	assert.Equal(t, "2024-03-10", stats.QAByDay[2].Date)
+	assert.Equal(t, int64(3), stats.QAByDay[2].Questions)
+	assert.Equal(t, int64(1), stats.QAByDay[2].CacheHits)
+	assert.Equal(t, int64(1), stats.QAByDay[2].Errors)
+	assert.InDelta(t, 160.0/3, stats.QAByDay[2].AvgLatencyMs, 1e-9)
+
+	assert.Equal(t, int64(4), stats.Questions)
+	assert.InDelta(t, 115.0, stats.AvgLatencyMs, 1e-9)
+	assert.InDelta(t, 0.25, stats.CacheHitRate, 1e-9)
+	assert.InDelta(t, 0.25, stats.ErrorRate, 1e-9)
+}
+
+// TestComparePromptVariants 测试按提示词变体汇总问答量、耗时和负反馈，已下线的变体排在配置的变体之后
+func TestComparePromptVariants(t *testing.T) {
+	service := setupStatsTestEnv(t)
+	ctx := context.Background()
+
+	today := time.Date(2024, 3, 10, 15, 0, 0, 0, time.Local)
+	service.now = func() time.Time { return today.AddDate(0, 0, -1) }
+	service.RecordPromptVariant(ctx, "control", 200*time.Millisecond, false)
+	service.RecordPromptVariant(ctx, "legacy", 500*time.Millisecond, true)
+	service.now = func() time.Time { return today }
+	service.RecordPromptVariant(ctx, "control", 100*time.Millisecond, false)
+	service.RecordPromptVariant(ctx, "control", 300*time.Millisecond, true)
+	service.RecordPromptVariant(ctx, "concise", 50*time.Millisecond, false)
+
+	feedbackRepo := repository.NewFeedbackRepository()
+	for _, f := range []*models.AnswerFeedback{
+		{ClusterID: "c1", Question: "问题", SegmentID: "manual_0", Variant: "control", CreatedAt: today},
+		{ClusterID: "c1", Question: "问题", SegmentID: "manual_1", Variant: "control", CreatedAt: today},
+		{ClusterID: "c1", Question: "问题", SegmentID: "manual_0", Variant: "concise", CreatedAt: today.AddDate(0, 0, -5)},
+		{ClusterID: "c1", Question: "问题", SegmentID: "faq_0", CreatedAt: today},
+	} {
+		require.NoError(t, feedbackRepo.CreateFeedback(f))
+	}
+
+	stats, err := service.ComparePromptVariants(ctx, 2, []PromptVariant{
+		{Name: "control", Weight: 80}, {Name: "concise", Weight: 20}, {Name: "verbose"},
+	})
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+
+	assert.Equal(t, "control", stats[0].Variant)
+	assert.Equal(t, 80, stats[0].Weight)
+	assert.Equal(t, int64(3), stats[0].Questions)
+	assert.Equal(t, int64(1), stats[0].Errors)
+	assert.Equal(t, int64(2), stats[0].NegativeFeedback)
+	assert.InDelta(t, 200.0, stats[0].AvgLatencyMs, 1e-9)
+	assert.InDelta(t, 1.0/3, stats[0].ErrorRate, 1e-9)
+	assert.InDelta(t, 2.0/3, stats[0].FeedbackRate, 1e-9)
+
+	// 统计范围之外的反馈不计入
+	assert.Equal(t, PromptVariantStats{Variant: "concise", Weight: 20, Questions: 1, AvgLatencyMs: 50}, stats[1])
+	assert.Equal(t, PromptVariantStats{Variant: "verbose"}, stats[2])
+	assert.Equal(t, "legacy", stats[3].Variant)
+	assert.Zero(t, stats[3].Weight)
+	assert.InDelta(t, 1.0, stats[3].ErrorRate, 1e-9)
+}
+
+// TestDescribeVectorDB 测试向量数据库信息和向量数与段落数的一致性检查
+func TestDescribeVectorDB(t *testing.T) {
+	service := setupStatsTestEnv(t)
+
+	info, err := service.DescribeVectorDB(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "memory", info.Type)
+	assert.Equal(t, 4, info.Dimension)
+	assert.Equal(t, vectordb.Cosine, info.Distance)
+	assert.True(t, info.Normalized)
+	assert.Equal(t, 2, info.Count)
+	assert.Positive(t, info.MemoryBytes)
+	assert.True(t, info.LastSave.IsZero())
+
+	// faq_0段落没有对应的向量
+	assert.Equal(t, int64(3), info.Segments)
+	assert.False(t, info.Consistent)
+}
+
+// TestQAObserve 测试问答观测记录缓存命中和失败情况
+func TestQAObserve(t *testing.T) {
+	stats := setupStatsTestEnv(t)
+	service := &QAService{stats: stats}
+
+	ctx, done := service.observe(context.Background(), "什么是RAG？")
+	markCacheHit(ctx)
+	done(nil)
+
+	_, done = service.observe(context.Background(), "什么是RAG？")
+	done(errors.New("llm unavailable"))
+
+	result, err := stats.Collect(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, result.QAByDay, 1)
+	assert.Equal(t, int64(2), result.QAByDay[0].Questions)
+	assert.Equal(t, int64(1), result.QAByDay[0].CacheHits)
+	assert.Equal(t, int64(1), result.QAByDay[0].Errors)
+
+	// 未配置统计服务时观测为空操作
+	ctx, done = (&QAService{}).observe(context.Background(), "什么是RAG？")
+	markCacheHit(ctx)
+	done(nil)
+
+	// 配置事件总线时发布问答事件
+	memory := events.NewMemoryPublisher(10)
+	bus := events.NewBus([]events.Publisher{memory})
+	ctx, done = (&QAService{events: bus}).observe(context.Background(), "什么是RAG？")
+	markCacheHit(ctx)
+	done(errors.New("llm unavailable"))
+	require.NoError(t, bus.Close(context.Background()))
+
+	entries, _ := memory.Since(0, 0)
+	require.Len(t, entries, 1)
+	assert.Equal(t, events.TypeQuestionAnswered, entries[0].Event.Type)
+	assert.Equal(t, "什么是RAG？", entries[0].Event.Data["question"])
+	assert.Equal(t, true, entries[0].Event.Data["cache_hit"])
+	assert.Equal(t, "llm unavailable", entries[0].Event.Data["error"])
+}
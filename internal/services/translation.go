@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/fyerfyer/doc-QA-system/pkg/langdetect"
+	"github.com/sirupsen/logrus"
+)
+
+// translateQuestionPrompt 检索前翻译问题的提示词模板
+const translateQuestionPrompt = `请将下面的问题翻译成%s，用于在%s文档中检索答案。
+要求：保留专有名词、产品名、代码和数字的原文；只输出译文，不要解释。
+
+问题：%s`
+
+// translateAnswerPrompt 翻译回答的提示词模板
+const translateAnswerPrompt = `请将下面的回答翻译成%s。
+要求：保留引用标记（如[1]）、代码、专有名词和Markdown格式；只输出译文，不要解释。
+
+回答：
+%s`
+
+// languageNames 语言代码对应的名称，用于提示词
+var languageNames = map[string]string{
+	"zh": "中文",
+	"en": "英文",
+	"ja": "日文",
+	"ko": "韩文",
+	"fr": "法文",
+	"de": "德文",
+	"es": "西班牙文",
+	"it": "意大利文",
+	"pt": "葡萄牙文",
+	"nl": "荷兰文",
+	"ru": "俄文",
+	"ar": "阿拉伯文",
+	"th": "泰文",
+	"hi": "印地文",
+	"el": "希腊文",
+	"he": "希伯来文",
+}
+
+// languageName 返回语言的名称，未知语言返回代码本身
+func languageName(lang string) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return lang
+}
+
+// Translation 一次跨语言问答的翻译信息
+type Translation struct {
+	QuestionLanguage   string // 识别出的问题语言
+	TranslatedQuestion string // 用于检索的译文，问题未翻译时为空
+	AnswerLanguage     string // 回答的语言
+	AnswerTranslated   bool   // 回答是否经过翻译
+}
+
+// TranslationService 跨语言问答
+// 问题语言与文档库语言不同时，检索前用大模型把问题翻译成文档库语言，
+// 生成回答后再翻译回提问者的语言，使英文用户也能查询中文手册
+type TranslationService struct {
+	llm           llm.Client     // 用于翻译的大模型客户端
+	indexLanguage string         // 文档库的主要语言
+	byDefault     bool           // 请求未指定时是否翻译
+	logger        *logrus.Logger // 日志记录器
+}
+
+// TranslationOption 跨语言问答配置选项
+type TranslationOption func(*TranslationService)
+
+// WithIndexLanguage 设置文档库的主要语言，默认中文
+func WithIndexLanguage(lang string) TranslationOption {
+	return func(t *TranslationService) {
+		if lang != "" {
+			t.indexLanguage = strings.ToLower(lang)
+		}
+	}
+}
+
+// WithTranslateByDefault 设置请求未指定时是否翻译，默认只在请求中要求时翻译
+func WithTranslateByDefault(enabled bool) TranslationOption {
+	return func(t *TranslationService) {
+		t.byDefault = enabled
+	}
+}
+
+// WithTranslationLogger 设置日志记录器
+func WithTranslationLogger(logger *logrus.Logger) TranslationOption {
+	return func(t *TranslationService) {
+		t.logger = logger
+	}
+}
+
+// NewTranslationService 创建跨语言问答服务
+func NewTranslationService(client llm.Client, opts ...TranslationOption) *TranslationService {
+	t := &TranslationService{
+		llm:           client,
+		indexLanguage: langdetect.Chinese,
+		logger:        logrus.New(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Enabled 判断本次请求是否翻译，requested为nil时使用默认设置
+// 未配置跨语言问答时始终返回false
+func (t *TranslationService) Enabled(requested *bool) bool {
+	if t == nil {
+		return false
+	}
+	if requested != nil {
+		return *requested
+	}
+	return t.byDefault
+}
+
+// Answer 跨语言生成回答
+// answerLanguage为空时使用问题的语言；问题语言与文档库语言相同或无法识别时不翻译问题，
+// 回答语言与文档库语言相同时不翻译回答。翻译失败时使用原文继续，不影响问答
+func (t *TranslationService) Answer(ctx context.Context, question, answerLanguage string, answer QuestionAnswerFunc) (string, []vectordb.Document, *Translation, error) {
+	info := &Translation{QuestionLanguage: langdetect.Detect(question)}
+	info.AnswerLanguage = strings.ToLower(answerLanguage)
+	if info.AnswerLanguage == "" {
+		info.AnswerLanguage = info.QuestionLanguage
+	}
+
+	query := question
+	if t.needsTranslation(info.QuestionLanguage) {
+		translated, err := t.translate(ctx, fmt.Sprintf(translateQuestionPrompt,
+			languageName(t.indexLanguage), languageName(t.indexLanguage), question), 200)
+		if err != nil {
+			t.logger.WithContext(ctx).WithError(err).Warn("Failed to translate question, using original")
+		} else {
+			query = translated
+			info.TranslatedQuestion = translated
+		}
+	}
+
+	text, sources, err := answer(ctx, query)
+	if err != nil {
+		return "", nil, info, err
+	}
+
+	if t.needsTranslation(info.AnswerLanguage) && text != "" {
+		translated, err := t.translate(ctx, fmt.Sprintf(translateAnswerPrompt, languageName(info.AnswerLanguage), text), 2000)
+		if err != nil {
+			t.logger.WithContext(ctx).WithError(err).Warn("Failed to translate answer, returning original")
+			info.AnswerLanguage = t.indexLanguage
+		} else {
+			text = translated
+			info.AnswerTranslated = true
+		}
+	} else if info.AnswerLanguage == langdetect.Unknown {
+		info.AnswerLanguage = t.indexLanguage
+	}
+
+	t.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"question_language":   info.QuestionLanguage,
+		"answer_language":     info.AnswerLanguage,
+		"question_translated": info.TranslatedQuestion != "",
+		"answer_translated":   info.AnswerTranslated,
+	}).Debug("Cross-language answer generated")
+	return text, sources, info, nil
+}
+
+// needsTranslation 判断文本是否需要在该语言和文档库语言之间翻译
+func (t *TranslationService) needsTranslation(lang string) bool {
+	return lang != "" && lang != langdetect.Unknown && lang != t.indexLanguage
+}
+
+// translate 调用大模型翻译，返回去掉首尾空白的译文
+func (t *TranslationService) translate(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	response, err := t.llm.Generate(ctx, prompt,
+		llm.WithGenerateMaxTokens(maxTokens),
+		llm.WithGenerateTemperature(0.1))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(response.Text)
+	if text == "" {
+		return "", fmt.Errorf("empty translation")
+	}
+	return text, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTranslationAnswer 测试跨语言问答翻译问题和回答
+func TestTranslationAnswer(t *testing.T) {
+	ctx := context.Background()
+	sources := []vectordb.Document{{ID: "seg-1"}}
+
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "翻译成中文") && strings.Contains(prompt, "How do I reset the device?")
+		}), mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: " 如何重置设备？\n"}, nil).Once()
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.MatchedBy(func(prompt string) bool {
+			return strings.Contains(prompt, "翻译成英文") && strings.Contains(prompt, "长按电源键10秒[1]")
+		}), mock.Anything, mock.Anything).
+		Return(&llm.Response{Text: "Press and hold the power button for 10 seconds [1]."}, nil).Once()
+
+	translator := NewTranslationService(llmClient)
+
+	var asked []string
+	ask := func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+		asked = append(asked, question)
+		return "长按电源键10秒[1]", sources, nil
+	}
+
+	answer, docs, info, err := translator.Answer(ctx, "How do I reset the device?", "", ask)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"如何重置设备？"}, asked)
+	assert.Equal(t, "Press and hold the power button for 10 seconds [1].", answer)
+	assert.Equal(t, sources, docs)
+	assert.Equal(t, &Translation{
+		QuestionLanguage:   "en",
+		TranslatedQuestion: "如何重置设备？",
+		AnswerLanguage:     "en",
+		AnswerTranslated:   true,
+	}, info)
+
+	// 与文档库语言相同的问题不调用大模型
+	asked = nil
+	answer, _, info, err = translator.Answer(ctx, "如何重置设备？", "", ask)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"如何重置设备？"}, asked)
+	assert.Equal(t, "长按电源键10秒[1]", answer)
+	assert.False(t, info.AnswerTranslated)
+	assert.Equal(t, "zh", info.AnswerLanguage)
+}
+
+// TestTranslationFallback 测试翻译失败时使用原文继续问答
+func TestTranslationFallback(t *testing.T) {
+	llmClient := llm.NewMockClient(t)
+	llmClient.EXPECT().
+		Generate(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("llm unavailable")).Twice()
+
+	translator := NewTranslationService(llmClient)
+	answer, _, info, err := translator.Answer(context.Background(), "How do I reset the device?", "",
+		func(ctx context.Context, question string) (string, []vectordb.Document, error) {
+			assert.Equal(t, "How do I reset the device?", question)
+			return "长按电源键", nil, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, "长按电源键", answer)
+	assert.Empty(t, info.TranslatedQuestion)
+	assert.Equal(t, "zh", info.AnswerLanguage)
+	assert.False(t, info.AnswerTranslated)
+}
+
+// TestTranslationEnabled 测试请求级别的翻译开关
+func TestTranslationEnabled(t *testing.T) {
+	on, off := true, false
+
+	var disabled *TranslationService
+	assert.False(t, disabled.Enabled(&on))
+
+	translator := NewTranslationService(nil)
+	assert.False(t, translator.Enabled(nil))
+	assert.True(t, translator.Enabled(&on))
+
+	translator = NewTranslationService(nil, WithTranslateByDefault(true))
+	assert.True(t, translator.Enabled(nil))
+	assert.False(t, translator.Enabled(&off))
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultUploadPartSize 默认分片大小
+	DefaultUploadPartSize int64 = 8 << 20
+	// DefaultUploadExpiry 默认分片上传会话的有效期
+	DefaultUploadExpiry = 24 * time.Hour
+	// maxUploadParts 单次上传的最大分片数
+	maxUploadParts = 10000
+
+	uploadManifest   = "upload.json"
+	uploadPartPrefix = "part-"
+)
+
+var (
+	// ErrFileTooLarge 文件超过允许的大小
+	ErrFileTooLarge = errors.New("file exceeds maximum upload size")
+	// ErrUploadNotFound 分片上传会话不存在或已过期
+	ErrUploadNotFound = errors.New("upload not found or expired")
+	// ErrInvalidUploadPart 分片编号或大小不正确
+	ErrInvalidUploadPart = errors.New("invalid upload part")
+	// ErrUploadIncomplete 还有分片未上传
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+)
+
+// UploadSession 分片上传会话
+// 会话信息保存在暂存目录的清单文件中，服务重启后仍可继续上传
+type UploadSession struct {
+	ID         string    `json:"id"`               // 上传ID
+	FileName   string    `json:"file_name"`        // 原始文件名
+	Size       int64     `json:"size"`             // 文件总大小
+	PartSize   int64     `json:"part_size"`        // 分片大小，最后一个分片可以更小
+	TotalParts int       `json:"total_parts"`      // 分片总数
+	Tags       string    `json:"tags,omitempty"`   // 文档标签
+	Tenant     string    `json:"tenant,omitempty"` // 发起上传的租户
+	CreatedAt  time.Time `json:"created_at"`       // 创建时间
+	ExpiresAt  time.Time `json:"expires_at"`       // 过期时间，过期后未完成的分片被清理
+}
+
+// PartSizeOf 返回第part个分片（从1开始）应有的大小
+func (u *UploadSession) PartSizeOf(part int) int64 {
+	if part == u.TotalParts {
+		return u.Size - u.PartSize*int64(u.TotalParts-1)
+	}
+	return u.PartSize
+}
+
+// UploadService 大文件分片上传
+// 客户端先创建上传会话，再逐个上传分片，网络中断后可以查询已收到的分片并续传，
+// 全部分片到齐后按顺序流式写入文件存储，不在内存中缓存整个文件
+type UploadService struct {
+	store     storage.Storage      // 文件存储
+	dir       string               // 分片暂存目录
+	partSize  int64                // 分片大小
+	maxSize   int64                // 文件最大字节数，0表示不限制
+	expiry    time.Duration        // 上传会话有效期
+	validator *filecheck.Validator // 合并前校验文件内容，为空时不校验
+	logger    *logrus.Logger       // 日志记录器
+	now       func() time.Time
+}
+
+// UploadOption 分片上传配置选项
+type UploadOption func(*UploadService)
+
+// WithUploadPartSize 设置分片大小
+func WithUploadPartSize(size int64) UploadOption {
+	return func(s *UploadService) {
+		if size > 0 {
+			s.partSize = size
+		}
+	}
+}
+
+// WithMaxUploadSize 设置文件最大字节数，0表示不限制
+func WithMaxUploadSize(size int64) UploadOption {
+	return func(s *UploadService) {
+		s.maxSize = size
+	}
+}
+
+// WithUploadExpiry 设置上传会话有效期
+func WithUploadExpiry(expiry time.Duration) UploadOption {
+	return func(s *UploadService) {
+		if expiry > 0 {
+			s.expiry = expiry
+		}
+	}
+}
+
+// WithUploadValidator 设置文件校验器，合并分片前校验文件类型、加密和病毒
+func WithUploadValidator(validator *filecheck.Validator) UploadOption {
+	return func(s *UploadService) {
+		s.validator = validator
+	}
+}
+
+// WithUploadLogger 设置日志记录器
+func WithUploadLogger(logger *logrus.Logger) UploadOption {
+	return func(s *UploadService) {
+		s.logger = logger
+	}
+}
+
+// NewUploadService 创建分片上传服务，dir为分片暂存目录
+func NewUploadService(store storage.Storage, dir string, opts ...UploadOption) (*UploadService, error) {
+	s := &UploadService{
+		store:    store,
+		dir:      dir,
+		partSize: DefaultUploadPartSize,
+		expiry:   DefaultUploadExpiry,
+		logger:   logrus.New(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload staging directory: %w", err)
+	}
+	return s, nil
+}
+
+// MaxSize 返回文件最大字节数，0表示不限制
+func (s *UploadService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// InitUpload 创建分片上传会话
+func (s *UploadService) InitUpload(ctx context.Context, fileName string, size int64, tags string) (*UploadSession, error) {
+	if size <= 0 {
+		return nil, errors.New("file size must be positive")
+	}
+	if s.maxSize > 0 && size > s.maxSize {
+		return nil, fmt.Errorf("%w: %d > %d bytes", ErrFileTooLarge, size, s.maxSize)
+	}
+	parts := int((size + s.partSize - 1) / s.partSize)
+	if parts > maxUploadParts {
+		return nil, fmt.Errorf("file needs %d parts, more than %d", parts, maxUploadParts)
+	}
+
+	// 顺便清理过期的上传，失败不影响本次上传
+	if _, err := s.CleanupExpired(ctx); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Warn("Failed to clean up expired uploads")
+	}
+
+	now := s.now()
+	upload := &UploadSession{
+		ID:         uuid.New().String(),
+		FileName:   filepath.Base(fileName),
+		Size:       size,
+		PartSize:   s.partSize,
+		TotalParts: parts,
+		Tags:       tags,
+		Tenant:     usage.TenantFromContext(ctx),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.expiry),
+	}
+	if err := os.MkdirAll(s.uploadDir(upload.ID), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.uploadDir(upload.ID), uploadManifest), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save upload manifest: %w", err)
+	}
+
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"upload_id": upload.ID,
+		"file_name": upload.FileName,
+		"size":      size,
+		"parts":     parts,
+	}).Info("Chunked upload started")
+	return upload, nil
+}
+
+// UploadPart 保存一个分片，part从1开始
+// 分片先写入临时文件，大小正确后再改名，重复上传同一分片会覆盖之前的内容
+func (s *UploadService) UploadPart(ctx context.Context, uploadID string, part int, r io.Reader) (int64, error) {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	if part < 1 || part > upload.TotalParts {
+		return 0, fmt.Errorf("%w: part %d out of range 1-%d", ErrInvalidUploadPart, part, upload.TotalParts)
+	}
+	expected := upload.PartSizeOf(part)
+
+	tmp, err := os.CreateTemp(s.uploadDir(uploadID), "tmp-")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create part file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(r, expected+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write part %d: %w", part, err)
+	}
+	if written != expected {
+		return 0, fmt.Errorf("%w: part %d has %d bytes, expected %d", ErrInvalidUploadPart, part, written, expected)
+	}
+	if err := os.Rename(tmp.Name(), s.partPath(uploadID, part)); err != nil {
+		return 0, fmt.Errorf("failed to save part %d: %w", part, err)
+	}
+	return written, nil
+}
+
+// GetUpload 获取上传会话
+// 不存在、已过期或属于其他租户时返回ErrUploadNotFound
+func (s *UploadService) GetUpload(ctx context.Context, uploadID string) (*UploadSession, error) {
+	upload, err := s.loadUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if s.now().After(upload.ExpiresAt) || upload.Tenant != usage.TenantFromContext(ctx) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// loadUpload 读取上传会话的清单文件
+func (s *UploadService) loadUpload(uploadID string) (*UploadSession, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil, ErrUploadNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.uploadDir(uploadID), uploadManifest))
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	var upload UploadSession
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to parse upload manifest: %w", err)
+	}
+	return &upload, nil
+}
+
+// ReceivedParts 返回已收到的分片编号，按升序排列，用于断点续传
+func (s *UploadService) ReceivedParts(ctx context.Context, uploadID string) ([]int, error) {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.uploadDir(uploadID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upload parts: %w", err)
+	}
+	parts := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		part, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), uploadPartPrefix))
+		if !strings.HasPrefix(entry.Name(), uploadPartPrefix) || err != nil || part < 1 || part > upload.TotalParts {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	sort.Ints(parts)
+	return parts, nil
+}
+
+// CompleteUpload 合并全部分片并保存到文件存储，成功后删除暂存的分片
+func (s *UploadService) CompleteUpload(ctx context.Context, uploadID string) (storage.FileInfo, *UploadSession, error) {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return storage.FileInfo{}, nil, err
+	}
+	received, err := s.ReceivedParts(ctx, uploadID)
+	if err != nil {
+		return storage.FileInfo{}, nil, err
+	}
+	if len(received) != upload.TotalParts {
+		return storage.FileInfo{}, nil, fmt.Errorf("%w: received %d of %d parts", ErrUploadIncomplete, len(received), upload.TotalParts)
+	}
+
+	// 按顺序打开全部分片，流式写入存储
+	readers := make([]io.Reader, 0, upload.TotalParts)
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for part := 1; part <= upload.TotalParts; part++ {
+		f, err := os.Open(s.partPath(uploadID, part))
+		if err != nil {
+			return storage.FileInfo{}, nil, fmt.Errorf("failed to open part %d: %w", part, err)
+		}
+		files = append(files, f)
+		readers = append(readers, f)
+	}
+
+	// 合并前校验完整的文件内容，未通过时丢弃已上传的分片
+	if err := s.validator.Validate(ctx, upload.FileName, io.MultiReader(readers...)); err != nil {
+		if removeErr := os.RemoveAll(s.uploadDir(uploadID)); removeErr != nil {
+			s.logger.WithContext(ctx).WithError(removeErr).WithField("upload_id", uploadID).Warn("Failed to remove upload parts")
+		}
+		return storage.FileInfo{}, nil, err
+	}
+	for _, f := range files {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return storage.FileInfo{}, nil, fmt.Errorf("failed to rewind upload part: %w", err)
+		}
+	}
+
+	info, err := s.store.Save(io.MultiReader(readers...), upload.FileName)
+	if err != nil {
+		return storage.FileInfo{}, nil, fmt.Errorf("failed to save uploaded file: %w", err)
+	}
+	if info.Size != upload.Size {
+		s.store.Delete(info.ID)
+		return storage.FileInfo{}, nil, fmt.Errorf("%w: saved %d bytes, expected %d", ErrInvalidUploadPart, info.Size, upload.Size)
+	}
+
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		s.logger.WithContext(ctx).WithError(err).WithField("upload_id", uploadID).Warn("Failed to remove upload parts")
+	}
+	s.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"upload_id": uploadID,
+		"file_id":   info.ID,
+		"size":      info.Size,
+	}).Info("Chunked upload completed")
+	return info, upload, nil
+}
+
+// AbortUpload 取消上传并删除已收到的分片
+func (s *UploadService) AbortUpload(ctx context.Context, uploadID string) error {
+	if _, err := s.GetUpload(ctx, uploadID); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.uploadDir(uploadID))
+}
+
+// CleanupExpired 删除过期的上传会话，返回删除的数量
+// 没有清单文件的目录可能正在创建，超过一分钟未修改才删除
+func (s *UploadService) CleanupExpired(ctx context.Context) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	removed := 0
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		upload, err := s.loadUpload(entry.Name())
+		if err == nil && !now.After(upload.ExpiresAt) {
+			continue
+		}
+		if err != nil {
+			info, statErr := entry.Info()
+			if statErr != nil || now.Sub(info.ModTime()) < time.Minute {
+				continue
+			}
+		}
+		if err := os.RemoveAll(s.uploadDir(entry.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// uploadDir 返回上传会话的暂存目录
+func (s *UploadService) uploadDir(uploadID string) string {
+	return filepath.Join(s.dir, uploadID)
+}
+
+// partPath 返回分片文件路径
+func (s *UploadService) partPath(uploadID string, part int) string {
+	return filepath.Join(s.uploadDir(uploadID), fmt.Sprintf("%s%05d", uploadPartPrefix, part))
+}
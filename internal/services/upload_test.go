@@ -0,0 +1,131 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/usage"
+	"github.com/fyerfyer/doc-QA-system/pkg/filecheck"
+	"github.com/fyerfyer/doc-QA-system/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUploadService 创建使用临时目录的分片上传服务
+func setupUploadService(t *testing.T, opts ...UploadOption) (*UploadService, storage.Storage) {
+	store, err := storage.NewLocalStorage(storage.LocalConfig{Path: t.TempDir()})
+	require.NoError(t, err)
+	uploads, err := NewUploadService(store, t.TempDir(), opts...)
+	require.NoError(t, err)
+	return uploads, store
+}
+
+// TestUploadService_ChunkedUpload 测试分片上传、断点续传和合并
+func TestUploadService_ChunkedUpload(t *testing.T) {
+	uploads, store := setupUploadService(t, WithUploadPartSize(4), WithMaxUploadSize(100))
+	ctx := usage.WithTenant(context.Background(), "alice")
+	content := "0123456789"
+
+	upload, err := uploads.InitUpload(ctx, "../manual.pdf", int64(len(content)), "手册")
+	require.NoError(t, err)
+	assert.Equal(t, "manual.pdf", upload.FileName)
+	assert.Equal(t, 3, upload.TotalParts)
+	assert.EqualValues(t, 2, upload.PartSizeOf(3))
+
+	// 分片可以乱序上传，大小不对或编号越界的分片被拒绝
+	_, err = uploads.UploadPart(ctx, upload.ID, 3, strings.NewReader("89"))
+	require.NoError(t, err)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, strings.NewReader("012"))
+	assert.ErrorIs(t, err, ErrInvalidUploadPart)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, strings.NewReader("01234"))
+	assert.ErrorIs(t, err, ErrInvalidUploadPart)
+	_, err = uploads.UploadPart(ctx, upload.ID, 4, strings.NewReader("xx"))
+	assert.ErrorIs(t, err, ErrInvalidUploadPart)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, strings.NewReader("0123"))
+	require.NoError(t, err)
+
+	received, err := uploads.ReceivedParts(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 3}, received)
+
+	_, _, err = uploads.CompleteUpload(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrUploadIncomplete)
+
+	// 其他租户看不到该上传
+	_, err = uploads.GetUpload(usage.WithTenant(context.Background(), "bob"), upload.ID)
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	_, err = uploads.UploadPart(ctx, upload.ID, 2, strings.NewReader("4567"))
+	require.NoError(t, err)
+	info, completed, err := uploads.CompleteUpload(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "手册", completed.Tags)
+	assert.EqualValues(t, len(content), info.Size)
+
+	reader, err := store.Get(info.ID)
+	require.NoError(t, err)
+	defer reader.Close()
+	saved, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(saved))
+
+	// 合并后暂存的分片被删除
+	_, err = uploads.GetUpload(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	_, err = uploads.InitUpload(ctx, "big.pdf", 101, "")
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+}
+
+// TestUploadService_Expiry 测试过期的上传被拒绝并清理
+func TestUploadService_Expiry(t *testing.T) {
+	uploads, _ := setupUploadService(t, WithUploadExpiry(time.Hour))
+	ctx := context.Background()
+
+	upload, err := uploads.InitUpload(ctx, "manual.md", 3, "")
+	require.NoError(t, err)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, bytes.NewReader([]byte("abc")))
+	require.NoError(t, err)
+
+	uploads.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, bytes.NewReader([]byte("abc")))
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	removed, err := uploads.CleanupExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	_, err = os.Stat(uploads.uploadDir(upload.ID))
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestUploadService_Validation 测试合并前校验文件内容，未通过时丢弃分片
+func TestUploadService_Validation(t *testing.T) {
+	uploads, store := setupUploadService(t, WithUploadValidator(filecheck.New()))
+	ctx := context.Background()
+
+	upload, err := uploads.InitUpload(ctx, "manual.pdf", 10, "")
+	require.NoError(t, err)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, strings.NewReader("plain text"))
+	require.NoError(t, err)
+
+	_, _, err = uploads.CompleteUpload(ctx, upload.ID)
+	assert.ErrorIs(t, err, filecheck.ErrTypeMismatch)
+	_, err = uploads.GetUpload(ctx, upload.ID)
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+	files, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	upload, err = uploads.InitUpload(ctx, "notes.md", 8, "")
+	require.NoError(t, err)
+	_, err = uploads.UploadPart(ctx, upload.ID, 1, strings.NewReader("# 标题"))
+	require.NoError(t, err)
+	info, _, err := uploads.CompleteUpload(ctx, upload.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 8, info.Size)
+}
@@ -0,0 +1,79 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// AnonymousTenant 未携带登记的API密钥的请求归属的租户
+const AnonymousTenant = "anonymous"
+
+// tenantKey 上下文中保存租户标识的键
+type tenantKey struct{}
+
+// WithTenant 返回带有租户标识的上下文
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 从上下文读取租户标识，未设置时返回AnonymousTenant
+func TenantFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok && tenant != "" {
+			return tenant
+		}
+	}
+	return AnonymousTenant
+}
+
+// Recorder 用量记录器
+// 将每次调用累加到按天聚合的用量表，写入失败只记录日志，不影响调用本身
+type Recorder struct {
+	repo   repository.UsageRepository
+	logger *logrus.Logger
+	now    func() time.Time
+}
+
+// NewRecorder 创建用量记录器
+func NewRecorder(repo repository.UsageRepository, logger *logrus.Logger) *Recorder {
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &Recorder{
+		repo:   repo,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Record 记录一次调用的用量，租户从上下文中读取
+func (r *Recorder) Record(ctx context.Context, kind models.UsageKind, provider, model string, tokens int, callErr error) {
+	if r == nil {
+		return
+	}
+
+	record := &models.UsageRecord{
+		Date:     r.now().Format("2006-01-02"),
+		Tenant:   TenantFromContext(ctx),
+		Provider: provider,
+		Model:    model,
+		Kind:     kind,
+		Requests: 1,
+		Tokens:   int64(tokens),
+	}
+	if callErr != nil {
+		record.Errors = 1
+	}
+
+	if err := r.repo.Increment(record); err != nil {
+		r.logger.WithError(err).WithFields(logrus.Fields{
+			"provider": provider,
+			"model":    model,
+			"kind":     kind,
+		}).Warn("Failed to record usage")
+	}
+}
@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/database"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+	"github.com/fyerfyer/doc-QA-system/internal/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupUsageTestDB 创建内存数据库并替换全局连接
+func setupUsageTestDB(t *testing.T) {
+	dbName := fmt.Sprintf("file:memdb_usage_%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.UsageRecord{}))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+}
+
+// newTestRecorder 创建日期固定、日志静默的记录器
+func newTestRecorder(repo repository.UsageRepository, date time.Time) *Recorder {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	recorder := NewRecorder(repo, logger)
+	recorder.now = func() time.Time { return date }
+	return recorder
+}
+
+// TestRecorderAggregates 测试用量按天和租户累加
+func TestRecorderAggregates(t *testing.T) {
+	setupUsageTestDB(t)
+	repo := repository.NewUsageRepository()
+
+	day1 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	recorder := newTestRecorder(repo, day1)
+
+	teamA := WithTenant(context.Background(), "team-a")
+	recorder.Record(teamA, models.UsageKindLLM, "openai", "gpt-4o", 100, nil)
+	recorder.Record(teamA, models.UsageKindLLM, "openai", "gpt-4o", 50, errors.New("timeout"))
+	recorder.Record(context.Background(), models.UsageKindLLM, "openai", "gpt-4o", 10, nil)
+
+	recorder.now = func() time.Time { return day1.AddDate(0, 0, 1) }
+	recorder.Record(teamA, models.UsageKindEmbedding, "tongyi", "text-embedding-v1", 20, nil)
+
+	records, err := repo.List(repository.UsageFilter{Tenant: "team-a", Kind: models.UsageKindLLM})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "2024-03-01", records[0].Date)
+	assert.Equal(t, int64(2), records[0].Requests)
+	assert.Equal(t, int64(1), records[0].Errors)
+	assert.Equal(t, int64(150), records[0].Tokens)
+
+	records, err = repo.List(repository.UsageFilter{Tenant: AnonymousTenant})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, int64(10), records[0].Tokens)
+
+	records, err = repo.List(repository.UsageFilter{From: "2024-03-02", To: "2024-03-02"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.UsageKindEmbedding, records[0].Kind)
+}
+
+// TestWrapLLM 测试大模型客户端包装器记录token用量
+func TestWrapLLM(t *testing.T) {
+	setupUsageTestDB(t)
+	repo := repository.NewUsageRepository()
+	recorder := newTestRecorder(repo, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	mockClient := llm.NewMockClient(t)
+	mockClient.EXPECT().Name().Return("qwen-turbo")
+	mockClient.EXPECT().Generate(mock.Anything, "问题").Return(&llm.Response{Text: "回答", TokenCount: 42}, nil)
+
+	client := WrapLLM(mockClient, "tongyi", recorder)
+	_, err := client.Generate(WithTenant(context.Background(), "team-b"), "问题")
+	require.NoError(t, err)
+
+	records, err := repo.List(repository.UsageFilter{Provider: "tongyi"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "team-b", records[0].Tenant)
+	assert.Equal(t, "qwen-turbo", records[0].Model)
+	assert.Equal(t, int64(42), records[0].Tokens)
+}
@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/models"
+)
+
+// WrapLLM 包装大模型客户端，记录每次调用的请求数和token数
+// 应包装在具体提供商上，组合客户端中的每个提供商分别计量
+func WrapLLM(client llm.Client, provider string, recorder *Recorder) llm.Client {
+	if recorder == nil {
+		return client
+	}
+	return &llmClient{Client: client, provider: provider, recorder: recorder}
+}
+
+// WrapEmbedding 包装嵌入模型客户端，记录每次调用的请求数和估算的token数
+func WrapEmbedding(client embedding.Client, provider string, recorder *Recorder) embedding.Client {
+	if recorder == nil {
+		return client
+	}
+	return &embeddingClient{Client: client, provider: provider, recorder: recorder}
+}
+
+// llmClient 带用量统计的大模型客户端
+type llmClient struct {
+	llm.Client
+	provider string
+	recorder *Recorder
+}
+
+func (c *llmClient) Generate(ctx context.Context, prompt string, options ...llm.GenerateOption) (*llm.Response, error) {
+	resp, err := c.Client.Generate(ctx, prompt, options...)
+	c.record(ctx, resp, err)
+	return resp, err
+}
+
+func (c *llmClient) Chat(ctx context.Context, messages []llm.Message, options ...llm.ChatOption) (*llm.Response, error) {
+	resp, err := c.Client.Chat(ctx, messages, options...)
+	c.record(ctx, resp, err)
+	return resp, err
+}
+
+func (c *llmClient) record(ctx context.Context, resp *llm.Response, err error) {
+	tokens := 0
+	if resp != nil {
+		tokens = resp.TokenCount
+	}
+	c.recorder.Record(ctx, models.UsageKindLLM, c.provider, c.Client.Name(), tokens, err)
+}
+
+// embeddingClient 带用量统计的嵌入模型客户端
+// 嵌入接口不返回token数，使用llm.EstimateTokenizer估算
+type embeddingClient struct {
+	embedding.Client
+	provider string
+	recorder *Recorder
+}
+
+func (c *embeddingClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	vec, err := c.Client.Embed(ctx, text)
+	c.recorder.Record(ctx, models.UsageKindEmbedding, c.provider, c.Client.Name(), estimateTokens(text), err)
+	return vec, err
+}
+
+func (c *embeddingClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vecs, err := c.Client.EmbedBatch(ctx, texts)
+	c.recorder.Record(ctx, models.UsageKindEmbedding, c.provider, c.Client.Name(), estimateTokens(texts...), err)
+	return vecs, err
+}
+
+// estimateTokens 估算文本的token总数
+func estimateTokens(texts ...string) int {
+	tokenizer := llm.EstimateTokenizer{}
+	total := 0
+	for _, text := range texts {
+		total += tokenizer.Count(text)
+	}
+	return total
+}
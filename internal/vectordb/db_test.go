@@ -211,6 +211,47 @@ func TestFaissAutoSave(t *testing.T) {
 	assert.Equal(t, 5, count)
 }
 
+// TestFaissBackgroundSave 测试FAISS在后台保存索引以及Flush立即保存
+func TestFaissBackgroundSave(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.faiss")
+	config := Config{
+		Type:              "faiss",
+		Dimension:         4,
+		DistanceType:      Cosine,
+		Path:              indexPath,
+		CreateIfNotExists: true,
+		SaveInterval:      time.Hour,
+	}
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+	defer repo.Close()
+	faissRepo := repo.(*FaissRepository)
+	faissRepo.mu.Lock()
+	faissRepo.autoSaveCount = 2
+	faissRepo.mu.Unlock()
+
+	// 写操作不等待保存
+	require.NoError(t, repo.Add(createTestDoc("doc1", "file1", 1, []float32{0.1, 0.2, 0.3, 0.4})))
+	_, err = os.Stat(indexPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// 未保存的变更达到阈值后由后台goroutine保存
+	require.NoError(t, repo.Add(createTestDoc("doc2", "file1", 2, []float32{0.5, 0.6, 0.7, 0.8})))
+	require.Eventually(t, func() bool {
+		return faissRepo.savedOps.Load() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Flush立即保存，其他实例可以读到全部文档
+	require.NoError(t, repo.Add(createTestDoc("doc3", "file2", 1, []float32{0.9, 0.8, 0.7, 0.6})))
+	require.NoError(t, repo.Flush())
+	reloaded, err := NewRepository(config)
+	require.NoError(t, err)
+	count, err := reloaded.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	require.NoError(t, reloaded.Close())
+}
+
 // TestFaissSearchWithFilters 测试FAISS的过滤搜索功能
 func TestFaissSearchWithFilters(t *testing.T) {
 	// 创建内存模式的FAISS仓库进行测试
@@ -297,6 +338,27 @@ func TestFaissSearchWithFilters(t *testing.T) {
 		}
 	})
 
+	// 测试排除文件
+	t.Run("exclude file IDs", func(t *testing.T) {
+		filter := DefaultSearchFilter()
+		filter.ExcludeFileIDs = []string{"file1"}
+
+		searchVector := []float32{0.5, 0.5, 0.5, 0.5}
+		results, err := repo.Search(searchVector, filter)
+		require.NoError(t, err)
+
+		// 应该只返回file2的文档
+		require.Len(t, results, 1)
+		assert.Equal(t, "file2", results[0].Document.FileID)
+
+		// 与FileIDs同时指定时以排除为准
+		filter.FileIDs = []string{"file1", "file2"}
+		results, err = repo.Search(searchVector, filter)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "file2", results[0].Document.FileID)
+	})
+
 	// 测试最小分数过滤
 	t.Run("min score filter", func(t *testing.T) {
 		filter := DefaultSearchFilter()
@@ -1,34 +1,68 @@
 package vectordb
 
 import (
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/DataIntelligenceCrew/go-faiss"
+	"github.com/sirupsen/logrus"
 )
 
 // FaissRepository 实现基于Faiss的向量仓库
+// 每个命名空间使用独立的Faiss索引，按命名空间搜索时只需检索对应的索引
 type FaissRepository struct {
 	*BaseRepository
-	mu             sync.RWMutex        // 并发锁
-	index          faiss.Index         // Faiss索引
-	documents      map[string]Document // 文档存储
-	fileToDocIDs   map[string][]string // 文件ID到文档ID的映射
-	idToPosition   map[string]int      // 文档ID到向量位置的映射
-	indexPath      string              // 索引文件路径
-	metaPath       string              // 元数据文件路径
-	dimension      int                 // 向量维度
-	distanceType   DistanceType        // 距离计算类型
-	saveOnClose    bool                // 关闭时是否保存
-	autoSave       bool                // 是否自动保存
-	autoSaveCount  int                 // 自动保存的操作计数阈值
-	operationCount int                 // 当前操作计数
-	queryCache     *TimedCache         // 查询缓存
-	lastSave       time.Time           // 上次保存时间
+	mu             sync.RWMutex               // 并发锁
+	namespaces     map[string]*faissNamespace // 命名空间到索引的映射
+	documents      map[string]Document        // 文档存储
+	fileToDocIDs   map[string][]string        // 文件ID到文档ID的映射
+	indexPath      string                     // 索引文件路径
+	metaPath       string                     // 元数据文件路径
+	dimension      int                        // 向量维度
+	distanceType   DistanceType               // 距离计算类型
+	saveOnClose    bool                       // 关闭时是否保存
+	autoSave       bool                       // 是否自动保存
+	autoSaveCount  int                        // 未保存的变更数达到该值时提前保存
+	operationCount int                        // 累计的变更操作数
+	queryCache     *queryCache                // 查询结果缓存
+	spec           faissIndexSpec             // 配置的索引类型及参数
+	logger         *logrus.Logger             // 日志记录器
+
+	// 后台保存：写操作只记录变更数，由后台goroutine按间隔或变更数阈值保存
+	saveMu    sync.Mutex    // 串行化保存
+	saveSeq   uint64        // 最近一次保存的序号，由saveMu保护
+	savedOps  atomic.Int64  // 最近一次保存时的operationCount
+	saveKick  chan struct{} // 通知后台goroutine立即保存，未启动后台保存时为nil
+	saveStop  chan struct{} // 关闭时通知后台goroutine退出
+	saveDone  chan struct{} // 后台goroutine已退出
+	closeOnce sync.Once
+}
+
+// faissNamespace 单个命名空间的Faiss索引
+type faissNamespace struct {
+	index        faiss.Index    // Faiss索引，尚未从磁盘加载时为nil
+	idToPosition map[string]int // 文档ID到向量位置的映射
+	activeType   string         // 当前实际使用的索引类型
+}
+
+// newFaissNamespace 创建空的命名空间
+func newFaissNamespace() *faissNamespace {
+	return &faissNamespace{
+		idToPosition: make(map[string]int),
+		activeType:   faissIndexFlat,
+	}
 }
 
 // NewFaissRepository 创建新的Faiss向量仓库
@@ -51,6 +85,12 @@ func NewFaissRepository(config Config) (Repository, error) {
 		distType = Cosine // 默认使用余弦距离
 	}
 
+	// 解析索引类型及参数
+	spec, err := newFaissIndexSpec(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建基础仓库
 	base := NewBaseRepository(config.Dimension, distType)
 
@@ -64,24 +104,41 @@ func NewFaissRepository(config Config) (Repository, error) {
 	// 创建仓库实例
 	repo := &FaissRepository{
 		BaseRepository: base,
+		namespaces:     map[string]*faissNamespace{"": newFaissNamespace()},
 		documents:      make(map[string]Document),
 		fileToDocIDs:   make(map[string][]string),
-		idToPosition:   make(map[string]int),
 		indexPath:      indexPath,
 		metaPath:       metaPath,
 		dimension:      config.Dimension,
 		distanceType:   distType,
 		saveOnClose:    true,
 		autoSave:       true,
-		autoSaveCount:  100,                            // 默认每100次操作自动保存一次
-		queryCache:     NewTimedCache(5 * time.Minute), // 查询缓存5分钟
-		lastSave:       time.Now(),
+		autoSaveCount:  100, // 默认每100次操作提前保存一次
+		queryCache:     newQueryCache(queryCacheSize(config), defaultQueryCacheTTL),
+		spec:           spec,
+		logger:         config.Logger,
+	}
+	if repo.logger == nil {
+		repo.logger = logrus.StandardLogger()
 	}
 
 	var index faiss.Index
-	var err error
 
-	// 尝试从文件加载索引
+	// 恢复上次中断的保存，保证索引文件和元数据来自同一次保存
+	if indexPath != "" && !config.InMemory {
+		seq, err := recoverFaissSave(indexPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover Faiss index: %v", err)
+		}
+		if seq > 0 {
+			repo.logger.WithFields(logrus.Fields{
+				"save_seq":   seq,
+				"index_path": indexPath,
+			}).Warn("Completed interrupted save of Faiss index")
+		}
+	}
+
+	// 尝试从文件加载默认命名空间的索引，其他命名空间的索引在首次访问时加载
 	if indexPath != "" && !config.InMemory && fileExists(indexPath) {
 		// 加载预先存储的索引文件
 		index, err = faiss.ReadIndex(indexPath, 0)
@@ -95,6 +152,18 @@ func NewFaissRepository(config Config) (Repository, error) {
 			} else {
 				return nil, fmt.Errorf("failed to load Faiss index: %v", err)
 			}
+		} else if d := index.D(); d != config.Dimension {
+			// 索引文件由其他维度的嵌入模型生成，继续使用会在写入和检索时失败；
+			// 空索引没有需要保留的数据，允许创建时按配置的维度重建
+			empty := index.Ntotal() == 0
+			index.Delete()
+			if !empty || !config.CreateIfNotExists {
+				return nil, fmt.Errorf("%w: index file %s has dimension %d, configured %d", ErrInvalidDimension, indexPath, d, config.Dimension)
+			}
+			index, err = createFaissIndex(config.Dimension, distType)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create Faiss index: %v", err)
+			}
 		} else {
 			// 加载元数据
 			if err := repo.loadMetadata(metaPath); err != nil {
@@ -110,30 +179,121 @@ func NewFaissRepository(config Config) (Repository, error) {
 		}
 	}
 
-	repo.index = index
+	defaultNS, ok := repo.namespaces[""]
+	if !ok {
+		defaultNS = newFaissNamespace()
+		repo.namespaces[""] = defaultNS
+	}
+	defaultNS.index = index
+	if err := repo.prepareNamespace(defaultNS); err != nil {
+		return nil, err
+	}
+
+	// 加载的数据与磁盘一致，之后的变更由后台goroutine保存
+	repo.savedOps.Store(int64(repo.operationCount))
+	if repo.indexPath != "" && repo.autoSave {
+		interval := config.SaveInterval
+		if interval <= 0 {
+			interval = DefaultSaveInterval
+		}
+		repo.saveKick = make(chan struct{}, 1)
+		repo.saveStop = make(chan struct{})
+		repo.saveDone = make(chan struct{})
+		go repo.saveLoop(interval)
+	}
 
 	return repo, nil
 }
 
-// 创建Faiss索引时根据不同的向量维度和数据量动态选择索引类型
-func createFaissIndex(dimension int, distType DistanceType) (faiss.Index, error) {
-	var metric int
+// namespace 返回命名空间的索引，不存在时创建，索引尚未加载时从磁盘加载，调用方需持有写锁
+func (r *FaissRepository) namespace(name string) (*faissNamespace, error) {
+	ns, ok := r.namespaces[name]
+	if !ok {
+		ns = newFaissNamespace()
+		r.namespaces[name] = ns
+	}
+	if ns.index != nil {
+		return ns, nil
+	}
+
+	// 优先读取保存的索引文件，文件不存在或读取失败时用已保存的向量重建
+	if path := r.namespaceIndexPath(name); path != "" && len(ns.idToPosition) > 0 && fileExists(path) {
+		index, err := faiss.ReadIndex(path, 0)
+		if err == nil {
+			ns.index = index
+			if err := r.prepareNamespace(ns); err != nil {
+				return nil, err
+			}
+			return ns, nil
+		}
+		fmt.Printf("Warning: Failed to load index of namespace %q, rebuilding: %v\n", name, err)
+	}
+	if err := r.rebuildIndex(ns, r.targetIndexType(ns)); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// prepareNamespace 已有索引类型与配置不一致（或IVF索引已有足够的训练数据）时重建索引，否则设置查询参数
+func (r *FaissRepository) prepareNamespace(ns *faissNamespace) error {
+	if target := r.targetIndexType(ns); ns.activeType != target {
+		return r.rebuildIndex(ns, target)
+	}
+	if err := r.spec.applySearchParams(ns.index, ns.activeType); err != nil {
+		return fmt.Errorf("failed to set faiss search parameters: %v", err)
+	}
+	return nil
+}
+
+// namespaceIndexPath 返回命名空间索引文件的路径，默认命名空间沿用配置的路径
+func (r *FaissRepository) namespaceIndexPath(name string) string {
+	if r.indexPath == "" || name == "" {
+		return r.indexPath
+	}
+	return r.indexPath + ".ns." + url.PathEscape(name)
+}
+
+// loadedNamespaces 确保指定的命名空间索引都已加载
+// 所有索引都已加载时只持有读锁，否则获取写锁加载
+func (r *FaissRepository) loadedNamespaces(names []string) error {
+	r.mu.RLock()
+	missing := false
+	for _, name := range names {
+		if ns, ok := r.namespaces[name]; ok && ns.index == nil {
+			missing = true
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if !missing {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range names {
+		if _, ok := r.namespaces[name]; !ok {
+			continue
+		}
+		if _, err := r.namespace(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	// 根据距离类型选择合适的度量方式
-	switch distType {
-	case Cosine, DotProduct:
-		// 对于余弦距离和点积，使用内积度量方式
-		metric = int(faiss.MetricInnerProduct)
-	case Euclidean:
-		// 对于欧几里得距离，使用L2度量方式
-		metric = int(faiss.MetricL2)
-	default:
-		// 默认使用L2度量方式
-		metric = int(faiss.MetricL2)
+// removeFromNamespace 从文档所在命名空间的位置映射中移除文档，调用方需持有写锁
+// 向量本身仍保留在Faiss索引中，重建索引时才会清除
+func (r *FaissRepository) removeFromNamespace(doc Document) {
+	if ns, ok := r.namespaces[doc.Namespace]; ok {
+		delete(ns.idToPosition, doc.ID)
 	}
+}
 
-	// 创建扁平索引（最简单但速度适中的索引类型）
-	return faiss.NewIndexFlat(dimension, metric)
+// createFaissIndex 创建扁平索引（精确搜索）
+// 配置了HNSW或IVF索引时由rebuildIndex创建对应类型的索引
+func createFaissIndex(dimension int, distType DistanceType) (faiss.Index, error) {
+	return faiss.NewIndexFlat(dimension, faissMetric(distType))
 }
 
 // Add 添加单个文档到仓库
@@ -161,38 +321,96 @@ func (r *FaissRepository) Add(doc Document) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// 获取文档所在命名空间的索引
+	ns, err := r.namespace(doc.Namespace)
+	if err != nil {
+		return err
+	}
+
 	// 获取当前向量总数作为新向量的位置
-	nextPos := int(r.index.Ntotal())
+	nextPos := int(ns.index.Ntotal())
 
 	// 添加向量到Faiss索引
-	err := r.index.Add(doc.Vector)
+	err = ns.index.Add(doc.Vector)
 	if err != nil {
 		return fmt.Errorf("failed to add vector to Faiss index: %v", err)
 	}
 
+	// 同ID文档已存在时从原命名空间移除
+	if old, exists := r.documents[doc.ID]; exists {
+		r.removeFromNamespace(old)
+		r.queryCache.invalidate(fileCacheTag(old.FileID))
+	}
+	r.queryCache.invalidate(scopeCacheTag(""), scopeCacheTag(doc.FileID))
+
 	// 更新映射关系
 	r.documents[doc.ID] = doc
-	r.idToPosition[doc.ID] = nextPos
+	ns.idToPosition[doc.ID] = nextPos
 	r.fileToDocIDs[doc.FileID] = append(r.fileToDocIDs[doc.FileID], doc.ID)
-	r.operationCount++
+	r.markDirty(1)
+
+	// 向量数量达到训练要求时切换到IVF索引
+	return r.ensureIndexType(ns)
+}
 
-	// 如果启用了自动保存，检查是否需要保存
-	if r.autoSave && r.shouldSave() {
-		if err := r.saveIndex(); err != nil {
-			// 保存失败只记录错误，不影响添加操作
-			fmt.Printf("Warning: Failed to auto save index: %v\n", err)
+// markDirty 记录n次变更，未保存的变更数达到阈值时通知后台goroutine尽快保存，调用方持有写锁
+func (r *FaissRepository) markDirty(n int) {
+	r.operationCount += n
+	if r.autoSave && int64(r.operationCount)-r.savedOps.Load() >= int64(r.autoSaveCount) {
+		select {
+		case r.saveKick <- struct{}{}:
+		default:
 		}
-		r.operationCount = 0
-		r.lastSave = time.Now()
 	}
+}
 
+// saveLoop 后台保存循环，每隔interval或收到通知时保存未持久化的变更
+func (r *FaissRepository) saveLoop(interval time.Duration) {
+	defer close(r.saveDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.saveStop:
+			return
+		case <-ticker.C:
+		case <-r.saveKick:
+		}
+		if err := r.persist(false); err != nil {
+			// 保存失败只记录错误，下一次保存时重试
+			r.logger.WithError(err).Warn("Failed to auto save Faiss index")
+		}
+	}
+}
+
+// persist 将索引和元数据写入磁盘，force为false时只在有未保存的变更时写入
+// 写入期间持有读锁：检索照常进行，写操作等待保存完成
+func (r *FaissRepository) persist(force bool) error {
+	if r.indexPath == "" {
+		return nil
+	}
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.RLock()
+	ops := r.operationCount
+	if !force && int64(ops) == r.savedOps.Load() {
+		r.mu.RUnlock()
+		return nil
+	}
+	err := r.saveIndex()
+	r.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	r.savedOps.Store(int64(ops))
 	return nil
 }
 
-// shouldSave 确定是否需要保存索引
-func (r *FaissRepository) shouldSave() bool {
-	// 操作次数超过阈值或上次保存时间超过1小时
-	return r.operationCount >= r.autoSaveCount || time.Since(r.lastSave) > time.Hour
+// Flush 立即保存尚未持久化的变更
+func (r *FaissRepository) Flush() error {
+	return r.persist(false)
 }
 
 // AddBatch 批量添加文档到仓库
@@ -201,8 +419,9 @@ func (r *FaissRepository) AddBatch(docs []Document) error {
 		return nil
 	}
 
-	// 预处理所有向量
-	vectors := make([][]float32, len(docs))
+	// 预处理所有向量，并按命名空间分组
+	var order []string
+	groups := make(map[string][]int)
 	for i := range docs {
 		// 验证向量
 		if err := ValidateVector(docs[i].Vector, r.dimension); err != nil {
@@ -214,7 +433,10 @@ func (r *FaissRepository) AddBatch(docs []Document) error {
 			docs[i].Vector = normalizeVector(docs[i].Vector)
 		}
 
-		vectors[i] = docs[i].Vector
+		if _, ok := groups[docs[i].Namespace]; !ok {
+			order = append(order, docs[i].Namespace)
+		}
+		groups[docs[i].Namespace] = append(groups[docs[i].Namespace], i)
 
 		// 设置创建时间（如果未设置）
 		if docs[i].CreatedAt.IsZero() {
@@ -230,35 +452,43 @@ func (r *FaissRepository) AddBatch(docs []Document) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// 记录起始位置
-	startPos := int(r.index.Ntotal())
-
-	// 使用循环添加向量
-	for i, vector := range vectors {
-		if err := r.index.Add(vector); err != nil {
-			return fmt.Errorf("failed to add vector %d to Faiss index: %v", i, err)
+	for _, name := range order {
+		ns, err := r.namespace(name)
+		if err != nil {
+			return err
 		}
-	}
 
-	// 更新映射关系
-	for i, doc := range docs {
-		position := startPos + i
-		r.documents[doc.ID] = doc
-		r.idToPosition[doc.ID] = position
-		r.fileToDocIDs[doc.FileID] = append(r.fileToDocIDs[doc.FileID], doc.ID)
-	}
+		// 记录起始位置
+		startPos := int(ns.index.Ntotal())
+
+		// 使用循环添加向量
+		for _, i := range groups[name] {
+			if err := ns.index.Add(docs[i].Vector); err != nil {
+				return fmt.Errorf("failed to add vector %d to Faiss index: %v", i, err)
+			}
+		}
 
-	r.operationCount += len(docs)
+		// 更新映射关系
+		for j, i := range groups[name] {
+			doc := docs[i]
+			if old, exists := r.documents[doc.ID]; exists {
+				r.removeFromNamespace(old)
+				r.queryCache.invalidate(fileCacheTag(old.FileID))
+			}
+			r.queryCache.invalidate(scopeCacheTag(""), scopeCacheTag(doc.FileID))
+			r.documents[doc.ID] = doc
+			ns.idToPosition[doc.ID] = startPos + j
+			r.fileToDocIDs[doc.FileID] = append(r.fileToDocIDs[doc.FileID], doc.ID)
+		}
 
-	// 如果启用了自动保存，检查是否需要保存
-	if r.autoSave && r.shouldSave() {
-		if err := r.saveIndex(); err != nil {
-			fmt.Printf("Warning: Failed to auto save index: %v\n", err)
+		// 向量数量达到训练要求时切换到IVF索引
+		if err := r.ensureIndexType(ns); err != nil {
+			return err
 		}
-		r.operationCount = 0
-		r.lastSave = time.Now()
 	}
 
+	r.markDirty(len(docs))
+
 	return nil
 }
 
@@ -288,7 +518,7 @@ func (r *FaissRepository) Delete(id string) error {
 
 	// 在内存中清除对应映射
 	delete(r.documents, id)
-	delete(r.idToPosition, id)
+	r.removeFromNamespace(doc)
 
 	// 更新文件ID到文档ID的映射
 	if fileIDs, ok := r.fileToDocIDs[doc.FileID]; ok {
@@ -307,10 +537,10 @@ func (r *FaissRepository) Delete(id string) error {
 	}
 
 	// 记录操作
-	r.operationCount++
+	r.markDirty(1)
 
-	// 清除与该文档相关的查询缓存
-	r.clearRelatedCaches(doc.FileID)
+	// 结果中包含该文件的查询缓存失效
+	r.queryCache.invalidate(fileCacheTag(doc.FileID))
 
 	return nil
 }
@@ -329,26 +559,22 @@ func (r *FaissRepository) DeleteByFileID(fileID string) error {
 
 	// 删除所有关联的文档记录
 	for _, id := range docIDs {
-		delete(r.documents, id)
-		delete(r.idToPosition, id)
+		if doc, ok := r.documents[id]; ok {
+			r.removeFromNamespace(doc)
+			delete(r.documents, id)
+		}
 	}
 
 	// 删除文件映射
 	delete(r.fileToDocIDs, fileID)
-	r.operationCount += len(docIDs)
+	r.markDirty(len(docIDs))
 
-	// 清除与该文件相关的查询缓存
-	r.clearRelatedCaches(fileID)
+	// 结果中包含该文件的查询缓存失效
+	r.queryCache.invalidate(fileCacheTag(fileID))
 
 	return nil
 }
 
-// clearRelatedCaches 清除与特定文件相关的查询缓存
-func (r *FaissRepository) clearRelatedCaches(fileID string) {
-	// 简单实现：清空整个缓存
-	r.queryCache = NewTimedCache(5 * time.Minute)
-}
-
 // Search 相似度搜索
 func (r *FaissRepository) Search(vector []float32, filter SearchFilter) ([]SearchResult, error) {
 	// 验证查询向量
@@ -361,23 +587,57 @@ func (r *FaissRepository) Search(vector []float32, filter SearchFilter) ([]Searc
 		vector = normalizeVector(vector)
 	}
 
-	// 基于向量和过滤器生成缓存键
+	// 基于向量和过滤器生成缓存键，缓存中保存深拷贝，调用方修改结果不影响缓存
 	cacheKey := generateCacheKey(vector, filter)
-	// fmt.Printf("generate cache key: %s\n", cacheKey)
-
-	// 尝试从缓存获取结果
-	if cachedValue, found := r.queryCache.Get(cacheKey); found {
-		// fmt.Println("cache hit!")
-		if results, ok := cachedValue.([]SearchResult); ok {
-			// 检查缓存的元数据
-			if len(results) > 0 {
-				fmt.Printf("cached document ID: %s, metadata: %+v\n", results[0].Document.ID, results[0].Document.Metadata)
-			}
-			return results, nil
+	if results, found := r.queryCache.get(cacheKey); found {
+		return deepCopyResults(results), nil
+	}
+
+	// 查询期间发生的数据变更会递增代数，此时结果可能已过时，不写入缓存
+	generation := r.queryCache.currentGeneration()
+	batch, err := r.searchBatch([][]float32{vector}, filter)
+	if err != nil {
+		return nil, err
+	}
+	results := batch[0]
+	r.queryCache.set(cacheKey, deepCopyResults(results), searchCacheTags(filter, results), generation)
+
+	return results, nil
+}
+
+// SearchBatch 批量相似度搜索
+// 每个命名空间的索引只调用一次Faiss搜索，由Faiss并行处理所有查询向量；批量搜索不使用查询缓存
+func (r *FaissRepository) SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	queries := make([][]float32, len(vectors))
+	for i, vector := range vectors {
+		if err := ValidateVector(vector, r.dimension); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
 		}
-		// fmt.Println("failed to cast cached value to SearchResult slice")
-	} else {
-		// fmt.Println("cache miss!")
+		// 对于余弦距离，需要对查询向量进行归一化
+		if r.distanceType == Cosine {
+			vector = normalizeVector(vector)
+		}
+		queries[i] = vector
+	}
+	return r.searchBatch(queries, filter)
+}
+
+// searchBatch 在相关命名空间的索引中搜索已归一化的查询向量
+func (r *FaissRepository) searchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	batch := make([][]SearchResult, len(vectors))
+	for i := range batch {
+		batch[i] = []SearchResult{}
+	}
+	if len(vectors) == 0 {
+		return batch, nil
+	}
+
+	// 确定需要检索的命名空间并加载其索引
+	r.mu.RLock()
+	names := r.searchNamespaces(filter)
+	r.mu.RUnlock()
+	if err := r.loadedNamespaces(names); err != nil {
+		return nil, err
 	}
 
 	r.mu.RLock()
@@ -385,7 +645,7 @@ func (r *FaissRepository) Search(vector []float32, filter SearchFilter) ([]Searc
 
 	// 如果没有文档，直接返回空结果
 	if len(r.documents) == 0 {
-		return []SearchResult{}, nil
+		return batch, nil
 	}
 
 	// 确定要检索的向量数量
@@ -394,36 +654,100 @@ func (r *FaissRepository) Search(vector []float32, filter SearchFilter) ([]Searc
 		k = 10 // 默认返回前10个结果
 	}
 
-	// 查询更多结果以确保过滤后有足够的结果
-	queryLimit := k * 4
-	total := int(r.index.Ntotal())
-	if queryLimit > total {
-		queryLimit = total
+	// 排除的文件的向量会占用检索名额，相应多取
+	excludedVectors := 0
+	for _, fileID := range filter.ExcludeFileIDs {
+		excludedVectors += len(r.fileToDocIDs[fileID])
 	}
-	if queryLimit == 0 {
-		return []SearchResult{}, nil
+
+	// 将所有查询向量拼接为一个矩阵
+	matrix := make([]float32, 0, len(vectors)*r.dimension)
+	for _, vector := range vectors {
+		matrix = append(matrix, vector...)
 	}
 
-	// 使用Faiss执行搜索，获取距离和索引
-	distances, indices, err := r.index.Search(vector, int64(queryLimit))
-	if err != nil {
-		return nil, fmt.Errorf("failed to search Faiss index: %v", err)
+	for _, name := range names {
+		ns, ok := r.namespaces[name]
+		if !ok || ns.index == nil || len(ns.idToPosition) == 0 {
+			continue
+		}
+
+		// 查询更多结果以确保过滤后有足够的结果
+		queryLimit := k*4 + excludedVectors
+		total := int(ns.index.Ntotal())
+		if queryLimit > total {
+			queryLimit = total
+		}
+		if queryLimit == 0 {
+			continue
+		}
+
+		// 使用Faiss执行搜索，获取距离和索引，结果按查询依次排列，每个查询queryLimit个
+		distances, indices, err := ns.index.Search(matrix, int64(queryLimit))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search Faiss index: %v", err)
+		}
+
+		// 处理搜索结果
+		for i := range vectors {
+			from, to := i*queryLimit, (i+1)*queryLimit
+			nsResults, err := r.processSearchResults(ns, distances[from:to], indices[from:to], filter)
+			if err != nil {
+				return nil, err
+			}
+			batch[i] = append(batch[i], nsResults...)
+		}
 	}
 
-	// 处理搜索结果
-	results, err := r.processSearchResults(distances, indices, filter)
-	if err != nil {
-		return nil, err
+	// 合并多个命名空间的结果
+	if len(names) > 1 {
+		for i, results := range batch {
+			SortSearchResults(results)
+			if filter.MaxResults > 0 && len(results) > filter.MaxResults {
+				batch[i] = results[:filter.MaxResults]
+			}
+		}
 	}
 
-	// 缓存结果 - 关键修改：存入深拷贝而不是原引用
-	r.queryCache.Set(cacheKey, deepCopyResults(results))
+	return batch, nil
+}
 
-	return results, nil
+// searchNamespaces 返回搜索需要检索的命名空间，调用方需持有读锁
+// 未指定命名空间但按文件过滤时，只检索这些文件所在的命名空间
+func (r *FaissRepository) searchNamespaces(filter SearchFilter) []string {
+	if filter.Namespace != "" {
+		if _, ok := r.namespaces[filter.Namespace]; !ok {
+			return nil
+		}
+		return []string{filter.Namespace}
+	}
+
+	seen := make(map[string]bool)
+	if len(filter.FileIDs) > 0 {
+		for _, fileID := range filter.FileIDs {
+			for _, id := range r.fileToDocIDs[fileID] {
+				if doc, ok := r.documents[id]; ok {
+					seen[doc.Namespace] = true
+				}
+			}
+		}
+	} else {
+		for name := range r.namespaces {
+			seen[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // processSearchResults 处理Faiss返回的搜索结果
 func (r *FaissRepository) processSearchResults(
+	ns *faissNamespace,
 	distances []float32,
 	indices []int64,
 	filter SearchFilter,
@@ -437,6 +761,8 @@ func (r *FaissRepository) processSearchResults(
 	hasFileFilter := len(filter.FileIDs) > 0
 	hasMetaFilter := len(filter.Metadata) > 0
 
+	excluded := excludedFiles(filter)
+
 	// 文件ID过滤器的快速查找表
 	fileFilter := make(map[string]bool)
 	if hasFileFilter {
@@ -456,7 +782,7 @@ func (r *FaissRepository) processSearchResults(
 		var docID string
 		found := false
 
-		for id, pos := range r.idToPosition {
+		for id, pos := range ns.idToPosition {
 			if pos == int(idx) {
 				docID = id
 				found = true
@@ -475,7 +801,7 @@ func (r *FaissRepository) processSearchResults(
 		}
 
 		// 应用文件ID过滤器
-		if hasFileFilter && !fileFilter[doc.FileID] {
+		if (hasFileFilter && !fileFilter[doc.FileID]) || excluded[doc.FileID] {
 			continue
 		}
 
@@ -533,17 +859,58 @@ func (r *FaissRepository) processSearchResults(
 
 // generateCacheKey 为搜索查询生成缓存键
 func generateCacheKey(vector []float32, filter SearchFilter) string {
-	// 简化实现：使用向量的前几个值和长度作为缓存键的一部分
-	key := fmt.Sprintf("v%d_%f_%f", len(vector), vector[0], vector[1])
+	// 完整的向量和全部过滤条件参与哈希，避免不同查询共用缓存
+	h := fnv.New128a()
+	buf := make([]byte, 4)
+	for _, v := range vector {
+		binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+		h.Write(buf)
+	}
+	fileIDs := append([]string(nil), filter.FileIDs...)
+	sort.Strings(fileIDs)
+	excluded := append([]string(nil), filter.ExcludeFileIDs...)
+	sort.Strings(excluded)
+	// fmt按键排序输出map，元数据条件的顺序不影响缓存键
+	fmt.Fprintf(h, "|%q|%q|%q|%v|%g|%d", filter.Namespace, fileIDs, excluded, filter.Metadata, filter.MinScore, filter.MaxResults)
+	return hex.EncodeToString(h.Sum(nil))
+}
 
-	// 添加过滤条件信息
-	if len(filter.FileIDs) > 0 {
-		for _, fileID := range filter.FileIDs {
-			key += "_f" + fileID[:min(8, len(fileID))]
+// fileCacheTag 结果中包含指定文件的查询缓存标签，文件的向量被删除或覆盖时失效
+func fileCacheTag(fileID string) string {
+	return "file:" + fileID
+}
+
+// scopeCacheTag 查询范围包含指定文件的缓存标签，文件新增向量时失效；
+// 未限定文件的查询使用空文件ID，任何文件新增向量时都失效
+func scopeCacheTag(fileID string) string {
+	return "scope:" + fileID
+}
+
+// searchCacheTags 生成查询结果的缓存标签
+func searchCacheTags(filter SearchFilter, results []SearchResult) []string {
+	tags := make([]string, 0, len(filter.FileIDs)+len(results)+1)
+	if len(filter.FileIDs) == 0 {
+		tags = append(tags, scopeCacheTag(""))
+	}
+	for _, fileID := range filter.FileIDs {
+		tags = append(tags, scopeCacheTag(fileID))
+	}
+	seen := make(map[string]bool, len(results))
+	for _, result := range results {
+		if !seen[result.Document.FileID] {
+			seen[result.Document.FileID] = true
+			tags = append(tags, fileCacheTag(result.Document.FileID))
 		}
 	}
+	return tags
+}
 
-	return key
+// queryCacheSize 返回配置的查询缓存容量，0使用默认值，负数禁用缓存
+func queryCacheSize(config Config) int {
+	if config.QueryCacheSize == 0 {
+		return DefaultQueryCacheSize
+	}
+	return config.QueryCacheSize
 }
 
 // 添加一个新的辅助函数来创建结果的深拷贝
@@ -587,14 +954,49 @@ func (r *FaissRepository) Count() (int, error) {
 	return len(r.documents), nil
 }
 
-// Close 关闭仓库
+// Describe 返回向量数据库的配置和状态
+// 内存占用包括段落副本和已加载的索引，最近保存时间取索引文件的修改时间
+func (r *FaissRepository) Describe() (Info, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cacheStats := r.queryCache.snapshot()
+	info := Info{
+		Type:       "faiss",
+		IndexType:  r.spec.Type,
+		Dimension:  r.dimension,
+		Distance:   r.distanceType,
+		Normalized: r.distanceType == Cosine,
+		Count:      len(r.documents),
+		QueryCache: &cacheStats,
+	}
+	for _, doc := range r.documents {
+		info.MemoryBytes += documentBytes(doc)
+	}
+	for _, ns := range r.namespaces {
+		if ns.index != nil {
+			info.MemoryBytes += ns.index.Ntotal() * int64(r.dimension) * 4
+		}
+	}
+	if r.indexPath != "" {
+		if stat, err := os.Stat(r.indexPath); err == nil {
+			info.LastSave = stat.ModTime()
+		}
+	}
+	return info, nil
+}
+
+// Close 关闭仓库，停止后台保存并保存索引和元数据
 func (r *FaissRepository) Close() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	r.closeOnce.Do(func() {
+		if r.saveStop != nil {
+			close(r.saveStop)
+			<-r.saveDone
+		}
+	})
 
-	// 如果设置了保存标志且有索引路径，保存索引和元数据
-	if r.saveOnClose && r.indexPath != "" {
-		if err := r.saveIndex(); err != nil {
+	if r.saveOnClose {
+		if err := r.persist(true); err != nil {
 			return fmt.Errorf("failed to save index on close: %v", err)
 		}
 	}
@@ -607,6 +1009,34 @@ func (r *FaissRepository) GetDimension() int {
 	return r.dimension
 }
 
+// FileSegmentCounts 返回文件ID到段落数的映射
+func (r *FaissRepository) FileSegmentCounts() (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int, len(r.fileToDocIDs))
+	for fileID, ids := range r.fileToDocIDs {
+		counts[fileID] = len(ids)
+	}
+	return counts, nil
+}
+
+// Export 将全部文档导出为快照
+func (r *FaissRepository) Export(w io.Writer) error {
+	r.mu.RLock()
+	docs := make([]Document, 0, len(r.documents))
+	for _, doc := range r.documents {
+		docs = append(docs, doc)
+	}
+	r.mu.RUnlock()
+
+	return writeDocumentsSnapshot(w, r.dimension, r.distanceType, docs)
+}
+
+// Import 从快照导入文档
+func (r *FaissRepository) Import(reader io.Reader) (int, error) {
+	return importSnapshot(r, reader)
+}
+
 // saveIndex 保存索引和文档数据到文件
 func (r *FaissRepository) saveIndex() error {
 	// 如果没有指定索引路径，不执行保存
@@ -619,33 +1049,67 @@ func (r *FaissRepository) saveIndex() error {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	// 保存Faiss索引
-	if err := faiss.WriteIndex(r.index, r.indexPath); err != nil {
-		return fmt.Errorf("failed to write Faiss index: %v", err)
+	// 各命名空间的Faiss索引和元数据先写入临时文件，未加载的索引在磁盘上没有变化
+	journal := faissJournal{Sequence: r.saveSeq + 1}
+	for name, ns := range r.namespaces {
+		if ns.index == nil {
+			continue
+		}
+		path := r.namespaceIndexPath(name)
+		if err := faiss.WriteIndex(ns.index, path+faissTempSuffix); err != nil {
+			return fmt.Errorf("failed to write Faiss index: %v", err)
+		}
+		if err := syncFile(path + faissTempSuffix); err != nil {
+			return fmt.Errorf("failed to sync Faiss index: %v", err)
+		}
+		journal.Files = append(journal.Files, path)
+	}
+	if r.metaPath != "" {
+		if err := r.saveMetadata(journal.Sequence); err != nil {
+			return err
+		}
+		journal.Files = append(journal.Files, r.metaPath)
 	}
 
-	// 保存元数据
-	return r.saveMetadata()
+	// 提交后替换为正式文件，提交前崩溃时保留上一次完整保存的文件
+	if err := commitFaissSave(r.indexPath, journal); err != nil {
+		return err
+	}
+	r.saveSeq = journal.Sequence
+	return nil
 }
 
-// saveMetadata 保存文档元数据到文件
-func (r *FaissRepository) saveMetadata() error {
-	// 如果没有指定元数据路径，不执行保存
-	if r.metaPath == "" {
-		return nil
+// saveMetadata 将文档元数据写入临时文件，由saveIndex提交
+func (r *FaissRepository) saveMetadata(seq uint64) error {
+	// 默认命名空间沿用原有字段，兼容旧版本的元数据文件
+	defaultNS, ok := r.namespaces[""]
+	if !ok {
+		defaultNS = newFaissNamespace()
+	}
+	namespaces := make(map[string]faissNamespaceMeta)
+	for name, ns := range r.namespaces {
+		if name != "" {
+			namespaces[name] = faissNamespaceMeta{IDToPosition: ns.idToPosition, IndexType: ns.activeType}
+		}
 	}
 
 	// 准备元数据结构
 	metadata := struct {
-		Documents      map[string]Document `json:"documents"`
-		FileToDocIDs   map[string][]string `json:"file_to_doc_ids"`
-		IDToPosition   map[string]int      `json:"id_to_position"`
-		OperationCount int                 `json:"operation_count"`
+		Documents      map[string]Document           `json:"documents"`
+		FileToDocIDs   map[string][]string           `json:"file_to_doc_ids"`
+		IDToPosition   map[string]int                `json:"id_to_position"`
+		OperationCount int                           `json:"operation_count"`
+		IndexType      string                        `json:"index_type"`
+		Namespaces     map[string]faissNamespaceMeta `json:"namespaces,omitempty"`
+		Sequence       uint64                        `json:"sequence,omitempty"`
 	}{
 		Documents:      r.documents,
 		FileToDocIDs:   r.fileToDocIDs,
-		IDToPosition:   r.idToPosition,
+		IDToPosition:   defaultNS.idToPosition,
 		OperationCount: r.operationCount,
+		IndexType:      defaultNS.activeType,
+		Namespaces:     namespaces,
+		Sequence:       seq,
 	}
 
 	// 序列化为JSON
@@ -654,8 +1118,8 @@ func (r *FaissRepository) saveMetadata() error {
 		return fmt.Errorf("failed to marshal metadata: %v", err)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(r.metaPath, data, 0644); err != nil {
+	// 写入临时文件
+	if err := writeFileSync(r.metaPath+faissTempSuffix, data); err != nil {
 		return fmt.Errorf("failed to write metadata file: %v", err)
 	}
 
@@ -677,10 +1141,13 @@ func (r *FaissRepository) loadMetadata(path string) error {
 
 	// 准备元数据结构
 	metadata := struct {
-		Documents      map[string]Document `json:"documents"`
-		FileToDocIDs   map[string][]string `json:"file_to_doc_ids"`
-		IDToPosition   map[string]int      `json:"id_to_position"`
-		OperationCount int                 `json:"operation_count"`
+		Documents      map[string]Document           `json:"documents"`
+		FileToDocIDs   map[string][]string           `json:"file_to_doc_ids"`
+		IDToPosition   map[string]int                `json:"id_to_position"`
+		OperationCount int                           `json:"operation_count"`
+		IndexType      string                        `json:"index_type"`
+		Namespaces     map[string]faissNamespaceMeta `json:"namespaces,omitempty"`
+		Sequence       uint64                        `json:"sequence,omitempty"`
 	}{}
 
 	// 解析JSON
@@ -691,12 +1158,40 @@ func (r *FaissRepository) loadMetadata(path string) error {
 	// 应用加载的元数据
 	r.documents = metadata.Documents
 	r.fileToDocIDs = metadata.FileToDocIDs
-	r.idToPosition = metadata.IDToPosition
 	r.operationCount = metadata.OperationCount
+	r.saveSeq = metadata.Sequence
+
+	// 命名空间的索引在首次访问时才从磁盘加载
+	r.namespaces = make(map[string]*faissNamespace, len(metadata.Namespaces)+1)
+	r.namespaces[""] = loadedFaissNamespace(faissNamespaceMeta{
+		IDToPosition: metadata.IDToPosition,
+		IndexType:    metadata.IndexType,
+	})
+	for name, meta := range metadata.Namespaces {
+		r.namespaces[name] = loadedFaissNamespace(meta)
+	}
 
 	return nil
 }
 
+// faissNamespaceMeta 元数据文件中命名空间的索引信息
+type faissNamespaceMeta struct {
+	IDToPosition map[string]int `json:"id_to_position"`
+	IndexType    string         `json:"index_type"`
+}
+
+// loadedFaissNamespace 根据元数据创建尚未加载索引的命名空间
+func loadedFaissNamespace(meta faissNamespaceMeta) *faissNamespace {
+	ns := newFaissNamespace()
+	if meta.IDToPosition != nil {
+		ns.idToPosition = meta.IDToPosition
+	}
+	if meta.IndexType != "" {
+		ns.activeType = meta.IndexType
+	}
+	return ns
+}
+
 // fileExists 检查文件是否存在
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -0,0 +1,220 @@
+package vectordb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataIntelligenceCrew/go-faiss"
+)
+
+// Faiss索引类型
+const (
+	faissIndexFlat  = "flat"  // 精确搜索
+	faissIndexHNSW  = "hnsw"  // 分层可导航小世界图，无需训练
+	faissIndexIVF   = "ivf"   // 倒排文件，需要训练
+	faissIndexIVFPQ = "ivfpq" // 倒排文件加乘积量化，需要训练，占用内存更少
+)
+
+const (
+	// defaultIVFTrainFactor 训练IVF索引默认需要的向量数为聚类中心数的倍数
+	defaultIVFTrainFactor = 39
+	// defaultPQM 乘积量化默认的子向量数量
+	defaultPQM = 8
+)
+
+// faissIndexSpec Faiss索引类型及参数
+type faissIndexSpec struct {
+	Type      string // 索引类型
+	HNSWM     int    // HNSW每个节点的最大连接数
+	EfSearch  int    // HNSW查询时的候选列表大小，0表示使用Faiss默认值
+	NList     int    // IVF聚类中心数量
+	NProbe    int    // IVF查询时探查的聚类数量，0表示使用Faiss默认值
+	PQM       int    // 乘积量化的子向量数量
+	TrainSize int    // 训练IVF索引所需的最少向量数
+}
+
+// newFaissIndexSpec 根据配置生成索引参数并校验
+func newFaissIndexSpec(config Config) (faissIndexSpec, error) {
+	spec := faissIndexSpec{
+		Type:      strings.ToLower(config.IndexType),
+		HNSWM:     config.HNSWM,
+		EfSearch:  config.HNSWEfRuntime,
+		NList:     config.IVFLists,
+		NProbe:    config.IVFNProbe,
+		PQM:       config.PQM,
+		TrainSize: config.IVFTrainSize,
+	}
+
+	switch spec.Type {
+	case "", "none", faissIndexFlat:
+		spec.Type = faissIndexFlat
+	case faissIndexHNSW:
+		if spec.HNSWM <= 0 {
+			spec.HNSWM = defaultHNSWM
+		}
+	case "ivfflat", faissIndexIVF, faissIndexIVFPQ:
+		if spec.Type == "ivfflat" {
+			spec.Type = faissIndexIVF
+		}
+		if spec.NList <= 0 {
+			spec.NList = defaultIVFLists
+		}
+		if spec.TrainSize <= 0 {
+			spec.TrainSize = spec.NList * defaultIVFTrainFactor
+		}
+		// 每个聚类中心至少需要一个训练向量
+		if spec.TrainSize < spec.NList {
+			spec.TrainSize = spec.NList
+		}
+		if spec.Type == faissIndexIVFPQ {
+			if spec.PQM <= 0 {
+				spec.PQM = defaultPQM
+			}
+			if config.Dimension%spec.PQM != 0 {
+				return spec, fmt.Errorf("pq sub-quantizers %d must divide vector dimension %d", spec.PQM, config.Dimension)
+			}
+		}
+	default:
+		return spec, fmt.Errorf("unsupported faiss index type: %s", config.IndexType)
+	}
+
+	return spec, nil
+}
+
+// needsTraining 索引是否需要训练
+func (s faissIndexSpec) needsTraining() bool {
+	return s.Type == faissIndexIVF || s.Type == faissIndexIVFPQ
+}
+
+// factory 返回Faiss index_factory的索引描述
+func (s faissIndexSpec) factory() string {
+	switch s.Type {
+	case faissIndexHNSW:
+		return fmt.Sprintf("HNSW%d", s.HNSWM)
+	case faissIndexIVF:
+		return fmt.Sprintf("IVF%d,Flat", s.NList)
+	case faissIndexIVFPQ:
+		return fmt.Sprintf("IVF%d,PQ%d", s.NList, s.PQM)
+	default:
+		return "Flat"
+	}
+}
+
+// faissMetric 返回距离类型对应的Faiss度量方式
+func faissMetric(distType DistanceType) int {
+	switch distType {
+	case Cosine, DotProduct:
+		// 对于余弦距离和点积，使用内积度量方式
+		return int(faiss.MetricInnerProduct)
+	default:
+		// 欧几里得距离和其他情况使用L2度量方式
+		return int(faiss.MetricL2)
+	}
+}
+
+// applySearchParams 设置索引的查询参数，读取或重建索引后都需要重新设置
+func (s faissIndexSpec) applySearchParams(index faiss.Index, typ string) error {
+	var name string
+	var value int
+	switch {
+	case typ == faissIndexHNSW && s.EfSearch > 0:
+		name, value = "efSearch", s.EfSearch
+	case (typ == faissIndexIVF || typ == faissIndexIVFPQ) && s.NProbe > 0:
+		name, value = "nprobe", s.NProbe
+	default:
+		return nil
+	}
+
+	ps, err := faiss.NewParameterSpace()
+	if err != nil {
+		return err
+	}
+	defer ps.Delete()
+	return ps.SetIndexParameter(index, name, float64(value))
+}
+
+// targetIndexType 返回命名空间当前数据量下应使用的索引类型
+// 需要训练的索引在向量数量达到训练要求前先使用精确搜索
+func (r *FaissRepository) targetIndexType(ns *faissNamespace) string {
+	if r.spec.needsTraining() && len(ns.idToPosition) < r.spec.TrainSize {
+		return faissIndexFlat
+	}
+	return r.spec.Type
+}
+
+// ensureIndexType 命名空间当前索引类型与应使用的类型不一致时重建索引，调用方需持有写锁
+func (r *FaissRepository) ensureIndexType(ns *faissNamespace) error {
+	target := r.targetIndexType(ns)
+	if ns.activeType == target {
+		return nil
+	}
+	return r.rebuildIndex(ns, target)
+}
+
+// rebuildIndex 用已保存的文档向量重建命名空间指定类型的索引，调用方需持有写锁
+// 需要训练的索引使用全部向量训练；重建会重新分配向量位置，同时清除已删除文档占用的位置
+func (r *FaissRepository) rebuildIndex(ns *faissNamespace, typ string) error {
+	spec := r.spec
+	spec.Type = typ
+
+	var index faiss.Index
+	var err error
+	if typ == faissIndexFlat {
+		index, err = createFaissIndex(r.dimension, r.distanceType)
+	} else {
+		index, err = faiss.IndexFactory(r.dimension, spec.factory(), faissMetric(r.distanceType))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s index: %v", typ, err)
+	}
+
+	// 按原有位置排序，保持文档顺序稳定
+	ids := make([]string, 0, len(ns.idToPosition))
+	for id := range ns.idToPosition {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		pi, pj := ns.idToPosition[ids[i]], ns.idToPosition[ids[j]]
+		if pi != pj {
+			return pi < pj
+		}
+		return ids[i] < ids[j]
+	})
+
+	vectors := make([]float32, 0, len(ids)*r.dimension)
+	for _, id := range ids {
+		vectors = append(vectors, r.documents[id].Vector...)
+	}
+
+	if spec.needsTraining() {
+		if err := index.Train(vectors); err != nil {
+			index.Delete()
+			return fmt.Errorf("failed to train %s index: %v", typ, err)
+		}
+	}
+	if len(vectors) > 0 {
+		if err := index.Add(vectors); err != nil {
+			index.Delete()
+			return fmt.Errorf("failed to add vectors to %s index: %v", typ, err)
+		}
+	}
+	if err := spec.applySearchParams(index, typ); err != nil {
+		index.Delete()
+		return fmt.Errorf("failed to set %s search parameters: %v", typ, err)
+	}
+
+	positions := make(map[string]int, len(ids))
+	for i, id := range ids {
+		positions[id] = i
+	}
+
+	if ns.index != nil {
+		ns.index.Delete()
+	}
+	ns.index = index
+	ns.idToPosition = positions
+	ns.activeType = typ
+	r.queryCache.clear()
+	return nil
+}
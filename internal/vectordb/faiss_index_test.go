@@ -0,0 +1,140 @@
+package vectordb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFaissIndexSpec 测试Faiss索引参数解析
+func TestFaissIndexSpec(t *testing.T) {
+	spec, err := newFaissIndexSpec(Config{Dimension: 8})
+	require.NoError(t, err)
+	assert.Equal(t, faissIndexFlat, spec.Type)
+	assert.False(t, spec.needsTraining())
+
+	spec, err = newFaissIndexSpec(Config{Dimension: 8, IndexType: "HNSW", HNSWEfRuntime: 40})
+	require.NoError(t, err)
+	assert.Equal(t, "HNSW16", spec.factory())
+	assert.Equal(t, 40, spec.EfSearch)
+
+	spec, err = newFaissIndexSpec(Config{Dimension: 8, IndexType: "ivfflat", IVFLists: 10})
+	require.NoError(t, err)
+	assert.Equal(t, "IVF10,Flat", spec.factory())
+	assert.Equal(t, 390, spec.TrainSize)
+	assert.True(t, spec.needsTraining())
+
+	spec, err = newFaissIndexSpec(Config{Dimension: 8, IndexType: "ivfpq", IVFLists: 10, PQM: 4, IVFTrainSize: 5})
+	require.NoError(t, err)
+	assert.Equal(t, "IVF10,PQ4", spec.factory())
+	assert.Equal(t, 10, spec.TrainSize, "training needs at least one vector per list")
+
+	_, err = newFaissIndexSpec(Config{Dimension: 10, IndexType: "ivfpq", PQM: 4})
+	assert.Error(t, err)
+	_, err = newFaissIndexSpec(Config{Dimension: 8, IndexType: "lsh"})
+	assert.Error(t, err)
+}
+
+// TestFaissIVFTraining 测试IVF索引在向量数量达到训练要求后才切换，并在重新加载时保留索引类型
+func TestFaissIVFTraining(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.faiss")
+	config := Config{
+		Type:         "faiss",
+		Path:         path,
+		Dimension:    4,
+		DistanceType: Cosine,
+		IndexType:    "ivfflat",
+		IVFLists:     2,
+		IVFNProbe:    2,
+		IVFTrainSize: 6,
+	}
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+	faissRepo := repo.(*FaissRepository)
+	assert.Equal(t, faissIndexFlat, faissRepo.namespaces[""].activeType)
+
+	addDocs := func(from, to int) {
+		docs := make([]Document, 0, to-from)
+		for i := from; i < to; i++ {
+			docs = append(docs, Document{
+				ID:     fmt.Sprintf("doc_%d", i),
+				FileID: "doc",
+				Text:   fmt.Sprintf("段落%d", i),
+				Vector: []float32{float32(i + 1), 1, 0, float32(i % 2)},
+			})
+		}
+		require.NoError(t, repo.AddBatch(docs))
+	}
+
+	addDocs(0, 4)
+	assert.Equal(t, faissIndexFlat, faissRepo.namespaces[""].activeType)
+	require.NoError(t, repo.Delete("doc_1"))
+
+	// 删除后剩3个向量，再添加3个后达到训练要求
+	addDocs(4, 7)
+	assert.Equal(t, faissIndexIVF, faissRepo.namespaces[""].activeType)
+	assert.Equal(t, int64(6), faissRepo.namespaces[""].index.Ntotal(), "rebuild drops deleted vectors")
+
+	results, err := repo.Search([]float32{6, 1, 0, 1}, SearchFilter{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc_5", results[0].Document.ID)
+	require.NoError(t, repo.Close())
+
+	// 重新加载后保持IVF索引
+	reloaded, err := NewRepository(config)
+	require.NoError(t, err)
+	assert.Equal(t, faissIndexIVF, reloaded.(*FaissRepository).namespaces[""].activeType)
+	count, err := reloaded.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+	require.NoError(t, reloaded.Close())
+
+	// 配置改为HNSW时用已保存的向量重建索引
+	config.IndexType = "hnsw"
+	rebuilt, err := NewRepository(config)
+	require.NoError(t, err)
+	assert.Equal(t, faissIndexHNSW, rebuilt.(*FaissRepository).namespaces[""].activeType)
+	results, err = rebuilt.Search([]float32{6, 1, 0, 1}, SearchFilter{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc_5", results[0].Document.ID)
+}
+
+// TestFaissDimensionMismatch 测试索引文件维度与配置不一致时的处理
+func TestFaissDimensionMismatch(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{
+		Type:              "faiss",
+		Path:              filepath.Join(dir, "index.faiss"),
+		Dimension:         4,
+		DistanceType:      Cosine,
+		CreateIfNotExists: true,
+	}
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+	require.NoError(t, repo.Add(Document{ID: "doc_1", FileID: "doc", Text: "段落", Vector: []float32{1, 0, 0, 0}}))
+	require.NoError(t, repo.Close())
+
+	// 已有向量的索引无法换成其他维度
+	config.Dimension = 8
+	_, err = NewRepository(config)
+	assert.ErrorIs(t, err, ErrInvalidDimension)
+
+	// 空索引按配置的维度重建
+	config.Path = filepath.Join(dir, "empty.faiss")
+	config.Dimension = 4
+	repo, err = NewRepository(config)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	config.Dimension = 8
+	repo, err = NewRepository(config)
+	require.NoError(t, err)
+	assert.Equal(t, 8, repo.GetDimension())
+	require.NoError(t, repo.Add(Document{ID: "doc_1", FileID: "doc", Text: "段落", Vector: make([]float32, 8)}))
+	require.NoError(t, repo.Close())
+}
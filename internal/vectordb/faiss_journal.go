@@ -0,0 +1,143 @@
+package vectordb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Faiss索引的两阶段保存
+// 索引文件和元数据文件无法原子地同时替换，进程在两者之间崩溃会导致索引和元数据不一致。
+// 保存时先把所有文件写入临时文件，再写入日志作为提交点，最后将临时文件替换为正式文件并删除日志。
+// 启动时存在日志说明上次保存已提交但替换未完成，按日志补完替换；
+// 没有日志时残留的临时文件属于未提交的保存，删除后沿用上一次完整保存的文件
+
+// faissTempSuffix 保存过程中临时文件的后缀
+const faissTempSuffix = ".tmp"
+
+// faissJournal 两阶段保存的提交日志
+type faissJournal struct {
+	Sequence uint64   `json:"sequence"` // 保存序号，与元数据中的序号一致
+	Files    []string `json:"files"`    // 需要替换的正式文件路径，对应的临时文件为路径加faissTempSuffix
+}
+
+// faissJournalPath 返回索引的提交日志路径
+func faissJournalPath(indexPath string) string {
+	return indexPath + ".journal"
+}
+
+// commitFaissSave 写入提交日志后将临时文件替换为正式文件，全部替换后删除日志
+func commitFaissSave(indexPath string, journal faissJournal) error {
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal save journal: %v", err)
+	}
+	path := faissJournalPath(indexPath)
+	if err := writeFileSync(path+faissTempSuffix, data); err != nil {
+		return fmt.Errorf("failed to write save journal: %v", err)
+	}
+	// 日志改名成功即为提交点
+	if err := os.Rename(path+faissTempSuffix, path); err != nil {
+		return fmt.Errorf("failed to commit save journal: %v", err)
+	}
+	syncDir(filepath.Dir(path))
+
+	if err := applyFaissJournal(journal); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove save journal: %v", err)
+	}
+	return nil
+}
+
+// applyFaissJournal 将日志中的临时文件替换为正式文件，已经替换过的文件跳过
+func applyFaissJournal(journal faissJournal) error {
+	for _, path := range journal.Files {
+		if !fileExists(path + faissTempSuffix) {
+			continue
+		}
+		if err := os.Rename(path+faissTempSuffix, path); err != nil {
+			return fmt.Errorf("failed to replace %s: %v", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// recoverFaissSave 启动时恢复中断的保存，返回补完的保存序号，没有需要补完的保存时为0
+func recoverFaissSave(indexPath string) (uint64, error) {
+	path := faissJournalPath(indexPath)
+	if data, err := os.ReadFile(path); err == nil {
+		var journal faissJournal
+		if err := json.Unmarshal(data, &journal); err != nil {
+			return 0, fmt.Errorf("corrupted save journal %s: %v", path, err)
+		}
+		if err := applyFaissJournal(journal); err != nil {
+			return 0, err
+		}
+		if err := os.Remove(path); err != nil {
+			return 0, fmt.Errorf("failed to remove save journal: %v", err)
+		}
+		return journal.Sequence, nil
+	} else if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read save journal: %v", err)
+	}
+
+	// 没有提交日志，删除未提交的保存留下的临时文件
+	dir, prefix := filepath.Dir(indexPath), filepath.Base(indexPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to list index directory: %v", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		ours := name == prefix+faissTempSuffix || strings.HasPrefix(name, prefix+".")
+		if ours && strings.HasSuffix(name, faissTempSuffix) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return 0, fmt.Errorf("failed to remove uncommitted file %s: %v", name, err)
+			}
+		}
+	}
+	return 0, nil
+}
+
+// writeFileSync 写入文件并同步到磁盘
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncFile 将已写入的文件同步到磁盘
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// syncDir 同步目录，使改名操作持久化，不支持时忽略
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		f.Sync()
+		f.Close()
+	}
+}
@@ -0,0 +1,72 @@
+package vectordb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataIntelligenceCrew/go-faiss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFaissSaveRecovery 测试保存中断后启动时的恢复：已提交的保存补完替换，未提交的保存回滚
+func TestFaissSaveRecovery(t *testing.T) {
+	config := Config{
+		Type:              "faiss",
+		Path:              filepath.Join(t.TempDir(), "index.faiss"),
+		Dimension:         4,
+		DistanceType:      Cosine,
+		CreateIfNotExists: true,
+	}
+
+	// crashAfterWrite 写入doc的临时文件后模拟崩溃，commit为true时在写入提交日志后崩溃
+	crashAfterWrite := func(id string, commit bool) {
+		repo, err := NewRepository(config)
+		require.NoError(t, err)
+		r := repo.(*FaissRepository)
+		require.NoError(t, repo.Add(Document{ID: id, FileID: "doc", Text: id, Vector: []float32{1, 0, 0, 0}}))
+
+		r.saveMu.Lock()
+		r.mu.RLock()
+		seq := r.saveSeq + 1
+		require.NoError(t, faiss.WriteIndex(r.namespaces[""].index, config.Path+faissTempSuffix))
+		require.NoError(t, r.saveMetadata(seq))
+		r.mu.RUnlock()
+		r.saveMu.Unlock()
+		if commit {
+			data, err := json.Marshal(faissJournal{Sequence: seq, Files: []string{config.Path, r.metaPath}})
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(faissJournalPath(config.Path), data, 0644))
+		}
+
+		r.saveOnClose = false
+		require.NoError(t, repo.Close())
+	}
+
+	countDocs := func() int {
+		repo, err := NewRepository(config)
+		require.NoError(t, err)
+		defer repo.Close()
+		count, err := repo.Count()
+		require.NoError(t, err)
+		return count
+	}
+
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+	require.NoError(t, repo.Add(Document{ID: "doc_0", FileID: "doc", Text: "doc_0", Vector: []float32{0, 1, 0, 0}}))
+	require.NoError(t, repo.Close())
+
+	// 未提交的保存被丢弃，临时文件被清理
+	crashAfterWrite("doc_1", false)
+	assert.Equal(t, 1, countDocs())
+	assert.NoFileExists(t, config.Path+faissTempSuffix)
+	assert.NoFileExists(t, config.Path+".meta.json"+faissTempSuffix)
+
+	// 已提交的保存在启动时补完
+	crashAfterWrite("doc_2", true)
+	assert.Equal(t, 2, countDocs())
+	assert.NoFileExists(t, faissJournalPath(config.Path))
+}
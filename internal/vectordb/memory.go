@@ -2,7 +2,9 @@ package vectordb
 
 import (
 	"fmt"
-	"runtime"
+	"io"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -10,11 +12,12 @@ import (
 // MemoryRepository 内存向量仓库实现
 // 用于开发和测试环境的简单内存存储
 type MemoryRepository struct {
-	*BaseRepository                     // 嵌入基础仓库实现
-	mu              sync.RWMutex        // 读写锁，确保并发安全
-	documents       map[string]Document // 文档存储，ID到文档的映射
-	fileToDocIDs    map[string][]string // 文件ID到文档ID的映射
-	vectorCache     *vectorCache        // 向量缓存，用于加速常见搜索
+	*BaseRepository                                // 嵌入基础仓库实现
+	mu              sync.RWMutex                   // 读写锁，确保并发安全
+	documents       map[string]Document            // 文档存储，ID到文档的映射
+	fileToDocIDs    map[string][]string            // 文件ID到文档ID的映射
+	namespaceDocs   map[string]map[string]struct{} // 命名空间到文档ID集合的映射
+	vectorCache     *vectorCache                   // 向量缓存，用于加速常见搜索
 }
 
 // vectorCache 用于缓存已计算的向量距离和查询结果
@@ -46,8 +49,15 @@ func cacheKey(vector []float32, filter SearchFilter) string {
 	key := fmt.Sprintf("v%d_%f_%f", len(vector), vector[0], vector[1])
 
 	// 添加过滤条件信息
+	if filter.Namespace != "" {
+		key += "_n" + filter.Namespace
+	}
+	// 文件范围不同的查询结果不同，不能只按文件数量区分
 	if len(filter.FileIDs) > 0 {
-		key += fmt.Sprintf("_f%d", len(filter.FileIDs))
+		key += "_f" + strings.Join(filter.FileIDs, ",")
+	}
+	if len(filter.ExcludeFileIDs) > 0 {
+		key += "_x" + strings.Join(filter.ExcludeFileIDs, ",")
 	}
 	if len(filter.Metadata) > 0 {
 		key += fmt.Sprintf("_m%d", len(filter.Metadata))
@@ -227,6 +237,7 @@ func NewMemoryRepository(config Config) (Repository, error) {
 		BaseRepository: base,
 		documents:      make(map[string]Document),
 		fileToDocIDs:   make(map[string][]string),
+		namespaceDocs:  make(map[string]map[string]struct{}),
 		vectorCache:    newVectorCache(),
 	}, nil
 }
@@ -257,12 +268,41 @@ func (r *MemoryRepository) Add(doc Document) error {
 	defer r.mu.Unlock()
 
 	// 存储文档
+	r.storeDocument(doc)
+
+	return nil
+}
+
+// storeDocument 存储文档并更新索引映射，调用方需持有写锁
+func (r *MemoryRepository) storeDocument(doc Document) {
+	// 同ID文档已存在且命名空间变化时，从原命名空间移除
+	if old, exists := r.documents[doc.ID]; exists && old.Namespace != doc.Namespace {
+		r.removeFromNamespace(old)
+	}
 	r.documents[doc.ID] = doc
 
 	// 更新文件到文档的映射
 	r.fileToDocIDs[doc.FileID] = append(r.fileToDocIDs[doc.FileID], doc.ID)
 
-	return nil
+	// 更新命名空间到文档的映射
+	ids, ok := r.namespaceDocs[doc.Namespace]
+	if !ok {
+		ids = make(map[string]struct{})
+		r.namespaceDocs[doc.Namespace] = ids
+	}
+	ids[doc.ID] = struct{}{}
+}
+
+// removeFromNamespace 从命名空间映射中移除文档，调用方需持有写锁
+func (r *MemoryRepository) removeFromNamespace(doc Document) {
+	ids, ok := r.namespaceDocs[doc.Namespace]
+	if !ok {
+		return
+	}
+	delete(ids, doc.ID)
+	if len(ids) == 0 {
+		delete(r.namespaceDocs, doc.Namespace)
+	}
 }
 
 // AddBatch 批量添加文档到内存仓库
@@ -299,10 +339,7 @@ func (r *MemoryRepository) AddBatch(docs []Document) error {
 		}
 
 		// 存储文档
-		r.documents[doc.ID] = *doc
-
-		// 更新文件到文档的映射
-		r.fileToDocIDs[doc.FileID] = append(r.fileToDocIDs[doc.FileID], doc.ID)
+		r.storeDocument(*doc)
 	}
 
 	return nil
@@ -333,6 +370,7 @@ func (r *MemoryRepository) Delete(id string) error {
 
 	// 删除文档
 	delete(r.documents, id)
+	r.removeFromNamespace(doc)
 
 	// 更新文件到文档映射
 	if fileIDs, ok := r.fileToDocIDs[doc.FileID]; ok {
@@ -367,7 +405,10 @@ func (r *MemoryRepository) DeleteByFileID(fileID string) error {
 
 	// 删除所有关联的文档
 	for _, id := range docIDs {
-		delete(r.documents, id)
+		if doc, ok := r.documents[id]; ok {
+			r.removeFromNamespace(doc)
+			delete(r.documents, id)
+		}
 	}
 
 	// 删除文件到文档的映射
@@ -412,11 +453,21 @@ func (r *MemoryRepository) Search(vector []float32, filter SearchFilter) ([]Sear
 
 			for _, docID := range docIDs {
 				doc, exists := r.documents[docID]
-				if exists && matchMetadata(doc.Metadata, filter.Metadata) {
+				if exists && matchNamespace(doc, filter.Namespace) && matchMetadata(doc.Metadata, filter.Metadata) {
 					filteredDocs = append(filteredDocs, doc)
 				}
 			}
 		}
+	} else if filter.Namespace != "" {
+		// 指定了命名空间时只检索该命名空间的文档
+		docIDs := r.namespaceDocs[filter.Namespace]
+		filteredDocs = make([]Document, 0, len(docIDs))
+		for docID := range docIDs {
+			doc := r.documents[docID]
+			if matchMetadata(doc.Metadata, filter.Metadata) {
+				filteredDocs = append(filteredDocs, doc)
+			}
+		}
 	} else {
 		// 否则检索所有文档并应用元数据过滤
 		filteredDocs = make([]Document, 0, len(r.documents))
@@ -427,6 +478,17 @@ func (r *MemoryRepository) Search(vector []float32, filter SearchFilter) ([]Sear
 		}
 	}
 
+	// 去掉排除的文件
+	if excluded := excludedFiles(filter); excluded != nil {
+		kept := filteredDocs[:0]
+		for _, doc := range filteredDocs {
+			if !excluded[doc.FileID] {
+				kept = append(kept, doc)
+			}
+		}
+		filteredDocs = kept
+	}
+
 	// 如果没有符合条件的文档，返回空结果
 	if len(filteredDocs) == 0 {
 		return []SearchResult{}, nil
@@ -446,6 +508,11 @@ func (r *MemoryRepository) Search(vector []float32, filter SearchFilter) ([]Sear
 	}
 }
 
+// SearchBatch 批量相似度搜索，各查询并发执行
+func (r *MemoryRepository) SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	return searchConcurrently(r.Search, vectors, filter)
+}
+
 // serialSearch 串行搜索实现
 func (r *MemoryRepository) serialSearch(vector []float32, docs []Document, filter SearchFilter) ([]SearchResult, error) {
 	results := make([]SearchResult, 0, len(docs))
@@ -594,6 +661,30 @@ func (r *MemoryRepository) Count() (int, error) {
 	return len(r.documents), nil
 }
 
+// Describe 返回向量数据库的配置和状态，内存实现为精确搜索
+func (r *MemoryRepository) Describe() (Info, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info := Info{
+		Type:       "memory",
+		IndexType:  "flat",
+		Dimension:  r.dimension,
+		Distance:   r.distType,
+		Normalized: r.distType == Cosine,
+		Count:      len(r.documents),
+	}
+	for _, doc := range r.documents {
+		info.MemoryBytes += documentBytes(doc)
+	}
+	return info, nil
+}
+
+// Flush 内存实现不持久化，直接返回
+func (r *MemoryRepository) Flush() error {
+	return nil
+}
+
 // Close 关闭数据库连接
 // 对于内存实现这是一个空操作
 func (r *MemoryRepository) Close() error {
@@ -605,6 +696,34 @@ func (r *MemoryRepository) GetDimension() int {
 	return r.dimension
 }
 
+// FileSegmentCounts 返回文件ID到段落数的映射
+func (r *MemoryRepository) FileSegmentCounts() (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts := make(map[string]int, len(r.fileToDocIDs))
+	for fileID, ids := range r.fileToDocIDs {
+		counts[fileID] = len(ids)
+	}
+	return counts, nil
+}
+
+// Export 将全部文档导出为快照
+func (r *MemoryRepository) Export(w io.Writer) error {
+	r.mu.RLock()
+	docs := make([]Document, 0, len(r.documents))
+	for _, doc := range r.documents {
+		docs = append(docs, doc)
+	}
+	r.mu.RUnlock()
+
+	return writeDocumentsSnapshot(w, r.dimension, r.distType, docs)
+}
+
+// Import 从快照导入文档
+func (r *MemoryRepository) Import(reader io.Reader) (int, error) {
+	return importSnapshot(r, reader)
+}
+
 // 在包初始化时注册内存仓库
 func init() {
 	RegisterRepository("memory", NewMemoryRepository)
@@ -1,8 +1,13 @@
 package vectordb
 
 import (
+	"context"
 	"errors"
+	"io"
+	"strings"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 // 常用错误定义
@@ -17,6 +22,7 @@ var (
 // 包含向量表示及其元数据
 type Document struct {
 	ID        string                 // 唯一标识符
+	Namespace string                 // 命名空间（如租户或集合），为空表示默认命名空间
 	FileID    string                 // 所属文件ID
 	FileName  string                 // 文件名
 	Position  int                    // 在原文档中的段落位置
@@ -47,10 +53,12 @@ type SearchResult struct {
 
 // SearchFilter 搜索过滤条件
 type SearchFilter struct {
-	FileIDs    []string               // 按文件ID过滤
-	Metadata   map[string]interface{} // 按元数据过滤
-	MinScore   float32                // 最小相似度分数
-	MaxResults int                    // 最大返回结果数
+	Namespace      string                 // 限定搜索的命名空间，为空时搜索全部命名空间
+	FileIDs        []string               // 按文件ID过滤
+	ExcludeFileIDs []string               // 排除的文件ID，与FileIDs同时指定时以排除为准
+	Metadata       map[string]interface{} // 按元数据过滤
+	MinScore       float32                // 最小相似度分数
+	MaxResults     int                    // 最大返回结果数
 }
 
 // DefaultSearchFilter 返回默认的搜索过滤器
@@ -82,16 +90,115 @@ type Repository interface {
 	// Search 相似度搜索
 	Search(vector []float32, filter SearchFilter) ([]SearchResult, error)
 
+	// SearchBatch 使用相同的过滤条件批量搜索多个向量，结果与输入向量一一对应
+	SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error)
+
 	// Count 获取文档总数
 	Count() (int, error)
 
 	// GetDimension 返回向量维数
 	GetDimension() int
 
+	// Export 将全部文档（含向量和元数据）导出为快照
+	Export(w io.Writer) error
+
+	// Import 从快照导入文档，已存在的同ID文档被覆盖，返回导入的文档数
+	Import(r io.Reader) (int, error)
+
+	// Flush 立即持久化尚未保存的变更，写入即持久化的实现直接返回nil
+	Flush() error
+
 	// Close 关闭数据库连接
 	Close() error
 }
 
+// Pinger 连接远程服务的向量数据库实现的可选接口，用于健康检查
+type Pinger interface {
+	// Ping 检查与向量数据库服务的连接
+	Ping(ctx context.Context) error
+}
+
+// Ping 检查向量数据库是否可用
+// 实现了Pinger的远程数据库检查连接，本地实现通过Count确认索引可读
+func Ping(ctx context.Context, repo Repository) error {
+	if pinger, ok := repo.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	_, err := repo.Count()
+	return err
+}
+
+// FileLister 能直接统计各文件段落数的向量数据库实现的可选接口，用于存储垃圾回收
+type FileLister interface {
+	// FileSegmentCounts 返回文件ID到段落数的映射
+	FileSegmentCounts() (map[string]int, error)
+}
+
+// FileSegmentCounts 统计向量数据库中每个文件的段落数
+// 实现了FileLister的数据库直接统计，其他实现通过导出快照统计
+func FileSegmentCounts(repo Repository) (map[string]int, error) {
+	if lister, ok := repo.(FileLister); ok {
+		return lister.FileSegmentCounts()
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(repo.Export(pw))
+	}()
+	counts, err := countSnapshotFiles(pr)
+	pr.CloseWithError(err)
+	return counts, err
+}
+
+// Info 向量数据库的配置和状态，用于核对维度、距离度量和向量归一化是否与嵌入模型相符
+type Info struct {
+	Type        string       // 数据库类型
+	IndexType   string       // 配置的索引类型
+	Dimension   int          // 向量维度
+	Distance    DistanceType // 距离度量方式
+	Normalized  bool         // 写入和查询前是否将向量归一化为单位长度
+	Count       int          // 向量总数
+	MemoryBytes int64        // 本地实现为向量和文本占用内存的估算值，远程数据库为服务端的存储占用，未知时为0
+	LastSave    time.Time    // 索引最近一次持久化的时间，未持久化时为零值
+
+	QueryCache *QueryCacheStats // 查询结果缓存的命中统计，没有查询缓存的实现为空
+}
+
+// Describer 能报告自身配置和状态的向量数据库实现的可选接口
+type Describer interface {
+	// Describe 返回向量数据库的配置和状态
+	Describe() (Info, error)
+}
+
+// Describe 返回向量数据库的配置和状态
+// 未实现Describer的数据库只报告维度和向量总数
+func Describe(repo Repository) (Info, error) {
+	if describer, ok := repo.(Describer); ok {
+		return describer.Describe()
+	}
+	count, err := repo.Count()
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{Dimension: repo.GetDimension(), Count: count}, nil
+}
+
+// configuredIndexType 返回配置的索引类型名称，未配置时为默认的hnsw
+func configuredIndexType(indexType string) string {
+	switch t := strings.ToLower(indexType); t {
+	case "":
+		return "hnsw"
+	case "none":
+		return "flat"
+	default:
+		return t
+	}
+}
+
+// documentBytes 估算段落的向量和文本占用的内存
+func documentBytes(doc Document) int64 {
+	return int64(len(doc.Vector)*4 + len(doc.ID) + len(doc.FileID) + len(doc.FileName) + len(doc.Text))
+}
+
 // Config 向量数据库配置
 type Config struct {
 	Type              string       // 数据库类型，如 "memory", "faiss", "qdrant"
@@ -100,8 +207,27 @@ type Config struct {
 	DistanceType      DistanceType // 距离计算类型
 	CreateIfNotExists bool         // 如果不存在是否创建
 	InMemory          bool         // 是否仅在内存中运行
+
+	// 以下为近似索引参数，零值表示使用默认值，各实现支持的索引类型不同
+	IndexType          string // 向量索引类型：flat（精确搜索）、hnsw、ivfflat、ivfpq（仅faiss）
+	IVFLists           int    // IVFFlat索引的聚类中心数量
+	HNSWM              int    // HNSW索引每个节点的最大连接数
+	HNSWEfConstruction int    // HNSW索引构建时的候选列表大小
+	HNSWEfRuntime      int    // HNSW索引查询时的候选列表大小（efSearch）
+	IVFNProbe          int    // IVF索引查询时探查的聚类数量
+	IVFTrainSize       int    // 训练IVF索引所需的最少向量数，达到前使用精确搜索
+	PQM                int    // 乘积量化的子向量数量，需整除向量维度
+	TableName          string // 存储向量的表名或索引名
+	QueryCacheSize     int    // 查询结果缓存的最大条目数（仅faiss），0使用默认值，负数禁用缓存
+
+	SaveInterval time.Duration // 后台保存索引文件的间隔（仅faiss），0使用DefaultSaveInterval
+
+	Logger *logrus.Logger // 日志记录器（仅faiss），为空时使用logrus默认的日志记录器
 }
 
+// DefaultSaveInterval Faiss索引默认的后台保存间隔
+const DefaultSaveInterval = 30 * time.Second
+
 // Factory 向量数据库工厂函数类型
 type Factory func(config Config) (Repository, error)
 
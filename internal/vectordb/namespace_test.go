@@ -0,0 +1,109 @@
+package vectordb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// namespaceTestDocs 生成两个命名空间中方向相同的文档
+func namespaceTestDocs() []Document {
+	var docs []Document
+	for i, ns := range []string{"tenant-a", "tenant-b", "tenant-a", "tenant-b"} {
+		docs = append(docs, Document{
+			ID:        fmt.Sprintf("doc_%d", i),
+			Namespace: ns,
+			FileID:    fmt.Sprintf("file_%d", i%2),
+			Text:      fmt.Sprintf("段落%d", i),
+			Vector:    []float32{1, float32(i) * 0.1, 0, 0},
+		})
+	}
+	return docs
+}
+
+// TestNamespaceSearch 测试按命名空间限定搜索范围
+func TestNamespaceSearch(t *testing.T) {
+	configs := map[string]Config{
+		"memory": {Type: "memory", Dimension: 4},
+		"faiss":  {Type: "faiss", Dimension: 4, Path: filepath.Join(t.TempDir(), "index.faiss")},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			repo, err := NewRepository(config)
+			require.NoError(t, err)
+			defer repo.Close()
+			require.NoError(t, repo.AddBatch(namespaceTestDocs()))
+
+			results, err := repo.Search([]float32{1, 0, 0, 0}, SearchFilter{Namespace: "tenant-b", MaxResults: 10})
+			require.NoError(t, err)
+			require.Len(t, results, 2)
+			for _, result := range results {
+				assert.Equal(t, "tenant-b", result.Document.Namespace)
+			}
+			assert.Equal(t, "doc_1", results[0].Document.ID)
+
+			// 未指定命名空间时搜索全部命名空间
+			results, err = repo.Search([]float32{1, 0, 0, 0}, SearchFilter{MaxResults: 3})
+			require.NoError(t, err)
+			require.Len(t, results, 3)
+			assert.Equal(t, "doc_0", results[0].Document.ID)
+			assert.Equal(t, "doc_1", results[1].Document.ID)
+
+			results, err = repo.Search([]float32{1, 0, 0, 0}, SearchFilter{Namespace: "unknown", MaxResults: 3})
+			require.NoError(t, err)
+			assert.Empty(t, results)
+
+			// 删除后不再出现在命名空间的搜索结果中（换一个查询向量，避开查询缓存）
+			require.NoError(t, repo.Delete("doc_1"))
+			results, err = repo.Search([]float32{1, 0.5, 0, 0}, SearchFilter{Namespace: "tenant-b", MaxResults: 10})
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, "doc_3", results[0].Document.ID)
+		})
+	}
+}
+
+// TestFaissNamespaceLazyLoad 测试Faiss每个命名空间使用独立索引，重新打开时按需加载
+func TestFaissNamespaceLazyLoad(t *testing.T) {
+	config := Config{Type: "faiss", Dimension: 4, Path: filepath.Join(t.TempDir(), "index.faiss")}
+	repo, err := NewRepository(config)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddBatch(namespaceTestDocs()))
+	require.NoError(t, repo.Add(Document{ID: "default_0", FileID: "file_2", Vector: []float32{0, 0, 1, 0}}))
+
+	faissRepo := repo.(*FaissRepository)
+	assert.Len(t, faissRepo.namespaces, 3)
+	assert.Equal(t, int64(1), faissRepo.namespaces[""].index.Ntotal())
+	assert.Equal(t, int64(2), faissRepo.namespaces["tenant-a"].index.Ntotal())
+	require.NoError(t, repo.Close())
+	assert.FileExists(t, config.Path+".ns.tenant-a")
+
+	reloaded, err := NewRepository(config)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	faissRepo = reloaded.(*FaissRepository)
+	assert.Nil(t, faissRepo.namespaces["tenant-a"].index, "namespace index is loaded on first use")
+	assert.Nil(t, faissRepo.namespaces["tenant-b"].index)
+
+	results, err := reloaded.Search([]float32{1, 0, 0, 0}, SearchFilter{Namespace: "tenant-a", MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc_0", results[0].Document.ID)
+	assert.NotNil(t, faissRepo.namespaces["tenant-a"].index)
+	assert.Nil(t, faissRepo.namespaces["tenant-b"].index)
+
+	// 按文件过滤时只加载文件所在的命名空间
+	results, err = reloaded.Search([]float32{0, 0, 1, 0}, SearchFilter{FileIDs: []string{"file_2"}, MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "default_0", results[0].Document.ID)
+	assert.Nil(t, faissRepo.namespaces["tenant-b"].index)
+
+	// 写入未加载的命名空间时先加载已有索引
+	require.NoError(t, reloaded.Add(Document{ID: "doc_9", Namespace: "tenant-b", FileID: "file_1", Vector: []float32{1, 0, 0, 0}}))
+	assert.Equal(t, int64(3), faissRepo.namespaces["tenant-b"].index.Ntotal())
+}
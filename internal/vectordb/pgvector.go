@@ -0,0 +1,654 @@
+package vectordb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // 注册pgx数据库驱动
+)
+
+const (
+	// defaultPgTable 默认的向量表名
+	defaultPgTable = "document_vectors"
+	// defaultIVFLists IVFFlat索引默认的聚类中心数量
+	defaultIVFLists = 100
+	// defaultHNSWM HNSW索引默认的最大连接数
+	defaultHNSWM = 16
+	// defaultHNSWEfConstruction HNSW索引默认的构建候选列表大小
+	defaultHNSWEfConstruction = 64
+	// pgQueryTimeout 单次SQL操作的超时时间
+	pgQueryTimeout = 30 * time.Second
+)
+
+// pgDocumentColumns 读取文档时查询的列，顺序与scanPgDocument一致
+const pgDocumentColumns = `id, namespace, file_id, file_name, position, text, embedding::text, metadata::text, created_at`
+
+// pgIdentifier 合法的表名
+var pgIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PgVectorRepository 基于Postgres pgvector扩展的向量仓库
+// 向量和元数据存放在同一张表中，命名空间、文件和元数据过滤直接在SQL中完成
+type PgVectorRepository struct {
+	db           *sql.DB      // 数据库连接池
+	table        string       // 向量表名
+	dimension    int          // 向量维度
+	distanceType DistanceType // 距离计算类型
+	indexType    string       // 配置的向量索引类型
+}
+
+// NewPgVectorRepository 创建pgvector向量仓库
+// config.Path为Postgres连接串，创建时自动执行迁移：启用vector扩展、建表并创建向量索引
+func NewPgVectorRepository(config Config) (Repository, error) {
+	if config.Dimension <= 0 {
+		return nil, fmt.Errorf("vector dimension must be positive")
+	}
+	if config.Path == "" {
+		return nil, fmt.Errorf("pgvector requires a postgres connection string in path")
+	}
+
+	table := config.TableName
+	if table == "" {
+		table = defaultPgTable
+	}
+	if !pgIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid pgvector table name: %s", table)
+	}
+
+	distType := config.DistanceType
+	if distType == "" {
+		distType = Cosine
+	}
+	if _, err := pgDistanceOperator(distType); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("pgx", config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection: %w", err)
+	}
+
+	repo := &PgVectorRepository{
+		db:           db,
+		table:        table,
+		dimension:    config.Dimension,
+		distanceType: distType,
+		indexType:    configuredIndexType(config.IndexType),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+	if err := repo.migrate(ctx, config); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// migrate 启用vector扩展并创建向量表和索引
+func (r *PgVectorRepository) migrate(ctx context.Context, config Config) error {
+	indexDDL, err := pgIndexDDL(r.table, r.distanceType, config)
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			namespace TEXT NOT NULL DEFAULT '',
+			file_id TEXT NOT NULL,
+			file_name TEXT NOT NULL DEFAULT '',
+			position INTEGER NOT NULL DEFAULT 0,
+			text TEXT NOT NULL DEFAULT '',
+			embedding vector(%d) NOT NULL,
+			metadata JSONB NOT NULL DEFAULT '{}'::jsonb,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, r.table, r.dimension),
+		// 早期版本创建的表没有命名空间列
+		fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS namespace TEXT NOT NULL DEFAULT ''`, r.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_file_id_idx ON %s (file_id)`, r.table, r.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_namespace_idx ON %s (namespace)`, r.table, r.table),
+	}
+	if indexDDL != "" {
+		statements = append(statements, indexDDL)
+	}
+
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("pgvector migration failed: %w", err)
+		}
+	}
+
+	// 已有表的向量维度与配置不一致时尽早报错，避免写入时才失败
+	var dim int
+	err = r.db.QueryRowContext(ctx, `SELECT atttypmod FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attname = 'embedding'`, r.table).Scan(&dim)
+	if err == nil && dim > 0 && dim != r.dimension {
+		return fmt.Errorf("%w: table %s has dimension %d, configured %d", ErrInvalidDimension, r.table, dim, r.dimension)
+	}
+
+	return nil
+}
+
+// pgIndexDDL 生成向量索引的建索引语句，flat表示不建索引（精确搜索）
+func pgIndexDDL(table string, distType DistanceType, config Config) (string, error) {
+	ops, err := pgOperatorClass(distType)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(config.IndexType) {
+	case "", "hnsw":
+		m := config.HNSWM
+		if m <= 0 {
+			m = defaultHNSWM
+		}
+		ef := config.HNSWEfConstruction
+		if ef <= 0 {
+			ef = defaultHNSWEfConstruction
+		}
+		return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding_idx ON %s USING hnsw (embedding %s) WITH (m = %d, ef_construction = %d)`,
+			table, table, ops, m, ef), nil
+	case "ivfflat":
+		lists := config.IVFLists
+		if lists <= 0 {
+			lists = defaultIVFLists
+		}
+		return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding_idx ON %s USING ivfflat (embedding %s) WITH (lists = %d)`,
+			table, table, ops, lists), nil
+	case "flat", "none":
+		return "", nil
+	default:
+		return "", fmt.Errorf("unsupported pgvector index type: %s", config.IndexType)
+	}
+}
+
+// pgOperatorClass 返回距离类型对应的pgvector索引操作符类
+func pgOperatorClass(distType DistanceType) (string, error) {
+	switch distType {
+	case Cosine:
+		return "vector_cosine_ops", nil
+	case DotProduct:
+		return "vector_ip_ops", nil
+	case Euclidean:
+		return "vector_l2_ops", nil
+	default:
+		return "", fmt.Errorf("unsupported distance type: %s", distType)
+	}
+}
+
+// pgDistanceOperator 返回距离类型对应的pgvector距离运算符
+// 注意<#>返回的是负内积
+func pgDistanceOperator(distType DistanceType) (string, error) {
+	switch distType {
+	case Cosine:
+		return "<=>", nil
+	case DotProduct:
+		return "<#>", nil
+	case Euclidean:
+		return "<->", nil
+	default:
+		return "", fmt.Errorf("unsupported distance type: %s", distType)
+	}
+}
+
+// formatPgVector 将向量编码为pgvector的文本格式，如[1,2,3]
+func formatPgVector(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// parsePgVector 解析pgvector的文本格式
+func parsePgVector(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '[' || s[len(s)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector literal: %q", s)
+	}
+	body := s[1 : len(s)-1]
+	if body == "" {
+		return []float32{}, nil
+	}
+	parts := strings.Split(body, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %q: %w", part, err)
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
+
+// buildPgFilter 将搜索过滤条件转换为SQL条件
+// 元数据过滤与内存实现语义一致：支持"^="前缀匹配、"$="后缀匹配，其余为精确匹配
+// 参数占位符从$start开始编号
+func buildPgFilter(filter SearchFilter, start int) (string, []interface{}, error) {
+	var conds []string
+	var args []interface{}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", start+len(args)-1)
+	}
+
+	if filter.Namespace != "" {
+		conds = append(conds, fmt.Sprintf("namespace = %s", next(filter.Namespace)))
+	}
+	if len(filter.FileIDs) > 0 {
+		conds = append(conds, fmt.Sprintf("file_id = ANY(%s)", next(filter.FileIDs)))
+	}
+	if len(filter.ExcludeFileIDs) > 0 {
+		conds = append(conds, fmt.Sprintf("NOT (file_id = ANY(%s))", next(filter.ExcludeFileIDs)))
+	}
+
+	for key, value := range filter.Metadata {
+		if s, ok := value.(string); ok && len(s) > 2 {
+			switch s[:2] {
+			case "^=":
+				conds = append(conds, fmt.Sprintf("metadata->>%s LIKE %s", next(key), next(escapeLike(s[2:])+"%")))
+				continue
+			case "$=":
+				conds = append(conds, fmt.Sprintf("metadata->>%s LIKE %s", next(key), next("%"+escapeLike(s[2:]))))
+				continue
+			}
+		}
+		data, err := json.Marshal(map[string]interface{}{key: value})
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid metadata filter %s: %w", key, err)
+		}
+		conds = append(conds, fmt.Sprintf("metadata @> %s::jsonb", next(string(data))))
+	}
+
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// escapeLike 转义LIKE模式中的特殊字符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Add 添加单个文档
+func (r *PgVectorRepository) Add(doc Document) error {
+	return r.AddBatch([]Document{doc})
+}
+
+// AddBatch 在一个事务中批量添加文档，ID已存在时覆盖
+func (r *PgVectorRepository) AddBatch(docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return ErrInvalidID
+		}
+		if err := ValidateVector(doc.Vector, r.dimension); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s
+		(id, namespace, file_id, file_name, position, text, embedding, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::vector, $8::jsonb, $9)
+		ON CONFLICT (id) DO UPDATE SET
+			namespace = EXCLUDED.namespace,
+			file_id = EXCLUDED.file_id,
+			file_name = EXCLUDED.file_name,
+			position = EXCLUDED.position,
+			text = EXCLUDED.text,
+			embedding = EXCLUDED.embedding,
+			metadata = EXCLUDED.metadata,
+			created_at = EXCLUDED.created_at`, r.table))
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, doc := range docs {
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metaJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of %s: %w", doc.ID, err)
+		}
+		createdAt := doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		if _, err := stmt.ExecContext(ctx, doc.ID, doc.Namespace, doc.FileID, doc.FileName, doc.Position, doc.Text,
+			formatPgVector(doc.Vector), string(metaJSON), createdAt); err != nil {
+			return fmt.Errorf("failed to insert document %s: %w", doc.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit documents: %w", err)
+	}
+	return nil
+}
+
+// Get 获取单个文档
+func (r *PgVectorRepository) Get(id string) (Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, pgDocumentColumns, r.table), id)
+	doc, err := scanPgDocument(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Document{}, ErrDocumentNotFound
+	}
+	if err != nil {
+		return Document{}, err
+	}
+	return doc, nil
+}
+
+// scanPgDocument 从查询结果中读取文档，dest为额外需要读取的列
+func scanPgDocument(scan func(dest ...interface{}) error, dest ...interface{}) (Document, error) {
+	var doc Document
+	var vector, metadata string
+	cols := append([]interface{}{&doc.ID, &doc.Namespace, &doc.FileID, &doc.FileName, &doc.Position, &doc.Text,
+		&vector, &metadata, &doc.CreatedAt}, dest...)
+	if err := scan(cols...); err != nil {
+		return Document{}, err
+	}
+
+	var err error
+	if doc.Vector, err = parsePgVector(vector); err != nil {
+		return Document{}, err
+	}
+	if err := json.Unmarshal([]byte(metadata), &doc.Metadata); err != nil {
+		return Document{}, fmt.Errorf("failed to decode metadata of %s: %w", doc.ID, err)
+	}
+	return doc, nil
+}
+
+// Delete 删除单个文档
+func (r *PgVectorRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.table), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrDocumentNotFound
+	}
+	return nil
+}
+
+// DeleteByFileID 删除指定文件的所有段落
+func (r *PgVectorRepository) DeleteByFileID(fileID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE file_id = $1`, r.table), fileID); err != nil {
+		return fmt.Errorf("failed to delete file documents: %w", err)
+	}
+	return nil
+}
+
+// Search 相似度搜索，按距离排序由数据库利用向量索引完成
+func (r *PgVectorRepository) Search(vector []float32, filter SearchFilter) ([]SearchResult, error) {
+	if err := ValidateVector(vector, r.dimension); err != nil {
+		return nil, err
+	}
+	op, err := pgDistanceOperator(r.distanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args, err := buildPgFilter(filter, 2)
+	if err != nil {
+		return nil, err
+	}
+	limit := filter.MaxResults
+	if limit <= 0 {
+		limit = DefaultSearchFilter().MaxResults
+	}
+
+	query := fmt.Sprintf(`SELECT %s, embedding %s $1::vector AS distance FROM %s %s ORDER BY distance LIMIT %d`,
+		pgDocumentColumns, op, r.table, where, limit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{formatPgVector(vector)}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]SearchResult, 0, limit)
+	for rows.Next() {
+		var distance float64
+		doc, err := scanPgDocument(rows.Scan, &distance)
+		if err != nil {
+			return nil, err
+		}
+		if result, ok := r.searchResult(doc, distance, filter); ok {
+			results = append(results, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	SortSearchResults(results)
+	return results, nil
+}
+
+// SearchBatch 批量相似度搜索
+// 所有查询向量作为数组参数传入，通过LATERAL子查询在一次SQL往返中完成，每个子查询都能使用向量索引
+func (r *PgVectorRepository) SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	literals := make([]string, len(vectors))
+	for i, vector := range vectors {
+		if err := ValidateVector(vector, r.dimension); err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+		literals[i] = formatPgVector(vector)
+	}
+	batch := make([][]SearchResult, len(vectors))
+	for i := range batch {
+		batch[i] = []SearchResult{}
+	}
+	if len(vectors) == 0 {
+		return batch, nil
+	}
+
+	op, err := pgDistanceOperator(r.distanceType)
+	if err != nil {
+		return nil, err
+	}
+	where, args, err := buildPgFilter(filter, 2)
+	if err != nil {
+		return nil, err
+	}
+	limit := filter.MaxResults
+	if limit <= 0 {
+		limit = DefaultSearchFilter().MaxResults
+	}
+
+	query := fmt.Sprintf(`SELECT q.idx, d.* FROM unnest($1::text[]) WITH ORDINALITY AS q(vec, idx)
+		CROSS JOIN LATERAL (SELECT %s, embedding %s q.vec::vector AS distance FROM %s %s ORDER BY distance LIMIT %d) d
+		ORDER BY q.idx, d.distance`, pgDocumentColumns, op, r.table, where, limit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{literals}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var idx int64
+		var distance float64
+		doc, err := scanPgDocument(func(dest ...interface{}) error {
+			return rows.Scan(append([]interface{}{&idx}, dest...)...)
+		}, &distance)
+		if err != nil {
+			return nil, err
+		}
+		// WITH ORDINALITY从1开始编号
+		if idx < 1 || int(idx) > len(batch) {
+			return nil, fmt.Errorf("unexpected query index %d", idx)
+		}
+		if result, ok := r.searchResult(doc, distance, filter); ok {
+			batch[idx-1] = append(batch[idx-1], result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	for _, results := range batch {
+		SortSearchResults(results)
+	}
+	return batch, nil
+}
+
+// searchResult 将数据库返回的距离转换为搜索结果，分数低于阈值时返回false
+func (r *PgVectorRepository) searchResult(doc Document, distance float64, filter SearchFilter) (SearchResult, bool) {
+	dist := float32(distance)
+	if r.distanceType == DotProduct {
+		dist = -dist // <#>返回负内积，转换为内积
+	}
+	score := DistanceToScore(dist, r.distanceType)
+	if score < filter.MinScore {
+		return SearchResult{}, false
+	}
+	return SearchResult{Document: doc, Score: score, Distance: dist}, true
+}
+
+// Count 获取文档总数
+func (r *PgVectorRepository) Count() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, r.table)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	return count, nil
+}
+
+// Describe 返回向量数据库的配置和状态，存储占用包括向量表的索引和TOAST数据
+func (r *PgVectorRepository) Describe() (Info, error) {
+	count, err := r.Count()
+	if err != nil {
+		return Info{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgQueryTimeout)
+	defer cancel()
+	var size int64
+	if err := r.db.QueryRowContext(ctx, `SELECT pg_total_relation_size($1::regclass)`, r.table).Scan(&size); err != nil {
+		return Info{}, fmt.Errorf("failed to get table size: %w", err)
+	}
+
+	return Info{
+		Type:        "pgvector",
+		IndexType:   r.indexType,
+		Dimension:   r.dimension,
+		Distance:    r.distanceType,
+		Count:       count,
+		MemoryBytes: size,
+	}, nil
+}
+
+// Flush 写入时已提交到Postgres，直接返回
+func (r *PgVectorRepository) Flush() error {
+	return nil
+}
+
+// GetDimension 返回向量维数
+func (r *PgVectorRepository) GetDimension() int {
+	return r.dimension
+}
+
+// Export 将全部文档导出为快照
+// 在只读的可重复读事务中读取，保证导出的是同一时刻的数据
+func (r *PgVectorRepository) Export(w io.Writer) error {
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, r.table)).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT %s FROM %s ORDER BY id`, pgDocumentColumns, r.table))
+	if err != nil {
+		return fmt.Errorf("failed to read documents: %w", err)
+	}
+	defer rows.Close()
+
+	return writeSnapshot(w, r.dimension, r.distanceType, count, func(emit func(Document) error) error {
+		for rows.Next() {
+			doc, err := scanPgDocument(rows.Scan)
+			if err != nil {
+				return err
+			}
+			if err := emit(doc); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// Import 从快照导入文档
+func (r *PgVectorRepository) Import(reader io.Reader) (int, error) {
+	return importSnapshot(r, reader)
+}
+
+// Ping 检查与Postgres的连接
+func (r *PgVectorRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// Close 关闭数据库连接
+func (r *PgVectorRepository) Close() error {
+	return r.db.Close()
+}
+
+func init() {
+	RegisterRepository("pgvector", NewPgVectorRepository)
+}
@@ -0,0 +1,136 @@
+package vectordb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPgVectorHelpers 测试pgvector向量编码、索引语句和过滤条件生成
+func TestPgVectorHelpers(t *testing.T) {
+	literal := formatPgVector([]float32{0.5, -1, 2.25})
+	assert.Equal(t, "[0.5,-1,2.25]", literal)
+	vector, err := parsePgVector(literal)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.5, -1, 2.25}, vector)
+	_, err = parsePgVector("0.5,1")
+	assert.Error(t, err)
+
+	ddl, err := pgIndexDDL("vectors", Cosine, Config{})
+	require.NoError(t, err)
+	assert.Contains(t, ddl, "USING hnsw (embedding vector_cosine_ops) WITH (m = 16, ef_construction = 64)")
+	ddl, err = pgIndexDDL("vectors", Euclidean, Config{IndexType: "ivfflat", IVFLists: 50})
+	require.NoError(t, err)
+	assert.Contains(t, ddl, "USING ivfflat (embedding vector_l2_ops) WITH (lists = 50)")
+	ddl, err = pgIndexDDL("vectors", DotProduct, Config{IndexType: "flat"})
+	require.NoError(t, err)
+	assert.Empty(t, ddl)
+	_, err = pgIndexDDL("vectors", Cosine, Config{IndexType: "lsh"})
+	assert.Error(t, err)
+
+	where, args, err := buildPgFilter(SearchFilter{
+		FileIDs:  []string{"a", "b"},
+		Metadata: map[string]interface{}{"section": "^=安装_"},
+	}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "WHERE file_id = ANY($2) AND metadata->>$3 LIKE $4", where)
+	assert.Equal(t, []interface{}{[]string{"a", "b"}, "section", `安装\_%`}, args)
+
+	where, args, err = buildPgFilter(SearchFilter{Metadata: map[string]interface{}{"page": "3"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "WHERE metadata @> $1::jsonb", where)
+	assert.Equal(t, []interface{}{`{"page":"3"}`}, args)
+
+	where, args, err = buildPgFilter(SearchFilter{Namespace: "tenant-a", FileIDs: []string{"a"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "WHERE namespace = $1 AND file_id = ANY($2)", where)
+	assert.Equal(t, []interface{}{"tenant-a", []string{"a"}}, args)
+
+	where, args, err = buildPgFilter(SearchFilter{ExcludeFileIDs: []string{"draft"}}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "WHERE NOT (file_id = ANY($1))", where)
+	assert.Equal(t, []interface{}{[]string{"draft"}}, args)
+
+	where, _, err = buildPgFilter(SearchFilter{}, 1)
+	require.NoError(t, err)
+	assert.Empty(t, where)
+}
+
+// TestPgVectorRepository 测试pgvector仓库的读写和过滤搜索
+// 需要设置PGVECTOR_DSN指向安装了pgvector扩展的Postgres
+func TestPgVectorRepository(t *testing.T) {
+	dsn := os.Getenv("PGVECTOR_DSN")
+	if dsn == "" {
+		t.Skip("PGVECTOR_DSN environment variable not set, skipping pgvector tests")
+	}
+
+	table := fmt.Sprintf("test_vectors_%d", time.Now().UnixNano())
+	repo, err := NewRepository(Config{
+		Type:         "pgvector",
+		Path:         dsn,
+		Dimension:    3,
+		DistanceType: Cosine,
+		TableName:    table,
+	})
+	require.NoError(t, err)
+	pg := repo.(*PgVectorRepository)
+	t.Cleanup(func() {
+		pg.db.Exec("DROP TABLE IF EXISTS " + table)
+		repo.Close()
+	})
+
+	require.NoError(t, repo.AddBatch([]Document{
+		{ID: "a_0", FileID: "a", FileName: "a.md", Text: "安装步骤", Vector: []float32{1, 0, 0}, Metadata: map[string]interface{}{"section": "安装"}},
+		{ID: "a_1", FileID: "a", FileName: "a.md", Position: 1, Text: "配置说明", Vector: []float32{0, 1, 0}, Metadata: map[string]interface{}{"section": "配置"}},
+		{ID: "b_0", Namespace: "faq", FileID: "b", FileName: "b.md", Text: "常见问题", Vector: []float32{0.9, 0.1, 0}},
+	}))
+
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	doc, err := repo.Get("a_1")
+	require.NoError(t, err)
+	assert.Equal(t, "配置说明", doc.Text)
+	assert.Equal(t, []float32{0, 1, 0}, doc.Vector)
+	assert.Equal(t, "配置", doc.Metadata["section"])
+
+	results, err := repo.Search([]float32{1, 0, 0}, SearchFilter{MaxResults: 2})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "a_0", results[0].Document.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	results, err = repo.Search([]float32{1, 0, 0}, SearchFilter{Namespace: "faq", MaxResults: 5})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "b_0", results[0].Document.ID)
+	assert.Equal(t, "faq", results[0].Document.Namespace)
+
+	batch, err := repo.SearchBatch([][]float32{{1, 0, 0}, {0, 1, 0}}, SearchFilter{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.Len(t, batch[0], 1)
+	require.Len(t, batch[1], 1)
+	assert.Equal(t, "a_0", batch[0][0].Document.ID)
+	assert.Equal(t, "a_1", batch[1][0].Document.ID)
+
+	results, err = repo.Search([]float32{1, 0, 0}, SearchFilter{FileIDs: []string{"a"}, Metadata: map[string]interface{}{"section": "配置"}, MaxResults: 5})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a_1", results[0].Document.ID)
+
+	require.NoError(t, repo.Delete("a_0"))
+	assert.ErrorIs(t, repo.Delete("a_0"), ErrDocumentNotFound)
+	_, err = repo.Get("a_0")
+	assert.ErrorIs(t, err, ErrDocumentNotFound)
+
+	require.NoError(t, repo.DeleteByFileID("a"))
+	count, err = repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,208 @@
+package vectordb
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 查询缓存的默认容量和有效期
+const (
+	DefaultQueryCacheSize = 1000
+	defaultQueryCacheTTL  = 5 * time.Minute
+)
+
+// QueryCacheStats 查询缓存的命中统计
+type QueryCacheStats struct {
+	Hits          int64 `json:"hits"`          // 命中次数
+	Misses        int64 `json:"misses"`        // 未命中次数
+	Evictions     int64 `json:"evictions"`     // 超出容量或过期被淘汰的条目数
+	Invalidations int64 `json:"invalidations"` // 数据变更后失效的条目数
+	Entries       int   `json:"entries"`       // 当前条目数
+	MaxEntries    int   `json:"max_entries"`   // 最大条目数
+}
+
+// HitRate 返回命中率，没有查询时为0
+func (s QueryCacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// WritePrometheus 以Prometheus文本格式输出指标
+func (s QueryCacheStats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("# HELP docqa_vectordb_query_cache_requests_total Vector search cache lookups by result.\n")
+	b.WriteString("# TYPE docqa_vectordb_query_cache_requests_total counter\n")
+	fmt.Fprintf(&b, "docqa_vectordb_query_cache_requests_total{result=\"hit\"} %d\n", s.Hits)
+	fmt.Fprintf(&b, "docqa_vectordb_query_cache_requests_total{result=\"miss\"} %d\n", s.Misses)
+
+	b.WriteString("# HELP docqa_vectordb_query_cache_evictions_total Cache entries evicted by capacity or expiry.\n")
+	b.WriteString("# TYPE docqa_vectordb_query_cache_evictions_total counter\n")
+	fmt.Fprintf(&b, "docqa_vectordb_query_cache_evictions_total %d\n", s.Evictions)
+
+	b.WriteString("# HELP docqa_vectordb_query_cache_invalidations_total Cache entries invalidated by index changes.\n")
+	b.WriteString("# TYPE docqa_vectordb_query_cache_invalidations_total counter\n")
+	fmt.Fprintf(&b, "docqa_vectordb_query_cache_invalidations_total %d\n", s.Invalidations)
+
+	b.WriteString("# HELP docqa_vectordb_query_cache_entries Entries currently in the vector search cache.\n")
+	b.WriteString("# TYPE docqa_vectordb_query_cache_entries gauge\n")
+	fmt.Fprintf(&b, "docqa_vectordb_query_cache_entries %d\n", s.Entries)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// queryCacheEntry 查询缓存条目
+type queryCacheEntry struct {
+	key     string
+	results []SearchResult
+	tags    []string
+	expires time.Time
+}
+
+// queryCache 并发安全的LRU查询结果缓存
+// 条目带有标签（如结果涉及的文件、查询限定的文件范围），数据变更时只失效相关标签的条目。
+// 每次失效都会递增代数，查询开始后发生过失效的结果不再写入，避免缓存查询期间被删除的数据
+type queryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List                     // 按最近使用排序，表头为最近使用
+	entries    map[string]*list.Element       // 缓存键到链表节点的映射
+	tags       map[string]map[string]struct{} // 标签到缓存键集合的映射
+	generation uint64                         // 失效代数
+	stats      QueryCacheStats
+	now        func() time.Time
+}
+
+// newQueryCache 创建查询缓存，maxEntries不大于0时缓存不保存任何条目
+func newQueryCache(maxEntries int, ttl time.Duration) *queryCache {
+	if maxEntries < 0 {
+		maxEntries = 0
+	}
+	return &queryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+		stats:      QueryCacheStats{MaxEntries: maxEntries},
+		now:        time.Now,
+	}
+}
+
+// get 查询缓存，命中时将条目移到表头
+func (c *queryCache) get(key string) ([]SearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := el.Value.(*queryCacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(el)
+		c.stats.Evictions++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.stats.Hits++
+	return entry.results, true
+}
+
+// currentGeneration 返回当前的失效代数，查询开始前读取，写入缓存时传给set
+func (c *queryCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// set 写入缓存，generation与当前代数不同说明查询期间数据已变更，结果不写入
+func (c *queryCache) set(key string, results []SearchResult, tags []string, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries == 0 || generation != c.generation {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+
+	entry := &queryCacheEntry{key: key, results: results, tags: tags, expires: c.now().Add(c.ttl)}
+	c.entries[key] = c.ll.PushFront(entry)
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for c.ll.Len() > c.maxEntries {
+		c.remove(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+// invalidate 使带有任一标签的条目失效
+func (c *queryCache) invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.entries[key]; ok {
+				c.remove(el)
+				c.stats.Invalidations++
+			}
+		}
+	}
+}
+
+// clear 使全部条目失效，用于重建索引等影响所有查询的变更
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.stats.Invalidations += int64(c.ll.Len())
+	c.ll.Init()
+	c.entries = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// snapshot 返回命中统计
+func (c *queryCache) snapshot() QueryCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.ll.Len()
+	return stats
+}
+
+// remove 删除条目及其标签索引，调用方持有锁
+func (c *queryCache) remove(el *list.Element) {
+	entry := c.ll.Remove(el).(*queryCacheEntry)
+	delete(c.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package vectordb
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestQueryCacheLRU 测试查询缓存的LRU淘汰、过期、按标签失效和命中统计
+func TestQueryCacheLRU(t *testing.T) {
+	cache := newQueryCache(2, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	results := []SearchResult{{Document: Document{ID: "a_0", FileID: "a"}}}
+
+	gen := cache.currentGeneration()
+	cache.set("q1", results, []string{"file:a"}, gen)
+	cache.set("q2", results, []string{"file:b"}, gen)
+	_, ok := cache.get("q1")
+	assert.True(t, ok)
+
+	// 超出容量时淘汰最久未使用的q2
+	cache.set("q3", results, []string{"file:b"}, gen)
+	_, ok = cache.get("q2")
+	assert.False(t, ok)
+
+	// 按标签失效，其他条目保留
+	cache.invalidate("file:b")
+	_, ok = cache.get("q3")
+	assert.False(t, ok)
+	_, ok = cache.get("q1")
+	assert.True(t, ok)
+
+	// 查询开始后发生过失效的结果不写入
+	cache.set("q4", results, nil, gen)
+	_, ok = cache.get("q4")
+	assert.False(t, ok)
+
+	// 过期条目视为未命中
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.get("q1")
+	assert.False(t, ok)
+
+	stats := cache.snapshot()
+	assert.Equal(t, QueryCacheStats{Hits: 2, Misses: 4, Evictions: 2, Invalidations: 1, Entries: 0, MaxEntries: 2}, stats)
+	assert.InDelta(t, 2.0/6, stats.HitRate(), 1e-9)
+
+	var buf bytes.Buffer
+	require.NoError(t, stats.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), `docqa_vectordb_query_cache_requests_total{result="hit"} 2`)
+	assert.Contains(t, buf.String(), "docqa_vectordb_query_cache_evictions_total 2")
+
+	// 容量为0时不缓存
+	disabled := newQueryCache(-1, time.Minute)
+	disabled.set("q1", results, nil, disabled.currentGeneration())
+	_, ok = disabled.get("q1")
+	assert.False(t, ok)
+}
+
+// TestFaissQueryCacheInvalidation 测试Faiss数据变更后查询缓存不返回过时结果
+func TestFaissQueryCacheInvalidation(t *testing.T) {
+	repo, err := NewRepository(Config{
+		Type:         "faiss",
+		Path:         filepath.Join(t.TempDir(), "index.faiss"),
+		Dimension:    4,
+		DistanceType: Cosine,
+	})
+	require.NoError(t, err)
+	defer repo.Close()
+	faissRepo := repo.(*FaissRepository)
+
+	require.NoError(t, repo.AddBatch([]Document{
+		{ID: "a_0", FileID: "a", Text: "甲", Vector: []float32{1, 0, 0, 0}},
+		{ID: "b_0", FileID: "b", Text: "乙", Vector: []float32{0, 1, 0, 0}},
+	}))
+	query := []float32{1, 0.1, 0, 0}
+	search := func(filter SearchFilter) []string {
+		results, err := repo.Search(query, filter)
+		require.NoError(t, err)
+		ids := make([]string, 0, len(results))
+		for _, r := range results {
+			ids = append(ids, r.Document.ID)
+		}
+		return ids
+	}
+
+	all := SearchFilter{MaxResults: 1}
+	onlyB := SearchFilter{MaxResults: 1, FileIDs: []string{"b"}}
+	assert.Equal(t, []string{"a_0"}, search(all))
+	assert.Equal(t, []string{"b_0"}, search(onlyB))
+	assert.Equal(t, []string{"a_0"}, search(all))
+	assert.Equal(t, int64(1), faissRepo.queryCache.snapshot().Hits)
+
+	// 不同的结果数使用不同的缓存键
+	assert.Equal(t, []string{"a_0", "b_0"}, search(SearchFilter{MaxResults: 2}))
+
+	// 新增文件c只影响未限定文件的查询
+	require.NoError(t, repo.Add(Document{ID: "c_0", FileID: "c", Text: "丙", Vector: []float32{1, 0.1, 0, 0}}))
+	assert.Equal(t, []string{"c_0"}, search(all))
+	hits := faissRepo.queryCache.snapshot().Hits
+	assert.Equal(t, []string{"b_0"}, search(onlyB))
+	assert.Equal(t, hits+1, faissRepo.queryCache.snapshot().Hits)
+
+	// 删除文件后包含该文件的结果失效
+	require.NoError(t, repo.DeleteByFileID("c"))
+	assert.Equal(t, []string{"a_0"}, search(all))
+	require.NoError(t, repo.Delete("b_0"))
+	assert.Empty(t, search(onlyB))
+
+	// 修改返回的结果不影响缓存
+	results, err := repo.Search(query, all)
+	require.NoError(t, err)
+	results[0].Document.ID = "changed"
+	assert.Equal(t, []string{"a_0"}, search(all))
+}
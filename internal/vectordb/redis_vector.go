@@ -0,0 +1,627 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// defaultRedisIndex 默认的向量索引名
+	defaultRedisIndex = "docqa_vectors"
+	// redisQueryTimeout 单次Redis操作的超时时间
+	redisQueryTimeout = 30 * time.Second
+	// redisMetadataOverfetch 有元数据过滤时多取的候选倍数
+	redisMetadataOverfetch = 10
+)
+
+// RedisVectorRepository 基于Redis Stack（RediSearch）的向量仓库
+// 每个段落存为一个Hash，向量以FLOAT32二进制存储并由RediSearch建立向量索引；
+// 每个文件维护一个段落ID集合，用于按文件删除
+// RediSearch无法索引任意元数据，元数据过滤在取回候选后进行
+type RedisVectorRepository struct {
+	client       *redis.Client // Redis客户端
+	index        string        // RediSearch索引名
+	docPrefix    string        // 段落Hash的键前缀
+	filePrefix   string        // 文件段落集合的键前缀
+	dimension    int           // 向量维度
+	distanceType DistanceType  // 距离计算类型
+	indexType    string        // 配置的向量索引类型
+}
+
+// NewRedisVectorRepository 创建Redis向量仓库
+// config.Path为Redis地址（host:port）或redis://连接串，索引不存在时自动创建
+func NewRedisVectorRepository(config Config) (Repository, error) {
+	if config.Dimension <= 0 {
+		return nil, fmt.Errorf("vector dimension must be positive")
+	}
+
+	distType := config.DistanceType
+	if distType == "" {
+		distType = Cosine
+	}
+	if _, err := redisDistanceMetric(distType); err != nil {
+		return nil, err
+	}
+
+	opts, err := redisOptions(config.Path)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	index := config.TableName
+	if index == "" {
+		index = defaultRedisIndex
+	}
+	repo := &RedisVectorRepository{
+		client:       client,
+		index:        index,
+		docPrefix:    index + ":doc:",
+		filePrefix:   index + ":file:",
+		dimension:    config.Dimension,
+		distanceType: distType,
+		indexType:    configuredIndexType(config.IndexType),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	if err := repo.ensureIndex(ctx, config); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// redisOptions 解析Redis地址
+// 搜索命令的返回结构只在RESP2下稳定，因此固定使用RESP2协议
+func redisOptions(path string) (*redis.Options, error) {
+	if path == "" {
+		path = "localhost:6379"
+	}
+	opts := &redis.Options{Addr: path}
+	if strings.Contains(path, "://") {
+		parsed, err := redis.ParseURL(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis url: %w", err)
+		}
+		opts = parsed
+	}
+	opts.Protocol = 2
+	return opts, nil
+}
+
+// ensureIndex 索引不存在时创建向量索引
+func (r *RedisVectorRepository) ensureIndex(ctx context.Context, config Config) error {
+	err := r.client.Do(ctx, "FT.INFO", r.index).Err()
+	if err == nil {
+		// 早期版本创建的索引没有命名空间字段，字段已存在时忽略错误
+		err = r.client.Do(ctx, "FT.ALTER", r.index, "SCHEMA", "ADD", "namespace", "TAG").Err()
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate") {
+			return fmt.Errorf("failed to add namespace field to redis index: %w", err)
+		}
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "unknown index") && !strings.Contains(msg, "no such index") {
+		return fmt.Errorf("failed to inspect redis index (is RediSearch loaded?): %w", err)
+	}
+
+	vectorArgs, err := redisVectorArgs(r.dimension, r.distanceType, config)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.FTCreate(ctx, r.index,
+		&redis.FTCreateOptions{OnHash: true, Prefix: []interface{}{r.docPrefix}},
+		&redis.FieldSchema{FieldName: "file_id", FieldType: redis.SearchFieldTypeTag},
+		&redis.FieldSchema{FieldName: "namespace", FieldType: redis.SearchFieldTypeTag},
+		&redis.FieldSchema{FieldName: "position", FieldType: redis.SearchFieldTypeNumeric},
+		&redis.FieldSchema{FieldName: "embedding", FieldType: redis.SearchFieldTypeVector, VectorArgs: vectorArgs},
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to create redis vector index: %w", err)
+	}
+	return nil
+}
+
+// redisVectorArgs 生成向量字段的索引参数
+func redisVectorArgs(dimension int, distType DistanceType, config Config) (*redis.FTVectorArgs, error) {
+	metric, err := redisDistanceMetric(distType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(config.IndexType) {
+	case "", "hnsw":
+		m := config.HNSWM
+		if m <= 0 {
+			m = defaultHNSWM
+		}
+		ef := config.HNSWEfConstruction
+		if ef <= 0 {
+			ef = defaultHNSWEfConstruction
+		}
+		return &redis.FTVectorArgs{HNSWOptions: &redis.FTHNSWOptions{
+			Type:                   "FLOAT32",
+			Dim:                    dimension,
+			DistanceMetric:         metric,
+			MaxEdgesPerNode:        m,
+			MaxAllowedEdgesPerNode: ef, // 对应EF_CONSTRUCTION
+			EFRunTime:              config.HNSWEfRuntime,
+		}}, nil
+	case "flat", "none":
+		return &redis.FTVectorArgs{FlatOptions: &redis.FTFlatOptions{
+			Type:           "FLOAT32",
+			Dim:            dimension,
+			DistanceMetric: metric,
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported redis index type: %s", config.IndexType)
+	}
+}
+
+// redisDistanceMetric 返回距离类型对应的RediSearch距离度量
+func redisDistanceMetric(distType DistanceType) (string, error) {
+	switch distType {
+	case Cosine:
+		return "COSINE", nil
+	case DotProduct:
+		return "IP", nil
+	case Euclidean:
+		return "L2", nil
+	default:
+		return "", fmt.Errorf("unsupported distance type: %s", distType)
+	}
+}
+
+// redisDistance 将RediSearch返回的距离转换为本包的距离语义
+// COSINE返回1-余弦相似度，与本包一致；IP返回1-内积；L2返回欧氏距离的平方
+func redisDistance(raw float64, distType DistanceType) float32 {
+	switch distType {
+	case DotProduct:
+		return float32(1 - raw)
+	case Euclidean:
+		return float32(math.Sqrt(math.Max(raw, 0)))
+	default:
+		return float32(raw)
+	}
+}
+
+// encodeRedisVector 将向量编码为小端FLOAT32字节串
+func encodeRedisVector(vector []float32) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+// decodeRedisVector 解码小端FLOAT32字节串
+func decodeRedisVector(data []byte) ([]float32, error) {
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector blob length: %d", len(data))
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vector, nil
+}
+
+// escapeRedisTag 转义TAG查询中的特殊字符
+func escapeRedisTag(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r < 128 && !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// redisKNNQuery 生成KNN查询语句，指定命名空间、文件或排除的文件时先在索引内过滤
+func redisKNNQuery(namespace string, fileIDs, excludeFileIDs []string, k int) string {
+	var conds []string
+	if namespace != "" {
+		conds = append(conds, "@namespace:{"+escapeRedisTag(namespace)+"}")
+	}
+	if len(fileIDs) > 0 {
+		tags := make([]string, len(fileIDs))
+		for i, id := range fileIDs {
+			tags[i] = escapeRedisTag(id)
+		}
+		conds = append(conds, "@file_id:{"+strings.Join(tags, "|")+"}")
+	}
+	if len(excludeFileIDs) > 0 {
+		tags := make([]string, len(excludeFileIDs))
+		for i, id := range excludeFileIDs {
+			tags[i] = escapeRedisTag(id)
+		}
+		conds = append(conds, "-@file_id:{"+strings.Join(tags, "|")+"}")
+	}
+	prefilter := "*"
+	if len(conds) > 0 {
+		prefilter = "(" + strings.Join(conds, " ") + ")"
+	}
+	return fmt.Sprintf("%s=>[KNN %d @embedding $vec AS distance]", prefilter, k)
+}
+
+// docKey 返回段落Hash的键
+func (r *RedisVectorRepository) docKey(id string) string {
+	return r.docPrefix + id
+}
+
+// fileKey 返回文件段落集合的键
+func (r *RedisVectorRepository) fileKey(fileID string) string {
+	return r.filePrefix + fileID
+}
+
+// Add 添加单个文档
+func (r *RedisVectorRepository) Add(doc Document) error {
+	return r.AddBatch([]Document{doc})
+}
+
+// AddBatch 批量添加文档，ID已存在时覆盖
+func (r *RedisVectorRepository) AddBatch(docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return ErrInvalidID
+		}
+		if err := ValidateVector(doc.Vector, r.dimension); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	// 覆盖已有文档时需要把它从原文件的集合中移除
+	pipe := r.client.Pipeline()
+	oldFiles := make([]*redis.StringCmd, len(docs))
+	for i, doc := range docs {
+		oldFiles[i] = pipe.HGet(ctx, r.docKey(doc.ID), "file_id")
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("failed to read existing documents: %w", err)
+	}
+
+	pipe = r.client.TxPipeline()
+	for i, doc := range docs {
+		metadata := doc.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metaJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of %s: %w", doc.ID, err)
+		}
+		createdAt := doc.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+
+		if old, err := oldFiles[i].Result(); err == nil && old != doc.FileID {
+			pipe.SRem(ctx, r.fileKey(old), doc.ID)
+		}
+		pipe.HSet(ctx, r.docKey(doc.ID),
+			"id", doc.ID,
+			"namespace", doc.Namespace,
+			"file_id", doc.FileID,
+			"file_name", doc.FileName,
+			"position", doc.Position,
+			"text", doc.Text,
+			"metadata", string(metaJSON),
+			"created_at", createdAt.Format(time.RFC3339Nano),
+			"embedding", encodeRedisVector(doc.Vector),
+		)
+		pipe.SAdd(ctx, r.fileKey(doc.FileID), doc.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store documents: %w", err)
+	}
+	return nil
+}
+
+// Get 获取单个文档
+func (r *RedisVectorRepository) Get(id string) (Document, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	fields, err := r.client.HGetAll(ctx, r.docKey(id)).Result()
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to get document: %w", err)
+	}
+	if len(fields) == 0 {
+		return Document{}, ErrDocumentNotFound
+	}
+	return redisDocument(fields)
+}
+
+// redisDocument 从Hash字段还原文档
+func redisDocument(fields map[string]string) (Document, error) {
+	doc := Document{
+		ID:        fields["id"],
+		Namespace: fields["namespace"],
+		FileID:    fields["file_id"],
+		FileName:  fields["file_name"],
+		Text:      fields["text"],
+	}
+	doc.Position, _ = strconv.Atoi(fields["position"])
+	doc.CreatedAt, _ = time.Parse(time.RFC3339Nano, fields["created_at"])
+
+	var err error
+	if doc.Vector, err = decodeRedisVector([]byte(fields["embedding"])); err != nil {
+		return Document{}, err
+	}
+	if meta := fields["metadata"]; meta != "" {
+		if err := json.Unmarshal([]byte(meta), &doc.Metadata); err != nil {
+			return Document{}, fmt.Errorf("failed to decode metadata of %s: %w", doc.ID, err)
+		}
+	}
+	return doc, nil
+}
+
+// Delete 删除单个文档
+func (r *RedisVectorRepository) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	fileID, err := r.client.HGet(ctx, r.docKey(id), "file_id").Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrDocumentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Del(ctx, r.docKey(id))
+	pipe.SRem(ctx, r.fileKey(fileID), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// DeleteByFileID 删除指定文件的所有段落
+func (r *RedisVectorRepository) DeleteByFileID(fileID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	ids, err := r.client.SMembers(ctx, r.fileKey(fileID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list file documents: %w", err)
+	}
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, r.docKey(id))
+	}
+	keys = append(keys, r.fileKey(fileID))
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete file documents: %w", err)
+	}
+	return nil
+}
+
+// Search 相似度搜索
+// 命名空间和文件过滤在索引内完成；有元数据过滤时多取候选再过滤，极端情况下结果可能少于MaxResults
+func (r *RedisVectorRepository) Search(vector []float32, filter SearchFilter) ([]SearchResult, error) {
+	results, err := r.SearchBatch([][]float32{vector}, filter)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SearchBatch 批量相似度搜索，所有查询通过一次管道发送
+func (r *RedisVectorRepository) SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	for _, vector := range vectors {
+		if err := ValidateVector(vector, r.dimension); err != nil {
+			return nil, err
+		}
+	}
+	if len(vectors) == 0 {
+		return [][]SearchResult{}, nil
+	}
+
+	limit := filter.MaxResults
+	if limit <= 0 {
+		limit = DefaultSearchFilter().MaxResults
+	}
+	k := limit
+	if len(filter.Metadata) > 0 {
+		k = limit * redisMetadataOverfetch
+	}
+	query := redisKNNQuery(filter.Namespace, filter.FileIDs, filter.ExcludeFileIDs, k)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.FTSearchCmd, len(vectors))
+	for i, vector := range vectors {
+		cmds[i] = pipe.FTSearchWithArgs(ctx, r.index, query, &redis.FTSearchOptions{
+			Params:         map[string]interface{}{"vec": encodeRedisVector(vector)},
+			SortBy:         []redis.FTSearchSortBy{{FieldName: "distance", Asc: true}},
+			Limit:          k,
+			DialectVersion: 2,
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+
+	batch := make([][]SearchResult, len(vectors))
+	for i, cmd := range cmds {
+		results, err := r.searchResults(cmd.Val(), filter, limit)
+		if err != nil {
+			return nil, err
+		}
+		batch[i] = results
+	}
+	return batch, nil
+}
+
+// searchResults 将FT.SEARCH的返回转换为搜索结果，并应用元数据和分数过滤
+func (r *RedisVectorRepository) searchResults(res redis.FTSearchResult, filter SearchFilter, limit int) ([]SearchResult, error) {
+	results := make([]SearchResult, 0, limit)
+	for _, hit := range res.Docs {
+		doc, err := redisDocument(hit.Fields)
+		if err != nil {
+			return nil, err
+		}
+		if !matchMetadata(doc.Metadata, filter.Metadata) {
+			continue
+		}
+
+		raw, err := strconv.ParseFloat(hit.Fields["distance"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid distance for %s: %w", doc.ID, err)
+		}
+		dist := redisDistance(raw, r.distanceType)
+		score := DistanceToScore(dist, r.distanceType)
+		if score < filter.MinScore {
+			continue
+		}
+		results = append(results, SearchResult{Document: doc, Score: score, Distance: dist})
+	}
+
+	SortSearchResults(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Count 获取文档总数
+func (r *RedisVectorRepository) Count() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisQueryTimeout)
+	defer cancel()
+
+	res, err := r.client.Do(ctx, "FT.SEARCH", r.index, "*", "LIMIT", 0, 0).Slice()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	total, ok := res[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count reply: %v", res[0])
+	}
+	return int(total), nil
+}
+
+// Describe 返回向量数据库的配置和状态，内存占用由Redis服务端管理，不做统计
+func (r *RedisVectorRepository) Describe() (Info, error) {
+	count, err := r.Count()
+	if err != nil {
+		return Info{}, err
+	}
+	return Info{
+		Type:      "redis",
+		IndexType: r.indexType,
+		Dimension: r.dimension,
+		Distance:  r.distanceType,
+		Count:     count,
+	}, nil
+}
+
+// Flush 持久化由Redis服务端的RDB/AOF配置负责，直接返回
+func (r *RedisVectorRepository) Flush() error {
+	return nil
+}
+
+// GetDimension 返回向量维数
+func (r *RedisVectorRepository) GetDimension() int {
+	return r.dimension
+}
+
+// Export 将全部文档导出为快照
+// Redis没有快照读，导出期间的写入可能部分可见
+func (r *RedisVectorRepository) Export(w io.Writer) error {
+	ctx := context.Background()
+
+	var keys []string
+	iter := r.client.Scan(ctx, 0, r.docPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to list documents: %w", err)
+	}
+	sort.Strings(keys)
+
+	return writeSnapshot(w, r.dimension, r.distanceType, len(keys), func(emit func(Document) error) error {
+		for start := 0; start < len(keys); start += snapshotBatchSize {
+			end := start + snapshotBatchSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+
+			pipe := r.client.Pipeline()
+			cmds := make([]*redis.MapStringStringCmd, 0, end-start)
+			for _, key := range keys[start:end] {
+				cmds = append(cmds, pipe.HGetAll(ctx, key))
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return fmt.Errorf("failed to read documents: %w", err)
+			}
+
+			for _, cmd := range cmds {
+				fields := cmd.Val()
+				if len(fields) == 0 {
+					continue // 导出期间被删除
+				}
+				doc, err := redisDocument(fields)
+				if err != nil {
+					return err
+				}
+				if err := emit(doc); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Import 从快照导入文档
+func (r *RedisVectorRepository) Import(reader io.Reader) (int, error) {
+	return importSnapshot(r, reader)
+}
+
+// Ping 检查与Redis的连接
+func (r *RedisVectorRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Close 关闭Redis连接
+func (r *RedisVectorRepository) Close() error {
+	return r.client.Close()
+}
+
+func init() {
+	RegisterRepository("redis", NewRedisVectorRepository)
+}
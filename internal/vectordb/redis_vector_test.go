@@ -0,0 +1,127 @@
+package vectordb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisVectorHelpers 测试Redis向量编码、查询语句和距离转换
+func TestRedisVectorHelpers(t *testing.T) {
+	vector := []float32{0.5, -1, 2.25}
+	decoded, err := decodeRedisVector(encodeRedisVector(vector))
+	require.NoError(t, err)
+	assert.Equal(t, vector, decoded)
+	_, err = decodeRedisVector([]byte{1, 2, 3})
+	assert.Error(t, err)
+
+	assert.Equal(t, "*=>[KNN 5 @embedding $vec AS distance]", redisKNNQuery("", nil, nil, 5))
+	assert.Equal(t, `(@file_id:{a\-1|b_2})=>[KNN 3 @embedding $vec AS distance]`, redisKNNQuery("", []string{"a-1", "b_2"}, nil, 3))
+	assert.Equal(t, `(@namespace:{tenant\-a} @file_id:{a})=>[KNN 3 @embedding $vec AS distance]`, redisKNNQuery("tenant-a", []string{"a"}, nil, 3))
+	assert.Equal(t, `(-@file_id:{draft\-1})=>[KNN 3 @embedding $vec AS distance]`, redisKNNQuery("", nil, []string{"draft-1"}, 3))
+
+	assert.InDelta(t, 0.2, redisDistance(0.2, Cosine), 1e-6)
+	assert.InDelta(t, 0.8, redisDistance(0.2, DotProduct), 1e-6)
+	assert.InDelta(t, 2.0, redisDistance(4, Euclidean), 1e-6)
+
+	args, err := redisVectorArgs(3, Cosine, Config{HNSWM: 32, HNSWEfRuntime: 20})
+	require.NoError(t, err)
+	require.NotNil(t, args.HNSWOptions)
+	assert.Equal(t, "COSINE", args.HNSWOptions.DistanceMetric)
+	assert.Equal(t, 32, args.HNSWOptions.MaxEdgesPerNode)
+	assert.Equal(t, defaultHNSWEfConstruction, args.HNSWOptions.MaxAllowedEdgesPerNode)
+	assert.Equal(t, 20, args.HNSWOptions.EFRunTime)
+	args, err = redisVectorArgs(3, Euclidean, Config{IndexType: "flat"})
+	require.NoError(t, err)
+	require.NotNil(t, args.FlatOptions)
+	assert.Equal(t, "L2", args.FlatOptions.DistanceMetric)
+	_, err = redisVectorArgs(3, Cosine, Config{IndexType: "ivfflat"})
+	assert.Error(t, err)
+
+	opts, err := redisOptions("redis://:secret@cache:6380/2")
+	require.NoError(t, err)
+	assert.Equal(t, "cache:6380", opts.Addr)
+	assert.Equal(t, 2, opts.DB)
+	assert.Equal(t, 2, opts.Protocol)
+}
+
+// TestRedisVectorRepository 测试Redis向量仓库的读写和过滤搜索
+// 需要设置REDIS_STACK_ADDR指向加载了RediSearch模块的Redis
+func TestRedisVectorRepository(t *testing.T) {
+	addr := os.Getenv("REDIS_STACK_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_STACK_ADDR environment variable not set, skipping redis vector tests")
+	}
+
+	index := fmt.Sprintf("test_vectors_%d", time.Now().UnixNano())
+	repo, err := NewRepository(Config{
+		Type:         "redis",
+		Path:         addr,
+		Dimension:    3,
+		DistanceType: Cosine,
+		TableName:    index,
+	})
+	require.NoError(t, err)
+	rv := repo.(*RedisVectorRepository)
+	t.Cleanup(func() {
+		rv.DeleteByFileID("a")
+		rv.DeleteByFileID("b")
+		rv.client.Do(context.Background(), "FT.DROPINDEX", index)
+		repo.Close()
+	})
+
+	require.NoError(t, repo.AddBatch([]Document{
+		{ID: "a_0", FileID: "a", FileName: "a.md", Text: "安装步骤", Vector: []float32{1, 0, 0}, Metadata: map[string]interface{}{"section": "安装"}},
+		{ID: "a_1", FileID: "a", FileName: "a.md", Position: 1, Text: "配置说明", Vector: []float32{0, 1, 0}, Metadata: map[string]interface{}{"section": "配置"}},
+		{ID: "b_0", Namespace: "faq", FileID: "b", FileName: "b.md", Text: "常见问题", Vector: []float32{0.9, 0.1, 0}},
+	}))
+
+	// 索引是异步建立的，等待文档可被搜索
+	require.Eventually(t, func() bool {
+		count, err := repo.Count()
+		return err == nil && count == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	doc, err := repo.Get("a_1")
+	require.NoError(t, err)
+	assert.Equal(t, "配置说明", doc.Text)
+	assert.Equal(t, 1, doc.Position)
+	assert.Equal(t, []float32{0, 1, 0}, doc.Vector)
+
+	results, err := repo.Search([]float32{1, 0, 0}, SearchFilter{MaxResults: 2})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "a_0", results[0].Document.ID)
+	assert.InDelta(t, 1.0, results[0].Score, 1e-5)
+
+	results, err = repo.Search([]float32{1, 0, 0}, SearchFilter{Namespace: "faq", MaxResults: 5})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "b_0", results[0].Document.ID)
+	assert.Equal(t, "faq", results[0].Document.Namespace)
+
+	batch, err := repo.SearchBatch([][]float32{{1, 0, 0}, {0, 1, 0}}, SearchFilter{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.Len(t, batch[0], 1)
+	require.Len(t, batch[1], 1)
+	assert.Equal(t, "a_0", batch[0][0].Document.ID)
+	assert.Equal(t, "a_1", batch[1][0].Document.ID)
+
+	results, err = repo.Search([]float32{1, 0, 0}, SearchFilter{FileIDs: []string{"a"}, Metadata: map[string]interface{}{"section": "配置"}, MaxResults: 5})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "a_1", results[0].Document.ID)
+
+	require.NoError(t, repo.Delete("a_0"))
+	assert.ErrorIs(t, repo.Delete("a_0"), ErrDocumentNotFound)
+
+	require.NoError(t, repo.DeleteByFileID("a"))
+	_, err = repo.Get("a_1")
+	assert.ErrorIs(t, err, ErrDocumentNotFound)
+}
@@ -0,0 +1,284 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// remoteTimeout 单次请求远程向量索引服务的超时时间，导出和导入不受限制
+const remoteTimeout = 30 * time.Second
+
+// RemoteRepository 调用独立向量索引服务（docqa faiss-server）的向量仓库
+// 索引保存在单独的进程中（可以部署在带GPU的机器上），API服务不再持有索引内存，
+// 两者可以分别扩缩容。服务端协议见NewServer
+type RemoteRepository struct {
+	baseURL   string
+	dimension int
+	client    *http.Client
+}
+
+// NewRemoteRepository 创建远程向量仓库，config.Path为向量索引服务的地址
+// 创建时读取服务端的索引信息，维度与配置不一致时返回ErrInvalidDimension
+func NewRemoteRepository(config Config) (Repository, error) {
+	if config.Dimension <= 0 {
+		return nil, fmt.Errorf("vector dimension must be positive")
+	}
+	u, err := url.Parse(config.Path)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("faiss-server requires an http(s) address in path, got %q", config.Path)
+	}
+
+	repo := &RemoteRepository{
+		baseURL:   strings.TrimRight(config.Path, "/"),
+		dimension: config.Dimension,
+		client:    &http.Client{},
+	}
+	info, err := repo.Describe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to faiss-server: %w", err)
+	}
+	if info.Dimension != config.Dimension {
+		return nil, fmt.Errorf("%w: faiss-server index has dimension %d, configured %d", ErrInvalidDimension, info.Dimension, config.Dimension)
+	}
+	return repo, nil
+}
+
+// Add 添加单个文档
+func (r *RemoteRepository) Add(doc Document) error {
+	return r.AddBatch([]Document{doc})
+}
+
+// AddBatch 批量添加文档
+func (r *RemoteRepository) AddBatch(docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	for _, doc := range docs {
+		if doc.ID == "" {
+			return ErrInvalidID
+		}
+		if err := ValidateVector(doc.Vector, r.dimension); err != nil {
+			return err
+		}
+	}
+	req := remoteAddRequest{Documents: make([]snapshotDocument, len(docs))}
+	for i, doc := range docs {
+		req.Documents[i] = snapshotDocument(doc)
+	}
+	return r.call(http.MethodPost, "/v1/documents", req, nil)
+}
+
+// Get 获取单个文档
+func (r *RemoteRepository) Get(id string) (Document, error) {
+	var doc snapshotDocument
+	if err := r.call(http.MethodGet, "/v1/documents/"+url.PathEscape(id), nil, &doc); err != nil {
+		return Document{}, err
+	}
+	return Document(doc), nil
+}
+
+// Delete 删除单个文档
+func (r *RemoteRepository) Delete(id string) error {
+	return r.call(http.MethodDelete, "/v1/documents/"+url.PathEscape(id), nil, nil)
+}
+
+// DeleteByFileID 删除指定文件的所有段落
+func (r *RemoteRepository) DeleteByFileID(fileID string) error {
+	return r.call(http.MethodDelete, "/v1/files/"+url.PathEscape(fileID), nil, nil)
+}
+
+// Search 相似度搜索
+func (r *RemoteRepository) Search(vector []float32, filter SearchFilter) ([]SearchResult, error) {
+	results, err := r.SearchBatch([][]float32{vector}, filter)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SearchBatch 批量相似度搜索，所有向量在一次请求中发送
+func (r *RemoteRepository) SearchBatch(vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	if len(vectors) == 0 {
+		return [][]SearchResult{}, nil
+	}
+	for _, vector := range vectors {
+		if err := ValidateVector(vector, r.dimension); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp remoteSearchResponse
+	if err := r.call(http.MethodPost, "/v1/search", remoteSearchRequest{Vectors: vectors, Filter: toRemoteFilter(filter)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(vectors) {
+		return nil, fmt.Errorf("faiss-server returned %d result lists for %d vectors", len(resp.Results), len(vectors))
+	}
+	results := make([][]SearchResult, len(resp.Results))
+	for i, list := range resp.Results {
+		results[i] = make([]SearchResult, len(list))
+		for j, res := range list {
+			results[i][j] = SearchResult{Document: Document(res.Document), Score: res.Score, Distance: res.Distance}
+		}
+	}
+	return results, nil
+}
+
+// Count 获取文档总数
+func (r *RemoteRepository) Count() (int, error) {
+	info, err := r.Describe()
+	if err != nil {
+		return 0, err
+	}
+	return info.Count, nil
+}
+
+// GetDimension 返回向量维数
+func (r *RemoteRepository) GetDimension() int {
+	return r.dimension
+}
+
+// Export 将服务端的全部文档导出为快照
+func (r *RemoteRepository) Export(w io.Writer) error {
+	resp, err := r.stream(http.MethodGet, "/v1/export", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to download snapshot from faiss-server: %w", err)
+	}
+	return nil
+}
+
+// Import 将快照上传到服务端导入
+func (r *RemoteRepository) Import(rd io.Reader) (int, error) {
+	resp, err := r.stream(http.MethodPost, "/v1/import", rd)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var result remoteImportResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode faiss-server response: %w", err)
+	}
+	return result.Imported, nil
+}
+
+// Flush 要求服务端立即保存索引
+func (r *RemoteRepository) Flush() error {
+	return r.call(http.MethodPost, "/v1/flush", nil, nil)
+}
+
+// Close 关闭仓库，服务端的索引不受影响
+func (r *RemoteRepository) Close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+// Ping 检查与向量索引服务的连接
+func (r *RemoteRepository) Ping(ctx context.Context) error {
+	return r.do(ctx, http.MethodGet, "/v1/health", nil, nil)
+}
+
+// FileSegmentCounts 返回服务端统计的文件ID到段落数的映射
+func (r *RemoteRepository) FileSegmentCounts() (map[string]int, error) {
+	var counts map[string]int
+	if err := r.call(http.MethodGet, "/v1/files", nil, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// Describe 返回服务端索引的配置和状态
+func (r *RemoteRepository) Describe() (Info, error) {
+	var info remoteInfo
+	if err := r.call(http.MethodGet, "/v1/info", nil, &info); err != nil {
+		return Info{}, err
+	}
+	return Info{
+		Type:        "faiss-server",
+		IndexType:   info.IndexType,
+		Dimension:   info.Dimension,
+		Distance:    info.Distance,
+		Normalized:  info.Normalized,
+		Count:       info.Count,
+		MemoryBytes: info.MemoryBytes,
+		LastSave:    info.LastSave,
+		QueryCache:  info.QueryCache,
+	}, nil
+}
+
+// call 发送带超时的JSON请求
+func (r *RemoteRepository) call(method, path string, body, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	return r.do(ctx, method, path, body, out)
+}
+
+// do 发送JSON请求并解码响应，错误响应转换为对应的错误
+func (r *RemoteRepository) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("faiss-server request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return decodeRemoteError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode faiss-server response: %w", err)
+	}
+	return nil
+}
+
+// stream 发送不限时的请求，用于传输快照，调用方负责关闭响应体
+func (r *RemoteRepository) stream(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, r.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("faiss-server request failed: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, decodeRemoteError(resp)
+	}
+	return resp, nil
+}
+
+// 注册远程向量仓库
+func init() {
+	RegisterRepository("faiss-server", NewRemoteRepository)
+}
@@ -0,0 +1,277 @@
+package vectordb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 向量索引服务的HTTP协议
+// 文档使用与快照相同的JSON格式，错误响应为{"code": ..., "error": ...}，
+// code用于在客户端还原ErrDocumentNotFound等错误
+
+// remoteAddRequest 批量添加文档的请求
+type remoteAddRequest struct {
+	Documents []snapshotDocument `json:"documents"`
+}
+
+// remoteFilter 搜索过滤条件
+type remoteFilter struct {
+	Namespace      string                 `json:"namespace,omitempty"`
+	FileIDs        []string               `json:"file_ids,omitempty"`
+	ExcludeFileIDs []string               `json:"exclude_file_ids,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	MinScore       float32                `json:"min_score"`
+	MaxResults     int                    `json:"max_results"`
+}
+
+// toRemoteFilter 转换为协议中的过滤条件
+func toRemoteFilter(f SearchFilter) remoteFilter {
+	return remoteFilter{Namespace: f.Namespace, FileIDs: f.FileIDs, ExcludeFileIDs: f.ExcludeFileIDs, Metadata: f.Metadata, MinScore: f.MinScore, MaxResults: f.MaxResults}
+}
+
+// remoteSearchRequest 批量搜索的请求
+type remoteSearchRequest struct {
+	Vectors [][]float32  `json:"vectors"`
+	Filter  remoteFilter `json:"filter"`
+}
+
+// remoteSearchResult 单条搜索结果
+type remoteSearchResult struct {
+	Document snapshotDocument `json:"document"`
+	Score    float32          `json:"score"`
+	Distance float32          `json:"distance"`
+}
+
+// remoteSearchResponse 批量搜索的响应，与请求中的向量一一对应
+type remoteSearchResponse struct {
+	Results [][]remoteSearchResult `json:"results"`
+}
+
+// remoteImportResponse 导入快照的响应
+type remoteImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+// remoteInfo 索引的配置和状态
+type remoteInfo struct {
+	Type        string           `json:"type"`
+	IndexType   string           `json:"index_type"`
+	Dimension   int              `json:"dimension"`
+	Distance    DistanceType     `json:"distance"`
+	Normalized  bool             `json:"normalized"`
+	Count       int              `json:"count"`
+	MemoryBytes int64            `json:"memory_bytes"`
+	LastSave    time.Time        `json:"last_save"`
+	QueryCache  *QueryCacheStats `json:"query_cache,omitempty"`
+}
+
+// remoteError 错误响应
+type remoteError struct {
+	Code  string `json:"code"`
+	Error string `json:"error"`
+}
+
+// remoteErrorCodes 可以在客户端还原的错误
+var remoteErrorCodes = []struct {
+	code   string
+	err    error
+	status int
+}{
+	{"not_found", ErrDocumentNotFound, http.StatusNotFound},
+	{"invalid_dimension", ErrInvalidDimension, http.StatusBadRequest},
+	{"empty_vector", ErrEmptyVector, http.StatusBadRequest},
+	{"invalid_id", ErrInvalidID, http.StatusBadRequest},
+}
+
+// decodeRemoteError 将错误响应转换为错误，已知错误码还原为对应的错误
+func decodeRemoteError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var e remoteError
+	if json.Unmarshal(body, &e) != nil || e.Error == "" {
+		return fmt.Errorf("faiss-server returned status %d: %s", resp.StatusCode, string(body))
+	}
+	for _, known := range remoteErrorCodes {
+		if known.code != e.Code {
+			continue
+		}
+		// 调用方会直接比较ErrDocumentNotFound，不做包装
+		if known.err == ErrDocumentNotFound {
+			return ErrDocumentNotFound
+		}
+		return fmt.Errorf("%w: %s", known.err, e.Error)
+	}
+	return fmt.Errorf("faiss-server returned status %d: %s", resp.StatusCode, e.Error)
+}
+
+// remoteServer 向量索引服务
+type remoteServer struct {
+	repo Repository
+}
+
+// NewServer 创建向量索引服务的HTTP处理器，把本地的向量仓库提供给RemoteRepository使用
+// 服务不做鉴权，只应在内部网络中监听
+func NewServer(repo Repository) http.Handler {
+	s := &remoteServer{repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/health", s.health)
+	mux.HandleFunc("GET /v1/info", s.info)
+	mux.HandleFunc("POST /v1/documents", s.add)
+	mux.HandleFunc("GET /v1/documents/{id}", s.get)
+	mux.HandleFunc("DELETE /v1/documents/{id}", s.delete)
+	mux.HandleFunc("GET /v1/files", s.files)
+	mux.HandleFunc("DELETE /v1/files/{file_id}", s.deleteFile)
+	mux.HandleFunc("POST /v1/search", s.search)
+	mux.HandleFunc("GET /v1/export", s.export)
+	mux.HandleFunc("POST /v1/import", s.importSnapshot)
+	mux.HandleFunc("POST /v1/flush", s.flush)
+	return mux
+}
+
+func (s *remoteServer) health(w http.ResponseWriter, r *http.Request) {
+	if err := Ping(r.Context(), s.repo); err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	writeRemoteJSON(w, map[string]string{"status": "ok"})
+}
+
+func (s *remoteServer) info(w http.ResponseWriter, r *http.Request) {
+	info, err := Describe(s.repo)
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	writeRemoteJSON(w, remoteInfo(info))
+}
+
+func (s *remoteServer) add(w http.ResponseWriter, r *http.Request) {
+	var req remoteAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRemoteBadRequest(w, err)
+		return
+	}
+	docs := make([]Document, len(req.Documents))
+	for i, doc := range req.Documents {
+		docs[i] = Document(doc)
+	}
+	if err := s.repo.AddBatch(docs); err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *remoteServer) get(w http.ResponseWriter, r *http.Request) {
+	doc, err := s.repo.Get(r.PathValue("id"))
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	writeRemoteJSON(w, snapshotDocument(doc))
+}
+
+func (s *remoteServer) delete(w http.ResponseWriter, r *http.Request) {
+	if err := s.repo.Delete(r.PathValue("id")); err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *remoteServer) files(w http.ResponseWriter, r *http.Request) {
+	counts, err := FileSegmentCounts(s.repo)
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	writeRemoteJSON(w, counts)
+}
+
+func (s *remoteServer) deleteFile(w http.ResponseWriter, r *http.Request) {
+	if err := s.repo.DeleteByFileID(r.PathValue("file_id")); err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *remoteServer) search(w http.ResponseWriter, r *http.Request) {
+	var req remoteSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRemoteBadRequest(w, err)
+		return
+	}
+	f := req.Filter
+	lists, err := s.repo.SearchBatch(req.Vectors, SearchFilter{
+		Namespace: f.Namespace, FileIDs: f.FileIDs, ExcludeFileIDs: f.ExcludeFileIDs, Metadata: f.Metadata, MinScore: f.MinScore, MaxResults: f.MaxResults,
+	})
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+
+	resp := remoteSearchResponse{Results: make([][]remoteSearchResult, len(lists))}
+	for i, list := range lists {
+		resp.Results[i] = make([]remoteSearchResult, len(list))
+		for j, res := range list {
+			resp.Results[i][j] = remoteSearchResult{Document: snapshotDocument(res.Document), Score: res.Score, Distance: res.Distance}
+		}
+	}
+	writeRemoteJSON(w, resp)
+}
+
+func (s *remoteServer) export(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/gzip")
+	// 开始写出后无法再返回错误状态，客户端会因快照不完整而导入失败
+	if err := s.repo.Export(w); err != nil {
+		writeRemoteError(w, err)
+	}
+}
+
+func (s *remoteServer) importSnapshot(w http.ResponseWriter, r *http.Request) {
+	n, err := s.repo.Import(r.Body)
+	if err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	writeRemoteJSON(w, remoteImportResponse{Imported: n})
+}
+
+func (s *remoteServer) flush(w http.ResponseWriter, r *http.Request) {
+	if err := s.repo.Flush(); err != nil {
+		writeRemoteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRemoteJSON 写出JSON响应
+func writeRemoteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeRemoteBadRequest 写出请求格式错误的响应
+func writeRemoteBadRequest(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(remoteError{Code: "bad_request", Error: err.Error()})
+}
+
+// writeRemoteError 写出错误响应，已知错误带上错误码
+func writeRemoteError(w http.ResponseWriter, err error) {
+	code, status := "internal", http.StatusInternalServerError
+	for _, known := range remoteErrorCodes {
+		if errors.Is(err, known.err) {
+			code, status = known.code, known.status
+			break
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(remoteError{Code: code, Error: err.Error()})
+}
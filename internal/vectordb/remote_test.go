@@ -0,0 +1,90 @@
+package vectordb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRemoteRepository 测试通过向量索引服务读写远程索引
+func TestRemoteRepository(t *testing.T) {
+	backend, err := NewRepository(Config{Type: "memory", Dimension: 4, DistanceType: Cosine})
+	require.NoError(t, err)
+	server := httptest.NewServer(NewServer(backend))
+	defer server.Close()
+
+	// 服务端索引的维度与配置不一致时拒绝连接
+	_, err = NewRepository(Config{Type: "faiss-server", Path: server.URL, Dimension: 8})
+	assert.True(t, errors.Is(err, ErrInvalidDimension))
+
+	repo, err := NewRepository(Config{Type: "faiss-server", Path: server.URL, Dimension: 4})
+	require.NoError(t, err)
+	defer repo.Close()
+	require.NoError(t, Ping(context.Background(), repo))
+
+	require.NoError(t, repo.AddBatch([]Document{
+		{ID: "a/0", FileID: "a", FileName: "a.md", Text: "安装步骤", Vector: []float32{1, 0, 0, 0}, Metadata: map[string]interface{}{"page": float64(1)}},
+		{ID: "a/1", FileID: "a", FileName: "a.md", Position: 1, Text: "部署步骤", Vector: []float32{0, 1, 0, 0}},
+		{ID: "b/0", FileID: "b", FileName: "b.md", Text: "无关内容", Vector: []float32{0, 0, 0, 1}},
+	}))
+	assert.Error(t, repo.Add(Document{ID: "bad", Vector: []float32{1, 0}}), "维度不一致的向量在客户端拒绝")
+
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// ID中的特殊字符经过转义
+	doc, err := repo.Get("a/0")
+	require.NoError(t, err)
+	assert.Equal(t, "安装步骤", doc.Text)
+	assert.Equal(t, float64(1), doc.Metadata["page"])
+	_, err = repo.Get("missing")
+	assert.Equal(t, ErrDocumentNotFound, err)
+
+	results, err := repo.Search([]float32{1, 0.1, 0, 0}, SearchFilter{FileIDs: []string{"a"}, MaxResults: 5})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "a/0", results[0].Document.ID)
+	assert.Len(t, results[0].Document.Vector, 4)
+
+	lists, err := repo.SearchBatch([][]float32{{1, 0, 0, 0}, {0, 0, 0, 1}}, SearchFilter{MinScore: 0.9, MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+	assert.Equal(t, "a/0", lists[0][0].Document.ID)
+	assert.Equal(t, "b/0", lists[1][0].Document.ID)
+
+	counts, err := FileSegmentCounts(repo)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 2, "b": 1}, counts)
+
+	info, err := Describe(repo)
+	require.NoError(t, err)
+	assert.Equal(t, "faiss-server", info.Type)
+	assert.Equal(t, 3, info.Count)
+
+	// 快照经过服务端导出后可以导入其他数据库
+	var buf bytes.Buffer
+	require.NoError(t, repo.Export(&buf))
+	target, err := NewRepository(Config{Type: "memory", Dimension: 4, DistanceType: Cosine})
+	require.NoError(t, err)
+	n, err := target.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	require.NoError(t, repo.Delete("b/0"))
+	assert.Equal(t, ErrDocumentNotFound, repo.Delete("b/0"))
+	require.NoError(t, repo.DeleteByFileID("a"))
+	count, err = backend.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	n, err = repo.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	require.NoError(t, repo.Flush())
+}
@@ -226,6 +226,18 @@ func normalizeVector(v []float32) []float32 {
 	return result
 }
 
+// excludedFiles 返回排除文件的查找表，没有排除的文件时返回nil
+func excludedFiles(filter SearchFilter) map[string]bool {
+	if len(filter.ExcludeFileIDs) == 0 {
+		return nil
+	}
+	excluded := make(map[string]bool, len(filter.ExcludeFileIDs))
+	for _, id := range filter.ExcludeFileIDs {
+		excluded[id] = true
+	}
+	return excluded
+}
+
 // FilterDocuments 根据过滤条件筛选文档
 // 优化：提前计算映射，减少查找开销
 func FilterDocuments(docs []Document, filter SearchFilter) []Document {
@@ -246,6 +258,17 @@ func FilterDocuments(docs []Document, filter SearchFilter) []Document {
 	// 预留足够的空间，减少扩容
 	result = make([]Document, 0, len(docs))
 
+	// 先去掉排除的文件
+	if excluded := excludedFiles(filter); excluded != nil {
+		kept := make([]Document, 0, len(docs))
+		for _, doc := range docs {
+			if !excluded[doc.FileID] {
+				kept = append(kept, doc)
+			}
+		}
+		docs = kept
+	}
+
 	// 筛选文档
 	hasFileFilter := len(fileIDMap) > 0
 	hasMetaFilter := len(filter.Metadata) > 0
@@ -280,6 +303,11 @@ func FilterDocuments(docs []Document, filter SearchFilter) []Document {
 	return result
 }
 
+// matchNamespace 检查文档是否属于过滤条件指定的命名空间，未指定命名空间时全部匹配
+func matchNamespace(doc Document, namespace string) bool {
+	return namespace == "" || doc.Namespace == namespace
+}
+
 // matchMetadata 检查文档元数据是否匹配过滤条件
 // 优化：支持更复杂的元数据匹配（前缀、后缀、包含关系等）
 func matchMetadata(docMeta map[string]interface{}, filterMeta map[string]interface{}) bool {
@@ -327,6 +355,34 @@ func matchMetadata(docMeta map[string]interface{}, filterMeta map[string]interfa
 	return true
 }
 
+// searchConcurrently 并发执行多个单向量搜索，供不支持原生批量搜索的实现使用
+// 任一查询失败时返回第一个错误
+func searchConcurrently(search func([]float32, SearchFilter) ([]SearchResult, error), vectors [][]float32, filter SearchFilter) ([][]SearchResult, error) {
+	results := make([][]SearchResult, len(vectors))
+	errs := make([]error, len(vectors))
+
+	// 限制并发数，避免大批量查询占满CPU
+	sem := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for i, vector := range vectors {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, vector []float32) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = search(vector, filter)
+		}(i, vector)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("query %d: %w", i, err)
+		}
+	}
+	return results, nil
+}
+
 // SortSearchResults 对搜索结果按相似度评分排序（降序）
 // 优化：使用快速排序替代插入排序，提高大数据集排序效率
 func SortSearchResults(results []SearchResult) {
@@ -0,0 +1,65 @@
+package vectordb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchBatch 测试批量搜索的结果与逐个搜索一致
+func TestSearchBatch(t *testing.T) {
+	configs := map[string]Config{
+		"memory": {Type: "memory", Dimension: 4},
+		"faiss":  {Type: "faiss", Dimension: 4, Path: filepath.Join(t.TempDir(), "index.faiss")},
+	}
+
+	docs := make([]Document, 0, 12)
+	for i := 0; i < 12; i++ {
+		docs = append(docs, Document{
+			ID:        fmt.Sprintf("doc_%d", i),
+			Namespace: []string{"", "tenant"}[i%2],
+			FileID:    fmt.Sprintf("file_%d", i%3),
+			Vector:    []float32{float32(i%4 + 1), float32(i / 4), 1, float32(i % 2)},
+		})
+	}
+	queries := [][]float32{{1, 0, 1, 0}, {4, 2, 1, 1}, {2, 1, 1, 0}}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			repo, err := NewRepository(config)
+			require.NoError(t, err)
+			defer repo.Close()
+			require.NoError(t, repo.AddBatch(docs))
+
+			filters := []SearchFilter{
+				{MaxResults: 3},
+				{Namespace: "tenant", MaxResults: 2},
+				{FileIDs: []string{"file_1"}, MaxResults: 5},
+			}
+			for _, filter := range filters {
+				batch, err := repo.SearchBatch(queries, filter)
+				require.NoError(t, err)
+				require.Len(t, batch, len(queries))
+				for i, query := range queries {
+					expected, err := repo.Search(query, filter)
+					require.NoError(t, err)
+					require.Len(t, batch[i], len(expected), "query %d with filter %+v", i, filter)
+					for j := range expected {
+						assert.Equal(t, expected[j].Document.ID, batch[i][j].Document.ID)
+						assert.InDelta(t, expected[j].Score, batch[i][j].Score, 1e-5)
+					}
+				}
+			}
+
+			batch, err := repo.SearchBatch(nil, DefaultSearchFilter())
+			require.NoError(t, err)
+			assert.Empty(t, batch)
+
+			_, err = repo.SearchBatch([][]float32{{1, 0, 0, 0}, {1, 0}}, DefaultSearchFilter())
+			assert.Error(t, err)
+		})
+	}
+}
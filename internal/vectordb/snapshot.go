@@ -0,0 +1,198 @@
+package vectordb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	// SnapshotFormat 快照格式标识
+	SnapshotFormat = "docqa-vectors"
+	// SnapshotVersion 快照格式版本
+	SnapshotVersion = 1
+	// snapshotBatchSize 导入时每批写入的文档数
+	snapshotBatchSize = 500
+)
+
+// SnapshotHeader 快照头部
+// 快照是gzip压缩的JSON Lines：第一行为头部，其后每行一个文档，与具体后端无关，
+// 可用于在不同向量数据库之间迁移数据
+type SnapshotHeader struct {
+	Format    string       `json:"format"`     // 格式标识，固定为docqa-vectors
+	Version   int          `json:"version"`    // 格式版本
+	Dimension int          `json:"dimension"`  // 向量维度
+	Distance  DistanceType `json:"distance"`   // 导出时使用的距离类型
+	Count     int          `json:"count"`      // 文档数量
+	CreatedAt time.Time    `json:"created_at"` // 导出时间
+}
+
+// snapshotDocument 快照中的文档
+type snapshotDocument struct {
+	ID        string                 `json:"id"`
+	Namespace string                 `json:"namespace,omitempty"`
+	FileID    string                 `json:"file_id"`
+	FileName  string                 `json:"file_name,omitempty"`
+	Position  int                    `json:"position"`
+	Text      string                 `json:"text"`
+	Vector    []float32              `json:"vector"`
+	CreatedAt time.Time              `json:"created_at"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// writeSnapshot 写入快照，count为文档数量，each依次输出每个文档
+func writeSnapshot(w io.Writer, dimension int, distType DistanceType, count int, each func(emit func(Document) error) error) error {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	if err := enc.Encode(SnapshotHeader{
+		Format:    SnapshotFormat,
+		Version:   SnapshotVersion,
+		Dimension: dimension,
+		Distance:  distType,
+		Count:     count,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to write snapshot header: %w", err)
+	}
+
+	err := each(func(doc Document) error {
+		if err := enc.Encode(snapshotDocument(doc)); err != nil {
+			return fmt.Errorf("failed to write document %s: %w", doc.ID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeDocumentsSnapshot 将内存中的文档按ID排序后写入快照，便于比较两次导出
+func writeDocumentsSnapshot(w io.Writer, dimension int, distType DistanceType, docs []Document) error {
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return writeSnapshot(w, dimension, distType, len(docs), func(emit func(Document) error) error {
+		for _, doc := range docs {
+			if err := emit(doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadSnapshotHeader 读取快照头部，用于在导入前检查快照
+func ReadSnapshotHeader(r io.Reader) (SnapshotHeader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return SnapshotHeader{}, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gz.Close()
+	return decodeSnapshotHeader(json.NewDecoder(bufio.NewReader(gz)))
+}
+
+// decodeSnapshotHeader 解码并校验快照头部
+func decodeSnapshotHeader(dec *json.Decoder) (SnapshotHeader, error) {
+	var header SnapshotHeader
+	if err := dec.Decode(&header); err != nil {
+		return header, fmt.Errorf("invalid snapshot header: %w", err)
+	}
+	if header.Format != SnapshotFormat {
+		return header, fmt.Errorf("invalid snapshot format: %q", header.Format)
+	}
+	if header.Version > SnapshotVersion {
+		return header, fmt.Errorf("unsupported snapshot version: %d", header.Version)
+	}
+	return header, nil
+}
+
+// countSnapshotFiles 统计快照中每个文件的段落数
+func countSnapshotFiles(r io.Reader) (map[string]int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	if _, err := decodeSnapshotHeader(dec); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for {
+		var doc struct {
+			FileID string `json:"file_id"`
+		}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid snapshot document: %w", err)
+		}
+		counts[doc.FileID]++
+	}
+}
+
+// importSnapshot 从快照导入文档，返回导入的文档数
+// 已存在的同ID文档先删除再写入，避免部分实现中残留旧向量
+func importSnapshot(repo Repository, r io.Reader) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	header, err := decodeSnapshotHeader(dec)
+	if err != nil {
+		return 0, err
+	}
+	if dim := repo.GetDimension(); dim > 0 && header.Dimension != dim {
+		return 0, fmt.Errorf("%w: snapshot has dimension %d, repository %d", ErrInvalidDimension, header.Dimension, dim)
+	}
+
+	imported := 0
+	batch := make([]Document, 0, snapshotBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		for _, doc := range batch {
+			if err := repo.Delete(doc.ID); err != nil && !errors.Is(err, ErrDocumentNotFound) {
+				return fmt.Errorf("failed to replace document %s: %w", doc.ID, err)
+			}
+		}
+		if err := repo.AddBatch(batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = make([]Document, 0, snapshotBatchSize)
+		return nil
+	}
+
+	for {
+		var doc snapshotDocument
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, fmt.Errorf("invalid snapshot document after %d documents: %w", imported+len(batch), err)
+		}
+		batch = append(batch, Document(doc))
+		if len(batch) >= snapshotBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
@@ -0,0 +1,117 @@
+package vectordb
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSnapshotMigration 测试从内存数据库导出快照并导入Faiss
+func TestSnapshotMigration(t *testing.T) {
+	source, err := NewRepository(Config{Type: "memory", Dimension: 4, DistanceType: Cosine})
+	require.NoError(t, err)
+
+	docs := make([]Document, 0, 3)
+	for i := 0; i < 3; i++ {
+		docs = append(docs, Document{
+			ID:       fmt.Sprintf("doc_%d", i),
+			FileID:   "doc",
+			FileName: "doc.md",
+			Position: i,
+			Text:     fmt.Sprintf("段落%d", i),
+			Vector:   []float32{float32(i + 1), 1, 0, 0},
+			Metadata: map[string]interface{}{"page": float64(i)},
+		})
+	}
+	require.NoError(t, source.AddBatch(docs))
+
+	var buf bytes.Buffer
+	require.NoError(t, source.Export(&buf))
+
+	header, err := ReadSnapshotHeader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotFormat, header.Format)
+	assert.Equal(t, 4, header.Dimension)
+	assert.Equal(t, 3, header.Count)
+
+	target, err := NewRepository(Config{
+		Type:         "faiss",
+		Path:         filepath.Join(t.TempDir(), "index.faiss"),
+		Dimension:    4,
+		DistanceType: Cosine,
+	})
+	require.NoError(t, err)
+	defer target.Close()
+
+	imported, err := target.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, imported)
+
+	doc, err := target.Get("doc_2")
+	require.NoError(t, err)
+	assert.Equal(t, "段落2", doc.Text)
+	assert.Equal(t, 2, doc.Position)
+	assert.Equal(t, float64(2), doc.Metadata["page"])
+
+	// 重复导入覆盖同ID文档
+	imported, err = target.Import(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, imported)
+	count, err := target.Count()
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	results, err := target.Search([]float32{3, 1, 0, 0}, SearchFilter{MaxResults: 1})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "doc_2", results[0].Document.ID)
+}
+
+// TestSnapshotDimensionMismatch 测试导入维度不一致的快照
+func TestSnapshotDimensionMismatch(t *testing.T) {
+	source, err := NewRepository(Config{Type: "memory", Dimension: 4})
+	require.NoError(t, err)
+	require.NoError(t, source.Add(Document{ID: "a", FileID: "f", Vector: []float32{1, 0, 0, 0}}))
+
+	var buf bytes.Buffer
+	require.NoError(t, source.Export(&buf))
+
+	target, err := NewRepository(Config{Type: "memory", Dimension: 8})
+	require.NoError(t, err)
+	_, err = target.Import(&buf)
+	assert.ErrorIs(t, err, ErrInvalidDimension)
+
+	_, err = target.Import(bytes.NewReader([]byte("not a snapshot")))
+	assert.Error(t, err)
+}
+
+// exportOnly 隐藏FileLister，只能通过导出快照统计文件
+type exportOnly struct {
+	Repository
+}
+
+// TestFileSegmentCounts 测试直接统计和通过快照统计各文件的段落数
+func TestFileSegmentCounts(t *testing.T) {
+	repo, err := NewRepository(Config{Type: "memory", Dimension: 4, DistanceType: Cosine})
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.Add(Document{
+			ID:     fmt.Sprintf("seg_%d", i),
+			FileID: fmt.Sprintf("file_%d", i%2),
+			Vector: []float32{1, float32(i), 0, 0},
+		}))
+	}
+
+	want := map[string]int{"file_0": 3, "file_1": 2}
+	counts, err := FileSegmentCounts(repo)
+	require.NoError(t, err)
+	assert.Equal(t, want, counts)
+
+	counts, err = FileSegmentCounts(exportOnly{repo})
+	require.NoError(t, err)
+	assert.Equal(t, want, counts)
+}
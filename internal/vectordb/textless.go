@@ -0,0 +1,68 @@
+package vectordb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// MetaTextHash 不保存文本时记录在元数据中的段落文本SHA-256，与段落表的text_hash一致，
+// 读取文本的一方据此判断缓存的文本是否仍然有效
+const MetaTextHash = "text_hash"
+
+// textlessRepository 不保存段落文本的向量数据库
+type textlessRepository struct {
+	Repository
+}
+
+// WithoutText 包装向量数据库，写入时丢弃段落文本，只保留ID、文件和位置等定位信息
+// 段落文本已经保存在段落表中，向量数据库（尤其是faiss的内存元数据）再保存一份会显著增加内存占用。
+// 检索结果的Text为空，由调用方按段落ID从段落表读取
+func WithoutText(repo Repository) Repository {
+	return &textlessRepository{Repository: repo}
+}
+
+// Add 丢弃文本后添加单个文档
+func (r *textlessRepository) Add(doc Document) error {
+	return r.Repository.Add(stripText(doc))
+}
+
+// AddBatch 丢弃文本后批量添加文档
+func (r *textlessRepository) AddBatch(docs []Document) error {
+	stripped := make([]Document, len(docs))
+	for i, doc := range docs {
+		stripped[i] = stripText(doc)
+	}
+	return r.Repository.AddBatch(stripped)
+}
+
+// Ping 检查底层向量数据库的连接
+func (r *textlessRepository) Ping(ctx context.Context) error {
+	return Ping(ctx, r.Repository)
+}
+
+// FileSegmentCounts 统计底层向量数据库中每个文件的段落数
+func (r *textlessRepository) FileSegmentCounts() (map[string]int, error) {
+	return FileSegmentCounts(r.Repository)
+}
+
+// Describe 返回底层向量数据库的配置和状态
+func (r *textlessRepository) Describe() (Info, error) {
+	return Describe(r.Repository)
+}
+
+// stripText 清空文本并在元数据中记录文本哈希，不修改调用方的元数据
+func stripText(doc Document) Document {
+	if doc.Text == "" {
+		return doc
+	}
+	sum := sha256.Sum256([]byte(doc.Text))
+	metadata := make(map[string]interface{}, len(doc.Metadata)+1)
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetaTextHash] = hex.EncodeToString(sum[:])
+	doc.Metadata = metadata
+	doc.Text = ""
+	return doc
+}
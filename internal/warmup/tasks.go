@@ -0,0 +1,107 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fyerfyer/doc-QA-system/internal/embedding"
+	"github.com/fyerfyer/doc-QA-system/internal/llm"
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+)
+
+// warmupText 建立模型服务连接时发送的文本
+const warmupText = "ping"
+
+// QuestionAnswerer 预热问答缓存所需的问答服务
+type QuestionAnswerer interface {
+	// GetRecentQuestions 获取最近的用户问题
+	GetRecentQuestions(ctx context.Context, limit int) ([]string, error)
+
+	// Answer 回答问题，结果写入问答缓存
+	Answer(ctx context.Context, question string) (string, []vectordb.Document, error)
+}
+
+// VectorIndexTask 向量索引预热任务
+// 执行一次检索，让索引数据进入内存并完成检索线程池等一次性初始化
+func VectorIndexTask(repo vectordb.Repository) TaskFunc {
+	return func(ctx context.Context) error {
+		count, err := repo.Count()
+		if err != nil {
+			return fmt.Errorf("failed to count vectors: %w", err)
+		}
+		if count == 0 {
+			return nil
+		}
+
+		vector := make([]float32, repo.GetDimension())
+		vector[0] = 1
+		if _, err := repo.Search(vector, vectordb.SearchFilter{MaxResults: 1}); err != nil {
+			return fmt.Errorf("failed to search vector index: %w", err)
+		}
+		return nil
+	}
+}
+
+// EmbeddingTask 嵌入模型连接预热任务，提前完成DNS解析和TLS握手
+func EmbeddingTask(client embedding.Client) TaskFunc {
+	return func(ctx context.Context) error {
+		_, err := client.Embed(ctx, warmupText)
+		return err
+	}
+}
+
+// LLMTask 大模型连接预热任务，只生成一个token以控制成本
+func LLMTask(client llm.Client) TaskFunc {
+	return func(ctx context.Context) error {
+		_, err := client.Generate(ctx, warmupText, llm.WithGenerateMaxTokens(1))
+		return err
+	}
+}
+
+// QueryCacheTask 问答缓存预热任务
+// 以concurrency个并发重新回答最近的limit个问题，已缓存的问题直接命中缓存
+func QueryCacheTask(qa QuestionAnswerer, limit, concurrency int) TaskFunc {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	return func(ctx context.Context) error {
+		questions, err := qa.GetRecentQuestions(ctx, limit)
+		if err != nil {
+			return fmt.Errorf("failed to get recent questions: %w", err)
+		}
+
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed int
+			sem    = make(chan struct{}, concurrency)
+		)
+		for _, question := range questions {
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return ctx.Err()
+			case sem <- struct{}{}:
+			}
+
+			wg.Add(1)
+			go func(question string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if _, _, err := qa.Answer(ctx, question); err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}(question)
+		}
+		wg.Wait()
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d questions failed to warm", failed, len(questions))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,183 @@
+package warmup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TaskFunc 预热任务的执行函数
+type TaskFunc func(ctx context.Context) error
+
+// TaskResult 单个预热任务的执行结果
+type TaskResult struct {
+	Name     string        // 任务名称
+	Done     bool          // 是否已执行完成
+	Duration time.Duration // 执行耗时
+	Error    string        // 失败原因，成功时为空
+}
+
+// Status 预热状态
+type Status struct {
+	Ready bool         // 是否已就绪，所有任务结束（无论成败）后为true
+	Tasks []TaskResult // 各任务的执行结果，按注册顺序排列
+}
+
+// task 已注册的预热任务
+type task struct {
+	name string
+	fn   TaskFunc
+}
+
+// Warmer 启动预热器
+// 服务启动后并行执行已注册的预热任务（加载向量索引、预热问答缓存、建立模型服务连接等），
+// 全部任务结束前Ready返回false，供就绪探针在预热完成前把实例挡在负载均衡之外
+// 单个任务失败只记录日志，不会阻止服务就绪，首个真实请求会按原有路径处理
+type Warmer struct {
+	tasks   []task
+	results []TaskResult
+	timeout time.Duration // 整体预热超时时间
+	logger  *logrus.Logger
+
+	mu    sync.RWMutex
+	once  sync.Once
+	done  chan struct{}
+	ready bool
+}
+
+// Option 预热器配置选项
+type Option func(*Warmer)
+
+// WithLogger 设置日志记录器
+func WithLogger(logger *logrus.Logger) Option {
+	return func(w *Warmer) {
+		if logger != nil {
+			w.logger = logger
+		}
+	}
+}
+
+// WithTimeout 设置整体预热超时时间，超时后未完成的任务被取消，服务照常就绪
+func WithTimeout(timeout time.Duration) Option {
+	return func(w *Warmer) {
+		if timeout > 0 {
+			w.timeout = timeout
+		}
+	}
+}
+
+// New 创建启动预热器
+func New(opts ...Option) *Warmer {
+	w := &Warmer{
+		timeout: 2 * time.Minute,
+		logger:  logrus.New(),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Add 注册预热任务，必须在Start之前调用
+func (w *Warmer) Add(name string, fn TaskFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tasks = append(w.tasks, task{name: name, fn: fn})
+	w.results = append(w.results, TaskResult{Name: name})
+}
+
+// Start 在后台并行执行所有预热任务，重复调用只执行一次
+func (w *Warmer) Start(ctx context.Context) {
+	w.once.Do(func() {
+		go w.run(ctx)
+	})
+}
+
+// Run 并行执行所有预热任务并等待结束，重复调用只执行一次
+func (w *Warmer) Run(ctx context.Context) Status {
+	w.Start(ctx)
+	<-w.done
+	return w.Status()
+}
+
+// Done 返回预热结束时关闭的通道
+func (w *Warmer) Done() <-chan struct{} {
+	return w.done
+}
+
+// Ready 返回是否已完成预热
+func (w *Warmer) Ready() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.ready
+}
+
+// Status 返回预热状态的快照
+func (w *Warmer) Status() Status {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	results := make([]TaskResult, len(w.results))
+	copy(results, w.results)
+	return Status{Ready: w.ready, Tasks: results}
+}
+
+// run 执行全部预热任务
+func (w *Warmer) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	w.mu.RLock()
+	tasks := make([]task, len(w.tasks))
+	copy(tasks, w.tasks)
+	w.mu.RUnlock()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i, t := range tasks {
+		wg.Add(1)
+		go func(i int, t task) {
+			defer wg.Done()
+			w.runTask(ctx, i, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	w.mu.Lock()
+	w.ready = true
+	w.mu.Unlock()
+	close(w.done)
+
+	w.logger.WithField("duration", time.Since(start)).Info("Startup warmup finished")
+}
+
+// runTask 执行单个预热任务，任务中的panic被恢复并记录为失败
+func (w *Warmer) runTask(ctx context.Context, i int, t task) {
+	start := time.Now()
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		err = t.fn(ctx)
+	}()
+
+	result := TaskResult{Name: t.name, Done: true, Duration: time.Since(start)}
+	fields := logrus.Fields{"task": t.name, "duration": result.Duration}
+	if err != nil {
+		result.Error = err.Error()
+		w.logger.WithFields(fields).Warnf("Warmup task failed: %v", err)
+	} else {
+		w.logger.WithFields(fields).Info("Warmup task completed")
+	}
+
+	w.mu.Lock()
+	w.results[i] = result
+	w.mu.Unlock()
+}
@@ -0,0 +1,119 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fyerfyer/doc-QA-system/internal/vectordb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAnswerer 记录并发数的问答服务
+type fakeAnswerer struct {
+	questions []string
+	active    int32
+	peak      int32
+	calls     int32
+}
+
+func (f *fakeAnswerer) GetRecentQuestions(ctx context.Context, limit int) ([]string, error) {
+	if len(f.questions) > limit {
+		return f.questions[:limit], nil
+	}
+	return f.questions, nil
+}
+
+func (f *fakeAnswerer) Answer(ctx context.Context, question string) (string, []vectordb.Document, error) {
+	n := atomic.AddInt32(&f.active, 1)
+	defer atomic.AddInt32(&f.active, -1)
+	for {
+		peak := atomic.LoadInt32(&f.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&f.peak, peak, n) {
+			break
+		}
+	}
+	atomic.AddInt32(&f.calls, 1)
+	time.Sleep(10 * time.Millisecond)
+	if question == "bad" {
+		return "", nil, errors.New("llm unavailable")
+	}
+	return "answer", nil, nil
+}
+
+// TestWarmerReadiness 测试全部任务结束后才就绪，失败的任务不阻止就绪
+func TestWarmerReadiness(t *testing.T) {
+	release := make(chan struct{})
+	w := New()
+	w.Add("slow", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	w.Add("broken", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	w.Add("panicky", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	w.Start(context.Background())
+	w.Start(context.Background()) // 重复调用不会重复执行
+
+	assert.Eventually(t, func() bool {
+		return w.Status().Tasks[1].Done && w.Status().Tasks[2].Done
+	}, time.Second, 5*time.Millisecond)
+	assert.False(t, w.Ready(), "must not be ready while a task is running")
+
+	close(release)
+	status := w.Run(context.Background())
+	assert.True(t, status.Ready)
+	assert.True(t, w.Ready())
+	require.Len(t, status.Tasks, 3)
+	assert.Equal(t, "slow", status.Tasks[0].Name)
+	assert.Empty(t, status.Tasks[0].Error)
+	assert.Equal(t, "connection refused", status.Tasks[1].Error)
+	assert.Contains(t, status.Tasks[2].Error, "boom")
+}
+
+// TestWarmerTimeout 测试超时后取消未完成的任务并就绪
+func TestWarmerTimeout(t *testing.T) {
+	w := New(WithTimeout(20 * time.Millisecond))
+	w.Add("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	w.Start(context.Background())
+	select {
+	case <-w.Done():
+	case <-time.After(time.Second):
+		t.Fatal("warmup did not finish after timeout")
+	}
+	assert.True(t, w.Ready())
+	assert.Equal(t, context.DeadlineExceeded.Error(), w.Status().Tasks[0].Error)
+}
+
+// TestQueryCacheTask 测试问答缓存预热的并发限制和失败统计
+func TestQueryCacheTask(t *testing.T) {
+	qa := &fakeAnswerer{questions: []string{"q1", "q2", "bad", "q4", "q5", "q6"}}
+
+	err := QueryCacheTask(qa, 5, 2)(context.Background())
+	assert.EqualError(t, err, "1 of 5 questions failed to warm")
+	assert.Equal(t, int32(5), atomic.LoadInt32(&qa.calls))
+	assert.LessOrEqual(t, atomic.LoadInt32(&qa.peak), int32(2))
+}
+
+// TestVectorIndexTask 测试向量索引预热
+func TestVectorIndexTask(t *testing.T) {
+	repo, err := vectordb.NewRepository(vectordb.Config{Type: "memory", Dimension: 3})
+	require.NoError(t, err)
+
+	// 空索引直接跳过
+	assert.NoError(t, VectorIndexTask(repo)(context.Background()))
+
+	require.NoError(t, repo.Add(vectordb.Document{ID: "doc_0", FileID: "doc", Text: "内容", Vector: []float32{0, 1, 0}}))
+	assert.NoError(t, VectorIndexTask(repo)(context.Background()))
+}
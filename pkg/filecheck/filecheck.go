@@ -0,0 +1,283 @@
+// Package filecheck 校验上传的文件
+// 按文件头的魔数识别真实类型而不是只看扩展名，拒绝加密的PDF，并可调用病毒扫描
+package filecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 识别出的文件类型
+const (
+	KindPDF     = "pdf"
+	KindText    = "text"
+	KindZip     = "zip"
+	KindOLE     = "ole"
+	KindPNG     = "png"
+	KindJPEG    = "jpeg"
+	KindGIF     = "gif"
+	KindGzip    = "gzip"
+	KindELF     = "elf"
+	KindExe     = "exe"
+	KindBinary  = "binary"
+	KindUnknown = "unknown"
+)
+
+var (
+	// ErrTypeNotAllowed 扩展名不在允许的列表中
+	ErrTypeNotAllowed = errors.New("file type not allowed")
+	// ErrTypeMismatch 文件内容与扩展名不符
+	ErrTypeMismatch = errors.New("file content does not match its extension")
+	// ErrEncryptedPDF PDF已加密或设置了密码，无法解析
+	ErrEncryptedPDF = errors.New("pdf is encrypted or password protected")
+	// ErrInfected 病毒扫描发现威胁
+	ErrInfected = errors.New("file is infected")
+)
+
+// DefaultAllowedTypes 默认允许上传的扩展名
+var DefaultAllowedTypes = []string{".pdf", ".md", ".markdown", ".txt"}
+
+// sniffLen 识别类型时读取的文件头字节数
+const sniffLen = 8 << 10
+
+// pdfEncryptToken PDF加密字典的标记，出现在trailer或交叉引用流中
+var pdfEncryptToken = []byte("/Encrypt")
+
+// expectedKinds 扩展名对应的文件类型
+var expectedKinds = map[string]string{
+	".pdf":      KindPDF,
+	".md":       KindText,
+	".markdown": KindText,
+	".txt":      KindText,
+	".csv":      KindText,
+	".html":     KindText,
+	".htm":      KindText,
+	".docx":     KindZip,
+	".xlsx":     KindZip,
+	".pptx":     KindZip,
+	".zip":      KindZip,
+	".eml":      KindText,
+	".msg":      KindOLE,
+	".doc":      KindOLE,
+	".xls":      KindOLE,
+	".ppt":      KindOLE,
+}
+
+// signatures 常见二进制格式的魔数，按顺序匹配
+var signatures = []struct {
+	magic []byte
+	kind  string
+}{
+	{[]byte("PK\x03\x04"), KindZip},
+	{[]byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"), KindOLE},
+	{[]byte("\x89PNG\r\n\x1a\n"), KindPNG},
+	{[]byte("\xFF\xD8\xFF"), KindJPEG},
+	{[]byte("GIF87a"), KindGIF},
+	{[]byte("GIF89a"), KindGIF},
+	{[]byte("\x1f\x8b"), KindGzip},
+	{[]byte("\x7fELF"), KindELF},
+	{[]byte("MZ"), KindExe},
+}
+
+// Detect 根据文件头识别文件类型
+// PDF允许魔数前有少量其他字节；没有已知魔数、是合法UTF-8且不含NUL的内容视为文本
+func Detect(head []byte) string {
+	if len(head) > sniffLen {
+		head = head[:sniffLen]
+	}
+	if i := bytes.Index(head, []byte("%PDF-")); i >= 0 && i < 1024 {
+		return KindPDF
+	}
+	for _, sig := range signatures {
+		if bytes.HasPrefix(head, sig.magic) {
+			return sig.kind
+		}
+	}
+	if len(head) == 0 {
+		return KindUnknown
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return KindBinary
+	}
+	// 文件头可能截断在多字节字符中间，去掉末尾不完整的字符再判断
+	text := head
+	for i := 0; i < utf8.UTFMax && len(text) > 0 && !utf8.Valid(text); i++ {
+		text = text[:len(text)-1]
+	}
+	if len(text) > 0 && utf8.Valid(text) {
+		return KindText
+	}
+	return KindBinary
+}
+
+// Scanner 病毒扫描接口，发现威胁时返回包装了ErrInfected的错误
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// Validator 上传文件校验器
+// 为nil时不做任何校验
+type Validator struct {
+	allowed map[string]bool // 允许的扩展名
+	scanner Scanner         // 病毒扫描，为空时不扫描
+}
+
+// Option 校验器配置选项
+type Option func(*Validator)
+
+// WithAllowedTypes 设置允许上传的扩展名，可以带或不带点，为空时使用默认列表
+func WithAllowedTypes(exts ...string) Option {
+	return func(v *Validator) {
+		if len(exts) == 0 {
+			return
+		}
+		v.allowed = make(map[string]bool, len(exts))
+		for _, ext := range exts {
+			ext = strings.ToLower(strings.TrimSpace(ext))
+			if ext == "" {
+				continue
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			v.allowed[ext] = true
+		}
+	}
+}
+
+// WithScanner 设置病毒扫描
+func WithScanner(scanner Scanner) Option {
+	return func(v *Validator) {
+		v.scanner = scanner
+	}
+}
+
+// New 创建上传文件校验器
+func New(opts ...Option) *Validator {
+	v := &Validator{}
+	WithAllowedTypes(DefaultAllowedTypes...)(v)
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// AllowedTypes 返回允许上传的扩展名，按字母排序
+func (v *Validator) AllowedTypes() []string {
+	if v == nil {
+		return nil
+	}
+	exts := make([]string, 0, len(v.allowed))
+	for ext := range v.allowed {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// CheckName 检查文件扩展名是否允许上传，在读取文件内容前调用
+func (v *Validator) CheckName(filename string) error {
+	if v == nil {
+		return nil
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !v.allowed[ext] {
+		return fmt.Errorf("%w: %q, allowed types: %s", ErrTypeNotAllowed, ext, strings.Join(v.AllowedTypes(), ", "))
+	}
+	return nil
+}
+
+// Validate 校验文件内容
+// 只读取一遍数据：识别文件头的真实类型，PDF检查是否加密，配置了病毒扫描时同时把数据交给扫描器
+func (v *Validator) Validate(ctx context.Context, filename string, r io.Reader) error {
+	if v == nil {
+		return nil
+	}
+	if err := v.CheckName(filename); err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	// 配置了扫描器时，边读边通过管道交给扫描器
+	var (
+		pw       *io.PipeWriter
+		scanDone chan error
+	)
+	if v.scanner != nil {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		scanDone = make(chan error, 1)
+		go func() {
+			err := v.scanner.Scan(ctx, pr)
+			// 扫描器提前返回时继续读完，避免阻塞写入方
+			io.Copy(io.Discard, pr)
+			scanDone <- err
+		}()
+		r = io.TeeReader(r, pw)
+	}
+
+	err := v.inspect(ext, r)
+	if pw != nil {
+		if err != nil {
+			pw.CloseWithError(err)
+			<-scanDone
+			return err
+		}
+		pw.Close()
+		if scanErr := <-scanDone; scanErr != nil {
+			return scanErr
+		}
+	}
+	return err
+}
+
+// inspect 识别文件类型并检查PDF加密，读完全部数据
+func (v *Validator) inspect(ext string, r io.Reader) error {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	kind := Detect(head)
+	if expected, ok := expectedKinds[ext]; ok && kind != expected {
+		return fmt.Errorf("%w: %s file detected as %s", ErrTypeMismatch, ext, kind)
+	}
+	if kind != KindPDF {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	}
+
+	// 加密字典可能在文件任意位置，按块查找，保留上一块末尾防止标记跨块
+	keep := len(pdfEncryptToken) - 1
+	buf := make([]byte, 64<<10)
+	var tail []byte
+	chunk, eof := head, false
+	for {
+		window := append(tail, chunk...)
+		if bytes.Contains(window, pdfEncryptToken) {
+			io.Copy(io.Discard, r)
+			return ErrEncryptedPDF
+		}
+		if eof {
+			return nil
+		}
+		tail = append(tail[:0], window[max(len(window)-keep, 0):]...)
+
+		n, err := r.Read(buf)
+		chunk = buf[:n]
+		if errors.Is(err, io.EOF) {
+			eof = true
+		} else if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package filecheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubScanner 记录扫描到的内容，内容包含marker时报告病毒
+type stubScanner struct {
+	marker  string
+	scanned []byte
+}
+
+func (s *stubScanner) Scan(_ context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.scanned = data
+	if bytes.Contains(data, []byte(s.marker)) {
+		return fmt.Errorf("%w: Eicar-Test-Signature", ErrInfected)
+	}
+	return nil
+}
+
+// TestDetect 测试按文件头识别类型
+func TestDetect(t *testing.T) {
+	assert.Equal(t, KindPDF, Detect([]byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3")))
+	assert.Equal(t, KindPDF, Detect([]byte("\xef\xbb\xbf%PDF-1.4")))
+	assert.Equal(t, KindZip, Detect([]byte("PK\x03\x04\x14\x00")))
+	assert.Equal(t, KindExe, Detect([]byte("MZ\x90\x00\x03")))
+	assert.Equal(t, KindText, Detect([]byte("# 标题\n\n正文")))
+	assert.Equal(t, KindBinary, Detect([]byte("abc\x00def")))
+	assert.Equal(t, KindUnknown, Detect(nil))
+
+	// 文件头截断在多字节字符中间仍识别为文本
+	head := []byte(strings.Repeat("中", sniffLen/3+1))[:sniffLen]
+	assert.Equal(t, KindText, Detect(head))
+}
+
+// TestValidator 测试扩展名允许列表、内容与扩展名不符和加密PDF
+func TestValidator(t *testing.T) {
+	ctx := context.Background()
+	v := New(WithAllowedTypes("PDF", "md"))
+	assert.Equal(t, []string{".md", ".pdf"}, v.AllowedTypes())
+
+	assert.NoError(t, v.CheckName("Report.PDF"))
+	assert.ErrorIs(t, v.CheckName("notes.txt"), ErrTypeNotAllowed)
+	assert.ErrorIs(t, v.Validate(ctx, "setup.exe", strings.NewReader("MZ")), ErrTypeNotAllowed)
+
+	assert.NoError(t, v.Validate(ctx, "notes.md", strings.NewReader("# 标题")))
+	assert.ErrorIs(t, v.Validate(ctx, "notes.md", bytes.NewReader([]byte("MZ\x90\x00"))), ErrTypeMismatch)
+	assert.ErrorIs(t, v.Validate(ctx, "report.pdf", strings.NewReader("hello")), ErrTypeMismatch)
+
+	pdf := "%PDF-1.7\n" + strings.Repeat("x", 100<<10)
+	assert.NoError(t, v.Validate(ctx, "report.pdf", strings.NewReader(pdf+"trailer\n<< /Root 1 0 R >>")))
+	assert.ErrorIs(t, v.Validate(ctx, "report.pdf", strings.NewReader(pdf+"trailer\n<< /Encrypt 5 0 R >>")), ErrEncryptedPDF)
+
+	// 加密标记跨越两次读取的边界
+	split := "%PDF-1.7\n" + strings.Repeat("x", sniffLen-9-3) + "/Encrypt"
+	assert.ErrorIs(t, v.Validate(ctx, "report.pdf", io.MultiReader(strings.NewReader(split[:sniffLen]), strings.NewReader(split[sniffLen:]))), ErrEncryptedPDF)
+
+	var nilValidator *Validator
+	assert.NoError(t, nilValidator.Validate(ctx, "setup.exe", strings.NewReader("MZ")))
+}
+
+// TestValidatorScanner 测试病毒扫描收到完整的文件内容
+func TestValidatorScanner(t *testing.T) {
+	ctx := context.Background()
+	scanner := &stubScanner{marker: "EICAR"}
+	v := New(WithScanner(scanner))
+
+	content := strings.Repeat("正文", 20<<10)
+	require.NoError(t, v.Validate(ctx, "notes.txt", strings.NewReader(content)))
+	assert.Equal(t, content, string(scanner.scanned))
+
+	err := v.Validate(ctx, "notes.txt", strings.NewReader(content+"EICAR"))
+	assert.ErrorIs(t, err, ErrInfected)
+	assert.Contains(t, err.Error(), "Eicar-Test-Signature")
+
+	// 类型校验失败时不等待扫描结果
+	assert.ErrorIs(t, v.Validate(ctx, "notes.txt", bytes.NewReader([]byte("\x7fELF\x02"))), ErrTypeMismatch)
+}
+
+// TestClamdScanner 测试通过INSTREAM协议与clamd交互
+func TestClamdScanner(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	// 模拟clamd：读取命令和分块数据，内容包含EICAR时报告病毒
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				cmd := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, cmd); err != nil || string(cmd) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, conn, int64(size)); err != nil {
+						return
+					}
+				}
+				if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+					conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	scanner := NewClamdScanner(lis.Addr().String(), 5*time.Second)
+	ctx := context.Background()
+	assert.NoError(t, scanner.Scan(ctx, strings.NewReader(strings.Repeat("a", 200<<10))))
+
+	err = scanner.Scan(ctx, strings.NewReader("X5O!P%@AP EICAR"))
+	assert.ErrorIs(t, err, ErrInfected)
+	assert.Contains(t, err.Error(), "Eicar-Test-Signature")
+
+	err = NewClamdScanner("127.0.0.1:1", time.Second).Scan(ctx, strings.NewReader("a"))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInfected))
+}
@@ -0,0 +1,145 @@
+// Package langdetect 根据文字系统和常用词识别文本的语言
+// 只依据字符统计，不依赖模型，适合在文档处理和问答时快速判断语言，结果为ISO 639-1语言代码
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// Unknown 无法判断语言时返回的代码
+	Unknown = "und"
+	// Chinese 中文
+	Chinese = "zh"
+	// English 英文
+	English = "en"
+
+	// sampleRunes 判断语言时最多读取的字符数
+	sampleRunes = 4000
+	// cjkShare 中日韩文字占全部字母的比例达到该值时按中日韩语言处理
+	// 中文技术文档常夹杂英文术语，而一个汉字的信息量约相当于几个拉丁字母
+	cjkShare = 0.2
+	// minStopwords 拉丁字母文本至少命中的常用词数
+	minStopwords = 2
+)
+
+// stopwords 拉丁字母语言的常用词，用于区分使用相同字母的语言
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "are", "this", "be", "on", "you", "how", "what"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "du", "que", "pour", "dans", "pas", "sur", "avec", "comment"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "von", "sich", "auf", "wie"},
+	"es": {"el", "los", "las", "y", "es", "una", "que", "por", "para", "con", "del", "como", "se", "está", "cómo"},
+	"it": {"il", "di", "che", "non", "una", "per", "sono", "della", "con", "gli", "come", "è", "anche", "delle"},
+	"pt": {"o", "os", "as", "e", "uma", "não", "que", "para", "com", "do", "da", "em", "como", "são", "você"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "op", "voor", "met", "zijn", "hoe", "wat"},
+}
+
+// stopwordIndex 常用词到语言的索引，一个词可能属于多个语言
+var stopwordIndex = buildStopwordIndex()
+
+// buildStopwordIndex 构建常用词索引
+func buildStopwordIndex() map[string][]string {
+	index := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			index[w] = append(index[w], lang)
+		}
+	}
+	return index
+}
+
+// Detect 识别文本的语言
+// 中日韩、西里尔、阿拉伯等文字按文字系统判断；拉丁字母按常用词判断，
+// 常用词不足以区分时（如只有几个技术术语）返回Unknown
+func Detect(text string) string {
+	var han, kana, hangul, latin, total int
+	scripts := make(map[string]int)
+	n := 0
+	for _, r := range text {
+		if n >= sampleRunes {
+			break
+		}
+		n++
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ar"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hi"]++
+		case unicode.Is(unicode.Greek, r):
+			scripts["el"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["he"]++
+		}
+	}
+	if total == 0 {
+		return Unknown
+	}
+
+	// 日文夹杂大量汉字，出现假名即可判断为日文
+	if cjk := han + kana + hangul; float64(cjk) >= float64(total)*cjkShare {
+		switch {
+		case kana > 0 && kana*10 >= cjk:
+			return "ja"
+		case hangul > han:
+			return "ko"
+		default:
+			return Chinese
+		}
+	}
+
+	best, bestCount := "", 0
+	for lang, count := range scripts {
+		if count > bestCount || (count == bestCount && lang < best) {
+			best, bestCount = lang, count
+		}
+	}
+	if bestCount > latin {
+		return best
+	}
+	return detectLatin(text)
+}
+
+// detectLatin 按常用词识别拉丁字母文本的语言
+func detectLatin(text string) string {
+	if len(text) > sampleRunes*4 {
+		text = text[:sampleRunes*4]
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	scores := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range stopwordIndex[w] {
+			scores[lang]++
+		}
+	}
+
+	best, bestScore := Unknown, 0
+	for lang, score := range scores {
+		if score > bestScore || (score == bestScore && lang < best) {
+			best, bestScore = lang, score
+		}
+	}
+	if bestScore < minStopwords {
+		return Unknown
+	}
+	return best
+}
@@ -0,0 +1,35 @@
+package langdetect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDetect 测试常见语言和混合文本的识别
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"中文", "如何配置数据库连接？", Chinese},
+		{"中文夹杂英文术语", "使用Redis Cluster部署缓存集群时需要配置哨兵", Chinese},
+		{"英文", "How do I configure the database connection for this service?", English},
+		{"法文", "Comment configurer la connexion à la base de données pour le service ?", "fr"},
+		{"德文", "Wie konfiguriere ich die Verbindung zu der Datenbank und den Dienst?", "de"},
+		{"西班牙文", "¿Cómo se configura la conexión con la base de datos para el servicio?", "es"},
+		{"日文", "データベースの接続を設定する方法を教えてください", "ja"},
+		{"日文汉字较多", "東京都の天気予報を確認する", "ja"},
+		{"韩文", "데이터베이스 연결을 어떻게 설정합니까?", "ko"},
+		{"俄文", "Как настроить подключение к базе данных?", "ru"},
+		{"只有术语", "Kubernetes Helm", Unknown},
+		{"没有文字", "12345 !!!", Unknown},
+		{"空文本", "", Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.text))
+		})
+	}
+}
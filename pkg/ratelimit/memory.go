@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理空闲令牌桶的最小间隔
+const sweepInterval = time.Minute
+
+// overflowKey 客户端数达到上限后新客户端共用的令牌桶
+const overflowKey = "\x00overflow"
+
+// bucket 单个客户端的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryLimiter 进程内令牌桶限流器
+// 适用于单副本部署，多副本部署时各副本分别计数，应使用RedisLimiter
+type MemoryLimiter struct {
+	config    Config
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 创建进程内限流器
+func NewMemoryLimiter(config Config) *MemoryLimiter {
+	return &MemoryLimiter{
+		config:  config.normalize(),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow 为指定客户端消耗一个令牌
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok && len(l.buckets) >= l.config.MaxKeys {
+		// 大量不同的客户端标识（如伪造的来源地址）不会无限增加令牌桶，超出上限的客户端一起限流
+		key = overflowKey
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		b = &bucket{tokens: float64(l.config.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	var result Result
+	b.tokens, result = l.config.take(b.tokens, now.Sub(b.last))
+	b.last = now
+	return result, nil
+}
+
+// sweep 清理已经补满的令牌桶，避免客户端数量增长导致内存泄漏
+// 补满的桶与新建的桶等价，删除不影响限流结果
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	idle := l.config.refillTime()
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package ratelimit 按客户端限制请求速率
+// 使用令牌桶算法：每个客户端的桶以固定速率补充令牌，容量决定允许的突发请求数
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limiter 限流器接口
+type Limiter interface {
+	// Allow 为指定客户端消耗一个令牌，令牌不足时Result.Allowed为false
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Result 限流判断结果
+type Result struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 令牌桶容量
+	Remaining  int           // 剩余令牌数
+	RetryAfter time.Duration // 被拒绝时距离下一个令牌可用的时间
+}
+
+// DefaultMaxKeys 进程内限流器默认最多跟踪的客户端数
+const DefaultMaxKeys = 100000
+
+// Config 令牌桶配置
+type Config struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 令牌桶容量，即允许的最大突发请求数
+	// MaxKeys 进程内限流器最多跟踪的客户端数，达到后新客户端共用一个令牌桶，为0时使用DefaultMaxKeys；
+	// Redis中的令牌桶在空闲后过期，不受此限制
+	MaxKeys int
+}
+
+// normalize 补全无效的配置
+func (c Config) normalize() Config {
+	if c.MaxKeys <= 0 {
+		c.MaxKeys = DefaultMaxKeys
+	}
+	if c.Rate <= 0 {
+		c.Rate = 1
+	}
+	if c.Burst <= 0 {
+		c.Burst = int(math.Ceil(c.Rate))
+	}
+	return c
+}
+
+// refillTime 令牌桶从空到满所需的时间
+func (c Config) refillTime() time.Duration {
+	return time.Duration(float64(c.Burst) / c.Rate * float64(time.Second))
+}
+
+// take 按经过的时间补充令牌后尝试消耗一个令牌，返回剩余令牌数和判断结果
+func (c Config) take(tokens float64, elapsed time.Duration) (float64, Result) {
+	if elapsed > 0 {
+		tokens = math.Min(float64(c.Burst), tokens+elapsed.Seconds()*c.Rate)
+	}
+
+	result := Result{Limit: c.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - tokens) / c.Rate * float64(time.Second))
+	}
+	result.Remaining = int(tokens)
+	return tokens, result
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+// testLimiter 对限流器执行相同的令牌桶断言
+func testLimiter(t *testing.T, limiter Limiter, clock *fakeClock) {
+	ctx := context.Background()
+
+	// 容量内的突发请求全部放行
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(ctx, "client-a")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	// 令牌耗尽后拒绝，并给出重试等待时间
+	result, err := limiter.Allow(ctx, "client-a")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, float64(500*time.Millisecond), float64(result.RetryAfter), float64(10*time.Millisecond))
+
+	// 其他客户端不受影响
+	result, err = limiter.Allow(ctx, "client-b")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// 按速率补充令牌
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	result, err = limiter.Allow(ctx, "client-a")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, "client-a")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// 空闲足够久后补满，但不超过容量
+	clock.t = clock.t.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		result, err = limiter.Allow(ctx, "client-a")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+	result, err = limiter.Allow(ctx, "client-a")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
+
+// TestMemoryLimiter 测试进程内令牌桶限流
+func TestMemoryLimiter(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	limiter := NewMemoryLimiter(Config{Rate: 2, Burst: 3})
+	limiter.now = clock.now
+
+	testLimiter(t, limiter, clock)
+
+	// 补满的令牌桶会被清理
+	clock.t = clock.t.Add(2 * sweepInterval)
+	_, err := limiter.Allow(context.Background(), "client-c")
+	require.NoError(t, err)
+	assert.Len(t, limiter.buckets, 1)
+
+	// 客户端数达到上限后，新客户端共用一个令牌桶
+	capped := NewMemoryLimiter(Config{Rate: 0.001, Burst: 1, MaxKeys: 2})
+	ctx := context.Background()
+	for _, key := range []string{"client-a", "client-b", "client-c"} {
+		result, err := capped.Allow(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed, key)
+	}
+	result, err := capped.Allow(ctx, "client-d")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Len(t, capped.buckets, 3)
+}
+
+// TestRedisLimiter 测试多副本共享的Redis令牌桶限流
+func TestRedisLimiter(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	limiter := NewRedisLimiter(client, Config{Rate: 2, Burst: 3}, WithKeyPrefix("test:"))
+	limiter.now = clock.now
+
+	testLimiter(t, limiter, clock)
+	assert.True(t, mr.Exists("test:client-a"))
+
+	// 另一个副本共享同一个令牌桶
+	replica := NewRedisLimiter(client, Config{Rate: 2, Burst: 3}, WithKeyPrefix("test:"))
+	replica.now = clock.now
+	result, err := replica.Allow(context.Background(), "client-a")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+}
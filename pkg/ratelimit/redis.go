@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultKeyPrefix Redis中令牌桶键的默认前缀
+const defaultKeyPrefix = "docqa:ratelimit:"
+
+// tokenBucketScript 在Redis中原子地补充并消耗令牌
+// 令牌桶保存为哈希（tokens、ts），空闲到补满后自动过期；
+// Lua返回的小数会被截断为整数，因此剩余令牌数以字符串返回
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+local elapsed = now - ts
+if elapsed > 0 then
+  tokens = math.min(burst, tokens + elapsed * rate / 1000)
+else
+  now = ts
+end
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter 基于Redis的令牌桶限流器
+// 多个副本共享同一组令牌桶，客户端在任何副本上的请求都计入同一个限额
+type RedisLimiter struct {
+	client redis.Scripter
+	config Config
+	prefix string
+	now    func() time.Time
+}
+
+// RedisOption Redis限流器配置选项
+type RedisOption func(*RedisLimiter)
+
+// WithKeyPrefix 设置令牌桶键的前缀，多个环境共用一个Redis时用于区分
+func WithKeyPrefix(prefix string) RedisOption {
+	return func(l *RedisLimiter) {
+		l.prefix = prefix
+	}
+}
+
+// NewRedisLimiter 创建基于Redis的限流器
+func NewRedisLimiter(client redis.Scripter, config Config, opts ...RedisOption) *RedisLimiter {
+	l := &RedisLimiter{
+		client: client,
+		config: config.normalize(),
+		prefix: defaultKeyPrefix,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Allow 为指定客户端消耗一个令牌
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	ttl := l.config.refillTime() + time.Second
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key},
+		l.config.Rate,
+		l.config.Burst,
+		l.now().UnixMilli(),
+		ttl.Milliseconds(),
+	).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid token count %q: %w", tokensStr, err)
+	}
+
+	result := Result{
+		Allowed:   allowed == 1,
+		Limit:     l.config.Burst,
+		Remaining: int(tokens),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / l.config.Rate * float64(time.Second))
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+// Package requestid 在请求上下文中传递请求ID，用于关联同一请求产生的日志、异步任务和下游调用
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Header 传递请求ID的HTTP头
+	Header = "X-Request-ID"
+	// Field 日志中请求ID的字段名
+	Field = "request_id"
+	// maxLength 接受的客户端请求ID最大长度，超过时重新生成
+	maxLength = 128
+)
+
+// requestIDKey 上下文中保存请求ID的键
+type requestIDKey struct{}
+
+// New 生成新的请求ID
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid 判断客户端传入的请求ID是否可用
+// 只接受长度有限的可见ASCII字符，避免日志注入
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID 返回带有请求ID的上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext 从上下文读取请求ID，未设置时返回空字符串
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Hook 日志钩子，日志条目通过WithContext携带上下文时自动添加请求ID字段
+type Hook struct{}
+
+// Levels 对所有日志级别生效
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 添加请求ID字段，已显式设置时不覆盖
+func (Hook) Fire(entry *logrus.Entry) error {
+	if _, ok := entry.Data[Field]; ok {
+		return nil
+	}
+	if id := FromContext(entry.Context); id != "" {
+		entry.Data[Field] = id
+	}
+	return nil
+}
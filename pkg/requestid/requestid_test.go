@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRequestIDContext 测试请求ID的上下文传递和校验
+func TestRequestIDContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", FromContext(ctx))
+	assert.Equal(t, ctx, WithRequestID(ctx, ""))
+
+	assert.True(t, Valid(New()))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("bad id"))
+	assert.False(t, Valid("bad\nid"))
+	assert.False(t, Valid(strings.Repeat("a", maxLength+1)))
+}
+
+// TestHook 测试日志钩子从上下文添加请求ID
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(Hook{})
+
+	ctx := WithRequestID(context.Background(), "req-2")
+	logger.WithContext(ctx).WithField("document_id", "doc-1").Info("processing")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "req-2", entry[Field])
+	assert.Equal(t, "doc-1", entry["document_id"])
+
+	// 没有上下文时不添加字段
+	buf.Reset()
+	logger.Info("no context")
+	entry = map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry, Field)
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -151,6 +152,7 @@ func (s *LocalStorage) List() ([]FileInfo, error) {
 			Size:     info.Size(),
 			MimeType: getMimeType(fileName),
 			Path:     relPath,
+			ModTime:  info.ModTime(),
 		})
 
 		return nil
@@ -163,6 +165,18 @@ func (s *LocalStorage) List() ([]FileInfo, error) {
 	return files, nil
 }
 
+// Ping 检查存储目录是否可访问
+func (s *LocalStorage) Ping(ctx context.Context) error {
+	info, err := os.Stat(s.basePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage path is not a directory: %s", s.basePath)
+	}
+	return nil
+}
+
 // Exists 检查文件是否存在
 func (s *LocalStorage) Exists(id string) (bool, error) {
 	_, err := s.findFilePathById(id)
@@ -1,11 +1,9 @@
 package storage
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"time"
@@ -15,6 +13,9 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// minioPartSize 流式上传时的分片大小
+const minioPartSize = 16 << 20
+
 // MinioStorage MinIO存储实现
 type MinioStorage struct {
 	client     *minio.Client // MinIO客户端
@@ -76,29 +77,20 @@ func (s *MinioStorage) Save(reader io.Reader, filename string) (FileInfo, error)
 	// 构建对象名
 	objectName := fmt.Sprintf("%s/%s%s", datePath, id, ext)
 
-	// 读取文件内容到内存，以获取大小和进行上传
-	// 注意：对于大文件，应该使用流式上传而不是加载到内存
-	content, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return FileInfo{}, fmt.Errorf("failed to read file content: %v", err)
-	}
-
-	// 上传文件到MinIO
-	contentReader := bytes.NewReader(content)
-	size := int64(len(content))
+	// 流式上传到MinIO，大小未知时按分片上传，只缓存一个分片而不是整个文件
 	contentType := getMimeType(filename)
-
-	_, err = s.client.PutObject(
+	info, err := s.client.PutObject(
 		context.Background(),
 		s.bucketName,
 		objectName,
-		contentReader,
-		size,
-		minio.PutObjectOptions{ContentType: contentType},
+		reader,
+		-1,
+		minio.PutObjectOptions{ContentType: contentType, PartSize: minioPartSize},
 	)
 	if err != nil {
 		return FileInfo{}, fmt.Errorf("failed to upload file: %v", err)
 	}
+	size := info.Size
 
 	// 返回文件信息
 	return FileInfo{
@@ -209,12 +201,25 @@ func (s *MinioStorage) List() ([]FileInfo, error) {
 			Size:     object.Size,
 			MimeType: getMimeTypeFromPath(objectName),
 			Path:     objectName,
+			ModTime:  object.LastModified,
 		})
 	}
 
 	return files, nil
 }
 
+// Ping 检查MinIO服务和存储桶是否可用
+func (s *MinioStorage) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucketName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket not found: %s", s.bucketName)
+	}
+	return nil
+}
+
 // Exists 检查MinIO中是否存在指定ID的文件
 func (s *MinioStorage) Exists(id string) (bool, error) {
 	// 使用List操作查找文件
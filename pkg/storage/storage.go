@@ -1,16 +1,19 @@
 package storage
 
 import (
+	"context"
 	"io"
+	"time"
 )
 
 // FileInfo 文件元数据结构
 type FileInfo struct {
-	ID       string // 文件唯一标识符
-	Name     string // 原始文件名
-	Size     int64  // 文件大小(字节)
-	MimeType string // 文件MIME类型(可选)
-	Path     string // 内部存储路径(实现相关)
+	ID       string    // 文件唯一标识符
+	Name     string    // 原始文件名
+	Size     int64     // 文件大小(字节)
+	MimeType string    // 文件MIME类型(可选)
+	Path     string    // 内部存储路径(实现相关)
+	ModTime  time.Time // 最后修改时间(List返回)
 }
 
 // Storage 文件存储接口
@@ -32,6 +35,22 @@ type Storage interface {
 	Exists(id string) (bool, error)
 }
 
+// Pinger 存储实现的可选接口，用于健康检查
+type Pinger interface {
+	// Ping 检查存储后端是否可用
+	Ping(ctx context.Context) error
+}
+
+// Ping 检查存储是否可用
+// 实现了Pinger的存储直接检查后端，其他实现通过Exists确认可访问
+func Ping(ctx context.Context, s Storage) error {
+	if pinger, ok := s.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	_, err := s.Exists(".healthcheck")
+	return err
+}
+
 // Factory 存储实现的工厂函数
 // 用于根据配置创建不同类型的存储实现
 type Factory func(cfg interface{}) (Storage, error)
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -131,6 +132,18 @@ func TestLocalStorage(t *testing.T) {
 		}
 	})
 
+	// 测试 Ping 功能
+	t.Run("Ping", func(t *testing.T) {
+		if err := Ping(context.Background(), localStorage); err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+
+		missing := &LocalStorage{basePath: filepath.Join(tempDir, "missing")}
+		if err := Ping(context.Background(), missing); err == nil {
+			t.Error("Ping should fail for missing storage directory")
+		}
+	})
+
 	// 测试 Delete 功能
 	t.Run("Delete", func(t *testing.T) {
 		err := localStorage.Delete(fileInfo.ID)
@@ -0,0 +1,150 @@
+package taskqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// 死信队列有序集合键，成员为任务ID，分数为进入死信队列的时间
+	deadLetterKey = "taskqueue:dead_letter"
+	// 各任务类型累计进入死信队列的次数
+	deadLetterTotalKey = "taskqueue:dead_letter:total"
+	// 死信任务列表的默认分页大小
+	defaultDeadLetterLimit = 20
+)
+
+// addToDeadLetter 将最终失败的任务加入死信队列并累加计数
+func (q *RedisQueue) addToDeadLetter(ctx context.Context, task *Task) {
+	at := time.Now()
+	if task.DeadLetteredAt != nil {
+		at = *task.DeadLetteredAt
+	}
+
+	pipe := q.redisClient.Pipeline()
+	pipe.ZAdd(ctx, deadLetterKey, redis.Z{Score: float64(at.UnixMilli()), Member: task.ID})
+	pipe.HIncrBy(ctx, deadLetterTotalKey, string(task.Type), 1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		q.logger.WithError(err).WithField("task_id", task.ID).Error("Failed to move task to dead letter queue")
+		return
+	}
+
+	q.logger.WithFields(logrus.Fields{
+		"task_id":     task.ID,
+		"task_type":   task.Type,
+		"document_id": task.DocumentID,
+		"attempts":    task.Attempts,
+		"error":       task.Error,
+	}).Warn("Task moved to dead letter queue")
+}
+
+// ListDeadLetter 分页获取死信队列中的任务
+// 任务数据已过期的成员在读取时从死信队列中移除
+func (q *RedisQueue) ListDeadLetter(ctx context.Context, offset, limit int) ([]*Task, int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+
+	total, err := q.redisClient.ZCard(ctx, deadLetterKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count dead letter tasks: %w", err)
+	}
+
+	taskIDs, err := q.redisClient.ZRevRange(ctx, deadLetterKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list dead letter tasks: %w", err)
+	}
+
+	tasks := make([]*Task, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		task, err := q.GetTask(ctx, taskID)
+		if err != nil {
+			if errors.Is(err, ErrTaskNotFound) {
+				q.redisClient.ZRem(ctx, deadLetterKey, taskID)
+				total--
+				continue
+			}
+			return nil, 0, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, total, nil
+}
+
+// RequeueDeadLetter 将死信队列中的任务重新加入队列
+// 任务状态重置为等待处理，错误记录和执行次数保留，便于判断是否为反复失败的毒任务
+func (q *RedisQueue) RequeueDeadLetter(ctx context.Context, taskID string) error {
+	if err := q.redisClient.ZScore(ctx, deadLetterKey, taskID).Err(); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return ErrNotDeadLettered
+		}
+		return fmt.Errorf("failed to check dead letter queue: %w", err)
+	}
+
+	task, err := q.GetTask(ctx, taskID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			q.redisClient.ZRem(ctx, deadLetterKey, taskID)
+		}
+		return err
+	}
+
+	task.Status = StatusPending
+	task.Error = ""
+	task.UpdatedAt = time.Now()
+	task.CompletedAt = nil
+	task.DeadLetteredAt = nil
+	if err := q.saveTaskToRedis(ctx, task); err != nil {
+		return err
+	}
+
+	// asynq中归档的原任务占用相同的任务ID，需要先删除
+	if err := q.inspector.DeleteTask(queueOf(task.Type), taskID); err != nil {
+		q.logger.WithError(err).WithField("task_id", taskID).Debug("No archived asynq task to delete")
+	}
+	if err := q.enqueueTask(ctx, task); err != nil {
+		return fmt.Errorf("failed to requeue task: %w", err)
+	}
+
+	if err := q.redisClient.ZRem(ctx, deadLetterKey, taskID).Err(); err != nil {
+		return fmt.Errorf("failed to remove task from dead letter queue: %w", err)
+	}
+
+	q.logger.WithFields(logrus.Fields{
+		"task_id":   taskID,
+		"task_type": task.Type,
+	}).Info("Dead letter task requeued")
+
+	return q.NotifyTaskUpdate(ctx, taskID)
+}
+
+// deadLetterStats 获取死信队列大小和各任务类型累计进入死信队列的次数
+func (q *RedisQueue) deadLetterStats(ctx context.Context) (int, map[TaskType]int64, error) {
+	size, err := q.redisClient.ZCard(ctx, deadLetterKey).Result()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count dead letter tasks: %w", err)
+	}
+
+	counts, err := q.redisClient.HGetAll(ctx, deadLetterTotalKey).Result()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read dead letter totals: %w", err)
+	}
+
+	totals := make(map[TaskType]int64, len(counts))
+	for t, v := range counts {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			totals[TaskType(t)] = n
+		}
+	}
+	return int(size), totals, nil
+}
@@ -0,0 +1,87 @@
+package taskqueue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisQueue_DeadLetter 测试最终失败的任务进入死信队列并重新入队
+func TestRedisQueue_DeadLetter(t *testing.T) {
+	redisAddr, cleanup := setupRedisTest(t)
+	defer cleanup()
+
+	q, err := NewRedisQueue(&Config{RedisAddr: redisAddr, RetryLimit: 1})
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+	taskID, err := q.Enqueue(ctx, TaskDocumentReembed, "doc-1", &DocumentReembedPayload{DocumentID: "doc-1"})
+	require.NoError(t, err)
+
+	// 第一次失败后等待重试，不进入死信队列
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskID, StatusProcessing, nil, ""))
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskID, StatusPending, nil, "timeout"))
+	tasks, total, err := q.ListDeadLetter(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, tasks)
+
+	// 重试耗尽后进入死信队列，保留每次的错误
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskID, StatusProcessing, nil, ""))
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskID, StatusFailed, nil, "connection refused"))
+	// 重复的失败回调不重复计入
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskID, StatusFailed, nil, ""))
+
+	tasks, total, err = q.ListDeadLetter(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, tasks, 1)
+	task := tasks[0]
+	assert.Equal(t, taskID, task.ID)
+	assert.Equal(t, StatusFailed, task.Status)
+	assert.Equal(t, 2, task.Attempts)
+	assert.NotNil(t, task.DeadLetteredAt)
+	require.Len(t, task.ErrorHistory, 2)
+	assert.Equal(t, 1, task.ErrorHistory[0].Attempt)
+	assert.Equal(t, "timeout", task.ErrorHistory[0].Error)
+	assert.Equal(t, 2, task.ErrorHistory[1].Attempt)
+	assert.Equal(t, "connection refused", task.ErrorHistory[1].Error)
+
+	stats, err := q.(StatsProvider).Stats(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.DeadLetter)
+	assert.Equal(t, int64(1), stats.DeadLettered[TaskDocumentReembed])
+
+	var buf bytes.Buffer
+	require.NoError(t, stats.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), "docqa_queue_dead_letter 1")
+	assert.Contains(t, buf.String(), fmt.Sprintf("docqa_task_dead_lettered_total{type=%q} 1", TaskDocumentReembed))
+
+	// 重新入队后任务回到等待状态，错误记录保留
+	require.NoError(t, q.RequeueDeadLetter(ctx, taskID))
+	task, err = q.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, task.Status)
+	assert.Nil(t, task.DeadLetteredAt)
+	assert.Nil(t, task.CompletedAt)
+	assert.Len(t, task.ErrorHistory, 2)
+
+	_, total, err = q.ListDeadLetter(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	assert.ErrorIs(t, q.RequeueDeadLetter(ctx, taskID), ErrNotDeadLettered)
+}
+
+// TestIsFinalAttempt 测试失败后是否继续重试的判断
+func TestIsFinalAttempt(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, isFinalAttempt(ctx, fmt.Errorf("bad input: %w", ErrInvalidPayload)))
+	// 不在asynq处理上下文中时无法重试
+	assert.True(t, isFinalAttempt(ctx, fmt.Errorf("timeout")))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -63,6 +64,11 @@ func (p *CallbackProcessor) ProcessCallback(ctx context.Context, callbackData []
 		return fmt.Errorf("failed to get task: %w", err)
 	}
 
+	// 回调处理沿用创建任务的请求ID，与上传请求的日志关联
+	if task.RequestID != "" {
+		ctx = requestid.WithRequestID(ctx, task.RequestID)
+	}
+
 	// 更新任务状态
 	err = p.queue.UpdateTaskStatus(ctx, callback.TaskID, callback.Status, callback.Result, callback.Error)
 	if err != nil {
@@ -98,7 +104,7 @@ func (p *CallbackProcessor) ProcessCallback(ctx context.Context, callbackData []
 	}
 
 	// 调用处理函数
-	p.logger.Debugf("Calling handler for task: %s (type: %s)", task.ID, task.Type)
+	p.logger.WithContext(ctx).Debugf("Calling handler for task: %s (type: %s)", task.ID, task.Type)
 	return handler(ctx, task, callback.Result)
 }
 
@@ -411,6 +411,73 @@ func (_c *MockQueue_GetTasksByDocument_Call) RunAndReturn(run func(context.Conte
 	return _c
 }
 
+// ListDeadLetter provides a mock function with given fields: ctx, offset, limit
+func (_m *MockQueue) ListDeadLetter(ctx context.Context, offset int, limit int) ([]*Task, int64, error) {
+	ret := _m.Called(ctx, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeadLetter")
+	}
+
+	var r0 []*Task
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) ([]*Task, int64, error)); ok {
+		return rf(ctx, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) []*Task); ok {
+		r0 = rf(ctx, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Task)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) int64); ok {
+		r1 = rf(ctx, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = rf(ctx, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockQueue_ListDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDeadLetter'
+type MockQueue_ListDeadLetter_Call struct {
+	*mock.Call
+}
+
+// ListDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset int
+//   - limit int
+func (_e *MockQueue_Expecter) ListDeadLetter(ctx interface{}, offset interface{}, limit interface{}) *MockQueue_ListDeadLetter_Call {
+	return &MockQueue_ListDeadLetter_Call{Call: _e.mock.On("ListDeadLetter", ctx, offset, limit)}
+}
+
+func (_c *MockQueue_ListDeadLetter_Call) Run(run func(ctx context.Context, offset int, limit int)) *MockQueue_ListDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *MockQueue_ListDeadLetter_Call) Return(_a0 []*Task, _a1 int64, _a2 error) *MockQueue_ListDeadLetter_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockQueue_ListDeadLetter_Call) RunAndReturn(run func(context.Context, int, int) ([]*Task, int64, error)) *MockQueue_ListDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NotifyTaskUpdate provides a mock function with given fields: ctx, taskID
 func (_m *MockQueue) NotifyTaskUpdate(ctx context.Context, taskID string) error {
 	ret := _m.Called(ctx, taskID)
@@ -458,6 +525,53 @@ func (_c *MockQueue_NotifyTaskUpdate_Call) RunAndReturn(run func(context.Context
 	return _c
 }
 
+// RequeueDeadLetter provides a mock function with given fields: ctx, taskID
+func (_m *MockQueue) RequeueDeadLetter(ctx context.Context, taskID string) error {
+	ret := _m.Called(ctx, taskID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueDeadLetter")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, taskID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockQueue_RequeueDeadLetter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RequeueDeadLetter'
+type MockQueue_RequeueDeadLetter_Call struct {
+	*mock.Call
+}
+
+// RequeueDeadLetter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - taskID string
+func (_e *MockQueue_Expecter) RequeueDeadLetter(ctx interface{}, taskID interface{}) *MockQueue_RequeueDeadLetter_Call {
+	return &MockQueue_RequeueDeadLetter_Call{Call: _e.mock.On("RequeueDeadLetter", ctx, taskID)}
+}
+
+func (_c *MockQueue_RequeueDeadLetter_Call) Run(run func(ctx context.Context, taskID string)) *MockQueue_RequeueDeadLetter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockQueue_RequeueDeadLetter_Call) Return(_a0 error) *MockQueue_RequeueDeadLetter_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockQueue_RequeueDeadLetter_Call) RunAndReturn(run func(context.Context, string) error) *MockQueue_RequeueDeadLetter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateTaskStatus provides a mock function with given fields: ctx, taskID, status, result, errorMsg
 func (_m *MockQueue) UpdateTaskStatus(ctx context.Context, taskID string, status TaskStatus, result interface{}, errorMsg string) error {
 	ret := _m.Called(ctx, taskID, status, result, errorMsg)
@@ -17,8 +17,22 @@ const (
 	TaskVectorize TaskType = "vectorize"
 	// TaskProcessComplete 文档处理完整流程任务
 	TaskProcessComplete TaskType = "process_complete"
+	// TaskDocumentCleanup 文档删除后清理Python端缓存的任务
+	TaskDocumentCleanup TaskType = "document_cleanup"
+	// TaskDocumentRecrawl 重新抓取网页来源文档的任务
+	TaskDocumentRecrawl TaskType = "document_recrawl"
+	// TaskDocumentReembed 使用当前嵌入模型重新向量化文档的任务
+	TaskDocumentReembed TaskType = "document_reembed"
+	// TaskQAAnswer 异步问答任务
+	TaskQAAnswer TaskType = "qa_answer"
 )
 
+// MaintenanceQueue 文档维护任务（重新抓取、重新向量化）使用的队列
+const MaintenanceQueue = "maintenance"
+
+// QAQueue 异步问答任务使用的队列
+const QAQueue = "qa"
+
 // TaskStatus 任务状态
 type TaskStatus string
 
@@ -48,6 +62,19 @@ type Task struct {
 	CompletedAt *time.Time      `json:"completed_at"` // 完成时间
 	Attempts    int             `json:"attempts"`     // 尝试次数
 	MaxRetries  int             `json:"max_retries"`  // 最大重试次数
+	// ErrorHistory 每次失败的错误记录，重试和进入死信队列后保留
+	ErrorHistory []TaskAttemptError `json:"error_history,omitempty"`
+	// DeadLetteredAt 进入死信队列的时间，不在死信队列中时为空
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+	// RequestID 创建任务的请求ID，处理任务时写回上下文，用于关联请求和异步处理日志
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// TaskAttemptError 单次执行失败的记录
+type TaskAttemptError struct {
+	Attempt  int       `json:"attempt"`   // 第几次执行
+	Error    string    `json:"error"`     // 错误信息
+	FailedAt time.Time `json:"failed_at"` // 失败时间
 }
 
 // DocumentParsePayload 文档解析任务载荷
@@ -141,6 +168,30 @@ type ProcessCompleteResult struct {
 	Vectors      []VectorInfo `json:"vectors"`       // 可选，根据配置决定是否返回向量数据
 }
 
+// DocumentCleanupPayload 文档缓存清理任务载荷
+type DocumentCleanupPayload struct {
+	DocumentID string `json:"document_id"` // 文档ID
+}
+
+// DocumentCleanupResult 文档缓存清理结果
+type DocumentCleanupResult struct {
+	DocumentID  string `json:"document_id"`  // 文档ID
+	DeletedKeys int    `json:"deleted_keys"` // 删除的缓存键数量
+	Error       string `json:"error"`        // 错误信息（如果有）
+}
+
+// DocumentRecrawlPayload 网页重新抓取任务载荷
+type DocumentRecrawlPayload struct {
+	DocumentID string `json:"document_id"` // 文档ID
+	URL        string `json:"url"`         // 来源URL
+}
+
+// DocumentReembedPayload 重新向量化任务载荷
+type DocumentReembedPayload struct {
+	DocumentID string `json:"document_id"` // 文档ID
+	Model      string `json:"model"`       // 目标嵌入模型
+}
+
 // TaskCallback 任务回调信息
 type TaskCallback struct {
 	TaskID     string          `json:"task_id"`     // 任务ID
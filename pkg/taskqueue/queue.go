@@ -37,10 +37,23 @@ type Queue interface {
 	// NotifyTaskUpdate 通知任务状态已更新
 	NotifyTaskUpdate(ctx context.Context, taskID string) error
 
+	// ListDeadLetter 分页获取死信队列中的任务，按进入死信队列的时间倒序排列
+	ListDeadLetter(ctx context.Context, offset, limit int) ([]*Task, int64, error)
+
+	// RequeueDeadLetter 将死信队列中的任务重新加入队列，保留错误记录
+	RequeueDeadLetter(ctx context.Context, taskID string) error
+
 	// Close 关闭队列连接
 	Close() error
 }
 
+// Pinger 检查队列后端连接的接口，用于健康检查
+// 与Queue接口分离，不依赖外部服务的队列实现无需实现
+type Pinger interface {
+	// Ping 检查与队列后端的连接
+	Ping(ctx context.Context) error
+}
+
 // Handler 任务处理器接口
 // 负责实际执行任务的逻辑
 type Handler interface {
@@ -170,8 +183,12 @@ var ErrTaskNotFound = TaskError("task not found")
 var ErrTaskTimeout = TaskError("task timed out")
 
 // ErrInvalidPayload 无效的任务载荷错误
+// 处理器返回该错误时任务不再重试，直接进入死信队列
 var ErrInvalidPayload = TaskError("invalid task payload")
 
+// ErrNotDeadLettered 任务不在死信队列中
+var ErrNotDeadLettered = TaskError("task is not in dead letter queue")
+
 // TaskError 任务错误类型
 type TaskError string
 
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fyerfyer/doc-QA-system/pkg/requestid"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
@@ -68,6 +69,7 @@ func NewRedisQueue(cfg *Config) (Queue, error) {
 
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(requestid.Hook{})
 
 	return &RedisQueue{
 		client:      client,
@@ -98,6 +100,7 @@ func (q *RedisQueue) Enqueue(ctx context.Context, taskType TaskType, documentID
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 		MaxRetries: q.cfg.RetryLimit,
+		RequestID:  requestid.FromContext(ctx),
 	}
 
 	// 将任务信息存储到Redis
@@ -106,16 +109,12 @@ func (q *RedisQueue) Enqueue(ctx context.Context, taskType TaskType, documentID
 		return "", fmt.Errorf("failed to save task to redis: %w", err)
 	}
 
-	// 创建asynq任务，使用taskID作为任务负载
-	asynqTask := asynq.NewTask(string(taskType), []byte(taskID))
-
 	// 将任务加入队列
-	_, err = q.client.EnqueueContext(ctx, asynqTask)
-	if err != nil {
+	if err := q.enqueueTask(ctx, task); err != nil {
 		return "", fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
-	q.logger.WithFields(logrus.Fields{
+	q.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"task_id":     taskID,
 		"task_type":   taskType,
 		"document_id": documentID,
@@ -142,6 +141,7 @@ func (q *RedisQueue) EnqueueAt(ctx context.Context, taskType TaskType, documentI
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 		MaxRetries: q.cfg.RetryLimit,
+		RequestID:  requestid.FromContext(ctx),
 	}
 
 	err = q.saveTaskToRedis(ctx, task)
@@ -149,15 +149,26 @@ func (q *RedisQueue) EnqueueAt(ctx context.Context, taskType TaskType, documentI
 		return "", fmt.Errorf("failed to save task to redis: %w", err)
 	}
 
-	asynqTask := asynq.NewTask(string(taskType), []byte(taskID))
-	_, err = q.client.EnqueueContext(ctx, asynqTask, asynq.ProcessAt(processAt))
-	if err != nil {
+	if err := q.enqueueTask(ctx, task, asynq.ProcessAt(processAt)); err != nil {
 		return "", fmt.Errorf("failed to enqueue task with delay: %w", err)
 	}
 
 	return taskID, nil
 }
 
+// enqueueTask 创建asynq任务并加入队列，使用taskID作为任务负载和asynq任务ID
+// 最大重试次数取配置的RetryLimit，重试耗尽后任务进入死信队列
+func (q *RedisQueue) enqueueTask(ctx context.Context, task *Task, opts ...asynq.Option) error {
+	asynqTask := asynq.NewTask(string(task.Type), []byte(task.ID))
+	opts = append([]asynq.Option{
+		asynq.Queue(queueOf(task.Type)),
+		asynq.TaskID(task.ID),
+		asynq.MaxRetry(q.cfg.RetryLimit),
+	}, opts...)
+	_, err := q.client.EnqueueContext(ctx, asynqTask, opts...)
+	return err
+}
+
 // EnqueueIn 在指定延迟后将任务加入队列
 func (q *RedisQueue) EnqueueIn(ctx context.Context, taskType TaskType, documentID string, payload interface{}, delay time.Duration) (string, error) {
 	return q.EnqueueAt(ctx, taskType, documentID, payload, time.Now().Add(delay))
@@ -375,7 +386,7 @@ func (q *RedisQueue) DeleteTask(ctx context.Context, taskID string) error {
 
 	// 尝试从asynq队列中删除任务（如果尚未处理）
 	// 注意：已在处理中的任务可能无法删除
-	err = q.inspector.DeleteTask("default", taskID)
+	err = q.inspector.DeleteTask(queueOf(task.Type), taskID)
 	if err != nil {
 		q.logger.WithError(err).WithField("task_id", taskID).Warn("Failed to delete task from asynq queue")
 	}
@@ -383,6 +394,11 @@ func (q *RedisQueue) DeleteTask(ctx context.Context, taskID string) error {
 	return nil
 }
 
+// Ping 检查与Redis的连接
+func (q *RedisQueue) Ping(ctx context.Context) error {
+	return q.redisClient.Ping(ctx).Err()
+}
+
 // Close 关闭队列连接
 func (q *RedisQueue) Close() error {
 	if err := q.client.Close(); err != nil {
@@ -429,6 +445,11 @@ func (q *RedisQueue) UpdateTaskStatus(ctx context.Context, taskID string, status
 		return err
 	}
 
+	// 每次从其他状态进入处理中计为一次执行，处理中的进度回调不重复计数
+	if status == StatusProcessing && task.Status != StatusProcessing {
+		task.Attempts++
+	}
+
 	task.Status = status
 	task.UpdatedAt = time.Now()
 
@@ -452,6 +473,21 @@ func (q *RedisQueue) UpdateTaskStatus(ctx context.Context, taskID string, status
 
 	if errMsg != "" {
 		task.Error = errMsg
+		// 等待重试（pending）和最终失败（failed）时保留本次错误
+		if status == StatusPending || status == StatusFailed {
+			task.ErrorHistory = append(task.ErrorHistory, TaskAttemptError{
+				Attempt:  max(task.Attempts, len(task.ErrorHistory)+1),
+				Error:    errMsg,
+				FailedAt: task.UpdatedAt,
+			})
+		}
+	}
+
+	// 最终失败的任务进入死信队列，重复的失败回调不重复计入
+	deadLetter := status == StatusFailed && task.DeadLetteredAt == nil
+	if deadLetter {
+		now := time.Now()
+		task.DeadLetteredAt = &now
 	}
 
 	// 保存更新后的任务状态
@@ -460,6 +496,15 @@ func (q *RedisQueue) UpdateTaskStatus(ctx context.Context, taskID string, status
 		return err
 	}
 
+	if deadLetter {
+		q.addToDeadLetter(ctx, task)
+	}
+
+	// 记录完成指标，用于队列统计和自动扩缩容
+	if status == StatusCompleted || status == StatusFailed {
+		q.recordTaskMetric(ctx, task)
+	}
+
 	// 状态更新成功后自动发送通知
 	if err := q.NotifyTaskUpdate(ctx, taskID); err != nil {
 		q.logger.WithError(err).WithField("task_id", taskID).Warn("Failed to notify task update")
@@ -482,6 +527,19 @@ type RedisWorker struct {
 	logger   *logrus.Logger
 }
 
+// queueOf 返回任务类型对应的asynq队列
+// 文档维护任务和异步问答任务由Go侧的worker消费，放在独立的队列中，避免worker取走交给Python处理的任务
+func queueOf(taskType TaskType) string {
+	switch taskType {
+	case TaskDocumentRecrawl, TaskDocumentReembed:
+		return MaintenanceQueue
+	case TaskQAAnswer:
+		return QAQueue
+	default:
+		return "default"
+	}
+}
+
 // NewRedisWorker 创建Redis工作者
 func NewRedisWorker(queue *RedisQueue, cfg *Config) Worker {
 	if cfg == nil {
@@ -533,17 +591,23 @@ func (w *RedisWorker) Start() error {
 		mux.HandleFunc(taskTypeStr, func(ctx context.Context, task *asynq.Task) error {
 			taskID := string(task.Payload())
 
-			// 获取任务信息
+			// 获取任务信息，任务数据已不存在时重试也无法处理
 			taskInfo, err := w.queue.GetTask(ctx, taskID)
 			if err != nil {
 				w.logger.WithError(err).WithField("task_id", taskID).Error("Failed to get task info")
+				if errors.Is(err, ErrTaskNotFound) {
+					return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+				}
 				return err
 			}
 
+			// 恢复创建任务的请求ID，处理器的日志和下游调用可以与原请求关联
+			ctx = requestid.WithRequestID(ctx, taskInfo.RequestID)
+
 			// 更新任务状态为处理中
 			err = w.queue.UpdateTaskStatus(ctx, taskID, StatusProcessing, nil, "")
 			if err != nil {
-				w.logger.WithError(err).WithField("task_id", taskID).Error("Failed to update task status to processing")
+				w.logger.WithContext(ctx).WithError(err).WithField("task_id", taskID).Error("Failed to update task status to processing")
 			}
 
 			// 通知状态更新
@@ -553,20 +617,28 @@ func (w *RedisWorker) Start() error {
 			err = h.ProcessTask(ctx, taskInfo)
 
 			// 根据处理结果更新任务状态
+			// 还有重试机会时回到等待状态，重试耗尽或载荷无效时标记失败并进入死信队列
 			if err != nil {
 				errMsg := err.Error()
-				updateErr := w.queue.UpdateTaskStatus(ctx, taskID, StatusFailed, nil, errMsg)
+				status := StatusPending
+				if isFinalAttempt(ctx, err) {
+					status = StatusFailed
+				}
+				updateErr := w.queue.UpdateTaskStatus(ctx, taskID, status, nil, errMsg)
 				if updateErr != nil {
-					w.logger.WithError(updateErr).WithField("task_id", taskID).Error("Failed to update task status after failure")
+					w.logger.WithContext(ctx).WithError(updateErr).WithField("task_id", taskID).Error("Failed to update task status after failure")
 				}
 				w.queue.NotifyTaskUpdate(ctx, taskID)
+				if errors.Is(err, ErrInvalidPayload) {
+					return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+				}
 				return err
 			}
 
 			// 处理成功，更新任务状态
 			err = w.queue.UpdateTaskStatus(ctx, taskID, StatusCompleted, nil, "")
 			if err != nil {
-				w.logger.WithError(err).WithField("task_id", taskID).Error("Failed to update task status after completion")
+				w.logger.WithContext(ctx).WithError(err).WithField("task_id", taskID).Error("Failed to update task status after completion")
 			}
 			w.queue.NotifyTaskUpdate(ctx, taskID)
 			return nil
@@ -579,6 +651,23 @@ func (w *RedisWorker) Start() error {
 	return w.server.Start(mux)
 }
 
+// isFinalAttempt 判断本次失败后是否不再重试
+// 载荷无效的任务重试也不会成功，直接视为最终失败
+func isFinalAttempt(ctx context.Context, err error) bool {
+	if errors.Is(err, ErrInvalidPayload) {
+		return true
+	}
+	retried, ok := asynq.GetRetryCount(ctx)
+	if !ok {
+		return true
+	}
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	if !ok {
+		return true
+	}
+	return retried >= maxRetry
+}
+
 // Stop 停止工作者
 func (w *RedisWorker) Stop() {
 	w.server.Shutdown()
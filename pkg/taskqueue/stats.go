@@ -0,0 +1,310 @@
+package taskqueue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 任务指标有序集合键前缀，按完成时间排序
+	metricsKeyPrefix = "taskqueue:metrics:"
+	// 出现过的任务类型集合
+	metricsTypesKey = "taskqueue:metrics:types"
+	// 指标保留时间，超出的记录在写入时清理
+	metricsRetention = time.Hour
+	// 默认统计窗口
+	defaultStatsWindow = 5 * time.Minute
+)
+
+// ScalingThresholds 扩缩容阈值
+// 供Kubernetes HPA/KEDA等组件参考，超过任一阈值时QueueStats.ScaleUp为true
+//
+// 推荐用法：
+//   - KEDA metrics-api scaler：valueLocation设为"data.backlog"，targetValue设为MaxBacklog
+//   - Prometheus Adapter：使用docqa_queue_lag_seconds和docqa_queue_backlog指标
+type ScalingThresholds struct {
+	MaxLag     time.Duration // 最老待处理任务的等待时间上限，默认30秒
+	MaxBacklog int           // 积压任务数上限（pending+retry），默认20
+}
+
+// DefaultScalingThresholds 返回默认扩缩容阈值
+func DefaultScalingThresholds() ScalingThresholds {
+	return ScalingThresholds{
+		MaxLag:     30 * time.Second,
+		MaxBacklog: 20,
+	}
+}
+
+// QueueDepth 单个队列的积压情况
+type QueueDepth struct {
+	Name           string  `json:"name"`            // 队列名称
+	Size           int     `json:"size"`            // 队列中的任务总数
+	Pending        int     `json:"pending"`         // 等待处理的任务数
+	Active         int     `json:"active"`          // 处理中的任务数
+	Scheduled      int     `json:"scheduled"`       // 定时任务数
+	Retry          int     `json:"retry"`           // 等待重试的任务数
+	Archived       int     `json:"archived"`        // 已归档（最终失败）的任务数
+	LagSeconds     float64 `json:"lag_seconds"`     // 最老待处理任务已等待的秒数
+	ProcessedToday int     `json:"processed_today"` // 今日已处理任务数
+	FailedToday    int     `json:"failed_today"`    // 今日失败任务数
+	Paused         bool    `json:"paused"`          // 队列是否暂停
+}
+
+// TaskTypeStats 单个任务类型在统计窗口内的指标
+type TaskTypeStats struct {
+	Type          TaskType `json:"type"`            // 任务类型
+	Completed     int      `json:"completed"`       // 成功完成数
+	Failed        int      `json:"failed"`          // 失败数
+	RatePerMinute float64  `json:"rate_per_minute"` // 每分钟处理速率（含失败）
+	AvgLatencyMs  int64    `json:"avg_latency_ms"`  // 平均端到端延迟（创建到完成）
+	P95LatencyMs  int64    `json:"p95_latency_ms"`  // P95端到端延迟
+}
+
+// QueueStats 队列统计信息，用于监控和自动扩缩容
+type QueueStats struct {
+	Queues           []QueueDepth       `json:"queues"`                        // 各队列积压情况
+	TaskTypes        []TaskTypeStats    `json:"task_types"`                    // 各任务类型指标
+	Backlog          int                `json:"backlog"`                       // 总积压任务数（pending+retry）
+	DeadLetter       int                `json:"dead_letter"`                   // 死信队列中的任务数
+	DeadLettered     map[TaskType]int64 `json:"dead_lettered_total,omitempty"` // 各任务类型累计进入死信队列的次数
+	MaxLagSeconds    float64            `json:"max_lag_seconds"`               // 所有队列中的最大等待时间
+	WindowSeconds    float64            `json:"window_seconds"`                // 任务类型指标的统计窗口
+	LagThreshold     float64            `json:"lag_threshold_seconds"`         // 等待时间扩容阈值（秒）
+	BacklogThreshold int                `json:"backlog_threshold"`             // 积压任务数扩容阈值
+	ScaleUp          bool               `json:"scale_up"`                      // 是否建议扩容
+	Reason           string             `json:"reason,omitempty"`              // 建议扩容的原因
+	CollectedAt      time.Time          `json:"collected_at"`                  // 采集时间
+}
+
+// StatsProvider 提供队列统计信息的接口
+// 与Queue接口分离，不支持统计的队列实现无需实现
+type StatsProvider interface {
+	// Stats 获取队列统计信息，window为任务类型指标的统计窗口，0表示默认5分钟
+	Stats(ctx context.Context, window time.Duration) (*QueueStats, error)
+}
+
+// Evaluate 根据阈值计算是否需要扩容
+func (s *QueueStats) Evaluate(thresholds ScalingThresholds) {
+	s.LagThreshold = thresholds.MaxLag.Seconds()
+	s.BacklogThreshold = thresholds.MaxBacklog
+	s.ScaleUp = false
+	s.Reason = ""
+
+	var reasons []string
+	if thresholds.MaxLag > 0 && s.MaxLagSeconds > thresholds.MaxLag.Seconds() {
+		reasons = append(reasons, fmt.Sprintf("lag %.0fs exceeds %.0fs", s.MaxLagSeconds, thresholds.MaxLag.Seconds()))
+	}
+	if thresholds.MaxBacklog > 0 && s.Backlog > thresholds.MaxBacklog {
+		reasons = append(reasons, fmt.Sprintf("backlog %d exceeds %d", s.Backlog, thresholds.MaxBacklog))
+	}
+
+	if len(reasons) > 0 {
+		s.ScaleUp = true
+		s.Reason = strings.Join(reasons, "; ")
+	}
+}
+
+// WritePrometheus 以Prometheus文本格式输出指标
+func (s *QueueStats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("# HELP docqa_queue_backlog Pending and retry tasks across all queues.\n")
+	b.WriteString("# TYPE docqa_queue_backlog gauge\n")
+	fmt.Fprintf(&b, "docqa_queue_backlog %d\n", s.Backlog)
+
+	b.WriteString("# HELP docqa_queue_lag_seconds Age of the oldest pending task.\n")
+	b.WriteString("# TYPE docqa_queue_lag_seconds gauge\n")
+	for _, q := range s.Queues {
+		fmt.Fprintf(&b, "docqa_queue_lag_seconds{queue=%q} %g\n", q.Name, q.LagSeconds)
+	}
+
+	b.WriteString("# HELP docqa_queue_tasks Tasks in queue by state.\n")
+	b.WriteString("# TYPE docqa_queue_tasks gauge\n")
+	for _, q := range s.Queues {
+		for _, kv := range []struct {
+			state string
+			n     int
+		}{{"pending", q.Pending}, {"active", q.Active}, {"scheduled", q.Scheduled}, {"retry", q.Retry}, {"archived", q.Archived}} {
+			fmt.Fprintf(&b, "docqa_queue_tasks{queue=%q,state=%q} %d\n", q.Name, kv.state, kv.n)
+		}
+	}
+
+	b.WriteString("# HELP docqa_queue_dead_letter Tasks in the dead letter queue.\n")
+	b.WriteString("# TYPE docqa_queue_dead_letter gauge\n")
+	fmt.Fprintf(&b, "docqa_queue_dead_letter %d\n", s.DeadLetter)
+
+	b.WriteString("# HELP docqa_task_dead_lettered_total Tasks moved to the dead letter queue after exhausting retries.\n")
+	b.WriteString("# TYPE docqa_task_dead_lettered_total counter\n")
+	types := make([]string, 0, len(s.DeadLettered))
+	for t := range s.DeadLettered {
+		types = append(types, string(t))
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&b, "docqa_task_dead_lettered_total{type=%q} %d\n", t, s.DeadLettered[TaskType(t)])
+	}
+
+	b.WriteString("# HELP docqa_task_rate_per_minute Tasks finished per minute in the stats window.\n")
+	b.WriteString("# TYPE docqa_task_rate_per_minute gauge\n")
+	for _, t := range s.TaskTypes {
+		fmt.Fprintf(&b, "docqa_task_rate_per_minute{type=%q} %g\n", t.Type, t.RatePerMinute)
+	}
+
+	b.WriteString("# HELP docqa_task_latency_ms End-to-end task latency in the stats window.\n")
+	b.WriteString("# TYPE docqa_task_latency_ms gauge\n")
+	for _, t := range s.TaskTypes {
+		fmt.Fprintf(&b, "docqa_task_latency_ms{type=%q,quantile=\"avg\"} %d\n", t.Type, t.AvgLatencyMs)
+		fmt.Fprintf(&b, "docqa_task_latency_ms{type=%q,quantile=\"0.95\"} %d\n", t.Type, t.P95LatencyMs)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// recordTaskMetric 记录任务完成指标
+// 成员格式为 taskID|延迟毫秒|状态，分数为完成时间
+func (q *RedisQueue) recordTaskMetric(ctx context.Context, task *Task) {
+	if task.CompletedAt == nil {
+		return
+	}
+
+	latency := task.CompletedAt.Sub(task.CreatedAt).Milliseconds()
+	if latency < 0 {
+		latency = 0
+	}
+
+	key := metricsKeyPrefix + string(task.Type)
+	member := fmt.Sprintf("%s|%d|%s", task.ID, latency, task.Status)
+	now := task.CompletedAt
+
+	pipe := q.redisClient.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: member})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-metricsRetention).UnixMilli(), 10))
+	pipe.Expire(ctx, key, metricsRetention)
+	pipe.SAdd(ctx, metricsTypesKey, string(task.Type))
+	if _, err := pipe.Exec(ctx); err != nil {
+		q.logger.WithError(err).WithField("task_id", task.ID).Warn("Failed to record task metric")
+	}
+}
+
+// Stats 获取队列统计信息
+func (q *RedisQueue) Stats(ctx context.Context, window time.Duration) (*QueueStats, error) {
+	if window <= 0 {
+		window = defaultStatsWindow
+	}
+	if window > metricsRetention {
+		window = metricsRetention
+	}
+
+	stats := &QueueStats{
+		WindowSeconds: window.Seconds(),
+		CollectedAt:   time.Now(),
+	}
+
+	// 队列积压情况来自asynq
+	queues, err := q.inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queues: %w", err)
+	}
+	sort.Strings(queues)
+
+	for _, name := range queues {
+		info, err := q.inspector.GetQueueInfo(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get queue info for %s: %w", name, err)
+		}
+
+		depth := QueueDepth{
+			Name:           name,
+			Size:           info.Size,
+			Pending:        info.Pending,
+			Active:         info.Active,
+			Scheduled:      info.Scheduled,
+			Retry:          info.Retry,
+			Archived:       info.Archived,
+			LagSeconds:     info.Latency.Seconds(),
+			ProcessedToday: info.Processed,
+			FailedToday:    info.Failed,
+			Paused:         info.Paused,
+		}
+		stats.Queues = append(stats.Queues, depth)
+		stats.Backlog += depth.Pending + depth.Retry
+		if depth.LagSeconds > stats.MaxLagSeconds {
+			stats.MaxLagSeconds = depth.LagSeconds
+		}
+	}
+
+	// 死信队列由所有失败路径共享，包含Go侧worker和回调上报的Python任务
+	stats.DeadLetter, stats.DeadLettered, err = q.deadLetterStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 任务类型指标来自任务完成记录，包含回调上报的Python任务
+	types, err := q.redisClient.SMembers(ctx, metricsTypesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list task types: %w", err)
+	}
+	sort.Strings(types)
+
+	since := strconv.FormatInt(stats.CollectedAt.Add(-window).UnixMilli(), 10)
+	for _, t := range types {
+		members, err := q.redisClient.ZRangeByScore(ctx, metricsKeyPrefix+t, &redis.ZRangeBy{
+			Min: since,
+			Max: "+inf",
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metrics for %s: %w", t, err)
+		}
+		stats.TaskTypes = append(stats.TaskTypes, summarizeTaskMetrics(TaskType(t), members, window))
+	}
+
+	stats.Evaluate(DefaultScalingThresholds())
+	return stats, nil
+}
+
+// summarizeTaskMetrics 汇总单个任务类型的指标记录
+func summarizeTaskMetrics(taskType TaskType, members []string, window time.Duration) TaskTypeStats {
+	result := TaskTypeStats{Type: taskType}
+	latencies := make([]int64, 0, len(members))
+
+	for _, m := range members {
+		parts := strings.Split(m, "|")
+		if len(parts) != 3 {
+			continue
+		}
+		latency, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if TaskStatus(parts[2]) == StatusFailed {
+			result.Failed++
+		} else {
+			result.Completed++
+		}
+		latencies = append(latencies, latency)
+	}
+
+	if len(latencies) == 0 {
+		return result
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total int64
+	for _, l := range latencies {
+		total += l
+	}
+	result.AvgLatencyMs = total / int64(len(latencies))
+	result.P95LatencyMs = latencies[(len(latencies)*95-1)/100]
+	result.RatePerMinute = float64(len(latencies)) / window.Minutes()
+
+	return result
+}
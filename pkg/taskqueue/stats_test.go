@@ -0,0 +1,70 @@
+package taskqueue
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisQueue_Stats 测试队列统计信息
+func TestRedisQueue_Stats(t *testing.T) {
+	redisAddr, cleanup := setupRedisTest(t)
+	defer cleanup()
+
+	q, err := NewRedisQueue(&Config{RedisAddr: redisAddr, RetryLimit: 1})
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx := context.Background()
+
+	// 入队三个任务，完成其中两个
+	var taskIDs []string
+	for i := 0; i < 3; i++ {
+		id, err := q.Enqueue(ctx, TaskDocumentParse, fmt.Sprintf("doc-%d", i), nil)
+		require.NoError(t, err)
+		taskIDs = append(taskIDs, id)
+	}
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskIDs[0], StatusCompleted, nil, ""))
+	require.NoError(t, q.UpdateTaskStatus(ctx, taskIDs[1], StatusFailed, nil, "boom"))
+
+	provider, ok := q.(StatsProvider)
+	require.True(t, ok, "redis queue should provide stats")
+
+	stats, err := provider.Stats(ctx, time.Minute)
+	require.NoError(t, err)
+
+	require.Len(t, stats.Queues, 1)
+	assert.Equal(t, "default", stats.Queues[0].Name)
+	assert.Equal(t, 3, stats.Queues[0].Pending)
+	assert.Equal(t, 3, stats.Backlog)
+
+	require.Len(t, stats.TaskTypes, 1)
+	typeStats := stats.TaskTypes[0]
+	assert.Equal(t, TaskDocumentParse, typeStats.Type)
+	assert.Equal(t, 1, typeStats.Completed)
+	assert.Equal(t, 1, typeStats.Failed)
+	assert.InDelta(t, 2.0, typeStats.RatePerMinute, 0.001)
+}
+
+// TestQueueStats_Evaluate 测试扩容阈值判断
+func TestQueueStats_Evaluate(t *testing.T) {
+	stats := &QueueStats{Backlog: 5, MaxLagSeconds: 10}
+
+	stats.Evaluate(DefaultScalingThresholds())
+	assert.False(t, stats.ScaleUp)
+	assert.Equal(t, 30.0, stats.LagThreshold)
+
+	stats.Evaluate(ScalingThresholds{MaxLag: 5 * time.Second, MaxBacklog: 3})
+	assert.True(t, stats.ScaleUp)
+	assert.Contains(t, stats.Reason, "lag")
+	assert.Contains(t, stats.Reason, "backlog")
+
+	var buf bytes.Buffer
+	require.NoError(t, stats.WritePrometheus(&buf))
+	assert.Contains(t, buf.String(), "docqa_queue_backlog 5")
+}
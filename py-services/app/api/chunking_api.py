@@ -9,7 +9,7 @@ from app.document_processing.factory import create_chunker
 from app.document_processing.utils import format_chunk_for_embedding
 from app.utils.utils import logger
 from app.models.model import Task, TaskType, TaskStatus, TextChunkResult, ChunkInfo
-from app.worker.tasks import get_redis_client, get_task_from_redis
+from app.worker.tasks import get_redis_client, get_task_from_redis, STORED_RESULT_TTL
 
 # 创建路由器
 router = APIRouter(prefix="/api/python/documents", tags=["documents"])
@@ -22,7 +22,8 @@ async def chunk_text(
     chunk_overlap: int = Body(200, description="Overlap size between chunks"),
     split_type: str = Body("paragraph", description="Splitting strategy (paragraph, sentence, semantic)"),
     store_result: bool = Body(True, description="Whether to store the chunking result"),
-    metadata: Optional[Dict[str, Any]] = Body(None, description="Additional metadata")
+    metadata: Optional[Dict[str, Any]] = Body(None, description="Additional metadata"),
+    breakpoint_threshold: Optional[float] = Body(None, description="Percentile breakpoint threshold for semantic splitting (0-100)")
 ):
     """
     分割文本内容为多个块
@@ -35,6 +36,7 @@ async def chunk_text(
     - split_type: 分割策略，如paragraph、sentence
     - store_result: 是否存储分块结果
     - metadata: 附加元数据
+    - breakpoint_threshold: 语义分块断点阈值（百分位），仅split_type为semantic时使用
     """
     try:
         start_time = time.time()
@@ -49,6 +51,9 @@ async def chunk_text(
             
         if chunk_overlap < 0:
             raise HTTPException(status_code=400, detail="Chunk overlap cannot be negative")
+
+        if breakpoint_threshold is not None and not 0 < breakpoint_threshold <= 100:
+            raise HTTPException(status_code=400, detail="Breakpoint threshold must be in (0, 100]")
             
         logger.info(f"Chunking text for document {document_id}: {len(text)} chars, type: {split_type}")
         
@@ -59,7 +64,7 @@ async def chunk_text(
         metadata["document_id"] = document_id
         
         # 创建分块器并执行文本分块 (使用新的LlamaIndex-based chunker)
-        chunker = create_chunker(chunk_size, chunk_overlap, split_type)
+        chunker = create_chunker(chunk_size, chunk_overlap, split_type, breakpoint_threshold)
         chunks_data = chunker.chunk_text(text, metadata)
         
         # 格式化分块结果 - 使format_chunk_for_embedding确保结果一致性
@@ -91,17 +96,18 @@ async def chunk_text(
             
             # 保存到Redis
             redis_client = get_redis_client()
-            redis_client.set(f"task:{task_id}", task.to_json())
+            redis_client.set(f"task:{task_id}", task.to_json(), ex=STORED_RESULT_TTL)
             
             # 将chunks序列化为JSON并存储
             chunks_json = json.dumps([{
                 "text": chunk.text, 
                 "index": chunk.index
             } for chunk in chunks])
-            redis_client.set(f"chunks:{document_id}", chunks_json)
+            redis_client.set(f"chunks:{document_id}", chunks_json, ex=STORED_RESULT_TTL)
             
             # 添加到文档任务集合
             redis_client.sadd(f"document_tasks:{document_id}", task_id)
+            redis_client.expire(f"document_tasks:{document_id}", STORED_RESULT_TTL)
             logger.info(f"Stored chunking result for document {document_id} with task {task_id}")
         
         # 计算处理时间
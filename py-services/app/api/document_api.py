@@ -11,7 +11,7 @@ from app.document_processing.factory import create_parser, detect_content_type,
 from app.utils.minio_client import get_minio_client
 from app.utils.utils import logger, count_words, count_chars
 from app.models.model import Task, TaskType, TaskStatus, DocumentParseResult
-from app.worker.tasks import get_redis_client, get_task_from_redis
+from app.worker.tasks import get_redis_client, get_task_from_redis, STORED_RESULT_TTL
 
 # 创建路由器
 router = APIRouter(prefix="/api/python/documents", tags=["documents"])
@@ -148,11 +148,12 @@ async def parse_document(
                 
                 # 保存到Redis
                 redis_client = get_redis_client()
-                redis_client.set(f"task:{task_id}", task.to_json())
-                redis_client.set(f"parse_result:{document_id}", json.dumps(result.__dict__))
+                redis_client.set(f"task:{task_id}", task.to_json(), ex=STORED_RESULT_TTL)
+                redis_client.set(f"parse_result:{document_id}", json.dumps(result.__dict__), ex=STORED_RESULT_TTL)
                 
                 # 添加到文档任务集合
                 redis_client.sadd(f"document_tasks:{document_id}", task_id)
+                redis_client.expire(f"document_tasks:{document_id}", STORED_RESULT_TTL)
                 logger.info(f"Stored parsing result for document {document_id} with task {task_id}")
             
             # 计算处理时间
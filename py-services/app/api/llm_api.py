@@ -470,4 +470,70 @@ async def cancel_streaming(request_id: str):
     return {
         "success": True, 
         "message": f"Cancel signal sent for request {request_id}"
-    }
\ No newline at end of file
+    }
+
+# 默认的NLI模型，标签顺序为 contradiction、entailment、neutral
+DEFAULT_NLI_MODEL = "cross-encoder/nli-deberta-v3-small"
+
+# 已加载的NLI模型，按模型名缓存
+_nli_models: Dict[str, Any] = {}
+
+class VerifyRequest(BaseModel):
+    """回答依据校验请求模型"""
+    claim: str
+    contexts: List[str]
+    model: Optional[str] = None
+    threshold: float = 0.5
+
+class VerifyResponse(BaseModel):
+    """回答依据校验响应模型"""
+    supported: bool
+    score: float
+    model: str
+    processing_time: float = 0
+
+def _load_nli_model(model_name: str):
+    """加载NLI交叉编码器模型，首次调用时下载并缓存"""
+    if model_name not in _nli_models:
+        from sentence_transformers import CrossEncoder
+        _nli_models[model_name] = CrossEncoder(model_name)
+    return _nli_models[model_name]
+
+@router.post("/verify", response_model=VerifyResponse)
+async def verify_claim(request: VerifyRequest):
+    """
+    校验回答是否有检索上下文作为依据
+
+    以每个上下文为前提、回答为假设计算蕴含概率，取最大值作为置信度
+    """
+    start_time = time.time()
+    if not request.claim.strip() or not request.contexts:
+        raise HTTPException(status_code=400, detail="claim and contexts are required")
+
+    model_name = request.model or DEFAULT_NLI_MODEL
+    try:
+        model = await asyncio.to_thread(_load_nli_model, model_name)
+        pairs = [(context, request.claim) for context in request.contexts]
+        scores = await asyncio.to_thread(model.predict, pairs, apply_softmax=True)
+
+        # 从模型配置中找到entailment标签的下标，找不到时按默认顺序
+        entail_index = 1
+        label2id = getattr(model.model.config, "label2id", None) or {}
+        for label, index in label2id.items():
+            if label.lower() == "entailment":
+                entail_index = index
+                break
+
+        score = float(max(row[entail_index] for row in scores))
+        response = VerifyResponse(
+            supported=score >= request.threshold,
+            score=score,
+            model=model_name,
+            processing_time=time.time() - start_time
+        )
+        logger.info(f"Verified claim with score {score:.3f} in {response.processing_time:.2f}s")
+        return response
+
+    except Exception as e:
+        logger.error(f"Error verifying claim: {str(e)}")
+        raise HTTPException(status_code=500, detail=f"Error verifying claim: {str(e)}")
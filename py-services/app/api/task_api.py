@@ -6,12 +6,13 @@ from pydantic import BaseModel, Field
 from app.models.model import (
     Task, TaskType, TaskStatus,
     DocumentParsePayload, TextChunkPayload,
-    VectorizePayload, ProcessCompletePayload
+    VectorizePayload, ProcessCompletePayload,
+    DocumentCleanupPayload
 )
 from app.utils.utils import logger, get_task_key
 from app.worker.tasks import (
     parse_document, chunk_text, vectorize_text, process_document,
-    get_redis_client, get_task_from_redis
+    cleanup_document, get_redis_client, get_task_from_redis
 )
 
 # 创建路由器
@@ -48,6 +49,9 @@ class ProcessCompleteRequest(BaseModel):
     model: str = "default"
     metadata: Dict[str, Any] = Field(default_factory=dict)
 
+class DocumentCleanupRequest(BaseModel):
+    document_id: str
+
 class TaskResponse(BaseModel):
     task_id: str
     status: str = "pending"
@@ -273,6 +277,51 @@ async def create_process_task(request: ProcessCompleteRequest = Body(...)):
             detail=f"Failed to create complete process task: {str(e)}"
         )
 
+# 文档清理任务接口
+@router.post("/cleanup", response_model=TaskResponse)
+async def create_cleanup_task(request: DocumentCleanupRequest):
+    """创建文档清理任务，删除文档在Python端缓存的中间数据"""
+    try:
+        # 创建任务负载
+        payload = DocumentCleanupPayload(document_id=request.document_id)
+
+        # 创建任务ID
+        task_id = f"cleanup_{int(time.time())}_{request.document_id}"
+
+        # 创建任务对象
+        task = Task(
+            id=task_id,
+            type=TaskType.DOCUMENT_CLEANUP,
+            document_id=request.document_id,
+            status=TaskStatus.PENDING,
+            payload=payload.__dict__
+        )
+
+        # 保存任务到Redis
+        client = get_redis_client()
+        key = get_task_key(task_id)
+        client.set(key, task.to_json())
+
+        # 以low优先级发送到Celery队列，清理不影响在线处理
+        cleanup_document.apply_async(
+            args=[task_id],
+            queue='low'
+        )
+
+        logger.info(f"Created document cleanup task: {task_id}")
+        return {
+            "task_id": task_id,
+            "status": "pending",
+            "task_type": "document_cleanup"
+        }
+
+    except Exception as e:
+        logger.error(f"Failed to create cleanup task: {str(e)}")
+        raise HTTPException(
+            status_code=500,
+            detail=f"Failed to create document cleanup task: {str(e)}"
+        )
+
 # 获取任务状态接口
 @router.get("/{task_id}")
 async def get_task_status(task_id: str):
@@ -21,6 +21,7 @@ class ChunkOptions:
     split_type: str = "sentence"
     include_metadata: bool = True
     include_stats: bool = True
+    breakpoint_threshold: float = 95  # 语义分块断点阈值（百分位，0-100）
 
 
 class DocumentChunker:
@@ -163,7 +164,7 @@ class DocumentChunker:
                     
                     return SemanticSplitterNodeParser(
                         buffer_size=1,
-                        breakpoint_percentile_threshold=95,
+                        breakpoint_percentile_threshold=options.breakpoint_threshold,
                         embed_model=embed_model
                     )
                 except ImportError:
@@ -149,7 +149,8 @@ def create_parser(file_path: str, mime_type: Optional[str] = None, file_extensio
 def create_chunker(
     chunk_size: int = 1000,
     chunk_overlap: int = 200, 
-    split_type: str = "sentence"
+    split_type: str = "sentence",
+    breakpoint_threshold: Optional[float] = None
 ) -> DocumentChunker:
     """
     创建文本分块器
@@ -158,6 +159,7 @@ def create_chunker(
         chunk_size: 块大小
         chunk_overlap: 块重叠大小
         split_type: 分块类型 (paragraph, sentence, token, semantic, hierarchical)
+        breakpoint_threshold: 语义分块断点阈值（百分位，0-100），为空时使用默认值
         
     返回:
         DocumentChunker: 文档分块器实例
@@ -167,6 +169,8 @@ def create_chunker(
         chunk_overlap=chunk_overlap,
         split_type=split_type
     )
+    if breakpoint_threshold:
+        options.breakpoint_threshold = breakpoint_threshold
     
     chunker = DocumentChunker(options)
     logger.info(f"Created document chunker with type: {split_type}, size: {chunk_size}, overlap: {chunk_overlap}")
@@ -24,6 +24,9 @@ from app.utils.minio_client import get_minio_client
 # 获取MinIO客户端
 minio_client = get_minio_client()
 
+# 分页文档各页之间的分隔符，与Go端document.PageBreak对应
+PAGE_SEPARATOR = "\n\f\n"
+
 
 class DocumentParser:
     """
@@ -117,9 +120,11 @@ class DocumentParser:
                 return ""
             
             # 合并多文档内容
+            # PDF每页是一个文档，页之间用分页符分隔，Go端据此推断每个分块所在的页码
             if len(docs) > 1:
                 logger.info(f"Merging {len(docs)} document sections")
-                self.content = "\n\n".join([doc.get_content() for doc in docs])
+                separator = PAGE_SEPARATOR if isinstance(reader, (PyMuPDFReader, PDFReader)) else "\n\n"
+                self.content = separator.join([doc.get_content() for doc in docs])
             else:
                 self.content = docs[0].get_content()
             
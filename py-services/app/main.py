@@ -94,6 +94,17 @@ app.add_middleware(
     allow_headers=["*"],
 )
 
+# 访问令牌校验中间件，设置API_AUTH_TOKEN后除健康检查外的接口都需要携带Bearer令牌
+API_AUTH_TOKEN = os.getenv("API_AUTH_TOKEN", "")
+
+@app.middleware("http")
+async def verify_auth_token(request: Request, call_next):
+    """校验Go服务发送的访问令牌"""
+    if API_AUTH_TOKEN and request.url.path.startswith("/api/") and not request.url.path.startswith("/api/health"):
+        if request.headers.get("Authorization") != f"Bearer {API_AUTH_TOKEN}":
+            return JSONResponse(status_code=401, content={"detail": "Invalid or missing auth token"})
+    return await call_next(request)
+
 # 初始化MinIO客户端
 from app.utils.minio_client import get_minio_client
 try:
@@ -136,7 +147,10 @@ async def add_process_time_header(request: Request, call_next):
     # 记录请求处理情况
     status_code = response.status_code
     path = request.url.path
-    logger.info(f"Request {request.method} {path} completed with status {status_code} in {process_time:.4f}s")
+    request_id = request.headers.get("X-Request-ID")
+    if request_id:
+        response.headers["X-Request-ID"] = request_id
+    logger.info(f"Request {request.method} {path} completed with status {status_code} in {process_time:.4f}s (request_id={request_id or '-'})")
     
     return response
 
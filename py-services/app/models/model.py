@@ -11,6 +11,7 @@ class TaskType(str, Enum):
     TEXT_CHUNK = "text_chunk"
     VECTORIZE = "vectorize"
     PROCESS_COMPLETE = "process_complete"
+    DOCUMENT_CLEANUP = "document_cleanup"
 
 
 class TaskStatus(str, Enum):
@@ -176,6 +177,20 @@ class ProcessCompleteResult:
     vectors: List[VectorInfo] = field(default_factory=list)
 
 
+@dataclass
+class DocumentCleanupPayload:
+    """文档清理任务载荷，对应Go中的DocumentCleanupPayload"""
+    document_id: str
+
+
+@dataclass
+class DocumentCleanupResult:
+    """文档清理任务结果，对应Go中的DocumentCleanupResult"""
+    document_id: str
+    deleted_keys: int = 0
+    error: str = ""
+
+
 @dataclass
 class TaskCallback:
     """任务回调信息，对应Go中的TaskCallback"""
@@ -46,6 +46,7 @@ app.conf.update(
         "app.worker.tasks.parse_document": {"queue": "default"},
         "app.worker.tasks.chunk_text": {"queue": "default"},
         "app.worker.tasks.vectorize_text": {"queue": "default"},
+        "app.worker.tasks.cleanup_document": {"queue": "low"},
     },
 
     # 添加imports配置确保任务模块被自动加载
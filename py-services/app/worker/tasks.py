@@ -13,7 +13,8 @@ from app.models.model import (
     DocumentParsePayload, DocumentParseResult,
     TextChunkPayload, TextChunkResult, ChunkInfo,
     VectorizePayload, VectorizeResult, VectorInfo,
-    ProcessCompletePayload, ProcessCompleteResult
+    ProcessCompletePayload, ProcessCompleteResult,
+    DocumentCleanupPayload, DocumentCleanupResult
 )
 from app.utils.utils import (
     logger, parse_redis_url, get_task_key,
@@ -25,6 +26,9 @@ from app.worker.celery_app import app
 REDIS_URL = os.getenv("REDIS_URL", "redis://localhost:6379/0")
 REDIS_PARAMS = parse_redis_url(REDIS_URL)
 CALLBACK_URL = os.getenv("CALLBACK_URL", "http://localhost:8080/api/tasks/callback")
+# 解析和分块接口按调用方给出的document_id保存结果，同步处理使用的是临时ID，
+# 文档删除时无法按ID清理，保存的结果在该时长后自动过期
+STORED_RESULT_TTL = int(os.getenv("STORED_RESULT_TTL_SECONDS", str(24 * 60 * 60)))
 
 # 获取Redis连接
 def get_redis_client():
@@ -513,4 +517,59 @@ def process_document(task_id: str) -> bool:
         error_msg = f"Document processing failed: {str(e)}\n{traceback.format_exc()}"
         logger.error(error_msg)
         update_task_status(task, TaskStatus.FAILED, error=error_msg)
-        return False
\ No newline at end of file
+        return False
+
+# 文档清理任务
+@shared_task(name="app.worker.tasks.cleanup_document")
+def cleanup_document(task_id: str) -> bool:
+    """
+    清理文档在Python端缓存的中间数据
+
+    文档在Go端删除后触发，删除按document_id保存的解析结果、分块结果和历史任务记录
+
+    参数:
+        task_id: 任务ID
+
+    返回:
+        bool: 成功返回True，失败返回False
+    """
+    logger.info(f"Starting document cleanup task: {task_id}")
+
+    # 获取任务信息
+    task = get_task_from_redis(task_id)
+    if not task:
+        logger.error(f"Task {task_id} not found")
+        return False
+
+    # 更新任务状态为处理中
+    update_task_status(task, TaskStatus.PROCESSING)
+
+    try:
+        # 解析任务载荷
+        payload = DocumentCleanupPayload(**task.payload)
+        document_id = payload.document_id
+
+        client = get_redis_client()
+        doc_tasks_key = f"document_tasks:{document_id}"
+
+        # 收集按文档ID写入的键：解析和分块接口保存的结果（store_result为真时）、
+        # 文档的任务记录及任务集合，保留当前清理任务本身以便回调查询
+        keys = [f"parse_result:{document_id}", f"chunks:{document_id}"]
+        for member in client.smembers(doc_tasks_key):
+            other_id = member.decode("utf-8") if isinstance(member, bytes) else member
+            if other_id != task.id:
+                keys.append(get_task_key(other_id))
+        keys.append(doc_tasks_key)
+
+        deleted = client.delete(*keys)
+        logger.info(f"Document cleanup completed for {document_id}: deleted {deleted} keys")
+
+        result = DocumentCleanupResult(document_id=document_id, deleted_keys=deleted)
+        update_task_status(task, TaskStatus.COMPLETED, result=result.__dict__)
+        return True
+
+    except Exception as e:
+        error_msg = f"Document cleanup failed: {str(e)}\n{traceback.format_exc()}"
+        logger.error(error_msg)
+        update_task_status(task, TaskStatus.FAILED, error=error_msg)
+        return False
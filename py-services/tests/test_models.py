@@ -7,6 +7,7 @@ from app.models.model import (
     TextChunkPayload, TextChunkResult, ChunkInfo,
     VectorizePayload, VectorizeResult, VectorInfo,
     ProcessCompletePayload, ProcessCompleteResult,
+    DocumentCleanupPayload, DocumentCleanupResult,
     TaskCallback
 )
 
@@ -283,6 +284,16 @@ class TestModels(unittest.TestCase):
         self.assertEqual(result.error, "")
         self.assertEqual(len(result.vectors), 0)  # 默认为空列表
 
+    def test_document_cleanup(self):
+        """测试DocumentCleanupPayload和DocumentCleanupResult类"""
+        payload = DocumentCleanupPayload(document_id="doc-123")
+        self.assertEqual(payload.document_id, "doc-123")
+
+        result = DocumentCleanupResult(document_id="doc-123")
+        self.assertEqual(result.deleted_keys, 0)
+        self.assertEqual(result.error, "")
+        self.assertEqual(TaskType.DOCUMENT_CLEANUP.value, "document_cleanup")
+
     def test_task_callback(self):
         """测试TaskCallback类"""
         now = datetime.now()